@@ -13,6 +13,6 @@ var (
 
 func main() {
 	if err := execute(); err != nil {
-		exit(1)
+		exit(cmd.ExitCode(err))
 	}
 }
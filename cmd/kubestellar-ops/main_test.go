@@ -2,7 +2,10 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cmd/tools"
 )
 
 type exitCode int
@@ -45,3 +48,24 @@ func TestMainExitsOnFailure(t *testing.T) {
 
 	main()
 }
+
+func TestMainExitsWithFailOnCode(t *testing.T) {
+	oldExecute, oldExit := execute, exit
+	t.Cleanup(func() {
+		execute = oldExecute
+		exit = oldExit
+	})
+
+	execute = func() error {
+		return fmt.Errorf("run: %w", &tools.ExitError{Code: tools.ExitDrift, Err: errors.New("drift")})
+	}
+	exit = func(code int) { panic(exitCode(code)) }
+
+	defer func() {
+		if code := recover(); code != exitCode(tools.ExitDrift) {
+			t.Fatalf("exit code = %v, want %d", code, tools.ExitDrift)
+		}
+	}()
+
+	main()
+}
@@ -0,0 +1,338 @@
+// Package advisories tracks the support status of Kubernetes and OpenShift
+// releases: when each minor version reaches end of life and which critical
+// CVEs its patch releases fix. A copy is built in; $KUBESTELLAR_ADVISORIES_URL
+// names a JSON document in the same format to refresh it from, so clusters
+// are judged against current data without a new release of the tools.
+package advisories
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvAdvisoriesURL is an http(s) URL of advisory data replacing the built-in
+// copy. It is fetched at most once per RefreshInterval.
+const EnvAdvisoriesURL = "KUBESTELLAR_ADVISORIES_URL"
+
+// RefreshInterval is how long advisory data fetched from a URL is used
+// before it is fetched again.
+const RefreshInterval = 24 * time.Hour
+
+// maxDocumentSize bounds the advisory data read from a URL.
+const maxDocumentSize = 4 << 20
+
+// Product is a distribution whose releases are tracked.
+type Product string
+
+const (
+	Kubernetes Product = "kubernetes"
+	OpenShift  Product = "openshift"
+)
+
+// CVE is a vulnerability of a minor version.
+type CVE struct {
+	ID       string `json:"id"`
+	Severity string `json:"severity"`
+	Summary  string `json:"summary"`
+	// FixedIn is the first patch release of the minor version with the
+	// fix. Empty means the minor version has no fix.
+	FixedIn string `json:"fixedIn,omitempty"`
+}
+
+// Release is the support status of a minor version.
+type Release struct {
+	Minor string `json:"minor"`
+	// EndOfLife is the date, as YYYY-MM-DD, after which the minor version
+	// gets no more patch releases.
+	EndOfLife string `json:"endOfLife"`
+	CVEs      []CVE  `json:"cves,omitempty"`
+}
+
+// Data is the advisory document.
+type Data struct {
+	// Updated is when the data was last reviewed, as YYYY-MM-DD.
+	Updated    string    `json:"updated"`
+	Kubernetes []Release `json:"kubernetes"`
+	OpenShift  []Release `json:"openshift"`
+}
+
+//go:embed advisories.json
+var bundled []byte
+
+// Parse reads and validates an advisory document.
+func Parse(data []byte) (*Data, error) {
+	var d Data
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("invalid advisory data: %w", err)
+	}
+	for product, releases := range map[Product][]Release{Kubernetes: d.Kubernetes, OpenShift: d.OpenShift} {
+		for _, r := range releases {
+			if _, ok := parseVersion(r.Minor); !ok {
+				return nil, fmt.Errorf("invalid advisory data: %s release %q is not a minor version", product, r.Minor)
+			}
+			if _, err := time.Parse(time.DateOnly, r.EndOfLife); err != nil {
+				return nil, fmt.Errorf("invalid advisory data: %s %s endOfLife: %w", product, r.Minor, err)
+			}
+			for _, cve := range r.CVEs {
+				if cve.ID == "" {
+					return nil, fmt.Errorf("invalid advisory data: %s %s has a CVE without an id", product, r.Minor)
+				}
+				if v, ok := parseVersion(cve.FixedIn); cve.FixedIn != "" && (!ok || v.minor() != r.Minor) {
+					return nil, fmt.Errorf("invalid advisory data: %s fixedIn %q is not a %s patch release", cve.ID, cve.FixedIn, r.Minor)
+				}
+			}
+		}
+	}
+	return &d, nil
+}
+
+// Bundled returns the advisory data built into the binary.
+func Bundled() *Data {
+	d, err := Parse(bundled)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// httpClient fetches advisory data. Tests replace it.
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// Fetch reads advisory data from url.
+func Fetch(ctx context.Context, url string) (*Data, error) {
+	if !strings.HasPrefix(url, "https://") && !strings.HasPrefix(url, "http://") {
+		return nil, fmt.Errorf("invalid advisory URL %q: must be http or https", url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid advisory URL %q: %w", url, err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch advisory data: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch advisory data: %s returned %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch advisory data: %w", err)
+	}
+	return Parse(body)
+}
+
+var (
+	currentMu      sync.Mutex
+	current        *Data
+	currentURL     string
+	currentFetched time.Time
+)
+
+// Current returns the advisory data fetched from $KUBESTELLAR_ADVISORIES_URL,
+// refreshed every RefreshInterval, or the built-in data when it is unset. A
+// failed fetch is logged and the last good data, or the built-in data, used
+// until the next refresh.
+func Current(ctx context.Context) *Data {
+	url := strings.TrimSpace(os.Getenv(EnvAdvisoriesURL))
+	currentMu.Lock()
+	defer currentMu.Unlock()
+	if url != currentURL {
+		current, currentURL, currentFetched = nil, url, time.Time{}
+	}
+	if current != nil && (url == "" || time.Since(currentFetched) < RefreshInterval) {
+		return current
+	}
+	if url != "" {
+		d, err := Fetch(ctx, url)
+		currentFetched = time.Now()
+		if err == nil {
+			current = d
+			return current
+		}
+		log.Printf("Using built-in advisory data: %v", err)
+		if current != nil {
+			return current
+		}
+	}
+	current = Bundled()
+	return current
+}
+
+// Assessment is the support status of a cluster's version.
+type Assessment struct {
+	Product Product
+	Version string
+	// Minor is the tracked minor version, empty when the version is newer
+	// than every tracked release or cannot be parsed.
+	Minor string
+	// EndOfLife is when Minor's support ends.
+	EndOfLife time.Time
+	// EOL is set for versions past end of life, including those older
+	// than every tracked release.
+	EOL bool
+	// CVEs are the known vulnerabilities not fixed in Version.
+	CVEs []CVE
+	// Recommended is the version to upgrade to, a minor version or the
+	// patch release fixing CVEs, empty when no upgrade is needed.
+	Recommended string
+}
+
+// Flagged reports whether the version is past end of life or vulnerable.
+func (a Assessment) Flagged() bool {
+	return a.EOL || len(a.CVEs) > 0
+}
+
+// Assess judges version ver of product as of now.
+func (d *Data) Assess(product Product, ver string, now time.Time) Assessment {
+	a := Assessment{Product: product, Version: ver}
+	v, ok := parseVersion(ver)
+	if !ok {
+		return a
+	}
+	releases := d.releases(product)
+	if len(releases) == 0 {
+		return a
+	}
+	var release *Release
+	for i := range releases {
+		if releases[i].Minor == v.minor() {
+			release = &releases[i]
+			break
+		}
+	}
+	if release == nil {
+		oldest, _ := parseVersion(releases[0].Minor)
+		if v.less(oldest) {
+			a.EOL = true
+			a.Recommended = supportedAfter(releases, v, now)
+		}
+		return a
+	}
+
+	a.Minor = release.Minor
+	a.EndOfLife, _ = time.Parse(time.DateOnly, release.EndOfLife)
+	a.EOL = !now.Before(a.EndOfLife.AddDate(0, 0, 1))
+	var fix version
+	unfixed := false
+	for _, cve := range release.CVEs {
+		fixed, ok := parseVersion(cve.FixedIn)
+		if ok && !v.less(fixed) {
+			continue
+		}
+		a.CVEs = append(a.CVEs, cve)
+		if !ok {
+			unfixed = true
+		} else if fix.less(fixed) {
+			fix = fixed
+		}
+	}
+	switch {
+	case a.EOL || unfixed:
+		// Only a newer minor version is supported, or fixes every CVE.
+		a.Recommended = supportedAfter(releases, v, now)
+	case len(a.CVEs) > 0:
+		a.Recommended = fix.String()
+	}
+	return a
+}
+
+func (d *Data) releases(product Product) []Release {
+	var releases []Release
+	switch product {
+	case Kubernetes:
+		releases = append(releases, d.Kubernetes...)
+	case OpenShift:
+		releases = append(releases, d.OpenShift...)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		a, _ := parseVersion(releases[i].Minor)
+		b, _ := parseVersion(releases[j].Minor)
+		return a.less(b)
+	})
+	return releases
+}
+
+// supportedAfter returns the oldest release newer than v still supported at
+// now, with the patch release fixing its known CVEs, if any.
+func supportedAfter(releases []Release, v version, now time.Time) string {
+	for _, r := range releases {
+		minor, _ := parseVersion(r.Minor)
+		eol, _ := time.Parse(time.DateOnly, r.EndOfLife)
+		if !v.less(minor) || !now.Before(eol.AddDate(0, 0, 1)) {
+			continue
+		}
+		fix := minor
+		for _, cve := range r.CVEs {
+			if fixed, ok := parseVersion(cve.FixedIn); ok && fix.less(fixed) {
+				fix = fixed
+			}
+		}
+		return fix.String()
+	}
+	return ""
+}
+
+// version is a parsed major.minor[.patch] version.
+type version struct {
+	major, min, patch int
+	hasPatch          bool
+}
+
+// parseVersion reads the leading major.minor[.patch] of versions such as
+// v1.29.2, v1.29.2-eks-5e0fdde, v1.29.2+k3s1 and 4.14.5.
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+ "); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return version{}, false
+	}
+	nums := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		nums[i] = n
+	}
+	v := version{major: nums[0], min: nums[1]}
+	if len(nums) == 3 {
+		v.patch, v.hasPatch = nums[2], true
+	}
+	return v, true
+}
+
+func (v version) minor() string {
+	return fmt.Sprintf("%d.%d", v.major, v.min)
+}
+
+func (v version) less(o version) bool {
+	if v.major != o.major {
+		return v.major < o.major
+	}
+	if v.min != o.min {
+		return v.min < o.min
+	}
+	return v.patch < o.patch
+}
+
+func (v version) String() string {
+	if v.hasPatch {
+		return fmt.Sprintf("%d.%d.%d", v.major, v.min, v.patch)
+	}
+	return v.minor()
+}
@@ -0,0 +1,142 @@
+package advisories
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testData = `{
+  "updated": "2026-01-01",
+  "kubernetes": [
+    {"minor": "1.31", "endOfLife": "2026-10-28"},
+    {"minor": "1.29", "endOfLife": "2025-02-28", "cves": [
+      {"id": "CVE-2024-10220", "severity": "high", "fixedIn": "1.29.7", "summary": "gitRepo volumes"}
+    ]},
+    {"minor": "1.30", "endOfLife": "2026-06-28", "cves": [
+      {"id": "CVE-2024-10220", "severity": "high", "fixedIn": "1.30.3", "summary": "gitRepo volumes"},
+      {"id": "CVE-2030-1", "severity": "critical", "fixedIn": "1.30.9", "summary": "later fix"}
+    ]},
+    {"minor": "1.32", "endOfLife": "2026-12-28", "cves": [
+      {"id": "CVE-2030-2", "severity": "critical", "fixedIn": "1.32.4", "summary": "fixed"}
+    ]},
+    {"minor": "1.33", "endOfLife": "2027-02-28", "cves": [
+      {"id": "CVE-2030-3", "severity": "critical", "summary": "no fix"}
+    ]}
+  ],
+  "openshift": [
+    {"minor": "4.14", "endOfLife": "2025-10-31"},
+    {"minor": "4.16", "endOfLife": "2026-06-27"},
+    {"minor": "4.18", "endOfLife": "2027-02-25"}
+  ]
+}`
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func testAdvisories(t *testing.T) *Data {
+	t.Helper()
+	d, err := Parse([]byte(testData))
+	require.NoError(t, err)
+	return d
+}
+
+func TestBundledParses(t *testing.T) {
+	d := Bundled()
+	assert.NotEmpty(t, d.Updated)
+	assert.NotEmpty(t, d.Kubernetes)
+	assert.NotEmpty(t, d.OpenShift)
+}
+
+func TestParseRejectsInvalidData(t *testing.T) {
+	for name, doc := range map[string]string{
+		"minor":   `{"kubernetes": [{"minor": "latest", "endOfLife": "2025-01-01"}]}`,
+		"date":    `{"kubernetes": [{"minor": "1.30", "endOfLife": "June 2026"}]}`,
+		"id":      `{"kubernetes": [{"minor": "1.30", "endOfLife": "2026-06-28", "cves": [{"fixedIn": "1.30.3"}]}]}`,
+		"fixedIn": `{"kubernetes": [{"minor": "1.30", "endOfLife": "2026-06-28", "cves": [{"id": "CVE-1", "fixedIn": "1.31.2"}]}]}`,
+		"json":    `[]`,
+	} {
+		_, err := Parse([]byte(doc))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestAssess(t *testing.T) {
+	d := testAdvisories(t)
+	for _, tt := range []struct {
+		product     Product
+		version     string
+		eol         bool
+		cves        []string
+		recommended string
+	}{
+		{Kubernetes, "v1.31.4", false, nil, ""},
+		{Kubernetes, "v1.29.9-eks-5e0fdde", true, nil, "1.30.9"},
+		{Kubernetes, "v1.29.2", true, []string{"CVE-2024-10220"}, "1.30.9"},
+		{Kubernetes, "v1.30.2+k3s1", false, []string{"CVE-2024-10220", "CVE-2030-1"}, "1.30.9"},
+		{Kubernetes, "v1.30.5", false, []string{"CVE-2030-1"}, "1.30.9"},
+		{Kubernetes, "v1.30.9", false, nil, ""},
+		{Kubernetes, "v1.33.1", false, []string{"CVE-2030-3"}, ""},
+		{Kubernetes, "v1.26.15", true, nil, "1.30.9"},
+		{Kubernetes, "v1.40.0", false, nil, ""},
+		{Kubernetes, "unknown", false, nil, ""},
+		{OpenShift, "4.14.20", true, nil, "4.16"},
+		{OpenShift, "4.18.3", false, nil, ""},
+	} {
+		a := d.Assess(tt.product, tt.version, testNow)
+		var ids []string
+		for _, cve := range a.CVEs {
+			ids = append(ids, cve.ID)
+		}
+		assert.Equal(t, tt.eol, a.EOL, tt.version)
+		assert.Equal(t, tt.cves, ids, tt.version)
+		assert.Equal(t, tt.recommended, a.Recommended, tt.version)
+		assert.Equal(t, tt.eol || len(tt.cves) > 0, a.Flagged(), tt.version)
+	}
+}
+
+func TestAssessEndOfLifeDay(t *testing.T) {
+	d := testAdvisories(t)
+	eol := time.Date(2026, 6, 28, 23, 0, 0, 0, time.UTC)
+	assert.False(t, d.Assess(Kubernetes, "v1.30.9", eol).EOL, "supported through its last day")
+	assert.True(t, d.Assess(Kubernetes, "v1.30.9", eol.Add(2*time.Hour)).EOL)
+}
+
+func TestCurrentFetchesFromURL(t *testing.T) {
+	var fetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write([]byte(testData))
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { current, currentURL = nil, "" })
+
+	t.Setenv(EnvAdvisoriesURL, srv.URL)
+	assert.Equal(t, "2026-01-01", Current(context.Background()).Updated)
+	assert.Equal(t, "2026-01-01", Current(context.Background()).Updated)
+	assert.Equal(t, int32(1), fetches.Load(), "fetched once per refresh interval")
+
+	t.Setenv(EnvAdvisoriesURL, "")
+	assert.Equal(t, Bundled().Updated, Current(context.Background()).Updated)
+}
+
+func TestCurrentFallsBackToBundled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "gone", http.StatusNotFound)
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { current, currentURL = nil, "" })
+
+	t.Setenv(EnvAdvisoriesURL, srv.URL)
+	assert.Equal(t, Bundled().Updated, Current(context.Background()).Updated)
+
+	_, err := Fetch(context.Background(), srv.URL)
+	assert.ErrorContains(t, err, "404")
+	_, err = Fetch(context.Background(), "file:///etc/advisories.json")
+	assert.ErrorContains(t, err, "must be http or https")
+}
@@ -0,0 +1,293 @@
+// Package approval implements the plan/apply workflow for mutating tools.
+// When enabled, a mutating tool call runs as a dry run unless the caller
+// passes approved: true, and in plan mode the approval must also name the
+// plan returned by an earlier dry run of the same arguments. This gives
+// agent-driven changes the same review step as terraform plan/apply.
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// Mode selects how mutating tools are gated.
+type Mode string
+
+const (
+	// ModeOff runs mutating tools as requested.
+	ModeOff Mode = "off"
+	// ModeApprove runs mutating tools as dry runs unless approved: true.
+	ModeApprove Mode = "approve"
+	// ModePlan additionally requires plan_id from a dry run of the same
+	// tool and arguments.
+	ModePlan Mode = "plan"
+)
+
+// EnvApprovalMode selects the Mode; unset means ModeOff.
+const EnvApprovalMode = "KUBESTELLAR_APPROVAL_MODE"
+
+// Argument names added to every mutating tool. ArgDryRun is the argument
+// the tools already accept; the gate forces it on for unapproved calls.
+const (
+	ArgApproved = "approved"
+	ArgPlanID   = "plan_id"
+	ArgDryRun   = "dry_run"
+)
+
+// DefaultPlanTTL is how long a plan from a dry run can be approved.
+const DefaultPlanTTL = 15 * time.Minute
+
+// ParseMode parses a mode name. The empty string is ModeOff.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(s))) {
+	case "", ModeOff:
+		return ModeOff, nil
+	case ModeApprove:
+		return ModeApprove, nil
+	case ModePlan:
+		return ModePlan, nil
+	default:
+		return "", fmt.Errorf("invalid %s %q (expected off, approve, or plan)", EnvApprovalMode, s)
+	}
+}
+
+// Plan records a dry run that may later be approved.
+type Plan struct {
+	ID          string    `json:"id"`
+	Tool        string    `json:"tool"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// Decision is the outcome of Gate.Check for one tool call.
+type Decision struct {
+	// Args are the arguments to run the tool with: the approval arguments
+	// are removed, and dry_run is forced on when DryRun is set.
+	Args map[string]interface{}
+	// DryRun reports that the gate turned the call into a dry run.
+	DryRun bool
+}
+
+// Gate applies a Mode to mutating tool calls and keeps the plans issued
+// for dry runs. A nil *Gate behaves like ModeOff.
+type Gate struct {
+	mode    Mode
+	modeErr error
+	store   store.Store
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewGate creates a gate that keeps plans in st.
+func NewGate(mode Mode, st store.Store) *Gate {
+	return &Gate{mode: mode, store: st, ttl: DefaultPlanTTL, now: time.Now}
+}
+
+// GateFromEnv creates a gate for $KUBESTELLAR_APPROVAL_MODE. An invalid
+// mode fails closed: every mutating call is rejected with the parse error.
+func GateFromEnv(st store.Store) *Gate {
+	mode, err := ParseMode(os.Getenv(EnvApprovalMode))
+	g := NewGate(mode, st)
+	g.modeErr = err
+	return g
+}
+
+// Enabled reports whether mutating calls are gated at all.
+func (g *Gate) Enabled() bool {
+	return g != nil && (g.mode != ModeOff || g.modeErr != nil)
+}
+
+// Mode returns the configured mode.
+func (g *Gate) Mode() Mode {
+	if g == nil {
+		return ModeOff
+	}
+	return g.mode
+}
+
+// Check decides how a call to a mutating tool runs. Unapproved calls become
+// dry runs. Approved calls run as requested, after the named plan (required
+// in plan mode, checked if given in approve mode) is validated and consumed.
+func (g *Gate) Check(ctx context.Context, tool string, args map[string]interface{}) (Decision, error) {
+	if !g.Enabled() {
+		return Decision{Args: args}, nil
+	}
+	if g.modeErr != nil {
+		return Decision{}, g.modeErr
+	}
+	return g.check(ctx, g.mode, tool, args)
+}
+
+// CheckRequired is Check for a call that must be approved whatever the
+// configured mode, e.g. because a policy requires it. With the gate off it
+// applies approve mode.
+func (g *Gate) CheckRequired(ctx context.Context, tool string, args map[string]interface{}) (Decision, error) {
+	if g == nil {
+		return Decision{}, fmt.Errorf("%s requires approval, but no approval gate is configured", tool)
+	}
+	if g.modeErr != nil {
+		return Decision{}, g.modeErr
+	}
+	mode := g.mode
+	if mode == ModeOff {
+		mode = ModeApprove
+	}
+	return g.check(ctx, mode, tool, args)
+}
+
+func (g *Gate) check(ctx context.Context, mode Mode, tool string, args map[string]interface{}) (Decision, error) {
+	approved := truthy(args[ArgApproved])
+	planID, _ := args[ArgPlanID].(string)
+	runArgs := withoutApproval(args)
+
+	if !approved {
+		runArgs[ArgDryRun] = true
+		return Decision{Args: runArgs, DryRun: true}, nil
+	}
+	if planID == "" {
+		if mode == ModePlan {
+			return Decision{}, fmt.Errorf("%s requires %s from a dry run: call it without %s first and review the result", tool, ArgPlanID, ArgApproved)
+		}
+		return Decision{Args: runArgs}, nil
+	}
+	if err := g.consumePlan(ctx, planID, tool, args); err != nil {
+		return Decision{}, err
+	}
+	return Decision{Args: runArgs}, nil
+}
+
+// RecordPlan stores a plan for a dry run of tool with args, so the same
+// call can be approved later, and drops plans that have expired.
+func (g *Gate) RecordPlan(ctx context.Context, tool string, args map[string]interface{}) (*Plan, error) {
+	id, err := newPlanID()
+	if err != nil {
+		return nil, err
+	}
+	now := g.now()
+	plan := &Plan{
+		ID:          id,
+		Tool:        tool,
+		Fingerprint: Fingerprint(tool, args),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(g.ttl),
+	}
+	g.pruneExpired(ctx, now)
+	if err := store.PutJSON(ctx, g.store, store.BucketPlans, id, plan); err != nil {
+		return nil, fmt.Errorf("failed to save plan: %w", err)
+	}
+	return plan, nil
+}
+
+// Meta describes a dry run forced by the gate for a result's _meta.
+func (p *Plan) Meta() map[string]interface{} {
+	return map[string]interface{}{
+		"dryRun":        true,
+		"planId":        p.ID,
+		"planExpiresAt": p.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// Note tells the caller how to apply a dry run.
+func (p *Plan) Note() string {
+	return fmt.Sprintf("Dry run only: nothing was changed. Review the result above, then repeat the call with %s: true and %s: %q before %s to apply it.",
+		ArgApproved, ArgPlanID, p.ID, p.ExpiresAt.UTC().Format(time.RFC3339))
+}
+
+func (g *Gate) consumePlan(ctx context.Context, id, tool string, args map[string]interface{}) error {
+	var plan Plan
+	if err := store.GetJSON(ctx, g.store, store.BucketPlans, id, &plan); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return fmt.Errorf("plan %q not found: it may have expired or already been applied", id)
+		}
+		return fmt.Errorf("failed to load plan %q: %w", id, err)
+	}
+	if g.now().After(plan.ExpiresAt) {
+		_ = g.store.Delete(ctx, store.BucketPlans, id)
+		return fmt.Errorf("plan %q expired at %s: run the dry run again", id, plan.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	if plan.Tool != tool || plan.Fingerprint != Fingerprint(tool, args) {
+		return fmt.Errorf("plan %q was issued for different arguments: run the dry run again with the arguments to apply", id)
+	}
+	// Plans are single use so an approval cannot be replayed.
+	if err := g.store.Delete(ctx, store.BucketPlans, id); err != nil {
+		return fmt.Errorf("failed to consume plan %q: %w", id, err)
+	}
+	return nil
+}
+
+func (g *Gate) pruneExpired(ctx context.Context, now time.Time) {
+	items, err := g.store.List(ctx, store.BucketPlans)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		var plan Plan
+		if json.Unmarshal(item.Value, &plan) != nil || now.After(plan.ExpiresAt) {
+			_ = g.store.Delete(ctx, store.BucketPlans, item.Key)
+		}
+	}
+}
+
+// Fingerprint identifies a tool call by its arguments, ignoring the
+// approval and dry-run arguments, so a plan matches only the call it was
+// issued for. encoding/json sorts map keys, so argument order is irrelevant.
+func Fingerprint(tool string, args map[string]interface{}) string {
+	filtered := withoutApproval(args)
+	delete(filtered, ArgDryRun)
+	data, _ := json.Marshal(filtered)
+	sum := sha256.Sum256(append([]byte(tool+"\x00"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+func withoutApproval(args map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args)+1)
+	for k, v := range args {
+		if k == ArgApproved || k == ArgPlanID {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func newPlanID() (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate plan id: %w", err)
+	}
+	return "plan-" + hex.EncodeToString(b[:]), nil
+}
+
+// Approved reports whether args carry approved: true.
+func Approved(args map[string]interface{}) bool {
+	return truthy(args[ArgApproved])
+}
+
+// DryRunRequested reports whether args carry dry_run: true.
+func DryRunRequested(args map[string]interface{}) bool {
+	return truthy(args[ArgDryRun])
+}
+
+// truthy accepts JSON booleans and the strings "true"/"false", matching
+// how other boolean-ish tool arguments are passed.
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,177 @@
+package approval
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": ModeOff, "off": ModeOff, "approve": ModeApprove, " PLAN ": ModePlan} {
+		got, err := ParseMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMode("yolo"); err == nil {
+		t.Error("ParseMode(yolo) should fail")
+	}
+}
+
+func TestGateOffPassesThrough(t *testing.T) {
+	args := map[string]interface{}{"app": "web", ArgApproved: false}
+	for _, g := range []*Gate{nil, NewGate(ModeOff, store.NewMemory())} {
+		d, err := g.Check(context.Background(), "scale_app", args)
+		if err != nil || d.DryRun || d.Args["app"] != "web" {
+			t.Fatalf("Check = %+v, %v; want pass-through", d, err)
+		}
+	}
+}
+
+func TestGateApproveMode(t *testing.T) {
+	ctx := context.Background()
+	g := NewGate(ModeApprove, store.NewMemory())
+
+	d, err := g.Check(ctx, "helm_install", map[string]interface{}{"release": "web", ArgDryRun: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.DryRun || d.Args[ArgDryRun] != true {
+		t.Fatalf("unapproved call should be a dry run, got %+v", d)
+	}
+
+	d, err = g.Check(ctx, "helm_install", map[string]interface{}{"release": "web", ArgApproved: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.DryRun || d.Args[ArgDryRun] != nil {
+		t.Fatalf("approved call should run as requested, got %+v", d)
+	}
+	if _, ok := d.Args[ArgApproved]; ok {
+		t.Error("approved should be stripped from the tool arguments")
+	}
+}
+
+func TestGatePlanMode(t *testing.T) {
+	ctx := context.Background()
+	g := NewGate(ModePlan, store.NewMemory())
+	args := map[string]interface{}{"app": "web", "replicas": float64(3)}
+
+	if _, err := g.Check(ctx, "scale_app", withArgs(args, ArgApproved, true)); err == nil || !strings.Contains(err.Error(), ArgPlanID) {
+		t.Fatalf("approval without plan_id should fail, got %v", err)
+	}
+
+	plan, err := g.RecordPlan(ctx, "scale_app", withArgs(args, ArgDryRun, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := withArgs(withArgs(args, "replicas", float64(30)), ArgApproved, true)
+	if _, err := g.Check(ctx, "scale_app", withArgs(changed, ArgPlanID, plan.ID)); err == nil {
+		t.Fatal("plan must not approve different arguments")
+	}
+	if _, err := g.Check(ctx, "patch_app", withArgs(withArgs(args, ArgApproved, true), ArgPlanID, plan.ID)); err == nil {
+		t.Fatal("plan must not approve a different tool")
+	}
+
+	approved := withArgs(withArgs(args, ArgApproved, "true"), ArgPlanID, plan.ID)
+	d, err := g.Check(ctx, "scale_app", approved)
+	if err != nil {
+		t.Fatalf("approving the planned call failed: %v", err)
+	}
+	if d.DryRun || d.Args["replicas"] != float64(3) {
+		t.Fatalf("unexpected decision %+v", d)
+	}
+	if _, err := g.Check(ctx, "scale_app", approved); err == nil {
+		t.Fatal("plans must be single use")
+	}
+}
+
+func TestGatePlanExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	g := NewGate(ModePlan, store.NewMemory())
+	g.now = func() time.Time { return now }
+
+	plan, err := g.RecordPlan(ctx, "delete_resource", map[string]interface{}{"name": "web"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(DefaultPlanTTL + time.Second)
+	_, err = g.Check(ctx, "delete_resource", map[string]interface{}{"name": "web", ArgApproved: true, ArgPlanID: plan.ID})
+	if err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Fatalf("expected expiry error, got %v", err)
+	}
+}
+
+func TestGateCheckRequired(t *testing.T) {
+	ctx := context.Background()
+	g := NewGate(ModeOff, store.NewMemory())
+
+	d, err := g.CheckRequired(ctx, "delete_resource", map[string]interface{}{"name": "web"})
+	if err != nil || !d.DryRun {
+		t.Fatalf("CheckRequired = %+v, %v; want a dry run with the gate off", d, err)
+	}
+	d, err = g.CheckRequired(ctx, "delete_resource", map[string]interface{}{"name": "web", ArgApproved: true})
+	if err != nil || d.DryRun {
+		t.Fatalf("CheckRequired = %+v, %v; want the approved call to run", d, err)
+	}
+
+	plan := NewGate(ModePlan, store.NewMemory())
+	if _, err := plan.CheckRequired(ctx, "delete_resource", map[string]interface{}{ArgApproved: true}); err == nil {
+		t.Fatal("plan mode must still require plan_id")
+	}
+}
+
+func TestGateInvalidModeFailsClosed(t *testing.T) {
+	t.Setenv(EnvApprovalMode, "sometimes")
+	g := GateFromEnv(store.NewMemory())
+	if !g.Enabled() {
+		t.Fatal("an invalid mode must not disable the gate")
+	}
+	if _, err := g.Check(context.Background(), "deploy_app", map[string]interface{}{ArgApproved: true}); err == nil {
+		t.Fatal("expected the mode error")
+	}
+}
+
+func TestWrapAddsDryRun(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.Method+" "+r.URL.Query().Get("dryRun"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","namespace":"default"}}`))
+	}))
+	defer srv.Close()
+
+	client := kubernetes.NewForConfigOrDie(Wrap(&rest.Config{Host: srv.URL}))
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm"}}
+	dry := WithDryRun(context.Background())
+
+	_, _ = client.CoreV1().ConfigMaps("default").Create(context.Background(), cm, metav1.CreateOptions{})
+	_, _ = client.CoreV1().ConfigMaps("default").Create(dry, cm, metav1.CreateOptions{})
+	_, _ = client.CoreV1().ConfigMaps("default").Get(dry, "cm", metav1.GetOptions{})
+
+	want := []string{"POST ", "POST All", "GET "}
+	if strings.Join(queries, "|") != strings.Join(want, "|") {
+		t.Fatalf("requests = %q, want %q", queries, want)
+	}
+}
+
+func withArgs(args map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(args)+1)
+	for k, v := range args {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
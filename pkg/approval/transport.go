@@ -0,0 +1,64 @@
+package approval
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+type dryRunKey struct{}
+
+// WithDryRun marks ctx so that clients built from a config passed through
+// Wrap send every mutating request as a server-side dry run. Tools that do
+// not take a dry_run argument are gated this way.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun reports whether ctx was marked by WithDryRun.
+func IsDryRun(ctx context.Context) bool {
+	v, _ := ctx.Value(dryRunKey{}).(bool)
+	return v
+}
+
+// Wrap returns a copy of config whose transport adds dryRun=All to
+// mutating requests made with a context marked by WithDryRun.
+func Wrap(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &dryRunTransport{next: rt}
+	})
+	return config
+}
+
+type dryRunTransport struct {
+	next http.RoundTripper
+}
+
+func (t *dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !IsDryRun(req.Context()) || !isMutation(req.Method) || isReview(req.URL.Path) {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	query := req.URL.Query()
+	query.Set("dryRun", "All")
+	req.URL.RawQuery = query.Encode()
+	return t.next.RoundTrip(req)
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isReview reports whether path is an access or token review. Those are
+// "create to ask" APIs that change nothing and do not accept dryRun.
+func isReview(path string) bool {
+	return strings.HasPrefix(path, "/apis/authorization.k8s.io/") ||
+		strings.HasPrefix(path, "/apis/authentication.k8s.io/")
+}
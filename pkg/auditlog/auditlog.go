@@ -0,0 +1,218 @@
+// Package auditlog queries Kubernetes API server audit logs: JSON-lines log
+// files, events the API server's webhook backend posts to a Receiver, and
+// the audit logs of managed clusters in AWS CloudWatch Logs and Google
+// Cloud Logging. Where find_resource_owners guesses who manages an object
+// from its managedFields, the audit log records who changed it and when.
+package auditlog
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EnvAuditLog lists audit log sources as comma-separated cluster=source
+// entries; the cluster * is the default for the rest.
+const EnvAuditLog = "KUBESTELLAR_AUDIT_LOG"
+
+// DefaultLimit is the number of events a query returns when its Limit is
+// not set.
+const DefaultLimit = 50
+
+// WriteVerbs are the verbs a query matches when it names none: the ones
+// that change objects.
+var WriteVerbs = []string{"create", "update", "patch", "delete", "deletecollection"}
+
+// Event is an audit.k8s.io/v1 Event, reduced to the fields queries use.
+type Event struct {
+	AuditID          string          `json:"auditID,omitempty"`
+	Stage            string          `json:"stage,omitempty"`
+	RequestURI       string          `json:"requestURI,omitempty"`
+	Verb             string          `json:"verb"`
+	User             UserInfo        `json:"user"`
+	ImpersonatedUser *UserInfo       `json:"impersonatedUser,omitempty"`
+	SourceIPs        []string        `json:"sourceIPs,omitempty"`
+	UserAgent        string          `json:"userAgent,omitempty"`
+	ObjectRef        *ObjectRef      `json:"objectRef,omitempty"`
+	ResponseStatus   *ResponseStatus `json:"responseStatus,omitempty"`
+	// StageTimestamp is when the event's stage was reached; for the
+	// ResponseComplete stage, when the change was made.
+	StageTimestamp time.Time `json:"stageTimestamp"`
+}
+
+// UserInfo identifies the caller of an audited request.
+type UserInfo struct {
+	Username string   `json:"username"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// ObjectRef is the object an audited request acted on.
+type ObjectRef struct {
+	Resource    string `json:"resource,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	APIGroup    string `json:"apiGroup,omitempty"`
+	APIVersion  string `json:"apiVersion,omitempty"`
+	Subresource string `json:"subresource,omitempty"`
+}
+
+// ResponseStatus is the outcome of an audited request.
+type ResponseStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Query selects audit events. Zero fields match everything, except Verbs,
+// which default to WriteVerbs.
+type Query struct {
+	Since time.Time
+	Until time.Time
+	Verbs []string
+	// Resource is a resource (deployments) or kind (Deployment).
+	Resource  string
+	Namespace string
+	Name      string
+	// User matches usernames containing it.
+	User  string
+	Limit int
+}
+
+func (q Query) verbs() []string {
+	if len(q.Verbs) == 0 {
+		return WriteVerbs
+	}
+	return q.Verbs
+}
+
+func (q Query) limit() int {
+	if q.Limit <= 0 {
+		return DefaultLimit
+	}
+	return q.Limit
+}
+
+// Matches reports whether e is selected by q. Only events of the
+// ResponseComplete stage match, so each request is reported once.
+func (q Query) Matches(e Event) bool {
+	if e.Stage != "" && e.Stage != "ResponseComplete" {
+		return false
+	}
+	if !q.Since.IsZero() && e.StageTimestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && e.StageTimestamp.After(q.Until) {
+		return false
+	}
+	if !slices.Contains(q.verbs(), e.Verb) {
+		return false
+	}
+	if q.User != "" && !strings.Contains(strings.ToLower(e.User.Username), strings.ToLower(q.User)) {
+		return false
+	}
+	if q.Resource == "" && q.Namespace == "" && q.Name == "" {
+		return true
+	}
+	ref := e.ObjectRef
+	if ref == nil {
+		return false
+	}
+	return (q.Resource == "" || matchesResource(ref.Resource, q.Resource)) &&
+		(q.Namespace == "" || ref.Namespace == q.Namespace) &&
+		(q.Name == "" || ref.Name == q.Name)
+}
+
+// matchesResource reports whether the audited resource, always a
+// lowercase plural, is want, given as a resource or a kind.
+func matchesResource(resource, want string) bool {
+	return slices.Contains(resourceCandidates(want), resource)
+}
+
+// resourceCandidates are the resources want may name: itself, lowercased,
+// and its plural forms.
+func resourceCandidates(want string) []string {
+	want = strings.ToLower(want)
+	candidates := []string{want, want + "s", want + "es"}
+	if strings.HasSuffix(want, "y") {
+		candidates = append(candidates, strings.TrimSuffix(want, "y")+"ies")
+	}
+	return candidates
+}
+
+// newest sorts events newest first and keeps the query's limit.
+func newest(events []Event, q Query) []Event {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].StageTimestamp.After(events[j].StageTimestamp)
+	})
+	if len(events) > q.limit() {
+		events = events[:q.limit()]
+	}
+	return events
+}
+
+// Source is where the audit log of a cluster is read from.
+type Source interface {
+	// Query returns the events q selects, newest first.
+	Query(ctx context.Context, q Query) ([]Event, error)
+	// String describes the source.
+	String() string
+}
+
+// ParseSource parses a source: file:<path or glob>, webhook, or
+// cloudwatch:<log group> or gcp:<project>/<cluster> for EKS and GKE.
+// Webhook sources read the events posted to DefaultReceiver for cluster.
+func ParseSource(spec, cluster string) (Source, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "file":
+		if arg == "" {
+			return nil, fmt.Errorf("audit log source %q: missing path", spec)
+		}
+		return fileSource{pattern: arg}, nil
+	case "webhook":
+		return webhookSource{receiver: DefaultReceiver, cluster: cluster}, nil
+	case "cloudwatch":
+		if arg == "" {
+			return nil, fmt.Errorf("audit log source %q: missing log group (e.g. /aws/eks/<cluster>/cluster)", spec)
+		}
+		return cloudWatchSource{logGroup: arg}, nil
+	case "gcp":
+		project, name, ok := strings.Cut(arg, "/")
+		if !ok || project == "" || name == "" {
+			return nil, fmt.Errorf("audit log source %q: expected gcp:<project>/<cluster>", spec)
+		}
+		return gcpSource{project: project, cluster: name}, nil
+	}
+	return nil, fmt.Errorf("unknown audit log source %q (expected file:, webhook, cloudwatch:, or gcp:)", spec)
+}
+
+// SourceSpec returns the source for cluster from EnvAuditLog, falling back
+// to the * entry, or "" if there is none.
+func SourceSpec(cluster string) string {
+	fallback := ""
+	for _, entry := range strings.Split(os.Getenv(EnvAuditLog), ",") {
+		name, spec, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		switch name {
+		case cluster:
+			return spec
+		case "*":
+			fallback = spec
+		}
+	}
+	return fallback
+}
+
+// SourceFor returns the configured source for cluster.
+func SourceFor(cluster string) (Source, error) {
+	spec := SourceSpec(cluster)
+	if spec == "" {
+		return nil, fmt.Errorf("no audit log source is configured for cluster %s (set %s)", cluster, EnvAuditLog)
+	}
+	return ParseSource(spec, cluster)
+}
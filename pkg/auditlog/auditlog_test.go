@@ -0,0 +1,209 @@
+package auditlog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+func auditEvent(verb, user, resource, namespace, name string, age time.Duration) Event {
+	return Event{
+		AuditID:        verb + "-" + name,
+		Stage:          "ResponseComplete",
+		Verb:           verb,
+		User:           UserInfo{Username: user},
+		ObjectRef:      &ObjectRef{Resource: resource, Namespace: namespace, Name: name, APIGroup: "apps", APIVersion: "v1"},
+		ResponseStatus: &ResponseStatus{Code: 200},
+		StageTimestamp: now.Add(-age),
+	}
+}
+
+func jsonLines(t *testing.T, events ...Event) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, e := range events {
+		data, err := json.Marshal(e)
+		require.NoError(t, err)
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func TestQueryMatches(t *testing.T) {
+	deleted := auditEvent("delete", "alice@example.com", "deployments", "shop", "web", time.Hour)
+	q := Query{Since: now.Add(-24 * time.Hour), Resource: "Deployment", Namespace: "shop", Name: "web"}
+	assert.True(t, q.Matches(deleted))
+
+	assert.False(t, Query{Since: now.Add(-30 * time.Minute)}.Matches(deleted), "too old")
+	assert.False(t, Query{Verbs: []string{"patch"}}.Matches(deleted))
+	assert.False(t, Query{}.Matches(auditEvent("get", "alice", "deployments", "shop", "web", 0)), "reads are not matched by default")
+	assert.True(t, Query{User: "ALICE"}.Matches(deleted))
+	assert.False(t, Query{User: "bob"}.Matches(deleted))
+	assert.True(t, Query{Resource: "NetworkPolicy"}.Matches(auditEvent("create", "a", "networkpolicies", "", "", 0)))
+	assert.True(t, Query{Resource: "ingress"}.Matches(auditEvent("create", "a", "ingresses", "", "", 0)))
+
+	started := deleted
+	started.Stage = "RequestReceived"
+	assert.False(t, Query{}.Matches(started), "each request is reported once")
+}
+
+func TestFileSource(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "audit.log"), append(jsonLines(t,
+		auditEvent("delete", "alice", "deployments", "shop", "web", time.Hour),
+		auditEvent("get", "bob", "deployments", "shop", "web", time.Hour),
+	), "not json\n"...), 0o600))
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, _ = w.Write(jsonLines(t, auditEvent("create", "carol", "deployments", "shop", "web", 48*time.Hour)))
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "audit-2026-10-14.log.gz"), gz.Bytes(), 0o600))
+
+	source, err := ParseSource("file:"+filepath.Join(dir, "audit*"), "prod")
+	require.NoError(t, err)
+	events, err := source.Query(context.Background(), Query{Name: "web"})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "alice", events[0].User.Username, "newest first")
+	assert.Equal(t, "carol", events[1].User.Username, "rotated, gzipped files are read")
+
+	events, err = source.Query(context.Background(), Query{Name: "web", Limit: 1})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	_, err = fileSource{pattern: filepath.Join(dir, "missing*")}.Query(context.Background(), Query{})
+	assert.ErrorContains(t, err, "no audit log files match")
+}
+
+func TestReceiver(t *testing.T) {
+	t.Setenv(EnvWebhookToken, "s3cret")
+	receiver := NewReceiver(2)
+	post := func(path, token string, events ...Event) int {
+		body, _ := json.Marshal(map[string]interface{}{"kind": "EventList", "apiVersion": "audit.k8s.io/v1", "items": events})
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		receiver.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	started := auditEvent("delete", "alice", "deployments", "shop", "web", 3*time.Hour)
+	started.Stage = "ResponseStarted"
+
+	assert.Equal(t, http.StatusUnauthorized, post("/audit/prod", "wrong"))
+	assert.Equal(t, http.StatusNotFound, post("/audit/", "s3cret"))
+	assert.Equal(t, http.StatusOK, post("/audit/prod", "s3cret",
+		started,
+		auditEvent("create", "alice", "deployments", "shop", "web", 3*time.Hour),
+		auditEvent("patch", "bob", "deployments", "shop", "web", 2*time.Hour),
+		auditEvent("delete", "carol", "deployments", "shop", "web", time.Hour)))
+
+	source := webhookSource{receiver: receiver, cluster: "prod"}
+	events, err := source.Query(context.Background(), Query{})
+	require.NoError(t, err)
+	require.Len(t, events, 2, "the oldest events beyond capacity are dropped")
+	assert.Equal(t, []string{"carol", "bob"}, []string{events[0].User.Username, events[1].User.Username})
+	assert.Empty(t, receiver.Query("staging", Query{}))
+}
+
+func stubCommand(t *testing.T, out string) *[]string {
+	t.Helper()
+	var called []string
+	orig := runCommand
+	runCommand = func(_ context.Context, name string, args ...string) ([]byte, error) {
+		called = append([]string{name}, args...)
+		return []byte(out), nil
+	}
+	t.Cleanup(func() { runCommand = orig })
+	return &called
+}
+
+func TestCloudWatchSource(t *testing.T) {
+	message, _ := json.Marshal(auditEvent("delete", "arn:aws:iam::1:user/alice", "deployments", "shop", "web", time.Hour))
+	out, _ := json.Marshal(map[string]interface{}{"events": []map[string]interface{}{{"message": string(message)}, {"message": "garbage"}}})
+	called := stubCommand(t, string(out))
+
+	source, err := ParseSource("cloudwatch:/aws/eks/prod/cluster", "prod")
+	require.NoError(t, err)
+	events, err := source.Query(context.Background(), Query{Since: now.Add(-24 * time.Hour), Resource: "deployment", Name: "web"})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "arn:aws:iam::1:user/alice", events[0].User.Username)
+
+	args := strings.Join(*called, " ")
+	assert.Contains(t, args, "aws logs filter-log-events --log-group-name /aws/eks/prod/cluster --log-stream-name-prefix kube-apiserver-audit")
+	assert.Contains(t, args, `($.objectRef.resource = "deployment" || $.objectRef.resource = "deployments" || $.objectRef.resource = "deploymentes")`)
+	assert.Contains(t, args, `$.objectRef.name = "web"`)
+	assert.Contains(t, args, "--start-time 1792065600000")
+}
+
+func TestGCPSource(t *testing.T) {
+	called := stubCommand(t, `[
+	  {"insertId": "abc", "timestamp": "2026-10-16T11:00:00Z", "protoPayload": {
+	    "methodName": "io.k8s.apps.v1.deployments.delete",
+	    "resourceName": "apps/v1/namespaces/shop/deployments/web",
+	    "authenticationInfo": {"principalEmail": "alice@example.com"},
+	    "requestMetadata": {"callerIp": "10.0.0.1", "callerSuppliedUserAgent": "kubectl/v1.31"},
+	    "status": {}}},
+	  {"insertId": "def", "timestamp": "2026-10-16T10:00:00Z", "protoPayload": {
+	    "methodName": "io.k8s.core.v1.pods.status.patch",
+	    "resourceName": "core/v1/namespaces/shop/pods/web-1/status",
+	    "status": {"code": 7, "message": "forbidden"}}},
+	  {"insertId": "ghi", "protoPayload": {"methodName": "google.container.v1.ClusterManager.UpdateCluster"}}
+	]`)
+
+	source, err := ParseSource("gcp:my-project/prod", "prod")
+	require.NoError(t, err)
+	events, err := source.Query(context.Background(), Query{Namespace: "shop"})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, Event{
+		AuditID: "abc", Verb: "delete",
+		User:           UserInfo{Username: "alice@example.com"},
+		SourceIPs:      []string{"10.0.0.1"},
+		UserAgent:      "kubectl/v1.31",
+		ObjectRef:      &ObjectRef{Resource: "deployments", Namespace: "shop", Name: "web", APIGroup: "apps", APIVersion: "v1"},
+		ResponseStatus: &ResponseStatus{Code: 200},
+		StageTimestamp: time.Date(2026, 10, 16, 11, 0, 0, 0, time.UTC),
+	}, events[0])
+	assert.Equal(t, &ObjectRef{Resource: "pods", Subresource: "status", Namespace: "shop", Name: "web-1", APIVersion: "v1"}, events[1].ObjectRef)
+	assert.Equal(t, 403, events[1].ResponseStatus.Code)
+
+	filter := (*called)[3]
+	assert.Contains(t, filter, `resource.labels.cluster_name="prod"`)
+	assert.Contains(t, filter, `protoPayload.resourceName:"/namespaces/shop/"`)
+	assert.Contains(t, filter, `protoPayload.methodName=~"^io\\.k8s\\..*\\.(create|update|patch|delete|deletecollection)$"`)
+	assert.Equal(t, []string{"--project", "my-project", "--order", "desc", "--limit", "50", "--format", "json"}, (*called)[4:])
+}
+
+func TestSourceFor(t *testing.T) {
+	t.Setenv(EnvAuditLog, "*=webhook, prod=gcp:my-project/prod")
+	source, err := SourceFor("prod")
+	require.NoError(t, err)
+	assert.Equal(t, "Cloud Logging my-project/prod", source.String())
+	source, err = SourceFor("dev")
+	require.NoError(t, err)
+	assert.Equal(t, "webhook /audit/dev", source.String())
+
+	t.Setenv(EnvAuditLog, "prod=file:/var/log/audit.log")
+	_, err = SourceFor("dev")
+	assert.ErrorContains(t, err, "no audit log source is configured for cluster dev")
+
+	for _, spec := range []string{"file:", "cloudwatch:", "gcp:my-project", "loki:http://loki"} {
+		_, err := ParseSource(spec, "prod")
+		assert.Error(t, err, spec)
+	}
+}
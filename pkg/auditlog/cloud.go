@@ -0,0 +1,249 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCloudWatchEvents bounds the events fetched from CloudWatch Logs,
+// which returns them oldest first.
+const maxCloudWatchEvents = 10000
+
+// runCommand runs a cloud CLI and returns its stdout. Tests replace it.
+var runCommand = func(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %s", name, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", name, err)
+	}
+	return out, nil
+}
+
+// cloudWatchSource reads the kube-apiserver-audit streams EKS writes to
+// the cluster's CloudWatch Logs group with the aws CLI, which uses its own
+// credentials and region.
+type cloudWatchSource struct {
+	logGroup string
+}
+
+func (c cloudWatchSource) String() string { return "CloudWatch Logs " + c.logGroup }
+
+func (c cloudWatchSource) Query(ctx context.Context, q Query) ([]Event, error) {
+	args := []string{"logs", "filter-log-events",
+		"--log-group-name", c.logGroup,
+		"--log-stream-name-prefix", "kube-apiserver-audit",
+		"--filter-pattern", cloudWatchFilter(q),
+		"--max-items", strconv.Itoa(maxCloudWatchEvents),
+		"--output", "json",
+	}
+	if !q.Since.IsZero() {
+		args = append(args, "--start-time", strconv.FormatInt(q.Since.UnixMilli(), 10))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, "--end-time", strconv.FormatInt(q.Until.UnixMilli(), 10))
+	}
+	out, err := runCommand(ctx, "aws", args...)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Events []struct {
+			Message string `json:"message"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse aws output: %w", err)
+	}
+	var events []Event
+	for _, le := range result.Events {
+		var e Event
+		if err := json.Unmarshal([]byte(le.Message), &e); err == nil && q.Matches(e) {
+			events = append(events, e)
+		}
+	}
+	return newest(events, q), nil
+}
+
+// cloudWatchFilter is a CloudWatch Logs JSON filter pattern for q. The
+// user, matched as a substring, is filtered afterwards.
+func cloudWatchFilter(q Query) string {
+	oneOf := func(field string, values []string) string {
+		terms := make([]string, len(values))
+		for i, v := range values {
+			terms[i] = fmt.Sprintf("%s = %q", field, v)
+		}
+		return "(" + strings.Join(terms, " || ") + ")"
+	}
+	terms := []string{`$.stage = "ResponseComplete"`, oneOf("$.verb", q.verbs())}
+	if q.Resource != "" {
+		terms = append(terms, oneOf("$.objectRef.resource", resourceCandidates(q.Resource)))
+	}
+	if q.Namespace != "" {
+		terms = append(terms, fmt.Sprintf("$.objectRef.namespace = %q", q.Namespace))
+	}
+	if q.Name != "" {
+		terms = append(terms, fmt.Sprintf("$.objectRef.name = %q", q.Name))
+	}
+	return "{ " + strings.Join(terms, " && ") + " }"
+}
+
+// gcpSource reads a GKE cluster's Kubernetes audit logs from Cloud Logging
+// with the gcloud CLI, which uses its own credentials.
+type gcpSource struct {
+	project string
+	cluster string
+}
+
+func (g gcpSource) String() string { return "Cloud Logging " + g.project + "/" + g.cluster }
+
+func (g gcpSource) Query(ctx context.Context, q Query) ([]Event, error) {
+	out, err := runCommand(ctx, "gcloud", "logging", "read", gcpFilter(g.cluster, q),
+		"--project", g.project, "--order", "desc", "--limit", strconv.Itoa(q.limit()), "--format", "json")
+	if err != nil {
+		return nil, err
+	}
+	var entries []gcpEntry
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse gcloud output: %w", err)
+	}
+	var events []Event
+	for _, entry := range entries {
+		if e, ok := entry.event(); ok && q.Matches(e) {
+			events = append(events, e)
+		}
+	}
+	return newest(events, q), nil
+}
+
+// gcpFilter is a Cloud Logging filter for q on cluster. Method names are
+// io.k8s.<group>.<version>.<resource>[.<subresource>].<verb> and resource
+// names <group>/<version>/namespaces/<namespace>/<resource>/<name>.
+func gcpFilter(cluster string, q Query) string {
+	terms := []string{
+		`resource.type="k8s_cluster"`,
+		fmt.Sprintf("resource.labels.cluster_name=%q", cluster),
+		`logName:"cloudaudit.googleapis.com"`,
+	}
+	if !q.Since.IsZero() {
+		terms = append(terms, fmt.Sprintf("timestamp>=%q", q.Since.UTC().Format(time.RFC3339)))
+	}
+	if !q.Until.IsZero() {
+		terms = append(terms, fmt.Sprintf("timestamp<=%q", q.Until.UTC().Format(time.RFC3339)))
+	}
+	quote := func(values []string) string {
+		quoted := make([]string, len(values))
+		for i, v := range values {
+			quoted[i] = regexp.QuoteMeta(v)
+		}
+		return strings.Join(quoted, "|")
+	}
+	method := `^io\.k8s\..*`
+	if q.Resource != "" {
+		method += `\.(` + quote(resourceCandidates(q.Resource)) + `)(\.[a-z]+)?`
+	}
+	method += `\.(` + quote(q.verbs()) + `)$`
+	terms = append(terms, fmt.Sprintf("protoPayload.methodName=~%q", method))
+	switch {
+	case q.Namespace != "" && q.Name != "":
+		terms = append(terms, fmt.Sprintf("protoPayload.resourceName=~%q", "/namespaces/"+regexp.QuoteMeta(q.Namespace)+"/[^/]+/"+regexp.QuoteMeta(q.Name)+"(/|$)"))
+	case q.Namespace != "":
+		terms = append(terms, fmt.Sprintf("protoPayload.resourceName:%q", "/namespaces/"+q.Namespace+"/"))
+	case q.Name != "":
+		terms = append(terms, fmt.Sprintf("protoPayload.resourceName=~%q", "/"+regexp.QuoteMeta(q.Name)+"(/|$)"))
+	}
+	if q.User != "" {
+		terms = append(terms, fmt.Sprintf("protoPayload.authenticationInfo.principalEmail:%q", q.User))
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// gcpEntry is a Cloud Audit Logs entry for a Kubernetes API request.
+type gcpEntry struct {
+	InsertID     string    `json:"insertId"`
+	Timestamp    time.Time `json:"timestamp"`
+	ProtoPayload struct {
+		MethodName         string `json:"methodName"`
+		ResourceName       string `json:"resourceName"`
+		AuthenticationInfo struct {
+			PrincipalEmail string `json:"principalEmail"`
+		} `json:"authenticationInfo"`
+		RequestMetadata struct {
+			CallerIP                string `json:"callerIp"`
+			CallerSuppliedUserAgent string `json:"callerSuppliedUserAgent"`
+		} `json:"requestMetadata"`
+		Status struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"status"`
+	} `json:"protoPayload"`
+}
+
+// grpcToHTTP maps the gRPC status codes of failed Kubernetes requests back
+// to the HTTP status the API server returned.
+var grpcToHTTP = map[int]int{0: 200, 3: 400, 5: 404, 6: 409, 7: 403, 9: 422, 10: 409, 16: 401}
+
+// event converts entry to an audit Event, or reports false if it is not
+// for a Kubernetes API request.
+func (entry gcpEntry) event() (Event, bool) {
+	p := entry.ProtoPayload
+	parts := strings.Split(strings.TrimPrefix(p.MethodName, "io.k8s."), ".")
+	version := -1
+	for i, part := range parts {
+		if len(part) > 1 && part[0] == 'v' && part[1] >= '0' && part[1] <= '9' {
+			version = i
+			break
+		}
+	}
+	if !strings.HasPrefix(p.MethodName, "io.k8s.") || version < 0 || len(parts) < version+3 {
+		return Event{}, false
+	}
+	ref := &ObjectRef{
+		APIGroup:   strings.Join(parts[:version], "."),
+		APIVersion: parts[version],
+		Resource:   parts[version+1],
+	}
+	if ref.APIGroup == "core" {
+		ref.APIGroup = ""
+	}
+	if len(parts) > version+3 {
+		ref.Subresource = parts[version+2]
+	}
+	segments := strings.Split(p.ResourceName, "/")
+	for i, s := range segments {
+		if s == "namespaces" && i+1 < len(segments) && ref.Resource != "namespaces" {
+			ref.Namespace = segments[i+1]
+		}
+		if s == ref.Resource && i+1 < len(segments) {
+			ref.Name = segments[i+1]
+		}
+	}
+	code, ok := grpcToHTTP[p.Status.Code]
+	if !ok {
+		code = 500
+	}
+	e := Event{
+		AuditID:        entry.InsertID,
+		Verb:           parts[len(parts)-1],
+		User:           UserInfo{Username: p.AuthenticationInfo.PrincipalEmail},
+		UserAgent:      p.RequestMetadata.CallerSuppliedUserAgent,
+		ObjectRef:      ref,
+		ResponseStatus: &ResponseStatus{Code: code, Message: p.Status.Message},
+		StageTimestamp: entry.Timestamp,
+	}
+	if p.RequestMetadata.CallerIP != "" {
+		e.SourceIPs = []string{p.RequestMetadata.CallerIP}
+	}
+	return e, true
+}
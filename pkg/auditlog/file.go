@@ -0,0 +1,88 @@
+package auditlog
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxLineBytes bounds one audit event line; events with large request
+// bodies can exceed bufio.Scanner's default.
+const maxLineBytes = 4 << 20
+
+// fileSource reads the JSON-lines logs of the API server's log backend
+// (--audit-log-path). The pattern may be a glob, to include rotated files,
+// which may be gzipped.
+type fileSource struct {
+	pattern string
+}
+
+func (f fileSource) String() string { return "file " + f.pattern }
+
+func (f fileSource) Query(ctx context.Context, q Query) ([]Event, error) {
+	paths, err := filepath.Glob(f.pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit log path %q: %w", f.pattern, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no audit log files match %s", f.pattern)
+	}
+	var events []Event
+	for _, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		matched, err := readLogFile(path, q)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, matched...)
+	}
+	return newest(events, q), nil
+}
+
+func readLogFile(path string, q Query) ([]Event, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+	return scanEvents(r, q)
+}
+
+// scanEvents returns the events q matches from JSON lines, skipping lines
+// that are not events.
+func scanEvents(r io.Reader, q Query) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if q.Matches(e) {
+			events = append(events, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return events, nil
+}
@@ -0,0 +1,136 @@
+package auditlog
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvWebhookToken, if set, is the bearer token API servers must send to the
+// Receiver, from the token field of their audit webhook kubeconfig.
+const EnvWebhookToken = "KUBESTELLAR_AUDIT_WEBHOOK_TOKEN"
+
+// WebhookPath is where the Receiver accepts events; the cluster name
+// follows it, e.g. /audit/prod-east.
+const WebhookPath = "/audit/"
+
+// defaultCapacity is the number of events a Receiver keeps per cluster.
+const defaultCapacity = 10000
+
+// maxBatchBytes bounds one posted EventList.
+const maxBatchBytes = 32 << 20
+
+// DefaultReceiver holds the events posted to the webhook listener, and is
+// what webhook sources read.
+var DefaultReceiver = NewReceiver(defaultCapacity)
+
+// Receiver is an http.Handler for the API server's audit webhook backend
+// (--audit-webhook-config-file). It keeps the most recent completed events
+// of each cluster in memory, so they are lost on restart.
+type Receiver struct {
+	capacity int
+	token    string
+	mu       sync.Mutex
+	events   map[string][]Event
+}
+
+// NewReceiver returns a Receiver that keeps capacity events per cluster and
+// requires $KUBESTELLAR_AUDIT_WEBHOOK_TOKEN if it is set.
+func NewReceiver(capacity int) *Receiver {
+	return &Receiver{capacity: capacity, token: os.Getenv(EnvWebhookToken), events: make(map[string][]Event)}
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.token != "" {
+		got := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(r.token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+	cluster := strings.TrimPrefix(req.URL.Path, WebhookPath)
+	if cluster == "" || cluster == req.URL.Path || strings.Contains(cluster, "/") {
+		http.Error(w, "post events to "+WebhookPath+"<cluster>", http.StatusNotFound)
+		return
+	}
+	var list struct {
+		Items []Event `json:"items"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxBatchBytes)).Decode(&list); err != nil {
+		http.Error(w, fmt.Sprintf("invalid EventList: %v", err), http.StatusBadRequest)
+		return
+	}
+	r.Add(cluster, list.Items...)
+	w.WriteHeader(http.StatusOK)
+}
+
+// Add records the completed events among events for cluster, dropping the
+// oldest beyond the Receiver's capacity.
+func (r *Receiver) Add(cluster string, events ...Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.events[cluster]
+	for _, e := range events {
+		if e.Stage == "" || e.Stage == "ResponseComplete" {
+			kept = append(kept, e)
+		}
+	}
+	if over := len(kept) - r.capacity; over > 0 {
+		kept = append([]Event(nil), kept[over:]...)
+	}
+	r.events[cluster] = kept
+}
+
+// Query returns the events of cluster that q selects, newest first.
+func (r *Receiver) Query(cluster string, q Query) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var events []Event
+	for _, e := range r.events[cluster] {
+		if q.Matches(e) {
+			events = append(events, e)
+		}
+	}
+	return newest(events, q)
+}
+
+// Serve accepts audit webhook events for DefaultReceiver on addr until ctx
+// is done.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(WebhookPath, DefaultReceiver)
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("audit webhook listener: %w", err)
+	}
+	return nil
+}
+
+// webhookSource reads the events a Receiver holds for a cluster.
+type webhookSource struct {
+	receiver *Receiver
+	cluster  string
+}
+
+func (w webhookSource) String() string { return "webhook " + WebhookPath + w.cluster }
+
+func (w webhookSource) Query(_ context.Context, q Query) ([]Event, error) {
+	return w.receiver.Query(w.cluster, q), nil
+}
@@ -0,0 +1,452 @@
+// Package auth authenticates MCP clients that connect over HTTP. Callers
+// present an OIDC ID token as a bearer token; the token is verified against
+// the issuer's published keys and mapped to a Kubernetes user and groups,
+// following the same rules as the API server's --oidc-* flags.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Identity is an authenticated caller.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// OIDCConfig configures token verification.
+type OIDCConfig struct {
+	// IssuerURL must match the token's iss claim exactly. Keys are found
+	// through IssuerURL/.well-known/openid-configuration.
+	IssuerURL string
+	// ClientID must appear in the token's aud claim.
+	ClientID string
+	// UsernameClaim names the claim used as the user; the default is sub.
+	UsernameClaim string
+	// GroupsClaim names the claim holding the user's groups; the default
+	// is groups. A missing claim means no groups.
+	GroupsClaim string
+	// UsernamePrefix and GroupsPrefix are prepended to the mapped names,
+	// e.g. "oidc:", to keep them apart from other authenticators' users.
+	UsernamePrefix string
+	GroupsPrefix   string
+	// HTTPClient fetches the discovery document and keys. The default is
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// clockSkew is the leeway allowed when checking exp and nbf.
+const clockSkew = time.Minute
+
+// keyRefreshInterval limits how often an unknown key ID triggers a refetch
+// of the issuer's keys.
+const keyRefreshInterval = time.Minute
+
+// keyFetchTimeout bounds a fetch of the issuer's keys, which outlives the
+// request that started it when other requests wait for the same keys.
+const keyFetchTimeout = 30 * time.Second
+
+// minRSAKeyBits is the smallest RSA modulus accepted from the issuer.
+const minRSAKeyBits = 2048
+
+// OIDCVerifier verifies ID tokens from one issuer.
+type OIDCVerifier struct {
+	config OIDCConfig
+	now    func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]signingKey
+	lastFetched time.Time
+	// fetches collapses concurrent fetches of the key set into one. It
+	// runs without mu held, so tokens signed with known keys are verified
+	// while a fetch is in flight.
+	fetches singleflight.Group
+}
+
+// signingKey is one of the issuer's keys and the algorithm its JWK
+// restricts it to, if any.
+type signingKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// NewOIDCVerifier creates a verifier. Keys are fetched on first use.
+func NewOIDCVerifier(config OIDCConfig) (*OIDCVerifier, error) {
+	if config.IssuerURL == "" {
+		return nil, errors.New("OIDC issuer URL is required")
+	}
+	if config.ClientID == "" {
+		return nil, errors.New("OIDC client ID is required")
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "sub"
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	return &OIDCVerifier{config: config, now: time.Now}, nil
+}
+
+// BearerToken returns the token from an Authorization: Bearer header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Verify checks the token's signature, issuer, audience, and validity
+// period, and returns the identity it asserts.
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, errors.New("malformed token")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Identity{}, fmt.Errorf("malformed token header: %w", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, fmt.Errorf("malformed token signature: %w", err)
+	}
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return Identity{}, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], sig); err != nil {
+		return Identity{}, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Identity{}, fmt.Errorf("malformed token claims: %w", err)
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return Identity{}, err
+	}
+	return v.identity(claims)
+}
+
+func (v *OIDCVerifier) checkClaims(claims map[string]interface{}) error {
+	if iss, _ := claims["iss"].(string); iss != v.config.IssuerURL {
+		return fmt.Errorf("token issuer %q is not %q", iss, v.config.IssuerURL)
+	}
+	if !containsString(claims["aud"], v.config.ClientID) {
+		return fmt.Errorf("token audience does not include %q", v.config.ClientID)
+	}
+	now := v.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(clockSkew)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(clockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+func (v *OIDCVerifier) identity(claims map[string]interface{}) (Identity, error) {
+	user, _ := claims[v.config.UsernameClaim].(string)
+	if user == "" {
+		return Identity{}, fmt.Errorf("token has no %s claim", v.config.UsernameClaim)
+	}
+	if v.config.UsernameClaim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			return Identity{}, errors.New("token email is not verified")
+		}
+	}
+	id := Identity{User: v.config.UsernamePrefix + user}
+	for _, group := range stringList(claims[v.config.GroupsClaim]) {
+		id.Groups = append(id.Groups, v.config.GroupsPrefix+group)
+	}
+	return id, nil
+}
+
+// key returns the issuer's key with id kid, refetching the key set when
+// the key is unknown (the issuer may have rotated keys).
+func (v *OIDCVerifier) key(ctx context.Context, kid string) (signingKey, error) {
+	v.mu.Lock()
+	key, ok := v.lookup(kid)
+	recent := v.keys != nil && v.now().Sub(v.lastFetched) < keyRefreshInterval
+	v.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if recent {
+		return signingKey{}, fmt.Errorf("token signed with unknown key %q", kid)
+	}
+
+	fetched := v.fetches.DoChan("keys", func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), keyFetchTimeout)
+		defer cancel()
+		keys, err := v.fetchKeys(ctx)
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		v.lastFetched = v.now()
+		if err == nil {
+			v.keys = keys
+		}
+		return nil, err
+	})
+	select {
+	case <-ctx.Done():
+		return signingKey{}, ctx.Err()
+	case result := <-fetched:
+		if result.Err != nil {
+			return signingKey{}, result.Err
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+	return signingKey{}, fmt.Errorf("token signed with unknown key %q", kid)
+}
+
+// lookup finds kid among the cached keys. A token without kid may use the
+// issuer's only key. v.mu must be held.
+func (v *OIDCVerifier) lookup(kid string) (signingKey, bool) {
+	if key, ok := v.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	return signingKey{}, false
+}
+
+func (v *OIDCVerifier) fetchKeys(ctx context.Context) (map[string]signingKey, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	wellKnown := strings.TrimSuffix(v.config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := v.getJSON(ctx, wellKnown, &discovery); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if discovery.Issuer != v.config.IssuerURL {
+		return nil, fmt.Errorf("OIDC discovery issuer %q does not match %q", discovery.Issuer, v.config.IssuerURL)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, discovery.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC keys: %w", err)
+	}
+	keys := make(map[string]signingKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = signingKey{key: key, alg: jwk.Alg}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("issuer publishes no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if n.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("RSA key of %d bits is too small", n.BitLen())
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 || e.Bit(0) == 0 {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+		// ECDH rejects points that are not on the curve.
+		if _, err := key.ECDH(); err != nil {
+			return nil, fmt.Errorf("invalid EC key: %w", err)
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// ecAlgorithms maps each curve to the only algorithm its keys may verify.
+var ecAlgorithms = map[string]string{"P-256": "ES256", "P-384": "ES384", "P-521": "ES512"}
+
+// verifySignature checks sig with key. The token's alg must be one the key
+// is for: the JWK's alg if it names one, RS* for RSA keys, and the ES*
+// algorithm of an EC key's curve.
+func verifySignature(alg string, key signingKey, signed string, sig []byte) error {
+	if key.alg != "" && key.alg != alg {
+		return fmt.Errorf("key is for %q, not token algorithm %q", key.alg, alg)
+	}
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported token algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	switch key := key.key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("key does not match token algorithm %q", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(key, hash, digest, sig); err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if ecAlgorithms[key.Curve.Params().Name] != alg {
+			return fmt.Errorf("key does not match token algorithm %q", alg)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	}
+	return errors.New("unsupported key")
+}
+
+func decodeSegment(seg string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// containsString reports whether v, a string or array of strings, holds s.
+func containsString(v interface{}, s string) bool {
+	for _, item := range stringList(v) {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func stringList(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
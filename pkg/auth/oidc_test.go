@@ -0,0 +1,265 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testIssuer struct {
+	srv    *httptest.Server
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+	fetch  int
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": iss.srv.URL, "jwks_uri": iss.srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		iss.fetch++
+		b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	iss.srv = httptest.NewServer(mux)
+	t.Cleanup(iss.srv.Close)
+	return iss
+}
+
+func (iss *testIssuer) token(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	alg := "RS256"
+	if kid == "ec" {
+		alg = "ES256"
+	}
+	enc := func(v interface{}) string {
+		data, _ := json.Marshal(v)
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	signed := enc(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+	var sig []byte
+	var err error
+	if kid == "ec" {
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, iss.ecKey, digest[:])
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	} else {
+		sig, err = rsa.SignPKCS1v15(rand.Reader, iss.rsaKey, crypto.SHA256, digest[:])
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (iss *testIssuer) claims(extra map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":    iss.srv.URL,
+		"aud":    []string{"kubestellar-mcp", "other"},
+		"sub":    "alice",
+		"email":  "alice@example.com",
+		"groups": []string{"sre", "dev"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	return claims
+}
+
+func TestOIDCVerifier(t *testing.T) {
+	iss := newTestIssuer(t)
+	v, err := NewOIDCVerifier(OIDCConfig{IssuerURL: iss.srv.URL, ClientID: "kubestellar-mcp", GroupsPrefix: "oidc:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	for _, kid := range []string{"rsa", "ec"} {
+		id, err := v.Verify(ctx, iss.token(t, kid, iss.claims(nil)))
+		if err != nil {
+			t.Fatalf("%s token: %v", kid, err)
+		}
+		want := Identity{User: "alice", Groups: []string{"oidc:sre", "oidc:dev"}}
+		if !reflect.DeepEqual(id, want) {
+			t.Errorf("%s identity = %+v, want %+v", kid, id, want)
+		}
+	}
+	if iss.fetch != 1 {
+		t.Errorf("keys fetched %d times, want 1", iss.fetch)
+	}
+
+	rejected := map[string]string{
+		"expired":        iss.token(t, "rsa", iss.claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"wrong audience": iss.token(t, "rsa", iss.claims(map[string]interface{}{"aud": "someone-else"})),
+		"wrong issuer":   iss.token(t, "rsa", iss.claims(map[string]interface{}{"iss": "https://evil.example"})),
+		"not yet valid":  iss.token(t, "rsa", iss.claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()})),
+		"unknown key":    iss.token(t, "other", iss.claims(nil)),
+		"malformed":      "not-a-token",
+	}
+	tampered := iss.token(t, "rsa", iss.claims(nil))
+	parts := strings.Split(tampered, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"root"}`))
+	rejected["tampered"] = strings.Join(parts, ".")
+
+	for name, token := range rejected {
+		if _, err := v.Verify(ctx, token); err == nil {
+			t.Errorf("%s token was accepted", name)
+		}
+	}
+}
+
+func TestVerifySignatureChecksKeyAgainstAlgorithm(t *testing.T) {
+	iss := newTestIssuer(t)
+	tests := map[string]struct {
+		alg string
+		key signingKey
+	}{
+		"EC key with another curve's algorithm": {"ES384", signingKey{key: &iss.ecKey.PublicKey}},
+		"EC key with an RSA algorithm":          {"RS256", signingKey{key: &iss.ecKey.PublicKey}},
+		"RSA key with an EC algorithm":          {"ES256", signingKey{key: &iss.rsaKey.PublicKey}},
+		"JWK restricted to another algorithm":   {"RS256", signingKey{key: &iss.rsaKey.PublicKey, alg: "RS384"}},
+	}
+	for name, tt := range tests {
+		if err := verifySignature(tt.alg, tt.key, "header.claims", make([]byte, 64)); err == nil || strings.Contains(err.Error(), "invalid token signature") {
+			t.Errorf("%s: err = %v, want a key/algorithm mismatch", name, err)
+		}
+	}
+}
+
+func TestJSONWebKeyRejectsWeakAndInvalidKeys(t *testing.T) {
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid := map[string]jsonWebKey{
+		"1024-bit RSA":    {Kty: "RSA", N: b64(small.N.Bytes()), E: b64(big.NewInt(int64(small.E)).Bytes())},
+		"off-curve point": {Kty: "EC", Crv: "P-256", X: b64(make([]byte, 32)), Y: b64([]byte{1})},
+		"unknown curve":   {Kty: "EC", Crv: "secp256k1", X: b64([]byte{1}), Y: b64([]byte{1})},
+	}
+	for name, jwk := range invalid {
+		if _, err := jwk.publicKey(); err == nil {
+			t.Errorf("%s key was accepted", name)
+		}
+	}
+}
+
+func TestOIDCVerifierKeepsVerifyingDuringKeyFetch(t *testing.T) {
+	iss := newTestIssuer(t)
+	release := make(chan struct{})
+	fetching := make(chan struct{}, 1)
+	var blocking atomic.Bool
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/keys" && blocking.Load() {
+			fetching <- struct{}{}
+			<-release
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	v, _ := NewOIDCVerifier(OIDCConfig{IssuerURL: iss.srv.URL, ClientID: "kubestellar-mcp", HTTPClient: client})
+	ctx := context.Background()
+	if _, err := v.Verify(ctx, iss.token(t, "rsa", iss.claims(nil))); err != nil {
+		t.Fatal(err)
+	}
+
+	// An unknown key, once the refresh interval has passed, refetches the
+	// key set; the fetch hangs until released.
+	v.now = func() time.Time { return time.Now().Add(2 * keyRefreshInterval) }
+	blocking.Store(true)
+	done := make(chan error, 1)
+	go func() {
+		_, err := v.Verify(ctx, iss.token(t, "rotated", iss.claims(nil)))
+		done <- err
+	}()
+	<-fetching
+
+	verified := make(chan error, 1)
+	go func() {
+		_, err := v.Verify(ctx, iss.token(t, "rsa", iss.claims(nil)))
+		verified <- err
+	}()
+	select {
+	case err := <-verified:
+		if err != nil {
+			t.Fatalf("known key: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a token signed with a known key waited for the key fetch")
+	}
+	close(release)
+	if err := <-done; err == nil {
+		t.Fatal("a token signed with an unpublished key was accepted")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestOIDCVerifierEmailClaim(t *testing.T) {
+	iss := newTestIssuer(t)
+	v, _ := NewOIDCVerifier(OIDCConfig{IssuerURL: iss.srv.URL, ClientID: "kubestellar-mcp", UsernameClaim: "email"})
+	id, err := v.Verify(context.Background(), iss.token(t, "rsa", iss.claims(nil)))
+	if err != nil || id.User != "alice@example.com" {
+		t.Fatalf("Verify = %+v, %v", id, err)
+	}
+	unverified := iss.token(t, "rsa", iss.claims(map[string]interface{}{"email_verified": false}))
+	if _, err := v.Verify(context.Background(), unverified); err == nil {
+		t.Error("unverified email must be rejected")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	if _, ok := BearerToken(r); ok {
+		t.Error("no header should give no token")
+	}
+	r.Header.Set("Authorization", "bearer abc.def.ghi")
+	if token, ok := BearerToken(r); !ok || token != "abc.def.ghi" {
+		t.Errorf("BearerToken = %q, %v", token, ok)
+	}
+	r.Header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	if _, ok := BearerToken(r); ok {
+		t.Error("basic auth is not a bearer token")
+	}
+}
+
+func TestNewOIDCVerifierRequiresIssuerAndClient(t *testing.T) {
+	if _, err := NewOIDCVerifier(OIDCConfig{ClientID: "x"}); err == nil {
+		t.Error("issuer is required")
+	}
+	if _, err := NewOIDCVerifier(OIDCConfig{IssuerURL: "https://issuer"}); err == nil {
+		t.Error("client ID is required")
+	}
+}
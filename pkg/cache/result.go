@@ -0,0 +1,155 @@
+// Package cache provides a small in-memory cache for expensive tool results
+// with explicit freshness controls.
+package cache
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Argument names every cacheable tool accepts to control freshness. They are
+// excluded from cache keys.
+const (
+	ArgAllowStale   = "allow_stale"
+	ArgForceRefresh = "force_refresh"
+)
+
+const defaultMaxEntries = 256
+
+// Entry is a cached result and the time it was produced.
+type Entry struct {
+	Value interface{}
+	AsOf  time.Time
+}
+
+// Options are the per-call freshness controls.
+type Options struct {
+	// AllowStale returns a cached result even if it is older than the TTL.
+	AllowStale bool
+	// ForceRefresh ignores any cached result and recomputes it.
+	ForceRefresh bool
+}
+
+// ParseOptions reads allow_stale and force_refresh from tool arguments.
+// Both JSON booleans and the strings "true"/"false" are accepted, matching
+// how other boolean-ish tool arguments are passed.
+func ParseOptions(args map[string]interface{}) Options {
+	return Options{
+		AllowStale:   truthy(args[ArgAllowStale]),
+		ForceRefresh: truthy(args[ArgForceRefresh]),
+	}
+}
+
+func truthy(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	default:
+		return false
+	}
+}
+
+// Key builds a cache key from a tool name and its arguments, ignoring the
+// freshness arguments. encoding/json sorts map keys, so equal arguments give
+// equal keys regardless of order.
+func Key(tool string, args map[string]interface{}) string {
+	filtered := make(map[string]interface{}, len(args))
+	for k, v := range args {
+		if k == ArgAllowStale || k == ArgForceRefresh {
+			continue
+		}
+		filtered[k] = v
+	}
+	data, err := json.Marshal(filtered)
+	if err != nil {
+		return ""
+	}
+	return tool + "\x00" + string(data)
+}
+
+// ResultCache holds tool results keyed by tool and arguments.
+type ResultCache struct {
+	mu         sync.Mutex
+	entries    map[string]Entry
+	maxEntries int
+	now        func() time.Time
+}
+
+// NewResultCache creates a cache holding at most maxEntries results. A
+// non-positive maxEntries selects a default.
+func NewResultCache(maxEntries int) *ResultCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+	return &ResultCache{
+		entries:    make(map[string]Entry),
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+// Get returns the cached entry for key if it is younger than ttl, or of any
+// age when opts.AllowStale is set. opts.ForceRefresh always misses.
+func (c *ResultCache) Get(key string, ttl time.Duration, opts Options) (Entry, bool) {
+	if key == "" || opts.ForceRefresh {
+		return Entry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return Entry{}, false
+	}
+	if !opts.AllowStale && c.now().Sub(entry.AsOf) > ttl {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Put stores value under key, evicting the oldest entry when full, and
+// returns the stored entry.
+func (c *ResultCache) Put(key string, value interface{}) Entry {
+	entry := Entry{Value: value, AsOf: c.now()}
+	if key == "" {
+		return entry
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.maxEntries {
+		c.evictOldestLocked()
+	}
+	c.entries[key] = entry
+	return entry
+}
+
+// Clear drops every entry. Servers call it after changing cluster state, so
+// no cached scan from before the change is served as current.
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+func (c *ResultCache) evictOldestLocked() {
+	var oldestKey string
+	var oldest time.Time
+	for k, e := range c.entries {
+		if oldestKey == "" || e.AsOf.Before(oldest) {
+			oldestKey, oldest = k, e.AsOf
+		}
+	}
+	delete(c.entries, oldestKey)
+}
+
+// Meta returns the _meta fields describing a result's freshness.
+func Meta(entry Entry, cached bool) map[string]interface{} {
+	return map[string]interface{}{
+		"asOf":   entry.AsOf.UTC().Format(time.RFC3339),
+		"cached": cached,
+	}
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeyIgnoresFreshnessArgsAndOrder(t *testing.T) {
+	a := Key("find_pod_issues", map[string]interface{}{"cluster": "prod", "namespace": "web", ArgForceRefresh: true})
+	b := Key("find_pod_issues", map[string]interface{}{"namespace": "web", "cluster": "prod"})
+	if a != b {
+		t.Fatalf("Key() differs for equivalent arguments: %q vs %q", a, b)
+	}
+	if c := Key("find_deployment_issues", map[string]interface{}{"namespace": "web", "cluster": "prod"}); c == a {
+		t.Fatal("Key() must include the tool name")
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	opts := ParseOptions(map[string]interface{}{ArgAllowStale: "true", ArgForceRefresh: true})
+	if !opts.AllowStale || !opts.ForceRefresh {
+		t.Fatalf("ParseOptions() = %+v, want both set", opts)
+	}
+	if opts := ParseOptions(map[string]interface{}{ArgAllowStale: "yes"}); opts.AllowStale {
+		t.Fatal("only true or \"true\" should enable allow_stale")
+	}
+}
+
+func TestResultCacheFreshness(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewResultCache(0)
+	c.now = func() time.Time { return now }
+
+	stored := c.Put("k", "v1")
+	if !stored.AsOf.Equal(now) {
+		t.Fatalf("AsOf = %v, want %v", stored.AsOf, now)
+	}
+
+	if got, ok := c.Get("k", time.Minute, Options{}); !ok || got.Value != "v1" {
+		t.Fatalf("fresh Get() = %+v, %v", got, ok)
+	}
+	if _, ok := c.Get("k", time.Minute, Options{ForceRefresh: true}); ok {
+		t.Fatal("force_refresh must bypass the cache")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("k", time.Minute, Options{}); ok {
+		t.Fatal("expired entry must miss")
+	}
+	if got, ok := c.Get("k", time.Minute, Options{AllowStale: true}); !ok || got.Value != "v1" {
+		t.Fatalf("allow_stale Get() = %+v, %v", got, ok)
+	}
+}
+
+func TestResultCacheEvictsOldest(t *testing.T) {
+	now := time.Unix(0, 0)
+	c := NewResultCache(2)
+	c.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+
+	if _, ok := c.Get("a", time.Hour, Options{}); ok {
+		t.Fatal("oldest entry should have been evicted")
+	}
+	for _, k := range []string{"b", "c"} {
+		if _, ok := c.Get(k, time.Hour, Options{}); !ok {
+			t.Fatalf("entry %q missing", k)
+		}
+	}
+}
+
+func TestResultCacheClear(t *testing.T) {
+	c := NewResultCache(0)
+	c.Put("a", 1)
+	c.Put("b", 2)
+
+	c.Clear()
+	for _, k := range []string{"a", "b"} {
+		if _, ok := c.Get(k, time.Hour, Options{AllowStale: true}); ok {
+			t.Fatalf("entry %q survived Clear()", k)
+		}
+	}
+}
+
+func TestMeta(t *testing.T) {
+	meta := Meta(Entry{AsOf: time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)}, true)
+	if meta["asOf"] != "2026-03-04T05:06:07Z" || meta["cached"] != true {
+		t.Fatalf("Meta() = %v", meta)
+	}
+}
@@ -3,11 +3,15 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
 )
 
 const healthCheckTimeout = 10 * time.Second
@@ -20,6 +24,9 @@ type ClusterInfo struct {
 	Context string
 	Current bool
 	Status  string
+	// Aliases are the names multicluster.EnvClusterAliases gives the
+	// cluster.
+	Aliases []string
 }
 
 // HealthInfo contains health information about a cluster
@@ -34,6 +41,9 @@ type HealthInfo struct {
 // Discoverer handles cluster discovery from multiple sources
 type Discoverer struct {
 	kubeconfig string
+	// transform, when set, adjusts every REST config before a client is
+	// built from it (e.g. to apply impersonation).
+	transform func(*rest.Config) *rest.Config
 }
 
 // NewDiscoverer creates a new cluster discoverer
@@ -43,6 +53,12 @@ func NewDiscoverer(kubeconfig string) *Discoverer {
 	}
 }
 
+// SetConfigTransform sets a function applied to every REST config the
+// discoverer builds clients from.
+func (d *Discoverer) SetConfigTransform(transform func(*rest.Config) *rest.Config) {
+	d.transform = transform
+}
+
 // DiscoverClusters discovers clusters from the specified source
 func (d *Discoverer) DiscoverClusters(source string) ([]ClusterInfo, error) {
 	var clusters []ClusterInfo
@@ -84,6 +100,12 @@ func (d *Discoverer) discoverFromKubeconfig() ([]ClusterInfo, error) {
 		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
 
+	aliases, err := multicluster.ParseClusterAliases(os.Getenv(multicluster.EnvClusterAliases))
+	if err != nil {
+		return nil, err
+	}
+	resolver := multicluster.NewResolver(*config, aliases)
+
 	var clusters []ClusterInfo
 
 	for contextName, ctx := range config.Contexts {
@@ -99,6 +121,7 @@ func (d *Discoverer) discoverFromKubeconfig() ([]ClusterInfo, error) {
 			Context: contextName,
 			Current: contextName == config.CurrentContext,
 			Status:  "Unknown",
+			Aliases: resolver.Aliases(contextName),
 		})
 	}
 
@@ -180,7 +203,10 @@ func (d *Discoverer) buildClient(contextName string) (*kubernetes.Clientset, err
 	}
 
 	restConfig.Timeout = healthCheckTimeout
-	return kubernetes.NewForConfig(restConfig)
+	if d.transform != nil {
+		restConfig = d.transform(restConfig)
+	}
+	return kubernetes.NewForConfig(multicluster.ProtobufConfig(restConfig))
 }
 
 // CheckHealthByContext checks cluster health by context name
@@ -0,0 +1,341 @@
+// Package dashboard provides the dashboard command, a live terminal view of
+// fleet health, policy violations, drift, and upgrades built from the same
+// tools the MCP server runs.
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+)
+
+// maxConcurrentClusters bounds how many clusters are refreshed at once.
+const maxConcurrentClusters = 8
+
+// toolRunner runs a tool by name.
+type toolRunner interface {
+	CallTool(ctx context.Context, name string, args map[string]interface{}) (server.CallToolResult, error)
+}
+
+var newRunner = func(kubeconfig string, imp server.Impersonation) toolRunner {
+	srv := server.NewServer(kubeconfig)
+	srv.SetImpersonation(imp)
+	return srv
+}
+
+type clusterDiscoverer interface {
+	DiscoverClusters(source string) ([]cluster.ClusterInfo, error)
+}
+
+var newDiscoverer = func(kubeconfig string) clusterDiscoverer {
+	return cluster.NewDiscoverer(kubeconfig)
+}
+
+// runProgram runs the dashboard until the user quits. Tests replace it.
+var runProgram = func(ctx context.Context, m tea.Model, cmd *cobra.Command) error {
+	_, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx),
+		tea.WithInput(cmd.InOrStdin()), tea.WithOutput(cmd.OutOrStdout())).Run()
+	return err
+}
+
+// NewDashboardCommand creates the dashboard command
+func NewDashboardCommand(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var (
+		opts     options
+		interval time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "dashboard",
+		Short: "Live terminal dashboard of fleet health, violations, drift, and upgrades",
+		Long: `Show a live dashboard of every discovered cluster in the terminal.
+
+Each row is a cluster and each column a summary from an ops tool:
+  - Health: get_cluster_health
+  - Violations: list_ownership_violations
+  - Drift: detect_drift, when --repo-url is given
+  - Upgrade: get_upgrade_status
+
+Select a cell with the arrow keys to see the tool's full result. The
+dashboard refreshes every --interval; press r to refresh now and q to quit.
+
+Examples:
+  # Watch the fleet
+  kubestellar-ops dashboard
+
+  # Include drift from Git, refreshing every minute
+  kubestellar-ops dashboard --repo-url https://github.com/org/config --path prod --interval=1m`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			kubeconfig := ""
+			if configFlags.KubeConfig != nil {
+				kubeconfig = *configFlags.KubeConfig
+			}
+			var imp server.Impersonation
+			if configFlags.Impersonate != nil {
+				imp.User = *configFlags.Impersonate
+			}
+			if configFlags.ImpersonateGroup != nil {
+				imp.Groups = *configFlags.ImpersonateGroup
+			}
+			m := newModel(newRunner(kubeconfig, imp), newDiscoverer(kubeconfig), panels(opts), interval)
+			return runProgram(cmd.Context(), m, cmd)
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 30*time.Second, "Refresh interval")
+	cmd.Flags().StringVar(&opts.repoURL, "repo-url", "", "Git repository to check drift against")
+	cmd.Flags().StringVar(&opts.path, "path", "", "Path within the repository containing manifests")
+	cmd.Flags().StringVar(&opts.branch, "branch", "", "Branch to check drift against")
+
+	return cmd
+}
+
+// refreshMsg carries the results of a refresh.
+type refreshMsg struct {
+	clusters []string
+	cells    map[string][]cell
+	at       time.Time
+	err      error
+}
+
+// tickMsg starts a scheduled refresh.
+type tickMsg time.Time
+
+type model struct {
+	runner     toolRunner
+	discoverer clusterDiscoverer
+	panels     []panel
+	interval   time.Duration
+
+	clusters   []string
+	cells      map[string][]cell
+	updated    time.Time
+	err        error
+	refreshing bool
+
+	row, col      int
+	width, height int
+}
+
+func newModel(runner toolRunner, discoverer clusterDiscoverer, panels []panel, interval time.Duration) *model {
+	return &model{
+		runner:     runner,
+		discoverer: discoverer,
+		panels:     panels,
+		interval:   interval,
+		refreshing: true,
+		width:      100,
+		height:     30,
+	}
+}
+
+func (m *model) Init() tea.Cmd {
+	return m.refresh
+}
+
+// refresh runs every panel's tool on every cluster, clusters in parallel.
+func (m *model) refresh() tea.Msg {
+	discovered, err := m.discoverer.DiscoverClusters("all")
+	if err != nil {
+		return refreshMsg{at: time.Now(), err: fmt.Errorf("failed to discover clusters: %w", err)}
+	}
+	clusters := make([]string, 0, len(discovered))
+	for _, c := range discovered {
+		clusters = append(clusters, c.Name)
+	}
+	sort.Strings(clusters)
+
+	ctx := context.Background()
+	cells := make(map[string][]cell, len(clusters))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentClusters)
+	for _, name := range clusters {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			row := make([]cell, len(m.panels))
+			for i, p := range m.panels {
+				args := map[string]interface{}{"cluster": name}
+				for k, v := range p.args {
+					args[k] = v
+				}
+				result, err := m.runner.CallTool(ctx, p.tool, args)
+				if err != nil {
+					row[i] = p.result(err.Error(), true)
+					continue
+				}
+				var text strings.Builder
+				for _, block := range result.Content {
+					text.WriteString(block.Text)
+				}
+				row[i] = p.result(text.String(), result.IsError)
+			}
+			mu.Lock()
+			cells[name] = row
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+	return refreshMsg{clusters: clusters, cells: cells, at: time.Now()}
+}
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case refreshMsg:
+		m.refreshing = false
+		m.updated, m.err = msg.at, msg.err
+		if msg.err == nil {
+			m.clusters, m.cells = msg.clusters, msg.cells
+			m.row = min(m.row, max(len(m.clusters)-1, 0))
+		}
+		return m, tea.Tick(m.interval, func(t time.Time) tea.Msg { return tickMsg(t) })
+	case tickMsg:
+		if !m.refreshing {
+			m.refreshing = true
+			return m, m.refresh
+		}
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		case "r":
+			if !m.refreshing {
+				m.refreshing = true
+				return m, m.refresh
+			}
+		case "up", "k":
+			m.row = max(m.row-1, 0)
+		case "down", "j":
+			m.row = min(m.row+1, max(len(m.clusters)-1, 0))
+		case "left", "h", "shift+tab":
+			m.col = (m.col + len(m.panels) - 1) % len(m.panels)
+		case "right", "l", "tab":
+			m.col = (m.col + 1) % len(m.panels)
+		}
+	}
+	return m, nil
+}
+
+const (
+	colorReset   = "\033[0m"
+	colorBold    = "\033[1m"
+	colorReverse = "\033[7m"
+	colorDim     = "\033[2m"
+)
+
+var levelColors = map[level]string{
+	levelOK:   "\033[32m",
+	levelWarn: "\033[33m",
+	levelFail: "\033[31m",
+}
+
+var levelMarks = map[level]string{
+	levelUnknown: "?",
+	levelOK:      "●",
+	levelWarn:    "▲",
+	levelFail:    "✖",
+}
+
+func (m *model) View() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%sKubeStellar fleet dashboard%s  %d clusters", colorBold, colorReset, len(m.clusters))
+	switch {
+	case m.updated.IsZero():
+		b.WriteString("  loading...")
+	case m.refreshing:
+		fmt.Fprintf(&b, "  updated %s, refreshing...", m.updated.Format("15:04:05"))
+	default:
+		fmt.Fprintf(&b, "  updated %s, every %s", m.updated.Format("15:04:05"), m.interval)
+	}
+	b.WriteString("\n")
+	if m.err != nil {
+		fmt.Fprintf(&b, "%s%v%s\n", levelColors[levelFail], m.err, colorReset)
+	}
+	b.WriteString("\n")
+
+	nameWidth := len("CLUSTER")
+	for _, c := range m.clusters {
+		nameWidth = max(nameWidth, width(c))
+	}
+	cellWidth := max((m.width-nameWidth-2)/max(len(m.panels), 1)-2, 12)
+
+	fmt.Fprintf(&b, "%s%s", colorBold, pad("CLUSTER", nameWidth))
+	for _, p := range m.panels {
+		fmt.Fprintf(&b, "  %s", pad(strings.ToUpper(p.title), cellWidth))
+	}
+	b.WriteString(colorReset + "\n")
+	for r, name := range m.clusters {
+		b.WriteString(pad(name, nameWidth))
+		for c := range m.panels {
+			b.WriteString("  ")
+			cl := cell{summary: "...", level: levelUnknown}
+			if row, ok := m.cells[name]; ok {
+				cl = row[c]
+			}
+			text := pad(levelMarks[cl.level]+" "+cl.summary, cellWidth)
+			if r == m.row && c == m.col {
+				b.WriteString(colorReverse)
+			}
+			fmt.Fprintf(&b, "%s%s%s", levelColors[cl.level], text, colorReset)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(m.clusters) > 0 && len(m.panels) > 0 {
+		name := m.clusters[m.row]
+		fmt.Fprintf(&b, "\n%s%s / %s%s\n", colorBold, name, m.panels[m.col].title, colorReset)
+		if row, ok := m.cells[name]; ok {
+			// Leave room for the header, grid, and key help.
+			lines := strings.Split(strings.TrimRight(row[m.col].text, "\n"), "\n")
+			avail := max(m.height-len(m.clusters)-8, 3)
+			if len(lines) > avail {
+				lines = append(lines[:avail-1], fmt.Sprintf("... %d more lines", len(lines)-avail+1))
+			}
+			for _, line := range lines {
+				b.WriteString(truncate(line, m.width) + "\n")
+			}
+		}
+	}
+	fmt.Fprintf(&b, "\n%s↑/↓ cluster  ←/→ panel  r refresh  q quit%s\n", colorDim, colorReset)
+	return b.String()
+}
+
+func width(s string) int {
+	return len([]rune(s))
+}
+
+// pad truncates or pads s to exactly n columns.
+func pad(s string, n int) string {
+	s = truncate(s, n)
+	return s + strings.Repeat(" ", n-width(s))
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 1 {
+		return string(r[:n])
+	}
+	return string(r[:n-1]) + "…"
+}
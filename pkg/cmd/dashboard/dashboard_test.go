@@ -0,0 +1,180 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+)
+
+type fakeRunner struct {
+	mu      sync.Mutex
+	calls   []string
+	results map[string]server.CallToolResult
+}
+
+func (f *fakeRunner) CallTool(_ context.Context, name string, args map[string]interface{}) (server.CallToolResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := args["cluster"].(string) + "/" + name
+	f.calls = append(f.calls, key)
+	if result, ok := f.results[key]; ok {
+		return result, nil
+	}
+	return server.CallToolResult{}, errors.New("no result for " + key)
+}
+
+type fakeDiscoverer struct {
+	clusters []cluster.ClusterInfo
+	err      error
+}
+
+func (f fakeDiscoverer) DiscoverClusters(string) ([]cluster.ClusterInfo, error) {
+	return f.clusters, f.err
+}
+
+func text(s string, isError bool) server.CallToolResult {
+	return server.CallToolResult{Content: []server.ContentBlock{{Type: "text", Text: s}}, IsError: isError}
+}
+
+func TestPanelsIncludeDriftOnlyWithRepo(t *testing.T) {
+	titles := func(ps []panel) []string {
+		var out []string
+		for _, p := range ps {
+			out = append(out, p.title)
+		}
+		return out
+	}
+	require.Equal(t, []string{"Health", "Violations", "Upgrade"}, titles(panels(options{})))
+
+	ps := panels(options{repoURL: "https://github.com/org/config", path: "prod"})
+	require.Equal(t, []string{"Health", "Violations", "Drift", "Upgrade"}, titles(ps))
+	require.Equal(t, map[string]interface{}{"repo_url": "https://github.com/org/config", "path": "prod"}, ps[2].args)
+}
+
+func TestSummaries(t *testing.T) {
+	tests := []struct {
+		name      string
+		summarize func(string) cell
+		text      string
+		want      cell
+	}{
+		{"healthy", summarizeHealth, "Cluster: a\nStatus: Healthy\nAPI Server: Healthy\nNodes Ready: 3/3\n", cell{summary: "Healthy (3/3 nodes)", level: levelOK}},
+		{"degraded", summarizeHealth, "Cluster: a\nStatus: Degraded\nNodes Ready: 2/3\n", cell{summary: "Degraded (2/3 nodes)", level: levelWarn}},
+		{"no violations", summarizeViolations, "# Ownership Label Violations\n\n**Mode:** dryrun\n\n**No violations found!**", cell{summary: "none", level: levelOK}},
+		{"violations", summarizeViolations, "# Ownership Label Violations\n\n**Mode:** dryrun\n**Total Violations:** 12\n", cell{summary: "12", level: levelWarn}},
+		{"no policy", summarizeViolations, "Ownership policy not installed. Use `install_ownership_policy` to set it up.", cell{summary: "policy not installed"}},
+		{"in sync", summarizeDrift, "# GitOps Drift Detection\n\n✅ **No drift detected** - cluster state matches Git manifests\n", cell{summary: "in sync", level: levelOK}},
+		{"drift", summarizeDrift, "# GitOps Drift Detection\n\n⚠️ **Drift detected**: 4 resource(s) out of sync\n", cell{summary: "4 out of sync", level: levelWarn}},
+		{"upgrading", summarizeUpgrade, "# Upgrade Status\n\n**Cluster Type:** OpenShift\n\n**Target Version:** 4.18.30\n**Status:** Upgrade in progress\n**Progress:** 22% complete\n", cell{summary: "upgrading to 4.18.30: 22% complete", level: levelWarn}},
+		{"idle", summarizeUpgrade, "# Upgrade Status\n\n**Cluster Type:** OpenShift\n\n**Target Version:** 4.18.30\n**Status:** Not currently upgrading\n", cell{summary: "idle at 4.18.30", level: levelOK}},
+		{"kubernetes", summarizeUpgrade, "# Upgrade Status\n\n**Cluster Type:** Kubernetes\n\n## Node Versions\n", cell{summary: "not tracked"}},
+		{"unrecognized", summarizeUpgrade, "# Something Else\n", cell{summary: "Something Else"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.summarize(tt.text))
+		})
+	}
+}
+
+func TestRefreshFillsCells(t *testing.T) {
+	runner := &fakeRunner{results: map[string]server.CallToolResult{
+		"a/get_cluster_health":        text("Status: Healthy\nNodes Ready: 3/3\n", false),
+		"a/list_ownership_violations": text("**Total Violations:** 2\n", false),
+		"a/get_upgrade_status":        text("**Cluster Type:** Kubernetes\n", false),
+		"b/get_cluster_health":        text("Failed to check health: connection refused", true),
+		"b/list_ownership_violations": text("**No violations found!**", false),
+	}}
+	discoverer := fakeDiscoverer{clusters: []cluster.ClusterInfo{{Name: "b"}, {Name: "a"}}}
+	m := newModel(runner, discoverer, panels(options{}), time.Minute)
+
+	msg := m.Init()().(refreshMsg)
+	require.NoError(t, msg.err)
+	require.Equal(t, []string{"a", "b"}, msg.clusters)
+	require.Equal(t, "Healthy (3/3 nodes)", msg.cells["a"][0].summary)
+	require.Equal(t, levelFail, msg.cells["b"][0].level)
+	require.Equal(t, "Failed to check health: connection refused", msg.cells["b"][0].summary)
+	require.Equal(t, levelFail, msg.cells["b"][2].level, "a failed call should show as a failure")
+	require.Len(t, runner.calls, 6)
+
+	_, cmd := m.Update(msg)
+	require.NotNil(t, cmd, "a refresh should schedule the next one")
+	require.False(t, m.refreshing)
+
+	view := m.View()
+	require.Contains(t, view, "2 clusters")
+	require.Contains(t, view, "Healthy (3/3 nodes)")
+	require.Contains(t, view, "a / Health")
+}
+
+func TestRefreshReportsDiscoveryFailure(t *testing.T) {
+	m := newModel(&fakeRunner{}, fakeDiscoverer{err: errors.New("no kubeconfig")}, panels(options{}), time.Minute)
+	m.Update(m.refresh())
+	require.Contains(t, m.View(), "failed to discover clusters: no kubeconfig")
+}
+
+func TestKeysMoveSelection(t *testing.T) {
+	m := newModel(&fakeRunner{}, fakeDiscoverer{}, panels(options{}), time.Minute)
+	m.Update(refreshMsg{clusters: []string{"a", "b"}, cells: map[string][]cell{}, at: time.Now()})
+
+	press := func(key tea.KeyType) tea.Cmd {
+		_, cmd := m.Update(tea.KeyMsg{Type: key})
+		return cmd
+	}
+	press(tea.KeyDown)
+	press(tea.KeyDown)
+	require.Equal(t, 1, m.row, "selection should stop at the last cluster")
+	press(tea.KeyLeft)
+	require.Equal(t, 2, m.col, "panel selection should wrap")
+	press(tea.KeyTab)
+	require.Equal(t, 0, m.col)
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	require.NotNil(t, cmd)
+	require.True(t, m.refreshing)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	require.Nil(t, cmd, "r should not start a second refresh")
+
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	require.IsType(t, tea.QuitMsg{}, cmd())
+}
+
+func TestDashboardCommand(t *testing.T) {
+	oldRun, oldRunner, oldDiscoverer := runProgram, newRunner, newDiscoverer
+	t.Cleanup(func() { runProgram, newRunner, newDiscoverer = oldRun, oldRunner, oldDiscoverer })
+	newRunner = func(string, server.Impersonation) toolRunner { return &fakeRunner{} }
+	newDiscoverer = func(string) clusterDiscoverer { return fakeDiscoverer{} }
+	var got *model
+	runProgram = func(_ context.Context, m tea.Model, _ *cobra.Command) error {
+		got = m.(*model)
+		return nil
+	}
+
+	cmd := NewDashboardCommand(genericclioptions.NewConfigFlags(true))
+	cmd.SetArgs([]string{"--repo-url", "https://github.com/org/config", "--interval", "1m"})
+	require.NoError(t, cmd.Execute())
+	require.Equal(t, time.Minute, got.interval)
+	require.Len(t, got.panels, 4)
+
+	cmd = NewDashboardCommand(genericclioptions.NewConfigFlags(true))
+	cmd.SetArgs([]string{"--interval", "0s"})
+	cmd.SilenceUsage, cmd.SilenceErrors = true, true
+	require.EqualError(t, cmd.Execute(), "--interval must be positive")
+}
+
+func TestPadAndTruncate(t *testing.T) {
+	require.Equal(t, "ab  ", pad("ab", 4))
+	require.Equal(t, "abc…", pad("abcdef", 4))
+	require.True(t, strings.HasSuffix(truncate("● healthy cluster", 5), "…"))
+}
@@ -0,0 +1,160 @@
+package dashboard
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+)
+
+// level is how a cell is colored.
+type level int
+
+const (
+	levelUnknown level = iota
+	levelOK
+	levelWarn
+	levelFail
+)
+
+// cell is one panel's state in one cluster: a one-line summary for the
+// grid and the tool's full result for the detail pane.
+type cell struct {
+	summary string
+	level   level
+	text    string
+}
+
+// panel is a dashboard column, filled in per cluster by an ops tool.
+type panel struct {
+	title     string
+	tool      string
+	args      map[string]interface{}
+	summarize func(text string) cell
+}
+
+// options are the dashboard settings that choose its panels.
+type options struct {
+	repoURL string
+	path    string
+	branch  string
+}
+
+// panels returns the dashboard's columns. Drift is only shown when a
+// repository to compare with is given.
+func panels(opts options) []panel {
+	ps := []panel{
+		{title: "Health", tool: "get_cluster_health", summarize: summarizeHealth},
+		{title: "Violations", tool: "list_ownership_violations", summarize: summarizeViolations},
+	}
+	if opts.repoURL != "" {
+		args := map[string]interface{}{"repo_url": opts.repoURL}
+		if opts.path != "" {
+			args["path"] = opts.path
+		}
+		if opts.branch != "" {
+			args["branch"] = opts.branch
+		}
+		ps = append(ps, panel{title: "Drift", tool: "detect_drift", args: args, summarize: summarizeDrift})
+	}
+	return append(ps, panel{title: "Upgrade", tool: "get_upgrade_status", summarize: summarizeUpgrade})
+}
+
+// result summarizes a tool result, which failed if isError is set.
+func (p panel) result(text string, isError bool) cell {
+	if isError {
+		return cell{summary: firstLine(text), level: levelFail, text: text}
+	}
+	c := p.summarize(text)
+	c.text = text
+	return c
+}
+
+var (
+	healthStatusPattern = regexp.MustCompile(`(?m)^Status: (\S+)`)
+	healthNodesPattern  = regexp.MustCompile(`(?m)^Nodes Ready: (\S+)`)
+	violationsPattern   = regexp.MustCompile(`\*\*Total Violations:\*\* (\d+)`)
+	driftPattern        = regexp.MustCompile(`Drift detected\*\*: (\d+)`)
+)
+
+func summarizeHealth(text string) cell {
+	m := healthStatusPattern.FindStringSubmatch(text)
+	if m == nil {
+		return cell{summary: firstLine(text)}
+	}
+	c := cell{summary: m[1]}
+	if n := healthNodesPattern.FindStringSubmatch(text); n != nil {
+		c.summary += fmt.Sprintf(" (%s nodes)", n[1])
+	}
+	switch m[1] {
+	case "Healthy":
+		c.level = levelOK
+	case "Degraded":
+		c.level = levelWarn
+	case "Unhealthy":
+		c.level = levelFail
+	}
+	return c
+}
+
+func summarizeViolations(text string) cell {
+	switch {
+	case strings.Contains(text, "not installed"):
+		return cell{summary: "policy not installed"}
+	case strings.Contains(text, "No violations"):
+		return cell{summary: "none", level: levelOK}
+	}
+	if m := violationsPattern.FindStringSubmatch(text); m != nil {
+		return cell{summary: m[1], level: levelWarn}
+	}
+	return cell{summary: firstLine(text)}
+}
+
+func summarizeDrift(text string) cell {
+	switch {
+	case strings.Contains(text, "No drift detected"):
+		return cell{summary: "in sync", level: levelOK}
+	case strings.HasPrefix(text, "No manifests found"):
+		return cell{summary: "no manifests"}
+	}
+	if m := driftPattern.FindStringSubmatch(text); m != nil {
+		return cell{summary: m[1] + " out of sync", level: levelWarn}
+	}
+	return cell{summary: firstLine(text)}
+}
+
+// summarizeUpgrade reads get_upgrade_status through the message catalog,
+// so it works with translated and ASCII reports.
+func summarizeUpgrade(text string) cell {
+	target := ""
+	if m := messages.Pattern(messages.TargetVersion).FindStringSubmatch(text); m != nil {
+		target = " to " + strings.TrimSpace(m[1])
+	}
+	switch {
+	case messages.Pattern(messages.StatusUpgrading).MatchString(text):
+		c := cell{summary: "upgrading" + target, level: levelWarn}
+		if m := messages.Pattern(messages.Progress).FindStringSubmatch(text); m != nil {
+			c.summary += ": " + strings.TrimSpace(m[1])
+		}
+		return c
+	case messages.Pattern(messages.StatusIdle).MatchString(text):
+		return cell{summary: "idle" + strings.Replace(target, " to ", " at ", 1), level: levelOK}
+	}
+	if m := messages.Pattern(messages.ClusterType).FindStringSubmatch(text); m != nil && strings.TrimSpace(m[1]) == "Kubernetes" {
+		return cell{summary: "not tracked"}
+	}
+	return cell{summary: firstLine(text)}
+}
+
+// firstLine returns the first non-empty line of text without Markdown
+// heading or emphasis markers.
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.TrimLeft(line, "# "))
+		if line != "" {
+			return strings.ReplaceAll(line, "**", "")
+		}
+	}
+	return ""
+}
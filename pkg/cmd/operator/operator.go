@@ -0,0 +1,78 @@
+// Package operator provides the CLI command that runs kubestellar-ops as a
+// Kubernetes operator.
+package operator
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/operator"
+)
+
+var runOperator = func(ctx context.Context, config *rest.Config, opts operator.Options) error {
+	return operator.Run(ctx, config, opts)
+}
+
+// NewOperatorCommand creates the operator command
+func NewOperatorCommand(configFlags *genericclioptions.ConfigFlags) *cobra.Command {
+	var opts operator.Options
+
+	cmd := &cobra.Command{
+		Use:   "operator",
+		Short: "Run as an operator that reconciles DriftCheck, UpgradeCampaign, and FleetReport resources",
+		Long: `Run kubestellar-ops as a Kubernetes operator.
+
+The operator watches the hub cluster of the current kubeconfig context (or the
+in-cluster config) for resources in the ops.kubestellar.io group and runs the
+operations they declare against the clusters of the kubeconfig:
+  - DriftCheck: detect_drift on a cron schedule, with results in its status
+  - FleetReport: generate_report on a cron schedule, written to a ConfigMap
+  - UpgradeCampaign: OpenShift upgrades of a list of clusters, one at a time
+
+Install the CustomResourceDefinitions in config/crd first.
+
+Examples:
+  # Reconcile resources in every namespace of the hub
+  kubestellar-ops operator
+
+  # Reconcile resources in one namespace, with leader election
+  kubestellar-ops operator -n kubestellar-ops --leader-elect`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := configFlags.ToRESTConfig()
+			if err != nil {
+				return err
+			}
+			if configFlags.KubeConfig != nil {
+				opts.Kubeconfig = *configFlags.KubeConfig
+			}
+			if configFlags.Namespace != nil {
+				opts.Namespace = *configFlags.Namespace
+			}
+			if configFlags.Impersonate != nil {
+				opts.Impersonation.User = *configFlags.Impersonate
+			}
+			if configFlags.ImpersonateGroup != nil {
+				opts.Impersonation.Groups = *configFlags.ImpersonateGroup
+			}
+			ctx := cmd.Context()
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runOperator(ctx, config, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.LeaderElection, "leader-elect", false, "Use leader election so only one of several replicas reconciles")
+	cmd.Flags().StringVar(&opts.HealthProbeAddr, "health-probe-addr", ":8081", "Serve /healthz and /readyz on this address; empty disables them")
+
+	return cmd
+}
@@ -0,0 +1,59 @@
+package operator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/operator"
+)
+
+func TestOperatorCommandPassesFlags(t *testing.T) {
+	oldRunOperator := runOperator
+	t.Cleanup(func() { runOperator = oldRunOperator })
+
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: hub
+  cluster: {server: https://hub.example.com}
+contexts:
+- name: hub
+  context: {cluster: hub, user: hub}
+current-context: hub
+users:
+- name: hub
+  user: {}
+`), 0o600))
+	configFlags := genericclioptions.NewConfigFlags(true)
+	configFlags.KubeConfig = &kubeconfig
+	namespace, user := "ops", "alice"
+	configFlags.Namespace = &namespace
+	configFlags.Impersonate = &user
+
+	var got operator.Options
+	var host string
+	runOperator = func(ctx context.Context, config *rest.Config, opts operator.Options) error {
+		require.NotNil(t, ctx)
+		host, got = config.Host, opts
+		return nil
+	}
+
+	cmd := NewOperatorCommand(configFlags)
+	cmd.SetArgs([]string{"--leader-elect", "--health-probe-addr="})
+	require.NoError(t, cmd.Execute())
+	require.Equal(t, "https://hub.example.com", host)
+	require.Equal(t, operator.Options{
+		Kubeconfig:     kubeconfig,
+		Namespace:      "ops",
+		LeaderElection: true,
+		Impersonation:  got.Impersonation,
+	}, got)
+	require.Equal(t, "alice", got.Impersonation.User)
+}
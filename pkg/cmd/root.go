@@ -13,9 +13,16 @@ import (
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 
 	"github.com/kubestellar/kubestellar-mcp/internal/version"
+	"github.com/kubestellar/kubestellar-mcp/pkg/auditlog"
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
 	"github.com/kubestellar/kubestellar-mcp/pkg/cmd/ai"
 	"github.com/kubestellar/kubestellar-mcp/pkg/cmd/clusters"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cmd/dashboard"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cmd/operator"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cmd/tools"
 	"github.com/kubestellar/kubestellar-mcp/pkg/cmd/upgrade"
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+	"github.com/kubestellar/kubestellar-mcp/pkg/grpcapi"
 	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
 )
 
@@ -30,14 +37,43 @@ var (
 	targetCluster string
 	mcpServer     bool
 
+	// Configuration file and the profile in it to use
+	configPath string
+	profile    string
+
+	// Identities MCP clients may impersonate for their session
+	allowedImpersonationUsers  []string
+	allowedImpersonationGroups []string
+
+	// HTTP transport and the OIDC settings that authenticate its callers
+	mcpHTTPAddr string
+	oidcConfig  auth.OIDCConfig
+
+	// Listener for API server audit webhook events, read by query_audit_log
+	auditWebhookAddr string
+
+	// Listener for the gRPC API, served alongside MCP
+	grpcListenAddr string
+
 	// Kubernetes config flags
 	configFlags *genericclioptions.ConfigFlags
 
-	newQueryCommand           = ai.NewQueryCommand
-	newMCPServer              = func(kubeconfig string) mcpServerRunner { return server.NewServer(kubeconfig) }
-	signalNotify              = signal.Notify
-	stderr          io.Writer = os.Stderr
-	exitFunc                  = os.Exit
+	newQueryCommand = ai.NewQueryCommand
+	newMCPServer    = func(kubeconfig string, imp server.Impersonation) mcpServerRunner {
+		srv := server.NewServer(kubeconfig)
+		srv.SetImpersonation(imp)
+		var runner mcpServerRunner = srv
+		if mcpHTTPAddr != "" {
+			runner = &httpServerRunner{srv: srv, addr: mcpHTTPAddr, oidc: oidcConfig}
+		}
+		if grpcListenAddr != "" {
+			runner = &grpcServerRunner{mcp: runner, srv: srv, addr: grpcListenAddr, oidc: oidcConfig}
+		}
+		return runner
+	}
+	signalNotify           = signal.Notify
+	stderr       io.Writer = os.Stderr
+	exitFunc               = os.Exit
 )
 
 // rootCmd represents the base command
@@ -62,8 +98,14 @@ Examples:
   kubestellar-ops clusters list
 
   # Check cluster health
-  kubestellar-ops clusters health --all-clusters`,
+  kubestellar-ops clusters health --all-clusters
+
+  # Find pod issues in every cluster, as JSON
+  kubestellar-ops diagnose pods --all-clusters -o json`,
 	Version: version.Version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyConfig(cmd)
+	},
 	// Handle natural language queries directly
 	Args: func(cmd *cobra.Command, args []string) error {
 		// Allow any args - we'll handle natural language queries in Run
@@ -77,7 +119,7 @@ Examples:
 				kubeconfig = *configFlags.KubeConfig
 			}
 
-			srv := newMCPServer(kubeconfig)
+			srv := newMCPServer(kubeconfig, impersonationFromFlags())
 
 			// Handle shutdown gracefully
 			ctx, cancel := context.WithCancel(context.Background())
@@ -91,6 +133,14 @@ Examples:
 				cancel()
 			}()
 
+			if auditWebhookAddr != "" {
+				go func() {
+					if err := auditlog.Serve(ctx, auditWebhookAddr); err != nil {
+						_, _ = fmt.Fprintf(stderr, "Audit webhook error: %v\n", err)
+					}
+				}()
+			}
+
 			if err := srv.Run(ctx); err != nil {
 				_, _ = fmt.Fprintf(stderr, "MCP server error: %v\n", err)
 				exitFunc(1)
@@ -121,6 +171,12 @@ func Execute() error {
 	return rootCmd.Execute()
 }
 
+// ExitCode returns the exit code for an error returned by Execute: 1, or
+// the code of a tool command's --fail-on threshold.
+func ExitCode(err error) int {
+	return tools.ExitCode(err)
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
@@ -132,11 +188,27 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&allClusters, "all-clusters", false, "Operate on all discovered clusters")
 	rootCmd.PersistentFlags().StringVar(&targetCluster, "target-cluster", "", "Target specific cluster by name")
 	rootCmd.PersistentFlags().BoolVar(&mcpServer, "mcp-server", false, "Run as MCP server (for Claude Code integration)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Configuration file (default $KUBESTELLAR_CONFIG or ~/.config/kubestellar-mcp/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Configuration profile to use (default $KUBESTELLAR_PROFILE or the file's defaultProfile)")
+	rootCmd.PersistentFlags().StringSliceVar(&allowedImpersonationUsers, "impersonation-allowed-users", nil, "Users an MCP client may impersonate for its session (wildcards allowed, e.g. system:serviceaccount:team-a:*)")
+	rootCmd.PersistentFlags().StringSliceVar(&allowedImpersonationGroups, "impersonation-allowed-groups", nil, "Groups an MCP client may impersonate for its session (wildcards allowed)")
+	rootCmd.PersistentFlags().StringVar(&mcpHTTPAddr, "mcp-http-addr", "", "Serve MCP over HTTP on this address (e.g. :8080) instead of stdio; requires --oidc-issuer-url unless the address is loopback")
+	rootCmd.PersistentFlags().StringVar(&oidcConfig.IssuerURL, "oidc-issuer-url", "", "OIDC issuer whose ID tokens authenticate HTTP callers")
+	rootCmd.PersistentFlags().StringVar(&oidcConfig.ClientID, "oidc-client-id", "", "Audience HTTP callers' ID tokens must be issued for")
+	rootCmd.PersistentFlags().StringVar(&oidcConfig.UsernameClaim, "oidc-username-claim", "sub", "ID token claim mapped to the Kubernetes user")
+	rootCmd.PersistentFlags().StringVar(&oidcConfig.GroupsClaim, "oidc-groups-claim", "groups", "ID token claim mapped to the Kubernetes groups")
+	rootCmd.PersistentFlags().StringVar(&oidcConfig.UsernamePrefix, "oidc-username-prefix", "", "Prefix added to OIDC usernames (e.g. oidc:)")
+	rootCmd.PersistentFlags().StringVar(&oidcConfig.GroupsPrefix, "oidc-groups-prefix", "", "Prefix added to OIDC groups (e.g. oidc:)")
+	rootCmd.PersistentFlags().StringVar(&grpcListenAddr, "grpc-listen", "", "With --mcp-server, also serve the gRPC API on this address (e.g. :9090); requires --oidc-issuer-url unless the address is loopback")
+	rootCmd.PersistentFlags().StringVar(&auditWebhookAddr, "audit-webhook-addr", "", "With --mcp-server, accept API server audit webhook events on this address (e.g. :8443) for query_audit_log sources set to webhook")
 
 	// Add subcommands
 	rootCmd.AddCommand(clusters.NewClustersCommand(configFlags))
 	rootCmd.AddCommand(ai.NewQueryCommand(configFlags))
 	rootCmd.AddCommand(upgrade.NewWatchCommand(configFlags))
+	rootCmd.AddCommand(tools.NewCommands(configFlags)...)
+	rootCmd.AddCommand(dashboard.NewDashboardCommand(configFlags))
+	rootCmd.AddCommand(operator.NewOperatorCommand(configFlags))
 	rootCmd.AddCommand(newVersionCommand())
 }
 
@@ -152,10 +224,14 @@ func isNaturalLanguageQuery(args []string) bool {
 		"clusters":      true,
 		"query":         true,
 		"watch-upgrade": true,
+		"dashboard":     true,
 		"version":       true,
 		"help":          true,
 		"completion":    true,
 	}
+	for _, name := range tools.Names() {
+		subcommands[name] = true
+	}
 
 	first := strings.ToLower(args[0])
 
@@ -173,6 +249,108 @@ func isNaturalLanguageQuery(args []string) bool {
 	return true
 }
 
+// impersonationFromFlags builds the MCP server impersonation settings from
+// --as/--as-group and the session allow lists.
+// httpServerRunner serves an MCP server over HTTP, authenticating callers
+// with OIDC when an issuer is configured.
+type httpServerRunner struct {
+	srv  *server.Server
+	addr string
+	oidc auth.OIDCConfig
+}
+
+func (r *httpServerRunner) Run(ctx context.Context) error {
+	if err := setOIDCVerifier(r.srv, r.oidc); err != nil {
+		return err
+	}
+	return r.srv.RunHTTP(ctx, r.addr)
+}
+
+// grpcServerRunner serves the gRPC API alongside an MCP transport, until
+// either stops.
+type grpcServerRunner struct {
+	mcp  mcpServerRunner
+	srv  *server.Server
+	addr string
+	oidc auth.OIDCConfig
+}
+
+func (r *grpcServerRunner) Run(ctx context.Context) error {
+	// Both transports share the verifier, so set it before either starts.
+	if err := setOIDCVerifier(r.srv, r.oidc); err != nil {
+		return err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	grpcErr := make(chan error, 1)
+	go func() { grpcErr <- grpcapi.Serve(ctx, r.addr, r.srv) }()
+	mcpErr := make(chan error, 1)
+	go func() { mcpErr <- r.mcp.Run(ctx) }()
+	select {
+	case err := <-grpcErr:
+		// The stdio transport may be blocked reading; do not wait for it.
+		if err != nil {
+			return fmt.Errorf("gRPC: %w", err)
+		}
+		return nil
+	case err := <-mcpErr:
+		cancel()
+		if gerr := <-grpcErr; err == nil && gerr != nil {
+			err = fmt.Errorf("gRPC: %w", gerr)
+		}
+		return err
+	}
+}
+
+// setOIDCVerifier authenticates the server's HTTP and gRPC callers with
+// OIDC when an issuer is configured.
+func setOIDCVerifier(srv *server.Server, oidc auth.OIDCConfig) error {
+	if oidc.IssuerURL == "" || srv.TokenVerifier() != nil {
+		return nil
+	}
+	verifier, err := auth.NewOIDCVerifier(oidc)
+	if err != nil {
+		return err
+	}
+	srv.SetTokenVerifier(verifier)
+	return nil
+}
+
+func impersonationFromFlags() server.Impersonation {
+	imp := server.Impersonation{
+		AllowedUsers:  allowedImpersonationUsers,
+		AllowedGroups: allowedImpersonationGroups,
+	}
+	if configFlags.Impersonate != nil {
+		imp.User = *configFlags.Impersonate
+	}
+	if configFlags.ImpersonateGroup != nil {
+		imp.Groups = *configFlags.ImpersonateGroup
+	}
+	return imp
+}
+
+// applyConfig loads the configuration file and profile. Its context and
+// namespace become the defaults of --context and --namespace; the rest,
+// including the kubeconfig, reaches the server through the environment.
+func applyConfig(cmd *cobra.Command) error {
+	settings, err := config.Load(configPath, profile)
+	if err != nil {
+		return err
+	}
+	if err := settings.Apply(); err != nil {
+		return err
+	}
+	flags := cmd.Flags()
+	if settings.Context != "" && configFlags.Context != nil && !flags.Changed("context") {
+		*configFlags.Context = settings.Context
+	}
+	if settings.Namespace != "" && configFlags.Namespace != nil && !flags.Changed("namespace") {
+		*configFlags.Namespace = settings.Namespace
+	}
+	return nil
+}
+
 func initConfig() {
 	// Set kubeconfig from flag or environment
 	if kubeconfig == "" {
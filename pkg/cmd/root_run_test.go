@@ -11,6 +11,8 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
 )
 
 type fakeMCPRunner struct {
@@ -117,8 +119,11 @@ func TestRootRunStartsMCPServer(t *testing.T) {
 	signalNotify = func(c chan<- os.Signal, sig ...os.Signal) {}
 
 	called := false
-	newMCPServer = func(kubeconfig string) mcpServerRunner {
+	impersonateUser := "alice"
+	configFlags.Impersonate = &impersonateUser
+	newMCPServer = func(kubeconfig string, imp server.Impersonation) mcpServerRunner {
 		require.Equal(t, kubeconfigPath, kubeconfig)
+		require.Equal(t, "alice", imp.User)
 		return fakeMCPRunner{runFn: func(ctx context.Context) error {
 			called = true
 			require.NotNil(t, ctx)
@@ -149,7 +154,7 @@ func TestRootRunExitsWhenMCPServerFails(t *testing.T) {
 	exitFunc = func(code int) { panic(exitCode(code)) }
 	var errBuf bytes.Buffer
 	stderr = &errBuf
-	newMCPServer = func(string) mcpServerRunner {
+	newMCPServer = func(string, server.Impersonation) mcpServerRunner {
 		return fakeMCPRunner{runFn: func(ctx context.Context) error {
 			return errors.New("server boom")
 		}}
@@ -178,3 +183,29 @@ func TestExecuteRunsVersionCommand(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, output, "kubestellar-ops version")
 }
+
+func TestGRPCServerRunnerStopsWhenGRPCFails(t *testing.T) {
+	runner := &grpcServerRunner{
+		mcp: fakeMCPRunner{runFn: func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}},
+		srv:  server.NewServer(""),
+		addr: "0.0.0.0:0",
+	}
+
+	err := runner.Run(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unauthenticated gRPC")
+}
+
+func TestGRPCServerRunnerStopsGRPCWithMCP(t *testing.T) {
+	runner := &grpcServerRunner{
+		mcp:  fakeMCPRunner{runFn: func(context.Context) error { return errors.New("stdin closed") }},
+		srv:  server.NewServer(""),
+		addr: "127.0.0.1:0",
+	}
+
+	err := runner.Run(context.Background())
+	require.EqualError(t, err, "stdin closed")
+}
@@ -1,9 +1,12 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/require"
 )
 
@@ -16,6 +19,8 @@ func TestRootCommand_HasExpectedFlags(t *testing.T) {
 		{name: "all-clusters flag", flagName: "all-clusters"},
 		{name: "target-cluster flag", flagName: "target-cluster"},
 		{name: "context flag", flagName: "context"},
+		{name: "config flag", flagName: "config"},
+		{name: "profile flag", flagName: "profile"},
 	}
 
 	for _, tt := range tests {
@@ -65,6 +70,8 @@ func TestIsNaturalLanguageQuery_SubcommandsFalse(t *testing.T) {
 		{name: "watch-upgrade command", args: []string{"watch-upgrade"}, want: false},
 		{name: "version command", args: []string{"version"}, want: false},
 		{name: "help command", args: []string{"help"}, want: false},
+		{name: "diagnose command", args: []string{"diagnose", "pods"}, want: false},
+		{name: "dashboard command", args: []string{"dashboard"}, want: false},
 		{name: "flag arg", args: []string{"--all-clusters"}, want: false},
 		{name: "short flag arg", args: []string{"-h"}, want: false},
 	}
@@ -150,3 +157,37 @@ func TestRootCommand_FlagsParsedCorrectly(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyConfigSetsProfileDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+profiles:
+  prod:
+    context: prod-east
+    namespace: shop
+    policy:
+      approvalMode: plan
+`), 0o600))
+	t.Setenv("KUBESTELLAR_APPROVAL_MODE", "")
+	require.NoError(t, os.Unsetenv("KUBESTELLAR_APPROVAL_MODE"))
+
+	oldPath, oldProfile := configPath, profile
+	oldContext, oldNamespace := *configFlags.Context, *configFlags.Namespace
+	t.Cleanup(func() {
+		configPath, profile = oldPath, oldProfile
+		*configFlags.Context, *configFlags.Namespace = oldContext, oldNamespace
+	})
+	configPath, profile = path, "prod"
+	cmd := &cobra.Command{Use: "test"}
+	configFlags.AddFlags(cmd.Flags())
+	require.NoError(t, cmd.Flags().Set("namespace", "team-a"))
+	*configFlags.Context = ""
+
+	require.NoError(t, applyConfig(cmd))
+	require.Equal(t, "prod-east", *configFlags.Context)
+	require.Equal(t, "team-a", *configFlags.Namespace, "flags given on the command line should win")
+	require.Equal(t, "plan", os.Getenv("KUBESTELLAR_APPROVAL_MODE"))
+
+	profile = "qa"
+	require.ErrorContains(t, applyConfig(cmd), `unknown profile "qa"`)
+}
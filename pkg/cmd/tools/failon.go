@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+)
+
+// Exit codes of the tool commands, so CI can tell a failed run from one
+// that reached its --fail-on threshold.
+const (
+	// ExitFailed is the exit code of commands that failed to run.
+	ExitFailed = 1
+	// ExitFindings is the exit code of commands whose findings reached
+	// the --fail-on severity.
+	ExitFindings = 2
+	// ExitDrift is the exit code of commands that found drift with
+	// --fail-on drift.
+	ExitDrift = 3
+)
+
+// ExitError is an error that exits with Code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// ExitCode returns the exit code for err: the code of an ExitError, or
+// ExitFailed.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailed
+}
+
+// gate reads the findings of a tool's result for --fail-on.
+type gate struct {
+	// levels are the --fail-on values, most severe first. --fail-on
+	// fails on findings at the level given or any more severe one.
+	levels []string
+	// code is the exit code when the threshold is reached.
+	code int
+	// count returns the number of findings at each level in a result.
+	count func(text string) map[string]int
+}
+
+// securityFindings are the messages of check_security_issues findings, by
+// --fail-on level. They are matched in the current catalog, so findings
+// are counted in any language and with ASCII output.
+var securityFindings = map[string][]messages.Key{
+	"critical": {messages.SecurityHostNetwork, messages.SecurityHostPID, messages.SecurityHostIPC,
+		messages.SecurityPrivileged, messages.SecurityDockerSocket},
+	"high": {messages.SecurityRunsAsRoot},
+	"medium": {messages.SecurityPrivilegeEscalation, messages.SecurityWritableRootFS,
+		messages.SecurityNoSecurityContext},
+}
+
+// jsonBlockPattern matches the fenced JSON that the drift tools append to
+// their reports for programs to read.
+var jsonBlockPattern = regexp.MustCompile("(?s)```json\n(.*?)\n```")
+
+// gates are the tools whose commands take --fail-on.
+var gates = map[string]gate{
+	"check_security_issues": {
+		levels: []string{"critical", "high", "medium"},
+		code:   ExitFindings,
+		count: func(text string) map[string]int {
+			counts := make(map[string]int)
+			for level, keys := range securityFindings {
+				for _, key := range keys {
+					// Each finding is a list item of its own.
+					item := regexp.MustCompile(`(?m)^\s*-\s+` + messages.Pattern(key).String())
+					counts[level] += len(item.FindAllStringIndex(text, -1))
+				}
+			}
+			return counts
+		},
+	},
+	"get_upgrade_prerequisites": {
+		levels: []string{"failed", "warning"},
+		code:   ExitFindings,
+		count: func(text string) map[string]int {
+			return map[string]int{
+				"failed":  matchedTotal(messages.Pattern(messages.PrereqFailed), text),
+				"warning": matchedTotal(messages.Pattern(messages.PrereqWarnings), text),
+			}
+		},
+	},
+	"detect_drift": {
+		levels: []string{"drift"},
+		code:   ExitDrift,
+		count: func(text string) map[string]int {
+			drift := 0
+			for _, block := range jsonBlocks(text) {
+				var result struct {
+					Summary struct {
+						Drifted int `json:"drifted"`
+					} `json:"summary"`
+				}
+				if json.Unmarshal(block, &result) == nil {
+					drift += result.Summary.Drifted
+				}
+			}
+			return map[string]int{"drift": drift}
+		},
+	},
+	"detect_helm_values_drift": {
+		levels: []string{"drift"},
+		code:   ExitDrift,
+		count: func(text string) map[string]int {
+			drift := 0
+			for _, block := range jsonBlocks(text) {
+				var result struct {
+					Clusters []struct {
+						Drifted bool `json:"drifted"`
+					} `json:"clusters"`
+				}
+				if json.Unmarshal(block, &result) != nil {
+					continue
+				}
+				for _, c := range result.Clusters {
+					if c.Drifted {
+						drift++
+					}
+				}
+			}
+			return map[string]int{"drift": drift}
+		},
+	},
+}
+
+// matchedTotal returns the sum of the numbers captured by every match of
+// pattern in text, such as the per-cluster counts of a fleet-wide report.
+func matchedTotal(pattern *regexp.Regexp, text string) int {
+	total := 0
+	for _, m := range pattern.FindAllStringSubmatch(text, -1) {
+		n, _ := strconv.Atoi(strings.TrimSpace(m[1]))
+		total += n
+	}
+	return total
+}
+
+// jsonBlocks returns the fenced JSON blocks of a report.
+func jsonBlocks(text string) [][]byte {
+	var blocks [][]byte
+	for _, m := range jsonBlockPattern.FindAllStringSubmatch(text, -1) {
+		blocks = append(blocks, []byte(m[1]))
+	}
+	return blocks
+}
+
+// threshold returns the levels --fail-on level fails on.
+func (g gate) threshold(level string) ([]string, error) {
+	for i, l := range g.levels {
+		if l == level {
+			return g.levels[:i+1], nil
+		}
+	}
+	return nil, fmt.Errorf("invalid --fail-on %q: must be one of %s", level, strings.Join(g.levels, ", "))
+}
+
+// check returns an ExitError if the findings in totals reach levels.
+func (g gate) check(tool string, levels []string, totals map[string]int) error {
+	var found []string
+	for _, l := range levels {
+		if totals[l] > 0 {
+			found = append(found, fmt.Sprintf("%d %s", totals[l], l))
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	return &ExitError{Code: g.code, Err: fmt.Errorf("%s found %s (--fail-on %s)", tool, strings.Join(found, ", "), levels[len(levels)-1])}
+}
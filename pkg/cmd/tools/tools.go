@@ -0,0 +1,364 @@
+// Package tools provides CLI commands that run kubestellar-ops MCP tools
+// directly, so the same diagnostics are usable in CI and by people without
+// an MCP client.
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/output"
+)
+
+// toolCommand is a subcommand that runs one tool.
+type toolCommand struct {
+	use  string
+	tool string
+}
+
+// commandGroup is a top-level command whose subcommands run related tools.
+type commandGroup struct {
+	use      string
+	short    string
+	commands []toolCommand
+}
+
+var groups = []commandGroup{
+	{use: "diagnose", short: "Find problems in workloads", commands: []toolCommand{
+		{use: "pods", tool: "find_pod_issues"},
+		{use: "deployments", tool: "find_deployment_issues"},
+		{use: "security", tool: "check_security_issues"},
+		{use: "limits", tool: "check_resource_limits"},
+		{use: "namespace", tool: "analyze_namespace"},
+		{use: "events", tool: "get_warning_events"},
+		{use: "certificates", tool: "diagnose_certificates"},
+		{use: "external-secrets", tool: "diagnose_external_secrets"},
+		{use: "mesh", tool: "diagnose_service_mesh"},
+		{use: "anomalies", tool: "find_resource_anomalies"},
+		{use: "network", tool: "get_multicluster_network_status"},
+		{use: "scc", tool: "check_workload_scc"},
+		{use: "priority", tool: "check_pod_priority"},
+		{use: "webhooks", tool: "check_admission_webhooks"},
+		{use: "vulnerabilities", tool: "get_image_vulnerabilities"},
+	}},
+	{use: "upgrade", short: "Check cluster upgrade readiness", commands: []toolCommand{
+		{use: "preflight", tool: "get_upgrade_prerequisites"},
+		{use: "impact", tool: "simulate_upgrade_impact"},
+		{use: "status", tool: "get_upgrade_status"},
+		{use: "version", tool: "get_cluster_version_info"},
+		{use: "detect-type", tool: "detect_cluster_type"},
+		{use: "helm", tool: "check_helm_release_upgrades"},
+		{use: "operators", tool: "check_olm_operator_upgrades"},
+		{use: "addons", tool: "list_addons"},
+	}},
+	{use: "drift", short: "Compare clusters with Git", commands: []toolCommand{
+		{use: "detect", tool: "detect_drift"},
+		{use: "helm-values", tool: "detect_helm_values_drift"},
+	}},
+	{use: "rbac", short: "Analyze RBAC permissions", commands: []toolCommand{
+		{use: "can-i", tool: "can_i"},
+		{use: "subject", tool: "analyze_subject_permissions"},
+		{use: "role", tool: "describe_role"},
+		{use: "owners", tool: "find_resource_owners"},
+	}},
+	{use: "report", short: "Generate shareable fleet reports", commands: []toolCommand{
+		{use: "generate", tool: "generate_report"},
+	}},
+}
+
+// commands are top-level commands that each run one tool.
+var commands = []toolCommand{
+	{use: "doctor", tool: "check_environment"},
+}
+
+// Names returns the names of the command groups and top-level commands,
+// which are not natural language queries.
+func Names() []string {
+	names := make([]string, 0, len(groups)+len(commands))
+	for _, g := range groups {
+		names = append(names, g.use)
+	}
+	for _, tc := range commands {
+		names = append(names, tc.use)
+	}
+	return names
+}
+
+// toolRunner runs a tool by name.
+type toolRunner interface {
+	CallTool(ctx context.Context, name string, args map[string]interface{}) (server.CallToolResult, error)
+}
+
+var newRunner = func(kubeconfig string, imp server.Impersonation) toolRunner {
+	srv := server.NewServer(kubeconfig)
+	srv.SetImpersonation(imp)
+	return srv
+}
+
+type clusterDiscoverer interface {
+	DiscoverClusters(source string) ([]cluster.ClusterInfo, error)
+}
+
+var newDiscoverer = func(kubeconfig string) clusterDiscoverer {
+	return cluster.NewDiscoverer(kubeconfig)
+}
+
+// skippedArgs are tool arguments the CLI sets itself or that have no use
+// outside an MCP session.
+var skippedArgs = map[string]bool{
+	"cluster":             true,
+	"namespace":           true,
+	cache.ArgAllowStale:   true,
+	cache.ArgForceRefresh: true,
+}
+
+// NewCommands creates the command groups and top-level commands that
+// mirror the MCP tools.
+func NewCommands(configFlags *genericclioptions.ConfigFlags) []*cobra.Command {
+	var cmds []*cobra.Command
+	for _, g := range groups {
+		parent := &cobra.Command{
+			Use:   g.use,
+			Short: g.short,
+		}
+		for _, tc := range g.commands {
+			if cmd := newToolCommand(configFlags, tc); cmd != nil {
+				parent.AddCommand(cmd)
+			}
+		}
+		cmds = append(cmds, parent)
+	}
+	for _, tc := range commands {
+		if cmd := newToolCommand(configFlags, tc); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// newToolCommand builds a command with a flag for each argument of the
+// tool's schema, with underscores in names replaced by dashes.
+func newToolCommand(configFlags *genericclioptions.ConfigFlags, tc toolCommand) *cobra.Command {
+	schema, ok := server.ToolSchema(tc.tool)
+	if !ok {
+		return nil
+	}
+	var allClusters bool
+	var failOn string
+	g, gated := gates[tc.tool]
+	cmd := &cobra.Command{
+		Use:   tc.use,
+		Short: schema.Description,
+		Long: fmt.Sprintf(`%s
+
+Runs the %s MCP tool. The cluster is the current context unless --context or
+--all-clusters is given, and -n sets the namespace for tools that take one.
+The command fails if the tool reports an error, so it can gate CI jobs.`, schema.Description, tc.tool),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			args, err := toolArgs(cmd.Flags(), schema)
+			if err != nil {
+				return err
+			}
+			var threshold *failThreshold
+			if failOn != "" {
+				levels, err := g.threshold(failOn)
+				if err != nil {
+					return err
+				}
+				threshold = &failThreshold{gate: g, levels: levels}
+			}
+			return runTool(cmd.Context(), cmd.OutOrStdout(), configFlags, schema, args, allClusters, threshold)
+		},
+	}
+	if gated {
+		cmd.Long += fmt.Sprintf(`
+
+--fail-on exits with code %d when the result has findings at the given level
+or a more severe one, summed over every cluster, and code %d if the command
+fails.`, g.code, ExitFailed)
+		cmd.Flags().StringVar(&failOn, "fail-on", "", fmt.Sprintf("Exit with code %d on findings at this level or above: %s", g.code, strings.Join(g.levels, ", ")))
+	}
+	for name, prop := range schema.InputSchema.Properties {
+		if skippedArgs[name] {
+			continue
+		}
+		flag := flagName(name)
+		switch prop.Type {
+		case "boolean":
+			cmd.Flags().Bool(flag, false, prop.Description)
+		case "integer":
+			cmd.Flags().Int(flag, 0, prop.Description)
+		case "array":
+			cmd.Flags().StringSlice(flag, nil, prop.Description)
+		default:
+			if name == output.Arg {
+				cmd.Flags().StringP(flag, "o", "", prop.Description)
+			} else {
+				cmd.Flags().String(flag, "", prop.Description)
+			}
+		}
+	}
+	for _, name := range schema.InputSchema.Required {
+		if !skippedArgs[name] {
+			_ = cmd.MarkFlagRequired(flagName(name))
+		}
+	}
+	if _, ok := schema.InputSchema.Properties["cluster"]; ok {
+		cmd.Flags().BoolVar(&allClusters, "all-clusters", false, "Run on every discovered cluster")
+	}
+	return cmd
+}
+
+func flagName(arg string) string {
+	return strings.ReplaceAll(arg, "_", "-")
+}
+
+// toolArgs collects the flags that were set into tool arguments.
+func toolArgs(flags *pflag.FlagSet, schema server.Tool) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	for name, prop := range schema.InputSchema.Properties {
+		flag := flagName(name)
+		if skippedArgs[name] || !flags.Changed(flag) {
+			continue
+		}
+		var (
+			value interface{}
+			err   error
+		)
+		switch prop.Type {
+		case "boolean":
+			value, err = flags.GetBool(flag)
+		case "integer":
+			value, err = flags.GetInt(flag)
+		case "array":
+			var items []string
+			items, err = flags.GetStringSlice(flag)
+			list := make([]interface{}, len(items))
+			for i, item := range items {
+				list[i] = item
+			}
+			value = list
+		default:
+			value, err = flags.GetString(flag)
+		}
+		if err != nil {
+			return nil, err
+		}
+		args[name] = value
+	}
+	return args, nil
+}
+
+// failThreshold is the --fail-on level of a command, as the gate levels it
+// fails on.
+type failThreshold struct {
+	gate   gate
+	levels []string
+}
+
+// runTool runs the tool on each target cluster and prints the results. It
+// fails if any run does, or if the results reach threshold.
+func runTool(ctx context.Context, out io.Writer, configFlags *genericclioptions.ConfigFlags, schema server.Tool, args map[string]interface{}, allClusters bool, threshold *failThreshold) error {
+	kubeconfig := ""
+	if configFlags.KubeConfig != nil {
+		kubeconfig = *configFlags.KubeConfig
+	}
+	var imp server.Impersonation
+	if configFlags.Impersonate != nil {
+		imp.User = *configFlags.Impersonate
+	}
+	if configFlags.ImpersonateGroup != nil {
+		imp.Groups = *configFlags.ImpersonateGroup
+	}
+	props := schema.InputSchema.Properties
+	if _, ok := props["namespace"]; ok && configFlags.Namespace != nil && *configFlags.Namespace != "" {
+		args["namespace"] = *configFlags.Namespace
+	}
+
+	clusters := []string{""}
+	if _, ok := props["cluster"]; ok {
+		switch {
+		case allClusters:
+			discovered, err := newDiscoverer(kubeconfig).DiscoverClusters("all")
+			if err != nil {
+				return fmt.Errorf("failed to discover clusters: %w", err)
+			}
+			if len(discovered) == 0 {
+				return fmt.Errorf("no clusters found")
+			}
+			clusters = clusters[:0]
+			for _, c := range discovered {
+				clusters = append(clusters, c.Name)
+			}
+		case configFlags.Context != nil && *configFlags.Context != "":
+			clusters = []string{*configFlags.Context}
+		}
+	}
+
+	// Findings are read from the tool's own format, and the output
+	// format is applied here.
+	var format output.Format
+	if threshold != nil {
+		var err error
+		if format, err = output.Parse(args); err != nil {
+			return err
+		}
+	}
+
+	runner := newRunner(kubeconfig, imp)
+	failed := 0
+	totals := make(map[string]int)
+	for i, c := range clusters {
+		callArgs := make(map[string]interface{}, len(args)+1)
+		for k, v := range args {
+			callArgs[k] = v
+		}
+		if c != "" {
+			callArgs["cluster"] = c
+		}
+		if threshold != nil {
+			delete(callArgs, output.Arg)
+		}
+		result, err := runner.CallTool(ctx, schema.Name, callArgs)
+		if err != nil {
+			return err
+		}
+		if len(clusters) > 1 {
+			if i > 0 {
+				_, _ = fmt.Fprintln(out)
+			}
+			_, _ = fmt.Fprintf(out, "=== %s ===\n", c)
+		}
+		for _, block := range result.Content {
+			text := block.Text
+			if threshold != nil {
+				for level, n := range threshold.gate.count(text) {
+					totals[level] += n
+				}
+				text = output.Render(text, format)
+			}
+			_, _ = fmt.Fprintln(out, strings.TrimRight(text, "\n"))
+		}
+		if result.IsError {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s failed on %d of %d clusters", schema.Name, failed, len(clusters))
+	}
+	if threshold != nil {
+		return threshold.gate.check(schema.Name, threshold.levels, totals)
+	}
+	return nil
+}
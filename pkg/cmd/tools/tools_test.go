@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+)
+
+type call struct {
+	name string
+	args map[string]interface{}
+}
+
+type fakeRunner struct {
+	calls  *[]call
+	result func(args map[string]interface{}) server.CallToolResult
+}
+
+func (f fakeRunner) CallTool(_ context.Context, name string, args map[string]interface{}) (server.CallToolResult, error) {
+	*f.calls = append(*f.calls, call{name: name, args: args})
+	return f.result(args), nil
+}
+
+type fakeDiscoverer struct {
+	clusters []cluster.ClusterInfo
+	err      error
+}
+
+func (f fakeDiscoverer) DiscoverClusters(string) ([]cluster.ClusterInfo, error) {
+	return f.clusters, f.err
+}
+
+func textResult(text string, isError bool) server.CallToolResult {
+	return server.CallToolResult{Content: []server.ContentBlock{{Type: "text", Text: text}}, IsError: isError}
+}
+
+// execute runs the tools commands with args under a root command carrying
+// the kubectl config flags, as kubestellar-ops does.
+func execute(t *testing.T, runner fakeRunner, args ...string) (string, error) {
+	t.Helper()
+	oldRunner := newRunner
+	newRunner = func(string, server.Impersonation) toolRunner { return runner }
+	t.Cleanup(func() { newRunner = oldRunner })
+
+	root := &cobra.Command{Use: "kubestellar-ops"}
+	configFlags := genericclioptions.NewConfigFlags(true)
+	configFlags.AddFlags(root.PersistentFlags())
+	root.AddCommand(NewCommands(configFlags)...)
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs(args)
+	err := root.ExecuteContext(context.Background())
+	return out.String(), err
+}
+
+func TestNewCommandsMirrorTools(t *testing.T) {
+	cmds := NewCommands(genericclioptions.NewConfigFlags(true))
+	require.Len(t, cmds, len(groups)+len(commands))
+	for i, g := range groups {
+		require.Equal(t, g.use, cmds[i].Use)
+		require.Len(t, cmds[i].Commands(), len(g.commands), "every tool in %s should be registered", g.use)
+	}
+	for i, tc := range commands {
+		require.Equal(t, tc.use, cmds[len(groups)+i].Use)
+	}
+}
+
+func TestToolCommandFlagsFollowSchema(t *testing.T) {
+	var calls []call
+	runner := fakeRunner{calls: &calls, result: func(map[string]interface{}) server.CallToolResult {
+		return textResult("ok", false)
+	}}
+
+	out, err := execute(t, runner, "rbac", "can-i", "--context", "prod", "-n", "shop",
+		"--verb", "delete", "--resource", "pods", "-o", "json")
+	require.NoError(t, err)
+	require.Equal(t, "ok\n", out)
+	require.Equal(t, []call{{name: "can_i", args: map[string]interface{}{
+		"cluster":   "prod",
+		"namespace": "shop",
+		"verb":      "delete",
+		"resource":  "pods",
+		"output":    "json",
+	}}}, calls)
+}
+
+func TestToolCommandRequiresRequiredFlags(t *testing.T) {
+	var calls []call
+	runner := fakeRunner{calls: &calls, result: func(map[string]interface{}) server.CallToolResult {
+		return textResult("ok", false)
+	}}
+
+	_, err := execute(t, runner, "rbac", "can-i", "--resource", "pods")
+	require.ErrorContains(t, err, `"verb" not set`)
+	require.Empty(t, calls)
+}
+
+func TestToolCommandAllClusters(t *testing.T) {
+	oldDiscoverer := newDiscoverer
+	newDiscoverer = func(string) clusterDiscoverer {
+		return fakeDiscoverer{clusters: []cluster.ClusterInfo{{Name: "a"}, {Name: "b"}}}
+	}
+	t.Cleanup(func() { newDiscoverer = oldDiscoverer })
+
+	var calls []call
+	runner := fakeRunner{calls: &calls, result: func(args map[string]interface{}) server.CallToolResult {
+		if args["cluster"] == "b" {
+			return textResult("error: connection refused", true)
+		}
+		return textResult("No pod issues found", false)
+	}}
+
+	out, err := execute(t, runner, "diagnose", "pods", "--all-clusters")
+	require.EqualError(t, err, "find_pod_issues failed on 1 of 2 clusters")
+	require.Equal(t, "=== a ===\nNo pod issues found\n\n=== b ===\nerror: connection refused\n", out)
+	require.Len(t, calls, 2)
+}
+
+func TestToolCommandDiscoveryFailure(t *testing.T) {
+	oldDiscoverer := newDiscoverer
+	newDiscoverer = func(string) clusterDiscoverer {
+		return fakeDiscoverer{err: errors.New("no kubeconfig")}
+	}
+	t.Cleanup(func() { newDiscoverer = oldDiscoverer })
+
+	var calls []call
+	runner := fakeRunner{calls: &calls}
+	_, err := execute(t, runner, "upgrade", "preflight", "--all-clusters")
+	require.EqualError(t, err, "failed to discover clusters: no kubeconfig")
+	require.Empty(t, calls)
+}
+
+func TestToolCommandFailOnSeverity(t *testing.T) {
+	report := "Found 2 pods with security concerns:\n🔴 Critical | 🟠 High | 🟡 Medium\n" +
+		"\n🔓 shop/web\n   - 🟠 Container app runs as root (UID 0)\n   - 🟡 Container app has no security context\n" +
+		"\n🔓 shop/db\n   - 🟡 Container db has writable root filesystem\n"
+	var calls []call
+	runner := fakeRunner{calls: &calls, result: func(map[string]interface{}) server.CallToolResult {
+		return textResult(report, false)
+	}}
+
+	_, err := execute(t, runner, "diagnose", "security", "--fail-on", "critical")
+	require.NoError(t, err, "no critical findings")
+
+	out, err := execute(t, runner, "diagnose", "security", "--fail-on", "high")
+	require.EqualError(t, err, "check_security_issues found 1 high (--fail-on high)")
+	require.Equal(t, ExitFindings, ExitCode(err))
+	require.Equal(t, report, out)
+
+	_, err = execute(t, runner, "diagnose", "security", "--fail-on", "medium")
+	require.EqualError(t, err, "check_security_issues found 1 high, 2 medium (--fail-on medium)")
+
+	report = messages.ASCII(report)
+	_, err = execute(t, runner, "diagnose", "security", "--fail-on", "medium")
+	require.EqualError(t, err, "check_security_issues found 1 high, 2 medium (--fail-on medium)", "findings are read from ASCII output too")
+
+	_, err = execute(t, runner, "diagnose", "security", "--fail-on", "low")
+	require.EqualError(t, err, `invalid --fail-on "low": must be one of critical, high, medium`)
+	require.Equal(t, ExitFailed, ExitCode(err))
+}
+
+func TestToolCommandFailOnDriftAcrossClusters(t *testing.T) {
+	oldDiscoverer := newDiscoverer
+	newDiscoverer = func(string) clusterDiscoverer {
+		return fakeDiscoverer{clusters: []cluster.ClusterInfo{{Name: "a"}, {Name: "b"}}}
+	}
+	t.Cleanup(func() { newDiscoverer = oldDiscoverer })
+
+	var calls []call
+	runner := fakeRunner{calls: &calls, result: func(args map[string]interface{}) server.CallToolResult {
+		if args["cluster"] == "b" {
+			return textResult("# GitOps Drift Detection\n\n⚠️ **Drift detected**: 2 resource(s) out of sync\n"+
+				"\n```json\n{\"drifted\": true, \"summary\": {\"total\": 3, \"drifted\": 2}}\n```\n", false)
+		}
+		return textResult("# GitOps Drift Detection\n\n✅ **No drift detected** - cluster state matches Git manifests\n"+
+			"\n```json\n{\"drifted\": false, \"summary\": {\"total\": 3, \"drifted\": 0}}\n```\n", false)
+	}}
+
+	out, err := execute(t, runner, "drift", "detect", "--repo-url", "https://github.com/org/fleet", "--all-clusters", "--fail-on", "drift", "-o", "json")
+	require.EqualError(t, err, "detect_drift found 2 drift (--fail-on drift)")
+	require.Equal(t, ExitDrift, ExitCode(err))
+	require.Contains(t, out, "=== b ===")
+	for _, c := range calls {
+		require.NotContains(t, c.args, "output", "findings are read from the tool's own format")
+	}
+}
+
+func TestToolCommandFailOnHelmValuesDrift(t *testing.T) {
+	var calls []call
+	runner := fakeRunner{calls: &calls, result: func(map[string]interface{}) server.CallToolResult {
+		return textResult("# Helm Values Drift: web\n\n**Clusters:** 3 checked, 2 drifted\n"+
+			"\n```json\n{\"release\": \"web\", \"drifted\": true, \"clusters\": "+
+			"[{\"drifted\": true}, {\"drifted\": false}, {\"drifted\": true}]}\n```\n", false)
+	}}
+
+	_, err := execute(t, runner, "drift", "helm-values", "--release", "web", "--repo-url", "https://github.com/org/fleet",
+		"--values-files", "web/values.yaml", "--fail-on", "drift")
+	require.EqualError(t, err, "detect_helm_values_drift found 2 drift (--fail-on drift)")
+	require.Equal(t, ExitDrift, ExitCode(err))
+}
+
+func TestToolCommandFailOnPreflight(t *testing.T) {
+	var calls []call
+	runner := fakeRunner{calls: &calls, result: func(map[string]interface{}) server.CallToolResult {
+		return textResult("## Summary\n\n- **Passed:** 5\n- **Failed:** 0\n- **Warnings:** 1\n\n", false)
+	}}
+
+	_, err := execute(t, runner, "upgrade", "preflight", "--fail-on", "failed")
+	require.NoError(t, err)
+	_, err = execute(t, runner, "upgrade", "preflight", "--fail-on", "warning")
+	require.EqualError(t, err, "get_upgrade_prerequisites found 1 warning (--fail-on warning)")
+
+	runner.result = func(map[string]interface{}) server.CallToolResult {
+		return textResult("## c1\n\n- **Failed:** 1\n- **Warnings:** 0\n\n## c2\n\n- **Failed:** 2\n- **Warnings:** 0\n\n", false)
+	}
+	_, err = execute(t, runner, "upgrade", "preflight", "--fail-on", "failed")
+	require.EqualError(t, err, "get_upgrade_prerequisites found 3 failed (--fail-on failed)", "every summary in the report counts")
+
+	_, err = execute(t, runner, "diagnose", "pods", "--fail-on", "high")
+	require.ErrorContains(t, err, "unknown flag: --fail-on", "only commands with findings to gate on take --fail-on")
+}
@@ -0,0 +1,528 @@
+// Package config loads the kubestellar-mcp configuration file. The file
+// holds the settings otherwise given as flags and KUBESTELLAR_* environment
+// variables, plus named profiles that override them, e.g. one per fleet.
+//
+// Settings reach the rest of the tree through the same environment
+// variables the subsystems already read: Apply sets each one the
+// environment does not, so flags and the environment still win.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/advisories"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/auditlog"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cron"
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/notify"
+	"github.com/kubestellar/kubestellar-mcp/pkg/policy"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+const (
+	// EnvConfigPath overrides the configuration file location.
+	EnvConfigPath = "KUBESTELLAR_CONFIG"
+	// EnvProfile selects a profile when --profile is not given.
+	EnvProfile = "KUBESTELLAR_PROFILE"
+	// EnvPrometheus lists Prometheus endpoints as comma-separated
+	// cluster=URL entries; the cluster * is the default for the rest.
+	EnvPrometheus = "KUBESTELLAR_PROMETHEUS"
+	// EnvSchedules holds the scheduled tool runs of the configuration
+	// file as JSON, for kubestellar-ops.
+	EnvSchedules = "KUBESTELLAR_SCHEDULES"
+	// EnvQueries holds the saved queries of the configuration file as
+	// JSON, for kubestellar-ops.
+	EnvQueries = "KUBESTELLAR_QUERIES"
+	// envEnvironments is read by kubestellar-deploy's promote_app.
+	envEnvironments = "KUBESTELLAR_ENVIRONMENTS"
+)
+
+// Settings are the values a configuration file, or one of its profiles,
+// can set. Zero values are unset.
+type Settings struct {
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	Context    string `json:"context,omitempty"`
+	// Namespace is the default namespace of CLI commands.
+	Namespace string `json:"namespace,omitempty"`
+	// ClusterGroups name sets of clusters. In order, they are the
+	// environments apps are promoted through, the last one production.
+	ClusterGroups []ClusterGroup `json:"clusterGroups,omitempty"`
+	Policy        Policy         `json:"policy,omitempty"`
+	// Prometheus maps cluster names to Prometheus URLs; * is the default.
+	Prometheus map[string]string `json:"prometheus,omitempty"`
+	// AuditLog maps cluster names to audit log sources; * is the default.
+	AuditLog map[string]string `json:"auditLog,omitempty"`
+	// ClusterAliases maps alias names to the clusters they stand for.
+	ClusterAliases map[string]string `json:"clusterAliases,omitempty"`
+	Git            Git               `json:"git,omitempty"`
+	StateStore     string            `json:"stateStore,omitempty"`
+	// Notifications routes alerts from background subsystems to sinks.
+	Notifications *notify.Config `json:"notifications,omitempty"`
+	// Schedules are kubestellar-ops tools run on cron schedules.
+	Schedules []ScheduledRun `json:"schedules,omitempty"`
+	// Queries are named kubestellar-ops tool calls, run with run_query.
+	Queries []SavedQuery `json:"queries,omitempty"`
+	Output  Output       `json:"output,omitempty"`
+	// AdvisoriesURL refreshes the built-in Kubernetes and OpenShift
+	// end-of-life and CVE data from a URL.
+	AdvisoriesURL string `json:"advisoriesURL,omitempty"`
+}
+
+// Output is how tool results are written.
+type Output struct {
+	// ASCII makes tool results plain ASCII.
+	ASCII bool `json:"ascii,omitempty"`
+	// Messages is a translation file for the message catalog.
+	Messages string `json:"messages,omitempty"`
+}
+
+// ScheduledRun runs a kubestellar-ops tool with fixed arguments on a cron
+// schedule.
+type ScheduledRun struct {
+	Name      string                 `json:"name"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Schedule  string                 `json:"schedule"`
+	TimeZone  string                 `json:"timeZone,omitempty"`
+}
+
+// SavedQuery names a kubestellar-ops tool call with fixed arguments.
+type SavedQuery struct {
+	Name        string                 `json:"name"`
+	Tool        string                 `json:"tool"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	Description string                 `json:"description,omitempty"`
+}
+
+// ClusterGroup is a named set of clusters.
+type ClusterGroup struct {
+	Name     string   `json:"name"`
+	Clusters []string `json:"clusters"`
+}
+
+// Policy is the tool policy: what tools may do and where.
+type Policy struct {
+	// File is a .rego file or directory evaluated for every tool call.
+	File              string   `json:"file,omitempty"`
+	OPABinary         string   `json:"opaBinary,omitempty"`
+	ApprovalMode      string   `json:"approvalMode,omitempty"`
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// ProtectedNamespaces replaces the default system namespaces; an
+	// empty list turns the protection off.
+	ProtectedNamespaces *[]string `json:"protectedNamespaces,omitempty"`
+}
+
+// Git holds references to git credentials, never the secrets themselves.
+type Git struct {
+	Credentials []gitops.Credential `json:"credentials,omitempty"`
+}
+
+// File is the configuration file: default settings and named profiles.
+type File struct {
+	Settings
+	DefaultProfile string              `json:"defaultProfile,omitempty"`
+	Profiles       map[string]Settings `json:"profiles,omitempty"`
+}
+
+// DefaultPath returns the configuration file location: $KUBESTELLAR_CONFIG,
+// or kubestellar-mcp/config.yaml under $XDG_CONFIG_HOME or ~/.config.
+func DefaultPath() string {
+	if path := os.Getenv(EnvConfigPath); path != "" {
+		return path
+	}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "kubestellar-mcp", "config.yaml")
+}
+
+// Load reads the configuration file at path and returns its settings with
+// profile applied. An empty path means DefaultPath, which need not exist.
+// An empty profile means $KUBESTELLAR_PROFILE, then the file's
+// defaultProfile.
+func Load(path, profile string) (Settings, error) {
+	explicit := path != "" || os.Getenv(EnvConfigPath) != ""
+	if path == "" {
+		path = DefaultPath()
+	}
+	if profile == "" {
+		profile = os.Getenv(EnvProfile)
+	}
+	var f File
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		if profile != "" {
+			return Settings{}, fmt.Errorf("profile %q requested but %s does not exist", profile, path)
+		}
+		return Settings{}, nil
+	case err != nil:
+		return Settings{}, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return Settings{}, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return f.Resolve(profile)
+}
+
+// Resolve returns the file's settings overridden by the named profile, or
+// by the default profile if name is empty.
+func (f File) Resolve(name string) (Settings, error) {
+	if name == "" {
+		name = f.DefaultProfile
+	}
+	s := f.Settings
+	if name != "" {
+		p, ok := f.Profiles[name]
+		if !ok {
+			return Settings{}, fmt.Errorf("unknown profile %q (available: %s)", name, strings.Join(f.profileNames(), ", "))
+		}
+		s = s.merge(p)
+	}
+	if err := s.Validate(); err != nil {
+		if name != "" {
+			return Settings{}, fmt.Errorf("profile %q: %w", name, err)
+		}
+		return Settings{}, err
+	}
+	return s, nil
+}
+
+func (f File) profileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
+
+// merge returns s with every value set in o replacing its own. Prometheus
+// endpoints, audit log sources, and cluster aliases merge per cluster; lists, notifications,
+// schedules, and queries are replaced whole.
+func (s Settings) merge(o Settings) Settings {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&s.Kubeconfig, o.Kubeconfig)
+	set(&s.Context, o.Context)
+	set(&s.Namespace, o.Namespace)
+	set(&s.StateStore, o.StateStore)
+	set(&s.AdvisoriesURL, o.AdvisoriesURL)
+	set(&s.Policy.File, o.Policy.File)
+	set(&s.Policy.OPABinary, o.Policy.OPABinary)
+	set(&s.Policy.ApprovalMode, o.Policy.ApprovalMode)
+	set(&s.Output.Messages, o.Output.Messages)
+	if o.Output.ASCII {
+		s.Output.ASCII = true
+	}
+	if o.ClusterGroups != nil {
+		s.ClusterGroups = o.ClusterGroups
+	}
+	if o.Policy.AllowedNamespaces != nil {
+		s.Policy.AllowedNamespaces = o.Policy.AllowedNamespaces
+	}
+	if o.Policy.ProtectedNamespaces != nil {
+		s.Policy.ProtectedNamespaces = o.Policy.ProtectedNamespaces
+	}
+	if o.Git.Credentials != nil {
+		s.Git.Credentials = o.Git.Credentials
+	}
+	if o.Notifications != nil {
+		s.Notifications = o.Notifications
+	}
+	if o.Schedules != nil {
+		s.Schedules = o.Schedules
+	}
+	if o.Queries != nil {
+		s.Queries = o.Queries
+	}
+	s.Prometheus = mergeClusterMap(s.Prometheus, o.Prometheus)
+	s.AuditLog = mergeClusterMap(s.AuditLog, o.AuditLog)
+	s.ClusterAliases = mergeClusterMap(s.ClusterAliases, o.ClusterAliases)
+	return s
+}
+
+// mergeClusterMap returns the per-cluster entries of base overridden by
+// those of o.
+func mergeClusterMap(base, o map[string]string) map[string]string {
+	if len(o) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(o))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range o {
+		merged[k] = v
+	}
+	return merged
+}
+
+// Validate checks the settings the subsystems would otherwise reject only
+// when they are first used.
+func (s Settings) Validate() error {
+	seen := make(map[string]bool)
+	for _, g := range s.ClusterGroups {
+		if g.Name == "" || strings.ContainsAny(g.Name, "=;,") {
+			return fmt.Errorf("clusterGroups: invalid name %q", g.Name)
+		}
+		if seen[g.Name] {
+			return fmt.Errorf("clusterGroups: %q is listed twice", g.Name)
+		}
+		seen[g.Name] = true
+		if len(g.Clusters) == 0 {
+			return fmt.Errorf("clusterGroups: %q has no clusters", g.Name)
+		}
+	}
+	if s.Policy.ApprovalMode != "" {
+		if _, err := approval.ParseMode(s.Policy.ApprovalMode); err != nil {
+			return fmt.Errorf("policy.approvalMode: %w", err)
+		}
+	}
+	for cluster, endpoint := range s.Prometheus {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("prometheus: %s: %q is not an http(s) URL", cluster, endpoint)
+		}
+		if strings.ContainsAny(cluster, "=,") || strings.Contains(endpoint, ",") {
+			return fmt.Errorf("prometheus: invalid entry %s=%s", cluster, endpoint)
+		}
+	}
+	for cluster, spec := range s.AuditLog {
+		if strings.ContainsAny(cluster, "=,") || strings.Contains(spec, ",") {
+			return fmt.Errorf("auditLog: invalid entry %s=%s", cluster, spec)
+		}
+		if _, err := auditlog.ParseSource(spec, cluster); err != nil {
+			return fmt.Errorf("auditLog: %s: %w", cluster, err)
+		}
+	}
+	for alias, cluster := range s.ClusterAliases {
+		if alias == "" || cluster == "" || strings.ContainsAny(alias, "=,") || strings.Contains(cluster, ",") {
+			return fmt.Errorf("clusterAliases: invalid entry %s=%s", alias, cluster)
+		}
+	}
+	for _, c := range s.Git.Credentials {
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("git.credentials: %w", err)
+		}
+	}
+	if s.Notifications != nil {
+		if err := s.Notifications.Validate(); err != nil {
+			return err
+		}
+	}
+	names := make(map[string]bool)
+	for _, r := range s.Schedules {
+		if r.Name == "" || r.Tool == "" || r.Schedule == "" {
+			return fmt.Errorf("schedules: name, tool, and schedule are required")
+		}
+		if names[r.Name] {
+			return fmt.Errorf("schedules: %q is listed twice", r.Name)
+		}
+		names[r.Name] = true
+		if _, err := cron.Parse(r.Schedule, r.TimeZone); err != nil {
+			return fmt.Errorf("schedules: %s: %w", r.Name, err)
+		}
+	}
+	queries := make(map[string]bool)
+	for _, q := range s.Queries {
+		if q.Name == "" || q.Tool == "" {
+			return fmt.Errorf("queries: name and tool are required")
+		}
+		if queries[q.Name] {
+			return fmt.Errorf("queries: %q is listed twice", q.Name)
+		}
+		queries[q.Name] = true
+	}
+	if s.Output.Messages != "" {
+		if _, err := messages.Load(expandHome(s.Output.Messages)); err != nil {
+			return fmt.Errorf("output.messages: %w", err)
+		}
+	}
+	if s.AdvisoriesURL != "" {
+		if u, err := url.Parse(s.AdvisoriesURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("advisoriesURL: %q must be an http or https URL", s.AdvisoriesURL)
+		}
+	}
+	return nil
+}
+
+// Env returns the environment variables that carry the settings.
+func (s Settings) Env() map[string]string {
+	env := make(map[string]string)
+	setPath := func(key, v string) {
+		if v != "" {
+			env[key] = expandHome(v)
+		}
+	}
+	setPath("KUBECONFIG", s.Kubeconfig)
+	setPath(policy.EnvPolicyPath, s.Policy.File)
+	setPath(policy.EnvOPABinary, s.Policy.OPABinary)
+	setPath(messages.EnvMessages, s.Output.Messages)
+	if s.Output.ASCII {
+		env[messages.EnvASCII] = "true"
+	}
+	if s.Policy.ApprovalMode != "" {
+		env[approval.EnvApprovalMode] = s.Policy.ApprovalMode
+	}
+	if len(s.Policy.AllowedNamespaces) > 0 {
+		env[guardrail.EnvAllowedNamespaces] = strings.Join(s.Policy.AllowedNamespaces, ",")
+	}
+	if s.Policy.ProtectedNamespaces != nil {
+		env[guardrail.EnvProtectedNamespaces] = strings.Join(*s.Policy.ProtectedNamespaces, ",")
+	}
+	if s.StateStore != "" {
+		env[store.EnvStateStore] = s.StateStore
+	}
+	if s.AdvisoriesURL != "" {
+		env[advisories.EnvAdvisoriesURL] = s.AdvisoriesURL
+	}
+	if len(s.ClusterGroups) > 0 {
+		groups := make([]string, len(s.ClusterGroups))
+		for i, g := range s.ClusterGroups {
+			groups[i] = g.Name + "=" + strings.Join(g.Clusters, ",")
+		}
+		env[envEnvironments] = strings.Join(groups, ";")
+	}
+	if len(s.Prometheus) > 0 {
+		entries := make([]string, 0, len(s.Prometheus))
+		for cluster, endpoint := range s.Prometheus {
+			entries = append(entries, cluster+"="+endpoint)
+		}
+		sort.Strings(entries)
+		env[EnvPrometheus] = strings.Join(entries, ",")
+	}
+	if len(s.AuditLog) > 0 {
+		entries := make([]string, 0, len(s.AuditLog))
+		for cluster, spec := range s.AuditLog {
+			entries = append(entries, cluster+"="+spec)
+		}
+		sort.Strings(entries)
+		env[auditlog.EnvAuditLog] = strings.Join(entries, ",")
+	}
+	if len(s.ClusterAliases) > 0 {
+		entries := make([]string, 0, len(s.ClusterAliases))
+		for alias, cluster := range s.ClusterAliases {
+			entries = append(entries, alias+"="+cluster)
+		}
+		sort.Strings(entries)
+		env[multicluster.EnvClusterAliases] = strings.Join(entries, ",")
+	}
+	if len(s.Git.Credentials) > 0 {
+		creds := make([]string, len(s.Git.Credentials))
+		for i, c := range s.Git.Credentials {
+			if c.TokenFile != "" {
+				c.TokenFile = expandHome(c.TokenFile)
+			}
+			creds[i] = c.String()
+		}
+		env[gitops.EnvGitCredentials] = strings.Join(creds, ",")
+	}
+	if s.Notifications != nil {
+		data, _ := json.Marshal(s.Notifications)
+		env[notify.EnvNotifications] = string(data)
+	}
+	if len(s.Schedules) > 0 {
+		data, _ := json.Marshal(s.Schedules)
+		env[EnvSchedules] = string(data)
+	}
+	if len(s.Queries) > 0 {
+		data, _ := json.Marshal(s.Queries)
+		env[EnvQueries] = string(data)
+	}
+	return env
+}
+
+// Apply sets each of the settings' environment variables that is not
+// already set, so the environment takes precedence over the file.
+func (s Settings) Apply() error {
+	for key, value := range s.Env() {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// PrometheusURL returns the Prometheus endpoint for cluster from
+// EnvPrometheus, falling back to the * entry, or "" if there is none.
+func PrometheusURL(cluster string) string {
+	fallback := ""
+	for _, entry := range strings.Split(os.Getenv(EnvPrometheus), ",") {
+		name, endpoint, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+		switch name {
+		case cluster:
+			return endpoint
+		case "*":
+			fallback = endpoint
+		}
+	}
+	return fallback
+}
+
+// SchedulesFromEnv returns the scheduled tool runs in EnvSchedules.
+func SchedulesFromEnv() ([]ScheduledRun, error) {
+	value := os.Getenv(EnvSchedules)
+	if value == "" {
+		return nil, nil
+	}
+	var runs []ScheduledRun
+	if err := json.Unmarshal([]byte(value), &runs); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvSchedules, err)
+	}
+	return runs, nil
+}
+
+// QueriesFromEnv returns the saved queries in EnvQueries.
+func QueriesFromEnv() ([]SavedQuery, error) {
+	value := os.Getenv(EnvQueries)
+	if value == "" {
+		return nil, nil
+	}
+	var queries []SavedQuery
+	if err := json.Unmarshal([]byte(value), &queries); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvQueries, err)
+	}
+	return queries, nil
+}
+
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
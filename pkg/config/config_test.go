@@ -0,0 +1,275 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+kubeconfig: /etc/kube/fleet.yaml
+namespace: platform
+policy:
+  approvalMode: approve
+  protectedNamespaces: [kube-system]
+prometheus:
+  "*": https://prometheus.example.com
+auditLog:
+  "*": webhook
+git:
+  credentials:
+  - host: github.com
+    tokenEnv: GITHUB_TOKEN
+defaultProfile: dev
+profiles:
+  dev:
+    context: kind-dev
+  prod:
+    context: prod-east
+    namespace: shop
+    clusterGroups:
+    - name: staging
+      clusters: [stg-east]
+    - name: prod
+      clusters: [prod-east, prod-west]
+    policy:
+      approvalMode: plan
+      allowedNamespaces: [shop, payments]
+    prometheus:
+      prod-east: https://prom.prod-east.example.com
+    auditLog:
+      prod-east: cloudwatch:/aws/eks/prod-east/cluster
+`
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadProfiles(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	path := writeConfig(t, testConfig)
+
+	dev, err := Load(path, "")
+	require.NoError(t, err)
+	require.Equal(t, "kind-dev", dev.Context, "defaultProfile should apply")
+	require.Equal(t, "platform", dev.Namespace)
+	require.Equal(t, "approve", dev.Policy.ApprovalMode)
+
+	prod, err := Load(path, "prod")
+	require.NoError(t, err)
+	require.Equal(t, "prod-east", prod.Context)
+	require.Equal(t, "shop", prod.Namespace)
+	require.Equal(t, "/etc/kube/fleet.yaml", prod.Kubeconfig, "unset profile values should keep the defaults")
+	require.Equal(t, "plan", prod.Policy.ApprovalMode)
+	require.Equal(t, []string{"kube-system"}, *prod.Policy.ProtectedNamespaces)
+	require.Equal(t, map[string]string{
+		"*":         "https://prometheus.example.com",
+		"prod-east": "https://prom.prod-east.example.com",
+	}, prod.Prometheus)
+	require.Equal(t, map[string]string{
+		"*":         "webhook",
+		"prod-east": "cloudwatch:/aws/eks/prod-east/cluster",
+	}, prod.AuditLog)
+
+	t.Setenv(EnvProfile, "prod")
+	fromEnv, err := Load(path, "")
+	require.NoError(t, err)
+	require.Equal(t, prod, fromEnv)
+
+	_, err = Load(path, "qa")
+	require.EqualError(t, err, `unknown profile "qa" (available: dev, prod)`)
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	t.Setenv(EnvConfigPath, "")
+	t.Setenv(EnvProfile, "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	settings, err := Load("", "")
+	require.NoError(t, err, "the default file is optional")
+	require.Equal(t, Settings{}, settings)
+
+	_, err = Load("", "prod")
+	require.ErrorContains(t, err, `profile "prod" requested but`)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yaml"), "")
+	require.ErrorContains(t, err, "failed to read config")
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown field", "namespce: shop\n", `unknown field "namespce"`},
+		{"approval mode", "policy:\n  approvalMode: sometimes\n", "policy.approvalMode: invalid"},
+		{"prometheus", "prometheus:\n  a: prometheus:9090\n", `prometheus: a: "prometheus:9090" is not an http(s) URL`},
+		{"audit log", "auditLog:\n  prod: loki:http://loki\n", `auditLog: prod: unknown audit log source "loki:http://loki"`},
+		{"git credential", "git:\n  credentials:\n  - host: github.com\n", "git.credentials: git credential for github.com: set exactly one of tokenEnv and tokenFile"},
+		{"notifications", "notifications:\n  sinks:\n  - name: oncall\n    slack: {}\n", `notifications: sink "oncall": set exactly one of url and urlEnv`},
+		{"schedule", "schedules:\n- name: nightly\n  tool: check_security_issues\n  schedule: 0 2 * *\n", "schedules: nightly: schedule"},
+		{"query", "queries:\n- name: prod-pods\n  tool: find_pod_issues\n- name: prod-pods\n  tool: get_pods\n", `queries: "prod-pods" is listed twice`},
+		{"messages", "output:\n  messages: /nonexistent/messages.yaml\n", "output.messages: failed to read messages"},
+		{"empty group", "clusterGroups:\n- name: prod\n", `clusterGroups: "prod" has no clusters`},
+		{"cluster alias", "clusterAliases:\n  prod: \"\"\n", "clusterAliases: invalid entry prod="},
+		{"profile", "profiles:\n  prod:\n    policy:\n      approvalMode: never\n", `profile "prod": policy.approvalMode`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile := ""
+			if tt.name == "profile" {
+				profile = "prod"
+			}
+			_, err := Load(writeConfig(t, tt.content), profile)
+			require.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestEnvAndApply(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	settings, err := Load(writeConfig(t, testConfig), "prod")
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"KUBECONFIG":                       "/etc/kube/fleet.yaml",
+		"KUBESTELLAR_APPROVAL_MODE":        "plan",
+		"KUBESTELLAR_ALLOWED_NAMESPACES":   "shop,payments",
+		"KUBESTELLAR_PROTECTED_NAMESPACES": "kube-system",
+		"KUBESTELLAR_ENVIRONMENTS":         "staging=stg-east;prod=prod-east,prod-west",
+		"KUBESTELLAR_PROMETHEUS":           "*=https://prometheus.example.com,prod-east=https://prom.prod-east.example.com",
+		"KUBESTELLAR_AUDIT_LOG":            "*=webhook,prod-east=cloudwatch:/aws/eks/prod-east/cluster",
+		"KUBESTELLAR_GIT_CREDENTIALS":      "github.com=env:GITHUB_TOKEN",
+	}, settings.Env())
+
+	for key := range settings.Env() {
+		t.Setenv(key, "")
+		require.NoError(t, os.Unsetenv(key))
+	}
+	t.Setenv("KUBESTELLAR_APPROVAL_MODE", "off")
+	require.NoError(t, settings.Apply())
+	require.Equal(t, "off", os.Getenv("KUBESTELLAR_APPROVAL_MODE"), "the environment should win over the file")
+	require.Equal(t, "shop,payments", os.Getenv("KUBESTELLAR_ALLOWED_NAMESPACES"))
+
+	require.Equal(t, "https://prom.prod-east.example.com", PrometheusURL("prod-east"))
+	require.Equal(t, "https://prometheus.example.com", PrometheusURL("stg-east"))
+}
+
+func TestClusterAliasesMergePerAlias(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	settings, err := Load(writeConfig(t, `
+clusterAliases:
+  prod: gke_acme_us-east1_prod
+  dev: kind-dev
+profiles:
+  eu:
+    clusterAliases:
+      prod: gke_acme_europe-west1_prod
+`), "eu")
+	require.NoError(t, err)
+	require.Equal(t, "dev=kind-dev,prod=gke_acme_europe-west1_prod", settings.Env()["KUBESTELLAR_CLUSTER_ALIASES"])
+}
+
+func TestEmptyProtectedNamespacesDisablesProtection(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	settings, err := Load(writeConfig(t, "policy:\n  protectedNamespaces: []\n"), "")
+	require.NoError(t, err)
+	value, ok := settings.Env()["KUBESTELLAR_PROTECTED_NAMESPACES"]
+	require.True(t, ok)
+	require.Empty(t, value)
+}
+
+func TestNotificationsPassThroughEnv(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	settings, err := Load(writeConfig(t, `
+notifications:
+  sinks:
+  - name: oncall
+    slack:
+      urlEnv: SLACK_WEBHOOK_URL
+  routes:
+  - events: [rollout, scaling]
+    minSeverity: warning
+    sinks: [oncall]
+`), "")
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"sinks": [{"name": "oncall", "slack": {"urlEnv": "SLACK_WEBHOOK_URL"}}],
+		"routes": [{"events": ["rollout", "scaling"], "minSeverity": "warning", "sinks": ["oncall"]}]
+	}`, settings.Env()["KUBESTELLAR_NOTIFICATIONS"])
+}
+
+func TestSchedulesPassThroughEnv(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	settings, err := Load(writeConfig(t, `
+schedules:
+- name: nightly-security
+  tool: check_security_issues
+  schedule: "0 2 * * *"
+  timeZone: Europe/Berlin
+- name: weekly-rbac
+  tool: get_cluster_role_bindings
+  arguments:
+    cluster: prod
+  schedule: "@weekly"
+`), "")
+	require.NoError(t, err)
+
+	t.Setenv(EnvSchedules, settings.Env()[EnvSchedules])
+	runs, err := SchedulesFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, settings.Schedules, runs)
+	require.Equal(t, "Europe/Berlin", runs[0].TimeZone)
+	require.Equal(t, map[string]interface{}{"cluster": "prod"}, runs[1].Arguments)
+}
+
+func TestQueriesPassThroughEnv(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	settings, err := Load(writeConfig(t, `
+queries:
+- name: prod-pod-issues
+  tool: find_pod_issues
+  arguments:
+    cluster: prod
+    namespace: payments
+  description: Pod issues in the payments namespace of prod
+`), "")
+	require.NoError(t, err)
+
+	t.Setenv(EnvQueries, settings.Env()[EnvQueries])
+	queries, err := QueriesFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, settings.Queries, queries)
+	require.Equal(t, map[string]interface{}{"cluster": "prod", "namespace": "payments"}, queries[0].Arguments)
+}
+
+func TestOutputPassesThroughEnv(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	translation := filepath.Join(t.TempDir(), "de.yaml")
+	require.NoError(t, os.WriteFile(translation, []byte("podIssues.none: Keine Pod-Probleme gefunden\n"), 0o600))
+	settings, err := Load(writeConfig(t, "output:\n  messages: "+translation+"\nprofiles:\n  ci:\n    output:\n      ascii: true\n"), "ci")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"KUBESTELLAR_MESSAGES": translation,
+		"KUBESTELLAR_ASCII":    "true",
+	}, settings.Env())
+}
+
+func TestAdvisoriesURLPassesThroughEnv(t *testing.T) {
+	t.Setenv(EnvProfile, "")
+	settings, err := Load(writeConfig(t, "advisoriesURL: https://example.com/advisories.json\n"), "")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"KUBESTELLAR_ADVISORIES_URL": "https://example.com/advisories.json",
+	}, settings.Env())
+
+	_, err = Load(writeConfig(t, "advisoriesURL: /etc/advisories.json\n"), "")
+	require.ErrorContains(t, err, "must be an http or https URL")
+}
@@ -0,0 +1,78 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNext(t *testing.T) {
+	// A Monday.
+	from := time.Date(2026, time.March, 2, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		timeZone string
+		want     time.Time
+	}{
+		{"*/15 * * * *", "", time.Date(2026, time.March, 2, 10, 15, 0, 0, time.UTC)},
+		{"0 2 * * *", "", time.Date(2026, time.March, 3, 2, 0, 0, 0, time.UTC)},
+		{"@hourly", "", time.Date(2026, time.March, 2, 11, 0, 0, 0, time.UTC)},
+		{"30 9 * * mon-fri", "", time.Date(2026, time.March, 3, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", "", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan,jul *", "", time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches.
+		{"0 0 15 * fri", "", time.Date(2026, time.March, 6, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", "", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * *", "Asia/Kolkata", time.Date(2026, time.March, 3, 6, 30, 0, 0, time.UTC)},
+		{"CRON_TZ=America/New_York 0 6 * * *", "", time.Date(2026, time.March, 2, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			schedule, err := Parse(tt.schedule, tt.timeZone)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(from).UTC())
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "* * * * funday"} {
+		_, err := Parse(spec, "")
+		assert.Error(t, err, spec)
+	}
+	_, err := Parse("* * * * *", "Mars/Olympus")
+	assert.Error(t, err)
+}
+
+func TestNextNeverFires(t *testing.T) {
+	schedule, err := Parse("0 0 31 2 *", "")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
+
+func TestPrev(t *testing.T) {
+	// A Monday.
+	at := time.Date(2026, time.March, 2, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		schedule string
+		want     time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, time.March, 2, 10, 0, 0, 0, time.UTC)},
+		{"7 10 * * *", time.Date(2026, time.March, 2, 10, 7, 0, 0, time.UTC)},
+		{"0 20 * * mon-fri", time.Date(2026, time.February, 27, 20, 0, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schedule, func(t *testing.T) {
+			schedule, err := Parse(tt.schedule, "")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Prev(at).UTC())
+		})
+	}
+
+	schedule, err := Parse("0 0 29 2 *", "")
+	require.NoError(t, err)
+	assert.True(t, schedule.Prev(at).IsZero())
+}
@@ -7,11 +7,14 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
 	"github.com/kubestellar/kubestellar-mcp/pkg/deploy/mcp"
 )
 
 var (
 	mcpServer      bool
+	configPath     string
+	profile        string
 	runMCPServer             = mcp.RunMCPServer
 	newRootCommand           = NewRootCommand
 	stderr         io.Writer = os.Stderr
@@ -39,6 +42,15 @@ Examples:
 
   # Show version
   kubestellar-deploy version`,
+		// The server reads its settings from the environment, where the
+		// configuration file's are added unless already set.
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			settings, err := config.Load(configPath, profile)
+			if err != nil {
+				return err
+			}
+			return settings.Apply()
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if mcpServer {
 				return runMCPServer()
@@ -48,6 +60,8 @@ Examples:
 	}
 
 	cmd.PersistentFlags().BoolVar(&mcpServer, "mcp-server", false, "Run as MCP server for Claude Code integration")
+	cmd.PersistentFlags().StringVar(&configPath, "config", "", "Configuration file (default $KUBESTELLAR_CONFIG or ~/.config/kubestellar-mcp/config.yaml)")
+	cmd.PersistentFlags().StringVar(&profile, "profile", "", "Configuration profile to use (default $KUBESTELLAR_PROFILE or the file's defaultProfile)")
 
 	cmd.AddCommand(newVersionCommand())
 
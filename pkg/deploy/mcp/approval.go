@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// mutatingTools lists the tools that change cluster state and are subject
+// to the approval gate and the change journal. Each must honor dry_run;
+// tools that write through client-go also honor approval.WithDryRun.
+var mutatingTools = map[string]bool{
+	"deploy_app":                     true,
+	"scale_app":                      true,
+	"rolling_restart_fleet":          true,
+	"patch_app":                      true,
+	"set_statefulset_partition":      true,
+	"restart_statefulset":            true,
+	"sync_from_git":                  true,
+	"reconcile":                      true,
+	"helm_install":                   true,
+	"helm_uninstall":                 true,
+	"helm_rollback":                  true,
+	"delete_resource":                true,
+	"kubectl_apply":                  true,
+	"kustomize_apply":                true,
+	"kustomize_delete":               true,
+	"add_labels":                     true,
+	"remove_labels":                  true,
+	"taint_nodes":                    true,
+	"untaint_nodes":                  true,
+	"rebalance_pods":                 true,
+	"undo_change":                    true,
+	"resume_rollout":                 true,
+	"abort_rollout":                  true,
+	"start_blue_green":               true,
+	"shift_traffic":                  true,
+	"finish_blue_green":              true,
+	"migrate_app":                    true,
+	"clone_namespace":                true,
+	"snapshot_app_volumes":           true,
+	"restore_volume_snapshot":        true,
+	"promote_app":                    true,
+	"distribute_secret":              true,
+	"update_config":                  true,
+	"apply_resource_recommendations": true,
+	"schedule_scaling":               true,
+	"override_scaling_schedule":      true,
+}
+
+// withApprovalArgs adds approved and plan_id to the input schema of every
+// mutating tool.
+func withApprovalArgs(tools []map[string]interface{}) []map[string]interface{} {
+	for _, tool := range tools {
+		name, _ := tool["name"].(string)
+		if !mutatingTools[name] {
+			continue
+		}
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		if properties == nil {
+			continue
+		}
+		properties[approval.ArgApproved] = map[string]interface{}{
+			"type":        "boolean",
+			"description": "Apply the change. Required when the server runs in approval mode; without it the call is a dry run",
+		}
+		properties[approval.ArgPlanID] = map[string]interface{}{
+			"type":        "string",
+			"description": "Plan id returned by the dry run being approved (required in plan mode)",
+		}
+	}
+	return tools
+}
+
+// dryRunResponse records a plan for a dry run forced by the approval gate
+// and returns the tool output with instructions for applying it, and the
+// policy's reasons when the policy required approval.
+func (s *Server) dryRunResponse(ctx context.Context, id interface{}, tool string, args map[string]interface{}, text string, requireApproval []string) *MCPResponse {
+	plan, err := s.getApprovalGate().RecordPlan(ctx, tool, args)
+	if err != nil {
+		return toolErrorResponse(id, err)
+	}
+	resp := toolTextResponse(id, text, plan.Meta())
+	result := resp.Result.(map[string]interface{})
+	content := result["content"].([]map[string]interface{})
+	if len(requireApproval) > 0 {
+		content = append(content, map[string]interface{}{"type": "text", "text": policyApprovalNote(requireApproval)})
+	}
+	result["content"] = append(content, map[string]interface{}{
+		"type": "text",
+		"text": plan.Note(),
+	})
+	return resp
+}
+
+// getApprovalGate returns the gate configured by $KUBESTELLAR_APPROVAL_MODE,
+// keeping plans in the state store.
+func (s *Server) getApprovalGate() *approval.Gate {
+	s.approvalGateOnce.Do(func() {
+		if s.approvalGate == nil {
+			s.approvalGate = approval.GateFromEnv(s.getStateStore())
+		}
+	})
+	return s.approvalGate
+}
+
+// getStateStore returns the store selected by $KUBESTELLAR_STATE_STORE,
+// shared by approval plans and the change journal. If it cannot be opened
+// state is kept in memory.
+func (s *Server) getStateStore() store.Store {
+	s.stateStoreOnce.Do(func() {
+		if s.stateStore != nil {
+			return
+		}
+		st, err := store.FromEnv(stateStoreClient)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to open state store, keeping state in memory: %v\n", err)
+			st = store.NewMemory()
+		}
+		s.stateStore = st
+	})
+	return s.stateStore
+}
+
+// stateStoreClient builds a client for the current kubeconfig context.
+// Server state is not subject to the namespace guardrails.
+func stateStoreClient() (kubernetes.Interface, error) {
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
+
+// applyApproval runs a tool call through the approval gate. Mutating tools
+// are gated by the approval mode, or always when the authorization policy
+// requires approval (requireApproval); read-only tools the policy requires
+// approval for must be called with approved: true. It returns the
+// arguments and context to run the tool with, and for dry runs forced by
+// the gate, the original arguments to record a plan for.
+func (s *Server) applyApproval(ctx context.Context, tool string, raw json.RawMessage, requireApproval []string) (context.Context, json.RawMessage, map[string]interface{}, error) {
+	if !mutatingTools[tool] {
+		if len(requireApproval) > 0 && !approval.Approved(cacheArgs(raw)) {
+			return ctx, nil, nil, fmt.Errorf("%s Repeat the call with %s: true to proceed", policyApprovalNote(requireApproval), approval.ArgApproved)
+		}
+		return ctx, raw, nil, nil
+	}
+	gate := s.getApprovalGate()
+	check := gate.Check
+	if len(requireApproval) > 0 {
+		check = gate.CheckRequired
+	} else if !gate.Enabled() {
+		return ctx, raw, nil, nil
+	}
+	args := cacheArgs(raw)
+	decision, err := check(ctx, tool, args)
+	if err != nil {
+		return ctx, nil, nil, err
+	}
+	runArgs, err := json.Marshal(decision.Args)
+	if err != nil {
+		return ctx, nil, nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if !decision.DryRun {
+		return ctx, runArgs, nil, nil
+	}
+	return approval.WithDryRun(ctx), runArgs, args, nil
+}
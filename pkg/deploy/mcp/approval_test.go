@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleToolCall_PlanModeDryRunsUntilApproved(t *testing.T) {
+	var mu sync.Mutex
+	var writes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(`{"kind":"DeploymentList","apiVersion":"apps/v1","items":[{"metadata":{"name":"web","namespace":"default"},"spec":{"replicas":1}}]}`))
+			return
+		}
+		mu.Lock()
+		writes = append(writes, r.Method+" dryRun="+r.URL.Query().Get("dryRun"))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"web","namespace":"default"}}`))
+	}))
+	defer srv.Close()
+
+	mgr, err := multicluster.NewClientManager(writeKubeconfig(t, map[string]string{"c1": srv.URL}))
+	require.NoError(t, err)
+	mgr.SetConfigTransform(approval.Wrap)
+	server := newServerWithManager(mgr)
+	server.approvalGate = approval.NewGate(approval.ModePlan, store.NewMemory())
+
+	call := func(args map[string]interface{}) map[string]interface{} {
+		resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+			"name":      "scale_app",
+			"arguments": args,
+		})})
+		require.Nil(t, resp.Error)
+		return resp.Result.(map[string]interface{})
+	}
+	args := map[string]interface{}{"app": "web", "replicas": 3, "clusters": []string{"c1"}}
+
+	preview := call(args)
+	assert.Nil(t, preview["isError"])
+	meta := preview["_meta"].(map[string]interface{})
+	planID, _ := meta["planId"].(string)
+	require.NotEmpty(t, planID)
+	assert.Equal(t, true, meta["dryRun"])
+	content := preview["content"].([]map[string]interface{})
+	require.Len(t, content, 2)
+	assert.Contains(t, content[1]["text"], planID)
+	assert.Equal(t, []string{"PUT dryRun=All"}, writes)
+
+	rejected := call(map[string]interface{}{"app": "web", "replicas": 3, "clusters": []string{"c1"}, "approved": true})
+	assert.Equal(t, true, rejected["isError"])
+	assert.Len(t, writes, 1, "approval without a plan must not write")
+
+	applied := call(map[string]interface{}{"app": "web", "replicas": 3, "clusters": []string{"c1"}, "approved": true, "plan_id": planID})
+	assert.Nil(t, applied["isError"])
+	assert.Equal(t, []string{"PUT dryRun=All", "PUT dryRun="}, writes)
+}
+
+func TestApplyApproval_ForcesDryRunArgument(t *testing.T) {
+	server := &Server{approvalGate: approval.NewGate(approval.ModeApprove, store.NewMemory())}
+
+	ctx, args, planArgs, err := server.applyApproval(context.Background(), "helm_install", []byte(`{"release":"web","dry_run":false}`), nil)
+	require.NoError(t, err)
+	assert.True(t, approval.IsDryRun(ctx))
+	assert.JSONEq(t, `{"release":"web","dry_run":true}`, string(args))
+	assert.Equal(t, "web", planArgs["release"])
+
+	ctx, args, planArgs, err = server.applyApproval(context.Background(), "helm_install", []byte(`{"release":"web","approved":true}`), nil)
+	require.NoError(t, err)
+	assert.False(t, approval.IsDryRun(ctx))
+	assert.JSONEq(t, `{"release":"web"}`, string(args))
+	assert.Nil(t, planArgs)
+
+	raw := []byte(`{"release":"web"}`)
+	_, args, _, err = server.applyApproval(context.Background(), "helm_list", raw, nil)
+	require.NoError(t, err)
+	assert.Equal(t, string(raw), string(args), "read-only tools are not gated")
+}
+
+func TestApplyApproval_PolicyRequiresApproval(t *testing.T) {
+	server := &Server{approvalGate: approval.NewGate(approval.ModeOff, store.NewMemory())}
+	reasons := []string{"prod needs review"}
+
+	ctx, args, planArgs, err := server.applyApproval(context.Background(), "scale_app", []byte(`{"app":"web"}`), reasons)
+	require.NoError(t, err)
+	assert.True(t, approval.IsDryRun(ctx), "the policy gates mutating tools even with approval mode off")
+	assert.JSONEq(t, `{"app":"web","dry_run":true}`, string(args))
+	assert.NotNil(t, planArgs)
+
+	_, _, _, err = server.applyApproval(context.Background(), "get_app_logs", []byte(`{"app":"web"}`), reasons)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prod needs review")
+
+	_, _, _, err = server.applyApproval(context.Background(), "get_app_logs", []byte(`{"app":"web","approved":true}`), reasons)
+	assert.NoError(t, err)
+}
+
+func TestWithApprovalArgs_OnlyMutatingTools(t *testing.T) {
+	s := &Server{}
+	resp := s.handleListTools(&MCPRequest{JSONRPC: "2.0", ID: 1})
+	tools := resp.Result.(map[string]interface{})["tools"].([]map[string]interface{})
+	for _, tool := range tools {
+		name := tool["name"].(string)
+		properties := tool["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+		_, hasApproved := properties[approval.ArgApproved]
+		assert.Equal(t, mutatingTools[name], hasApproved, "tool %s", name)
+		if mutatingTools[name] && name != "scale_app" && name != "patch_app" && name != "reconcile" {
+			assert.Contains(t, properties, approval.ArgDryRun, "mutating tool %s must accept dry_run", name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"encoding/json"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+)
+
+// withRetryFailedArg adds retry_failed to the input schema of every tool
+// that runs on clusters.
+func withRetryFailedArg(tools []map[string]interface{}) []map[string]interface{} {
+	for _, tool := range tools {
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		_, single := properties["cluster"]
+		_, multi := properties["clusters"]
+		if !single && !multi {
+			continue
+		}
+		properties[fanout.ArgRetryFailed] = map[string]interface{}{
+			"type":        "boolean",
+			"description": fanout.ArgDescription,
+		}
+	}
+	return tools
+}
+
+// retryFailedArg reads retry_failed and strips it from the arguments, since
+// it only narrows the clusters a call runs on.
+func retryFailedArg(raw json.RawMessage) (bool, json.RawMessage, error) {
+	args := cacheArgs(raw)
+	if _, ok := args[fanout.ArgRetryFailed]; !ok {
+		return false, raw, nil
+	}
+	retry := fanout.Requested(args)
+	stripped, err := json.Marshal(args)
+	if err != nil {
+		return false, raw, err
+	}
+	return retry, stripped, nil
+}
+
+// withClusterStatus adds the status matrix of the clusters a call ran on to
+// its result, and remembers it for retry_failed unless the call was a dry
+// run.
+func (s *Server) withClusterStatus(resp *MCPResponse, callKey string, args map[string]interface{}, statuses *fanout.Recorder) *MCPResponse {
+	meta := statuses.Meta()
+	if resp == nil || meta == nil {
+		return resp
+	}
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return resp
+	}
+	resultMeta, _ := result["_meta"].(map[string]interface{})
+	if dryRun, _ := resultMeta["dryRun"].(bool); !dryRun && !approval.DryRunRequested(args) {
+		s.getFanoutMemory().Remember(callKey, statuses)
+	}
+	return withResultMeta(resp, meta)
+}
+
+func (s *Server) getFanoutMemory() *fanout.Memory {
+	s.fanoutMemoryOnce.Do(func() {
+		s.fanoutMemory = fanout.NewMemory()
+	})
+	return s.fanoutMemory
+}
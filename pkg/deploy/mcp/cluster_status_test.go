@@ -0,0 +1,63 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+)
+
+// callToolMeta calls a tool and returns the _meta of its result.
+func callToolMeta(t *testing.T, server *Server, name string, args map[string]interface{}) (map[string]interface{}, bool) {
+	t.Helper()
+	resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+		"name":      name,
+		"arguments": args,
+	})})
+	require.Nil(t, resp.Error)
+	result := resp.Result.(map[string]interface{})
+	meta, _ := result["_meta"].(map[string]interface{})
+	return meta, result["isError"] == true
+}
+
+func clusterStatuses(meta map[string]interface{}) map[string]fanout.Status {
+	statuses := make(map[string]fanout.Status)
+	matrix, _ := meta[fanout.MetaKey].([]fanout.ClusterStatus)
+	for _, s := range matrix {
+		statuses[s.Cluster] = s.Status
+	}
+	return statuses
+}
+
+func TestRetryFailedClusters(t *testing.T) {
+	prod, prodURL := newObjectAPIServer(t, false)
+	prod.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", liveDeployment("web"))
+	server := newAtomicTestServer(t, map[string]string{"prod": prodURL})
+	args := map[string]interface{}{"namespace": "shop", "clusters": []string{"prod", "staging"}}
+
+	_, isError := callToolMeta(t, server, "dump_namespace", map[string]interface{}{"namespace": "shop", "clusters": []string{"prod"}, fanout.ArgRetryFailed: true})
+	require.True(t, isError, "retrying a call that never ran should fail")
+
+	meta, isError := callToolMeta(t, server, "dump_namespace", args)
+	require.False(t, isError)
+	assert.Equal(t, map[string]fanout.Status{"prod": fanout.StatusOK, "staging": fanout.StatusFailed}, clusterStatuses(meta))
+	assert.Equal(t, map[fanout.Status]int{fanout.StatusOK: 1, fanout.StatusFailed: 1}, meta[fanout.MetaSummaryKey])
+
+	args[fanout.ArgRetryFailed] = true
+	meta, _ = callToolMeta(t, server, "dump_namespace", args)
+	assert.Equal(t, map[string]fanout.Status{"prod": fanout.StatusSkipped, "staging": fanout.StatusFailed}, clusterStatuses(meta))
+}
+
+func TestRetryFailedArgInSchemas(t *testing.T) {
+	resp := newHelmTestServer(t, map[string]string{}).handleListTools(&MCPRequest{JSONRPC: "2.0", ID: 1})
+	for _, tool := range resp.Result.(map[string]interface{})["tools"].([]map[string]interface{}) {
+		properties := tool["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+		_, single := properties["cluster"]
+		_, multi := properties["clusters"]
+		_, retry := properties[fanout.ArgRetryFailed]
+		assert.Equal(t, single || multi, retry, tool["name"])
+	}
+}
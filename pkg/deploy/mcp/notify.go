@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/notify"
+)
+
+// getNotifier returns the notifier configured by $KUBESTELLAR_NOTIFICATIONS,
+// or nil, which drops every event, if there is none.
+func (s *Server) getNotifier() *notify.Notifier {
+	s.notifierOnce.Do(func() {
+		if s.notifier != nil {
+			return
+		}
+		n, err := notify.FromEnv(ServerName)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Notifications disabled: %v\n", err)
+			return
+		}
+		s.notifier = n
+	})
+	return s.notifier
+}
+
+// notify sends e, logging delivery failures since nobody waits on the
+// background subsystems that raise events.
+func (s *Server) notify(ctx context.Context, e notify.Event) {
+	if err := s.getNotifier().Notify(ctx, e); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Failed to send notification: %v\n", err)
+	}
+}
+
+// notifyRollout reports a rollout that failed, completed, or paused.
+func (s *Server) notifyRollout(ctx context.Context, r *rollout) {
+	e := notify.Event{
+		Type:    notify.EventRollout,
+		Message: r.Message,
+		Fields:  map[string]string{"rollout": r.ID, "batches": fmt.Sprintf("%d/%d", r.NextBatch, len(r.Batches))},
+	}
+	switch r.State {
+	case rolloutFailed:
+		e.Severity, e.Title = notify.SeverityCritical, fmt.Sprintf("Rollout %s failed", r.ID)
+	case rolloutCompleted:
+		e.Severity, e.Title = notify.SeverityInfo, fmt.Sprintf("Rollout %s completed", r.ID)
+	case rolloutPaused:
+		e.Severity, e.Title = notify.SeverityInfo, fmt.Sprintf("Rollout %s paused", r.ID)
+	default:
+		return
+	}
+	s.notify(ctx, e)
+}
+
+// notifyScaling reports a scheduled scaling run, as a warning if it failed
+// in any cluster.
+func (s *Server) notifyScaling(ctx context.Context, sc *ScalingSchedule, run ScalingRun) {
+	var scaled, failed []string
+	for _, r := range run.Results {
+		switch {
+		case r.Error != "" && r.Cluster != "":
+			failed = append(failed, fmt.Sprintf("%s: %s", r.Cluster, r.Error))
+		case r.Error != "":
+			failed = append(failed, r.Error)
+		case r.Skipped == "":
+			scaled = append(scaled, r.Cluster)
+		}
+	}
+	e := notify.Event{
+		Type:     notify.EventScaling,
+		Severity: notify.SeverityInfo,
+		Title:    fmt.Sprintf("Scaling schedule %s scaled %s %s", sc.Name, sc.App, run.State),
+		Fields:   map[string]string{"schedule": sc.Name, "trigger": run.Trigger},
+	}
+	if len(scaled) > 0 {
+		e.Fields["clusters"] = strings.Join(scaled, ", ")
+	}
+	if run.ChangeID != "" {
+		e.Fields["changeId"] = run.ChangeID
+	}
+	if len(failed) > 0 {
+		e.Severity = notify.SeverityWarning
+		e.Title = fmt.Sprintf("Scaling schedule %s failed to scale %s %s", sc.Name, sc.App, run.State)
+		e.Message = strings.Join(failed, "\n")
+	}
+	s.notify(ctx, e)
+}
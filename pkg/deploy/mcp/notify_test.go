@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withWebhookNotifier points server's notifier at a test webhook and
+// returns a func listing the events it received.
+func withWebhookNotifier(t *testing.T, server *Server) func() []notify.Event {
+	var mu sync.Mutex
+	var events []notify.Event
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e notify.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}))
+	t.Cleanup(hook.Close)
+
+	n, err := notify.New(notify.Config{
+		Sinks:  []notify.SinkConfig{{Name: "hook", Webhook: &notify.WebhookConfig{URL: hook.URL}}},
+		Routes: []notify.Route{{Events: []notify.EventType{notify.EventScaling, notify.EventRollout}, Sinks: []string{"hook"}}},
+	}, ServerName)
+	require.NoError(t, err)
+	server.notifier = n
+	return func() []notify.Event {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]notify.Event(nil), events...)
+	}
+}
+
+func TestScalingSchedulerNotifies(t *testing.T) {
+	_, _, server := newScalingClusters(t)
+	received := withWebhookNotifier(t, server)
+	ctx := context.Background()
+
+	_, errText := callTool(t, server, "schedule_scaling", nightly)
+	require.Empty(t, errText)
+	server.runScalingSchedules(ctx, nextFire(t, "0 20 * * *", time.Now()).Add(time.Minute))
+
+	events := received()
+	require.Len(t, events, 1)
+	e := events[0]
+	assert.Equal(t, notify.EventScaling, e.Type)
+	assert.Equal(t, notify.SeverityInfo, e.Severity)
+	assert.Equal(t, "Scaling schedule web-nights scaled web down", e.Title)
+	assert.Equal(t, ServerName, e.Source)
+	assert.Equal(t, "east, west", e.Fields["clusters"])
+	assert.Equal(t, "web-nights", e.Fields["schedule"])
+	assert.NotEmpty(t, e.Fields["changeId"])
+}
+
+func TestNotifyRolloutSkipsRunningRollouts(t *testing.T) {
+	_, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"east": url})
+	received := withWebhookNotifier(t, server)
+	ctx := context.Background()
+
+	server.notifyRollout(ctx, &rollout{ID: "r1", State: rolloutRunning})
+	server.notifyRollout(ctx, &rollout{ID: "r2", State: rolloutFailed, Message: "batch 1 failed", Batches: make([]rolloutBatch, 2)})
+
+	events := received()
+	require.Len(t, events, 1)
+	assert.Equal(t, notify.SeverityCritical, events[0].Severity)
+	assert.Equal(t, "Rollout r2 failed", events[0].Title)
+	assert.Equal(t, "batch 1 failed", events[0].Message)
+	assert.Equal(t, "0/2", events[0].Fields["batches"])
+}
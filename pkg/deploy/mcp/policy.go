@@ -0,0 +1,46 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/policy"
+)
+
+// authorizeToolCall evaluates the authorization policy for a call. It
+// returns an error if the call is denied or the policy cannot be evaluated,
+// and otherwise the policy's reasons for requiring approval, if any. The
+// server acts with its own kubeconfig identity, so input.identity is empty.
+func (s *Server) authorizeToolCall(ctx context.Context, tool string, raw json.RawMessage) ([]string, error) {
+	engine := s.getPolicyEngine()
+	if !engine.Enabled() {
+		return nil, nil
+	}
+	in := policy.NewInput(ServerName, tool, cacheArgs(raw), mutatingTools[tool], policy.Identity{})
+	decision, err := engine.Evaluate(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if err := decision.Err(); err != nil {
+		return nil, err
+	}
+	return decision.RequireApproval, nil
+}
+
+// getPolicyEngine returns the engine configured by $KUBESTELLAR_POLICY, or
+// nil if no policy is configured.
+func (s *Server) getPolicyEngine() *policy.Engine {
+	s.policyEngineOnce.Do(func() {
+		if s.policyEngine == nil {
+			s.policyEngine = policy.FromEnv()
+		}
+	})
+	return s.policyEngine
+}
+
+// policyApprovalNote explains why a call needs approval.
+func policyApprovalNote(reasons []string) string {
+	return fmt.Sprintf("Approval required by policy: %s.", strings.Join(reasons, "; "))
+}
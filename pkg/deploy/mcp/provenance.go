@@ -0,0 +1,47 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/provenance"
+)
+
+// getProvenanceSigner returns the signer configured by
+// $KUBESTELLAR_PROVENANCE_KEY, or nil if results are not signed.
+func (s *Server) getProvenanceSigner() *provenance.Signer {
+	s.provenanceOnce.Do(func() {
+		if s.provenanceSigner != nil {
+			return
+		}
+		signer, err := provenance.SignerFromEnv()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Tool results will not be signed: %v\n", err)
+			return
+		}
+		s.provenanceSigner = signer
+	})
+	return s.provenanceSigner
+}
+
+// withProvenance signs a tool result as it will be sent and attaches the
+// block as _meta.provenance. Protocol errors carry no result to sign.
+func withProvenance(resp *MCPResponse, signer *provenance.Signer, tool string, reads *provenance.Collector) *MCPResponse {
+	if resp == nil {
+		return resp
+	}
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return resp
+	}
+	content, _ := result["content"].([]map[string]interface{})
+	texts := make([]string, len(content))
+	for i, block := range content {
+		texts[i], _ = block["text"].(string)
+	}
+	meta, _ := result["_meta"].(map[string]interface{})
+	cached, _ := meta["cached"].(bool)
+	return withResultMeta(resp, map[string]interface{}{
+		"provenance": signer.Sign(ServerName, ServerVersion, tool, texts, reads, cached),
+	})
+}
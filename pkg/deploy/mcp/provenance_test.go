@@ -0,0 +1,27 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/provenance"
+)
+
+func TestWithProvenance_SignsTheSentResult(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	signer := provenance.NewHMACSigner(key)
+
+	resp := withProvenance(toolTextResponse(1, `{"healthy": true}`, map[string]interface{}{"cached": true}), signer, "get_app_status", nil)
+	meta := resp.Result.(map[string]interface{})["_meta"].(map[string]interface{})
+	block, ok := meta["provenance"].(*provenance.Block)
+	require.True(t, ok)
+	assert.Equal(t, ServerName, block.Server)
+	assert.Equal(t, "get_app_status", block.Tool)
+	assert.True(t, block.Cached)
+	assert.NoError(t, provenance.Verify(block, []string{`{"healthy": true}`}, key))
+
+	protocolErr := &MCPResponse{JSONRPC: "2.0", ID: 2, Error: &MCPError{Code: -32601, Message: "Unknown tool"}}
+	assert.Same(t, protocolErr, withProvenance(protocolErr, signer, "missing", nil))
+}
@@ -0,0 +1,66 @@
+package mcp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+)
+
+// fleetScanCacheTTL is how long fleet-wide read-only scans are reused.
+const fleetScanCacheTTL = 2 * time.Minute
+
+// cachedToolTTLs lists the read-only tools whose results are cached per
+// argument set. Tools that change cluster state must never appear here.
+var cachedToolTTLs = map[string]time.Duration{
+	"get_app_instances":          fleetScanCacheTTL,
+	"get_app_status":             fleetScanCacheTTL,
+	"get_app_versions":           fleetScanCacheTTL,
+	"list_cluster_capabilities":  fleetScanCacheTTL,
+	"find_clusters_for_workload": fleetScanCacheTTL,
+	"list_kueue_queues":          fleetScanCacheTTL,
+	"list_pending_workloads":     fleetScanCacheTTL,
+	"detect_drift":               fleetScanCacheTTL,
+}
+
+// withFreshnessArgs adds allow_stale and force_refresh to the input schema
+// of every cached tool.
+func withFreshnessArgs(tools []map[string]interface{}) []map[string]interface{} {
+	for _, tool := range tools {
+		name, _ := tool["name"].(string)
+		if _, ok := cachedToolTTLs[name]; !ok {
+			continue
+		}
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		if properties == nil {
+			continue
+		}
+		properties[cache.ArgAllowStale] = map[string]interface{}{
+			"type":        "boolean",
+			"description": "Return a cached result even if it is older than the cache TTL",
+		}
+		properties[cache.ArgForceRefresh] = map[string]interface{}{
+			"type":        "boolean",
+			"description": "Ignore any cached result and rescan the fleet",
+		}
+	}
+	return tools
+}
+
+// cacheArgs decodes tool arguments for cache keying. Arguments that are not
+// a JSON object are keyed as empty; the handler reports them as invalid.
+func cacheArgs(raw json.RawMessage) map[string]interface{} {
+	var args map[string]interface{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &args)
+	}
+	return args
+}
+
+func (s *Server) getResultCache() *cache.ResultCache {
+	s.resultCacheOnce.Do(func() {
+		s.resultCache = cache.NewResultCache(0)
+	})
+	return s.resultCache
+}
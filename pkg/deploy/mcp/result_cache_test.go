@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleToolCall_CachesFleetScansUntilForceRefresh(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"List","apiVersion":"v1","items":[]}`))
+	}))
+	defer srv.Close()
+
+	mgr, err := multicluster.NewClientManager(writeKubeconfig(t, map[string]string{"c1": srv.URL}))
+	require.NoError(t, err)
+	server := newServerWithManager(mgr)
+
+	call := func(args map[string]interface{}) map[string]interface{} {
+		resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+			"name":      "get_app_instances",
+			"arguments": args,
+		})})
+		require.Nil(t, resp.Error)
+		return resp.Result.(map[string]interface{})
+	}
+
+	first := call(map[string]interface{}{"app": "demo"})
+	scanned := atomic.LoadInt32(&requests)
+	require.NotZero(t, scanned)
+	meta := first["_meta"].(map[string]interface{})
+	assert.Equal(t, false, meta["cached"])
+	assert.NotEmpty(t, meta["asOf"])
+
+	second := call(map[string]interface{}{"app": "demo"})
+	assert.Equal(t, scanned, atomic.LoadInt32(&requests), "cached call must not hit the API server")
+	assert.Equal(t, true, second["_meta"].(map[string]interface{})["cached"])
+	assert.Equal(t, meta["asOf"], second["_meta"].(map[string]interface{})["asOf"])
+	assert.Equal(t, first["content"], second["content"])
+
+	refreshed := call(map[string]interface{}{"app": "demo", "force_refresh": true})
+	assert.Greater(t, atomic.LoadInt32(&requests), scanned)
+	assert.Equal(t, false, refreshed["_meta"].(map[string]interface{})["cached"])
+}
+
+func TestHandleToolCall_MutationsClearCachedScans(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind":"List","apiVersion":"v1","items":[]}`))
+	}))
+	defer srv.Close()
+
+	mgr, err := multicluster.NewClientManager(writeKubeconfig(t, map[string]string{"c1": srv.URL}))
+	require.NoError(t, err)
+	server := newServerWithManager(mgr)
+
+	call := func(name string, args map[string]interface{}) map[string]interface{} {
+		resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+			"name":      name,
+			"arguments": args,
+		})})
+		require.Nil(t, resp.Error)
+		return resp.Result.(map[string]interface{})
+	}
+
+	call("get_app_instances", map[string]interface{}{"app": "demo"})
+	call("scale_app", map[string]interface{}{"app": "demo", "clusters": []string{"c1"}, "replicas": 2, "dry_run": true})
+	assert.Equal(t, true, call("get_app_instances", map[string]interface{}{"app": "demo"})["_meta"].(map[string]interface{})["cached"],
+		"a dry run changes nothing, so the cache is kept")
+
+	call("scale_app", map[string]interface{}{"app": "demo", "clusters": []string{"c1"}, "replicas": 2})
+	assert.Equal(t, false, call("get_app_instances", map[string]interface{}{"app": "demo"})["_meta"].(map[string]interface{})["cached"],
+		"scans cached before a change must not be served after it")
+}
+
+func TestHandleListTools_AddsFreshnessArgsToCachedTools(t *testing.T) {
+	server := newHelmTestServer(t, map[string]string{})
+	resp := server.handleListTools(&MCPRequest{JSONRPC: "2.0", ID: 1})
+	tools := resp.Result.(map[string]interface{})["tools"].([]map[string]interface{})
+
+	for _, tool := range tools {
+		name := tool["name"].(string)
+		properties := tool["inputSchema"].(map[string]interface{})["properties"].(map[string]interface{})
+		_, cached := cachedToolTTLs[name]
+		assert.Equalf(t, cached, properties["allow_stale"] != nil, "allow_stale on %q", name)
+		assert.Equalf(t, cached, properties["force_refresh"] != nil, "force_refresh on %q", name)
+	}
+}
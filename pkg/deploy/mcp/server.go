@@ -6,10 +6,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
 	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
 	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/protocol"
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
 	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/notify"
+	"github.com/kubestellar/kubestellar-mcp/pkg/output"
+	"github.com/kubestellar/kubestellar-mcp/pkg/policy"
+	"github.com/kubestellar/kubestellar-mcp/pkg/provenance"
+	"github.com/kubestellar/kubestellar-mcp/pkg/redact"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	"github.com/kubestellar/kubestellar-mcp/pkg/toolerror"
 	"k8s.io/client-go/rest"
 )
 
@@ -33,6 +48,58 @@ type Server struct {
 	// newManifestSyncer is a factory for creating manifest syncers.
 	// Tests can override this to avoid talking to a real API server.
 	newManifestSyncer func(*rest.Config) (manifestSyncer, error)
+	// resolveDigest resolves an image to a digest for deploy_app's
+	// pin_digests. Tests can override this to avoid talking to registries.
+	resolveDigest func(ctx context.Context, image string) (string, error)
+	// outMu serializes writes to stdout so progress notifications emitted
+	// while a tool runs never interleave with responses.
+	outMu sync.Mutex
+	// namespacePolicy guards every cluster client built by manager, and is
+	// checked directly by tools that shell out to helm or kubectl.
+	namespacePolicy guardrail.NamespacePolicy
+	// resultCache holds results of the read-only tools in cachedToolTTLs.
+	resultCache     *cache.ResultCache
+	resultCacheOnce sync.Once
+	// approvalGate decides whether the tools in mutatingTools run or
+	// dry-run; see approval.go.
+	approvalGate     *approval.Gate
+	approvalGateOnce sync.Once
+	// provenanceSigner signs every tool result when
+	// $KUBESTELLAR_PROVENANCE_KEY is set; see provenance.go.
+	provenanceSigner *provenance.Signer
+	provenanceOnce   sync.Once
+	// fanoutMemory keeps the per-cluster status of recent calls for
+	// retry_failed; see cluster_status.go.
+	fanoutMemory     *fanout.Memory
+	fanoutMemoryOnce sync.Once
+	// policyEngine authorizes every tool call against the Rego policies in
+	// $KUBESTELLAR_POLICY; see policy.go.
+	policyEngine     *policy.Engine
+	policyEngineOnce sync.Once
+	// journal records the before-image of objects changed by the tools in
+	// mutatingTools; see tools_journal.go.
+	journal     *journal.Journal
+	journalOnce sync.Once
+	// rolloutMu guards rollout records and rolloutRuns, the rollouts this
+	// process is applying; see tools_rollout.go.
+	rolloutMu   sync.Mutex
+	rolloutRuns map[string]*rolloutRun
+	// stateStore backs approval plans, the change journal, rollouts,
+	// promotions, and scaling schedules.
+	stateStore     store.Store
+	stateStoreOnce sync.Once
+	// environments is the promotion pipeline from $KUBESTELLAR_ENVIRONMENTS;
+	// see tools_promote.go.
+	environments     []environment
+	environmentsErr  error
+	environmentsOnce sync.Once
+	// scalingMu serializes changes to scaling schedules between the tools
+	// and the scheduler; see tools_scaling.go.
+	scalingMu sync.Mutex
+	// notifier alerts on rollouts and scheduled scaling as they happen in
+	// the background, per $KUBESTELLAR_NOTIFICATIONS; see notify.go.
+	notifier     *notify.Notifier
+	notifierOnce sync.Once
 }
 
 // NewServer creates a new MCP server
@@ -41,6 +108,10 @@ func NewServer() (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client manager: %w", err)
 	}
+	namespacePolicy := guardrail.NamespacePolicyFromEnv()
+	manager.SetConfigTransform(func(config *rest.Config) *rest.Config {
+		return approval.Wrap(provenance.Wrap(journal.Wrap(namespacePolicy.Wrap(config))))
+	})
 
 	executor := multicluster.NewExecutor(manager)
 	selector := multicluster.NewSelector(executor)
@@ -49,6 +120,7 @@ func NewServer() (*Server, error) {
 		manager:           manager,
 		executor:          executor,
 		selector:          selector,
+		namespacePolicy:   namespacePolicy,
 		newManifestReader: gitops.NewManifestReader,
 		newManifestSyncer: func(config *rest.Config) (manifestSyncer, error) {
 			return gitops.NewSyncer(config)
@@ -88,6 +160,11 @@ func RunMCPServer() error {
 
 // Run starts the server loop
 func (s *Server) Run() error {
+	// Scaling schedules fire from this process while it serves requests.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.runScalingScheduler(ctx)
+
 	scanner := bufio.NewScanner(os.Stdin)
 	// Increase buffer size for large messages
 	buf := make([]byte, 0, 64*1024)
@@ -172,6 +249,14 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Namespace to search in (all namespaces if not specified)",
 					},
+					"page_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum clusters to scan in this call (all clusters if not specified). Use with continue to page through large fleets.",
+					},
+					"continue": map[string]interface{}{
+						"type":        "string",
+						"description": "Continue token returned by a previous call to fetch the next page of clusters",
+					},
 				},
 				"required": []string{"app"},
 			},
@@ -216,6 +301,44 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Only return logs newer than duration (e.g., 1h, 30m)",
 					},
+					"page_size": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum clusters to collect logs from in this call (all clusters if not specified). Use with continue to page through large fleets.",
+					},
+					"continue": map[string]interface{}{
+						"type":        "string",
+						"description": "Continue token returned by a previous call to fetch the next page of clusters",
+					},
+					"max_bytes": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum total bytes of log text to return across all clusters (default 4 MiB). The response sets truncated when the limit is hit.",
+					},
+					"max_bytes_per_container": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum bytes of log text to read from each container (default 256 KiB)",
+					},
+					"sample_every": map[string]interface{}{
+						"type":        "integer",
+						"description": "Keep only every Nth line from each container to thin out noisy logs (default 1, keep all)",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
+		{
+			"name":        "get_app_versions",
+			"description": "Report the container images (tags, running digests, and git SHA when recorded) an app runs on each cluster, and flag version skew: containers whose image differs across clusters, with the clusters running older images.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (all namespaces if not specified)",
+					},
 				},
 				"required": []string{"app"},
 			},
@@ -235,7 +358,7 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 		},
 		{
 			"name":        "find_clusters_for_workload",
-			"description": "Find clusters that can run a workload with specific requirements (GPU, memory, CPU, labels).",
+			"description": "Find clusters that can run a workload with specific requirements (GPU, memory, CPU, labels, tolerations, regions, zones) and rank them by unrequested CPU and memory headroom, share of schedulable nodes, cost, and spread away from clusters already running the app. Returns per-criterion scores and the reasons other clusters were excluded.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -259,12 +382,95 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "object",
 						"description": "Required node labels",
 					},
+					"tolerations": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "object"},
+						"description": "Tolerations of the workload, as in a pod spec ({key, operator, value, effect}). Nodes with NoSchedule or NoExecute taints they do not tolerate are not counted",
+					},
+					"regions": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Allowed regions (topology.kubernetes.io/region); only nodes in them are counted",
+					},
+					"zones": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Allowed zones (topology.kubernetes.io/zone); only nodes in them are counted",
+					},
+					"os": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"linux", "windows"},
+						"description": "Node OS the workload's images are built for (kubernetes.io/os); only nodes running it are counted",
+					},
+					"arch": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "CPU architectures the workload's images are built for (kubernetes.io/arch), e.g. [\"amd64\", \"arm64\"]; only nodes with one of them are counted",
+					},
+					"cluster_costs": map[string]interface{}{
+						"type":        "object",
+						"description": "Relative cost weight per cluster name, e.g. {\"on-prem\": 1, \"aws-east\": 2.5}; unlisted clusters cost 1",
+					},
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App whose clusters are scored lower, to spread it across the fleet",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace of app (default: search all namespaces)",
+					},
+					"weights": map[string]interface{}{
+						"type":        "object",
+						"description": "Weight of each criterion (headroom, schedulable, cost, spread) in the total score (default: 1 each)",
+					},
+				},
+			},
+		},
+		{
+			"name":        "list_kueue_queues",
+			"description": "List Kueue ClusterQueues and LocalQueues per cluster: whether each is active, its pending and admitted workloads, and for ClusterQueues the nominal quota, borrowing limit, and usage of every resource flavor (e.g. GPUs). Use this to see why batch and training jobs queue on a cluster.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Specific cluster (all clusters if not specified)",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list LocalQueues in this namespace",
+					},
+				},
+			},
+		},
+		{
+			"name":        "list_pending_workloads",
+			"description": "List Kueue Workloads waiting for admission per cluster, longest waiting first, with the job they belong to, their queue, total resource requests, the ClusterQueue quota for those resources, and why Kueue has not admitted them (insufficient quota, inactive or missing queue, pending admission checks, eviction). Use this to explain why a training job is queued rather than running on the cluster find_clusters_for_workload selected.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Specific cluster (all clusters if not specified)",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (all namespaces if not specified)",
+					},
+					"queue": map[string]interface{}{
+						"type":        "string",
+						"description": "Only workloads submitted to this LocalQueue",
+					},
+					"job": map[string]interface{}{
+						"type":        "string",
+						"description": "Only the workload of this job (the name of the Job, JobSet, RayJob, etc., or of the Workload)",
+					},
 				},
 			},
 		},
 		{
 			"name":        "deploy_app",
-			"description": "Deploy an app to clusters. Can specify clusters explicitly or let kubestellar find matching clusters based on requirements.",
+			"description": "Deploy an app to clusters. Can specify clusters explicitly or let kubestellar find matching clusters based on requirements. Returns platformWarnings for workloads that lack a kubernetes.io/os or kubernetes.io/arch node selector on clusters with mixed Windows/Linux or amd64/arm64 nodes, or that no usable node matches. Namespaces and CustomResourceDefinitions are applied before the resources that need them.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -289,13 +495,112 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "boolean",
 						"description": "Preview changes without applying",
 					},
+					"preflight": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Before applying anywhere, send the manifest to every target cluster as a server-side dry run, so admission webhooks (OPA Gatekeeper, Kyverno), Pod Security, quotas, and validation judge it. If any cluster would reject it, nothing is applied and each rejection is reported per cluster. With dry_run, returns the dry-run results of every cluster",
+					},
+					"atomic": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Roll back the change on every cluster if the apply fails on any of them or its workloads are not ready within timeout_seconds",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long an atomic deploy waits for Deployments, StatefulSets, and DaemonSets, or wait for those and Jobs, to become ready (default 300)",
+					},
+					"wait": map[string]interface{}{
+						"type":        "boolean",
+						"description": "After applying, wait up to timeout_seconds for the manifest's Deployments, StatefulSets, DaemonSets, and Jobs to become ready on each cluster, and return their health",
+					},
+					"create_namespaces": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Create the namespaces of the manifest's resources that do not exist yet",
+					},
+					"image_overrides": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Change images before applying, without editing the manifest: maps an image name as written in the manifest, without tag or digest (e.g. \"ghcr.io/acme/web\"), to a new tag (\"1234\") or digest (\"sha256:...\")",
+					},
+					"pin_digests": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Resolve every image tag to its digest in the registry at deploy time and deploy image:tag@digest. Works for public images; the deploy fails if an image cannot be resolved",
+					},
+					"template": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Substitute ${NAME} references in the manifest's string values for each cluster. Built-ins: ${CLUSTER_NAME}, and ${REGION} from the cluster's topology.kubernetes.io/region node label. Write $${ for a literal ${. Implied by variables and cluster_variables",
+					},
+					"variables": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Variables substituted on every cluster, e.g. {\"DOMAIN\": \"example.com\"}. An undefined variable fails the call before any cluster is changed",
+					},
+					"cluster_variables": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+						"description":          "Per-cluster variables, overriding variables and the built-ins, e.g. {\"prod-east\": {\"STORAGE_CLASS\": \"gp3\"}}",
+					},
+					"apply_set": map[string]interface{}{
+						"type":        "string",
+						"description": "Track the applied objects as an apply set, named \"name\" or \"namespace/name\" (default namespace: default). A ConfigMap of that name records the set's kinds and namespaces",
+					},
+					"prune": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Delete the members of apply_set that are no longer in the manifest, once the manifest applied without failures",
+					},
+					"apply_mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"update", "ssa"},
+						"description": "How objects are written. update (default) creates missing objects and server-side applies existing ones, taking over every field they set. ssa server-side applies every object as field_manager, leaving fields set by other controllers alone, and reports the fields another manager owns as a conflict unless force is set",
+					},
+					"field_manager": map[string]interface{}{
+						"type":        "string",
+						"description": "Field manager of an ssa apply, recorded in managedFields as the owner of the fields the manifest sets (default kubestellar-deploy)",
+					},
+					"force": map[string]interface{}{
+						"type":        "boolean",
+						"description": "With apply_mode ssa, take ownership of fields other field managers own instead of reporting them as a conflict",
+					},
+					"rollout_strategy": map[string]interface{}{
+						"type":        "object",
+						"description": "Deploy in stages instead of to every cluster at once: a canary batch first, then the remaining clusters in batches, each gated on workload health. The rollout runs in the background; follow it with get_rollout",
+						"properties": map[string]interface{}{
+							"canary_clusters": map[string]interface{}{
+								"type":        "array",
+								"items":       map[string]interface{}{"type": "string"},
+								"description": "Clusters to deploy first (default: the first canary_count target clusters by name)",
+							},
+							"canary_count": map[string]interface{}{
+								"type":        "integer",
+								"description": "Number of canary clusters when canary_clusters is not set (default: 1)",
+							},
+							"batch_size": map[string]interface{}{
+								"type":        "integer",
+								"description": "Clusters per batch after the canary (default: all remaining clusters in one batch)",
+							},
+							"health_timeout_seconds": map[string]interface{}{
+								"type":        "integer",
+								"description": "How long each batch's Deployments, StatefulSets, and DaemonSets may take to become ready (default 300)",
+							},
+							"max_restarts": map[string]interface{}{
+								"type":        "integer",
+								"description": "Fail a batch if any container of its workloads restarted more than this many times",
+							},
+							"pause_after_canary": map[string]interface{}{
+								"type":        "boolean",
+								"description": "Pause after the canary batch until resume_rollout is called",
+							},
+							"pause_between_batches": map[string]interface{}{
+								"type":        "boolean",
+								"description": "Pause after every batch until resume_rollout is called",
+							},
+						},
+					},
 				},
 				"required": []string{"manifest"},
 			},
 		},
 		{
 			"name":        "scale_app",
-			"description": "Scale an app across clusters. Can target specific clusters or all clusters where app runs.",
+			"description": "Scale an app's Deployment or StatefulSet across clusters. Can target specific clusters or all clusters where app runs. Without namespace, every namespace is searched; if several workloads match, set namespace or kind.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -305,7 +610,12 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 					},
 					"namespace": map[string]interface{}{
 						"type":        "string",
-						"description": "Namespace",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"Deployment", "StatefulSet"},
+						"description": "Workload kind, to choose between matches",
 					},
 					"replicas": map[string]interface{}{
 						"type":        "integer",
@@ -316,13 +626,65 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "Target clusters (all clusters where app runs if not specified)",
 					},
+					"override": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Scale even if another controller manages the workload: a HorizontalPodAutoscaler that targets it, Argo CD or Flux that applied it, or an owning controller. Without override such clusters fail, because the other controller would undo the change",
+					},
 				},
 				"required": []string{"app", "replicas"},
 			},
 		},
+		{
+			"name":        "rolling_restart_fleet",
+			"description": "Restart an app's pods (as kubectl rollout restart does) cluster by cluster, so a fleet-wide restart never takes the app down everywhere at once. Clusters are restarted in waves of at most max_unavailable_clusters, and each wave must be ready again before the next starts; a wave that does not become ready stops the restart. Clusters where the app is already unhealthy are not restarted and count against max_unavailable_clusters, and clusters where a PodDisruptionBudget covering the app allows no disruption are skipped.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"Deployment", "StatefulSet", "DaemonSet"},
+						"description": "Workload kind, to choose between matches",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to restart, in name order (default: every cluster running the app)",
+					},
+					"environment": map[string]interface{}{
+						"type":        "string",
+						"description": "Restart the clusters of this environment from $KUBESTELLAR_ENVIRONMENTS instead of clusters",
+					},
+					"max_unavailable_clusters": map[string]interface{}{
+						"type":        "integer",
+						"description": "Most clusters where the app may be restarting or unhealthy at once (default: 1)",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long each wave may take to become ready (default: 300)",
+					},
+					"ignore_pdbs": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Restart clusters even where a PodDisruptionBudget allows no disruption",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report the waves and skipped clusters without restarting anything",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
 		{
 			"name":        "patch_app",
-			"description": "Apply a patch to an app across clusters.",
+			"description": "Apply a patch to an app's Deployment, StatefulSet, or DaemonSet across clusters. Without namespace, every namespace is searched; if several workloads match, set namespace or kind.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -332,7 +694,12 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 					},
 					"namespace": map[string]interface{}{
 						"type":        "string",
-						"description": "Namespace",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"Deployment", "StatefulSet", "DaemonSet"},
+						"description": "Workload kind, to choose between matches",
 					},
 					"patch": map[string]interface{}{
 						"type":        "string",
@@ -347,14 +714,116 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "Target clusters",
 					},
+					"override": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Patch even if another controller manages the workload: Argo CD or Flux that applied it, an owning controller, or, for a patch that sets replicas, a HorizontalPodAutoscaler. Without override such clusters fail, because the other controller would undo the change",
+					},
 				},
 				"required": []string{"app", "patch"},
 			},
 		},
+		// StatefulSet tools
+		{
+			"name":        "get_statefulset_status",
+			"description": "Show an app's StatefulSet per cluster: update strategy and partition, the revision each pod runs, and the claims created from its volume claim templates with their retention policy, including claims orphaned by scale-downs and whether deleting the StatefulSet deletes its data.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to inspect (all clusters where app runs if not specified)",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
+		{
+			"name":        "set_statefulset_partition",
+			"description": "Set the rolling update partition of an app's StatefulSet across clusters, optionally with new images, for a partitioned canary: only pods with an ordinal at or above the partition are updated. Start a canary with partition = replicas - 1 and the new image, lower the partition to widen it, and set 0 to finish the rollout.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters where app runs if not specified)",
+					},
+					"partition": map[string]interface{}{
+						"type":        "integer",
+						"description": "Lowest ordinal to update; pods below it keep the current revision",
+					},
+					"image": map[string]interface{}{
+						"type":        "string",
+						"description": "New image for a StatefulSet with one container",
+					},
+					"images": map[string]interface{}{
+						"type":        "object",
+						"description": "New images by container name",
+					},
+					"override": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Change the StatefulSet even if Argo CD, Flux, or an owning controller manages it and would revert the change",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the change without applying it",
+					},
+				},
+				"required": []string{"app", "partition"},
+			},
+		},
+		{
+			"name":        "restart_statefulset",
+			"description": "Restart the pods of an app's StatefulSet one at a time, highest ordinal first, one cluster at a time. Pods are evicted, so PodDisruptionBudgets are honored, and each must be recreated and ready before the next; the first failure stops the restart. Also rolls StatefulSets with the OnDelete update strategy onto their latest template.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to restart, in name order (all clusters where app runs if not specified)",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long each pod may take to be ready again (default: 300)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report the restart order without restarting anything",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
 		// GitOps Tools
 		{
 			"name":        "detect_drift",
-			"description": "Detect drift between git manifests and cluster state. Shows which resources differ between git and what's deployed.",
+			"description": "Detect drift between manifests in git, a local path, or an uploaded archive and cluster state. Shows which resources differ between git and what's deployed.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -362,13 +831,21 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Git repository URL (e.g., https://github.com/org/manifests)",
 					},
+					"local_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Local directory, manifest file, or .tar.gz of manifests to use instead of a git repository, e.g. a working tree before it is pushed. Must be within $KUBESTELLAR_MANIFEST_ROOTS (default: the server's working directory)",
+					},
+					"archive": map[string]interface{}{
+						"type":        "string",
+						"description": "Base64-encoded .tar.gz of manifests to use instead of a git repository",
+					},
 					"path": map[string]interface{}{
 						"type":        "string",
-						"description": "Path within repo to manifests (e.g., production/)",
+						"description": "Path to the manifests within the repo, local directory, or archive (e.g., production/)",
 					},
 					"branch": map[string]interface{}{
 						"type":        "string",
-						"description": "Git branch (default: main)",
+						"description": "Git branch, tag, or full commit SHA (default: main)",
 					},
 					"clusters": map[string]interface{}{
 						"type":        "array",
@@ -376,12 +853,11 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"description": "Target clusters (all clusters if not specified)",
 					},
 				},
-				"required": []string{"repo"},
 			},
 		},
 		{
 			"name":        "sync_from_git",
-			"description": "Sync manifests from a git repository to clusters. Applies all manifests found in the specified path.",
+			"description": "Sync manifests from a git repository, a local path, or an uploaded archive to clusters. Applies all manifests found in the specified path.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -389,13 +865,21 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Git repository URL",
 					},
+					"local_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Local directory, manifest file, or .tar.gz of manifests to use instead of a git repository, e.g. a working tree before it is pushed. Must be within $KUBESTELLAR_MANIFEST_ROOTS (default: the server's working directory)",
+					},
+					"archive": map[string]interface{}{
+						"type":        "string",
+						"description": "Base64-encoded .tar.gz of manifests to use instead of a git repository",
+					},
 					"path": map[string]interface{}{
 						"type":        "string",
-						"description": "Path within repo to manifests",
+						"description": "Path to the manifests within the repo, local directory, or archive",
 					},
 					"branch": map[string]interface{}{
 						"type":        "string",
-						"description": "Git branch (default: main)",
+						"description": "Git branch, tag, or full commit SHA (default: main)",
 					},
 					"clusters": map[string]interface{}{
 						"type":        "array",
@@ -410,8 +894,21 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Override namespace for all resources",
 					},
+					"template": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Substitute ${NAME} references in the manifest's string values for each cluster. Built-ins: ${CLUSTER_NAME}, and ${REGION} from the cluster's topology.kubernetes.io/region node label. Write $${ for a literal ${. Implied by variables and cluster_variables",
+					},
+					"variables": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Variables substituted on every cluster, e.g. {\"DOMAIN\": \"example.com\"}. An undefined variable fails the call before any cluster is changed",
+					},
+					"cluster_variables": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+						"description":          "Per-cluster variables, overriding variables and the built-ins, e.g. {\"prod-east\": {\"STORAGE_CLASS\": \"gp3\"}}",
+					},
 				},
-				"required": []string{"repo"},
 			},
 		},
 		{
@@ -424,13 +921,21 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Git repository URL",
 					},
+					"local_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Local directory, manifest file, or .tar.gz of manifests to use instead of a git repository, e.g. a working tree before it is pushed. Must be within $KUBESTELLAR_MANIFEST_ROOTS (default: the server's working directory)",
+					},
+					"archive": map[string]interface{}{
+						"type":        "string",
+						"description": "Base64-encoded .tar.gz of manifests to use instead of a git repository",
+					},
 					"path": map[string]interface{}{
 						"type":        "string",
-						"description": "Path within repo to manifests",
+						"description": "Path to the manifests within the repo, local directory, or archive",
 					},
 					"branch": map[string]interface{}{
 						"type":        "string",
-						"description": "Git branch (default: main)",
+						"description": "Git branch, tag, or full commit SHA (default: main)",
 					},
 					"clusters": map[string]interface{}{
 						"type":        "array",
@@ -438,12 +943,11 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"description": "Target clusters (all clusters if not specified)",
 					},
 				},
-				"required": []string{"repo"},
 			},
 		},
 		{
 			"name":        "preview_changes",
-			"description": "Preview what would change if manifests were synced from git. Dry-run mode.",
+			"description": "Preview what would change if manifests were synced from git, a local path, or an uploaded archive. Dry-run mode.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -451,13 +955,21 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"type":        "string",
 						"description": "Git repository URL",
 					},
+					"local_path": map[string]interface{}{
+						"type":        "string",
+						"description": "Local directory, manifest file, or .tar.gz of manifests to use instead of a git repository, e.g. a working tree before it is pushed. Must be within $KUBESTELLAR_MANIFEST_ROOTS (default: the server's working directory)",
+					},
+					"archive": map[string]interface{}{
+						"type":        "string",
+						"description": "Base64-encoded .tar.gz of manifests to use instead of a git repository",
+					},
 					"path": map[string]interface{}{
 						"type":        "string",
-						"description": "Path within repo to manifests",
+						"description": "Path to the manifests within the repo, local directory, or archive",
 					},
 					"branch": map[string]interface{}{
 						"type":        "string",
-						"description": "Git branch (default: main)",
+						"description": "Git branch, tag, or full commit SHA (default: main)",
 					},
 					"clusters": map[string]interface{}{
 						"type":        "array",
@@ -465,13 +977,47 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"description": "Target clusters (all clusters if not specified)",
 					},
 				},
-				"required": []string{"repo"},
 			},
 		},
-		// Helm Tools
 		{
-			"name":        "helm_install",
-			"description": "Install or upgrade a Helm chart to clusters. Supports values overrides and targeting specific clusters.",
+			"name":        "compare_git_refs",
+			"description": "Compare the manifests at two git refs, such as main and a pull request's branch, and dry-run the changed ones on each cluster to show which resources would be created, updated, or rejected if the head ref were merged and synced.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"repo": map[string]interface{}{
+						"type":        "string",
+						"description": "Git repository URL",
+					},
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the manifests within the repo",
+					},
+					"base": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch, tag, or full commit SHA to merge into (default: main)",
+					},
+					"head": map[string]interface{}{
+						"type":        "string",
+						"description": "Branch, tag, or full commit SHA whose changes to preview, e.g. a pull request's branch",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters if not specified)",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Override namespace for all resources, as in sync_from_git",
+					},
+				},
+				"required": []string{"repo", "head"},
+			},
+		},
+		// Helm Tools
+		{
+			"name":        "helm_install",
+			"description": "Install or upgrade a Helm chart to clusters. Supports values overrides and targeting specific clusters.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -643,7 +1189,7 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 		// Generic kubectl apply
 		{
 			"name":        "kubectl_apply",
-			"description": "Apply any Kubernetes manifest to clusters. Supports all resource types using dynamic client.",
+			"description": "Apply any Kubernetes manifest to clusters. Supports all resource types using dynamic client. Namespaces are applied first, then CustomResourceDefinitions, then everything else once those CRDs are established.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -660,10 +1206,209 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "Target clusters (all clusters if not specified)",
 					},
+					"create_namespaces": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Create the namespaces of the manifest's resources that do not exist yet",
+					},
+					"wait": map[string]interface{}{
+						"type":        "boolean",
+						"description": "After applying, wait up to timeout_seconds for the manifest's Deployments, StatefulSets, DaemonSets, and Jobs to become ready on each cluster, and return their health",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long wait waits for the workloads (default 300)",
+					},
+					"apply_mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"update", "ssa"},
+						"description": "How objects are written. update (default) creates missing objects and replaces existing ones, dropping fields set by other controllers. ssa server-side applies every object as field_manager, leaving those fields alone, and reports the fields another manager owns as a conflict unless force is set",
+					},
+					"field_manager": map[string]interface{}{
+						"type":        "string",
+						"description": "Field manager of an ssa apply, recorded in managedFields as the owner of the fields the manifest sets (default kubestellar-deploy)",
+					},
+					"force": map[string]interface{}{
+						"type":        "boolean",
+						"description": "With apply_mode ssa, take ownership of fields other field managers own instead of reporting them as a conflict",
+					},
 				},
 				"required": []string{"manifest"},
 			},
 		},
+		{
+			"name":        "export_resource",
+			"description": "Export live objects as clean YAML to commit to a GitOps repo: status, managedFields, uid, creationTimestamp, cluster-assigned fields, and fields left at their API defaults are removed. The reverse of sync_from_git. Secrets are not exported.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"description": "Resource kind, e.g. Deployment, or Kind.group for custom resources, e.g. Rollout.argoproj.io",
+					},
+					"api_version": map[string]interface{}{
+						"type":        "string",
+						"description": "API version of the kind, e.g. apps/v1 (default: the cluster's preferred version)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Object name (all objects of the kind if not specified)",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: default for a named object, all namespaces otherwise; ignored for cluster-scoped kinds)",
+					},
+					"label_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Label selector for the objects to export when no name is given, e.g. app=web",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to export from (all clusters if not specified)",
+					},
+				},
+				"required": []string{"kind"},
+			},
+		},
+		{
+			"name":        "dump_namespace",
+			"description": "Dump every resource of a namespace from each cluster as clean YAML into one base64 .tar.gz, for a backup or to compare environments. Reports the resources missing from or differing between clusters. Secrets are skipped, or encrypted with the server's KUBESTELLAR_DUMP_PASSPHRASE.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace to dump",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to dump from (all clusters if not specified)",
+					},
+					"kinds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Kinds to dump, e.g. [\"Deployment\", \"ConfigMap\"] (default: all kinds clone_namespace copies)",
+					},
+					"label_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Only dump objects matching this label selector, e.g. app=web",
+					},
+					"secret_mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"skip", "encrypt"},
+						"description": "skip (default) leaves Secrets out; encrypt adds them encrypted for openssl enc -d -aes-256-cbc -pbkdf2 -iter 100000 -md sha256",
+					},
+				},
+				"required": []string{"namespace"},
+			},
+		},
+		// Configuration baseline tools
+		{
+			"name":        "capture_baseline",
+			"description": "Capture the configuration of a reference cluster as a named baseline: the clean manifests of the selected kinds in the selected namespaces, kept in the state store. audit_baseline then reports how other clusters deviate from it, unlike detect_drift, whose reference is git. Secrets and controller-owned objects are left out. Calling it again with the same name replaces the baseline.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Baseline name, e.g. payments-golden",
+					},
+					"description": map[string]interface{}{
+						"type":        "string",
+						"description": "What the baseline is for",
+					},
+					"cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Reference cluster to capture from",
+					},
+					"namespaces": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Namespaces to capture",
+					},
+					"kinds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Kinds to capture, e.g. [\"Deployment\", \"ConfigMap\"] (default: all kinds clone_namespace copies)",
+					},
+					"label_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Only capture objects matching this label selector, e.g. app=web",
+					},
+					"allow": map[string]interface{}{
+						"type":        "array",
+						"description": "Expected differences, which audits report apart from deviations",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"resource": map[string]interface{}{"type": "string", "description": "Kind namespace/name, with * wildcards, e.g. \"Deployment */web\" or \"ConfigMap payments/*\""},
+								"field":    map[string]interface{}{"type": "string", "description": "Dotted path of the field that may differ, e.g. spec.replicas (default: the whole resource, which may also be missing or extra)"},
+								"clusters": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Only allow the difference on these clusters"},
+								"reason":   map[string]interface{}{"type": "string", "description": "Why the difference is expected"},
+							},
+							"required": []string{"resource"},
+						},
+					},
+				},
+				"required": []string{"name", "cluster", "namespaces"},
+			},
+		},
+		{
+			"name":        "audit_baseline",
+			"description": "Audit clusters against a baseline from capture_baseline. For each cluster, reports the resources missing from it, the extra ones in the baseline's scope, and the changed fields with their expected and actual values. Differences the baseline's or the call's allow entries expect are reported separately and do not fail the audit.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Baseline name",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to audit (default: every cluster but the reference)",
+					},
+					"allow": map[string]interface{}{
+						"type":        "array",
+						"description": "Expected differences for this audit, in addition to those of the baseline",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"resource": map[string]interface{}{"type": "string", "description": "Kind namespace/name, with * wildcards, e.g. \"Deployment */web\" or \"ConfigMap payments/*\""},
+								"field":    map[string]interface{}{"type": "string", "description": "Dotted path of the field that may differ, e.g. spec.replicas (default: the whole resource, which may also be missing or extra)"},
+								"clusters": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Only allow the difference on these clusters"},
+								"reason":   map[string]interface{}{"type": "string", "description": "Why the difference is expected"},
+							},
+							"required": []string{"resource"},
+						},
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
+		{
+			"name":        "list_baselines",
+			"description": "List the baselines captured with capture_baseline, with their reference cluster, scope, allowed differences, and resource count.",
+			"inputSchema": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		{
+			"name":        "delete_baseline",
+			"description": "Delete a baseline captured with capture_baseline.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Baseline name",
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
 		// Kustomize Tools
 		{
 			"name":        "kustomize_build",
@@ -728,7 +1473,7 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 		// Label Tools
 		{
 			"name":        "add_labels",
-			"description": "Add labels to a Kubernetes resource across clusters.",
+			"description": "Add labels to a Kubernetes resource across clusters, or to every resource of a kind matching a label or field selector. With a selector, matching objects in all target clusters are listed first and the call is refused if more than max_objects would change; dry_run returns that preview.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -738,11 +1483,11 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 					},
 					"name": map[string]interface{}{
 						"type":        "string",
-						"description": "Resource name",
+						"description": "Resource name (omit to use selector or field_selector)",
 					},
 					"namespace": map[string]interface{}{
 						"type":        "string",
-						"description": "Namespace (default: default, ignored for cluster-scoped)",
+						"description": "Namespace (default: default, ignored for cluster-scoped; with a selector, all non-system namespaces)",
 					},
 					"labels": map[string]interface{}{
 						"type":        "object",
@@ -757,13 +1502,29 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "Target clusters (all clusters if not specified)",
 					},
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Instead of name, act on every object of kind matching this label selector (e.g. app=checkout,tier!=cache)",
+					},
+					"field_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Instead of name, or with selector, act on every object of kind matching this field selector (e.g. metadata.name!=legacy)",
+					},
+					"max_objects": map[string]interface{}{
+						"type":        "integer",
+						"description": "With a selector, refuse if more than this many objects would change across all clusters (default: 50, max: 1000)",
+					},
+					"environment": map[string]interface{}{
+						"type":        "string",
+						"description": "Target the clusters of this environment in $KUBESTELLAR_ENVIRONMENTS instead of clusters",
+					},
 				},
-				"required": []string{"kind", "name", "labels"},
+				"required": []string{"kind", "labels"},
 			},
 		},
 		{
 			"name":        "remove_labels",
-			"description": "Remove labels from a Kubernetes resource across clusters.",
+			"description": "Remove labels from a Kubernetes resource across clusters, or from every resource of a kind matching a label or field selector. With a selector, matching objects in all target clusters are listed first and the call is refused if more than max_objects would change; dry_run returns that preview.",
 			"inputSchema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -773,11 +1534,11 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 					},
 					"name": map[string]interface{}{
 						"type":        "string",
-						"description": "Resource name",
+						"description": "Resource name (omit to use selector or field_selector)",
 					},
 					"namespace": map[string]interface{}{
 						"type":        "string",
-						"description": "Namespace (default: default, ignored for cluster-scoped)",
+						"description": "Namespace (default: default, ignored for cluster-scoped; with a selector, all non-system namespaces)",
 					},
 					"labels": map[string]interface{}{
 						"type":        "array",
@@ -793,136 +1554,1438 @@ func (s *Server) handleListTools(req *MCPRequest) *MCPResponse {
 						"items":       map[string]interface{}{"type": "string"},
 						"description": "Target clusters (all clusters if not specified)",
 					},
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Instead of name, act on every object of kind matching this label selector (e.g. app=checkout,tier!=cache)",
+					},
+					"field_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Instead of name, or with selector, act on every object of kind matching this field selector (e.g. metadata.name!=legacy)",
+					},
+					"max_objects": map[string]interface{}{
+						"type":        "integer",
+						"description": "With a selector, refuse if more than this many objects would change across all clusters (default: 50, max: 1000)",
+					},
+					"environment": map[string]interface{}{
+						"type":        "string",
+						"description": "Target the clusters of this environment in $KUBESTELLAR_ENVIRONMENTS instead of clusters",
+					},
 				},
-				"required": []string{"kind", "name", "labels"},
+				"required": []string{"kind", "labels"},
 			},
 		},
-	}
-
-	return &MCPResponse{
-		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result: map[string]interface{}{
-			"tools": tools,
-		},
-	}
-}
-
-// handleToolCall dispatches tool calls to handlers
-func (s *Server) handleToolCall(ctx context.Context, req *MCPRequest) *MCPResponse {
-	var params struct {
-		Name      string          `json:"name"`
-		Arguments json.RawMessage `json:"arguments"`
-	}
-	if err := json.Unmarshal(req.Params, &params); err != nil {
-		return &MCPResponse{
-			JSONRPC: "2.0",
-			ID:      req.ID,
-			Error:   &MCPError{Code: -32602, Message: "Invalid params"},
-		}
-	}
-
-	var result interface{}
-	var err error
-
-	switch params.Name {
-	case "get_app_instances":
-		result, err = s.handleGetAppInstances(ctx, params.Arguments)
-	case "get_app_status":
-		result, err = s.handleGetAppStatus(ctx, params.Arguments)
-	case "get_app_logs":
-		result, err = s.handleGetAppLogs(ctx, params.Arguments)
-	case "list_cluster_capabilities":
-		result, err = s.handleListClusterCapabilities(ctx, params.Arguments)
-	case "find_clusters_for_workload":
-		result, err = s.handleFindClustersForWorkload(ctx, params.Arguments)
-	case "deploy_app":
-		result, err = s.handleDeployApp(ctx, params.Arguments)
-	case "scale_app":
-		result, err = s.handleScaleApp(ctx, params.Arguments)
-	case "patch_app":
-		result, err = s.handlePatchApp(ctx, params.Arguments)
-	// GitOps tools
-	case "detect_drift":
-		result, err = s.handleDetectDrift(ctx, params.Arguments)
-	case "sync_from_git":
-		result, err = s.handleSyncFromGit(ctx, params.Arguments)
-	case "reconcile":
-		result, err = s.handleReconcile(ctx, params.Arguments)
-	case "preview_changes":
-		result, err = s.handlePreviewChanges(ctx, params.Arguments)
-	// Helm tools
-	case "helm_install":
-		result, err = s.handleHelmInstall(ctx, params.Arguments)
-	case "helm_uninstall":
-		result, err = s.handleHelmUninstall(ctx, params.Arguments)
-	case "helm_list":
-		result, err = s.handleHelmList(ctx, params.Arguments)
-	case "helm_rollback":
-		result, err = s.handleHelmRollback(ctx, params.Arguments)
-	// Delete and kubectl tools
-	case "delete_resource":
-		result, err = s.handleDeleteResource(ctx, params.Arguments)
-	case "kubectl_apply":
-		result, err = s.handleKubectlApply(ctx, params.Arguments)
-	// Kustomize tools
-	case "kustomize_build":
-		result, err = s.handleKustomizeBuild(ctx, params.Arguments)
-	case "kustomize_apply":
-		result, err = s.handleKustomizeApply(ctx, params.Arguments)
-	case "kustomize_delete":
-		result, err = s.handleKustomizeDelete(ctx, params.Arguments)
-	// Label tools
-	case "add_labels":
-		result, err = s.handleAddLabels(ctx, params.Arguments)
-	case "remove_labels":
-		result, err = s.handleRemoveLabels(ctx, params.Arguments)
-	default:
-		return &MCPResponse{
-			JSONRPC: "2.0",
-			ID:      req.ID,
-			Error:   &MCPError{Code: -32601, Message: fmt.Sprintf("Unknown tool: %s", params.Name)},
-		}
-	}
-
-	if err != nil {
-		return &MCPResponse{
-			JSONRPC: "2.0",
-			ID:      req.ID,
-			Result: map[string]interface{}{
-				"content": []map[string]interface{}{
-					{
-						"type": "text",
-						"text": fmt.Sprintf("Error: %v", err),
+		{
+			"name":        "taint_nodes",
+			"description": "Add taints to nodes across clusters, selected by name or by label or field selector, or change the value of a taint with the same key and effect. Matching nodes in all target clusters are listed first and the call is refused if more than max_objects would change; dry_run returns that preview. NoExecute taints evict the pods that do not tolerate them. Label nodes, e.g. with GPU or zone labels for find_clusters_for_workload, with add_labels and kind Node.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"taints": map[string]interface{}{
+						"type":        "array",
+						"description": "Taints to add",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"key":    map[string]interface{}{"type": "string"},
+								"value":  map[string]interface{}{"type": "string"},
+								"effect": map[string]interface{}{"type": "string", "enum": []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}},
+							},
+							"required": []string{"key", "effect"},
+						},
+					},
+					"nodes": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Names of the nodes to change (or use selector or field_selector)",
 					},
-				},
-				"isError": true,
-			},
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Act on every node matching this label selector, e.g. nvidia.com/gpu.present=true",
+					},
+					"field_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Act on every node matching this field selector, e.g. spec.unschedulable=false",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters if not specified)",
+					},
+					"environment": map[string]interface{}{
+						"type":        "string",
+						"description": "Target the clusters of this environment in $KUBESTELLAR_ENVIRONMENTS instead of clusters",
+					},
+					"max_objects": map[string]interface{}{
+						"type":        "integer",
+						"description": "Refuse if more than this many nodes would change across all clusters (default: 50, max: 1000)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Preview changes without applying",
+					},
+				},
+				"required": []string{"taints"},
+			},
+		},
+		{
+			"name":        "untaint_nodes",
+			"description": "Remove taints from nodes across clusters, selected by name or by label or field selector. Matching nodes in all target clusters are listed first and the call is refused if more than max_objects would change; dry_run returns that preview.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"taints": map[string]interface{}{
+						"type":        "array",
+						"description": "Taints to remove, by key and, if given, effect (all effects otherwise)",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"key":    map[string]interface{}{"type": "string"},
+								"effect": map[string]interface{}{"type": "string", "enum": []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}},
+							},
+							"required": []string{"key"},
+						},
+					},
+					"nodes": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Names of the nodes to change (or use selector or field_selector)",
+					},
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Act on every node matching this label selector, e.g. nvidia.com/gpu.present=true",
+					},
+					"field_selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Act on every node matching this field selector, e.g. spec.unschedulable=false",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters if not specified)",
+					},
+					"environment": map[string]interface{}{
+						"type":        "string",
+						"description": "Target the clusters of this environment in $KUBESTELLAR_ENVIRONMENTS instead of clusters",
+					},
+					"max_objects": map[string]interface{}{
+						"type":        "integer",
+						"description": "Refuse if more than this many nodes would change across all clusters (default: 50, max: 1000)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Preview changes without applying",
+					},
+				},
+				"required": []string{"taints"},
+			},
+		},
+		{
+			"name":        "rebalance_pods",
+			"description": "Find hot-spotted nodes, whose CPU or memory use is at or above hot_percent of allocatable, and evict pods from them for the scheduler to place on cooler nodes. Uses metrics-server when installed and pod requests otherwise. Evictions go through the Eviction API, so PodDisruptionBudgets are respected; only pods a controller recreates are moved, never DaemonSet, static, critical, or local-storage pods. Nothing is evicted in a cluster with no schedulable node below cool_percent. Also returns a descheduler policy with the same thresholds, to keep clusters balanced on their own.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters if not specified)",
+					},
+					"environment": map[string]interface{}{
+						"type":        "string",
+						"description": "Target the clusters of this environment in $KUBESTELLAR_ENVIRONMENTS instead of clusters",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Only evict pods of this namespace",
+					},
+					"hot_percent": map[string]interface{}{
+						"type":        "number",
+						"description": "A node is hot at or above this percent of its allocatable CPU or memory (default: 80)",
+					},
+					"cool_percent": map[string]interface{}{
+						"type":        "number",
+						"description": "A schedulable node below this percent of both can take evicted pods (default: 50)",
+					},
+					"max_evictions": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum pods to evict per cluster (default: 5, max: 100)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report node utilization and the pods that would be evicted without evicting them",
+					},
+				},
+			},
+		},
+		// Change journal tools
+		{
+			"name":        "list_changes",
+			"description": "List recent changes made by this server's tools, newest first, with the objects each one touched. Use the change ID with undo_change.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of changes to return (default: 20)",
+					},
+					"tool": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list changes made by this tool",
+					},
+				},
+			},
+		},
+		{
+			"name":        "undo_change",
+			"description": "Revert a change made by this server: objects it created are deleted, and objects it updated or deleted are restored to their previous state. The undo is itself journaled.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"change_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Change ID from list_changes or a tool result's _meta.changeId",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Preview the revert without applying",
+					},
+				},
+				"required": []string{"change_id"},
+			},
+		},
+		// Rollout tools
+		{
+			"name":        "get_rollout",
+			"description": "Show the state of a staged rollout started by deploy_app with rollout_strategy: each batch's clusters, status, results, and health gate failures. Omit rollout_id to list recent rollouts.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"rollout_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Rollout ID returned by deploy_app",
+					},
+				},
+			},
+		},
+		{
+			"name":        "pause_rollout",
+			"description": "Pause a running rollout. The batch in progress finishes; later batches wait for resume_rollout.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"rollout_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Rollout ID returned by deploy_app",
+					},
+				},
+				"required": []string{"rollout_id"},
+			},
+		},
+		{
+			"name":        "resume_rollout",
+			"description": "Resume a paused rollout from its next batch.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"rollout_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Rollout ID returned by deploy_app",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Show the remaining batches without resuming",
+					},
+				},
+				"required": []string{"rollout_id"},
+			},
+		},
+		{
+			"name":        "abort_rollout",
+			"description": "Stop a rollout for good, interrupting the batch in progress. With rollback, the changes of every batch it applied are undone, newest first.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"rollout_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Rollout ID returned by deploy_app",
+					},
+					"rollback": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Undo the changes the rollout already applied",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Preview the rollback without stopping or changing anything",
+					},
+				},
+				"required": []string{"rollout_id"},
+			},
+		},
+		// Blue/green tools
+		{
+			"name":        "start_blue_green",
+			"description": "Start a blue/green deploy: create a candidate copy of the app's Deployment running the new image(s), plus a <service>-preview Service for testing it, and wait for the candidate to become ready. A candidate that is not ready in time is removed. Traffic is not moved until shift_traffic.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"service": map[string]interface{}{
+						"type":        "string",
+						"description": "Service that sends traffic to the app (default: the only Service selecting its pods)",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters where app runs if not specified)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the candidate objects without creating them",
+					},
+					"image": map[string]interface{}{
+						"type":        "string",
+						"description": "New image for a single-container Deployment",
+					},
+					"images": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "New images by container name",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long to wait for the candidate to become ready (default 300)",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
+		{
+			"name":        "shift_traffic",
+			"description": "Send a percentage of an app's traffic to the candidate of its blue/green deploy. Without http_route the Service selector is switched, so weight must be 0 or 100; with http_route the weights of the Gateway API HTTPRoute backends are split between the Service and the preview Service. Refuses to send traffic to a candidate that is not ready.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"service": map[string]interface{}{
+						"type":        "string",
+						"description": "Service of the blue/green deploy, to choose between several",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters where app runs if not specified)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the change without applying it",
+					},
+					"weight": map[string]interface{}{
+						"type":        "integer",
+						"description": "Percentage of traffic for the candidate, 0-100 (default 100)",
+					},
+					"http_route": map[string]interface{}{
+						"type":        "string",
+						"description": "HTTPRoute, in the Service's namespace, whose backend weights split the traffic",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
+		{
+			"name":        "finish_blue_green",
+			"description": "Finish a blue/green deploy. promote (after shift_traffic to 100) keeps the candidate and deletes the old Deployment; rollback sends all traffic back to the live Deployment and deletes the candidate. Both delete the preview Service.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"service": map[string]interface{}{
+						"type":        "string",
+						"description": "Service of the blue/green deploy, to choose between several",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (all clusters where app runs if not specified)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the change without applying it",
+					},
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"promote", "rollback"},
+						"description": "promote or rollback",
+					},
+				},
+				"required": []string{"app", "action"},
+			},
+		},
+		// Migration tools
+		{
+			"name":        "migrate_app",
+			"description": "Copy an app from one cluster to another: its workloads and the ConfigMaps, Secrets, PersistentVolumeClaims, and Services they use. Objects that already exist on the target are left alone, and volume data is not copied. After the workloads are ready on the target, optionally scales the source down. Reports each step.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"source_cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Cluster to copy the app from",
+					},
+					"target_cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Cluster to copy the app to",
+					},
+					"scale_down_source": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Scale the source Deployments and StatefulSets to 0 once the target is healthy (default: false)",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long to wait for the workloads to become ready on the target (default: 300)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the copy without applying it",
+					},
+				},
+				"required": []string{"app", "source_cluster", "target_cluster"},
+			},
+		},
+		{
+			"name":        "clone_namespace",
+			"description": "Copy the resources of a namespace in one cluster to other clusters, e.g. to stand up a new regional cluster like an existing one. Objects that already exist on a target are left alone. Secrets are skipped unless secret_mode says otherwise; objects owned by controllers and service account tokens are never copied.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"source_cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Cluster to copy the namespace from",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace to copy",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to copy the namespace to",
+					},
+					"target_namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace to create on the targets (default: same as namespace)",
+					},
+					"kinds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Kinds to copy (default: ServiceAccount, ConfigMap, Secret, PersistentVolumeClaim, Service, Deployment, StatefulSet, DaemonSet, CronJob, Ingress, NetworkPolicy, HorizontalPodAutoscaler, PodDisruptionBudget)",
+					},
+					"secret_mode": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"skip", "copy", "keys-only"},
+						"description": "skip Secrets, copy them, or copy only their keys with empty values to fill in on the target (default: skip)",
+					},
+					"rewrites": map[string]interface{}{
+						"type":        "object",
+						"description": "Replacements applied to every string of the copied objects, such as names, references, hosts, and config values, e.g. {\"us-east-1\": \"eu-west-1\"}. Secret data is not rewritten.",
+					},
+					"labels": map[string]interface{}{
+						"type":        "object",
+						"description": "Labels to set on every copied object",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the copy without applying it",
+					},
+				},
+				"required": []string{"source_cluster", "namespace", "clusters"},
+			},
+		},
+		// Volume snapshot tools
+		{
+			"name":        "snapshot_app_volumes",
+			"description": "Take a CSI VolumeSnapshot of every PersistentVolumeClaim an app mounts, including StatefulSet claims, on every cluster it runs on. Use this before deleting, migrating, or otherwise risking an app's data. Each claim's snapshot class is the default VolumeSnapshotClass of its driver unless snapshot_class is set; claims without one are skipped. The snapshots of one call share a snapshot set label.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the app",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace of the app (all namespaces if not specified)",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Target clusters (clusters where the app runs if not specified)",
+					},
+					"snapshot_class": map[string]interface{}{
+						"type":        "string",
+						"description": "VolumeSnapshotClass to use for every claim (default: the default class of each claim's CSI driver)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the snapshots without taking them",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
+		{
+			"name":        "list_volume_snapshots",
+			"description": "List VolumeSnapshots per cluster with their source claim, class, restore size, and whether they are ready to restore, optionally only those snapshot_app_volumes took of an app or in one snapshot set.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Specific cluster (all clusters if not specified)",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (all namespaces if not specified)",
+					},
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "Only snapshots snapshot_app_volumes took of this app",
+					},
+					"snapshot_set": map[string]interface{}{
+						"type":        "string",
+						"description": "Only snapshots of this snapshot set, as returned by snapshot_app_volumes",
+					},
+				},
+			},
+		},
+		{
+			"name":        "restore_volume_snapshot",
+			"description": "Restore a VolumeSnapshot to a new PersistentVolumeClaim in one cluster. By default the claim gets the name, storage class, and access modes of the claim the snapshot was taken of, so an app whose claim was deleted can mount it again. An existing claim is never replaced.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Cluster of the snapshot",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace of the snapshot",
+					},
+					"snapshot": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the VolumeSnapshot",
+					},
+					"pvc": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the claim to create (default: the snapshot's source claim)",
+					},
+					"storage_class": map[string]interface{}{
+						"type":        "string",
+						"description": "Storage class of the new claim (default: that of the source claim)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the restore without creating the claim",
+					},
+				},
+				"required": []string{"cluster", "namespace", "snapshot"},
+			},
+		},
+		// Secret tools
+		{
+			"name":        "distribute_secret",
+			"description": "Write a secret to namespaces in several clusters, from a Secret in a source cluster, literal data, or an External Secrets Operator store. Each copy carries a hash annotation, so later calls report copies that are in sync, out of date, or changed in place. Existing copies are only overwritten with rotate, which can also restart the workloads that use the secret. Secret values never appear in the result.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the Secret to create on the targets",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace to write the secret to",
+					},
+					"namespaces": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Namespaces to write the secret to, instead of namespace",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to write the secret to",
+					},
+					"source_cluster": map[string]interface{}{
+						"type":        "string",
+						"description": "Copy the secret from this cluster",
+					},
+					"source_namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace of the source secret (default: the first target namespace)",
+					},
+					"source_name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the source secret (default: name)",
+					},
+					"data": map[string]interface{}{
+						"type":        "object",
+						"description": "Literal secret data as plain-text key/value pairs, instead of source_cluster",
+					},
+					"type": map[string]interface{}{
+						"type":        "string",
+						"description": "Secret type for literal data (default: Opaque)",
+					},
+					"external_secret": map[string]interface{}{
+						"type":        "object",
+						"description": "Create an ExternalSecret (external-secrets.io/v1) on each target instead of a Secret: {\"store\": ..., \"store_kind\": \"SecretStore\" or \"ClusterSecretStore\", \"remote_key\": ..., \"refresh_interval\": \"1h\"}",
+					},
+					"rotate": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Overwrite existing copies that differ, including ones changed in place (default: false, which only reports them)",
+					},
+					"restart_consumers": map[string]interface{}{
+						"type":        "boolean",
+						"description": "With rotate, restart the Deployments, StatefulSets, and DaemonSets that use an updated secret",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report what would change without writing anything",
+					},
+				},
+				"required": []string{"name", "clusters"},
+			},
+		},
+		{
+			"name":        "update_config",
+			"description": "Update keys of a ConfigMap or Secret in every cluster that has it. Lists the Deployments, StatefulSets, and DaemonSets that mount or env-reference it in each cluster, and whether they need a restart to see the change, then optionally restarts only those workloads. Reports progress per cluster; Secret values never appear in the result. Run with dry_run first to see the impact.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"ConfigMap", "Secret"},
+						"description": "Kind of object to update (default: ConfigMap)",
+					},
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Name of the ConfigMap or Secret",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace of the ConfigMap or Secret",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to update (default: all clusters; clusters without the object are reported as not-found)",
+					},
+					"data": map[string]interface{}{
+						"type":        "object",
+						"description": "Keys to set, as plain-text key/value pairs",
+					},
+					"remove_keys": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Keys to remove",
+					},
+					"restart": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"none", "affected", "required"},
+						"description": "Restart no workloads, every workload that uses the object, or only those that need a restart to see the change because they use it through environment variables or subPath mounts (default: none)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report the affected workloads and changed keys without updating anything",
+					},
+				},
+				"required": []string{"name", "namespace"},
+			},
+		},
+		// Right-sizing tools
+		{
+			"name":        "recommend_resources",
+			"description": "Recommend CPU and memory requests and limits for each container of an app from its observed usage in metrics-server, pooled over every cluster that runs it. Requests target a percentile of usage plus a safety margin, like the Vertical Pod Autoscaler; limits keep their current ratio to requests. Read-only.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to sample (default: all clusters)",
+					},
+					"samples": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of usage samples to take from metrics-server per pod (default: 1, max: 30). Recommendations from fewer than 3 samples per container are marked lowConfidence",
+					},
+					"sample_interval_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds between samples (default: 15)",
+					},
+					"cpu_percentile": map[string]interface{}{
+						"type":        "number",
+						"description": "Percentile of observed CPU usage to target with the request (default: 90)",
+					},
+					"memory_percentile": map[string]interface{}{
+						"type":        "number",
+						"description": "Percentile of observed memory usage to target with the request (default: 95)",
+					},
+					"safety_margin_percent": map[string]interface{}{
+						"type":        "number",
+						"description": "Headroom added on top of the target percentile (default: 15)",
+					},
+					"min_change_percent": map[string]interface{}{
+						"type":        "number",
+						"description": "Leave requests that would change by less than this percentage alone (default: 10)",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
+		{
+			"name":        "apply_resource_recommendations",
+			"description": "Compute the recommendations of recommend_resources and set them on the app's Deployments, StatefulSets, and DaemonSets in every cluster that runs them, then wait for the workloads to become ready. If any update fails or a workload does not become ready, every update is rolled back. Run with dry_run first to review the changes.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to sample (default: all clusters)",
+					},
+					"samples": map[string]interface{}{
+						"type":        "integer",
+						"description": "Number of usage samples to take from metrics-server per pod (default: 1, max: 30). Recommendations from fewer than 3 samples per container are marked lowConfidence",
+					},
+					"sample_interval_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds between samples (default: 15)",
+					},
+					"cpu_percentile": map[string]interface{}{
+						"type":        "number",
+						"description": "Percentile of observed CPU usage to target with the request (default: 90)",
+					},
+					"memory_percentile": map[string]interface{}{
+						"type":        "number",
+						"description": "Percentile of observed memory usage to target with the request (default: 95)",
+					},
+					"safety_margin_percent": map[string]interface{}{
+						"type":        "number",
+						"description": "Headroom added on top of the target percentile (default: 15)",
+					},
+					"min_change_percent": map[string]interface{}{
+						"type":        "number",
+						"description": "Leave requests that would change by less than this percentage alone (default: 10)",
+					},
+					"containers": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Only change these containers (default: every container with a recommended update)",
+					},
+					"include_low_confidence": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also apply recommendations based on fewer than 3 samples (default: false)",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long to wait for the workloads to become ready before rolling back (default: 300)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report the recommendations and the workloads that would change without updating anything",
+					},
+				},
+				"required": []string{"app"},
+			},
+		},
+		// Promotion tools
+		{
+			"name":        "promote_app",
+			"description": "Promote an app from one environment to the next (e.g. dev to staging to prod) as configured by $KUBESTELLAR_ENVIRONMENTS. Sets the images the app runs in the source environment on its workloads in the next environment, creates the workloads and the objects they use where missing, waits for them to become ready, and records the promotion. Promoting into the last environment returns a preview unless confirm is true.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"from": map[string]interface{}{
+						"type":        "string",
+						"description": "Environment to promote from; the app is promoted to the environment after it",
+					},
+					"confirm": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Confirm a promotion into the last environment (default: false, which returns a preview)",
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "How long to wait for the workloads to become ready (default: 300)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the promotion without applying it",
+					},
+				},
+				"required": []string{"app", "from"},
+			},
+		},
+		{
+			"name":        "get_promotion_history",
+			"description": "List recorded promotions, newest first, with the images promoted and the outcome in each cluster, along with the configured environments.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "Only list promotions of this app",
+					},
+					"limit": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum number of promotions to return (default: 20)",
+					},
+				},
+			},
+		},
+		// Scaling schedule tools
+		{
+			"name":        "schedule_scaling",
+			"description": "Scale an app down and back up on cron schedules to save cost, e.g. dev clusters to zero at night and back at 8am on weekdays. The schedule is stored in the state store and executed by the server's background scheduler at each transition; a scale-up restores each cluster's replica count from before the scale-down unless up_replicas is set. Creating a schedule does not scale anything now. Calling it again with the same name replaces the schedule.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Schedule name, e.g. dev-nights",
+					},
+					"app": map[string]interface{}{
+						"type":        "string",
+						"description": "App name",
+					},
+					"namespace": map[string]interface{}{
+						"type":        "string",
+						"description": "Namespace (default: search all namespaces)",
+					},
+					"kind": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"Deployment", "StatefulSet"},
+						"description": "Workload kind, to choose between matches",
+					},
+					"clusters": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Clusters to scale (default: every cluster running the app at each transition)",
+					},
+					"environment": map[string]interface{}{
+						"type":        "string",
+						"description": "Scale the clusters of this environment from $KUBESTELLAR_ENVIRONMENTS instead of clusters",
+					},
+					"scale_down_schedule": map[string]interface{}{
+						"type":        "string",
+						"description": "Cron schedule of the scale-down, e.g. '0 20 * * 1-5'",
+					},
+					"scale_up_schedule": map[string]interface{}{
+						"type":        "string",
+						"description": "Cron schedule of the scale-up, e.g. '0 8 * * 1-5'",
+					},
+					"time_zone": map[string]interface{}{
+						"type":        "string",
+						"description": "IANA time zone of the schedules, e.g. Europe/Berlin (default: UTC)",
+					},
+					"down_replicas": map[string]interface{}{
+						"type":        "integer",
+						"description": "Replicas while scaled down (default: 0)",
+					},
+					"up_replicas": map[string]interface{}{
+						"type":        "integer",
+						"description": "Replicas while scaled up (default: each cluster's count before the scale-down)",
+					},
+					"override_conflicts": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Scale even where another controller, such as a HorizontalPodAutoscaler or Argo CD, manages the workload (see scale_app's override)",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Validate the schedule and report its next transition without saving it",
+					},
+				},
+				"required": []string{"name", "app", "scale_down_schedule", "scale_up_schedule"},
+			},
+		},
+		{
+			"name":        "list_scaling_schedules",
+			"description": "List scaling schedules with the state each last applied, the state its schedule says the app should be in now, its next transition, any override, and its last run. With name, returns that schedule with its run history.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Only return this schedule, with its run history",
+					},
+				},
+			},
+		},
+		{
+			"name":        "override_scaling_schedule",
+			"description": "Manually scale the app of a scaling schedule up or down now and hold it there until a time, by default the schedule's next transition. Scheduled transitions are skipped while the override lasts. With clear, ends the override and applies the state the schedule is in now.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Schedule name",
+					},
+					"state": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"up", "down"},
+						"description": "State to scale to and hold",
+					},
+					"until": map[string]interface{}{
+						"type":        "string",
+						"description": "RFC 3339 time the override ends (default: the next scheduled transition)",
+					},
+					"reason": map[string]interface{}{
+						"type":        "string",
+						"description": "Why the schedule is overridden, shown by list_scaling_schedules",
+					},
+					"clear": map[string]interface{}{
+						"type":        "boolean",
+						"description": "End the override and apply the scheduled state now",
+					},
+					"dry_run": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Report the replica changes without scaling or saving anything",
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
+		{
+			"name":        "delete_scaling_schedule",
+			"description": "Delete a scaling schedule. The app keeps its current replica count, so scale it up first if it is scaled down.",
+			"inputSchema": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Schedule name",
+					},
+				},
+				"required": []string{"name"},
+			},
+		},
+	}
+
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result: map[string]interface{}{
+			"tools": withOutputArg(withRetryFailedArg(withApprovalArgs(withFreshnessArgs(tools)))),
+		},
+	}
+}
+
+// handleToolCall dispatches tool calls to handlers
+func (s *Server) handleToolCall(ctx context.Context, req *MCPRequest) (resp *MCPResponse) {
+	var params struct {
+		Name      string                `json:"name"`
+		Arguments json.RawMessage       `json:"arguments"`
+		Meta      *protocol.RequestMeta `json:"_meta,omitempty"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &MCPError{Code: -32602, Message: "Invalid params"},
+		}
+	}
+	if params.Meta != nil && params.Meta.ProgressToken != nil {
+		ctx = withProgress(ctx, func(done, total int, message string) {
+			s.sendNotification(protocol.ProgressNotificationMethod, protocol.ProgressParams{
+				ProgressToken: params.Meta.ProgressToken,
+				Progress:      float64(done),
+				Total:         float64(total),
+				Message:       message,
+			})
+		})
+	}
+
+	if signer := s.getProvenanceSigner(); signer != nil {
+		reads := provenance.NewCollector()
+		ctx = provenance.WithCollector(ctx, reads)
+		defer func() { resp = withProvenance(resp, signer, params.Name, reads) }()
+	}
+
+	format, arguments, err := outputFormat(params.Arguments)
+	if err != nil {
+		return toolErrorResponse(req.ID, err)
+	}
+	params.Arguments = arguments
+	// Runs before the provenance defer, so the signature covers the
+	// rendered output.
+	defer func() { resp = withASCII(withOutputFormat(resp, format)) }()
+
+	retryFailed, arguments, err := retryFailedArg(params.Arguments)
+	if err != nil {
+		return toolErrorResponse(req.ID, err)
+	}
+	params.Arguments = arguments
+	statuses := fanout.NewRecorder()
+	ctx = fanout.WithRecorder(ctx, statuses)
+	callArgs := cacheArgs(params.Arguments)
+	callKey := approval.Fingerprint(params.Name, callArgs)
+	if retryFailed {
+		if ctx, err = s.getFanoutMemory().Retry(ctx, callKey); err != nil {
+			return toolErrorResponse(req.ID, err)
+		}
+	}
+	defer func() { resp = s.withClusterStatus(resp, callKey, callArgs, statuses) }()
+
+	requireApproval, err := s.authorizeToolCall(ctx, params.Name, params.Arguments)
+	if err != nil {
+		return toolErrorResponse(req.ID, err)
+	}
+	ctx, arguments, planArgs, err := s.applyApproval(ctx, params.Name, params.Arguments, requireApproval)
+	if err != nil {
+		return toolErrorResponse(req.ID, err)
+	}
+	params.Arguments = arguments
+
+	var rec *journal.Recorder
+	if mutatingTools[params.Name] && planArgs == nil {
+		rec = s.getJournal().Begin(params.Name)
+		ctx = journal.WithRecorder(ctx, rec)
+	}
+	// Transient cluster errors are retried only for tools that do not
+	// change cluster state.
+	if !mutatingTools[params.Name] {
+		ctx = retry.WithIdempotent(ctx)
+	}
+
+	ttl, cacheable := cachedToolTTLs[params.Name]
+	// A retry runs on only some clusters, so its result is not cached.
+	cacheable = cacheable && !retryFailed
+	var cacheKey string
+	if cacheable {
+		args := cacheArgs(params.Arguments)
+		cacheKey = cache.Key(params.Name, args)
+		if entry, ok := s.getResultCache().Get(cacheKey, ttl, cache.ParseOptions(args)); ok {
+			return toolTextResponse(req.ID, entry.Value.(string), cache.Meta(entry, true))
+		}
+	}
+
+	var result interface{}
+
+	switch params.Name {
+	case "get_app_instances":
+		result, err = s.handleGetAppInstances(ctx, params.Arguments)
+	case "get_app_status":
+		result, err = s.handleGetAppStatus(ctx, params.Arguments)
+	case "get_app_logs":
+		result, err = s.handleGetAppLogs(ctx, params.Arguments)
+	case "get_app_versions":
+		result, err = s.handleGetAppVersions(ctx, params.Arguments)
+	case "list_cluster_capabilities":
+		result, err = s.handleListClusterCapabilities(ctx, params.Arguments)
+	case "find_clusters_for_workload":
+		result, err = s.handleFindClustersForWorkload(ctx, params.Arguments)
+	// Kueue tools
+	case "list_kueue_queues":
+		result, err = s.handleListKueueQueues(ctx, params.Arguments)
+	case "list_pending_workloads":
+		result, err = s.handleListPendingWorkloads(ctx, params.Arguments)
+	case "deploy_app":
+		result, err = s.handleDeployApp(ctx, params.Arguments)
+	case "scale_app":
+		result, err = s.handleScaleApp(ctx, params.Arguments)
+	case "rolling_restart_fleet":
+		result, err = s.handleRollingRestartFleet(ctx, params.Arguments)
+	case "patch_app":
+		result, err = s.handlePatchApp(ctx, params.Arguments)
+	// StatefulSet tools
+	case "get_statefulset_status":
+		result, err = s.handleGetStatefulSetStatus(ctx, params.Arguments)
+	case "set_statefulset_partition":
+		result, err = s.handleSetStatefulSetPartition(ctx, params.Arguments)
+	case "restart_statefulset":
+		result, err = s.handleRestartStatefulSet(ctx, params.Arguments)
+	// GitOps tools
+	case "detect_drift":
+		result, err = s.handleDetectDrift(ctx, params.Arguments)
+	case "sync_from_git":
+		result, err = s.handleSyncFromGit(ctx, params.Arguments)
+	case "reconcile":
+		result, err = s.handleReconcile(ctx, params.Arguments)
+	case "preview_changes":
+		result, err = s.handlePreviewChanges(ctx, params.Arguments)
+	case "compare_git_refs":
+		result, err = s.handleCompareGitRefs(ctx, params.Arguments)
+	// Helm tools
+	case "helm_install":
+		result, err = s.handleHelmInstall(ctx, params.Arguments)
+	case "helm_uninstall":
+		result, err = s.handleHelmUninstall(ctx, params.Arguments)
+	case "helm_list":
+		result, err = s.handleHelmList(ctx, params.Arguments)
+	case "helm_rollback":
+		result, err = s.handleHelmRollback(ctx, params.Arguments)
+	// Delete and kubectl tools
+	case "delete_resource":
+		result, err = s.handleDeleteResource(ctx, params.Arguments)
+	case "kubectl_apply":
+		result, err = s.handleKubectlApply(ctx, params.Arguments)
+	case "export_resource":
+		result, err = s.handleExportResource(ctx, params.Arguments)
+	case "dump_namespace":
+		result, err = s.handleDumpNamespace(ctx, params.Arguments)
+	// Configuration baseline tools
+	case "capture_baseline":
+		result, err = s.handleCaptureBaseline(ctx, params.Arguments)
+	case "audit_baseline":
+		result, err = s.handleAuditBaseline(ctx, params.Arguments)
+	case "list_baselines":
+		result, err = s.handleListBaselines(ctx, params.Arguments)
+	case "delete_baseline":
+		result, err = s.handleDeleteBaseline(ctx, params.Arguments)
+	// Kustomize tools
+	case "kustomize_build":
+		result, err = s.handleKustomizeBuild(ctx, params.Arguments)
+	case "kustomize_apply":
+		result, err = s.handleKustomizeApply(ctx, params.Arguments)
+	case "kustomize_delete":
+		result, err = s.handleKustomizeDelete(ctx, params.Arguments)
+	// Label tools
+	case "add_labels":
+		result, err = s.handleAddLabels(ctx, params.Arguments)
+	case "remove_labels":
+		result, err = s.handleRemoveLabels(ctx, params.Arguments)
+	// Node taint tools
+	case "taint_nodes":
+		result, err = s.handleTaintNodes(ctx, params.Arguments)
+	case "untaint_nodes":
+		result, err = s.handleUntaintNodes(ctx, params.Arguments)
+	case "rebalance_pods":
+		result, err = s.handleRebalancePods(ctx, params.Arguments)
+	// Change journal tools
+	case "list_changes":
+		result, err = s.handleListChanges(ctx, params.Arguments)
+	case "undo_change":
+		result, err = s.handleUndoChange(ctx, params.Arguments)
+	// Rollout tools
+	case "get_rollout":
+		result, err = s.handleGetRollout(ctx, params.Arguments)
+	case "pause_rollout":
+		result, err = s.handlePauseRollout(ctx, params.Arguments)
+	case "resume_rollout":
+		result, err = s.handleResumeRollout(ctx, params.Arguments)
+	case "abort_rollout":
+		result, err = s.handleAbortRollout(ctx, params.Arguments)
+	// Blue/green tools
+	case "start_blue_green":
+		result, err = s.handleStartBlueGreen(ctx, params.Arguments)
+	case "shift_traffic":
+		result, err = s.handleShiftTraffic(ctx, params.Arguments)
+	case "finish_blue_green":
+		result, err = s.handleFinishBlueGreen(ctx, params.Arguments)
+	// Migration tools
+	case "migrate_app":
+		result, err = s.handleMigrateApp(ctx, params.Arguments)
+	case "clone_namespace":
+		result, err = s.handleCloneNamespace(ctx, params.Arguments)
+	// Volume snapshot tools
+	case "snapshot_app_volumes":
+		result, err = s.handleSnapshotAppVolumes(ctx, params.Arguments)
+	case "list_volume_snapshots":
+		result, err = s.handleListVolumeSnapshots(ctx, params.Arguments)
+	case "restore_volume_snapshot":
+		result, err = s.handleRestoreVolumeSnapshot(ctx, params.Arguments)
+	// Secret tools
+	case "distribute_secret":
+		result, err = s.handleDistributeSecret(ctx, params.Arguments)
+	case "update_config":
+		result, err = s.handleUpdateConfig(ctx, params.Arguments)
+	// Right-sizing tools
+	case "recommend_resources":
+		result, err = s.handleRecommendResources(ctx, params.Arguments)
+	case "apply_resource_recommendations":
+		result, err = s.handleApplyResourceRecommendations(ctx, params.Arguments)
+	// Promotion tools
+	case "promote_app":
+		result, err = s.handlePromoteApp(ctx, params.Arguments)
+	case "get_promotion_history":
+		result, err = s.handleGetPromotionHistory(ctx, params.Arguments)
+	// Scaling schedule tools
+	case "schedule_scaling":
+		result, err = s.handleScheduleScaling(ctx, params.Arguments)
+	case "list_scaling_schedules":
+		result, err = s.handleListScalingSchedules(ctx, params.Arguments)
+	case "override_scaling_schedule":
+		result, err = s.handleOverrideScalingSchedule(ctx, params.Arguments)
+	case "delete_scaling_schedule":
+		result, err = s.handleDeleteScalingSchedule(ctx, params.Arguments)
+	default:
+		return &MCPResponse{
+			JSONRPC: "2.0",
+			ID:      req.ID,
+			Error:   &MCPError{Code: -32601, Message: fmt.Sprintf("Unknown tool: %s", params.Name)},
+		}
+	}
+
+	// Journal whatever changed, even if the tool failed part way.
+	changeMeta := s.commitChange(ctx, rec)
+	if rec != nil && !approval.DryRunRequested(cacheArgs(params.Arguments)) {
+		// Cached scans may predate the change.
+		s.getResultCache().Clear()
+	}
+	if err != nil {
+		var target struct {
+			Cluster string `json:"cluster"`
 		}
+		_ = json.Unmarshal(params.Arguments, &target)
+		return withResultMeta(toolErrorResponse(req.ID, toolerror.InCluster(target.Cluster, err)), changeMeta)
 	}
 
 	// Format result as MCP content
 	resultJSON, _ := json.MarshalIndent(result, "", "  ")
+	if planArgs != nil {
+		return s.dryRunResponse(ctx, req.ID, params.Name, planArgs, string(resultJSON), requireApproval)
+	}
+	if cacheable {
+		entry := s.getResultCache().Put(cacheKey, string(resultJSON))
+		return toolTextResponse(req.ID, string(resultJSON), cache.Meta(entry, false))
+	}
+	return toolTextResponse(req.ID, string(resultJSON), changeMeta)
+}
+
+// nextTools suggests, for each kind of failure, the tool that helps an agent
+// recover from it.
+var nextTools = map[toolerror.Code]string{
+	toolerror.NotFound:       "get_app_instances",
+	toolerror.UnknownCluster: "list_cluster_capabilities",
+	toolerror.Unavailable:    "list_cluster_capabilities",
+}
+
+// toolErrorResponse reports a failed tool call as an error result, with
+// credentials redacted from the message. The classified error is returned
+// under _meta.error for agents to branch on.
+func toolErrorResponse(id interface{}, err error) *MCPResponse {
+	text, redactions := redact.String(fmt.Sprintf("Error: %v", err))
+	payload := toolerror.From(err).Suggest(nextTools)
+	payload.Message, _ = redact.String(payload.Message)
+	meta := map[string]interface{}{toolerror.MetaKey: payload}
+	if redactions > 0 {
+		meta["redactions"] = redactions
+	}
+	result := map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+		"isError": true,
+		"_meta":   meta,
+	}
 	return &MCPResponse{
 		JSONRPC: "2.0",
-		ID:      req.ID,
-		Result: map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": string(resultJSON),
-				},
+		ID:      id,
+		Result:  result,
+	}
+}
+
+// withOutputArg adds the output argument, which every tool honors, to each
+// tool's input schema.
+func withOutputArg(tools []map[string]interface{}) []map[string]interface{} {
+	for _, tool := range tools {
+		schema, _ := tool["inputSchema"].(map[string]interface{})
+		properties, _ := schema["properties"].(map[string]interface{})
+		if properties == nil {
+			continue
+		}
+		properties[output.Arg] = map[string]interface{}{
+			"type":        "string",
+			"description": output.Description,
+			"enum":        output.Names(),
+		}
+	}
+	return tools
+}
+
+// outputFormat reads the output argument and returns the arguments without
+// it. The format applies to the rendered result, so approval plans and
+// cache entries ignore it.
+func outputFormat(raw json.RawMessage) (output.Format, json.RawMessage, error) {
+	args := cacheArgs(raw)
+	if _, ok := args[output.Arg]; !ok {
+		return "", raw, nil
+	}
+	format, err := output.Parse(args)
+	if err != nil {
+		return "", raw, err
+	}
+	delete(args, output.Arg)
+	stripped, err := json.Marshal(args)
+	if err != nil {
+		return "", raw, err
+	}
+	return format, stripped, nil
+}
+
+// withOutputFormat renders the tool output of a successful result, its
+// first content block, in format. Error results are left alone.
+func withOutputFormat(resp *MCPResponse, format output.Format) *MCPResponse {
+	if format == "" || resp == nil {
+		return resp
+	}
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok || result["isError"] == true {
+		return resp
+	}
+	content, _ := result["content"].([]map[string]interface{})
+	if len(content) == 0 {
+		return resp
+	}
+	text, _ := content[0]["text"].(string)
+	content[0]["text"] = output.Render(text, format)
+	return resp
+}
+
+// withASCII makes every content block of a result, errors included,
+// plain ASCII when $KUBESTELLAR_ASCII is set.
+func withASCII(resp *MCPResponse) *MCPResponse {
+	if resp == nil || !messages.ASCIIEnabled() {
+		return resp
+	}
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		return resp
+	}
+	content, _ := result["content"].([]map[string]interface{})
+	for _, block := range content {
+		if text, ok := block["text"].(string); ok {
+			block["text"] = messages.ASCII(text)
+		}
+	}
+	return resp
+}
+
+// toolTextResponse wraps text as a successful tool result, with optional
+// result metadata under _meta. Credentials are redacted from text first.
+func toolTextResponse(id interface{}, text string, meta map[string]interface{}) *MCPResponse {
+	text, redactions := redact.String(text)
+	if redactions > 0 {
+		withCount := map[string]interface{}{"redactions": redactions}
+		for k, v := range meta {
+			withCount[k] = v
+		}
+		meta = withCount
+	}
+	result := map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
 			},
 		},
 	}
+	if meta != nil {
+		result["_meta"] = meta
+	}
+	return &MCPResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  result,
+	}
 }
 
 // sendResponse writes a response to stdout
 func (s *Server) sendResponse(resp *MCPResponse) {
 	data, _ := json.Marshal(resp)
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	fmt.Println(string(data))
+}
+
+// sendNotification writes a JSON-RPC notification to stdout
+func (s *Server) sendNotification(method string, params interface{}) {
+	data, err := json.Marshal(protocol.Notification{
+		JSONRPC: protocol.JSONRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return
+	}
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
 	fmt.Println(string(data))
 }
 
+type progressKey struct{}
+
+// progressFunc reports that done of total units of work have finished.
+type progressFunc func(done, total int, message string)
+
+// withProgress attaches a progress reporter for the current tool call.
+func withProgress(ctx context.Context, fn progressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress forwards a progress update if the caller asked for one.
+func reportProgress(ctx context.Context, done, total int, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(progressFunc); ok {
+		fn(done, total, message)
+	}
+}
+
 // sendError sends an error response
 func (s *Server) sendError(id interface{}, code int, message string) {
 	resp := &MCPResponse{
@@ -56,15 +56,21 @@ func TestRunProcessesMultipleRequestsAndHandlesEOF(t *testing.T) {
 	require.NoError(t, err)
 	_ = stdinW.Close()
 
+	// Drain stdout while the server runs: the tools/list response is
+	// larger than a pipe buffer.
+	outputC := make(chan []byte)
+	go func() {
+		output, _ := io.ReadAll(stdoutR)
+		outputC <- output
+	}()
+
 	// Run the server (blocks until EOF)
 	runErr := server.Run()
 	_ = stdoutW.Close()
 
 	assert.NoError(t, runErr)
 
-	// Read all output
-	output, err := io.ReadAll(stdoutR)
-	require.NoError(t, err)
+	output := <-outputC
 
 	// Parse responses - one per line
 	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
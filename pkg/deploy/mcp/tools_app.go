@@ -1,16 +1,16 @@
 package mcp
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"strings"
 	"sync"
 	"time"
 
 	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -56,6 +56,8 @@ func (s *Server) handleGetAppInstances(ctx context.Context, args json.RawMessage
 	var params struct {
 		App       string `json:"app"`
 		Namespace string `json:"namespace,omitempty"`
+		PageSize  int    `json:"page_size,omitempty"`
+		Continue  string `json:"continue,omitempty"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
@@ -73,34 +75,57 @@ func (s *Server) handleGetAppInstances(ctx context.Context, args json.RawMessage
 		}
 	}
 
-	results, err := s.executor.Execute(ctx, "", func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
-		return s.findAppInCluster(ctx, client, clusterName, params.App, params.Namespace)
-	})
+	page, next, err := s.clusterPage(params.Continue, params.PageSize)
 	if err != nil {
 		return nil, err
 	}
 
-	// Flatten results
+	// Fold each cluster's instances in as it completes rather than holding
+	// every per-cluster result until the whole page is done.
 	var instances []AppInstance
-	for _, result := range results {
+	done := 0
+	s.executor.ExecuteStream(ctx, page, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.findAppInCluster(ctx, client, clusterName, params.App, params.Namespace)
+	}, func(result multicluster.ClusterResult) {
+		done++
+		reportProgress(ctx, done, len(page), fmt.Sprintf("scanned %s", result.Cluster))
 		if result.Error != "" {
-			continue
+			return
 		}
 		if clusterInstances, ok := result.Result.([]AppInstance); ok {
 			instances = append(instances, clusterInstances...)
 		}
-	}
+	})
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"app":       claude.SanitizeForPrompt(params.App),
 		"instances": instances,
 		"count":     len(instances),
-	}, nil
+	}
+	if next != "" {
+		response["continue"] = next
+	}
+	return response, nil
 }
 
-// findAppInCluster searches for an app in a single cluster
+// clusterPage resolves the clusters a paginated fleet-wide call should cover.
+func (s *Server) clusterPage(continueToken string, pageSize int) ([]string, string, error) {
+	if pageSize < 0 {
+		return nil, "", fmt.Errorf("page_size must not be negative")
+	}
+	names, err := s.executor.ClusterNames()
+	if err != nil {
+		return nil, "", err
+	}
+	page, next := multicluster.PageClusters(names, continueToken, pageSize)
+	return page, next, nil
+}
+
+// findAppInCluster searches for an app in a single cluster. Kinds that
+// cannot be listed are skipped when another kind matched, so the result
+// may be partial; when nothing matched, the list error is returned so a
+// denied or failed search is not reported as the app being absent.
 func (s *Server) findAppInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace string) ([]AppInstance, error) {
-	var instances []AppInstance
 	ns := namespace
 	if ns == "" {
 		ns = metav1.NamespaceAll
@@ -112,61 +137,144 @@ func (s *Server) findAppInCluster(ctx context.Context, client *kubernetes.Client
 		}
 	}
 
+	workloads, err := listAppWorkloads(ctx, client, ns, appName)
+	if err != nil && len(workloads) == 0 {
+		return nil, err
+	}
+	instances := make([]AppInstance, 0, len(workloads))
+	for _, w := range workloads {
+		instances = append(instances, w.instance(clusterName))
+	}
+	if len(instances) == 0 {
+		return nil, nil
+	}
+	return instances, nil
+}
+
+// appWorkload is a Deployment, StatefulSet, or DaemonSet that matched an
+// app name. Exactly one of the object fields is set, as listed, so callers
+// can update it without reading it again.
+type appWorkload struct {
+	Kind        string
+	deployment  *appsv1.Deployment
+	statefulSet *appsv1.StatefulSet
+	daemonSet   *appsv1.DaemonSet
+}
+
+func (w appWorkload) meta() metav1.Object {
+	switch {
+	case w.deployment != nil:
+		return w.deployment
+	case w.statefulSet != nil:
+		return w.statefulSet
+	default:
+		return w.daemonSet
+	}
+}
+
+// String returns Kind namespace/name.
+func (w appWorkload) String() string {
+	return fmt.Sprintf("%s %s/%s", w.Kind, w.meta().GetNamespace(), w.meta().GetName())
+}
+
+func (w appWorkload) instance(clusterName string) AppInstance {
+	inst := AppInstance{Cluster: clusterName, Namespace: w.meta().GetNamespace(), Name: w.meta().GetName(), Kind: w.Kind}
+	switch {
+	case w.deployment != nil:
+		inst.Replicas = replicasOrDefault(w.deployment.Spec.Replicas)
+		inst.ReadyReplicas = w.deployment.Status.ReadyReplicas
+		inst.Status = getDeploymentStatus(w.deployment)
+	case w.statefulSet != nil:
+		inst.Replicas = replicasOrDefault(w.statefulSet.Spec.Replicas)
+		inst.ReadyReplicas = w.statefulSet.Status.ReadyReplicas
+		inst.Status = getStatefulSetStatus(w.statefulSet)
+	default:
+		inst.Replicas = w.daemonSet.Status.DesiredNumberScheduled
+		inst.ReadyReplicas = w.daemonSet.Status.NumberReady
+		inst.Status = getDaemonSetStatus(w.daemonSet)
+	}
+	return inst
+}
+
+// listAppWorkloads returns the Deployments, StatefulSets, and DaemonSets in
+// ns (all namespaces when empty) that match appName. The workloads of every
+// kind that could be listed are returned along with any list errors.
+func listAppWorkloads(ctx context.Context, client *kubernetes.Clientset, ns, appName string) ([]appWorkload, error) {
+	var (
+		workloads []appWorkload
+		errs      []error
+	)
+
 	// Search Deployments
-	deployments, err := client.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, d := range deployments.Items {
-			if matchesApp(d.Name, d.Labels, appName) {
-				instances = append(instances, AppInstance{
-					Cluster:       clusterName,
-					Namespace:     d.Namespace,
-					Name:          d.Name,
-					Kind:          "Deployment",
-					Replicas:      replicasOrDefault(d.Spec.Replicas),
-					ReadyReplicas: d.Status.ReadyReplicas,
-					Status:        getDeploymentStatus(&d),
-				})
+	deployments, err := listAppObjects(ctx, ns, appName, appObjectQuery[appsv1.Deployment]{
+		rest:     client.AppsV1().RESTClient(),
+		resource: "deployments",
+		list: func(ctx context.Context, opts metav1.ListOptions) ([]appsv1.Deployment, error) {
+			list, err := client.AppsV1().Deployments(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
 			}
-		}
+			return list.Items, nil
+		},
+		get: func(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
+			return client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+		meta: func(d *appsv1.Deployment) metav1.Object { return d },
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing deployments: %w", err))
+	}
+	for i := range deployments {
+		workloads = append(workloads, appWorkload{Kind: "Deployment", deployment: &deployments[i]})
 	}
 
 	// Search StatefulSets
-	statefulsets, err := client.AppsV1().StatefulSets(ns).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, s := range statefulsets.Items {
-			if matchesApp(s.Name, s.Labels, appName) {
-				instances = append(instances, AppInstance{
-					Cluster:       clusterName,
-					Namespace:     s.Namespace,
-					Name:          s.Name,
-					Kind:          "StatefulSet",
-					Replicas:      replicasOrDefault(s.Spec.Replicas),
-					ReadyReplicas: s.Status.ReadyReplicas,
-					Status:        getStatefulSetStatus(&s),
-				})
+	statefulsets, err := listAppObjects(ctx, ns, appName, appObjectQuery[appsv1.StatefulSet]{
+		rest:     client.AppsV1().RESTClient(),
+		resource: "statefulsets",
+		list: func(ctx context.Context, opts metav1.ListOptions) ([]appsv1.StatefulSet, error) {
+			list, err := client.AppsV1().StatefulSets(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
 			}
-		}
+			return list.Items, nil
+		},
+		get: func(ctx context.Context, namespace, name string) (*appsv1.StatefulSet, error) {
+			return client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+		meta: func(s *appsv1.StatefulSet) metav1.Object { return s },
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing statefulsets: %w", err))
+	}
+	for i := range statefulsets {
+		workloads = append(workloads, appWorkload{Kind: "StatefulSet", statefulSet: &statefulsets[i]})
 	}
 
 	// Search DaemonSets
-	daemonsets, err := client.AppsV1().DaemonSets(ns).List(ctx, metav1.ListOptions{})
-	if err == nil {
-		for _, d := range daemonsets.Items {
-			if matchesApp(d.Name, d.Labels, appName) {
-				instances = append(instances, AppInstance{
-					Cluster:       clusterName,
-					Namespace:     d.Namespace,
-					Name:          d.Name,
-					Kind:          "DaemonSet",
-					Replicas:      d.Status.DesiredNumberScheduled,
-					ReadyReplicas: d.Status.NumberReady,
-					Status:        getDaemonSetStatus(&d),
-				})
+	daemonsets, err := listAppObjects(ctx, ns, appName, appObjectQuery[appsv1.DaemonSet]{
+		rest:     client.AppsV1().RESTClient(),
+		resource: "daemonsets",
+		list: func(ctx context.Context, opts metav1.ListOptions) ([]appsv1.DaemonSet, error) {
+			list, err := client.AppsV1().DaemonSets(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
 			}
-		}
+			return list.Items, nil
+		},
+		get: func(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error) {
+			return client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+		meta: func(d *appsv1.DaemonSet) metav1.Object { return d },
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listing daemonsets: %w", err))
+	}
+	for i := range daemonsets {
+		workloads = append(workloads, appWorkload{Kind: "DaemonSet", daemonSet: &daemonsets[i]})
 	}
 
-	return instances, nil
+	return workloads, errors.Join(errs...)
 }
 
 // handleGetAppStatus returns unified status of an app
@@ -252,8 +360,13 @@ func (s *Server) handleGetAppLogs(ctx context.Context, args json.RawMessage) (in
 	var params struct {
 		App       string `json:"app"`
 		Namespace string `json:"namespace"`
-		Tail      int64  `json:"tail"`
-		Since     string `json:"since"`
+		Tail                 int64  `json:"tail"`
+		Since                string `json:"since"`
+		PageSize             int    `json:"page_size"`
+		Continue             string `json:"continue"`
+		MaxBytes             int64  `json:"max_bytes"`
+		MaxBytesPerContainer int64  `json:"max_bytes_per_container"`
+		SampleEvery          int    `json:"sample_every"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
@@ -272,36 +385,63 @@ func (s *Server) handleGetAppLogs(ctx context.Context, args json.RawMessage) (in
 	}
 
 	if params.Tail == 0 {
-		params.Tail = 100
+		params.Tail = defaultLogTailLines
+	}
+	if params.MaxBytes < 0 || params.MaxBytesPerContainer < 0 || params.SampleEvery < 0 {
+		return nil, fmt.Errorf("max_bytes, max_bytes_per_container, and sample_every must not be negative")
+	}
+	if params.MaxBytes == 0 {
+		params.MaxBytes = defaultLogMaxBytes
+	}
+	if params.MaxBytesPerContainer == 0 {
+		params.MaxBytesPerContainer = defaultLogMaxBytesPerContainer
+	}
+	query := logQuery{
+		Tail:                 params.Tail,
+		Since:                params.Since,
+		SampleEvery:          params.SampleEvery,
+		MaxBytesPerContainer: params.MaxBytesPerContainer,
+		Budget:               newLogBudget(params.MaxBytes),
 	}
 
-	results, err := s.executor.Execute(ctx, "", func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
-		return s.getLogsFromCluster(ctx, client, clusterName, params.App, params.Namespace, params.Tail, params.Since)
-	})
+	page, next, err := s.clusterPage(params.Continue, params.PageSize)
 	if err != nil {
 		return nil, err
 	}
 
-	// Aggregate logs
+	// Aggregate logs as each cluster finishes; with page_size set, at most
+	// one page of clusters is held in memory per call.
 	var allLogs []LogEntry
-	for _, result := range results {
+	done := 0
+	s.executor.ExecuteStream(ctx, page, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.getLogsFromCluster(ctx, client, clusterName, params.App, params.Namespace, query)
+	}, func(result multicluster.ClusterResult) {
+		done++
+		reportProgress(ctx, done, len(page), fmt.Sprintf("collected logs from %s", result.Cluster))
 		if result.Error != "" {
-			continue
+			return
 		}
 		if logs, ok := result.Result.([]LogEntry); ok {
 			allLogs = append(allLogs, logs...)
 		}
-	}
+	})
 
-	return map[string]interface{}{
+	response := map[string]interface{}{
 		"app":      claude.SanitizeForPrompt(params.App),
 		"logCount": len(allLogs),
 		"logs":     allLogs,
-	}, nil
+	}
+	if query.Budget.Truncated() {
+		response["truncated"] = true
+	}
+	if next != "" {
+		response["continue"] = next
+	}
+	return response, nil
 }
 
 // getLogsFromCluster gets logs for an app from a single cluster
-func (s *Server) getLogsFromCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace string, tail int64, since string) ([]LogEntry, error) {
+func (s *Server) getLogsFromCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace string, query logQuery) ([]LogEntry, error) {
 	ns := namespace
 	if ns == "" {
 		ns = metav1.NamespaceAll
@@ -314,35 +454,55 @@ func (s *Server) getLogsFromCluster(ctx context.Context, client *kubernetes.Clie
 	}
 
 	// Find pods matching app
-	pods, err := client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+	pods, err := listAppObjects(ctx, ns, appName, appObjectQuery[corev1.Pod]{
+		rest:     client.CoreV1().RESTClient(),
+		resource: "pods",
+		list: func(ctx context.Context, opts metav1.ListOptions) ([]corev1.Pod, error) {
+			list, err := client.CoreV1().Pods(ns).List(ctx, opts)
+			if err != nil {
+				return nil, err
+			}
+			return list.Items, nil
+		},
+		get: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			return client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		},
+		meta: func(p *corev1.Pod) metav1.Object { return p },
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	var sinceTime *metav1.Time
+	if query.Since != "" {
+		if duration, err := time.ParseDuration(query.Since); err == nil {
+			t := metav1.NewTime(time.Now().Add(-duration))
+			sinceTime = &t
+		}
+	}
+
 	var logs []LogEntry
 	var wg sync.WaitGroup
 	var mu sync.Mutex
+	sem := make(chan struct{}, maxConcurrentLogStreams)
 
-	for _, pod := range pods.Items {
-		if !matchesApp(pod.Name, pod.Labels, appName) {
-			continue
-		}
-
+	for _, pod := range pods {
 		for _, container := range pod.Spec.Containers {
 			wg.Add(1)
 			go func(podName, containerName, podNamespace string) {
 				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
 
+				tail := query.Tail
 				opts := &corev1.PodLogOptions{
 					Container: containerName,
 					TailLines: &tail,
+					SinceTime: sinceTime,
 				}
-
-				if since != "" {
-					if duration, err := time.ParseDuration(since); err == nil {
-						sinceTime := metav1.NewTime(time.Now().Add(-duration))
-						opts.SinceTime = &sinceTime
-					}
+				if query.MaxBytesPerContainer > 0 {
+					limit := query.MaxBytesPerContainer
+					opts.LimitBytes = &limit
 				}
 
 				req := client.CoreV1().Pods(podNamespace).GetLogs(podName, opts)
@@ -354,25 +514,16 @@ func (s *Server) getLogsFromCluster(ctx context.Context, client *kubernetes.Clie
 					_ = stream.Close()
 				}()
 
-				buf := new(bytes.Buffer)
-				_, err = io.Copy(buf, stream)
-				if err != nil {
-					return
-				}
-
-				lines := strings.Split(buf.String(), "\n")
-				mu.Lock()
-				for _, line := range lines {
-					if line == "" {
-						continue
-					}
-					logs = append(logs, LogEntry{
+				entries := readLogLines(stream, query, func(line string) LogEntry {
+					return LogEntry{
 						Cluster:   clusterName,
 						Pod:       podName,
 						Container: containerName,
 						Message:   line,
-					})
-				}
+					}
+				})
+				mu.Lock()
+				logs = append(logs, entries...)
 				mu.Unlock()
 			}(pod.Name, container.Name, pod.Namespace)
 		}
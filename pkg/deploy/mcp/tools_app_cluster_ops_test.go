@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
@@ -76,10 +77,12 @@ type findAppFixtures struct {
 	deployments  []appsv1.Deployment
 	statefulsets []appsv1.StatefulSet
 	daemonsets   []appsv1.DaemonSet
+	hpas         []autoscalingv2.HorizontalPodAutoscaler
 }
 
 // startAppsServer serves list requests for deployments/statefulsets/daemonsets
-// (both cluster-scoped "all namespaces" and namespaced paths) plus a
+// (both cluster-scoped "all namespaces" and namespaced paths) and, if fx has
+// any, HorizontalPodAutoscalers, plus a
 // per-deployment PUT/PATCH handler used by scale/patch tests. Any deployment
 // name in updated must match a name in fx.deployments to succeed.
 func startAppsServer(t *testing.T, fx findAppFixtures, updated map[string]*appsv1.Deployment) *httptest.Server {
@@ -148,6 +151,12 @@ func startAppsServer(t *testing.T, fx findAppFixtures, updated map[string]*appsv
 				Items:    fx.daemonsets,
 			}
 			_ = json.NewEncoder(w).Encode(&list)
+		case strings.HasSuffix(p, "/horizontalpodautoscalers") && fx.hpas != nil:
+			list := autoscalingv2.HorizontalPodAutoscalerList{
+				TypeMeta: metav1.TypeMeta{Kind: "HorizontalPodAutoscalerList", APIVersion: "autoscaling/v2"},
+				Items:    fx.hpas,
+			}
+			_ = json.NewEncoder(w).Encode(&list)
 		default:
 			http.NotFound(w, r)
 		}
@@ -161,6 +170,24 @@ func TestFindAppInCluster_InvalidNamespace(t *testing.T) {
 	}
 }
 
+func TestFindAppInCluster_ReturnsListDenial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"Forbidden","code":403,"message":"listing across all namespaces is not allowed"}`))
+	}))
+	defer server.Close()
+
+	srv := &Server{}
+	got, err := srv.findAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "")
+	if err == nil {
+		t.Fatalf("expected the list denial, got instances %+v", got)
+	}
+	if !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("error %q does not carry the denial", err)
+	}
+}
+
 func TestFindAppInCluster_MatchesAcrossKinds(t *testing.T) {
 	fx := findAppFixtures{
 		deployments: []appsv1.Deployment{
@@ -234,7 +261,7 @@ func TestScaleAppInCluster_UpdatesReplicasAndReturnsOld(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	res, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", 5)
+	res, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 5, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -263,7 +290,7 @@ func TestScaleAppInCluster_NilSpecReplicasDefaultsToOne(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	res, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "default", 3)
+	res, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "default", "", 3, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -281,7 +308,7 @@ func TestScaleAppInCluster_NotFound(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	_, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", 3)
+	_, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 3, false)
 	if err == nil {
 		t.Fatal("expected not-found error, got nil")
 	}
@@ -297,7 +324,7 @@ func TestScaleAppInCluster_ListError(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	if _, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", 3); err == nil {
+	if _, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 3, false); err == nil {
 		t.Fatal("expected list error")
 	}
 }
@@ -311,7 +338,7 @@ func TestPatchAppInCluster_PatchesFirstMatch(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	res, err := srv.patchAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", []byte(`{"spec":{"replicas":9}}`), types.MergePatchType)
+	res, err := srv.patchAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", []byte(`{"spec":{"replicas":9}}`), types.MergePatchType, false)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -336,7 +363,7 @@ func TestPatchAppInCluster_NotFound(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	if _, err := srv.patchAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", []byte(`{}`), types.MergePatchType); err == nil {
+	if _, err := srv.patchAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", []byte(`{}`), types.MergePatchType, false); err == nil {
 		t.Fatal("expected not-found error")
 	}
 }
@@ -348,7 +375,162 @@ func TestPatchAppInCluster_ListError(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	if _, err := srv.patchAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "app", []byte(`{}`), types.MergePatchType); err == nil {
+	if _, err := srv.patchAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "app", "", []byte(`{}`), types.MergePatchType, false); err == nil {
 		t.Fatal("expected list error")
 	}
 }
+
+func TestScaleAppInCluster_FindsAppOutsideDefaultNamespace(t *testing.T) {
+	fx := findAppFixtures{
+		deployments: []appsv1.Deployment{mkDeployment("demo", "shop", "demo", 2, 2)},
+	}
+	updated := map[string]*appsv1.Deployment{}
+	server := startAppsServer(t, fx, updated)
+	defer server.Close()
+
+	srv := &Server{}
+	res, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 4, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m := res.(map[string]interface{})
+	if m["namespace"] != "shop" || m["kind"] != "Deployment" || updated["demo"] == nil {
+		t.Fatalf("unexpected result: %+v", m)
+	}
+}
+
+func TestScaleAppInCluster_AmbiguousMatches(t *testing.T) {
+	fx := findAppFixtures{
+		deployments:  []appsv1.Deployment{mkDeployment("demo-web", "shop", "demo", 2, 2)},
+		statefulsets: []appsv1.StatefulSet{mkStatefulSet("demo-db", "shop", "demo", 1, 1)},
+	}
+	server := startAppsServer(t, fx, nil)
+	defer server.Close()
+
+	srv := &Server{}
+	_, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 3, false)
+	if err == nil {
+		t.Fatal("expected an ambiguity error")
+	}
+	for _, want := range []string{"Deployment shop/demo-web", "StatefulSet shop/demo-db", "set namespace or kind"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestScaleAppInCluster_PrefersExactNameAndHonorsKind(t *testing.T) {
+	fx := findAppFixtures{
+		deployments: []appsv1.Deployment{
+			mkDeployment("demo", "shop", "demo", 2, 2),
+			mkDeployment("demo-worker", "shop", "demo", 1, 1),
+		},
+		statefulsets: []appsv1.StatefulSet{mkStatefulSet("demo-db", "shop", "demo", 1, 1)},
+	}
+	updated := map[string]*appsv1.Deployment{}
+	server := startAppsServer(t, fx, updated)
+	defer server.Close()
+
+	srv := &Server{}
+	if _, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "deployment", 3, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated["demo"] == nil || updated["demo-worker"] != nil {
+		t.Fatalf("expected only the exact-name deployment to be scaled, got %v", updated)
+	}
+	if _, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "DaemonSet", 3, false); err == nil {
+		t.Fatal("DaemonSets cannot be scaled")
+	}
+}
+
+func TestScaleAppInCluster_DaemonSetOnly(t *testing.T) {
+	fx := findAppFixtures{
+		daemonsets: []appsv1.DaemonSet{mkDaemonSet("demo", "shop", "demo", 3, 3)},
+	}
+	server := startAppsServer(t, fx, nil)
+	defer server.Close()
+
+	srv := &Server{}
+	_, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 3, false)
+	if err == nil || !strings.Contains(err.Error(), "is DaemonSet shop/demo") {
+		t.Fatalf("expected a DaemonSet error, got %v", err)
+	}
+}
+
+func TestScaleAppInCluster_SkipsSystemNamespacesUnlessNamed(t *testing.T) {
+	fx := findAppFixtures{
+		deployments: []appsv1.Deployment{mkDeployment("demo", "kube-system", "demo", 1, 1)},
+	}
+	server := startAppsServer(t, fx, nil)
+	defer server.Close()
+
+	srv := &Server{}
+	_, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 3, false)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("expected system namespaces to be skipped, got %v", err)
+	}
+}
+
+func TestScaleAppInCluster_RefusesHPAManagedWorkloadWithoutOverride(t *testing.T) {
+	fx := findAppFixtures{
+		deployments: []appsv1.Deployment{mkDeployment("demo", "shop", "demo", 2, 2)},
+		hpas: []autoscalingv2.HorizontalPodAutoscaler{{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "shop"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "demo"},
+				MaxReplicas:    10,
+			},
+		}},
+	}
+	updated := map[string]*appsv1.Deployment{}
+	server := startAppsServer(t, fx, updated)
+	defer server.Close()
+
+	srv := &Server{}
+	_, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 5, false)
+	if err == nil || !strings.Contains(err.Error(), "HorizontalPodAutoscaler demo scales it between 1 and 10 replicas") || !strings.Contains(err.Error(), "override: true") {
+		t.Fatalf("expected an HPA conflict error, got %v", err)
+	}
+	if updated["demo"] != nil {
+		t.Fatal("a refused scale must not update the deployment")
+	}
+
+	res, err := srv.scaleAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", 5, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conflicts, _ := res.(map[string]interface{})["conflicts"].([]controllerConflict)
+	if len(conflicts) != 1 || conflicts[0].Controller != "HorizontalPodAutoscaler" || updated["demo"] == nil {
+		t.Fatalf("expected the scale to go ahead and report the HPA, got %+v", res)
+	}
+}
+
+func TestPatchAppInCluster_RefusesGitOpsManagedWorkloadWithoutOverride(t *testing.T) {
+	dep := mkDeployment("demo", "shop", "demo", 2, 2)
+	dep.Labels["kustomize.toolkit.fluxcd.io/name"] = "apps"
+	fx := findAppFixtures{
+		deployments: []appsv1.Deployment{dep},
+		hpas: []autoscalingv2.HorizontalPodAutoscaler{{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "shop"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "demo"},
+				MaxReplicas:    10,
+			},
+		}},
+	}
+	updated := map[string]*appsv1.Deployment{}
+	server := startAppsServer(t, fx, updated)
+	defer server.Close()
+
+	srv := &Server{}
+	_, err := srv.patchAppInCluster(context.Background(), clientForServer(t, server), "cA", "demo", "", "", []byte(`{"spec":{"template":{"metadata":{"labels":{"tier":"web"}}}}}`), types.MergePatchType, false)
+	if err == nil || !strings.Contains(err.Error(), "Flux (label kustomize.toolkit.fluxcd.io/name=apps)") {
+		t.Fatalf("expected a Flux conflict error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "HorizontalPodAutoscaler") {
+		t.Fatalf("a patch without replicas must not conflict with the HPA: %v", err)
+	}
+	if updated["demo"] != nil {
+		t.Fatal("a refused patch must not reach the server")
+	}
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+const (
+	// defaultLogTailLines is how many lines per container get_app_logs asks
+	// for when the caller does not say.
+	defaultLogTailLines = 100
+	// defaultLogMaxBytes bounds the log text a single get_app_logs call keeps
+	// across every cluster, pod, and container.
+	defaultLogMaxBytes = 4 << 20
+	// defaultLogMaxBytesPerContainer bounds what one container may contribute,
+	// so a single chatty pod cannot consume the whole budget.
+	defaultLogMaxBytesPerContainer = 256 << 10
+	// maxConcurrentLogStreams caps open log streams per cluster.
+	maxConcurrentLogStreams = 8
+	// maxLogLineBytes is the longest line read before a stream is abandoned.
+	maxLogLineBytes = 64 << 10
+)
+
+// logQuery describes which log lines to fetch and how much of them to keep.
+type logQuery struct {
+	Tail  int64
+	Since string
+	// SampleEvery keeps every Nth line of each container; 0 or 1 keeps all.
+	SampleEvery int
+	// MaxBytesPerContainer caps each container's stream; 0 means no cap.
+	MaxBytesPerContainer int64
+	// Budget is shared by every cluster in one call; nil means unbounded.
+	Budget *logBudget
+}
+
+// logBudget is a byte allowance shared by concurrent log readers.
+type logBudget struct {
+	remaining atomic.Int64
+	truncated atomic.Bool
+}
+
+func newLogBudget(maxBytes int64) *logBudget {
+	b := &logBudget{}
+	b.remaining.Store(maxBytes)
+	return b
+}
+
+// take reserves n bytes, reporting false (and marking the output truncated)
+// once the budget cannot cover them.
+func (b *logBudget) take(n int64) bool {
+	if b == nil {
+		return true
+	}
+	for {
+		remaining := b.remaining.Load()
+		if remaining < n {
+			b.truncated.Store(true)
+			return false
+		}
+		if b.remaining.CompareAndSwap(remaining, remaining-n) {
+			return true
+		}
+	}
+}
+
+// markTruncated records that some output was dropped by a per-stream cap.
+func (b *logBudget) markTruncated() {
+	if b != nil {
+		b.truncated.Store(true)
+	}
+}
+
+// Truncated reports whether any log output was dropped to stay in budget.
+func (b *logBudget) Truncated() bool {
+	return b != nil && b.truncated.Load()
+}
+
+// readLogLines scans one container's log stream line by line, applying the
+// query's sampling, per-container cap, and shared budget as it goes so the
+// whole stream is never buffered.
+func readLogLines(stream io.Reader, query logQuery, entry func(line string) LogEntry) []LogEntry {
+	if query.MaxBytesPerContainer > 0 {
+		// LimitBytes is also sent to the API server; this guards servers
+		// that ignore it. One extra byte distinguishes "exactly at the cap"
+		// from "over the cap".
+		stream = io.LimitReader(stream, query.MaxBytesPerContainer+1)
+	}
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 4096), maxLogLineBytes)
+
+	var entries []LogEntry
+	var read int64
+	lineNo := 0
+	for scanner.Scan() {
+		line := scanner.Text()
+		read += int64(len(line))
+		if query.MaxBytesPerContainer > 0 && read > query.MaxBytesPerContainer {
+			query.Budget.markTruncated()
+			break
+		}
+		read++ // the newline the scanner stripped
+		if line == "" {
+			continue
+		}
+		lineNo++
+		if query.SampleEvery > 1 && (lineNo-1)%query.SampleEvery != 0 {
+			continue
+		}
+		if !query.Budget.take(int64(len(line))) {
+			break
+		}
+		entries = append(entries, entry(line))
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		query.Budget.markTruncated()
+	}
+	return entries
+}
@@ -87,7 +87,7 @@ func startPodsAndLogsServer(t *testing.T, pods []corev1.Pod, logLines map[string
 
 func TestGetLogsFromCluster_InvalidNamespace(t *testing.T) {
 	srv := &Server{}
-	if _, err := srv.getLogsFromCluster(context.Background(), nil, "c1", "demo", "kube-system", 10, ""); err == nil {
+	if _, err := srv.getLogsFromCluster(context.Background(), nil, "c1", "demo", "kube-system", logQuery{Tail: 10}); err == nil {
 		t.Fatal("expected error for protected namespace")
 	}
 }
@@ -99,7 +99,7 @@ func TestGetLogsFromCluster_ListError(t *testing.T) {
 	defer badSrv.Close()
 
 	srv := &Server{}
-	if _, err := srv.getLogsFromCluster(context.Background(), clientForServer(t, badSrv), "c1", "demo", "", 10, ""); err == nil {
+	if _, err := srv.getLogsFromCluster(context.Background(), clientForServer(t, badSrv), "c1", "demo", "", logQuery{Tail: 10}); err == nil {
 		t.Fatal("expected list error")
 	}
 }
@@ -123,7 +123,7 @@ func TestGetLogsFromCluster_AggregatesLinesAcrossPodsAndContainers(t *testing.T)
 	defer server.Close()
 
 	srv := &Server{}
-	got, err := srv.getLogsFromCluster(context.Background(), clientForServer(t, server), "cA", "demo", "app", 100, "")
+	got, err := srv.getLogsFromCluster(context.Background(), clientForServer(t, server), "cA", "demo", "app", logQuery{Tail: 100})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -169,7 +169,7 @@ func TestGetLogsFromCluster_SinceDurationAccepted(t *testing.T) {
 	defer server.Close()
 
 	srv := &Server{}
-	got, err := srv.getLogsFromCluster(context.Background(), clientForServer(t, server), "c1", "demo", "app", 50, "1h")
+	got, err := srv.getLogsFromCluster(context.Background(), clientForServer(t, server), "c1", "demo", "app", logQuery{Tail: 50, Since: "1h"})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -178,7 +178,7 @@ func TestGetLogsFromCluster_SinceDurationAccepted(t *testing.T) {
 	}
 
 	// Malformed duration must be silently ignored (function must not fail).
-	got, err = srv.getLogsFromCluster(context.Background(), clientForServer(t, server), "c1", "demo", "app", 50, "not-a-duration")
+	got, err = srv.getLogsFromCluster(context.Background(), clientForServer(t, server), "c1", "demo", "app", logQuery{Tail: 50, Since: "not-a-duration"})
 	if err != nil {
 		t.Fatalf("unexpected error on bad duration: %v", err)
 	}
@@ -251,3 +251,133 @@ func TestHandleGetAppLogs_TailDefaultsTo100(t *testing.T) {
 		t.Fatalf("logCount = %v, want 1", m["logCount"])
 	}
 }
+
+func TestHandleGetAppLogs_PagesClustersAndReportsProgress(t *testing.T) {
+	fx := []corev1.Pod{mkPod("demo-1", "app", "demo", "web")}
+	logs := map[string]map[string][]string{"demo-1": {"web": {"line"}}}
+	servers := map[string]string{}
+	for _, name := range []string{"c1", "c2", "c3"} {
+		srv := startPodsAndLogsServer(t, fx, logs)
+		defer srv.Close()
+		servers[name] = srv.URL
+	}
+
+	mgr, err := multicluster.NewClientManager(writeKubeconfig(t, servers))
+	if err != nil {
+		t.Fatalf("mgr: %v", err)
+	}
+	server := newServerWithManager(mgr)
+
+	var progress []int
+	ctx := withProgress(context.Background(), func(done, total int, message string) {
+		if total != 2 {
+			t.Errorf("progress total = %d, want 2", total)
+		}
+		progress = append(progress, done)
+	})
+	res, err := server.handleGetAppLogs(ctx, json.RawMessage(`{"app":"demo","namespace":"app","page_size":2}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m := res.(map[string]interface{})
+	if m["logCount"] != 2 || m["continue"] != "c2" {
+		t.Fatalf("first page = logCount %v continue %v, want 2 and c2", m["logCount"], m["continue"])
+	}
+	if fmt.Sprint(progress) != "[1 2]" {
+		t.Fatalf("progress = %v, want [1 2]", progress)
+	}
+
+	res, err = server.handleGetAppLogs(context.Background(), json.RawMessage(`{"app":"demo","namespace":"app","page_size":2,"continue":"c2"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m = res.(map[string]interface{})
+	if m["logCount"] != 1 {
+		t.Fatalf("second page logCount = %v, want 1", m["logCount"])
+	}
+	if _, ok := m["continue"]; ok {
+		t.Fatalf("last page must not carry a continue token: %v", m["continue"])
+	}
+	if got := m["logs"].([]LogEntry)[0].Cluster; got != "c3" {
+		t.Fatalf("second page cluster = %q, want c3", got)
+	}
+}
+
+func collectLines(entries []LogEntry) []string {
+	lines := make([]string, 0, len(entries))
+	for _, e := range entries {
+		lines = append(lines, e.Message)
+	}
+	return lines
+}
+
+func TestReadLogLines_SamplesEveryNthLine(t *testing.T) {
+	stream := strings.NewReader("l1\nl2\nl3\n\nl4\nl5\n")
+	got := readLogLines(stream, logQuery{SampleEvery: 2}, func(line string) LogEntry {
+		return LogEntry{Message: line}
+	})
+	if fmt.Sprint(collectLines(got)) != "[l1 l3 l5]" {
+		t.Fatalf("sampled lines = %v, want [l1 l3 l5]", collectLines(got))
+	}
+}
+
+func TestReadLogLines_PerContainerCapTruncates(t *testing.T) {
+	budget := newLogBudget(1 << 20)
+	stream := strings.NewReader("aaaa\nbbbb\ncccc\n")
+	got := readLogLines(stream, logQuery{MaxBytesPerContainer: 10, Budget: budget}, func(line string) LogEntry {
+		return LogEntry{Message: line}
+	})
+	if fmt.Sprint(collectLines(got)) != "[aaaa bbbb]" {
+		t.Fatalf("capped lines = %v, want [aaaa bbbb]", collectLines(got))
+	}
+	if !budget.Truncated() {
+		t.Fatal("expected per-container cap to mark output truncated")
+	}
+
+	exact := newLogBudget(1 << 20)
+	got = readLogLines(strings.NewReader("aaaa\nbbbb"), logQuery{MaxBytesPerContainer: 9, Budget: exact}, func(line string) LogEntry {
+		return LogEntry{Message: line}
+	})
+	if len(got) != 2 || exact.Truncated() {
+		t.Fatalf("stream exactly at cap: lines = %v, truncated = %v", collectLines(got), exact.Truncated())
+	}
+}
+
+func TestReadLogLines_SharedBudgetStopsAllReaders(t *testing.T) {
+	budget := newLogBudget(6)
+	entry := func(line string) LogEntry { return LogEntry{Message: line} }
+
+	first := readLogLines(strings.NewReader("abc\ndef\nghi\n"), logQuery{Budget: budget}, entry)
+	second := readLogLines(strings.NewReader("xyz\n"), logQuery{Budget: budget}, entry)
+
+	if len(first) != 2 || len(second) != 0 {
+		t.Fatalf("first = %v, second = %v; want two lines then none", collectLines(first), collectLines(second))
+	}
+	if !budget.Truncated() {
+		t.Fatal("expected exhausted budget to mark output truncated")
+	}
+}
+
+func TestHandleGetAppLogs_ReportsTruncationWhenOverBudget(t *testing.T) {
+	pods := []corev1.Pod{mkPod("demo-1", "app", "demo", "web")}
+	logs := map[string]map[string][]string{"demo-1": {"web": {"0123456789", "0123456789"}}}
+	srv := startPodsAndLogsServer(t, pods, logs)
+	defer srv.Close()
+
+	mgr, err := multicluster.NewClientManager(writeKubeconfig(t, map[string]string{"c1": srv.URL}))
+	if err != nil {
+		t.Fatalf("mgr: %v", err)
+	}
+	res, err := newServerWithManager(mgr).handleGetAppLogs(context.Background(), json.RawMessage(`{"app":"demo","namespace":"app","max_bytes":15}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m := res.(map[string]interface{})
+	if m["logCount"] != 1 || m["truncated"] != true {
+		t.Fatalf("logCount = %v, truncated = %v; want 1 and true", m["logCount"], m["truncated"])
+	}
+
+	if _, err := newServerWithManager(mgr).handleGetAppLogs(context.Background(), json.RawMessage(`{"app":"demo","sample_every":-1}`)); err == nil {
+		t.Fatal("expected error for negative sample_every")
+	}
+}
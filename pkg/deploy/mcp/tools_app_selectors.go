@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/rest"
+)
+
+// appLabelKeys are the labels matchesApp recognises, in match order.
+var appLabelKeys = []string{"app", "app.kubernetes.io/name", "app.kubernetes.io/instance"}
+
+// partialMetadataListAccept asks the API server for a metadata-only list,
+// falling back to a full JSON list on servers that do not support it.
+const partialMetadataListAccept = "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1,application/json"
+
+// appLabelSelectors returns one equality selector per app label key. Label
+// selectors cannot OR across keys, so each key is queried separately. App
+// names that are not valid label values cannot match a label at all.
+func appLabelSelectors(appName string) []string {
+	if len(validation.IsValidLabelValue(appName)) > 0 {
+		return nil
+	}
+	selectors := make([]string, 0, len(appLabelKeys))
+	for _, key := range appLabelKeys {
+		selectors = append(selectors, labels.Set{key: appName}.String())
+	}
+	return selectors
+}
+
+// appObjectQuery adapts a typed client's list and get calls for
+// listAppObjects.
+type appObjectQuery[T any] struct {
+	// rest is the typed client's REST client, used for the metadata-only list.
+	rest     rest.Interface
+	resource string
+	list     func(ctx context.Context, opts metav1.ListOptions) ([]T, error)
+	get      func(ctx context.Context, namespace, name string) (*T, error)
+	meta     func(item *T) metav1.Object
+}
+
+// listAppObjects returns the objects in namespace that matchesApp accepts
+// without listing full objects for the whole namespace. Label matches are
+// pushed to the API server as selectors; the name-contains fallback scans a
+// metadata-only list and fetches just the objects whose names match.
+func listAppObjects[T any](ctx context.Context, namespace, appName string, q appObjectQuery[T]) ([]T, error) {
+	seen := make(map[string]bool)
+	var items []T
+	add := func(item T) {
+		obj := q.meta(&item)
+		key := obj.GetNamespace() + "/" + obj.GetName()
+		// Servers that ignore the selector still return correct results.
+		if seen[key] || !matchesApp(obj.GetName(), obj.GetLabels(), appName) {
+			return
+		}
+		seen[key] = true
+		items = append(items, item)
+	}
+
+	for _, selector := range appLabelSelectors(appName) {
+		listed, err := q.list(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range listed {
+			add(item)
+		}
+	}
+
+	metas, err := listObjectMetadata(ctx, q.rest, q.resource, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range metas.Items {
+		if seen[m.Namespace+"/"+m.Name] || !strings.Contains(m.Name, appName) {
+			continue
+		}
+		item, err := q.get(ctx, m.Namespace, m.Name)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		add(*item)
+	}
+
+	return items, nil
+}
+
+// listObjectMetadata lists only object metadata for resource in namespace
+// (all namespaces when empty).
+func listObjectMetadata(ctx context.Context, client rest.Interface, resource, namespace string) (*metav1.PartialObjectMetadataList, error) {
+	raw, err := client.Get().
+		Namespace(namespace).
+		Resource(resource).
+		SetHeader("Accept", partialMetadataListAccept).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list metav1.PartialObjectMetadataList
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode %s metadata: %w", resource, err)
+	}
+	return &list, nil
+}
@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAppLabelSelectors(t *testing.T) {
+	got := appLabelSelectors("demo")
+	want := []string{"app=demo", "app.kubernetes.io/name=demo", "app.kubernetes.io/instance=demo"}
+	if strings.Join(got, ";") != strings.Join(want, ";") {
+		t.Fatalf("appLabelSelectors() = %v, want %v", got, want)
+	}
+
+	// Longer than 63 characters: valid object name, invalid label value.
+	if got := appLabelSelectors(strings.Repeat("a", 64)); got != nil {
+		t.Fatalf("appLabelSelectors(long) = %v, want nil", got)
+	}
+}
+
+func TestGetLogsFromCluster_PushesSelectorsAndFetchesNameMatchesByMetadata(t *testing.T) {
+	labelled := mkPod("web-1", "app", "demo", "web")
+	named := mkPod("demo-worker", "app", "", "worker")
+	unrelated := mkPod("other", "app", "", "web")
+
+	var mu sync.Mutex
+	var selectors []string
+	var metadataLists, gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/log"):
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("line\n"))
+		case strings.HasSuffix(r.URL.Path, "/pods/demo-worker"):
+			gets++
+			_ = json.NewEncoder(w).Encode(&named)
+		case strings.HasSuffix(r.URL.Path, "/pods"):
+			if strings.Contains(r.Header.Get("Accept"), "as=PartialObjectMetadataList") {
+				metadataLists++
+				list := metav1.PartialObjectMetadataList{
+					TypeMeta: metav1.TypeMeta{Kind: "PartialObjectMetadataList", APIVersion: "meta.k8s.io/v1"},
+				}
+				for _, pod := range []corev1.Pod{labelled, named, unrelated} {
+					list.Items = append(list.Items, metav1.PartialObjectMetadata{ObjectMeta: pod.ObjectMeta})
+				}
+				_ = json.NewEncoder(w).Encode(&list)
+				return
+			}
+			selector := r.URL.Query().Get("labelSelector")
+			selectors = append(selectors, selector)
+			list := corev1.PodList{TypeMeta: metav1.TypeMeta{Kind: "PodList", APIVersion: "v1"}}
+			if selector == "app=demo" {
+				list.Items = []corev1.Pod{labelled}
+			}
+			_ = json.NewEncoder(w).Encode(&list)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	got, err := (&Server{}).getLogsFromCluster(context.Background(), clientForServer(t, srv), "c1", "demo", "app", logQuery{Tail: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pods := map[string]bool{}
+	for _, entry := range got {
+		pods[entry.Pod] = true
+	}
+	if len(pods) != 2 || !pods["web-1"] || !pods["demo-worker"] {
+		t.Fatalf("pods with logs = %v, want web-1 and demo-worker", pods)
+	}
+	if len(selectors) != len(appLabelKeys) {
+		t.Fatalf("label-selected lists = %v, want one per app label", selectors)
+	}
+	for _, selector := range selectors {
+		if selector == "" {
+			t.Fatal("pods were listed without a label selector")
+		}
+	}
+	if metadataLists != 1 || gets != 1 {
+		t.Fatalf("metadata lists = %d, gets = %d; want 1 and 1", metadataLists, gets)
+	}
+}
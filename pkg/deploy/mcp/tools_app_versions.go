@@ -0,0 +1,360 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+)
+
+// gitSHAKeys are the labels and annotations, checked in order, that build
+// tooling commonly uses to record the commit an image was built from.
+// Kubernetes does not expose an image's own OCI labels, so they are looked
+// up on the pod template and the workload instead.
+var gitSHAKeys = []string{
+	"org.opencontainers.image.revision",
+	"org.label-schema.vcs-ref",
+	"vcs-ref",
+	"git-sha",
+	"git-commit",
+}
+
+// gitSHATag matches image tags that are abbreviated or full commit SHAs.
+var gitSHATag = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// Skew bases: how the latest version of a container was chosen.
+const (
+	skewBasisSemver    = "semver"
+	skewBasisMajority  = "majority"
+	skewBasisUndecided = "undecided"
+)
+
+// ContainerVersion is the image one container of an app runs.
+type ContainerVersion struct {
+	Container string `json:"container"`
+	Image     string `json:"image"`
+	Tag       string `json:"tag,omitempty"`
+	// Digests are the image digests the app's running pods resolved the
+	// image to. More than one means pods run different builds of a tag.
+	Digests []string `json:"digests,omitempty"`
+	GitSHA  string   `json:"gitSha,omitempty"`
+}
+
+// AppVersionInstance is the set of images one workload of an app runs in a
+// cluster.
+type AppVersionInstance struct {
+	Cluster    string             `json:"cluster"`
+	Namespace  string             `json:"namespace"`
+	Name       string             `json:"name"`
+	Kind       string             `json:"kind"`
+	Containers []ContainerVersion `json:"containers"`
+}
+
+// ImageVersion is one image of a container and where it runs.
+type ImageVersion struct {
+	Image    string   `json:"image"`
+	Tag      string   `json:"tag,omitempty"`
+	GitSHA   string   `json:"gitSha,omitempty"`
+	Digests  []string `json:"digests,omitempty"`
+	Clusters []string `json:"clusters"`
+}
+
+// VersionSkew reports a container that does not run the same image on
+// every cluster, or whose tag resolves to different digests.
+type VersionSkew struct {
+	Container string `json:"container"`
+	// Latest is the image the rest of the fleet should converge on, chosen
+	// as described by Basis. It is empty when Basis is undecided.
+	Latest string `json:"latest,omitempty"`
+	Basis  string `json:"basis"`
+	// OutdatedClusters run an image other than Latest.
+	OutdatedClusters []string       `json:"outdatedClusters,omitempty"`
+	DigestMismatch   bool           `json:"digestMismatch,omitempty"`
+	Versions         []ImageVersion `json:"versions"`
+}
+
+// AppVersions is the image inventory of an app across the fleet.
+type AppVersions struct {
+	App          string               `json:"app"`
+	Instances    []AppVersionInstance `json:"instances"`
+	SkewDetected bool                 `json:"skewDetected"`
+	Skew         []VersionSkew        `json:"skew,omitempty"`
+	Issues       []string             `json:"issues,omitempty"`
+}
+
+// handleGetAppVersions reports the images an app runs on each cluster and
+// flags version skew across the fleet.
+func (s *Server) handleGetAppVersions(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		App       string `json:"app"`
+		Namespace string `json:"namespace,omitempty"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if err := claude.ValidateK8sName(params.App); err != nil {
+		return nil, fmt.Errorf("invalid app name: %w", err)
+	}
+	if params.Namespace != "" {
+		if err := claude.ValidateK8sNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+
+	results, err := s.executor.Execute(ctx, "", func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return appVersionsInCluster(ctx, client, clusterName, params.App, params.Namespace)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versions := AppVersions{App: claude.SanitizeForPrompt(params.App)}
+	for _, result := range results {
+		if result.Error != "" {
+			versions.Issues = append(versions.Issues, fmt.Sprintf("%s: %s", result.Cluster, result.Error))
+			continue
+		}
+		if instances, ok := result.Result.([]AppVersionInstance); ok {
+			versions.Instances = append(versions.Instances, instances...)
+		}
+	}
+	versions.Skew = versionSkew(versions.Instances)
+	versions.SkewDetected = len(versions.Skew) > 0
+	return versions, nil
+}
+
+// appVersionsInCluster returns the images of every workload of appName in
+// a cluster, with the digests its pods are running.
+func appVersionsInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace string) ([]AppVersionInstance, error) {
+	ns := namespace
+	if ns == "" {
+		ns = metav1.NamespaceAll
+	}
+	workloads, err := listAppWorkloads(ctx, client, ns, appName)
+	if err != nil && len(workloads) == 0 {
+		return nil, err
+	}
+
+	instances := make([]AppVersionInstance, 0, len(workloads))
+	for _, w := range workloads {
+		template, selector := w.podTemplate()
+		inst := AppVersionInstance{Cluster: clusterName, Namespace: w.meta().GetNamespace(), Name: w.meta().GetName(), Kind: w.Kind}
+
+		digests := make(map[string]map[string]bool)
+		if selector != nil {
+			// Without pods the spec images are still reported, so a failed
+			// pod list only loses the digests.
+			sel, _ := metav1.LabelSelectorAsSelector(selector)
+			if sel != nil && !sel.Empty() {
+				pods, _ := client.CoreV1().Pods(inst.Namespace).List(ctx, metav1.ListOptions{LabelSelector: sel.String()})
+				if pods != nil {
+					digests = podImageDigests(pods.Items)
+				}
+			}
+		}
+
+		workloadSHA := recordedGitSHA(template.Labels, template.Annotations, w.meta().GetLabels(), w.meta().GetAnnotations())
+		for _, c := range template.Spec.Containers {
+			_, tag, _ := splitImage(c.Image)
+			cv := ContainerVersion{Container: c.Name, Image: c.Image, Tag: tag, Digests: sortedKeys(digests[c.Name]), GitSHA: workloadSHA}
+			if gitSHATag.MatchString(tag) {
+				cv.GitSHA = tag
+			}
+			inst.Containers = append(inst.Containers, cv)
+		}
+		instances = append(instances, inst)
+	}
+	return instances, nil
+}
+
+// podTemplate returns the pod template and selector of the workload.
+func (w appWorkload) podTemplate() (corev1.PodTemplateSpec, *metav1.LabelSelector) {
+	switch {
+	case w.deployment != nil:
+		return w.deployment.Spec.Template, w.deployment.Spec.Selector
+	case w.statefulSet != nil:
+		return w.statefulSet.Spec.Template, w.statefulSet.Spec.Selector
+	default:
+		return w.daemonSet.Spec.Template, w.daemonSet.Spec.Selector
+	}
+}
+
+// podImageDigests returns, by container name, the image digests the pods'
+// containers are running.
+func podImageDigests(pods []corev1.Pod) map[string]map[string]bool {
+	digests := make(map[string]map[string]bool)
+	for _, pod := range pods {
+		for _, status := range pod.Status.ContainerStatuses {
+			_, _, digest := splitImage(status.ImageID)
+			if digest == "" && strings.HasPrefix(status.ImageID, "sha256:") {
+				// containerd reports the local image ID when it has no
+				// repository digest; it still tells builds apart.
+				digest = status.ImageID
+			}
+			if digest == "" {
+				continue
+			}
+			if digests[status.Name] == nil {
+				digests[status.Name] = make(map[string]bool)
+			}
+			digests[status.Name][digest] = true
+		}
+	}
+	return digests
+}
+
+// recordedGitSHA returns the first git SHA recorded under gitSHAKeys in maps.
+func recordedGitSHA(maps ...map[string]string) string {
+	for _, key := range gitSHAKeys {
+		for _, m := range maps {
+			if v := m[key]; v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// splitImage splits an image reference such as
+// registry:5000/org/app:v1.2@sha256:abc into its repository, tag, and
+// digest. It also accepts container status image IDs, which may carry a
+// runtime prefix such as docker-pullable://.
+func splitImage(image string) (repo, tag, digest string) {
+	if i := strings.Index(image, "://"); i >= 0 {
+		image = image[i+len("://"):]
+	}
+	repo = image
+	if i := strings.Index(repo, "@"); i >= 0 {
+		repo, digest = repo[:i], repo[i+1:]
+	}
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo, tag = repo[:i], repo[i+1:]
+	}
+	return repo, tag, digest
+}
+
+// versionSkew groups the instances' images by container name and reports
+// the containers that run more than one image, or one tag resolving to
+// more than one digest, across clusters.
+func versionSkew(instances []AppVersionInstance) []VersionSkew {
+	type imageUse struct {
+		version  ImageVersion
+		clusters map[string]bool
+		digests  map[string]bool
+	}
+	byContainer := make(map[string]map[string]*imageUse)
+	for _, inst := range instances {
+		for _, c := range inst.Containers {
+			images := byContainer[c.Container]
+			if images == nil {
+				images = make(map[string]*imageUse)
+				byContainer[c.Container] = images
+			}
+			use := images[c.Image]
+			if use == nil {
+				use = &imageUse{
+					version:  ImageVersion{Image: c.Image, Tag: c.Tag, GitSHA: c.GitSHA},
+					clusters: make(map[string]bool),
+					digests:  make(map[string]bool),
+				}
+				images[c.Image] = use
+			}
+			use.clusters[inst.Cluster] = true
+			for _, d := range c.Digests {
+				use.digests[d] = true
+			}
+		}
+	}
+
+	containers := make([]string, 0, len(byContainer))
+	for name := range byContainer {
+		containers = append(containers, name)
+	}
+	sort.Strings(containers)
+
+	var skew []VersionSkew
+	for _, name := range containers {
+		images := byContainer[name]
+		report := VersionSkew{Container: name}
+		for _, use := range images {
+			use.version.Clusters = sortedKeys(use.clusters)
+			use.version.Digests = sortedKeys(use.digests)
+			if len(use.version.Digests) > 1 {
+				report.DigestMismatch = true
+			}
+			report.Versions = append(report.Versions, use.version)
+		}
+		if len(report.Versions) == 1 && !report.DigestMismatch {
+			continue
+		}
+		sort.Slice(report.Versions, func(i, j int) bool { return report.Versions[i].Image < report.Versions[j].Image })
+
+		report.Latest, report.Basis = latestImage(report.Versions)
+		if report.Latest != "" {
+			outdated := make(map[string]bool)
+			for _, v := range report.Versions {
+				if v.Image == report.Latest {
+					continue
+				}
+				for _, cluster := range v.Clusters {
+					outdated[cluster] = true
+				}
+			}
+			report.OutdatedClusters = sortedKeys(outdated)
+		}
+		skew = append(skew, report)
+	}
+	return skew
+}
+
+// latestImage picks the image the fleet should converge on: the highest
+// version when every tag is a semantic version, otherwise the image that
+// runs on the most clusters. A tie for most clusters decides nothing.
+func latestImage(versions []ImageVersion) (string, string) {
+	var (
+		latest   string
+		highest  semver.Version
+		semverOK = true
+	)
+	for _, v := range versions {
+		parsed, err := semver.ParseTolerant(v.Tag)
+		if err != nil {
+			semverOK = false
+			break
+		}
+		if latest == "" || parsed.GT(highest) {
+			latest, highest = v.Image, parsed
+		}
+	}
+	if semverOK {
+		return latest, skewBasisSemver
+	}
+
+	latest, most, tied := "", 0, false
+	for _, v := range versions {
+		switch {
+		case len(v.Clusters) > most:
+			latest, most, tied = v.Image, len(v.Clusters), false
+		case len(v.Clusters) == most:
+			tied = true
+		}
+	}
+	if tied {
+		return "", skewBasisUndecided
+	}
+	return latest, skewBasisMajority
+}
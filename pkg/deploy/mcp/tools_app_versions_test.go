@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func withImage(d appsv1.Deployment, image string) appsv1.Deployment {
+	d.Spec.Template.Spec.Containers = []corev1.Container{{Name: "web", Image: image}}
+	return d
+}
+
+func TestSplitImage(t *testing.T) {
+	for _, tc := range []struct {
+		in, repo, tag, digest string
+	}{
+		{"nginx", "nginx", "", ""},
+		{"nginx:1.27", "nginx", "1.27", ""},
+		{"registry:5000/org/app:v1.2", "registry:5000/org/app", "v1.2", ""},
+		{"registry:5000/org/app", "registry:5000/org/app", "", ""},
+		{"org/app:v1@sha256:abc", "org/app", "v1", "sha256:abc"},
+		{"docker-pullable://org/app@sha256:abc", "org/app", "", "sha256:abc"},
+	} {
+		repo, tag, digest := splitImage(tc.in)
+		if repo != tc.repo || tag != tc.tag || digest != tc.digest {
+			t.Errorf("splitImage(%q) = %q, %q, %q; want %q, %q, %q", tc.in, repo, tag, digest, tc.repo, tc.tag, tc.digest)
+		}
+	}
+}
+
+func TestVersionSkew(t *testing.T) {
+	instance := func(cluster, image string, digests ...string) AppVersionInstance {
+		_, tag, _ := splitImage(image)
+		return AppVersionInstance{Cluster: cluster, Containers: []ContainerVersion{{Container: "web", Image: image, Tag: tag, Digests: digests}}}
+	}
+
+	if skew := versionSkew([]AppVersionInstance{instance("a", "web:v1.2.0"), instance("b", "web:v1.2.0")}); len(skew) != 0 {
+		t.Fatalf("same image everywhere is not skew, got %+v", skew)
+	}
+
+	skew := versionSkew([]AppVersionInstance{instance("a", "web:v1.10.0"), instance("b", "web:v1.9.0"), instance("c", "web:v1.9.0")})
+	if len(skew) != 1 || skew[0].Basis != skewBasisSemver || skew[0].Latest != "web:v1.10.0" {
+		t.Fatalf("semver tags should pick the highest version, got %+v", skew)
+	}
+	if !reflect.DeepEqual(skew[0].OutdatedClusters, []string{"b", "c"}) {
+		t.Fatalf("OutdatedClusters = %v", skew[0].OutdatedClusters)
+	}
+
+	skew = versionSkew([]AppVersionInstance{instance("a", "web:abc1234"), instance("b", "web:abc1234"), instance("c", "web:def5678")})
+	if len(skew) != 1 || skew[0].Basis != skewBasisMajority || skew[0].Latest != "web:abc1234" {
+		t.Fatalf("non-semver tags should pick the majority, got %+v", skew)
+	}
+
+	skew = versionSkew([]AppVersionInstance{instance("a", "web:main"), instance("b", "web:dev")})
+	if len(skew) != 1 || skew[0].Basis != skewBasisUndecided || skew[0].Latest != "" || len(skew[0].OutdatedClusters) != 0 {
+		t.Fatalf("a tie should decide nothing, got %+v", skew)
+	}
+
+	skew = versionSkew([]AppVersionInstance{instance("a", "web:latest", "sha256:1"), instance("b", "web:latest", "sha256:2")})
+	if len(skew) != 1 || !skew[0].DigestMismatch {
+		t.Fatalf("one tag with different digests is skew, got %+v", skew)
+	}
+}
+
+func TestHandleGetAppVersions_ReportsSkewAcrossClusters(t *testing.T) {
+	sha := withImage(mkDeployment("demo", "app", "demo", 1, 1), "org/demo:v2.0.0")
+	sha.Spec.Template.Annotations = map[string]string{"org.opencontainers.image.revision": "0123abc"}
+	mgr, cleanup := managerWithAppsServers(t, map[string]findAppFixtures{
+		"cA": {deployments: []appsv1.Deployment{sha}},
+		"cB": {deployments: []appsv1.Deployment{withImage(mkDeployment("demo", "app", "demo", 1, 1), "org/demo:v1.4.0")}},
+	})
+	defer cleanup()
+
+	srv := newServerWithManager(mgr)
+	res, err := srv.handleGetAppVersions(context.Background(), json.RawMessage(`{"app":"demo"}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	versions := res.(AppVersions)
+	if len(versions.Instances) != 2 || !versions.SkewDetected {
+		t.Fatalf("unexpected result: %+v", versions)
+	}
+	skew := versions.Skew[0]
+	if skew.Latest != "org/demo:v2.0.0" || !reflect.DeepEqual(skew.OutdatedClusters, []string{"cB"}) {
+		t.Fatalf("unexpected skew: %+v", skew)
+	}
+	for _, v := range skew.Versions {
+		if v.Image == "org/demo:v2.0.0" && v.GitSHA != "0123abc" {
+			t.Fatalf("git SHA from the pod template was not reported: %+v", v)
+		}
+	}
+}
+
+func TestHandleGetAppVersions_InvalidArgs(t *testing.T) {
+	srv := &Server{}
+	for _, args := range []string{`{`, `{"app":"bad;name"}`, `{"app":"demo","namespace":"kube-system"}`} {
+		if _, err := srv.handleGetAppVersions(context.Background(), json.RawMessage(args)); err == nil {
+			t.Errorf("expected an error for %s", args)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// Apply modes of kubectl_apply and deploy_app.
+const (
+	applyModeUpdate = "update"
+	applyModeSSA    = "ssa"
+)
+
+// maxFieldManagerLength is the longest field manager the API server
+// accepts.
+const maxFieldManagerLength = 128
+
+// applyModeArgs are the arguments of kubectl_apply and deploy_app that
+// choose how objects are written.
+type applyModeArgs struct {
+	ApplyMode    string `json:"apply_mode"`
+	FieldManager string `json:"field_manager"`
+	Force        bool   `json:"force"`
+}
+
+// serverSideApply is an apply_mode of ssa: every object is server-side
+// applied as FieldManager, and fields other managers own are a conflict
+// unless Force is set.
+type serverSideApply struct {
+	FieldManager string
+	Force        bool
+}
+
+// serverSideApply validates the arguments and returns the server-side
+// apply they ask for, or nil for the default update mode.
+func (a applyModeArgs) serverSideApply() (*serverSideApply, error) {
+	switch a.ApplyMode {
+	case "", applyModeUpdate:
+		if a.FieldManager != "" || a.Force {
+			return nil, fmt.Errorf("field_manager and force require apply_mode ssa")
+		}
+		return nil, nil
+	case applyModeSSA:
+	default:
+		return nil, fmt.Errorf("invalid apply_mode %q: must be update or ssa", a.ApplyMode)
+	}
+	if len(a.FieldManager) > maxFieldManagerLength {
+		return nil, fmt.Errorf("field_manager must be at most %d characters", maxFieldManagerLength)
+	}
+	ssa := &serverSideApply{FieldManager: a.FieldManager, Force: a.Force}
+	if ssa.FieldManager == "" {
+		ssa.FieldManager = kubemanifest.DefaultFieldManager
+	}
+	return ssa, nil
+}
+
+// applyServerSide server-side applies obj and returns the status of the
+// ApplyResult: created, updated, unchanged, conflict, or failed.
+func applyServerSide(ctx context.Context, resource dynamic.ResourceInterface, obj *unstructured.Unstructured, ssa *serverSideApply) (status, message string) {
+	existing, err := resource.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "failed", err.Error()
+	}
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return "failed", err.Error()
+	}
+	updated, err := resource.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: ssa.FieldManager,
+		Force:        boolPtr(ssa.Force),
+	})
+	if fields, ok := kubemanifest.ApplyConflict(err); ok {
+		return "conflict", kubemanifest.ApplyConflictMessage(fields)
+	}
+	switch {
+	case err != nil:
+		return "failed", err.Error()
+	case existing == nil:
+		return "created", ""
+	case existing.GetResourceVersion() == updated.GetResourceVersion():
+		return "unchanged", ""
+	}
+	return "updated", ""
+}
@@ -0,0 +1,97 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ssaManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  mode: blue
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-extra
+data:
+  mode: blue
+`
+
+func TestKubectlApplyServerSideApply(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	cluster.put(t, configMapPath, map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]interface{}{"name": "web-config", "namespace": "default", "resourceVersion": "1"},
+		"data":     map[string]interface{}{"mode": "green", "owner": "hpa"},
+	})
+	cluster.applyConflict = ".data.mode"
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	out, errText := callTool(t, server, "kubectl_apply", map[string]interface{}{
+		"manifest": ssaManifest, "apply_mode": "ssa", "field_manager": "team-a",
+	})
+	require.Empty(t, errText)
+	results := out["results"].([]interface{})
+	require.Len(t, results, 2)
+	conflict := results[0].(map[string]interface{})
+	assert.Equal(t, "conflict", conflict["status"])
+	assert.Contains(t, conflict["message"], `.data.mode (conflict with "kubectl" using v1)`)
+	assert.Equal(t, "created", results[1].(map[string]interface{})["status"])
+	assert.EqualValues(t, 1, out["successCount"])
+	assert.Equal(t, []string{"team-a", "team-a"}, cluster.fieldManagers)
+	assert.Equal(t, "green", cluster.get(configMapPath)["data"].(map[string]interface{})["mode"])
+
+	out, errText = callTool(t, server, "kubectl_apply", map[string]interface{}{
+		"manifest": ssaManifest, "apply_mode": "ssa", "force": true,
+	})
+	require.Empty(t, errText)
+	results = out["results"].([]interface{})
+	assert.Equal(t, "updated", results[0].(map[string]interface{})["status"])
+	// Fields the manifest does not set are left to their owners.
+	assert.Equal(t, map[string]interface{}{"mode": "blue", "owner": "hpa"}, cluster.get(configMapPath)["data"])
+	assert.Equal(t, "kubestellar-deploy", cluster.fieldManagers[len(cluster.fieldManagers)-1])
+
+	_, errText = callTool(t, server, "kubectl_apply", map[string]interface{}{"manifest": ssaManifest, "force": true})
+	assert.Contains(t, errText, "require apply_mode ssa")
+	_, errText = callTool(t, server, "kubectl_apply", map[string]interface{}{"manifest": ssaManifest, "apply_mode": "replace"})
+	assert.Contains(t, errText, "invalid apply_mode")
+}
+
+func TestDeployAppServerSideApplyConflicts(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	cluster.put(t, configMapPath, map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]interface{}{"name": "web-config", "namespace": "default", "resourceVersion": "1"},
+		"data":     map[string]interface{}{"mode": "green"},
+	})
+	cluster.applyConflict = ".data.mode"
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest": ssaManifest, "clusters": []string{"alpha"}, "apply_mode": "ssa", "field_manager": "team-a", "preflight": true,
+	})
+	preflight := out["preflight"].(map[string]interface{})
+	assert.Equal(t, false, preflight["passed"])
+	results := out["results"].([]interface{})
+	assert.Equal(t, "conflict", results[0].(map[string]interface{})["status"])
+	assert.False(t, cluster.has("/api/v1/namespaces/default/configmaps/web-extra"), "nothing is applied when preflight finds a conflict")
+
+	out, _ = callDeployApp(t, server, map[string]interface{}{
+		"manifest": ssaManifest, "clusters": []string{"alpha"}, "apply_mode": "ssa", "field_manager": "team-a",
+	})
+	results = out["results"].([]interface{})
+	assert.Equal(t, "conflict", results[0].(map[string]interface{})["status"])
+	assert.Equal(t, "created", results[1].(map[string]interface{})["status"])
+	assert.NotContains(t, cluster.fieldManagers, "kubestellar-deploy")
+
+	out, _ = callDeployApp(t, server, map[string]interface{}{
+		"manifest": ssaManifest, "clusters": []string{"alpha"}, "apply_mode": "ssa", "force": true,
+	})
+	results = out["results"].([]interface{})
+	assert.Equal(t, "updated", results[0].(map[string]interface{})["status"])
+}
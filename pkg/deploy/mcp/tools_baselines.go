@@ -0,0 +1,455 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxBaselineBytes bounds the manifests of one baseline, which is kept in
+// the state store; a ConfigMap-backed store holds at most 1 MiB per bucket.
+const maxBaselineBytes = 512 << 10
+
+// Ways a cluster deviates from a baseline.
+const (
+	// deviationMissing is a resource of the baseline the cluster lacks.
+	deviationMissing = "missing"
+	// deviationExtra is a resource the cluster has in the baseline's scope
+	// that the reference cluster did not.
+	deviationExtra = "extra"
+	// deviationChanged is a resource whose manifest differs.
+	deviationChanged = "changed"
+)
+
+// ConfigBaseline is the configuration of a reference cluster, captured by
+// capture_baseline to audit other clusters against. It is persisted in
+// store.BucketBaselines.
+type ConfigBaseline struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Cluster is the reference cluster the baseline was captured from.
+	Cluster       string   `json:"cluster"`
+	Namespaces    []string `json:"namespaces"`
+	Kinds         []string `json:"kinds,omitempty"`
+	LabelSelector string   `json:"labelSelector,omitempty"`
+	// Allow lists the expected differences, which audits report apart
+	// from deviations.
+	Allow       []BaselineAllowance `json:"allow,omitempty"`
+	ObjectCount int                 `json:"objectCount"`
+	// Objects are the exported manifests by "Kind namespace/name". They
+	// are left out of list_baselines.
+	Objects    map[string]map[string]interface{} `json:"objects,omitempty"`
+	CapturedAt time.Time                         `json:"capturedAt"`
+}
+
+// BaselineAllowance is an expected difference from a baseline, e.g. the
+// replica count of a Deployment in a smaller region.
+type BaselineAllowance struct {
+	// Resource is a "Kind namespace/name" pattern, with * matching any
+	// part of a kind, namespace, or name, e.g. "Deployment */web".
+	Resource string `json:"resource"`
+	// Field is the dotted path of the allowed field, e.g. spec.replicas,
+	// which also allows the fields under it. An empty field allows the
+	// whole resource to differ, be missing, or be extra.
+	Field string `json:"field,omitempty"`
+	// Clusters limits the allowance to these clusters.
+	Clusters []string `json:"clusters,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// BaselineAudit is how each audited cluster deviates from a baseline.
+type BaselineAudit struct {
+	Baseline   string                 `json:"baseline"`
+	Reference  string                 `json:"reference"`
+	CapturedAt time.Time              `json:"capturedAt"`
+	Clusters   []ClusterBaselineAudit `json:"clusters"`
+	Issues     []string               `json:"issues,omitempty"`
+}
+
+// ClusterBaselineAudit is how one cluster deviates from a baseline.
+type ClusterBaselineAudit struct {
+	Cluster string `json:"cluster"`
+	// Conforms is true when every difference is allowed.
+	Conforms   bool                `json:"conforms"`
+	Deviations []BaselineDeviation `json:"deviations,omitempty"`
+	// Allowed are the differences the baseline's allowances expect.
+	Allowed []BaselineDeviation `json:"allowed,omitempty"`
+}
+
+// BaselineDeviation is a resource that differs from the baseline.
+type BaselineDeviation struct {
+	Resource string `json:"resource"`
+	// Type is missing, extra, or changed.
+	Type string `json:"type"`
+	// Fields are the differing fields of a changed resource.
+	Fields []BaselineFieldDiff `json:"fields,omitempty"`
+	// Reason is the reason of the allowance of a missing or extra
+	// resource, or of a whole changed one.
+	Reason string `json:"reason,omitempty"`
+}
+
+// BaselineFieldDiff is a field whose value differs from the baseline.
+type BaselineFieldDiff struct {
+	Path string `json:"path"`
+	// Type is missing (set in the baseline only), extra (set on the
+	// cluster only), or changed.
+	Type     string      `json:"type"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+	Reason   string      `json:"reason,omitempty"`
+}
+
+// handleCaptureBaseline captures the configuration of kinds in namespaces
+// of a reference cluster as a named baseline.
+func (s *Server) handleCaptureBaseline(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name          string              `json:"name"`
+		Description   string              `json:"description"`
+		Cluster       string              `json:"cluster"`
+		Namespaces    []string            `json:"namespaces"`
+		Kinds         []string            `json:"kinds"`
+		LabelSelector string              `json:"label_selector"`
+		Allow         []BaselineAllowance `json:"allow"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.Name); err != nil {
+		return nil, fmt.Errorf("invalid baseline name: %w", err)
+	}
+	if params.Cluster == "" {
+		return nil, fmt.Errorf("cluster is required")
+	}
+	if len(params.Namespaces) == 0 {
+		return nil, fmt.Errorf("namespaces is required")
+	}
+	for _, ns := range params.Namespaces {
+		if err := server.ValidateNamespace(ns); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	for _, kind := range params.Kinds {
+		if _, ok := portableGVR(kind); !ok {
+			return nil, fmt.Errorf("unsupported kind %q", kind)
+		}
+	}
+	if err := validateAllowances(params.Allow); err != nil {
+		return nil, err
+	}
+
+	baseline := &ConfigBaseline{
+		Name:          params.Name,
+		Description:   params.Description,
+		Cluster:       params.Cluster,
+		Namespaces:    params.Namespaces,
+		Kinds:         params.Kinds,
+		LabelSelector: params.LabelSelector,
+		Allow:         params.Allow,
+		CapturedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, []string{params.Cluster}, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.baselineObjects(ctx, clusterName, baseline)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if results[0].Error != "" {
+		return nil, fmt.Errorf("failed to capture %s: %s", params.Cluster, results[0].Error)
+	}
+	baseline.Objects = results[0].Result.(map[string]map[string]interface{})
+	baseline.ObjectCount = len(baseline.Objects)
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBaselineBytes {
+		return nil, fmt.Errorf("the baseline is larger than %d KiB; narrow it with kinds, label_selector, or namespaces", maxBaselineBytes>>10)
+	}
+	if err := s.getStateStore().Put(ctx, store.BucketBaselines, baseline.Name, data); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"baseline":  baselineSummary(baseline),
+		"resources": sortedKeysOf(baseline.Objects),
+		"message":   fmt.Sprintf("Captured %d resources from %s; audit other clusters with audit_baseline name=%s", baseline.ObjectCount, baseline.Cluster, baseline.Name),
+	}, nil
+}
+
+// handleAuditBaseline compares clusters with a baseline and reports the
+// resources that are missing, extra, or changed, apart from the allowed
+// differences.
+func (s *Server) handleAuditBaseline(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name     string              `json:"name"`
+		Clusters []string            `json:"clusters"`
+		Allow    []BaselineAllowance `json:"allow"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if err := validateAllowances(params.Allow); err != nil {
+		return nil, err
+	}
+	baseline, err := s.loadBaseline(ctx, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	allow := append(append([]BaselineAllowance{}, baseline.Allow...), params.Allow...)
+
+	targetClusters := params.Clusters
+	if len(targetClusters) == 0 {
+		clusters, err := s.manager.DiscoverClusters()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range clusters {
+			if c.Name != baseline.Cluster {
+				targetClusters = append(targetClusters, c.Name)
+			}
+		}
+	}
+	if len(targetClusters) == 0 {
+		return nil, fmt.Errorf("no clusters to audit besides the reference cluster %s", baseline.Cluster)
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		live, err := s.baselineObjects(ctx, clusterName, baseline)
+		if err != nil {
+			return nil, err
+		}
+		return auditBaseline(baseline, clusterName, live, allow), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortClusterResults(results)
+
+	audit := BaselineAudit{
+		Baseline:   baseline.Name,
+		Reference:  baseline.Cluster,
+		CapturedAt: baseline.CapturedAt,
+		Clusters:   []ClusterBaselineAudit{},
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			audit.Issues = append(audit.Issues, fmt.Sprintf("%s: %s", result.Cluster, result.Error))
+			continue
+		}
+		audit.Clusters = append(audit.Clusters, result.Result.(ClusterBaselineAudit))
+	}
+	return audit, nil
+}
+
+// handleListBaselines lists the captured baselines, without their
+// manifests.
+func (s *Server) handleListBaselines(ctx context.Context, _ json.RawMessage) (interface{}, error) {
+	items, err := s.getStateStore().List(ctx, store.BucketBaselines)
+	if err != nil {
+		return nil, err
+	}
+	baselines := []*ConfigBaseline{}
+	for _, item := range items {
+		var b ConfigBaseline
+		if json.Unmarshal(item.Value, &b) != nil {
+			continue
+		}
+		baselines = append(baselines, baselineSummary(&b))
+	}
+	return map[string]interface{}{
+		"baselines": baselines,
+		"count":     len(baselines),
+	}, nil
+}
+
+// handleDeleteBaseline deletes a baseline.
+func (s *Server) handleDeleteBaseline(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if _, err := s.loadBaseline(ctx, params.Name); err != nil {
+		return nil, err
+	}
+	if err := s.getStateStore().Delete(ctx, store.BucketBaselines, params.Name); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"deleted": params.Name}, nil
+}
+
+// loadBaseline reads baseline name from the state store.
+func (s *Server) loadBaseline(ctx context.Context, name string) (*ConfigBaseline, error) {
+	var b ConfigBaseline
+	if err := store.GetJSON(ctx, s.getStateStore(), store.BucketBaselines, name, &b); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("baseline %q not found", name)
+		}
+		return nil, err
+	}
+	return &b, nil
+}
+
+// baselineSummary returns a copy of b without its manifests.
+func baselineSummary(b *ConfigBaseline) *ConfigBaseline {
+	summary := *b
+	summary.Objects = nil
+	return &summary
+}
+
+// baselineObjects exports the objects in the scope of baseline from one
+// cluster, by "Kind namespace/name". Objects clone_namespace would not
+// copy, including every Secret, are left out.
+func (s *Server) baselineObjects(ctx context.Context, clusterName string, baseline *ConfigBaseline) (map[string]map[string]interface{}, error) {
+	dyn, err := s.dynamicClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	kinds := make(map[string]bool, len(baseline.Kinds))
+	for _, kind := range baseline.Kinds {
+		kinds[kind] = true
+	}
+	objects := make(map[string]map[string]interface{})
+	for _, namespace := range baseline.Namespaces {
+		for _, k := range portableKinds {
+			if len(kinds) > 0 && !kinds[k.Kind] {
+				continue
+			}
+			list, err := dyn.Resource(k.GVR).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: baseline.LabelSelector})
+			if apierrors.IsNotFound(err) {
+				// The cluster does not serve this resource.
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("listing %s in %s: %w", k.GVR.Resource, namespace, err)
+			}
+			for i := range list.Items {
+				obj := &list.Items[i]
+				obj.SetKind(k.Kind)
+				obj.SetAPIVersion(k.GVR.GroupVersion().String())
+				if cloneSkipReason(obj, secretModeSkip) != "" {
+					continue
+				}
+				objects[k.Kind+" "+namespace+"/"+obj.GetName()] = exportObject(obj).Object
+			}
+		}
+	}
+	return objects, nil
+}
+
+// auditBaseline compares the live objects of a cluster with baseline.
+func auditBaseline(baseline *ConfigBaseline, clusterName string, live map[string]map[string]interface{}, allow []BaselineAllowance) ClusterBaselineAudit {
+	audit := ClusterBaselineAudit{Cluster: clusterName}
+	add := func(d BaselineDeviation) {
+		if reason, ok := allowedBy(allow, clusterName, d.Resource, ""); ok {
+			d.Reason = reason
+			audit.Allowed = append(audit.Allowed, d)
+		} else {
+			audit.Deviations = append(audit.Deviations, d)
+		}
+	}
+	for resource, expected := range baseline.Objects {
+		actual, ok := live[resource]
+		if !ok {
+			add(BaselineDeviation{Resource: resource, Type: deviationMissing})
+			continue
+		}
+		diffs := gitops.DiffValues(expected, actual)
+		if len(diffs) == 0 {
+			continue
+		}
+		if reason, ok := allowedBy(allow, clusterName, resource, ""); ok {
+			audit.Allowed = append(audit.Allowed, BaselineDeviation{Resource: resource, Type: deviationChanged, Fields: baselineFields(diffs), Reason: reason})
+			continue
+		}
+		deviation := BaselineDeviation{Resource: resource, Type: deviationChanged}
+		allowed := BaselineDeviation{Resource: resource, Type: deviationChanged}
+		for _, f := range baselineFields(diffs) {
+			if reason, ok := allowedBy(allow, clusterName, resource, f.Path); ok {
+				f.Reason = reason
+				allowed.Fields = append(allowed.Fields, f)
+			} else {
+				deviation.Fields = append(deviation.Fields, f)
+			}
+		}
+		if len(deviation.Fields) > 0 {
+			audit.Deviations = append(audit.Deviations, deviation)
+		}
+		if len(allowed.Fields) > 0 {
+			audit.Allowed = append(audit.Allowed, allowed)
+		}
+	}
+	for resource := range live {
+		if _, ok := baseline.Objects[resource]; !ok {
+			add(BaselineDeviation{Resource: resource, Type: deviationExtra})
+		}
+	}
+	sort.Slice(audit.Deviations, func(i, j int) bool { return audit.Deviations[i].Resource < audit.Deviations[j].Resource })
+	sort.Slice(audit.Allowed, func(i, j int) bool { return audit.Allowed[i].Resource < audit.Allowed[j].Resource })
+	audit.Conforms = len(audit.Deviations) == 0
+	return audit
+}
+
+// baselineFields converts the differences of a manifest from its baseline.
+func baselineFields(diffs []gitops.ValuesDifference) []BaselineFieldDiff {
+	fields := make([]BaselineFieldDiff, 0, len(diffs))
+	for _, d := range diffs {
+		fields = append(fields, BaselineFieldDiff{Path: d.Key, Type: string(d.Type), Expected: d.GitValue, Actual: d.ClusterValue})
+	}
+	return fields
+}
+
+// allowedBy returns the reason of the first allowance that covers field of
+// resource on cluster; an empty field is the whole resource, which only
+// allowances without a field cover.
+func allowedBy(allow []BaselineAllowance, cluster, resource, field string) (string, bool) {
+	for _, a := range allow {
+		if len(a.Clusters) > 0 && !slices.Contains(a.Clusters, cluster) {
+			continue
+		}
+		if ok, _ := path.Match(a.Resource, resource); !ok {
+			continue
+		}
+		if a.Field == "" || (field != "" && (field == a.Field || strings.HasPrefix(field, a.Field+"."))) {
+			reason := a.Reason
+			if reason == "" {
+				reason = "allowed"
+			}
+			return reason, true
+		}
+	}
+	return "", false
+}
+
+// validateAllowances checks the resource patterns of allow.
+func validateAllowances(allow []BaselineAllowance) error {
+	for _, a := range allow {
+		if a.Resource == "" {
+			return fmt.Errorf("each allow entry needs a resource pattern, e.g. \"Deployment */web\"")
+		}
+		if _, err := path.Match(a.Resource, ""); err != nil {
+			return fmt.Errorf("invalid allow resource pattern %q: %w", a.Resource, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func settingsConfigMap(color string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "settings", "namespace": "shop", "uid": "a1"},
+		"data": map[string]interface{}{"color": color},
+	}
+}
+
+func TestBaselineAudit(t *testing.T) {
+	prod, prodURL := newObjectAPIServer(t, false)
+	staging, stagingURL := newObjectAPIServer(t, false)
+	dev, devURL := newObjectAPIServer(t, false)
+	for _, api := range []*objectAPIServer{prod, staging, dev} {
+		api.put(t, "/api/v1/namespaces/shop/configmaps/kube-root-ca.crt", map[string]interface{}{
+			"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "kube-root-ca.crt", "namespace": "shop"},
+		})
+	}
+	prod.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", liveDeployment("web"))
+	prod.put(t, "/api/v1/namespaces/shop/configmaps/settings", settingsConfigMap("blue"))
+	scaled := liveDeployment("web")
+	scaled["spec"].(map[string]interface{})["replicas"] = int64(1)
+	staging.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", scaled)
+	staging.put(t, "/api/v1/namespaces/shop/configmaps/settings", settingsConfigMap("green"))
+	staging.put(t, "/api/v1/namespaces/shop/configmaps/debug", map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "debug", "namespace": "shop"},
+	})
+	dev.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", liveDeployment("web"))
+	server := newAtomicTestServer(t, map[string]string{"prod": prodURL, "staging": stagingURL, "dev": devURL})
+
+	out, errText := callTool(t, server, "capture_baseline", map[string]interface{}{
+		"name": "shop-golden", "cluster": "prod", "namespaces": []string{"shop"},
+		"allow": []map[string]interface{}{{"resource": "Deployment */web", "field": "spec.replicas", "clusters": []string{"staging"}, "reason": "smaller region"}},
+	})
+	require.Empty(t, errText)
+	assert.Equal(t, []interface{}{"ConfigMap shop/settings", "Deployment shop/web"}, out["resources"])
+	assert.Nil(t, out["baseline"].(map[string]interface{})["objects"])
+
+	out, errText = callTool(t, server, "audit_baseline", map[string]interface{}{"name": "shop-golden"})
+	require.Empty(t, errText)
+	assert.Equal(t, "prod", out["reference"])
+	clusters := out["clusters"].([]interface{})
+	require.Len(t, clusters, 2)
+	assert.Equal(t, map[string]interface{}{
+		"cluster": "dev", "conforms": false,
+		"deviations": []interface{}{map[string]interface{}{"resource": "ConfigMap shop/settings", "type": "missing"}},
+	}, clusters[0])
+	assert.Equal(t, map[string]interface{}{
+		"cluster": "staging", "conforms": false,
+		"deviations": []interface{}{
+			map[string]interface{}{"resource": "ConfigMap shop/debug", "type": "extra"},
+			map[string]interface{}{"resource": "ConfigMap shop/settings", "type": "changed", "fields": []interface{}{
+				map[string]interface{}{"path": "data.color", "type": "changed", "expected": "blue", "actual": "green"},
+			}},
+		},
+		"allowed": []interface{}{
+			map[string]interface{}{"resource": "Deployment shop/web", "type": "changed", "fields": []interface{}{
+				map[string]interface{}{"path": "spec.replicas", "type": "changed", "expected": float64(3), "actual": float64(1), "reason": "smaller region"},
+			}},
+		},
+	}, clusters[1])
+
+	out, errText = callTool(t, server, "audit_baseline", map[string]interface{}{
+		"name": "shop-golden", "clusters": []string{"staging"},
+		"allow": []map[string]interface{}{{"resource": "ConfigMap shop/*"}},
+	})
+	require.Empty(t, errText)
+	staged := out["clusters"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, true, staged["conforms"])
+	assert.Len(t, staged["allowed"], 3)
+
+	_, errText = callTool(t, server, "audit_baseline", map[string]interface{}{"name": "shop-golden", "allow": []map[string]interface{}{{"resource": "["}}})
+	assert.Contains(t, errText, "invalid allow resource pattern")
+
+	out, errText = callTool(t, server, "list_baselines", map[string]interface{}{})
+	require.Empty(t, errText)
+	baselines := out["baselines"].([]interface{})
+	require.Len(t, baselines, 1)
+	assert.EqualValues(t, 2, baselines[0].(map[string]interface{})["objectCount"])
+
+	_, errText = callTool(t, server, "delete_baseline", map[string]interface{}{"name": "shop-golden"})
+	require.Empty(t, errText)
+	_, errText = callTool(t, server, "audit_baseline", map[string]interface{}{"name": "shop-golden"})
+	assert.Contains(t, errText, `baseline "shop-golden" not found`)
+}
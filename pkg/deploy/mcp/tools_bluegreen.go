@@ -0,0 +1,730 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// slotLabel marks the pods of a blue/green Deployment with its slot.
+	slotLabel = "kubestellar.io/slot"
+	// slotAppLabel is shared by the pods of both slots of an app. With
+	// slotLabel it forms the selector the Service switches between.
+	slotAppLabel = "kubestellar.io/blue-green-app"
+	// blueGreenAnnotation holds the blueGreenState of a Service while a
+	// blue/green deploy is in progress.
+	blueGreenAnnotation = "kubestellar.io/blue-green"
+
+	slotBlue  = "blue"
+	slotGreen = "green"
+)
+
+// Actions accepted by finish_blue_green.
+const (
+	blueGreenPromote  = "promote"
+	blueGreenRollback = "rollback"
+)
+
+var httpRouteGVR = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+
+// blueGreenState records a blue/green deploy on the Service it switches,
+// so the deploy can be finished from any session.
+type blueGreenState struct {
+	App string `json:"app"`
+	// Live is the Deployment serving traffic before the deploy.
+	Live string `json:"live"`
+	// Candidate is the Deployment created by start_blue_green.
+	Candidate      string `json:"candidate"`
+	PreviewService string `json:"previewService"`
+	// LiveSelector and CandidateSelector are the Service selectors that
+	// send all traffic to Live and to Candidate.
+	LiveSelector      map[string]string `json:"liveSelector"`
+	CandidateSelector map[string]string `json:"candidateSelector"`
+	// HTTPRoute is set once traffic is split through an HTTPRoute.
+	HTTPRoute string `json:"httpRoute,omitempty"`
+	// Weight is the percentage of traffic sent to Candidate.
+	Weight int32 `json:"weight"`
+}
+
+// blueGreenRequest holds the arguments of the blue/green tools.
+type blueGreenRequest struct {
+	App       string
+	Namespace string
+	Service   string
+	Image     string
+	Images    map[string]string
+	Timeout   time.Duration
+	Weight    int32
+	HTTPRoute string
+	Action    string
+	DryRun    bool
+}
+
+// blueGreenParams are the arguments shared by the blue/green tools.
+type blueGreenParams struct {
+	App       string   `json:"app"`
+	Namespace string   `json:"namespace"`
+	Service   string   `json:"service"`
+	Clusters  []string `json:"clusters"`
+	DryRun    bool     `json:"dry_run"`
+}
+
+// prepare validates the shared arguments, resolves the target clusters, and
+// marks ctx as a dry run when requested.
+func (p blueGreenParams) prepare(ctx context.Context, s *Server) (context.Context, []string, blueGreenRequest, error) {
+	req := blueGreenRequest{App: p.App, Namespace: p.Namespace, Service: p.Service, DryRun: p.DryRun || approval.IsDryRun(ctx)}
+	if err := claude.ValidateK8sName(p.App); err != nil {
+		return ctx, nil, req, fmt.Errorf("invalid app name: %w", err)
+	}
+	if p.Namespace != "" {
+		if err := server.ValidateNamespace(p.Namespace); err != nil {
+			return ctx, nil, req, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	if p.Service != "" {
+		if err := claude.ValidateK8sName(p.Service); err != nil {
+			return ctx, nil, req, fmt.Errorf("invalid service name: %w", err)
+		}
+	}
+	if req.DryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+	clusters := p.Clusters
+	if len(clusters) == 0 {
+		var err error
+		if clusters, err = s.appClusters(ctx, p.App, p.Namespace); err != nil {
+			return ctx, nil, req, err
+		}
+	}
+	if len(clusters) == 0 {
+		return ctx, nil, req, fmt.Errorf("app %s not found in any cluster", p.App)
+	}
+	return ctx, clusters, req, nil
+}
+
+// handleStartBlueGreen creates a candidate Deployment and preview Service
+// next to an app's live Deployment and waits for the candidate to become
+// ready. Traffic is not moved; see handleShiftTraffic.
+func (s *Server) handleStartBlueGreen(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		blueGreenParams
+		Image          string            `json:"image"`
+		Images         map[string]string `json:"images"`
+		TimeoutSeconds int               `json:"timeout_seconds"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Image == "" && len(params.Images) == 0 {
+		return nil, fmt.Errorf("image or images is required")
+	}
+	if params.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative")
+	}
+
+	ctx, clusters, req, err := params.prepare(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	req.Image, req.Images = params.Image, params.Images
+	req.Timeout = defaultHealthTimeout
+	if params.TimeoutSeconds > 0 {
+		req.Timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.startBlueGreenInCluster(ctx, client, clusterName, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"app":     params.App,
+		"dryRun":  req.DryRun,
+		"results": results,
+	}, nil
+}
+
+// startBlueGreenInCluster starts a blue/green deploy in one cluster. A
+// candidate that is not ready within the timeout is removed again.
+func (s *Server) startBlueGreenInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName string, req blueGreenRequest) (interface{}, error) {
+	w, err := resolveAppWorkload(ctx, client, clusterName, req.App, req.Namespace, "Deployment", "Deployment")
+	if err != nil {
+		return nil, err
+	}
+	live := w.deployment
+	svc, err := liveService(ctx, client, live, req.Service)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := svc.Annotations[blueGreenAnnotation]; ok {
+		return nil, fmt.Errorf("service %s/%s already has a blue/green deploy in progress; finish or roll it back first", svc.Namespace, svc.Name)
+	}
+	candidate, err := candidateDeployment(live, svc.Spec.Selector, req.Image, req.Images)
+	if err != nil {
+		return nil, err
+	}
+	preview := previewService(svc, candidate.Spec.Selector.MatchLabels)
+
+	result := map[string]interface{}{
+		"cluster":        clusterName,
+		"namespace":      live.Namespace,
+		"service":        svc.Name,
+		"live":           live.Name,
+		"candidate":      candidate.Name,
+		"previewService": preview.Name,
+	}
+	if _, err := client.AppsV1().Deployments(live.Namespace).Create(ctx, candidate, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create candidate deployment %s: %w", candidate.Name, err)
+	}
+	if _, err := client.CoreV1().Services(live.Namespace).Create(ctx, preview, metav1.CreateOptions{}); err != nil {
+		_ = removeBlueGreenObjects(ctx, client, live.Namespace, candidate.Name, "")
+		return nil, fmt.Errorf("failed to create preview service %s: %w", preview.Name, err)
+	}
+	if req.DryRun {
+		result["status"] = "dry-run"
+		return result, nil
+	}
+
+	pending, err := waitForReady(ctx, client, []gitops.Manifest{deploymentManifest(live.Namespace, candidate.Name)}, req.Timeout)
+	if err == nil && len(pending) > 0 {
+		err = fmt.Errorf("not ready within %s", req.Timeout)
+	}
+	if err != nil {
+		if rmErr := removeBlueGreenObjects(ctx, client, live.Namespace, candidate.Name, preview.Name); rmErr != nil {
+			return nil, fmt.Errorf("candidate %s failed its health check (%v) and could not be removed: %w", candidate.Name, err, rmErr)
+		}
+		return nil, fmt.Errorf("candidate %s failed its health check and was removed: %w", candidate.Name, err)
+	}
+
+	state := blueGreenState{
+		App:               req.App,
+		Live:              live.Name,
+		Candidate:         candidate.Name,
+		PreviewService:    preview.Name,
+		LiveSelector:      svc.Spec.Selector,
+		CandidateSelector: candidate.Spec.Selector.MatchLabels,
+	}
+	if _, err := saveBlueGreenState(ctx, client, svc, &state); err != nil {
+		return nil, err
+	}
+	result["status"] = "ready"
+	return result, nil
+}
+
+// handleShiftTraffic moves an app's traffic between the live and candidate
+// Deployments of a blue/green deploy.
+func (s *Server) handleShiftTraffic(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		blueGreenParams
+		Weight    *int32 `json:"weight"`
+		HTTPRoute string `json:"http_route"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	weight := int32(100)
+	if params.Weight != nil {
+		weight = *params.Weight
+	}
+	if weight < 0 || weight > 100 {
+		return nil, fmt.Errorf("weight must be between 0 and 100")
+	}
+	if params.HTTPRoute != "" {
+		if err := claude.ValidateK8sName(params.HTTPRoute); err != nil {
+			return nil, fmt.Errorf("invalid http_route: %w", err)
+		}
+	}
+
+	ctx, clusters, req, err := params.prepare(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	req.Weight, req.HTTPRoute = weight, params.HTTPRoute
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.shiftTrafficInCluster(ctx, client, clusterName, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"app":     params.App,
+		"weight":  weight,
+		"dryRun":  req.DryRun,
+		"results": results,
+	}, nil
+}
+
+// shiftTrafficInCluster sends req.Weight percent of the app's traffic to
+// the candidate. Without an HTTPRoute the Service selector is switched, so
+// traffic moves all at once.
+func (s *Server) shiftTrafficInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName string, req blueGreenRequest) (interface{}, error) {
+	svc, state, err := findBlueGreen(ctx, client, clusterName, req.App, req.Namespace, req.Service)
+	if err != nil {
+		return nil, err
+	}
+	if req.Weight > 0 {
+		pending, err := notReady(ctx, client, []gitops.Manifest{deploymentManifest(svc.Namespace, state.Candidate)})
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) > 0 {
+			return nil, fmt.Errorf("candidate %s is not ready; traffic was not shifted", state.Candidate)
+		}
+	}
+
+	route := req.HTTPRoute
+	if route == "" {
+		route = state.HTTPRoute
+	}
+	if state.HTTPRoute != "" && route != state.HTTPRoute {
+		return nil, fmt.Errorf("traffic is already split through HTTPRoute %s", state.HTTPRoute)
+	}
+	if route == "" {
+		switch req.Weight {
+		case 0:
+			svc.Spec.Selector = state.LiveSelector
+		case 100:
+			svc.Spec.Selector = state.CandidateSelector
+		default:
+			return nil, fmt.Errorf("without http_route the Service selector moves all traffic at once; weight must be 0 or 100")
+		}
+	} else {
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		if err := setRouteWeights(ctx, dyn, svc.Namespace, route, svc.Name, state.PreviewService, req.Weight); err != nil {
+			return nil, err
+		}
+	}
+
+	state.Weight, state.HTTPRoute = req.Weight, route
+	if _, err := saveBlueGreenState(ctx, client, svc, &state); err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{
+		"cluster":   clusterName,
+		"namespace": svc.Namespace,
+		"service":   svc.Name,
+		"candidate": state.Candidate,
+		"weight":    req.Weight,
+	}
+	if route != "" {
+		result["httpRoute"] = route
+	}
+	return result, nil
+}
+
+// handleFinishBlueGreen ends a blue/green deploy: promote keeps the
+// candidate and removes the old live Deployment, rollback sends all traffic
+// back to the live Deployment and removes the candidate.
+func (s *Server) handleFinishBlueGreen(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		blueGreenParams
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Action != blueGreenPromote && params.Action != blueGreenRollback {
+		return nil, fmt.Errorf("action must be %s or %s", blueGreenPromote, blueGreenRollback)
+	}
+
+	ctx, clusters, req, err := params.prepare(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	req.Action = params.Action
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.finishBlueGreenInCluster(ctx, client, clusterName, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"app":     params.App,
+		"action":  params.Action,
+		"dryRun":  req.DryRun,
+		"results": results,
+	}, nil
+}
+
+// finishBlueGreenInCluster promotes or rolls back a blue/green deploy in
+// one cluster. The state annotation is removed last, so a failed call can
+// be repeated.
+func (s *Server) finishBlueGreenInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName string, req blueGreenRequest) (interface{}, error) {
+	svc, state, err := findBlueGreen(ctx, client, clusterName, req.App, req.Namespace, req.Service)
+	if err != nil {
+		return nil, err
+	}
+
+	serving, removed, status := state.Candidate, state.Live, "promoted"
+	svc.Spec.Selector = state.CandidateSelector
+	if req.Action == blueGreenRollback {
+		serving, removed, status = state.Live, state.Candidate, "rolled-back"
+		svc.Spec.Selector = state.LiveSelector
+	} else if state.Weight != 100 {
+		return nil, fmt.Errorf("candidate %s receives %d%% of traffic; shift_traffic to 100 before promoting", state.Candidate, state.Weight)
+	}
+
+	updated, err := client.CoreV1().Services(svc.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service %s: %w", svc.Name, err)
+	}
+	svc = updated
+	if state.HTTPRoute != "" {
+		// The Service now selects the Deployment being kept, so the route
+		// sends everything to it and drops the preview backend.
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		if err := setRouteWeights(ctx, dyn, svc.Namespace, state.HTTPRoute, svc.Name, state.PreviewService, 0); err != nil {
+			return nil, err
+		}
+	}
+	if err := removeBlueGreenObjects(ctx, client, svc.Namespace, removed, state.PreviewService); err != nil {
+		return nil, err
+	}
+	if _, err := saveBlueGreenState(ctx, client, svc, nil); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"cluster":   clusterName,
+		"namespace": svc.Namespace,
+		"service":   svc.Name,
+		"serving":   serving,
+		"removed":   removed,
+		"status":    status,
+	}, nil
+}
+
+// appClusters returns the clusters where app runs, sorted by name.
+func (s *Server) appClusters(ctx context.Context, app, namespace string) ([]string, error) {
+	results, err := s.executor.Execute(ctx, "", func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.findAppInCluster(ctx, client, clusterName, app, namespace)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var clusters []string
+	for _, r := range results {
+		if instances, _ := r.Result.([]AppInstance); len(instances) > 0 {
+			clusters = append(clusters, r.Cluster)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters, nil
+}
+
+// liveService returns the Service that sends traffic to live: the named
+// one, or else the only Service in its namespace whose selector matches
+// its pods.
+func liveService(ctx context.Context, client kubernetes.Interface, live *appsv1.Deployment, name string) (*corev1.Service, error) {
+	podLabels := labels.Set(live.Spec.Template.Labels)
+	if name != "" {
+		svc, err := client.CoreV1().Services(live.Namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if len(svc.Spec.Selector) == 0 || !labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels) {
+			return nil, fmt.Errorf("service %s/%s does not select the pods of deployment %s", svc.Namespace, svc.Name, live.Name)
+		}
+		return svc, nil
+	}
+
+	services, err := client.CoreV1().Services(live.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var matches []*corev1.Service
+	for i := range services.Items {
+		svc := &services.Items[i]
+		if len(svc.Spec.Selector) > 0 && labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels) {
+			matches = append(matches, svc)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return nil, fmt.Errorf("no service selects the pods of deployment %s/%s", live.Namespace, live.Name)
+	default:
+		names := make([]string, 0, len(matches))
+		for _, svc := range matches {
+			names = append(names, svc.Name)
+		}
+		return nil, fmt.Errorf("%d services select the pods of deployment %s/%s: %s; set service to choose one",
+			len(matches), live.Namespace, live.Name, strings.Join(names, ", "))
+	}
+}
+
+// candidateDeployment returns a copy of live for the other slot, running
+// the new images. Its pods drop the labels serviceSelector matches on, so
+// the Service keeps sending traffic only to live until it is switched.
+func candidateDeployment(live *appsv1.Deployment, serviceSelector map[string]string, image string, images map[string]string) (*appsv1.Deployment, error) {
+	slot := slotGreen
+	if live.Spec.Template.Labels[slotLabel] == slotGreen {
+		slot = slotBlue
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(live.Name, "-"+slotBlue), "-"+slotGreen)
+	selector := map[string]string{slotAppLabel: base, slotLabel: slot}
+
+	candidate := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      base + "-" + slot,
+			Namespace: live.Namespace,
+			Labels:    make(map[string]string),
+		},
+		Spec: *live.Spec.DeepCopy(),
+	}
+	for k, v := range live.Labels {
+		candidate.Labels[k] = v
+	}
+	candidate.Labels[slotLabel] = slot
+	candidate.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+	podLabels := make(map[string]string)
+	for k, v := range live.Spec.Template.Labels {
+		if _, ok := serviceSelector[k]; !ok {
+			podLabels[k] = v
+		}
+	}
+	for k, v := range selector {
+		podLabels[k] = v
+	}
+	candidate.Spec.Template.Labels = podLabels
+
+	if err := setImages("deployment "+live.Name, candidate.Spec.Template.Spec.Containers, image, images); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// setImages sets the image of the only container to image, and that of
+// each container named in images, of the workload described by what.
+func setImages(what string, containers []corev1.Container, image string, images map[string]string) error {
+	if image != "" {
+		if len(containers) != 1 {
+			return fmt.Errorf("%s has %d containers; use images to set each one", what, len(containers))
+		}
+		containers[0].Image = image
+	}
+	for name, img := range images {
+		found := false
+		for i := range containers {
+			if containers[i].Name == name {
+				containers[i].Image = img
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s has no container %q", what, name)
+		}
+	}
+	return nil
+}
+
+// previewService returns a Service with the ports of svc that selects the
+// candidate's pods, for testing the candidate before it takes traffic.
+func previewService(svc *corev1.Service, selector map[string]string) *corev1.Service {
+	preview := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svc.Name + "-preview",
+			Namespace: svc.Namespace,
+			Labels:    svc.Labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: selector,
+		},
+	}
+	for _, port := range svc.Spec.Ports {
+		port.NodePort = 0
+		preview.Spec.Ports = append(preview.Spec.Ports, port)
+	}
+	return preview
+}
+
+// findBlueGreen returns the Service of the app's blue/green deploy in
+// progress and its state.
+func findBlueGreen(ctx context.Context, client kubernetes.Interface, clusterName, app, namespace, service string) (*corev1.Service, blueGreenState, error) {
+	ns := namespace
+	if ns == "" {
+		ns = metav1.NamespaceAll
+	}
+	services, err := client.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, blueGreenState{}, err
+	}
+	var (
+		matches []*corev1.Service
+		states  []blueGreenState
+	)
+	for i := range services.Items {
+		svc := &services.Items[i]
+		raw, ok := svc.Annotations[blueGreenAnnotation]
+		if !ok || (service != "" && svc.Name != service) {
+			continue
+		}
+		var state blueGreenState
+		if err := json.Unmarshal([]byte(raw), &state); err != nil {
+			return nil, blueGreenState{}, fmt.Errorf("service %s/%s has an invalid %s annotation: %w", svc.Namespace, svc.Name, blueGreenAnnotation, err)
+		}
+		if state.App == app {
+			matches = append(matches, svc)
+			states = append(states, state)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], states[0], nil
+	case 0:
+		return nil, blueGreenState{}, fmt.Errorf("no blue/green deploy of app %s is in progress in cluster %s", app, clusterName)
+	default:
+		names := make([]string, 0, len(matches))
+		for _, svc := range matches {
+			names = append(names, svc.Namespace+"/"+svc.Name)
+		}
+		return nil, blueGreenState{}, fmt.Errorf("app %s has %d blue/green deploys in progress in cluster %s: %s; set namespace or service to choose one",
+			app, len(matches), clusterName, strings.Join(names, ", "))
+	}
+}
+
+// saveBlueGreenState stores state on svc, or removes it when state is nil,
+// together with any other change made to svc.
+func saveBlueGreenState(ctx context.Context, client kubernetes.Interface, svc *corev1.Service, state *blueGreenState) (*corev1.Service, error) {
+	if state == nil {
+		delete(svc.Annotations, blueGreenAnnotation)
+	} else {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+		if svc.Annotations == nil {
+			svc.Annotations = make(map[string]string)
+		}
+		svc.Annotations[blueGreenAnnotation] = string(data)
+	}
+	updated, err := client.CoreV1().Services(svc.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service %s: %w", svc.Name, err)
+	}
+	return updated, nil
+}
+
+// removeBlueGreenObjects deletes a slot's Deployment and the preview
+// Service. Objects that are already gone are skipped.
+func removeBlueGreenObjects(ctx context.Context, client kubernetes.Interface, namespace, deployment, preview string) error {
+	if err := client.AppsV1().Deployments(namespace).Delete(ctx, deployment, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete deployment %s: %w", deployment, err)
+	}
+	if preview == "" {
+		return nil
+	}
+	if err := client.CoreV1().Services(namespace).Delete(ctx, preview, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete service %s: %w", preview, err)
+	}
+	return nil
+}
+
+// setRouteWeights splits the traffic of every rule of an HTTPRoute that
+// sends to service between it and preview, with weight percent going to
+// preview. A weight of 0 removes the preview backend.
+func setRouteWeights(ctx context.Context, dyn dynamic.Interface, namespace, name, service, preview string, weight int32) error {
+	routes := dyn.Resource(httpRouteGVR).Namespace(namespace)
+	route, err := routes.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get HTTPRoute %s: %w", name, err)
+	}
+	rules, _, err := unstructured.NestedSlice(route.Object, "spec", "rules")
+	if err != nil {
+		return fmt.Errorf("HTTPRoute %s has invalid rules: %w", name, err)
+	}
+
+	matched := false
+	for i, rule := range rules {
+		r, _ := rule.(map[string]interface{})
+		refs, _, _ := unstructured.NestedSlice(r, "backendRefs")
+		var out []interface{}
+		for _, ref := range refs {
+			m, _ := ref.(map[string]interface{})
+			switch {
+			case !isServiceRef(m):
+				out = append(out, ref)
+			case m["name"] == preview:
+				// Re-added below with the new weight.
+			case m["name"] == service:
+				matched = true
+				m["weight"] = int64(100 - weight)
+				out = append(out, m)
+				if weight > 0 {
+					previewRef := runtime.DeepCopyJSON(m)
+					previewRef["name"] = preview
+					previewRef["weight"] = int64(weight)
+					out = append(out, previewRef)
+				}
+			default:
+				out = append(out, ref)
+			}
+		}
+		if r != nil {
+			r["backendRefs"] = out
+			rules[i] = r
+		}
+	}
+	if !matched {
+		return fmt.Errorf("HTTPRoute %s/%s has no rule that sends traffic to service %s", namespace, name, service)
+	}
+	if err := unstructured.SetNestedSlice(route.Object, rules, "spec", "rules"); err != nil {
+		return err
+	}
+	if _, err := routes.Update(ctx, route, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update HTTPRoute %s: %w", name, err)
+	}
+	return nil
+}
+
+// isServiceRef reports whether an HTTPRoute backendRef refers to a Service.
+func isServiceRef(ref map[string]interface{}) bool {
+	if ref == nil {
+		return false
+	}
+	group, _ := ref["group"].(string)
+	kind, _ := ref["kind"].(string)
+	return group == "" && (kind == "" || kind == "Service")
+}
+
+// dynamicClient returns a dynamic client for a cluster.
+func (s *Server) dynamicClient(clusterName string) (dynamic.Interface, error) {
+	config, err := s.manager.GetConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
+	}
+	return dynamic.NewForConfig(config)
+}
+
+func deploymentManifest(namespace, name string) gitops.Manifest {
+	return gitops.Manifest{Kind: "Deployment", Metadata: gitops.ManifestMetadata{Name: name, Namespace: namespace}}
+}
@@ -0,0 +1,183 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	webPath        = "/apis/apps/v1/namespaces/default/deployments/web"
+	webGreenPath   = "/apis/apps/v1/namespaces/default/deployments/web-green"
+	webSvcPath     = "/api/v1/namespaces/default/services/web"
+	webPreviewPath = "/api/v1/namespaces/default/services/web-preview"
+	webRoutePath   = "/apis/gateway.networking.k8s.io/v1/namespaces/default/httproutes/web"
+)
+
+// newBlueGreenCluster returns a cluster running Deployment web behind
+// Service web.
+func newBlueGreenCluster(t *testing.T, ready bool) (*objectAPIServer, *Server) {
+	cluster, url := newObjectAPIServer(t, false)
+	cluster.readyDeployments = ready
+	replicas := int32(2)
+	cluster.put(t, webPath, &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web", "tier": "frontend"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "web:v1"}}},
+			},
+		},
+	})
+	cluster.put(t, webSvcPath, &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{"app": "web"},
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	})
+	return cluster, newAtomicTestServer(t, map[string]string{"c1": url})
+}
+
+func serviceSelector(t *testing.T, cluster *objectAPIServer) map[string]string {
+	t.Helper()
+	selector, _, err := unstructured.NestedStringMap(cluster.get(webSvcPath), "spec", "selector")
+	require.NoError(t, err)
+	return selector
+}
+
+func routeBackends(t *testing.T, cluster *objectAPIServer) map[string]int64 {
+	t.Helper()
+	rules, _, err := unstructured.NestedSlice(cluster.get(webRoutePath), "spec", "rules")
+	require.NoError(t, err)
+	refs, _, _ := unstructured.NestedSlice(rules[0].(map[string]interface{}), "backendRefs")
+	weights := make(map[string]int64)
+	for _, ref := range refs {
+		m := ref.(map[string]interface{})
+		weight, _, _ := unstructured.NestedFieldNoCopy(m, "weight")
+		switch w := weight.(type) {
+		case int64:
+			weights[m["name"].(string)] = w
+		case float64:
+			weights[m["name"].(string)] = int64(w)
+		}
+	}
+	return weights
+}
+
+// callBlueGreen calls a blue/green tool on cluster c1 and returns the
+// cluster's result, or its error text.
+func callBlueGreen(t *testing.T, server *Server, name string, args map[string]interface{}) (map[string]interface{}, string) {
+	t.Helper()
+	args["clusters"] = []string{"c1"}
+	out, errText := callTool(t, server, name, args)
+	if errText != "" {
+		return nil, errText
+	}
+	results := out["results"].([]interface{})
+	require.Len(t, results, 1)
+	result := results[0].(map[string]interface{})
+	if e, _ := result["error"].(string); e != "" {
+		return nil, e
+	}
+	return result["result"].(map[string]interface{}), ""
+}
+
+func TestBlueGreenSwitchesServiceAndPromotes(t *testing.T) {
+	cluster, server := newBlueGreenCluster(t, true)
+
+	_, errText := callBlueGreen(t, server, "start_blue_green", map[string]interface{}{"app": "web", "image": "web:v2"})
+	require.Empty(t, errText)
+
+	green := cluster.get(webGreenPath)
+	require.NotNil(t, green, "the candidate is created")
+	podLabels, _, _ := unstructured.NestedStringMap(green, "spec", "template", "metadata", "labels")
+	assert.Equal(t, map[string]string{"tier": "frontend", slotAppLabel: "web", slotLabel: slotGreen}, podLabels,
+		"candidate pods must not match the live Service selector")
+	containers, _, _ := unstructured.NestedSlice(green, "spec", "template", "spec", "containers")
+	assert.Equal(t, "web:v2", containers[0].(map[string]interface{})["image"])
+	assert.True(t, cluster.has(webPreviewPath))
+	assert.Equal(t, map[string]string{"app": "web"}, serviceSelector(t, cluster), "start does not move traffic")
+
+	_, errText = callBlueGreen(t, server, "shift_traffic", map[string]interface{}{"app": "web", "weight": 50})
+	assert.Contains(t, errText, "weight must be 0 or 100")
+
+	_, errText = callBlueGreen(t, server, "finish_blue_green", map[string]interface{}{"app": "web", "action": "promote"})
+	assert.Contains(t, errText, "shift_traffic to 100")
+
+	_, errText = callBlueGreen(t, server, "shift_traffic", map[string]interface{}{"app": "web"})
+	require.Empty(t, errText)
+	assert.Equal(t, map[string]string{slotAppLabel: "web", slotLabel: slotGreen}, serviceSelector(t, cluster))
+
+	out, errText := callBlueGreen(t, server, "finish_blue_green", map[string]interface{}{"app": "web", "action": "promote"})
+	require.Empty(t, errText)
+	assert.Equal(t, "promoted", out["status"])
+	assert.False(t, cluster.has(webPath), "the old live deployment is removed")
+	assert.False(t, cluster.has(webPreviewPath))
+	assert.True(t, cluster.has(webGreenPath))
+	annotations, _, _ := unstructured.NestedStringMap(cluster.get(webSvcPath), "metadata", "annotations")
+	assert.NotContains(t, annotations, blueGreenAnnotation)
+}
+
+func TestBlueGreenSplitsHTTPRouteAndRollsBack(t *testing.T) {
+	cluster, server := newBlueGreenCluster(t, true)
+	cluster.put(t, webRoutePath, map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1",
+		"kind":       "HTTPRoute",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"backendRefs": []interface{}{map[string]interface{}{"name": "web", "port": 80}}},
+			},
+		},
+	})
+
+	_, errText := callBlueGreen(t, server, "start_blue_green", map[string]interface{}{"app": "web", "images": map[string]string{"web": "web:v2"}})
+	require.Empty(t, errText)
+
+	_, errText = callBlueGreen(t, server, "shift_traffic", map[string]interface{}{"app": "web", "weight": 25, "http_route": "web"})
+	require.Empty(t, errText)
+	assert.Equal(t, map[string]int64{"web": 75, "web-preview": 25}, routeBackends(t, cluster))
+	assert.Equal(t, map[string]string{"app": "web"}, serviceSelector(t, cluster), "a route split leaves the Service on live")
+
+	_, errText = callBlueGreen(t, server, "finish_blue_green", map[string]interface{}{"app": "web", "action": "rollback"})
+	require.Empty(t, errText)
+	assert.Equal(t, map[string]int64{"web": 100}, routeBackends(t, cluster))
+	assert.False(t, cluster.has(webGreenPath), "the candidate is removed")
+	assert.False(t, cluster.has(webPreviewPath))
+	assert.True(t, cluster.has(webPath))
+}
+
+func TestBlueGreenRemovesUnhealthyCandidate(t *testing.T) {
+	saved := healthPollInterval
+	healthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = saved })
+
+	cluster, server := newBlueGreenCluster(t, false)
+	_, errText := callBlueGreen(t, server, "start_blue_green", map[string]interface{}{"app": "web", "image": "web:v2", "timeout_seconds": 1})
+	assert.Contains(t, errText, "failed its health check and was removed")
+	assert.False(t, cluster.has(webGreenPath))
+	assert.False(t, cluster.has(webPreviewPath))
+
+	_, errText = callBlueGreen(t, server, "shift_traffic", map[string]interface{}{"app": "web"})
+	assert.Contains(t, errText, "no blue/green deploy")
+}
+
+func TestBlueGreenDryRunChangesNothing(t *testing.T) {
+	cluster, server := newBlueGreenCluster(t, true)
+	out, errText := callBlueGreen(t, server, "start_blue_green", map[string]interface{}{"app": "web", "image": "web:v2", "dry_run": true})
+	require.Empty(t, errText)
+	assert.Equal(t, "dry-run", out["status"])
+	assert.False(t, cluster.has(webGreenPath))
+	assert.False(t, cluster.has(webPreviewPath))
+}
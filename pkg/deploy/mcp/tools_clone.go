@@ -0,0 +1,347 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// clonedFromAnnotation records cluster/namespace of the source on every
+// object clone_namespace creates.
+const clonedFromAnnotation = "kubestellar.io/cloned-from"
+
+// Secret modes of clone_namespace.
+const (
+	secretModeSkip     = "skip"
+	secretModeCopy     = "copy"
+	secretModeKeysOnly = "keys-only"
+)
+
+// clusterManagedObjects are created in every namespace by the cluster
+// itself, so they are never cloned.
+var clusterManagedObjects = map[string]bool{
+	"ConfigMap/kube-root-ca.crt":         true,
+	"ConfigMap/openshift-service-ca.crt": true,
+	"ServiceAccount/default":             true,
+}
+
+// CloneResult is the result of clone_namespace on one target cluster.
+type CloneResult struct {
+	Namespace string          `json:"namespace"`
+	Created   int             `json:"created"`
+	Existing  int             `json:"existing"`
+	Failed    int             `json:"failed"`
+	Steps     []MigrationStep `json:"steps"`
+}
+
+// cloneRequest is a clone_namespace call after validation.
+type cloneRequest struct {
+	Source          string
+	Namespace       string
+	TargetNamespace string
+	SecretMode      string
+	Rewrites        map[string]string
+	Labels          map[string]string
+	// Objects are the source objects to clone, in copy order, already made
+	// portable and skipped entries removed.
+	Objects []*unstructured.Unstructured
+	// Skipped are the source objects that are not cloned, with the reason.
+	Skipped []MigrationStep
+}
+
+// handleCloneNamespace copies the resources of a namespace in one cluster
+// to other clusters, optionally renaming the namespace, rewriting strings,
+// and adding labels.
+func (s *Server) handleCloneNamespace(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		SourceCluster   string            `json:"source_cluster"`
+		Namespace       string            `json:"namespace"`
+		Clusters        []string          `json:"clusters"`
+		TargetNamespace string            `json:"target_namespace"`
+		Kinds           []string          `json:"kinds"`
+		SecretMode      string            `json:"secret_mode"`
+		Rewrites        map[string]string `json:"rewrites"`
+		Labels          map[string]string `json:"labels"`
+		DryRun          bool              `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.SourceCluster == "" {
+		return nil, fmt.Errorf("source_cluster is required")
+	}
+	if len(params.Clusters) == 0 {
+		return nil, fmt.Errorf("clusters is required")
+	}
+	if params.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if err := claude.ValidateK8sNamespace(params.Namespace); err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	if err := server.ValidateNamespace(params.Namespace); err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	req := cloneRequest{
+		Source:          params.SourceCluster,
+		Namespace:       params.Namespace,
+		TargetNamespace: params.TargetNamespace,
+		SecretMode:      params.SecretMode,
+		Rewrites:        params.Rewrites,
+		Labels:          params.Labels,
+	}
+	if req.TargetNamespace == "" {
+		req.TargetNamespace = req.Namespace
+	}
+	if err := claude.ValidateK8sNamespace(req.TargetNamespace); err != nil {
+		return nil, fmt.Errorf("invalid target_namespace: %w", err)
+	}
+	if err := server.ValidateNamespace(req.TargetNamespace); err != nil {
+		return nil, fmt.Errorf("invalid target_namespace: %w", err)
+	}
+	for _, cluster := range params.Clusters {
+		if cluster == req.Source && req.TargetNamespace == req.Namespace {
+			return nil, fmt.Errorf("cannot clone namespace %s onto itself in cluster %s; set target_namespace", req.Namespace, cluster)
+		}
+	}
+	switch req.SecretMode {
+	case "":
+		req.SecretMode = secretModeSkip
+	case secretModeSkip, secretModeCopy, secretModeKeysOnly:
+	default:
+		return nil, fmt.Errorf("invalid secret_mode %q: must be skip, copy, or keys-only", req.SecretMode)
+	}
+	for key, value := range req.Labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label value %q: %s", value, strings.Join(errs, "; "))
+		}
+	}
+	for from := range req.Rewrites {
+		if from == "" {
+			return nil, fmt.Errorf("rewrites must not have an empty key")
+		}
+	}
+	kinds := params.Kinds
+	if len(kinds) == 0 {
+		for _, k := range portableKinds {
+			kinds = append(kinds, k.Kind)
+		}
+	}
+	for _, kind := range kinds {
+		if _, ok := portableGVR(kind); !ok {
+			return nil, fmt.Errorf("unsupported kind %q", kind)
+		}
+	}
+
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+	if err := s.cloneSource(ctx, &req, kinds); err != nil {
+		return nil, err
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, params.Clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.cloneToCluster(ctx, client, clusterName, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"source":          req.Source,
+		"namespace":       req.Namespace,
+		"targetNamespace": req.TargetNamespace,
+		"dryRun":          dryRun,
+		"objects":         len(req.Objects),
+		"skipped":         req.Skipped,
+		"results":         results,
+	}, nil
+}
+
+// cloneSource lists the objects of kinds in the source namespace and fills
+// in req.Objects and req.Skipped.
+func (s *Server) cloneSource(ctx context.Context, req *cloneRequest, kinds []string) error {
+	sourceDyn, err := s.dynamicClient(req.Source)
+	if err != nil {
+		return err
+	}
+	want := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		want[kind] = true
+	}
+	origin := req.Source + "/" + req.Namespace
+	for _, k := range portableKinds {
+		if !want[k.Kind] {
+			continue
+		}
+		list, err := sourceDyn.Resource(k.GVR).Namespace(req.Namespace).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			// The source cluster does not serve this resource.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("listing %s in cluster %s: %w", k.GVR.Resource, req.Source, err)
+		}
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+		for i := range list.Items {
+			obj := &list.Items[i]
+			obj.SetKind(k.Kind)
+			obj.SetAPIVersion(k.GVR.GroupVersion().String())
+			resource := k.Kind + " " + req.Namespace + "/" + obj.GetName()
+			if reason := cloneSkipReason(obj, req.SecretMode); reason != "" {
+				req.Skipped = append(req.Skipped, MigrationStep{Step: migrateStepDiscover, Cluster: req.Source, Resource: resource, Status: "skipped", Message: reason})
+				continue
+			}
+			req.Objects = append(req.Objects, cloneObject(obj, *req, origin))
+		}
+	}
+	return nil
+}
+
+// cloneSkipReason returns why obj is not cloned, or "".
+func cloneSkipReason(obj *unstructured.Unstructured, secretMode string) string {
+	if clusterManagedObjects[obj.GetKind()+"/"+obj.GetName()] {
+		return "created by the cluster in every namespace"
+	}
+	if owners := obj.GetOwnerReferences(); len(owners) > 0 {
+		return fmt.Sprintf("managed by %s %s", owners[0].Kind, owners[0].Name)
+	}
+	if obj.GetKind() == "Secret" {
+		if obj.Object["type"] == string(corev1.SecretTypeServiceAccountToken) {
+			return "service account tokens are issued by the target cluster"
+		}
+		if secretMode == secretModeSkip {
+			return "secret_mode is skip"
+		}
+	}
+	return ""
+}
+
+// cloneObject returns a portable copy of obj for the target namespace,
+// with the request's rewrites and labels applied.
+func cloneObject(obj *unstructured.Unstructured, req cloneRequest, origin string) *unstructured.Unstructured {
+	c := portableCopy(obj)
+	if c.GetKind() == "Secret" && req.SecretMode == secretModeKeysOnly {
+		// Keep the keys, so workloads that mount the Secret still start,
+		// but not the values, which must be set on the target.
+		data, _, _ := unstructured.NestedMap(c.Object, "data")
+		for key := range data {
+			data[key] = ""
+		}
+		if len(data) > 0 {
+			_ = unstructured.SetNestedMap(c.Object, data, "data")
+		}
+		unstructured.RemoveNestedField(c.Object, "stringData")
+	}
+	if len(req.Rewrites) > 0 {
+		c.Object = rewriteStrings(c.Object, req.Rewrites, c.GetKind() == "Secret").(map[string]interface{})
+	}
+	c.SetNamespace(req.TargetNamespace)
+	if len(req.Labels) > 0 {
+		labels := c.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		for key, value := range req.Labels {
+			labels[key] = value
+		}
+		c.SetLabels(labels)
+	}
+	annotate(c, clonedFromAnnotation, origin)
+	return c
+}
+
+// rewriteStrings replaces every key of rewrites with its value in the
+// string values of v, longest key first. The base64 data of Secrets is left
+// alone.
+func rewriteStrings(v interface{}, rewrites map[string]string, secret bool) interface{} {
+	keys := make([]string, 0, len(rewrites))
+	for from := range rewrites {
+		keys = append(keys, from)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	pairs := make([]string, 0, 2*len(keys))
+	for _, from := range keys {
+		pairs = append(pairs, from, rewrites[from])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	var walk func(v interface{}, top bool) interface{}
+	walk = func(v interface{}, top bool) interface{} {
+		switch val := v.(type) {
+		case string:
+			return replacer.Replace(val)
+		case map[string]interface{}:
+			for key, child := range val {
+				if top && secret && key == "data" {
+					continue
+				}
+				val[key] = walk(child, false)
+			}
+			return val
+		case []interface{}:
+			for i, child := range val {
+				val[i] = walk(child, false)
+			}
+			return val
+		default:
+			return v
+		}
+	}
+	return walk(v, true)
+}
+
+// cloneToCluster creates the target namespace and the request's objects in
+// a target cluster, leaving objects that already exist there untouched.
+func (s *Server) cloneToCluster(ctx context.Context, client *kubernetes.Clientset, clusterName string, req cloneRequest) (*CloneResult, error) {
+	targetDyn, err := s.dynamicClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	result := &CloneResult{Namespace: req.TargetNamespace}
+	status, nsMissing, err := ensureNamespace(ctx, client, req.TargetNamespace, clonedFromAnnotation, req.Source+"/"+req.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("creating namespace %s: %w", req.TargetNamespace, err)
+	}
+	if status != "" {
+		result.Steps = append(result.Steps, MigrationStep{Step: migrateStepCopy, Cluster: clusterName, Resource: "Namespace " + req.TargetNamespace, Status: status})
+	}
+
+	for _, obj := range req.Objects {
+		resource := obj.GetKind() + " " + req.TargetNamespace + "/" + obj.GetName()
+		gvr, _ := portableGVR(obj.GetKind())
+		step := MigrationStep{Step: migrateStepCopy, Cluster: clusterName, Resource: resource}
+		step.Status, err = createIfMissing(ctx, targetDyn, gvr, obj.DeepCopy(), nsMissing)
+		switch {
+		case err != nil:
+			step.Status, step.Message = "failed", err.Error()
+			result.Failed++
+		case step.Status == "exists":
+			result.Existing++
+		default:
+			result.Created++
+		}
+		result.Steps = append(result.Steps, step)
+	}
+	return result, nil
+}
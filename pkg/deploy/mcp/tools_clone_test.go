@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// newCloneClusters returns a source cluster with namespace shop and two
+// empty target clusters, eu and ap.
+func newCloneClusters(t *testing.T) (source, eu, ap *objectAPIServer, server *Server) {
+	source, sourceURL := newObjectAPIServer(t, false)
+	eu, euURL := newObjectAPIServer(t, false)
+	ap, apURL := newObjectAPIServer(t, false)
+
+	source.put(t, "/api/v1/namespaces/shop/configmaps/app-config", &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "shop", Labels: map[string]string{"region": "us-east-1"}},
+		Data:       map[string]string{"DB_HOST": "db.us-east-1.example.com"},
+	})
+	source.put(t, "/api/v1/namespaces/shop/configmaps/kube-root-ca.crt", &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-root-ca.crt", Namespace: "shop"},
+	})
+	source.put(t, "/api/v1/namespaces/shop/configmaps/operator-state", &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "operator-state", Namespace: "shop",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "example.com/v1", Kind: "Database", Name: "db", UID: "1"}}},
+	})
+	source.put(t, "/api/v1/namespaces/shop/secrets/db-creds", &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "db-creds", Namespace: "shop"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	})
+	replicas := int32(2)
+	source.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", UID: "src-uid"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: "registry.us-east-1.example.com/web:v1"}}},
+			},
+		},
+	})
+	return source, eu, ap, newAtomicTestServer(t, map[string]string{"us": sourceURL, "eu": euURL, "ap": apURL})
+}
+
+func TestCloneNamespaceRewritesAndSkipsSecretsByDefault(t *testing.T) {
+	_, eu, ap, server := newCloneClusters(t)
+	ap.put(t, "/api/v1/namespaces/shop-west", map[string]interface{}{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]interface{}{"name": "shop-west"}})
+	ap.put(t, "/api/v1/namespaces/shop-west/configmaps/app-config", map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]interface{}{"name": "app-config", "namespace": "shop-west"},
+		"data":     map[string]interface{}{"DB_HOST": "local"},
+	})
+
+	out, errText := callTool(t, server, "clone_namespace", map[string]interface{}{
+		"source_cluster":   "us",
+		"namespace":        "shop",
+		"clusters":         []string{"eu", "ap"},
+		"target_namespace": "shop-west",
+		"rewrites":         map[string]string{"us-east-1": "eu-west-1"},
+		"labels":           map[string]string{"cloned": "true"},
+	})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 2, out["objects"], "app-config and web")
+	skipped := map[string]string{}
+	for _, s := range out["skipped"].([]interface{}) {
+		step := s.(map[string]interface{})
+		skipped[step["resource"].(string)] = step["message"].(string)
+	}
+	assert.Contains(t, skipped["ConfigMap shop/kube-root-ca.crt"], "created by the cluster")
+	assert.Contains(t, skipped["ConfigMap shop/operator-state"], "managed by Database db")
+	assert.Contains(t, skipped["Secret shop/db-creds"], "secret_mode is skip")
+
+	cm := eu.get("/api/v1/namespaces/shop-west/configmaps/app-config")
+	require.NotNil(t, cm)
+	data, _, _ := unstructured.NestedStringMap(cm, "data")
+	assert.Equal(t, "db.eu-west-1.example.com", data["DB_HOST"])
+	labels, _, _ := unstructured.NestedStringMap(cm, "metadata", "labels")
+	assert.Equal(t, map[string]string{"region": "eu-west-1", "cloned": "true"}, labels)
+	annotations, _, _ := unstructured.NestedStringMap(cm, "metadata", "annotations")
+	assert.Equal(t, "us/shop", annotations[clonedFromAnnotation])
+
+	web := eu.get("/apis/apps/v1/namespaces/shop-west/deployments/web")
+	require.NotNil(t, web)
+	containers, _, _ := unstructured.NestedSlice(web, "spec", "template", "spec", "containers")
+	assert.Equal(t, "registry.eu-west-1.example.com/web:v1", containers[0].(map[string]interface{})["image"])
+	assert.Empty(t, web["metadata"].(map[string]interface{})["uid"])
+	assert.False(t, eu.has("/api/v1/namespaces/shop-west/secrets/db-creds"))
+	assert.True(t, eu.has("/api/v1/namespaces/shop-west"))
+
+	data, _, _ = unstructured.NestedStringMap(ap.get("/api/v1/namespaces/shop-west/configmaps/app-config"), "data")
+	assert.Equal(t, "local", data["DB_HOST"], "existing objects are not overwritten")
+	for _, r := range out["results"].([]interface{}) {
+		result := r.(map[string]interface{})
+		clone := result["result"].(map[string]interface{})
+		if result["cluster"] == "ap" {
+			assert.EqualValues(t, 1, clone["existing"])
+			assert.EqualValues(t, 1, clone["created"])
+		}
+	}
+}
+
+func TestCloneNamespaceCopiesSecretKeysOnly(t *testing.T) {
+	_, eu, _, server := newCloneClusters(t)
+	out, errText := callTool(t, server, "clone_namespace", map[string]interface{}{
+		"source_cluster": "us",
+		"namespace":      "shop",
+		"clusters":       []string{"eu"},
+		"kinds":          []string{"Secret"},
+		"secret_mode":    "keys-only",
+		"rewrites":       map[string]string{"aHVudGVy": "changed"},
+	})
+	require.Empty(t, errText)
+	assert.NotContains(t, mustMarshalJSON(t, out), "aHVudGVyMg==", "secret data is never reported")
+	data, _, _ := unstructured.NestedStringMap(eu.get("/api/v1/namespaces/shop/secrets/db-creds"), "data")
+	assert.Equal(t, map[string]string{"password": ""}, data)
+	assert.False(t, eu.has("/api/v1/namespaces/shop/configmaps/app-config"), "only the listed kinds are copied")
+}
+
+func TestCloneNamespaceCopiesSecretsUnchanged(t *testing.T) {
+	_, eu, _, server := newCloneClusters(t)
+	_, errText := callTool(t, server, "clone_namespace", map[string]interface{}{
+		"source_cluster": "us", "namespace": "shop", "clusters": []string{"eu"}, "kinds": []string{"Secret"},
+		"secret_mode": "copy", "rewrites": map[string]string{"aHVudGVy": "changed"},
+	})
+	require.Empty(t, errText)
+	data, _, _ := unstructured.NestedStringMap(eu.get("/api/v1/namespaces/shop/secrets/db-creds"), "data")
+	assert.Equal(t, "aHVudGVyMg==", data["password"], "secret data is not rewritten")
+}
+
+func TestCloneNamespaceDryRunChangesNothing(t *testing.T) {
+	_, eu, _, server := newCloneClusters(t)
+	out, errText := callTool(t, server, "clone_namespace", map[string]interface{}{
+		"source_cluster": "us", "namespace": "shop", "clusters": []string{"eu"}, "dry_run": true,
+	})
+	require.Empty(t, errText)
+	assert.Equal(t, true, out["dryRun"])
+	result := out["results"].([]interface{})[0].(map[string]interface{})["result"].(map[string]interface{})
+	assert.EqualValues(t, 2, result["created"])
+	first := result["steps"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "Namespace shop", first["resource"])
+	assert.Equal(t, "would-create", first["status"])
+	assert.False(t, eu.has("/api/v1/namespaces/shop"))
+	assert.False(t, eu.has("/apis/apps/v1/namespaces/shop/deployments/web"))
+}
+
+func TestCloneNamespaceInvalidArgs(t *testing.T) {
+	_, _, _, server := newCloneClusters(t)
+	for _, args := range []map[string]interface{}{
+		{"namespace": "shop", "clusters": []string{"eu"}},
+		{"source_cluster": "us", "clusters": []string{"eu"}},
+		{"source_cluster": "us", "namespace": "shop"},
+		{"source_cluster": "us", "namespace": "kube-system", "clusters": []string{"eu"}},
+		{"source_cluster": "us", "namespace": "shop", "clusters": []string{"us"}},
+		{"source_cluster": "us", "namespace": "shop", "clusters": []string{"eu"}, "kinds": []string{"ClusterRoleBinding"}},
+		{"source_cluster": "us", "namespace": "shop", "clusters": []string{"eu"}, "secret_mode": "encrypt"},
+		{"source_cluster": "us", "namespace": "shop", "clusters": []string{"eu"}, "labels": map[string]string{"bad key": "x"}},
+	} {
+		_, errText := callTool(t, server, "clone_namespace", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+}
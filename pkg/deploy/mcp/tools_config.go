@@ -0,0 +1,414 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// restartedAtAnnotation is the pod template annotation kubectl rollout
+// restart sets.
+const restartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
+// How a pod uses a ConfigMap or Secret. Only volume mounts without a
+// subPath see an update without a restart.
+const (
+	usageVolume          = "volume"
+	usageSubPath         = "subPath"
+	usageEnvFrom         = "envFrom"
+	usageEnv             = "env"
+	usageImagePullSecret = "imagePullSecret"
+)
+
+// Restart modes of update_config.
+const (
+	restartNone     = "none"
+	restartAffected = "affected"
+	restartRequired = "required"
+)
+
+// ConfigConsumer is a workload whose pods use a ConfigMap or Secret.
+type ConfigConsumer struct {
+	Workload string   `json:"workload"`
+	Uses     []string `json:"uses"`
+	// NeedsRestart is set when the pods only see a change after a restart:
+	// environment variables and subPath mounts are not refreshed.
+	NeedsRestart bool   `json:"needsRestart"`
+	Restarted    bool   `json:"restarted,omitempty"`
+	Error        string `json:"error,omitempty"`
+
+	workload appWorkload
+}
+
+// ConfigUpdate is the outcome of update_config in one cluster.
+type ConfigUpdate struct {
+	Cluster string `json:"cluster"`
+	// Status is updated, would-update, unchanged, or not-found.
+	Status string `json:"status"`
+	// Changed lists the keys set or removed. Values are never reported.
+	Changed   []string         `json:"changed,omitempty"`
+	Consumers []ConfigConsumer `json:"consumers"`
+	Restarted int              `json:"restarted"`
+}
+
+// handleUpdateConfig updates a ConfigMap or Secret in every cluster that
+// has it, reporting the workloads that use it and restarting them on
+// request.
+func (s *Server) handleUpdateConfig(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Kind       string            `json:"kind"`
+		Name       string            `json:"name"`
+		Namespace  string            `json:"namespace"`
+		Clusters   []string          `json:"clusters"`
+		Data       map[string]string `json:"data"`
+		RemoveKeys []string          `json:"remove_keys"`
+		Restart    string            `json:"restart"`
+		DryRun     bool              `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	switch params.Kind {
+	case "":
+		params.Kind = "ConfigMap"
+	case "ConfigMap", "Secret":
+	default:
+		return nil, fmt.Errorf("invalid kind %q: must be ConfigMap or Secret", params.Kind)
+	}
+	if err := claude.ValidateK8sName(params.Name); err != nil {
+		return nil, fmt.Errorf("invalid name: %w", err)
+	}
+	if err := server.ValidateNamespace(params.Namespace); err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	if len(params.Data) == 0 && len(params.RemoveKeys) == 0 {
+		return nil, fmt.Errorf("data or remove_keys is required")
+	}
+	for key := range params.Data {
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid data key %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	for _, key := range params.RemoveKeys {
+		if _, ok := params.Data[key]; ok {
+			return nil, fmt.Errorf("key %q is both set in data and listed in remove_keys", key)
+		}
+	}
+	switch params.Restart {
+	case "":
+		params.Restart = restartNone
+	case restartNone, restartAffected, restartRequired:
+	default:
+		return nil, fmt.Errorf("invalid restart %q: must be none, affected, or required", params.Restart)
+	}
+	clusters := params.Clusters
+	if len(clusters) == 0 {
+		names, err := s.executor.ClusterNames()
+		if err != nil {
+			return nil, err
+		}
+		clusters = names
+	}
+
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+	var results []multicluster.ClusterResult
+	done := 0
+	s.executor.ExecuteStream(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return updateConfigInCluster(ctx, client, clusterName, params.Kind, params.Namespace, params.Name, params.Data, params.RemoveKeys, params.Restart)
+	}, func(result multicluster.ClusterResult) {
+		done++
+		message := "updated " + result.Cluster
+		if update, ok := result.Result.(*ConfigUpdate); ok {
+			message = fmt.Sprintf("%s: %s, %d workloads restarted", result.Cluster, update.Status, update.Restarted)
+		} else if result.Error != "" {
+			message = fmt.Sprintf("%s: failed", result.Cluster)
+		}
+		reportProgress(ctx, done, len(clusters), message)
+		results = append(results, result)
+	})
+	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+
+	consumers, restarted := 0, 0
+	for _, r := range results {
+		if update, ok := r.Result.(*ConfigUpdate); ok {
+			consumers += len(update.Consumers)
+			restarted += update.Restarted
+		}
+	}
+	return map[string]interface{}{
+		"kind":      params.Kind,
+		"name":      params.Name,
+		"namespace": params.Namespace,
+		"restart":   params.Restart,
+		"dryRun":    dryRun,
+		"consumers": consumers,
+		"restarted": restarted,
+		"results":   results,
+	}, nil
+}
+
+// updateConfigInCluster applies the update in one cluster and restarts the
+// consumers the restart mode selects.
+func updateConfigInCluster(ctx context.Context, client kubernetes.Interface, clusterName, kind, namespace, name string, set map[string]string, remove []string, restart string) (*ConfigUpdate, error) {
+	update := &ConfigUpdate{Cluster: clusterName, Consumers: []ConfigConsumer{}}
+	var err error
+	if kind == "Secret" {
+		update.Changed, err = updateSecretData(ctx, client, namespace, name, set, remove)
+	} else {
+		update.Changed, err = updateConfigMapData(ctx, client, namespace, name, set, remove)
+	}
+	if apierrors.IsNotFound(err) {
+		update.Status = "not-found"
+		return update, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	consumers, err := findConfigConsumers(ctx, client, namespace, kind, name)
+	if err != nil {
+		return nil, fmt.Errorf("finding workloads that use %s %s/%s: %w", kind, namespace, name, err)
+	}
+	update.Consumers = consumers
+	switch {
+	case len(update.Changed) == 0:
+		update.Status = "unchanged"
+		return update, nil
+	case approval.IsDryRun(ctx):
+		update.Status = "would-update"
+	default:
+		update.Status = "updated"
+	}
+	if restart == restartNone {
+		return update, nil
+	}
+	now := time.Now()
+	for i := range update.Consumers {
+		c := &update.Consumers[i]
+		if restart == restartRequired && !c.NeedsRestart {
+			continue
+		}
+		if err := restartWorkload(ctx, client, c.workload, now); err != nil {
+			c.Error = err.Error()
+			continue
+		}
+		c.Restarted = true
+		update.Restarted++
+	}
+	return update, nil
+}
+
+// updateConfigMapData sets and removes keys of a ConfigMap and returns the
+// keys that changed.
+func updateConfigMapData(ctx context.Context, client kubernetes.Interface, namespace, name string, set map[string]string, remove []string) ([]string, error) {
+	cm, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for key, value := range set {
+		if current, ok := cm.Data[key]; !ok || current != value {
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+			cm.Data[key] = value
+			changed = append(changed, key)
+		}
+	}
+	for _, key := range remove {
+		if _, ok := cm.Data[key]; ok {
+			delete(cm.Data, key)
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	if cm.Immutable != nil && *cm.Immutable {
+		return nil, fmt.Errorf("ConfigMap %s/%s is immutable; create a new one and point the workloads at it", namespace, name)
+	}
+	if _, err := client.CoreV1().ConfigMaps(namespace).Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// updateSecretData sets and removes keys of a Secret and returns the keys
+// that changed.
+func updateSecretData(ctx context.Context, client kubernetes.Interface, namespace, name string, set map[string]string, remove []string) ([]string, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for key, value := range set {
+		if current, ok := secret.Data[key]; !ok || string(current) != value {
+			if secret.Data == nil {
+				secret.Data = make(map[string][]byte)
+			}
+			secret.Data[key] = []byte(value)
+			changed = append(changed, key)
+		}
+	}
+	for _, key := range remove {
+		if _, ok := secret.Data[key]; ok {
+			delete(secret.Data, key)
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) == 0 {
+		return nil, nil
+	}
+	if secret.Immutable != nil && *secret.Immutable {
+		return nil, fmt.Errorf("Secret %s/%s is immutable; create a new one and point the workloads at it", namespace, name)
+	}
+	if _, err := client.CoreV1().Secrets(namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// findConfigConsumers returns the Deployments, StatefulSets, and DaemonSets
+// in namespace whose pods use the ConfigMap or Secret name.
+func findConfigConsumers(ctx context.Context, client kubernetes.Interface, namespace, kind, name string) ([]ConfigConsumer, error) {
+	var workloads []appWorkload
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range deployments.Items {
+		workloads = append(workloads, appWorkload{Kind: "Deployment", deployment: &deployments.Items[i]})
+	}
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range statefulSets.Items {
+		workloads = append(workloads, appWorkload{Kind: "StatefulSet", statefulSet: &statefulSets.Items[i]})
+	}
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for i := range daemonSets.Items {
+		workloads = append(workloads, appWorkload{Kind: "DaemonSet", daemonSet: &daemonSets.Items[i]})
+	}
+
+	consumers := []ConfigConsumer{}
+	for _, w := range workloads {
+		template, _ := w.podTemplate()
+		uses := configUsage(template.Spec, kind, name)
+		if len(uses) == 0 {
+			continue
+		}
+		c := ConfigConsumer{Workload: w.Kind + "/" + w.meta().GetName(), Uses: uses, workload: w}
+		for _, use := range uses {
+			if use == usageSubPath || use == usageEnvFrom || use == usageEnv {
+				c.NeedsRestart = true
+			}
+		}
+		consumers = append(consumers, c)
+	}
+	return consumers, nil
+}
+
+// configUsage returns how a pod spec uses the ConfigMap or Secret name.
+func configUsage(spec corev1.PodSpec, kind, name string) []string {
+	uses := make(map[string]bool)
+	volumes := make(map[string]bool)
+	for _, v := range spec.Volumes {
+		switch {
+		case kind == "ConfigMap" && v.ConfigMap != nil && v.ConfigMap.Name == name,
+			kind == "Secret" && v.Secret != nil && v.Secret.SecretName == name:
+			volumes[v.Name] = true
+		case v.Projected != nil:
+			for _, src := range v.Projected.Sources {
+				if (kind == "ConfigMap" && src.ConfigMap != nil && src.ConfigMap.Name == name) ||
+					(kind == "Secret" && src.Secret != nil && src.Secret.Name == name) {
+					volumes[v.Name] = true
+				}
+			}
+		}
+	}
+	if kind == "Secret" {
+		for _, ps := range spec.ImagePullSecrets {
+			if ps.Name == name {
+				uses[usageImagePullSecret] = true
+			}
+		}
+	}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, m := range c.VolumeMounts {
+			if !volumes[m.Name] {
+				continue
+			}
+			if m.SubPath != "" || m.SubPathExpr != "" {
+				uses[usageSubPath] = true
+			} else {
+				uses[usageVolume] = true
+			}
+		}
+		for _, from := range c.EnvFrom {
+			if (kind == "ConfigMap" && from.ConfigMapRef != nil && from.ConfigMapRef.Name == name) ||
+				(kind == "Secret" && from.SecretRef != nil && from.SecretRef.Name == name) {
+				uses[usageEnvFrom] = true
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if (kind == "ConfigMap" && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == name) ||
+				(kind == "Secret" && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name) {
+				uses[usageEnv] = true
+			}
+		}
+	}
+	return sortedKeys(uses)
+}
+
+// restartWorkload restarts a workload's pods as kubectl rollout restart
+// does, by stamping its pod template.
+func restartWorkload(ctx context.Context, client kubernetes.Interface, w appWorkload, now time.Time) error {
+	stamp := func(template *corev1.PodTemplateSpec) {
+		if template.Annotations == nil {
+			template.Annotations = make(map[string]string)
+		}
+		template.Annotations[restartedAtAnnotation] = now.UTC().Format(time.RFC3339)
+	}
+	var err error
+	switch {
+	case w.deployment != nil:
+		d := w.deployment.DeepCopy()
+		stamp(&d.Spec.Template)
+		_, err = client.AppsV1().Deployments(d.Namespace).Update(ctx, d, metav1.UpdateOptions{})
+	case w.statefulSet != nil:
+		ss := w.statefulSet.DeepCopy()
+		stamp(&ss.Spec.Template)
+		_, err = client.AppsV1().StatefulSets(ss.Namespace).Update(ctx, ss, metav1.UpdateOptions{})
+	default:
+		ds := w.daemonSet.DeepCopy()
+		stamp(&ds.Spec.Template)
+		_, err = client.AppsV1().DaemonSets(ds.Namespace).Update(ctx, ds, metav1.UpdateOptions{})
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConfigUsage(t *testing.T) {
+	spec := corev1.PodSpec{
+		Volumes: []corev1.Volume{
+			{Name: "conf", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "app"}}}},
+			{Name: "all", VolumeSource: corev1.VolumeSource{Projected: &corev1.ProjectedVolumeSource{Sources: []corev1.VolumeProjection{
+				{Secret: &corev1.SecretProjection{LocalObjectReference: corev1.LocalObjectReference{Name: "creds"}}},
+			}}}},
+		},
+		ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+		Containers: []corev1.Container{{
+			Name:         "web",
+			VolumeMounts: []corev1.VolumeMount{{Name: "conf", MountPath: "/etc/app"}, {Name: "all", MountPath: "/etc/creds/key", SubPath: "key"}},
+			Env: []corev1.EnvVar{{Name: "MODE", ValueFrom: &corev1.EnvVarSource{
+				ConfigMapKeyRef: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "flags"}, Key: "mode"},
+			}}},
+		}},
+	}
+	assert.Equal(t, []string{usageVolume}, configUsage(spec, "ConfigMap", "app"))
+	assert.Equal(t, []string{usageSubPath}, configUsage(spec, "Secret", "creds"))
+	assert.Equal(t, []string{usageEnv}, configUsage(spec, "ConfigMap", "flags"))
+	assert.Equal(t, []string{usageImagePullSecret}, configUsage(spec, "Secret", "registry"))
+	assert.Empty(t, configUsage(spec, "Secret", "app"), "kinds are not confused")
+}
+
+// newConfigClusters returns two clusters with ConfigMap shop/web-config,
+// used by Deployment web through envFrom and by DaemonSet agent through a
+// volume, and a third cluster without it.
+func newConfigClusters(t *testing.T) (east, west *objectAPIServer, server *Server) {
+	east, eastURL := newObjectAPIServer(t, false)
+	west, westURL := newObjectAPIServer(t, false)
+	_, emptyURL := newObjectAPIServer(t, false)
+	for _, cluster := range []*objectAPIServer{east, west} {
+		cluster.put(t, shopConfigPath, &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "shop"},
+			Data:       map[string]string{"MODE": "blue", "OLD": "x"},
+		})
+		cluster.put(t, shopDeployPath, webDeployment("web:v1"))
+		cluster.put(t, "/apis/apps/v1/namespaces/shop/daemonsets/agent", &appsv1.DaemonSet{
+			TypeMeta:   metav1.TypeMeta{Kind: "DaemonSet", APIVersion: "apps/v1"},
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "shop"},
+			Spec: appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Volumes:    []corev1.Volume{{Name: "conf", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}}}},
+				Containers: []corev1.Container{{Name: "agent", VolumeMounts: []corev1.VolumeMount{{Name: "conf", MountPath: "/etc/agent"}}}},
+			}}},
+		})
+	}
+	return east, west, newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL, "empty": emptyURL})
+}
+
+func TestUpdateConfigRestartsOnlyWorkloadsThatNeedIt(t *testing.T) {
+	east, west, server := newConfigClusters(t)
+
+	out, errText := callTool(t, server, "update_config", map[string]interface{}{
+		"name": "web-config", "namespace": "shop",
+		"data": map[string]string{"MODE": "green"}, "remove_keys": []string{"OLD"}, "restart": "required",
+	})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 4, out["consumers"])
+	assert.EqualValues(t, 2, out["restarted"])
+
+	results := out["results"].([]interface{})
+	require.Len(t, results, 3)
+	assert.Equal(t, "not-found", results[1].(map[string]interface{})["result"].(map[string]interface{})["status"])
+	update := results[0].(map[string]interface{})["result"].(map[string]interface{})
+	assert.Equal(t, "east", update["cluster"])
+	assert.Equal(t, "updated", update["status"])
+	assert.Equal(t, []interface{}{"MODE", "OLD"}, update["changed"])
+	consumers := map[string]map[string]interface{}{}
+	for _, c := range update["consumers"].([]interface{}) {
+		consumer := c.(map[string]interface{})
+		consumers[consumer["workload"].(string)] = consumer
+	}
+	assert.Equal(t, true, consumers["Deployment/web"]["needsRestart"])
+	assert.Equal(t, true, consumers["Deployment/web"]["restarted"])
+	assert.Equal(t, []interface{}{usageVolume}, consumers["DaemonSet/agent"]["uses"])
+	assert.Nil(t, consumers["DaemonSet/agent"]["restarted"])
+
+	for _, cluster := range []*objectAPIServer{east, west} {
+		data, _, _ := unstructured.NestedStringMap(cluster.get(shopConfigPath), "data")
+		assert.Equal(t, map[string]string{"MODE": "green"}, data)
+		restartedAt, _, _ := unstructured.NestedString(cluster.get(shopDeployPath), "spec", "template", "metadata", "annotations", restartedAtAnnotation)
+		assert.NotEmpty(t, restartedAt)
+		_, found, _ := unstructured.NestedString(cluster.get("/apis/apps/v1/namespaces/shop/daemonsets/agent"), "spec", "template", "metadata", "annotations", restartedAtAnnotation)
+		assert.False(t, found)
+	}
+
+	out, errText = callTool(t, server, "update_config", map[string]interface{}{
+		"name": "web-config", "namespace": "shop", "clusters": []string{"east"},
+		"data": map[string]string{"MODE": "green"}, "restart": "affected",
+	})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 0, out["restarted"], "unchanged objects restart nothing")
+}
+
+func TestUpdateConfigSecretDryRun(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	east.put(t, shopSecretPath, &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-creds", Namespace: "shop"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	})
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL})
+
+	out, errText := callTool(t, server, "update_config", map[string]interface{}{
+		"kind": "Secret", "name": "web-creds", "namespace": "shop",
+		"data": map[string]string{"password": "correct-horse"}, "restart": "affected", "dry_run": true,
+	})
+	require.Empty(t, errText)
+	assert.NotContains(t, mustMarshalJSON(t, out), "correct-horse")
+	assert.NotContains(t, mustMarshalJSON(t, out), "hunter2")
+	update := out["results"].([]interface{})[0].(map[string]interface{})["result"].(map[string]interface{})
+	assert.Equal(t, "would-update", update["status"])
+	data, _, _ := unstructured.NestedStringMap(east.get(shopSecretPath), "data")
+	assert.Equal(t, "aHVudGVyMg==", data["password"])
+}
+
+func TestUpdateConfigRefusesImmutableObjects(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	immutable := true
+	east.put(t, shopConfigPath, &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "shop"},
+		Data:       map[string]string{"MODE": "blue"},
+		Immutable:  &immutable,
+	})
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL})
+	out, errText := callTool(t, server, "update_config", map[string]interface{}{
+		"name": "web-config", "namespace": "shop", "data": map[string]string{"MODE": "green"},
+	})
+	require.Empty(t, errText)
+	assert.Contains(t, out["results"].([]interface{})[0].(map[string]interface{})["error"], "immutable")
+}
+
+func TestUpdateConfigInvalidArgs(t *testing.T) {
+	_, _, server := newConfigClusters(t)
+	data := map[string]string{"MODE": "green"}
+	for _, args := range []map[string]interface{}{
+		{"namespace": "shop", "data": data},
+		{"name": "web-config", "data": data},
+		{"name": "web-config", "namespace": "kube-system", "data": data},
+		{"name": "web-config", "namespace": "shop"},
+		{"name": "web-config", "namespace": "shop", "kind": "Deployment", "data": data},
+		{"name": "web-config", "namespace": "shop", "data": data, "restart": "always"},
+		{"name": "web-config", "namespace": "shop", "data": data, "remove_keys": []string{"MODE"}},
+		{"name": "web-config", "namespace": "shop", "data": map[string]string{"bad key": "x"}},
+	} {
+		_, errText := callTool(t, server, "update_config", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+}
@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// controllerConflict is another controller that manages a workload, and
+// that would revert an edit to it or fight over it.
+type controllerConflict struct {
+	// Controller is HorizontalPodAutoscaler, Argo CD, Flux, or the kind
+	// of the workload's controlling owner.
+	Controller string `json:"controller"`
+	Reason     string `json:"reason"`
+}
+
+// gitOpsLabels and gitOpsAnnotations mark objects that a GitOps tool
+// applied. Argo CD's default tracking label, app.kubernetes.io/instance,
+// is not among them: Helm charts set it too.
+var (
+	gitOpsLabels = []gitOpsMarker{
+		{"argocd.argoproj.io/instance", "Argo CD"},
+		{"kustomize.toolkit.fluxcd.io/name", "Flux"},
+		{"helm.toolkit.fluxcd.io/name", "Flux"},
+	}
+	gitOpsAnnotations = []gitOpsMarker{
+		{"argocd.argoproj.io/tracking-id", "Argo CD"},
+	}
+)
+
+type gitOpsMarker struct {
+	key, controller string
+}
+
+// gitOpsManagers are the field managers of GitOps controllers.
+var gitOpsManagers = map[string]string{
+	"argocd-controller":             "Argo CD",
+	"argocd-application-controller": "Argo CD",
+	"kustomize-controller":          "Flux",
+	"helm-controller":               "Flux",
+}
+
+// workloadConflicts returns the controllers other than the tools that
+// manage w: its controlling owner, a GitOps tool that applied it, and, if
+// the change sets replicas, a HorizontalPodAutoscaler that scales it.
+func workloadConflicts(ctx context.Context, client kubernetes.Interface, w appWorkload, replicas bool) ([]controllerConflict, error) {
+	obj := w.meta()
+	var conflicts []controllerConflict
+	seen := make(map[string]bool)
+	add := func(controller, reason string) {
+		if !seen[controller] {
+			seen[controller] = true
+			conflicts = append(conflicts, controllerConflict{Controller: controller, Reason: reason})
+		}
+	}
+
+	if owner := metav1.GetControllerOfNoCopy(obj); owner != nil {
+		add(owner.Kind, fmt.Sprintf("controlled by %s %s", owner.Kind, owner.Name))
+	}
+	for _, m := range gitOpsLabels {
+		if value, ok := obj.GetLabels()[m.key]; ok {
+			add(m.controller, fmt.Sprintf("label %s=%s", m.key, value))
+		}
+	}
+	for _, m := range gitOpsAnnotations {
+		if value, ok := obj.GetAnnotations()[m.key]; ok {
+			add(m.controller, fmt.Sprintf("annotation %s=%s", m.key, value))
+		}
+	}
+	for _, entry := range obj.GetManagedFields() {
+		if controller, ok := gitOpsManagers[entry.Manager]; ok {
+			add(controller, "fields managed by "+entry.Manager)
+		}
+	}
+
+	if replicas {
+		hpas, err := client.AutoscalingV2().HorizontalPodAutoscalers(obj.GetNamespace()).List(ctx, metav1.ListOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to list HorizontalPodAutoscalers: %w", err)
+		}
+		if hpas != nil {
+			for _, hpa := range hpas.Items {
+				ref := hpa.Spec.ScaleTargetRef
+				if ref.Kind == w.Kind && ref.Name == obj.GetName() {
+					add("HorizontalPodAutoscaler", fmt.Sprintf("HorizontalPodAutoscaler %s scales it between %d and %d replicas",
+						hpa.Name, replicasOrDefault(hpa.Spec.MinReplicas), hpa.Spec.MaxReplicas))
+				}
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// checkConflicts returns an error naming the controllers that manage w,
+// unless override is set.
+func checkConflicts(ctx context.Context, client kubernetes.Interface, w appWorkload, replicas, override bool) ([]controllerConflict, error) {
+	conflicts, err := workloadConflicts(ctx, client, w, replicas)
+	if err != nil || len(conflicts) == 0 || override {
+		return conflicts, err
+	}
+	reasons := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		reasons = append(reasons, fmt.Sprintf("%s (%s)", c.Controller, c.Reason))
+	}
+	return nil, fmt.Errorf("%s is managed by %s, which would revert or override the change; change it there, or pass override: true",
+		w, strings.Join(reasons, ", "))
+}
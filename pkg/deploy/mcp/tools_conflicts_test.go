@@ -0,0 +1,48 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWorkloadConflicts(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx := context.Background()
+
+	plain := mkDeployment("web", "shop", "web", 1, 1)
+	conflicts, err := workloadConflicts(ctx, client, appWorkload{Kind: "Deployment", deployment: &plain}, true)
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	managed := mkDeployment("web", "shop", "web", 1, 1)
+	managed.Annotations = map[string]string{"argocd.argoproj.io/tracking-id": "shop:apps/Deployment:shop/web"}
+	managed.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "argocd-controller"}, {Manager: "helm-controller"}}
+	managed.OwnerReferences = []metav1.OwnerReference{{Kind: "WebApp", Name: "web", Controller: boolPtr(true)}}
+	conflicts, err = workloadConflicts(ctx, client, appWorkload{Kind: "Deployment", deployment: &managed}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []controllerConflict{
+		{Controller: "WebApp", Reason: "controlled by WebApp web"},
+		{Controller: "Argo CD", Reason: "annotation argocd.argoproj.io/tracking-id=shop:apps/Deployment:shop/web"},
+		{Controller: "Flux", Reason: "fields managed by helm-controller"},
+	}, conflicts)
+}
+
+func TestCheckConflictsOverride(t *testing.T) {
+	managed := mkStatefulSet("db", "shop", "db", 1, 1)
+	managed.Labels["argocd.argoproj.io/instance"] = "shop"
+	w := appWorkload{Kind: "StatefulSet", statefulSet: &managed}
+	client := fake.NewSimpleClientset()
+
+	_, err := checkConflicts(context.Background(), client, w, true, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "StatefulSet shop/db is managed by Argo CD (label argocd.argoproj.io/instance=shop)")
+
+	conflicts, err := checkConflicts(context.Background(), client, w, true, true)
+	require.NoError(t, err)
+	assert.Len(t, conflicts, 1)
+}
@@ -4,18 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"strings"
+	"time"
 
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
 	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
 	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
 	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -23,8 +26,11 @@ import (
 type DeployResult struct {
 	Cluster  string `json:"cluster"`
 	Resource string `json:"resource"`
-	Status   string `json:"status"` // created, updated, unchanged, failed
+	Status   string `json:"status"` // created, updated, unchanged, conflict, failed
 	Message  string `json:"message,omitempty"`
+	// Warnings are the API server's warnings, such as Pod Security
+	// violations of a workload's pod template.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // boolPtr returns a pointer to a bool value
@@ -61,14 +67,24 @@ func (s *Server) handleListClusterCapabilities(ctx context.Context, args json.Ra
 	return s.selector.GetClusterCapabilities(ctx)
 }
 
-// handleFindClustersForWorkload finds clusters matching requirements
+// handleFindClustersForWorkload finds clusters matching requirements and
+// ranks them by placement score
 func (s *Server) handleFindClustersForWorkload(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	var params struct {
-		GPUType   string            `json:"gpu_type"`
-		MinGPU    int64             `json:"min_gpu"`
-		MinMemory string            `json:"min_memory"`
-		MinCPU    string            `json:"min_cpu"`
-		Labels    map[string]string `json:"labels"`
+		GPUType      string              `json:"gpu_type"`
+		MinGPU       int64               `json:"min_gpu"`
+		MinMemory    string              `json:"min_memory"`
+		MinCPU       string              `json:"min_cpu"`
+		Labels       map[string]string   `json:"labels"`
+		Tolerations  []corev1.Toleration `json:"tolerations"`
+		Regions      []string            `json:"regions"`
+		Zones        []string            `json:"zones"`
+		OS           string              `json:"os"`
+		Arch         []string            `json:"arch"`
+		ClusterCosts map[string]float64  `json:"cluster_costs"`
+		App          string              `json:"app"`
+		Namespace    string              `json:"namespace"`
+		Weights      map[string]float64  `json:"weights"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
@@ -81,16 +97,53 @@ func (s *Server) handleFindClustersForWorkload(ctx context.Context, args json.Ra
 		MinCPU:     params.MinCPU,
 		NodeLabels: params.Labels,
 	}
+	placement := multicluster.PlacementRequest{
+		WorkloadRequirements: req,
+		Tolerations:          params.Tolerations,
+		Regions:              params.Regions,
+		Zones:                params.Zones,
+		OS:                   params.OS,
+		Arch:                 params.Arch,
+		Costs:                params.ClusterCosts,
+		Weights:              params.Weights,
+	}
+	if err := placement.Validate(); err != nil {
+		return nil, err
+	}
+	if params.App != "" {
+		if err := claude.ValidateK8sName(params.App); err != nil {
+			return nil, fmt.Errorf("invalid app name: %w", err)
+		}
+		running, err := s.appClusters(ctx, params.App, params.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		placement.RunningIn = running
+	}
 
-	clusters, err := s.selector.FindClustersForWorkload(ctx, req)
+	scores, err := s.selector.ScoreClusters(ctx, placement)
 	if err != nil {
 		return nil, err
 	}
 
+	clusters := []string{}
+	ranking := []multicluster.ClusterScore{}
+	excluded := []multicluster.ClusterScore{}
+	for _, score := range scores {
+		if score.Eligible {
+			clusters = append(clusters, score.Cluster)
+			ranking = append(ranking, score)
+		} else {
+			excluded = append(excluded, score)
+		}
+	}
+
 	return map[string]interface{}{
 		"matchingClusters": clusters,
 		"count":            len(clusters),
 		"requirements":     req,
+		"ranking":          ranking,
+		"excluded":         excluded,
 	}, nil
 }
 
@@ -102,10 +155,47 @@ func (s *Server) handleDeployApp(ctx context.Context, args json.RawMessage) (int
 		GPUType  string   `json:"gpu_type"`
 		MinGPU   int64    `json:"min_gpu"`
 		DryRun   bool     `json:"dry_run"`
+		// Preflight dry-runs the manifest on every cluster on the server
+		// first, and applies it nowhere if any cluster would reject it.
+		Preflight bool `json:"preflight"`
+		// Atomic rolls back every cluster if the apply fails anywhere or
+		// the workloads are not ready within TimeoutSeconds.
+		Atomic         bool `json:"atomic"`
+		TimeoutSeconds int  `json:"timeout_seconds"`
+		// RolloutStrategy deploys in stages instead of to every cluster at
+		// once; see tools_rollout.go.
+		RolloutStrategy *rolloutStrategy `json:"rollout_strategy"`
+		// CreateNamespaces creates missing namespaces of the manifest's
+		// resources before applying them.
+		CreateNamespaces bool `json:"create_namespaces"`
+		// ApplySet tracks the applied objects as an apply set, and Prune
+		// deletes the members that the manifest no longer has.
+		ApplySet string `json:"apply_set"`
+		Prune    bool   `json:"prune"`
+		// Wait waits up to TimeoutSeconds for the manifest's workloads to
+		// become ready and reports their health.
+		Wait bool `json:"wait"`
+		// ImageOverrides maps image repositories to the tag or digest to
+		// deploy, and PinDigests deploys every image by digest.
+		ImageOverrides map[string]string `json:"image_overrides"`
+		PinDigests     bool              `json:"pin_digests"`
+		// Template substitutes ${NAME} references in the manifest for each
+		// cluster, from Variables, ClusterVariables, and the built-ins;
+		// either map implies it.
+		Template         bool                         `json:"template"`
+		Variables        map[string]string            `json:"variables"`
+		ClusterVariables map[string]map[string]string `json:"cluster_variables"`
+		// ApplyMode ssa server-side applies every object as FieldManager;
+		// see tools_apply_mode.go.
+		applyModeArgs
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
+	ssa, err := params.serverSideApply()
+	if err != nil {
+		return nil, err
+	}
 
 	// Determine target clusters
 	targetClusters := params.Clusters
@@ -137,14 +227,187 @@ func (s *Server) handleDeployApp(ctx context.Context, args json.RawMessage) (int
 		return nil, fmt.Errorf("no clusters found matching requirements")
 	}
 
+	opts := applyOptions{DryRun: params.DryRun, CreateNamespaces: params.CreateNamespaces, Prune: params.Prune, SSA: ssa}
+	if params.ApplySet != "" {
+		set, err := kubemanifest.ParseApplySet(params.ApplySet, "default")
+		if err != nil {
+			return nil, err
+		}
+		if err := server.ValidateNamespace(set.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid apply set namespace: %w", err)
+		}
+		opts.ApplySet = set
+	} else if params.Prune {
+		return nil, fmt.Errorf("prune requires apply_set")
+	}
+
+	vars, err := newManifestVariables(params.Template, params.Variables, params.ClusterVariables, targetClusters)
+	if err != nil {
+		return nil, err
+	}
+	if vars != nil {
+		manifests, err := decodeManifests(params.Manifest)
+		if err != nil {
+			return nil, err
+		}
+		if err := vars.validate(manifests, targetClusters); err != nil {
+			return nil, err
+		}
+		opts.Variables = vars
+	}
+
+	var imageChanges []ImageChange
+	if len(params.ImageOverrides) > 0 || params.PinDigests {
+		manifest, changes, err := s.rewriteImages(ctx, params.Manifest, params.ImageOverrides, params.PinDigests)
+		if err != nil {
+			return nil, err
+		}
+		params.Manifest, imageChanges = manifest, changes
+	}
+
+	var preflight *preflightReport
+	if params.Preflight {
+		report, results, err := s.preflight(ctx, targetClusters, params.Manifest, opts)
+		if err != nil {
+			return nil, err
+		}
+		if params.DryRun || !report.Passed {
+			out := map[string]interface{}{
+				"targetClusters": targetClusters,
+				"successCount":   len(targetClusters) - len(report.RejectedClusters),
+				"totalClusters":  len(targetClusters),
+				"results":        results,
+				"dryRun":         params.DryRun,
+				"preflight":      report,
+			}
+			if !params.DryRun {
+				out["successCount"] = 0
+				report.Reason += "; nothing was applied"
+			}
+			if len(imageChanges) > 0 {
+				out["imageChanges"] = imageChanges
+			}
+			return out, nil
+		}
+		preflight = report
+	}
+
+	if params.RolloutStrategy != nil {
+		if params.Atomic {
+			return nil, fmt.Errorf("atomic cannot be combined with rollout_strategy; use abort_rollout with rollback instead")
+		}
+		if params.Wait {
+			return nil, fmt.Errorf("wait cannot be combined with rollout_strategy; every batch already waits for its workloads")
+		}
+		result, err := s.startRollout(ctx, targetClusters, params.Manifest, *params.RolloutStrategy, opts)
+		if out, ok := result.(map[string]interface{}); ok {
+			if len(imageChanges) > 0 {
+				out["imageChanges"] = imageChanges
+			}
+			if preflight != nil {
+				out["preflight"] = preflight
+			}
+		}
+		return result, err
+	}
+
+	atomic := params.Atomic && !params.DryRun
+	timeout := defaultHealthTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	var (
+		rec  *journal.Recorder
+		mark int
+	)
+	if atomic {
+		ctx, rec, mark = s.beginTransaction(ctx)
+	}
+
 	// Deploy to clusters
-	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
-		return s.applyManifest(ctx, client, clusterName, params.Manifest, params.DryRun)
-	})
+	deployResults, successCount, err := s.deployToClusters(ctx, targetClusters, params.Manifest, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	out := map[string]interface{}{
+		"targetClusters": targetClusters,
+		"successCount":   successCount,
+		"totalClusters":  len(targetClusters),
+		"results":        deployResults,
+		"dryRun":         params.DryRun,
+	}
+	if len(imageChanges) > 0 {
+		out["imageChanges"] = imageChanges
+	}
+	if preflight != nil {
+		out["preflight"] = preflight
+	}
+	// Platform warnings are advisory; a manifest that cannot be read has
+	// already failed the deploy above.
+	if warnings, err := s.platformWarnings(ctx, targetClusters, params.Manifest, vars); err == nil && len(warnings) > 0 {
+		out["platformWarnings"] = warnings
+	}
+	committed := true
+	if atomic {
+		tx := s.finishTransaction(ctx, rec, mark, targetClusters, params.Manifest, vars, deployResults, timeout)
+		out["transaction"] = tx
+		committed = tx.Outcome == txCommitted
+	}
+	if params.Wait && committed && !params.DryRun && !approval.IsDryRun(ctx) {
+		s.reportHealth(ctx, out, targetClusters, failedClusters(deployResults), params.Manifest, vars, timeout)
+	}
+	return out, nil
+}
+
+// failedClusters returns the clusters with a failed or conflicting result.
+func failedClusters(results []DeployResult) map[string]bool {
+	failed := make(map[string]bool)
+	for _, r := range results {
+		if r.Status == "failed" || r.Status == "conflict" {
+			failed[r.Cluster] = true
+		}
+	}
+	return failed
+}
+
+// applyOptions control how deploy_app applies a manifest to a cluster.
+type applyOptions struct {
+	DryRun           bool
+	CreateNamespaces bool
+	// ApplySet, if set, labels the applied objects as its members; with
+	// Prune, the members the manifest no longer has are deleted.
+	ApplySet *kubemanifest.ApplySet
+	Prune    bool
+	// Variables, if set, are substituted in the manifest for each cluster.
+	Variables *manifestVariables
+	// SSA, if set, server-side applies every object instead of creating
+	// missing ones and force-applying the rest.
+	SSA *serverSideApply
+}
+
+// syncOptions returns the options of the manifest syncer for opts.
+func (opts applyOptions) syncOptions() gitops.SyncOptions {
+	sync := gitops.SyncOptions{CreateNamespaces: opts.CreateNamespaces}
+	if opts.SSA != nil {
+		sync.ServerSideApply = true
+		sync.FieldManager = opts.SSA.FieldManager
+		sync.Force = opts.SSA.Force
+	}
+	return sync
+}
+
+// deployToClusters applies manifest to clusters in parallel. It returns the
+// per-resource results, with one failed result for each cluster that could
+// not be reached, and the number of clusters the apply ran on.
+func (s *Server) deployToClusters(ctx context.Context, clusters []string, manifest string, opts applyOptions) ([]DeployResult, int, error) {
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.applyManifest(ctx, client, clusterName, manifest, opts)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Summarize results
 	var deployResults []DeployResult
 	successCount := 0
@@ -160,54 +423,58 @@ func (s *Server) handleDeployApp(ctx context.Context, args json.RawMessage) (int
 			successCount++
 		}
 	}
-
-	return map[string]interface{}{
-		"targetClusters": targetClusters,
-		"successCount":   successCount,
-		"totalClusters":  len(targetClusters),
-		"results":        deployResults,
-		"dryRun":         params.DryRun,
-	}, nil
+	return deployResults, successCount, nil
 }
 
-// applyManifest applies a manifest to a cluster
-func (s *Server) applyManifest(ctx context.Context, client kubernetes.Interface, clusterName, manifest string, dryRun bool) ([]DeployResult, error) {
-	_ = client
+// decodeManifests decodes the objects of a manifest to deploy, with Lists
+// replaced by their items. Unlike Git repositories, deploy manifests must
+// hold only Kubernetes objects.
+func decodeManifests(manifest string) ([]gitops.Manifest, error) {
+	objects, err := kubemanifest.DecodeString(manifest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	manifests := make([]gitops.Manifest, 0, len(objects))
+	for _, obj := range objects {
+		manifests = append(manifests, gitops.NewManifest(obj))
+	}
+	return manifests, nil
+}
 
-	var results []DeployResult
-	if dryRun {
-		decoder := yaml.NewYAMLOrJSONDecoder(strings.NewReader(manifest), 4096)
-		for {
-			var rawObj map[string]interface{}
-			if err := decoder.Decode(&rawObj); err != nil {
-				if err == io.EOF {
-					break
+// validateManifestNamespaces returns a failed result if a resource of
+// manifests is in a system namespace (#377).
+func validateManifestNamespaces(clusterName string, manifests []gitops.Manifest) *DeployResult {
+	for _, m := range manifests {
+		if namespace := m.GetNamespace(); namespace != "" {
+			if err := server.ValidateNamespace(namespace); err != nil {
+				return &DeployResult{
+					Cluster: clusterName, Status: "failed",
+					Message: fmt.Sprintf("invalid namespace in manifest: %v", err),
 				}
-				return nil, fmt.Errorf("failed to decode manifest: %w", err)
-			}
-			if rawObj == nil {
-				continue
-			}
-
-			kind, _ := rawObj["kind"].(string)
-			metadata, _ := rawObj["metadata"].(map[string]interface{})
-			name, _ := metadata["name"].(string)
-			namespace, _ := metadata["namespace"].(string)
-			if namespace == "" {
-				namespace = "default"
 			}
+		}
+	}
+	return nil
+}
 
-			// Validate namespace from manifest to prevent access to system namespaces (#377).
-			if namespace != "" {
-				if err := server.ValidateNamespace(namespace); err != nil {
-					return []DeployResult{{
-						Cluster: clusterName, Status: "failed",
-						Message: fmt.Sprintf("invalid namespace in manifest: %v", err),
-					}}, nil
-				}
-			}
+// applyManifest applies a manifest to a cluster
+func (s *Server) applyManifest(ctx context.Context, client kubernetes.Interface, clusterName, manifest string, opts applyOptions) ([]DeployResult, error) {
+	manifests, err := decodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if manifests, err = opts.Variables.render(ctx, client, clusterName, manifests); err != nil {
+		return nil, err
+	}
 
-			resourceName := fmt.Sprintf("%s/%s", kind, name)
+	var results []DeployResult
+	if opts.DryRun {
+		if result := validateManifestNamespaces(clusterName, manifests); result != nil {
+			return []DeployResult{*result}, nil
+		}
+		for _, m := range manifests {
+			namespace := m.GetNamespace()
+			resourceName := fmt.Sprintf("%s/%s", m.Kind, m.Metadata.Name)
 			results = append(results, DeployResult{
 				Cluster:  clusterName,
 				Resource: resourceName,
@@ -215,15 +482,20 @@ func (s *Server) applyManifest(ctx context.Context, client kubernetes.Interface,
 				Message:  fmt.Sprintf("Would apply %s to namespace %s", resourceName, namespace),
 			})
 		}
+		if opts.ApplySet != nil && opts.Prune {
+			target, err := s.newApplySetTarget(clusterName, opts.ApplySet, manifests)
+			if err != nil {
+				return nil, err
+			}
+			pruned, err := target.prune(ctx, true)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, pruned...)
+		}
 		return results, nil
 	}
 
-	reader := s.getManifestReader()
-	manifests, err := reader.ReadFromReader(strings.NewReader(manifest))
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode manifest: %w", err)
-	}
-
 	config, err := s.manager.GetConfig(clusterName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
@@ -234,7 +506,17 @@ func (s *Server) applyManifest(ctx context.Context, client kubernetes.Interface,
 		return nil, fmt.Errorf("failed to create manifest syncer: %w", err)
 	}
 
-	summary, err := syncer.Sync(ctx, manifests, clusterName, gitops.SyncOptions{})
+	var target *applySetTarget
+	if opts.ApplySet != nil {
+		if target, err = s.newApplySetTarget(clusterName, opts.ApplySet, manifests); err != nil {
+			return nil, err
+		}
+		if err := target.prepare(ctx, manifests); err != nil {
+			return nil, err
+		}
+	}
+
+	summary, err := syncer.Sync(ctx, manifests, clusterName, opts.syncOptions())
 	if err != nil {
 		return nil, fmt.Errorf("failed to apply manifest: %w", err)
 	}
@@ -245,9 +527,26 @@ func (s *Server) applyManifest(ctx context.Context, client kubernetes.Interface,
 			Resource: fmt.Sprintf("%s/%s", result.Kind, result.Name),
 			Status:   string(result.Action),
 			Message:  result.Message,
+			Warnings: result.Warnings,
 		})
 	}
 
+	// Members are only pruned, and the apply set narrowed to the
+	// manifest, once the whole manifest applied; otherwise the next
+	// deploy retries.
+	if target != nil && summary.Failed == 0 && summary.Conflicts == 0 {
+		if opts.Prune {
+			pruned, err := target.prune(ctx, approval.IsDryRun(ctx))
+			results = append(results, pruned...)
+			if err != nil {
+				return append(results, DeployResult{Cluster: clusterName, Status: "failed", Message: err.Error()}), nil
+			}
+		}
+		if err := target.set.Commit(ctx, target.client, target.members); err != nil {
+			return append(results, DeployResult{Cluster: clusterName, Status: "failed", Message: err.Error()}), nil
+		}
+	}
+
 	return results, nil
 }
 
@@ -420,8 +719,12 @@ func (s *Server) handleScaleApp(ctx context.Context, args json.RawMessage) (inte
 	var params struct {
 		App       string   `json:"app"`
 		Namespace string   `json:"namespace"`
+		Kind      string   `json:"kind"`
 		Replicas  int32    `json:"replicas"`
 		Clusters  []string `json:"clusters"`
+		// Override changes workloads that another controller manages; see
+		// tools_conflicts.go.
+		Override bool `json:"override"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
@@ -458,7 +761,7 @@ func (s *Server) handleScaleApp(ctx context.Context, args json.RawMessage) (inte
 
 	// Scale on each cluster
 	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
-		return s.scaleAppInCluster(ctx, client, clusterName, params.App, params.Namespace, params.Replicas)
+		return s.scaleAppInCluster(ctx, client, clusterName, params.App, params.Namespace, params.Kind, params.Replicas, params.Override)
 	})
 	if err != nil {
 		return nil, err
@@ -471,40 +774,121 @@ func (s *Server) handleScaleApp(ctx context.Context, args json.RawMessage) (inte
 	}, nil
 }
 
-// scaleAppInCluster scales an app in a single cluster
-func (s *Server) scaleAppInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace string, replicas int32) (interface{}, error) {
-	ns := namespace
-	if ns == "" {
-		ns = "default"
+// scaleAppInCluster scales an app's Deployment or StatefulSet in a single
+// cluster. See resolveAppWorkload for how the workload is found. A workload
+// that another controller manages is only scaled with override.
+func (s *Server) scaleAppInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace, kind string, replicas int32, override bool) (interface{}, error) {
+	w, err := resolveAppWorkload(ctx, client, clusterName, appName, namespace, kind, "Deployment", "StatefulSet")
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := checkConflicts(ctx, client, w, true, override)
+	if err != nil {
+		return nil, err
 	}
 
-	// Find deployment
-	deployments, err := client.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
+	var oldReplicas int32
+	switch {
+	case w.deployment != nil:
+		d := w.deployment
+		oldReplicas = replicasOrDefault(d.Spec.Replicas)
+		d.Spec.Replicas = &replicas
+		_, err = client.AppsV1().Deployments(d.Namespace).Update(ctx, d, metav1.UpdateOptions{})
+	case w.statefulSet != nil:
+		ss := w.statefulSet
+		oldReplicas = replicasOrDefault(ss.Spec.Replicas)
+		ss.Spec.Replicas = &replicas
+		_, err = client.AppsV1().StatefulSets(ss.Namespace).Update(ctx, ss, metav1.UpdateOptions{})
+	}
 	if err != nil {
 		return nil, err
 	}
+	result := map[string]interface{}{
+		"cluster":               clusterName,
+		"kind":                  w.Kind,
+		"namespace":             w.meta().GetNamespace(),
+		strings.ToLower(w.Kind): w.meta().GetName(),
+		"oldReplicas":           oldReplicas,
+		"newReplicas":           replicas,
+	}
+	if len(conflicts) > 0 {
+		result["conflicts"] = conflicts
+	}
+	return result, nil
+}
 
-	for _, d := range deployments.Items {
-		if matchesApp(d.Name, d.Labels, appName) {
-			oldReplicas := int32(1)
-			if d.Spec.Replicas != nil {
-				oldReplicas = *d.Spec.Replicas
+// resolveAppWorkload finds the one workload of appName in a cluster, in
+// namespace or, when it is empty, in every namespace the tools may change.
+// Only the given kinds are considered, narrowed further by kind if set.
+// When several workloads match, one whose name is exactly appName wins;
+// otherwise the caller must disambiguate with namespace or kind.
+func resolveAppWorkload(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace, kind string, kinds ...string) (appWorkload, error) {
+	if kind != "" {
+		canonical := ""
+		for _, k := range kinds {
+			if strings.EqualFold(k, kind) {
+				canonical = k
 			}
-			d.Spec.Replicas = &replicas
-			_, err := client.AppsV1().Deployments(ns).Update(ctx, &d, metav1.UpdateOptions{})
-			if err != nil {
-				return nil, err
+		}
+		if canonical == "" {
+			return appWorkload{}, fmt.Errorf("kind must be one of %s, got %q", strings.Join(kinds, ", "), kind)
+		}
+		kinds = []string{canonical}
+	}
+	allowed := make(map[string]bool, len(kinds))
+	for _, k := range kinds {
+		allowed[k] = true
+	}
+
+	found, err := listAppWorkloads(ctx, client, namespace, appName)
+	if err != nil {
+		// A kind that could not be listed might hold the workload the
+		// caller meant, so do not guess from a partial view.
+		return appWorkload{}, err
+	}
+	var candidates, excluded []appWorkload
+	for _, w := range found {
+		switch {
+		case namespace == "" && server.ValidateNamespace(w.meta().GetNamespace()) != nil:
+			// System namespaces are only changed when named explicitly.
+		case allowed[w.Kind]:
+			candidates = append(candidates, w)
+		default:
+			excluded = append(excluded, w)
+		}
+	}
+
+	if len(candidates) > 1 {
+		var exact []appWorkload
+		for _, w := range candidates {
+			if w.meta().GetName() == appName {
+				exact = append(exact, w)
 			}
-			return map[string]interface{}{
-				"cluster":     clusterName,
-				"deployment":  d.Name,
-				"oldReplicas": oldReplicas,
-				"newReplicas": replicas,
-			}, nil
+		}
+		if len(exact) == 1 {
+			candidates = exact
 		}
 	}
 
-	return nil, fmt.Errorf("deployment %s not found in cluster %s", appName, clusterName)
+	where := "any namespace"
+	if namespace != "" {
+		where = "namespace " + namespace
+	}
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) > 1:
+		names := make([]string, 0, len(candidates))
+		for _, w := range candidates {
+			names = append(names, w.String())
+		}
+		return appWorkload{}, fmt.Errorf("app %s matches %d workloads in cluster %s: %s; set namespace or kind to choose one",
+			appName, len(candidates), clusterName, strings.Join(names, ", "))
+	case len(excluded) > 0:
+		return appWorkload{}, fmt.Errorf("app %s in cluster %s is %s, not a %s", appName, clusterName, excluded[0], strings.Join(kinds, " or "))
+	default:
+		return appWorkload{}, fmt.Errorf("%s %s not found in %s of cluster %s", strings.Join(kinds, "/"), appName, where, clusterName)
+	}
 }
 
 // handlePatchApp patches an app across clusters
@@ -512,9 +896,11 @@ func (s *Server) handlePatchApp(ctx context.Context, args json.RawMessage) (inte
 	var params struct {
 		App       string   `json:"app"`
 		Namespace string   `json:"namespace"`
+		Kind      string   `json:"kind"`
 		Patch     string   `json:"patch"`
 		PatchType string   `json:"patch_type"`
 		Clusters  []string `json:"clusters"`
+		Override  bool     `json:"override"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
@@ -550,7 +936,7 @@ func (s *Server) handlePatchApp(ctx context.Context, args json.RawMessage) (inte
 
 	// Patch on each cluster
 	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
-		return s.patchAppInCluster(ctx, client, clusterName, params.App, params.Namespace, []byte(params.Patch), patchType)
+		return s.patchAppInCluster(ctx, client, clusterName, params.App, params.Namespace, params.Kind, []byte(params.Patch), patchType, params.Override)
 	})
 	if err != nil {
 		return nil, err
@@ -562,32 +948,42 @@ func (s *Server) handlePatchApp(ctx context.Context, args json.RawMessage) (inte
 	}, nil
 }
 
-// patchAppInCluster patches an app in a single cluster
-func (s *Server) patchAppInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace string, patch []byte, patchType types.PatchType) (interface{}, error) {
-	ns := namespace
-	if ns == "" {
-		ns = "default"
+// patchAppInCluster patches an app's Deployment, StatefulSet, or DaemonSet
+// in a single cluster. See resolveAppWorkload for how the workload is found.
+// A workload that another controller manages is only patched with
+// override; a HorizontalPodAutoscaler only counts if the patch mentions
+// replicas.
+func (s *Server) patchAppInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, appName, namespace, kind string, patch []byte, patchType types.PatchType, override bool) (interface{}, error) {
+	w, err := resolveAppWorkload(ctx, client, clusterName, appName, namespace, kind, "Deployment", "StatefulSet", "DaemonSet")
+	if err != nil {
+		return nil, err
 	}
-
-	// Find deployment
-	deployments, err := client.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
+	conflicts, err := checkConflicts(ctx, client, w, strings.Contains(string(patch), "replicas"), override)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, d := range deployments.Items {
-		if matchesApp(d.Name, d.Labels, appName) {
-			_, err := client.AppsV1().Deployments(ns).Patch(ctx, d.Name, patchType, patch, metav1.PatchOptions{})
-			if err != nil {
-				return nil, err
-			}
-			return map[string]interface{}{
-				"cluster":    clusterName,
-				"deployment": d.Name,
-				"status":     "patched",
-			}, nil
-		}
+	ns, name := w.meta().GetNamespace(), w.meta().GetName()
+	switch w.Kind {
+	case "Deployment":
+		_, err = client.AppsV1().Deployments(ns).Patch(ctx, name, patchType, patch, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = client.AppsV1().StatefulSets(ns).Patch(ctx, name, patchType, patch, metav1.PatchOptions{})
+	case "DaemonSet":
+		_, err = client.AppsV1().DaemonSets(ns).Patch(ctx, name, patchType, patch, metav1.PatchOptions{})
 	}
-
-	return nil, fmt.Errorf("deployment %s not found in cluster %s", appName, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]interface{}{
+		"cluster":               clusterName,
+		"kind":                  w.Kind,
+		"namespace":             ns,
+		strings.ToLower(w.Kind): name,
+		"status":                "patched",
+	}
+	if len(conflicts) > 0 {
+		result["conflicts"] = conflicts
+	}
+	return result, nil
 }
@@ -0,0 +1,274 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultHealthTimeout bounds how long deploy_app waits for its workloads
+// to become ready before rolling back an atomic deploy or failing a
+// rollout batch.
+const defaultHealthTimeout = 5 * time.Minute
+
+// healthPollInterval is how often workload readiness is checked.
+var healthPollInterval = 2 * time.Second
+
+// Transaction outcomes reported by an atomic deploy_app.
+const (
+	txCommitted          = "committed"
+	txRolledBack         = "rolled-back"
+	txRollbackIncomplete = "rollback-incomplete"
+)
+
+// deployTransaction is the consolidated outcome of an atomic deploy_app.
+type deployTransaction struct {
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+	// FailedClusters are the clusters whose apply failed or whose workloads
+	// did not become ready.
+	FailedClusters []string `json:"failedClusters,omitempty"`
+	// Pending lists, per cluster, the workloads that were not ready when
+	// the timeout expired.
+	Pending map[string][]string `json:"pending,omitempty"`
+	// Rollback reports what was reverted, newest change first.
+	Rollback []journal.UndoResult `json:"rollback,omitempty"`
+}
+
+// beginTransaction returns the recorder that journals the objects this
+// call changes and how many objects it held before the call, so a rollback
+// reverts only this call's changes. Calls made outside handleToolCall get
+// a recorder of their own, which is used for the rollback but not stored.
+func (s *Server) beginTransaction(ctx context.Context) (context.Context, *journal.Recorder, int) {
+	rec := journal.RecorderFrom(ctx)
+	if rec == nil {
+		rec = s.getJournal().Begin("deploy_app")
+		ctx = journal.WithRecorder(ctx, rec)
+	}
+	return ctx, rec, len(rec.Objects())
+}
+
+// finishTransaction checks the outcome of an atomic apply. If any cluster
+// failed, or the manifest's workloads, rendered with vars, are not ready on
+// every cluster within timeout, everything recorded since mark is reverted
+// on all clusters.
+func (s *Server) finishTransaction(ctx context.Context, rec *journal.Recorder, mark int, clusters []string, manifest string, vars *manifestVariables, results []DeployResult, timeout time.Duration) *deployTransaction {
+	tx := &deployTransaction{Outcome: txCommitted}
+
+	if failed := failedClusters(results); len(failed) > 0 {
+		tx.FailedClusters = sortedKeys(failed)
+		tx.Reason = fmt.Sprintf("apply failed on %s", strings.Join(tx.FailedClusters, ", "))
+	} else if pending, err := s.waitForWorkloads(ctx, clusters, manifest, vars, timeout); err != nil {
+		tx.Reason = fmt.Sprintf("health check failed: %v", err)
+	} else if len(pending) > 0 {
+		for cluster := range pending {
+			tx.FailedClusters = append(tx.FailedClusters, cluster)
+		}
+		sort.Strings(tx.FailedClusters)
+		tx.Pending = pending
+		tx.Reason = fmt.Sprintf("workloads not ready within %s on %s", timeout, strings.Join(tx.FailedClusters, ", "))
+	}
+	if tx.Reason == "" {
+		return tx
+	}
+
+	changed := rec.Objects()[mark:]
+	// The rollback is not journaled: once it succeeds the call has changed
+	// nothing, and its own objects are dropped from the change.
+	tx.Rollback = journal.Revert(journal.WithRecorder(ctx, nil), changed, s.configForServer, false)
+	tx.Outcome = txRolledBack
+	for _, r := range tx.Rollback {
+		if r.Error != "" {
+			// Keep the journaled objects so undo_change can finish the job.
+			tx.Outcome = txRollbackIncomplete
+			return tx
+		}
+	}
+	rec.Truncate(mark)
+	return tx
+}
+
+// waitForWorkloads polls the Deployments, StatefulSets, and DaemonSets in
+// manifest, rendered with vars for each cluster, on every cluster until they
+// are ready or timeout expires. It returns the workloads still pending, by
+// cluster.
+func (s *Server) waitForWorkloads(ctx context.Context, clusters []string, manifest string, vars *manifestVariables, timeout time.Duration) (map[string][]string, error) {
+	workloads, err := s.manifestWorkloads(manifest)
+	if err != nil || len(workloads) == 0 {
+		return nil, err
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		rendered, err := vars.render(ctx, client, clusterName, workloads)
+		if err != nil {
+			return nil, err
+		}
+		return waitForReady(ctx, client, rendered, timeout)
+	})
+	if err != nil {
+		return nil, err
+	}
+	pending := make(map[string][]string)
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			pending[r.Cluster] = []string{r.Error}
+		default:
+			if names, _ := r.Result.([]string); len(names) > 0 {
+				pending[r.Cluster] = names
+			}
+		}
+	}
+	return pending, nil
+}
+
+// manifestWorkloads returns the Deployments, StatefulSets, and DaemonSets
+// in manifest.
+func (s *Server) manifestWorkloads(manifest string) ([]gitops.Manifest, error) {
+	manifests, err := decodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var workloads []gitops.Manifest
+	for _, m := range manifests {
+		switch m.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet":
+			workloads = append(workloads, m)
+		}
+	}
+	return workloads, nil
+}
+
+// waitForReady polls workloads until all are ready or timeout expires, and
+// returns the ones that are not ready.
+func waitForReady(ctx context.Context, client kubernetes.Interface, workloads []gitops.Manifest, timeout time.Duration) ([]string, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+	for {
+		pending, err := notReady(ctx, client, workloads)
+		if err != nil || len(pending) == 0 {
+			return pending, err
+		}
+		select {
+		case <-ctx.Done():
+			return pending, ctx.Err()
+		case <-deadline.C:
+			return pending, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// notReady returns the workloads whose latest spec is not yet fully rolled
+// out and ready, as Kind/name.
+func notReady(ctx context.Context, client kubernetes.Interface, workloads []gitops.Manifest) ([]string, error) {
+	var pending []string
+	for _, w := range workloads {
+		ready, err := workloadReady(ctx, client, w.Kind, w.GetNamespace(), w.Metadata.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !ready {
+			pending = append(pending, fmt.Sprintf("%s/%s", w.Kind, w.Metadata.Name))
+		}
+	}
+	return pending, nil
+}
+
+func workloadReady(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) (bool, error) {
+	state, _, err := workloadState(ctx, client, kind, namespace, name)
+	return state == healthReady, err
+}
+
+// workloadState returns whether the latest spec of a workload is rolled out
+// and ready, is still progressing, or has failed for good, with a summary
+// of its status. Kinds other than Deployments, StatefulSets, DaemonSets,
+// and Jobs are always ready.
+func workloadState(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) (string, string, error) {
+	var (
+		state   = healthProgressing
+		message string
+		err     error
+	)
+	switch kind {
+	case "Deployment":
+		d, getErr := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			message = fmt.Sprintf("%d/%d replicas ready, %d updated", d.Status.ReadyReplicas, replicasOrDefault(d.Spec.Replicas), d.Status.UpdatedReplicas)
+			if d.Status.ObservedGeneration >= d.Generation &&
+				d.Status.UpdatedReplicas == replicasOrDefault(d.Spec.Replicas) &&
+				getDeploymentStatus(d) == "healthy" {
+				state = healthReady
+			}
+			for _, c := range d.Status.Conditions {
+				if state != healthReady && c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+					state, message = healthFailed, message+": "+c.Message
+				}
+			}
+		}
+	case "StatefulSet":
+		ss, getErr := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			message = fmt.Sprintf("%d/%d replicas ready, %d updated", ss.Status.ReadyReplicas, replicasOrDefault(ss.Spec.Replicas), ss.Status.UpdatedReplicas)
+			if ss.Status.ObservedGeneration >= ss.Generation &&
+				ss.Status.UpdatedReplicas == replicasOrDefault(ss.Spec.Replicas) &&
+				getStatefulSetStatus(ss) == "healthy" {
+				state = healthReady
+			}
+		}
+	case "DaemonSet":
+		ds, getErr := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			message = fmt.Sprintf("%d/%d pods ready, %d updated", ds.Status.NumberReady, ds.Status.DesiredNumberScheduled, ds.Status.UpdatedNumberScheduled)
+			if ds.Status.ObservedGeneration >= ds.Generation &&
+				ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+				getDaemonSetStatus(ds) == "healthy" {
+				state = healthReady
+			}
+		}
+	case "Job":
+		job, getErr := client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err = getErr; err == nil {
+			message = fmt.Sprintf("%d active, %d succeeded, %d failed pods", job.Status.Active, job.Status.Succeeded, job.Status.Failed)
+			for _, c := range job.Status.Conditions {
+				if c.Status != corev1.ConditionTrue {
+					continue
+				}
+				switch c.Type {
+				case batchv1.JobComplete:
+					state, message = healthReady, "completed"
+				case batchv1.JobFailed:
+					state, message = healthFailed, fmt.Sprintf("%s: %s", c.Reason, c.Message)
+				}
+			}
+		}
+	default:
+		return healthReady, "", nil
+	}
+	if apierrors.IsNotFound(err) {
+		return healthProgressing, "not found", nil
+	}
+	return state, message, err
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,393 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	pathpkg "path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// objectAPIServer is a minimal API server that stores objects of any kind
+// by path and applies JSON merge patches. Created Deployments report ready
+// status only when readyDeployments is set, and updated Deployments report
+// no ready replicas when unreadyUpdates is set, as if their new pods crash.
+// Writes return warning, if set, as an API server warning.
+type objectAPIServer struct {
+	mu               sync.Mutex
+	objects          map[string]map[string]interface{}
+	rejectCreates    bool
+	readyDeployments bool
+	unreadyUpdates   bool
+	warning          string
+	// applyConflict, if set, is a field other managers own in every
+	// existing object, so unforced server-side applies to them conflict.
+	applyConflict string
+	// fieldManagers are the field managers of the server-side applies.
+	fieldManagers []string
+}
+
+// listKinds maps the resources objectAPIServer can list to their list kind.
+var listKinds = map[string]string{
+	"configmaps":             "ConfigMapList",
+	"secrets":                "SecretList",
+	"serviceaccounts":        "ServiceAccountList",
+	"persistentvolumeclaims": "PersistentVolumeClaimList",
+	"deployments":            "DeploymentList",
+	"statefulsets":           "StatefulSetList",
+	"daemonsets":             "DaemonSetList",
+	"services":               "ServiceList",
+	"pods":                   "PodList",
+	"httproutes":             "HTTPRouteList",
+	"poddisruptionbudgets":   "PodDisruptionBudgetList",
+	"clusterqueues":          "ClusterQueueList",
+	"localqueues":            "LocalQueueList",
+	"workloads":              "WorkloadList",
+	"nodes":                  "NodeList",
+	"volumesnapshots":        "VolumeSnapshotList",
+	"volumesnapshotclasses":  "VolumeSnapshotClassList",
+}
+
+func newObjectAPIServer(t *testing.T, rejectCreates bool) (*objectAPIServer, string) {
+	f := &objectAPIServer{objects: make(map[string]map[string]interface{}), rejectCreates: rejectCreates}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+func (f *objectAPIServer) has(path string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.objects[path]
+	return ok
+}
+
+// get returns a copy of the object stored at path, or nil.
+func (f *objectAPIServer) get(path string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if obj, ok := f.objects[path]; ok {
+		return runtime.DeepCopyJSON(obj)
+	}
+	return nil
+}
+
+// put stores obj, a typed object or a map, at path.
+func (f *objectAPIServer) put(t *testing.T, path string, obj interface{}) {
+	t.Helper()
+	data, err := json.Marshal(obj)
+	require.NoError(t, err)
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &m))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[path] = m
+}
+
+// decode reads a request body sent by a typed (protobuf) or dynamic (JSON)
+// client.
+func (f *objectAPIServer) decode(r *http.Request) map[string]interface{} {
+	body, _ := io.ReadAll(r.Body)
+	if obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(body, nil, nil); err == nil {
+		obj.GetObjectKind().SetGroupVersionKind(*gvk)
+		body, _ = json.Marshal(obj)
+	}
+	var m map[string]interface{}
+	_ = json.Unmarshal(body, &m)
+	return m
+}
+
+// list returns the objects in the collection at path, which may span all
+// namespaces, that match the request's equality label selector.
+func (f *objectAPIServer) list(r *http.Request) (map[string]interface{}, bool) {
+	path := r.URL.Path
+	resource := path[strings.LastIndex(path, "/")+1:]
+	kind, ok := listKinds[resource]
+	if !ok {
+		return nil, false
+	}
+	want := map[string]string{}
+	for _, req := range strings.Split(r.URL.Query().Get("labelSelector"), ",") {
+		if k, v, ok := strings.Cut(req, "="); ok {
+			want[k] = v
+		}
+	}
+	prefix := strings.TrimSuffix(path, "/"+resource)
+	items := []interface{}{}
+	for key, obj := range f.objects {
+		dir, _ := pathpkg.Split(key)
+		dir = strings.TrimSuffix(dir, "/")
+		if !strings.HasSuffix(dir, "/"+resource) {
+			continue
+		}
+		owner := strings.TrimSuffix(dir, "/"+resource)
+		if owner != prefix && !(strings.HasPrefix(owner, prefix+"/namespaces/") && !strings.Contains(prefix, "/namespaces/")) {
+			continue
+		}
+		objLabels, _, _ := unstructured.NestedStringMap(obj, "metadata", "labels")
+		if labels.SelectorFromSet(want).Matches(labels.Set(objLabels)) {
+			items = append(items, obj)
+		}
+	}
+	apiVersion := "v1"
+	if rest, ok := strings.CutPrefix(path, "/apis/"); ok {
+		parts := strings.SplitN(rest, "/", 3)
+		apiVersion = parts[0] + "/" + parts[1]
+	}
+	return map[string]interface{}{"kind": kind, "apiVersion": apiVersion, "metadata": map[string]interface{}{}, "items": items}, true
+}
+
+func (f *objectAPIServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if f.warning != "" && r.Method != http.MethodGet {
+		w.Header().Set("Warning", `299 - "`+strings.ReplaceAll(f.warning, `"`, `\"`)+`"`)
+	}
+	status := func(code int, reason string) {
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": reason, "code": code, "message": reason})
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if obj, ok := f.objects[r.URL.Path]; ok {
+			_ = json.NewEncoder(w).Encode(obj)
+			return
+		}
+		if list, ok := f.list(r); ok {
+			_ = json.NewEncoder(w).Encode(list)
+			return
+		}
+		status(http.StatusNotFound, "NotFound")
+	case http.MethodPost:
+		if f.rejectCreates {
+			status(http.StatusForbidden, "Forbidden")
+			return
+		}
+		obj := f.decode(r)
+		meta := obj["metadata"].(map[string]interface{})
+		meta["resourceVersion"] = "1"
+		if f.readyDeployments && obj["kind"] == "Deployment" {
+			replicas, found, _ := unstructured.NestedFieldNoCopy(obj, "spec", "replicas")
+			if !found {
+				replicas = 1
+			}
+			obj["status"] = map[string]interface{}{"replicas": replicas, "updatedReplicas": replicas, "readyReplicas": replicas, "availableReplicas": replicas}
+		}
+		if r.URL.Query().Get("dryRun") == "" {
+			f.objects[r.URL.Path+"/"+meta["name"].(string)] = obj
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(obj)
+	case http.MethodPut:
+		if _, ok := f.objects[r.URL.Path]; !ok {
+			status(http.StatusNotFound, "NotFound")
+			return
+		}
+		obj := f.decode(r)
+		obj["metadata"].(map[string]interface{})["resourceVersion"] = "2"
+		if f.unreadyUpdates && obj["kind"] == "Deployment" {
+			_ = unstructured.SetNestedField(obj, int64(0), "status", "readyReplicas")
+			_ = unstructured.SetNestedField(obj, int64(0), "status", "availableReplicas")
+		}
+		if r.URL.Query().Get("dryRun") == "" {
+			f.objects[r.URL.Path] = obj
+		}
+		_ = json.NewEncoder(w).Encode(obj)
+	case http.MethodPatch:
+		current, ok := f.objects[r.URL.Path]
+		apply := r.Header.Get("Content-Type") == string(types.ApplyPatchType)
+		if apply {
+			f.fieldManagers = append(f.fieldManagers, r.URL.Query().Get("fieldManager"))
+		}
+		switch {
+		case !ok && apply:
+			// Server-side apply creates missing objects.
+			obj := f.decode(r)
+			obj["metadata"].(map[string]interface{})["resourceVersion"] = "1"
+			if r.URL.Query().Get("dryRun") == "" {
+				f.objects[r.URL.Path] = obj
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(obj)
+			return
+		case !ok:
+			status(http.StatusNotFound, "NotFound")
+			return
+		case apply && f.applyConflict != "" && r.URL.Query().Get("force") != "true":
+			w.WriteHeader(http.StatusConflict)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "Conflict", "code": http.StatusConflict,
+				"message": "Apply failed with 1 conflict",
+				"details": map[string]interface{}{"causes": []interface{}{map[string]interface{}{
+					"reason": "FieldManagerConflict", "message": `conflict with "kubectl" using v1`, "field": f.applyConflict,
+				}}},
+			})
+			return
+		}
+		// Server-side apply patches are treated as merge patches.
+		if ct := r.Header.Get("Content-Type"); ct != string(types.MergePatchType) && ct != string(types.ApplyPatchType) {
+			status(http.StatusUnsupportedMediaType, "UnsupportedMediaType")
+			return
+		}
+		obj := mergePatch(runtime.DeepCopyJSON(current), f.decode(r))
+		obj["metadata"].(map[string]interface{})["resourceVersion"] = "2"
+		if r.URL.Query().Get("dryRun") == "" {
+			f.objects[r.URL.Path] = obj
+		}
+		_ = json.NewEncoder(w).Encode(obj)
+	case http.MethodDelete:
+		if _, ok := f.objects[r.URL.Path]; !ok {
+			status(http.StatusNotFound, "NotFound")
+			return
+		}
+		if r.URL.Query().Get("dryRun") == "" {
+			delete(f.objects, r.URL.Path)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Success"})
+	default:
+		status(http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// mergePatch applies a JSON merge patch (RFC 7386) to obj.
+func mergePatch(obj, patch map[string]interface{}) map[string]interface{} {
+	for k, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(obj, k)
+		case map[string]interface{}:
+			current, _ := obj[k].(map[string]interface{})
+			if current == nil {
+				current = map[string]interface{}{}
+			}
+			obj[k] = mergePatch(current, v)
+		default:
+			obj[k] = v
+		}
+	}
+	return obj
+}
+
+func newAtomicTestServer(t *testing.T, servers map[string]string) *Server {
+	mgr, err := multicluster.NewClientManager(writeKubeconfig(t, servers))
+	require.NoError(t, err)
+	mgr.SetConfigTransform(func(c *rest.Config) *rest.Config {
+		// The fake API server needs no client-side rate limiting.
+		c.QPS = -1
+		return approval.Wrap(journal.Wrap(c))
+	})
+	server := newServerWithManager(mgr)
+	server.stateStore = store.NewMemory()
+	return server
+}
+
+func callDeployApp(t *testing.T, server *Server, args map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	t.Helper()
+	resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+		"name":      "deploy_app",
+		"arguments": args,
+	})})
+	require.Nil(t, resp.Error)
+	result := resp.Result.(map[string]interface{})
+	require.Nil(t, result["isError"], "deploy_app failed: %v", result["content"])
+	var out map[string]interface{}
+	text := result["content"].([]map[string]interface{})[0]["text"].(string)
+	require.NoError(t, json.Unmarshal([]byte(text), &out))
+	meta, _ := result["_meta"].(map[string]interface{})
+	return out, meta
+}
+
+const atomicConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+`
+
+const configMapPath = "/api/v1/namespaces/default/configmaps/web-config"
+
+func TestDeployAppAtomicRollsBackWhenAClusterFails(t *testing.T) {
+	good, goodURL := newObjectAPIServer(t, false)
+	_, badURL := newObjectAPIServer(t, true)
+	server := newAtomicTestServer(t, map[string]string{"good": goodURL, "bad": badURL})
+
+	out, meta := callDeployApp(t, server, map[string]interface{}{
+		"manifest": atomicConfigMap,
+		"clusters": []string{"good", "bad"},
+		"atomic":   true,
+	})
+
+	tx := out["transaction"].(map[string]interface{})
+	assert.Equal(t, txRolledBack, tx["outcome"])
+	assert.Equal(t, []interface{}{"bad"}, tx["failedClusters"])
+	assert.Contains(t, tx["reason"], "apply failed on bad")
+	rollback := tx["rollback"].([]interface{})
+	require.Len(t, rollback, 1)
+	assert.Equal(t, "deleted", rollback[0].(map[string]interface{})["action"])
+	assert.False(t, good.has(configMapPath), "the object created on the healthy cluster should be rolled back")
+	assert.Nil(t, meta["changeId"], "a rolled-back deploy changes nothing and is not journaled")
+}
+
+func TestDeployAppAtomicRollsBackUnreadyWorkloads(t *testing.T) {
+	saved := healthPollInterval
+	healthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = saved })
+
+	cluster, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"c1": url})
+	manifest := atomicConfigMap + `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+`
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":        manifest,
+		"clusters":        []string{"c1"},
+		"atomic":          true,
+		"timeout_seconds": 1,
+	})
+
+	tx := out["transaction"].(map[string]interface{})
+	assert.Equal(t, txRolledBack, tx["outcome"])
+	assert.Equal(t, []interface{}{"Deployment/web"}, tx["pending"].(map[string]interface{})["c1"])
+	assert.False(t, cluster.has(configMapPath))
+	assert.False(t, cluster.has("/apis/apps/v1/namespaces/default/deployments/web"))
+}
+
+func TestDeployAppAtomicCommitsOnSuccess(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"c1": url})
+
+	out, meta := callDeployApp(t, server, map[string]interface{}{
+		"manifest": atomicConfigMap,
+		"clusters": []string{"c1"},
+		"atomic":   true,
+	})
+
+	tx := out["transaction"].(map[string]interface{})
+	assert.Equal(t, txCommitted, tx["outcome"])
+	assert.True(t, cluster.has(configMapPath))
+	assert.NotEmpty(t, meta["changeId"], "a committed deploy is journaled")
+}
@@ -0,0 +1,268 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// imageTagPattern is the syntax of an image tag.
+var imageTagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+// imageDigestPattern is the syntax of an image digest.
+var imageDigestPattern = regexp.MustCompile(`^[a-z0-9]+:[a-f0-9]{32,}$`)
+
+// containerFields are the container lists of a pod spec.
+var containerFields = []string{"initContainers", "containers", "ephemeralContainers"}
+
+// overrideImage returns the image repo with the tag or digest of an
+// image_overrides value: "1234", "sha256:...", or "@sha256:...".
+func overrideImage(repo, value string) (string, error) {
+	if digest := strings.TrimPrefix(value, "@"); imageDigestPattern.MatchString(digest) {
+		return repo + "@" + digest, nil
+	}
+	if imageTagPattern.MatchString(value) {
+		return repo + ":" + value, nil
+	}
+	return "", fmt.Errorf("image_overrides[%s]: %q is not a tag or digest", repo, value)
+}
+
+// rewriteImages sets the images of the containers in the manifest's
+// workloads whose repository is in overrides to the tag or digest given
+// there, and with pin, adds the digest the registry reports to every image
+// that has none. It returns the rewritten manifest and the changes; a
+// manifest without changes is returned as is.
+func (s *Server) rewriteImages(ctx context.Context, manifest string, overrides map[string]string, pin bool) (string, []ImageChange, error) {
+	objects, err := kubemanifest.DecodeString(manifest)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	used := make(map[string]bool)
+	digests := make(map[string]string)
+	var changes []ImageChange
+	for _, obj := range objects {
+		path, ok := podTemplatePaths[obj.GetKind()]
+		if !ok {
+			continue
+		}
+		specPath := append([]string{"spec"}, path...)
+		for _, field := range containerFields {
+			fieldPath := append(specPath[:len(specPath):len(specPath)], field)
+			containers, found, _ := unstructured.NestedSlice(obj.Object, fieldPath...)
+			if !found {
+				continue
+			}
+			changed := false
+			for _, c := range containers {
+				container, _ := c.(map[string]interface{})
+				image, _ := container["image"].(string)
+				if image == "" {
+					continue
+				}
+				newImage := image
+				repo, _, _ := splitImage(image)
+				if value, ok := overrides[repo]; ok {
+					used[repo] = true
+					if newImage, err = overrideImage(repo, value); err != nil {
+						return "", nil, err
+					}
+				}
+				if _, _, digest := splitImage(newImage); pin && digest == "" {
+					if _, ok := digests[newImage]; !ok {
+						if digests[newImage], err = s.getDigestResolver()(ctx, newImage); err != nil {
+							return "", nil, fmt.Errorf("failed to resolve %s to a digest: %w", newImage, err)
+						}
+					}
+					newImage += "@" + digests[newImage]
+				}
+				if newImage != image {
+					name, _ := container["name"].(string)
+					changes = append(changes, ImageChange{Workload: obj.GetKind() + "/" + obj.GetName(), Container: name, From: image, To: newImage})
+					container["image"] = newImage
+					changed = true
+				}
+			}
+			if changed {
+				if err := unstructured.SetNestedSlice(obj.Object, containers, fieldPath...); err != nil {
+					return "", nil, err
+				}
+			}
+		}
+	}
+
+	var unused []string
+	for repo := range overrides {
+		if !used[repo] {
+			unused = append(unused, repo)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return "", nil, fmt.Errorf("image_overrides: no container in the manifest uses %s", strings.Join(unused, ", "))
+	}
+	if len(changes) == 0 {
+		return manifest, nil, nil
+	}
+
+	var sb strings.Builder
+	for i, obj := range objects {
+		data, err := yaml.Marshal(obj.Object)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to encode manifest: %w", err)
+		}
+		if i > 0 {
+			sb.WriteString("---\n")
+		}
+		sb.Write(data)
+	}
+	return sb.String(), changes, nil
+}
+
+// getDigestResolver returns the function that resolves an image to the
+// digest of its manifest in the registry.
+func (s *Server) getDigestResolver() func(context.Context, string) (string, error) {
+	if s.resolveDigest != nil {
+		return s.resolveDigest
+	}
+	return (&registryClient{http: &http.Client{Timeout: 30 * time.Second}}).digest
+}
+
+// manifestMediaTypes are the manifest types a digest is resolved for, image
+// indexes first, so a multi-platform image pins to its index.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// registryClient resolves image tags to digests with the registry HTTP API,
+// anonymously or with the bearer token a registry hands out for public
+// repositories.
+type registryClient struct {
+	http *http.Client
+}
+
+// registryRepository returns the registry host and repository path of
+// image repo, with Docker Hub's defaults.
+func registryRepository(repo string) (string, string) {
+	host, path, found := strings.Cut(repo, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		host, path = "registry-1.docker.io", repo
+		if !strings.Contains(path, "/") {
+			path = "library/" + path
+		}
+	}
+	if host == "docker.io" || host == "index.docker.io" {
+		host = "registry-1.docker.io"
+	}
+	return host, path
+}
+
+func (c *registryClient) digest(ctx context.Context, image string) (string, error) {
+	repo, tag, _ := splitImage(image)
+	if tag == "" {
+		tag = "latest"
+	}
+	host, path := registryRepository(repo)
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, path, tag)
+
+	resp, err := c.head(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := c.token(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if resp, err = c.head(ctx, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry %s returned %s", host, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !imageDigestPattern.MatchString(digest) {
+		return "", fmt.Errorf("registry %s returned no digest", host)
+	}
+	return digest, nil
+}
+
+func (c *registryClient) head(ctx context.Context, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+	return resp, nil
+}
+
+// token gets an anonymous bearer token for the challenge of a registry's
+// WWW-Authenticate header.
+func (c *registryClient) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("registry requires %s authentication, which is not supported", scheme)
+	}
+	fields := make(map[string]string)
+	for _, param := range strings.Split(params, ",") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok {
+			fields[k] = strings.Trim(v, `"`)
+		}
+	}
+	realm, err := url.Parse(fields["realm"])
+	if err != nil || realm.Scheme != "https" {
+		return "", fmt.Errorf("registry token realm %q is not an https URL", fields["realm"])
+	}
+	query := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if fields[k] != "" {
+			query.Set(k, fields[k])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request returned %s; private images cannot be pinned", resp.Status)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
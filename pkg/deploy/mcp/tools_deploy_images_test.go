@@ -0,0 +1,143 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const imagesManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: ghcr.io/acme/web:1.0
+      containers:
+      - name: web
+        image: ghcr.io/acme/web:1.0
+      - name: proxy
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  image: ghcr.io/acme/web:1.0
+`
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestDeployAppImageOverrides(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+	var resolved []string
+	server.resolveDigest = func(_ context.Context, image string) (string, error) {
+		resolved = append(resolved, image)
+		return testDigest, nil
+	}
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":        imagesManifest,
+		"clusters":        []string{"alpha"},
+		"image_overrides": map[string]string{"ghcr.io/acme/web": "1234"},
+	})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"workload": "Deployment/web", "container": "migrate", "from": "ghcr.io/acme/web:1.0", "to": "ghcr.io/acme/web:1234"},
+		map[string]interface{}{"workload": "Deployment/web", "container": "web", "from": "ghcr.io/acme/web:1.0", "to": "ghcr.io/acme/web:1234"},
+	}, out["imageChanges"])
+	deployment := cluster.get("/apis/apps/v1/namespaces/default/deployments/web")
+	require.NotNil(t, deployment)
+	containers := deployment["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	assert.Equal(t, "ghcr.io/acme/web:1234", containers[0].(map[string]interface{})["image"])
+	assert.Equal(t, "nginx:1.25", containers[1].(map[string]interface{})["image"])
+	assert.Equal(t, "ghcr.io/acme/web:1.0", cluster.get("/api/v1/namespaces/default/configmaps/web-config")["data"].(map[string]interface{})["image"], "only container images change")
+	assert.Empty(t, resolved)
+
+	// Pinning resolves each distinct image once.
+	out, _ = callDeployApp(t, server, map[string]interface{}{
+		"manifest":    imagesManifest,
+		"clusters":    []string{"alpha"},
+		"pin_digests": true,
+		"dry_run":     true,
+	})
+	assert.Equal(t, []string{"ghcr.io/acme/web:1.0", "nginx:1.25"}, resolved)
+	changes := out["imageChanges"].([]interface{})
+	require.Len(t, changes, 3)
+	assert.Equal(t, "nginx:1.25@"+testDigest, changes[2].(map[string]interface{})["to"])
+}
+
+func TestRewriteImagesErrors(t *testing.T) {
+	server := &Server{}
+	server.resolveDigest = func(context.Context, string) (string, error) {
+		return "", fmt.Errorf("401 Unauthorized")
+	}
+	ctx := context.Background()
+
+	_, _, err := server.rewriteImages(ctx, imagesManifest, map[string]string{"ghcr.io/acme/api": "2", "redis": "7"}, false)
+	assert.EqualError(t, err, "image_overrides: no container in the manifest uses ghcr.io/acme/api, redis")
+
+	_, _, err = server.rewriteImages(ctx, imagesManifest, map[string]string{"nginx": "nginx:1.27"}, false)
+	assert.EqualError(t, err, `image_overrides[nginx]: "nginx:1.27" is not a tag or digest`)
+
+	_, _, err = server.rewriteImages(ctx, imagesManifest, map[string]string{"nginx": "@" + testDigest}, true)
+	assert.EqualError(t, err, "failed to resolve ghcr.io/acme/web:1.0 to a digest: 401 Unauthorized")
+
+	manifest, changes, err := server.rewriteImages(ctx, imagesManifest, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, imagesManifest, manifest)
+	assert.Empty(t, changes)
+}
+
+func TestRegistryRepository(t *testing.T) {
+	for repo, want := range map[string][2]string{
+		"nginx":                      {"registry-1.docker.io", "library/nginx"},
+		"bitnami/redis":              {"registry-1.docker.io", "bitnami/redis"},
+		"docker.io/library/nginx":    {"registry-1.docker.io", "library/nginx"},
+		"ghcr.io/acme/web":           {"ghcr.io", "acme/web"},
+		"localhost/web":              {"localhost", "web"},
+		"registry.local:5000/team/x": {"registry.local:5000", "team/x"},
+	} {
+		host, path := registryRepository(repo)
+		assert.Equal(t, want, [2]string{host, path}, repo)
+	}
+}
+
+func TestRegistryClientDigest(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			assert.Equal(t, "repository:acme/web:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"anonymous"}`))
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:acme/web:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/acme/web/manifests/1.0":
+			assert.Contains(t, r.Header.Get("Accept"), "application/vnd.oci.image.index.v1+json")
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "https://")
+	client := &registryClient{http: srv.Client()}
+
+	digest, err := client.digest(context.Background(), host+"/acme/web:1.0")
+	require.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	_, err = client.digest(context.Background(), host+"/acme/web:missing")
+	assert.ErrorContains(t, err, "404")
+}
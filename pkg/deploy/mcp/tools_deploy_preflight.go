@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"k8s.io/client-go/kubernetes"
+)
+
+// preflightReport is the outcome of deploy_app's server-side dry run of
+// the manifest on every target cluster.
+type preflightReport struct {
+	Passed bool `json:"passed"`
+	// RejectedClusters would reject the manifest, or could not be checked.
+	RejectedClusters []string `json:"rejectedClusters,omitempty"`
+	Reason           string   `json:"reason,omitempty"`
+}
+
+// preflight sends manifest to every cluster as a server-side dry run, so
+// admission webhooks (OPA Gatekeeper, Kyverno), Pod Security, quotas, and
+// validation judge it before anything is applied. It returns the report
+// and the per-resource results.
+func (s *Server) preflight(ctx context.Context, clusters []string, manifest string, opts applyOptions) (*preflightReport, []DeployResult, error) {
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.preflightCluster(ctx, client, clusterName, manifest, opts)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &preflightReport{Passed: true}
+	var deployResults []DeployResult
+	rejected := make(map[string]bool)
+	for _, result := range results {
+		if result.Error != "" {
+			deployResults = append(deployResults, DeployResult{
+				Cluster: result.Cluster,
+				Status:  "failed",
+				Message: fmt.Sprintf("could not be checked: %s", result.Error),
+			})
+			rejected[result.Cluster] = true
+			continue
+		}
+		dr, _ := result.Result.([]DeployResult)
+		for _, r := range dr {
+			if r.Status == "rejected" || r.Status == "failed" || r.Status == "conflict" {
+				rejected[r.Cluster] = true
+			}
+		}
+		deployResults = append(deployResults, dr...)
+	}
+	if len(rejected) > 0 {
+		report.Passed = false
+		for cluster := range rejected {
+			report.RejectedClusters = append(report.RejectedClusters, cluster)
+		}
+		sort.Strings(report.RejectedClusters)
+		report.Reason = fmt.Sprintf("%d of %d clusters would reject the manifest: %s", len(rejected), len(clusters), strings.Join(report.RejectedClusters, ", "))
+	}
+	return report, deployResults, nil
+}
+
+// preflightCluster dry-runs manifest on a cluster.
+func (s *Server) preflightCluster(ctx context.Context, client kubernetes.Interface, clusterName, manifest string, opts applyOptions) ([]DeployResult, error) {
+	manifests, err := decodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	if manifests, err = opts.Variables.render(ctx, client, clusterName, manifests); err != nil {
+		return nil, err
+	}
+	if result := validateManifestNamespaces(clusterName, manifests); result != nil {
+		return []DeployResult{*result}, nil
+	}
+
+	config, err := s.manager.GetConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
+	}
+	syncer, err := s.getManifestSyncer(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest syncer: %w", err)
+	}
+	syncOpts := opts.syncOptions()
+	syncOpts.DryRun = true
+	summary, err := syncer.Sync(ctx, manifests, clusterName, syncOpts)
+	if err != nil {
+		return nil, fmt.Errorf("failed dry-run check: %w", err)
+	}
+
+	results := make([]DeployResult, 0, len(summary.Results))
+	for _, r := range summary.Results {
+		result := DeployResult{
+			Cluster:  clusterName,
+			Resource: fmt.Sprintf("%s/%s", r.Kind, r.Name),
+			Status:   "would-apply",
+			Message:  r.Message,
+			Warnings: r.Warnings,
+		}
+		switch r.Action {
+		case gitops.SyncActionRejected:
+			result.Status = "rejected"
+			result.Message = "would be rejected because: " + r.Message
+		case gitops.SyncActionConflict:
+			result.Status = "conflict"
+			result.Message = "would conflict: " + r.Message
+		case gitops.SyncActionFailed, gitops.SyncActionUnchanged, gitops.SyncActionSkipped:
+			result.Status = string(r.Action)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
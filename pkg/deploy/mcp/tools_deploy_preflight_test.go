@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeployAppPreflightAppliesNowhereIfAClusterRejects(t *testing.T) {
+	good, goodURL := newObjectAPIServer(t, false)
+	_, badURL := newObjectAPIServer(t, true)
+	server := newAtomicTestServer(t, map[string]string{"good": goodURL, "bad": badURL})
+
+	out, meta := callDeployApp(t, server, map[string]interface{}{
+		"manifest":  atomicConfigMap,
+		"clusters":  []string{"good", "bad"},
+		"preflight": true,
+	})
+
+	report := out["preflight"].(map[string]interface{})
+	assert.Equal(t, false, report["passed"])
+	assert.Equal(t, []interface{}{"bad"}, report["rejectedClusters"])
+	assert.Equal(t, "1 of 2 clusters would reject the manifest: bad; nothing was applied", report["reason"])
+	assert.EqualValues(t, 0, out["successCount"])
+
+	byCluster := make(map[string]map[string]interface{})
+	for _, r := range out["results"].([]interface{}) {
+		result := r.(map[string]interface{})
+		byCluster[result["cluster"].(string)] = result
+	}
+	assert.Equal(t, "rejected", byCluster["bad"]["status"])
+	assert.Contains(t, byCluster["bad"]["message"], "would be rejected because: ")
+	assert.Equal(t, "would-apply", byCluster["good"]["status"])
+	assert.False(t, good.has(configMapPath), "nothing is applied when a cluster would reject the manifest")
+	assert.Nil(t, meta["changeId"])
+}
+
+func TestDeployAppPreflightAppliesWhenEveryClusterAccepts(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	cluster.warning = `would violate PodSecurity "restricted:latest": runAsNonRoot != true`
+	server := newAtomicTestServer(t, map[string]string{"c1": url})
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":  atomicConfigMap,
+		"clusters":  []string{"c1"},
+		"preflight": true,
+	})
+
+	assert.Equal(t, map[string]interface{}{"passed": true}, out["preflight"])
+	assert.True(t, cluster.has(configMapPath))
+	results := out["results"].([]interface{})
+	require.Len(t, results, 1)
+	assert.Equal(t, []interface{}{cluster.warning}, results[0].(map[string]interface{})["warnings"])
+}
+
+func TestDeployAppPreflightDryRunReportsEveryCluster(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"c1": url})
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":  atomicConfigMap,
+		"clusters":  []string{"c1"},
+		"preflight": true,
+		"dry_run":   true,
+	})
+
+	assert.Equal(t, true, out["preflight"].(map[string]interface{})["passed"])
+	results := out["results"].([]interface{})
+	require.Len(t, results, 1)
+	assert.Equal(t, "would-apply", results[0].(map[string]interface{})["status"])
+	assert.False(t, cluster.has(configMapPath))
+}
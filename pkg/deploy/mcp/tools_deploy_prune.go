@@ -0,0 +1,83 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// applySetTarget is an apply set on one cluster, with the members a
+// deploy_app manifest gives it.
+type applySetTarget struct {
+	cluster string
+	set     *kubemanifest.ApplySet
+	client  dynamic.Interface
+	mapper  meta.RESTMapper
+	members []kubemanifest.Member
+}
+
+func (s *Server) newApplySetTarget(clusterName string, set *kubemanifest.ApplySet, manifests []gitops.Manifest) (*applySetTarget, error) {
+	config, err := s.manager.GetConfig(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	t := &applySetTarget{cluster: clusterName, set: set, client: client}
+	for _, m := range manifests {
+		mapping, err := kubemanifest.LookupResource(config, m.APIVersion, m.Kind)
+		if err != nil {
+			// The apply reports the object as failed, so nothing is
+			// pruned or committed.
+			continue
+		}
+		member := kubemanifest.Member{
+			GroupKind: schema.FromAPIVersionAndKind(m.APIVersion, m.Kind).GroupKind(),
+			Name:      m.Metadata.Name,
+		}
+		if !mapping.ClusterScoped {
+			member.Namespace = m.GetNamespace()
+		}
+		t.members = append(t.members, member)
+	}
+	// Taken after the lookups, which may have discovered the API again.
+	t.mapper = kubemanifest.NewRESTMapper(config)
+	return t, nil
+}
+
+// prepare labels manifests as members of the apply set and records their
+// kinds and namespaces in its parent before they are applied.
+func (t *applySetTarget) prepare(ctx context.Context, manifests []gitops.Manifest) error {
+	for _, m := range manifests {
+		t.set.Label(&unstructured.Unstructured{Object: m.Raw})
+	}
+	return t.set.Prepare(ctx, t.client, t.members)
+}
+
+// prune deletes the members of the apply set that are not in the manifest,
+// and returns a result for each.
+func (t *applySetTarget) prune(ctx context.Context, dryRun bool) ([]DeployResult, error) {
+	pruned, err := t.set.Prune(ctx, t.client, t.mapper, t.members, dryRun)
+	status, message := "pruned", "Deleted %s: no longer in the manifest of apply set %s"
+	if dryRun {
+		status, message = "would-prune", "Would delete %s: no longer in the manifest of apply set %s"
+	}
+	results := make([]DeployResult, 0, len(pruned))
+	for _, m := range pruned {
+		results = append(results, DeployResult{
+			Cluster:  t.cluster,
+			Resource: m.Kind + "/" + m.Name,
+			Status:   status,
+			Message:  fmt.Sprintf(message, m, t.set),
+		})
+	}
+	return results, err
+}
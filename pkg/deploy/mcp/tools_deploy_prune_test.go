@@ -0,0 +1,106 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+)
+
+const pruneConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+`
+
+const pruneService = `---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`
+
+func deployStatuses(out map[string]interface{}) map[string]string {
+	statuses := make(map[string]string)
+	for _, r := range out["results"].([]interface{}) {
+		result := r.(map[string]interface{})
+		statuses[result["resource"].(string)] = result["status"].(string)
+	}
+	return statuses
+}
+
+func TestDeployAppPrunesApplySetMembers(t *testing.T) {
+	api, url := newObjectAPIServer(t, false)
+	api.put(t, "/api/v1/namespaces/default/configmaps/unrelated", &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"},
+	})
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+	set, err := kubemanifest.ParseApplySet("web", "default")
+	require.NoError(t, err)
+
+	callDeployApp(t, server, map[string]interface{}{
+		"manifest": pruneConfigMap + pruneService, "clusters": []string{"alpha"}, "apply_set": "web",
+	})
+	parent := api.get("/api/v1/namespaces/default/configmaps/web")
+	require.NotNil(t, parent)
+	assert.Equal(t, set.ID, parent["metadata"].(map[string]interface{})["labels"].(map[string]interface{})[kubemanifest.ApplySetIDLabel])
+	assert.Equal(t, "ConfigMap,Service", parent["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["applyset.kubernetes.io/contains-group-kinds"])
+	service := api.get("/api/v1/namespaces/default/services/web")
+	require.NotNil(t, service)
+	assert.Equal(t, set.ID, service["metadata"].(map[string]interface{})["labels"].(map[string]interface{})[kubemanifest.ApplySetPartOfLabel])
+
+	// A dry run previews the prune.
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest": pruneConfigMap, "clusters": []string{"alpha"}, "apply_set": "web", "prune": true, "dry_run": true,
+	})
+	assert.Equal(t, map[string]string{"ConfigMap/web-config": "would-apply", "Service/web": "would-prune"}, deployStatuses(out))
+	assert.True(t, api.has("/api/v1/namespaces/default/services/web"))
+
+	out, _ = callDeployApp(t, server, map[string]interface{}{
+		"manifest": pruneConfigMap, "clusters": []string{"alpha"}, "apply_set": "web", "prune": true,
+	})
+	assert.Equal(t, map[string]string{"ConfigMap/web-config": "updated", "Service/web": "pruned"}, deployStatuses(out))
+	assert.False(t, api.has("/api/v1/namespaces/default/services/web"))
+	assert.True(t, api.has("/api/v1/namespaces/default/configmaps/web-config"))
+	assert.True(t, api.has("/api/v1/namespaces/default/configmaps/unrelated"), "objects outside the apply set are never pruned")
+	parent = api.get("/api/v1/namespaces/default/configmaps/web")
+	assert.Equal(t, "ConfigMap", parent["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["applyset.kubernetes.io/contains-group-kinds"])
+}
+
+func TestDeployAppApplySetErrors(t *testing.T) {
+	api, url := newObjectAPIServer(t, false)
+	api.put(t, "/api/v1/namespaces/default/configmaps/unrelated", &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"},
+	})
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	_, err := server.handleDeployApp(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"manifest": pruneConfigMap, "clusters": []string{"alpha"}, "prune": true,
+	}))
+	assert.EqualError(t, err, "prune requires apply_set")
+
+	_, err = server.handleDeployApp(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"manifest": pruneConfigMap, "clusters": []string{"alpha"}, "apply_set": "kube-system/web",
+	}))
+	assert.ErrorContains(t, err, "invalid apply set namespace")
+
+	// An existing ConfigMap that is not an apply set parent is not taken over.
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest": pruneConfigMap, "clusters": []string{"alpha"}, "apply_set": "unrelated",
+	})
+	results := out["results"].([]interface{})
+	require.Len(t, results, 1)
+	assert.Equal(t, "failed", results[0].(map[string]interface{})["status"])
+	assert.Contains(t, results[0].(map[string]interface{})["message"], "is not the parent of apply set unrelated")
+	assert.False(t, api.has("/api/v1/namespaces/default/configmaps/web-config"))
+}
@@ -55,6 +55,19 @@ func TestHandleFindClustersForWorkloadValidatesArguments(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid arguments")
 }
 
+func TestHandleFindClustersForWorkloadValidatesPlacement(t *testing.T) {
+	server := newHelmTestServer(t, map[string]string{})
+
+	for _, args := range []map[string]interface{}{
+		{"weights": map[string]float64{"latency": 1}},
+		{"cluster_costs": map[string]float64{"prod": -1}},
+		{"app": "bad;name"},
+	} {
+		_, err := server.handleFindClustersForWorkload(context.Background(), mustMarshalJSON(t, args))
+		assert.Error(t, err, "%v", args)
+	}
+}
+
 func TestHandleFindClustersForWorkloadReturnsRequirements(t *testing.T) {
 	server := newHelmTestServer(t, map[string]string{})
 
@@ -146,17 +159,43 @@ metadata:
   name: demo
 `
 
-	results, err := server.applyManifest(context.Background(), nil, "alpha", manifest, true)
+	results, err := server.applyManifest(context.Background(), nil, "alpha", manifest, applyOptions{DryRun: true})
 	require.NoError(t, err)
 	require.Len(t, results, 1)
 	assert.Equal(t, "would-apply", results[0].Status)
 	assert.Contains(t, results[0].Message, "namespace default")
 }
 
+func TestApplyManifestDryRunFlattensLists(t *testing.T) {
+	server := newHelmTestServer(t, map[string]string{})
+	manifest := `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: demo-config
+  data:
+    banner: |
+      ---
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: demo-service
+    namespace: shop
+`
+
+	results, err := server.applyManifest(context.Background(), nil, "alpha", manifest, applyOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "ConfigMap/demo-config", results[0].Resource)
+	assert.Equal(t, "Would apply Service/demo-service to namespace shop", results[1].Message)
+}
+
 func TestApplyManifestReturnsDecodeError(t *testing.T) {
 	server := newHelmTestServer(t, map[string]string{})
 
-	_, err := server.applyManifest(context.Background(), nil, "alpha", "[", true)
+	_, err := server.applyManifest(context.Background(), nil, "alpha", "[", applyOptions{DryRun: true})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to decode manifest")
 }
@@ -254,7 +293,7 @@ metadata:
   name: demo-clusterrolebinding
 `
 
-	results, err := server.applyManifest(context.Background(), nil, "alpha", manifest, false)
+	results, err := server.applyManifest(context.Background(), nil, "alpha", manifest, applyOptions{})
 	require.NoError(t, err)
 	require.Len(t, results, 12)
 	assert.Equal(t, []string{
@@ -276,6 +315,23 @@ metadata:
 	}
 }
 
+func TestDeployAppCreatesNamespaces(t *testing.T) {
+	api, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":          "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web-config\n  namespace: team-a\n",
+		"clusters":          []string{"alpha"},
+		"create_namespaces": true,
+	})
+	results := out["results"].([]interface{})
+	require.Len(t, results, 2)
+	assert.Equal(t, "Namespace/team-a", results[0].(map[string]interface{})["resource"])
+	assert.Equal(t, "ConfigMap/web-config", results[1].(map[string]interface{})["resource"])
+	assert.True(t, api.has("/api/v1/namespaces/team-a"))
+	assert.True(t, api.has("/api/v1/namespaces/team-a/configmaps/web-config"))
+}
+
 func TestApplyResourceFunctionsUseServerSideApplyPatch(t *testing.T) {
 	server := newHelmTestServer(t, map[string]string{})
 	tests := []struct {
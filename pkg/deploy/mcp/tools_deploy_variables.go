@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Built-in variables, defined for every cluster unless the caller sets
+// them: the cluster's name, and the region its nodes are labeled with.
+const (
+	clusterNameVariable = "CLUSTER_NAME"
+	regionVariable      = "REGION"
+)
+
+// regionLabel is the well-known node label REGION is read from.
+const regionLabel = "topology.kubernetes.io/region"
+
+// manifestVariables are the variables deploy_app and sync_from_git
+// substitute in a manifest for each cluster. Per-cluster values take
+// precedence over global ones, and both over the built-ins.
+type manifestVariables struct {
+	global   map[string]string
+	clusters map[string]map[string]string
+}
+
+// newManifestVariables returns the variables of the template, variables,
+// and cluster_variables parameters for deploying to targets, or nil if the
+// manifest is not a template.
+func newManifestVariables(template bool, global map[string]string, clusters map[string]map[string]string, targets []string) (*manifestVariables, error) {
+	if !template && len(global) == 0 && len(clusters) == 0 {
+		return nil, nil
+	}
+	for name := range global {
+		if !kubemanifest.VariableNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("variables: invalid variable name %q", name)
+		}
+	}
+	isTarget := make(map[string]bool, len(targets))
+	for _, c := range targets {
+		isTarget[c] = true
+	}
+	for cluster, vars := range clusters {
+		if !isTarget[cluster] {
+			return nil, fmt.Errorf("cluster_variables: %s is not a target cluster", cluster)
+		}
+		for name := range vars {
+			if !kubemanifest.VariableNamePattern.MatchString(name) {
+				return nil, fmt.Errorf("cluster_variables[%s]: invalid variable name %q", cluster, name)
+			}
+		}
+	}
+	return &manifestVariables{global: global, clusters: clusters}, nil
+}
+
+// forCluster returns the variables the caller set for cluster, with
+// CLUSTER_NAME; REGION is looked up when a manifest needs it.
+func (v *manifestVariables) forCluster(cluster string) map[string]string {
+	vars := map[string]string{clusterNameVariable: cluster}
+	for name, value := range v.global {
+		vars[name] = value
+	}
+	for name, value := range v.clusters[cluster] {
+		vars[name] = value
+	}
+	return vars
+}
+
+// validate checks that every variable the manifests reference is defined
+// for every cluster, so that a deploy fails before it touches any cluster.
+func (v *manifestVariables) validate(manifests []gitops.Manifest, clusters []string) error {
+	referenced, err := referencedVariables(manifests)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		vars := v.forCluster(cluster)
+		var undefined []string
+		for _, name := range referenced {
+			if _, ok := vars[name]; !ok && name != regionVariable {
+				undefined = append(undefined, "${"+name+"}")
+			}
+		}
+		if len(undefined) > 0 {
+			return fmt.Errorf("manifest references undefined variables for cluster %s: %s", cluster, strings.Join(undefined, ", "))
+		}
+	}
+	return nil
+}
+
+// render returns the manifests with the variables of cluster substituted.
+// The manifests themselves are left as they are.
+func (v *manifestVariables) render(ctx context.Context, client kubernetes.Interface, cluster string, manifests []gitops.Manifest) ([]gitops.Manifest, error) {
+	if v == nil {
+		return manifests, nil
+	}
+	vars := v.forCluster(cluster)
+	if _, ok := vars[regionVariable]; !ok {
+		referenced, err := referencedVariables(manifests)
+		if err != nil {
+			return nil, err
+		}
+		if i := sort.SearchStrings(referenced, regionVariable); i < len(referenced) && referenced[i] == regionVariable {
+			if vars[regionVariable], err = clusterRegion(ctx, client, cluster); err != nil {
+				return nil, err
+			}
+		}
+	}
+	rendered := make([]gitops.Manifest, 0, len(manifests))
+	for _, m := range manifests {
+		raw := runtime.DeepCopyJSON(m.Raw)
+		if err := kubemanifest.Substitute(raw, vars); err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", m.Kind, m.Metadata.Name, err)
+		}
+		rendered = append(rendered, gitops.NewManifest(&unstructured.Unstructured{Object: raw}))
+	}
+	return rendered, nil
+}
+
+// referencedVariables returns the sorted, distinct variables manifests
+// reference.
+func referencedVariables(manifests []gitops.Manifest) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, m := range manifests {
+		names, err := kubemanifest.Variables(m.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", m.Kind, m.Metadata.Name, err)
+		}
+		for _, name := range names {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// clusterRegion returns the region the cluster's nodes are labeled with.
+// A cluster without the label, or whose nodes span regions, has no region;
+// REGION must then be set in cluster_variables.
+func clusterRegion(ctx context.Context, client kubernetes.Interface, cluster string) (string, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes of cluster %s for ${REGION}: %w", cluster, err)
+	}
+	regions := make(map[string]bool)
+	for _, node := range nodes.Items {
+		if region := node.Labels[regionLabel]; region != "" {
+			regions[region] = true
+		}
+	}
+	switch len(regions) {
+	case 1:
+		for region := range regions {
+			return region, nil
+		}
+	case 0:
+		return "", fmt.Errorf("cluster %s has no %s node label for ${REGION}; set REGION in cluster_variables", cluster, regionLabel)
+	}
+	names := make([]string, 0, len(regions))
+	for region := range regions {
+		names = append(names, region)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("cluster %s spans regions %s; set REGION in cluster_variables", cluster, strings.Join(names, ", "))
+}
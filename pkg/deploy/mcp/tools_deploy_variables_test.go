@@ -0,0 +1,211 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const templatedConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: default
+data:
+  host: web.${CLUSTER_NAME}.${DOMAIN}
+  region: ${REGION}
+  storageClass: ${STORAGE_CLASS}
+  script: echo $${HOME}
+`
+
+func regionNode(name, region string) *corev1.Node {
+	return &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{regionLabel: region}},
+	}
+}
+
+func TestDeployAppSubstitutesVariablesPerCluster(t *testing.T) {
+	alpha, alphaURL := newObjectAPIServer(t, false)
+	beta, betaURL := newObjectAPIServer(t, false)
+	alpha.put(t, "/api/v1/nodes/node-1", regionNode("node-1", "us-east-1"))
+	server := newAtomicTestServer(t, map[string]string{"alpha": alphaURL, "beta": betaURL})
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":  templatedConfigMap,
+		"clusters":  []string{"alpha", "beta"},
+		"variables": map[string]string{"DOMAIN": "example.com", "STORAGE_CLASS": "standard"},
+		"cluster_variables": map[string]map[string]string{
+			"beta": {"REGION": "eu-west-1", "STORAGE_CLASS": "gp3"},
+		},
+	})
+	assert.Equal(t, float64(2), out["successCount"])
+
+	for _, tc := range []struct {
+		api                        *objectAPIServer
+		host, region, storageClass string
+	}{
+		{alpha, "web.alpha.example.com", "us-east-1", "standard"},
+		{beta, "web.beta.example.com", "eu-west-1", "gp3"},
+	} {
+		cm := tc.api.get("/api/v1/namespaces/default/configmaps/web-config")
+		require.NotNil(t, cm)
+		data := cm["data"].(map[string]interface{})
+		assert.Equal(t, tc.host, data["host"])
+		assert.Equal(t, tc.region, data["region"])
+		assert.Equal(t, tc.storageClass, data["storageClass"])
+		assert.Equal(t, "echo ${HOME}", data["script"])
+	}
+}
+
+func TestDeployAppRejectsUndefinedVariablesBeforeApplying(t *testing.T) {
+	api, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	_, err := server.handleDeployApp(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"manifest":  templatedConfigMap,
+		"clusters":  []string{"alpha"},
+		"variables": map[string]string{"DOMAIN": "example.com"},
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "undefined variables for cluster alpha: ${STORAGE_CLASS}")
+	assert.False(t, api.has("/api/v1/namespaces/default/configmaps/web-config"))
+
+	_, err = server.handleDeployApp(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"manifest":          templatedConfigMap,
+		"clusters":          []string{"alpha"},
+		"cluster_variables": map[string]map[string]string{"gamma": {"DOMAIN": "example.com"}},
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cluster_variables: gamma is not a target cluster")
+}
+
+func TestDeployAppWithoutTemplateKeepsReferences(t *testing.T) {
+	api, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	callDeployApp(t, server, map[string]interface{}{
+		"manifest": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: script\n  namespace: default\ndata:\n  run: echo ${HOME}\n",
+		"clusters": []string{"alpha"},
+	})
+	cm := api.get("/api/v1/namespaces/default/configmaps/script")
+	require.NotNil(t, cm)
+	assert.Equal(t, "echo ${HOME}", cm["data"].(map[string]interface{})["run"])
+}
+
+const templatedDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web-${CLUSTER_NAME}
+  namespace: default
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`
+
+// newTemplatedDeployServer returns a server for clusters alpha and beta,
+// whose Deployments become ready as soon as they are created.
+func newTemplatedDeployServer(t *testing.T) *Server {
+	shortHealthPolls(t)
+	alpha, alphaURL := newObjectAPIServer(t, false)
+	beta, betaURL := newObjectAPIServer(t, false)
+	alpha.readyDeployments, beta.readyDeployments = true, true
+	return newAtomicTestServer(t, map[string]string{"alpha": alphaURL, "beta": betaURL})
+}
+
+func TestDeployAppAtomicChecksRenderedWorkloads(t *testing.T) {
+	server := newTemplatedDeployServer(t)
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":        templatedDeployment,
+		"clusters":        []string{"alpha", "beta"},
+		"template":        true,
+		"atomic":          true,
+		"timeout_seconds": 1,
+	})
+	tx := out["transaction"].(map[string]interface{})
+	assert.Equal(t, txCommitted, tx["outcome"], "transaction: %v", tx)
+}
+
+func TestDeployAppWaitChecksRenderedWorkloads(t *testing.T) {
+	server := newTemplatedDeployServer(t)
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":        templatedDeployment,
+		"clusters":        []string{"alpha", "beta"},
+		"template":        true,
+		"wait":            true,
+		"timeout_seconds": 1,
+	})
+	assert.Equal(t, true, out["healthy"], "health: %v", out["health"])
+	var workloads []interface{}
+	for _, h := range out["health"].([]interface{}) {
+		workloads = append(workloads, h.(map[string]interface{})["workload"])
+	}
+	assert.Equal(t, []interface{}{"Deployment/web-alpha", "Deployment/web-beta"}, workloads)
+}
+
+func TestRolloutChecksRenderedWorkloads(t *testing.T) {
+	server := newTemplatedDeployServer(t)
+
+	started, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest": templatedDeployment,
+		"clusters": []string{"alpha", "beta"},
+		"template": true,
+		"rollout_strategy": map[string]interface{}{
+			"canary_clusters":        []string{"alpha"},
+			"health_timeout_seconds": 1,
+			"max_restarts":           0,
+		},
+	})
+	done := waitForRolloutState(t, server, started["rolloutId"].(string), rolloutCompleted)
+	for _, batch := range done["batches"].([]interface{}) {
+		assert.Equal(t, batchSucceeded, batch.(map[string]interface{})["status"])
+	}
+}
+
+func TestClusterRegion(t *testing.T) {
+	ctx := context.Background()
+
+	region, err := clusterRegion(ctx, fake.NewSimpleClientset(regionNode("a", "us-east-1"), regionNode("b", "us-east-1")), "alpha")
+	require.NoError(t, err)
+	assert.Equal(t, "us-east-1", region)
+
+	_, err = clusterRegion(ctx, fake.NewSimpleClientset(regionNode("a", "us-east-1"), regionNode("b", "us-west-2")), "alpha")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "spans regions us-east-1, us-west-2")
+
+	_, err = clusterRegion(ctx, fake.NewSimpleClientset(), "alpha")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "set REGION in cluster_variables")
+}
+
+func TestHandleSyncFromGitRejectsUndefinedVariables(t *testing.T) {
+	setGitOpsTempDir(t)
+	repo := createGitRepo(t, map[string]string{"manifests/app.yaml": templatedConfigMap})
+	server := newHelmTestServer(t, map[string]string{})
+
+	_, err := server.handleSyncFromGit(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"repo":      repo,
+		"path":      "manifests",
+		"clusters":  []string{"missing"},
+		"variables": map[string]string{"DOMAIN": "example.com"},
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "undefined variables for cluster missing: ${STORAGE_CLASS}")
+}
@@ -0,0 +1,145 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Workload health states reported when deploy_app or kubectl_apply waits.
+const (
+	healthReady       = "ready"
+	healthProgressing = "progressing"
+	healthFailed      = "failed"
+)
+
+// workloadHealth is the health of a workload on a cluster when the wait
+// for it ended.
+type workloadHealth struct {
+	Cluster   string `json:"cluster"`
+	Workload  string `json:"workload"`
+	Namespace string `json:"namespace"`
+	// Status is ready, failed, or progressing if the wait timed out.
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// waitedWorkloads returns the Deployments, StatefulSets, DaemonSets, and
+// Jobs in manifests.
+func waitedWorkloads(manifests []gitops.Manifest) []gitops.Manifest {
+	var workloads []gitops.Manifest
+	for _, m := range manifests {
+		switch m.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet", "Job":
+			workloads = append(workloads, m)
+		}
+	}
+	return workloads
+}
+
+// appliedClusters returns the clusters, in order, whose results have no
+// failure.
+func appliedClusters(clusters []string, failed map[string]bool) []string {
+	var applied []string
+	for _, c := range clusters {
+		if !failed[c] {
+			applied = append(applied, c)
+		}
+	}
+	return applied
+}
+
+// waitForHealth waits on every cluster until the workloads, rendered with
+// vars for the cluster, are ready or have failed, or timeout expires, and
+// returns their health at that point. The second result reports whether
+// every workload is ready.
+func (s *Server) waitForHealth(ctx context.Context, clusters []string, workloads []gitops.Manifest, vars *manifestVariables, timeout time.Duration) ([]workloadHealth, bool, error) {
+	if len(clusters) == 0 || len(workloads) == 0 {
+		return nil, true, nil
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		rendered, err := vars.render(ctx, client, clusterName, workloads)
+		if err != nil {
+			return nil, err
+		}
+		return pollHealth(ctx, client, clusterName, rendered, timeout)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+
+	var health []workloadHealth
+	healthy := true
+	for _, r := range results {
+		if r.Error != "" {
+			health = append(health, workloadHealth{Cluster: r.Cluster, Status: healthFailed, Message: r.Error})
+			healthy = false
+			continue
+		}
+		cluster, _ := r.Result.([]workloadHealth)
+		for _, h := range cluster {
+			healthy = healthy && h.Status == healthReady
+		}
+		health = append(health, cluster...)
+	}
+	return health, healthy, nil
+}
+
+// pollHealth checks workloads until none is progressing or timeout expires.
+func pollHealth(ctx context.Context, client kubernetes.Interface, cluster string, workloads []gitops.Manifest, timeout time.Duration) ([]workloadHealth, error) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+	for {
+		health := make([]workloadHealth, 0, len(workloads))
+		progressing := false
+		for _, w := range workloads {
+			state, message, err := workloadState(ctx, client, w.Kind, w.GetNamespace(), w.Metadata.Name)
+			if err != nil {
+				return nil, err
+			}
+			progressing = progressing || state == healthProgressing
+			health = append(health, workloadHealth{
+				Cluster:   cluster,
+				Workload:  fmt.Sprintf("%s/%s", w.Kind, w.Metadata.Name),
+				Namespace: w.GetNamespace(),
+				Status:    state,
+				Message:   message,
+			})
+		}
+		if !progressing {
+			return health, nil
+		}
+		select {
+		case <-ctx.Done():
+			return health, ctx.Err()
+		case <-deadline.C:
+			return health, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// reportHealth waits for the workloads of manifest, rendered with vars, on
+// the clusters where it applied without failures, and adds their health to
+// out, the result of deploy_app or kubectl_apply.
+func (s *Server) reportHealth(ctx context.Context, out map[string]interface{}, clusters []string, failed map[string]bool, manifest string, vars *manifestVariables, timeout time.Duration) {
+	manifests, err := decodeManifests(manifest)
+	if err != nil {
+		// The apply has already failed on every cluster.
+		return
+	}
+	health, healthy, err := s.waitForHealth(ctx, appliedClusters(clusters, failed), waitedWorkloads(manifests), vars, timeout)
+	if err != nil {
+		out["healthError"] = err.Error()
+		return
+	}
+	out["health"] = health
+	out["healthy"] = healthy
+}
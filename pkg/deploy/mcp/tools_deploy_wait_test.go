@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const waitDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`
+
+func shortHealthPolls(t *testing.T) {
+	saved := healthPollInterval
+	healthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = saved })
+}
+
+func TestDeployAppWaitReportsHealth(t *testing.T) {
+	shortHealthPolls(t)
+	ready, readyURL := newObjectAPIServer(t, false)
+	ready.readyDeployments = true
+	_, slowURL := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"ready": readyURL, "slow": slowURL})
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest": waitDeployment, "clusters": []string{"slow", "ready"}, "wait": true, "timeout_seconds": 1,
+	})
+	assert.Equal(t, false, out["healthy"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"cluster": "ready", "workload": "Deployment/web", "namespace": "default", "status": "ready", "message": "2/2 replicas ready, 2 updated"},
+		map[string]interface{}{"cluster": "slow", "workload": "Deployment/web", "namespace": "default", "status": "progressing", "message": "0/2 replicas ready, 0 updated"},
+	}, out["health"])
+
+	// Without wait, deploy_app returns as soon as the objects are applied.
+	out, _ = callDeployApp(t, server, map[string]interface{}{"manifest": waitDeployment, "clusters": []string{"ready"}})
+	assert.NotContains(t, out, "health")
+}
+
+func TestKubectlApplyWaitReportsHealth(t *testing.T) {
+	shortHealthPolls(t)
+	cluster, url := newObjectAPIServer(t, false)
+	cluster.readyDeployments = true
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	got, err := server.handleKubectlApply(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"manifest": waitDeployment, "clusters": []string{"alpha"}, "wait": true, "timeout_seconds": 1,
+	}))
+	require.NoError(t, err)
+	out := got.(map[string]interface{})
+	assert.Equal(t, true, out["healthy"])
+	data, err := json.Marshal(out["health"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"cluster":"alpha","workload":"Deployment/web","namespace":"default","status":"ready","message":"2/2 replicas ready, 2 updated"}]`, string(data))
+}
+
+func TestWorkloadState(t *testing.T) {
+	job := func(name string, conditions ...batchv1.JobCondition) *batchv1.Job {
+		return &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     batchv1.JobStatus{Active: 1, Conditions: conditions},
+		}
+	}
+	stalled := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "stalled", Namespace: "default"},
+		Status: appsv1.DeploymentStatus{Conditions: []appsv1.DeploymentCondition{{
+			Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: "ProgressDeadlineExceeded",
+			Message: `ReplicaSet "stalled-1" has timed out progressing.`,
+		}}},
+	}
+	client := fake.NewSimpleClientset(
+		job("running"),
+		job("done", batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}),
+		job("broken", batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "BackoffLimitExceeded", Message: "Job has reached the specified backoff limit"}),
+		stalled,
+	)
+
+	tests := []struct {
+		kind, name, state, message string
+	}{
+		{"Job", "running", healthProgressing, "1 active, 0 succeeded, 0 failed pods"},
+		{"Job", "done", healthReady, "completed"},
+		{"Job", "broken", healthFailed, "BackoffLimitExceeded: Job has reached the specified backoff limit"},
+		{"Job", "missing", healthProgressing, "not found"},
+		{"Deployment", "stalled", healthFailed, `0/1 replicas ready, 0 updated: ReplicaSet "stalled-1" has timed out progressing.`},
+		{"ConfigMap", "settings", healthReady, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.kind+"/"+tt.name, func(t *testing.T) {
+			state, message, err := workloadState(context.Background(), client, tt.kind, "default", tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.state, state)
+			assert.Equal(t, tt.message, message)
+		})
+	}
+}
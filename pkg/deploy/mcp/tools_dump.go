@@ -0,0 +1,352 @@
+package mcp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// EnvDumpPassphrase is the passphrase dump_namespace encrypts Secrets with.
+// It stays on the server, so an agent can take encrypted dumps without
+// being able to read them.
+const EnvDumpPassphrase = "KUBESTELLAR_DUMP_PASSPHRASE"
+
+// Secret modes of dump_namespace.
+const (
+	dumpSecretsSkip    = "skip"
+	dumpSecretsEncrypt = "encrypt"
+)
+
+// dumpIterations is the PBKDF2 iteration count of encrypted Secrets; it
+// has to be passed to openssl as -iter to decrypt them.
+const dumpIterations = 100000
+
+// maxDumpBytes bounds the manifests of one dump, before compression.
+const maxDumpBytes = 32 << 20
+
+// NamespaceDump is a namespace's resources dumped from several clusters.
+type NamespaceDump struct {
+	Namespace  string        `json:"namespace"`
+	SecretMode string        `json:"secretMode"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	Clusters   []ClusterDump `json:"clusters"`
+	// Differences are the resources that are not the same on every
+	// dumped cluster.
+	Differences []DumpDifference `json:"differences,omitempty"`
+	Issues      []string         `json:"issues,omitempty"`
+	// Archive is the dump as a base64-encoded .tar.gz.
+	Archive      string `json:"archive,omitempty"`
+	ArchiveBytes int    `json:"archiveBytes,omitempty"`
+}
+
+// ClusterDump is what was dumped from one cluster.
+type ClusterDump struct {
+	Cluster string `json:"cluster"`
+	// Dir is the cluster's directory in the archive.
+	Dir     string          `json:"dir"`
+	Objects int             `json:"objects"`
+	Secrets int             `json:"secrets,omitempty"`
+	Skipped []MigrationStep `json:"skipped,omitempty"`
+
+	files []dumpFile
+}
+
+// DumpDifference is a resource that is missing on some clusters or whose
+// manifest differs between them.
+type DumpDifference struct {
+	Resource    string   `json:"resource"`
+	MissingFrom []string `json:"missingFrom,omitempty"`
+	// Variants groups the clusters that have the resource by identical
+	// manifests, when there is more than one variant.
+	Variants [][]string `json:"variants,omitempty"`
+}
+
+// dumpFile is one file of a cluster's dump.
+type dumpFile struct {
+	resource string
+	name     string
+	data     []byte
+	// sum is the SHA-256 of the manifest before encryption, to compare
+	// clusters.
+	sum [sha256.Size]byte
+}
+
+// handleDumpNamespace exports every resource of a namespace from each
+// cluster into one archive, for backups and for comparing environments.
+func (s *Server) handleDumpNamespace(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Namespace     string   `json:"namespace"`
+		Clusters      []string `json:"clusters"`
+		Kinds         []string `json:"kinds"`
+		LabelSelector string   `json:"label_selector"`
+		SecretMode    string   `json:"secret_mode"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if err := claude.ValidateK8sNamespace(params.Namespace); err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	if err := server.ValidateNamespace(params.Namespace); err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	var passphrase string
+	switch params.SecretMode {
+	case "":
+		params.SecretMode = dumpSecretsSkip
+	case dumpSecretsSkip:
+	case dumpSecretsEncrypt:
+		if passphrase = os.Getenv(EnvDumpPassphrase); passphrase == "" {
+			return nil, fmt.Errorf("secret_mode encrypt needs a passphrase in %s on the server", EnvDumpPassphrase)
+		}
+	default:
+		return nil, fmt.Errorf("invalid secret_mode %q: must be skip or encrypt", params.SecretMode)
+	}
+	kinds := make(map[string]bool, len(params.Kinds))
+	for _, kind := range params.Kinds {
+		if _, ok := portableGVR(kind); !ok {
+			return nil, fmt.Errorf("unsupported kind %q", kind)
+		}
+		kinds[kind] = true
+	}
+
+	targetClusters := params.Clusters
+	if len(targetClusters) == 0 {
+		clusters, err := s.manager.DiscoverClusters()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range clusters {
+			targetClusters = append(targetClusters, c.Name)
+		}
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.dumpCluster(ctx, clusterName, params.Namespace, kinds, params.LabelSelector, params.SecretMode, passphrase)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortClusterResults(results)
+
+	dump := NamespaceDump{
+		Namespace:  params.Namespace,
+		SecretMode: params.SecretMode,
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		Clusters:   []ClusterDump{},
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			dump.Issues = append(dump.Issues, fmt.Sprintf("%s: %s", result.Cluster, result.Error))
+			continue
+		}
+		dump.Clusters = append(dump.Clusters, result.Result.(ClusterDump))
+	}
+	dump.Differences = dumpDifferences(dump.Clusters)
+	archive, err := dumpArchive(dump)
+	if err != nil {
+		return nil, err
+	}
+	dump.Archive = base64.StdEncoding.EncodeToString(archive)
+	dump.ArchiveBytes = len(archive)
+	return dump, nil
+}
+
+// dumpCluster exports the objects of kinds (or every portable kind) in
+// namespace from one cluster.
+func (s *Server) dumpCluster(ctx context.Context, clusterName, namespace string, kinds map[string]bool, selector, secretMode, passphrase string) (ClusterDump, error) {
+	dump := ClusterDump{Cluster: clusterName, Dir: path.Join(archiveDirName(clusterName), namespace)}
+	dyn, err := s.dynamicClient(clusterName)
+	if err != nil {
+		return dump, err
+	}
+	cloneMode := secretModeSkip
+	if secretMode == dumpSecretsEncrypt {
+		cloneMode = secretModeCopy
+	}
+	for _, k := range portableKinds {
+		if len(kinds) > 0 && !kinds[k.Kind] {
+			continue
+		}
+		list, err := dyn.Resource(k.GVR).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if apierrors.IsNotFound(err) {
+			// The cluster does not serve this resource.
+			continue
+		}
+		if err != nil {
+			return dump, fmt.Errorf("listing %s: %w", k.GVR.Resource, err)
+		}
+		sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].GetName() < list.Items[j].GetName() })
+		for i := range list.Items {
+			obj := &list.Items[i]
+			obj.SetKind(k.Kind)
+			obj.SetAPIVersion(k.GVR.GroupVersion().String())
+			resource := k.Kind + " " + namespace + "/" + obj.GetName()
+			if reason := cloneSkipReason(obj, cloneMode); reason != "" {
+				dump.Skipped = append(dump.Skipped, MigrationStep{Step: "dump", Cluster: clusterName, Resource: resource, Status: "skipped", Message: reason})
+				continue
+			}
+			data, err := yaml.Marshal(exportObject(obj).Object)
+			if err != nil {
+				return dump, fmt.Errorf("failed to encode %s: %w", resource, err)
+			}
+			file := dumpFile{resource: resource, name: path.Join(dump.Dir, k.GVR.Resource, obj.GetName()+".yaml"), data: data, sum: sha256.Sum256(data)}
+			if k.Kind == "Secret" {
+				if file.data, err = encryptOpenSSL(data, passphrase); err != nil {
+					return dump, fmt.Errorf("failed to encrypt %s: %w", resource, err)
+				}
+				file.name += ".enc"
+				dump.Secrets++
+			}
+			dump.files = append(dump.files, file)
+		}
+	}
+	dump.Objects = len(dump.files)
+	return dump, nil
+}
+
+// archiveDirName makes a cluster name, which may contain slashes (as EKS
+// context ARNs do), usable as a directory name.
+func archiveDirName(cluster string) string {
+	name := strings.NewReplacer("/", "_", "\\", "_").Replace(cluster)
+	if name == "." || name == ".." {
+		name = "_" + name
+	}
+	return name
+}
+
+// dumpDifferences compares the resources dumped from each cluster.
+func dumpDifferences(clusters []ClusterDump) []DumpDifference {
+	if len(clusters) < 2 {
+		return nil
+	}
+	variants := make(map[string]map[[sha256.Size]byte][]string)
+	for _, c := range clusters {
+		for _, f := range c.files {
+			if variants[f.resource] == nil {
+				variants[f.resource] = make(map[[sha256.Size]byte][]string)
+			}
+			variants[f.resource][f.sum] = append(variants[f.resource][f.sum], c.Cluster)
+		}
+	}
+	var diffs []DumpDifference
+	for resource, bySum := range variants {
+		diff := DumpDifference{Resource: resource}
+		present := make(map[string]bool)
+		for _, group := range bySum {
+			for _, cluster := range group {
+				present[cluster] = true
+			}
+			if len(bySum) > 1 {
+				diff.Variants = append(diff.Variants, group)
+			}
+		}
+		for _, c := range clusters {
+			if !present[c.Cluster] {
+				diff.MissingFrom = append(diff.MissingFrom, c.Cluster)
+			}
+		}
+		if len(diff.MissingFrom) == 0 && len(diff.Variants) == 0 {
+			continue
+		}
+		sort.Slice(diff.Variants, func(i, j int) bool { return diff.Variants[i][0] < diff.Variants[j][0] })
+		diffs = append(diffs, diff)
+	}
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Resource < diffs[j].Resource })
+	return diffs
+}
+
+// dumpArchive writes the dump as a .tar.gz: an index.json with the dump's
+// summary, and each object at <cluster>/<namespace>/<resource>/<name>.yaml,
+// with .enc appended for encrypted Secrets. The YAML files of a cluster's
+// directory can be synced back with sync_from_git's archive source.
+func dumpArchive(dump NamespaceDump) ([]byte, error) {
+	index, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	total := len(index)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: dump.CreatedAt, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := write("index.json", index); err != nil {
+		return nil, err
+	}
+	for _, c := range dump.Clusters {
+		for _, f := range c.files {
+			if total += len(f.data); total > maxDumpBytes {
+				return nil, fmt.Errorf("the dump is larger than %d MiB; narrow it with kinds, label_selector, or clusters", maxDumpBytes>>20)
+			}
+			if err := write(f.name, f.data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encryptOpenSSL encrypts data as
+//
+//	openssl enc -aes-256-cbc -pbkdf2 -iter 100000 -md sha256
+//
+// does, so a Secret can be decrypted without this server by running the
+// same command with -d.
+func encryptOpenSSL(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, dumpIterations, 32+aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key[:32])
+	if err != nil {
+		return nil, err
+	}
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	out := make([]byte, 16+len(plain))
+	copy(out, "Salted__")
+	copy(out[8:], salt)
+	cipher.NewCBCEncrypter(block, key[32:]).CryptBlocks(out[16:], plain)
+	return out, nil
+}
@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readDumpArchive returns the files of a dump_namespace archive by name.
+func readDumpArchive(t *testing.T, encoded string) map[string][]byte {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		files[hdr.Name], err = io.ReadAll(tr)
+		require.NoError(t, err)
+	}
+}
+
+func TestEncryptOpenSSL(t *testing.T) {
+	plain := []byte("apiVersion: v1\nkind: Secret\n")
+	data, err := encryptOpenSSL(plain, "hunter2")
+	require.NoError(t, err)
+	require.Equal(t, "Salted__", string(data[:8]))
+	require.Zero(t, (len(data)-16)%aes.BlockSize)
+
+	key, err := pbkdf2.Key(sha256.New, "hunter2", data[8:16], dumpIterations, 32+aes.BlockSize)
+	require.NoError(t, err)
+	block, err := aes.NewCipher(key[:32])
+	require.NoError(t, err)
+	out := make([]byte, len(data)-16)
+	cipher.NewCBCDecrypter(block, key[32:]).CryptBlocks(out, data[16:])
+	assert.Equal(t, plain, out[:len(out)-int(out[len(out)-1])])
+
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not installed")
+	}
+	cmd := exec.Command(openssl, "enc", "-d", "-aes-256-cbc", "-pbkdf2", "-iter", "100000", "-md", "sha256", "-pass", "pass:hunter2")
+	cmd.Stdin = bytes.NewReader(data)
+	decrypted, err := cmd.Output()
+	require.NoError(t, err)
+	assert.Equal(t, plain, decrypted)
+}
+
+func TestDumpNamespace(t *testing.T) {
+	t.Setenv(EnvDumpPassphrase, "hunter2")
+	prod, prodURL := newObjectAPIServer(t, false)
+	staging, stagingURL := newObjectAPIServer(t, false)
+	for _, api := range []*objectAPIServer{prod, staging} {
+		api.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", liveDeployment("web"))
+		api.put(t, "/api/v1/namespaces/shop/configmaps/kube-root-ca.crt", map[string]interface{}{
+			"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "kube-root-ca.crt", "namespace": "shop"},
+		})
+	}
+	prod.put(t, "/api/v1/namespaces/shop/configmaps/settings", map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "settings", "namespace": "shop"},
+		"data": map[string]interface{}{"color": "blue"},
+	})
+	prod.put(t, "/api/v1/namespaces/shop/secrets/db", map[string]interface{}{
+		"apiVersion": "v1", "kind": "Secret", "type": "Opaque", "metadata": map[string]interface{}{"name": "db", "namespace": "shop"},
+		"data": map[string]interface{}{"password": "c2VjcmV0"},
+	})
+	scaled := liveDeployment("web")
+	scaled["spec"].(map[string]interface{})["replicas"] = int64(1)
+	staging.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", scaled)
+	server := newAtomicTestServer(t, map[string]string{"prod": prodURL, "staging": stagingURL})
+
+	out, errText := callTool(t, server, "dump_namespace", map[string]interface{}{"namespace": "shop", "clusters": []string{"staging", "prod"}})
+	require.Empty(t, errText)
+	clusters := out["clusters"].([]interface{})
+	require.Len(t, clusters, 2)
+	assert.Equal(t, "prod", clusters[0].(map[string]interface{})["cluster"])
+	assert.EqualValues(t, 2, clusters[0].(map[string]interface{})["objects"])
+	var skipped []string
+	for _, s := range clusters[0].(map[string]interface{})["skipped"].([]interface{}) {
+		skipped = append(skipped, s.(map[string]interface{})["resource"].(string))
+	}
+	assert.ElementsMatch(t, []string{"ConfigMap shop/kube-root-ca.crt", "Secret shop/db"}, skipped)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"resource": "ConfigMap shop/settings", "missingFrom": []interface{}{"staging"}},
+		map[string]interface{}{"resource": "Deployment shop/web", "variants": []interface{}{[]interface{}{"prod"}, []interface{}{"staging"}}},
+	}, out["differences"])
+
+	files := readDumpArchive(t, out["archive"].(string))
+	assert.Contains(t, files, "index.json")
+	assert.Contains(t, string(files["prod/shop/configmaps/settings.yaml"]), "color: blue")
+	assert.Contains(t, string(files["staging/shop/deployments/web.yaml"]), "replicas: 1")
+	assert.NotContains(t, string(files["staging/shop/deployments/web.yaml"]), "resourceVersion")
+	assert.Len(t, files, 4)
+
+	out, errText = callTool(t, server, "dump_namespace", map[string]interface{}{"namespace": "shop", "clusters": []string{"prod"}, "kinds": []string{"Secret"}, "secret_mode": "encrypt"})
+	require.Empty(t, errText)
+	assert.Nil(t, out["differences"])
+	files = readDumpArchive(t, out["archive"].(string))
+	secret := files["prod/shop/secrets/db.yaml.enc"]
+	require.NotEmpty(t, secret)
+	assert.Equal(t, "Salted__", string(secret[:8]))
+	assert.False(t, strings.Contains(string(secret), "c2VjcmV0"))
+
+	t.Setenv(EnvDumpPassphrase, "")
+	_, errText = callTool(t, server, "dump_namespace", map[string]interface{}{"namespace": "shop", "secret_mode": "encrypt"})
+	assert.Contains(t, errText, EnvDumpPassphrase)
+	_, errText = callTool(t, server, "dump_namespace", map[string]interface{}{"namespace": "shop", "kinds": []string{"Pod"}})
+	assert.Contains(t, errText, `unsupported kind "Pod"`)
+}
@@ -0,0 +1,394 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// ExportedResources are live objects exported as manifests from each
+// cluster.
+type ExportedResources struct {
+	Kind     string          `json:"kind"`
+	Clusters []ClusterExport `json:"clusters"`
+	Issues   []string        `json:"issues,omitempty"`
+}
+
+// ClusterExport is the manifests exported from one cluster.
+type ClusterExport struct {
+	Cluster string `json:"cluster"`
+	Objects int    `json:"objects"`
+	// YAML is the exported objects as a multi-document YAML stream, in
+	// name order.
+	YAML    string          `json:"yaml"`
+	Skipped []MigrationStep `json:"skipped,omitempty"`
+}
+
+// exportDefault is a field the API server sets to a default value when a
+// manifest leaves it out, so an export leaves it out too.
+type exportDefault struct {
+	path  []string
+	value interface{}
+}
+
+// exportDefaults are the defaulted fields of each kind, outside its pod
+// template.
+var exportDefaults = map[string][]exportDefault{
+	"Deployment": {
+		{[]string{"spec", "progressDeadlineSeconds"}, int64(600)},
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+		{[]string{"spec", "strategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+		}},
+	},
+	"StatefulSet": {
+		{[]string{"spec", "podManagementPolicy"}, "OrderedReady"},
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+		{[]string{"spec", "updateStrategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"partition": int64(0)},
+		}},
+		{[]string{"spec", "persistentVolumeClaimRetentionPolicy"}, map[string]interface{}{"whenDeleted": "Retain", "whenScaled": "Retain"}},
+	},
+	"DaemonSet": {
+		{[]string{"spec", "revisionHistoryLimit"}, int64(10)},
+		{[]string{"spec", "updateStrategy"}, map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": int64(0), "maxUnavailable": int64(1)},
+		}},
+	},
+	"Job": {
+		{[]string{"spec", "backoffLimit"}, int64(6)},
+		{[]string{"spec", "completionMode"}, "NonIndexed"},
+		{[]string{"spec", "completions"}, int64(1)},
+		{[]string{"spec", "parallelism"}, int64(1)},
+		{[]string{"spec", "suspend"}, false},
+		{[]string{"spec", "podReplacementPolicy"}, "TerminatingOrFailed"},
+	},
+	"CronJob": {
+		{[]string{"spec", "concurrencyPolicy"}, "Allow"},
+		{[]string{"spec", "failedJobsHistoryLimit"}, int64(1)},
+		{[]string{"spec", "successfulJobsHistoryLimit"}, int64(3)},
+		{[]string{"spec", "suspend"}, false},
+	},
+	"Service": {
+		{[]string{"spec", "type"}, "ClusterIP"},
+		{[]string{"spec", "sessionAffinity"}, "None"},
+		{[]string{"spec", "internalTrafficPolicy"}, "Cluster"},
+		{[]string{"spec", "ipFamilyPolicy"}, "SingleStack"},
+	},
+	"PersistentVolumeClaim": {
+		{[]string{"spec", "volumeMode"}, "Filesystem"},
+	},
+	"Namespace": {
+		{[]string{"spec", "finalizers"}, []interface{}{"kubernetes"}},
+	},
+}
+
+// podSpecDefaults and containerDefaults are the defaulted fields of pod
+// specs and their containers.
+var (
+	podSpecDefaults = []exportDefault{
+		{[]string{"restartPolicy"}, "Always"},
+		{[]string{"dnsPolicy"}, "ClusterFirst"},
+		{[]string{"schedulerName"}, "default-scheduler"},
+		{[]string{"terminationGracePeriodSeconds"}, int64(30)},
+		{[]string{"securityContext"}, map[string]interface{}{}},
+	}
+	containerDefaults = []exportDefault{
+		{[]string{"terminationMessagePath"}, "/dev/termination-log"},
+		{[]string{"terminationMessagePolicy"}, "File"},
+		{[]string{"resources"}, map[string]interface{}{}},
+	}
+)
+
+// jobControllerLabels are the labels the Job controller adds to a Job and
+// its pod template, along with a selector on them.
+var jobControllerLabels = []string{"controller-uid", "batch.kubernetes.io/controller-uid", "job-name", "batch.kubernetes.io/job-name"}
+
+// handleExportResource exports live objects as manifests fit to commit to
+// a GitOps repository: the reverse of sync_from_git.
+func (s *Server) handleExportResource(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Kind          string   `json:"kind"`
+		APIVersion    string   `json:"api_version"`
+		Name          string   `json:"name"`
+		Namespace     string   `json:"namespace"`
+		LabelSelector string   `json:"label_selector"`
+		Clusters      []string `json:"clusters"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Kind == "" {
+		return nil, fmt.Errorf("kind is required")
+	}
+	if strings.EqualFold(params.Kind, "secret") || strings.EqualFold(params.Kind, "secrets") {
+		return nil, fmt.Errorf("Secrets are not exported, since their data should not be committed to git; use a sealed or external secret instead")
+	}
+	if params.Name != "" && params.LabelSelector != "" {
+		return nil, fmt.Errorf("name and label_selector cannot be combined")
+	}
+	if err := validateOptionalNamespace(params.Namespace); err != nil {
+		return nil, err
+	}
+
+	targetClusters := params.Clusters
+	if len(targetClusters) == 0 {
+		clusters, err := s.manager.DiscoverClusters()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range clusters {
+			targetClusters = append(targetClusters, c.Name)
+		}
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.exportFromCluster(ctx, clusterName, params.APIVersion, params.Kind, params.Name, params.Namespace, params.LabelSelector)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortClusterResults(results)
+
+	out := ExportedResources{Kind: params.Kind, Clusters: []ClusterExport{}}
+	for _, result := range results {
+		if result.Error != "" {
+			out.Issues = append(out.Issues, fmt.Sprintf("%s: %s", result.Cluster, result.Error))
+			continue
+		}
+		out.Clusters = append(out.Clusters, result.Result.(ClusterExport))
+	}
+	return out, nil
+}
+
+// exportFromCluster exports the named object, or the objects matching
+// selector, from one cluster.
+func (s *Server) exportFromCluster(ctx context.Context, clusterName, apiVersion, kind, name, namespace, selector string) (ClusterExport, error) {
+	export := ClusterExport{Cluster: clusterName}
+	config, err := s.manager.GetConfig(clusterName)
+	if err != nil {
+		return export, fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
+	}
+	dyn, err := s.dynamicClient(clusterName)
+	if err != nil {
+		return export, err
+	}
+
+	var (
+		mapping kubemanifest.Mapping
+		gvk     schema.GroupVersionKind
+	)
+	if apiVersion != "" {
+		gvk = schema.FromAPIVersionAndKind(apiVersion, kind)
+		mapping, err = kubemanifest.LookupResource(config, apiVersion, kind)
+		if err == nil && mapping.Guessed && kubemanifest.NewRESTMapper(config) != nil {
+			err = kubemanifest.UnknownKindError(apiVersion, kind)
+		}
+	} else {
+		mapping, gvk, err = kubemanifest.LookupKind(config, kind)
+	}
+	if err != nil {
+		return export, err
+	}
+	if mapping.GVR.Resource == "secrets" {
+		return export, fmt.Errorf("Secrets are not exported, since their data should not be committed to git")
+	}
+
+	resource := dyn.Resource(mapping.GVR)
+	var objects []unstructured.Unstructured
+	if name != "" {
+		var obj *unstructured.Unstructured
+		if mapping.ClusterScoped {
+			obj, err = resource.Get(ctx, name, metav1.GetOptions{})
+		} else {
+			ns := namespace
+			if ns == "" {
+				ns = "default"
+			}
+			obj, err = resource.Namespace(ns).Get(ctx, name, metav1.GetOptions{})
+		}
+		if err != nil {
+			return export, err
+		}
+		objects = append(objects, *obj)
+	} else {
+		var list *unstructured.UnstructuredList
+		opts := metav1.ListOptions{LabelSelector: selector}
+		if mapping.ClusterScoped || namespace == "" {
+			list, err = resource.List(ctx, opts)
+		} else {
+			list, err = resource.Namespace(namespace).List(ctx, opts)
+		}
+		if err != nil {
+			return export, err
+		}
+		for _, obj := range list.Items {
+			// Listing all namespaces would export the cluster's own objects.
+			if obj.GetNamespace() != "" && namespace == "" && validateOptionalNamespace(obj.GetNamespace()) != nil {
+				continue
+			}
+			if reason := cloneSkipReason(&obj, secretModeSkip); reason != "" {
+				export.Skipped = append(export.Skipped, MigrationStep{Step: "export", Cluster: clusterName,
+					Resource: obj.GetKind() + " " + objectPath(&obj), Status: "skipped", Message: reason})
+				continue
+			}
+			objects = append(objects, obj)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objectPath(&objects[i]) < objectPath(&objects[j]) })
+	for i := range objects {
+		objects[i].SetGroupVersionKind(gvk)
+	}
+
+	docs := make([]string, 0, len(objects))
+	for i := range objects {
+		data, err := yaml.Marshal(exportObject(&objects[i]).Object)
+		if err != nil {
+			return export, fmt.Errorf("failed to encode %s: %w", objectPath(&objects[i]), err)
+		}
+		docs = append(docs, string(data))
+	}
+	export.Objects = len(docs)
+	export.YAML = strings.Join(docs, "---\n")
+	return export, nil
+}
+
+// objectPath is namespace/name, or name for cluster-scoped objects.
+func objectPath(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// exportObject returns a copy of obj as it would be written by hand: the
+// fields the cluster assigned and the defaults it filled in are removed.
+func exportObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	c := portableCopy(obj)
+	kind := c.GetKind()
+	removeDefaults(c.Object, exportDefaults[kind])
+
+	switch kind {
+	case "Service":
+		unstructured.RemoveNestedField(c.Object, "spec", "ipFamilies")
+		ports, _, _ := unstructured.NestedSlice(c.Object, "spec", "ports")
+		for _, p := range ports {
+			if m, ok := p.(map[string]interface{}); ok {
+				removeDefaults(m, []exportDefault{{[]string{"protocol"}, "TCP"}})
+				if reflect.DeepEqual(m["targetPort"], m["port"]) {
+					delete(m, "targetPort")
+				}
+			}
+		}
+		if len(ports) > 0 {
+			_ = unstructured.SetNestedSlice(c.Object, ports, "spec", "ports")
+		}
+	case "Job":
+		if labels := c.GetLabels(); labels["batch.kubernetes.io/controller-uid"] != "" || labels["controller-uid"] != "" {
+			unstructured.RemoveNestedField(c.Object, "spec", "selector")
+			removeLabels(c.Object, []string{"metadata"})
+			removeLabels(c.Object, []string{"spec", "template", "metadata"})
+		}
+	case "Pod":
+		unstructured.RemoveNestedField(c.Object, "spec", "nodeName")
+	}
+	if path, ok := podTemplatePaths[kind]; ok {
+		spec := append([]string{"spec"}, path...)
+		exportPodSpec(c.Object, spec)
+		if kind != "Pod" {
+			metadata := append(spec[:len(spec)-1:len(spec)-1], "metadata")
+			unstructured.RemoveNestedField(c.Object, append(metadata, "creationTimestamp")...)
+			// restart_app and kubectl rollout restart set this to roll
+			// the pods; it is not part of the desired state.
+			unstructured.RemoveNestedField(c.Object, append(metadata, "annotations", restartedAtAnnotation)...)
+			pruneEmpty(c.Object, append(metadata, "annotations"))
+			pruneEmpty(c.Object, metadata)
+		}
+	}
+	return c
+}
+
+// exportPodSpec removes the defaults of the pod spec at path in obj.
+func exportPodSpec(obj map[string]interface{}, path []string) {
+	spec, found, _ := unstructured.NestedMap(obj, path...)
+	if !found {
+		return
+	}
+	removeDefaults(spec, podSpecDefaults)
+	if spec["serviceAccount"] == spec["serviceAccountName"] {
+		// serviceAccount is the deprecated copy of serviceAccountName.
+		delete(spec, "serviceAccount")
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _ := spec[field].([]interface{})
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			removeDefaults(container, containerDefaults)
+			if image, _ := container["image"].(string); container["imagePullPolicy"] == defaultPullPolicy(image) {
+				delete(container, "imagePullPolicy")
+			}
+			ports, _ := container["ports"].([]interface{})
+			for _, p := range ports {
+				if port, ok := p.(map[string]interface{}); ok {
+					removeDefaults(port, []exportDefault{{[]string{"protocol"}, "TCP"}})
+				}
+			}
+		}
+	}
+	_ = unstructured.SetNestedMap(obj, spec, path...)
+}
+
+// defaultPullPolicy is the imagePullPolicy the API server sets for image:
+// Always for the latest tag or no tag, and IfNotPresent otherwise.
+func defaultPullPolicy(image string) string {
+	if strings.Contains(image, "@") {
+		return "IfNotPresent"
+	}
+	name := image[strings.LastIndex(image, "/")+1:]
+	if _, tag, ok := strings.Cut(name, ":"); !ok || tag == "latest" {
+		return "Always"
+	}
+	return "IfNotPresent"
+}
+
+// removeDefaults removes each field of obj that has its default value.
+func removeDefaults(obj map[string]interface{}, defaults []exportDefault) {
+	for _, d := range defaults {
+		if value, found, _ := unstructured.NestedFieldNoCopy(obj, d.path...); found && reflect.DeepEqual(value, d.value) {
+			unstructured.RemoveNestedField(obj, d.path...)
+		}
+	}
+}
+
+// removeLabels removes the Job controller's labels from the metadata at
+// path in obj.
+func removeLabels(obj map[string]interface{}, path []string) {
+	labels := append(append([]string{}, path...), "labels")
+	for _, label := range jobControllerLabels {
+		unstructured.RemoveNestedField(obj, append(append([]string{}, labels...), label)...)
+	}
+	pruneEmpty(obj, labels)
+}
+
+// pruneEmpty removes the map at path in obj if it is empty.
+func pruneEmpty(obj map[string]interface{}, path []string) {
+	if m, found, _ := unstructured.NestedMap(obj, path...); found && len(m) == 0 {
+		unstructured.RemoveNestedField(obj, path...)
+	}
+}
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// liveDeployment is a Deployment as the API server returns it.
+func liveDeployment(name string) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"metadata": map[string]interface{}{
+			"name": name, "namespace": "shop", "uid": "3f1c", "resourceVersion": "4711", "generation": int64(3),
+			"creationTimestamp": "2026-10-01T09:00:00Z",
+			"labels":            map[string]interface{}{"app": name},
+			"annotations": map[string]interface{}{
+				"deployment.kubernetes.io/revision":                "3",
+				"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				"team": "checkout",
+			},
+			"managedFields": []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"spec": map[string]interface{}{
+			"replicas":                int64(3),
+			"progressDeadlineSeconds": int64(600),
+			"revisionHistoryLimit":    int64(5),
+			"selector":                map[string]interface{}{"matchLabels": map[string]interface{}{"app": name}},
+			"strategy": map[string]interface{}{
+				"type":          "RollingUpdate",
+				"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"creationTimestamp": nil,
+					"labels":            map[string]interface{}{"app": name},
+					"annotations":       map[string]interface{}{restartedAtAnnotation: "2026-10-02T10:00:00Z"},
+				},
+				"spec": map[string]interface{}{
+					"restartPolicy": "Always", "dnsPolicy": "ClusterFirst", "schedulerName": "default-scheduler",
+					"terminationGracePeriodSeconds": int64(30), "securityContext": map[string]interface{}{},
+					"serviceAccount": "web", "serviceAccountName": "web",
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name": "web", "image": "registry.example.com/web:1.4", "imagePullPolicy": "IfNotPresent",
+							"terminationMessagePath": "/dev/termination-log", "terminationMessagePolicy": "File",
+							"resources": map[string]interface{}{},
+							"ports":     []interface{}{map[string]interface{}{"containerPort": int64(8080), "protocol": "TCP"}},
+						},
+						map[string]interface{}{"name": "sidecar", "image": "proxy", "imagePullPolicy": "IfNotPresent"},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": int64(3), "readyReplicas": int64(3)},
+	}
+}
+
+func TestExportObject(t *testing.T) {
+	got := exportObject(&unstructured.Unstructured{Object: liveDeployment("web")})
+	data, err := yaml.Marshal(got.Object)
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  annotations:
+    team: checkout
+  labels:
+    app: web
+  name: web
+  namespace: shop
+spec:
+  replicas: 3
+  revisionHistoryLimit: 5
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - image: registry.example.com/web:1.4
+        name: web
+        ports:
+        - containerPort: 8080
+      - image: proxy
+        imagePullPolicy: IfNotPresent
+        name: sidecar
+      serviceAccountName: web
+`, string(data), "non-default values, like an untagged image pulled IfNotPresent, are kept")
+
+	service := exportObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1", "kind": "Service",
+		"metadata": map[string]interface{}{"name": "web", "namespace": "shop"},
+		"spec": map[string]interface{}{
+			"type": "ClusterIP", "clusterIP": "10.96.4.2", "clusterIPs": []interface{}{"10.96.4.2"},
+			"ipFamilies": []interface{}{"IPv4"}, "ipFamilyPolicy": "SingleStack", "sessionAffinity": "None", "internalTrafficPolicy": "Cluster",
+			"selector": map[string]interface{}{"app": "web"},
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "port": int64(80), "targetPort": int64(8080), "protocol": "TCP"},
+				map[string]interface{}{"name": "metrics", "port": int64(9090), "targetPort": int64(9090), "protocol": "UDP"},
+			},
+		},
+	}})
+	assert.Equal(t, map[string]interface{}{
+		"selector": map[string]interface{}{"app": "web"},
+		"ports": []interface{}{
+			map[string]interface{}{"name": "http", "port": int64(80), "targetPort": int64(8080)},
+			map[string]interface{}{"name": "metrics", "port": int64(9090), "protocol": "UDP"},
+		},
+	}, service.Object["spec"])
+
+	job := exportObject(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1", "kind": "Job",
+		"metadata": map[string]interface{}{"name": "migrate", "namespace": "shop", "labels": map[string]interface{}{
+			"batch.kubernetes.io/controller-uid": "9a2e", "batch.kubernetes.io/job-name": "migrate", "controller-uid": "9a2e", "job-name": "migrate",
+		}},
+		"spec": map[string]interface{}{
+			"backoffLimit": int64(6), "completions": int64(1), "parallelism": int64(1), "completionMode": "NonIndexed", "suspend": false,
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"batch.kubernetes.io/controller-uid": "9a2e"}},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"labels": map[string]interface{}{
+					"batch.kubernetes.io/controller-uid": "9a2e", "batch.kubernetes.io/job-name": "migrate", "controller-uid": "9a2e", "job-name": "migrate",
+				}},
+				"spec": map[string]interface{}{"restartPolicy": "Never", "containers": []interface{}{map[string]interface{}{"name": "migrate", "image": "migrate:2"}}},
+			},
+		},
+	}})
+	assert.Equal(t, map[string]interface{}{"name": "migrate", "namespace": "shop"}, job.Object["metadata"])
+	assert.Equal(t, map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+		"restartPolicy": "Never", "containers": []interface{}{map[string]interface{}{"name": "migrate", "image": "migrate:2"}},
+	}}}, job.Object["spec"], "the generated selector and labels are removed")
+}
+
+func TestDefaultPullPolicy(t *testing.T) {
+	for image, want := range map[string]string{
+		"nginx":                         "Always",
+		"nginx:latest":                  "Always",
+		"localhost:5000/nginx":          "Always",
+		"localhost:5000/nginx:1.27":     "IfNotPresent",
+		"nginx@sha256:0123456789abcdef": "IfNotPresent",
+	} {
+		assert.Equal(t, want, defaultPullPolicy(image), image)
+	}
+}
+
+func TestExportResource(t *testing.T) {
+	api, url := newObjectAPIServer(t, false)
+	api.put(t, "/apis/apps/v1/namespaces/shop/deployments/web", liveDeployment("web"))
+	api.put(t, "/apis/apps/v1/namespaces/shop/deployments/api", liveDeployment("api"))
+	owned := liveDeployment("operated")
+	owned["metadata"].(map[string]interface{})["ownerReferences"] = []interface{}{map[string]interface{}{"apiVersion": "example.com/v1", "kind": "Shop", "name": "main", "uid": "1"}}
+	api.put(t, "/apis/apps/v1/namespaces/shop/deployments/operated", owned)
+	server := newAtomicTestServer(t, map[string]string{"prod": url})
+
+	out, errText := callTool(t, server, "export_resource", map[string]interface{}{"kind": "Deployment", "namespace": "shop", "clusters": []string{"prod"}})
+	require.Empty(t, errText)
+	clusters := out["clusters"].([]interface{})
+	require.Len(t, clusters, 1)
+	export := clusters[0].(map[string]interface{})
+	assert.EqualValues(t, 2, export["objects"])
+	text := export["yaml"].(string)
+	assert.Contains(t, text, "  name: api\n")
+	assert.Contains(t, text, "---\napiVersion: apps/v1\nkind: Deployment\n")
+	assert.NotContains(t, text, "status:")
+	assert.NotContains(t, text, "resourceVersion")
+	skipped := export["skipped"].([]interface{})
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "managed by Shop main", skipped[0].(map[string]interface{})["message"])
+
+	out, errText = callTool(t, server, "export_resource", map[string]interface{}{"kind": "Deployment", "name": "web", "namespace": "shop", "clusters": []string{"prod"}})
+	require.Empty(t, errText)
+	export = out["clusters"].([]interface{})[0].(map[string]interface{})
+	assert.EqualValues(t, 1, export["objects"])
+	assert.Contains(t, export["yaml"], "  name: web\n")
+
+	_, errText = callTool(t, server, "export_resource", map[string]interface{}{"kind": "Secret", "namespace": "shop"})
+	assert.Contains(t, errText, "Secrets are not exported")
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 
 	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
@@ -46,43 +47,93 @@ func runGitOpsClusterTasks(clusterNames []string, fn func(string)) {
 	wg.Wait()
 }
 
-// handleDetectDrift detects drift between git and clusters
-func (s *Server) handleDetectDrift(ctx context.Context, args json.RawMessage) (interface{}, error) {
-	var params struct {
-		Repo     string   `json:"repo"`
-		Path     string   `json:"path"`
-		Branch   string   `json:"branch"`
-		Clusters []string `json:"clusters"`
-	}
-	if err := json.Unmarshal(args, &params); err != nil {
-		return nil, fmt.Errorf("invalid arguments: %w", err)
-	}
+// manifestSourceParams are the arguments that say where the GitOps tools
+// read manifests from: a git repository, a local path, or an uploaded
+// archive.
+type manifestSourceParams struct {
+	Repo      string `json:"repo"`
+	Path      string `json:"path"`
+	Branch    string `json:"branch"`
+	LocalPath string `json:"local_path"`
+	Archive   string `json:"archive"`
+}
 
-	if params.Repo == "" {
-		return nil, fmt.Errorf("repo is required")
+func (p manifestSourceParams) source() (gitops.ManifestSource, error) {
+	if p.Repo == "" && p.LocalPath == "" && p.Archive == "" {
+		return gitops.ManifestSource{}, fmt.Errorf("one of repo, local_path, or archive is required")
+	}
+	archive, err := gitops.DecodeArchive(p.Archive)
+	if err != nil {
+		return gitops.ManifestSource{}, err
 	}
+	return gitops.ManifestSource{
+		Repo:      p.Repo,
+		Path:      p.Path,
+		Branch:    p.Branch,
+		LocalPath: p.LocalPath,
+		Archive:   archive,
+	}, nil
+}
 
-	source := gitops.ManifestSource{
-		Repo:   params.Repo,
-		Path:   params.Path,
-		Branch: params.Branch,
+// args returns p as tool arguments, for tools that call another.
+func (p manifestSourceParams) args() map[string]interface{} {
+	return map[string]interface{}{
+		"repo":       p.Repo,
+		"path":       p.Path,
+		"branch":     p.Branch,
+		"local_path": p.LocalPath,
+		"archive":    p.Archive,
 	}
+}
 
-	// Read manifests from git
+// readManifests reads the manifests of source, or returns the result to
+// give when there are none.
+func (s *Server) readManifests(ctx context.Context, source gitops.ManifestSource) ([]gitops.Manifest, interface{}, error) {
 	reader := s.getManifestReader()
 	defer reader.Cleanup()
 
-	manifests, err := reader.ReadFromGit(ctx, source)
+	manifests, err := reader.Read(ctx, source)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read manifests from git: %w", err)
+		if source.Repo != "" {
+			return nil, nil, fmt.Errorf("failed to read manifests from git: %w", err)
+		}
+		return nil, nil, fmt.Errorf("failed to read manifests from %s: %w", source.Location(), err)
 	}
-
 	if len(manifests) == 0 {
-		return map[string]interface{}{
-			"message": "No manifests found in repository",
+		where := "repository"
+		switch {
+		case source.LocalPath != "":
+			where = "local path"
+		case len(source.Archive) > 0:
+			where = "archive"
+		}
+		return nil, map[string]interface{}{
+			"message": "No manifests found in " + where,
 			"source":  source,
 		}, nil
 	}
+	return manifests, nil, nil
+}
+
+// handleDetectDrift detects drift between git and clusters
+func (s *Server) handleDetectDrift(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		manifestSourceParams
+		Clusters []string `json:"clusters"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	source, err := params.source()
+	if err != nil {
+		return nil, err
+	}
+
+	manifests, empty, err := s.readManifests(ctx, source)
+	if err != nil || empty != nil {
+		return empty, err
+	}
 
 	// Get target clusters
 	targetClusters := params.Clusters
@@ -156,21 +207,25 @@ func (s *Server) handleDetectDrift(ctx context.Context, args json.RawMessage) (i
 // handleSyncFromGit syncs manifests from git to clusters
 func (s *Server) handleSyncFromGit(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	var params struct {
-		Repo      string   `json:"repo"`
-		Path      string   `json:"path"`
-		Branch    string   `json:"branch"`
+		manifestSourceParams
 		Clusters  []string `json:"clusters"`
 		DryRun    bool     `json:"dry_run"`
 		Namespace string   `json:"namespace"`
 		Include   []string `json:"include"`
 		Exclude   []string `json:"exclude"`
+		// Template, Variables, and ClusterVariables substitute variables
+		// in the manifests for each cluster, as in deploy_app.
+		Template         bool                         `json:"template"`
+		Variables        map[string]string            `json:"variables"`
+		ClusterVariables map[string]map[string]string `json:"cluster_variables"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	if params.Repo == "" {
-		return nil, fmt.Errorf("repo is required")
+	source, err := params.source()
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate namespace override to prevent access to system namespaces (#377).
@@ -180,26 +235,9 @@ func (s *Server) handleSyncFromGit(ctx context.Context, args json.RawMessage) (i
 		}
 	}
 
-	source := gitops.ManifestSource{
-		Repo:   params.Repo,
-		Path:   params.Path,
-		Branch: params.Branch,
-	}
-
-	// Read manifests from git
-	reader := s.getManifestReader()
-	defer reader.Cleanup()
-
-	manifests, err := reader.ReadFromGit(ctx, source)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read manifests from git: %w", err)
-	}
-
-	if len(manifests) == 0 {
-		return map[string]interface{}{
-			"message": "No manifests found in repository",
-			"source":  source,
-		}, nil
+	manifests, empty, err := s.readManifests(ctx, source)
+	if err != nil || empty != nil {
+		return empty, err
 	}
 
 	// Get target clusters
@@ -214,6 +252,16 @@ func (s *Server) handleSyncFromGit(ctx context.Context, args json.RawMessage) (i
 		}
 	}
 
+	vars, err := newManifestVariables(params.Template, params.Variables, params.ClusterVariables, targetClusters)
+	if err != nil {
+		return nil, err
+	}
+	if vars != nil {
+		if err := vars.validate(manifests, targetClusters); err != nil {
+			return nil, err
+		}
+	}
+
 	// Sync to each cluster
 	result := &GitOpsSyncResult{
 		Source: source,
@@ -263,7 +311,29 @@ func (s *Server) handleSyncFromGit(ctx context.Context, args json.RawMessage) (i
 			return
 		}
 
-		summary, err := syncer.Sync(ctx, manifests, cluster, opts)
+		clusterManifests := manifests
+		if vars != nil {
+			client, err := kubernetes.NewForConfig(config)
+			if err == nil {
+				clusterManifests, err = vars.render(ctx, client, cluster, manifests)
+			}
+			if err != nil {
+				mu.Lock()
+				summaries = append(summaries, gitops.SyncSummary{
+					Cluster: cluster,
+					Failed:  1,
+					Results: []gitops.SyncResult{{
+						Cluster: cluster,
+						Action:  gitops.SyncActionFailed,
+						Message: fmt.Sprintf("Failed to render manifests: %v", err),
+					}},
+				})
+				mu.Unlock()
+				return
+			}
+		}
+
+		summary, err := syncer.Sync(ctx, clusterManifests, cluster, opts)
 		if err != nil {
 			mu.Lock()
 			summaries = append(summaries, gitops.SyncSummary{
@@ -290,55 +360,172 @@ func (s *Server) handleSyncFromGit(ctx context.Context, args json.RawMessage) (i
 
 // handleReconcile brings clusters back in sync with git
 func (s *Server) handleReconcile(ctx context.Context, args json.RawMessage) (interface{}, error) {
-	// Reconcile is just sync without dry_run
+	// Reconcile is just sync without dry_run, unless the approval gate
+	// turned the call into a dry run.
 	var params struct {
-		Repo      string   `json:"repo"`
-		Path      string   `json:"path"`
-		Branch    string   `json:"branch"`
+		manifestSourceParams
 		Clusters  []string `json:"clusters"`
 		Namespace string   `json:"namespace"`
+		DryRun    bool     `json:"dry_run"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
 	// Build sync args
-	syncArgs, _ := json.Marshal(map[string]interface{}{
-		"repo":      params.Repo,
-		"path":      params.Path,
-		"branch":    params.Branch,
-		"clusters":  params.Clusters,
-		"namespace": params.Namespace,
-		"dry_run":   false,
-	})
+	syncParams := params.args()
+	syncParams["clusters"] = params.Clusters
+	syncParams["namespace"] = params.Namespace
+	syncParams["dry_run"] = params.DryRun
+	syncArgs, _ := json.Marshal(syncParams)
 
 	return s.handleSyncFromGit(ctx, syncArgs)
 }
 
 // handlePreviewChanges shows what would change without applying
 func (s *Server) handlePreviewChanges(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		manifestSourceParams
+		Clusters  []string `json:"clusters"`
+		Namespace string   `json:"namespace"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	// Build sync args with dry_run=true
+	syncParams := params.args()
+	syncParams["clusters"] = params.Clusters
+	syncParams["namespace"] = params.Namespace
+	syncParams["dry_run"] = true
+	syncArgs, _ := json.Marshal(syncParams)
+
+	return s.handleSyncFromGit(ctx, syncArgs)
+}
+
+// GitRefComparison is what would change on each cluster if the head ref
+// of a repository were merged into the base ref and synced.
+type GitRefComparison struct {
+	Repo string `json:"repo"`
+	Path string `json:"path,omitempty"`
+	Base string `json:"base"`
+	Head string `json:"head"`
+	// Changes are the resources whose manifests differ between the refs.
+	Changes []gitops.ManifestChange `json:"changes"`
+	// Clusters are dry runs of the added and modified manifests of the
+	// head ref on each cluster.
+	Clusters []gitops.SyncSummary `json:"clusters"`
+	Note     string               `json:"note,omitempty"`
+}
+
+// handleCompareGitRefs previews the impact of merging one git ref into
+// another on each cluster.
+func (s *Server) handleCompareGitRefs(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	var params struct {
 		Repo      string   `json:"repo"`
 		Path      string   `json:"path"`
-		Branch    string   `json:"branch"`
+		Base      string   `json:"base"`
+		Head      string   `json:"head"`
 		Clusters  []string `json:"clusters"`
 		Namespace string   `json:"namespace"`
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
+	if params.Repo == "" {
+		return nil, fmt.Errorf("repo is required")
+	}
+	if params.Head == "" {
+		return nil, fmt.Errorf("head is required")
+	}
+	if params.Base == "" {
+		params.Base = "main"
+	}
+	if params.Base == params.Head {
+		return nil, fmt.Errorf("base and head are both %s", params.Base)
+	}
+	if params.Namespace != "" {
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
 
-	// Build sync args with dry_run=true
-	syncArgs, _ := json.Marshal(map[string]interface{}{
-		"repo":      params.Repo,
-		"path":      params.Path,
-		"branch":    params.Branch,
-		"clusters":  params.Clusters,
-		"namespace": params.Namespace,
-		"dry_run":   true,
+	refs := make(map[string][]gitops.Manifest, 2)
+	for _, ref := range []string{params.Base, params.Head} {
+		reader := s.getManifestReader()
+		manifests, err := reader.Read(ctx, gitops.ManifestSource{Repo: params.Repo, Path: params.Path, Branch: ref})
+		reader.Cleanup()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read manifests at %s: %w", ref, err)
+		}
+		refs[ref] = manifests
+	}
+
+	result := &GitRefComparison{
+		Repo:     params.Repo,
+		Path:     params.Path,
+		Base:     params.Base,
+		Head:     params.Head,
+		Changes:  gitops.DiffManifests(refs[params.Base], refs[params.Head]),
+		Clusters: []gitops.SyncSummary{},
+	}
+	var changed []gitops.Manifest
+	for _, change := range result.Changes {
+		if change.Head != nil {
+			changed = append(changed, *change.Head)
+		} else {
+			result.Note = "Resources removed at the head ref are not deleted from clusters by sync_from_git or reconcile; delete them with delete_resource."
+		}
+	}
+	if len(changed) == 0 {
+		return result, nil
+	}
+
+	targetClusters := params.Clusters
+	if len(targetClusters) == 0 {
+		clusters, err := s.manager.DiscoverClusters()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range clusters {
+			targetClusters = append(targetClusters, c.Name)
+		}
+	}
+
+	opts := gitops.SyncOptions{DryRun: true, Namespace: params.Namespace}
+	var mu sync.Mutex
+	runGitOpsClusterTasks(targetClusters, func(cluster string) {
+		summary, err := s.previewManifests(ctx, cluster, changed, opts)
+		if err != nil {
+			summary = &gitops.SyncSummary{
+				Cluster: cluster,
+				Failed:  1,
+				Results: []gitops.SyncResult{{Cluster: cluster, Action: gitops.SyncActionFailed, Message: err.Error()}},
+			}
+		}
+		mu.Lock()
+		result.Clusters = append(result.Clusters, *summary)
+		mu.Unlock()
 	})
+	sort.Slice(result.Clusters, func(i, j int) bool { return result.Clusters[i].Cluster < result.Clusters[j].Cluster })
+	return result, nil
+}
 
-	return s.handleSyncFromGit(ctx, syncArgs)
+// previewManifests dry-runs manifests on cluster.
+func (s *Server) previewManifests(ctx context.Context, cluster string, manifests []gitops.Manifest, opts gitops.SyncOptions) (*gitops.SyncSummary, error) {
+	config, err := s.manager.GetConfig(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	syncer, err := s.getManifestSyncer(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create syncer: %w", err)
+	}
+	summary, err := syncer.Sync(ctx, manifests, cluster, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sync: %w", err)
+	}
+	return summary, nil
 }
 
 // Unused but kept for interface compatibility
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
 )
 
 func TestHandleDetectDriftValidatesArguments(t *testing.T) {
@@ -24,7 +26,7 @@ func TestHandleDetectDriftValidatesArguments(t *testing.T) {
 		wantErr string
 	}{
 		{name: "invalid json", args: []byte(`{invalid`), wantErr: "invalid arguments"},
-		{name: "missing repo", args: []byte(`{}`), wantErr: "repo is required"},
+		{name: "missing source", args: []byte(`{}`), wantErr: "one of repo, local_path, or archive is required"},
 	}
 
 	for _, tt := range tests {
@@ -79,7 +81,7 @@ func TestHandleSyncFromGitValidatesArguments(t *testing.T) {
 		wantErr string
 	}{
 		{name: "invalid json", args: []byte(`{invalid`), wantErr: "invalid arguments"},
-		{name: "missing repo", args: []byte(`{}`), wantErr: "repo is required"},
+		{name: "missing source", args: []byte(`{}`), wantErr: "one of repo, local_path, or archive is required"},
 	}
 
 	for _, tt := range tests {
@@ -263,3 +265,113 @@ func createGitRepo(t *testing.T, files map[string]string) string {
 
 	return "file://" + absDir
 }
+
+func TestHandlePreviewChangesReadsLocalPathAndArchive(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "manifests"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifests", "app.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: demo\n"), 0o644))
+	server := newHelmTestServer(t, map[string]string{})
+	server.newManifestReader = func() *gitops.ManifestReader {
+		return &gitops.ManifestReader{LocalRoots: []string{dir}}
+	}
+
+	got, err := server.handlePreviewChanges(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"local_path": dir,
+		"path":       "manifests",
+		"clusters":   []string{"missing"},
+	}))
+	require.NoError(t, err)
+	result := got.(*GitOpsSyncResult)
+	assert.Equal(t, dir, result.Source.LocalPath)
+	require.Len(t, result.Summaries, 1)
+	assert.Contains(t, result.Summaries[0].Results[0].Message, "Failed to get config", "the manifests were read")
+
+	// An empty gzip stream is a valid archive with no manifests.
+	got, err = server.handleDetectDrift(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"archive": "H4sIAAAAAAAA/wMAAAAAAAAAAAA=",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "No manifests found in archive", got.(map[string]interface{})["message"])
+
+	_, err = server.handleSyncFromGit(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"local_path": t.TempDir(),
+	}))
+	assert.ErrorContains(t, err, "outside the manifest roots")
+}
+
+// dryRunSyncer reports each manifest as created on clusters without it in
+// existing, and updated on the others.
+type dryRunSyncer struct {
+	existing map[string]bool
+	dryRun   []bool
+}
+
+func (s *dryRunSyncer) Sync(_ context.Context, manifests []gitops.Manifest, clusterName string, opts gitops.SyncOptions) (*gitops.SyncSummary, error) {
+	s.dryRun = append(s.dryRun, opts.DryRun)
+	summary := &gitops.SyncSummary{Cluster: clusterName}
+	for _, m := range manifests {
+		action := gitops.SyncActionCreated
+		if s.existing[clusterName+"/"+m.Metadata.Name] {
+			action = gitops.SyncActionUpdated
+			summary.Updated++
+		} else {
+			summary.Created++
+		}
+		summary.Results = append(summary.Results, gitops.SyncResult{Cluster: clusterName, Kind: m.Kind, Name: m.Metadata.Name, Action: action})
+	}
+	return summary, nil
+}
+
+func TestHandleCompareGitRefs(t *testing.T) {
+	setGitOpsTempDir(t)
+	repo := createGitRepo(t, map[string]string{
+		"manifests/web.yaml":    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  color: blue\n",
+		"manifests/legacy.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: legacy\n",
+		"manifests/same.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: same\n",
+	})
+	dir := strings.TrimPrefix(repo, "file://")
+	for _, args := range [][]string{
+		{"checkout", "-q", "-b", "feature"},
+		{"rm", "-q", "manifests/legacy.yaml"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifests", "web.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  color: green\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "manifests", "api.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: api\n"), 0o644))
+	out, err := exec.Command("git", "-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "add", ".").CombinedOutput()
+	require.NoErrorf(t, err, "%s", out)
+	out, err = exec.Command("git", "-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-qm", "feature").CombinedOutput()
+	require.NoErrorf(t, err, "%s", out)
+
+	server := newHelmTestServer(t, map[string]string{"alpha": "https://alpha.example.com", "beta": "https://beta.example.com"})
+	syncer := &dryRunSyncer{existing: map[string]bool{"alpha/web": true, "beta/web": true, "beta/api": true}}
+	server.newManifestSyncer = func(*rest.Config) (manifestSyncer, error) { return syncer, nil }
+
+	got, err := server.handleCompareGitRefs(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"repo": repo, "path": "manifests", "head": "feature",
+	}))
+	require.NoError(t, err)
+	result := got.(*GitRefComparison)
+	assert.Equal(t, "main", result.Base)
+	require.Len(t, result.Changes, 3, "the unchanged ConfigMap is left out")
+	assert.Equal(t, gitops.ManifestAdded, result.Changes[0].Type)
+	assert.Equal(t, "api", result.Changes[0].Name)
+	assert.Equal(t, gitops.ManifestRemoved, result.Changes[1].Type)
+	assert.Equal(t, "legacy", result.Changes[1].Name)
+	assert.Equal(t, []gitops.ManifestFieldChange{{Field: "data.color", Base: "blue", Head: "green"}}, result.Changes[2].Fields)
+	assert.Contains(t, result.Note, "not deleted")
+
+	require.Len(t, result.Clusters, 2)
+	assert.Equal(t, []bool{true, true}, syncer.dryRun)
+	assert.Equal(t, "alpha", result.Clusters[0].Cluster)
+	assert.Equal(t, 1, result.Clusters[0].Created)
+	assert.Equal(t, 1, result.Clusters[0].Updated)
+	assert.Equal(t, "beta", result.Clusters[1].Cluster)
+	assert.Equal(t, 2, result.Clusters[1].Updated)
+
+	_, err = server.handleCompareGitRefs(context.Background(), mustMarshalJSON(t, map[string]interface{}{"repo": repo, "head": "main"}))
+	assert.ErrorContains(t, err, "base and head are both main")
+	_, err = server.handleCompareGitRefs(context.Background(), mustMarshalJSON(t, map[string]interface{}{"repo": repo, "head": "missing"}))
+	assert.ErrorContains(t, err, "failed to read manifests at missing")
+}
@@ -0,0 +1,48 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+)
+
+func TestHelmHandlersEnforceNamespaceAllowlist(t *testing.T) {
+	s := &Server{namespacePolicy: guardrail.NamespacePolicy{Allowed: []string{"team-*"}}}
+
+	_, err := s.handleHelmInstall(context.Background(), json.RawMessage(`{"release_name":"demo","chart":"nginx","namespace":"payments"}`))
+	if err == nil || !strings.Contains(err.Error(), "outside the allowed namespaces") {
+		t.Fatalf("helm install outside allowlist error = %v", err)
+	}
+
+	_, err = s.handleHelmUninstall(context.Background(), json.RawMessage(`{"release_name":"demo","namespace":"payments"}`))
+	if err == nil || !strings.Contains(err.Error(), "outside the allowed namespaces") {
+		t.Fatalf("helm uninstall outside allowlist error = %v", err)
+	}
+
+	_, err = s.handleHelmList(context.Background(), json.RawMessage(`{"all_namespaces":true}`))
+	if err == nil || !strings.Contains(err.Error(), "all namespaces") {
+		t.Fatalf("helm list across namespaces error = %v", err)
+	}
+}
+
+func TestCheckManifestNamespaces(t *testing.T) {
+	s := &Server{namespacePolicy: guardrail.NamespacePolicy{Allowed: []string{"team-a"}, Protected: guardrail.DefaultProtectedNamespaces}}
+
+	ok := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: team-a\n"
+	if err := s.checkManifestNamespaces(ok); err != nil {
+		t.Fatalf("allowed manifest rejected: %v", err)
+	}
+
+	outside := ok + "---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n  namespace: payments\n"
+	if err := s.checkManifestNamespaces(outside); err == nil || !strings.Contains(err.Error(), "ConfigMap b") {
+		t.Fatalf("manifest outside allowlist error = %v", err)
+	}
+
+	namespace := "apiVersion: v1\nkind: Namespace\nmetadata:\n  name: payments\n"
+	if err := s.checkManifestNamespaces(namespace); err == nil {
+		t.Fatal("creating a Namespace outside the allowlist should be rejected")
+	}
+}
@@ -298,6 +298,9 @@ func (s *Server) handleHelmInstall(ctx context.Context, args json.RawMessage) (i
 	if err := server.ValidateNamespace(params.Namespace); err != nil {
 		return nil, fmt.Errorf("invalid namespace: %w", err)
 	}
+	if err := s.namespacePolicy.CheckNamespace(params.Namespace, true); err != nil {
+		return nil, err
+	}
 
 	// Validate chart ref to prevent local filesystem access and OCI SSRF (see #246).
 	if err := validateHelmChartRef(params.Chart); err != nil {
@@ -500,6 +503,9 @@ func (s *Server) handleHelmUninstall(ctx context.Context, args json.RawMessage)
 	if err := server.ValidateNamespace(params.Namespace); err != nil {
 		return nil, fmt.Errorf("invalid namespace: %w", err)
 	}
+	if err := s.namespacePolicy.CheckNamespace(params.Namespace, true); err != nil {
+		return nil, err
+	}
 
 	// Validate identifiers against Kubernetes naming rules to prevent flag injection (#269).
 	if err := validateHelmIdentifier("release_name", params.ReleaseName); err != nil {
@@ -626,6 +632,12 @@ func (s *Server) handleHelmList(ctx context.Context, args json.RawMessage) (inte
 			return nil, fmt.Errorf("invalid namespace: %w", err)
 		}
 	}
+	if err := s.namespacePolicy.CheckNamespace(params.Namespace, false); err != nil {
+		return nil, err
+	}
+	if (params.AllNs || params.Namespace == "") && len(s.namespacePolicy.Allowed) > 0 {
+		return nil, fmt.Errorf("listing releases across all namespaces is not allowed when operations are restricted to namespaces; specify a namespace")
+	}
 
 	// Validate filter to prevent flag injection (#344).
 	if params.Filter != "" && strings.HasPrefix(params.Filter, "-") {
@@ -729,6 +741,9 @@ func (s *Server) handleHelmRollback(ctx context.Context, args json.RawMessage) (
 	if err := server.ValidateNamespace(params.Namespace); err != nil {
 		return nil, fmt.Errorf("invalid namespace: %w", err)
 	}
+	if err := s.namespacePolicy.CheckNamespace(params.Namespace, true); err != nil {
+		return nil, err
+	}
 
 	// Validate identifiers against Kubernetes naming rules to prevent flag injection (#269).
 	if err := validateHelmIdentifier("release_name", params.ReleaseName); err != nil {
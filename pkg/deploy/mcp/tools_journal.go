@@ -0,0 +1,167 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"k8s.io/client-go/rest"
+)
+
+// defaultListChanges is how many changes list_changes returns by default.
+const defaultListChanges = 20
+
+func (s *Server) getJournal() *journal.Journal {
+	s.journalOnce.Do(func() {
+		if s.journal == nil {
+			s.journal = journal.New(s.getStateStore())
+		}
+	})
+	return s.journal
+}
+
+// commitChange stores the objects recorded during a tool call and returns
+// the result metadata that points at the change, if anything changed.
+func (s *Server) commitChange(ctx context.Context, rec *journal.Recorder) map[string]interface{} {
+	if rec == nil {
+		return nil
+	}
+	change, err := s.getJournal().Commit(ctx, rec)
+	if err != nil {
+		return map[string]interface{}{"journalError": err.Error()}
+	}
+	if change == nil {
+		return nil
+	}
+	meta := map[string]interface{}{"changeId": change.ID}
+	if notUndoable := change.NotUndoable(); len(notUndoable) > 0 {
+		meta["notUndoable"] = notUndoable
+	}
+	return meta
+}
+
+// withResultMeta merges meta into the _meta of a tool result.
+func withResultMeta(resp *MCPResponse, meta map[string]interface{}) *MCPResponse {
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok || len(meta) == 0 {
+		return resp
+	}
+	merged, _ := result["_meta"].(map[string]interface{})
+	if merged == nil {
+		merged = make(map[string]interface{}, len(meta))
+	}
+	for k, v := range meta {
+		merged[k] = v
+	}
+	result["_meta"] = merged
+	return resp
+}
+
+// configForServer finds the kubeconfig context for an API server URL
+// recorded in the journal.
+func (s *Server) configForServer(server string) (*rest.Config, error) {
+	clusters, err := s.manager.DiscoverClusters()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		if journal.SameServer(c.Server, server) {
+			return s.manager.GetConfig(c.Name)
+		}
+	}
+	return nil, fmt.Errorf("no kubeconfig context points at %s", server)
+}
+
+// handleListChanges lists journaled changes, newest first.
+func (s *Server) handleListChanges(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Limit int    `json:"limit"`
+		Tool  string `json:"tool"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if params.Limit <= 0 {
+		params.Limit = defaultListChanges
+	}
+
+	all, err := s.getJournal().List(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	type objectSummary struct {
+		Server      string `json:"server"`
+		Path        string `json:"path"`
+		Op          string `json:"op"`
+		NotUndoable string `json:"notUndoable,omitempty"`
+	}
+	type changeSummary struct {
+		ID       string          `json:"id"`
+		Tool     string          `json:"tool"`
+		Time     string          `json:"time"`
+		Objects  []objectSummary `json:"objects"`
+		UndoOf   string          `json:"undoOf,omitempty"`
+		UndoneBy string          `json:"undoneBy,omitempty"`
+	}
+	changes := []changeSummary{}
+	for _, c := range all {
+		if params.Tool != "" && c.Tool != params.Tool {
+			continue
+		}
+		summary := changeSummary{ID: c.ID, Tool: c.Tool, Time: c.Time.UTC().Format("2006-01-02T15:04:05Z"), UndoOf: c.UndoOf, UndoneBy: c.UndoneBy}
+		for _, o := range c.Objects {
+			summary.Objects = append(summary.Objects, objectSummary{Server: o.Server, Path: o.Path, Op: o.Op, NotUndoable: o.NotUndoable})
+		}
+		changes = append(changes, summary)
+		if len(changes) == params.Limit {
+			break
+		}
+	}
+	return map[string]interface{}{
+		"changes": changes,
+		"count":   len(changes),
+	}, nil
+}
+
+// handleUndoChange reverts a journaled change.
+func (s *Server) handleUndoChange(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		ChangeID string `json:"change_id"`
+		DryRun   bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.ChangeID == "" {
+		return nil, fmt.Errorf("change_id is required")
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+
+	undo, results, err := s.getJournal().Undo(ctx, params.ChangeID, s.configForServer, dryRun)
+	if err != nil && results == nil {
+		return nil, err
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	out := map[string]interface{}{
+		"changeId": params.ChangeID,
+		"dryRun":   dryRun,
+		"results":  results,
+		"failed":   failed,
+	}
+	if undo != nil {
+		out["undoChangeId"] = undo.ID
+	}
+	if err != nil {
+		out["journalError"] = err.Error()
+	}
+	return out, nil
+}
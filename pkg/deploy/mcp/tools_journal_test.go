@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func TestHandleToolCall_JournalsAndUndoesScale(t *testing.T) {
+	var mu sync.Mutex
+	replicas := 1
+	deployment := func() map[string]interface{} {
+		return map[string]interface{}{
+			"kind": "Deployment", "apiVersion": "apps/v1",
+			"metadata": map[string]interface{}{"name": "web", "namespace": "default", "resourceVersion": "1"},
+			"spec":     map[string]interface{}{"replicas": replicas},
+		}
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/deployments"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"kind": "DeploymentList", "apiVersion": "apps/v1", "items": []interface{}{deployment()}})
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/statefulsets"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"kind": "StatefulSetList", "apiVersion": "apps/v1", "items": []interface{}{}})
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/daemonsets"):
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"kind": "DaemonSetList", "apiVersion": "apps/v1", "items": []interface{}{}})
+		case r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(deployment())
+		case r.Method == http.MethodPut:
+			// Typed clients send protobuf, undo sends JSON.
+			body, _ := io.ReadAll(r.Body)
+			obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(body, nil, nil)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			replicas = int(*obj.(*appsv1.Deployment).Spec.Replicas)
+			_ = json.NewEncoder(w).Encode(deployment())
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer srv.Close()
+
+	mgr, err := multicluster.NewClientManager(writeKubeconfig(t, map[string]string{"c1": srv.URL}))
+	require.NoError(t, err)
+	mgr.SetConfigTransform(journal.Wrap)
+	server := newServerWithManager(mgr)
+	server.stateStore = store.NewMemory()
+
+	call := func(name string, args map[string]interface{}) map[string]interface{} {
+		resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+			"name":      name,
+			"arguments": args,
+		})})
+		require.Nil(t, resp.Error)
+		result := resp.Result.(map[string]interface{})
+		require.Nil(t, result["isError"], "%s failed: %v", name, result["content"])
+		return result
+	}
+
+	scaled := call("scale_app", map[string]interface{}{"app": "web", "replicas": 5, "clusters": []string{"c1"}})
+	changeID, _ := scaled["_meta"].(map[string]interface{})["changeId"].(string)
+	require.NotEmpty(t, changeID)
+	assert.Equal(t, 5, replicas)
+
+	listed := call("list_changes", map[string]interface{}{})
+	text := listed["content"].([]map[string]interface{})[0]["text"].(string)
+	assert.Contains(t, text, changeID)
+	assert.Contains(t, text, "/apis/apps/v1/namespaces/default/deployments/web")
+
+	call("undo_change", map[string]interface{}{"change_id": changeID})
+	assert.Equal(t, 1, replicas, "undo should restore the previous replica count")
+
+	resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+		"name":      "undo_change",
+		"arguments": map[string]interface{}{"change_id": changeID},
+	})})
+	assert.Equal(t, true, resp.Result.(map[string]interface{})["isError"], "a change can only be undone once")
+}
@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
 	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8syaml "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -31,7 +34,7 @@ type ApplyResult struct {
 	Kind      string `json:"kind"`
 	Name      string `json:"name"`
 	Namespace string `json:"namespace,omitempty"`
-	Status    string `json:"status"` // created, updated, unchanged, failed
+	Status    string `json:"status"` // created, updated, unchanged, conflict, failed
 	Message   string `json:"message,omitempty"`
 }
 
@@ -55,21 +58,20 @@ func sensitiveKindError(kind string) error {
 	return fmt.Errorf("%q resources are blocked via MCP kubectl tools to prevent privilege escalation; use kubectl directly for this sensitive operation", kind)
 }
 
-func manifestSensitiveKind(doc string) (string, bool) {
-	doc = strings.TrimSpace(doc)
-	if doc == "" {
+// manifestSensitiveKind returns the first sensitive kind among the objects
+// of a manifest, Lists included, or the kind of its first object. Manifests
+// that cannot be decoded are not blocked here; applying them fails.
+func manifestSensitiveKind(manifest string) (string, bool) {
+	objects, err := kubemanifest.DecodeString(manifest)
+	if err != nil || len(objects) == 0 {
 		return "", false
 	}
-
-	obj := &unstructured.Unstructured{}
-	if err := obj.UnmarshalJSON([]byte(yamlToJSON(doc))); err != nil {
-		if err := unstructuredFromYAML(doc, obj); err != nil {
-			return "", false
+	for _, obj := range objects {
+		if isSensitiveKind(obj.GetKind()) {
+			return obj.GetKind(), true
 		}
 	}
-
-	kind := obj.GetKind()
-	return kind, isSensitiveKind(kind)
+	return objects[0].GetKind(), false
 }
 
 // handleDeleteResource deletes a resource from clusters
@@ -112,8 +114,8 @@ func (s *Server) handleDeleteResource(ctx context.Context, args json.RawMessage)
 		}
 	}
 
-	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
-		return s.deleteResourceInCluster(ctx, client, clusterName, params.Kind, params.Name, params.Namespace, params.DryRun)
+	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.deleteResourceInCluster(ctx, clusterName, params.Kind, params.Name, params.Namespace, params.DryRun)
 	})
 	if err != nil {
 		return nil, err
@@ -148,7 +150,7 @@ func (s *Server) handleDeleteResource(ctx context.Context, args json.RawMessage)
 }
 
 // deleteResourceInCluster deletes a resource in a single cluster
-func (s *Server) deleteResourceInCluster(ctx context.Context, client *kubernetes.Clientset, clusterName, kind, name, namespace string, dryRun bool) (DeleteResult, error) {
+func (s *Server) deleteResourceInCluster(ctx context.Context, clusterName, kind, name, namespace string, dryRun bool) (DeleteResult, error) {
 	result := DeleteResult{
 		Cluster:   clusterName,
 		Resource:  kind,
@@ -156,57 +158,39 @@ func (s *Server) deleteResourceInCluster(ctx context.Context, client *kubernetes
 		Namespace: namespace,
 	}
 
+	mapping, err := s.resolveKind(clusterName, kind)
+	if err != nil {
+		result.Status = "failed"
+		result.Message = fmt.Sprintf("Unsupported resource kind: %v", err)
+		return result, nil
+	}
+	// Kinds may be named in ways the check of the handler does not know,
+	// such as short names or with their group.
+	if isSensitiveKind(mapping.GVR.Resource) && (mapping.GVR.Group == "" || mapping.GVR.Group == "rbac.authorization.k8s.io") {
+		result.Status = "failed"
+		result.Message = sensitiveKindError(kind).Error()
+		return result, nil
+	}
+
 	if dryRun {
 		result.Status = "would-delete"
 		result.Message = fmt.Sprintf("Would delete %s/%s", kind, name)
 		return result, nil
 	}
 
-	var err error
-	ns := namespace
-	if ns == "" {
-		ns = "default"
-	}
-
-	switch strings.ToLower(kind) {
-	case "deployment", "deployments":
-		err = client.AppsV1().Deployments(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "service", "services", "svc":
-		err = client.CoreV1().Services(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "configmap", "configmaps", "cm":
-		err = client.CoreV1().ConfigMaps(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "secret", "secrets":
-		err = client.CoreV1().Secrets(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "pod", "pods":
-		err = client.CoreV1().Pods(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "statefulset", "statefulsets", "sts":
-		err = client.AppsV1().StatefulSets(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "daemonset", "daemonsets", "ds":
-		err = client.AppsV1().DaemonSets(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "job", "jobs":
-		err = client.BatchV1().Jobs(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "cronjob", "cronjobs":
-		err = client.BatchV1().CronJobs(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "ingress", "ingresses", "ing":
-		err = client.NetworkingV1().Ingresses(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "pvc", "persistentvolumeclaim", "persistentvolumeclaims":
-		err = client.CoreV1().PersistentVolumeClaims(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "namespace", "namespaces", "ns":
-		err = client.CoreV1().Namespaces().Delete(ctx, name, metav1.DeleteOptions{})
-	case "serviceaccount", "serviceaccounts", "sa":
-		err = client.CoreV1().ServiceAccounts(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "role", "roles":
-		err = client.RbacV1().Roles(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "rolebinding", "rolebindings":
-		err = client.RbacV1().RoleBindings(ns).Delete(ctx, name, metav1.DeleteOptions{})
-	case "clusterrole", "clusterroles":
-		err = client.RbacV1().ClusterRoles().Delete(ctx, name, metav1.DeleteOptions{})
-	case "clusterrolebinding", "clusterrolebindings":
-		err = client.RbacV1().ClusterRoleBindings().Delete(ctx, name, metav1.DeleteOptions{})
-	default:
-		result.Status = "failed"
-		result.Message = fmt.Sprintf("Unsupported resource kind: %s", kind)
-		return result, nil
+	dyn, err := s.dynamicClient(clusterName)
+	if err != nil {
+		return result, err
+	}
+	resource := dyn.Resource(mapping.GVR)
+	if mapping.ClusterScoped {
+		err = resource.Delete(ctx, name, metav1.DeleteOptions{})
+	} else {
+		ns := namespace
+		if ns == "" {
+			ns = "default"
+		}
+		err = resource.Namespace(ns).Delete(ctx, name, metav1.DeleteOptions{})
 	}
 
 	if err != nil {
@@ -227,9 +211,13 @@ func (s *Server) deleteResourceInCluster(ctx context.Context, client *kubernetes
 // handleKubectlApply applies any Kubernetes resource using dynamic client
 func (s *Server) handleKubectlApply(ctx context.Context, args json.RawMessage) (interface{}, error) {
 	var params struct {
-		Manifest string   `json:"manifest"`
-		Clusters []string `json:"clusters"`
-		DryRun   bool     `json:"dry_run"`
+		Manifest         string   `json:"manifest"`
+		Clusters         []string `json:"clusters"`
+		DryRun           bool     `json:"dry_run"`
+		CreateNamespaces bool     `json:"create_namespaces"`
+		Wait             bool     `json:"wait"`
+		TimeoutSeconds   int      `json:"timeout_seconds"`
+		applyModeArgs
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
@@ -238,11 +226,13 @@ func (s *Server) handleKubectlApply(ctx context.Context, args json.RawMessage) (
 	if params.Manifest == "" {
 		return nil, fmt.Errorf("manifest is required")
 	}
+	ssa, err := params.serverSideApply()
+	if err != nil {
+		return nil, err
+	}
 
-	for _, doc := range strings.Split(params.Manifest, "---") {
-		if kind, blocked := manifestSensitiveKind(doc); blocked {
-			return nil, sensitiveKindError(kind)
-		}
+	if kind, blocked := manifestSensitiveKind(params.Manifest); blocked {
+		return nil, sensitiveKindError(kind)
 	}
 
 	// Get target clusters
@@ -258,7 +248,7 @@ func (s *Server) handleKubectlApply(ctx context.Context, args json.RawMessage) (
 	}
 
 	results, err := s.executor.ExecuteOnSelected(ctx, targetClusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
-		return s.applyManifestDynamic(ctx, clusterName, params.Manifest, params.DryRun)
+		return s.applyManifestDynamic(ctx, clusterName, params.Manifest, params.DryRun, params.CreateNamespaces, ssa)
 	})
 	if err != nil {
 		return nil, err
@@ -276,24 +266,42 @@ func (s *Server) handleKubectlApply(ctx context.Context, args json.RawMessage) (
 		} else if ar, ok := result.Result.([]ApplyResult); ok {
 			applyResults = append(applyResults, ar...)
 			for _, r := range ar {
-				if r.Status == "created" || r.Status == "updated" || r.Status == "would-apply" {
+				if r.Status == "created" || r.Status == "updated" || r.Status == "unchanged" || r.Status == "would-apply" {
 					successCount++
 				}
 			}
 		}
 	}
 
-	return map[string]interface{}{
+	out := map[string]interface{}{
 		"targetClusters": targetClusters,
 		"successCount":   successCount,
 		"totalClusters":  len(targetClusters),
 		"results":        applyResults,
 		"dryRun":         params.DryRun,
-	}, nil
+	}
+	if params.Wait && !params.DryRun && !approval.IsDryRun(ctx) {
+		timeout := defaultHealthTimeout
+		if params.TimeoutSeconds > 0 {
+			timeout = time.Duration(params.TimeoutSeconds) * time.Second
+		}
+		failed := make(map[string]bool)
+		for _, r := range applyResults {
+			if r.Status == "failed" || r.Status == "conflict" {
+				failed[r.Cluster] = true
+			}
+		}
+		s.reportHealth(ctx, out, targetClusters, failed, params.Manifest, nil, timeout)
+	}
+	return out, nil
 }
 
-// applyManifestDynamic applies manifests using the dynamic client for any resource type
-func (s *Server) applyManifestDynamic(ctx context.Context, clusterName, manifest string, dryRun bool) ([]ApplyResult, error) {
+// applyManifestDynamic applies manifests using the dynamic client for any
+// resource type the cluster serves, custom resources included. Namespaces
+// are applied first, then CRDs, then everything else once those CRDs are
+// established. Objects are created or replaced, or with ssa, server-side
+// applied.
+func (s *Server) applyManifestDynamic(ctx context.Context, clusterName, manifest string, dryRun, createNamespaces bool, ssa *serverSideApply) ([]ApplyResult, error) {
 	var results []ApplyResult
 
 	// Get the dynamic client for this cluster
@@ -307,26 +315,40 @@ func (s *Server) applyManifestDynamic(ctx context.Context, clusterName, manifest
 		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
 	}
 
-	// Parse YAML documents
-	docs := strings.Split(manifest, "---")
-	for _, doc := range docs {
-		doc = strings.TrimSpace(doc)
-		if doc == "" {
-			continue
+	objects, err := kubemanifest.DecodeString(manifest)
+	if err != nil {
+		return []ApplyResult{{Cluster: clusterName, Status: "failed",
+			Message: fmt.Sprintf("failed to parse manifest: %v", err)}}, nil
+	}
+	kubemanifest.SortForApply(objects)
+	declared := make(map[string]bool)
+	for _, obj := range objects {
+		if kubemanifest.ApplyPhase(obj.GetAPIVersion(), obj.GetKind()) == kubemanifest.PhaseNamespace {
+			declared[obj.GetName()] = true
 		}
+	}
+	ensured := make(map[string]bool)
+	var (
+		crds   []string
+		crdErr error
+	)
+
+	// The mapper is the cluster's cached discovery, rediscovered once when
+	// a kind is missing, such as a custom resource whose CRD comes earlier
+	// in the same manifest or was installed since
+	var mapper meta.RESTMapper
+	if !dryRun && len(objects) > 0 {
+		mapper = kubemanifest.NewRESTMapper(config)
+	}
+	rediscovered := false
 
-		// Parse as unstructured
-		obj := &unstructured.Unstructured{}
-		if err := obj.UnmarshalJSON([]byte(yamlToJSON(doc))); err != nil {
-			// Try YAML parsing
-			if err := unstructuredFromYAML(doc, obj); err != nil {
-				results = append(results, ApplyResult{
-					Cluster: clusterName,
-					Status:  "failed",
-					Message: fmt.Sprintf("failed to parse manifest: %v", err),
-				})
-				continue
+	for _, obj := range objects {
+		phase := kubemanifest.ApplyPhase(obj.GetAPIVersion(), obj.GetKind())
+		if phase == kubemanifest.PhaseResource && len(crds) > 0 {
+			if crdErr = kubemanifest.WaitForCRDs(ctx, dynClient, crds, kubemanifest.CRDEstablishTimeout); crdErr == nil {
+				mapper = kubemanifest.Rediscover(config)
 			}
+			crds = nil
 		}
 
 		kind := obj.GetKind()
@@ -359,25 +381,54 @@ func (s *Server) applyManifestDynamic(ctx context.Context, clusterName, manifest
 		}
 
 		// Get the GVR for this resource
-		gvr, namespaced := getGVR(kind)
-		if gvr.Resource == "" {
+		mapping, err := kubemanifest.Resolve(mapper, obj.GetAPIVersion(), kind)
+		if err == nil && mapping.Guessed && mapper != nil && !rediscovered {
+			rediscovered = true
+			mapper = kubemanifest.Rediscover(config)
+			mapping, err = kubemanifest.Resolve(mapper, obj.GetAPIVersion(), kind)
+		}
+		if err != nil {
 			result.Status = "failed"
-			result.Message = fmt.Sprintf("unknown resource kind: %s", kind)
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if mapping.Guessed {
+			result.Status = "failed"
+			result.Message = kubemanifest.UnknownKindError(obj.GetAPIVersion(), kind).Error()
+			if crdErr != nil {
+				result.Message = crdErr.Error()
+			}
 			results = append(results, result)
 			continue
 		}
 
+		if createNamespaces && !mapping.ClusterScoped && !declared[namespace] && !ensured[namespace] {
+			ensured[namespace] = true
+			created, err := kubemanifest.EnsureNamespace(ctx, dynClient, namespace, false)
+			if err != nil {
+				result.Status = "failed"
+				result.Message = err.Error()
+				results = append(results, result)
+				continue
+			}
+			if created {
+				results = append(results, ApplyResult{Cluster: clusterName, Kind: "Namespace", Name: namespace,
+					Status: "created", Message: "Created for the manifest's resources"})
+			}
+		}
+
 		// Apply the resource
 		var resourceClient dynamic.ResourceInterface
-		if namespaced {
-			resourceClient = dynClient.Resource(gvr).Namespace(namespace)
+		if !mapping.ClusterScoped {
+			resourceClient = dynClient.Resource(mapping.GVR).Namespace(namespace)
 		} else {
-			resourceClient = dynClient.Resource(gvr)
+			resourceClient = dynClient.Resource(mapping.GVR)
 		}
 
-		// Try to get existing
-		existing, err := resourceClient.Get(ctx, name, metav1.GetOptions{})
-		if err == nil {
+		if ssa != nil {
+			result.Status, result.Message = applyServerSide(ctx, resourceClient, obj, ssa)
+		} else if existing, err := resourceClient.Get(ctx, name, metav1.GetOptions{}); err == nil {
 			// Update
 			obj.SetResourceVersion(existing.GetResourceVersion())
 			_, err = resourceClient.Update(ctx, obj, metav1.UpdateOptions{})
@@ -397,6 +448,9 @@ func (s *Server) applyManifestDynamic(ctx context.Context, clusterName, manifest
 				result.Status = "created"
 			}
 		}
+		if phase == kubemanifest.PhaseCRD && (result.Status == "created" || result.Status == "updated" || result.Status == "unchanged") {
+			crds = append(crds, name)
+		}
 
 		results = append(results, result)
 	}
@@ -404,67 +458,17 @@ func (s *Server) applyManifestDynamic(ctx context.Context, clusterName, manifest
 	return results, nil
 }
 
-// getGVR returns the GroupVersionResource for common Kubernetes kinds
-func getGVR(kind string) (schema.GroupVersionResource, bool) {
-	kindLower := strings.ToLower(kind)
-	switch kindLower {
-	// Core v1
-	case "pod", "pods":
-		return schema.GroupVersionResource{Version: "v1", Resource: "pods"}, true
-	case "service", "services":
-		return schema.GroupVersionResource{Version: "v1", Resource: "services"}, true
-	case "configmap", "configmaps":
-		return schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}, true
-	case "secret", "secrets":
-		return schema.GroupVersionResource{Version: "v1", Resource: "secrets"}, true
-	case "namespace", "namespaces":
-		return schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}, false
-	case "serviceaccount", "serviceaccounts":
-		return schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}, true
-	case "persistentvolumeclaim", "persistentvolumeclaims":
-		return schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}, true
-	case "persistentvolume", "persistentvolumes":
-		return schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}, false
-
-	// Apps v1
-	case "deployment", "deployments":
-		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, true
-	case "statefulset", "statefulsets":
-		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}, true
-	case "daemonset", "daemonsets":
-		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}, true
-	case "replicaset", "replicasets":
-		return schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}, true
-
-	// Batch v1
-	case "job", "jobs":
-		return schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}, true
-	case "cronjob", "cronjobs":
-		return schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, true
-
-	// Networking v1
-	case "ingress", "ingresses":
-		return schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}, true
-	case "networkpolicy", "networkpolicies":
-		return schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}, true
-
-	// RBAC v1
-	case "role", "roles":
-		return schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}, true
-	case "rolebinding", "rolebindings":
-		return schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}, true
-	case "clusterrole", "clusterroles":
-		return schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, false
-	case "clusterrolebinding", "clusterrolebindings":
-		return schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, false
-
-	// HPA
-	case "horizontalpodautoscaler", "horizontalpodautoscalers", "hpa":
-		return schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}, true
-
-	default:
-		return schema.GroupVersionResource{}, false
+// resolveKind returns the resource of kind, named as a user would
+// (Deployment, deployments, sts, or Rollout.argoproj.io), on a cluster. It
+// uses the cluster's shared, discovery-backed RESTMapper, so custom
+// resources resolve, and the built-in kinds when discovery fails.
+func (s *Server) resolveKind(clusterName, kind string) (kubemanifest.Mapping, error) {
+	config, err := s.manager.GetConfig(clusterName)
+	if err != nil {
+		return kubemanifest.Mapping{}, fmt.Errorf("failed to get config for cluster %s: %w", clusterName, err)
 	}
+	mapping, _, err := kubemanifest.LookupKind(config, kind)
+	return mapping, err
 }
 
 // yamlToJSON converts YAML or JSON strings to JSON for Kubernetes decoding.
@@ -500,26 +504,44 @@ func parseYAML(data []byte, v interface{}) error {
 	return json.Unmarshal(jsonData, v)
 }
 
-// validateManifestDocs validates every --- separated document in a built manifest,
-// enforcing the same sensitive-kind and namespace rules used by the kubectl handlers.
-// Called by kustomize handlers before piping built output to kubectl apply/delete.
+// validateManifestDocs validates every object in a built manifest, Lists
+// included, enforcing the same sensitive-kind and namespace rules used by
+// the kubectl handlers. Called by kustomize handlers before piping built
+// output to kubectl apply/delete, which rejects documents that are not
+// objects, so those are skipped here.
 func validateManifestDocs(manifest string) error {
-	for _, doc := range strings.Split(manifest, "---") {
-		doc = strings.TrimSpace(doc)
-		if doc == "" {
-			continue
-		}
-		if kind, blocked := manifestSensitiveKind(doc); blocked {
-			return sensitiveKindError(kind)
+	objects, err := kubemanifest.DecodeMixed(strings.NewReader(manifest))
+	if err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+	for _, obj := range objects {
+		if isSensitiveKind(obj.GetKind()) {
+			return sensitiveKindError(obj.GetKind())
 		}
-		obj := &unstructured.Unstructured{}
-		if err := unstructuredFromYAML(doc, obj); err == nil {
-			if ns := obj.GetNamespace(); ns != "" {
-				if err := server.ValidateNamespace(ns); err != nil {
-					return fmt.Errorf("invalid namespace in manifest: %w", err)
-				}
+		if ns := obj.GetNamespace(); ns != "" {
+			if err := server.ValidateNamespace(ns); err != nil {
+				return fmt.Errorf("invalid namespace in manifest: %w", err)
 			}
 		}
 	}
 	return nil
 }
+
+// checkManifestNamespaces applies the namespace guardrails to manifests that
+// are applied by shelling out to kubectl, which bypasses the guarded clients.
+func (s *Server) checkManifestNamespaces(manifest string) error {
+	objects, err := kubemanifest.DecodeMixed(strings.NewReader(manifest))
+	if err != nil {
+		return fmt.Errorf("invalid manifest: %w", err)
+	}
+	for _, obj := range objects {
+		namespace := obj.GetNamespace()
+		if obj.GetKind() == "Namespace" {
+			namespace = obj.GetName()
+		}
+		if err := s.namespacePolicy.CheckNamespace(namespace, true); err != nil {
+			return fmt.Errorf("%s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+	}
+	return nil
+}
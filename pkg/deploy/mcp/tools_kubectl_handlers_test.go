@@ -72,8 +72,9 @@ func TestHandleDeleteResourceDryRunUnsupportedKind(t *testing.T) {
 		resultMap, ok := result.(map[string]interface{})
 		require.True(t, ok)
 		results, ok := resultMap["results"].([]DeleteResult)
+		// Dry runs resolve the kind too, so unknown kinds fail early.
 		if ok && len(results) > 0 {
-			assert.Equal(t, "would-delete", results[0].Status)
+			assert.Equal(t, "failed", results[0].Status)
 			assert.Contains(t, results[0].Message, "Widget")
 		}
 	}
@@ -194,7 +195,7 @@ metadata:
 func TestDeleteResourceInClusterUnsupportedKind(t *testing.T) {
 	server := newHelmTestServer(t, map[string]string{"alpha": "https://alpha.example.com"})
 
-	result, err := server.deleteResourceInCluster(context.Background(), nil, "alpha", "Widget", "my-widget", "default", false)
+	result, err := server.deleteResourceInCluster(context.Background(), "alpha", "Widget", "my-widget", "default", false)
 	require.NoError(t, err)
 
 	assert.Equal(t, "failed", result.Status)
@@ -204,7 +205,7 @@ func TestDeleteResourceInClusterUnsupportedKind(t *testing.T) {
 func TestDeleteResourceInClusterDryRun(t *testing.T) {
 	server := newHelmTestServer(t, map[string]string{"alpha": "https://alpha.example.com"})
 
-	result, err := server.deleteResourceInCluster(context.Background(), nil, "alpha", "Pod", "my-pod", "default", true)
+	result, err := server.deleteResourceInCluster(context.Background(), "alpha", "Pod", "my-pod", "default", true)
 	require.NoError(t, err)
 
 	assert.Equal(t, "would-delete", result.Status)
@@ -221,7 +222,7 @@ metadata:
 data:
   key: value`
 
-	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, true)
+	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, true, false, nil)
 	if err != nil {
 		assert.Contains(t, err.Error(), "alpha")
 	} else {
@@ -235,7 +236,7 @@ data:
 func TestApplyManifestDynamicInvalidYAML(t *testing.T) {
 	server := newHelmTestServer(t, map[string]string{"alpha": "https://alpha.example.com"})
 
-	results, err := server.applyManifestDynamic(context.Background(), "alpha", "not: [valid: yaml: {{", true)
+	results, err := server.applyManifestDynamic(context.Background(), "alpha", "not: [valid: yaml: {{", true, false, nil)
 	if err != nil {
 		return
 	}
@@ -249,7 +250,7 @@ func TestApplyManifestDynamicUnknownKind(t *testing.T) {
 
 	manifest := `{"apiVersion":"v1","kind":"UnknownThing","metadata":{"name":"x"}}`
 
-	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, false)
+	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, false, false, nil)
 	if err != nil {
 		return
 	}
@@ -273,7 +274,7 @@ metadata:
   name: cm2
   namespace: default`
 
-	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, true)
+	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, true, false, nil)
 	if err != nil {
 		return
 	}
@@ -289,13 +290,57 @@ func TestApplyManifestDynamicEmptyDocs(t *testing.T) {
 
 	manifest := "---\n---\n"
 
-	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, true)
+	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, true, false, nil)
 	if err != nil {
 		return
 	}
 	assert.Len(t, results, 0)
 }
 
+func TestApplyManifestDynamicCreatesNamespacesFirst(t *testing.T) {
+	api, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"alpha": url})
+
+	manifest := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: team-a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: team-b
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-b
+`
+
+	results, err := server.applyManifestDynamic(context.Background(), "alpha", manifest, false, true, nil)
+	require.NoError(t, err)
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Kind+"/"+r.Namespace+"/"+r.Name+":"+r.Status)
+	}
+	assert.Equal(t, []string{
+		"Namespace/default/team-b:created",
+		"Namespace//team-a:created",
+		"ConfigMap/team-a/settings:created",
+		"ConfigMap/team-b/settings:created",
+	}, got)
+	assert.True(t, api.has("/api/v1/namespaces/team-a"))
+	assert.True(t, api.has("/api/v1/namespaces/team-a/configmaps/settings"))
+
+	// Without create_namespaces a missing namespace is left to fail.
+	results, err = server.applyManifestDynamic(context.Background(), "alpha", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\n  namespace: team-c\n", false, false, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, api.has("/api/v1/namespaces/team-c"))
+}
+
 func TestDeleteResultJSON(t *testing.T) {
 	dr := DeleteResult{
 		Cluster:  "alpha",
@@ -194,3 +194,32 @@ func TestManifestSensitiveKind_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+// TestManifestSensitiveKind_Streams verifies that sensitive kinds are found
+// in any document of a stream and inside Lists.
+func TestManifestSensitiveKind_Streams(t *testing.T) {
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\n"
+	tests := []struct {
+		name      string
+		manifest  string
+		wantKind  string
+		wantBlock bool
+	}{
+		{name: "second document", manifest: configMap + "---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: s\n", wantKind: "Secret", wantBlock: true},
+		{
+			name:      "List item",
+			manifest:  "apiVersion: v1\nkind: List\nitems:\n- apiVersion: rbac.authorization.k8s.io/v1\n  kind: ClusterRoleBinding\n  metadata:\n    name: admin\n",
+			wantKind:  "ClusterRoleBinding",
+			wantBlock: true,
+		},
+		{name: "separator in a string", manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\ndata:\n  sep: |\n    ---\n    kind: Secret\n", wantKind: "ConfigMap", wantBlock: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, blocked := manifestSensitiveKind(tt.manifest)
+			assert.Equal(t, tt.wantKind, kind)
+			assert.Equal(t, tt.wantBlock, blocked)
+		})
+	}
+}
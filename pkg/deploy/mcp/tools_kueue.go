@@ -0,0 +1,587 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+)
+
+var (
+	clusterQueueGVR  = schema.GroupVersionResource{Group: "kueue.x-k8s.io", Version: "v1beta1", Resource: "clusterqueues"}
+	localQueueGVR    = schema.GroupVersionResource{Group: "kueue.x-k8s.io", Version: "v1beta1", Resource: "localqueues"}
+	kueueWorkloadGVR = schema.GroupVersionResource{Group: "kueue.x-k8s.io", Version: "v1beta1", Resource: "workloads"}
+)
+
+// errKueueNotInstalled is reported for clusters without the Kueue CRDs.
+var errKueueNotInstalled = errors.New("the Kueue CRDs are not installed")
+
+// ResourceQuota is the quota of one resource in one flavor of a
+// ClusterQueue and how much of it admitted workloads use.
+type ResourceQuota struct {
+	Flavor         string `json:"flavor"`
+	Resource       string `json:"resource"`
+	NominalQuota   string `json:"nominalQuota"`
+	BorrowingLimit string `json:"borrowingLimit,omitempty"`
+	Used           string `json:"used"`
+	Borrowed       string `json:"borrowed,omitempty"`
+}
+
+// ClusterQueueSummary is a Kueue ClusterQueue with its quota and backlog.
+type ClusterQueueSummary struct {
+	Name   string `json:"name"`
+	Cohort string `json:"cohort,omitempty"`
+	Active bool   `json:"active"`
+	// InactiveReason explains why an inactive queue admits nothing, such
+	// as a missing ResourceFlavor or a stop policy.
+	InactiveReason    string          `json:"inactiveReason,omitempty"`
+	PendingWorkloads  int64           `json:"pendingWorkloads"`
+	AdmittedWorkloads int64           `json:"admittedWorkloads"`
+	Quotas            []ResourceQuota `json:"quotas,omitempty"`
+}
+
+// LocalQueueSummary is a namespaced Kueue LocalQueue and the ClusterQueue
+// it submits to.
+type LocalQueueSummary struct {
+	Namespace         string `json:"namespace"`
+	Name              string `json:"name"`
+	ClusterQueue      string `json:"clusterQueue"`
+	Active            bool   `json:"active"`
+	InactiveReason    string `json:"inactiveReason,omitempty"`
+	PendingWorkloads  int64  `json:"pendingWorkloads"`
+	AdmittedWorkloads int64  `json:"admittedWorkloads"`
+}
+
+// ClusterKueueQueues is the Kueue queues of one cluster.
+type ClusterKueueQueues struct {
+	Cluster       string                `json:"cluster"`
+	ClusterQueues []ClusterQueueSummary `json:"clusterQueues"`
+	LocalQueues   []LocalQueueSummary   `json:"localQueues"`
+}
+
+// KueueQueues is the Kueue queues across the fleet.
+type KueueQueues struct {
+	Clusters []ClusterKueueQueues `json:"clusters"`
+	Issues   []string             `json:"issues,omitempty"`
+}
+
+// PendingWorkload is a Kueue Workload that has not been admitted, and why.
+type PendingWorkload struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Job is the kind and name of the job the Workload was created for.
+	Job          string `json:"job,omitempty"`
+	Queue        string `json:"queue"`
+	ClusterQueue string `json:"clusterQueue,omitempty"`
+	Priority     int64  `json:"priority"`
+	// Requests is the total of every pod of the job.
+	Requests map[string]string `json:"requests,omitempty"`
+	Created  time.Time         `json:"created"`
+	Waiting  string            `json:"waiting"`
+	Reason   string            `json:"reason"`
+	// Quota is the ClusterQueue's quota for the resources the job requests.
+	Quota []ResourceQuota `json:"quota,omitempty"`
+}
+
+// PendingWorkloads is the pending Kueue Workloads across the fleet, longest
+// waiting first.
+type PendingWorkloads struct {
+	Workloads []PendingWorkload `json:"workloads"`
+	Issues    []string          `json:"issues,omitempty"`
+}
+
+// listKueue lists gvr in namespace, reporting a missing CRD as
+// errKueueNotInstalled.
+func listKueue(ctx context.Context, dyn dynamic.Interface, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+	list, err := dyn.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errKueueNotInstalled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+	return items, nil
+}
+
+// kueueCondition returns the status, reason, and message of a condition of
+// a Kueue object.
+func kueueCondition(obj *unstructured.Unstructured, condType string) (status, reason, message string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, _ := c.(map[string]interface{})
+		if t, _ := cond["type"].(string); t != condType {
+			continue
+		}
+		status, _ = cond["status"].(string)
+		reason, _ = cond["reason"].(string)
+		message, _ = cond["message"].(string)
+		return status, reason, message
+	}
+	return "", "", ""
+}
+
+// queueActive reports whether a ClusterQueue or LocalQueue is active and,
+// if not, why.
+func queueActive(obj *unstructured.Unstructured) (bool, string) {
+	status, reason, message := kueueCondition(obj, "Active")
+	if status == "True" {
+		return true, ""
+	}
+	switch {
+	case reason != "" && message != "":
+		return false, reason + ": " + message
+	case message != "":
+		return false, message
+	case reason != "":
+		return false, reason
+	}
+	return false, "not yet reconciled by Kueue"
+}
+
+func nestedInt64(obj *unstructured.Unstructured, fields ...string) int64 {
+	v, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+	return v
+}
+
+// clusterQueueQuotas returns the quota of every flavor and resource of a
+// ClusterQueue with the usage Kueue reports for it.
+func clusterQueueQuotas(cq *unstructured.Unstructured) []ResourceQuota {
+	type key struct{ flavor, resource string }
+	usage := make(map[key][2]string)
+	flavorsUsage, _, _ := unstructured.NestedSlice(cq.Object, "status", "flavorsUsage")
+	for _, f := range flavorsUsage {
+		flavor, _ := f.(map[string]interface{})
+		name, _ := flavor["name"].(string)
+		resources, _ := flavor["resources"].([]interface{})
+		for _, r := range resources {
+			res, _ := r.(map[string]interface{})
+			resName, _ := res["name"].(string)
+			total, _ := res["total"].(string)
+			borrowed, _ := res["borrowed"].(string)
+			usage[key{name, resName}] = [2]string{total, borrowed}
+		}
+	}
+
+	var quotas []ResourceQuota
+	groups, _, _ := unstructured.NestedSlice(cq.Object, "spec", "resourceGroups")
+	for _, g := range groups {
+		group, _ := g.(map[string]interface{})
+		flavors, _ := group["flavors"].([]interface{})
+		for _, f := range flavors {
+			flavor, _ := f.(map[string]interface{})
+			name, _ := flavor["name"].(string)
+			resources, _ := flavor["resources"].([]interface{})
+			for _, r := range resources {
+				res, _ := r.(map[string]interface{})
+				resName, _ := res["name"].(string)
+				q := ResourceQuota{Flavor: name, Resource: resName, Used: "0"}
+				q.NominalQuota = quantityString(res["nominalQuota"])
+				q.BorrowingLimit = quantityString(res["borrowingLimit"])
+				if u, ok := usage[key{name, resName}]; ok {
+					if u[0] != "" {
+						q.Used = u[0]
+					}
+					if u[1] != "" && u[1] != "0" {
+						q.Borrowed = u[1]
+					}
+				}
+				quotas = append(quotas, q)
+			}
+		}
+	}
+	return quotas
+}
+
+// quantityString formats a quantity field, which the API server returns as
+// a string or, for whole numbers written as such, a number.
+func quantityString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return fmt.Sprint(v)
+	case float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+func summarizeClusterQueue(cq *unstructured.Unstructured) ClusterQueueSummary {
+	cohort, _, _ := unstructured.NestedString(cq.Object, "spec", "cohort")
+	active, reason := queueActive(cq)
+	return ClusterQueueSummary{
+		Name:              cq.GetName(),
+		Cohort:            cohort,
+		Active:            active,
+		InactiveReason:    reason,
+		PendingWorkloads:  nestedInt64(cq, "status", "pendingWorkloads"),
+		AdmittedWorkloads: nestedInt64(cq, "status", "admittedWorkloads"),
+		Quotas:            clusterQueueQuotas(cq),
+	}
+}
+
+func summarizeLocalQueue(lq *unstructured.Unstructured) LocalQueueSummary {
+	clusterQueue, _, _ := unstructured.NestedString(lq.Object, "spec", "clusterQueue")
+	active, reason := queueActive(lq)
+	return LocalQueueSummary{
+		Namespace:         lq.GetNamespace(),
+		Name:              lq.GetName(),
+		ClusterQueue:      clusterQueue,
+		Active:            active,
+		InactiveReason:    reason,
+		PendingWorkloads:  nestedInt64(lq, "status", "pendingWorkloads"),
+		AdmittedWorkloads: nestedInt64(lq, "status", "admittedWorkloads"),
+	}
+}
+
+// workloadPending reports whether a Workload is waiting for admission.
+func workloadPending(wl *unstructured.Unstructured) bool {
+	if status, _, _ := kueueCondition(wl, "Finished"); status == "True" {
+		return false
+	}
+	status, _, _ := kueueCondition(wl, "Admitted")
+	return status != "True"
+}
+
+// workloadPendingReason explains why a pending Workload is not admitted:
+// it was deactivated, its quota is reserved but admission checks are
+// outstanding, or Kueue could not reserve quota for it.
+func workloadPendingReason(wl *unstructured.Unstructured) string {
+	var parts []string
+	if active, found, _ := unstructured.NestedBool(wl.Object, "spec", "active"); found && !active {
+		parts = append(parts, "deactivated (spec.active is false)")
+	}
+	if status, reason, message := kueueCondition(wl, "Evicted"); status == "True" {
+		parts = append(parts, "evicted: "+joinNonEmpty(": ", reason, message))
+	}
+	if requeueAt, found, _ := unstructured.NestedString(wl.Object, "status", "requeueState", "requeueAt"); found && requeueAt != "" {
+		parts = append(parts, "requeued at "+requeueAt)
+	}
+
+	status, reason, message := kueueCondition(wl, "QuotaReserved")
+	switch {
+	case status == "True":
+		var checks []string
+		admissionChecks, _, _ := unstructured.NestedSlice(wl.Object, "status", "admissionChecks")
+		for _, c := range admissionChecks {
+			check, _ := c.(map[string]interface{})
+			state, _ := check["state"].(string)
+			if state == "Ready" {
+				continue
+			}
+			name, _ := check["name"].(string)
+			msg, _ := check["message"].(string)
+			checks = append(checks, joinNonEmpty(": ", fmt.Sprintf("%s (%s)", name, state), msg))
+		}
+		if len(checks) > 0 {
+			parts = append(parts, "quota reserved, waiting for admission checks: "+strings.Join(checks, "; "))
+		} else {
+			parts = append(parts, "quota reserved, waiting for admission")
+		}
+	case message != "":
+		parts = append(parts, message)
+	case reason != "":
+		parts = append(parts, reason)
+	default:
+		queue, _, _ := unstructured.NestedString(wl.Object, "spec", "queueName")
+		parts = append(parts, fmt.Sprintf("not yet considered by Kueue; check that LocalQueue %q exists and is active", queue))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// workloadRequests sums the resource requests of every pod of a Workload.
+func workloadRequests(wl *unstructured.Unstructured) map[string]string {
+	totals := make(map[string]*resource.Quantity)
+	podSets, _, _ := unstructured.NestedSlice(wl.Object, "spec", "podSets")
+	for _, p := range podSets {
+		podSet, _ := p.(map[string]interface{})
+		count, _ := podSet["count"].(int64)
+		if count == 0 {
+			count = 1
+		}
+		containers, _, _ := unstructured.NestedSlice(podSet, "template", "spec", "containers")
+		for _, c := range containers {
+			container, _ := c.(map[string]interface{})
+			requests, _, _ := unstructured.NestedMap(container, "resources", "requests")
+			for name, v := range requests {
+				q, err := resource.ParseQuantity(quantityString(v))
+				if err != nil {
+					continue
+				}
+				total, ok := totals[name]
+				if !ok {
+					total = resource.NewQuantity(0, q.Format)
+					totals[name] = total
+				}
+				for i := int64(0); i < count; i++ {
+					total.Add(q)
+				}
+			}
+		}
+	}
+	if len(totals) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(totals))
+	for name, q := range totals {
+		out[name] = q.String()
+	}
+	return out
+}
+
+// workloadJob returns the kind and name of the job that owns a Workload.
+func workloadJob(wl *unstructured.Unstructured) string {
+	for _, ref := range wl.GetOwnerReferences() {
+		if ref.Controller != nil && *ref.Controller {
+			return ref.Kind + "/" + ref.Name
+		}
+	}
+	if refs := wl.GetOwnerReferences(); len(refs) > 0 {
+		return refs[0].Kind + "/" + refs[0].Name
+	}
+	return ""
+}
+
+// kueueFilter narrows pending workloads to one queue or job.
+type kueueFilter struct {
+	Namespace string
+	Queue     string
+	Job       string
+}
+
+func (f kueueFilter) matches(wl *unstructured.Unstructured) bool {
+	if f.Queue != "" {
+		if queue, _, _ := unstructured.NestedString(wl.Object, "spec", "queueName"); queue != f.Queue {
+			return false
+		}
+	}
+	if f.Job != "" {
+		job := workloadJob(wl)
+		if job == "" || (job[strings.Index(job, "/")+1:] != f.Job && wl.GetName() != f.Job) {
+			return false
+		}
+	}
+	return true
+}
+
+// pendingWorkloadsInCluster returns the pending Workloads of a cluster that
+// match filter, with the quota of their ClusterQueue for what they request.
+func pendingWorkloadsInCluster(ctx context.Context, dyn dynamic.Interface, clusterName string, filter kueueFilter, now time.Time) ([]PendingWorkload, error) {
+	workloads, err := listKueue(ctx, dyn, kueueWorkloadGVR, filter.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	localQueues, err := listKueue(ctx, dyn, localQueueGVR, filter.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	queueTargets := make(map[string]string, len(localQueues))
+	for i := range localQueues {
+		cq, _, _ := unstructured.NestedString(localQueues[i].Object, "spec", "clusterQueue")
+		queueTargets[localQueues[i].GetNamespace()+"/"+localQueues[i].GetName()] = cq
+	}
+	clusterQueues, err := listKueue(ctx, dyn, clusterQueueGVR, "")
+	if err != nil {
+		return nil, err
+	}
+	quotas := make(map[string][]ResourceQuota, len(clusterQueues))
+	for i := range clusterQueues {
+		quotas[clusterQueues[i].GetName()] = clusterQueueQuotas(&clusterQueues[i])
+	}
+
+	var pending []PendingWorkload
+	for i := range workloads {
+		wl := &workloads[i]
+		if !workloadPending(wl) || !filter.matches(wl) {
+			continue
+		}
+		queue, _, _ := unstructured.NestedString(wl.Object, "spec", "queueName")
+		priority, _, _ := unstructured.NestedInt64(wl.Object, "spec", "priority")
+		created := wl.GetCreationTimestamp().Time
+		p := PendingWorkload{
+			Cluster:      clusterName,
+			Namespace:    wl.GetNamespace(),
+			Name:         wl.GetName(),
+			Job:          workloadJob(wl),
+			Queue:        queue,
+			ClusterQueue: queueTargets[wl.GetNamespace()+"/"+queue],
+			Priority:     priority,
+			Requests:     workloadRequests(wl),
+			Created:      created,
+			Waiting:      now.Sub(created).Truncate(time.Second).String(),
+			Reason:       workloadPendingReason(wl),
+		}
+		for _, q := range quotas[p.ClusterQueue] {
+			if _, ok := p.Requests[q.Resource]; ok {
+				p.Quota = append(p.Quota, q)
+			}
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// validateOptionalNamespace validates the namespace argument of tools for
+// which it is optional.
+func validateOptionalNamespace(namespace string) error {
+	if namespace == "" {
+		return nil
+	}
+	if err := claude.ValidateK8sNamespace(namespace); err != nil {
+		return fmt.Errorf("invalid namespace: %w", err)
+	}
+	if err := server.ValidateNamespace(namespace); err != nil {
+		return fmt.Errorf("invalid namespace: %w", err)
+	}
+	return nil
+}
+
+// handleListKueueQueues lists the Kueue ClusterQueues and LocalQueues of
+// each cluster with their quota, usage, and backlog.
+func (s *Server) handleListKueueQueues(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Cluster   string `json:"cluster"`
+		Namespace string `json:"namespace"`
+	}
+	if args != nil {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if err := validateOptionalNamespace(params.Namespace); err != nil {
+		return nil, err
+	}
+
+	results, err := s.executor.Execute(ctx, params.Cluster, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		clusterQueues, err := listKueue(ctx, dyn, clusterQueueGVR, "")
+		if err != nil {
+			return nil, err
+		}
+		localQueues, err := listKueue(ctx, dyn, localQueueGVR, params.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		queues := ClusterKueueQueues{
+			Cluster:       clusterName,
+			ClusterQueues: make([]ClusterQueueSummary, 0, len(clusterQueues)),
+			LocalQueues:   make([]LocalQueueSummary, 0, len(localQueues)),
+		}
+		for i := range clusterQueues {
+			queues.ClusterQueues = append(queues.ClusterQueues, summarizeClusterQueue(&clusterQueues[i]))
+		}
+		for i := range localQueues {
+			queues.LocalQueues = append(queues.LocalQueues, summarizeLocalQueue(&localQueues[i]))
+		}
+		return queues, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortClusterResults(results)
+
+	out := KueueQueues{Clusters: []ClusterKueueQueues{}}
+	for _, result := range results {
+		if result.Error != "" {
+			out.Issues = append(out.Issues, fmt.Sprintf("%s: %s", result.Cluster, result.Error))
+			continue
+		}
+		out.Clusters = append(out.Clusters, result.Result.(ClusterKueueQueues))
+	}
+	return out, nil
+}
+
+// handleListPendingWorkloads lists the Kueue Workloads waiting for
+// admission on each cluster and explains why each is queued.
+func (s *Server) handleListPendingWorkloads(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Cluster   string `json:"cluster"`
+		Namespace string `json:"namespace"`
+		Queue     string `json:"queue"`
+		Job       string `json:"job"`
+	}
+	if args != nil {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if err := validateOptionalNamespace(params.Namespace); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{params.Queue, params.Job} {
+		if name == "" {
+			continue
+		}
+		if err := claude.ValidateK8sName(name); err != nil {
+			return nil, fmt.Errorf("invalid name: %w", err)
+		}
+	}
+	filter := kueueFilter{Namespace: params.Namespace, Queue: params.Queue, Job: params.Job}
+
+	now := time.Now()
+	results, err := s.executor.Execute(ctx, params.Cluster, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		return pendingWorkloadsInCluster(ctx, dyn, clusterName, filter, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortClusterResults(results)
+
+	out := PendingWorkloads{Workloads: []PendingWorkload{}}
+	for _, result := range results {
+		if result.Error != "" {
+			out.Issues = append(out.Issues, fmt.Sprintf("%s: %s", result.Cluster, result.Error))
+			continue
+		}
+		out.Workloads = append(out.Workloads, result.Result.([]PendingWorkload)...)
+	}
+	sort.SliceStable(out.Workloads, func(i, j int) bool {
+		return out.Workloads[i].Created.Before(out.Workloads[j].Created)
+	})
+	return out, nil
+}
+
+// sortClusterResults orders results by cluster name.
+func sortClusterResults(results []multicluster.ClusterResult) {
+	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+}
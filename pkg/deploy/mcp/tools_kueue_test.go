@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const kueueAPI = "/apis/kueue.x-k8s.io/v1beta1"
+
+func kueueCond(condType, status, reason, message string) interface{} {
+	return map[string]interface{}{"type": condType, "status": status, "reason": reason, "message": message}
+}
+
+// trainingWorkload returns the Workload of the Job name in namespace ml,
+// which runs workers pods requesting 4 GPUs each from the training queue.
+func trainingWorkload(name string, workers int64, created time.Time, conditions ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "kueue.x-k8s.io/v1beta1", "kind": "Workload",
+		"metadata": map[string]interface{}{
+			"name": "job-" + name + "-5f2c1", "namespace": "ml",
+			"creationTimestamp": created.UTC().Format(time.RFC3339),
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": "batch/v1", "kind": "Job", "name": name, "uid": name, "controller": true,
+			}},
+		},
+		"spec": map[string]interface{}{
+			"queueName": "training",
+			"podSets": []interface{}{map[string]interface{}{
+				"name": "main", "count": workers,
+				"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+					map[string]interface{}{"name": "trainer", "resources": map[string]interface{}{
+						"requests": map[string]interface{}{"nvidia.com/gpu": "4", "cpu": "500m"},
+					}},
+				}}},
+			}},
+		},
+		"status": map[string]interface{}{"conditions": conditions},
+	}
+}
+
+// newKueueClusters returns a cluster gpu, whose gpu-queue ClusterQueue has
+// 8 A100 GPUs of which 4 are used, and a cluster cpu without Kueue objects.
+func newKueueClusters(t *testing.T) (*objectAPIServer, *Server) {
+	gpu, gpuURL := newObjectAPIServer(t, false)
+	_, cpuURL := newObjectAPIServer(t, false)
+	gpu.put(t, kueueAPI+"/clusterqueues/gpu-queue", map[string]interface{}{
+		"apiVersion": "kueue.x-k8s.io/v1beta1", "kind": "ClusterQueue",
+		"metadata": map[string]interface{}{"name": "gpu-queue"},
+		"spec": map[string]interface{}{
+			"cohort": "research",
+			"resourceGroups": []interface{}{map[string]interface{}{
+				"coveredResources": []interface{}{"nvidia.com/gpu"},
+				"flavors": []interface{}{map[string]interface{}{"name": "a100", "resources": []interface{}{
+					map[string]interface{}{"name": "nvidia.com/gpu", "nominalQuota": "8", "borrowingLimit": "0"},
+				}}},
+			}},
+		},
+		"status": map[string]interface{}{
+			"pendingWorkloads": 1, "admittedWorkloads": 1,
+			"flavorsUsage": []interface{}{map[string]interface{}{"name": "a100", "resources": []interface{}{
+				map[string]interface{}{"name": "nvidia.com/gpu", "total": "4"},
+			}}},
+			"conditions": []interface{}{kueueCond("Active", "True", "Ready", "Can admit new workloads")},
+		},
+	})
+	gpu.put(t, kueueAPI+"/namespaces/ml/localqueues/training", map[string]interface{}{
+		"apiVersion": "kueue.x-k8s.io/v1beta1", "kind": "LocalQueue",
+		"metadata": map[string]interface{}{"name": "training", "namespace": "ml"},
+		"spec":     map[string]interface{}{"clusterQueue": "gpu-queue"},
+		"status": map[string]interface{}{
+			"pendingWorkloads": 1, "admittedWorkloads": 1,
+			"conditions": []interface{}{kueueCond("Active", "True", "Ready", "")},
+		},
+	})
+	now := time.Now()
+	gpu.put(t, kueueAPI+"/namespaces/ml/workloads/job-finetune-5f2c1", trainingWorkload("finetune", 2, now.Add(-time.Hour),
+		kueueCond("QuotaReserved", "False", "Pending", "couldn't assign flavors to pod set main: insufficient unused quota for nvidia.com/gpu in flavor a100, 4 more needed")))
+	gpu.put(t, kueueAPI+"/namespaces/ml/workloads/job-eval-5f2c1", trainingWorkload("eval", 1, now.Add(-2*time.Hour),
+		kueueCond("QuotaReserved", "True", "QuotaReserved", ""), kueueCond("Admitted", "True", "Admitted", "")))
+	return gpu, newAtomicTestServer(t, map[string]string{"gpu": gpuURL, "cpu": cpuURL})
+}
+
+func TestWorkloadPendingReason(t *testing.T) {
+	wl := &unstructured.Unstructured{Object: trainingWorkload("a", 1, time.Now())}
+	assert.Equal(t, `not yet considered by Kueue; check that LocalQueue "training" exists and is active`, workloadPendingReason(wl))
+
+	wl = &unstructured.Unstructured{Object: trainingWorkload("a", 1, time.Now(),
+		kueueCond("QuotaReserved", "True", "QuotaReserved", ""),
+		kueueCond("Evicted", "True", "Preempted", "Preempted to accommodate a higher priority Workload"))}
+	wl.Object["status"].(map[string]interface{})["admissionChecks"] = []interface{}{
+		map[string]interface{}{"name": "provision-a100", "state": "Pending", "message": "Waiting for nodes"},
+		map[string]interface{}{"name": "budget", "state": "Ready"},
+	}
+	assert.Equal(t, "evicted: Preempted: Preempted to accommodate a higher priority Workload; quota reserved, waiting for admission checks: provision-a100 (Pending): Waiting for nodes", workloadPendingReason(wl))
+
+	wl = &unstructured.Unstructured{Object: trainingWorkload("a", 1, time.Now(), kueueCond("QuotaReserved", "False", "Inadmissible", "LocalQueue training doesn't exist"))}
+	_ = unstructured.SetNestedField(wl.Object, false, "spec", "active")
+	assert.Equal(t, "deactivated (spec.active is false); LocalQueue training doesn't exist", workloadPendingReason(wl))
+}
+
+func TestWorkloadRequests(t *testing.T) {
+	wl := &unstructured.Unstructured{Object: trainingWorkload("a", 3, time.Now())}
+	assert.Equal(t, map[string]string{"nvidia.com/gpu": "12", "cpu": "1500m"}, workloadRequests(wl))
+}
+
+func TestListKueueQueues(t *testing.T) {
+	_, server := newKueueClusters(t)
+
+	out, errText := callTool(t, server, "list_kueue_queues", map[string]interface{}{"cluster": "gpu"})
+	require.Empty(t, errText)
+	clusters := out["clusters"].([]interface{})
+	require.Len(t, clusters, 1)
+	gpu := clusters[0].(map[string]interface{})
+	cq := gpu["clusterQueues"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "gpu-queue", cq["name"])
+	assert.Equal(t, "research", cq["cohort"])
+	assert.Equal(t, true, cq["active"])
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"flavor": "a100", "resource": "nvidia.com/gpu", "nominalQuota": "8", "borrowingLimit": "0", "used": "4",
+	}}, cq["quotas"])
+	lq := gpu["localQueues"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "gpu-queue", lq["clusterQueue"])
+	assert.Equal(t, float64(1), lq["pendingWorkloads"])
+}
+
+func TestListPendingWorkloadsExplainsQueuedJobs(t *testing.T) {
+	_, server := newKueueClusters(t)
+
+	out, errText := callTool(t, server, "list_pending_workloads", map[string]interface{}{"job": "finetune"})
+	require.Empty(t, errText)
+	workloads := out["workloads"].([]interface{})
+	require.Len(t, workloads, 1, "admitted workloads are not pending")
+	wl := workloads[0].(map[string]interface{})
+	assert.Equal(t, "gpu", wl["cluster"])
+	assert.Equal(t, "Job/finetune", wl["job"])
+	assert.Equal(t, "gpu-queue", wl["clusterQueue"])
+	assert.Equal(t, map[string]interface{}{"nvidia.com/gpu": "8", "cpu": "1"}, wl["requests"])
+	assert.Contains(t, wl["reason"], "insufficient unused quota for nvidia.com/gpu in flavor a100, 4 more needed")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"flavor": "a100", "resource": "nvidia.com/gpu", "nominalQuota": "8", "borrowingLimit": "0", "used": "4",
+	}}, wl["quota"], "only the quota for requested resources is shown")
+
+	out, errText = callTool(t, server, "list_pending_workloads", map[string]interface{}{"job": "eval"})
+	require.Empty(t, errText)
+	assert.Empty(t, out["workloads"])
+
+	_, errText = callTool(t, server, "list_pending_workloads", map[string]interface{}{"namespace": "Not A Namespace"})
+	assert.Contains(t, errText, "invalid namespace")
+}
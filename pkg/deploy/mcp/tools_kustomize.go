@@ -169,6 +169,9 @@ func (s *Server) handleKustomizeApply(ctx context.Context, args json.RawMessage)
 	if err := validateManifestDocs(manifest); err != nil {
 		return nil, err
 	}
+	if err := s.checkManifestNamespaces(manifest); err != nil {
+		return nil, err
+	}
 
 	// Get target clusters
 	targetClusters := params.Clusters
@@ -281,6 +284,9 @@ func (s *Server) handleKustomizeDelete(ctx context.Context, args json.RawMessage
 	if err := validateManifestDocs(manifest); err != nil {
 		return nil, err
 	}
+	if err := s.checkManifestNamespaces(manifest); err != nil {
+		return nil, err
+	}
 
 	// Get target clusters
 	targetClusters := params.Clusters
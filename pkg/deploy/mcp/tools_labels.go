@@ -32,17 +32,28 @@ func (s *Server) handleAddLabels(ctx context.Context, args json.RawMessage) (int
 		Labels    map[string]string `json:"labels"`
 		Clusters  []string          `json:"clusters"`
 		DryRun    bool              `json:"dry_run"`
+		labelSelection
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	if params.Kind == "" || params.Name == "" {
-		return nil, fmt.Errorf("kind and name are required")
-	}
 	if len(params.Labels) == 0 {
 		return nil, fmt.Errorf("labels are required")
 	}
+	if params.Name == "" && params.selects() {
+		return s.labelBySelector(ctx, labelBatch{
+			labelSelection: params.labelSelection,
+			kind:           params.Kind,
+			namespace:      params.Namespace,
+			clusters:       params.Clusters,
+			set:            params.Labels,
+			dryRun:         params.DryRun,
+		})
+	}
+	if err := params.checkSingle(params.Kind, params.Name); err != nil {
+		return nil, err
+	}
 
 	// Validate namespace to prevent access to system namespaces (#377).
 	if params.Namespace != "" {
@@ -52,7 +63,10 @@ func (s *Server) handleAddLabels(ctx context.Context, args json.RawMessage) (int
 	}
 
 	// Get target clusters
-	targetClusters := params.Clusters
+	targetClusters, err := s.resolveClusters(params.Clusters, params.Environment)
+	if err != nil {
+		return nil, err
+	}
 	if len(targetClusters) == 0 {
 		clusters, err := s.manager.DiscoverClusters()
 		if err != nil {
@@ -177,17 +191,28 @@ func (s *Server) handleRemoveLabels(ctx context.Context, args json.RawMessage) (
 		Labels    []string `json:"labels"` // Label keys to remove
 		Clusters  []string `json:"clusters"`
 		DryRun    bool     `json:"dry_run"`
+		labelSelection
 	}
 	if err := json.Unmarshal(args, &params); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	if params.Kind == "" || params.Name == "" {
-		return nil, fmt.Errorf("kind and name are required")
-	}
 	if len(params.Labels) == 0 {
 		return nil, fmt.Errorf("labels are required")
 	}
+	if params.Name == "" && params.selects() {
+		return s.labelBySelector(ctx, labelBatch{
+			labelSelection: params.labelSelection,
+			kind:           params.Kind,
+			namespace:      params.Namespace,
+			clusters:       params.Clusters,
+			remove:         params.Labels,
+			dryRun:         params.DryRun,
+		})
+	}
+	if err := params.checkSingle(params.Kind, params.Name); err != nil {
+		return nil, err
+	}
 
 	// Validate namespace to prevent access to system namespaces (#377).
 	if params.Namespace != "" {
@@ -197,7 +222,10 @@ func (s *Server) handleRemoveLabels(ctx context.Context, args json.RawMessage) (
 	}
 
 	// Get target clusters
-	targetClusters := params.Clusters
+	targetClusters, err := s.resolveClusters(params.Clusters, params.Environment)
+	if err != nil {
+		return nil, err
+	}
 	if len(targetClusters) == 0 {
 		clusters, err := s.manager.DiscoverClusters()
 		if err != nil {
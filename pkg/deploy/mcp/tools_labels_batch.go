@@ -0,0 +1,331 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Limits on how many objects one selector-based label call may change.
+const (
+	defaultMaxLabelObjects = 50
+	maxLabelObjects        = 1000
+)
+
+// labelSelection are the arguments of add_labels and remove_labels that
+// select objects by selector instead of by name, and clusters by
+// environment.
+type labelSelection struct {
+	Selector      string `json:"selector"`
+	FieldSelector string `json:"field_selector"`
+	MaxObjects    int    `json:"max_objects"`
+	Environment   string `json:"environment"`
+}
+
+func (l labelSelection) selects() bool {
+	return l.Selector != "" || l.FieldSelector != ""
+}
+
+// checkSingle validates the arguments of a call that labels one named
+// object.
+func (l labelSelection) checkSingle(kind, name string) error {
+	if kind == "" || name == "" {
+		return fmt.Errorf("kind and name are required (or kind and a selector)")
+	}
+	if l.selects() {
+		return fmt.Errorf("name cannot be combined with selector or field_selector")
+	}
+	if l.MaxObjects != 0 {
+		return fmt.Errorf("max_objects only applies with selector or field_selector")
+	}
+	return nil
+}
+
+// labelBatch is a selector-based add_labels or remove_labels call. Exactly
+// one of set and remove is non-empty.
+type labelBatch struct {
+	labelSelection
+	kind      string
+	namespace string
+	clusters  []string
+	set       map[string]string
+	remove    []string
+	dryRun    bool
+}
+
+// LabelTarget is an object matched by a selector and how its labels
+// change.
+type LabelTarget struct {
+	Cluster   string   `json:"cluster"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Status    string   `json:"status"` // labeled, unlabeled, unchanged, failed, or would-label/would-unlabel
+	Changes   []string `json:"changes,omitempty"`
+	Message   string   `json:"message,omitempty"`
+}
+
+// labelBySelector adds or removes labels on every object of a kind that
+// matches a label and/or field selector in each target cluster. It first
+// lists the matches everywhere and refuses if more than max_objects would
+// change, so a loose selector cannot relabel a whole fleet; dry_run returns
+// that preview without changing anything.
+func (s *Server) labelBySelector(ctx context.Context, b labelBatch) (interface{}, error) {
+	if b.kind == "" {
+		return nil, fmt.Errorf("kind is required")
+	}
+	if b.namespace != "" {
+		if err := server.ValidateNamespace(b.namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	if _, err := labels.Parse(b.Selector); err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+	if _, err := fields.ParseSelector(b.FieldSelector); err != nil {
+		return nil, fmt.Errorf("invalid field_selector: %w", err)
+	}
+	for key, value := range b.set {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid label key %q: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid value for label %q: %s", key, strings.Join(errs, "; "))
+		}
+	}
+	switch {
+	case b.MaxObjects == 0:
+		b.MaxObjects = defaultMaxLabelObjects
+	case b.MaxObjects < 0 || b.MaxObjects > maxLabelObjects:
+		return nil, fmt.Errorf("max_objects must be between 1 and %d", maxLabelObjects)
+	}
+	dryRun := b.dryRun || approval.IsDryRun(ctx)
+
+	clusters, err := s.resolveClusters(b.clusters, b.Environment)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusters) == 0 {
+		if clusters, err = s.executor.ClusterNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Clusters may serve different custom resources, so the kind is
+	// resolved on each.
+	mappings, clusterErrors, err := s.resolveKindOn(ctx, clusters, b.kind)
+	if err != nil {
+		return nil, err
+	}
+	for _, mapping := range mappings {
+		if b.namespace != "" && mapping.ClusterScoped {
+			return nil, fmt.Errorf("%s is cluster-scoped; omit namespace", b.kind)
+		}
+	}
+
+	// Preview: find what would change in every cluster before changing
+	// anything.
+	results, err := s.executor.ExecuteOnSelected(ctx, sortedKeysOf(mappings), func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.matchLabelTargets(ctx, clusterName, mappings[clusterName].GVR, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		targets    []LabelTarget
+		perCluster = make(map[string]int)
+		toChange   int
+	)
+	for _, r := range results {
+		if r.Error != "" {
+			clusterErrors[r.Cluster] = r.Error
+			continue
+		}
+		matched, _ := r.Result.([]LabelTarget)
+		for _, t := range matched {
+			if t.Status != "unchanged" {
+				perCluster[t.Cluster]++
+				toChange++
+			}
+		}
+		targets = append(targets, matched...)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		a, c := targets[i], targets[j]
+		if a.Cluster != c.Cluster {
+			return a.Cluster < c.Cluster
+		}
+		if a.Namespace != c.Namespace {
+			return a.Namespace < c.Namespace
+		}
+		return a.Name < c.Name
+	})
+	if toChange > b.MaxObjects {
+		return nil, fmt.Errorf("selector would change %d objects (%s), more than max_objects (%d); narrow the selector or raise max_objects",
+			toChange, formatClusterCounts(perCluster), b.MaxObjects)
+	}
+
+	response := map[string]interface{}{
+		"kind":           b.kind,
+		"selector":       b.Selector,
+		"fieldSelector":  b.FieldSelector,
+		"targetClusters": clusters,
+		"matched":        len(targets),
+		"toChange":       toChange,
+		"dryRun":         dryRun,
+		"clusterErrors":  clusterErrors,
+	}
+	if b.set != nil {
+		response["labels"] = b.set
+	} else {
+		response["labelKeys"] = b.remove
+	}
+	if dryRun || toChange == 0 {
+		response["results"] = targets
+		return response, nil
+	}
+
+	patch := buildLabelPatch(b.set, false)
+	if b.set == nil {
+		remove := make(map[string]string, len(b.remove))
+		for _, key := range b.remove {
+			remove[key] = ""
+		}
+		patch = buildLabelPatch(remove, true)
+	}
+	done := "labeled"
+	if b.set == nil {
+		done = "unlabeled"
+	}
+	byCluster := make(map[string][]int)
+	for i, t := range targets {
+		if t.Status != "unchanged" {
+			byCluster[t.Cluster] = append(byCluster[t.Cluster], i)
+		}
+	}
+	applied, err := s.executor.ExecuteOnSelected(ctx, sortedKeysOf(byCluster), func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		statuses := make(map[int]error, len(byCluster[clusterName]))
+		for _, i := range byCluster[clusterName] {
+			t := targets[i]
+			_, statuses[i] = dyn.Resource(mappings[clusterName].GVR).Namespace(t.Namespace).Patch(ctx, t.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+		}
+		return statuses, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	successCount := 0
+	for _, r := range applied {
+		for _, i := range byCluster[r.Cluster] {
+			if r.Error != "" {
+				targets[i].Status, targets[i].Message = "failed", r.Error
+				continue
+			}
+			if patchErr := r.Result.(map[int]error)[i]; patchErr != nil {
+				targets[i].Status, targets[i].Message = "failed", patchErr.Error()
+				continue
+			}
+			targets[i].Status = done
+			successCount++
+		}
+	}
+	response["successCount"] = successCount
+	response["results"] = targets
+	return response, nil
+}
+
+// resolveKindOn resolves kind on each of clusters. Clusters where it does
+// not resolve are returned as errors, by cluster; it fails if kind
+// resolves on none of them.
+func (s *Server) resolveKindOn(ctx context.Context, clusters []string, kind string) (map[string]kubemanifest.Mapping, map[string]string, error) {
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, _ *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.resolveKind(clusterName, kind)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	mappings := make(map[string]kubemanifest.Mapping, len(results))
+	clusterErrors := make(map[string]string)
+	for _, r := range results {
+		if r.Error != "" {
+			clusterErrors[r.Cluster] = r.Error
+			continue
+		}
+		mappings[r.Cluster] = r.Result.(kubemanifest.Mapping)
+	}
+	if len(mappings) == 0 && len(clusterErrors) > 0 {
+		first := sortedKeysOf(clusterErrors)[0]
+		return nil, nil, fmt.Errorf("%s: %s", first, clusterErrors[first])
+	}
+	return mappings, clusterErrors, nil
+}
+
+// matchLabelTargets lists the objects matching the batch's selectors in
+// one cluster and works out how labeling would change each. Objects in
+// system namespaces are never matched.
+func (s *Server) matchLabelTargets(ctx context.Context, clusterName string, gvr schema.GroupVersionResource, b labelBatch) ([]LabelTarget, error) {
+	dyn, err := s.dynamicClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	list, err := dyn.Resource(gvr).Namespace(b.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: b.Selector,
+		FieldSelector: b.FieldSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	pending, unchanged := "would-label", "unchanged"
+	if b.set == nil {
+		pending = "would-unlabel"
+	}
+	targets := make([]LabelTarget, 0, len(list.Items))
+	for _, obj := range list.Items {
+		if ns := obj.GetNamespace(); ns != "" && server.ValidateNamespace(ns) != nil {
+			continue
+		}
+		current := obj.GetLabels()
+		t := LabelTarget{Cluster: clusterName, Namespace: obj.GetNamespace(), Name: obj.GetName(), Status: unchanged}
+		for _, key := range sortedKeysOf(b.set) {
+			value := b.set[key]
+			if old, ok := current[key]; !ok {
+				t.Changes = append(t.Changes, fmt.Sprintf("+%s=%s", key, value))
+			} else if old != value {
+				t.Changes = append(t.Changes, fmt.Sprintf("%s: %s -> %s", key, old, value))
+			}
+		}
+		for _, key := range b.remove {
+			if old, ok := current[key]; ok {
+				t.Changes = append(t.Changes, fmt.Sprintf("-%s=%s", key, old))
+			}
+		}
+		if len(t.Changes) > 0 {
+			t.Status = pending
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+func formatClusterCounts(counts map[string]int) string {
+	parts := make([]string, 0, len(counts))
+	for _, cluster := range sortedKeysOf(counts) {
+		parts = append(parts, fmt.Sprintf("%s: %d", cluster, counts[cluster]))
+	}
+	return strings.Join(parts, ", ")
+}
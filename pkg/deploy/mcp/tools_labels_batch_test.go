@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func deploymentPath(namespace, name string) string {
+	return fmt.Sprintf("/apis/apps/v1/namespaces/%s/deployments/%s", namespace, name)
+}
+
+func putLabeledDeployment(t *testing.T, cluster *objectAPIServer, namespace, name string, labels map[string]string) {
+	t.Helper()
+	cluster.put(t, deploymentPath(namespace, name), &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+	})
+}
+
+func objectLabels(cluster *objectAPIServer, path string) map[string]string {
+	labels, _, _ := unstructured.NestedStringMap(cluster.get(path), "metadata", "labels")
+	return labels
+}
+
+// newLabelClusters returns prod clusters east and west and a dev cluster,
+// each with payments Deployments api and worker and a shop Deployment web.
+func newLabelClusters(t *testing.T) (east, west, dev *objectAPIServer, server *Server) {
+	east, eastURL := newObjectAPIServer(t, false)
+	west, westURL := newObjectAPIServer(t, false)
+	dev, devURL := newObjectAPIServer(t, false)
+	for _, cluster := range []*objectAPIServer{east, west, dev} {
+		putLabeledDeployment(t, cluster, "payments", "api", map[string]string{"tier": "backend"})
+		putLabeledDeployment(t, cluster, "payments", "worker", map[string]string{"tier": "backend", "team": "payments"})
+		putLabeledDeployment(t, cluster, "shop", "web", map[string]string{"tier": "frontend"})
+	}
+	server = newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL, "dev": devURL})
+	server.environments = []environment{{Name: "dev", Clusters: []string{"dev"}}, {Name: "prod", Clusters: []string{"east", "west"}}}
+	return east, west, dev, server
+}
+
+func TestAddLabelsBySelectorPreviewsThenApplies(t *testing.T) {
+	east, west, dev, server := newLabelClusters(t)
+	args := map[string]interface{}{
+		"kind": "Deployment", "namespace": "payments", "selector": "tier=backend",
+		"labels": map[string]string{"team": "payments"}, "environment": "prod", "dry_run": true,
+	}
+
+	out, errText := callTool(t, server, "add_labels", args)
+	require.Empty(t, errText)
+	assert.EqualValues(t, 4, out["matched"])
+	assert.EqualValues(t, 2, out["toChange"])
+	results := out["results"].([]interface{})
+	require.Len(t, results, 4)
+	first := results[0].(map[string]interface{})
+	assert.Equal(t, "east", first["cluster"])
+	assert.Equal(t, "api", first["name"])
+	assert.Equal(t, "would-label", first["status"])
+	assert.Equal(t, []interface{}{"+team=payments"}, first["changes"])
+	assert.Equal(t, "unchanged", results[1].(map[string]interface{})["status"])
+	assert.NotContains(t, objectLabels(east, deploymentPath("payments", "api")), "team")
+
+	args["dry_run"] = false
+	out, errText = callTool(t, server, "add_labels", args)
+	require.Empty(t, errText)
+	assert.EqualValues(t, 2, out["successCount"])
+	for _, cluster := range []*objectAPIServer{east, west} {
+		assert.Equal(t, map[string]string{"tier": "backend", "team": "payments"}, objectLabels(cluster, deploymentPath("payments", "api")))
+		assert.Equal(t, map[string]string{"tier": "frontend"}, objectLabels(cluster, deploymentPath("shop", "web")))
+	}
+	assert.NotContains(t, objectLabels(dev, deploymentPath("payments", "api")), "team", "only prod clusters are labeled")
+}
+
+func TestLabelsBySelectorRefuseOverMaxObjects(t *testing.T) {
+	_, _, _, server := newLabelClusters(t)
+
+	_, errText := callTool(t, server, "add_labels", map[string]interface{}{
+		"kind": "Deployment", "selector": "tier", "labels": map[string]string{"owner": "sre"}, "max_objects": 5,
+	})
+	assert.Contains(t, errText, "would change 9 objects (dev: 3, east: 3, west: 3)")
+
+	out, errText := callTool(t, server, "add_labels", map[string]interface{}{
+		"kind": "Deployment", "selector": "tier", "labels": map[string]string{"owner": "sre"}, "max_objects": 9,
+	})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 9, out["successCount"])
+}
+
+func TestRemoveLabelsBySelector(t *testing.T) {
+	east, _, dev, server := newLabelClusters(t)
+
+	out, errText := callTool(t, server, "remove_labels", map[string]interface{}{
+		"kind": "Deployment", "selector": "team=payments", "labels": []string{"team"}, "clusters": []string{"east", "dev"},
+	})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 2, out["successCount"])
+	assert.Equal(t, map[string]string{"tier": "backend"}, objectLabels(east, deploymentPath("payments", "worker")))
+	assert.Equal(t, map[string]string{"tier": "backend"}, objectLabels(dev, deploymentPath("payments", "worker")))
+}
+
+func TestLabelsBySelectorInvalidArgs(t *testing.T) {
+	_, _, _, server := newLabelClusters(t)
+	labels := map[string]string{"team": "payments"}
+	for _, args := range []map[string]interface{}{
+		{"selector": "tier=backend", "labels": labels},
+		{"kind": "Gizmo", "selector": "tier=backend", "labels": labels},
+		{"kind": "Deployment", "selector": "tier in (", "labels": labels},
+		{"kind": "Deployment", "field_selector": "metadata.name", "labels": labels},
+		{"kind": "Deployment", "namespace": "kube-system", "selector": "tier", "labels": labels},
+		{"kind": "Node", "namespace": "payments", "selector": "tier", "labels": labels},
+		{"kind": "Deployment", "name": "api", "selector": "tier", "labels": labels},
+		{"kind": "Deployment", "selector": "tier", "labels": labels, "max_objects": 5000},
+		{"kind": "Deployment", "selector": "tier", "labels": map[string]string{"bad key": "x"}},
+		{"kind": "Deployment", "selector": "tier", "labels": labels, "environment": "qa"},
+		{"kind": "Deployment", "selector": "tier", "labels": labels, "environment": "prod", "clusters": []string{"east"}},
+	} {
+		_, errText := callTool(t, server, "add_labels", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+}
+
+func TestAddLabelsBySelectorClusterScopedKind(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	putNode(t, cluster, "gpu-1", map[string]string{"gpu": "true"})
+	putNode(t, cluster, "cpu-1", map[string]string{"gpu": "false"})
+	server := newAtomicTestServer(t, map[string]string{"east": url})
+
+	out, errText := callTool(t, server, "add_labels", map[string]interface{}{
+		"kind": "no", "selector": "gpu=true", "labels": map[string]string{"accelerator": "a100"},
+	})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 1, out["successCount"])
+	assert.Equal(t, map[string]string{"gpu": "true", "accelerator": "a100"}, objectLabels(cluster, "/api/v1/nodes/gpu-1"))
+	assert.Equal(t, map[string]string{"gpu": "false"}, objectLabels(cluster, "/api/v1/nodes/cpu-1"))
+}
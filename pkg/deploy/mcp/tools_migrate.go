@@ -0,0 +1,538 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// migratedFromAnnotation records the source cluster on every copied
+	// object.
+	migratedFromAnnotation = "kubestellar.io/migrated-from"
+	// migratedToAnnotation records the target cluster on every source
+	// workload that migrate_app scaled down.
+	migratedToAnnotation = "kubestellar.io/migrated-to"
+	// preMigrationReplicasAnnotation records the replicas of a source
+	// workload before migrate_app scaled it down.
+	preMigrationReplicasAnnotation = "kubestellar.io/pre-migration-replicas"
+)
+
+// Migration steps, in the order they run.
+const (
+	migrateStepDiscover  = "discover"
+	migrateStepCopy      = "copy"
+	migrateStepHealth    = "health"
+	migrateStepScaleDown = "scale-down"
+)
+
+// Migration outcomes.
+const (
+	migrationComplete  = "migrated"
+	migrationCopied    = "copied"
+	migrationUnhealthy = "unhealthy"
+	migrationFailed    = "failed"
+)
+
+// portableKinds are the resources migrate_app and clone_namespace can copy
+// between clusters, in copy order, so that what a workload references
+// exists before the workload starts.
+var portableKinds = []struct {
+	Kind string
+	GVR  schema.GroupVersionResource
+}{
+	{"ServiceAccount", schema.GroupVersionResource{Version: "v1", Resource: "serviceaccounts"}},
+	{"ConfigMap", schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}},
+	{"Secret", schema.GroupVersionResource{Version: "v1", Resource: "secrets"}},
+	{"PersistentVolumeClaim", schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}},
+	{"Service", schema.GroupVersionResource{Version: "v1", Resource: "services"}},
+	{"Deployment", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}},
+	{"StatefulSet", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}},
+	{"DaemonSet", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}},
+	{"CronJob", schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}},
+	{"Ingress", schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}},
+	{"NetworkPolicy", schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}},
+	{"HorizontalPodAutoscaler", schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}},
+	{"PodDisruptionBudget", schema.GroupVersionResource{Group: "policy", Version: "v1", Resource: "poddisruptionbudgets"}},
+}
+
+// portableGVR returns the resource of a kind in portableKinds.
+func portableGVR(kind string) (schema.GroupVersionResource, bool) {
+	for _, k := range portableKinds {
+		if k.Kind == kind {
+			return k.GVR, true
+		}
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+// MigrationStep is one step of migrate_app.
+type MigrationStep struct {
+	Step     string `json:"step"`
+	Cluster  string `json:"cluster"`
+	Resource string `json:"resource,omitempty"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+}
+
+// MigrationReport is the result of migrate_app.
+type MigrationReport struct {
+	App       string          `json:"app"`
+	Namespace string          `json:"namespace,omitempty"`
+	Source    string          `json:"source"`
+	Target    string          `json:"target"`
+	DryRun    bool            `json:"dryRun"`
+	Outcome   string          `json:"outcome"`
+	Steps     []MigrationStep `json:"steps"`
+}
+
+func (r *MigrationReport) add(step, cluster, resource, status, message string) {
+	r.Steps = append(r.Steps, MigrationStep{Step: step, Cluster: cluster, Resource: resource, Status: status, Message: message})
+}
+
+// migrationObject is a source object to copy, by kind.
+type migrationObject struct {
+	Kind string
+	Name string
+}
+
+// handleMigrateApp copies an app's workloads and the ConfigMaps, Secrets,
+// PersistentVolumeClaims, and Services they use from one cluster to
+// another, waits for the workloads to become ready on the target, and
+// optionally scales the source down.
+func (s *Server) handleMigrateApp(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		App             string `json:"app"`
+		Namespace       string `json:"namespace"`
+		SourceCluster   string `json:"source_cluster"`
+		TargetCluster   string `json:"target_cluster"`
+		ScaleDownSource bool   `json:"scale_down_source"`
+		TimeoutSeconds  int    `json:"timeout_seconds"`
+		DryRun          bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.App); err != nil {
+		return nil, fmt.Errorf("invalid app name: %w", err)
+	}
+	if params.Namespace != "" {
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	if params.SourceCluster == "" || params.TargetCluster == "" {
+		return nil, fmt.Errorf("source_cluster and target_cluster are required")
+	}
+	if params.SourceCluster == params.TargetCluster {
+		return nil, fmt.Errorf("source_cluster and target_cluster must differ")
+	}
+	if params.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative")
+	}
+	timeout := defaultHealthTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+
+	source, err := s.manager.GetClient(params.SourceCluster)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.manager.GetClient(params.TargetCluster)
+	if err != nil {
+		return nil, err
+	}
+	sourceDyn, err := s.dynamicClient(params.SourceCluster)
+	if err != nil {
+		return nil, err
+	}
+	targetDyn, err := s.dynamicClient(params.TargetCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MigrationReport{App: params.App, Source: params.SourceCluster, Target: params.TargetCluster, DryRun: dryRun}
+	namespace, objects, workloads, err := migrationObjects(ctx, source, params.SourceCluster, params.App, params.Namespace)
+	if err != nil {
+		report.Outcome = migrationFailed
+		report.add(migrateStepDiscover, params.SourceCluster, "", "failed", err.Error())
+		return report, nil
+	}
+	report.Namespace = namespace
+	for _, obj := range objects {
+		report.add(migrateStepDiscover, params.SourceCluster, obj.Kind+" "+namespace+"/"+obj.Name, "found", "")
+	}
+
+	if !copyToTarget(ctx, report, sourceDyn, targetDyn, target, namespace, objects) {
+		report.Outcome = migrationFailed
+		return report, nil
+	}
+	if dryRun {
+		report.Outcome = migrationCopied
+		return report, nil
+	}
+
+	manifests := make([]gitops.Manifest, 0, len(workloads))
+	for _, w := range workloads {
+		manifests = append(manifests, gitops.Manifest{Kind: w.Kind, Metadata: gitops.ManifestMetadata{Name: w.meta().GetName(), Namespace: namespace}})
+	}
+	pending, err := waitForReady(ctx, target, manifests, timeout)
+	switch {
+	case err != nil:
+		report.add(migrateStepHealth, params.TargetCluster, "", "failed", err.Error())
+	case len(pending) > 0:
+		report.add(migrateStepHealth, params.TargetCluster, "", "not-ready",
+			fmt.Sprintf("not ready within %s: %s; the source was left running", timeout, strings.Join(pending, ", ")))
+	default:
+		report.add(migrateStepHealth, params.TargetCluster, "", "ready", "")
+	}
+	if err != nil || len(pending) > 0 {
+		report.Outcome = migrationUnhealthy
+		return report, nil
+	}
+
+	report.Outcome = migrationCopied
+	if !params.ScaleDownSource {
+		return report, nil
+	}
+	report.Outcome = migrationComplete
+	for _, w := range workloads {
+		status, message := scaleDownForMigration(ctx, source, w, params.TargetCluster)
+		if status == "failed" {
+			report.Outcome = migrationFailed
+		}
+		report.add(migrateStepScaleDown, params.SourceCluster, w.String(), status, message)
+	}
+	return report, nil
+}
+
+// migrationObjects returns the app's workloads in the source cluster and
+// the objects to copy for them. All workloads must be in one namespace.
+func migrationObjects(ctx context.Context, client *kubernetes.Clientset, clusterName, app, namespace string) (string, []migrationObject, []appWorkload, error) {
+	found, err := listAppWorkloads(ctx, client, namespace, app)
+	if err != nil && len(found) == 0 {
+		return "", nil, nil, err
+	}
+	var workloads []appWorkload
+	namespaces := make(map[string]bool)
+	for _, w := range found {
+		if namespace == "" && server.ValidateNamespace(w.meta().GetNamespace()) != nil {
+			continue
+		}
+		workloads = append(workloads, w)
+		namespaces[w.meta().GetNamespace()] = true
+	}
+	switch {
+	case len(workloads) == 0:
+		return "", nil, nil, fmt.Errorf("app %s not found in cluster %s", app, clusterName)
+	case len(namespaces) > 1:
+		return "", nil, nil, fmt.Errorf("app %s runs in namespaces %s of cluster %s; set namespace to choose one",
+			app, strings.Join(sortedKeys(namespaces), ", "), clusterName)
+	}
+	ns := workloads[0].meta().GetNamespace()
+
+	refs := make(map[migrationObject]bool)
+	for _, w := range workloads {
+		template, _ := w.podTemplate()
+		for _, ref := range podReferences(template.Spec) {
+			refs[ref] = true
+		}
+		refs[migrationObject{Kind: w.Kind, Name: w.meta().GetName()}] = true
+	}
+
+	services, err := client.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("listing services: %w", err)
+	}
+	for _, svc := range services.Items {
+		if len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		selector := labels.SelectorFromSet(svc.Spec.Selector)
+		for _, w := range workloads {
+			template, _ := w.podTemplate()
+			if selector.Matches(labels.Set(template.Labels)) {
+				refs[migrationObject{Kind: "Service", Name: svc.Name}] = true
+			}
+		}
+	}
+
+	order := make(map[string]int, len(portableKinds))
+	for i, g := range portableKinds {
+		order[g.Kind] = i
+	}
+	objects := make([]migrationObject, 0, len(refs))
+	for ref := range refs {
+		objects = append(objects, ref)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Kind != objects[j].Kind {
+			return order[objects[i].Kind] < order[objects[j].Kind]
+		}
+		return objects[i].Name < objects[j].Name
+	})
+	return ns, objects, workloads, nil
+}
+
+// podReferences returns the ConfigMaps, Secrets, and PersistentVolumeClaims
+// a pod spec uses.
+func podReferences(spec corev1.PodSpec) []migrationObject {
+	var refs []migrationObject
+	add := func(kind, name string) {
+		if name != "" {
+			refs = append(refs, migrationObject{Kind: kind, Name: name})
+		}
+	}
+	for _, v := range spec.Volumes {
+		switch {
+		case v.ConfigMap != nil:
+			add("ConfigMap", v.ConfigMap.Name)
+		case v.Secret != nil:
+			add("Secret", v.Secret.SecretName)
+		case v.PersistentVolumeClaim != nil:
+			add("PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName)
+		case v.Projected != nil:
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil {
+					add("ConfigMap", src.ConfigMap.Name)
+				}
+				if src.Secret != nil {
+					add("Secret", src.Secret.Name)
+				}
+			}
+		}
+	}
+	for _, ps := range spec.ImagePullSecrets {
+		add("Secret", ps.Name)
+	}
+	containers := append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, c := range containers {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				add("ConfigMap", from.ConfigMapRef.Name)
+			}
+			if from.SecretRef != nil {
+				add("Secret", from.SecretRef.Name)
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				add("ConfigMap", ref.Name)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				add("Secret", ref.Name)
+			}
+		}
+	}
+	return refs
+}
+
+// copyToTarget creates the namespace and objects on the target cluster,
+// leaving objects that already exist there untouched. It reports false if
+// a copy failed.
+func copyToTarget(ctx context.Context, report *MigrationReport, sourceDyn, targetDyn dynamic.Interface, target kubernetes.Interface, namespace string, objects []migrationObject) bool {
+	status, nsMissing, err := ensureNamespace(ctx, target, namespace, migratedFromAnnotation, report.Source)
+	if err != nil {
+		report.add(migrateStepCopy, report.Target, "Namespace "+namespace, "failed", err.Error())
+		return false
+	}
+	if status != "" {
+		report.add(migrateStepCopy, report.Target, "Namespace "+namespace, status, "")
+	}
+
+	ok := true
+	for _, o := range objects {
+		resource := o.Kind + " " + namespace + "/" + o.Name
+		gvr, _ := portableGVR(o.Kind)
+		status, err := copyObject(ctx, sourceDyn, targetDyn, gvr, namespace, o.Name, migratedFromAnnotation, report.Source, nsMissing)
+		if err != nil {
+			report.add(migrateStepCopy, report.Target, resource, "failed", err.Error())
+			ok = false
+			continue
+		}
+		report.add(migrateStepCopy, report.Target, resource, status, "")
+	}
+	return ok
+}
+
+// copyObject copies one object from the source to the target cluster,
+// annotating the copy with key=value.
+func copyObject(ctx context.Context, sourceDyn, targetDyn dynamic.Interface, gvr schema.GroupVersionResource, namespace, name, key, value string, nsMissing bool) (string, error) {
+	obj, err := sourceDyn.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// A reference to a missing (often optional) object.
+		return "missing-in-source", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if obj.GetKind() == "Secret" && obj.Object["type"] == string(corev1.SecretTypeServiceAccountToken) {
+		return "skipped", nil
+	}
+	c := portableCopy(obj)
+	annotate(c, key, value)
+	return createIfMissing(ctx, targetDyn, gvr, c, nsMissing)
+}
+
+// ensureNamespace creates namespace on the target cluster, annotated with
+// key=value, unless it exists. It returns the create status, empty if the
+// namespace existed, and whether the namespace is still missing because
+// the create was a dry run.
+func ensureNamespace(ctx context.Context, target kubernetes.Interface, namespace, key, value string) (string, bool, error) {
+	_, err := target.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+	if err == nil {
+		return "", false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return "", false, err
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace, Annotations: map[string]string{key: value}}}
+	if _, err := target.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil {
+		return "", false, err
+	}
+	dryRun := approval.IsDryRun(ctx)
+	return createdStatus(dryRun), dryRun, nil
+}
+
+// createIfMissing creates obj on the target cluster unless an object of
+// that name exists there. When nsMissing, obj's namespace was only created
+// by a dry run, so the API server cannot validate obj and it is reported
+// as if created.
+func createIfMissing(ctx context.Context, targetDyn dynamic.Interface, gvr schema.GroupVersionResource, obj *unstructured.Unstructured, nsMissing bool) (string, error) {
+	dryRun := approval.IsDryRun(ctx)
+	if nsMissing {
+		return createdStatus(dryRun), nil
+	}
+	client := targetDyn.Resource(gvr).Namespace(obj.GetNamespace())
+	if _, err := client.Get(ctx, obj.GetName(), metav1.GetOptions{}); err == nil {
+		return "exists", nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", err
+	}
+	if _, err := client.Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+		return "", err
+	}
+	return createdStatus(dryRun), nil
+}
+
+// annotate sets the annotation key=value on obj.
+func annotate(obj *unstructured.Unstructured, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+// portableCopy strips the fields of obj that the source cluster assigned,
+// so it can be created on another cluster.
+func portableCopy(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	c := obj.DeepCopy()
+	for _, field := range []string{"uid", "resourceVersion", "creationTimestamp", "generation", "managedFields", "ownerReferences", "selfLink", "deletionTimestamp"} {
+		unstructured.RemoveNestedField(c.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(c.Object, "status")
+
+	annotations := c.GetAnnotations()
+	for key := range annotations {
+		if key == "kubectl.kubernetes.io/last-applied-configuration" || key == "deployment.kubernetes.io/revision" ||
+			strings.HasPrefix(key, "pv.kubernetes.io/") || strings.HasPrefix(key, "volume.beta.kubernetes.io/") ||
+			strings.HasPrefix(key, "volume.kubernetes.io/") {
+			delete(annotations, key)
+		}
+	}
+	if len(annotations) > 0 {
+		c.SetAnnotations(annotations)
+	} else {
+		unstructured.RemoveNestedField(c.Object, "metadata", "annotations")
+	}
+
+	switch c.GetKind() {
+	case "Service":
+		for _, field := range []string{"clusterIP", "clusterIPs", "healthCheckNodePort"} {
+			unstructured.RemoveNestedField(c.Object, "spec", field)
+		}
+		ports, _, _ := unstructured.NestedSlice(c.Object, "spec", "ports")
+		for _, p := range ports {
+			if m, ok := p.(map[string]interface{}); ok {
+				delete(m, "nodePort")
+			}
+		}
+		if len(ports) > 0 {
+			_ = unstructured.SetNestedSlice(c.Object, ports, "spec", "ports")
+		}
+	case "ServiceAccount":
+		// Token Secrets are created by the target cluster.
+		unstructured.RemoveNestedField(c.Object, "secrets")
+	case "PersistentVolumeClaim":
+		// The target provisions its own volume; data is not copied.
+		unstructured.RemoveNestedField(c.Object, "spec", "volumeName")
+	}
+	return c
+}
+
+// scaleDownForMigration scales a source workload to zero replicas and
+// records its previous replicas. DaemonSets cannot be scaled.
+func scaleDownForMigration(ctx context.Context, client *kubernetes.Clientset, w appWorkload, target string) (string, string) {
+	note := func(annotations map[string]string, replicas int32) map[string]string {
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[preMigrationReplicasAnnotation] = strconv.Itoa(int(replicas))
+		annotations[migratedToAnnotation] = target
+		return annotations
+	}
+	zero := int32(0)
+	switch {
+	case w.deployment != nil:
+		d := w.deployment.DeepCopy()
+		d.Annotations = note(d.Annotations, replicasOrDefault(d.Spec.Replicas))
+		d.Spec.Replicas = &zero
+		if _, err := client.AppsV1().Deployments(d.Namespace).Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+			return "failed", err.Error()
+		}
+	case w.statefulSet != nil:
+		ss := w.statefulSet.DeepCopy()
+		ss.Annotations = note(ss.Annotations, replicasOrDefault(ss.Spec.Replicas))
+		ss.Spec.Replicas = &zero
+		if _, err := client.AppsV1().StatefulSets(ss.Namespace).Update(ctx, ss, metav1.UpdateOptions{}); err != nil {
+			return "failed", err.Error()
+		}
+	default:
+		return "skipped", "DaemonSets cannot be scaled; delete it from the source once traffic has moved"
+	}
+	return "scaled-down", ""
+}
+
+func createdStatus(dryRun bool) string {
+	if dryRun {
+		return "would-create"
+	}
+	return "created"
+}
@@ -0,0 +1,205 @@
+package mcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	shopNSPath     = "/api/v1/namespaces/shop"
+	shopDeployPath = "/apis/apps/v1/namespaces/shop/deployments/web"
+	shopSvcPath    = "/api/v1/namespaces/shop/services/web"
+	shopOtherPath  = "/api/v1/namespaces/shop/services/other"
+	shopConfigPath = "/api/v1/namespaces/shop/configmaps/web-config"
+	shopSecretPath = "/api/v1/namespaces/shop/secrets/web-creds"
+	shopClaimPath  = "/api/v1/namespaces/shop/persistentvolumeclaims/web-data"
+)
+
+// newMigrationClusters returns a source cluster running Deployment web in
+// namespace shop, with the objects it uses, and an empty target cluster.
+func newMigrationClusters(t *testing.T, targetReady bool) (*objectAPIServer, *objectAPIServer, *Server) {
+	source, sourceURL := newObjectAPIServer(t, false)
+	target, targetURL := newObjectAPIServer(t, false)
+	target.readyDeployments = targetReady
+
+	replicas := int32(3)
+	source.put(t, shopDeployPath, &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", UID: "src-uid", ResourceVersion: "42", Labels: map[string]string{"app": "web"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:    "web",
+						Image:   "web:v1",
+						EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}}},
+					}},
+					Volumes: []corev1.Volume{
+						{Name: "creds", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: "web-creds"}}},
+						{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "web-data"}}},
+					},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 3},
+	})
+	source.put(t, shopSvcPath, &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec: corev1.ServiceSpec{
+			Type:       corev1.ServiceTypeNodePort,
+			Selector:   map[string]string{"app": "web"},
+			ClusterIP:  "10.0.0.12",
+			ClusterIPs: []string{"10.0.0.12"},
+			Ports:      []corev1.ServicePort{{Name: "http", Port: 80, NodePort: 30080}},
+		},
+	})
+	source.put(t, shopOtherPath, &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{Kind: "Service", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shop"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "other"}},
+	})
+	source.put(t, shopConfigPath, &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "shop"},
+		Data:       map[string]string{"MODE": "prod"},
+	})
+	source.put(t, shopSecretPath, &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-creds", Namespace: "shop"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	})
+	source.put(t, shopClaimPath, &corev1.PersistentVolumeClaim{
+		TypeMeta: metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-data", Namespace: "shop",
+			Annotations: map[string]string{"pv.kubernetes.io/bind-completed": "yes"}},
+		Spec:   corev1.PersistentVolumeClaimSpec{VolumeName: "pvc-1234"},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	})
+	return source, target, newAtomicTestServer(t, map[string]string{"src": sourceURL, "dst": targetURL})
+}
+
+func migrateArgs(extra map[string]interface{}) map[string]interface{} {
+	args := map[string]interface{}{"app": "web", "source_cluster": "src", "target_cluster": "dst"}
+	for k, v := range extra {
+		args[k] = v
+	}
+	return args
+}
+
+func TestMigrateAppCopiesAndScalesDownSource(t *testing.T) {
+	source, target, server := newMigrationClusters(t, true)
+
+	out, errText := callTool(t, server, "migrate_app", migrateArgs(map[string]interface{}{"scale_down_source": true}))
+	require.Empty(t, errText)
+	assert.Equal(t, migrationComplete, out["outcome"], "%v", out["steps"])
+	assert.Equal(t, "shop", out["namespace"])
+	assert.NotContains(t, mustMarshalJSON(t, out), "hunter2", "secret data is never reported")
+
+	for _, path := range []string{shopNSPath, shopDeployPath, shopSvcPath, shopConfigPath, shopSecretPath, shopClaimPath} {
+		assert.True(t, target.has(path), "%s is copied", path)
+	}
+	assert.False(t, target.has(shopOtherPath), "services of other apps are not copied")
+
+	deploy := target.get(shopDeployPath)
+	assert.Empty(t, deploy["metadata"].(map[string]interface{})["uid"])
+	annotations, _, _ := unstructured.NestedStringMap(deploy, "metadata", "annotations")
+	assert.Equal(t, "src", annotations[migratedFromAnnotation])
+
+	svc := target.get(shopSvcPath)
+	_, hasIP, _ := unstructured.NestedFieldNoCopy(svc, "spec", "clusterIP")
+	assert.False(t, hasIP, "the target assigns its own cluster IP")
+	ports, _, _ := unstructured.NestedSlice(svc, "spec", "ports")
+	assert.NotContains(t, ports[0], "nodePort")
+
+	claim := target.get(shopClaimPath)
+	_, hasVolume, _ := unstructured.NestedFieldNoCopy(claim, "spec", "volumeName")
+	assert.False(t, hasVolume, "the target provisions its own volume")
+	phase, _, _ := unstructured.NestedString(claim, "status", "phase")
+	assert.Empty(t, phase, "status is not copied")
+
+	src := source.get(shopDeployPath)
+	replicas, _, _ := unstructured.NestedFieldNoCopy(src, "spec", "replicas")
+	assert.EqualValues(t, 0, replicas)
+	srcAnnotations, _, _ := unstructured.NestedStringMap(src, "metadata", "annotations")
+	assert.Equal(t, "3", srcAnnotations[preMigrationReplicasAnnotation])
+	assert.Equal(t, "dst", srcAnnotations[migratedToAnnotation])
+}
+
+func TestMigrateAppLeavesExistingTargetObjects(t *testing.T) {
+	_, target, server := newMigrationClusters(t, true)
+	target.put(t, shopNSPath, map[string]interface{}{"apiVersion": "v1", "kind": "Namespace", "metadata": map[string]interface{}{"name": "shop"}})
+	target.put(t, shopConfigPath, map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]interface{}{"name": "web-config", "namespace": "shop"},
+		"data":     map[string]interface{}{"MODE": "staging"},
+	})
+
+	out, errText := callTool(t, server, "migrate_app", migrateArgs(nil))
+	require.Empty(t, errText)
+	assert.Equal(t, migrationCopied, out["outcome"])
+
+	statuses := map[string]string{}
+	for _, step := range out["steps"].([]interface{}) {
+		s := step.(map[string]interface{})
+		if s["step"] == migrateStepCopy {
+			statuses[s["resource"].(string)] = s["status"].(string)
+		}
+	}
+	assert.Equal(t, "exists", statuses["ConfigMap shop/web-config"])
+	assert.Equal(t, "created", statuses["Deployment shop/web"])
+	assert.NotContains(t, statuses, "Namespace shop")
+	data, _, _ := unstructured.NestedStringMap(target.get(shopConfigPath), "data")
+	assert.Equal(t, "staging", data["MODE"], "existing objects are not overwritten")
+}
+
+func TestMigrateAppKeepsSourceWhenTargetIsUnhealthy(t *testing.T) {
+	saved := healthPollInterval
+	healthPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = saved })
+
+	source, _, server := newMigrationClusters(t, false)
+	out, errText := callTool(t, server, "migrate_app", migrateArgs(map[string]interface{}{"scale_down_source": true, "timeout_seconds": 1}))
+	require.Empty(t, errText)
+	assert.Equal(t, migrationUnhealthy, out["outcome"])
+	replicas, _, _ := unstructured.NestedFieldNoCopy(source.get(shopDeployPath), "spec", "replicas")
+	assert.EqualValues(t, 3, replicas, "the source keeps running")
+}
+
+func TestMigrateAppDryRunChangesNothing(t *testing.T) {
+	source, target, server := newMigrationClusters(t, true)
+	out, errText := callTool(t, server, "migrate_app", migrateArgs(map[string]interface{}{"scale_down_source": true, "dry_run": true}))
+	require.Empty(t, errText)
+	assert.Equal(t, migrationCopied, out["outcome"])
+	assert.False(t, target.has(shopNSPath))
+	assert.False(t, target.has(shopDeployPath))
+	replicas, _, _ := unstructured.NestedFieldNoCopy(source.get(shopDeployPath), "spec", "replicas")
+	assert.EqualValues(t, 3, replicas)
+}
+
+func TestMigrateAppInvalidArgs(t *testing.T) {
+	_, _, server := newMigrationClusters(t, true)
+	for _, args := range []map[string]interface{}{
+		{"app": "web", "source_cluster": "src"},
+		{"app": "web", "source_cluster": "src", "target_cluster": "src"},
+		{"app": "bad;name", "source_cluster": "src", "target_cluster": "dst"},
+		migrateArgs(map[string]interface{}{"timeout_seconds": -1}),
+	} {
+		_, errText := callTool(t, server, "migrate_app", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+
+	out, errText := callTool(t, server, "migrate_app", migrateArgs(map[string]interface{}{"app": "missing"}))
+	require.Empty(t, errText)
+	assert.Equal(t, migrationFailed, out["outcome"])
+}
@@ -0,0 +1,312 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+	clientretry "k8s.io/client-go/util/retry"
+)
+
+// taintEffects are the effects a node taint may have.
+var taintEffects = []corev1.TaintEffect{corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute}
+
+// nodeTaintCall is a taint_nodes or untaint_nodes call. taint_nodes sets
+// add; untaint_nodes sets remove, whose taints match by key and, if set,
+// effect.
+type nodeTaintCall struct {
+	Nodes    []string `json:"nodes"`
+	Clusters []string `json:"clusters"`
+	DryRun   bool     `json:"dry_run"`
+	labelSelection
+	add    []corev1.Taint
+	remove []corev1.Taint
+}
+
+// NodeTaintTarget is a node selected by a taint call and how its taints
+// change.
+type NodeTaintTarget struct {
+	Cluster string   `json:"cluster"`
+	Name    string   `json:"name"`
+	Status  string   `json:"status"` // tainted, untainted, unchanged, failed, or would-taint/would-untaint
+	Changes []string `json:"changes,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// handleTaintNodes adds taints to, or updates the value of taints on, the
+// selected nodes.
+func (s *Server) handleTaintNodes(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		nodeTaintCall
+		Taints []corev1.Taint `json:"taints"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if len(params.Taints) == 0 {
+		return nil, fmt.Errorf("taints are required")
+	}
+	for _, t := range params.Taints {
+		if !slices.Contains(taintEffects, t.Effect) {
+			return nil, fmt.Errorf("taint %s needs an effect of NoSchedule, PreferNoSchedule, or NoExecute", t.Key)
+		}
+		if t.Value != "" {
+			if errs := validation.IsValidLabelValue(t.Value); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid value for taint %q: %s", t.Key, strings.Join(errs, "; "))
+			}
+		}
+	}
+	params.add = params.Taints
+	return s.changeNodeTaints(ctx, params.nodeTaintCall)
+}
+
+// handleUntaintNodes removes taints from the selected nodes.
+func (s *Server) handleUntaintNodes(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		nodeTaintCall
+		Taints []corev1.Taint `json:"taints"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if len(params.Taints) == 0 {
+		return nil, fmt.Errorf("taints are required")
+	}
+	for _, t := range params.Taints {
+		if t.Effect != "" && !slices.Contains(taintEffects, t.Effect) {
+			return nil, fmt.Errorf("invalid effect %q for taint %s: must be NoSchedule, PreferNoSchedule, or NoExecute", t.Effect, t.Key)
+		}
+	}
+	params.remove = params.Taints
+	return s.changeNodeTaints(ctx, params.nodeTaintCall)
+}
+
+// changeNodeTaints previews the change to the taints of every selected node
+// in each target cluster, refuses if more than max_objects nodes would
+// change, and otherwise applies it unless the call is a dry run, as
+// labelBySelector does for labels.
+func (s *Server) changeNodeTaints(ctx context.Context, c nodeTaintCall) (interface{}, error) {
+	if len(c.Nodes) == 0 && !c.selects() {
+		return nil, fmt.Errorf("nodes, selector, or field_selector is required")
+	}
+	for _, t := range append(append([]corev1.Taint{}, c.add...), c.remove...) {
+		if errs := validation.IsQualifiedName(t.Key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid taint key %q: %s", t.Key, strings.Join(errs, "; "))
+		}
+	}
+	if _, err := labels.Parse(c.Selector); err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+	if _, err := fields.ParseSelector(c.FieldSelector); err != nil {
+		return nil, fmt.Errorf("invalid field_selector: %w", err)
+	}
+	switch {
+	case c.MaxObjects == 0:
+		c.MaxObjects = defaultMaxLabelObjects
+	case c.MaxObjects < 0 || c.MaxObjects > maxLabelObjects:
+		return nil, fmt.Errorf("max_objects must be between 1 and %d", maxLabelObjects)
+	}
+	dryRun := c.DryRun || approval.IsDryRun(ctx)
+
+	clusters, err := s.resolveClusters(c.Clusters, c.Environment)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusters) == 0 {
+		if clusters, err = s.executor.ClusterNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return matchTaintTargets(ctx, client, clusterName, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		targets       []NodeTaintTarget
+		clusterErrors = make(map[string]string)
+		perCluster    = make(map[string]int)
+		toChange      int
+	)
+	for _, r := range results {
+		if r.Error != "" {
+			clusterErrors[r.Cluster] = r.Error
+			continue
+		}
+		matched, _ := r.Result.([]NodeTaintTarget)
+		for _, t := range matched {
+			if strings.HasPrefix(t.Status, "would-") {
+				perCluster[t.Cluster]++
+				toChange++
+			}
+		}
+		targets = append(targets, matched...)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Cluster != targets[j].Cluster {
+			return targets[i].Cluster < targets[j].Cluster
+		}
+		return targets[i].Name < targets[j].Name
+	})
+	if toChange > c.MaxObjects {
+		return nil, fmt.Errorf("the call would change %d nodes (%s), more than max_objects (%d); narrow the selection or raise max_objects",
+			toChange, formatClusterCounts(perCluster), c.MaxObjects)
+	}
+
+	response := map[string]interface{}{
+		"nodes":          c.Nodes,
+		"selector":       c.Selector,
+		"fieldSelector":  c.FieldSelector,
+		"targetClusters": clusters,
+		"matched":        len(targets),
+		"toChange":       toChange,
+		"dryRun":         dryRun,
+		"clusterErrors":  clusterErrors,
+	}
+	if c.add != nil {
+		response["taints"] = c.add
+	} else {
+		response["removedTaints"] = c.remove
+	}
+	if dryRun || toChange == 0 {
+		response["results"] = targets
+		return response, nil
+	}
+
+	done := "tainted"
+	if c.add == nil {
+		done = "untainted"
+	}
+	byCluster := make(map[string][]int)
+	for i, t := range targets {
+		if strings.HasPrefix(t.Status, "would-") {
+			byCluster[t.Cluster] = append(byCluster[t.Cluster], i)
+		}
+	}
+	applied, err := s.executor.ExecuteOnSelected(ctx, sortedKeysOf(byCluster), func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		statuses := make(map[int]error, len(byCluster[clusterName]))
+		for _, i := range byCluster[clusterName] {
+			name := targets[i].Name
+			statuses[i] = clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+				node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				node.Spec.Taints, _ = applyTaintChange(node.Spec.Taints, c)
+				_, err = client.CoreV1().Nodes().Update(ctx, node, metav1.UpdateOptions{})
+				return err
+			})
+		}
+		return statuses, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	successCount := 0
+	for _, r := range applied {
+		for _, i := range byCluster[r.Cluster] {
+			if r.Error != "" {
+				targets[i].Status, targets[i].Message = "failed", r.Error
+				continue
+			}
+			if updateErr := r.Result.(map[int]error)[i]; updateErr != nil {
+				targets[i].Status, targets[i].Message = "failed", updateErr.Error()
+				continue
+			}
+			targets[i].Status = done
+			successCount++
+		}
+	}
+	response["successCount"] = successCount
+	response["results"] = targets
+	return response, nil
+}
+
+// matchTaintTargets lists the nodes the call selects in one cluster and
+// works out how each one's taints would change. Named nodes that do not
+// exist are reported as failed.
+func matchTaintTargets(ctx context.Context, client kubernetes.Interface, clusterName string, c nodeTaintCall) ([]NodeTaintTarget, error) {
+	list, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: c.Selector, FieldSelector: c.FieldSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pending := "would-taint"
+	if c.add == nil {
+		pending = "would-untaint"
+	}
+	found := make(map[string]bool, len(list.Items))
+	targets := make([]NodeTaintTarget, 0, len(list.Items))
+	for _, node := range list.Items {
+		if len(c.Nodes) > 0 && !slices.Contains(c.Nodes, node.Name) {
+			continue
+		}
+		found[node.Name] = true
+		t := NodeTaintTarget{Cluster: clusterName, Name: node.Name, Status: "unchanged"}
+		if _, t.Changes = applyTaintChange(node.Spec.Taints, c); len(t.Changes) > 0 {
+			t.Status = pending
+		}
+		targets = append(targets, t)
+	}
+	for _, name := range c.Nodes {
+		if !found[name] && !c.selects() {
+			targets = append(targets, NodeTaintTarget{Cluster: clusterName, Name: name, Status: "failed", Message: "node not found"})
+		}
+	}
+	return targets, nil
+}
+
+// applyTaintChange returns the taints of a node after the call and the
+// changes made to them. A taint that is added replaces any taint with the
+// same key and effect.
+func applyTaintChange(current []corev1.Taint, c nodeTaintCall) ([]corev1.Taint, []string) {
+	taints := append([]corev1.Taint{}, current...)
+	var changes []string
+	for _, add := range c.add {
+		i := slices.IndexFunc(taints, func(t corev1.Taint) bool { return t.Key == add.Key && t.Effect == add.Effect })
+		switch {
+		case i < 0:
+			taint := corev1.Taint{Key: add.Key, Value: add.Value, Effect: add.Effect}
+			if add.Effect == corev1.TaintEffectNoExecute {
+				// As kubectl taint does, so tolerationSeconds count
+				// from now.
+				now := metav1.Now()
+				taint.TimeAdded = &now
+			}
+			taints = append(taints, taint)
+			changes = append(changes, "+"+formatTaint(add))
+		case taints[i].Value != add.Value:
+			changes = append(changes, fmt.Sprintf("%s -> %s", formatTaint(taints[i]), formatTaint(add)))
+			taints[i].Value = add.Value
+		}
+	}
+	for _, remove := range c.remove {
+		taints = slices.DeleteFunc(taints, func(t corev1.Taint) bool {
+			if t.Key != remove.Key || (remove.Effect != "" && t.Effect != remove.Effect) {
+				return false
+			}
+			changes = append(changes, "-"+formatTaint(t))
+			return true
+		})
+	}
+	return taints, changes
+}
+
+// formatTaint formats a taint as kubectl taint takes it: key=value:effect.
+func formatTaint(t corev1.Taint) string {
+	if t.Value == "" {
+		return t.Key + ":" + string(t.Effect)
+	}
+	return t.Key + "=" + t.Value + ":" + string(t.Effect)
+}
@@ -0,0 +1,110 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func putNode(t *testing.T, cluster *objectAPIServer, name string, labels map[string]string, taints ...corev1.Taint) {
+	t.Helper()
+	cluster.put(t, "/api/v1/nodes/"+name, &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.NodeSpec{Taints: taints},
+	})
+}
+
+func nodeTaints(t *testing.T, cluster *objectAPIServer, name string) []string {
+	t.Helper()
+	var taints []string
+	spec, _ := cluster.get("/api/v1/nodes/" + name)["spec"].(map[string]interface{})
+	list, _ := spec["taints"].([]interface{})
+	for _, item := range list {
+		taint := item.(map[string]interface{})
+		value, _ := taint["value"].(string)
+		taints = append(taints, formatTaint(corev1.Taint{Key: taint["key"].(string), Value: value, Effect: corev1.TaintEffect(taint["effect"].(string))}))
+	}
+	return taints
+}
+
+func TestTaintNodesBySelector(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	west, westURL := newObjectAPIServer(t, false)
+	gpu := map[string]string{"gpu": "true"}
+	putNode(t, east, "gpu-1", gpu)
+	putNode(t, east, "gpu-2", gpu, corev1.Taint{Key: "gpu", Value: "old", Effect: corev1.TaintEffectNoSchedule})
+	putNode(t, east, "cpu-1", map[string]string{"gpu": "false"})
+	putNode(t, west, "gpu-1", gpu, corev1.Taint{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule})
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL})
+	args := map[string]interface{}{
+		"selector": "gpu=true", "dry_run": true,
+		"taints": []map[string]interface{}{{"key": "gpu", "value": "true", "effect": "NoSchedule"}},
+	}
+
+	out, errText := callTool(t, server, "taint_nodes", args)
+	require.Empty(t, errText)
+	assert.EqualValues(t, 3, out["matched"])
+	assert.EqualValues(t, 2, out["toChange"])
+	results := out["results"].([]interface{})
+	require.Len(t, results, 3)
+	assert.Equal(t, map[string]interface{}{"cluster": "east", "name": "gpu-1", "status": "would-taint", "changes": []interface{}{"+gpu=true:NoSchedule"}}, results[0])
+	assert.Equal(t, []interface{}{"gpu=old:NoSchedule -> gpu=true:NoSchedule"}, results[1].(map[string]interface{})["changes"])
+	assert.Equal(t, "unchanged", results[2].(map[string]interface{})["status"])
+	assert.Empty(t, nodeTaints(t, east, "gpu-1"))
+
+	args["dry_run"] = false
+	out, errText = callTool(t, server, "taint_nodes", args)
+	require.Empty(t, errText)
+	assert.EqualValues(t, 2, out["successCount"])
+	assert.Equal(t, []string{"gpu=true:NoSchedule"}, nodeTaints(t, east, "gpu-1"))
+	assert.Equal(t, []string{"gpu=true:NoSchedule"}, nodeTaints(t, east, "gpu-2"))
+	assert.Empty(t, nodeTaints(t, east, "cpu-1"))
+
+	out, errText = callTool(t, server, "untaint_nodes", map[string]interface{}{
+		"nodes": []string{"gpu-1", "gpu-9"}, "clusters": []string{"east", "west"},
+		"taints": []map[string]interface{}{{"key": "gpu"}},
+	})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 2, out["successCount"])
+	assert.Empty(t, nodeTaints(t, east, "gpu-1"))
+	assert.Empty(t, nodeTaints(t, west, "gpu-1"))
+	assert.Equal(t, []string{"gpu=true:NoSchedule"}, nodeTaints(t, east, "gpu-2"))
+	var missing []string
+	for _, r := range out["results"].([]interface{}) {
+		if r := r.(map[string]interface{}); r["status"] == "failed" {
+			missing = append(missing, r["cluster"].(string)+"/"+r["name"].(string))
+		}
+	}
+	assert.Equal(t, []string{"east/gpu-9", "west/gpu-9"}, missing)
+
+	_, errText = callTool(t, server, "taint_nodes", map[string]interface{}{
+		"selector": "gpu=true", "max_objects": 1,
+		"taints": []map[string]interface{}{{"key": "maintenance", "effect": "NoExecute"}},
+	})
+	assert.Contains(t, errText, "would change 3 nodes (east: 2, west: 1)")
+}
+
+func TestTaintNodesInvalidArgs(t *testing.T) {
+	_, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"east": url})
+	taint := []map[string]interface{}{{"key": "gpu", "effect": "NoSchedule"}}
+	for _, tc := range []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"taint_nodes", map[string]interface{}{"taints": taint}},
+		{"taint_nodes", map[string]interface{}{"selector": "gpu", "taints": []map[string]interface{}{}}},
+		{"taint_nodes", map[string]interface{}{"selector": "gpu", "taints": []map[string]interface{}{{"key": "gpu"}}}},
+		{"taint_nodes", map[string]interface{}{"selector": "gpu", "taints": []map[string]interface{}{{"key": "bad key", "effect": "NoSchedule"}}}},
+		{"taint_nodes", map[string]interface{}{"selector": "gpu in (", "taints": taint}},
+		{"taint_nodes", map[string]interface{}{"selector": "gpu", "taints": taint, "max_objects": 5000}},
+		{"untaint_nodes", map[string]interface{}{"selector": "gpu", "taints": []map[string]interface{}{{"key": "gpu", "effect": "Sometimes"}}}},
+	} {
+		_, errText := callTool(t, server, tc.tool, tc.args)
+		assert.NotEmpty(t, errText, "%s %v", tc.tool, tc.args)
+	}
+}
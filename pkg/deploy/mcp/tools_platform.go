@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PlatformWarning is a workload in a manifest whose pods do not pin the OS
+// or architecture of a target cluster's mixed nodes, or that no usable
+// node matches.
+type PlatformWarning struct {
+	Cluster  string `json:"cluster"`
+	Workload string `json:"workload"`
+	Warning  string `json:"warning"`
+}
+
+// podTemplatePaths is where each workload kind keeps its pod spec.
+var podTemplatePaths = map[string][]string{
+	"Pod":         {},
+	"Deployment":  {"template", "spec"},
+	"StatefulSet": {"template", "spec"},
+	"DaemonSet":   {"template", "spec"},
+	"ReplicaSet":  {"template", "spec"},
+	"Job":         {"template", "spec"},
+	"CronJob":     {"jobTemplate", "spec", "template", "spec"},
+}
+
+// manifestPodSpec returns the pod spec of a workload manifest.
+func manifestPodSpec(m gitops.Manifest) (*corev1.PodSpec, bool) {
+	path, ok := podTemplatePaths[m.Kind]
+	if !ok {
+		return nil, false
+	}
+	raw := m.Spec
+	for _, field := range path {
+		next, ok := raw[field].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		raw = next
+	}
+	var spec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(raw, &spec); err != nil {
+		return nil, false
+	}
+	return &spec, true
+}
+
+// platformWarnings checks the workloads in manifest, rendered with vars for
+// each target cluster, against the node OS and architecture mix of the
+// cluster. Clusters whose nodes cannot be listed are skipped, since the
+// deploy reports them anyway.
+func (s *Server) platformWarnings(ctx context.Context, clusters []string, manifest string, vars *manifestVariables) ([]PlatformWarning, error) {
+	manifests, err := decodeManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	var workloads []gitops.Manifest
+	for _, m := range manifests {
+		if _, ok := manifestPodSpec(m); ok {
+			workloads = append(workloads, m)
+		}
+	}
+	if len(workloads) == 0 {
+		return nil, nil
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		rendered, err := vars.render(ctx, client, clusterName, workloads)
+		if err != nil {
+			return nil, err
+		}
+		nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		var warnings []PlatformWarning
+		for _, w := range rendered {
+			spec, ok := manifestPodSpec(w)
+			if !ok {
+				continue
+			}
+			for _, warning := range multicluster.PodPlatformWarnings(*spec, nodes.Items) {
+				warnings = append(warnings, PlatformWarning{Cluster: clusterName, Workload: w.Kind + "/" + w.Metadata.Name, Warning: warning})
+			}
+		}
+		return warnings, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var warnings []PlatformWarning
+	for _, r := range results {
+		if w, ok := r.Result.([]PlatformWarning); ok {
+			warnings = append(warnings, w...)
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool { return warnings[i].Cluster < warnings[j].Cluster })
+	return warnings, nil
+}
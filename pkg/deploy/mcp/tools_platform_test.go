@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+)
+
+func platformNode(name, os, arch string, taints ...interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "v1", "kind": "Node",
+		"metadata": map[string]interface{}{"name": name, "labels": map[string]interface{}{
+			"kubernetes.io/os": os, "kubernetes.io/arch": arch,
+		}},
+		"spec": map[string]interface{}{"taints": taints},
+		"status": map[string]interface{}{
+			"conditions":  []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+			"allocatable": map[string]interface{}{"cpu": "4", "memory": "8Gi"},
+		},
+	}
+}
+
+const platformManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: report
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          nodeSelector:
+            kubernetes.io/os: windows
+          containers:
+          - name: report
+            image: mcr.microsoft.com/windows/servercore
+`
+
+func TestManifestPodSpec(t *testing.T) {
+	spec, ok := manifestPodSpec(gitops.Manifest{Kind: "CronJob", Spec: map[string]interface{}{
+		"jobTemplate": map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+			"nodeSelector": map[string]interface{}{"kubernetes.io/os": "windows"},
+		}}}},
+	}})
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"kubernetes.io/os": "windows"}, spec.NodeSelector)
+
+	_, ok = manifestPodSpec(gitops.Manifest{Kind: "ConfigMap"})
+	assert.False(t, ok)
+}
+
+func TestDeployAppWarnsAboutMixedNodePlatforms(t *testing.T) {
+	mixed, mixedURL := newObjectAPIServer(t, false)
+	mixed.put(t, "/api/v1/nodes/linux-1", platformNode("linux-1", "linux", "amd64"))
+	mixed.put(t, "/api/v1/nodes/win-1", platformNode("win-1", "windows", "amd64"))
+	linux, linuxURL := newObjectAPIServer(t, false)
+	linux.put(t, "/api/v1/nodes/linux-1", platformNode("linux-1", "linux", "amd64"))
+	server := newAtomicTestServer(t, map[string]string{"mixed": mixedURL, "linux": linuxURL})
+
+	out, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest": platformManifest,
+		"clusters": []string{"mixed", "linux"},
+		"dry_run":  true,
+	})
+	warnings := out["platformWarnings"].([]interface{})
+	require.Len(t, warnings, 2)
+	assert.Equal(t, map[string]interface{}{
+		"cluster": "linux", "workload": "CronJob/report",
+		"warning": "no ready node matches its node selector and affinity; the cluster has 1 linux/amd64 nodes",
+	}, warnings[0])
+	assert.Equal(t, "mixed", warnings[1].(map[string]interface{})["cluster"])
+	assert.Equal(t, "Deployment/web", warnings[1].(map[string]interface{})["workload"])
+	assert.Contains(t, warnings[1].(map[string]interface{})["warning"], "set a kubernetes.io/os node selector")
+
+	// Tainting the Windows nodes keeps unpinned Linux workloads off them.
+	mixed.put(t, "/api/v1/nodes/win-1", platformNode("win-1", "windows", "amd64",
+		map[string]interface{}{"key": "os", "value": "windows", "effect": "NoSchedule"}))
+	out, _ = callDeployApp(t, server, map[string]interface{}{
+		"manifest": platformManifest,
+		"clusters": []string{"mixed"},
+		"dry_run":  true,
+	})
+	warnings = out["platformWarnings"].([]interface{})
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0].(map[string]interface{})["warning"], "have taints it does not tolerate (os=windows:NoSchedule)")
+}
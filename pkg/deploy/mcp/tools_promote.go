@@ -0,0 +1,573 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EnvEnvironments defines the promotion pipeline: environments in promotion
+// order, each a named group of clusters, e.g.
+// "dev=kind-dev;staging=stg-east,stg-west;prod=prod-east,prod-west".
+// Promotions into the last environment require confirmation.
+const EnvEnvironments = "KUBESTELLAR_ENVIRONMENTS"
+
+// promotedFromAnnotation records environment/cluster of the source on
+// objects promote_app creates.
+const promotedFromAnnotation = "kubestellar.io/promoted-from"
+
+// maxPromotions bounds how many promotions are kept in the state store.
+const maxPromotions = 200
+
+// Promotion outcomes.
+const (
+	promotionSucceeded = "succeeded"
+	promotionFailed    = "failed"
+	promotionPreview   = "preview"
+)
+
+// environment is one stage of the promotion pipeline.
+type environment struct {
+	Name     string   `json:"name"`
+	Clusters []string `json:"clusters"`
+}
+
+// parseEnvironments parses the value of $KUBESTELLAR_ENVIRONMENTS.
+func parseEnvironments(spec string) ([]environment, error) {
+	var envs []environment
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, list, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%s: expected name=cluster[,cluster...], got %q", EnvEnvironments, part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("%s: environment %q is listed twice", EnvEnvironments, name)
+		}
+		seen[name] = true
+		env := environment{Name: name}
+		for _, cluster := range strings.Split(list, ",") {
+			if cluster = strings.TrimSpace(cluster); cluster != "" {
+				env.Clusters = append(env.Clusters, cluster)
+			}
+		}
+		if len(env.Clusters) == 0 {
+			return nil, fmt.Errorf("%s: environment %q has no clusters", EnvEnvironments, name)
+		}
+		envs = append(envs, env)
+	}
+	if len(envs) < 2 {
+		return nil, fmt.Errorf("%s must list at least two environments, e.g. dev=cluster-a;prod=cluster-b", EnvEnvironments)
+	}
+	return envs, nil
+}
+
+// getEnvironments returns the pipeline configured by $KUBESTELLAR_ENVIRONMENTS.
+func (s *Server) getEnvironments() ([]environment, error) {
+	s.environmentsOnce.Do(func() {
+		if s.environments != nil {
+			return
+		}
+		spec := os.Getenv(EnvEnvironments)
+		if spec == "" {
+			s.environmentsErr = fmt.Errorf("no environments configured; set %s, e.g. dev=cluster-a;staging=cluster-b;prod=cluster-c", EnvEnvironments)
+			return
+		}
+		s.environments, s.environmentsErr = parseEnvironments(spec)
+	})
+	return s.environments, s.environmentsErr
+}
+
+// resolveClusters returns clusters, or the clusters of the named
+// environment in $KUBESTELLAR_ENVIRONMENTS. Both empty means all clusters,
+// returned as nil.
+func (s *Server) resolveClusters(clusters []string, environment string) ([]string, error) {
+	if environment == "" {
+		return clusters, nil
+	}
+	if len(clusters) > 0 {
+		return nil, fmt.Errorf("clusters and environment are mutually exclusive")
+	}
+	envs, err := s.getEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	for _, env := range envs {
+		if env.Name == environment {
+			return env.Clusters, nil
+		}
+	}
+	names := make([]string, 0, len(envs))
+	for _, env := range envs {
+		names = append(names, env.Name)
+	}
+	return nil, fmt.Errorf("unknown environment %q (configured: %s)", environment, strings.Join(names, ", "))
+}
+
+// ImageChange is a container image promote_app changed.
+type ImageChange struct {
+	Workload  string `json:"workload"`
+	Container string `json:"container"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to"`
+}
+
+// PromotionClusterResult is the outcome of a promotion in one cluster.
+type PromotionClusterResult struct {
+	Cluster string          `json:"cluster"`
+	Images  []ImageChange   `json:"images,omitempty"`
+	Steps   []MigrationStep `json:"steps,omitempty"`
+	Ready   bool            `json:"ready"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Promotion is one promote_app call, persisted in store.BucketPromotions.
+type Promotion struct {
+	ID        string `json:"id"`
+	App       string `json:"app"`
+	Namespace string `json:"namespace"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	// SourceCluster is the cluster the manifests were read from.
+	SourceCluster string `json:"sourceCluster"`
+	// Images are the images promoted, by workload and container.
+	Images    map[string]map[string]string `json:"images"`
+	Confirmed bool                         `json:"confirmed,omitempty"`
+	// ConfirmationRequired is set on the preview of a promotion into the
+	// last environment that was not confirmed.
+	ConfirmationRequired bool                     `json:"confirmationRequired,omitempty"`
+	DryRun               bool                     `json:"dryRun,omitempty"`
+	Outcome              string                   `json:"outcome"`
+	Clusters             []PromotionClusterResult `json:"clusters"`
+	Started              time.Time                `json:"started"`
+	Finished             time.Time                `json:"finished"`
+	Message              string                   `json:"message,omitempty"`
+}
+
+// promotionSource is an app as it runs in the source environment.
+type promotionSource struct {
+	app       string
+	cluster   string
+	namespace string
+	objects   []migrationObject
+	workloads []appWorkload
+	// images maps "Kind/name" to container name to image.
+	images map[string]map[string]string
+}
+
+// handlePromoteApp promotes an app's images, and any workloads missing
+// there, from one environment to the next.
+func (s *Server) handlePromoteApp(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		App            string `json:"app"`
+		Namespace      string `json:"namespace"`
+		From           string `json:"from"`
+		Confirm        bool   `json:"confirm"`
+		TimeoutSeconds int    `json:"timeout_seconds"`
+		DryRun         bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.App); err != nil {
+		return nil, fmt.Errorf("invalid app name: %w", err)
+	}
+	if params.Namespace != "" {
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	if params.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative")
+	}
+	timeout := defaultHealthTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	envs, err := s.getEnvironments()
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := nextEnvironment(envs, params.From)
+	if err != nil {
+		return nil, err
+	}
+
+	promotion := &Promotion{
+		ID:        newPromotionID(time.Now()),
+		App:       params.App,
+		From:      from.Name,
+		To:        to.Name,
+		Confirmed: params.Confirm,
+		DryRun:    params.DryRun || approval.IsDryRun(ctx),
+		Started:   time.Now().UTC(),
+	}
+	if to.Name == envs[len(envs)-1].Name && !params.Confirm && !promotion.DryRun {
+		// The last environment is production: show what would change and
+		// wait for the caller to confirm.
+		promotion.DryRun = true
+		promotion.ConfirmationRequired = true
+		promotion.Outcome = promotionPreview
+		promotion.Message = fmt.Sprintf("%s is the final environment; review this preview and call promote_app again with confirm: true to promote", to.Name)
+	}
+	if promotion.DryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+
+	source, err := s.promotionSource(ctx, from, params.App, params.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	promotion.Namespace = source.namespace
+	promotion.SourceCluster = source.cluster
+	promotion.Images = source.images
+
+	sourceDyn, err := s.dynamicClient(source.cluster)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, to.Clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.promoteToCluster(ctx, client, clusterName, sourceDyn, source, from.Name, timeout)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	failed := false
+	for _, r := range results {
+		result, ok := r.Result.(*PromotionClusterResult)
+		if !ok {
+			result = &PromotionClusterResult{Cluster: r.Cluster}
+		}
+		if r.Error != "" {
+			result.Error = r.Error
+		}
+		if result.Error != "" || (!promotion.DryRun && !result.Ready) {
+			failed = true
+		}
+		promotion.Clusters = append(promotion.Clusters, *result)
+	}
+	promotion.Finished = time.Now().UTC()
+	switch {
+	case promotion.Outcome != "":
+	case failed:
+		promotion.Outcome = promotionFailed
+	default:
+		promotion.Outcome = promotionSucceeded
+	}
+	if !promotion.DryRun {
+		if err := store.PutJSON(ctx, s.getStateStore(), store.BucketPromotions, promotion.ID, promotion); err != nil {
+			return nil, fmt.Errorf("recording promotion: %w", err)
+		}
+		s.trimPromotions(ctx)
+	}
+	return promotion, nil
+}
+
+// nextEnvironment returns the environment named from and the one after it.
+func nextEnvironment(envs []environment, from string) (environment, environment, error) {
+	names := make([]string, 0, len(envs))
+	for i, env := range envs {
+		names = append(names, env.Name)
+		if env.Name != from {
+			continue
+		}
+		if i == len(envs)-1 {
+			return environment{}, environment{}, fmt.Errorf("%s is the last environment; there is nothing to promote to", from)
+		}
+		return env, envs[i+1], nil
+	}
+	return environment{}, environment{}, fmt.Errorf("unknown environment %q: expected one of %s", from, strings.Join(names, ", "))
+}
+
+// promotionSource reads the app from the clusters of the source
+// environment. Every cluster running the app must run the same images.
+func (s *Server) promotionSource(ctx context.Context, from environment, app, namespace string) (*promotionSource, error) {
+	var (
+		source *promotionSource
+		skew   []string
+	)
+	for _, cluster := range from.Clusters {
+		client, err := s.manager.GetClient(cluster)
+		if err != nil {
+			return nil, err
+		}
+		ns, objects, workloads, err := migrationObjects(ctx, client, cluster, app, namespace)
+		if err != nil {
+			continue
+		}
+		images := workloadImages(workloads)
+		if source == nil {
+			source = &promotionSource{app: app, cluster: cluster, namespace: ns, objects: objects, workloads: workloads, images: images}
+			continue
+		}
+		if ns != source.namespace {
+			skew = append(skew, fmt.Sprintf("%s runs it in namespace %s, %s in %s", cluster, ns, source.cluster, source.namespace))
+			continue
+		}
+		for _, diff := range diffImages(source.images, images) {
+			skew = append(skew, fmt.Sprintf("%s: %s", cluster, diff))
+		}
+	}
+	if source == nil {
+		return nil, fmt.Errorf("app %s is not running in any cluster of environment %s", app, from.Name)
+	}
+	if len(skew) > 0 {
+		return nil, fmt.Errorf("app %s is not consistent across environment %s, so there is no single version to promote (compared with %s): %s",
+			app, from.Name, source.cluster, strings.Join(skew, "; "))
+	}
+	return source, nil
+}
+
+// workloadImages maps "Kind/name" of each workload to its container images.
+func workloadImages(workloads []appWorkload) map[string]map[string]string {
+	images := make(map[string]map[string]string, len(workloads))
+	for _, w := range workloads {
+		template, _ := w.podTemplate()
+		byContainer := make(map[string]string)
+		for _, c := range template.Spec.InitContainers {
+			byContainer[c.Name] = c.Image
+		}
+		for _, c := range template.Spec.Containers {
+			byContainer[c.Name] = c.Image
+		}
+		images[w.Kind+"/"+w.meta().GetName()] = byContainer
+	}
+	return images
+}
+
+// diffImages describes how got differs from want.
+func diffImages(want, got map[string]map[string]string) []string {
+	var diffs []string
+	for _, workload := range sortedKeysOf(want) {
+		if got[workload] == nil {
+			diffs = append(diffs, workload+" is missing")
+			continue
+		}
+		for _, container := range sortedKeysOf(want[workload]) {
+			if image := got[workload][container]; image != want[workload][container] {
+				diffs = append(diffs, fmt.Sprintf("%s container %s runs %s instead of %s", workload, container, image, want[workload][container]))
+			}
+		}
+	}
+	for _, workload := range sortedKeysOf(got) {
+		if want[workload] == nil {
+			diffs = append(diffs, workload+" is extra")
+		}
+	}
+	return diffs
+}
+
+// promoteToCluster sets the source images on the app's workloads in a
+// target cluster, creates the workloads and objects it lacks, and waits for
+// the workloads to become ready.
+func (s *Server) promoteToCluster(ctx context.Context, client *kubernetes.Clientset, clusterName string, sourceDyn dynamic.Interface, source *promotionSource, fromEnv string, timeout time.Duration) (*PromotionClusterResult, error) {
+	result := &PromotionClusterResult{Cluster: clusterName}
+	existing, err := listAppWorkloads(ctx, client, source.namespace, source.app)
+	if err != nil && len(existing) == 0 {
+		result.Error = err.Error()
+		return result, nil
+	}
+	byKey := make(map[string]appWorkload)
+	for _, w := range existing {
+		if w.meta().GetNamespace() == source.namespace {
+			byKey[w.Kind+"/"+w.meta().GetName()] = w
+		}
+	}
+
+	var missing []migrationObject
+	for _, o := range source.objects {
+		if w, ok := byKey[o.Kind+"/"+o.Name]; ok {
+			changes, err := setWorkloadImages(ctx, client, w, source.images[o.Kind+"/"+o.Name])
+			if err != nil {
+				result.Error = fmt.Sprintf("updating %s: %v", w, err)
+				return result, nil
+			}
+			result.Images = append(result.Images, changes...)
+			continue
+		}
+		switch o.Kind {
+		case "Deployment", "StatefulSet", "DaemonSet":
+			missing = append(missing, o)
+		}
+	}
+
+	if len(missing) > 0 {
+		// New workloads bring the objects they use, unless the environment
+		// already has its own.
+		targetDyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		origin := fromEnv + "/" + source.cluster
+		status, nsMissing, err := ensureNamespace(ctx, client, source.namespace, promotedFromAnnotation, origin)
+		if err != nil {
+			result.Error = fmt.Sprintf("creating namespace %s: %v", source.namespace, err)
+			return result, nil
+		}
+		if status != "" {
+			result.Steps = append(result.Steps, MigrationStep{Step: migrateStepCopy, Cluster: clusterName, Resource: "Namespace " + source.namespace, Status: status})
+		}
+		for _, o := range source.objects {
+			if _, exists := byKey[o.Kind+"/"+o.Name]; exists {
+				continue
+			}
+			gvr, _ := portableGVR(o.Kind)
+			step := MigrationStep{Step: migrateStepCopy, Cluster: clusterName, Resource: o.Kind + " " + source.namespace + "/" + o.Name}
+			step.Status, err = copyObject(ctx, sourceDyn, targetDyn, gvr, source.namespace, o.Name, promotedFromAnnotation, origin, nsMissing)
+			if err != nil {
+				step.Status, step.Message = "failed", err.Error()
+				result.Error = fmt.Sprintf("creating %s: %v", step.Resource, err)
+			}
+			result.Steps = append(result.Steps, step)
+		}
+		if result.Error != "" {
+			return result, nil
+		}
+	}
+
+	if approval.IsDryRun(ctx) {
+		return result, nil
+	}
+	manifests := make([]gitops.Manifest, 0, len(source.workloads))
+	for _, w := range source.workloads {
+		manifests = append(manifests, gitops.Manifest{Kind: w.Kind, Metadata: gitops.ManifestMetadata{Name: w.meta().GetName(), Namespace: source.namespace}})
+	}
+	pending, err := waitForReady(ctx, client, manifests, timeout)
+	switch {
+	case err != nil:
+		result.Error = err.Error()
+	case len(pending) > 0:
+		result.Error = fmt.Sprintf("not ready within %s: %s", timeout, strings.Join(pending, ", "))
+	default:
+		result.Ready = true
+	}
+	return result, nil
+}
+
+// setWorkloadImages sets the images of a workload's containers that appear
+// in images and returns the changes. Containers not in images keep theirs.
+func setWorkloadImages(ctx context.Context, client kubernetes.Interface, w appWorkload, images map[string]string) ([]ImageChange, error) {
+	var changes []ImageChange
+	apply := func(spec *corev1.PodSpec) {
+		for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for i := range containers {
+				c := &containers[i]
+				if image, ok := images[c.Name]; ok && image != c.Image {
+					changes = append(changes, ImageChange{Workload: w.String(), Container: c.Name, From: c.Image, To: image})
+					c.Image = image
+				}
+			}
+		}
+	}
+	var err error
+	switch {
+	case w.deployment != nil:
+		d := w.deployment.DeepCopy()
+		if apply(&d.Spec.Template.Spec); len(changes) > 0 {
+			_, err = client.AppsV1().Deployments(d.Namespace).Update(ctx, d, metav1.UpdateOptions{})
+		}
+	case w.statefulSet != nil:
+		ss := w.statefulSet.DeepCopy()
+		if apply(&ss.Spec.Template.Spec); len(changes) > 0 {
+			_, err = client.AppsV1().StatefulSets(ss.Namespace).Update(ctx, ss, metav1.UpdateOptions{})
+		}
+	default:
+		ds := w.daemonSet.DeepCopy()
+		if apply(&ds.Spec.Template.Spec); len(changes) > 0 {
+			_, err = client.AppsV1().DaemonSets(ds.Namespace).Update(ctx, ds, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// handleGetPromotionHistory lists recorded promotions, newest first.
+func (s *Server) handleGetPromotionHistory(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		App   string `json:"app"`
+		Limit int    `json:"limit"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	items, err := s.getStateStore().List(ctx, store.BucketPromotions)
+	if err != nil {
+		return nil, err
+	}
+	promotions := []Promotion{}
+	for i := len(items) - 1; i >= 0 && len(promotions) < params.Limit; i-- {
+		var p Promotion
+		if json.Unmarshal(items[i].Value, &p) != nil {
+			continue
+		}
+		if params.App != "" && p.App != params.App {
+			continue
+		}
+		promotions = append(promotions, p)
+	}
+	envs, _ := s.getEnvironments()
+	return map[string]interface{}{
+		"environments": envs,
+		"promotions":   promotions,
+		"count":        len(promotions),
+	}, nil
+}
+
+// trimPromotions drops the oldest promotions beyond maxPromotions.
+// Promotion IDs sort by time and List returns keys in order.
+func (s *Server) trimPromotions(ctx context.Context) {
+	st := s.getStateStore()
+	items, err := st.List(ctx, store.BucketPromotions)
+	if err != nil {
+		return
+	}
+	for i := 0; i < len(items)-maxPromotions; i++ {
+		_ = st.Delete(ctx, store.BucketPromotions, items[i].Key)
+	}
+}
+
+// newPromotionID returns an ID that sorts by creation time.
+func newPromotionID(now time.Time) string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return "pr-" + now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(b[:])
+}
+
+// sortedKeysOf returns the keys of m in order.
+func sortedKeysOf[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// webDeployment returns Deployment shop/web running image, reporting ready.
+func webDeployment(image string) *appsv1.Deployment {
+	replicas := int32(2)
+	return &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{Kind: "Deployment", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Labels: map[string]string{"app": "web"}},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:    "web",
+					Image:   image,
+					EnvFrom: []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "web-config"}}}},
+				}}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, ReadyReplicas: 2, AvailableReplicas: 2},
+	}
+}
+
+// newPromotionClusters returns a dev cluster running web:v2, a staging
+// cluster running web:v1, and an empty prod cluster.
+func newPromotionClusters(t *testing.T) (dev, stg, prod *objectAPIServer, server *Server) {
+	dev, devURL := newObjectAPIServer(t, false)
+	stg, stgURL := newObjectAPIServer(t, false)
+	prod, prodURL := newObjectAPIServer(t, false)
+	prod.readyDeployments = true
+
+	dev.put(t, shopDeployPath, webDeployment("web:v2"))
+	dev.put(t, shopConfigPath, &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{Kind: "ConfigMap", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web-config", Namespace: "shop"},
+		Data:       map[string]string{"MODE": "dev"},
+	})
+	stg.put(t, shopDeployPath, webDeployment("web:v1"))
+
+	server = newAtomicTestServer(t, map[string]string{"dev": devURL, "stg": stgURL, "prod": prodURL})
+	server.environments = []environment{
+		{Name: "dev", Clusters: []string{"dev"}},
+		{Name: "staging", Clusters: []string{"stg"}},
+		{Name: "prod", Clusters: []string{"prod"}},
+	}
+	return dev, stg, prod, server
+}
+
+func deploymentImage(t *testing.T, obj map[string]interface{}) string {
+	t.Helper()
+	require.NotNil(t, obj)
+	containers, _, _ := unstructured.NestedSlice(obj, "spec", "template", "spec", "containers")
+	require.NotEmpty(t, containers)
+	return containers[0].(map[string]interface{})["image"].(string)
+}
+
+func TestPromoteAppUpdatesImagesAndRecordsHistory(t *testing.T) {
+	_, stg, _, server := newPromotionClusters(t)
+
+	out, errText := callTool(t, server, "promote_app", map[string]interface{}{"app": "web", "from": "dev"})
+	require.Empty(t, errText)
+	assert.Equal(t, promotionSucceeded, out["outcome"])
+	assert.Equal(t, "staging", out["to"])
+	assert.Equal(t, "web:v2", deploymentImage(t, stg.get(shopDeployPath)))
+	assert.False(t, stg.has(shopConfigPath), "existing workloads keep their environment's config")
+
+	cluster := out["clusters"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, true, cluster["ready"])
+	change := cluster["images"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "web:v1", change["from"])
+	assert.Equal(t, "web:v2", change["to"])
+
+	history, errText := callTool(t, server, "get_promotion_history", map[string]interface{}{"app": "web"})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 1, history["count"])
+	recorded := history["promotions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, out["id"], recorded["id"])
+	assert.Len(t, history["environments"], 3)
+}
+
+func TestPromoteAppToLastEnvironmentRequiresConfirmation(t *testing.T) {
+	_, _, prod, server := newPromotionClusters(t)
+
+	out, errText := callTool(t, server, "promote_app", map[string]interface{}{"app": "web", "from": "staging"})
+	require.Empty(t, errText)
+	assert.Equal(t, promotionPreview, out["outcome"])
+	assert.Equal(t, true, out["confirmationRequired"])
+	assert.Equal(t, "stg", out["sourceCluster"])
+	assert.False(t, prod.has(shopNSPath))
+	assert.False(t, prod.has(shopDeployPath))
+	history, _ := callTool(t, server, "get_promotion_history", map[string]interface{}{})
+	assert.EqualValues(t, 0, history["count"], "previews are not recorded")
+
+	out, errText = callTool(t, server, "promote_app", map[string]interface{}{"app": "web", "from": "staging", "confirm": true})
+	require.Empty(t, errText)
+	assert.Equal(t, promotionSucceeded, out["outcome"])
+	assert.Equal(t, "web:v1", deploymentImage(t, prod.get(shopDeployPath)))
+	annotations, _, _ := unstructured.NestedStringMap(prod.get(shopDeployPath), "metadata", "annotations")
+	assert.Equal(t, "staging/stg", annotations[promotedFromAnnotation])
+	history, _ = callTool(t, server, "get_promotion_history", map[string]interface{}{})
+	assert.EqualValues(t, 1, history["count"])
+}
+
+func TestPromoteAppRejectsInconsistentSource(t *testing.T) {
+	dev, devURL := newObjectAPIServer(t, false)
+	dev2, dev2URL := newObjectAPIServer(t, false)
+	stg, stgURL := newObjectAPIServer(t, false)
+	dev.put(t, shopDeployPath, webDeployment("web:v2"))
+	dev2.put(t, shopDeployPath, webDeployment("web:v3"))
+	stg.put(t, shopDeployPath, webDeployment("web:v1"))
+	server := newAtomicTestServer(t, map[string]string{"dev": devURL, "dev2": dev2URL, "stg": stgURL})
+	server.environments = []environment{
+		{Name: "dev", Clusters: []string{"dev", "dev2"}},
+		{Name: "staging", Clusters: []string{"stg"}},
+	}
+
+	_, errText := callTool(t, server, "promote_app", map[string]interface{}{"app": "web", "from": "dev"})
+	assert.Contains(t, errText, "dev2: Deployment/web container web runs web:v3 instead of web:v2")
+	assert.Equal(t, "web:v1", deploymentImage(t, stg.get(shopDeployPath)))
+}
+
+func TestPromoteAppInvalidArgs(t *testing.T) {
+	_, _, _, server := newPromotionClusters(t)
+	for _, args := range []map[string]interface{}{
+		{"from": "dev"},
+		{"app": "web"},
+		{"app": "web", "from": "qa"},
+		{"app": "web", "from": "prod"},
+		{"app": "web", "from": "dev", "timeout_seconds": -1},
+		{"app": "api", "from": "dev"},
+	} {
+		_, errText := callTool(t, server, "promote_app", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+}
+
+func TestParseEnvironments(t *testing.T) {
+	envs, err := parseEnvironments(" dev=kind-dev ; staging=stg-east, stg-west;prod=prod ")
+	require.NoError(t, err)
+	assert.Equal(t, []environment{
+		{Name: "dev", Clusters: []string{"kind-dev"}},
+		{Name: "staging", Clusters: []string{"stg-east", "stg-west"}},
+		{Name: "prod", Clusters: []string{"prod"}},
+	}, envs)
+
+	for _, spec := range []string{"dev=a", "dev=a;prod=", "dev=a;dev=b", "a;prod=b"} {
+		_, err := parseEnvironments(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,451 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Defaults of rebalance_pods. As in the descheduler's LowNodeUtilization
+// strategy, a node is hot when its CPU or memory use is at or above
+// hot_percent of allocatable, and pods are only moved off it when some
+// schedulable node is below cool_percent of both to take them.
+const (
+	defaultHotPercent     = 80
+	defaultCoolPercent    = 50
+	defaultMaxEvictions   = 5
+	maxRebalanceEvictions = 100
+)
+
+// Sources of node utilization in rebalance_pods.
+const (
+	utilizationMetrics  = "metrics"
+	utilizationRequests = "requests"
+)
+
+// States of a node in rebalance_pods.
+const (
+	nodeHot    = "hot"
+	nodeCool   = "cool"
+	nodeNormal = "normal"
+)
+
+// Statuses of a pod in rebalance_pods.
+const (
+	evictionEvicted      = "evicted"
+	evictionWouldEvict   = "would-evict"
+	evictionBlockedByPDB = "blocked-by-pdb"
+	evictionFailed       = "failed"
+)
+
+// criticalPriorityClasses are never evicted, as the descheduler does not
+// evict them either.
+var criticalPriorityClasses = map[string]bool{
+	"system-cluster-critical": true,
+	"system-node-critical":    true,
+}
+
+// rebalanceParams are the arguments of rebalance_pods.
+type rebalanceParams struct {
+	Clusters     []string `json:"clusters"`
+	Environment  string   `json:"environment"`
+	Namespace    string   `json:"namespace"`
+	HotPercent   float64  `json:"hot_percent"`
+	CoolPercent  float64  `json:"cool_percent"`
+	MaxEvictions int      `json:"max_evictions"`
+	DryRun       bool     `json:"dry_run"`
+}
+
+// NodeUtilization is the CPU and memory use of a node, in percent of its
+// allocatable resources.
+type NodeUtilization struct {
+	Name          string  `json:"name"`
+	CPUPercent    float64 `json:"cpuPercent"`
+	MemoryPercent float64 `json:"memoryPercent"`
+	Pods          int     `json:"pods"`
+	State         string  `json:"state"` // hot, cool, or normal
+	Unschedulable bool    `json:"unschedulable,omitempty"`
+}
+
+// PodEviction is a pod rebalance_pods moved, or would move, off a hot node.
+type PodEviction struct {
+	Pod     string `json:"pod"` // namespace/name
+	Node    string `json:"node"`
+	Owner   string `json:"owner"` // kind/name of the controller
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ClusterRebalance is the outcome of rebalance_pods in one cluster.
+type ClusterRebalance struct {
+	Cluster string `json:"cluster"`
+	// Source is where utilization came from: metrics-server, or the
+	// requests of the pods on each node when it is not installed.
+	Source    string            `json:"source"`
+	Nodes     []NodeUtilization `json:"nodes"`
+	Evictions []PodEviction     `json:"evictions,omitempty"`
+	// Skipped counts the pods on hot nodes that were not candidates, by
+	// reason.
+	Skipped map[string]int `json:"skipped,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// nodeLoad is a node's allocatable resources and use, in millicores and
+// bytes.
+type nodeLoad struct {
+	node               *corev1.Node
+	allocCPU, allocMem int64
+	usedCPU, usedMem   int64
+	pods               []*corev1.Pod
+}
+
+func (n *nodeLoad) cpuPercent() float64 { return percentOf(n.usedCPU, n.allocCPU) }
+func (n *nodeLoad) memPercent() float64 { return percentOf(n.usedMem, n.allocMem) }
+
+func percentOf(used, alloc int64) float64 {
+	if alloc <= 0 {
+		return 0
+	}
+	return math.Round(float64(used)*1000/float64(alloc)) / 10
+}
+
+// handleRebalancePods finds hot nodes in each target cluster and evicts
+// pods from them, through the Eviction API so PodDisruptionBudgets are
+// respected, for the scheduler to place elsewhere.
+func (s *Server) handleRebalancePods(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var p rebalanceParams
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if p.Namespace != "" {
+		if err := server.ValidateNamespace(p.Namespace); err != nil {
+			return nil, err
+		}
+	}
+	if p.HotPercent == 0 {
+		p.HotPercent = defaultHotPercent
+	}
+	if p.CoolPercent == 0 {
+		p.CoolPercent = defaultCoolPercent
+	}
+	if p.HotPercent <= 0 || p.HotPercent > 100 || p.CoolPercent <= 0 || p.CoolPercent >= p.HotPercent {
+		return nil, fmt.Errorf("need 0 < cool_percent < hot_percent <= 100")
+	}
+	switch {
+	case p.MaxEvictions == 0:
+		p.MaxEvictions = defaultMaxEvictions
+	case p.MaxEvictions < 0 || p.MaxEvictions > maxRebalanceEvictions:
+		return nil, fmt.Errorf("max_evictions must be between 1 and %d", maxRebalanceEvictions)
+	}
+	dryRun := p.DryRun || approval.IsDryRun(ctx)
+
+	clusters, err := s.resolveClusters(p.Clusters, p.Environment)
+	if err != nil {
+		return nil, err
+	}
+	if len(clusters) == 0 {
+		if clusters, err = s.executor.ClusterNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return rebalanceCluster(ctx, client, clusterName, p, dryRun)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		outcomes      []*ClusterRebalance
+		clusterErrors = make(map[string]string)
+		evicted       int
+	)
+	for _, r := range results {
+		if r.Error != "" {
+			clusterErrors[r.Cluster] = r.Error
+			continue
+		}
+		outcome := r.Result.(*ClusterRebalance)
+		for _, e := range outcome.Evictions {
+			if e.Status == evictionEvicted || e.Status == evictionWouldEvict {
+				evicted++
+			}
+		}
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Slice(outcomes, func(i, j int) bool { return outcomes[i].Cluster < outcomes[j].Cluster })
+
+	return map[string]interface{}{
+		"hotPercent":        p.HotPercent,
+		"coolPercent":       p.CoolPercent,
+		"maxEvictions":      p.MaxEvictions,
+		"dryRun":            dryRun,
+		"evicted":           evicted,
+		"results":           outcomes,
+		"clusterErrors":     clusterErrors,
+		"deschedulerPolicy": deschedulerPolicy(p),
+	}, nil
+}
+
+// rebalanceCluster measures the nodes of one cluster and, if some are hot
+// and some cool, evicts pods from the hot ones, hottest first, until each
+// is estimated to be below hot_percent or max_evictions is reached. The
+// estimate subtracts the requests of the evicted pods.
+func rebalanceCluster(ctx context.Context, client *kubernetes.Clientset, clusterName string, p rebalanceParams, dryRun bool) (*ClusterRebalance, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	loads := make(map[string]*nodeLoad, len(nodes.Items))
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		loads[node.Name] = &nodeLoad{
+			node:     node,
+			allocCPU: node.Status.Allocatable.Cpu().MilliValue(),
+			allocMem: node.Status.Allocatable.Memory().Value(),
+		}
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		load, ok := loads[pod.Spec.NodeName]
+		if !ok || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		load.pods = append(load.pods, pod)
+		cpu, mem := podRequests(pod)
+		load.usedCPU += cpu
+		load.usedMem += mem
+	}
+
+	result := &ClusterRebalance{Cluster: clusterName, Source: utilizationRequests}
+	if usage, err := nodeMetrics(ctx, client); err == nil {
+		result.Source = utilizationMetrics
+		for name, load := range loads {
+			u := usage[name]
+			load.usedCPU, load.usedMem = u.cpu, u.memory
+		}
+	}
+
+	var hot []*nodeLoad
+	cool := 0
+	for _, load := range loads {
+		state := nodeNormal
+		switch cpu, mem := load.cpuPercent(), load.memPercent(); {
+		case cpu >= p.HotPercent || mem >= p.HotPercent:
+			state = nodeHot
+			hot = append(hot, load)
+		case !load.node.Spec.Unschedulable && cpu < p.CoolPercent && mem < p.CoolPercent:
+			state = nodeCool
+			cool++
+		}
+		result.Nodes = append(result.Nodes, NodeUtilization{
+			Name:          load.node.Name,
+			CPUPercent:    load.cpuPercent(),
+			MemoryPercent: load.memPercent(),
+			Pods:          len(load.pods),
+			State:         state,
+			Unschedulable: load.node.Spec.Unschedulable,
+		})
+	}
+	sort.Slice(result.Nodes, func(i, j int) bool { return result.Nodes[i].Name < result.Nodes[j].Name })
+	switch {
+	case len(hot) == 0:
+		result.Message = fmt.Sprintf("no node is at or above %g%% of its CPU or memory", p.HotPercent)
+		return result, nil
+	case cool == 0:
+		result.Message = fmt.Sprintf("%d hot nodes but no schedulable node below %g%% to take their pods; add capacity instead", len(hot), p.CoolPercent)
+		return result, nil
+	}
+
+	sort.Slice(hot, func(i, j int) bool {
+		return math.Max(hot[i].cpuPercent(), hot[i].memPercent()) > math.Max(hot[j].cpuPercent(), hot[j].memPercent())
+	})
+	moved := 0
+	for _, load := range hot {
+		candidates := make([]*corev1.Pod, 0, len(load.pods))
+		for _, pod := range load.pods {
+			if p.Namespace != "" && pod.Namespace != p.Namespace {
+				continue
+			}
+			if reason := evictionSkipReason(pod); reason != "" {
+				if result.Skipped == nil {
+					result.Skipped = make(map[string]int)
+				}
+				result.Skipped[reason]++
+				continue
+			}
+			candidates = append(candidates, pod)
+		}
+		// Move the pods that request the most of whichever resource
+		// is hotter first, so fewer pods have to move.
+		byCPU := load.cpuPercent() >= load.memPercent()
+		sort.SliceStable(candidates, func(i, j int) bool {
+			ci, mi := podRequests(candidates[i])
+			cj, mj := podRequests(candidates[j])
+			if byCPU {
+				return ci > cj
+			}
+			return mi > mj
+		})
+		for _, pod := range candidates {
+			if moved >= p.MaxEvictions || (load.cpuPercent() < p.HotPercent && load.memPercent() < p.HotPercent) {
+				break
+			}
+			eviction := evictPod(ctx, client, pod, dryRun)
+			result.Evictions = append(result.Evictions, eviction)
+			if eviction.Status == evictionEvicted || eviction.Status == evictionWouldEvict {
+				moved++
+				cpu, mem := podRequests(pod)
+				load.usedCPU -= cpu
+				load.usedMem -= mem
+			}
+		}
+	}
+	if moved >= p.MaxEvictions {
+		result.Message = fmt.Sprintf("stopped at max_evictions (%d); call again once the evicted pods are rescheduled", p.MaxEvictions)
+	}
+	return result, nil
+}
+
+// evictionSkipReason returns why pod must not be evicted to rebalance its
+// node, or "" if it may be. Only pods a controller recreates elsewhere
+// are moved.
+func evictionSkipReason(pod *corev1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	switch {
+	case pod.Status.Phase != corev1.PodRunning:
+		return "not running"
+	case pod.Annotations[corev1.MirrorPodAnnotationKey] != "":
+		return "static pod"
+	case owner == nil:
+		return "no controller"
+	case owner.Kind == "DaemonSet":
+		return "daemonset"
+	case criticalPriorityClasses[pod.Spec.PriorityClassName]:
+		return "critical priority"
+	case isSystemNamespace(pod.Namespace):
+		return "system namespace"
+	}
+	for _, v := range pod.Spec.Volumes {
+		if v.EmptyDir != nil {
+			return "local storage"
+		}
+	}
+	return ""
+}
+
+// isSystemNamespace reports whether namespace belongs to Kubernetes itself.
+func isSystemNamespace(namespace string) bool {
+	switch namespace {
+	case metav1.NamespaceSystem, metav1.NamespacePublic, corev1.NamespaceNodeLease:
+		return true
+	}
+	return false
+}
+
+// evictPod evicts pod unless dryRun is set. An eviction that would violate
+// a PodDisruptionBudget is reported as blocked rather than retried.
+func evictPod(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod, dryRun bool) PodEviction {
+	eviction := PodEviction{Pod: pod.Namespace + "/" + pod.Name, Node: pod.Spec.NodeName}
+	if owner := metav1.GetControllerOf(pod); owner != nil {
+		eviction.Owner = owner.Kind + "/" + owner.Name
+	}
+	if dryRun {
+		eviction.Status = evictionWouldEvict
+		return eviction
+	}
+	err := client.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+	})
+	switch {
+	case apierrors.IsTooManyRequests(err):
+		eviction.Status, eviction.Message = evictionBlockedByPDB, "evicting it would violate a PodDisruptionBudget"
+	case err != nil && !apierrors.IsNotFound(err):
+		eviction.Status, eviction.Message = evictionFailed, err.Error()
+	default:
+		eviction.Status = evictionEvicted
+	}
+	return eviction
+}
+
+// podRequests returns the CPU, in millicores, and memory, in bytes, that
+// the containers of pod request.
+func podRequests(pod *corev1.Pod) (cpu, memory int64) {
+	for _, c := range pod.Spec.Containers {
+		cpu += c.Resources.Requests.Cpu().MilliValue()
+		memory += c.Resources.Requests.Memory().Value()
+	}
+	return cpu, memory
+}
+
+// nodeMetrics reads the current usage of every node from the
+// metrics.k8s.io API. It fails if the API reports no nodes.
+func nodeMetrics(ctx context.Context, client *kubernetes.Clientset) (map[string]containerUsage, error) {
+	body, err := client.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/nodes").
+		SetHeader("Accept", "application/json").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("decoding node metrics: %w", err)
+	}
+	if len(list.Items) == 0 {
+		// As while metrics-server starts up.
+		return nil, fmt.Errorf("no node metrics reported")
+	}
+	usage := make(map[string]containerUsage, len(list.Items))
+	for _, item := range list.Items {
+		usage[item.Metadata.Name] = containerUsage{cpu: item.Usage.Cpu().MilliValue(), memory: item.Usage.Memory().Value()}
+	}
+	return usage, nil
+}
+
+// deschedulerPolicy returns a descheduler policy whose LowNodeUtilization
+// plugin uses the thresholds of the call, for clusters that should keep
+// rebalancing on their own.
+func deschedulerPolicy(p rebalanceParams) map[string]interface{} {
+	thresholds := func(pct float64) map[string]interface{} {
+		return map[string]interface{}{"cpu": pct, "memory": pct}
+	}
+	return map[string]interface{}{
+		"apiVersion": "descheduler/v1alpha2",
+		"kind":       "DeschedulerPolicy",
+		"profiles": []interface{}{map[string]interface{}{
+			"name": "rebalance",
+			"pluginConfig": []interface{}{
+				map[string]interface{}{"name": "DefaultEvictor", "args": map[string]interface{}{"evictLocalStoragePods": false}},
+				map[string]interface{}{"name": "LowNodeUtilization", "args": map[string]interface{}{
+					"thresholds":       thresholds(p.CoolPercent),
+					"targetThresholds": thresholds(p.HotPercent),
+				}},
+			},
+			"plugins": map[string]interface{}{
+				"balance": map[string]interface{}{"enabled": []string{"LowNodeUtilization"}},
+			},
+		}},
+	}
+}
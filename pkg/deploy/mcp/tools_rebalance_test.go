@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func putSizedNode(t *testing.T, cluster *objectAPIServer, name, cpu, memory string) {
+	t.Helper()
+	cluster.put(t, "/api/v1/nodes/"+name, &corev1.Node{
+		TypeMeta:   metav1.TypeMeta{Kind: "Node", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}},
+	})
+}
+
+func putRunningPod(t *testing.T, cluster *objectAPIServer, namespace, name, node, ownerKind, cpu string) {
+	t.Helper()
+	controller := true
+	cluster.put(t, "/api/v1/namespaces/"+namespace+"/pods/"+name, &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{Kind: "Pod", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, OwnerReferences: []metav1.OwnerReference{
+			{Kind: ownerKind, Name: name + "-owner", Controller: &controller},
+		}},
+		Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{
+			Name:      "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	})
+}
+
+func TestRebalancePods(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	putSizedNode(t, east, "hot-1", "4", "8Gi")
+	putSizedNode(t, east, "idle-1", "4", "8Gi")
+	putRunningPod(t, east, "shop", "web-a", "hot-1", "ReplicaSet", "1500m")
+	putRunningPod(t, east, "shop", "web-b", "hot-1", "ReplicaSet", "1000m")
+	putRunningPod(t, east, "shop", "web-c", "hot-1", "ReplicaSet", "800m")
+	putRunningPod(t, east, "shop", "agent", "hot-1", "DaemonSet", "200m")
+	putRunningPod(t, east, "kube-system", "dns", "hot-1", "ReplicaSet", "100m")
+	putRunningPod(t, east, "shop", "api", "idle-1", "ReplicaSet", "500m")
+
+	// west has metrics-server, which reports both nodes well below the
+	// threshold whatever their pods request.
+	west, westURL := newObjectAPIServer(t, false)
+	putSizedNode(t, west, "a", "2", "4Gi")
+	putSizedNode(t, west, "b", "2", "4Gi")
+	putRunningPod(t, west, "shop", "web-a", "a", "ReplicaSet", "1900m")
+	west.put(t, "/apis/metrics.k8s.io/v1beta1/nodes", map[string]interface{}{
+		"kind": "NodeMetricsList",
+		"items": []interface{}{
+			map[string]interface{}{"metadata": map[string]interface{}{"name": "a"}, "usage": map[string]interface{}{"cpu": "500m", "memory": "1Gi"}},
+			map[string]interface{}{"metadata": map[string]interface{}{"name": "b"}, "usage": map[string]interface{}{"cpu": "100m", "memory": "1Gi"}},
+		},
+	})
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL})
+
+	out, errText := callTool(t, server, "rebalance_pods", map[string]interface{}{"dry_run": true})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 1, out["evicted"])
+	results := out["results"].([]interface{})
+	require.Len(t, results, 2)
+	eastResult := results[0].(map[string]interface{})
+	assert.Equal(t, "requests", eastResult["source"])
+	nodes := eastResult["nodes"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "hot-1", "cpuPercent": 90.0, "memoryPercent": 0.0, "pods": 5.0, "state": "hot"}, nodes[0])
+	assert.Equal(t, "cool", nodes[1].(map[string]interface{})["state"])
+	// Evicting the largest pod brings hot-1 to 52.5%, below hot_percent.
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"pod": "shop/web-a", "node": "hot-1", "owner": "ReplicaSet/web-a-owner", "status": "would-evict",
+	}}, eastResult["evictions"])
+	assert.Equal(t, map[string]interface{}{"daemonset": 1.0, "system namespace": 1.0}, eastResult["skipped"])
+	westResult := results[1].(map[string]interface{})
+	assert.Equal(t, "metrics", westResult["source"])
+	assert.Contains(t, westResult["message"], "no node is at or above 80%")
+	assert.NotNil(t, out["deschedulerPolicy"])
+	assert.False(t, east.has("/api/v1/namespaces/shop/pods/web-a/eviction/web-a"))
+
+	out, errText = callTool(t, server, "rebalance_pods", map[string]interface{}{"clusters": []string{"east"}, "hot_percent": 50, "cool_percent": 20})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 2, out["evicted"])
+	assert.True(t, east.has("/api/v1/namespaces/shop/pods/web-a/eviction/web-a"))
+	assert.True(t, east.has("/api/v1/namespaces/shop/pods/web-b/eviction/web-b"))
+	assert.False(t, east.has("/api/v1/namespaces/shop/pods/agent/eviction/agent"))
+
+	out, errText = callTool(t, server, "rebalance_pods", map[string]interface{}{"clusters": []string{"east"}, "hot_percent": 90, "cool_percent": 10, "dry_run": true})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 0, out["evicted"])
+	assert.Contains(t, out["results"].([]interface{})[0].(map[string]interface{})["message"], "no schedulable node below 10%")
+
+	_, errText = callTool(t, server, "rebalance_pods", map[string]interface{}{"hot_percent": 40, "cool_percent": 60})
+	assert.NotEmpty(t, errText)
+}
@@ -0,0 +1,272 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Statuses of a cluster in a fleet restart.
+const (
+	restartRestarted    = "restarted"
+	restartFailed       = "failed"
+	restartUnhealthy    = "skipped-unhealthy"
+	restartBlockedByPDB = "blocked-by-pdb"
+	restartNotStarted   = "not-started"
+	restartWouldRestart = "would-restart"
+)
+
+// FleetRestartResult is the outcome of a fleet restart in one cluster.
+type FleetRestartResult struct {
+	Cluster  string `json:"cluster"`
+	Workload string `json:"workload,omitempty"`
+	Status   string `json:"status"`
+	// Wave is the 1-based wave the cluster was, or would be, restarted in.
+	Wave    int    `json:"wave,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// restartSurvey is what a fleet restart learned about one cluster before
+// restarting anything.
+type restartSurvey struct {
+	workload appWorkload
+	ready    bool
+	// blockingPDBs are the PodDisruptionBudgets covering the app's pods
+	// that allow no disruption right now.
+	blockingPDBs []string
+}
+
+// handleRollingRestartFleet restarts an app cluster by cluster, as kubectl
+// rollout restart would, in waves of at most max_unavailable_clusters. Each
+// wave must be ready again before the next starts, and a failed wave stops
+// the restart. Clusters where the app is already unhealthy are not
+// restarted and count against max_unavailable_clusters, and clusters whose
+// PodDisruptionBudgets allow no disruption are left alone.
+func (s *Server) handleRollingRestartFleet(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		App                    string   `json:"app"`
+		Namespace              string   `json:"namespace"`
+		Kind                   string   `json:"kind"`
+		Clusters               []string `json:"clusters"`
+		Environment            string   `json:"environment"`
+		MaxUnavailableClusters int      `json:"max_unavailable_clusters"`
+		TimeoutSeconds         int      `json:"timeout_seconds"`
+		IgnorePDBs             bool     `json:"ignore_pdbs"`
+		DryRun                 bool     `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.App); err != nil {
+		return nil, fmt.Errorf("invalid app name: %w", err)
+	}
+	if params.Namespace != "" {
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	switch {
+	case params.MaxUnavailableClusters == 0:
+		params.MaxUnavailableClusters = 1
+	case params.MaxUnavailableClusters < 0:
+		return nil, fmt.Errorf("max_unavailable_clusters must be at least 1")
+	}
+	if params.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative")
+	}
+	timeout := defaultHealthTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+
+	clusters, err := s.resolveClusters(params.Clusters, params.Environment)
+	if err != nil {
+		return nil, err
+	}
+	explicit := len(clusters) > 0
+	if !explicit {
+		if clusters, err = s.executor.ClusterNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	surveys, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		if !explicit {
+			instances, err := s.findAppInCluster(ctx, client, clusterName, params.App, params.Namespace)
+			if err == nil && len(instances) == 0 {
+				return nil, nil
+			}
+		}
+		return surveyRestart(ctx, client, clusterName, params.App, params.Namespace, params.Kind)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		results       []FleetRestartResult
+		eligible      []string
+		unhealthy     int
+		clusterErrors = make(map[string]string)
+	)
+	for _, r := range surveys {
+		if r.Error != "" {
+			clusterErrors[r.Cluster] = r.Error
+			continue
+		}
+		survey, _ := r.Result.(*restartSurvey)
+		if survey == nil {
+			continue
+		}
+		result := FleetRestartResult{Cluster: r.Cluster, Workload: survey.workload.String()}
+		switch {
+		case !survey.ready:
+			unhealthy++
+			result.Status, result.Message = restartUnhealthy, "not ready before the restart; restart it separately once it is healthy"
+		case len(survey.blockingPDBs) > 0 && !params.IgnorePDBs:
+			result.Status, result.Message = restartBlockedByPDB, fmt.Sprintf("PodDisruptionBudget %s allows no disruption", strings.Join(survey.blockingPDBs, ", "))
+		default:
+			eligible = append(eligible, r.Cluster)
+			result.Status = restartNotStarted
+		}
+		results = append(results, result)
+	}
+	if len(results) == 0 && len(clusterErrors) == 0 {
+		return nil, fmt.Errorf("app %s not found in any cluster", params.App)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+	sort.Strings(eligible)
+
+	response := map[string]interface{}{
+		"app":                    params.App,
+		"maxUnavailableClusters": params.MaxUnavailableClusters,
+		"unhealthyClusters":      unhealthy,
+		"clusterErrors":          clusterErrors,
+		"dryRun":                 dryRun,
+	}
+	// Clusters already down use up the budget.
+	waveSize := params.MaxUnavailableClusters - unhealthy
+	if len(eligible) > 0 && waveSize < 1 {
+		return nil, fmt.Errorf("%d clusters are already unhealthy, which uses up max_unavailable_clusters (%d); fix them or raise max_unavailable_clusters", unhealthy, params.MaxUnavailableClusters)
+	}
+	var waves [][]string
+	for rest := eligible; len(rest) > 0; {
+		n := min(waveSize, len(rest))
+		waves = append(waves, rest[:n])
+		rest = rest[n:]
+	}
+	response["waves"] = waves
+	byCluster := make(map[string]*FleetRestartResult, len(results))
+	for i := range results {
+		byCluster[results[i].Cluster] = &results[i]
+	}
+	for i, wave := range waves {
+		for _, c := range wave {
+			byCluster[c].Wave = i + 1
+			if dryRun {
+				byCluster[c].Status = restartWouldRestart
+			}
+		}
+	}
+	response["results"] = results
+	switch {
+	case len(waves) == 0:
+		response["outcome"] = "nothing to restart"
+		return response, nil
+	case dryRun:
+		return response, nil
+	}
+
+	outcome := "completed"
+	for i, wave := range waves {
+		now := time.Now()
+		restarted, err := s.executor.ExecuteOnSelected(ctx, wave, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+			return restartAndWait(ctx, client, clusterName, params.App, params.Namespace, params.Kind, now, timeout)
+		})
+		if err != nil {
+			return nil, err
+		}
+		failed := 0
+		for _, r := range restarted {
+			result := byCluster[r.Cluster]
+			switch pending, _ := r.Result.([]string); {
+			case r.Error != "":
+				result.Status, result.Message = restartFailed, r.Error
+				failed++
+			case len(pending) > 0:
+				result.Status, result.Message = restartFailed, fmt.Sprintf("not ready within %s: %s", timeout, strings.Join(pending, ", "))
+				failed++
+			default:
+				result.Status = restartRestarted
+			}
+		}
+		reportProgress(ctx, i+1, len(waves), fmt.Sprintf("restarted wave %d: %s", i+1, strings.Join(wave, ", ")))
+		if failed > 0 {
+			outcome = "halted"
+			response["reason"] = fmt.Sprintf("wave %d did not become healthy; later waves were not restarted", i+1)
+			break
+		}
+	}
+	response["outcome"] = outcome
+	return response, nil
+}
+
+// surveyRestart finds the app's workload in one cluster, whether it is
+// ready, and the PodDisruptionBudgets that would block a restart.
+func surveyRestart(ctx context.Context, client *kubernetes.Clientset, clusterName, app, namespace, kind string) (*restartSurvey, error) {
+	w, err := resolveAppWorkload(ctx, client, clusterName, app, namespace, kind, "Deployment", "StatefulSet", "DaemonSet")
+	if err != nil {
+		return nil, err
+	}
+	ready, err := workloadReady(ctx, client, w.Kind, w.meta().GetNamespace(), w.meta().GetName())
+	if err != nil {
+		return nil, err
+	}
+	survey := &restartSurvey{workload: w, ready: ready}
+
+	template, _ := w.podTemplate()
+	pdbs, err := client.PolicyV1().PodDisruptionBudgets(w.meta().GetNamespace()).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+	for _, pdb := range pdbs.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(template.Labels)) {
+			continue
+		}
+		if pdb.Status.DisruptionsAllowed < 1 {
+			survey.blockingPDBs = append(survey.blockingPDBs, pdb.Name)
+		}
+	}
+	return survey, nil
+}
+
+// restartAndWait restarts the app's workload in one cluster and waits for
+// it to be ready again. It returns the workload, as Kind/name, if it is
+// still not ready when timeout expires.
+func restartAndWait(ctx context.Context, client *kubernetes.Clientset, clusterName, app, namespace, kind string, now time.Time, timeout time.Duration) ([]string, error) {
+	// Read the workload again; it may have changed since the survey.
+	w, err := resolveAppWorkload(ctx, client, clusterName, app, namespace, kind, "Deployment", "StatefulSet", "DaemonSet")
+	if err != nil {
+		return nil, err
+	}
+	if err := restartWorkload(ctx, client, w, now); err != nil {
+		return nil, err
+	}
+	return waitForReady(ctx, client, []gitops.Manifest{{
+		Kind:     w.Kind,
+		Metadata: gitops.ManifestMetadata{Name: w.meta().GetName(), Namespace: w.meta().GetNamespace()},
+	}}, timeout)
+}
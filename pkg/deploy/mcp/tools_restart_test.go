@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newRestartClusters returns clusters a, b, and c, each running a ready web
+// Deployment.
+func newRestartClusters(t *testing.T) (map[string]*objectAPIServer, *Server) {
+	clusters := make(map[string]*objectAPIServer)
+	urls := make(map[string]string)
+	for _, name := range []string{"a", "b", "c"} {
+		cluster, url := newObjectAPIServer(t, false)
+		cluster.put(t, shopDeployPath, webDeployment("web:v1"))
+		clusters[name], urls[name] = cluster, url
+	}
+	return clusters, newAtomicTestServer(t, urls)
+}
+
+func restartedAt(cluster *objectAPIServer) string {
+	stamp, _, _ := unstructured.NestedString(cluster.get(shopDeployPath), "spec", "template", "metadata", "annotations", restartedAtAnnotation)
+	return stamp
+}
+
+func restartStatuses(out map[string]interface{}) map[string]string {
+	statuses := make(map[string]string)
+	for _, r := range out["results"].([]interface{}) {
+		result := r.(map[string]interface{})
+		statuses[result["cluster"].(string)] = result["status"].(string)
+	}
+	return statuses
+}
+
+func TestRollingRestartFleetInWaves(t *testing.T) {
+	clusters, server := newRestartClusters(t)
+	args := map[string]interface{}{"app": "web", "max_unavailable_clusters": 2, "dry_run": true}
+
+	out, errText := callTool(t, server, "rolling_restart_fleet", args)
+	require.Empty(t, errText)
+	assert.Equal(t, []interface{}{[]interface{}{"a", "b"}, []interface{}{"c"}}, out["waves"])
+	assert.Equal(t, map[string]string{"a": restartWouldRestart, "b": restartWouldRestart, "c": restartWouldRestart}, restartStatuses(out))
+	assert.Empty(t, restartedAt(clusters["a"]))
+
+	args["dry_run"] = false
+	out, errText = callTool(t, server, "rolling_restart_fleet", args)
+	require.Empty(t, errText)
+	assert.Equal(t, "completed", out["outcome"])
+	assert.Equal(t, map[string]string{"a": restartRestarted, "b": restartRestarted, "c": restartRestarted}, restartStatuses(out))
+	for _, cluster := range clusters {
+		assert.NotEmpty(t, restartedAt(cluster))
+	}
+}
+
+func TestRollingRestartFleetSkipsUnhealthyAndPDBBlockedClusters(t *testing.T) {
+	clusters, server := newRestartClusters(t)
+	minAvailable := intstr.FromInt32(2)
+	clusters["b"].put(t, "/apis/policy/v1/namespaces/shop/poddisruptionbudgets/web", &policyv1.PodDisruptionBudget{
+		TypeMeta:   metav1.TypeMeta{Kind: "PodDisruptionBudget", APIVersion: "policy/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{MinAvailable: &minAvailable, Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 0},
+	})
+	unready := webDeployment("web:v1")
+	unready.Status.ReadyReplicas = 0
+	clusters["c"].put(t, shopDeployPath, unready)
+
+	_, errText := callTool(t, server, "rolling_restart_fleet", map[string]interface{}{"app": "web"})
+	assert.Contains(t, errText, "1 clusters are already unhealthy")
+
+	out, errText := callTool(t, server, "rolling_restart_fleet", map[string]interface{}{"app": "web", "max_unavailable_clusters": 2})
+	require.Empty(t, errText)
+	assert.Equal(t, map[string]string{"a": restartRestarted, "b": restartBlockedByPDB, "c": restartUnhealthy}, restartStatuses(out))
+	assert.Empty(t, restartedAt(clusters["b"]))
+	assert.Empty(t, restartedAt(clusters["c"]))
+
+	out, errText = callTool(t, server, "rolling_restart_fleet", map[string]interface{}{"app": "web", "max_unavailable_clusters": 2, "ignore_pdbs": true, "dry_run": true})
+	require.Empty(t, errText)
+	assert.Equal(t, []interface{}{[]interface{}{"a"}, []interface{}{"b"}}, out["waves"])
+}
+
+func TestRollingRestartFleetHaltsOnUnhealthyWave(t *testing.T) {
+	clusters, server := newRestartClusters(t)
+	clusters["b"].unreadyUpdates = true
+
+	out, errText := callTool(t, server, "rolling_restart_fleet", map[string]interface{}{"app": "web", "timeout_seconds": 1})
+	require.Empty(t, errText)
+	assert.Equal(t, "halted", out["outcome"])
+	assert.Contains(t, out["reason"], "wave 2")
+	assert.Equal(t, map[string]string{"a": restartRestarted, "b": restartFailed, "c": restartNotStarted}, restartStatuses(out))
+	assert.Empty(t, restartedAt(clusters["c"]), "later waves are not restarted")
+}
+
+func TestRollingRestartFleetInvalidArgs(t *testing.T) {
+	_, server := newRestartClusters(t)
+	for _, args := range []map[string]interface{}{
+		{},
+		{"app": "web", "namespace": "kube-system"},
+		{"app": "web", "max_unavailable_clusters": -1},
+		{"app": "web", "timeout_seconds": -1},
+		{"app": "web", "environment": "qa"},
+		{"app": "api"},
+	} {
+		_, errText := callTool(t, server, "rolling_restart_fleet", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+}
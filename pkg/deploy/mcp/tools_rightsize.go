@@ -0,0 +1,755 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Defaults of the right-sizing model. Like the Vertical Pod Autoscaler,
+// requests target a high percentile of observed usage plus a safety
+// margin, and limits keep their current ratio to requests.
+const (
+	defaultCPUPercentile    = 90
+	defaultMemoryPercentile = 95
+	defaultSafetyMargin     = 15
+	// defaultMinChange is the relative change, in percent, below which a
+	// request is left alone.
+	defaultMinChange = 10
+	// minSamplesForConfidence is how many usage samples a container needs
+	// before its recommendation is considered reliable.
+	minSamplesForConfidence = 3
+	maxMetricsSamples       = 30
+)
+
+// Recommendations never go below these, so idle containers keep enough to
+// start.
+var (
+	minCPURequest    = resource.MustParse("10m")
+	minMemoryRequest = resource.MustParse("32Mi")
+)
+
+// Actions of a container recommendation.
+const (
+	rightsizeKeep   = "keep"
+	rightsizeUpdate = "update"
+	// rightsizeNoData means metrics-server reported no usage for the
+	// container.
+	rightsizeNoData = "no-data"
+)
+
+// ResourceValues is a container's CPU and memory requests or limits.
+type ResourceValues struct {
+	CPU    string `json:"cpu,omitempty"`
+	Memory string `json:"memory,omitempty"`
+}
+
+// UsageStats summarizes observed usage of one resource.
+type UsageStats struct {
+	P50 string `json:"p50"`
+	P90 string `json:"p90"`
+	Max string `json:"max"`
+}
+
+// ContainerRecommendation is the right-sizing recommendation for one
+// container of a workload.
+type ContainerRecommendation struct {
+	Container           string         `json:"container"`
+	Samples             int            `json:"samples"`
+	CPUUsage            *UsageStats    `json:"cpuUsage,omitempty"`
+	MemoryUsage         *UsageStats    `json:"memoryUsage,omitempty"`
+	CurrentRequests     ResourceValues `json:"currentRequests"`
+	CurrentLimits       ResourceValues `json:"currentLimits"`
+	RecommendedRequests ResourceValues `json:"recommendedRequests"`
+	RecommendedLimits   ResourceValues `json:"recommendedLimits"`
+	Action              string         `json:"action"`
+	Changes             []string       `json:"changes,omitempty"`
+	LowConfidence       bool           `json:"lowConfidence,omitempty"`
+}
+
+// WorkloadRecommendation holds the recommendations for a workload, pooled
+// over every cluster that runs it.
+type WorkloadRecommendation struct {
+	Kind       string                    `json:"kind"`
+	Name       string                    `json:"name"`
+	Namespace  string                    `json:"namespace"`
+	Clusters   []string                  `json:"clusters"`
+	Pods       int                       `json:"pods"`
+	Containers []ContainerRecommendation `json:"containers"`
+}
+
+// rightsizeParams are the arguments shared by recommend_resources and
+// apply_resource_recommendations.
+type rightsizeParams struct {
+	App                   string   `json:"app"`
+	Namespace             string   `json:"namespace"`
+	Clusters              []string `json:"clusters"`
+	Samples               int      `json:"samples"`
+	SampleIntervalSeconds int      `json:"sample_interval_seconds"`
+	CPUPercentile         float64  `json:"cpu_percentile"`
+	MemoryPercentile      float64  `json:"memory_percentile"`
+	SafetyMarginPercent   *float64 `json:"safety_margin_percent"`
+	MinChangePercent      *float64 `json:"min_change_percent"`
+}
+
+func (p *rightsizeParams) validate() error {
+	if err := claude.ValidateK8sName(p.App); err != nil {
+		return fmt.Errorf("invalid app name: %w", err)
+	}
+	if p.Namespace != "" {
+		if err := claude.ValidateK8sNamespace(p.Namespace); err != nil {
+			return fmt.Errorf("invalid namespace: %w", err)
+		}
+		if err := server.ValidateNamespace(p.Namespace); err != nil {
+			return fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	switch {
+	case p.Samples == 0:
+		p.Samples = 1
+	case p.Samples < 0 || p.Samples > maxMetricsSamples:
+		return fmt.Errorf("samples must be between 1 and %d", maxMetricsSamples)
+	}
+	if p.SampleIntervalSeconds < 0 {
+		return fmt.Errorf("sample_interval_seconds must not be negative")
+	}
+	if p.SampleIntervalSeconds == 0 {
+		p.SampleIntervalSeconds = 15
+	}
+	for _, pct := range []*float64{&p.CPUPercentile, &p.MemoryPercentile} {
+		if *pct < 0 || *pct > 100 {
+			return fmt.Errorf("percentiles must be between 1 and 100")
+		}
+	}
+	if p.CPUPercentile == 0 {
+		p.CPUPercentile = defaultCPUPercentile
+	}
+	if p.MemoryPercentile == 0 {
+		p.MemoryPercentile = defaultMemoryPercentile
+	}
+	if p.SafetyMarginPercent == nil {
+		margin := float64(defaultSafetyMargin)
+		p.SafetyMarginPercent = &margin
+	}
+	if p.MinChangePercent == nil {
+		change := float64(defaultMinChange)
+		p.MinChangePercent = &change
+	}
+	if *p.SafetyMarginPercent < 0 || *p.MinChangePercent < 0 {
+		return fmt.Errorf("safety_margin_percent and min_change_percent must not be negative")
+	}
+	return nil
+}
+
+// rightsizeObservation is what one cluster reports for the app: its
+// workloads and the usage samples of their containers.
+type rightsizeObservation struct {
+	workloads []appWorkload
+	// usage maps "Kind/namespace/name" to container name to samples.
+	usage map[string]map[string][]containerUsage
+	pods  map[string]int
+}
+
+type containerUsage struct {
+	cpu    int64 // millicores
+	memory int64 // bytes
+}
+
+// handleRecommendResources recommends requests and limits for an app's
+// containers from their observed usage.
+func (s *Server) handleRecommendResources(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params rightsizeParams
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	recommendations, errs, err := s.recommendResources(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"app":             params.App,
+		"workloads":       recommendations,
+		"count":           len(recommendations),
+		"clusterErrors":   errs,
+		"model":           rightsizeModel(params),
+		"recommendations": countUpdates(recommendations),
+	}, nil
+}
+
+// rightsizeModel describes the parameters a recommendation was made with.
+func rightsizeModel(p rightsizeParams) map[string]interface{} {
+	return map[string]interface{}{
+		"cpuPercentile":       p.CPUPercentile,
+		"memoryPercentile":    p.MemoryPercentile,
+		"safetyMarginPercent": *p.SafetyMarginPercent,
+		"minChangePercent":    *p.MinChangePercent,
+		"samples":             p.Samples,
+	}
+}
+
+func countUpdates(recommendations []WorkloadRecommendation) int {
+	n := 0
+	for _, w := range recommendations {
+		for _, c := range w.Containers {
+			if c.Action == rightsizeUpdate {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// recommendResources samples usage of the app's containers in every
+// cluster and pools the samples per workload. Clusters that could not be
+// read are returned by name with their error.
+func (s *Server) recommendResources(ctx context.Context, p rightsizeParams) ([]WorkloadRecommendation, map[string]string, error) {
+	clusters := p.Clusters
+	if len(clusters) == 0 {
+		names, err := s.executor.ClusterNames()
+		if err != nil {
+			return nil, nil, err
+		}
+		clusters = names
+	}
+	interval := time.Duration(p.SampleIntervalSeconds) * time.Second
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return observeUsage(ctx, client, p.App, p.Namespace, p.Samples, interval)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type pooled struct {
+		rec     *WorkloadRecommendation
+		current map[string]corev1.ResourceRequirements
+		usage   map[string][]containerUsage
+	}
+	byKey := make(map[string]*pooled)
+	clusterErrors := make(map[string]string)
+	for _, r := range results {
+		if r.Error != "" {
+			clusterErrors[r.Cluster] = r.Error
+			continue
+		}
+		obs, _ := r.Result.(*rightsizeObservation)
+		if obs == nil {
+			continue
+		}
+		for _, w := range obs.workloads {
+			key := workloadKey(w)
+			entry := byKey[key]
+			if entry == nil {
+				template, _ := w.podTemplate()
+				entry = &pooled{
+					rec:     &WorkloadRecommendation{Kind: w.Kind, Name: w.meta().GetName(), Namespace: w.meta().GetNamespace()},
+					current: make(map[string]corev1.ResourceRequirements),
+					usage:   make(map[string][]containerUsage),
+				}
+				// The first cluster's spec is the baseline to change.
+				for _, c := range template.Spec.Containers {
+					entry.current[c.Name] = c.Resources
+				}
+				byKey[key] = entry
+			}
+			entry.rec.Clusters = append(entry.rec.Clusters, r.Cluster)
+			entry.rec.Pods += obs.pods[key]
+			for container, samples := range obs.usage[key] {
+				entry.usage[container] = append(entry.usage[container], samples...)
+			}
+		}
+	}
+
+	recommendations := make([]WorkloadRecommendation, 0, len(byKey))
+	for _, key := range sortedKeysOf(byKey) {
+		entry := byKey[key]
+		sort.Strings(entry.rec.Clusters)
+		for _, container := range sortedKeysOf(entry.current) {
+			entry.rec.Containers = append(entry.rec.Containers, recommendContainer(container, entry.current[container], entry.usage[container], p))
+		}
+		recommendations = append(recommendations, *entry.rec)
+	}
+	return recommendations, clusterErrors, nil
+}
+
+func workloadKey(w appWorkload) string {
+	return w.Kind + "/" + w.meta().GetNamespace() + "/" + w.meta().GetName()
+}
+
+// observeUsage finds the app's workloads in a cluster and takes samples
+// of their pods' usage from metrics-server, interval apart.
+func observeUsage(ctx context.Context, client *kubernetes.Clientset, app, namespace string, samples int, interval time.Duration) (*rightsizeObservation, error) {
+	workloads, err := listAppWorkloads(ctx, client, namespace, app)
+	if err != nil && len(workloads) == 0 {
+		return nil, err
+	}
+	obs := &rightsizeObservation{
+		usage: make(map[string]map[string][]containerUsage),
+		pods:  make(map[string]int),
+	}
+	for _, w := range workloads {
+		if namespace == "" && server.ValidateNamespace(w.meta().GetNamespace()) != nil {
+			continue
+		}
+		obs.workloads = append(obs.workloads, w)
+		obs.usage[workloadKey(w)] = make(map[string][]containerUsage)
+	}
+	for i := 0; i < samples; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+		for _, w := range obs.workloads {
+			key := workloadKey(w)
+			_, selector := w.podTemplate()
+			if selector == nil {
+				continue
+			}
+			sel, err := metav1.LabelSelectorAsSelector(selector)
+			if err != nil {
+				continue
+			}
+			metrics, err := podMetrics(ctx, client, w.meta().GetNamespace(), sel.String())
+			if err != nil {
+				return nil, fmt.Errorf("reading pod metrics (is metrics-server installed?): %w", err)
+			}
+			if i == 0 {
+				obs.pods[key] = len(metrics)
+			}
+			for _, pod := range metrics {
+				for container, usage := range pod {
+					obs.usage[key][container] = append(obs.usage[key][container], usage)
+				}
+			}
+		}
+	}
+	return obs, nil
+}
+
+// podMetrics reads the current usage of the pods matching selector from
+// the metrics.k8s.io API, by pod and container.
+func podMetrics(ctx context.Context, client *kubernetes.Clientset, namespace, selector string) ([]map[string]containerUsage, error) {
+	body, err := client.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", namespace, "pods").
+		Param("labelSelector", selector).
+		SetHeader("Accept", "application/json").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []struct {
+			Containers []struct {
+				Name  string              `json:"name"`
+				Usage corev1.ResourceList `json:"usage"`
+			} `json:"containers"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("decoding pod metrics: %w", err)
+	}
+	pods := make([]map[string]containerUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		pod := make(map[string]containerUsage, len(item.Containers))
+		for _, c := range item.Containers {
+			pod[c.Name] = containerUsage{cpu: c.Usage.Cpu().MilliValue(), memory: c.Usage.Memory().Value()}
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// recommendContainer turns a container's usage samples into recommended
+// requests and limits.
+func recommendContainer(name string, current corev1.ResourceRequirements, samples []containerUsage, p rightsizeParams) ContainerRecommendation {
+	rec := ContainerRecommendation{
+		Container:       name,
+		Samples:         len(samples),
+		CurrentRequests: resourceValues(current.Requests),
+		CurrentLimits:   resourceValues(current.Limits),
+	}
+	if len(samples) == 0 {
+		rec.Action = rightsizeNoData
+		rec.RecommendedRequests, rec.RecommendedLimits = rec.CurrentRequests, rec.CurrentLimits
+		return rec
+	}
+	rec.LowConfidence = len(samples) < minSamplesForConfidence
+
+	cpu := make([]float64, len(samples))
+	memory := make([]float64, len(samples))
+	for i, s := range samples {
+		cpu[i], memory[i] = float64(s.cpu), float64(s.memory)
+	}
+	sort.Float64s(cpu)
+	sort.Float64s(memory)
+	rec.CPUUsage = &UsageStats{P50: milliCPU(percentile(cpu, 50)), P90: milliCPU(percentile(cpu, 90)), Max: milliCPU(cpu[len(cpu)-1])}
+	rec.MemoryUsage = &UsageStats{P50: memoryBytes(percentile(memory, 50)), P90: memoryBytes(percentile(memory, 90)), Max: memoryBytes(memory[len(memory)-1])}
+
+	margin := 1 + *p.SafetyMarginPercent/100
+	targetCPU := roundUp(percentile(cpu, p.CPUPercentile)*margin, 5)
+	targetMemory := roundUp(percentile(memory, p.MemoryPercentile)*margin, 1<<20)
+	targetCPU = math.Max(targetCPU, float64(minCPURequest.MilliValue()))
+	targetMemory = math.Max(targetMemory, float64(minMemoryRequest.Value()))
+
+	requests := current.Requests.DeepCopy()
+	limits := current.Limits.DeepCopy()
+	if requests == nil {
+		requests = corev1.ResourceList{}
+	}
+	for _, r := range []struct {
+		name   corev1.ResourceName
+		target resource.Quantity
+	}{
+		{corev1.ResourceCPU, *resource.NewMilliQuantity(int64(targetCPU), resource.DecimalSI)},
+		{corev1.ResourceMemory, *resource.NewQuantity(int64(targetMemory), resource.BinarySI)},
+	} {
+		cur, hasRequest := current.Requests[r.name]
+		if hasRequest && !significantChange(cur, r.target, *p.MinChangePercent) {
+			continue
+		}
+		requests[r.name] = r.target
+		if limit, ok := current.Limits[r.name]; ok {
+			newLimit := scaleLimit(limit, cur, hasRequest, r.target)
+			limits[r.name] = newLimit
+			rec.Changes = append(rec.Changes, fmt.Sprintf("%s limit %s -> %s", r.name, limit.String(), newLimit.String()))
+		}
+		from := "unset"
+		if hasRequest {
+			from = cur.String()
+		}
+		rec.Changes = append(rec.Changes, fmt.Sprintf("%s request %s -> %s", r.name, from, r.target.String()))
+	}
+	sort.Strings(rec.Changes)
+	rec.RecommendedRequests = resourceValues(requests)
+	rec.RecommendedLimits = resourceValues(limits)
+	rec.Action = rightsizeKeep
+	if len(rec.Changes) > 0 {
+		rec.Action = rightsizeUpdate
+	}
+	return rec
+}
+
+// significantChange reports whether target differs from current by more
+// than minChange percent.
+func significantChange(current, target resource.Quantity, minChange float64) bool {
+	cur := current.AsApproximateFloat64()
+	if cur == 0 {
+		return true
+	}
+	return math.Abs(target.AsApproximateFloat64()-cur)/cur*100 > minChange
+}
+
+// scaleLimit keeps a limit's ratio to its request as the request changes.
+// A limit without a request is only raised if the new request exceeds it.
+func scaleLimit(limit, request resource.Quantity, hasRequest bool, target resource.Quantity) resource.Quantity {
+	if !hasRequest || request.IsZero() {
+		if target.Cmp(limit) > 0 {
+			return target
+		}
+		return limit
+	}
+	if target.Format == resource.DecimalSI {
+		ratio := float64(limit.MilliValue()) / float64(request.MilliValue())
+		return *resource.NewMilliQuantity(int64(roundUp(float64(target.MilliValue())*ratio, 1)), resource.DecimalSI)
+	}
+	ratio := float64(limit.Value()) / float64(request.Value())
+	return *resource.NewQuantity(int64(roundUp(float64(target.Value())*ratio, 1<<20)), resource.BinarySI)
+}
+
+// percentile returns the pct percentile of sorted values, interpolating
+// between the nearest ranks.
+func percentile(sorted []float64, pct float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+	rank := pct / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// roundUp rounds v up to a multiple of step, ignoring floating point
+// noise.
+func roundUp(v, step float64) float64 {
+	return math.Ceil(v/step-1e-9) * step
+}
+
+func milliCPU(v float64) string {
+	return resource.NewMilliQuantity(int64(math.Round(v)), resource.DecimalSI).String()
+}
+
+func memoryBytes(v float64) string {
+	return resource.NewQuantity(int64(roundUp(v, 1<<20)), resource.BinarySI).String()
+}
+
+func resourceValues(list corev1.ResourceList) ResourceValues {
+	var v ResourceValues
+	if q, ok := list[corev1.ResourceCPU]; ok {
+		v.CPU = q.String()
+	}
+	if q, ok := list[corev1.ResourceMemory]; ok {
+		v.Memory = q.String()
+	}
+	return v
+}
+
+// ResourcePatch is the outcome of applying recommendations to one workload
+// in one cluster.
+type ResourcePatch struct {
+	Cluster  string   `json:"cluster"`
+	Workload string   `json:"workload"`
+	Status   string   `json:"status"`
+	Changes  []string `json:"changes,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// handleApplyResourceRecommendations computes recommendations and sets
+// them on the app's workloads in every cluster. If any update fails or the
+// workloads do not become ready, every update is rolled back.
+func (s *Server) handleApplyResourceRecommendations(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		rightsizeParams
+		Containers     []string `json:"containers"`
+		IncludeLowConf bool     `json:"include_low_confidence"`
+		TimeoutSeconds int      `json:"timeout_seconds"`
+		DryRun         bool     `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+	if params.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative")
+	}
+	timeout := defaultHealthTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+
+	recommendations, clusterErrors, err := s.recommendResources(ctx, params.rightsizeParams)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(params.Containers))
+	for _, c := range params.Containers {
+		wanted[c] = true
+	}
+	// plan maps "Kind/namespace/name" to the container recommendations to
+	// apply.
+	plan := make(map[string][]ContainerRecommendation)
+	var skipped []string
+	for _, w := range recommendations {
+		key := w.Kind + "/" + w.Namespace + "/" + w.Name
+		for _, c := range w.Containers {
+			if c.Action != rightsizeUpdate || (len(wanted) > 0 && !wanted[c.Container]) {
+				continue
+			}
+			if c.LowConfidence && !params.IncludeLowConf {
+				skipped = append(skipped, fmt.Sprintf("%s container %s: only %d samples; set include_low_confidence to apply", key, c.Container, c.Samples))
+				continue
+			}
+			plan[key] = append(plan[key], c)
+		}
+	}
+	response := map[string]interface{}{
+		"app":             params.App,
+		"dryRun":          dryRun,
+		"model":           rightsizeModel(params.rightsizeParams),
+		"recommendations": recommendations,
+		"skipped":         skipped,
+		"clusterErrors":   clusterErrors,
+	}
+	if len(plan) == 0 {
+		response["outcome"] = "nothing to apply"
+		response["patches"] = []ResourcePatch{}
+		return response, nil
+	}
+
+	ctx, rec, mark := s.beginTransaction(ctx)
+	clusters := make(map[string]bool)
+	for _, w := range recommendations {
+		if len(plan[w.Kind+"/"+w.Namespace+"/"+w.Name]) > 0 {
+			for _, c := range w.Clusters {
+				clusters[c] = true
+			}
+		}
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, sortedKeys(clusters), func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return applyResourcePlan(ctx, client, clusterName, params.App, params.Namespace, plan, timeout)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var (
+		patches []ResourcePatch
+		failed  []string
+	)
+	for _, r := range results {
+		clusterPatches, _ := r.Result.([]ResourcePatch)
+		if r.Error != "" {
+			clusterPatches = append(clusterPatches, ResourcePatch{Cluster: r.Cluster, Status: "failed", Error: r.Error})
+		}
+		for _, patch := range clusterPatches {
+			if patch.Status == "failed" || patch.Status == "unready" {
+				failed = append(failed, patch.Cluster+" "+patch.Workload)
+			}
+		}
+		patches = append(patches, clusterPatches...)
+	}
+	sort.Slice(patches, func(i, j int) bool {
+		if patches[i].Cluster != patches[j].Cluster {
+			return patches[i].Cluster < patches[j].Cluster
+		}
+		return patches[i].Workload < patches[j].Workload
+	})
+	response["patches"] = patches
+	response["outcome"] = txCommitted
+	if len(failed) == 0 || dryRun {
+		return response, nil
+	}
+
+	// Roll back every update this call made, as an atomic deploy does.
+	response["reason"] = "failed or not ready: " + strings.Join(failed, ", ")
+	rollback := journal.Revert(journal.WithRecorder(ctx, nil), rec.Objects()[mark:], s.configForServer, false)
+	response["rollback"] = rollback
+	response["outcome"] = txRolledBack
+	for _, r := range rollback {
+		if r.Error != "" {
+			response["outcome"] = txRollbackIncomplete
+			return response, nil
+		}
+	}
+	rec.Truncate(mark)
+	return response, nil
+}
+
+// applyResourcePlan sets the recommended resources on the app's workloads
+// in one cluster and waits for them to become ready.
+func applyResourcePlan(ctx context.Context, client *kubernetes.Clientset, clusterName, app, namespace string, plan map[string][]ContainerRecommendation, timeout time.Duration) ([]ResourcePatch, error) {
+	workloads, err := listAppWorkloads(ctx, client, namespace, app)
+	if err != nil && len(workloads) == 0 {
+		return nil, err
+	}
+	var (
+		patches []ResourcePatch
+		applied []gitops.Manifest
+	)
+	for _, w := range workloads {
+		containers := plan[workloadKey(w)]
+		if len(containers) == 0 {
+			continue
+		}
+		patch := ResourcePatch{Cluster: clusterName, Workload: w.Kind + "/" + w.meta().GetName(), Status: "updated"}
+		if approval.IsDryRun(ctx) {
+			patch.Status = "would-update"
+		}
+		for _, c := range containers {
+			patch.Changes = append(patch.Changes, c.Container+": "+strings.Join(c.Changes, ", "))
+		}
+		if err := setContainerResources(ctx, client, w, containers); err != nil {
+			patch.Status, patch.Error = "failed", err.Error()
+		} else {
+			applied = append(applied, gitops.Manifest{Kind: w.Kind, Metadata: gitops.ManifestMetadata{Name: w.meta().GetName(), Namespace: w.meta().GetNamespace()}})
+		}
+		patches = append(patches, patch)
+	}
+	if approval.IsDryRun(ctx) || len(applied) == 0 {
+		return patches, nil
+	}
+	pending, err := waitForReady(ctx, client, applied, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range patches {
+		for _, p := range pending {
+			if patches[i].Workload == p {
+				patches[i].Status = "unready"
+				patches[i].Error = fmt.Sprintf("not ready within %s", timeout)
+			}
+		}
+	}
+	return patches, nil
+}
+
+// setContainerResources updates a workload's containers to the
+// recommended requests and limits.
+func setContainerResources(ctx context.Context, client kubernetes.Interface, w appWorkload, containers []ContainerRecommendation) error {
+	apply := func(spec *corev1.PodSpec) error {
+		for _, rec := range containers {
+			for i := range spec.Containers {
+				c := &spec.Containers[i]
+				if c.Name != rec.Container {
+					continue
+				}
+				if err := setResourceValues(&c.Resources.Requests, rec.RecommendedRequests); err != nil {
+					return err
+				}
+				if err := setResourceValues(&c.Resources.Limits, rec.RecommendedLimits); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	var err error
+	switch {
+	case w.deployment != nil:
+		d := w.deployment.DeepCopy()
+		if err = apply(&d.Spec.Template.Spec); err == nil {
+			_, err = client.AppsV1().Deployments(d.Namespace).Update(ctx, d, metav1.UpdateOptions{})
+		}
+	case w.statefulSet != nil:
+		ss := w.statefulSet.DeepCopy()
+		if err = apply(&ss.Spec.Template.Spec); err == nil {
+			_, err = client.AppsV1().StatefulSets(ss.Namespace).Update(ctx, ss, metav1.UpdateOptions{})
+		}
+	default:
+		ds := w.daemonSet.DeepCopy()
+		if err = apply(&ds.Spec.Template.Spec); err == nil {
+			_, err = client.AppsV1().DaemonSets(ds.Namespace).Update(ctx, ds, metav1.UpdateOptions{})
+		}
+	}
+	return err
+}
+
+func setResourceValues(list *corev1.ResourceList, values ResourceValues) error {
+	for name, value := range map[corev1.ResourceName]string{corev1.ResourceCPU: values.CPU, corev1.ResourceMemory: values.Memory} {
+		if value == "" {
+			continue
+		}
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s quantity %q: %w", name, value, err)
+		}
+		if *list == nil {
+			*list = corev1.ResourceList{}
+		}
+		(*list)[name] = q
+	}
+	return nil
+}
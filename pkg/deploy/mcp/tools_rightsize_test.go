@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// sizedWebDeployment is webDeployment with requests and limits set.
+func sizedWebDeployment() *appsv1.Deployment {
+	d := webDeployment("web:v1")
+	d.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+		Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2"), corev1.ResourceMemory: resource.MustParse("2Gi")},
+	}
+	return d
+}
+
+// putPodMetrics stores the metrics-server usage of a web pod.
+func putPodMetrics(t *testing.T, cluster *objectAPIServer, pod, cpu, memory string) {
+	t.Helper()
+	cluster.put(t, "/apis/metrics.k8s.io/v1beta1/namespaces/shop/pods/"+pod, map[string]interface{}{
+		"kind": "PodMetrics", "apiVersion": "metrics.k8s.io/v1beta1",
+		"metadata":   map[string]interface{}{"name": pod, "namespace": "shop", "labels": map[string]interface{}{"app": "web"}},
+		"containers": []interface{}{map[string]interface{}{"name": "web", "usage": map[string]interface{}{"cpu": cpu, "memory": memory}}},
+	})
+}
+
+// newRightsizeClusters returns two clusters running an over-provisioned
+// web Deployment.
+func newRightsizeClusters(t *testing.T) (east, west *objectAPIServer, server *Server) {
+	east, eastURL := newObjectAPIServer(t, false)
+	west, westURL := newObjectAPIServer(t, false)
+	for _, cluster := range []*objectAPIServer{east, west} {
+		cluster.put(t, shopDeployPath, sizedWebDeployment())
+	}
+	putPodMetrics(t, east, "web-1", "100m", "200Mi")
+	putPodMetrics(t, east, "web-2", "150m", "250Mi")
+	putPodMetrics(t, west, "web-3", "200m", "300Mi")
+	return east, west, newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL})
+}
+
+func deploymentResources(t *testing.T, obj map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	containers, _, _ := unstructured.NestedSlice(obj, "spec", "template", "spec", "containers")
+	require.Len(t, containers, 1)
+	resources, _, _ := unstructured.NestedMap(containers[0].(map[string]interface{}), "resources")
+	return resources
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{100, 150, 200}
+	assert.Equal(t, 100.0, percentile(values, 0))
+	assert.Equal(t, 150.0, percentile(values, 50))
+	assert.InDelta(t, 190.0, percentile(values, 90), 1e-9)
+	assert.Equal(t, 200.0, percentile(values, 100))
+	assert.Equal(t, 42.0, percentile([]float64{42}, 90))
+}
+
+func TestRecommendResourcesPoolsUsageAcrossClusters(t *testing.T) {
+	_, _, server := newRightsizeClusters(t)
+
+	out, errText := callTool(t, server, "recommend_resources", map[string]interface{}{"app": "web", "namespace": "shop"})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 1, out["recommendations"])
+	workloads := out["workloads"].([]interface{})
+	require.Len(t, workloads, 1)
+	workload := workloads[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{"east", "west"}, workload["clusters"])
+	assert.EqualValues(t, 3, workload["pods"])
+
+	container := workload["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, rightsizeUpdate, container["action"])
+	assert.EqualValues(t, 3, container["samples"])
+	assert.Nil(t, container["lowConfidence"])
+	// p90 of 100m, 150m, 200m is 190m; with the 15% margin, 220m.
+	// p95 of 200Mi, 250Mi, 300Mi is 295Mi; with the margin, 340Mi.
+	// Limits keep their 2x ratio to requests.
+	assert.Equal(t, map[string]interface{}{"cpu": "220m", "memory": "340Mi"}, container["recommendedRequests"])
+	assert.Equal(t, map[string]interface{}{"cpu": "440m", "memory": "680Mi"}, container["recommendedLimits"])
+	assert.Contains(t, container["changes"], "cpu request 1 -> 220m")
+}
+
+func TestRecommendResourcesKeepsRequestsWithinMinChange(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	d := sizedWebDeployment()
+	d.Spec.Template.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse("120m"), corev1.ResourceMemory: resource.MustParse("64Mi"),
+	}
+	east.put(t, shopDeployPath, d)
+	putPodMetrics(t, east, "web-1", "100m", "1Mi")
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL})
+
+	out, errText := callTool(t, server, "recommend_resources", map[string]interface{}{"app": "web"})
+	require.Empty(t, errText)
+	container := out["workloads"].([]interface{})[0].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, true, container["lowConfidence"])
+	// 115m is within 10% of 120m, and memory never drops below 32Mi.
+	assert.Equal(t, map[string]interface{}{"cpu": "120m", "memory": "32Mi"}, container["recommendedRequests"])
+	assert.Equal(t, []interface{}{"memory limit 2Gi -> 1Gi", "memory request 64Mi -> 32Mi"}, container["changes"])
+}
+
+func TestApplyResourceRecommendations(t *testing.T) {
+	east, west, server := newRightsizeClusters(t)
+	args := map[string]interface{}{"app": "web", "namespace": "shop", "dry_run": true}
+
+	out, errText := callTool(t, server, "apply_resource_recommendations", args)
+	require.Empty(t, errText)
+	patches := out["patches"].([]interface{})
+	require.Len(t, patches, 2)
+	assert.Equal(t, "would-update", patches[0].(map[string]interface{})["status"])
+	assert.Equal(t, "1", deploymentResources(t, east.get(shopDeployPath))["requests"].(map[string]interface{})["cpu"])
+
+	args["dry_run"] = false
+	out, errText = callTool(t, server, "apply_resource_recommendations", args)
+	require.Empty(t, errText)
+	assert.Equal(t, txCommitted, out["outcome"])
+	for _, cluster := range []*objectAPIServer{east, west} {
+		resources := deploymentResources(t, cluster.get(shopDeployPath))
+		assert.Equal(t, map[string]interface{}{"cpu": "220m", "memory": "340Mi"}, resources["requests"])
+		assert.Equal(t, map[string]interface{}{"cpu": "440m", "memory": "680Mi"}, resources["limits"])
+	}
+
+	out, errText = callTool(t, server, "apply_resource_recommendations", args)
+	require.Empty(t, errText)
+	assert.Equal(t, "nothing to apply", out["outcome"])
+}
+
+func TestApplyResourceRecommendationsRollsBackUnreadyWorkloads(t *testing.T) {
+	east, west, server := newRightsizeClusters(t)
+	unready := sizedWebDeployment()
+	unready.Status = appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2}
+	west.put(t, shopDeployPath, unready)
+
+	out, errText := callTool(t, server, "apply_resource_recommendations", map[string]interface{}{
+		"app": "web", "namespace": "shop", "timeout_seconds": 1,
+	})
+	require.Empty(t, errText)
+	assert.Equal(t, txRolledBack, out["outcome"])
+	assert.Contains(t, out["reason"], "west Deployment/web")
+	for _, cluster := range []*objectAPIServer{east, west} {
+		requests := deploymentResources(t, cluster.get(shopDeployPath))["requests"].(map[string]interface{})
+		assert.Equal(t, "1", requests["cpu"])
+	}
+}
+
+func TestApplyResourceRecommendationsSkipsLowConfidence(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	east.put(t, shopDeployPath, sizedWebDeployment())
+	putPodMetrics(t, east, "web-1", "100m", "200Mi")
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL})
+
+	out, errText := callTool(t, server, "apply_resource_recommendations", map[string]interface{}{"app": "web"})
+	require.Empty(t, errText)
+	assert.Equal(t, "nothing to apply", out["outcome"])
+	assert.Len(t, out["skipped"], 1)
+
+	out, errText = callTool(t, server, "apply_resource_recommendations", map[string]interface{}{"app": "web", "include_low_confidence": true})
+	require.Empty(t, errText)
+	assert.Equal(t, txCommitted, out["outcome"])
+	assert.Equal(t, "115m", deploymentResources(t, east.get(shopDeployPath))["requests"].(map[string]interface{})["cpu"])
+}
+
+func TestRecommendResourcesInvalidArgs(t *testing.T) {
+	_, _, server := newRightsizeClusters(t)
+	for _, args := range []map[string]interface{}{
+		{},
+		{"app": "web", "namespace": "kube-system"},
+		{"app": "web", "samples": 31},
+		{"app": "web", "cpu_percentile": 120},
+		{"app": "web", "safety_margin_percent": -5},
+		{"app": "web", "sample_interval_seconds": -1},
+	} {
+		_, errText := callTool(t, server, "recommend_resources", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+}
@@ -0,0 +1,734 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Rollout states. Running and paused rollouts can still change clusters;
+// the others are final.
+const (
+	rolloutRunning   = "running"
+	rolloutPaused    = "paused"
+	rolloutCompleted = "completed"
+	rolloutFailed    = "failed"
+	rolloutAborted   = "aborted"
+)
+
+// Batch states.
+const (
+	batchPending   = "pending"
+	batchApplying  = "applying"
+	batchSucceeded = "succeeded"
+	batchFailed    = "failed"
+)
+
+// maxRollouts bounds how many finished rollouts are kept in the state store.
+const maxRollouts = 100
+
+// errRolloutStopped ends a rollout's runner when the rollout is no longer
+// running.
+var errRolloutStopped = errors.New("rollout is not running")
+
+// rolloutStrategy is the rollout_strategy argument of deploy_app.
+type rolloutStrategy struct {
+	// CanaryClusters are deployed first. If empty, the first CanaryCount
+	// target clusters, in name order, are the canary.
+	CanaryClusters []string `json:"canary_clusters,omitempty"`
+	CanaryCount    int      `json:"canary_count,omitempty"`
+	// BatchSize is how many of the remaining clusters are deployed at a
+	// time. Zero deploys them all in one batch.
+	BatchSize int `json:"batch_size,omitempty"`
+	// HealthTimeoutSeconds bounds how long each batch's workloads may take
+	// to become ready.
+	HealthTimeoutSeconds int `json:"health_timeout_seconds,omitempty"`
+	// MaxRestarts fails a batch if any container of its workloads has
+	// restarted more often than this.
+	MaxRestarts *int32 `json:"max_restarts,omitempty"`
+	// PauseAfterCanary and PauseBetweenBatches wait for resume_rollout.
+	PauseAfterCanary    bool `json:"pause_after_canary,omitempty"`
+	PauseBetweenBatches bool `json:"pause_between_batches,omitempty"`
+}
+
+func (st rolloutStrategy) healthTimeout() time.Duration {
+	if st.HealthTimeoutSeconds > 0 {
+		return time.Duration(st.HealthTimeoutSeconds) * time.Second
+	}
+	return defaultHealthTimeout
+}
+
+// rolloutBatch is one stage of a rollout.
+type rolloutBatch struct {
+	Clusters []string       `json:"clusters"`
+	Canary   bool           `json:"canary,omitempty"`
+	Status   string         `json:"status"`
+	Results  []DeployResult `json:"results,omitempty"`
+	// Unhealthy lists, per cluster, the workloads that failed the health or
+	// restart gate.
+	Unhealthy map[string][]string `json:"unhealthy,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	// ChangeID is the journaled change of this batch's apply.
+	ChangeID string `json:"changeId,omitempty"`
+}
+
+// rollout is a staged deploy_app, persisted in store.BucketRollouts. The
+// manifest is kept in memory only, since it may contain Secrets.
+type rollout struct {
+	ID        string          `json:"id"`
+	State     string          `json:"state"`
+	Message   string          `json:"message,omitempty"`
+	Strategy  rolloutStrategy `json:"strategy"`
+	Batches   []rolloutBatch  `json:"batches"`
+	NextBatch int             `json:"nextBatch"`
+	Created   time.Time       `json:"created"`
+	Updated   time.Time       `json:"updated"`
+	// RollbackChangeIDs are the undo changes made by abort_rollout.
+	RollbackChangeIDs []string `json:"rollbackChangeIds,omitempty"`
+}
+
+func (r *rollout) finished() bool {
+	return r.State != rolloutRunning && r.State != rolloutPaused
+}
+
+// rolloutRun is the in-memory side of a rollout started by this process.
+type rolloutRun struct {
+	manifest string
+	opts     applyOptions
+	// cancel and done are set while a runner goroutine is applying batches.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// planBatches splits targets into a canary batch followed by batches of
+// strategy.BatchSize clusters.
+func planBatches(targets []string, strategy rolloutStrategy) ([]rolloutBatch, error) {
+	if strategy.CanaryCount < 0 || strategy.BatchSize < 0 || strategy.HealthTimeoutSeconds < 0 {
+		return nil, fmt.Errorf("rollout_strategy counts must not be negative")
+	}
+	remaining := append([]string(nil), targets...)
+	sort.Strings(remaining)
+
+	var canary []string
+	if len(strategy.CanaryClusters) > 0 {
+		isTarget := make(map[string]bool, len(remaining))
+		for _, c := range remaining {
+			isTarget[c] = true
+		}
+		isCanary := make(map[string]bool, len(strategy.CanaryClusters))
+		for _, c := range strategy.CanaryClusters {
+			if !isTarget[c] {
+				return nil, fmt.Errorf("canary cluster %q is not a target cluster", c)
+			}
+			if !isCanary[c] {
+				isCanary[c] = true
+				canary = append(canary, c)
+			}
+		}
+		var rest []string
+		for _, c := range remaining {
+			if !isCanary[c] {
+				rest = append(rest, c)
+			}
+		}
+		remaining = rest
+	} else {
+		count := strategy.CanaryCount
+		if count == 0 {
+			count = 1
+		}
+		if count > len(remaining) {
+			count = len(remaining)
+		}
+		canary, remaining = remaining[:count], remaining[count:]
+	}
+
+	batches := []rolloutBatch{{Clusters: canary, Canary: true, Status: batchPending}}
+	size := strategy.BatchSize
+	if size == 0 {
+		size = len(remaining)
+	}
+	for len(remaining) > 0 {
+		n := size
+		if n > len(remaining) {
+			n = len(remaining)
+		}
+		batches = append(batches, rolloutBatch{Clusters: remaining[:n], Status: batchPending})
+		remaining = remaining[n:]
+	}
+	return batches, nil
+}
+
+// startRollout plans a staged deploy of manifest to targets and starts
+// applying it in the background. A dry run validates the manifest on every
+// target and returns the plan without starting anything.
+func (s *Server) startRollout(ctx context.Context, targets []string, manifest string, strategy rolloutStrategy, opts applyOptions) (interface{}, error) {
+	batches, err := planBatches(targets, strategy)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun || approval.IsDryRun(ctx) {
+		opts.DryRun = true
+		results, successCount, err := s.deployToClusters(ctx, targets, manifest, opts)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"targetClusters": targets,
+			"successCount":   successCount,
+			"totalClusters":  len(targets),
+			"results":        results,
+			"batches":        batches,
+			"dryRun":         true,
+		}, nil
+	}
+
+	now := time.Now()
+	r := &rollout{
+		ID:       newRolloutID(now),
+		State:    rolloutRunning,
+		Strategy: strategy,
+		Batches:  batches,
+		Created:  now,
+		Updated:  now,
+	}
+	s.rolloutMu.Lock()
+	err = s.saveRollout(ctx, r)
+	if err == nil {
+		s.launchRollout(r.ID, &rolloutRun{manifest: manifest, opts: opts})
+	}
+	s.rolloutMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	s.trimRollouts(ctx)
+
+	return map[string]interface{}{
+		"rolloutId":      r.ID,
+		"state":          r.State,
+		"targetClusters": targets,
+		"batches":        batches,
+		"message":        "Rollout started; follow it with get_rollout",
+	}, nil
+}
+
+// launchRollout starts a runner for rollout id. s.rolloutMu must be held.
+func (s *Server) launchRollout(id string, run *rolloutRun) {
+	if s.rolloutRuns == nil {
+		s.rolloutRuns = make(map[string]*rolloutRun)
+	}
+	// The runner outlives the tool call that started it.
+	ctx, cancel := context.WithCancel(context.Background())
+	run.cancel = cancel
+	run.done = make(chan struct{})
+	s.rolloutRuns[id] = run
+	go s.runRollout(ctx, id, run)
+}
+
+// runRollout applies the batches of rollout id in order until the rollout
+// completes, fails, or is paused or aborted.
+func (s *Server) runRollout(ctx context.Context, id string, run *rolloutRun) {
+	defer func() {
+		s.rolloutMu.Lock()
+		stopRun(run)
+		s.rolloutMu.Unlock()
+	}()
+	// Bookkeeping must land even after abort_rollout cancels ctx.
+	stateCtx := context.WithoutCancel(ctx)
+
+	for {
+		var (
+			index    int
+			batch    rolloutBatch
+			strategy rolloutStrategy
+		)
+		_, err := s.updateRollout(stateCtx, id, func(r *rollout) error {
+			if r.State != rolloutRunning || r.NextBatch >= len(r.Batches) {
+				// Stop under the lock, so resume_rollout either sees this
+				// runner still going or starts a new one.
+				stopRun(run)
+				if r.finished() {
+					delete(s.rolloutRuns, id)
+				}
+				return errRolloutStopped
+			}
+			index, strategy = r.NextBatch, r.Strategy
+			r.Batches[index].Status = batchApplying
+			batch = r.Batches[index]
+			return nil
+		})
+		if err != nil {
+			return
+		}
+
+		batch = s.runBatch(ctx, strategy, batch, run)
+
+		r, err := s.updateRollout(stateCtx, id, func(r *rollout) error {
+			r.Batches[index] = batch
+			switch {
+			case r.State == rolloutAborted:
+			case batch.Status == batchFailed:
+				r.State = rolloutFailed
+				r.Message = fmt.Sprintf("batch %d failed: %s; later batches were not deployed", index+1, batch.Error)
+			default:
+				r.NextBatch = index + 1
+				switch {
+				case r.NextBatch == len(r.Batches):
+					r.State = rolloutCompleted
+					r.Message = ""
+				case r.State == rolloutRunning && (strategy.PauseBetweenBatches || (batch.Canary && strategy.PauseAfterCanary)):
+					r.State = rolloutPaused
+					r.Message = fmt.Sprintf("paused after batch %d; call resume_rollout to continue", index+1)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+		s.notifyRollout(stateCtx, r)
+	}
+}
+
+// stopRun marks run as no longer applying batches. s.rolloutMu must be held.
+func stopRun(run *rolloutRun) {
+	if run.cancel == nil {
+		return
+	}
+	run.cancel()
+	run.cancel = nil
+	close(run.done)
+}
+
+// runBatch applies the manifest of run to the clusters of batch, journaling the change,
+// then checks the health and restart gates.
+func (s *Server) runBatch(ctx context.Context, strategy rolloutStrategy, batch rolloutBatch, run *rolloutRun) rolloutBatch {
+	rec := s.getJournal().Begin("deploy_app")
+	results, _, err := s.deployToClusters(journal.WithRecorder(ctx, rec), batch.Clusters, run.manifest, run.opts)
+	if change, commitErr := s.getJournal().Commit(context.WithoutCancel(ctx), rec); commitErr == nil && change != nil {
+		batch.ChangeID = change.ID
+	}
+	batch.Results = results
+	batch.Status = batchFailed
+	if err != nil {
+		batch.Error = err.Error()
+		return batch
+	}
+	if failed := failedClusters(results); len(failed) > 0 {
+		batch.Error = fmt.Sprintf("apply failed on %s", strings.Join(sortedKeys(failed), ", "))
+		return batch
+	}
+
+	unhealthy, err := s.waitForWorkloads(ctx, batch.Clusters, run.manifest, run.opts.Variables, strategy.healthTimeout())
+	if err != nil {
+		batch.Error = fmt.Sprintf("health check failed: %v", err)
+		return batch
+	}
+	if len(unhealthy) > 0 {
+		batch.Unhealthy = unhealthy
+		batch.Error = fmt.Sprintf("workloads not ready within %s", strategy.healthTimeout())
+		return batch
+	}
+	if strategy.MaxRestarts != nil {
+		restarted, err := s.restartedWorkloads(ctx, batch.Clusters, run.manifest, run.opts.Variables, *strategy.MaxRestarts)
+		if err != nil {
+			batch.Error = fmt.Sprintf("restart check failed: %v", err)
+			return batch
+		}
+		if len(restarted) > 0 {
+			batch.Unhealthy = restarted
+			batch.Error = fmt.Sprintf("containers restarted more than %d times", *strategy.MaxRestarts)
+			return batch
+		}
+	}
+	batch.Status = batchSucceeded
+	return batch
+}
+
+// restartedWorkloads returns, by cluster, the workloads in manifest,
+// rendered with vars, with a container that restarted more than maxRestarts
+// times.
+func (s *Server) restartedWorkloads(ctx context.Context, clusters []string, manifest string, vars *manifestVariables, maxRestarts int32) (map[string][]string, error) {
+	workloads, err := s.manifestWorkloads(manifest)
+	if err != nil || len(workloads) == 0 {
+		return nil, err
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		rendered, err := vars.render(ctx, client, clusterName, workloads)
+		if err != nil {
+			return nil, err
+		}
+		var restarted []string
+		for _, w := range rendered {
+			selector, err := workloadSelector(ctx, client, w.Kind, w.GetNamespace(), w.Metadata.Name)
+			if err != nil {
+				return nil, err
+			}
+			pods, err := client.CoreV1().Pods(w.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector})
+			if err != nil {
+				return nil, err
+			}
+		pods:
+			for _, pod := range pods.Items {
+				for _, cs := range pod.Status.ContainerStatuses {
+					if cs.RestartCount > maxRestarts {
+						restarted = append(restarted, fmt.Sprintf("%s/%s", w.Kind, w.Metadata.Name))
+						break pods
+					}
+				}
+			}
+		}
+		return restarted, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]string)
+	for _, r := range results {
+		if r.Error != "" {
+			out[r.Cluster] = []string{r.Error}
+		} else if names, _ := r.Result.([]string); len(names) > 0 {
+			out[r.Cluster] = names
+		}
+	}
+	return out, nil
+}
+
+// workloadSelector returns the pod label selector of a workload.
+func workloadSelector(ctx context.Context, client kubernetes.Interface, kind, namespace, name string) (string, error) {
+	var selector *metav1.LabelSelector
+	switch kind {
+	case "Deployment":
+		d, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = d.Spec.Selector
+	case "StatefulSet":
+		ss, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = ss.Spec.Selector
+	case "DaemonSet":
+		ds, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		selector = ds.Spec.Selector
+	default:
+		return "", fmt.Errorf("unsupported workload kind %s", kind)
+	}
+	parsed, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", err
+	}
+	return parsed.String(), nil
+}
+
+func (s *Server) loadRollout(ctx context.Context, id string) (*rollout, error) {
+	var r rollout
+	if err := store.GetJSON(ctx, s.getStateStore(), store.BucketRollouts, id, &r); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("rollout %q not found", id)
+		}
+		return nil, err
+	}
+	return &r, nil
+}
+
+func (s *Server) saveRollout(ctx context.Context, r *rollout) error {
+	r.Updated = time.Now()
+	return store.PutJSON(ctx, s.getStateStore(), store.BucketRollouts, r.ID, r)
+}
+
+// updateRollout applies fn to rollout id and saves it, unless fn fails.
+func (s *Server) updateRollout(ctx context.Context, id string, fn func(*rollout) error) (*rollout, error) {
+	s.rolloutMu.Lock()
+	defer s.rolloutMu.Unlock()
+	r, err := s.loadRollout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(r); err != nil {
+		return r, err
+	}
+	return r, s.saveRollout(ctx, r)
+}
+
+// trimRollouts drops the oldest finished rollouts beyond maxRollouts.
+// Rollout IDs sort by time and List returns keys in order.
+func (s *Server) trimRollouts(ctx context.Context) {
+	s.rolloutMu.Lock()
+	defer s.rolloutMu.Unlock()
+	st := s.getStateStore()
+	items, err := st.List(ctx, store.BucketRollouts)
+	if err != nil || len(items) <= maxRollouts {
+		return
+	}
+	excess := len(items) - maxRollouts
+	for _, item := range items {
+		if excess == 0 {
+			return
+		}
+		var r rollout
+		if json.Unmarshal(item.Value, &r) == nil && !r.finished() {
+			continue
+		}
+		_ = st.Delete(ctx, store.BucketRollouts, item.Key)
+		excess--
+	}
+}
+
+// newRolloutID returns an ID that sorts by creation time.
+func newRolloutID(now time.Time) string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return "ro-" + now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(b[:])
+}
+
+func rolloutIDArg(args json.RawMessage) (string, error) {
+	var params struct {
+		RolloutID string `json:"rollout_id"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	return params.RolloutID, nil
+}
+
+// handleGetRollout returns one rollout, or a summary of recent rollouts,
+// newest first, when rollout_id is omitted.
+func (s *Server) handleGetRollout(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	id, err := rolloutIDArg(args)
+	if err != nil {
+		return nil, err
+	}
+	if id != "" {
+		return s.loadRollout(ctx, id)
+	}
+
+	items, err := s.getStateStore().List(ctx, store.BucketRollouts)
+	if err != nil {
+		return nil, err
+	}
+	type rolloutSummary struct {
+		ID        string `json:"id"`
+		State     string `json:"state"`
+		NextBatch int    `json:"nextBatch"`
+		Batches   int    `json:"batches"`
+		Created   string `json:"created"`
+	}
+	rollouts := []rolloutSummary{}
+	for i := len(items) - 1; i >= 0; i-- {
+		var r rollout
+		if json.Unmarshal(items[i].Value, &r) != nil {
+			continue
+		}
+		rollouts = append(rollouts, rolloutSummary{ID: r.ID, State: r.State, NextBatch: r.NextBatch, Batches: len(r.Batches), Created: r.Created.UTC().Format("2006-01-02T15:04:05Z")})
+	}
+	return map[string]interface{}{
+		"rollouts": rollouts,
+		"count":    len(rollouts),
+	}, nil
+}
+
+// handlePauseRollout stops a running rollout after its current batch.
+func (s *Server) handlePauseRollout(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	id, err := rolloutIDArg(args)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, fmt.Errorf("rollout_id is required")
+	}
+	r, err := s.updateRollout(ctx, id, func(r *rollout) error {
+		if r.State != rolloutRunning {
+			return fmt.Errorf("rollout %s is %s, not running", id, r.State)
+		}
+		r.State = rolloutPaused
+		r.Message = "paused by pause_rollout; the batch in progress, if any, finishes first"
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// handleResumeRollout continues a paused rollout, or one whose runner was
+// lost, from its next batch.
+func (s *Server) handleResumeRollout(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		RolloutID string `json:"rollout_id"`
+		DryRun    bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.RolloutID == "" {
+		return nil, fmt.Errorf("rollout_id is required")
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+
+	s.rolloutMu.Lock()
+	defer s.rolloutMu.Unlock()
+	r, err := s.loadRollout(ctx, params.RolloutID)
+	if err != nil {
+		return nil, err
+	}
+	run := s.rolloutRuns[r.ID]
+	switch {
+	case r.finished():
+		return nil, fmt.Errorf("rollout %s is %s and cannot be resumed", r.ID, r.State)
+	case run == nil:
+		return nil, fmt.Errorf("rollout %s was started by another server process; its manifest is not available here, so run deploy_app again", r.ID)
+	case run.cancel != nil && r.State == rolloutRunning:
+		return nil, fmt.Errorf("rollout %s is already running", r.ID)
+	}
+	if dryRun {
+		return map[string]interface{}{
+			"rolloutId": r.ID,
+			"dryRun":    true,
+			"remaining": r.Batches[r.NextBatch:],
+		}, nil
+	}
+
+	r.State = rolloutRunning
+	r.Message = ""
+	if err := s.saveRollout(ctx, r); err != nil {
+		return nil, err
+	}
+	// A runner that is still finishing its batch after a pause picks the
+	// rollout up again by itself.
+	if run.cancel == nil {
+		s.launchRollout(r.ID, run)
+	}
+	return r, nil
+}
+
+// handleAbortRollout stops a rollout for good and, with rollback, undoes
+// the changes of every batch it applied, newest first.
+func (s *Server) handleAbortRollout(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		RolloutID string `json:"rollout_id"`
+		Rollback  bool   `json:"rollback"`
+		DryRun    bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.RolloutID == "" {
+		return nil, fmt.Errorf("rollout_id is required")
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+
+	var r *rollout
+	var err error
+	if dryRun {
+		r, err = s.loadRollout(ctx, params.RolloutID)
+	} else {
+		r, err = s.updateRollout(ctx, params.RolloutID, func(r *rollout) error {
+			switch {
+			case params.Rollback:
+			case r.State == rolloutAborted:
+				return fmt.Errorf("rollout %s was already aborted", r.ID)
+			case r.finished():
+				return fmt.Errorf("rollout %s is %s; pass rollback to undo it", r.ID, r.State)
+			}
+			r.State = rolloutAborted
+			r.Message = "aborted by abort_rollout"
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Interrupt the batch in progress and wait for its change to be
+	// journaled, so the rollback sees it.
+	s.rolloutMu.Lock()
+	run := s.rolloutRuns[r.ID]
+	var done chan struct{}
+	if run != nil && run.cancel != nil && !dryRun {
+		run.cancel()
+		done = run.done
+	}
+	s.rolloutMu.Unlock()
+	if done != nil {
+		<-done
+	}
+
+	out := map[string]interface{}{
+		"rolloutId": r.ID,
+		"dryRun":    dryRun,
+	}
+	if !params.Rollback {
+		if !dryRun {
+			r, err = s.loadRollout(ctx, r.ID)
+			if err != nil {
+				return nil, err
+			}
+		}
+		out["state"] = r.State
+		return out, nil
+	}
+
+	r, err = s.loadRollout(ctx, r.ID)
+	if err != nil {
+		return nil, err
+	}
+	type batchRollback struct {
+		Batch        int                  `json:"batch"`
+		ChangeID     string               `json:"changeId"`
+		UndoChangeID string               `json:"undoChangeId,omitempty"`
+		Results      []journal.UndoResult `json:"results,omitempty"`
+		Error        string               `json:"error,omitempty"`
+	}
+	var rollbacks []batchRollback
+	var undoIDs []string
+	for i := len(r.Batches) - 1; i >= 0; i-- {
+		b := r.Batches[i]
+		if b.ChangeID == "" {
+			continue
+		}
+		rb := batchRollback{Batch: i + 1, ChangeID: b.ChangeID}
+		undo, results, err := s.getJournal().Undo(ctx, b.ChangeID, s.configForServer, dryRun)
+		rb.Results = results
+		if err != nil {
+			rb.Error = err.Error()
+		}
+		if undo != nil {
+			rb.UndoChangeID = undo.ID
+			undoIDs = append(undoIDs, undo.ID)
+		}
+		rollbacks = append(rollbacks, rb)
+	}
+	if len(undoIDs) > 0 {
+		r, err = s.updateRollout(ctx, r.ID, func(r *rollout) error {
+			r.RollbackChangeIDs = append(r.RollbackChangeIDs, undoIDs...)
+			r.Message = "aborted by abort_rollout and rolled back"
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	out["state"] = r.State
+	out["rollback"] = rollbacks
+	return out, nil
+}
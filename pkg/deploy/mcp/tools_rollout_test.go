@@ -0,0 +1,159 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanBatches(t *testing.T) {
+	targets := []string{"e", "d", "c", "b", "a"}
+	clusters := func(batches []rolloutBatch) [][]string {
+		var out [][]string
+		for _, b := range batches {
+			out = append(out, b.Clusters)
+		}
+		return out
+	}
+
+	batches, err := planBatches(targets, rolloutStrategy{BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a"}, {"b", "c"}, {"d", "e"}}, clusters(batches))
+	assert.True(t, batches[0].Canary)
+
+	batches, err = planBatches(targets, rolloutStrategy{CanaryClusters: []string{"d"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"d"}, {"a", "b", "c", "e"}}, clusters(batches))
+
+	batches, err = planBatches(targets, rolloutStrategy{CanaryCount: 2, BatchSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d", "e"}}, clusters(batches))
+
+	_, err = planBatches(targets, rolloutStrategy{CanaryClusters: []string{"z"}})
+	assert.Error(t, err, "canary clusters must be targets")
+	_, err = planBatches(targets, rolloutStrategy{BatchSize: -1})
+	assert.Error(t, err)
+}
+
+// callTool calls a tool through handleToolCall and decodes its JSON output.
+// It returns nil and the error text if the tool failed.
+func callTool(t *testing.T, server *Server, name string, args map[string]interface{}) (map[string]interface{}, string) {
+	t.Helper()
+	resp := server.handleToolCall(context.Background(), &MCPRequest{JSONRPC: "2.0", ID: 1, Params: mustMarshalJSON(t, map[string]interface{}{
+		"name":      name,
+		"arguments": args,
+	})})
+	require.Nil(t, resp.Error)
+	result := resp.Result.(map[string]interface{})
+	text := result["content"].([]map[string]interface{})[0]["text"].(string)
+	if result["isError"] == true {
+		return nil, text
+	}
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(text), &out))
+	return out, ""
+}
+
+func waitForRolloutState(t *testing.T, server *Server, id, state string) map[string]interface{} {
+	t.Helper()
+	var got map[string]interface{}
+	require.Eventually(t, func() bool {
+		got, _ = callTool(t, server, "get_rollout", map[string]interface{}{"rollout_id": id})
+		return got["state"] == state
+	}, 5*time.Second, 10*time.Millisecond, "rollout never reached %s", state)
+	// Let the runner release the rollout before the test moves on.
+	require.Eventually(t, func() bool {
+		server.rolloutMu.Lock()
+		defer server.rolloutMu.Unlock()
+		run := server.rolloutRuns[id]
+		return run == nil || run.cancel == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	return got
+}
+
+func TestRolloutPausesAfterCanaryAndResumes(t *testing.T) {
+	a, aURL := newObjectAPIServer(t, false)
+	b, bURL := newObjectAPIServer(t, false)
+	c, cURL := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"a": aURL, "b": bURL, "c": cURL})
+
+	started, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest": atomicConfigMap,
+		"rollout_strategy": map[string]interface{}{
+			"canary_count":       1,
+			"batch_size":         1,
+			"pause_after_canary": true,
+		},
+	})
+	id := started["rolloutId"].(string)
+	require.NotEmpty(t, id)
+
+	paused := waitForRolloutState(t, server, id, rolloutPaused)
+	assert.Equal(t, float64(1), paused["nextBatch"])
+	assert.True(t, a.has(configMapPath), "the canary is deployed")
+	assert.False(t, b.has(configMapPath), "later batches wait for resume")
+	assert.False(t, c.has(configMapPath))
+
+	_, errText := callTool(t, server, "pause_rollout", map[string]interface{}{"rollout_id": id})
+	assert.Contains(t, errText, "not running")
+
+	preview, _ := callTool(t, server, "resume_rollout", map[string]interface{}{"rollout_id": id, "dry_run": true})
+	assert.Len(t, preview["remaining"], 2)
+	assert.False(t, b.has(configMapPath), "a dry-run resume changes nothing")
+
+	_, errText = callTool(t, server, "resume_rollout", map[string]interface{}{"rollout_id": id})
+	require.Empty(t, errText)
+	done := waitForRolloutState(t, server, id, rolloutCompleted)
+	assert.True(t, b.has(configMapPath))
+	assert.True(t, c.has(configMapPath))
+	for _, batch := range done["batches"].([]interface{}) {
+		batch := batch.(map[string]interface{})
+		assert.Equal(t, batchSucceeded, batch["status"])
+		assert.NotEmpty(t, batch["changeId"], "each batch is journaled")
+	}
+
+	listed, _ := callTool(t, server, "get_rollout", map[string]interface{}{})
+	assert.Equal(t, float64(1), listed["count"])
+}
+
+func TestRolloutStopsOnFailedBatchAndAbortRollsBack(t *testing.T) {
+	a, aURL := newObjectAPIServer(t, false)
+	_, bURL := newObjectAPIServer(t, true)
+	server := newAtomicTestServer(t, map[string]string{"a": aURL, "b": bURL})
+
+	started, _ := callDeployApp(t, server, map[string]interface{}{
+		"manifest":         atomicConfigMap,
+		"rollout_strategy": map[string]interface{}{"canary_clusters": []string{"a"}},
+	})
+	id := started["rolloutId"].(string)
+
+	failed := waitForRolloutState(t, server, id, rolloutFailed)
+	assert.Contains(t, failed["message"], "batch 2 failed")
+	assert.True(t, a.has(configMapPath))
+
+	_, errText := callTool(t, server, "abort_rollout", map[string]interface{}{"rollout_id": id})
+	assert.Contains(t, errText, "pass rollback")
+
+	aborted, errText := callTool(t, server, "abort_rollout", map[string]interface{}{"rollout_id": id, "rollback": true})
+	require.Empty(t, errText)
+	assert.Equal(t, rolloutAborted, aborted["state"])
+	assert.False(t, a.has(configMapPath), "the canary's change is undone")
+	rollbacks := aborted["rollback"].([]interface{})
+	require.Len(t, rollbacks, 1)
+	assert.NotEmpty(t, rollbacks[0].(map[string]interface{})["undoChangeId"])
+}
+
+func TestDeployAppRejectsAtomicRollout(t *testing.T) {
+	server := newAtomicTestServer(t, map[string]string{"a": "https://a.example.com"})
+	_, err := server.handleDeployApp(context.Background(), mustMarshalJSON(t, map[string]interface{}{
+		"manifest":         atomicConfigMap,
+		"atomic":           true,
+		"rollout_strategy": map[string]interface{}{},
+	}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "atomic")
+}
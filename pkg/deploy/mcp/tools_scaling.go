@@ -0,0 +1,613 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cron"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Scaling states.
+const (
+	scaledDown = "down"
+	scaledUp   = "up"
+)
+
+// What triggered a scaling run.
+const (
+	triggerSchedule        = "schedule"
+	triggerOverride        = "override"
+	triggerOverrideExpired = "override-expired"
+)
+
+// scalingTickInterval is how often the scheduler checks for due
+// transitions. Cron schedules have minute resolution.
+const scalingTickInterval = time.Minute
+
+// maxScalingRuns bounds the run history kept with each schedule.
+const maxScalingRuns = 20
+
+// ScalingSchedule scales an app down and back up on cron schedules, e.g. dev
+// clusters to zero at night. It is persisted in
+// store.BucketScalingSchedules and executed by the scheduler started in
+// Run.
+type ScalingSchedule struct {
+	Name      string `json:"name"`
+	App       string `json:"app"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	// Clusters or Environment select the clusters; if both are empty,
+	// every cluster running the app when a transition fires.
+	Clusters    []string `json:"clusters,omitempty"`
+	Environment string   `json:"environment,omitempty"`
+	ScaleDown   string   `json:"scaleDown"`
+	ScaleUp     string   `json:"scaleUp"`
+	TimeZone    string   `json:"timeZone,omitempty"`
+	// DownReplicas is the replica count while scaled down. UpReplicas, if
+	// set, is the count while scaled up; otherwise each cluster gets back
+	// its count from before the scale-down, kept in Saved.
+	DownReplicas int32            `json:"downReplicas"`
+	UpReplicas   *int32           `json:"upReplicas,omitempty"`
+	Saved        map[string]int32 `json:"saved,omitempty"`
+	// State is the state last applied, if any.
+	State    string           `json:"state,omitempty"`
+	Override *ScalingOverride `json:"override,omitempty"`
+	// OverrideConflicts scales the app even if another controller, such
+	// as a HorizontalPodAutoscaler, manages it.
+	OverrideConflicts bool `json:"overrideConflicts,omitempty"`
+	// LastChecked is when the scheduler last looked at the schedule;
+	// transitions up to then have been handled.
+	LastChecked time.Time    `json:"lastChecked"`
+	Runs        []ScalingRun `json:"runs,omitempty"`
+	Created     time.Time    `json:"created"`
+	Updated     time.Time    `json:"updated"`
+}
+
+// ScalingOverride holds a schedule in one state until a time, e.g. to keep
+// a dev cluster up for a late release.
+type ScalingOverride struct {
+	State  string    `json:"state"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// ScalingRun is one application of a scaling state.
+type ScalingRun struct {
+	Time    time.Time       `json:"time"`
+	State   string          `json:"state"`
+	Trigger string          `json:"trigger"`
+	Results []ScalingResult `json:"results"`
+	// ChangeID is the journaled change of a scheduled run, which
+	// undo_change can revert.
+	ChangeID string `json:"changeId,omitempty"`
+}
+
+// ScalingResult is the outcome of a scaling run in one cluster.
+type ScalingResult struct {
+	Cluster  string `json:"cluster"`
+	Workload string `json:"workload,omitempty"`
+	From     int32  `json:"from"`
+	To       int32  `json:"to"`
+	Skipped  string `json:"skipped,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// schedules parses the scale-down and scale-up schedules.
+func (sc *ScalingSchedule) schedules() (down, up *cron.Schedule, err error) {
+	if down, err = cron.Parse(sc.ScaleDown, sc.TimeZone); err != nil {
+		return nil, nil, fmt.Errorf("invalid scale_down_schedule: %w", err)
+	}
+	if up, err = cron.Parse(sc.ScaleUp, sc.TimeZone); err != nil {
+		return nil, nil, fmt.Errorf("invalid scale_up_schedule: %w", err)
+	}
+	return down, up, nil
+}
+
+// lastTransition returns the state of the latest transition at or before
+// now and when it fired, or "" if neither schedule fired in the past year.
+func lastTransition(down, up *cron.Schedule, now time.Time) (string, time.Time) {
+	d, u := down.Prev(now), up.Prev(now)
+	switch {
+	case d.IsZero() && u.IsZero():
+		return "", time.Time{}
+	case d.After(u):
+		return scaledDown, d
+	default:
+		return scaledUp, u
+	}
+}
+
+// nextTransition returns the state of the first transition after now and
+// when it fires.
+func nextTransition(down, up *cron.Schedule, now time.Time) (string, time.Time) {
+	d, u := down.Next(now), up.Next(now)
+	switch {
+	case d.IsZero() && u.IsZero():
+		return "", time.Time{}
+	case u.IsZero() || (!d.IsZero() && d.Before(u)):
+		return scaledDown, d
+	default:
+		return scaledUp, u
+	}
+}
+
+// due returns the state sched should be scaled to at now and why, or ""
+// if nothing is due. It clears an expired override.
+func (sc *ScalingSchedule) due(now time.Time) (state, trigger string, err error) {
+	down, up, err := sc.schedules()
+	if err != nil {
+		return "", "", err
+	}
+	if o := sc.Override; o != nil {
+		if now.Before(o.Until) {
+			return "", "", nil
+		}
+		// Transitions during the override were skipped; return to
+		// whatever the schedule says now.
+		sc.Override = nil
+		state, _ := lastTransition(down, up, now)
+		if state == "" || state == o.State {
+			return "", "", nil
+		}
+		return state, triggerOverrideExpired, nil
+	}
+	state, at := lastTransition(down, up, now)
+	if state == "" || !at.After(sc.LastChecked) {
+		return "", "", nil
+	}
+	return state, triggerSchedule, nil
+}
+
+// runScalingScheduler applies due scaling transitions every
+// scalingTickInterval until ctx is done.
+func (s *Server) runScalingScheduler(ctx context.Context) {
+	ticker := time.NewTicker(scalingTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runScalingSchedules(ctx, now)
+		}
+	}
+}
+
+// runScalingSchedules applies the transitions of every schedule that are
+// due at now. A transition missed while no server was running is applied
+// late, so a cluster meant to be down at night goes down when the server
+// comes back.
+func (s *Server) runScalingSchedules(ctx context.Context, now time.Time) {
+	items, err := s.getStateStore().List(ctx, store.BucketScalingSchedules)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		var fired *ScalingRun
+		sc, err := s.updateScalingSchedule(ctx, item.Key, func(sc *ScalingSchedule) error {
+			state, trigger, err := sc.due(now)
+			sc.LastChecked = now
+			if err != nil || state == "" {
+				return nil
+			}
+			rec := s.getJournal().Begin("schedule_scaling")
+			run := s.applyScalingState(journal.WithRecorder(ctx, rec), sc, state, trigger, now)
+			if change, err := s.getJournal().Commit(ctx, rec); err == nil && change != nil {
+				run.ChangeID = change.ID
+			}
+			sc.record(run)
+			fired = &run
+			return nil
+		})
+		// Notify outside the schedule lock; sinks can be slow.
+		if err == nil && fired != nil {
+			s.notifyScaling(ctx, sc, *fired)
+		}
+	}
+}
+
+// applyScalingState scales the app of sc to state in each of its clusters.
+// Replica counts before a scale-down are saved in sc so the scale-up can
+// restore them.
+func (s *Server) applyScalingState(ctx context.Context, sc *ScalingSchedule, state, trigger string, now time.Time) ScalingRun {
+	run := ScalingRun{Time: now, State: state, Trigger: trigger, Results: []ScalingResult{}}
+	clusters, err := s.resolveClusters(sc.Clusters, sc.Environment)
+	if err == nil && len(clusters) == 0 {
+		clusters, err = s.executor.ClusterNames()
+	}
+	if err != nil {
+		run.Results = append(run.Results, ScalingResult{Error: err.Error()})
+		return run
+	}
+	var mu sync.Mutex
+	_, _ = s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		result := ScalingResult{Cluster: clusterName}
+		defer func() {
+			mu.Lock()
+			run.Results = append(run.Results, result)
+			mu.Unlock()
+		}()
+		if len(sc.Clusters) == 0 && sc.Environment == "" {
+			instances, err := s.findAppInCluster(ctx, client, clusterName, sc.App, sc.Namespace)
+			if err == nil && len(instances) == 0 {
+				result.Skipped = "app not running"
+				return nil, nil
+			}
+		}
+
+		replicas := sc.DownReplicas
+		if state == scaledUp {
+			mu.Lock()
+			saved, ok := sc.Saved[clusterName]
+			mu.Unlock()
+			switch {
+			case sc.UpReplicas != nil:
+				replicas = *sc.UpReplicas
+			case ok:
+				replicas = saved
+			default:
+				result.Skipped = "no replica count saved by a scale-down"
+				return nil, nil
+			}
+		}
+		out, err := s.scaleAppInCluster(ctx, client, clusterName, sc.App, sc.Namespace, sc.Kind, replicas, sc.OverrideConflicts)
+		if err != nil {
+			result.Error = err.Error()
+			return nil, err
+		}
+		scaled := out.(map[string]interface{})
+		result.From, result.To = scaled["oldReplicas"].(int32), replicas
+		kind, _ := scaled["kind"].(string)
+		result.Workload = fmt.Sprintf("%s/%s/%s", kind, scaled["namespace"], scaled[strings.ToLower(kind)])
+		if approval.IsDryRun(ctx) {
+			return nil, nil
+		}
+		mu.Lock()
+		switch {
+		case state == scaledDown && result.From != sc.DownReplicas:
+			if sc.Saved == nil {
+				sc.Saved = make(map[string]int32)
+			}
+			sc.Saved[clusterName] = result.From
+		case state == scaledUp:
+			delete(sc.Saved, clusterName)
+		}
+		mu.Unlock()
+		return nil, nil
+	})
+	sort.Slice(run.Results, func(i, j int) bool { return run.Results[i].Cluster < run.Results[j].Cluster })
+	return run
+}
+
+// record appends run to the history of sc and makes its state current.
+func (sc *ScalingSchedule) record(run ScalingRun) {
+	sc.State = run.State
+	sc.Runs = append(sc.Runs, run)
+	if len(sc.Runs) > maxScalingRuns {
+		sc.Runs = sc.Runs[len(sc.Runs)-maxScalingRuns:]
+	}
+}
+
+func (s *Server) loadScalingSchedule(ctx context.Context, name string) (*ScalingSchedule, error) {
+	var sc ScalingSchedule
+	if err := store.GetJSON(ctx, s.getStateStore(), store.BucketScalingSchedules, name, &sc); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("scaling schedule %q not found", name)
+		}
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// updateScalingSchedule applies fn to schedule name and saves it, unless fn
+// fails. Schedules are changed one at a time, so the scheduler and the
+// tools never interleave.
+func (s *Server) updateScalingSchedule(ctx context.Context, name string, fn func(*ScalingSchedule) error) (*ScalingSchedule, error) {
+	s.scalingMu.Lock()
+	defer s.scalingMu.Unlock()
+	sc, err := s.loadScalingSchedule(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := fn(sc); err != nil {
+		return sc, err
+	}
+	sc.Updated = time.Now()
+	return sc, store.PutJSON(ctx, s.getStateStore(), store.BucketScalingSchedules, name, sc)
+}
+
+// scalingScheduleView is a schedule with where it stands now.
+type scalingScheduleView struct {
+	*ScalingSchedule
+	// ScheduledState is the state of the latest transition, which the
+	// app is in unless overridden or changed by hand.
+	ScheduledState string      `json:"scheduledState,omitempty"`
+	NextState      string      `json:"nextState,omitempty"`
+	NextTransition *time.Time  `json:"nextTransition,omitempty"`
+	LastRun        *ScalingRun `json:"lastRun,omitempty"`
+}
+
+func newScalingScheduleView(sc *ScalingSchedule, now time.Time, withRuns bool) scalingScheduleView {
+	v := scalingScheduleView{ScalingSchedule: sc}
+	if down, up, err := sc.schedules(); err == nil {
+		v.ScheduledState, _ = lastTransition(down, up, now)
+		state, at := nextTransition(down, up, now)
+		if state != "" {
+			v.NextState, v.NextTransition = state, &at
+		}
+	}
+	if !withRuns {
+		copied := *sc
+		if len(copied.Runs) > 0 {
+			v.LastRun = &copied.Runs[len(copied.Runs)-1]
+		}
+		copied.Runs = nil
+		v.ScalingSchedule = &copied
+	}
+	return v
+}
+
+// handleScheduleScaling creates or replaces a scaling schedule. Replacing
+// a schedule keeps its saved replica counts and history.
+func (s *Server) handleScheduleScaling(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name         string   `json:"name"`
+		App          string   `json:"app"`
+		Namespace    string   `json:"namespace"`
+		Kind         string   `json:"kind"`
+		Clusters     []string `json:"clusters"`
+		Environment  string   `json:"environment"`
+		ScaleDown    string   `json:"scale_down_schedule"`
+		ScaleUp      string   `json:"scale_up_schedule"`
+		TimeZone     string   `json:"time_zone"`
+		DownReplicas *int32   `json:"down_replicas"`
+		UpReplicas   *int32   `json:"up_replicas"`
+		DryRun       bool     `json:"dry_run"`
+		// OverrideConflicts is the override of scale_app.
+		OverrideConflicts bool `json:"override_conflicts"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.Name); err != nil {
+		return nil, fmt.Errorf("invalid name: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.App); err != nil {
+		return nil, fmt.Errorf("invalid app name: %w", err)
+	}
+	if params.Namespace != "" {
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	if params.Kind != "" && params.Kind != "Deployment" && params.Kind != "StatefulSet" {
+		return nil, fmt.Errorf("kind must be Deployment or StatefulSet, got %q", params.Kind)
+	}
+	if params.ScaleDown == "" || params.ScaleUp == "" {
+		return nil, fmt.Errorf("scale_down_schedule and scale_up_schedule are required")
+	}
+	if params.ScaleDown == params.ScaleUp {
+		return nil, fmt.Errorf("scale_down_schedule and scale_up_schedule must differ")
+	}
+	if _, err := s.resolveClusters(params.Clusters, params.Environment); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	sc := &ScalingSchedule{
+		Name:              params.Name,
+		App:               params.App,
+		Namespace:         params.Namespace,
+		Kind:              params.Kind,
+		Clusters:          params.Clusters,
+		Environment:       params.Environment,
+		ScaleDown:         params.ScaleDown,
+		ScaleUp:           params.ScaleUp,
+		TimeZone:          params.TimeZone,
+		UpReplicas:        params.UpReplicas,
+		LastChecked:       now,
+		OverrideConflicts: params.OverrideConflicts,
+		Created:           now,
+		Updated:           now,
+	}
+	if params.DownReplicas != nil {
+		sc.DownReplicas = *params.DownReplicas
+	}
+	if sc.DownReplicas < 0 || (sc.UpReplicas != nil && *sc.UpReplicas < 0) {
+		return nil, fmt.Errorf("replica counts must not be negative")
+	}
+	if sc.UpReplicas != nil && *sc.UpReplicas <= sc.DownReplicas {
+		return nil, fmt.Errorf("up_replicas must be greater than down_replicas")
+	}
+	if _, _, err := sc.schedules(); err != nil {
+		return nil, err
+	}
+
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if !dryRun {
+		s.scalingMu.Lock()
+		if existing, err := s.loadScalingSchedule(ctx, sc.Name); err == nil {
+			sc.Saved, sc.State, sc.Runs, sc.Created = existing.Saved, existing.State, existing.Runs, existing.Created
+		}
+		err := store.PutJSON(ctx, s.getStateStore(), store.BucketScalingSchedules, sc.Name, sc)
+		s.scalingMu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response := map[string]interface{}{
+		"schedule": newScalingScheduleView(sc, now, false),
+		"dryRun":   dryRun,
+	}
+	if view := response["schedule"].(scalingScheduleView); view.ScheduledState != "" {
+		response["message"] = fmt.Sprintf("The schedule takes effect at its next transition; the app is not scaled now. To apply the current scheduled state (%s) now, call override_scaling_schedule with clear", view.ScheduledState)
+	}
+	return response, nil
+}
+
+// handleListScalingSchedules returns one scaling schedule with its run
+// history, or every schedule with its last run.
+func (s *Server) handleListScalingSchedules(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name string `json:"name"`
+	}
+	if len(args) > 0 {
+		if err := json.Unmarshal(args, &params); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	now := time.Now()
+	if params.Name != "" {
+		sc, err := s.loadScalingSchedule(ctx, params.Name)
+		if err != nil {
+			return nil, err
+		}
+		return newScalingScheduleView(sc, now, true), nil
+	}
+
+	items, err := s.getStateStore().List(ctx, store.BucketScalingSchedules)
+	if err != nil {
+		return nil, err
+	}
+	schedules := []scalingScheduleView{}
+	for _, item := range items {
+		var sc ScalingSchedule
+		if json.Unmarshal(item.Value, &sc) != nil {
+			continue
+		}
+		schedules = append(schedules, newScalingScheduleView(&sc, now, false))
+	}
+	return map[string]interface{}{
+		"schedules": schedules,
+		"count":     len(schedules),
+	}, nil
+}
+
+// handleOverrideScalingSchedule scales the app of a schedule up or down now
+// and holds it there until a time, by default the next scheduled
+// transition. With clear, it ends an override and applies the scheduled
+// state.
+func (s *Server) handleOverrideScalingSchedule(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name   string `json:"name"`
+		State  string `json:"state"`
+		Until  string `json:"until"`
+		Reason string `json:"reason"`
+		Clear  bool   `json:"clear"`
+		DryRun bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	switch {
+	case params.Clear && (params.State != "" || params.Until != ""):
+		return nil, fmt.Errorf("clear cannot be combined with state or until")
+	case !params.Clear && params.State != scaledUp && params.State != scaledDown:
+		return nil, fmt.Errorf("state must be %q or %q (or set clear)", scaledUp, scaledDown)
+	}
+	now := time.Now()
+	var until time.Time
+	if params.Until != "" {
+		var err error
+		if until, err = time.Parse(time.RFC3339, params.Until); err != nil {
+			return nil, fmt.Errorf("until must be an RFC 3339 time, e.g. 2026-01-02T08:00:00Z: %w", err)
+		}
+		if !until.After(now) {
+			return nil, fmt.Errorf("until must be in the future")
+		}
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+
+	var run ScalingRun
+	apply := func(sc *ScalingSchedule) error {
+		down, up, err := sc.schedules()
+		if err != nil {
+			return err
+		}
+		state := params.State
+		if params.Clear {
+			sc.Override = nil
+			if state, _ = lastTransition(down, up, now); state == "" {
+				return fmt.Errorf("schedule %s has not fired in the past year; nothing to return to", sc.Name)
+			}
+		} else {
+			if until.IsZero() {
+				if _, until = nextTransition(down, up, now); until.IsZero() {
+					return fmt.Errorf("schedule %s has no upcoming transition; set until", sc.Name)
+				}
+			}
+			sc.Override = &ScalingOverride{State: state, Until: until, Reason: params.Reason}
+		}
+		run = s.applyScalingState(ctx, sc, state, triggerOverride, now)
+		if !dryRun {
+			sc.record(run)
+		}
+		return nil
+	}
+
+	var (
+		sc  *ScalingSchedule
+		err error
+	)
+	if dryRun {
+		if sc, err = s.loadScalingSchedule(ctx, params.Name); err == nil {
+			err = apply(sc)
+		}
+	} else {
+		sc, err = s.updateScalingSchedule(ctx, params.Name, apply)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"schedule": newScalingScheduleView(sc, now, false),
+		"run":      run,
+		"dryRun":   dryRun,
+	}, nil
+}
+
+// handleDeleteScalingSchedule removes a scaling schedule. The app keeps its
+// current replica count.
+func (s *Server) handleDeleteScalingSchedule(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	s.scalingMu.Lock()
+	defer s.scalingMu.Unlock()
+	sc, err := s.loadScalingSchedule(ctx, params.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.getStateStore().Delete(ctx, store.BucketScalingSchedules, params.Name); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"deleted": sc.Name,
+		"state":   sc.State,
+		"message": "Schedule deleted; the app keeps its current replica count",
+	}, nil
+}
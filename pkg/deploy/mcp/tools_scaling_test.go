@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func webReplicas(cluster *objectAPIServer) int {
+	replicas, _, _ := unstructured.NestedFieldNoCopy(cluster.get(shopDeployPath), "spec", "replicas")
+	n, _ := replicas.(float64)
+	return int(n)
+}
+
+// nextFire returns when spec next fires after t.
+func nextFire(t *testing.T, spec string, after time.Time) time.Time {
+	t.Helper()
+	schedule, err := cron.Parse(spec, "")
+	require.NoError(t, err)
+	return schedule.Next(after)
+}
+
+// newScalingClusters returns clusters east, running web with 2 replicas,
+// west, running web with 3, and empty, not running web.
+func newScalingClusters(t *testing.T) (east, west *objectAPIServer, server *Server) {
+	east, eastURL := newObjectAPIServer(t, false)
+	west, westURL := newObjectAPIServer(t, false)
+	_, emptyURL := newObjectAPIServer(t, false)
+	east.put(t, shopDeployPath, webDeployment("web:v1"))
+	three := webDeployment("web:v1")
+	replicas := int32(3)
+	three.Spec.Replicas = &replicas
+	west.put(t, shopDeployPath, three)
+	return east, west, newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL, "empty": emptyURL})
+}
+
+var nightly = map[string]interface{}{
+	"name": "web-nights", "app": "web", "namespace": "shop",
+	"scale_down_schedule": "0 20 * * *", "scale_up_schedule": "0 8 * * *",
+}
+
+func TestScalingScheduleDue(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+	sc := &ScalingSchedule{ScaleDown: "0 20 * * *", ScaleUp: "0 8 * * *", LastChecked: at(2, 12, 0)}
+
+	state, _, err := sc.due(at(2, 19, 59))
+	require.NoError(t, err)
+	assert.Empty(t, state)
+
+	state, trigger, _ := sc.due(at(2, 20, 0))
+	assert.Equal(t, scaledDown, state)
+	assert.Equal(t, triggerSchedule, trigger)
+
+	// Down for the night and back up were both missed: only the latest
+	// transition applies.
+	state, _, _ = sc.due(at(3, 9, 0))
+	assert.Equal(t, scaledUp, state)
+
+	sc.Override = &ScalingOverride{State: scaledUp, Until: at(3, 10, 0)}
+	state, _, _ = sc.due(at(2, 21, 0))
+	assert.Empty(t, state, "transitions are skipped during an override")
+	state, _, _ = sc.due(at(3, 10, 0))
+	assert.Empty(t, state, "an expired override that matches the schedule changes nothing")
+	assert.Nil(t, sc.Override)
+
+	sc.Override = &ScalingOverride{State: scaledUp, Until: at(2, 23, 0)}
+	state, trigger, _ = sc.due(at(2, 23, 0))
+	assert.Equal(t, scaledDown, state)
+	assert.Equal(t, triggerOverrideExpired, trigger)
+}
+
+func TestScalingSchedulerScalesDownAndRestores(t *testing.T) {
+	east, west, server := newScalingClusters(t)
+	ctx := context.Background()
+
+	out, errText := callTool(t, server, "schedule_scaling", nightly)
+	require.Empty(t, errText)
+	schedule := out["schedule"].(map[string]interface{})
+	assert.Contains(t, []interface{}{"up", "down"}, schedule["nextState"])
+	assert.Equal(t, 2, webReplicas(east), "creating a schedule scales nothing")
+
+	down := nextFire(t, "0 20 * * *", time.Now())
+	server.runScalingSchedules(ctx, down.Add(time.Minute))
+	assert.Equal(t, 0, webReplicas(east))
+	assert.Equal(t, 0, webReplicas(west))
+
+	sc, err := server.loadScalingSchedule(ctx, "web-nights")
+	require.NoError(t, err)
+	assert.Equal(t, scaledDown, sc.State)
+	assert.Equal(t, map[string]int32{"east": 2, "west": 3}, sc.Saved)
+	require.Len(t, sc.Runs, 1)
+	assert.NotEmpty(t, sc.Runs[0].ChangeID)
+	assert.Equal(t, ScalingResult{Cluster: "east", Workload: "Deployment/shop/web", From: 2, To: 0}, sc.Runs[0].Results[0])
+	assert.Equal(t, ScalingResult{Cluster: "empty", Skipped: "app not running"}, sc.Runs[0].Results[1])
+
+	// Ticking again before the next transition changes nothing.
+	server.runScalingSchedules(ctx, down.Add(2*time.Minute))
+	sc, _ = server.loadScalingSchedule(ctx, "web-nights")
+	assert.Len(t, sc.Runs, 1)
+
+	up := nextFire(t, "0 8 * * *", down)
+	server.runScalingSchedules(ctx, up.Add(time.Minute))
+	assert.Equal(t, 2, webReplicas(east))
+	assert.Equal(t, 3, webReplicas(west))
+
+	out, errText = callTool(t, server, "list_scaling_schedules", map[string]interface{}{})
+	require.Empty(t, errText)
+	assert.EqualValues(t, 1, out["count"])
+	listed := out["schedules"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "up", listed["state"])
+	assert.Nil(t, listed["runs"])
+	assert.Equal(t, "up", listed["lastRun"].(map[string]interface{})["state"])
+}
+
+func TestOverrideScalingSchedule(t *testing.T) {
+	east, west, server := newScalingClusters(t)
+	ctx := context.Background()
+	_, errText := callTool(t, server, "schedule_scaling", nightly)
+	require.Empty(t, errText)
+
+	up := nextFire(t, "0 8 * * *", nextFire(t, "0 20 * * *", time.Now()))
+	until := up.Add(2 * time.Hour)
+	override := map[string]interface{}{"name": "web-nights", "state": "down", "until": until.Format(time.RFC3339), "dry_run": true}
+
+	out, errText := callTool(t, server, "override_scaling_schedule", override)
+	require.Empty(t, errText)
+	assert.Len(t, out["run"].(map[string]interface{})["results"], 3)
+	assert.Equal(t, 2, webReplicas(east), "dry run scales nothing")
+
+	override["dry_run"] = false
+	override["reason"] = "cost freeze"
+	_, errText = callTool(t, server, "override_scaling_schedule", override)
+	require.Empty(t, errText)
+	assert.Equal(t, 0, webReplicas(east))
+	assert.Equal(t, 0, webReplicas(west))
+
+	// The scheduled scale-up is skipped while the override lasts...
+	server.runScalingSchedules(ctx, up.Add(time.Minute))
+	assert.Equal(t, 0, webReplicas(east))
+
+	// ...and applied when it expires.
+	server.runScalingSchedules(ctx, until.Add(time.Minute))
+	assert.Equal(t, 2, webReplicas(east))
+	assert.Equal(t, 3, webReplicas(west))
+	sc, err := server.loadScalingSchedule(ctx, "web-nights")
+	require.NoError(t, err)
+	assert.Nil(t, sc.Override)
+	require.Len(t, sc.Runs, 2)
+	assert.Equal(t, triggerOverride, sc.Runs[0].Trigger)
+	assert.Equal(t, triggerOverrideExpired, sc.Runs[1].Trigger)
+
+	out, errText = callTool(t, server, "delete_scaling_schedule", map[string]interface{}{"name": "web-nights"})
+	require.Empty(t, errText)
+	assert.Equal(t, "web-nights", out["deleted"])
+	_, errText = callTool(t, server, "list_scaling_schedules", map[string]interface{}{"name": "web-nights"})
+	assert.Contains(t, errText, "not found")
+}
+
+func TestScalingScheduleInvalidArgs(t *testing.T) {
+	_, _, server := newScalingClusters(t)
+	with := func(key string, value interface{}) map[string]interface{} {
+		args := make(map[string]interface{}, len(nightly)+1)
+		for k, v := range nightly {
+			args[k] = v
+		}
+		args[key] = value
+		return args
+	}
+	for _, args := range []map[string]interface{}{
+		with("name", ""),
+		with("app", "Not An App"),
+		with("namespace", "kube-system"),
+		with("kind", "DaemonSet"),
+		with("scale_down_schedule", "0 25 * * *"),
+		with("scale_up_schedule", "0 20 * * *"),
+		with("time_zone", "Mars/Olympus"),
+		with("down_replicas", -1),
+		with("up_replicas", 0),
+		with("environment", "qa"),
+	} {
+		_, errText := callTool(t, server, "schedule_scaling", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+
+	_, errText := callTool(t, server, "override_scaling_schedule", map[string]interface{}{"name": "web-nights", "state": "up"})
+	assert.Contains(t, errText, "not found")
+	_, errText = callTool(t, server, "override_scaling_schedule", map[string]interface{}{"name": "web-nights", "state": "sideways"})
+	assert.NotEmpty(t, errText)
+	_, errText = callTool(t, server, "override_scaling_schedule", map[string]interface{}{"name": "web-nights", "state": "up", "until": "2000-01-01T00:00:00Z"})
+	assert.Contains(t, errText, "future")
+}
@@ -0,0 +1,445 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// secretHashAnnotation records the hash of the content distribute_secret
+	// last wrote, so later calls can tell whether a copy was changed in place.
+	secretHashAnnotation = "kubestellar.io/secret-hash"
+	// distributedFromAnnotation records where a distributed secret came from.
+	distributedFromAnnotation = "kubestellar.io/distributed-from"
+)
+
+// State of a distributed secret in one namespace, and what was done to it.
+const (
+	secretInSync    = "in-sync"
+	secretOutOfDate = "out-of-date"
+	// secretDiverged means the copy was changed since it was distributed.
+	secretDiverged = "diverged"
+	// secretUnmanaged means the object exists but was not distributed.
+	secretUnmanaged = "unmanaged"
+	secretFailed    = "failed"
+)
+
+var (
+	secretGVR         = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	externalSecretGVR = schema.GroupVersionResource{Group: "external-secrets.io", Version: "v1", Resource: "externalsecrets"}
+)
+
+// ExternalSecretRef is a secret held in an external store, materialized on
+// each cluster by the External Secrets Operator.
+type ExternalSecretRef struct {
+	Store           string `json:"store"`
+	StoreKind       string `json:"store_kind,omitempty"`
+	RemoteKey       string `json:"remote_key"`
+	RefreshInterval string `json:"refresh_interval,omitempty"`
+}
+
+// SecretTarget is the outcome of distributing a secret to one namespace.
+type SecretTarget struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Status    string `json:"status"`
+	// Previous is the state of an existing copy before it was updated.
+	Previous  string   `json:"previous,omitempty"`
+	Message   string   `json:"message,omitempty"`
+	Restarted []string `json:"restarted,omitempty"`
+}
+
+// secretPayload is the object distribute_secret writes to every target.
+type secretPayload struct {
+	gvr        schema.GroupVersionResource
+	apiVersion string
+	kind       string
+	name       string
+	// content holds the fields of the object that carry the secret: type
+	// and data for a Secret, spec for an ExternalSecret.
+	content map[string]interface{}
+	hash    string
+	origin  string
+}
+
+// object returns the payload as an object in namespace.
+func (p *secretPayload) object(namespace string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: runtime.DeepCopyJSON(p.content)}
+	obj.SetAPIVersion(p.apiVersion)
+	obj.SetKind(p.kind)
+	obj.SetName(p.name)
+	obj.SetNamespace(namespace)
+	annotate(obj, secretHashAnnotation, p.hash)
+	annotate(obj, distributedFromAnnotation, p.origin)
+	return obj
+}
+
+// handleDistributeSecret writes a secret to namespaces in several clusters,
+// reports copies that diverged, and rotates them on request.
+func (s *Server) handleDistributeSecret(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Name             string             `json:"name"`
+		Namespace        string             `json:"namespace"`
+		Namespaces       []string           `json:"namespaces"`
+		Clusters         []string           `json:"clusters"`
+		SourceCluster    string             `json:"source_cluster"`
+		SourceNamespace  string             `json:"source_namespace"`
+		SourceName       string             `json:"source_name"`
+		Data             map[string]string  `json:"data"`
+		Type             string             `json:"type"`
+		ExternalSecret   *ExternalSecretRef `json:"external_secret"`
+		Rotate           bool               `json:"rotate"`
+		RestartConsumers bool               `json:"restart_consumers"`
+		DryRun           bool               `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.Name); err != nil {
+		return nil, fmt.Errorf("invalid name: %w", err)
+	}
+	if len(params.Clusters) == 0 {
+		return nil, fmt.Errorf("clusters is required")
+	}
+	namespaces := params.Namespaces
+	if len(namespaces) == 0 {
+		if params.Namespace == "" {
+			return nil, fmt.Errorf("namespace or namespaces is required")
+		}
+		namespaces = []string{params.Namespace}
+	}
+	for _, ns := range namespaces {
+		if err := server.ValidateNamespace(ns); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	sources := 0
+	for _, set := range []bool{params.SourceCluster != "", params.Data != nil, params.ExternalSecret != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, fmt.Errorf("set exactly one of source_cluster, data, or external_secret")
+	}
+	if params.RestartConsumers && !params.Rotate {
+		return nil, fmt.Errorf("restart_consumers requires rotate")
+	}
+
+	var (
+		payload *secretPayload
+		err     error
+	)
+	switch {
+	case params.SourceCluster != "":
+		if params.SourceNamespace == "" {
+			params.SourceNamespace = namespaces[0]
+		}
+		if params.SourceName == "" {
+			params.SourceName = params.Name
+		}
+		if err := server.ValidateNamespace(params.SourceNamespace); err != nil {
+			return nil, fmt.Errorf("invalid source_namespace: %w", err)
+		}
+		for _, cluster := range params.Clusters {
+			for _, ns := range namespaces {
+				if cluster == params.SourceCluster && ns == params.SourceNamespace && params.SourceName == params.Name {
+					return nil, fmt.Errorf("cannot distribute secret %s/%s onto itself in cluster %s", ns, params.Name, cluster)
+				}
+			}
+		}
+		payload, err = s.sourceSecretPayload(ctx, params.SourceCluster, params.SourceNamespace, params.SourceName, params.Name)
+	case params.Data != nil:
+		payload, err = literalSecretPayload(params.Name, params.Type, params.Data)
+	default:
+		payload, err = externalSecretPayload(params.Name, params.ExternalSecret)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, params.Clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return s.distributeToCluster(ctx, client, clusterName, payload, namespaces, params.Rotate, params.RestartConsumers)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summary := make(map[string]int)
+	for _, r := range results {
+		targets, _ := r.Result.([]SecretTarget)
+		for _, t := range targets {
+			summary[t.Status]++
+		}
+		if r.Error != "" {
+			summary[secretFailed] += len(namespaces)
+		}
+	}
+	return map[string]interface{}{
+		"name":    params.Name,
+		"kind":    payload.kind,
+		"source":  payload.origin,
+		"rotate":  params.Rotate,
+		"dryRun":  dryRun,
+		"summary": summary,
+		"results": results,
+	}, nil
+}
+
+// sourceSecretPayload reads the Secret to distribute from a cluster.
+func (s *Server) sourceSecretPayload(ctx context.Context, cluster, namespace, sourceName, name string) (*secretPayload, error) {
+	dyn, err := s.dynamicClient(cluster)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := dyn.Resource(secretGVR).Namespace(namespace).Get(ctx, sourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("reading secret %s/%s in cluster %s: %w", namespace, sourceName, cluster, err)
+	}
+	content := secretContent(obj.Object, nil)
+	if content["type"] == string(corev1.SecretTypeServiceAccountToken) {
+		return nil, fmt.Errorf("secret %s/%s is a service account token, which is only valid in its own cluster", namespace, sourceName)
+	}
+	return newSecretPayload(secretGVR, "v1", "Secret", name, content, cluster+"/"+namespace+"/"+sourceName), nil
+}
+
+// literalSecretPayload builds a Secret from plain-text values.
+func literalSecretPayload(name, secretType string, values map[string]string) (*secretPayload, error) {
+	if len(values) == 0 {
+		return nil, fmt.Errorf("data must not be empty")
+	}
+	if secretType == "" {
+		secretType = string(corev1.SecretTypeOpaque)
+	}
+	if secretType == string(corev1.SecretTypeServiceAccountToken) {
+		return nil, fmt.Errorf("service account token secrets cannot be distributed")
+	}
+	data := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid data key %q: %s", key, strings.Join(errs, "; "))
+		}
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	content := map[string]interface{}{"type": secretType, "data": data}
+	return newSecretPayload(secretGVR, "v1", "Secret", name, content, "literal"), nil
+}
+
+// externalSecretPayload builds an ExternalSecret that has the External
+// Secrets Operator create Secret name from a key in an external store.
+func externalSecretPayload(name string, ref *ExternalSecretRef) (*secretPayload, error) {
+	if ref.Store == "" || ref.RemoteKey == "" {
+		return nil, fmt.Errorf("external_secret requires store and remote_key")
+	}
+	switch ref.StoreKind {
+	case "":
+		ref.StoreKind = "SecretStore"
+	case "SecretStore", "ClusterSecretStore":
+	default:
+		return nil, fmt.Errorf("invalid external_secret store_kind %q: must be SecretStore or ClusterSecretStore", ref.StoreKind)
+	}
+	if ref.RefreshInterval == "" {
+		ref.RefreshInterval = "1h"
+	}
+	if _, err := time.ParseDuration(ref.RefreshInterval); err != nil {
+		return nil, fmt.Errorf("invalid external_secret refresh_interval: %w", err)
+	}
+	content := map[string]interface{}{"spec": map[string]interface{}{
+		"refreshInterval": ref.RefreshInterval,
+		"secretStoreRef":  map[string]interface{}{"name": ref.Store, "kind": ref.StoreKind},
+		"target":          map[string]interface{}{"name": name, "creationPolicy": "Owner"},
+		"dataFrom":        []interface{}{map[string]interface{}{"extract": map[string]interface{}{"key": ref.RemoteKey}}},
+	}}
+	origin := ref.StoreKind + "/" + ref.Store + "/" + ref.RemoteKey
+	return newSecretPayload(externalSecretGVR, "external-secrets.io/v1", "ExternalSecret", name, content, origin), nil
+}
+
+func newSecretPayload(gvr schema.GroupVersionResource, apiVersion, kind, name string, content map[string]interface{}, origin string) *secretPayload {
+	return &secretPayload{gvr: gvr, apiVersion: apiVersion, kind: kind, name: name, content: content, hash: contentHash(content), origin: origin}
+}
+
+// secretContent returns the fields of obj that carry the secret. For an
+// ExternalSecret these are the spec fields the payload sets, leaving out
+// the defaults the API server fills in.
+func secretContent(obj map[string]interface{}, payload *secretPayload) map[string]interface{} {
+	if payload != nil && payload.kind == "ExternalSecret" {
+		spec, _, _ := unstructured.NestedFieldNoCopy(obj, "spec")
+		return map[string]interface{}{"spec": project(spec, payload.content["spec"])}
+	}
+	secretType, _, _ := unstructured.NestedString(obj, "type")
+	if secretType == "" {
+		secretType = string(corev1.SecretTypeOpaque)
+	}
+	data, _, _ := unstructured.NestedMap(obj, "data")
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return map[string]interface{}{"type": secretType, "data": data}
+}
+
+// project returns the parts of value that shape also has.
+func project(value, shape interface{}) interface{} {
+	switch shape := shape.(type) {
+	case map[string]interface{}:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		out := make(map[string]interface{}, len(shape))
+		for k, v := range shape {
+			if mv, ok := m[k]; ok {
+				out[k] = project(mv, v)
+			}
+		}
+		return out
+	case []interface{}:
+		list, ok := value.([]interface{})
+		if !ok || len(list) != len(shape) {
+			return value
+		}
+		out := make([]interface{}, len(list))
+		for i := range list {
+			out[i] = project(list[i], shape[i])
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// contentHash hashes content; encoding/json sorts map keys, so equal
+// content always hashes the same.
+func contentHash(content map[string]interface{}) string {
+	data, _ := json.Marshal(content)
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// distributeToCluster writes the payload to each namespace of one cluster.
+// Failures are reported per namespace.
+func (s *Server) distributeToCluster(ctx context.Context, client *kubernetes.Clientset, clusterName string, payload *secretPayload, namespaces []string, rotate, restart bool) ([]SecretTarget, error) {
+	dyn, err := s.dynamicClient(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]SecretTarget, 0, len(namespaces))
+	for _, ns := range namespaces {
+		target := SecretTarget{Cluster: clusterName, Namespace: ns}
+		if err := distributeToNamespace(ctx, client, dyn.Resource(payload.gvr).Namespace(ns), payload, &target, rotate, restart); err != nil {
+			target.Status, target.Message = secretFailed, err.Error()
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// distributeToNamespace creates the payload in target.Namespace, or checks
+// an existing copy and, when rotate is set, updates it.
+func distributeToNamespace(ctx context.Context, client kubernetes.Interface, objects dynamic.ResourceInterface, payload *secretPayload, target *SecretTarget, rotate, restart bool) error {
+	dryRun := approval.IsDryRun(ctx)
+	_, nsMissing, err := ensureNamespace(ctx, client, target.Namespace, distributedFromAnnotation, payload.origin)
+	if err != nil {
+		return fmt.Errorf("creating namespace: %w", err)
+	}
+	desired := payload.object(target.Namespace)
+	if nsMissing {
+		target.Status = createdStatus(dryRun)
+		return nil
+	}
+	existing, err := objects.Get(ctx, payload.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, err := objects.Create(ctx, desired, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		target.Status = createdStatus(dryRun)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	state := secretState(existing, payload)
+	if state == secretInSync {
+		target.Status = secretInSync
+		return nil
+	}
+	if !rotate {
+		target.Status = state
+		switch state {
+		case secretDiverged:
+			target.Message = "changed on the cluster since it was distributed; set rotate to overwrite it"
+		case secretUnmanaged:
+			target.Message = "exists but was not distributed by this tool; set rotate to take it over"
+		default:
+			target.Message = "set rotate to update it"
+		}
+		return nil
+	}
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	desired.SetLabels(existing.GetLabels())
+	if _, err := objects.Update(ctx, desired, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	target.Previous = state
+	target.Status = "updated"
+	if dryRun {
+		target.Status = "would-update"
+	}
+	if !restart {
+		return nil
+	}
+	consumers, err := findConfigConsumers(ctx, client, target.Namespace, "Secret", payload.name)
+	if err != nil {
+		return fmt.Errorf("finding consumers: %w", err)
+	}
+	now := time.Now()
+	for _, c := range consumers {
+		if err := restartWorkload(ctx, client, c.workload, now); err != nil {
+			return fmt.Errorf("restarting %s: %w", c.Workload, err)
+		}
+		target.Restarted = append(target.Restarted, c.Workload)
+	}
+	return nil
+}
+
+// secretState compares an existing copy with the payload.
+func secretState(existing *unstructured.Unstructured, payload *secretPayload) string {
+	recorded := existing.GetAnnotations()[secretHashAnnotation]
+	actual := contentHash(secretContent(existing.Object, payload))
+	switch {
+	case recorded == "":
+		if actual == payload.hash {
+			return secretInSync
+		}
+		return secretUnmanaged
+	case recorded != actual:
+		return secretDiverged
+	case actual == payload.hash:
+		return secretInSync
+	default:
+		return secretOutOfDate
+	}
+}
@@ -0,0 +1,202 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	shopTokenPath    = "/api/v1/namespaces/shop/secrets/api-token"
+	shopExternalPath = "/apis/external-secrets.io/v1/namespaces/shop/externalsecrets/api-token"
+)
+
+// secretTargets flattens the per-cluster results of distribute_secret.
+func secretTargets(t *testing.T, out map[string]interface{}) map[string]map[string]interface{} {
+	t.Helper()
+	targets := map[string]map[string]interface{}{}
+	for _, r := range out["results"].([]interface{}) {
+		result := r.(map[string]interface{})
+		require.Empty(t, result["error"])
+		for _, tt := range result["result"].([]interface{}) {
+			target := tt.(map[string]interface{})
+			targets[target["cluster"].(string)+"/"+target["namespace"].(string)] = target
+		}
+	}
+	return targets
+}
+
+func TestDistributeSecretCreatesAndReportsUnmanagedCopies(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	west, westURL := newObjectAPIServer(t, false)
+	west.put(t, shopTokenPath, &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "api-token", Namespace: "shop"},
+		Data:       map[string][]byte{"token": []byte("local")},
+	})
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL})
+
+	out, errText := callTool(t, server, "distribute_secret", map[string]interface{}{
+		"name": "api-token", "namespace": "shop", "clusters": []string{"east", "west"},
+		"data": map[string]string{"token": "s3cret"},
+	})
+	require.Empty(t, errText)
+	assert.NotContains(t, mustMarshalJSON(t, out), "s3cret")
+	assert.NotContains(t, mustMarshalJSON(t, out), "czNjcmV0")
+	targets := secretTargets(t, out)
+	assert.Equal(t, "created", targets["east/shop"]["status"])
+	assert.Equal(t, secretUnmanaged, targets["west/shop"]["status"])
+
+	created := east.get(shopTokenPath)
+	require.NotNil(t, created)
+	data, _, _ := unstructured.NestedStringMap(created, "data")
+	assert.Equal(t, "czNjcmV0", data["token"])
+	annotations, _, _ := unstructured.NestedStringMap(created, "metadata", "annotations")
+	assert.Contains(t, annotations[secretHashAnnotation], "sha256:")
+	assert.Equal(t, "literal", annotations[distributedFromAnnotation])
+	assert.True(t, east.has(shopNSPath), "the namespace is created")
+
+	data, _, _ = unstructured.NestedStringMap(west.get(shopTokenPath), "data")
+	assert.Equal(t, "bG9jYWw=", data["token"], "unmanaged secrets are left alone without rotate")
+}
+
+func TestDistributeSecretDetectsDivergenceAndRotates(t *testing.T) {
+	east, eastURL := newObjectAPIServer(t, false)
+	west, westURL := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"east": eastURL, "west": westURL})
+	args := map[string]interface{}{
+		"name": "api-token", "namespace": "shop", "clusters": []string{"east", "west"},
+		"data": map[string]string{"token": "v1"},
+	}
+	_, errText := callTool(t, server, "distribute_secret", args)
+	require.Empty(t, errText)
+
+	out, errText := callTool(t, server, "distribute_secret", args)
+	require.Empty(t, errText)
+	assert.Equal(t, map[string]interface{}{secretInSync: float64(2)}, out["summary"])
+
+	edited := west.get(shopTokenPath)
+	edited["data"] = map[string]interface{}{"token": "ZWRpdGVk"}
+	west.put(t, shopTokenPath, edited)
+	deployment := webDeployment("web:v1")
+	deployment.Spec.Template.Spec.Containers[0].EnvFrom = []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "api-token"}}}}
+	east.put(t, shopDeployPath, deployment)
+
+	args["data"] = map[string]string{"token": "v2"}
+	out, errText = callTool(t, server, "distribute_secret", args)
+	require.Empty(t, errText)
+	targets := secretTargets(t, out)
+	assert.Equal(t, secretOutOfDate, targets["east/shop"]["status"])
+	assert.Equal(t, secretDiverged, targets["west/shop"]["status"])
+
+	args["rotate"] = true
+	args["restart_consumers"] = true
+	out, errText = callTool(t, server, "distribute_secret", args)
+	require.Empty(t, errText)
+	targets = secretTargets(t, out)
+	assert.Equal(t, "updated", targets["east/shop"]["status"])
+	assert.Equal(t, secretOutOfDate, targets["east/shop"]["previous"])
+	assert.Equal(t, []interface{}{"Deployment/web"}, targets["east/shop"]["restarted"])
+	assert.Equal(t, secretDiverged, targets["west/shop"]["previous"])
+	data, _, _ := unstructured.NestedStringMap(west.get(shopTokenPath), "data")
+	assert.Equal(t, "djI=", data["token"])
+	restartedAt, _, _ := unstructured.NestedString(east.get(shopDeployPath), "spec", "template", "metadata", "annotations", restartedAtAnnotation)
+	assert.NotEmpty(t, restartedAt)
+}
+
+func TestDistributeSecretFromSourceCluster(t *testing.T) {
+	source, sourceURL := newObjectAPIServer(t, false)
+	target, targetURL := newObjectAPIServer(t, false)
+	source.put(t, "/api/v1/namespaces/platform/secrets/registry", &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "platform", UID: "src-uid"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{".dockerconfigjson": []byte("{}")},
+	})
+	source.put(t, "/api/v1/namespaces/platform/secrets/sa-token", &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "sa-token", Namespace: "platform"},
+		Type:       corev1.SecretTypeServiceAccountToken,
+	})
+	server := newAtomicTestServer(t, map[string]string{"hub": sourceURL, "edge": targetURL})
+
+	out, errText := callTool(t, server, "distribute_secret", map[string]interface{}{
+		"name": "registry", "namespaces": []string{"shop", "web"}, "clusters": []string{"edge"},
+		"source_cluster": "hub", "source_namespace": "platform",
+	})
+	require.Empty(t, errText)
+	assert.Equal(t, "hub/platform/registry", out["source"])
+	copied := target.get("/api/v1/namespaces/web/secrets/registry")
+	require.NotNil(t, copied)
+	assert.Equal(t, string(corev1.SecretTypeDockerConfigJson), copied["type"])
+	assert.Empty(t, copied["metadata"].(map[string]interface{})["uid"])
+	assert.True(t, target.has("/api/v1/namespaces/shop/secrets/registry"))
+
+	_, errText = callTool(t, server, "distribute_secret", map[string]interface{}{
+		"name": "sa-token", "namespace": "shop", "clusters": []string{"edge"},
+		"source_cluster": "hub", "source_namespace": "platform",
+	})
+	assert.Contains(t, errText, "service account token")
+}
+
+func TestDistributeSecretExternalSecretIgnoresServerDefaults(t *testing.T) {
+	target, targetURL := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"edge": targetURL})
+	args := map[string]interface{}{
+		"name": "api-token", "namespace": "shop", "clusters": []string{"edge"},
+		"external_secret": map[string]string{"store": "vault", "store_kind": "ClusterSecretStore", "remote_key": "shop/api-token"},
+	}
+	out, errText := callTool(t, server, "distribute_secret", args)
+	require.Empty(t, errText)
+	assert.Equal(t, "ExternalSecret", out["kind"])
+	created := target.get(shopExternalPath)
+	require.NotNil(t, created)
+	store, _, _ := unstructured.NestedString(created, "spec", "secretStoreRef", "name")
+	assert.Equal(t, "vault", store)
+
+	// The API server fills in defaults the payload does not set.
+	require.NoError(t, unstructured.SetNestedField(created, "Retain", "spec", "target", "deletionPolicy"))
+	target.put(t, shopExternalPath, created)
+	out, errText = callTool(t, server, "distribute_secret", args)
+	require.Empty(t, errText)
+	assert.Equal(t, secretInSync, secretTargets(t, out)["edge/shop"]["status"])
+}
+
+func TestDistributeSecretDryRunChangesNothing(t *testing.T) {
+	target, targetURL := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"edge": targetURL})
+	out, errText := callTool(t, server, "distribute_secret", map[string]interface{}{
+		"name": "api-token", "namespace": "shop", "clusters": []string{"edge"},
+		"data": map[string]string{"token": "v1"}, "dry_run": true,
+	})
+	require.Empty(t, errText)
+	assert.Equal(t, "would-create", secretTargets(t, out)["edge/shop"]["status"])
+	assert.False(t, target.has(shopNSPath))
+	assert.False(t, target.has(shopTokenPath))
+}
+
+func TestDistributeSecretInvalidArgs(t *testing.T) {
+	_, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"edge": url})
+	data := map[string]string{"token": "v1"}
+	for _, args := range []map[string]interface{}{
+		{"namespace": "shop", "clusters": []string{"edge"}, "data": data},
+		{"name": "api-token", "clusters": []string{"edge"}, "data": data},
+		{"name": "api-token", "namespace": "shop", "data": data},
+		{"name": "api-token", "namespace": "kube-system", "clusters": []string{"edge"}, "data": data},
+		{"name": "api-token", "namespace": "shop", "clusters": []string{"edge"}},
+		{"name": "api-token", "namespace": "shop", "clusters": []string{"edge"}, "data": data, "source_cluster": "edge"},
+		{"name": "api-token", "namespace": "shop", "clusters": []string{"edge"}, "source_cluster": "edge"},
+		{"name": "api-token", "namespace": "shop", "clusters": []string{"edge"}, "data": map[string]string{"bad key": "x"}},
+		{"name": "api-token", "namespace": "shop", "clusters": []string{"edge"}, "data": data, "restart_consumers": true},
+		{"name": "api-token", "namespace": "shop", "clusters": []string{"edge"}, "external_secret": map[string]string{"store": "vault"}},
+		{"name": "api-token", "namespace": "shop", "clusters": []string{"edge"}, "external_secret": map[string]string{"store": "vault", "remote_key": "k", "store_kind": "Vault"}},
+	} {
+		_, errText := callTool(t, server, "distribute_secret", args)
+		assert.NotEmpty(t, errText, "%v", args)
+	}
+}
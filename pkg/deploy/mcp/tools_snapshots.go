@@ -0,0 +1,576 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// snapshotAppLabel marks the VolumeSnapshots snapshot_app_volumes takes
+	// with the app they were taken for.
+	snapshotAppLabel = "kubestellar.io/snapshot-app"
+	// snapshotSetLabel groups the VolumeSnapshots of one
+	// snapshot_app_volumes call across clusters.
+	snapshotSetLabel = "kubestellar.io/snapshot-set"
+	// snapshotSourceAnnotation holds the snapshotSource of a VolumeSnapshot,
+	// so a claim can be restored after the original is deleted.
+	snapshotSourceAnnotation = "kubestellar.io/snapshot-source"
+	// restoredFromAnnotation names the VolumeSnapshot a claim created by
+	// restore_volume_snapshot was restored from.
+	restoredFromAnnotation = "kubestellar.io/restored-from"
+	// defaultSnapshotClassAnnotation marks the default VolumeSnapshotClass
+	// of a CSI driver.
+	defaultSnapshotClassAnnotation = "snapshot.storage.kubernetes.io/is-default-class"
+)
+
+var (
+	volumeSnapshotGVR      = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshots"}
+	volumeSnapshotClassGVR = schema.GroupVersionResource{Group: "snapshot.storage.k8s.io", Version: "v1", Resource: "volumesnapshotclasses"}
+)
+
+// provisionerAnnotations are set on dynamically provisioned claims to the
+// CSI driver that provisioned them, newest first.
+var provisionerAnnotations = []string{
+	"volume.kubernetes.io/storage-provisioner",
+	"volume.beta.kubernetes.io/storage-provisioner",
+}
+
+// snapshotSource is the claim a VolumeSnapshot was taken of.
+type snapshotSource struct {
+	StorageClassName string                              `json:"storageClassName,omitempty"`
+	AccessModes      []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	VolumeMode       *corev1.PersistentVolumeMode        `json:"volumeMode,omitempty"`
+	Size             string                              `json:"size,omitempty"`
+}
+
+// VolumeSnapshotResult is the outcome of snapshotting one claim.
+type VolumeSnapshotResult struct {
+	Namespace string `json:"namespace"`
+	PVC       string `json:"pvc"`
+	Snapshot  string `json:"snapshot,omitempty"`
+	Class     string `json:"class,omitempty"`
+	// Status is created, dry-run, skipped, or failed.
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// VolumeSnapshotStatus is a VolumeSnapshot and whether it can be restored.
+type VolumeSnapshotStatus struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	PVC         string `json:"pvc,omitempty"`
+	Class       string `json:"class,omitempty"`
+	App         string `json:"app,omitempty"`
+	SnapshotSet string `json:"snapshotSet,omitempty"`
+	ReadyToUse  bool   `json:"readyToUse"`
+	RestoreSize string `json:"restoreSize,omitempty"`
+	CreatedAt   string `json:"createdAt,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// claimOrdinal matches the ordinal a StatefulSet appends to the claims it
+// creates from a volume claim template: <template>-<statefulset>-<ordinal>.
+var claimOrdinal = regexp.MustCompile(`^[0-9]+$`)
+
+// handleSnapshotAppVolumes takes a VolumeSnapshot of every
+// PersistentVolumeClaim an app mounts, on every cluster it runs on. Claims
+// whose driver has no VolumeSnapshotClass are skipped.
+func (s *Server) handleSnapshotAppVolumes(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		App           string   `json:"app"`
+		Namespace     string   `json:"namespace"`
+		Clusters      []string `json:"clusters"`
+		SnapshotClass string   `json:"snapshot_class"`
+		DryRun        bool     `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.App); err != nil {
+		return nil, fmt.Errorf("invalid app name: %w", err)
+	}
+	if params.Namespace != "" {
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	if params.SnapshotClass != "" {
+		if err := claude.ValidateK8sName(params.SnapshotClass); err != nil {
+			return nil, fmt.Errorf("invalid snapshot_class: %w", err)
+		}
+	}
+
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+	clusters := params.Clusters
+	if len(clusters) == 0 {
+		var err error
+		if clusters, err = s.appClusters(ctx, params.App, params.Namespace); err != nil {
+			return nil, err
+		}
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("app %s not found in any cluster", params.App)
+	}
+
+	set := time.Now().UTC().Format("20060102-150405")
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		return snapshotAppVolumesInCluster(ctx, client, dyn, clusterName, params.App, params.Namespace, params.SnapshotClass, set, dryRun)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"app":         params.App,
+		"snapshotSet": set,
+		"dryRun":      dryRun,
+		"results":     results,
+	}, nil
+}
+
+// snapshotAppVolumesInCluster snapshots the claims of app in one cluster.
+func snapshotAppVolumesInCluster(ctx context.Context, client *kubernetes.Clientset, dyn dynamic.Interface, clusterName, app, namespace, class, set string, dryRun bool) ([]VolumeSnapshotResult, error) {
+	claims, err := appVolumeClaims(ctx, client, clusterName, app, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if len(claims) == 0 {
+		return []VolumeSnapshotResult{}, nil
+	}
+	classes, err := dyn.Resource(volumeSnapshotClassGVR).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("cluster %s does not serve the VolumeSnapshot API; install the CSI external-snapshotter", clusterName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing volume snapshot classes: %w", err)
+	}
+
+	results := make([]VolumeSnapshotResult, 0, len(claims))
+	for i := range claims {
+		pvc := &claims[i]
+		result := VolumeSnapshotResult{Namespace: pvc.Namespace, PVC: pvc.Name}
+		if pvc.Status.Phase != corev1.ClaimBound {
+			result.Status, result.Message = "skipped", fmt.Sprintf("claim is %s, not Bound", pvc.Status.Phase)
+			results = append(results, result)
+			continue
+		}
+		result.Class = class
+		if class == "" {
+			driver, err := claimDriver(ctx, client, pvc)
+			if err != nil {
+				result.Status, result.Message = "failed", err.Error()
+				results = append(results, result)
+				continue
+			}
+			if result.Class, err = snapshotClassFor(classes.Items, driver); err != nil {
+				result.Status, result.Message = "skipped", err.Error()
+				results = append(results, result)
+				continue
+			}
+		}
+		snapshot, err := volumeSnapshot(pvc, result.Class, app, set)
+		if err != nil {
+			return nil, err
+		}
+		result.Snapshot = snapshot.GetName()
+		if _, err := dyn.Resource(volumeSnapshotGVR).Namespace(pvc.Namespace).Create(ctx, snapshot, metav1.CreateOptions{}); err != nil {
+			result.Status, result.Message = "failed", fmt.Sprintf("failed to create snapshot: %v", err)
+		} else if dryRun {
+			result.Status = "dry-run"
+		} else {
+			result.Status = "created"
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// appVolumeClaims returns the PersistentVolumeClaims the workloads of app
+// mount, including those created from StatefulSet volume claim templates,
+// sorted by namespace and name. Claims that do not exist are left out.
+func appVolumeClaims(ctx context.Context, client *kubernetes.Clientset, clusterName, app, namespace string) ([]corev1.PersistentVolumeClaim, error) {
+	found, err := listAppWorkloads(ctx, client, namespace, app)
+	if err != nil && len(found) == 0 {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("app %s not found in cluster %s", app, clusterName)
+	}
+	names := make(map[string]bool)
+	// templates are the claim name prefixes of StatefulSet claim templates
+	// by namespace.
+	templates := make(map[string][]string)
+	for _, w := range found {
+		ns := w.meta().GetNamespace()
+		if namespace == "" && server.ValidateNamespace(ns) != nil {
+			continue
+		}
+		template, _ := w.podTemplate()
+		for _, ref := range podReferences(template.Spec) {
+			if ref.Kind == "PersistentVolumeClaim" {
+				names[ns+"/"+ref.Name] = true
+			}
+		}
+		if w.statefulSet != nil {
+			for _, t := range w.statefulSet.Spec.VolumeClaimTemplates {
+				templates[ns] = append(templates[ns], t.Name+"-"+w.statefulSet.Name+"-")
+			}
+		}
+	}
+
+	namespaces := make(map[string]bool)
+	for key := range names {
+		ns, _, _ := strings.Cut(key, "/")
+		namespaces[ns] = true
+	}
+	for ns := range templates {
+		namespaces[ns] = true
+	}
+	var claims []corev1.PersistentVolumeClaim
+	for _, ns := range sortedKeys(namespaces) {
+		list, err := client.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing persistent volume claims in %s: %w", ns, err)
+		}
+		for _, pvc := range list.Items {
+			if names[ns+"/"+pvc.Name] || fromClaimTemplate(pvc.Name, templates[ns]) {
+				claims = append(claims, pvc)
+			}
+		}
+	}
+	sort.Slice(claims, func(i, j int) bool {
+		if claims[i].Namespace != claims[j].Namespace {
+			return claims[i].Namespace < claims[j].Namespace
+		}
+		return claims[i].Name < claims[j].Name
+	})
+	return claims, nil
+}
+
+// fromClaimTemplate reports whether a claim was created from a StatefulSet
+// claim template, given the <template>-<statefulset>- prefixes.
+func fromClaimTemplate(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if ordinal, ok := strings.CutPrefix(name, prefix); ok && claimOrdinal.MatchString(ordinal) {
+			return true
+		}
+	}
+	return false
+}
+
+// claimDriver returns the CSI driver that provisioned pvc, from its
+// annotations or else its StorageClass.
+func claimDriver(ctx context.Context, client kubernetes.Interface, pvc *corev1.PersistentVolumeClaim) (string, error) {
+	for _, key := range provisionerAnnotations {
+		if driver := pvc.Annotations[key]; driver != "" {
+			return driver, nil
+		}
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return "", fmt.Errorf("claim has no storage class, so its driver is unknown; set snapshot_class")
+	}
+	sc, err := client.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get storage class %s: %w", *pvc.Spec.StorageClassName, err)
+	}
+	return sc.Provisioner, nil
+}
+
+// snapshotClassFor returns the VolumeSnapshotClass to use for volumes of
+// driver: its default class, or its only class.
+func snapshotClassFor(classes []unstructured.Unstructured, driver string) (string, error) {
+	var matching []string
+	for _, c := range classes {
+		if d, _, _ := unstructured.NestedString(c.Object, "driver"); d != driver {
+			continue
+		}
+		if c.GetAnnotations()[defaultSnapshotClassAnnotation] == "true" {
+			return c.GetName(), nil
+		}
+		matching = append(matching, c.GetName())
+	}
+	switch len(matching) {
+	case 0:
+		return "", fmt.Errorf("no VolumeSnapshotClass for driver %s", driver)
+	case 1:
+		return matching[0], nil
+	}
+	sort.Strings(matching)
+	return "", fmt.Errorf("driver %s has several VolumeSnapshotClasses and none is the default (%v); set snapshot_class", driver, matching)
+}
+
+// volumeSnapshot returns a VolumeSnapshot of pvc, recording the claim's
+// storage class, access modes, and size for restore_volume_snapshot.
+func volumeSnapshot(pvc *corev1.PersistentVolumeClaim, class, app, set string) (*unstructured.Unstructured, error) {
+	source := snapshotSource{AccessModes: pvc.Spec.AccessModes, VolumeMode: pvc.Spec.VolumeMode}
+	if pvc.Spec.StorageClassName != nil {
+		source.StorageClassName = *pvc.Spec.StorageClassName
+	}
+	if size, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		source.Size = size.String()
+	} else if size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+		source.Size = size.String()
+	}
+	data, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "snapshot.storage.k8s.io/v1",
+		"kind":       "VolumeSnapshot",
+		"metadata": map[string]interface{}{
+			"name":        pvc.Name + "-" + set,
+			"namespace":   pvc.Namespace,
+			"labels":      map[string]interface{}{snapshotAppLabel: app, snapshotSetLabel: set},
+			"annotations": map[string]interface{}{snapshotSourceAnnotation: string(data)},
+		},
+		"spec": map[string]interface{}{
+			"volumeSnapshotClassName": class,
+			"source":                  map[string]interface{}{"persistentVolumeClaimName": pvc.Name},
+		},
+	}}
+	return snapshot, nil
+}
+
+// handleListVolumeSnapshots lists VolumeSnapshots per cluster with whether
+// each is ready to restore.
+func (s *Server) handleListVolumeSnapshots(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Cluster     string `json:"cluster"`
+		Namespace   string `json:"namespace"`
+		App         string `json:"app"`
+		SnapshotSet string `json:"snapshot_set"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Namespace != "" {
+		if err := server.ValidateNamespace(params.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	selector := labels.Set{}
+	if params.App != "" {
+		if err := claude.ValidateK8sName(params.App); err != nil {
+			return nil, fmt.Errorf("invalid app name: %w", err)
+		}
+		selector[snapshotAppLabel] = params.App
+	}
+	if params.SnapshotSet != "" {
+		selector[snapshotSetLabel] = params.SnapshotSet
+	}
+	if _, err := labels.ValidatedSelectorFromSet(selector); err != nil {
+		return nil, fmt.Errorf("invalid snapshot_set: %w", err)
+	}
+
+	results, err := s.executor.Execute(ctx, params.Cluster, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		return listVolumeSnapshots(ctx, dyn, clusterName, params.Namespace, selector.String())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"results": results}, nil
+}
+
+func listVolumeSnapshots(ctx context.Context, dyn dynamic.Interface, clusterName, namespace, selector string) ([]VolumeSnapshotStatus, error) {
+	list, err := dyn.Resource(volumeSnapshotGVR).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("cluster %s does not serve the VolumeSnapshot API", clusterName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing volume snapshots: %w", err)
+	}
+	snapshots := make([]VolumeSnapshotStatus, 0, len(list.Items))
+	for _, item := range list.Items {
+		if namespace == "" && server.ValidateNamespace(item.GetNamespace()) != nil {
+			continue
+		}
+		snapshots = append(snapshots, volumeSnapshotStatus(&item))
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Namespace != snapshots[j].Namespace {
+			return snapshots[i].Namespace < snapshots[j].Namespace
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+func volumeSnapshotStatus(obj *unstructured.Unstructured) VolumeSnapshotStatus {
+	status := VolumeSnapshotStatus{
+		Namespace:   obj.GetNamespace(),
+		Name:        obj.GetName(),
+		App:         obj.GetLabels()[snapshotAppLabel],
+		SnapshotSet: obj.GetLabels()[snapshotSetLabel],
+	}
+	status.PVC, _, _ = unstructured.NestedString(obj.Object, "spec", "source", "persistentVolumeClaimName")
+	status.Class, _, _ = unstructured.NestedString(obj.Object, "spec", "volumeSnapshotClassName")
+	status.ReadyToUse, _, _ = unstructured.NestedBool(obj.Object, "status", "readyToUse")
+	status.RestoreSize, _, _ = unstructured.NestedString(obj.Object, "status", "restoreSize")
+	status.CreatedAt, _, _ = unstructured.NestedString(obj.Object, "status", "creationTime")
+	status.Error, _, _ = unstructured.NestedString(obj.Object, "status", "error", "message")
+	return status
+}
+
+// handleRestoreVolumeSnapshot creates a PersistentVolumeClaim from a
+// VolumeSnapshot. It never replaces an existing claim.
+func (s *Server) handleRestoreVolumeSnapshot(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		Cluster      string `json:"cluster"`
+		Namespace    string `json:"namespace"`
+		Snapshot     string `json:"snapshot"`
+		PVC          string `json:"pvc"`
+		StorageClass string `json:"storage_class"`
+		DryRun       bool   `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Cluster == "" {
+		return nil, fmt.Errorf("cluster is required")
+	}
+	if params.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if err := server.ValidateNamespace(params.Namespace); err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	if err := claude.ValidateK8sName(params.Snapshot); err != nil {
+		return nil, fmt.Errorf("invalid snapshot name: %w", err)
+	}
+	if params.PVC != "" {
+		if err := claude.ValidateK8sName(params.PVC); err != nil {
+			return nil, fmt.Errorf("invalid pvc name: %w", err)
+		}
+	}
+
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, []string{params.Cluster}, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		dyn, err := s.dynamicClient(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		return restoreVolumeSnapshot(ctx, client, dyn, params.Namespace, params.Snapshot, params.PVC, params.StorageClass, dryRun)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 1 && results[0].Error != "" {
+		return nil, fmt.Errorf("%s", results[0].Error)
+	}
+	return results[0].Result, nil
+}
+
+func restoreVolumeSnapshot(ctx context.Context, client kubernetes.Interface, dyn dynamic.Interface, namespace, name, pvcName, storageClass string, dryRun bool) (map[string]interface{}, error) {
+	obj, err := dyn.Resource(volumeSnapshotGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume snapshot %s/%s: %w", namespace, name, err)
+	}
+	snapshot := volumeSnapshotStatus(obj)
+	if !snapshot.ReadyToUse {
+		msg := fmt.Sprintf("volume snapshot %s/%s is not ready to use", namespace, name)
+		if snapshot.Error != "" {
+			msg += ": " + snapshot.Error
+		}
+		return nil, fmt.Errorf("%s", msg)
+	}
+	var source snapshotSource
+	if data := obj.GetAnnotations()[snapshotSourceAnnotation]; data != "" {
+		_ = json.Unmarshal([]byte(data), &source)
+	}
+
+	if pvcName == "" {
+		pvcName = snapshot.PVC
+	}
+	if pvcName == "" {
+		return nil, fmt.Errorf("volume snapshot %s/%s does not name its source claim; set pvc", namespace, name)
+	}
+	if _, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, pvcName, metav1.GetOptions{}); err == nil {
+		return nil, fmt.Errorf("persistent volume claim %s/%s already exists; set pvc to restore to a new claim, or delete it first", namespace, pvcName)
+	} else if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get persistent volume claim %s/%s: %w", namespace, pvcName, err)
+	}
+
+	size := snapshot.RestoreSize
+	if size == "" {
+		size = source.Size
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return nil, fmt.Errorf("volume snapshot %s/%s has no usable restore size %q", namespace, name, size)
+	}
+	// A snapshot is restored at least as large as the claim it was taken of.
+	if original, err := resource.ParseQuantity(source.Size); err == nil && original.Cmp(quantity) > 0 {
+		quantity = original
+	}
+	accessModes := source.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	if storageClass == "" {
+		storageClass = source.StorageClassName
+	}
+	apiGroup := volumeSnapshotGVR.Group
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        pvcName,
+			Namespace:   namespace,
+			Annotations: map[string]string{restoredFromAnnotation: name},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: accessModes,
+			VolumeMode:  source.VolumeMode,
+			Resources:   corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: quantity}},
+			DataSource:  &corev1.TypedLocalObjectReference{APIGroup: &apiGroup, Kind: "VolumeSnapshot", Name: name},
+		},
+	}
+	if storageClass != "" {
+		pvc.Spec.StorageClassName = &storageClass
+	}
+	if _, err := client.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to create persistent volume claim %s/%s: %w", namespace, pvcName, err)
+	}
+	status := "created"
+	if dryRun {
+		status = "dry-run"
+	}
+	return map[string]interface{}{
+		"namespace":    namespace,
+		"snapshot":     name,
+		"pvc":          pvcName,
+		"size":         quantity.String(),
+		"storageClass": storageClass,
+		"status":       status,
+	}, nil
+}
@@ -0,0 +1,158 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	pvcsPath           = "/api/v1/namespaces/default/persistentvolumeclaims/"
+	volumeSnapshotsDir = "/apis/snapshot.storage.k8s.io/v1/namespaces/default/volumesnapshots/"
+	snapshotClassesDir = "/apis/snapshot.storage.k8s.io/v1/volumesnapshotclasses/"
+)
+
+func putClaim(t *testing.T, cluster *objectAPIServer, name, storageClass, provisioner string, phase corev1.PersistentVolumeClaimPhase) {
+	t.Helper()
+	pvc := &corev1.PersistentVolumeClaim{
+		TypeMeta:   metav1.TypeMeta{Kind: "PersistentVolumeClaim", APIVersion: "v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClass,
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources:        corev1.VolumeResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")}},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: phase},
+	}
+	if provisioner != "" {
+		pvc.Annotations = map[string]string{"volume.kubernetes.io/storage-provisioner": provisioner}
+	}
+	cluster.put(t, pvcsPath+name, pvc)
+}
+
+func putSnapshotClass(cluster *objectAPIServer, t *testing.T, name, driver string, isDefault bool) {
+	t.Helper()
+	meta := map[string]interface{}{"name": name}
+	if isDefault {
+		meta["annotations"] = map[string]interface{}{defaultSnapshotClassAnnotation: "true"}
+	}
+	cluster.put(t, snapshotClassesDir+name, map[string]interface{}{
+		"apiVersion": "snapshot.storage.k8s.io/v1", "kind": "VolumeSnapshotClass",
+		"metadata": meta, "driver": driver, "deletionPolicy": "Retain",
+	})
+}
+
+func TestSnapshotAppVolumesUsesEachDriversDefaultClass(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	replicas := int32(2)
+	cluster.put(t, "/apis/apps/v1/namespaces/default/statefulsets/db", &appsv1.StatefulSet{
+		TypeMeta:   metav1.TypeMeta{Kind: "StatefulSet", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"}},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "db", Image: "postgres:16"}},
+					Volumes: []corev1.Volume{{Name: "config", VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "db-config"},
+					}}},
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+		},
+	})
+	putClaim(t, cluster, "data-db-0", "gp3", "ebs.csi.aws.com", corev1.ClaimBound)
+	putClaim(t, cluster, "data-db-1", "gp3", "ebs.csi.aws.com", corev1.ClaimPending)
+	putClaim(t, cluster, "data-dbx-0", "gp3", "ebs.csi.aws.com", corev1.ClaimBound)
+	putClaim(t, cluster, "db-config", "standard", "", corev1.ClaimBound)
+	cluster.put(t, "/apis/storage.k8s.io/v1/storageclasses/standard", &storagev1.StorageClass{
+		TypeMeta:    metav1.TypeMeta{Kind: "StorageClass", APIVersion: "storage.k8s.io/v1"},
+		ObjectMeta:  metav1.ObjectMeta{Name: "standard"},
+		Provisioner: "rancher.io/local-path",
+	})
+	putSnapshotClass(cluster, t, "ebs-default", "ebs.csi.aws.com", true)
+	putSnapshotClass(cluster, t, "ebs-retain", "ebs.csi.aws.com", false)
+	server := newAtomicTestServer(t, map[string]string{"c1": url})
+
+	out, errText := callTool(t, server, "snapshot_app_volumes", map[string]interface{}{"app": "db", "clusters": []string{"c1"}})
+	require.Empty(t, errText)
+	set := out["snapshotSet"].(string)
+	results := out["results"].([]interface{})
+	require.Len(t, results, 1)
+	require.Empty(t, results[0].(map[string]interface{})["error"])
+
+	byClaim := make(map[string]map[string]interface{})
+	for _, r := range results[0].(map[string]interface{})["result"].([]interface{}) {
+		r := r.(map[string]interface{})
+		byClaim[r["pvc"].(string)] = r
+	}
+	require.Len(t, byClaim, 3, "data-dbx-0 belongs to another StatefulSet")
+	assert.Equal(t, "created", byClaim["data-db-0"]["status"])
+	assert.Equal(t, "ebs-default", byClaim["data-db-0"]["class"])
+	assert.Equal(t, "skipped", byClaim["data-db-1"]["status"])
+	assert.Equal(t, "skipped", byClaim["db-config"]["status"])
+	assert.Contains(t, byClaim["db-config"]["message"], "no VolumeSnapshotClass for driver rancher.io/local-path")
+
+	snapshot := cluster.get(volumeSnapshotsDir + "data-db-0-" + set)
+	require.NotNil(t, snapshot)
+	source, _, _ := unstructured.NestedString(snapshot, "spec", "source", "persistentVolumeClaimName")
+	assert.Equal(t, "data-db-0", source)
+	snapshotLabels, _, _ := unstructured.NestedStringMap(snapshot, "metadata", "labels")
+	assert.Equal(t, map[string]string{snapshotAppLabel: "db", snapshotSetLabel: set}, snapshotLabels)
+
+	out, errText = callTool(t, server, "list_volume_snapshots", map[string]interface{}{"cluster": "c1", "app": "db"})
+	require.Empty(t, errText)
+	listed := out["results"].([]interface{})[0].(map[string]interface{})["result"].([]interface{})
+	require.Len(t, listed, 1)
+	assert.Equal(t, "data-db-0", listed[0].(map[string]interface{})["pvc"])
+	assert.Equal(t, false, listed[0].(map[string]interface{})["readyToUse"])
+}
+
+func TestRestoreVolumeSnapshotRecreatesDeletedClaim(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	server := newAtomicTestServer(t, map[string]string{"c1": url})
+	snapshot := func(name string, ready bool) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "snapshot.storage.k8s.io/v1", "kind": "VolumeSnapshot",
+			"metadata": map[string]interface{}{
+				"name": name, "namespace": "default",
+				"annotations": map[string]interface{}{
+					snapshotSourceAnnotation: `{"storageClassName":"gp3","accessModes":["ReadWriteOnce"],"size":"20Gi"}`,
+				},
+			},
+			"spec":   map[string]interface{}{"source": map[string]interface{}{"persistentVolumeClaimName": "data-db-0"}},
+			"status": map[string]interface{}{"readyToUse": ready, "restoreSize": "10Gi"},
+		}
+	}
+	cluster.put(t, volumeSnapshotsDir+"pending", snapshot("pending", false))
+	cluster.put(t, volumeSnapshotsDir+"data-db-0-set", snapshot("data-db-0-set", true))
+
+	_, errText := callTool(t, server, "restore_volume_snapshot", map[string]interface{}{"cluster": "c1", "namespace": "default", "snapshot": "pending"})
+	assert.Contains(t, errText, "not ready to use")
+
+	out, errText := callTool(t, server, "restore_volume_snapshot", map[string]interface{}{"cluster": "c1", "namespace": "default", "snapshot": "data-db-0-set"})
+	require.Empty(t, errText)
+	assert.Equal(t, "created", out["status"])
+	assert.Equal(t, "data-db-0", out["pvc"])
+
+	pvc := cluster.get(pvcsPath + "data-db-0")
+	require.NotNil(t, pvc)
+	size, _, _ := unstructured.NestedString(pvc, "spec", "resources", "requests", "storage")
+	assert.Equal(t, "20Gi", size, "the claim is as large as the one the snapshot was taken of")
+	class, _, _ := unstructured.NestedString(pvc, "spec", "storageClassName")
+	assert.Equal(t, "gp3", class)
+	dataSource, _, _ := unstructured.NestedStringMap(pvc, "spec", "dataSource")
+	assert.Equal(t, map[string]string{"apiGroup": "snapshot.storage.k8s.io", "kind": "VolumeSnapshot", "name": "data-db-0-set"}, dataSource)
+
+	_, errText = callTool(t, server, "restore_volume_snapshot", map[string]interface{}{"cluster": "c1", "namespace": "default", "snapshot": "data-db-0-set"})
+	assert.Contains(t, errText, "already exists")
+}
@@ -0,0 +1,562 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/ai/claude"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	server "github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Statuses of a cluster in restart_statefulset.
+const (
+	statefulSetRestarted    = "restarted"
+	statefulSetFailed       = "failed"
+	statefulSetBlockedByPDB = "blocked-by-pdb"
+	statefulSetNotStarted   = "not-started"
+	statefulSetWouldRestart = "would-restart"
+)
+
+// StatefulSetPod is one replica of a StatefulSet.
+type StatefulSetPod struct {
+	Name     string `json:"name"`
+	Ordinal  int    `json:"ordinal"`
+	Revision string `json:"revision,omitempty"`
+	// Updated is whether the pod runs the StatefulSet's update revision.
+	Updated bool   `json:"updated"`
+	Ready   bool   `json:"ready"`
+	Phase   string `json:"phase"`
+}
+
+// StatefulSetClaim is a PersistentVolumeClaim created from one of a
+// StatefulSet's volume claim templates.
+type StatefulSetClaim struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+	Ordinal  int    `json:"ordinal"`
+	Phase    string `json:"phase"`
+	Capacity string `json:"capacity,omitempty"`
+	// Orphaned claims belong to ordinals above the current replica count,
+	// kept after a scale down.
+	Orphaned bool `json:"orphaned,omitempty"`
+	// OwnedBySet is whether the claim is deleted with the StatefulSet.
+	OwnedBySet bool `json:"ownedBySet,omitempty"`
+}
+
+// StatefulSetStatus is the rollout and storage state of a StatefulSet in
+// one cluster.
+type StatefulSetStatus struct {
+	Namespace       string `json:"namespace"`
+	Name            string `json:"name"`
+	Replicas        int32  `json:"replicas"`
+	ReadyReplicas   int32  `json:"readyReplicas"`
+	UpdatedReplicas int32  `json:"updatedReplicas"`
+	UpdateStrategy  string `json:"updateStrategy"`
+	// Partition is the lowest ordinal a rolling update replaces.
+	Partition       int32              `json:"partition"`
+	CurrentRevision string             `json:"currentRevision,omitempty"`
+	UpdateRevision  string             `json:"updateRevision,omitempty"`
+	PodManagement   string             `json:"podManagementPolicy"`
+	WhenDeleted     string             `json:"pvcWhenDeleted"`
+	WhenScaled      string             `json:"pvcWhenScaled"`
+	Pods            []StatefulSetPod   `json:"pods"`
+	Claims          []StatefulSetClaim `json:"claims,omitempty"`
+	Notes           []string           `json:"notes,omitempty"`
+}
+
+// StatefulSetRestartResult is the outcome of restart_statefulset in one
+// cluster.
+type StatefulSetRestartResult struct {
+	Cluster     string   `json:"cluster"`
+	StatefulSet string   `json:"statefulSet,omitempty"`
+	Status      string   `json:"status"`
+	Order       []string `json:"order,omitempty"`
+	Restarted   []string `json:"restarted,omitempty"`
+	Message     string   `json:"message,omitempty"`
+}
+
+// statefulSetParams are the arguments shared by the StatefulSet tools.
+type statefulSetParams struct {
+	App       string   `json:"app"`
+	Namespace string   `json:"namespace"`
+	Clusters  []string `json:"clusters"`
+}
+
+// targetClusters validates the shared arguments and returns the clusters
+// to act on: those given, or every cluster running the app.
+func (p statefulSetParams) targetClusters(ctx context.Context, s *Server) ([]string, error) {
+	if err := claude.ValidateK8sName(p.App); err != nil {
+		return nil, fmt.Errorf("invalid app name: %w", err)
+	}
+	if p.Namespace != "" {
+		if err := server.ValidateNamespace(p.Namespace); err != nil {
+			return nil, fmt.Errorf("invalid namespace: %w", err)
+		}
+	}
+	clusters := p.Clusters
+	if len(clusters) == 0 {
+		var err error
+		if clusters, err = s.appClusters(ctx, p.App, p.Namespace); err != nil {
+			return nil, err
+		}
+	}
+	if len(clusters) == 0 {
+		return nil, fmt.Errorf("app %s not found in any cluster", p.App)
+	}
+	sort.Strings(clusters)
+	return clusters, nil
+}
+
+// handleGetStatefulSetStatus reports an app's StatefulSet per cluster: the
+// update strategy and partition, which revision each pod runs, and the
+// claims its volume claim templates created with their retention policy.
+func (s *Server) handleGetStatefulSetStatus(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params statefulSetParams
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	clusters, err := params.targetClusters(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		w, err := resolveAppWorkload(ctx, client, clusterName, params.App, params.Namespace, "StatefulSet", "StatefulSet")
+		if err != nil {
+			return nil, err
+		}
+		return statefulSetStatus(ctx, client, w.statefulSet)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"app":     params.App,
+		"results": results,
+	}, nil
+}
+
+func statefulSetStatus(ctx context.Context, client kubernetes.Interface, ss *appsv1.StatefulSet) (*StatefulSetStatus, error) {
+	status := &StatefulSetStatus{
+		Namespace:       ss.Namespace,
+		Name:            ss.Name,
+		Replicas:        replicasOrDefault(ss.Spec.Replicas),
+		ReadyReplicas:   ss.Status.ReadyReplicas,
+		UpdatedReplicas: ss.Status.UpdatedReplicas,
+		UpdateStrategy:  string(ss.Spec.UpdateStrategy.Type),
+		Partition:       statefulSetPartition(ss),
+		CurrentRevision: ss.Status.CurrentRevision,
+		UpdateRevision:  ss.Status.UpdateRevision,
+		PodManagement:   string(ss.Spec.PodManagementPolicy),
+		WhenDeleted:     string(appsv1.RetainPersistentVolumeClaimRetentionPolicyType),
+		WhenScaled:      string(appsv1.RetainPersistentVolumeClaimRetentionPolicyType),
+	}
+	if status.UpdateStrategy == "" {
+		status.UpdateStrategy = string(appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+	if status.PodManagement == "" {
+		status.PodManagement = string(appsv1.OrderedReadyPodManagement)
+	}
+	if policy := ss.Spec.PersistentVolumeClaimRetentionPolicy; policy != nil {
+		if policy.WhenDeleted != "" {
+			status.WhenDeleted = string(policy.WhenDeleted)
+		}
+		if policy.WhenScaled != "" {
+			status.WhenScaled = string(policy.WhenScaled)
+		}
+	}
+
+	pods, err := statefulSetPods(ctx, client, ss)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		ordinal, _ := podOrdinal(ss.Name, pod.Name)
+		revision := pod.Labels[appsv1.StatefulSetRevisionLabel]
+		status.Pods = append(status.Pods, StatefulSetPod{
+			Name:     pod.Name,
+			Ordinal:  ordinal,
+			Revision: revision,
+			Updated:  revision != "" && revision == ss.Status.UpdateRevision,
+			Ready:    podIsReady(&pod),
+			Phase:    string(pod.Status.Phase),
+		})
+	}
+
+	if len(ss.Spec.VolumeClaimTemplates) > 0 {
+		claims, err := client.CoreV1().PersistentVolumeClaims(ss.Namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing persistent volume claims: %w", err)
+		}
+		for _, pvc := range claims.Items {
+			for _, t := range ss.Spec.VolumeClaimTemplates {
+				ordinal, ok := podOrdinal(t.Name+"-"+ss.Name, pvc.Name)
+				if !ok {
+					continue
+				}
+				claim := StatefulSetClaim{
+					Name: pvc.Name, Template: t.Name, Ordinal: ordinal, Phase: string(pvc.Status.Phase),
+					Orphaned: int32(ordinal) >= status.Replicas,
+				}
+				if size, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+					claim.Capacity = size.String()
+				}
+				for _, ref := range pvc.OwnerReferences {
+					if ref.Kind == "StatefulSet" && ref.UID == ss.UID {
+						claim.OwnedBySet = true
+					}
+				}
+				status.Claims = append(status.Claims, claim)
+			}
+		}
+		sort.Slice(status.Claims, func(i, j int) bool {
+			if status.Claims[i].Template != status.Claims[j].Template {
+				return status.Claims[i].Template < status.Claims[j].Template
+			}
+			return status.Claims[i].Ordinal < status.Claims[j].Ordinal
+		})
+	}
+	status.Notes = statefulSetNotes(status)
+	return status, nil
+}
+
+// statefulSetNotes points out what the rollout and retention state means
+// for an operator.
+func statefulSetNotes(status *StatefulSetStatus) []string {
+	var notes []string
+	if status.UpdateStrategy == string(appsv1.OnDeleteStatefulSetStrategyType) {
+		notes = append(notes, "OnDelete: pods only pick up template changes when deleted; use restart_statefulset")
+	}
+	if status.Partition > 0 && status.UpdateRevision != status.CurrentRevision {
+		var canary []string
+		for _, p := range status.Pods {
+			if int32(p.Ordinal) >= status.Partition {
+				canary = append(canary, p.Name)
+			}
+		}
+		notes = append(notes, fmt.Sprintf("canary in progress: ordinals %d and above (%s) update, the rest stay on %s", status.Partition, strings.Join(canary, ", "), status.CurrentRevision))
+	}
+	orphaned, unbound := 0, 0
+	for _, c := range status.Claims {
+		if c.Orphaned {
+			orphaned++
+		}
+		if c.Phase != string(corev1.ClaimBound) {
+			unbound++
+		}
+	}
+	if orphaned > 0 {
+		notes = append(notes, fmt.Sprintf("%d claims of scaled-down replicas are retained and still hold storage; scaling back up reuses them", orphaned))
+	}
+	if unbound > 0 {
+		notes = append(notes, fmt.Sprintf("%d claims are not Bound", unbound))
+	}
+	if status.WhenDeleted == string(appsv1.DeletePersistentVolumeClaimRetentionPolicyType) {
+		notes = append(notes, "deleting the StatefulSet deletes its claims and their data (pvcWhenDeleted: Delete); snapshot them first with snapshot_app_volumes")
+	}
+	if status.WhenScaled == string(appsv1.DeletePersistentVolumeClaimRetentionPolicyType) {
+		notes = append(notes, "scaling down deletes the claims of removed replicas (pvcWhenScaled: Delete)")
+	}
+	return notes
+}
+
+// statefulSetPartition returns the partition of a rolling update, or 0.
+func statefulSetPartition(ss *appsv1.StatefulSet) int32 {
+	if ru := ss.Spec.UpdateStrategy.RollingUpdate; ru != nil && ru.Partition != nil {
+		return *ru.Partition
+	}
+	return 0
+}
+
+// statefulSetPods returns the pods of ss, highest ordinal first.
+func statefulSetPods(ctx context.Context, client kubernetes.Interface, ss *appsv1.StatefulSet) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ss.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector on statefulset %s: %w", ss.Name, err)
+	}
+	list, err := client.CoreV1().Pods(ss.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range list.Items {
+		if _, ok := podOrdinal(ss.Name, pod.Name); ok {
+			pods = append(pods, pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		oi, _ := podOrdinal(ss.Name, pods[i].Name)
+		oj, _ := podOrdinal(ss.Name, pods[j].Name)
+		return oi > oj
+	})
+	return pods, nil
+}
+
+// podOrdinal returns the ordinal of name, <parent>-<ordinal>.
+func podOrdinal(parent, name string) (int, bool) {
+	suffix, ok := strings.CutPrefix(name, parent+"-")
+	if !ok || !claimOrdinal.MatchString(suffix) {
+		return 0, false
+	}
+	ordinal, err := strconv.Atoi(suffix)
+	return ordinal, err == nil
+}
+
+func podIsReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// handleSetStatefulSetPartition sets the rolling update partition of an
+// app's StatefulSet, optionally with new images, for a canary: only pods
+// with an ordinal at or above the partition are updated. Lowering the
+// partition widens the canary; 0 completes the rollout.
+func (s *Server) handleSetStatefulSetPartition(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		statefulSetParams
+		Partition *int32            `json:"partition"`
+		Image     string            `json:"image"`
+		Images    map[string]string `json:"images"`
+		Override  bool              `json:"override"`
+		DryRun    bool              `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.Partition == nil {
+		return nil, fmt.Errorf("partition is required")
+	}
+	if *params.Partition < 0 {
+		return nil, fmt.Errorf("partition must not be negative")
+	}
+	clusters, err := params.targetClusters(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+	if dryRun {
+		ctx = approval.WithDryRun(ctx)
+	}
+
+	results, err := s.executor.ExecuteOnSelected(ctx, clusters, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		w, err := resolveAppWorkload(ctx, client, clusterName, params.App, params.Namespace, "StatefulSet", "StatefulSet")
+		if err != nil {
+			return nil, err
+		}
+		conflicts, err := checkConflicts(ctx, client, w, false, params.Override)
+		if err != nil {
+			return nil, err
+		}
+		result, err := setStatefulSetPartition(ctx, client, w.statefulSet, *params.Partition, params.Image, params.Images, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		result["cluster"] = clusterName
+		if len(conflicts) > 0 {
+			result["conflicts"] = conflicts
+		}
+		return result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"app":       params.App,
+		"partition": *params.Partition,
+		"dryRun":    dryRun,
+		"results":   results,
+	}, nil
+}
+
+func setStatefulSetPartition(ctx context.Context, client kubernetes.Interface, live *appsv1.StatefulSet, partition int32, image string, images map[string]string, dryRun bool) (map[string]interface{}, error) {
+	if live.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return nil, fmt.Errorf("statefulset %s uses the OnDelete update strategy, which has no partition; use restart_statefulset to roll its pods", live.Name)
+	}
+	ss := live.DeepCopy()
+	previous := statefulSetPartition(ss)
+	if err := setImages("statefulset "+ss.Name, ss.Spec.Template.Spec.Containers, image, images); err != nil {
+		return nil, err
+	}
+	ss.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	if ss.Spec.UpdateStrategy.RollingUpdate == nil {
+		ss.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{}
+	}
+	ss.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+	if _, err := client.AppsV1().StatefulSets(ss.Namespace).Update(ctx, ss, metav1.UpdateOptions{}); err != nil {
+		return nil, err
+	}
+
+	replicas := replicasOrDefault(ss.Spec.Replicas)
+	updating := []string{}
+	for ordinal := replicas - 1; ordinal >= partition; ordinal-- {
+		updating = append(updating, fmt.Sprintf("%s-%d", ss.Name, ordinal))
+	}
+	status := "updated"
+	if dryRun {
+		status = "dry-run"
+	}
+	return map[string]interface{}{
+		"namespace":         ss.Namespace,
+		"statefulSet":       ss.Name,
+		"replicas":          replicas,
+		"previousPartition": previous,
+		"partition":         partition,
+		"updatingPods":      updating,
+		"status":            status,
+	}, nil
+}
+
+// handleRestartStatefulSet restarts the pods of an app's StatefulSet one at
+// a time, highest ordinal first as the StatefulSet controller would, one
+// cluster at a time. Pods are evicted, so PodDisruptionBudgets are
+// honored, and each must be recreated and ready before the next is
+// evicted. Unlike a rollout restart, this also rolls StatefulSets with the
+// OnDelete update strategy onto their latest template. The first failure
+// stops the restart.
+func (s *Server) handleRestartStatefulSet(ctx context.Context, args json.RawMessage) (interface{}, error) {
+	var params struct {
+		statefulSetParams
+		TimeoutSeconds int  `json:"timeout_seconds"`
+		DryRun         bool `json:"dry_run"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	if params.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("timeout_seconds must not be negative")
+	}
+	timeout := defaultHealthTimeout
+	if params.TimeoutSeconds > 0 {
+		timeout = time.Duration(params.TimeoutSeconds) * time.Second
+	}
+	clusters, err := params.targetClusters(ctx, s)
+	if err != nil {
+		return nil, err
+	}
+	dryRun := params.DryRun || approval.IsDryRun(ctx)
+
+	results := make([]StatefulSetRestartResult, 0, len(clusters))
+	outcome := "completed"
+	for i, cluster := range clusters {
+		if outcome != "completed" {
+			results = append(results, StatefulSetRestartResult{Cluster: cluster, Status: statefulSetNotStarted})
+			continue
+		}
+		restarted, err := s.executor.ExecuteOnSelected(ctx, []string{cluster}, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+			w, err := resolveAppWorkload(ctx, client, clusterName, params.App, params.Namespace, "StatefulSet", "StatefulSet")
+			if err != nil {
+				return nil, err
+			}
+			return restartStatefulSetPods(ctx, client, clusterName, w.statefulSet, timeout, dryRun), nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		result := StatefulSetRestartResult{Cluster: cluster, Status: statefulSetFailed}
+		if len(restarted) == 1 {
+			if r, ok := restarted[0].Result.(*StatefulSetRestartResult); ok {
+				result = *r
+			} else {
+				result.Message = restarted[0].Error
+			}
+		}
+		if result.Status != statefulSetRestarted && result.Status != statefulSetWouldRestart {
+			outcome = "halted"
+		}
+		results = append(results, result)
+		reportProgress(ctx, i+1, len(clusters), fmt.Sprintf("%s: %s", cluster, result.Status))
+	}
+	if dryRun {
+		outcome = "dry-run"
+	}
+	return map[string]interface{}{
+		"app":     params.App,
+		"outcome": outcome,
+		"dryRun":  dryRun,
+		"results": results,
+	}, nil
+}
+
+// restartStatefulSetPods evicts the pods of ss one at a time, highest
+// ordinal first, waiting up to timeout for each to come back ready.
+func restartStatefulSetPods(ctx context.Context, client kubernetes.Interface, clusterName string, ss *appsv1.StatefulSet, timeout time.Duration, dryRun bool) *StatefulSetRestartResult {
+	result := &StatefulSetRestartResult{Cluster: clusterName, StatefulSet: ss.Namespace + "/" + ss.Name}
+	pods, err := statefulSetPods(ctx, client, ss)
+	if err != nil {
+		result.Status, result.Message = statefulSetFailed, err.Error()
+		return result
+	}
+	for _, pod := range pods {
+		result.Order = append(result.Order, pod.Name)
+	}
+	if dryRun {
+		result.Status = statefulSetWouldRestart
+		return result
+	}
+	for _, pod := range pods {
+		if !podIsReady(&pod) {
+			result.Status, result.Message = statefulSetFailed, fmt.Sprintf("pod %s is not ready; fix it before restarting the others", pod.Name)
+			return result
+		}
+		err := client.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		})
+		switch {
+		case apierrors.IsTooManyRequests(err):
+			result.Status, result.Message = statefulSetBlockedByPDB, fmt.Sprintf("evicting %s would violate a PodDisruptionBudget", pod.Name)
+			return result
+		case err != nil && !apierrors.IsNotFound(err):
+			result.Status, result.Message = statefulSetFailed, fmt.Sprintf("failed to evict %s: %v", pod.Name, err)
+			return result
+		}
+		if err := waitForPodReplaced(ctx, client, pod.Namespace, pod.Name, pod.UID, timeout); err != nil {
+			result.Status, result.Message = statefulSetFailed, err.Error()
+			return result
+		}
+		result.Restarted = append(result.Restarted, pod.Name)
+	}
+	result.Status = statefulSetRestarted
+	return result
+}
+
+// waitForPodReplaced waits for the pod name to be recreated with a UID
+// other than old and to become ready.
+func waitForPodReplaced(ctx context.Context, client kubernetes.Interface, namespace, name string, old types.UID, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+	for {
+		pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+		case err != nil:
+			return fmt.Errorf("failed to get pod %s: %w", name, err)
+		case pod.UID != old && podIsReady(pod):
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("pod %s was not ready again within %s; later pods were not restarted", name, timeout)
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testStatefulSet(replicas int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		TypeMeta:   metav1.TypeMeta{Kind: "StatefulSet", APIVersion: "apps/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", UID: "db-uid", Labels: map[string]string{"app": "db"}},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db", Image: "postgres:16"}}},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+		},
+	}
+}
+
+func statefulSetPod(name, uid, revision string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "default", UID: types.UID(uid),
+			Labels: map[string]string{"app": "db", appsv1.StatefulSetRevisionLabel: revision},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning, Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func TestStatefulSetStatusReportsCanaryAndRetention(t *testing.T) {
+	ss := testStatefulSet(2)
+	partition := int32(1)
+	ss.Spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{
+		Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition},
+	}
+	ss.Spec.PersistentVolumeClaimRetentionPolicy = &appsv1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+		WhenDeleted: appsv1.DeletePersistentVolumeClaimRetentionPolicyType,
+	}
+	ss.Status = appsv1.StatefulSetStatus{CurrentRevision: "db-1", UpdateRevision: "db-2", ReadyReplicas: 2, UpdatedReplicas: 1}
+	objects := []runtime.Object{
+		statefulSetPod("db-0", "a", "db-1", true),
+		statefulSetPod("db-1", "b", "db-2", true),
+	}
+	for i := 0; i < 3; i++ {
+		objects = append(objects, &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("data-db-%d", i), Namespace: "default"},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:    corev1.ClaimBound,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		})
+	}
+	client := fake.NewSimpleClientset(objects...)
+
+	status, err := statefulSetStatus(context.Background(), client, ss)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), status.Partition)
+	assert.Equal(t, "Retain", status.WhenScaled)
+	require.Len(t, status.Pods, 2)
+	assert.Equal(t, "db-1", status.Pods[0].Name, "highest ordinal first")
+	assert.True(t, status.Pods[0].Updated)
+	assert.False(t, status.Pods[1].Updated)
+	require.Len(t, status.Claims, 3)
+	assert.True(t, status.Claims[2].Orphaned, "data-db-2 belongs to a scaled-down replica")
+	assert.False(t, status.Claims[1].Orphaned)
+	assert.Equal(t, []string{
+		"canary in progress: ordinals 1 and above (db-1) update, the rest stay on db-1",
+		"1 claims of scaled-down replicas are retained and still hold storage; scaling back up reuses them",
+		"deleting the StatefulSet deletes its claims and their data (pvcWhenDeleted: Delete); snapshot them first with snapshot_app_volumes",
+	}, status.Notes)
+}
+
+func TestSetStatefulSetPartitionStartsCanary(t *testing.T) {
+	cluster, url := newObjectAPIServer(t, false)
+	cluster.put(t, "/apis/apps/v1/namespaces/default/statefulsets/db", testStatefulSet(3))
+	server := newAtomicTestServer(t, map[string]string{"c1": url})
+
+	out, errText := callTool(t, server, "set_statefulset_partition", map[string]interface{}{
+		"app": "db", "clusters": []string{"c1"}, "partition": 2, "image": "postgres:17",
+	})
+	require.Empty(t, errText)
+	result := out["results"].([]interface{})[0].(map[string]interface{})
+	require.Empty(t, result["error"])
+	assert.Equal(t, []interface{}{"db-2"}, result["result"].(map[string]interface{})["updatingPods"])
+
+	stored := cluster.get("/apis/apps/v1/namespaces/default/statefulsets/db")
+	partition, _, _ := unstructured.NestedFieldNoCopy(stored, "spec", "updateStrategy", "rollingUpdate", "partition")
+	assert.EqualValues(t, 2, partition)
+	containers, _, _ := unstructured.NestedSlice(stored, "spec", "template", "spec", "containers")
+	assert.Equal(t, "postgres:17", containers[0].(map[string]interface{})["image"])
+
+	onDelete := testStatefulSet(3)
+	onDelete.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+	_, err := setStatefulSetPartition(context.Background(), fake.NewSimpleClientset(onDelete), onDelete, 1, "", nil, false)
+	assert.ErrorContains(t, err, "OnDelete")
+}
+
+func TestRestartStatefulSetEvictsHighestOrdinalFirst(t *testing.T) {
+	saved := healthPollInterval
+	healthPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { healthPollInterval = saved })
+
+	client := fake.NewSimpleClientset(
+		statefulSetPod("db-0", "a", "db-1", true),
+		statefulSetPod("db-1", "b", "db-1", true),
+		statefulSetPod("db-2", "c", "db-1", true),
+	)
+	var evicted []string
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+		if eviction.Name == "db-0" {
+			return true, nil, apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+		}
+		evicted = append(evicted, eviction.Name)
+		// The StatefulSet controller recreates the pod right away.
+		pod := statefulSetPod(eviction.Name, "new-"+eviction.Name, "db-2", true)
+		return true, nil, client.Tracker().Update(corev1.SchemeGroupVersion.WithResource("pods"), pod, "default")
+	})
+
+	result := restartStatefulSetPods(context.Background(), client, "c1", testStatefulSet(3), time.Second, false)
+	assert.Equal(t, []string{"db-2", "db-1", "db-0"}, result.Order)
+	assert.Equal(t, []string{"db-2", "db-1"}, evicted)
+	assert.Equal(t, []string{"db-2", "db-1"}, result.Restarted)
+	assert.Equal(t, statefulSetBlockedByPDB, result.Status)
+
+	dry := restartStatefulSetPods(context.Background(), client, "c1", testStatefulSet(3), time.Second, true)
+	assert.Equal(t, statefulSetWouldRestart, dry.Status)
+	assert.Len(t, evicted, 2, "a dry run evicts nothing")
+}
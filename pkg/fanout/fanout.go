@@ -0,0 +1,208 @@
+// Package fanout reports how a multi-cluster tool call went on each cluster,
+// and lets a call be repeated on only the clusters where it did not succeed.
+//
+// A server puts a Recorder in the context of each tool call. The cluster
+// executors of both servers record every cluster they run on, so each
+// fan-out tool gets a status matrix without handling it itself. A call with
+// retry_failed runs again with the clusters that succeeded last time
+// skipped.
+package fanout
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+	"github.com/kubestellar/kubestellar-mcp/pkg/redact"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
+)
+
+const (
+	// ArgRetryFailed is the argument that repeats a call on the clusters
+	// where the last call with the same arguments did not succeed.
+	ArgRetryFailed = "retry_failed"
+	// ArgDescription describes ArgRetryFailed in tool schemas.
+	ArgDescription = "Repeat the last call with the same arguments on only the clusters where it failed or was unreachable"
+	// MetaKey is the _meta key of the status matrix, and MetaSummaryKey
+	// that of the count of clusters per status.
+	MetaKey        = "clusterStatus"
+	MetaSummaryKey = "clusterSummary"
+)
+
+// Status is the outcome of a call on one cluster.
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusFailed      Status = "failed"
+	StatusUnreachable Status = "unreachable"
+	// StatusSkipped is a cluster a retry left out because it succeeded.
+	StatusSkipped Status = "skipped"
+)
+
+// severity orders statuses, so a cluster a tool ran on more than once
+// reports its worst outcome.
+var severity = map[Status]int{StatusSkipped: 0, StatusOK: 1, StatusFailed: 2, StatusUnreachable: 3}
+
+// ClusterStatus is one row of the status matrix.
+type ClusterStatus struct {
+	Cluster string `json:"cluster"`
+	Status  Status `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	// DurationMs is the time spent on the cluster, summed over every
+	// operation the tool ran on it.
+	DurationMs int64 `json:"durationMs"`
+}
+
+// Recorder collects the status of each cluster a tool call runs on.
+type Recorder struct {
+	mu       sync.Mutex
+	clusters map[string]*ClusterStatus
+}
+
+// NewRecorder returns an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{clusters: make(map[string]*ClusterStatus)}
+}
+
+type recorderKey struct{}
+
+type skipKey struct{}
+
+// WithRecorder returns ctx with r attached.
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// Record records that an operation on cluster took d and ended with err.
+// Network errors mark the cluster unreachable. It does nothing when ctx
+// has no Recorder.
+func Record(ctx context.Context, cluster string, err error, d time.Duration) {
+	status, reason := StatusOK, ""
+	if err != nil {
+		status = StatusFailed
+		if retry.Classify(err) == retry.ClassNetwork {
+			status = StatusUnreachable
+		}
+		reason, _ = redact.String(err.Error())
+	}
+	record(ctx, ClusterStatus{Cluster: cluster, Status: status, Reason: reason, DurationMs: d.Milliseconds()})
+}
+
+func record(ctx context.Context, s ClusterStatus) {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, ok := r.clusters[s.Cluster]
+	if !ok {
+		r.clusters[s.Cluster] = &s
+		return
+	}
+	prev.DurationMs += s.DurationMs
+	if severity[s.Status] > severity[prev.Status] {
+		prev.Status, prev.Reason = s.Status, s.Reason
+	}
+}
+
+// Matrix returns the status of every recorded cluster, by cluster name.
+func (r *Recorder) Matrix() []ClusterStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	matrix := make([]ClusterStatus, 0, len(r.clusters))
+	for _, s := range r.clusters {
+		matrix = append(matrix, *s)
+	}
+	sort.Slice(matrix, func(i, j int) bool { return matrix[i].Cluster < matrix[j].Cluster })
+	return matrix
+}
+
+// Meta returns the _meta fields of the status matrix, or nil if no cluster
+// was recorded.
+func (r *Recorder) Meta() map[string]interface{} {
+	matrix := r.Matrix()
+	if len(matrix) == 0 {
+		return nil
+	}
+	summary := make(map[Status]int)
+	for _, s := range matrix {
+		summary[s.Status]++
+	}
+	return map[string]interface{}{MetaKey: matrix, MetaSummaryKey: summary}
+}
+
+// Skip reports whether the retry in ctx leaves cluster out, and records it
+// as skipped if so. Executors call it before running on a cluster.
+func Skip(ctx context.Context, cluster string) bool {
+	succeeded, _ := ctx.Value(skipKey{}).(map[string]bool)
+	if !succeeded[cluster] {
+		return false
+	}
+	record(ctx, ClusterStatus{Cluster: cluster, Status: StatusSkipped, Reason: "succeeded in the previous call"})
+	return true
+}
+
+// Memory remembers the status matrix of recent calls, by tool and
+// arguments, for retry_failed.
+type Memory struct {
+	calls *cache.ResultCache
+}
+
+// NewMemory returns an empty Memory.
+func NewMemory() *Memory {
+	return &Memory{calls: cache.NewResultCache(0)}
+}
+
+// Remember stores the matrix of the call identified by key, unless the call
+// ran on no cluster. Servers key calls by approval.Fingerprint, so that the
+// applied call of an approved plan is retried like any other. A retry's
+// skipped clusters stay succeeded.
+func (m *Memory) Remember(key string, r *Recorder) {
+	matrix := r.Matrix()
+	if len(matrix) == 0 {
+		return
+	}
+	m.calls.Put(key, matrix)
+}
+
+// Retry returns ctx set up to repeat the call identified by key on the
+// clusters that did not succeed last time.
+func (m *Memory) Retry(ctx context.Context, key string) (context.Context, error) {
+	entry, ok := m.calls.Get(key, 0, cache.Options{AllowStale: true})
+	if !ok {
+		return ctx, fmt.Errorf("%s: there is no earlier call of this tool with the same arguments to retry", ArgRetryFailed)
+	}
+	matrix := entry.Value.([]ClusterStatus)
+	succeeded := make(map[string]bool)
+	for _, s := range matrix {
+		if s.Status == StatusOK || s.Status == StatusSkipped {
+			succeeded[s.Cluster] = true
+		}
+	}
+	if len(succeeded) == len(matrix) {
+		return ctx, fmt.Errorf("%s: the last call with these arguments succeeded on every cluster", ArgRetryFailed)
+	}
+	return context.WithValue(ctx, skipKey{}, succeeded), nil
+}
+
+// Requested reports whether args ask for a retry, and removes the argument
+// so that it is not part of the call's fingerprint.
+func Requested(args map[string]interface{}) bool {
+	v, ok := args[ArgRetryFailed]
+	if !ok {
+		return false
+	}
+	delete(args, ArgRetryFailed)
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
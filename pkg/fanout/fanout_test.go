@@ -0,0 +1,87 @@
+package fanout
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorderMatrix(t *testing.T) {
+	r := NewRecorder()
+	ctx := WithRecorder(context.Background(), r)
+	Record(ctx, "gamma", errors.New("dial tcp 10.0.0.3:6443: connect: connection refused"), 30*time.Millisecond)
+	Record(ctx, "alpha", nil, 10*time.Millisecond)
+	Record(ctx, "beta", nil, 5*time.Millisecond)
+	Record(ctx, "beta", errors.New("deployments.apps \"web\" is invalid"), 7*time.Millisecond)
+	Record(ctx, "alpha", nil, 10*time.Millisecond)
+	Record(context.Background(), "delta", nil, time.Millisecond)
+
+	want := []ClusterStatus{
+		{Cluster: "alpha", Status: StatusOK, DurationMs: 20},
+		{Cluster: "beta", Status: StatusFailed, Reason: "deployments.apps \"web\" is invalid", DurationMs: 12},
+		{Cluster: "gamma", Status: StatusUnreachable, Reason: "dial tcp 10.0.0.3:6443: connect: connection refused", DurationMs: 30},
+	}
+	got := r.Matrix()
+	if len(got) != len(want) {
+		t.Fatalf("Matrix() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Matrix()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	summary := r.Meta()[MetaSummaryKey].(map[Status]int)
+	if summary[StatusOK] != 1 || summary[StatusFailed] != 1 || summary[StatusUnreachable] != 1 {
+		t.Fatalf("summary = %v", summary)
+	}
+	if NewRecorder().Meta() != nil {
+		t.Fatal("Meta() of an empty recorder should be nil")
+	}
+}
+
+func TestMemoryRetry(t *testing.T) {
+	m := NewMemory()
+	if _, err := m.Retry(context.Background(), "scale"); err == nil || !strings.Contains(err.Error(), "no earlier call") {
+		t.Fatalf("Retry() of an unknown call error = %v", err)
+	}
+
+	first := NewRecorder()
+	ctx := WithRecorder(context.Background(), first)
+	Record(ctx, "alpha", nil, 0)
+	Record(ctx, "beta", errors.New("boom"), 0)
+	m.Remember("scale", first)
+
+	retry := NewRecorder()
+	ctx, err := m.Retry(WithRecorder(context.Background(), retry), "scale")
+	if err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	if !Skip(ctx, "alpha") || Skip(ctx, "beta") || Skip(ctx, "gamma") {
+		t.Fatal("Skip() should leave out only the cluster that succeeded")
+	}
+	Record(ctx, "beta", nil, 0)
+	if got := retry.Matrix(); len(got) != 2 || got[0].Status != StatusSkipped || got[1].Status != StatusOK {
+		t.Fatalf("retry Matrix() = %+v", got)
+	}
+	m.Remember("scale", retry)
+	if _, err := m.Retry(context.Background(), "scale"); err == nil || !strings.Contains(err.Error(), "succeeded on every cluster") {
+		t.Fatalf("Retry() after a successful retry error = %v", err)
+	}
+}
+
+func TestRequested(t *testing.T) {
+	for _, tt := range []struct {
+		value interface{}
+		want  bool
+	}{{true, true}, {"true", true}, {false, false}, {"yes", false}} {
+		args := map[string]interface{}{ArgRetryFailed: tt.value, "cluster": "prod"}
+		if got := Requested(args); got != tt.want {
+			t.Fatalf("Requested(%v) = %v, want %v", tt.value, got, tt.want)
+		}
+		if _, ok := args[ArgRetryFailed]; ok || len(args) != 1 {
+			t.Fatalf("Requested(%v) left args %v", tt.value, args)
+		}
+	}
+}
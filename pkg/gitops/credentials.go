@@ -0,0 +1,150 @@
+package gitops
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// EnvGitCredentials references the tokens used to clone private
+// repositories, as a comma-separated list of [user@]host=env:NAME or
+// [user@]host=file:PATH entries. Only the reference is in the variable; the
+// token is read when a repository on that host is cloned.
+const EnvGitCredentials = "KUBESTELLAR_GIT_CREDENTIALS"
+
+// defaultGitUsername is the user sent with a token when none is given,
+// which GitHub and Gitea accept for token authentication.
+const defaultGitUsername = "x-access-token"
+
+// Credential references the token for one git host.
+type Credential struct {
+	Host     string `json:"host"`
+	Username string `json:"username,omitempty"`
+	// Exactly one of TokenEnv and TokenFile names where the token is.
+	TokenEnv  string `json:"tokenEnv,omitempty"`
+	TokenFile string `json:"tokenFile,omitempty"`
+}
+
+// Validate checks that c names a host and exactly one token source.
+func (c Credential) Validate() error {
+	if c.Host == "" || strings.ContainsAny(c.Host, "/@=, ") {
+		return fmt.Errorf("git credential: invalid host %q", c.Host)
+	}
+	if strings.ContainsAny(c.Username, "@=, ") {
+		return fmt.Errorf("git credential for %s: invalid username %q", c.Host, c.Username)
+	}
+	if (c.TokenEnv == "") == (c.TokenFile == "") {
+		return fmt.Errorf("git credential for %s: set exactly one of tokenEnv and tokenFile", c.Host)
+	}
+	if strings.Contains(c.TokenFile, ",") {
+		return fmt.Errorf("git credential for %s: token file path must not contain a comma", c.Host)
+	}
+	return nil
+}
+
+// String returns c in EnvGitCredentials form.
+func (c Credential) String() string {
+	host := c.Host
+	if c.Username != "" {
+		host = c.Username + "@" + host
+	}
+	if c.TokenEnv != "" {
+		return host + "=env:" + c.TokenEnv
+	}
+	return host + "=file:" + c.TokenFile
+}
+
+// ParseCredentials parses an EnvGitCredentials value.
+func ParseCredentials(spec string) ([]Credential, error) {
+	var creds []Credential
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		host, ref, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s: expected [user@]host=env:NAME or [user@]host=file:PATH, got %q", EnvGitCredentials, part)
+		}
+		var c Credential
+		if user, h, ok := strings.Cut(host, "@"); ok {
+			c.Username, host = user, h
+		}
+		c.Host = host
+		switch {
+		case strings.HasPrefix(ref, "env:"):
+			c.TokenEnv = strings.TrimPrefix(ref, "env:")
+		case strings.HasPrefix(ref, "file:"):
+			c.TokenFile = strings.TrimPrefix(ref, "file:")
+		default:
+			return nil, fmt.Errorf("%s: token reference for %s must start with env: or file:, got %q", EnvGitCredentials, host, ref)
+		}
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", EnvGitCredentials, err)
+		}
+		creds = append(creds, c)
+	}
+	return creds, nil
+}
+
+// token reads the referenced token.
+func (c Credential) token() (string, error) {
+	if c.TokenEnv != "" {
+		token := os.Getenv(c.TokenEnv)
+		if token == "" {
+			return "", fmt.Errorf("git credential for %s: %s is not set", c.Host, c.TokenEnv)
+		}
+		return token, nil
+	}
+	data, err := os.ReadFile(c.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("git credential for %s: %w", c.Host, err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("git credential for %s: %s is empty", c.Host, c.TokenFile)
+	}
+	return token, nil
+}
+
+// gitCredentialEnv returns the environment for a git command fetching repo.
+// When EnvGitCredentials has a credential for the repository's host, the
+// token is passed as an Authorization header through git's GIT_CONFIG_*
+// variables, so it never appears in the command line or the repo config.
+func gitCredentialEnv(repo string) ([]string, error) {
+	env := os.Environ()
+	spec := os.Getenv(EnvGitCredentials)
+	if spec == "" {
+		return env, nil
+	}
+	creds, err := ParseCredentials(spec)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(repo)
+	if err != nil || u.Scheme != "https" {
+		return env, nil
+	}
+	for _, c := range creds {
+		if !strings.EqualFold(c.Host, u.Host) {
+			continue
+		}
+		token, err := c.token()
+		if err != nil {
+			return nil, err
+		}
+		user := c.Username
+		if user == "" {
+			user = defaultGitUsername
+		}
+		auth := base64.StdEncoding.EncodeToString([]byte(user + ":" + token))
+		return append(env,
+			"GIT_CONFIG_COUNT=1",
+			fmt.Sprintf("GIT_CONFIG_KEY_0=http.https://%s/.extraHeader", u.Host),
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+auth,
+		), nil
+	}
+	return env, nil
+}
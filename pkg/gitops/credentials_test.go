@@ -0,0 +1,65 @@
+package gitops
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCredentials(t *testing.T) {
+	creds, err := ParseCredentials("github.com=env:GITHUB_TOKEN, oauth2@gitlab.example.com=file:/run/secrets/gitlab")
+	require.NoError(t, err)
+	assert.Equal(t, []Credential{
+		{Host: "github.com", TokenEnv: "GITHUB_TOKEN"},
+		{Host: "gitlab.example.com", Username: "oauth2", TokenFile: "/run/secrets/gitlab"},
+	}, creds)
+	for _, c := range creds {
+		round, err := ParseCredentials(c.String())
+		require.NoError(t, err)
+		assert.Equal(t, []Credential{c}, round)
+	}
+
+	for _, spec := range []string{"github.com", "github.com=ghp_secret", "=env:TOKEN", "github.com=env:"} {
+		_, err := ParseCredentials(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestGitCredentialEnv(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0o600))
+	t.Setenv("TEST_GITHUB_TOKEN", "env-token")
+	t.Setenv(EnvGitCredentials, "github.com=env:TEST_GITHUB_TOKEN,oauth2@gitlab.example.com=file:"+tokenFile)
+
+	header := func(env []string) string {
+		for i, kv := range env {
+			if kv == "GIT_CONFIG_COUNT=1" {
+				return env[i+1] + "\n" + env[i+2]
+			}
+		}
+		return ""
+	}
+	basic := func(userpass string) string {
+		return "GIT_CONFIG_VALUE_0=Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(userpass))
+	}
+
+	env, err := gitCredentialEnv("https://github.com/org/private.git")
+	require.NoError(t, err)
+	assert.Equal(t, "GIT_CONFIG_KEY_0=http.https://github.com/.extraHeader\n"+basic("x-access-token:env-token"), header(env))
+
+	env, err = gitCredentialEnv("https://gitlab.example.com/team/config")
+	require.NoError(t, err)
+	assert.Equal(t, "GIT_CONFIG_KEY_0=http.https://gitlab.example.com/.extraHeader\n"+basic("oauth2:file-token"), header(env))
+
+	env, err = gitCredentialEnv("https://bitbucket.org/org/repo.git")
+	require.NoError(t, err)
+	assert.Empty(t, header(env), "hosts without a credential get no header")
+
+	t.Setenv("TEST_GITHUB_TOKEN", "")
+	_, err = gitCredentialEnv("https://github.com/org/private.git")
+	assert.EqualError(t, err, "git credential for github.com: TEST_GITHUB_TOKEN is not set")
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
-	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -15,6 +14,9 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
 )
 
 // DriftType indicates the type of drift detected
@@ -43,11 +45,14 @@ type DriftDetector struct {
 	client     *kubernetes.Clientset
 	dynClient  dynamic.Interface
 	restMapper meta.RESTMapper
+	// config rebuilds restMapper once when a kind does not resolve.
+	config       *rest.Config
+	rediscovered bool
 }
 
 // NewDriftDetector creates a new drift detector
 func NewDriftDetector(config *rest.Config) (*DriftDetector, error) {
-	client, err := kubernetes.NewForConfig(config)
+	client, err := kubernetes.NewForConfig(multicluster.ProtobufConfig(config))
 	if err != nil {
 		return nil, err
 	}
@@ -61,6 +66,7 @@ func NewDriftDetector(config *rest.Config) (*DriftDetector, error) {
 		client:     client,
 		dynClient:  dynClient,
 		restMapper: newRESTMapper(config),
+		config:     config,
 	}, nil
 }
 
@@ -102,10 +108,13 @@ func (d *DriftDetector) DetectDrift(ctx context.Context, manifests []Manifest, c
 
 // checkResource checks a single resource for drift
 func (d *DriftDetector) checkResource(ctx context.Context, manifest Manifest, clusterName string) (*DriftResult, error) {
-	mapping, err := resolveManifestResource(manifest, d.restMapper)
+	mapping, err := d.resolve(manifest)
 	if err != nil {
 		return nil, err
 	}
+	if mapping.Guessed && d.restMapper != nil {
+		return nil, kubemanifest.UnknownKindError(manifest.APIVersion, manifest.Kind)
+	}
 
 	var namespace string
 	if !mapping.ClusterScoped {
@@ -238,18 +247,31 @@ func compareObjects(path string, expected, actual map[string]interface{}) []stri
 	return differences
 }
 
-// getGVR returns the GroupVersionResource for a manifest.
+// getGVR returns the GroupVersionResource for a manifest, discovering the
+// API again once if its kind is not known.
 func (d *DriftDetector) getGVR(manifest Manifest) (schema.GroupVersionResource, error) {
-	mapping, err := resolveManifestResource(manifest, d.restMapper)
+	mapping, err := d.resolve(manifest)
 	if err != nil {
 		return schema.GroupVersionResource{}, err
 	}
 	return mapping.GVR, nil
 }
 
+// resolve returns the resource of manifest, discovering the API again once
+// when the RESTMapper does not know its kind.
+func (d *DriftDetector) resolve(manifest Manifest) (resourceMapping, error) {
+	mapping, err := resolveManifestResource(manifest, d.restMapper)
+	if err == nil && mapping.Guessed && d.restMapper != nil && d.config != nil && !d.rediscovered {
+		d.rediscovered = true
+		d.restMapper = rediscoverRESTMapper(d.config)
+		mapping, err = resolveManifestResource(manifest, d.restMapper)
+	}
+	return mapping, err
+}
+
 // IsManifestClusterScoped resolves manifest scope via the RESTMapper when available.
 func (d *DriftDetector) IsManifestClusterScoped(manifest Manifest) bool {
-	mapping, err := resolveManifestResource(manifest, d.restMapper)
+	mapping, err := d.resolve(manifest)
 	if err != nil {
 		return IsClusterScoped(manifest.Kind)
 	}
@@ -258,52 +280,12 @@ func (d *DriftDetector) IsManifestClusterScoped(manifest Manifest) bool {
 
 // kindToResource converts Kind to resource name
 func kindToResource(kind string) string {
-	// Common mappings
-	mappings := map[string]string{
-		"Deployment":              "deployments",
-		"Service":                 "services",
-		"ConfigMap":               "configmaps",
-		"Secret":                  "secrets",
-		"Pod":                     "pods",
-		"StatefulSet":             "statefulsets",
-		"DaemonSet":               "daemonsets",
-		"ReplicaSet":              "replicasets",
-		"Job":                     "jobs",
-		"CronJob":                 "cronjobs",
-		"Ingress":                 "ingresses",
-		"ServiceAccount":          "serviceaccounts",
-		"Role":                    "roles",
-		"RoleBinding":             "rolebindings",
-		"ClusterRole":             "clusterroles",
-		"ClusterRoleBinding":      "clusterrolebindings",
-		"PersistentVolumeClaim":   "persistentvolumeclaims",
-		"PersistentVolume":        "persistentvolumes",
-		"Namespace":               "namespaces",
-		"NetworkPolicy":           "networkpolicies",
-		"HorizontalPodAutoscaler": "horizontalpodautoscalers",
-	}
-
-	if resource, ok := mappings[kind]; ok {
-		return resource
-	}
-
-	// Default: lowercase and add 's'
-	return strings.ToLower(kind) + "s"
+	return kubemanifest.KindToResource(kind)
 }
 
 // IsClusterScoped returns true if the kind is cluster-scoped
 func IsClusterScoped(kind string) bool {
-	clusterScoped := map[string]bool{
-		"Namespace":                true,
-		"Node":                     true,
-		"PersistentVolume":         true,
-		"ClusterRole":              true,
-		"ClusterRoleBinding":       true,
-		"CustomResourceDefinition": true,
-		"StorageClass":             true,
-		"PriorityClass":            true,
-	}
-	return clusterScoped[kind]
+	return kubemanifest.IsClusterScoped(kind)
 }
 
 // isSystemManagedField returns true if the field is managed by Kubernetes
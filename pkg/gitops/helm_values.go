@@ -0,0 +1,125 @@
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ValuesDiffType is how a Helm value differs between git and a release.
+type ValuesDiffType string
+
+const (
+	// ValuesDiffChanged is a key whose value differs.
+	ValuesDiffChanged ValuesDiffType = "changed"
+	// ValuesDiffMissing is a key set in git but not in the release.
+	ValuesDiffMissing ValuesDiffType = "missing"
+	// ValuesDiffExtra is a key set in the release but not in git, such as
+	// one passed with --set.
+	ValuesDiffExtra ValuesDiffType = "extra"
+)
+
+// ValuesDifference is one key whose Helm value differs.
+type ValuesDifference struct {
+	// Key is the dotted path of the value.
+	Key          string         `json:"key"`
+	Type         ValuesDiffType `json:"type"`
+	GitValue     interface{}    `json:"gitValue,omitempty"`
+	ClusterValue interface{}    `json:"clusterValue,omitempty"`
+}
+
+// MergeValues merges Helm values files in order, as helm does with several
+// -f flags: maps are merged key by key, any other value replaces the one
+// before it, and a null removes the key.
+func MergeValues(files ...map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for _, values := range files {
+		mergeValuesInto(merged, values)
+	}
+	return merged
+}
+
+func mergeValuesInto(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeValuesInto(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			copied := map[string]interface{}{}
+			mergeValuesInto(copied, srcMap)
+			v = copied
+		}
+		dst[k] = v
+	}
+}
+
+// DiffValues compares the values declared in git with the user-supplied
+// values of a deployed release, key by key, sorted by key. Nested maps are
+// compared by their keys and lists as a whole.
+func DiffValues(git, cluster map[string]interface{}) []ValuesDifference {
+	var diffs []ValuesDifference
+	diffValues("", normalizeValues(git), normalizeValues(cluster), &diffs)
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Key < diffs[j].Key })
+	return diffs
+}
+
+func diffValues(prefix string, git, cluster map[string]interface{}, diffs *[]ValuesDifference) {
+	for k, gv := range git {
+		key := joinValuesKey(prefix, k)
+		cv, ok := cluster[k]
+		if !ok {
+			*diffs = append(*diffs, ValuesDifference{Key: key, Type: ValuesDiffMissing, GitValue: gv})
+			continue
+		}
+		gm, gitIsMap := gv.(map[string]interface{})
+		cm, clusterIsMap := cv.(map[string]interface{})
+		if gitIsMap && clusterIsMap {
+			diffValues(key, gm, cm, diffs)
+			continue
+		}
+		if !reflect.DeepEqual(gv, cv) {
+			*diffs = append(*diffs, ValuesDifference{Key: key, Type: ValuesDiffChanged, GitValue: gv, ClusterValue: cv})
+		}
+	}
+	for k, cv := range cluster {
+		if _, ok := git[k]; !ok {
+			*diffs = append(*diffs, ValuesDifference{Key: joinValuesKey(prefix, k), Type: ValuesDiffExtra, ClusterValue: cv})
+		}
+	}
+}
+
+// joinValuesKey appends k to a dotted key, quoting keys that contain dots,
+// such as annotation names.
+func joinValuesKey(prefix, k string) string {
+	if strings.ContainsAny(k, ". ") {
+		k = fmt.Sprintf("%q", k)
+	}
+	if prefix == "" {
+		return k
+	}
+	return prefix + "." + k
+}
+
+// normalizeValues round-trips values through JSON, so values decoded from
+// YAML and from a release compare equal when they are: numbers become
+// float64 and maps map[string]interface{}.
+func normalizeValues(values map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return values
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil || normalized == nil {
+		return map[string]interface{}{}
+	}
+	return normalized
+}
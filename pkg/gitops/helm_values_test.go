@@ -0,0 +1,88 @@
+package gitops
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func parseValues(t *testing.T, text string) map[string]interface{} {
+	t.Helper()
+	var v map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(text), &v))
+	return v
+}
+
+func TestMergeValues(t *testing.T) {
+	base := parseValues(t, "image:\n  repository: shop\n  tag: \"1.0\"\nreplicaCount: 2\ndebug: true\nports: [80, 443]\n")
+	override := parseValues(t, "image:\n  tag: \"1.1\"\nreplicaCount: 3\ndebug: null\nports: [8080]\n")
+
+	merged := MergeValues(base, override)
+	assert.Equal(t, parseValues(t, "image:\n  repository: shop\n  tag: \"1.1\"\nreplicaCount: 3\nports: [8080]\n"), merged)
+	assert.Equal(t, "1.0", base["image"].(map[string]interface{})["tag"], "inputs are not modified")
+}
+
+func TestDiffValues(t *testing.T) {
+	git := parseValues(t, `
+image:
+  tag: "1.1"
+replicaCount: 3
+resources:
+  limits:
+    memory: 512Mi
+podAnnotations:
+  prometheus.io/scrape: "true"
+`)
+	cluster := map[string]interface{}{
+		"image":          map[string]interface{}{"tag": "1.2"},
+		"replicaCount":   3, // decoded as an int, equal to git's float64
+		"podAnnotations": map[string]interface{}{"prometheus.io/scrape": "false"},
+		"debug":          true,
+	}
+
+	assert.Equal(t, []ValuesDifference{
+		{Key: "debug", Type: ValuesDiffExtra, ClusterValue: true},
+		{Key: "image.tag", Type: ValuesDiffChanged, GitValue: "1.1", ClusterValue: "1.2"},
+		{Key: `podAnnotations."prometheus.io/scrape"`, Type: ValuesDiffChanged, GitValue: "true", ClusterValue: "false"},
+		{Key: "resources", Type: ValuesDiffMissing, GitValue: map[string]interface{}{"limits": map[string]interface{}{"memory": "512Mi"}}},
+	}, DiffValues(git, cluster))
+
+	assert.Empty(t, DiffValues(git, git))
+	assert.Empty(t, DiffValues(nil, nil))
+}
+
+func TestReadFilesFromGit(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "charts", "envs", "prod"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "charts", "values.yaml"), []byte("replicaCount: 2\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "charts", "envs", "prod", "values.yaml"), []byte("replicaCount: 5\n"), 0o644))
+	for _, args := range [][]string{
+		{"init", "-b", "main"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "add", "."},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-m", "values"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+	}
+
+	r := NewManifestReaderWithSchemes(map[string]bool{"file": true})
+	defer r.Cleanup()
+	files, err := r.ReadFilesFromGit(context.Background(), ManifestSource{Repo: "file://" + dir, Path: "charts"},
+		[]string{"values.yaml", "envs/prod/values.yaml", "envs/dev/values.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{
+		"values.yaml":           []byte("replicaCount: 2\n"),
+		"envs/prod/values.yaml": []byte("replicaCount: 5\n"),
+	}, files)
+
+	_, err = r.ReadFilesFromGit(context.Background(), ManifestSource{Repo: "file://" + dir}, []string{"../../etc/passwd"})
+	assert.ErrorContains(t, err, "escapes repository directory")
+}
@@ -2,8 +2,10 @@ package gitops
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"net"
 	"net/url"
 	"os"
@@ -13,7 +15,9 @@ import (
 	"strings"
 	"time"
 
-	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
 )
 
 // allowedRepoSchemes restricts git clone to safe URL schemes.
@@ -24,6 +28,10 @@ var allowedRepoSchemes = map[string]bool{
 
 var validGitBranchPattern = regexp.MustCompile(`^[a-zA-Z0-9._/-]+$`)
 
+// gitCommitPattern matches a full commit SHA, which is fetched by itself
+// rather than cloned as a branch.
+var gitCommitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 var (
 	// gitopsCGNATNet is RFC 6598 Carrier-Grade NAT space (100.64.0.0/10).
 	_, gitopsCGNATNet, _ = net.ParseCIDR("100.64.0.0/10")
@@ -106,11 +114,17 @@ func validateBranchName(branch string) error {
 	return nil
 }
 
-// ManifestSource represents where to get manifests from
+// ManifestSource represents where to get manifests from. Exactly one of
+// Repo, LocalPath, and Archive is set.
 type ManifestSource struct {
 	Repo   string // Git repository URL
-	Path   string // Path within repo
-	Branch string // Branch name (default: main)
+	Path   string // Path within repo, local directory, or archive
+	Branch string // Branch, tag, or full commit SHA (default: main)
+	// LocalPath is a directory, manifest file, or .tar.gz of manifests on
+	// the server's file system, within the EnvManifestRoots directories.
+	LocalPath string `json:",omitempty"`
+	// Archive is an uploaded .tar.gz of manifests.
+	Archive []byte `json:"-"`
 }
 
 // Manifest represents a parsed Kubernetes manifest
@@ -153,6 +167,9 @@ type ManifestReader struct {
 	// When nil, the default safe set (https only) is used.
 	// Tests that need local repos can set this to include "file".
 	AllowedSchemes map[string]bool
+	// LocalRoots overrides the EnvManifestRoots directories local paths
+	// must be in.
+	LocalRoots []string
 }
 
 // NewManifestReader creates a new manifest reader with default safe URL schemes
@@ -170,31 +187,77 @@ func NewManifestReaderWithSchemes(schemes map[string]bool) *ManifestReader {
 // ctx is used to cancel the git clone subprocess if the caller's context is done.
 // The repo URL is validated against the reader's allowed schemes (defaults to https/http).
 func (r *ManifestReader) ReadFromGit(ctx context.Context, source ManifestSource) ([]Manifest, error) {
+	tempDir, err := r.clone(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	cleanupOnError := true
+	defer func() {
+		if cleanupOnError {
+			r.Cleanup()
+		}
+	}()
+
+	manifestPath, err := resolveManifestPath(tempDir, source.Path)
+	if err != nil {
+		return nil, err
+	}
+	manifests, err := r.ReadFromPath(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	cleanupOnError = false
+	return manifests, nil
+}
+
+// ReadFilesFromGit clones a repo and returns the contents of the files at
+// paths, relative to source.Path. Paths that do not exist in the repo are
+// left out of the result.
+func (r *ManifestReader) ReadFilesFromGit(ctx context.Context, source ManifestSource, paths []string) (map[string][]byte, error) {
+	tempDir, err := r.clone(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte, len(paths))
+	for _, p := range paths {
+		filePath, err := resolveManifestPath(tempDir, filepath.Join(source.Path, p))
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filePath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		files[p] = data
+	}
+	return files, nil
+}
+
+// clone validates source and clones its branch into a new temp directory,
+// which Cleanup removes.
+func (r *ManifestReader) clone(ctx context.Context, source ManifestSource) (string, error) {
 	// Validate repo URL to prevent SSRF and local file reads
 	schemes := r.AllowedSchemes
 	if schemes == nil {
 		schemes = allowedRepoSchemes
 	}
 	if err := validateRepoURLWithSchemes(source.Repo, schemes); err != nil {
-		return nil, fmt.Errorf("repo URL validation failed: %w", err)
+		return "", fmt.Errorf("repo URL validation failed: %w", err)
 	}
 
 	if err := r.resetTempDir(); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	// Create temp directory
 	tempDir, err := os.MkdirTemp("", "kubestellar-deploy-*")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+		return "", fmt.Errorf("failed to create temp dir: %w", err)
 	}
 	r.tempDir = tempDir
-	cleanupOnError := true
-	defer func() {
-		if cleanupOnError {
-			r.Cleanup()
-		}
-	}()
 
 	// Clone the repo
 	branch := source.Branch
@@ -202,25 +265,34 @@ func (r *ManifestReader) ReadFromGit(ctx context.Context, source ManifestSource)
 		branch = "main"
 	}
 	if err := validateBranchName(branch); err != nil {
-		return nil, err
+		r.Cleanup()
+		return "", err
 	}
 
-	cmd := exec.CommandContext(ctx, "git", "clone", "--depth", "1", "--branch", branch, "--", source.Repo, tempDir)
-	output, err := cmd.CombinedOutput()
+	env, err := gitCredentialEnv(source.Repo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to clone repo: %w\n%s", err, output)
-	}
-
-	manifestPath, err := resolveManifestPath(tempDir, source.Path)
-	if err != nil {
-		return nil, err
+		r.Cleanup()
+		return "", err
+	}
+	commands := [][]string{{"clone", "--depth", "1", "--branch", branch, "--", source.Repo, tempDir}}
+	if gitCommitPattern.MatchString(branch) {
+		// A commit cannot be cloned by name, so fetch just that commit.
+		commands = [][]string{
+			{"init", "-q", tempDir},
+			{"-C", tempDir, "fetch", "-q", "--depth", "1", "--", source.Repo, branch},
+			{"-C", tempDir, "checkout", "-q", "FETCH_HEAD"},
+		}
 	}
-	manifests, err := r.ReadFromPath(manifestPath)
-	if err != nil {
-		return nil, err
+	for _, args := range commands {
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Env = env
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			r.Cleanup()
+			return "", fmt.Errorf("failed to clone repo: %w\n%s", err, output)
+		}
 	}
-	cleanupOnError = false
-	return manifests, nil
+	return tempDir, nil
 }
 
 // ReadFromPath reads all YAML manifests from a directory
@@ -237,8 +309,7 @@ func (r *ManifestReader) ReadFromPath(path string) ([]Manifest, error) {
 		}
 
 		// Only process YAML files
-		ext := strings.ToLower(filepath.Ext(filePath))
-		if ext != ".yaml" && ext != ".yml" {
+		if !isManifestFile(filePath) {
 			return nil
 		}
 
@@ -271,31 +342,18 @@ func (r *ManifestReader) ReadFromFile(filePath string) ([]Manifest, error) {
 	return r.ReadFromReader(file)
 }
 
-// ReadFromReader reads manifests from an io.Reader
+// ReadFromReader reads manifests from an io.Reader. Documents that are not
+// Kubernetes objects, such as Helm values files, are skipped, and Lists are
+// replaced by their items.
 func (r *ManifestReader) ReadFromReader(reader io.Reader) ([]Manifest, error) {
-	var manifests []Manifest
-
-	decoder := yaml.NewYAMLOrJSONDecoder(reader, 4096)
-
-	for {
-		var raw map[string]interface{}
-		if err := decoder.Decode(&raw); err != nil {
-			if err == io.EOF {
-				break
-			}
-			return nil, err
-		}
-
-		if raw == nil {
-			continue
-		}
-
-		manifest := parseManifest(raw)
-		if manifest.Kind != "" {
-			manifests = append(manifests, manifest)
-		}
+	objects, err := kubemanifest.DecodeMixed(reader)
+	if err != nil {
+		return nil, err
+	}
+	manifests := make([]Manifest, 0, len(objects))
+	for _, obj := range objects {
+		manifests = append(manifests, NewManifest(obj))
 	}
-
 	return manifests, nil
 }
 
@@ -332,6 +390,11 @@ func (r *ManifestReader) Cleanup() {
 	}
 }
 
+// NewManifest returns the Manifest of a decoded object.
+func NewManifest(obj *unstructured.Unstructured) Manifest {
+	return parseManifest(obj.Object)
+}
+
 // parseManifest parses a raw map into a Manifest
 func parseManifest(raw map[string]interface{}) Manifest {
 	m := Manifest{Raw: raw}
@@ -0,0 +1,225 @@
+package gitops
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EnvManifestRoots lists the directories, separated like PATH, that local
+// manifest paths must be in. It defaults to the server's working
+// directory, so a server started in a checkout can read its working tree.
+const EnvManifestRoots = "KUBESTELLAR_MANIFEST_ROOTS"
+
+// maxArchiveBytes bounds the uncompressed size of the manifests read from
+// an archive.
+const maxArchiveBytes = 64 << 20
+
+// Location describes where source reads manifests from, for messages.
+func (s ManifestSource) Location() string {
+	switch {
+	case len(s.Archive) > 0:
+		return fmt.Sprintf("uploaded archive (%d bytes)", len(s.Archive))
+	case s.LocalPath != "":
+		return s.LocalPath
+	}
+	return s.Repo
+}
+
+// DecodeArchive decodes a base64-encoded .tar.gz of manifests, as tools
+// take it.
+func DecodeArchive(encoded string) ([]byte, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	archive, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("archive is not valid base64: %w", err)
+	}
+	return archive, nil
+}
+
+// Read reads the manifests of source from its git repository, local path,
+// or archive.
+func (r *ManifestReader) Read(ctx context.Context, source ManifestSource) ([]Manifest, error) {
+	set := 0
+	for _, ok := range []bool{source.Repo != "", source.LocalPath != "", len(source.Archive) > 0} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("set exactly one of a repo URL, a local path, and an archive")
+	}
+	if source.Repo == "" && source.Branch != "" {
+		return nil, errors.New("branch only applies to git repositories")
+	}
+	switch {
+	case len(source.Archive) > 0:
+		return r.ReadFromArchive(bytes.NewReader(source.Archive), source.Path)
+	case source.LocalPath != "":
+		return r.readLocal(source)
+	}
+	return r.ReadFromGit(ctx, source)
+}
+
+// readLocal reads the manifests in a local directory, under source.Path,
+// or in a local manifest file or archive.
+func (r *ManifestReader) readLocal(source ManifestSource) ([]Manifest, error) {
+	local, err := r.resolveLocalPath(source.LocalPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		dir, err := resolveManifestPath(local, source.Path)
+		if err != nil {
+			return nil, err
+		}
+		return r.ReadFromPath(dir)
+	}
+	if isManifestArchive(local) {
+		file, err := os.Open(local)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+		return r.ReadFromArchive(file, source.Path)
+	}
+	if source.Path != "" {
+		return nil, fmt.Errorf("path only applies to a directory or archive, and %s is a file", source.LocalPath)
+	}
+	return r.ReadFromFile(local)
+}
+
+// resolveLocalPath returns the real path of p after checking that it is in
+// one of the manifest roots. Symlinks are resolved first, so a link in a
+// root cannot lead out of it.
+func (r *ManifestReader) resolveLocalPath(p string) (string, error) {
+	roots := r.LocalRoots
+	if roots == nil {
+		roots = localManifestRoots()
+	}
+	resolved, err := realPath(p)
+	if err != nil {
+		return "", fmt.Errorf("local path %s: %w", p, err)
+	}
+	for _, root := range roots {
+		root, err := realPath(root)
+		if err != nil {
+			continue
+		}
+		rel, err := filepath.Rel(root, resolved)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return resolved, nil
+		}
+	}
+	return "", fmt.Errorf("local path %s is outside the manifest roots (%s); add its directory to %s",
+		p, strings.Join(roots, string(filepath.ListSeparator)), EnvManifestRoots)
+}
+
+func realPath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(abs)
+}
+
+// localManifestRoots returns the EnvManifestRoots directories, or the
+// working directory.
+func localManifestRoots() []string {
+	var roots []string
+	for _, root := range filepath.SplitList(os.Getenv(EnvManifestRoots)) {
+		if root != "" {
+			roots = append(roots, root)
+		}
+	}
+	if len(roots) == 0 {
+		if wd, err := os.Getwd(); err == nil {
+			roots = append(roots, wd)
+		}
+	}
+	return roots
+}
+
+// ReadFromArchive reads the YAML manifests under dir in a .tar.gz, in
+// name order. Only regular files are read, so links in the archive cannot
+// point outside it.
+func (r *ManifestReader) ReadFromArchive(archive io.Reader, dir string) ([]Manifest, error) {
+	prefix := path.Clean("/" + filepath.ToSlash(dir))[1:]
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer func() {
+		_ = gz.Close()
+	}()
+
+	files := make(map[string][]byte)
+	var total int64
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		name := path.Clean("/" + hdr.Name)[1:]
+		if hdr.Typeflag != tar.TypeReg || !isManifestFile(name) || (prefix != "" && !strings.HasPrefix(name, prefix+"/")) {
+			continue
+		}
+		if total += hdr.Size; total > maxArchiveBytes {
+			return nil, fmt.Errorf("the manifests in the archive are larger than %d MiB", maxArchiveBytes>>20)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, hdr.Size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", name, err)
+		}
+		files[name] = data
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var manifests []Manifest
+	for _, name := range names {
+		fileManifests, err := r.ReadFromReader(bytes.NewReader(files[name]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		manifests = append(manifests, fileManifests...)
+	}
+	return manifests, nil
+}
+
+// isManifestFile reports whether name is a YAML file.
+func isManifestFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// isManifestArchive reports whether name is a gzipped tarball.
+func isManifestArchive(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, ".tar.gz") || strings.HasSuffix(lower, ".tgz")
+}
@@ -0,0 +1,105 @@
+package gitops
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tarGz builds a gzipped tarball of files, in order; a value starting with
+// "->" makes a symlink to the rest of it.
+func tarGz(t *testing.T, files ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		name, content := f[0], f[1]
+		if target, ok := strings.CutPrefix(content, "->"); ok {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeSymlink, Linkname: target}))
+			continue
+		}
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func manifestNames(manifests []Manifest) []string {
+	names := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		names = append(names, m.Metadata.Name)
+	}
+	return names
+}
+
+func TestReadFromArchive(t *testing.T) {
+	archive := tarGz(t,
+		[2]string{"./prod/web.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n"},
+		[2]string{"prod/api.yml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: api\n"},
+		[2]string{"prod/README.md", "not a manifest"},
+		[2]string{"prod/passwd.yaml", "->/etc/passwd"},
+		[2]string{"staging/web.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: staging-web\n"},
+		[2]string{"../escape.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: escape\n"},
+	)
+	reader := NewManifestReader()
+
+	manifests, err := reader.ReadFromArchive(bytes.NewReader(archive), "prod/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "web"}, manifestNames(manifests), "name order, symlinks and other directories skipped")
+
+	manifests, err = reader.Read(context.Background(), ManifestSource{Archive: archive})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"escape", "api", "web", "staging-web"}, manifestNames(manifests), "names are kept inside the archive")
+
+	_, err = reader.ReadFromArchive(bytes.NewReader([]byte("plain text")), "")
+	assert.ErrorContains(t, err, "failed to read archive")
+}
+
+func TestReadLocalPath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "overlays", "prod"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "overlays", "prod", "web.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "base.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: base\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "bundle.tgz"), tarGz(t, [2]string{"prod/api.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: api\n"}), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "secret.yaml"), []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: secret\n"), 0o644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "link")))
+	reader := &ManifestReader{LocalRoots: []string{root}}
+	read := func(source ManifestSource) ([]string, error) {
+		manifests, err := reader.Read(context.Background(), source)
+		return manifestNames(manifests), err
+	}
+
+	names, err := read(ManifestSource{LocalPath: root, Path: "overlays/prod"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"web"}, names)
+	names, err = read(ManifestSource{LocalPath: filepath.Join(root, "base.yaml")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"base"}, names)
+	names, err = read(ManifestSource{LocalPath: filepath.Join(root, "bundle.tgz"), Path: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api"}, names)
+
+	_, err = read(ManifestSource{LocalPath: outside})
+	assert.ErrorContains(t, err, "outside the manifest roots")
+	_, err = read(ManifestSource{LocalPath: filepath.Join(root, "link")})
+	assert.ErrorContains(t, err, "outside the manifest roots", "symlinks are resolved before the check")
+	_, err = read(ManifestSource{LocalPath: root, Path: "../"})
+	assert.ErrorContains(t, err, "escapes")
+	_, err = read(ManifestSource{LocalPath: root, Branch: "main"})
+	assert.ErrorContains(t, err, "branch only applies to git repositories")
+	_, err = read(ManifestSource{LocalPath: root, Repo: "https://github.com/org/repo"})
+	assert.ErrorContains(t, err, "exactly one")
+}
@@ -0,0 +1,96 @@
+package gitops
+
+import "sort"
+
+// ManifestChangeType is how a resource changes between two git refs.
+type ManifestChangeType string
+
+const (
+	// ManifestAdded is a resource only at the head ref.
+	ManifestAdded ManifestChangeType = "added"
+	// ManifestRemoved is a resource only at the base ref.
+	ManifestRemoved ManifestChangeType = "removed"
+	// ManifestModified is a resource whose manifest differs between refs.
+	ManifestModified ManifestChangeType = "modified"
+)
+
+// ManifestChange is a resource whose manifest differs between a base ref,
+// such as main, and a head ref, such as a pull request's branch.
+type ManifestChange struct {
+	ResourceKey string             `json:"resourceKey"`
+	Kind        string             `json:"kind"`
+	Namespace   string             `json:"namespace,omitempty"`
+	Name        string             `json:"name"`
+	Type        ManifestChangeType `json:"type"`
+	// Fields are the fields of a modified manifest that differ.
+	Fields []ManifestFieldChange `json:"fields,omitempty"`
+	// Head is the manifest at the head ref, unless it was removed.
+	Head *Manifest `json:"-"`
+}
+
+// ManifestFieldChange is a field of a manifest that differs between refs.
+// Base or Head is nil when the field is only set at the other ref.
+type ManifestFieldChange struct {
+	// Field is the dotted path of the field.
+	Field string      `json:"field"`
+	Base  interface{} `json:"base,omitempty"`
+	Head  interface{} `json:"head,omitempty"`
+}
+
+// DiffManifests compares the manifests at a base ref with those at a head
+// ref, and returns the resources that were added, removed, or modified,
+// sorted by resource key.
+func DiffManifests(base, head []Manifest) []ManifestChange {
+	baseByKey := manifestsByKey(base)
+	headByKey := manifestsByKey(head)
+
+	var changes []ManifestChange
+	for key, h := range headByKey {
+		change := newManifestChange(key, h)
+		b, ok := baseByKey[key]
+		if !ok {
+			change.Type = ManifestAdded
+			change.Head = &h
+			changes = append(changes, change)
+			continue
+		}
+		diffs := DiffValues(h.Raw, b.Raw)
+		if len(diffs) == 0 {
+			continue
+		}
+		change.Type = ManifestModified
+		change.Head = &h
+		for _, d := range diffs {
+			change.Fields = append(change.Fields, ManifestFieldChange{Field: d.Key, Base: d.ClusterValue, Head: d.GitValue})
+		}
+		changes = append(changes, change)
+	}
+	for key, b := range baseByKey {
+		if _, ok := headByKey[key]; !ok {
+			change := newManifestChange(key, b)
+			change.Type = ManifestRemoved
+			changes = append(changes, change)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ResourceKey < changes[j].ResourceKey })
+	return changes
+}
+
+// manifestsByKey indexes manifests by resource key; a later manifest for
+// the same resource replaces an earlier one, as it would when applied.
+func manifestsByKey(manifests []Manifest) map[string]Manifest {
+	byKey := make(map[string]Manifest, len(manifests))
+	for _, m := range manifests {
+		byKey[m.GetKey().String()] = m
+	}
+	return byKey
+}
+
+func newManifestChange(key string, m Manifest) ManifestChange {
+	return ManifestChange{
+		ResourceKey: key,
+		Kind:        m.Kind,
+		Namespace:   m.Metadata.Namespace,
+		Name:        m.Metadata.Name,
+	}
+}
@@ -0,0 +1,139 @@
+package gitops
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readManifestsFromString(t *testing.T, yaml string) []Manifest {
+	t.Helper()
+	manifests, err := NewManifestReader().ReadFromReader(strings.NewReader(yaml))
+	require.NoError(t, err)
+	return manifests
+}
+
+func TestDiffManifests(t *testing.T) {
+	base := readManifestsFromString(t, `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:1.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: legacy
+  namespace: shop
+data:
+  mode: old
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: shop
+data:
+  color: blue
+`)
+	head := readManifestsFromString(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: shop
+data:
+  color: blue
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+  labels:
+    tier: frontend
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: web:1.1
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: shop
+spec:
+  ports:
+  - port: 80
+`)
+
+	changes := DiffManifests(base, head)
+	require.Len(t, changes, 3, "the unchanged ConfigMap is left out")
+
+	assert.Equal(t, "apps/v1/Deployment/shop/web", changes[0].ResourceKey)
+	assert.Equal(t, ManifestModified, changes[0].Type)
+	assert.Equal(t, []ManifestFieldChange{
+		{Field: "metadata.labels", Head: map[string]interface{}{"tier": "frontend"}},
+		{Field: "spec.replicas", Base: float64(2), Head: float64(3)},
+		{Field: "spec.template.spec.containers",
+			Base: []interface{}{map[string]interface{}{"name": "web", "image": "web:1.0"}},
+			Head: []interface{}{map[string]interface{}{"name": "web", "image": "web:1.1"}}},
+	}, changes[0].Fields)
+	require.NotNil(t, changes[0].Head)
+	assert.Equal(t, "web", changes[0].Head.Metadata.Name)
+
+	assert.Equal(t, "v1/ConfigMap/shop/legacy", changes[1].ResourceKey)
+	assert.Equal(t, ManifestRemoved, changes[1].Type)
+	assert.Nil(t, changes[1].Head)
+
+	assert.Equal(t, "v1/Service/shop/web", changes[2].ResourceKey)
+	assert.Equal(t, ManifestAdded, changes[2].Type)
+	assert.Empty(t, changes[2].Fields)
+	require.NotNil(t, changes[2].Head)
+}
+
+func TestReadFromGitChecksOutCommit(t *testing.T) {
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	write := func(replicas string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "app.yaml"),
+			[]byte("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: "+replicas+"\n"), 0o644))
+	}
+	git("init", "-q", "-b", "main")
+	write("2")
+	git("add", ".")
+	git("commit", "-qm", "two replicas")
+	first := git("rev-parse", "HEAD")
+	write("3")
+	git("commit", "-qam", "three replicas")
+
+	r := NewManifestReaderWithSchemes(map[string]bool{"file": true})
+	defer r.Cleanup()
+	for ref, want := range map[string]int64{first: 2, "main": 3} {
+		manifests, err := r.ReadFromGit(context.Background(), ManifestSource{Repo: "file://" + dir, Branch: ref})
+		require.NoError(t, err, ref)
+		require.Len(t, manifests, 1)
+		assert.Equal(t, want, manifests[0].Spec["replicas"], ref)
+	}
+}
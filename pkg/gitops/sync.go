@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -13,6 +16,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
 )
 
 // SyncAction represents what action was taken for a resource
@@ -24,6 +29,13 @@ const (
 	SyncActionUnchanged SyncAction = "unchanged"
 	SyncActionSkipped   SyncAction = "skipped"
 	SyncActionFailed    SyncAction = "failed"
+	// SyncActionRejected is a dry run the API server refused: admission
+	// (a webhook, Pod Security, a quota), validation, or authorization
+	// would reject the resource.
+	SyncActionRejected SyncAction = "rejected"
+	// SyncActionConflict is a server-side apply that other field managers
+	// own fields of; see SyncOptions.ServerSideApply.
+	SyncActionConflict SyncAction = "conflict"
 )
 
 // SyncResult represents the result of syncing a single resource
@@ -34,6 +46,9 @@ type SyncResult struct {
 	Namespace string     `json:"namespace,omitempty"`
 	Action    SyncAction `json:"action"`
 	Message   string     `json:"message,omitempty"`
+	// Warnings are the API server's warnings about the resource, such as
+	// the Pod Security violations of a workload's pod template.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // SyncSummary provides an overview of sync operation
@@ -44,6 +59,8 @@ type SyncSummary struct {
 	Unchanged int          `json:"unchanged"`
 	Failed    int          `json:"failed"`
 	Skipped   int          `json:"skipped"`
+	Rejected  int          `json:"rejected,omitempty"`
+	Conflicts int          `json:"conflicts,omitempty"`
 	Results   []SyncResult `json:"results"`
 }
 
@@ -51,11 +68,22 @@ type SyncSummary struct {
 type Syncer struct {
 	dynClient  dynamic.Interface
 	restMapper meta.RESTMapper
+	// config rebuilds restMapper once applied CRDs are established, or
+	// once when a kind does not resolve.
+	config       *rest.Config
+	rediscovered bool
+	// warnings collects the API server's warnings, reported with the
+	// resource whose request returned them.
+	warnings *warningRecorder
 }
 
 // NewSyncer creates a new syncer
 func NewSyncer(config *rest.Config) (*Syncer, error) {
-	dynClient, err := dynamic.NewForConfig(config)
+	warnings := &warningRecorder{}
+	withWarnings := rest.CopyConfig(config)
+	withWarnings.WarningHandler = warnings
+	withWarnings.WarningHandlerWithContext = nil
+	dynClient, err := dynamic.NewForConfig(withWarnings)
 	if err != nil {
 		return nil, err
 	}
@@ -63,25 +91,97 @@ func NewSyncer(config *rest.Config) (*Syncer, error) {
 	return &Syncer{
 		dynClient:  dynClient,
 		restMapper: newRESTMapper(config),
+		config:     config,
+		warnings:   warnings,
 	}, nil
 }
 
+// warningRecorder is a rest.WarningHandler that keeps the warnings until
+// they are taken.
+type warningRecorder struct {
+	mu       sync.Mutex
+	warnings []string
+}
+
+func (w *warningRecorder) HandleWarningHeader(code int, _ string, text string) {
+	if code != 299 || text == "" {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.warnings = append(w.warnings, text)
+}
+
+// take returns the warnings received since the last call.
+func (w *warningRecorder) take() []string {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	warnings := w.warnings
+	w.warnings = nil
+	return warnings
+}
+
 // SyncOptions controls sync behavior
 type SyncOptions struct {
-	DryRun    bool     // Preview changes without applying
+	// DryRun previews changes without applying them. Every resource is
+	// sent to the API server as a dry run, so admission webhooks, Pod
+	// Security, and quotas judge it as they would a real apply.
+	DryRun    bool
 	Namespace string   // Override namespace for all resources
 	Include   []string // Only sync these kinds
 	Exclude   []string // Don't sync these kinds
+	// CreateNamespaces creates the namespaces of resources that do not
+	// exist and are not in the manifests themselves.
+	CreateNamespaces bool
+	// ServerSideApply server-side applies every resource, new or existing,
+	// as FieldManager (default kubemanifest.DefaultFieldManager), and
+	// reports the fields other field managers own as a conflict unless
+	// Force is set. Otherwise missing resources are created and existing
+	// ones updated by a forced server-side apply.
+	ServerSideApply bool
+	FieldManager    string
+	Force           bool
 }
 
-// Sync applies manifests to a cluster
+// Sync applies manifests to a cluster. Namespaces are applied first, then
+// CustomResourceDefinitions, then everything else once those CRDs are
+// established.
 func (s *Syncer) Sync(ctx context.Context, manifests []Manifest, clusterName string, opts SyncOptions) (*SyncSummary, error) {
 	summary := &SyncSummary{
 		Cluster: clusterName,
 		Results: []SyncResult{},
 	}
 
+	manifests = slices.Clone(manifests)
+	slices.SortStableFunc(manifests, func(a, b Manifest) int {
+		return kubemanifest.ApplyPhase(a.APIVersion, a.Kind) - kubemanifest.ApplyPhase(b.APIVersion, b.Kind)
+	})
+	declared := make(map[string]bool)
+	declaredKinds := make(map[schema.GroupVersionKind]resourceMapping)
 	for _, manifest := range manifests {
+		switch kubemanifest.ApplyPhase(manifest.APIVersion, manifest.Kind) {
+		case kubemanifest.PhaseNamespace:
+			declared[manifest.Metadata.Name] = true
+		case kubemanifest.PhaseCRD:
+			maps.Copy(declaredKinds, kubemanifest.CRDMappings(manifest.Raw))
+		}
+	}
+	ensured := make(map[string]bool)
+	var (
+		crds   []string
+		crdErr error
+	)
+
+	for _, manifest := range manifests {
+		phase := kubemanifest.ApplyPhase(manifest.APIVersion, manifest.Kind)
+		if phase == kubemanifest.PhaseResource && len(crds) > 0 {
+			crdErr = s.waitForCRDs(ctx, crds)
+			crds = nil
+		}
+
 		// Check if kind should be included/excluded
 		if !s.shouldSync(manifest.Kind, opts) {
 			summary.Skipped++
@@ -96,7 +196,21 @@ func (s *Syncer) Sync(ctx context.Context, manifests []Manifest, clusterName str
 			continue
 		}
 
-		mapping, err := resolveManifestResource(manifest, s.restMapper)
+		mapping, err := s.resolve(manifest)
+		if err == nil && mapping.Guessed {
+			crdMapping, ok := declaredKinds[schema.FromAPIVersionAndKind(manifest.APIVersion, manifest.Kind)]
+			switch {
+			case crdErr != nil:
+				err = crdErr
+			case ok:
+				// The CRD is in this sync but not applied, as in a dry run.
+				mapping = crdMapping
+			case s.restMapper != nil:
+				// Guessing would apply a custom resource of unknown
+				// scope, which fails confusingly when it is wrong.
+				err = kubemanifest.UnknownKindError(manifest.APIVersion, manifest.Kind)
+			}
+		}
 		if err != nil {
 			summary.Failed++
 			summary.Results = append(summary.Results, SyncResult{
@@ -117,8 +231,40 @@ func (s *Syncer) Sync(ctx context.Context, manifests []Manifest, clusterName str
 				namespace = opts.Namespace
 			}
 		}
+		if opts.CreateNamespaces && namespace != "" && !declared[namespace] && !ensured[namespace] {
+			ensured[namespace] = true
+			created, err := kubemanifest.EnsureNamespace(ctx, s.dynClient, namespace, opts.DryRun)
+			if err != nil {
+				summary.Failed++
+				summary.Results = append(summary.Results, SyncResult{
+					Cluster:   clusterName,
+					Kind:      manifest.Kind,
+					Name:      manifest.Metadata.Name,
+					Namespace: namespace,
+					Action:    SyncActionFailed,
+					Message:   err.Error(),
+				})
+				continue
+			}
+			if created {
+				message := "Created for the manifest's resources"
+				if opts.DryRun {
+					message = "Would create (dry-run)"
+				}
+				summary.Created++
+				summary.Results = append(summary.Results, SyncResult{
+					Cluster: clusterName,
+					Kind:    "Namespace",
+					Name:    namespace,
+					Action:  SyncActionCreated,
+					Message: message,
+				})
+			}
+		}
 
-		result, err := s.syncResource(ctx, manifest, mapping, namespace, opts.DryRun)
+		s.warnings.take()
+		result, err := s.syncResource(ctx, manifest, mapping, namespace, opts)
+		warnings := s.warnings.take()
 		if err != nil {
 			summary.Failed++
 			summary.Results = append(summary.Results, SyncResult{
@@ -128,12 +274,17 @@ func (s *Syncer) Sync(ctx context.Context, manifests []Manifest, clusterName str
 				Namespace: namespace,
 				Action:    SyncActionFailed,
 				Message:   err.Error(),
+				Warnings:  warnings,
 			})
 			continue
 		}
 
 		result.Cluster = clusterName
+		result.Warnings = warnings
 		summary.Results = append(summary.Results, *result)
+		if phase == kubemanifest.PhaseCRD && !opts.DryRun {
+			crds = append(crds, manifest.Metadata.Name)
+		}
 
 		switch result.Action {
 		case SyncActionCreated:
@@ -142,14 +293,32 @@ func (s *Syncer) Sync(ctx context.Context, manifests []Manifest, clusterName str
 			summary.Updated++
 		case SyncActionUnchanged:
 			summary.Unchanged++
+		case SyncActionRejected:
+			summary.Rejected++
+		case SyncActionConflict:
+			summary.Conflicts++
 		}
 	}
 
 	return summary, nil
 }
 
-// syncResource syncs a single resource
-func (s *Syncer) syncResource(ctx context.Context, manifest Manifest, mapping resourceMapping, namespace string, dryRun bool) (*SyncResult, error) {
+// waitForCRDs waits for the CRDs applied by a sync to be established, and
+// then rediscovers the API so their custom resources resolve.
+func (s *Syncer) waitForCRDs(ctx context.Context, names []string) error {
+	if err := kubemanifest.WaitForCRDs(ctx, s.dynClient, names, kubemanifest.CRDEstablishTimeout); err != nil {
+		return err
+	}
+	if s.config != nil {
+		s.restMapper = rediscoverRESTMapper(s.config)
+	}
+	return nil
+}
+
+// syncResource syncs a single resource. Missing resources are created and
+// existing ones updated by a forced server-side apply, unless
+// opts.ServerSideApply applies both without forcing.
+func (s *Syncer) syncResource(ctx context.Context, manifest Manifest, mapping resourceMapping, namespace string, opts SyncOptions) (*SyncResult, error) {
 	// Create unstructured object from manifest
 	obj := &unstructured.Unstructured{Object: manifest.Raw}
 
@@ -163,36 +332,31 @@ func (s *Syncer) syncResource(ctx context.Context, manifest Manifest, mapping re
 		Namespace: namespace,
 	}
 
-	var (
-		existing *unstructured.Unstructured
-		err      error
-	)
-	if mapping.ClusterScoped {
-		existing, err = s.dynClient.Resource(mapping.GVR).Get(ctx, manifest.Metadata.Name, metav1.GetOptions{})
-	} else {
-		existing, err = s.dynClient.Resource(mapping.GVR).Namespace(namespace).Get(ctx, manifest.Metadata.Name, metav1.GetOptions{})
+	var resource dynamic.ResourceInterface = s.dynClient.Resource(mapping.GVR)
+	if !mapping.ClusterScoped {
+		resource = s.dynClient.Resource(mapping.GVR).Namespace(namespace)
 	}
+	dryRun := opts.DryRun
 
+	existing, err := resource.Get(ctx, manifest.Metadata.Name, metav1.GetOptions{})
 	if err != nil {
 		// Only proceed with create if resource truly doesn't exist
 		if !apierrors.IsNotFound(err) {
 			return nil, fmt.Errorf("failed to get resource: %w", err)
 		}
+		existing = nil
+	}
 
+	if existing == nil && !opts.ServerSideApply {
 		// Resource doesn't exist - create it
+		var createOpts metav1.CreateOptions
 		if dryRun {
-			result.Action = SyncActionCreated
-			result.Message = "Would create (dry-run)"
-			return result, nil
+			createOpts.DryRun = []string{metav1.DryRunAll}
 		}
-
-		var created *unstructured.Unstructured
-		if mapping.ClusterScoped {
-			created, err = s.dynClient.Resource(mapping.GVR).Create(ctx, obj, metav1.CreateOptions{})
-		} else {
-			created, err = s.dynClient.Resource(mapping.GVR).Namespace(namespace).Create(ctx, obj, metav1.CreateOptions{})
+		created, err := resource.Create(ctx, obj, createOpts)
+		if dryRun {
+			return dryRunCreateResult(result, err)
 		}
-
 		if err != nil {
 			return nil, fmt.Errorf("failed to create: %w", err)
 		}
@@ -202,29 +366,42 @@ func (s *Syncer) syncResource(ctx context.Context, manifest Manifest, mapping re
 		return result, nil
 	}
 
-	// Resource exists - update it using server-side apply
-	// Use server-side apply for updates
+	// Resource exists, or every resource is server-side applied
 	data, err := json.Marshal(obj.Object)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal: %w", err)
 	}
-
-	var updated *unstructured.Unstructured
+	patchOpts := metav1.PatchOptions{
+		FieldManager: kubemanifest.DefaultFieldManager,
+		Force:        boolPtr(true),
+	}
+	if opts.ServerSideApply {
+		if opts.FieldManager != "" {
+			patchOpts.FieldManager = opts.FieldManager
+		}
+		patchOpts.Force = boolPtr(opts.Force)
+	}
 	if dryRun {
 		// Use Kubernetes SSA dry-run mechanism
-		patchOpts := metav1.PatchOptions{
-			FieldManager: "kubestellar-deploy",
-			Force:        boolPtr(true),
-			DryRun:       []string{"All"},
-		}
-		if mapping.ClusterScoped {
-			updated, err = s.dynClient.Resource(mapping.GVR).Patch(ctx, manifest.Metadata.Name,
-				types.ApplyPatchType, data, patchOpts)
-		} else {
-			updated, err = s.dynClient.Resource(mapping.GVR).Namespace(namespace).Patch(ctx, manifest.Metadata.Name,
-				types.ApplyPatchType, data, patchOpts)
-		}
+		patchOpts.DryRun = []string{metav1.DryRunAll}
+	}
+
+	updated, err := resource.Patch(ctx, manifest.Metadata.Name, types.ApplyPatchType, data, patchOpts)
+	if fields, ok := kubemanifest.ApplyConflict(err); ok {
+		result.Action = SyncActionConflict
+		result.Message = kubemanifest.ApplyConflictMessage(fields)
+		return result, nil
+	}
+	if existing == nil && dryRun {
+		return dryRunCreateResult(result, err)
+	}
+	if dryRun {
 		if err != nil {
+			if isRejection(err) {
+				result.Action = SyncActionRejected
+				result.Message = err.Error()
+				return result, nil
+			}
 			return nil, fmt.Errorf("failed dry-run check: %w", err)
 		}
 		if existing.GetResourceVersion() == updated.GetResourceVersion() {
@@ -237,29 +414,22 @@ func (s *Syncer) syncResource(ctx context.Context, manifest Manifest, mapping re
 		return result, nil
 	}
 
-	if mapping.ClusterScoped {
-		updated, err = s.dynClient.Resource(mapping.GVR).Patch(ctx, manifest.Metadata.Name,
-			types.ApplyPatchType, data, metav1.PatchOptions{
-				FieldManager: "kubestellar-deploy",
-				Force:        boolPtr(true),
-			})
-	} else {
-		updated, err = s.dynClient.Resource(mapping.GVR).Namespace(namespace).Patch(ctx, manifest.Metadata.Name,
-			types.ApplyPatchType, data, metav1.PatchOptions{
-				FieldManager: "kubestellar-deploy",
-				Force:        boolPtr(true),
-			})
-	}
-
 	if err != nil {
+		if existing == nil {
+			return nil, fmt.Errorf("failed to create: %w", err)
+		}
 		return nil, fmt.Errorf("failed to update: %w", err)
 	}
 
 	// Check if anything actually changed
-	if existing.GetResourceVersion() == updated.GetResourceVersion() {
+	switch {
+	case existing == nil:
+		result.Action = SyncActionCreated
+		result.Message = fmt.Sprintf("Created %s", updated.GetUID())
+	case existing.GetResourceVersion() == updated.GetResourceVersion():
 		result.Action = SyncActionUnchanged
 		result.Message = "No changes"
-	} else {
+	default:
 		result.Action = SyncActionUpdated
 		result.Message = fmt.Sprintf("Updated (rv: %s -> %s)", existing.GetResourceVersion(), updated.GetResourceVersion())
 	}
@@ -267,6 +437,34 @@ func (s *Syncer) syncResource(ctx context.Context, manifest Manifest, mapping re
 	return result, nil
 }
 
+// dryRunCreateResult is the result of a dry run creating a resource that
+// does not exist, given the error of the dry-run request.
+func dryRunCreateResult(result *SyncResult, err error) (*SyncResult, error) {
+	switch {
+	case err == nil:
+		result.Action = SyncActionCreated
+		result.Message = "Would create (dry-run)"
+	case apierrors.IsNotFound(err):
+		// Its namespace or CRD is created by this sync, and does
+		// not exist in a dry run.
+		result.Action = SyncActionCreated
+		result.Message = "Would create (dry-run; not validated before its namespace or CRD exists)"
+	case isRejection(err):
+		result.Action = SyncActionRejected
+		result.Message = err.Error()
+	default:
+		return nil, fmt.Errorf("failed dry-run check: %w", err)
+	}
+	return result, nil
+}
+
+// isRejection reports whether err is the API server refusing a request:
+// an admission webhook or plugin, such as Pod Security or a quota, denying
+// it, the object failing validation, or the caller lacking permission.
+func isRejection(err error) bool {
+	return apierrors.IsForbidden(err) || apierrors.IsInvalid(err) || apierrors.IsBadRequest(err)
+}
+
 // shouldSync checks if a kind should be synced
 func (s *Syncer) shouldSync(kind string, opts SyncOptions) bool {
 	// Check excludes first
@@ -289,9 +487,22 @@ func (s *Syncer) shouldSync(kind string, opts SyncOptions) bool {
 	return true
 }
 
-// getGVR returns the GroupVersionResource for a manifest.
-func (s *Syncer) getGVR(manifest Manifest) (schema.GroupVersionResource, error) {
+// resolve returns the resource of manifest, discovering the API again once
+// when the RESTMapper does not know its kind.
+func (s *Syncer) resolve(manifest Manifest) (resourceMapping, error) {
 	mapping, err := resolveManifestResource(manifest, s.restMapper)
+	if err == nil && mapping.Guessed && s.restMapper != nil && s.config != nil && !s.rediscovered {
+		s.rediscovered = true
+		s.restMapper = rediscoverRESTMapper(s.config)
+		mapping, err = resolveManifestResource(manifest, s.restMapper)
+	}
+	return mapping, err
+}
+
+// getGVR returns the GroupVersionResource for a manifest, discovering the
+// API again once if its kind is not known.
+func (s *Syncer) getGVR(manifest Manifest) (schema.GroupVersionResource, error) {
+	mapping, err := s.resolve(manifest)
 	if err != nil {
 		return schema.GroupVersionResource{}, err
 	}
@@ -3,8 +3,11 @@ package gitops
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"strings"
 	"testing"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -194,6 +197,95 @@ func TestSyncDryRunUsesSSAPatchAndDetectsUnchangedResources(t *testing.T) {
 	}
 }
 
+func TestSyncDryRunReportsAdmissionRejections(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	configMaps := schema.GroupResource{Resource: "configmaps"}
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		create := action.(k8stesting.CreateActionImpl)
+		if dryRun := create.CreateOptions.DryRun; len(dryRun) != 1 || dryRun[0] != metav1.DryRunAll {
+			t.Fatalf("dry run options = %#v, want []string{\"All\"}", dryRun)
+		}
+		switch create.GetNamespace() {
+		case "locked":
+			return true, nil, apierrors.NewForbidden(configMaps, "denied",
+				errors.New(`admission webhook "validation.gatekeeper.sh" denied the request: [must-have-owner] missing label owner`))
+		case "new":
+			return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "new")
+		}
+		return false, nil, nil
+	})
+	syncer := &Syncer{dynClient: client}
+	manifests := []Manifest{
+		testManifest("v1", "ConfigMap", "denied", "locked"),
+		testManifest("v1", "ConfigMap", "pending", "new"),
+		testManifest("v1", "ConfigMap", "allowed", "apps"),
+	}
+
+	summary, err := syncer.Sync(context.Background(), manifests, "alpha", SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if summary.Rejected != 1 || summary.Created != 2 || summary.Failed != 0 {
+		t.Fatalf("unexpected summary counts: %#v", summary)
+	}
+	if got := summary.Results[0]; got.Action != SyncActionRejected || !strings.Contains(got.Message, "[must-have-owner] missing label owner") {
+		t.Fatalf("result = %+v, want the webhook's rejection", got)
+	}
+	if got := summary.Results[1]; got.Action != SyncActionCreated || !strings.Contains(got.Message, "not validated") {
+		t.Fatalf("result = %+v, want an unvalidated create", got)
+	}
+}
+
+func TestSyncServerSideApplyReportsConflicts(t *testing.T) {
+	existing := testManifestObject("ConfigMap", "owned", "apps", "1")
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), existing)
+	var patched []string
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		t.Fatalf("server-side apply created %v instead of applying it", action)
+		return true, nil, nil
+	})
+	client.PrependReactor("patch", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patchAction := action.(k8stesting.PatchAction)
+		options := action.(interface{ GetPatchOptions() metav1.PatchOptions }).GetPatchOptions()
+		if patchAction.GetPatchType() != types.ApplyPatchType || options.FieldManager != "team-a" || options.Force == nil || *options.Force {
+			t.Fatalf("patch = %v as %q with force %v, want an unforced apply as team-a", patchAction.GetPatchType(), options.FieldManager, options.Force)
+		}
+		patched = append(patched, patchAction.GetName())
+		if patchAction.GetName() == "owned" {
+			return true, nil, apierrors.NewApplyConflict([]metav1.StatusCause{{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "kubectl" using v1`,
+				Field:   ".data.mode",
+			}}, "Apply failed with 1 conflict")
+		}
+		var raw map[string]interface{}
+		if err := json.Unmarshal(patchAction.GetPatch(), &raw); err != nil {
+			t.Fatalf("json.Unmarshal() error = %v", err)
+		}
+		created := &unstructured.Unstructured{Object: raw}
+		created.SetUID("uid-1")
+		return true, created, nil
+	})
+
+	manifests := []Manifest{testManifest("v1", "ConfigMap", "owned", "apps"), testManifest("v1", "ConfigMap", "new", "apps")}
+	summary, err := (&Syncer{dynClient: client}).Sync(context.Background(), manifests, "alpha", SyncOptions{ServerSideApply: true, FieldManager: "team-a"})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if summary.Conflicts != 1 || summary.Created != 1 || summary.Failed != 0 {
+		t.Fatalf("unexpected summary counts: %#v", summary)
+	}
+	if strings.Join(patched, ",") != "owned,new" {
+		t.Fatalf("patched = %v, want owned and new", patched)
+	}
+	if r := summary.Results[0]; r.Action != SyncActionConflict || !strings.Contains(r.Message, `.data.mode (conflict with "kubectl" using v1)`) {
+		t.Fatalf("unexpected conflict result: %#v", r)
+	}
+	if r := summary.Results[1]; r.Action != SyncActionCreated || r.Message != "Created uid-1" {
+		t.Fatalf("unexpected created result: %#v", r)
+	}
+}
+
 func TestShouldSyncHonorsIncludeAndExclude(t *testing.T) {
 	syncer := &Syncer{}
 	if syncer.shouldSync("Secret", SyncOptions{Exclude: []string{"Secret"}}) {
@@ -264,6 +356,85 @@ func TestSyncIgnoresNamespaceOverrideForClusterScopedMapping(t *testing.T) {
 	}
 }
 
+func TestSyncRefusesKindsDiscoveryDoesNotKnow(t *testing.T) {
+	syncer := &Syncer{
+		dynClient:  dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		restMapper: meta.NewDefaultRESTMapper(nil),
+	}
+
+	summary, err := syncer.Sync(context.Background(), []Manifest{testManifest("example.io/v1", "ClusterWidget", "w", "")}, "alpha", SyncOptions{})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if summary.Failed != 1 || !strings.Contains(summary.Results[0].Message, "unknown resource kind: ClusterWidget") {
+		t.Fatalf("Sync() results = %+v, want the unknown kind to fail", summary.Results)
+	}
+}
+
+func TestSyncDryRunResolvesKindsOfCRDsInTheSync(t *testing.T) {
+	syncer := &Syncer{
+		dynClient:  dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+		restMapper: meta.NewDefaultRESTMapper(nil),
+	}
+	crd := testManifest("apiextensions.k8s.io/v1", "CustomResourceDefinition", "clusterwidgets.example.io", "")
+	crd.Raw["spec"] = map[string]interface{}{
+		"group":    "example.io",
+		"scope":    "Cluster",
+		"names":    map[string]interface{}{"kind": "ClusterWidget", "plural": "clusterwidgets"},
+		"versions": []interface{}{map[string]interface{}{"name": "v1"}},
+	}
+
+	summary, err := syncer.Sync(context.Background(), []Manifest{crd, testManifest("example.io/v1", "ClusterWidget", "w", "team")}, "alpha", SyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if summary.Failed != 0 {
+		t.Fatalf("Sync() results = %+v, want no failures", summary.Results)
+	}
+	if got := summary.Results[1]; got.Kind != "ClusterWidget" || got.Namespace != "" {
+		t.Fatalf("result = %+v, want the cluster-scoped widget without a namespace", got)
+	}
+}
+
+func TestSyncOrdersNamespacesAndCRDsAndCreatesNamespaces(t *testing.T) {
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	crd := testManifest("apiextensions.k8s.io/v1", "CustomResourceDefinition", "widgets.example.io", "")
+	crd.Raw["status"] = map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "Established", "status": "True"},
+	}}
+	manifests := []Manifest{
+		testManifest("example.io/v1", "Widget", "w", "team"),
+		testManifest("v1", "ConfigMap", "c", "extra"),
+		crd,
+		testManifest("v1", "Namespace", "team", ""),
+	}
+
+	summary, err := (&Syncer{dynClient: client}).Sync(context.Background(), manifests, "alpha", SyncOptions{CreateNamespaces: true})
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	var got []string
+	for _, r := range summary.Results {
+		got = append(got, r.Kind+"/"+r.Name+":"+string(r.Action))
+	}
+	want := []string{
+		"Namespace/team:created",
+		"CustomResourceDefinition/widgets.example.io:created",
+		"Widget/w:created",
+		"Namespace/extra:created",
+		"ConfigMap/c:created",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("results = %v, want %v", got, want)
+	}
+	if summary.Created != 5 || summary.Failed != 0 {
+		t.Fatalf("unexpected summary counts: %#v", summary)
+	}
+	if _, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).Get(context.Background(), "extra", metav1.GetOptions{}); err != nil {
+		t.Fatalf("namespace extra was not created: %v", err)
+	}
+}
+
 func testManifest(apiVersion, kind, name, namespace string) Manifest {
 	raw := map[string]interface{}{
 		"apiVersion": apiVersion,
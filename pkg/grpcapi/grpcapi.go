@@ -0,0 +1,259 @@
+// Package grpcapi serves the kubestellar-ops tools over gRPC, for
+// automation that wants to call them without MCP framing. The services are
+// declared in ops.proto. Their messages are google.protobuf.Struct, so the
+// service descriptors below are written by hand rather than generated;
+// clients generate stubs from ops.proto as usual.
+//
+// Every RPC runs its tool through pkg/ops on the MCP server, sharing its
+// handlers, clients, caches, policies, approval gate, redaction, and audit
+// log.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/ops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/toolerror"
+)
+
+// protoPackage is the package of the services in ops.proto.
+const protoPackage = "kubestellar.ops.v1"
+
+// method is an RPC that runs one tool.
+type method struct {
+	name, tool string
+}
+
+// services are the tool families of ops.proto, and the tool each RPC runs.
+var services = []struct {
+	name    string
+	methods []method
+}{
+	{"Diagnostics", []method{
+		{"FindPodIssues", "find_pod_issues"},
+		{"FindDeploymentIssues", "find_deployment_issues"},
+		{"CheckResourceLimits", "check_resource_limits"},
+		{"CheckSecurityIssues", "check_security_issues"},
+		{"AnalyzeNamespace", "analyze_namespace"},
+		{"GetWarningEvents", "get_warning_events"},
+	}},
+	{"Upgrades", []method{
+		{"DetectClusterType", "detect_cluster_type"},
+		{"GetClusterVersionInfo", "get_cluster_version_info"},
+		{"GetUpgradePrerequisites", "get_upgrade_prerequisites"},
+		{"GetUpgradeStatus", "get_upgrade_status"},
+		{"SimulateUpgradeImpact", "simulate_upgrade_impact"},
+		{"CheckHelmReleaseUpgrades", "check_helm_release_upgrades"},
+		{"CheckOLMOperatorUpgrades", "check_olm_operator_upgrades"},
+		{"TriggerOpenShiftUpgrade", "trigger_openshift_upgrade"},
+		{"PauseMCP", "pause_mcp"},
+		{"UnpauseMCP", "unpause_mcp"},
+	}},
+	{"Policy", []method{
+		{"CheckGatekeeper", "check_gatekeeper"},
+		{"GetOwnershipPolicyStatus", "get_ownership_policy_status"},
+		{"ListOwnershipViolations", "list_ownership_violations"},
+		{"InstallOwnershipPolicy", "install_ownership_policy"},
+		{"SetOwnershipPolicyMode", "set_ownership_policy_mode"},
+		{"UninstallOwnershipPolicy", "uninstall_ownership_policy"},
+	}},
+}
+
+// handler runs the RPCs of every service.
+type handler struct {
+	client *ops.Client
+}
+
+// Register registers the services of ops.proto on r, running tools on s.
+func Register(r grpc.ServiceRegistrar, s *server.Server) {
+	h := &handler{client: ops.NewForServer(s)}
+	for _, svc := range services {
+		desc := &grpc.ServiceDesc{
+			ServiceName: protoPackage + "." + svc.name,
+			HandlerType: (*interface{})(nil),
+			Metadata:    "ops.proto",
+		}
+		for _, m := range svc.methods {
+			desc.Methods = append(desc.Methods, unaryMethod(desc.ServiceName, m.name, func(ctx context.Context, args *structpb.Struct) (*structpb.Struct, error) {
+				return h.call(ctx, m.tool, args.AsMap())
+			}))
+		}
+		r.RegisterService(desc, h)
+	}
+	r.RegisterService(&grpc.ServiceDesc{
+		ServiceName: protoPackage + ".Tools",
+		HandlerType: (*interface{})(nil),
+		Methods:     []grpc.MethodDesc{unaryMethod(protoPackage+".Tools", "Call", h.callByName)},
+		Metadata:    "ops.proto",
+	}, h)
+}
+
+// NewServer returns a gRPC server for the services of ops.proto that
+// authenticates callers with s's token verifier, if it has one.
+func NewServer(s *server.Server) *grpc.Server {
+	gs := grpc.NewServer(grpc.UnaryInterceptor(authenticate(s.TokenVerifier())))
+	Register(gs, s)
+	return gs
+}
+
+// Serve serves the gRPC API on addr until ctx is done. Without a token
+// verifier it only listens on loopback addresses, since every caller would
+// act with the server's own credentials.
+func Serve(ctx context.Context, addr string, s *server.Server) error {
+	if s.TokenVerifier() == nil && !server.IsLoopback(addr) {
+		return fmt.Errorf("refusing to serve unauthenticated gRPC on %s: configure OIDC or listen on a loopback address", addr)
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	gs := NewServer(s)
+	errCh := make(chan error, 1)
+	go func() { errCh <- gs.Serve(lis) }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		gs.GracefulStop()
+		return nil
+	}
+}
+
+// unaryMethod returns the descriptor of an RPC that takes and returns a
+// Struct, as protoc-gen-go-grpc would generate it.
+func unaryMethod(service, name string, call func(context.Context, *structpb.Struct) (*structpb.Struct, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(structpb.Struct)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + service + "/" + name}
+			return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(ctx, req.(*structpb.Struct))
+			})
+		},
+	}
+}
+
+// callByName runs Tools.Call.
+func (h *handler) callByName(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	tool := req.GetFields()["tool"].GetStringValue()
+	if tool == "" {
+		return nil, status.Error(codes.InvalidArgument, "tool is required")
+	}
+	return h.call(ctx, tool, req.GetFields()["arguments"].GetStructValue().AsMap())
+}
+
+// call runs tool and converts its result or error.
+func (h *handler) call(ctx context.Context, tool string, args map[string]interface{}) (*structpb.Struct, error) {
+	result, err := h.client.Call(ctx, tool, args)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp, err := toStruct(map[string]interface{}{"tool": result.Tool, "text": result.Text, "meta": result.Meta})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode result of %s: %v", tool, err)
+	}
+	return resp, nil
+}
+
+// grpcCodes maps tool error codes to gRPC status codes.
+var grpcCodes = map[toolerror.Code]codes.Code{
+	toolerror.InvalidArgument:  codes.InvalidArgument,
+	toolerror.NotFound:         codes.NotFound,
+	toolerror.UnknownCluster:   codes.NotFound,
+	toolerror.AlreadyExists:    codes.AlreadyExists,
+	toolerror.Conflict:         codes.Aborted,
+	toolerror.Unauthenticated:  codes.Unauthenticated,
+	toolerror.PermissionDenied: codes.PermissionDenied,
+	toolerror.PolicyDenied:     codes.PermissionDenied,
+	toolerror.ApprovalRequired: codes.FailedPrecondition,
+	toolerror.Unavailable:      codes.Unavailable,
+	toolerror.Throttled:        codes.ResourceExhausted,
+	toolerror.Internal:         codes.Internal,
+}
+
+// toStatus returns the gRPC status of a failed call, with the tool error as
+// a Struct detail.
+func toStatus(err error) error {
+	var e *toolerror.Error
+	if !errors.As(err, &e) {
+		// Only an unknown tool fails without a tool error.
+		return status.Error(codes.NotFound, err.Error())
+	}
+	code, ok := grpcCodes[e.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	st := status.New(code, e.Message)
+	if detail, err := toStruct(e); err == nil {
+		if withDetail, err := st.WithDetails(detail); err == nil {
+			st = withDetail
+		}
+	}
+	return st.Err()
+}
+
+// toStruct converts v to a Struct through its JSON form, which also turns
+// typed metadata values into plain JSON values.
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return structpb.NewStruct(m)
+}
+
+// authenticate returns an interceptor that runs each call as the caller
+// its bearer token identifies, or, without a verifier, as the server.
+func authenticate(verifier server.TokenVerifier) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (interface{}, error) {
+		if verifier == nil {
+			return next(server.WithRemoteCaller(ctx, nil), req)
+		}
+		token, ok := bearerToken(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "bearer token required")
+		}
+		id, err := verifier.Verify(ctx, token)
+		if err != nil {
+			log.Printf("Rejected gRPC request: %v", err)
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+		return next(server.WithRemoteCaller(ctx, &id), req)
+	}
+}
+
+// bearerToken returns the token from the authorization metadata.
+func bearerToken(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		scheme, token, ok := strings.Cut(value, " ")
+		if ok && strings.EqualFold(scheme, "Bearer") && token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,175 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+)
+
+// TestServicesMatchProto checks that the hand-written service descriptors
+// serve exactly the RPCs of ops.proto, and that each runs a registered tool.
+func TestServicesMatchProto(t *testing.T) {
+	data, err := os.ReadFile("ops.proto")
+	require.NoError(t, err)
+	declared := make(map[string][]string)
+	var current string
+	for _, m := range regexp.MustCompile(`(?m)^service (\w+)|^\s+rpc (\w+)\(`).FindAllStringSubmatch(string(data), -1) {
+		if m[1] != "" {
+			current = m[1]
+			continue
+		}
+		declared[current] = append(declared[current], m[2])
+	}
+
+	served := map[string][]string{"Tools": {"Call"}}
+	for _, svc := range services {
+		for _, m := range svc.methods {
+			served[svc.name] = append(served[svc.name], m.name)
+			_, ok := server.ToolSchema(m.tool)
+			assert.True(t, ok, "%s.%s runs unregistered tool %s", svc.name, m.name, m.tool)
+		}
+	}
+	assert.Equal(t, declared, served)
+}
+
+// staticVerifier accepts one token.
+type staticVerifier struct {
+	token string
+}
+
+func (v staticVerifier) Verify(_ context.Context, token string) (auth.Identity, error) {
+	if token != v.token {
+		return auth.Identity{}, errors.New("unknown token")
+	}
+	return auth.Identity{User: "alice", Groups: []string{"ops"}}, nil
+}
+
+// dial serves the gRPC API for a cluster whose API server has a namespace
+// "shop" without pods, and returns a connection to it.
+func dial(t *testing.T, verifier server.TokenVerifier) *grpc.ClientConn {
+	t.Helper()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/namespaces/shop/pods" {
+			_, _ = w.Write([]byte(`{"kind":"PodList","apiVersion":"v1","metadata":{},"items":[]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+	}))
+	t.Cleanup(api.Close)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster: {server: `+api.URL+`}
+contexts:
+- name: test
+  context: {cluster: test, user: test}
+current-context: test
+users:
+- name: test
+  user: {}
+`), 0o600))
+
+	s := server.NewServer(kubeconfig)
+	if verifier != nil {
+		s.SetTokenVerifier(verifier)
+	}
+	lis := bufconn.Listen(1 << 20)
+	gs := NewServer(s)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func invoke(ctx context.Context, conn *grpc.ClientConn, method string, req map[string]interface{}) (*structpb.Struct, error) {
+	in, err := structpb.NewStruct(req)
+	if err != nil {
+		return nil, err
+	}
+	out := new(structpb.Struct)
+	return out, conn.Invoke(ctx, "/kubestellar.ops.v1."+method, in, out)
+}
+
+func TestRPCRunsTool(t *testing.T) {
+	conn := dial(t, nil)
+
+	out, err := invoke(context.Background(), conn, "Diagnostics/FindPodIssues", map[string]interface{}{"namespace": "shop"})
+	require.NoError(t, err)
+	assert.Equal(t, "find_pod_issues", out.GetFields()["tool"].GetStringValue())
+	assert.NotEmpty(t, out.GetFields()["text"].GetStringValue())
+
+	out, err = invoke(context.Background(), conn, "Tools/Call", map[string]interface{}{
+		"tool":      "find_pod_issues",
+		"arguments": map[string]interface{}{"namespace": "shop"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "find_pod_issues", out.GetFields()["tool"].GetStringValue())
+}
+
+func TestToolErrorsBecomeStatuses(t *testing.T) {
+	conn := dial(t, nil)
+
+	_, err := invoke(context.Background(), conn, "Tools/Call", map[string]interface{}{"tool": "no_such_tool"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = invoke(context.Background(), conn, "Tools/Call", map[string]interface{}{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = invoke(context.Background(), conn, "Diagnostics/FindPodIssues", map[string]interface{}{"cluster": "missing"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	require.Len(t, st.Details(), 1)
+	detail, ok := st.Details()[0].(*structpb.Struct)
+	require.True(t, ok)
+	assert.Equal(t, "unknown_cluster", detail.GetFields()["code"].GetStringValue())
+}
+
+func TestCallsRequireBearerTokenWithVerifier(t *testing.T) {
+	conn := dial(t, staticVerifier{token: "secret"})
+	args := map[string]interface{}{"namespace": "shop"}
+
+	_, err := invoke(context.Background(), conn, "Diagnostics/FindPodIssues", args)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer wrong")
+	_, err = invoke(ctx, conn, "Diagnostics/FindPodIssues", args)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	_, err = invoke(ctx, conn, "Diagnostics/FindPodIssues", args)
+	require.NoError(t, err)
+}
+
+func TestServeRefusesUnauthenticatedNonLoopback(t *testing.T) {
+	err := Serve(context.Background(), "0.0.0.0:0", server.NewServer(""))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "configure OIDC")
+}
@@ -0,0 +1,283 @@
+// Package guardrail enforces server-wide limits on what the MCP tools may
+// touch. Limits are applied to rest.Configs as a transport wrapper, so every
+// client built from a wrapped config inherits them regardless of which tool
+// issued the request.
+package guardrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/kubemanifest"
+)
+
+// DefaultProtectedNamespaces are closed to mutations unless the operator
+// overrides the protected list.
+var DefaultProtectedNamespaces = []string{"kube-system", "kube-public", "kube-node-lease", "openshift", "openshift-*"}
+
+// reviewGroups hold the cluster-scoped "create to ask" APIs (access and
+// token reviews). Creating one changes nothing, so they are always allowed.
+var reviewGroups = map[string]bool{
+	"authorization.k8s.io":  true,
+	"authentication.k8s.io": true,
+}
+
+// NamespacePolicy restricts which namespaces tools may operate on. Patterns
+// use path.Match syntax (e.g. "team-*").
+type NamespacePolicy struct {
+	// Allowed lists the namespaces tools may read or change. Empty means
+	// every namespace. When set, cluster-scoped mutations and reads of
+	// namespaced resources across all namespaces are denied too, since they
+	// cannot be attributed to an allowed namespace.
+	Allowed []string
+	// Protected lists namespaces that may be read but never changed.
+	Protected []string
+}
+
+// Environment variables that configure the namespace policy. Both take a
+// comma-separated list of namespace patterns. Setting the protected list to
+// an empty value turns the system-namespace protection off.
+const (
+	EnvAllowedNamespaces   = "KUBESTELLAR_ALLOWED_NAMESPACES"
+	EnvProtectedNamespaces = "KUBESTELLAR_PROTECTED_NAMESPACES"
+)
+
+// NamespacePolicyFromEnv returns DefaultNamespacePolicy adjusted by
+// $KUBESTELLAR_ALLOWED_NAMESPACES and $KUBESTELLAR_PROTECTED_NAMESPACES.
+func NamespacePolicyFromEnv() NamespacePolicy {
+	p := DefaultNamespacePolicy()
+	if v, ok := os.LookupEnv(EnvAllowedNamespaces); ok {
+		p.Allowed = splitList(v)
+	}
+	if v, ok := os.LookupEnv(EnvProtectedNamespaces); ok {
+		p.Protected = splitList(v)
+	}
+	return p
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// DefaultNamespacePolicy allows every namespace and protects the system
+// namespaces from mutations.
+func DefaultNamespacePolicy() NamespacePolicy {
+	return NamespacePolicy{Protected: append([]string(nil), DefaultProtectedNamespaces...)}
+}
+
+// IsZero reports whether the policy imposes no restrictions.
+func (p NamespacePolicy) IsZero() bool {
+	return len(p.Allowed) == 0 && len(p.Protected) == 0
+}
+
+// CheckNamespace returns an error if the policy forbids the operation on
+// namespace. mutate reports whether the operation changes state. An empty
+// namespace means a cluster-scoped resource; callers that list a namespaced
+// resource across all namespaces must check the allowlist themselves.
+func (p NamespacePolicy) CheckNamespace(namespace string, mutate bool) error {
+	if namespace == "" {
+		if mutate && len(p.Allowed) > 0 {
+			return fmt.Errorf("cluster-scoped changes are not allowed when operations are restricted to namespaces %s", strings.Join(p.Allowed, ", "))
+		}
+		return nil
+	}
+	if len(p.Allowed) > 0 && !MatchesAny(namespace, p.Allowed) {
+		return fmt.Errorf("namespace %q is outside the allowed namespaces (%s)", namespace, strings.Join(p.Allowed, ", "))
+	}
+	if mutate && MatchesAny(namespace, p.Protected) {
+		return fmt.Errorf("namespace %q is protected from changes", namespace)
+	}
+	return nil
+}
+
+// Wrap returns a copy of config whose transport enforces the policy.
+// Denied requests fail with a Forbidden API error before reaching the
+// API server.
+func (p NamespacePolicy) Wrap(config *rest.Config) *rest.Config {
+	if p.IsZero() {
+		return config
+	}
+	mapper := sharedMapper(rest.CopyConfig(config))
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &namespaceGuard{policy: p, mapper: mapper, next: rt}
+	})
+	return config
+}
+
+// sharedMapper returns the cluster's RESTMapper from the discovery cache
+// shared with the apply and drift tools, so guarded clients do not
+// discover the API each time they are built. rediscover refreshes it.
+func sharedMapper(config *rest.Config) func(rediscover bool) meta.RESTMapper {
+	return func(rediscover bool) meta.RESTMapper {
+		if rediscover {
+			return kubemanifest.Rediscover(config)
+		}
+		return kubemanifest.NewRESTMapper(config)
+	}
+}
+
+type namespaceGuard struct {
+	policy NamespacePolicy
+	// mapper tells namespaced resources from cluster-scoped ones. It
+	// returns nil when the API cannot be discovered.
+	mapper func(rediscover bool) meta.RESTMapper
+	next   http.RoundTripper
+}
+
+func (g *namespaceGuard) RoundTrip(req *http.Request) (*http.Response, error) {
+	target := parseRequestPath(req.URL.Path)
+	mutate := isMutation(req.Method) && !reviewGroups[target.group]
+	err := g.policy.CheckNamespace(target.namespace, mutate)
+	if err == nil && target.namespace == "" && !isMutation(req.Method) {
+		err = g.checkAllNamespacesRead(target)
+	}
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return forbidden(req, target, err), nil
+	}
+	return g.next.RoundTrip(req)
+}
+
+// checkAllNamespacesRead denies a namespace-less read of a namespaced
+// resource, such as GET /api/v1/pods, when the policy has an allowlist:
+// the API server would answer with objects from every namespace. Reads of
+// cluster-scoped resources such as nodes pass. A resource whose scope
+// cannot be determined is denied.
+func (g *namespaceGuard) checkAllNamespacesRead(t requestTarget) error {
+	if len(g.policy.Allowed) == 0 || t.resource == "" {
+		return nil
+	}
+	allowed := strings.Join(g.policy.Allowed, ", ")
+	namespaced, err := g.namespaced(t)
+	if err != nil {
+		return fmt.Errorf("cannot tell whether %s is namespaced, so it cannot be restricted to namespaces %s: %w", t.resource, allowed, err)
+	}
+	if namespaced {
+		return fmt.Errorf("reading %s across all namespaces is not allowed when operations are restricted to namespaces %s; specify a namespace", t.resource, allowed)
+	}
+	return nil
+}
+
+// namespaced reports whether the resource of t is namespaced. A resource
+// the mapper does not know is looked up once more after discovering the API
+// again, since its CRD may have been installed since.
+func (g *namespaceGuard) namespaced(t requestTarget) (bool, error) {
+	namespaced, err := resourceNamespaced(g.mapper(false), t)
+	if meta.IsNoMatchError(err) {
+		namespaced, err = resourceNamespaced(g.mapper(true), t)
+	}
+	return namespaced, err
+}
+
+func resourceNamespaced(mapper meta.RESTMapper, t requestTarget) (bool, error) {
+	if mapper == nil {
+		return false, fmt.Errorf("the API server's resources could not be discovered")
+	}
+	gvk, err := mapper.KindFor(schema.GroupVersionResource{Group: t.group, Version: t.version, Resource: t.resource})
+	if err != nil {
+		return false, err
+	}
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+type requestTarget struct {
+	group     string
+	version   string
+	resource  string
+	name      string
+	namespace string
+}
+
+// parseRequestPath extracts the API group, version, resource, and namespace
+// from a Kubernetes API path such as /api/v1/namespaces/ns/pods/name or
+// /apis/apps/v1/namespaces/ns/deployments. Requests for a Namespace object
+// itself report that namespace. The deprecated /watch/ prefix is skipped.
+func parseRequestPath(urlPath string) requestTarget {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	var t requestTarget
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		t.version = parts[1]
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		t.group, t.version = parts[1], parts[2]
+		parts = parts[3:]
+	default:
+		return t
+	}
+	if len(parts) > 0 && parts[0] == "watch" {
+		parts = parts[1:]
+	}
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		t.namespace = parts[1]
+		if len(parts) == 2 {
+			t.resource, t.name = "namespaces", parts[1]
+			return t
+		}
+		parts = parts[2:]
+	}
+	if len(parts) > 0 {
+		t.resource = parts[0]
+	}
+	if len(parts) > 1 {
+		t.name = parts[1]
+	}
+	return t
+}
+
+func forbidden(req *http.Request, t requestTarget, reason error) *http.Response {
+	status := apierrors.NewForbidden(schema.GroupResource{Group: t.group, Resource: t.resource}, t.name, reason).Status()
+	status.APIVersion, status.Kind = "v1", "Status"
+	body, _ := json.Marshal(&status)
+	return &http.Response{
+		StatusCode:    http.StatusForbidden,
+		Status:        fmt.Sprintf("%d %s", http.StatusForbidden, http.StatusText(http.StatusForbidden)),
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// MatchesAny reports whether name matches any of the path.Match glob
+// patterns. Malformed patterns match nothing.
+func MatchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package guardrail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestCheckNamespace(t *testing.T) {
+	defaults := DefaultNamespacePolicy()
+	scoped := NamespacePolicy{Allowed: []string{"team-*", "shared"}, Protected: DefaultProtectedNamespaces}
+
+	tests := []struct {
+		name      string
+		policy    NamespacePolicy
+		namespace string
+		mutate    bool
+		wantErr   bool
+	}{
+		{"default allows reads of kube-system", defaults, "kube-system", false, false},
+		{"default denies kube-system mutations", defaults, "kube-system", true, true},
+		{"default denies openshift-* mutations", defaults, "openshift-monitoring", true, true},
+		{"default allows app mutations", defaults, "payments", true, false},
+		{"default allows cluster-scoped mutations", defaults, "", true, false},
+		{"allowlist permits matching namespace", scoped, "team-a", true, false},
+		{"allowlist denies reads elsewhere", scoped, "payments", false, true},
+		{"allowlist denies cluster-scoped mutations", scoped, "", true, true},
+		{"zero policy allows everything", NamespacePolicy{}, "kube-system", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.CheckNamespace(tt.namespace, tt.mutate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckNamespace(%q, %v) error = %v, wantErr %v", tt.namespace, tt.mutate, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRequestPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want requestTarget
+	}{
+		{"/api/v1/namespaces/default/pods/web", requestTarget{version: "v1", resource: "pods", name: "web", namespace: "default"}},
+		{"/apis/apps/v1/namespaces/prod/deployments", requestTarget{group: "apps", version: "v1", resource: "deployments", namespace: "prod"}},
+		{"/api/v1/namespaces/kube-system", requestTarget{version: "v1", resource: "namespaces", name: "kube-system", namespace: "kube-system"}},
+		{"/api/v1/namespaces", requestTarget{version: "v1", resource: "namespaces"}},
+		{"/api/v1/nodes/n1", requestTarget{version: "v1", resource: "nodes", name: "n1"}},
+		{"/api/v1/watch/pods", requestTarget{version: "v1", resource: "pods"}},
+		{"/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", requestTarget{group: "authorization.k8s.io", version: "v1", resource: "selfsubjectaccessreviews"}},
+		{"/version", requestTarget{}},
+	}
+	for _, tt := range tests {
+		if got := parseRequestPath(tt.path); got != tt.want {
+			t.Errorf("parseRequestPath(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWrapDeniesRequestsBeforeTheyReachTheServer(t *testing.T) {
+	var reached int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&reached, 1)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"kind":"PodList","apiVersion":"v1","items":[]}`))
+		default:
+			_, _ = w.Write([]byte(`{"kind":"ConfigMap","apiVersion":"v1","metadata":{"name":"x"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultNamespacePolicy().Wrap(&rest.Config{Host: srv.URL})
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatalf("NewForConfig() error = %v", err)
+	}
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "x"}}
+
+	if _, err := client.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{}); err != nil {
+		t.Fatalf("reads of kube-system should pass: %v", err)
+	}
+	if _, err := client.CoreV1().ConfigMaps("apps").Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("mutations outside protected namespaces should pass: %v", err)
+	}
+	before := atomic.LoadInt32(&reached)
+
+	_, err = client.CoreV1().ConfigMaps("kube-system").Create(ctx, cm, metav1.CreateOptions{})
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("create in kube-system error = %v, want Forbidden", err)
+	}
+	err = client.CoreV1().Namespaces().Delete(ctx, "openshift-etcd", metav1.DeleteOptions{})
+	if !apierrors.IsForbidden(err) {
+		t.Fatalf("delete of openshift-etcd error = %v, want Forbidden", err)
+	}
+	if got := atomic.LoadInt32(&reached); got != before {
+		t.Fatalf("denied requests reached the API server (%d calls)", got-before)
+	}
+}
+
+func TestGuardDeniesAllNamespaceReadsOfNamespacedResources(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+
+	var reached int32
+	next := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&reached, 1)
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+	})
+	// Discovering the API again finds a CRD installed since.
+	rediscovered := meta.NewDefaultRESTMapper(nil)
+	rediscovered.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	rediscovered.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Gadget"}, meta.RESTScopeRoot)
+	rediscoveries := 0
+	guard := &namespaceGuard{
+		policy: NamespacePolicy{Allowed: []string{"team-*"}},
+		mapper: func(rediscover bool) meta.RESTMapper {
+			if rediscover {
+				rediscoveries++
+				return rediscovered
+			}
+			return mapper
+		},
+		next: next,
+	}
+
+	tests := []struct {
+		path   string
+		denied bool
+	}{
+		{"/api/v1/pods", true},
+		{"/api/v1/secrets", true},
+		{"/api/v1/watch/pods", true},
+		{"/apis/apps/v1/deployments", true},
+		{"/apis/example.com/v1/widgets", true},
+		{"/apis/example.com/v1/gadgets", false},
+		{"/api/v1/nodes", false},
+		{"/api/v1/nodes/n1", false},
+		{"/api/v1/namespaces", false},
+		{"/api/v1/namespaces/team-a/pods", false},
+		{"/version", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			before := atomic.LoadInt32(&reached)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			resp, err := guard.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip() error = %v", err)
+			}
+			if denied := resp.StatusCode == http.StatusForbidden; denied != tt.denied {
+				t.Fatalf("GET %s: status %d, want denied=%v", tt.path, resp.StatusCode, tt.denied)
+			}
+			if forwarded := atomic.LoadInt32(&reached) != before; forwarded == tt.denied {
+				t.Fatalf("GET %s: forwarded = %v, want %v", tt.path, forwarded, !tt.denied)
+			}
+		})
+	}
+	if rediscoveries != 2 {
+		t.Fatalf("rediscoveries = %d, want one for each unknown resource", rediscoveries)
+	}
+}
+
+func TestWrapLooksUpResourceScopeWithDiscovery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api":
+			_, _ = w.Write([]byte(`{"kind":"APIVersions","versions":["v1"]}`))
+		case "/apis":
+			_, _ = w.Write([]byte(`{"kind":"APIGroupList","apiVersion":"v1","groups":[]}`))
+		case "/api/v1":
+			_, _ = w.Write([]byte(`{"kind":"APIResourceList","groupVersion":"v1","resources":[` +
+				`{"name":"pods","namespaced":true,"kind":"Pod","verbs":["list"]},` +
+				`{"name":"nodes","namespaced":false,"kind":"Node","verbs":["list"]}]}`))
+		case "/api/v1/nodes":
+			_, _ = w.Write([]byte(`{"kind":"NodeList","apiVersion":"v1","items":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := NamespacePolicy{Allowed: []string{"team-a"}}.Wrap(&rest.Config{Host: srv.URL})
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		t.Fatalf("NewForConfig() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if _, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{}); !apierrors.IsForbidden(err) {
+		t.Fatalf("list of pods across namespaces error = %v, want Forbidden", err)
+	}
+	if _, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{}); err != nil {
+		t.Fatalf("list of nodes should pass: %v", err)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestWrapLeavesConfigUntouchedForZeroPolicy(t *testing.T) {
+	config := &rest.Config{Host: "https://example"}
+	if got := (NamespacePolicy{}).Wrap(config); got != config {
+		t.Fatal("zero policy should return the config unchanged")
+	}
+}
+
+func TestNamespacePolicyFromEnv(t *testing.T) {
+	t.Setenv(EnvAllowedNamespaces, "team-a, team-b,")
+	p := NamespacePolicyFromEnv()
+	if len(p.Allowed) != 2 || p.Allowed[0] != "team-a" || p.Allowed[1] != "team-b" {
+		t.Fatalf("Allowed = %v, want [team-a team-b]", p.Allowed)
+	}
+	if len(p.Protected) != len(DefaultProtectedNamespaces) {
+		t.Fatalf("Protected = %v, want defaults", p.Protected)
+	}
+
+	t.Setenv(EnvProtectedNamespaces, "")
+	if p := NamespacePolicyFromEnv(); len(p.Protected) != 0 {
+		t.Fatalf("Protected = %v, want protection disabled", p.Protected)
+	}
+}
@@ -0,0 +1,219 @@
+// Package journal records the before-image of every Kubernetes object the
+// MCP servers change, so a bad edit can be reverted with undo_change.
+// Recording happens in the client transport (see Wrap): every mutating
+// request made with a context carrying a Recorder is journaled, whichever
+// tool or client issued it.
+package journal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// Operations recorded for an object.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// DefaultMaxChanges bounds the journal. Older changes are dropped first,
+// which keeps ConfigMap-backed stores under their size limit.
+const DefaultMaxChanges = 200
+
+// Object is one object changed by a Change.
+type Object struct {
+	// Server is the API server URL (scheme and host) the change was sent to.
+	Server string `json:"server"`
+	// Path is the object's API path, e.g.
+	// /apis/apps/v1/namespaces/default/deployments/web.
+	Path string `json:"path"`
+	Op   string `json:"op"`
+	// Before is the object as JSON before the change. It is empty for
+	// creates, and for changes that cannot be undone.
+	Before json.RawMessage `json:"before,omitempty"`
+	// NotUndoable says why the change cannot be undone: Secret contents
+	// are never journaled, and an object whose previous state could not be
+	// read has nothing to restore.
+	NotUndoable string `json:"notUndoable,omitempty"`
+}
+
+// Change is the set of objects changed by one tool call.
+type Change struct {
+	ID      string    `json:"id"`
+	Tool    string    `json:"tool"`
+	Time    time.Time `json:"time"`
+	Objects []Object  `json:"objects"`
+	// UndoOf is the change this change reverted, if it was an undo.
+	UndoOf string `json:"undoOf,omitempty"`
+	// UndoneBy is the change that reverted this one, if any.
+	UndoneBy string `json:"undoneBy,omitempty"`
+}
+
+// NotUndoable lists the objects of the change that undo cannot revert, as
+// "<path> on <server>: <reason>".
+func (c *Change) NotUndoable() []string {
+	var out []string
+	for _, obj := range c.Objects {
+		if obj.NotUndoable != "" {
+			out = append(out, fmt.Sprintf("%s on %s: %s", obj.Path, obj.Server, obj.NotUndoable))
+		}
+	}
+	return out
+}
+
+// Recorder collects the objects changed during one tool call. It is safe
+// for concurrent use, since multi-cluster tools write in parallel.
+type Recorder struct {
+	id      string
+	tool    string
+	undoOf  string
+	started time.Time
+	mu      sync.Mutex
+	objects []Object
+}
+
+// ID returns the change ID the recorded objects will be committed under.
+func (r *Recorder) ID() string { return r.id }
+
+func (r *Recorder) record(obj Object) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.objects = append(r.objects, obj)
+}
+
+// Objects returns a copy of the objects recorded so far, oldest first.
+func (r *Recorder) Objects() []Object {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Object(nil), r.objects...)
+}
+
+// Truncate forgets every object recorded after the first n, for callers
+// that have already reverted them.
+func (r *Recorder) Truncate(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n < len(r.objects) {
+		r.objects = r.objects[:n]
+	}
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context whose mutating requests are recorded by r.
+// A nil r stops recording for requests made with the returned context.
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// RecorderFrom returns the Recorder carried by ctx, or nil.
+func RecorderFrom(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Journal stores Changes in a state store bucket.
+type Journal struct {
+	store      store.Store
+	maxChanges int
+	now        func() time.Time
+	// mu serializes read-modify-write of changes within this process.
+	mu sync.Mutex
+}
+
+// New creates a journal backed by st.
+func New(st store.Store) *Journal {
+	return &Journal{store: st, maxChanges: DefaultMaxChanges, now: time.Now}
+}
+
+// Begin starts recording a change made by tool.
+func (j *Journal) Begin(tool string) *Recorder {
+	now := j.now()
+	return &Recorder{id: newChangeID(now), tool: tool, started: now}
+}
+
+// Commit stores the objects recorded by r. It returns nil if the call
+// changed nothing.
+func (j *Journal) Commit(ctx context.Context, r *Recorder) (*Change, error) {
+	objects := r.Objects()
+	if len(objects) == 0 {
+		return nil, nil
+	}
+	change := &Change{ID: r.id, Tool: r.tool, Time: r.started, Objects: objects, UndoOf: r.undoOf}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := store.PutJSON(ctx, j.store, store.BucketChanges, change.ID, change); err != nil {
+		return nil, fmt.Errorf("failed to journal change %s: %w", change.ID, err)
+	}
+	if change.UndoOf != "" {
+		var undone Change
+		if err := store.GetJSON(ctx, j.store, store.BucketChanges, change.UndoOf, &undone); err == nil {
+			undone.UndoneBy = change.ID
+			_ = store.PutJSON(ctx, j.store, store.BucketChanges, undone.ID, &undone)
+		}
+	}
+	j.trim(ctx)
+	return change, nil
+}
+
+// Get returns the change with id.
+func (j *Journal) Get(ctx context.Context, id string) (*Change, error) {
+	var change Change
+	if err := store.GetJSON(ctx, j.store, store.BucketChanges, id, &change); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("change %q not found", id)
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+// List returns up to limit changes, newest first. A non-positive limit
+// returns every change.
+func (j *Journal) List(ctx context.Context, limit int) ([]Change, error) {
+	items, err := j.store.List(ctx, store.BucketChanges)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]Change, 0, len(items))
+	for i := len(items) - 1; i >= 0; i-- {
+		var change Change
+		if err := json.Unmarshal(items[i].Value, &change); err != nil {
+			continue
+		}
+		changes = append(changes, change)
+		if limit > 0 && len(changes) == limit {
+			break
+		}
+	}
+	return changes, nil
+}
+
+// trim drops the oldest changes beyond maxChanges. Change IDs sort by
+// time and List returns keys in order, so the oldest come first.
+func (j *Journal) trim(ctx context.Context) {
+	items, err := j.store.List(ctx, store.BucketChanges)
+	if err != nil || len(items) <= j.maxChanges {
+		return
+	}
+	for _, item := range items[:len(items)-j.maxChanges] {
+		_ = j.store.Delete(ctx, store.BucketChanges, item.Key)
+	}
+}
+
+// newChangeID returns an ID that sorts by creation time.
+func newChangeID(now time.Time) string {
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	return "chg-" + now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(b[:])
+}
@@ -0,0 +1,326 @@
+package journal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// fakeAPIServer stores ConfigMaps in the default namespace as JSON.
+type fakeAPIServer struct {
+	mu      sync.Mutex
+	objects map[string]map[string]interface{}
+	rv      int
+}
+
+const cmCollection = "/api/v1/namespaces/default/configmaps"
+
+func newFakeAPIServer(t *testing.T) (*fakeAPIServer, *httptest.Server) {
+	f := &fakeAPIServer{objects: make(map[string]map[string]interface{})}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeAPIServer) put(name, value string) {
+	f.rv++
+	f.objects[name] = map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]interface{}{"name": name, "namespace": "default", "resourceVersion": strconv.Itoa(f.rv)},
+		"data":     map[string]interface{}{"value": value},
+	}
+}
+
+func (f *fakeAPIServer) value(name string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[name]
+	if !ok {
+		return "", false
+	}
+	return obj["data"].(map[string]interface{})["value"].(string), true
+}
+
+func (f *fakeAPIServer) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, cmCollection), "/")
+	writeObj := func(obj interface{}) { _ = json.NewEncoder(w).Encode(obj) }
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		writeObj(map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": 404, "message": name + " not found"})
+	}
+	dryRun := r.URL.Query().Get("dryRun") != ""
+
+	switch r.Method {
+	case http.MethodGet:
+		if obj, ok := f.objects[name]; ok {
+			writeObj(obj)
+			return
+		}
+		notFound()
+	case http.MethodDelete:
+		obj, ok := f.objects[name]
+		if !ok {
+			notFound()
+			return
+		}
+		if !dryRun {
+			delete(f.objects, name)
+		}
+		writeObj(obj)
+	case http.MethodPost, http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		var obj map[string]interface{}
+		_ = json.Unmarshal(body, &obj)
+		objName := obj["metadata"].(map[string]interface{})["name"].(string)
+		if r.Method == http.MethodPut {
+			if _, ok := f.objects[objName]; !ok {
+				notFound()
+				return
+			}
+		}
+		data, _ := obj["data"].(map[string]interface{})
+		value, _ := data["value"].(string)
+		if !dryRun {
+			f.put(objName, value)
+		}
+		writeObj(f.objects[objName])
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func configMap(name, value string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name}, Data: map[string]string{"value": value}}
+}
+
+func TestJournalRecordsAndUndoes(t *testing.T) {
+	ctx := context.Background()
+	fake, srv := newFakeAPIServer(t)
+	fake.put("edited", "v1")
+	fake.put("removed", "keep-me")
+
+	config := Wrap(&rest.Config{Host: srv.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}})
+	client := kubernetes.NewForConfigOrDie(config)
+	j := New(store.NewMemory())
+	rec := j.Begin("kubectl_apply")
+	tctx := WithRecorder(ctx, rec)
+
+	cms := client.CoreV1().ConfigMaps("default")
+	if _, err := cms.Create(tctx, configMap("created", "new"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cms.Update(tctx, configMap("edited", "v2"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cms.Delete(tctx, "removed", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Requests without a recorder, and dry runs, are not journaled.
+	if _, err := cms.Create(ctx, configMap("untracked", "x"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cms.Create(tctx, configMap("dry", "x"), metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}); err != nil {
+		t.Fatal(err)
+	}
+
+	change, err := j.Commit(ctx, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change == nil || len(change.Objects) != 3 {
+		t.Fatalf("expected 3 journaled objects, got %+v", change)
+	}
+	wantOps := []string{OpCreate, OpUpdate, OpDelete}
+	for i, obj := range change.Objects {
+		if obj.Op != wantOps[i] {
+			t.Errorf("object %d op = %s, want %s", i, obj.Op, wantOps[i])
+		}
+		if obj.Server != srv.URL {
+			t.Errorf("object %d server = %s, want %s", i, obj.Server, srv.URL)
+		}
+	}
+	if change.Objects[0].Path != cmCollection+"/created" {
+		t.Errorf("create path = %s", change.Objects[0].Path)
+	}
+
+	configFor := func(server string) (*rest.Config, error) { return config, nil }
+
+	// A dry-run undo reports the plan without changing anything.
+	if _, results, err := j.Undo(ctx, change.ID, configFor, true); err != nil || len(results) != 3 {
+		t.Fatalf("dry-run undo = %+v, %v", results, err)
+	}
+	if v, _ := fake.value("edited"); v != "v2" {
+		t.Fatalf("dry-run undo changed the cluster")
+	}
+
+	undo, results, err := j.Undo(ctx, change.ID, configFor, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			t.Errorf("undo %s: %s", r.Path, r.Error)
+		}
+	}
+	if _, ok := fake.value("created"); ok {
+		t.Error("created object should be deleted by undo")
+	}
+	if v, _ := fake.value("edited"); v != "v1" {
+		t.Errorf("edited = %q, want v1", v)
+	}
+	if v, ok := fake.value("removed"); !ok || v != "keep-me" {
+		t.Errorf("removed = %q, %v; want it recreated", v, ok)
+	}
+
+	if undo == nil || undo.UndoOf != change.ID {
+		t.Fatalf("undo should be journaled as a change, got %+v", undo)
+	}
+	if _, _, err := j.Undo(ctx, change.ID, configFor, false); err == nil {
+		t.Error("a change can only be undone once")
+	}
+	changes, err := j.List(ctx, 0)
+	if err != nil || len(changes) != 2 || changes[0].ID != undo.ID || changes[1].UndoneBy != undo.ID {
+		t.Fatalf("List = %+v, %v", changes, err)
+	}
+}
+
+func TestJournalRecordsChangesItCannotUndo(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/configmaps/unreadable") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		if len(body) == 0 {
+			body = []byte(`{"metadata":{"name":"x"}}`)
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	config := Wrap(&rest.Config{Host: srv.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}})
+	client := kubernetes.NewForConfigOrDie(config)
+	j := New(store.NewMemory())
+	rec := j.Begin("update_config")
+	tctx := WithRecorder(ctx, rec)
+
+	if _, err := client.CoreV1().ConfigMaps("default").Update(tctx, configMap("unreadable", "v2"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "token"}, StringData: map[string]string{"token": "s3cr3t"}}
+	if _, err := client.CoreV1().Secrets("default").Update(tctx, secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	change, err := j.Commit(ctx, rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if change == nil || len(change.Objects) != 2 {
+		t.Fatalf("expected both changes to be journaled, got %+v", change)
+	}
+	for _, obj := range change.Objects {
+		if obj.Op != OpUpdate || obj.NotUndoable == "" || len(obj.Before) != 0 {
+			t.Errorf("object %s = %+v, want an update without a before-image, marked not undoable", obj.Path, obj)
+		}
+	}
+	if got := change.NotUndoable(); len(got) != 2 || !strings.Contains(got[1], "Secret contents are never journaled") {
+		t.Errorf("NotUndoable() = %q", got)
+	}
+
+	configFor := func(server string) (*rest.Config, error) { return config, nil }
+	_, results, err := j.Undo(ctx, change.ID, configFor, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if !strings.Contains(r.Error, "cannot be undone") {
+			t.Errorf("undo %s error = %q, want it to say the change cannot be undone", r.Path, r.Error)
+		}
+	}
+}
+
+func TestCommitWithoutChanges(t *testing.T) {
+	j := New(store.NewMemory())
+	change, err := j.Commit(context.Background(), j.Begin("scale_app"))
+	if err != nil || change != nil {
+		t.Fatalf("Commit = %+v, %v; want nothing stored", change, err)
+	}
+}
+
+func TestParseObjectPath(t *testing.T) {
+	tests := []struct {
+		path       string
+		collection string
+		name       string
+		ok         bool
+	}{
+		{"/api/v1/namespaces/default/configmaps", "/api/v1/namespaces/default/configmaps", "", true},
+		{"/apis/apps/v1/namespaces/prod/deployments/web/scale", "/apis/apps/v1/namespaces/prod/deployments", "web", true},
+		{"/api/v1/namespaces/team-a", "/api/v1/namespaces", "team-a", true},
+		{"/api/v1/namespaces/team-a/finalize", "/api/v1/namespaces", "team-a", true},
+		{"/api/v1/nodes/n1", "/api/v1/nodes", "n1", true},
+		{"/k8s/clusters/c-1/api/v1/namespaces/default/pods/p", "/k8s/clusters/c-1/api/v1/namespaces/default/pods", "p", true},
+		{"/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", "", "", false},
+		{"/version", "", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseObjectPath(tt.path)
+		if ok != tt.ok || got.collection != tt.collection || got.name != tt.name {
+			t.Errorf("parseObjectPath(%q) = %+v, %v; want %q %q %v", tt.path, got, ok, tt.collection, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestTrimKeepsNewestChanges(t *testing.T) {
+	ctx := context.Background()
+	j := New(store.NewMemory())
+	j.maxChanges = 2
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	j.now = func() time.Time { now = now.Add(time.Second); return now }
+	var ids []string
+	for i := 0; i < 3; i++ {
+		rec := j.Begin("add_labels")
+		rec.record(Object{Server: "https://c1", Path: "/api/v1/nodes/n1", Op: OpUpdate, Before: json.RawMessage(`{}`)})
+		change, err := j.Commit(ctx, rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, change.ID)
+	}
+	changes, _ := j.List(ctx, 0)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes after trim, got %d", len(changes))
+	}
+	if _, err := j.Get(ctx, ids[0]); err == nil {
+		t.Error("oldest change should have been trimmed")
+	}
+}
+
+func TestSameServer(t *testing.T) {
+	if !SameServer("https://API.example:6443/", "https://api.example:6443") {
+		t.Error("same host should match")
+	}
+	if SameServer("https://a:6443", "https://b:6443") {
+		t.Error("different hosts must not match")
+	}
+}
@@ -0,0 +1,224 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+// Wrap returns a copy of config whose transport journals mutating requests
+// made with a context carrying a Recorder. Before an object is updated or
+// deleted its current state is read and recorded; creates record the new
+// object's path. Dry runs and failed requests are not recorded. A change
+// whose previous state cannot be kept, because the object is a Secret or
+// could not be read, is recorded as not undoable.
+func Wrap(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &journalTransport{next: rt}
+	})
+	return config
+}
+
+type journalTransport struct {
+	next http.RoundTripper
+}
+
+func (t *journalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := RecorderFrom(req.Context())
+	if rec == nil || !isMutation(req.Method) || req.URL.Query().Get("dryRun") != "" {
+		return t.next.RoundTrip(req)
+	}
+	target, ok := parseObjectPath(req.URL.Path)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+	server := req.URL.Scheme + "://" + req.URL.Host
+
+	if req.Method == http.MethodPost {
+		// Only creates in a collection change an object we can revert;
+		// POSTs to subresources (evictions, token requests) are not recorded.
+		if target.name != "" {
+			return t.next.RoundTrip(req)
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept", "application/json")
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !succeeded(resp) {
+			return resp, err
+		}
+		if name := readName(resp); name != "" {
+			rec.record(Object{Server: server, Path: target.collection + "/" + name, Op: OpCreate})
+		}
+		return resp, nil
+	}
+
+	objectPath := target.collection + "/" + target.name
+	before, found, readErr := t.get(req.Context(), req, objectPath)
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !succeeded(resp) {
+		return resp, err
+	}
+	op := OpUpdate
+	if req.Method == http.MethodDelete {
+		op = OpDelete
+	}
+	switch {
+	case readErr != nil:
+		// The change went through, but there is nothing to restore.
+		rec.record(Object{Server: server, Path: objectPath, Op: op,
+			NotUndoable: fmt.Sprintf("its previous state could not be read (%v)", readErr)})
+	case !found && req.Method != http.MethodDelete:
+		// PUT or apply PATCH of a missing object creates it.
+		rec.record(Object{Server: server, Path: objectPath, Op: OpCreate})
+	case found && isSecret(target.collection):
+		// Secret contents must not be copied into the state store, which
+		// may be a ConfigMap.
+		rec.record(Object{Server: server, Path: objectPath, Op: op,
+			NotUndoable: "Secret contents are never journaled"})
+	case found:
+		rec.record(Object{Server: server, Path: objectPath, Op: op, Before: before})
+	}
+	return resp, nil
+}
+
+// get reads the object at path as JSON with the credentials of orig.
+func (t *journalTransport) get(ctx context.Context, orig *http.Request, path string) (json.RawMessage, bool, error) {
+	u := *orig.URL
+	u.Path, u.RawPath, u.RawQuery = path, "", ""
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if ua := orig.Header.Get("User-Agent"); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if !succeeded(resp) {
+		return nil, false, fmt.Errorf("reading %s: %s", path, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	return stripManagedFields(body), true, nil
+}
+
+func succeeded(resp *http.Response) bool {
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// readName returns metadata.name from a JSON response, restoring the body
+// for the caller.
+func readName(resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var obj struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if json.Unmarshal(body, &obj) != nil {
+		return ""
+	}
+	return obj.Metadata.Name
+}
+
+// stripManagedFields drops metadata.managedFields, which is large and not
+// needed to restore an object.
+func stripManagedFields(body []byte) json.RawMessage {
+	var obj map[string]interface{}
+	if json.Unmarshal(body, &obj) != nil {
+		return body
+	}
+	if meta, ok := obj["metadata"].(map[string]interface{}); ok {
+		delete(meta, "managedFields")
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+func isSecret(collection string) bool {
+	return strings.HasSuffix(collection, "/secrets")
+}
+
+func isMutation(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+type objectPath struct {
+	// collection is the path up to and including the resource, keeping any
+	// proxy prefix before /api or /apis.
+	collection string
+	name       string
+}
+
+// parseObjectPath splits an API path into its collection and object name,
+// dropping any subresource. Review APIs, which change nothing, and paths
+// that are not resource paths are rejected.
+func parseObjectPath(urlPath string) (objectPath, bool) {
+	parts := strings.Split(strings.Trim(urlPath, "/"), "/")
+	start := -1
+	for i, part := range parts {
+		if part == "api" || part == "apis" {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return objectPath{}, false
+	}
+	segs := parts[start:]
+	switch {
+	case segs[0] == "api" && len(segs) >= 3:
+		segs = segs[2:]
+	case segs[0] == "apis" && len(segs) >= 4:
+		if segs[1] == "authorization.k8s.io" || segs[1] == "authentication.k8s.io" {
+			return objectPath{}, false
+		}
+		segs = segs[3:]
+	default:
+		return objectPath{}, false
+	}
+	prefixLen := len(parts) - len(segs)
+	namespaceSubresource := len(segs) == 3 && (segs[2] == "status" || segs[2] == "finalize")
+	if len(segs) >= 3 && segs[0] == "namespaces" && !namespaceSubresource {
+		// namespaces/<ns>/<resource>[/<name>[/<subresource>]]
+		prefixLen += 2
+		segs = segs[2:]
+	}
+	// segs is now <resource>[/<name>[/<subresource>]].
+	if len(segs) == 0 {
+		return objectPath{}, false
+	}
+	collection := "/" + strings.Join(parts[:prefixLen+1], "/")
+	if len(segs) == 1 {
+		return objectPath{collection: collection}, true
+	}
+	return objectPath{collection: collection, name: segs[1]}, true
+}
@@ -0,0 +1,206 @@
+package journal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+// ConfigFunc returns a REST config for the cluster whose API server is at
+// server (scheme and host, as recorded in Object.Server). The config should
+// have passed through Wrap, so the revert is journaled.
+type ConfigFunc func(server string) (*rest.Config, error)
+
+// UndoResult reports what undo did, or would do, to one object.
+type UndoResult struct {
+	Server string `json:"server"`
+	Path   string `json:"path"`
+	// Action is deleted, restored, recreated, or unchanged.
+	Action string `json:"action,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Undo reverts change id: objects it created are deleted, and objects it
+// updated or deleted are put back to their recorded state, newest first.
+// The revert is itself journaled, so it can be undone in turn. With dryRun
+// set, every request is sent as a server-side dry run and nothing is
+// journaled.
+func (j *Journal) Undo(ctx context.Context, id string, configFor ConfigFunc, dryRun bool) (*Change, []UndoResult, error) {
+	change, err := j.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if change.UndoneBy != "" {
+		return nil, nil, fmt.Errorf("change %s was already undone by %s", id, change.UndoneBy)
+	}
+
+	rec := j.Begin("undo_change")
+	rec.undoOf = id
+	if !dryRun {
+		ctx = WithRecorder(ctx, rec)
+	}
+
+	results := Revert(ctx, change.Objects, configFor, dryRun)
+
+	if dryRun {
+		return nil, results, nil
+	}
+	undo, err := j.Commit(ctx, rec)
+	return undo, results, err
+}
+
+// Revert undoes objects, newest first: created objects are deleted, and
+// updated or deleted objects are put back to their recorded state. Requests
+// are made with ctx, so they are journaled if it carries a Recorder.
+func Revert(ctx context.Context, objects []Object, configFor ConfigFunc, dryRun bool) []UndoResult {
+	clients := make(map[string]*http.Client)
+	results := make([]UndoResult, 0, len(objects))
+	for i := len(objects) - 1; i >= 0; i-- {
+		obj := objects[i]
+		result := UndoResult{Server: obj.Server, Path: obj.Path}
+		client, ok := clients[obj.Server]
+		if !ok {
+			var err error
+			client, err = httpClientFor(configFor, obj.Server)
+			if err != nil {
+				result.Error = err.Error()
+				results = append(results, result)
+				continue
+			}
+			clients[obj.Server] = client
+		}
+		action, err := revert(ctx, client, obj, dryRun)
+		result.Action = action
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func httpClientFor(configFor ConfigFunc, server string) (*http.Client, error) {
+	config, err := configFor(server)
+	if err != nil {
+		return nil, fmt.Errorf("no cluster for %s: %w", server, err)
+	}
+	return rest.HTTPClientFor(config)
+}
+
+func revert(ctx context.Context, client *http.Client, obj Object, dryRun bool) (string, error) {
+	if obj.Op == OpCreate {
+		status, _, err := call(ctx, client, http.MethodDelete, obj.Server+obj.Path, nil, dryRun)
+		if status == http.StatusNotFound {
+			return "unchanged", nil
+		}
+		return "deleted", err
+	}
+
+	if obj.NotUndoable != "" {
+		return "", fmt.Errorf("cannot be undone: %s; restore it from its source", obj.NotUndoable)
+	}
+	if len(obj.Before) == 0 {
+		return "", fmt.Errorf("the previous state was not journaled (Secret contents are never recorded); restore it from its source")
+	}
+	var before map[string]interface{}
+	if err := json.Unmarshal(obj.Before, &before); err != nil {
+		return "", fmt.Errorf("invalid recorded object: %w", err)
+	}
+	status, body, err := call(ctx, client, http.MethodGet, obj.Server+obj.Path, nil, false)
+	switch {
+	case status == http.StatusNotFound:
+		collection := obj.Path[:strings.LastIndex(obj.Path, "/")]
+		_, _, err = call(ctx, client, http.MethodPost, obj.Server+collection, forCreate(before), dryRun)
+		return "recreated", err
+	case err != nil:
+		return "", err
+	}
+	var current struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	_ = json.Unmarshal(body, &current)
+	if meta, ok := before["metadata"].(map[string]interface{}); ok {
+		meta["resourceVersion"] = current.Metadata.ResourceVersion
+	}
+	_, _, err = call(ctx, client, http.MethodPut, obj.Server+obj.Path, before, dryRun)
+	return "restored", err
+}
+
+// forCreate strips the server-populated fields that a create rejects.
+func forCreate(obj map[string]interface{}) map[string]interface{} {
+	delete(obj, "status")
+	if meta, ok := obj["metadata"].(map[string]interface{}); ok {
+		for _, field := range []string{"resourceVersion", "uid", "creationTimestamp", "deletionTimestamp",
+			"deletionGracePeriodSeconds", "generation", "selfLink", "managedFields"} {
+			delete(meta, field)
+		}
+	}
+	return obj
+}
+
+func call(ctx context.Context, client *http.Client, method, rawURL string, body interface{}, dryRun bool) (int, []byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, nil, err
+	}
+	if dryRun {
+		q := u.Query()
+		q.Set("dryRun", "All")
+		u.RawQuery = q.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	if !succeeded(resp) {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &status) == nil && status.Message != "" {
+			return resp.StatusCode, data, fmt.Errorf("%s %s: %s", method, u.Path, status.Message)
+		}
+		return resp.StatusCode, data, fmt.Errorf("%s %s: %s", method, u.Path, resp.Status)
+	}
+	return resp.StatusCode, data, nil
+}
+
+// SameServer reports whether two API server URLs name the same server,
+// ignoring any path and a trailing slash.
+func SameServer(a, b string) bool {
+	ua, errA := url.Parse(a)
+	ub, errB := url.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
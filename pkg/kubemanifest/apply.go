@@ -0,0 +1,144 @@
+package kubemanifest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// Apply phases. Objects are applied in phase order: Namespaces before the
+// objects in them, and CustomResourceDefinitions before their custom
+// resources.
+const (
+	PhaseNamespace = iota
+	PhaseCRD
+	PhaseResource
+)
+
+// CRDEstablishTimeout is how long an apply waits for the CRDs it applied
+// to be served before applying custom resources.
+const CRDEstablishTimeout = 30 * time.Second
+
+var (
+	namespaceGVR = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	crdGVR       = schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+)
+
+// crdPollInterval is how often WaitForCRDs checks the CRDs; tests shorten it.
+var crdPollInterval = time.Second
+
+// ApplyPhase returns the phase an object of kind in apiVersion is applied in.
+func ApplyPhase(apiVersion, kind string) int {
+	group := ""
+	if gv, err := schema.ParseGroupVersion(apiVersion); err == nil {
+		group = gv.Group
+	}
+	switch {
+	case kind == "Namespace" && group == "":
+		return PhaseNamespace
+	case kind == "CustomResourceDefinition" && group == crdGVR.Group:
+		return PhaseCRD
+	default:
+		return PhaseResource
+	}
+}
+
+// SortForApply orders objects by apply phase, keeping the manifest order
+// within each phase.
+func SortForApply(objects []*unstructured.Unstructured) {
+	slices.SortStableFunc(objects, func(a, b *unstructured.Unstructured) int {
+		return ApplyPhase(a.GetAPIVersion(), a.GetKind()) - ApplyPhase(b.GetAPIVersion(), b.GetKind())
+	})
+}
+
+// EnsureNamespace creates namespace if it does not exist, and reports
+// whether it was missing. A dry run only reports it.
+func EnsureNamespace(ctx context.Context, client dynamic.Interface, namespace string, dryRun bool) (bool, error) {
+	_, err := client.Resource(namespaceGVR).Get(ctx, namespace, metav1.GetOptions{})
+	if err == nil {
+		return false, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	if dryRun {
+		return true, nil
+	}
+	ns := &unstructured.Unstructured{}
+	ns.SetAPIVersion("v1")
+	ns.SetKind("Namespace")
+	ns.SetName(namespace)
+	if _, err := client.Resource(namespaceGVR).Create(ctx, ns, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return false, fmt.Errorf("failed to create namespace %s: %w", namespace, err)
+	}
+	return true, nil
+}
+
+// WaitForCRDs waits until the named CustomResourceDefinitions are
+// Established, that is, until their custom resources are served.
+func WaitForCRDs(ctx context.Context, client dynamic.Interface, names []string, timeout time.Duration) error {
+	pending := slices.Clone(names)
+	err := wait.PollUntilContextTimeout(ctx, crdPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		var still []string
+		for _, name := range pending {
+			crd, err := client.Resource(crdGVR).Get(ctx, name, metav1.GetOptions{})
+			if err != nil || !crdEstablished(crd) {
+				still = append(still, name)
+			}
+		}
+		pending = still
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("CustomResourceDefinitions not established after %s: %s", timeout, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+func crdEstablished(crd *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if ok && cond["type"] == "Established" && cond["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultFieldManager is the field manager of server-side applies when the
+// caller does not name one.
+const DefaultFieldManager = "kubestellar-deploy"
+
+// ApplyConflict returns the fields of a server-side apply that other field
+// managers own, as "field (conflict with "manager" ...)", if err is such a
+// conflict. A plain update conflict on the resourceVersion is not one.
+func ApplyConflict(err error) ([]string, bool) {
+	var status apierrors.APIStatus
+	if !apierrors.IsConflict(err) || !errors.As(err, &status) || status.Status().Details == nil {
+		return nil, false
+	}
+	var fields []string
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			fields = append(fields, fmt.Sprintf("%s (%s)", cause.Field, cause.Message))
+		}
+	}
+	return fields, len(fields) > 0
+}
+
+// ApplyConflictMessage explains a server-side apply conflict returned by
+// ApplyConflict.
+func ApplyConflictMessage(fields []string) string {
+	return fmt.Sprintf("fields owned by other field managers: %s; apply with force to take ownership of them", strings.Join(fields, ", "))
+}
@@ -0,0 +1,120 @@
+package kubemanifest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestSortForApply(t *testing.T) {
+	objects, err := DecodeString(`apiVersion: example.io/v1
+kind: Widget
+metadata: {name: w, namespace: team}
+---
+apiVersion: v1
+kind: ConfigMap
+metadata: {name: c, namespace: team}
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata: {name: widgets.example.io}
+---
+apiVersion: v1
+kind: Namespace
+metadata: {name: team}
+---
+apiVersion: example.io/v1
+kind: Namespace
+metadata: {name: not-a-namespace}
+`)
+	require.NoError(t, err)
+
+	SortForApply(objects)
+	var names []string
+	for _, obj := range objects {
+		names = append(names, obj.GetName())
+	}
+	assert.Equal(t, []string{"team", "widgets.example.io", "w", "c", "not-a-namespace"}, names)
+}
+
+func TestEnsureNamespace(t *testing.T) {
+	existing := &unstructured.Unstructured{}
+	existing.SetAPIVersion("v1")
+	existing.SetKind("Namespace")
+	existing.SetName("existing")
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), existing)
+	ctx := context.Background()
+
+	created, err := EnsureNamespace(ctx, client, "existing", false)
+	require.NoError(t, err)
+	assert.False(t, created)
+
+	created, err = EnsureNamespace(ctx, client, "missing", true)
+	require.NoError(t, err)
+	assert.True(t, created)
+	_, err = client.Resource(namespaceGVR).Get(ctx, "missing", metav1.GetOptions{})
+	assert.Error(t, err, "a dry run must not create the namespace")
+
+	created, err = EnsureNamespace(ctx, client, "missing", false)
+	require.NoError(t, err)
+	assert.True(t, created)
+	_, err = client.Resource(namespaceGVR).Get(ctx, "missing", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func crd(name string, established bool) *unstructured.Unstructured {
+	status := "False"
+	if established {
+		status = "True"
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": name},
+		"status": map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Established", "status": status},
+		}},
+	}}
+}
+
+func TestWaitForCRDs(t *testing.T) {
+	crdPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { crdPollInterval = time.Second })
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{crdGVR: "CustomResourceDefinitionList"},
+		crd("widgets.example.io", true), crd("gadgets.example.io", false))
+	ctx := context.Background()
+
+	assert.NoError(t, WaitForCRDs(ctx, client, []string{"widgets.example.io"}, time.Second))
+
+	err := WaitForCRDs(ctx, client, []string{"widgets.example.io", "gadgets.example.io", "missing.example.io"}, 50*time.Millisecond)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gadgets.example.io, missing.example.io")
+	assert.NotContains(t, err.Error(), "widgets")
+}
+
+func TestApplyConflict(t *testing.T) {
+	err := apierrors.NewApplyConflict([]metav1.StatusCause{{
+		Type:    metav1.CauseTypeFieldManagerConflict,
+		Message: `conflict with "hpa-controller" using apps/v1`,
+		Field:   ".spec.replicas",
+	}}, "Apply failed with 1 conflict")
+	fields, ok := ApplyConflict(fmt.Errorf("failed to apply: %w", err))
+	require.True(t, ok)
+	assert.Equal(t, []string{`.spec.replicas (conflict with "hpa-controller" using apps/v1)`}, fields)
+
+	_, ok = ApplyConflict(apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "demo", fmt.Errorf("the object has been modified")))
+	assert.False(t, ok)
+	_, ok = ApplyConflict(apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "demo"))
+	assert.False(t, ok)
+}
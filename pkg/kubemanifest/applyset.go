@@ -0,0 +1,281 @@
+package kubemanifest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
+)
+
+// Labels and annotations of the ApplySet specification
+// (https://kep.k8s.io/3659), which kubectl apply --prune --applyset uses too.
+const (
+	ApplySetPartOfLabel          = "applyset.kubernetes.io/part-of"
+	ApplySetIDLabel              = "applyset.kubernetes.io/id"
+	applySetToolingAnnotation    = "applyset.kubernetes.io/tooling"
+	applySetGroupKindsAnnotation = "applyset.kubernetes.io/contains-group-kinds"
+	applySetNamespacesAnnotation = "applyset.kubernetes.io/additional-namespaces"
+)
+
+// ApplySetTooling is the tooling annotation of the apply sets this
+// repository manages.
+const ApplySetTooling = "kubestellar-deploy/v1"
+
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// ApplySet is a named set of objects applied together. Its members carry
+// the ApplySetPartOfLabel, and its parent, a ConfigMap of the same name,
+// records the kinds and namespaces of the members, so that the members a
+// later apply leaves out can be found and pruned.
+type ApplySet struct {
+	Name      string
+	Namespace string
+	// ID is the value of the members' ApplySetPartOfLabel.
+	ID string
+}
+
+// Member is an object of an apply set. Namespace is empty for
+// cluster-scoped objects.
+type Member struct {
+	schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+func (m Member) String() string {
+	if m.Namespace == "" {
+		return m.Kind + "/" + m.Name
+	}
+	return m.Kind + "/" + m.Namespace + "/" + m.Name
+}
+
+// ParseApplySet returns the apply set of ref, "name" or "namespace/name".
+// The parent ConfigMap of a bare name lives in defaultNamespace.
+func ParseApplySet(ref, defaultNamespace string) (*ApplySet, error) {
+	namespace, name, found := strings.Cut(ref, "/")
+	if !found {
+		namespace, name = defaultNamespace, ref
+	}
+	for _, part := range []string{namespace, name} {
+		if errs := validation.IsDNS1123Subdomain(part); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid apply set %q: %s", ref, strings.Join(errs, "; "))
+		}
+	}
+	// The ID is derived from the parent's name, namespace, kind, and group
+	// as the specification prescribes.
+	sum := sha256.Sum256([]byte(name + "." + namespace + ".ConfigMap."))
+	return &ApplySet{
+		Name:      name,
+		Namespace: namespace,
+		ID:        "applyset-" + base64.RawURLEncoding.EncodeToString(sum[:]) + "-v1",
+	}, nil
+}
+
+func (a *ApplySet) String() string {
+	return a.Namespace + "/" + a.Name
+}
+
+// Label marks obj as a member of the apply set.
+func (a *ApplySet) Label(obj *unstructured.Unstructured) {
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	labels[ApplySetPartOfLabel] = a.ID
+	obj.SetLabels(labels)
+}
+
+// Prepare creates or updates the parent so that it records the kinds and
+// namespaces of members as well as those already recorded. It runs before
+// the members are applied, so that an interrupted apply leaves no member
+// the next prune cannot find.
+func (a *ApplySet) Prepare(ctx context.Context, client dynamic.Interface, members []Member) error {
+	parent, err := a.getParent(ctx, client)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		parent = &unstructured.Unstructured{}
+		parent.SetAPIVersion("v1")
+		parent.SetKind("ConfigMap")
+		parent.SetName(a.Name)
+		parent.SetNamespace(a.Namespace)
+		parent.SetLabels(map[string]string{ApplySetIDLabel: a.ID})
+		a.record(parent, members, nil, nil)
+		if _, err := client.Resource(configMapGVR).Namespace(a.Namespace).Create(ctx, parent, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create apply set %s: %w", a, err)
+		}
+		return nil
+	}
+	groupKinds, namespaces := a.recorded(parent)
+	if !a.record(parent, members, groupKinds, namespaces) {
+		return nil
+	}
+	if _, err := client.Resource(configMapGVR).Namespace(a.Namespace).Update(ctx, parent, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update apply set %s: %w", a, err)
+	}
+	return nil
+}
+
+// Prune deletes the objects of the apply set that are not members, looking
+// in the kinds and namespaces the parent records, and returns them. A dry
+// run only returns them.
+func (a *ApplySet) Prune(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, members []Member, dryRun bool) ([]Member, error) {
+	parent, err := a.getParent(ctx, client)
+	if err != nil || parent == nil {
+		return nil, err
+	}
+	keep := make(map[Member]bool, len(members))
+	for _, m := range members {
+		keep[m] = true
+	}
+	groupKinds, namespaces := a.recorded(parent)
+	namespaces = append(namespaces, a.Namespace)
+	selector := metav1.ListOptions{LabelSelector: ApplySetPartOfLabel + "=" + a.ID}
+
+	var pruned []Member
+	for _, gk := range groupKinds {
+		mapping, err := ResolveGroupKind(mapper, gk)
+		if err != nil {
+			return pruned, err
+		}
+		scopes := []string{""}
+		if !mapping.ClusterScoped {
+			scopes = namespaces
+		}
+		for _, namespace := range scopes {
+			resource := dynamic.ResourceInterface(client.Resource(mapping.GVR))
+			if namespace != "" {
+				resource = client.Resource(mapping.GVR).Namespace(namespace)
+			}
+			list, err := resource.List(ctx, selector)
+			if err != nil {
+				if apierrors.IsNotFound(err) {
+					continue
+				}
+				return pruned, fmt.Errorf("failed to list %s: %w", mapping.GVR.GroupResource(), err)
+			}
+			for _, obj := range list.Items {
+				m := Member{GroupKind: gk, Namespace: obj.GetNamespace(), Name: obj.GetName()}
+				if keep[m] {
+					continue
+				}
+				if !dryRun {
+					err := resource.Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+					if err != nil && !apierrors.IsNotFound(err) {
+						return pruned, fmt.Errorf("failed to prune %s: %w", m, err)
+					}
+				}
+				pruned = append(pruned, m)
+			}
+		}
+	}
+	return pruned, nil
+}
+
+// Commit records exactly the kinds and namespaces of members in the
+// parent, once every member is applied and the rest pruned.
+func (a *ApplySet) Commit(ctx context.Context, client dynamic.Interface, members []Member) error {
+	parent, err := a.getParent(ctx, client)
+	if err != nil || parent == nil {
+		return err
+	}
+	if !a.record(parent, members, nil, nil) {
+		return nil
+	}
+	if _, err := client.Resource(configMapGVR).Namespace(a.Namespace).Update(ctx, parent, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update apply set %s: %w", a, err)
+	}
+	return nil
+}
+
+// getParent returns the parent, or nil if it does not exist. A ConfigMap
+// of the same name that is not this apply set's parent is an error, so
+// that an apply set never takes over an object it did not create.
+func (a *ApplySet) getParent(ctx context.Context, client dynamic.Interface) (*unstructured.Unstructured, error) {
+	parent, err := client.Resource(configMapGVR).Namespace(a.Namespace).Get(ctx, a.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get apply set %s: %w", a, err)
+	}
+	if parent.GetLabels()[ApplySetIDLabel] != a.ID {
+		return nil, fmt.Errorf("ConfigMap %s exists and is not the parent of apply set %s", a, a.Name)
+	}
+	if tooling := parent.GetAnnotations()[applySetToolingAnnotation]; tooling != "" && !strings.HasPrefix(tooling, "kubestellar-deploy/") {
+		return nil, fmt.Errorf("apply set %s is managed by %s", a, tooling)
+	}
+	return parent, nil
+}
+
+// recorded returns the kinds and additional namespaces parent records.
+func (a *ApplySet) recorded(parent *unstructured.Unstructured) ([]schema.GroupKind, []string) {
+	annotations := parent.GetAnnotations()
+	var groupKinds []schema.GroupKind
+	for _, s := range splitList(annotations[applySetGroupKindsAnnotation]) {
+		groupKinds = append(groupKinds, schema.ParseGroupKind(s))
+	}
+	return groupKinds, splitList(annotations[applySetNamespacesAnnotation])
+}
+
+// record sets the parent's annotations to the kinds and namespaces of
+// members together with groupKinds and namespaces, and reports whether
+// they changed.
+func (a *ApplySet) record(parent *unstructured.Unstructured, members []Member, groupKinds []schema.GroupKind, namespaces []string) bool {
+	kinds := make([]string, 0, len(groupKinds)+len(members))
+	for _, gk := range groupKinds {
+		kinds = append(kinds, gk.String())
+	}
+	for _, m := range members {
+		kinds = append(kinds, m.GroupKind.String())
+		if m.Namespace != "" && m.Namespace != a.Namespace {
+			namespaces = append(namespaces, m.Namespace)
+		}
+	}
+	annotations := parent.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	want := map[string]string{
+		applySetToolingAnnotation:    ApplySetTooling,
+		applySetGroupKindsAnnotation: joinList(kinds),
+		applySetNamespacesAnnotation: joinList(namespaces),
+	}
+	changed := false
+	for k, v := range want {
+		if annotations[k] != v {
+			annotations[k] = v
+			changed = true
+		}
+	}
+	parent.SetAnnotations(annotations)
+	return changed
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// joinList returns the sorted, distinct items joined by commas.
+func joinList(items []string) string {
+	items = slices.Clone(items)
+	slices.Sort(items)
+	return strings.Join(slices.Compact(items), ",")
+}
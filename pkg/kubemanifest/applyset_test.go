@@ -0,0 +1,37 @@
+package kubemanifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestParseApplySet(t *testing.T) {
+	set, err := ParseApplySet("web", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "default/web", set.String())
+	assert.Regexp(t, `^applyset-[A-Za-z0-9_-]{43}-v1$`, set.ID)
+	assert.LessOrEqual(t, len(set.ID), 63, "the ID must fit in a label value")
+	other, err := ParseApplySet("team-a/web", "default")
+	require.NoError(t, err)
+	assert.NotEqual(t, set.ID, other.ID)
+
+	assert.Equal(t, "team-a", other.Namespace)
+	assert.Equal(t, "web", other.Name)
+
+	_, err = ParseApplySet("Web App", "default")
+	assert.Error(t, err)
+	_, err = ParseApplySet("a/b/c", "default")
+	assert.Error(t, err)
+}
+
+func TestResolveGroupKind(t *testing.T) {
+	got, err := ResolveGroupKind(nil, schema.GroupKind{Group: "apps", Kind: "Deployment"})
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{GVR: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}}, got)
+
+	_, err = ResolveGroupKind(nil, schema.GroupKind{Group: "example.io", Kind: "Widget"})
+	assert.EqualError(t, err, "unknown resource kind: Widget.example.io")
+}
@@ -0,0 +1,110 @@
+// Package kubemanifest reads Kubernetes manifests: it decodes streams of
+// YAML documents or JSON objects into unstructured objects, flattening
+// Lists, and resolves their kinds to API resources, custom resources
+// included. deploy_app, kubectl_apply, the kustomize tools, and GitOps
+// sync and drift detection all read manifests through it, so a manifest
+// means the same objects everywhere.
+package kubemanifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// errNotObject is returned for documents that are not Kubernetes objects.
+var errNotObject = errors.New("not a Kubernetes object: apiVersion and kind are required")
+
+// Decode returns the objects in a stream of YAML documents separated by
+// "---" lines, or of JSON objects. Lists, either kind List or typed lists
+// such as ConfigMapList, are replaced by their items. Empty documents are
+// skipped; any other document that is not an object with an apiVersion and
+// kind is an error, reported with its position in the stream.
+func Decode(r io.Reader) ([]*unstructured.Unstructured, error) {
+	return decode(r, false)
+}
+
+// DecodeString is Decode for a manifest held in a string.
+func DecodeString(manifest string) ([]*unstructured.Unstructured, error) {
+	return Decode(strings.NewReader(manifest))
+}
+
+// DecodeMixed is Decode for streams that may mix Kubernetes objects with
+// other YAML, such as the files of a Git repository: it skips documents
+// that are not objects with an apiVersion and kind instead of failing.
+func DecodeMixed(r io.Reader) ([]*unstructured.Unstructured, error) {
+	return decode(r, true)
+}
+
+func decode(r io.Reader, skipOther bool) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(r, 4096)
+	var objects []*unstructured.Unstructured
+	for doc := 1; ; doc++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				return objects, nil
+			}
+			return nil, fmt.Errorf("document %d: %w", doc, err)
+		}
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+		var obj map[string]interface{}
+		if err := utiljson.Unmarshal(raw, &obj); err != nil {
+			if skipOther {
+				continue
+			}
+			return nil, fmt.Errorf("document %d: %w", doc, errNotObject)
+		}
+		var err error
+		if objects, err = appendObject(objects, obj, skipOther); err != nil {
+			return nil, fmt.Errorf("document %d: %w", doc, err)
+		}
+	}
+}
+
+// appendObject appends obj, or the items of obj if it is a List.
+func appendObject(objects []*unstructured.Unstructured, obj map[string]interface{}, skipOther bool) ([]*unstructured.Unstructured, error) {
+	u := &unstructured.Unstructured{Object: obj}
+	if u.GetAPIVersion() == "" || u.GetKind() == "" {
+		if skipOther {
+			return objects, nil
+		}
+		return nil, errNotObject
+	}
+	items, ok := listItems(u)
+	if !ok {
+		return append(objects, u), nil
+	}
+	for i, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s item %d: %w", u.GetKind(), i+1, errNotObject)
+		}
+		// Items of a List must be objects even in mixed streams.
+		var err error
+		if objects, err = appendObject(objects, m, false); err != nil {
+			return nil, fmt.Errorf("%s item %d: %w", u.GetKind(), i+1, err)
+		}
+	}
+	return objects, nil
+}
+
+// listItems returns the items of a List: kind List, as kubectl get -o yaml
+// prints, or a typed list such as ConfigMapList.
+func listItems(u *unstructured.Unstructured) ([]interface{}, bool) {
+	if !strings.HasSuffix(u.GetKind(), "List") {
+		return nil, false
+	}
+	items, ok := u.Object["items"].([]interface{})
+	return items, ok || u.GetKind() == "List"
+}
@@ -0,0 +1,114 @@
+package kubemanifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func names(objects []*unstructured.Unstructured) []string {
+	var out []string
+	for _, obj := range objects {
+		out = append(out, obj.GetKind()+"/"+obj.GetName())
+	}
+	return out
+}
+
+func TestDecode(t *testing.T) {
+	manifest := `# leading comment
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: script
+data:
+  run.sh: |
+    echo "---"
+    cat <<'DOC'
+    ---
+    DOC
+  replicas: "3"
+---
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: web
+- apiVersion: v1
+  kind: ConfigMapList
+  items:
+  - apiVersion: v1
+    kind: ConfigMap
+    metadata:
+      name: nested
+--- # trailing comment
+apiVersion: example.io/v1
+kind: Widget
+metadata:
+  name: w
+spec:
+  size: 3
+`
+	objects, err := DecodeString(manifest)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/script", "Service/web", "ConfigMap/nested", "Widget/w"}, names(objects))
+
+	// Separators inside block scalars are content, not documents.
+	data, _, _ := unstructured.NestedStringMap(objects[0].Object, "data")
+	assert.Equal(t, "echo \"---\"\ncat <<'DOC'\n---\nDOC\n", data["run.sh"])
+	// Integers stay integers, as the API server expects.
+	size, found, err := unstructured.NestedInt64(objects[3].Object, "spec", "size")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, int64(3), size)
+}
+
+func TestDecodeJSONStream(t *testing.T) {
+	objects, err := DecodeString(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}
+{"apiVersion":"v1","kind":"List","items":[{"apiVersion":"v1","kind":"Secret","metadata":{"name":"b"}}]}`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/a", "Secret/b"}, names(objects))
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{name: "missing kind", manifest: "apiVersion: v1\nkind: ConfigMap\n---\napiVersion: v1\nmetadata:\n  name: x\n", want: "document 2: not a Kubernetes object"},
+		{name: "scalar", manifest: "just text\n", want: "document 1: not a Kubernetes object"},
+		{name: "invalid YAML", manifest: "kind: [unclosed\n", want: "document 1:"},
+		{name: "List item without kind", manifest: "apiVersion: v1\nkind: List\nitems:\n- metadata:\n    name: x\n", want: "document 1: List item 1: not a Kubernetes object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeString(tt.manifest)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestDecodeMixedSkipsOtherDocuments(t *testing.T) {
+	objects, err := DecodeMixed(strings.NewReader("replicaCount: 2\n---\n- a\n- b\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap/a"}, names(objects))
+
+	_, err = DecodeMixed(strings.NewReader("apiVersion: v1\nkind: List\nitems:\n- name: x\n"))
+	assert.Error(t, err, "List items must be objects")
+}
+
+func TestDecodeEmpty(t *testing.T) {
+	for _, manifest := range []string{"", "---\n---\n", "# only a comment\n", "null\n"} {
+		objects, err := DecodeString(manifest)
+		require.NoError(t, err, manifest)
+		assert.Empty(t, objects, manifest)
+	}
+}
@@ -0,0 +1,379 @@
+package kubemanifest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/klog/v2"
+)
+
+// Mapping is the API resource of a kind.
+type Mapping struct {
+	GVR           schema.GroupVersionResource
+	ClusterScoped bool
+	// Guessed is set when neither discovery nor the built-in kinds know
+	// the kind, and the resource is its lowercased plural.
+	Guessed bool
+}
+
+// builtinResources are the resources of common built-in kinds, used when
+// discovery is not available.
+var builtinResources = map[string]string{
+	"Deployment":               "deployments",
+	"Service":                  "services",
+	"ConfigMap":                "configmaps",
+	"Secret":                   "secrets",
+	"Pod":                      "pods",
+	"StatefulSet":              "statefulsets",
+	"DaemonSet":                "daemonsets",
+	"ReplicaSet":               "replicasets",
+	"Job":                      "jobs",
+	"CronJob":                  "cronjobs",
+	"Ingress":                  "ingresses",
+	"ServiceAccount":           "serviceaccounts",
+	"Role":                     "roles",
+	"RoleBinding":              "rolebindings",
+	"ClusterRole":              "clusterroles",
+	"ClusterRoleBinding":       "clusterrolebindings",
+	"PersistentVolumeClaim":    "persistentvolumeclaims",
+	"PersistentVolume":         "persistentvolumes",
+	"Namespace":                "namespaces",
+	"NetworkPolicy":            "networkpolicies",
+	"HorizontalPodAutoscaler":  "horizontalpodautoscalers",
+	"Node":                     "nodes",
+	"StorageClass":             "storageclasses",
+	"PriorityClass":            "priorityclasses",
+	"CustomResourceDefinition": "customresourcedefinitions",
+}
+
+// builtinShortNames are the short names kubectl accepts for the built-in
+// kinds.
+var builtinShortNames = map[string]string{
+	"po":     "Pod",
+	"svc":    "Service",
+	"cm":     "ConfigMap",
+	"deploy": "Deployment",
+	"sts":    "StatefulSet",
+	"ds":     "DaemonSet",
+	"rs":     "ReplicaSet",
+	"cj":     "CronJob",
+	"ing":    "Ingress",
+	"netpol": "NetworkPolicy",
+	"sa":     "ServiceAccount",
+	"pvc":    "PersistentVolumeClaim",
+	"pv":     "PersistentVolume",
+	"ns":     "Namespace",
+	"no":     "Node",
+	"hpa":    "HorizontalPodAutoscaler",
+	"sc":     "StorageClass",
+	"pc":     "PriorityClass",
+	"crd":    "CustomResourceDefinition",
+}
+
+// builtinGroupVersions are the versions of the built-in API groups, used
+// to resolve a kind without a version when discovery is not available.
+var builtinGroupVersions = map[string]string{
+	"":                          "v1",
+	"apps":                      "v1",
+	"batch":                     "v1",
+	"networking.k8s.io":         "v1",
+	"rbac.authorization.k8s.io": "v1",
+	"autoscaling":               "v2",
+	"policy":                    "v1",
+	"storage.k8s.io":            "v1",
+	"scheduling.k8s.io":         "v1",
+	"apiextensions.k8s.io":      "v1",
+}
+
+// builtinKindGroups are the API groups of the built-in kinds outside the
+// core group, used to resolve a kind given without a group when discovery
+// is not available.
+var builtinKindGroups = map[string]string{
+	"Deployment":               "apps",
+	"StatefulSet":              "apps",
+	"DaemonSet":                "apps",
+	"ReplicaSet":               "apps",
+	"Job":                      "batch",
+	"CronJob":                  "batch",
+	"Ingress":                  "networking.k8s.io",
+	"NetworkPolicy":            "networking.k8s.io",
+	"Role":                     "rbac.authorization.k8s.io",
+	"RoleBinding":              "rbac.authorization.k8s.io",
+	"ClusterRole":              "rbac.authorization.k8s.io",
+	"ClusterRoleBinding":       "rbac.authorization.k8s.io",
+	"HorizontalPodAutoscaler":  "autoscaling",
+	"StorageClass":             "storage.k8s.io",
+	"PriorityClass":            "scheduling.k8s.io",
+	"CustomResourceDefinition": "apiextensions.k8s.io",
+}
+
+// clusterScopedKinds are the built-in kinds that are not namespaced.
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                true,
+	"Node":                     true,
+	"PersistentVolume":         true,
+	"ClusterRole":              true,
+	"ClusterRoleBinding":       true,
+	"CustomResourceDefinition": true,
+	"StorageClass":             true,
+	"PriorityClass":            true,
+}
+
+// RESTMapperTTL is how long a discovered RESTMapper is reused for a cluster
+// before its API is discovered again.
+const RESTMapperTTL = 10 * time.Minute
+
+// RESTMapperFailureTTL is how long a failed discovery is remembered, so
+// callers against an unreachable cluster fall back to the built-in kinds
+// without discovering, and warning, on every call.
+const RESTMapperFailureTTL = 30 * time.Second
+
+// cachedMapper is a RESTMapper discovered from one API server, or the
+// error discovering it.
+type cachedMapper struct {
+	mapper     meta.RESTMapper
+	err        error
+	discovered time.Time
+}
+
+// recentFailure reports whether discovery of host failed less than
+// RESTMapperFailureTTL ago.
+func recentFailure(host string) bool {
+	mappersMu.Lock()
+	defer mappersMu.Unlock()
+	cached, ok := mappers[host]
+	return ok && cached.err != nil && time.Since(cached.discovered) < RESTMapperFailureTTL
+}
+
+var (
+	mappersMu sync.Mutex
+	mappers   = map[string]cachedMapper{}
+)
+
+// discover builds a RESTMapper from the discovery information of the API
+// server of config. It is a variable so tests can count discoveries.
+var discover = func(config *rest.Config) (meta.RESTMapper, error) {
+	dc, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not create discovery client for RESTMapper: %w", err)
+	}
+	gr, err := restmapper.GetAPIGroupResources(dc)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch API group resources for RESTMapper: %w", err)
+	}
+	return restmapper.NewDiscoveryRESTMapper(gr), nil
+}
+
+// NewRESTMapper returns a RESTMapper from the cluster's discovery
+// information, which knows every kind the cluster serves, custom resources
+// included. The mapper is shared by every caller for the same API server
+// for RESTMapperTTL, so applies and drift checks do not discover the API
+// each time. It returns nil when discovery fails, or failed within
+// RESTMapperFailureTTL, and Resolve then falls back to the built-in kinds.
+func NewRESTMapper(config *rest.Config) meta.RESTMapper {
+	if config == nil {
+		return nil
+	}
+	mappersMu.Lock()
+	cached, ok := mappers[config.Host]
+	mappersMu.Unlock()
+	if ok && cached.err == nil && time.Since(cached.discovered) < RESTMapperTTL {
+		return cached.mapper
+	}
+	return Rediscover(config)
+}
+
+// Rediscover discovers the API of the cluster again, replacing the shared
+// RESTMapper, for callers that know it changed, such as after applying
+// CRDs or when a kind does not resolve. Within RESTMapperFailureTTL of a
+// failed discovery it returns nil without trying again.
+func Rediscover(config *rest.Config) meta.RESTMapper {
+	if config == nil || recentFailure(config.Host) {
+		return nil
+	}
+	mapper, err := discover(config)
+	mappersMu.Lock()
+	defer mappersMu.Unlock()
+	if err != nil {
+		klog.Warningf("%v; falling back to static mapping", err)
+		mappers[config.Host] = cachedMapper{err: err, discovered: time.Now()}
+		return nil
+	}
+	mappers[config.Host] = cachedMapper{mapper: mapper, discovered: time.Now()}
+	return mapper
+}
+
+// Resolve returns the resource of kind in apiVersion from mapper, or, when
+// mapper is nil or does not know the kind (for example a custom resource
+// whose CRD is applied in the same manifest), from the built-in kinds.
+func Resolve(mapper meta.RESTMapper, apiVersion, kind string) (Mapping, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return Mapping{}, fmt.Errorf("parse apiVersion %q: %w", apiVersion, err)
+	}
+
+	if mapper != nil {
+		mapping, err := mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: kind}, gv.Version)
+		if err == nil {
+			return Mapping{
+				GVR:           mapping.Resource,
+				ClusterScoped: mapping.Scope != nil && mapping.Scope.Name() == meta.RESTScopeNameRoot,
+			}, nil
+		}
+		klog.V(2).Infof("RESTMapper lookup failed for kind=%s group=%s version=%s: %v; falling back to static mapping",
+			kind, gv.Group, gv.Version, err)
+	}
+
+	_, known := builtinResources[kind]
+	return Mapping{
+		GVR:           gv.WithResource(KindToResource(kind)),
+		ClusterScoped: IsClusterScoped(kind),
+		Guessed:       !known,
+	}, nil
+}
+
+// LookupResource resolves kind in apiVersion, as Resolve does, with the
+// shared RESTMapper of the cluster of config. A kind the mapper does not
+// know is resolved once more after discovering the API again, since its
+// CRD may have been installed after the mapper was discovered; only then
+// is the resource guessed.
+func LookupResource(config *rest.Config, apiVersion, kind string) (Mapping, error) {
+	mapper := NewRESTMapper(config)
+	mapping, err := Resolve(mapper, apiVersion, kind)
+	if err == nil && mapping.Guessed && mapper != nil {
+		mapping, err = Resolve(Rediscover(config), apiVersion, kind)
+	}
+	return mapping, err
+}
+
+// UnknownKindError is the error for a kind that discovery does not know,
+// usually a custom resource whose CRD is not installed.
+func UnknownKindError(apiVersion, kind string) error {
+	return fmt.Errorf("unknown resource kind: %s (%s); is its CustomResourceDefinition installed?", kind, apiVersion)
+}
+
+// CRDMappings returns the resources a CustomResourceDefinition object
+// defines, one per version, so its custom resources resolve before the CRD
+// is established, as in a dry run.
+func CRDMappings(crd map[string]interface{}) map[schema.GroupVersionKind]Mapping {
+	group, _, _ := unstructured.NestedString(crd, "spec", "group")
+	kind, _, _ := unstructured.NestedString(crd, "spec", "names", "kind")
+	plural, _, _ := unstructured.NestedString(crd, "spec", "names", "plural")
+	scope, _, _ := unstructured.NestedString(crd, "spec", "scope")
+	versions, _, _ := unstructured.NestedSlice(crd, "spec", "versions")
+	if group == "" || kind == "" || plural == "" {
+		return nil
+	}
+	mappings := make(map[schema.GroupVersionKind]Mapping, len(versions))
+	for _, v := range versions {
+		version, _ := v.(map[string]interface{})
+		name, _ := version["name"].(string)
+		if name == "" {
+			continue
+		}
+		mappings[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = Mapping{
+			GVR:           schema.GroupVersionResource{Group: group, Version: name, Resource: plural},
+			ClusterScoped: scope == "Cluster",
+		}
+	}
+	return mappings
+}
+
+// KindToResource returns the resource of a built-in kind, or the
+// lowercased plural of any other kind, as kubectl guesses it.
+func KindToResource(kind string) string {
+	if resource, ok := builtinResources[kind]; ok {
+		return resource
+	}
+	plural, _ := meta.UnsafeGuessKindToResource(schema.GroupVersionKind{Kind: kind})
+	return plural.Resource
+}
+
+// IsClusterScoped reports whether a built-in kind is cluster-scoped.
+func IsClusterScoped(kind string) bool {
+	return clusterScopedKinds[kind]
+}
+
+// ResolveGroupKind returns the resource of a kind without a version, such
+// as one recorded by an apply set, in its preferred version. Without
+// mapper, only the built-in kinds resolve.
+func ResolveGroupKind(mapper meta.RESTMapper, gk schema.GroupKind) (Mapping, error) {
+	if mapper != nil {
+		if mapping, err := mapper.RESTMapping(gk); err == nil {
+			return Mapping{
+				GVR:           mapping.Resource,
+				ClusterScoped: mapping.Scope != nil && mapping.Scope.Name() == meta.RESTScopeNameRoot,
+			}, nil
+		}
+	}
+	version, ok := builtinGroupVersions[gk.Group]
+	if _, known := builtinResources[gk.Kind]; !ok || !known {
+		return Mapping{}, fmt.Errorf("unknown resource kind: %s", gk)
+	}
+	return Resolve(nil, schema.GroupVersion{Group: gk.Group, Version: version}.String(), gk.Kind)
+}
+
+// ResolveKind returns the resource of a kind named as a user would, without
+// a version: Deployment, deployments, sts, or Rollout.argoproj.io. The group
+// of a kind without one is found by discovery, or for the built-in kinds,
+// from the built-in table, which also knows their short names.
+func ResolveKind(mapper meta.RESTMapper, kind string) (Mapping, schema.GroupVersionKind, error) {
+	if mapper != nil {
+		resource := schema.ParseGroupResource(strings.ToLower(kind))
+		if gvk, err := mapper.KindFor(resource.WithVersion("")); err == nil {
+			mapping, err := Resolve(mapper, gvk.GroupVersion().String(), gvk.Kind)
+			return mapping, gvk, err
+		}
+	}
+	gk := schema.ParseGroupKind(kind)
+	if builtin, ok := builtinKind(gk.Kind); ok {
+		gk.Kind = builtin
+	}
+	if gk.Group == "" {
+		gk.Group = builtinKindGroups[gk.Kind]
+	}
+	mapping, err := ResolveGroupKind(mapper, gk)
+	if err != nil {
+		return Mapping{}, schema.GroupVersionKind{}, err
+	}
+	return mapping, mapping.GVR.GroupVersion().WithKind(gk.Kind), nil
+}
+
+// LookupKind resolves kind, as ResolveKind does, with the shared RESTMapper
+// of the cluster of config. A kind the mapper does not know is looked up
+// once more after discovering the API again, since its CRD may have been
+// installed after the mapper was discovered.
+func LookupKind(config *rest.Config, kind string) (Mapping, schema.GroupVersionKind, error) {
+	mapper := NewRESTMapper(config)
+	mapping, gvk, err := ResolveKind(mapper, kind)
+	if err != nil && mapper != nil {
+		mapping, gvk, err = ResolveKind(Rediscover(config), kind)
+	}
+	if err != nil {
+		return Mapping{}, schema.GroupVersionKind{}, fmt.Errorf("%w; is its CustomResourceDefinition installed?", err)
+	}
+	return mapping, gvk, nil
+}
+
+// builtinKind returns the built-in kind that name refers to: the kind in
+// any case, its resource, or its short name.
+func builtinKind(name string) (string, bool) {
+	lower := strings.ToLower(name)
+	if kind, ok := builtinShortNames[lower]; ok {
+		return kind, true
+	}
+	for kind, resource := range builtinResources {
+		if strings.ToLower(kind) == lower || resource == lower {
+			return kind, true
+		}
+	}
+	return "", false
+}
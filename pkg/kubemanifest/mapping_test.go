@@ -0,0 +1,293 @@
+package kubemanifest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+func TestResolve(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}, meta.RESTScopeRoot)
+
+	got, err := Resolve(mapper, "cert-manager.io/v1", "ClusterIssuer")
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{GVR: schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}, ClusterScoped: true}, got)
+
+	// Kinds the mapper does not know fall back to the built-in table, and
+	// to a guessed plural after that.
+	got, err = Resolve(mapper, "storage.k8s.io/v1", "StorageClass")
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{GVR: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}, ClusterScoped: true}, got)
+
+	got, err = Resolve(nil, "example.io/v1", "Policy")
+	require.NoError(t, err)
+	assert.Equal(t, Mapping{GVR: schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "policies"}, Guessed: true}, got)
+
+	_, err = Resolve(nil, "a/b/c", "Widget")
+	assert.Error(t, err)
+}
+
+func TestNewRESTMapperIsCachedPerCluster(t *testing.T) {
+	discoveries := 0
+	fail := false
+	orig := discover
+	discover = func(config *rest.Config) (meta.RESTMapper, error) {
+		discoveries++
+		if fail {
+			return nil, errors.New("could not fetch API group resources for RESTMapper: unreachable")
+		}
+		return meta.NewDefaultRESTMapper(nil), nil
+	}
+	t.Cleanup(func() {
+		discover = orig
+		mappersMu.Lock()
+		clear(mappers)
+		mappersMu.Unlock()
+	})
+
+	east := &rest.Config{Host: "https://east.example.com"}
+	first := NewRESTMapper(east)
+	require.NotNil(t, first)
+	assert.Same(t, first, NewRESTMapper(&rest.Config{Host: east.Host}))
+	assert.Equal(t, 1, discoveries)
+
+	assert.NotNil(t, NewRESTMapper(&rest.Config{Host: "https://west.example.com"}))
+	assert.Equal(t, 2, discoveries)
+
+	second := Rediscover(east)
+	assert.NotSame(t, first, second)
+	assert.Same(t, second, NewRESTMapper(east))
+	assert.Equal(t, 3, discoveries)
+
+	// A failure is remembered for RESTMapperFailureTTL, so the next
+	// callers fall back without discovering again.
+	fail = true
+	assert.Nil(t, Rediscover(east))
+	assert.Nil(t, NewRESTMapper(east))
+	assert.Nil(t, Rediscover(east))
+	assert.Equal(t, 4, discoveries)
+
+	mappersMu.Lock()
+	failed := mappers[east.Host]
+	failed.discovered = failed.discovered.Add(-RESTMapperFailureTTL)
+	mappers[east.Host] = failed
+	mappersMu.Unlock()
+	fail = false
+	assert.NotNil(t, NewRESTMapper(east))
+	assert.Equal(t, 5, discoveries)
+
+	assert.Nil(t, NewRESTMapper(nil))
+}
+
+func TestCRDMappings(t *testing.T) {
+	crd := map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"spec": map[string]interface{}{
+			"group": "example.io",
+			"scope": "Cluster",
+			"names": map[string]interface{}{"kind": "Widget", "plural": "widgets"},
+			"versions": []interface{}{
+				map[string]interface{}{"name": "v1"},
+				map[string]interface{}{"name": "v1beta1"},
+			},
+		},
+	}
+
+	got := CRDMappings(crd)
+	assert.Equal(t, map[schema.GroupVersionKind]Mapping{
+		{Group: "example.io", Version: "v1", Kind: "Widget"}: {
+			GVR: schema.GroupVersionResource{Group: "example.io", Version: "v1", Resource: "widgets"}, ClusterScoped: true,
+		},
+		{Group: "example.io", Version: "v1beta1", Kind: "Widget"}: {
+			GVR: schema.GroupVersionResource{Group: "example.io", Version: "v1beta1", Resource: "widgets"}, ClusterScoped: true,
+		},
+	}, got)
+
+	assert.Empty(t, CRDMappings(map[string]interface{}{"kind": "CustomResourceDefinition"}))
+}
+
+func TestResolveKind(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}, meta.RESTScopeNamespace)
+
+	for _, kind := range []string{"Deployment", "deployments", "Deployment.apps"} {
+		got, gvk, err := ResolveKind(mapper, kind)
+		require.NoError(t, err, kind)
+		assert.Equal(t, schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, got.GVR, kind)
+		assert.Equal(t, schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, gvk, kind)
+	}
+	_, gvk, err := ResolveKind(mapper, "Rollout.argoproj.io")
+	require.NoError(t, err)
+	assert.Equal(t, "argoproj.io/v1alpha1", gvk.GroupVersion().String())
+
+	// Without discovery, only the built-in kinds resolve.
+	got, gvk, err := ResolveKind(nil, "CronJob")
+	require.NoError(t, err)
+	assert.Equal(t, schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}, got.GVR)
+	assert.Equal(t, "CronJob", gvk.Kind)
+	got, _, err = ResolveKind(nil, "Namespace")
+	require.NoError(t, err)
+	assert.True(t, got.ClusterScoped)
+	_, _, err = ResolveKind(nil, "Rollout")
+	assert.ErrorContains(t, err, "unknown resource kind")
+}
+
+func TestResolveKindBuiltinNames(t *testing.T) {
+	tests := []struct {
+		kind       string
+		group      string
+		version    string
+		resource   string
+		namespaced bool
+	}{
+		// Core v1 (namespaced)
+		{kind: "pod", version: "v1", resource: "pods", namespaced: true},
+		{kind: "Pods", version: "v1", resource: "pods", namespaced: true},
+		{kind: "service", version: "v1", resource: "services", namespaced: true},
+		{kind: "Services", version: "v1", resource: "services", namespaced: true},
+		{kind: "configmap", version: "v1", resource: "configmaps", namespaced: true},
+		{kind: "ConfigMaps", version: "v1", resource: "configmaps", namespaced: true},
+		{kind: "secret", version: "v1", resource: "secrets", namespaced: true},
+		{kind: "Secrets", version: "v1", resource: "secrets", namespaced: true},
+		{kind: "serviceaccount", version: "v1", resource: "serviceaccounts", namespaced: true},
+		{kind: "ServiceAccounts", version: "v1", resource: "serviceaccounts", namespaced: true},
+		{kind: "persistentvolumeclaim", version: "v1", resource: "persistentvolumeclaims", namespaced: true},
+		{kind: "PersistentVolumeClaims", version: "v1", resource: "persistentvolumeclaims", namespaced: true},
+		// Core v1 (cluster-scoped)
+		{kind: "namespace", version: "v1", resource: "namespaces", namespaced: false},
+		{kind: "Namespaces", version: "v1", resource: "namespaces", namespaced: false},
+		{kind: "persistentvolume", version: "v1", resource: "persistentvolumes", namespaced: false},
+		{kind: "PersistentVolumes", version: "v1", resource: "persistentvolumes", namespaced: false},
+		// Apps v1
+		{kind: "Deployment", group: "apps", version: "v1", resource: "deployments", namespaced: true},
+		{kind: "deployments", group: "apps", version: "v1", resource: "deployments", namespaced: true},
+		{kind: "statefulset", group: "apps", version: "v1", resource: "statefulsets", namespaced: true},
+		{kind: "StatefulSets", group: "apps", version: "v1", resource: "statefulsets", namespaced: true},
+		{kind: "daemonset", group: "apps", version: "v1", resource: "daemonsets", namespaced: true},
+		{kind: "DaemonSets", group: "apps", version: "v1", resource: "daemonsets", namespaced: true},
+		{kind: "replicaset", group: "apps", version: "v1", resource: "replicasets", namespaced: true},
+		{kind: "ReplicaSets", group: "apps", version: "v1", resource: "replicasets", namespaced: true},
+		// Batch v1
+		{kind: "job", group: "batch", version: "v1", resource: "jobs", namespaced: true},
+		{kind: "Jobs", group: "batch", version: "v1", resource: "jobs", namespaced: true},
+		{kind: "cronjob", group: "batch", version: "v1", resource: "cronjobs", namespaced: true},
+		{kind: "CronJobs", group: "batch", version: "v1", resource: "cronjobs", namespaced: true},
+		// Networking v1
+		{kind: "ingress", group: "networking.k8s.io", version: "v1", resource: "ingresses", namespaced: true},
+		{kind: "Ingresses", group: "networking.k8s.io", version: "v1", resource: "ingresses", namespaced: true},
+		{kind: "networkpolicy", group: "networking.k8s.io", version: "v1", resource: "networkpolicies", namespaced: true},
+		{kind: "NetworkPolicies", group: "networking.k8s.io", version: "v1", resource: "networkpolicies", namespaced: true},
+		// RBAC v1
+		{kind: "role", group: "rbac.authorization.k8s.io", version: "v1", resource: "roles", namespaced: true},
+		{kind: "Roles", group: "rbac.authorization.k8s.io", version: "v1", resource: "roles", namespaced: true},
+		{kind: "rolebinding", group: "rbac.authorization.k8s.io", version: "v1", resource: "rolebindings", namespaced: true},
+		{kind: "RoleBindings", group: "rbac.authorization.k8s.io", version: "v1", resource: "rolebindings", namespaced: true},
+		{kind: "clusterrole", group: "rbac.authorization.k8s.io", version: "v1", resource: "clusterroles", namespaced: false},
+		{kind: "ClusterRoles", group: "rbac.authorization.k8s.io", version: "v1", resource: "clusterroles", namespaced: false},
+		{kind: "clusterrolebinding", group: "rbac.authorization.k8s.io", version: "v1", resource: "clusterrolebindings", namespaced: false},
+		{kind: "ClusterRoleBindings", group: "rbac.authorization.k8s.io", version: "v1", resource: "clusterrolebindings", namespaced: false},
+		// HPA and other short names
+		{kind: "horizontalpodautoscaler", group: "autoscaling", version: "v2", resource: "horizontalpodautoscalers", namespaced: true},
+		{kind: "HorizontalPodAutoscalers", group: "autoscaling", version: "v2", resource: "horizontalpodautoscalers", namespaced: true},
+		{kind: "hpa", group: "autoscaling", version: "v2", resource: "horizontalpodautoscalers", namespaced: true},
+		{kind: "HPA", group: "autoscaling", version: "v2", resource: "horizontalpodautoscalers", namespaced: true},
+		{kind: "sts", group: "apps", version: "v1", resource: "statefulsets", namespaced: true},
+		{kind: "svc", version: "v1", resource: "services", namespaced: true},
+		{kind: "ns", version: "v1", resource: "namespaces", namespaced: false},
+	}
+
+	// Without discovery, built-in kinds resolve by kind in any case,
+	// resource, or short name.
+	for _, tt := range tests {
+		got, _, err := ResolveKind(nil, tt.kind)
+		require.NoError(t, err, tt.kind)
+		assert.Equal(t, schema.GroupVersionResource{Group: tt.group, Version: tt.version, Resource: tt.resource}, got.GVR, tt.kind)
+		assert.Equal(t, !tt.namespaced, got.ClusterScoped, tt.kind)
+	}
+	for _, kind := range []string{"Widget", ""} {
+		_, _, err := ResolveKind(nil, kind)
+		assert.Error(t, err, kind)
+	}
+}
+
+func TestLookupKindRediscoversOnMiss(t *testing.T) {
+	installed := false
+	discoveries := 0
+	orig := discover
+	discover = func(config *rest.Config) (meta.RESTMapper, error) {
+		discoveries++
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+		if installed {
+			mapper.Add(schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}, meta.RESTScopeNamespace)
+		}
+		return mapper, nil
+	}
+	t.Cleanup(func() {
+		discover = orig
+		mappersMu.Lock()
+		clear(mappers)
+		mappersMu.Unlock()
+	})
+	config := &rest.Config{Host: "https://east.example.com"}
+
+	_, _, err := LookupKind(config, "Rollout")
+	assert.ErrorContains(t, err, "is its CustomResourceDefinition installed?")
+	assert.Equal(t, 2, discoveries)
+
+	// The CRD is installed after the mapper was discovered: the miss
+	// discovers the API again.
+	_, _, err = LookupKind(config, "deploy")
+	require.NoError(t, err)
+	assert.Equal(t, 2, discoveries)
+	installed = true
+	got, gvk, err := LookupKind(config, "rollouts")
+	require.NoError(t, err)
+	assert.Equal(t, "rollouts", got.GVR.Resource)
+	assert.Equal(t, "argoproj.io/v1alpha1", gvk.GroupVersion().String())
+	assert.Equal(t, 3, discoveries)
+	_, _, err = LookupKind(config, "Rollout")
+	require.NoError(t, err)
+	assert.Equal(t, 3, discoveries)
+}
+
+func TestLookupResourceRediscoversOnMiss(t *testing.T) {
+	installed := false
+	discoveries := 0
+	orig := discover
+	discover = func(config *rest.Config) (meta.RESTMapper, error) {
+		discoveries++
+		mapper := meta.NewDefaultRESTMapper(nil)
+		if installed {
+			mapper.Add(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, meta.RESTScopeRoot)
+		}
+		return mapper, nil
+	}
+	t.Cleanup(func() {
+		discover = orig
+		mappersMu.Lock()
+		clear(mappers)
+		mappersMu.Unlock()
+	})
+	config := &rest.Config{Host: "https://east.example.com"}
+
+	got, err := LookupResource(config, "example.com/v1", "Widget")
+	require.NoError(t, err)
+	assert.True(t, got.Guessed)
+	assert.Equal(t, 2, discoveries)
+
+	installed = true
+	got, err = LookupResource(config, "example.com/v1", "Widget")
+	require.NoError(t, err)
+	assert.False(t, got.Guessed)
+	assert.True(t, got.ClusterScoped, "the discovered scope is used, not the guess")
+	assert.Equal(t, 3, discoveries)
+}
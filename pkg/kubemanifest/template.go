@@ -0,0 +1,107 @@
+package kubemanifest
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// VariableNamePattern is the syntax of a variable name.
+var VariableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// variableReference matches "$${", which escapes "${", and references
+// "${...}", terminated or not.
+var variableReference = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}?`)
+
+// Variables returns the sorted, distinct names of the variables the string
+// values of obj reference.
+func Variables(obj map[string]interface{}) ([]string, error) {
+	seen := make(map[string]bool)
+	_, err := substituteValue(runtime.DeepCopyJSON(obj), nil, func(name string) (string, error) {
+		seen[name] = true
+		return "", nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Substitute replaces each ${NAME} in the string values of obj with
+// vars[NAME]; "$${" stands for a literal "${". Only values are substituted,
+// so a variable can never change the structure of an object. A reference
+// to a variable vars does not define is an error.
+func Substitute(obj map[string]interface{}, vars map[string]string) error {
+	_, err := substituteValue(obj, nil, func(name string) (string, error) {
+		value, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("undefined variable ${%s}", name)
+		}
+		return value, nil
+	})
+	return err
+}
+
+// substituteValue returns v with the variable references in its strings
+// replaced by lookup, updating maps and slices in place.
+func substituteValue(v interface{}, path []string, lookup func(string) (string, error)) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			value, err := substituteValue(item, append(path, k), lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = value
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			value, err := substituteValue(item, append(path, fmt.Sprint(i)), lookup)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+		}
+		return v, nil
+	case string:
+		return substituteString(v, path, lookup)
+	}
+	return v, nil
+}
+
+func substituteString(s string, path []string, lookup func(string) (string, error)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	field := strings.Join(path, ".")
+	var firstErr error
+	out := variableReference.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		if firstErr != nil {
+			return ref
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(ref, "${"), "}")
+		if !strings.HasSuffix(ref, "}") || !VariableNamePattern.MatchString(name) {
+			firstErr = fmt.Errorf("%s: invalid variable reference %q; write $${ for a literal ${", field, ref)
+			return ref
+		}
+		value, err := lookup(name)
+		if err != nil {
+			firstErr = fmt.Errorf("%s: %w", field, err)
+			return ref
+		}
+		return value
+	})
+	return out, firstErr
+}
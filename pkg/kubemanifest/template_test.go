@@ -0,0 +1,73 @@
+package kubemanifest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const templatedIngress = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  namespace: shop
+  annotations:
+    script: 'echo $${HOME}'
+spec:
+  rules:
+  - host: web.${CLUSTER_NAME}.${DOMAIN}
+  ingressClassName: ${CLASS}
+`
+
+func TestSubstitute(t *testing.T) {
+	objects, err := DecodeString(templatedIngress)
+	require.NoError(t, err)
+	obj := objects[0].Object
+
+	names, err := Variables(obj)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CLASS", "CLUSTER_NAME", "DOMAIN"}, names)
+	assert.Equal(t, "web.${CLUSTER_NAME}.${DOMAIN}", obj["spec"].(map[string]interface{})["rules"].([]interface{})[0].(map[string]interface{})["host"], "Variables must not change the object")
+
+	err = Substitute(obj, map[string]string{"CLUSTER_NAME": "east", "DOMAIN": "example.com"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "undefined variable ${CLASS}")
+
+	objects, _ = DecodeString(templatedIngress)
+	obj = objects[0].Object
+	require.NoError(t, Substitute(obj, map[string]string{"CLUSTER_NAME": "east", "DOMAIN": "example.com", "CLASS": "nginx"}))
+	spec := obj["spec"].(map[string]interface{})
+	assert.Equal(t, "web.east.example.com", spec["rules"].([]interface{})[0].(map[string]interface{})["host"])
+	assert.Equal(t, "nginx", spec["ingressClassName"])
+	assert.Equal(t, "echo ${HOME}", objects[0].GetAnnotations()["script"])
+
+	objects, _ = DecodeString("apiVersion: v1\nkind: ConfigMap\nmetadata: {name: 'app-${CLUSTER_NAME}', namespace: '${NS}'}\n")
+	require.NoError(t, Substitute(objects[0].Object, map[string]string{"CLUSTER_NAME": "east", "NS": "shop"}))
+	assert.Equal(t, "app-east", objects[0].GetName())
+	assert.Equal(t, "shop", objects[0].GetNamespace())
+}
+
+func TestSubstituteRejects(t *testing.T) {
+	for name, tc := range map[string]struct {
+		manifest string
+		want     string
+	}{
+		"unterminated": {
+			manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: app}\ndata: {a: '${CLUSTER_NAME'}\n",
+			want:     `data.a: invalid variable reference "${CLUSTER_NAME"`,
+		},
+		"shell default": {
+			manifest: "apiVersion: v1\nkind: ConfigMap\nmetadata: {name: app}\ndata: {a: '${X:-1}'}\n",
+			want:     `invalid variable reference "${X:-1}"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			objects, err := DecodeString(tc.manifest)
+			require.NoError(t, err)
+			err = Substitute(objects[0].Object, map[string]string{"CLUSTER_NAME": "east", "NS": "shop"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
@@ -35,6 +35,14 @@ type Response struct {
 	Error   *Error      `json:"error,omitempty"`
 }
 
+// Notification represents an outgoing JSON-RPC notification. Notifications
+// carry no ID and expect no response.
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 // Error represents a JSON-RPC error object.
 type Error struct {
 	Code    int         `json:"code"`
@@ -59,7 +67,9 @@ type InitializeResult struct {
 
 // Capabilities describes the server's MCP capabilities.
 type Capabilities struct {
-	Tools *ToolsCapability `json:"tools,omitempty"`
+	Tools     *ToolsCapability     `json:"tools,omitempty"`
+	Resources *ResourcesCapability `json:"resources,omitempty"`
+	Prompts   *PromptsCapability   `json:"prompts,omitempty"`
 }
 
 // ToolsCapability describes the tool-related capabilities.
@@ -67,6 +77,17 @@ type ToolsCapability struct {
 	ListChanged bool `json:"listChanged,omitempty"`
 }
 
+// ResourcesCapability describes the resource-related capabilities.
+type ResourcesCapability struct {
+	Subscribe   bool `json:"subscribe,omitempty"`
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
+// PromptsCapability describes the prompt-related capabilities.
+type PromptsCapability struct {
+	ListChanged bool `json:"listChanged,omitempty"`
+}
+
 // Tool describes an MCP tool schema.
 type Tool struct {
 	Name        string      `json:"name"`
@@ -103,12 +124,31 @@ type ToolsListResult struct {
 type CallToolParams struct {
 	Name      string                 `json:"name"`
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Meta      *RequestMeta           `json:"_meta,omitempty"`
+}
+
+// RequestMeta is the optional _meta object a client attaches to a request.
+// A non-nil ProgressToken asks the server for notifications/progress updates.
+type RequestMeta struct {
+	ProgressToken interface{} `json:"progressToken,omitempty"`
+}
+
+// ProgressNotificationMethod is the method name of MCP progress updates.
+const ProgressNotificationMethod = "notifications/progress"
+
+// ProgressParams is the payload of a notifications/progress message.
+type ProgressParams struct {
+	ProgressToken interface{} `json:"progressToken"`
+	Progress      float64     `json:"progress"`
+	Total         float64     `json:"total,omitempty"`
+	Message       string      `json:"message,omitempty"`
 }
 
 // CallToolResult is the result of a tools/call invocation.
 type CallToolResult struct {
-	Content []ContentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	Content []ContentBlock         `json:"content"`
+	IsError bool                   `json:"isError,omitempty"`
+	Meta    map[string]interface{} `json:"_meta,omitempty"`
 }
 
 // ContentBlock represents a content block in tool results.
@@ -117,6 +157,73 @@ type ContentBlock struct {
 	Text string `json:"text"`
 }
 
+// Resource describes a resource a client can read with resources/read.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourcesListResult wraps the resources/list response.
+type ResourcesListResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+// ReadResourceParams is the params for a resources/read request.
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+// ResourceContents is the text content of a resource.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text"`
+}
+
+// ReadResourceResult is the result of a resources/read request.
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+// Prompt describes a prompt template a client can get with prompts/get.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument of a prompt template.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptsListResult wraps the prompts/list response.
+type PromptsListResult struct {
+	Prompts []Prompt `json:"prompts"`
+}
+
+// GetPromptParams is the params for a prompts/get request.
+type GetPromptParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
+// PromptMessage is a message of a rendered prompt.
+type PromptMessage struct {
+	Role    string       `json:"role"`
+	Content ContentBlock `json:"content"`
+}
+
+// GetPromptResult is the result of a prompts/get request.
+type GetPromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
 // --- Transport helpers ---
 
 // Writer provides thread-safe JSON-RPC response writing over a line-delimited stream.
@@ -166,6 +273,22 @@ func (w *Writer) Send(resp Response) {
 	_, _ = fmt.Fprintf(w.w, "%s\n", data)
 }
 
+// SendNotification sends a JSON-RPC notification.
+func (w *Writer) SendNotification(method string, params interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := json.Marshal(Notification{
+		JSONRPC: JSONRPCVersion,
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w.w, "%s\n", data)
+}
+
 // TextResult is a convenience helper that builds a CallToolResult with a single text block.
 func TextResult(text string) CallToolResult {
 	return CallToolResult{
@@ -48,6 +48,28 @@ func TestWriterSendError(t *testing.T) {
 	}
 }
 
+func TestWriterSendNotification(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	w.SendNotification(ProgressNotificationMethod, ProgressParams{ProgressToken: "tok", Progress: 1, Total: 3})
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, hasID := got["id"]; hasID {
+		t.Errorf("notification must not carry an id: %v", got)
+	}
+	if got["method"] != ProgressNotificationMethod {
+		t.Errorf("method = %v, want %s", got["method"], ProgressNotificationMethod)
+	}
+	params, _ := got["params"].(map[string]interface{})
+	if params["progressToken"] != "tok" || params["progress"] != float64(1) || params["total"] != float64(3) {
+		t.Errorf("unexpected params: %v", params)
+	}
+}
+
 func TestTextResult(t *testing.T) {
 	r := TextResult("hello")
 	if len(r.Content) != 1 {
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// callMutatingTool runs a tool that changes cluster state through the
+// approval gate. Unapproved calls run with every write sent as a
+// server-side dry run, and the result carries the plan to approve. Calls
+// the authorization policy requires approval for (requireApproval) are gated
+// even when approval mode is off.
+func (s *Server) callMutatingTool(ctx context.Context, td *ToolDef, args map[string]interface{}, requireApproval []string) CallToolResult {
+	gate := s.getApprovalGate()
+	check := gate.Check
+	if len(requireApproval) > 0 {
+		check = gate.CheckRequired
+	}
+	decision, err := check(ctx, td.Schema.Name, args)
+	if err != nil {
+		return CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}
+	}
+	if !decision.DryRun {
+		rec := s.getJournal().Begin(td.Schema.Name)
+		text, isError := td.Handler(journal.WithRecorder(ctx, rec), s, decision.Args)
+		if !approval.DryRunRequested(decision.Args) {
+			// Cached results may predate the change.
+			s.getResultCache().Clear()
+		}
+		// Journal whatever changed, even if the tool failed part way.
+		meta := s.commitChange(ctx, rec)
+		content := []ContentBlock{{Type: "text", Text: text}}
+		if notUndoable, _ := meta["notUndoable"].([]string); len(notUndoable) > 0 {
+			content = append(content, ContentBlock{Type: "text",
+				Text: "undo_change cannot revert:\n- " + strings.Join(notUndoable, "\n- ")})
+		}
+		return CallToolResult{Content: content, IsError: isError, Meta: meta}
+	}
+
+	text, isError := td.Handler(approval.WithDryRun(ctx), s, decision.Args)
+	result := CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: text}},
+		IsError: isError,
+	}
+	if isError {
+		return result
+	}
+	plan, err := gate.RecordPlan(ctx, td.Schema.Name, args)
+	if err != nil {
+		result.Content = append(result.Content, ContentBlock{Type: "text", Text: err.Error()})
+		result.IsError = true
+		return result
+	}
+	if len(requireApproval) > 0 {
+		result.Content = append(result.Content, ContentBlock{Type: "text", Text: policyApprovalNote(requireApproval)})
+	}
+	result.Content = append(result.Content, ContentBlock{Type: "text", Text: plan.Note()})
+	result.Meta = plan.Meta()
+	return result
+}
+
+// getApprovalGate returns the gate configured by $KUBESTELLAR_APPROVAL_MODE,
+// keeping plans in the state store.
+func (s *Server) getApprovalGate() *approval.Gate {
+	s.approvalGateOnce.Do(func() {
+		if s.approvalGate == nil {
+			s.approvalGate = approval.GateFromEnv(s.getStateStore())
+		}
+	})
+	return s.approvalGate
+}
+
+// getStateStore returns the store selected by $KUBESTELLAR_STATE_STORE,
+// shared by approval plans and the change journal. If it cannot be opened
+// state is kept in memory.
+func (s *Server) getStateStore() store.Store {
+	s.stateStoreOnce.Do(func() {
+		if s.stateStore != nil {
+			return
+		}
+		st, err := store.FromEnv(s.stateStoreClient)
+		if err != nil {
+			log.Printf("Failed to open state store, keeping state in memory: %v", err)
+			st = store.NewMemory()
+		}
+		s.stateStore = st
+	})
+	return s.stateStore
+}
+
+// stateStoreClient builds a client for the current kubeconfig context with
+// the server's own identity. Server state is not the caller's to read or
+// write, so impersonation and guardrails are not applied.
+func (s *Server) stateStoreClient() (kubernetes.Interface, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if s.kubeconfig != "" {
+		loadingRules.ExplicitPath = s.kubeconfig
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		loadingRules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
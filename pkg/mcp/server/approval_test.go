@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+func TestCallMutatingTool_DryRunsUntilApproved(t *testing.T) {
+	var dryRuns []bool
+	td := &ToolDef{
+		Schema:   Tool{Name: "set_ownership_policy_mode"},
+		Mutating: true,
+		Handler: func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			dryRuns = append(dryRuns, approval.IsDryRun(ctx))
+			_, leaked := args[approval.ArgApproved]
+			assert.False(t, leaked, "approval arguments must not reach the handler")
+			return "mode updated", false
+		},
+	}
+	s := &Server{approvalGate: approval.NewGate(approval.ModePlan, store.NewMemory())}
+	args := map[string]interface{}{"mode": "enforce"}
+
+	preview := s.callMutatingTool(context.Background(), td, args, nil)
+	require.False(t, preview.IsError)
+	require.Len(t, preview.Content, 2)
+	planID, _ := preview.Meta["planId"].(string)
+	require.NotEmpty(t, planID)
+	assert.Contains(t, preview.Content[1].Text, planID)
+
+	denied := s.callMutatingTool(context.Background(), td, map[string]interface{}{"mode": "enforce", "approved": true}, nil)
+	assert.True(t, denied.IsError)
+
+	applied := s.callMutatingTool(context.Background(), td, map[string]interface{}{"mode": "enforce", "approved": true, "plan_id": planID}, nil)
+	assert.False(t, applied.IsError)
+	assert.Nil(t, applied.Meta)
+	assert.Equal(t, []bool{true, false}, dryRuns)
+}
+
+func TestCallMutatingTool_ClearsResultCache(t *testing.T) {
+	td := &ToolDef{
+		Schema:   Tool{Name: "trigger_cronjob"},
+		Mutating: true,
+		Handler: func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return "triggered", false
+		},
+	}
+	s := &Server{approvalGate: approval.NewGate(approval.ModeOff, store.NewMemory())}
+	cached := func() bool {
+		_, ok := s.getResultCache().Get("list_jobs", time.Hour, cache.Options{})
+		return ok
+	}
+
+	s.getResultCache().Put("list_jobs", "before")
+	s.callMutatingTool(context.Background(), td, map[string]interface{}{approval.ArgDryRun: true}, nil)
+	assert.True(t, cached(), "a dry run changes nothing, so the cache is kept")
+
+	s.callMutatingTool(context.Background(), td, map[string]interface{}{}, nil)
+	assert.False(t, cached(), "results cached before a change must not be served after it")
+}
+
+func TestRegisterMutatingTool_AddsApprovalArguments(t *testing.T) {
+	td := findToolDef("uninstall_ownership_policy")
+	require.NotNil(t, td)
+	assert.True(t, td.Mutating)
+	assert.Contains(t, td.Schema.InputSchema.Properties, approval.ArgApproved)
+	assert.Contains(t, td.Schema.InputSchema.Properties, approval.ArgPlanID)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// maxAuditEntries bounds the audit bucket; the oldest entries are dropped
+// first. auditTrimEvery is how many writes pass between trims.
+const (
+	maxAuditEntries = 1000
+	auditTrimEvery  = 50
+)
+
+// auditEntry records one tool call made over HTTP and who made it.
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	User     string    `json:"user,omitempty"`
+	Groups   []string  `json:"groups,omitempty"`
+	Tool     string    `json:"tool"`
+	Error    bool      `json:"error,omitempty"`
+	ChangeID string    `json:"changeId,omitempty"`
+	PlanID   string    `json:"planId,omitempty"`
+}
+
+// audit records a tool call received over HTTP in the state store's audit
+// bucket. Calls over stdio come from a single local user and are not
+// recorded.
+func (s *Server) audit(ctx context.Context, tool string, result CallToolResult) {
+	if !isHTTPRequest(ctx) {
+		return
+	}
+	now := time.Now().UTC()
+	entry := auditEntry{Time: now, Tool: tool, Error: result.IsError}
+	if caller := callerFrom(ctx); caller != nil {
+		entry.User, entry.Groups = caller.User, caller.Groups
+	}
+	entry.ChangeID, _ = result.Meta["changeId"].(string)
+	entry.PlanID, _ = result.Meta["planId"].(string)
+
+	var b [4]byte
+	_, _ = rand.Read(b[:])
+	key := now.Format("20060102T150405.000000000") + "-" + hex.EncodeToString(b[:])
+	st := s.getStateStore()
+	if err := store.PutJSON(ctx, st, store.BucketAudit, key, entry); err != nil {
+		log.Printf("Failed to record audit entry for %s: %v", tool, err)
+		return
+	}
+	if s.auditWrites.Add(1)%auditTrimEvery == 0 {
+		trimBucket(ctx, st, store.BucketAudit, maxAuditEntries)
+	}
+}
+
+// trimBucket drops the oldest keys beyond max from a bucket whose keys sort
+// by time.
+func trimBucket(ctx context.Context, st store.Store, bucket string, max int) {
+	items, err := st.List(ctx, bucket)
+	if err != nil || len(items) <= max {
+		return
+	}
+	for _, item := range items[:len(items)-max] {
+		_ = st.Delete(ctx, bucket, item.Key)
+	}
+}
@@ -0,0 +1,241 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+	"github.com/kubestellar/kubestellar-mcp/pkg/output"
+)
+
+// ArgDetail selects whether a summarized tool returns a summary with a
+// details token or its full result.
+const ArgDetail = "detail"
+
+// Values of ArgDetail. Auto summarizes results larger than
+// summaryThreshold; it is the default for MCP clients, while callers of
+// CallTool get full results unless they ask otherwise.
+const (
+	detailAuto    = "auto"
+	detailSummary = "summary"
+	detailFull    = "full"
+)
+
+const (
+	// summaryThreshold is the largest result auto returns whole.
+	summaryThreshold = 8 << 10
+	// detailsTTL is how long get_details can expand a summary.
+	detailsTTL = 30 * time.Minute
+	// maxDetailsEntries bounds the full results kept for get_details.
+	maxDetailsEntries = 64
+	// maxSummarySections and maxSummaryLines bound the section index and
+	// the preamble of a summary.
+	maxSummarySections = 50
+	maxSummaryLines    = 20
+)
+
+// detailsEntry is a full result kept behind a details token.
+type detailsEntry struct {
+	tool     string
+	owner    string
+	text     string
+	preamble string
+	sections []output.Section
+}
+
+func (s *Server) getDetails() *cache.ResultCache {
+	s.detailsOnce.Do(func() {
+		s.details = cache.NewResultCache(maxDetailsEntries)
+	})
+	return s.details
+}
+
+// parseDetail returns the detail level requested in args, or def if none
+// was.
+func parseDetail(args map[string]interface{}, def string) (string, error) {
+	v, ok := args[ArgDetail]
+	if !ok || v == nil {
+		return def, nil
+	}
+	switch detail, _ := v.(string); detail {
+	case detailAuto, detailSummary, detailFull:
+		return detail, nil
+	}
+	return "", fmt.Errorf("invalid %s %v: must be auto, summary, or full", ArgDetail, v)
+}
+
+// callerName returns the HTTP caller of ctx, or "" over stdio.
+func callerName(ctx context.Context) string {
+	if caller := callerFrom(ctx); caller != nil && isHTTPRequest(ctx) {
+		return caller.User
+	}
+	return ""
+}
+
+// summarizeResult replaces a large result with its preamble and an index
+// of its sections, and keeps the full result for get_details under the
+// token returned in _meta.detailsToken. Unless always is set, results up
+// to summaryThreshold are returned whole.
+func (s *Server) summarizeResult(ctx context.Context, tool string, result CallToolResult, always bool) CallToolResult {
+	if len(result.Content) != 1 || (!always && len(result.Content[0].Text) <= summaryThreshold) {
+		return result
+	}
+	text := result.Content[0].Text
+	preamble, sections := output.Sections(text)
+
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	token := "dt-" + hex.EncodeToString(b[:])
+	s.getDetails().Put(token, detailsEntry{tool: tool, owner: callerName(ctx), text: text, preamble: preamble, sections: sections})
+
+	var sb strings.Builder
+	sb.WriteString(firstLines(preamble, maxSummaryLines))
+	if len(sections) == 0 {
+		lines := strings.Count(text, "\n") + 1
+		_, _ = fmt.Fprintf(&sb, "\n\n**Summary:** showing %d of %d lines (%d KB in full). Call get_details with this details_token and section \"all\" for the rest, or a search term for the lines that mention it.\n", min(lines, maxSummaryLines), lines, len(text)>>10)
+	} else {
+		items := 0
+		for _, sec := range sections {
+			items += len(sec.Items)
+		}
+		_, _ = fmt.Fprintf(&sb, "\n\n**Summary:** %d sections, %d items (%d KB in full). Call get_details with this details_token and a section ID, a finding ID such as s1.2, or a name such as a cluster or namespace to expand it.\n\n", len(sections), items, len(text)>>10)
+		writeSectionIndex(&sb, sections)
+	}
+	_, _ = fmt.Fprintf(&sb, "\n**details_token:** `%s`\n", token)
+
+	result.Content = []ContentBlock{{Type: "text", Text: sb.String()}}
+	if result.Meta == nil {
+		result.Meta = make(map[string]interface{})
+	}
+	result.Meta["detailsToken"] = token
+	return result
+}
+
+// writeSectionIndex lists sections with their IDs and item counts.
+func writeSectionIndex(sb *strings.Builder, sections []output.Section) {
+	sb.WriteString("| ID | Section | Items |\n|----|---------|-------|\n")
+	for i, sec := range sections {
+		if i == maxSummarySections {
+			_, _ = fmt.Fprintf(sb, "\n%d more sections; call get_details with a name to find one.\n", len(sections)-maxSummarySections)
+			break
+		}
+		_, _ = fmt.Fprintf(sb, "| s%d | %s | %d |\n", i+1, strings.ReplaceAll(sec.Title, "|", `\|`), len(sec.Items))
+	}
+}
+
+func firstLines(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= n {
+		return text
+	}
+	return strings.Join(lines[:n], "\n") + "\n..."
+}
+
+var (
+	sectionIDPattern = regexp.MustCompile(`^s(\d+)$`)
+	findingIDPattern = regexp.MustCompile(`^s(\d+)\.(\d+)$`)
+)
+
+func (s *Server) toolGetDetails(ctx context.Context, args map[string]interface{}) (string, bool) {
+	token, _ := args["details_token"].(string)
+	section, _ := args["section"].(string)
+	section = strings.TrimSpace(section)
+	if token == "" {
+		return "details_token is required", true
+	}
+	found, ok := s.getDetails().Get(token, detailsTTL, cache.Options{})
+	entry, _ := found.Value.(detailsEntry)
+	if !ok || entry.owner != callerName(ctx) {
+		return fmt.Sprintf("details token %s was not found or has expired (tokens last %s); run the tool again", token, detailsTTL), true
+	}
+
+	sectionAt := func(id string) (output.Section, error) {
+		n, _ := strconv.Atoi(id)
+		if n < 1 || n > len(entry.sections) {
+			return output.Section{}, fmt.Errorf("no section s%s: the %s result has %d sections", id, entry.tool, len(entry.sections))
+		}
+		return entry.sections[n-1], nil
+	}
+	switch {
+	case section == "":
+		var sb strings.Builder
+		_, _ = fmt.Fprintf(&sb, "# Sections of %s\n\n", entry.tool)
+		if len(entry.sections) == 0 {
+			sb.WriteString("This result has no sections; use section \"all\" or a search term.\n")
+		} else {
+			writeSectionIndex(&sb, entry.sections)
+		}
+		return sb.String(), false
+	case section == "all":
+		return entry.text, false
+	case sectionIDPattern.MatchString(section):
+		sec, err := sectionAt(sectionIDPattern.FindStringSubmatch(section)[1])
+		if err != nil {
+			return err.Error(), true
+		}
+		return sec.Text, false
+	case findingIDPattern.MatchString(section):
+		m := findingIDPattern.FindStringSubmatch(section)
+		sec, err := sectionAt(m[1])
+		if err != nil {
+			return err.Error(), true
+		}
+		n, _ := strconv.Atoi(m[2])
+		if n < 1 || n > len(sec.Items) {
+			return fmt.Sprintf("no finding %s: section s%s has %d items", section, m[1], len(sec.Items)), true
+		}
+		return fmt.Sprintf("%s\n- %s\n", sec.Title, sec.Items[n-1]), false
+	}
+
+	if text := searchDetails(entry, section); text != "" {
+		return text, false
+	}
+	return fmt.Sprintf("Nothing in the %s result matches %q; call get_details without section for the section IDs", entry.tool, section), true
+}
+
+// searchDetails returns the sections whose titles contain query, and in
+// the other sections the parts whose titles or lines do, such as the pods
+// of a namespace under a cluster.
+func searchDetails(entry detailsEntry, query string) string {
+	query = strings.ToLower(query)
+	matches := func(s string) bool { return strings.Contains(strings.ToLower(s), query) }
+	sections := entry.sections
+	if len(sections) == 0 {
+		sections = []output.Section{{Text: entry.text}}
+	}
+	var parts []string
+	for _, sec := range sections {
+		if sec.Title != "" && matches(sec.Title) {
+			parts = append(parts, sec.Text)
+			continue
+		}
+		var found []string
+		if _, subs := output.Sections(sec.Text); len(subs) > 0 {
+			for _, sub := range subs {
+				if matches(sub.Text) {
+					found = append(found, sub.Text)
+				}
+			}
+		} else {
+			for _, line := range strings.Split(sec.Text, "\n") {
+				if matches(line) {
+					found = append(found, line)
+				}
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		if sec.Title != "" {
+			found = append([]string{"## " + sec.Title}, found...)
+		}
+		parts = append(parts, strings.Join(found, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+)
+
+// bigSecurityReport returns a check_security_issues-style result for n
+// pods, large enough to be summarized.
+func bigSecurityReport(n int) string {
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "Found %d pods with security concerns:\n🔴 Critical | 🟠 High | 🟡 Medium\n", n)
+	for i := 0; i < n; i++ {
+		ns := "shop"
+		if i%2 == 1 {
+			ns = "payments"
+		}
+		_, _ = fmt.Fprintf(&sb, "\n🔓 %s/pod-%d\n", ns, i)
+		_, _ = fmt.Fprintf(&sb, "   - 🔴 Container app is privileged\n")
+		_, _ = fmt.Fprintf(&sb, "   - 🟡 Container app has writable root filesystem, which lets an attacker persist changes\n")
+	}
+	return sb.String()
+}
+
+// registerSummarizedTestTool registers a summarized tool returning text
+// for the duration of the test.
+func registerSummarizedTestTool(t *testing.T, text string) {
+	t.Helper()
+	n := len(toolRegistry)
+	RegisterCachedTool(Tool{Name: "test_big_scan", InputSchema: InputSchema{Type: "object"}}, 0,
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return text, false
+		})
+	t.Cleanup(func() { toolRegistry = toolRegistry[:n] })
+}
+
+func callForTest(t *testing.T, s *Server, ctx context.Context, args map[string]interface{}) CallToolResult {
+	t.Helper()
+	var got Response
+	ctx = context.WithValue(ctx, httpRequestKey{}, &httpRequest{respond: func(r Response) { got = r }, caller: callerFrom(ctx)})
+	params, _ := json.Marshal(CallToolParams{Name: "test_big_scan", Arguments: args})
+	s.handleToolsCall(ctx, &Request{JSONRPC: "2.0", ID: 1, Method: "tools/call", Params: params})
+	require.Nil(t, got.Error)
+	result, ok := got.Result.(CallToolResult)
+	require.True(t, ok)
+	return result
+}
+
+func TestSummarizedTool_MCPGetsSummary(t *testing.T) {
+	report := bigSecurityReport(100)
+	registerSummarizedTestTool(t, report)
+	s := &Server{}
+
+	result := callForTest(t, s, context.Background(), nil)
+	text := result.Content[0].Text
+	assert.Less(t, len(text), len(report)/2)
+	assert.Contains(t, text, "Found 100 pods with security concerns")
+	assert.Contains(t, text, "**Summary:** 100 sections, 200 items")
+	assert.Contains(t, text, "| s1 | 🔓 shop/pod-0 | 2 |")
+	assert.Contains(t, text, "50 more sections")
+	token, _ := result.Meta["detailsToken"].(string)
+	require.NotEmpty(t, token)
+	assert.Contains(t, text, token)
+
+	out, isErr := s.toolGetDetails(context.Background(), map[string]interface{}{"details_token": token, "section": "s2"})
+	require.False(t, isErr, out)
+	assert.Equal(t, "🔓 payments/pod-1\n   - 🔴 Container app is privileged\n   - 🟡 Container app has writable root filesystem, which lets an attacker persist changes", out)
+
+	out, isErr = s.toolGetDetails(context.Background(), map[string]interface{}{"details_token": token, "section": "s3.1"})
+	require.False(t, isErr, out)
+	assert.Equal(t, "🔓 shop/pod-2\n- 🔴 Container app is privileged\n", out)
+
+	out, isErr = s.toolGetDetails(context.Background(), map[string]interface{}{"details_token": token, "section": "pod-42"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "🔓 shop/pod-42")
+	assert.NotContains(t, out, "pod-41")
+
+	out, isErr = s.toolGetDetails(context.Background(), map[string]interface{}{"details_token": token, "section": "all"})
+	require.False(t, isErr)
+	assert.Equal(t, report, out)
+
+	out, isErr = s.toolGetDetails(context.Background(), map[string]interface{}{"details_token": token, "section": "s101"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "has 100 sections")
+	out, isErr = s.toolGetDetails(context.Background(), map[string]interface{}{"details_token": token, "section": "nothing-like-this"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "Nothing in the test_big_scan result matches")
+}
+
+func TestSummarizedTool_DetailLevels(t *testing.T) {
+	report := bigSecurityReport(100)
+	registerSummarizedTestTool(t, report)
+	s := &Server{}
+
+	full := callForTest(t, s, context.Background(), map[string]interface{}{ArgDetail: detailFull})
+	assert.Equal(t, report, full.Content[0].Text)
+	assert.NotContains(t, full.Meta, "detailsToken")
+
+	result, err := s.CallTool(context.Background(), "test_big_scan", nil)
+	require.NoError(t, err)
+	assert.Equal(t, report, result.Content[0].Text, "CallTool callers get the full result by default")
+
+	result, err = s.CallTool(context.Background(), "test_big_scan", map[string]interface{}{ArgDetail: "everything"})
+	require.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "invalid detail")
+}
+
+func TestSummarizedTool_SmallResultsStayWhole(t *testing.T) {
+	report := bigSecurityReport(2)
+	registerSummarizedTestTool(t, report)
+	s := &Server{}
+
+	result := callForTest(t, s, context.Background(), nil)
+	assert.Equal(t, report, result.Content[0].Text)
+
+	result = callForTest(t, s, context.Background(), map[string]interface{}{ArgDetail: detailSummary})
+	assert.Contains(t, result.Content[0].Text, "**Summary:** 2 sections, 4 items")
+}
+
+func TestGetDetails_TokenBelongsToCaller(t *testing.T) {
+	registerSummarizedTestTool(t, bigSecurityReport(100))
+	s := &Server{}
+	alice := WithRemoteCaller(context.Background(), &auth.Identity{User: "alice"})
+	bob := WithRemoteCaller(context.Background(), &auth.Identity{User: "bob"})
+
+	result := callForTest(t, s, alice, nil)
+	token, _ := result.Meta["detailsToken"].(string)
+	require.NotEmpty(t, token)
+
+	_, isErr := s.toolGetDetails(alice, map[string]interface{}{"details_token": token})
+	assert.False(t, isErr)
+	out, isErr := s.toolGetDetails(bob, map[string]interface{}{"details_token": token})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "was not found or has expired")
+}
+
+func TestCallTool_ASCII(t *testing.T) {
+	registerSummarizedTestTool(t, bigSecurityReport(2))
+	t.Setenv(messages.EnvASCII, "true")
+	s := &Server{}
+
+	result, err := s.CallTool(context.Background(), "test_big_scan", nil)
+	require.NoError(t, err)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "[CRITICAL] Critical | [HIGH] High | [MEDIUM] Medium")
+	assert.Contains(t, text, "\nshop/pod-0\n   - [CRITICAL] Container app is privileged")
+	for _, r := range text {
+		require.Less(t, r, rune(128), "non-ASCII %q in:\n%s", r, text)
+	}
+}
@@ -3,16 +3,57 @@ package server
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
 )
 
 // Diagnostic Tools
 
+// activePodsFieldSelector lets the API server drop pods that have run to
+// completion. Fake and older clients may ignore it, so callers still filter
+// on phase locally.
+const activePodsFieldSelector = "status.phase!=Failed,status.phase!=Succeeded"
+
+// platformMismatchErrors are what runtimes and registries report when an
+// image is not built for the OS or architecture of its node.
+var platformMismatchErrors = []string{
+	"exec format error",
+	"no matching manifest for",
+	"cannot be used on this platform",
+}
+
+// containerPlatformMismatch returns the error showing that a container's
+// image does not match its node's platform, if there is one.
+func containerPlatformMismatch(cs corev1.ContainerStatus) string {
+	var msgs []string
+	if cs.State.Waiting != nil {
+		msgs = append(msgs, cs.State.Waiting.Message)
+	}
+	if cs.State.Terminated != nil {
+		msgs = append(msgs, cs.State.Terminated.Message)
+	}
+	if cs.LastTerminationState.Terminated != nil {
+		msgs = append(msgs, cs.LastTerminationState.Terminated.Message)
+	}
+	for _, msg := range msgs {
+		for _, e := range platformMismatchErrors {
+			if strings.Contains(msg, e) {
+				return msg
+			}
+		}
+	}
+	return ""
+}
+
 func (s *Server) toolFindPodIssues(ctx context.Context, args map[string]interface{}) (string, bool) {
 	cluster, _ := args["cluster"].(string)
 	namespace, err := extractAndValidateNamespace(args)
@@ -20,17 +61,23 @@ func (s *Server) toolFindPodIssues(ctx context.Context, args map[string]interfac
 		return fmt.Sprintf("error: %v", err), true
 	}
 	includeCompleted := args["include_completed"] == "true"
+	labelSelector, _ := args["label_selector"].(string)
 
 	client, err := s.getClientForCluster(cluster)
 	if err != nil {
 		return fmt.Sprintf("Failed to create client: %v", err), true
 	}
 
+	listOpts := metav1.ListOptions{LabelSelector: labelSelector}
+	if !includeCompleted {
+		listOpts.FieldSelector = activePodsFieldSelector
+	}
+
 	var pods *corev1.PodList
 	if namespace == "" {
-		pods, err = client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		pods, err = client.CoreV1().Pods("").List(ctx, listOpts)
 	} else {
-		pods, err = client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		pods, err = client.CoreV1().Pods(namespace).List(ctx, listOpts)
 	}
 
 	if err != nil {
@@ -39,6 +86,21 @@ func (s *Server) toolFindPodIssues(ctx context.Context, args map[string]interfac
 
 	var sb strings.Builder
 	issueCount := 0
+	missingSecrets := false
+	platformMismatch := false
+	// nodePlatforms caches the os/arch of nodes by name.
+	nodePlatforms := make(map[string]string)
+	nodePlatform := func(name string) string {
+		if p, ok := nodePlatforms[name]; ok {
+			return p
+		}
+		p := messages.Get(messages.NodePlatformUnknown)
+		if node, err := client.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{}); err == nil {
+			p = node.Labels[corev1.LabelOSStable] + "/" + node.Labels[corev1.LabelArchStable]
+		}
+		nodePlatforms[name] = p
+		return p
+	}
 
 	for _, pod := range pods.Items {
 		issues := []string{}
@@ -51,15 +113,15 @@ func (s *Server) toolFindPodIssues(ctx context.Context, args map[string]interfac
 		// Check pod phase
 		switch pod.Status.Phase {
 		case corev1.PodPending:
-			issues = append(issues, "Pod is Pending")
+			issues = append(issues, messages.Get(messages.PodPending))
 		case corev1.PodFailed:
-			issues = append(issues, fmt.Sprintf("Pod Failed: %s", pod.Status.Reason))
+			issues = append(issues, messages.Sprintf(messages.PodFailed, pod.Status.Reason))
 		}
 
 		// Check container statuses
 		for _, cs := range pod.Status.ContainerStatuses {
 			if cs.RestartCount > 5 {
-				issues = append(issues, fmt.Sprintf("Container %s has %d restarts", cs.Name, cs.RestartCount))
+				issues = append(issues, messages.Sprintf(messages.ContainerRestarts, cs.Name, cs.RestartCount))
 			}
 
 			if cs.State.Waiting != nil {
@@ -67,19 +129,27 @@ func (s *Server) toolFindPodIssues(ctx context.Context, args map[string]interfac
 				switch reason {
 				case "CrashLoopBackOff", "ImagePullBackOff", "ErrImagePull", "CreateContainerConfigError", "InvalidImageName":
 					msg := cs.State.Waiting.Message
+					if reason == "CreateContainerConfigError" && strings.Contains(msg, "secret") {
+						missingSecrets = true
+					}
 					if len(msg) > 100 {
 						msg = msg[:100] + "..."
 					}
-					issues = append(issues, fmt.Sprintf("Container %s: %s - %s", cs.Name, reason, msg))
+					issues = append(issues, messages.Sprintf(messages.ContainerWaiting, cs.Name, reason, msg))
 				}
 			}
 
+			if msg := containerPlatformMismatch(cs); msg != "" && pod.Spec.NodeName != "" {
+				platformMismatch = true
+				issues = append(issues, messages.Sprintf(messages.ContainerWrongPlatform, cs.Name, pod.Spec.NodeName, nodePlatform(pod.Spec.NodeName)))
+			}
+
 			if cs.State.Terminated != nil && cs.State.Terminated.Reason == "OOMKilled" {
-				issues = append(issues, fmt.Sprintf("Container %s was OOMKilled", cs.Name))
+				issues = append(issues, messages.Sprintf(messages.ContainerOOMKilled, cs.Name))
 			}
 
 			if !cs.Ready && cs.State.Running != nil {
-				issues = append(issues, fmt.Sprintf("Container %s running but not ready", cs.Name))
+				issues = append(issues, messages.Sprintf(messages.ContainerNotReady, cs.Name))
 			}
 		}
 
@@ -87,20 +157,20 @@ func (s *Server) toolFindPodIssues(ctx context.Context, args map[string]interfac
 		for _, cs := range pod.Status.InitContainerStatuses {
 			if cs.State.Waiting != nil {
 				reason := cs.State.Waiting.Reason
-				issues = append(issues, fmt.Sprintf("Init container %s waiting: %s", cs.Name, reason))
+				issues = append(issues, messages.Sprintf(messages.InitContainerWaiting, cs.Name, reason))
 			}
 		}
 
 		// Check for unschedulable
 		for _, cond := range pod.Status.Conditions {
 			if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
-				issues = append(issues, fmt.Sprintf("Unschedulable: %s", cond.Message))
+				issues = append(issues, messages.Sprintf(messages.PodUnschedulable, cond.Message))
 			}
 		}
 
 		if len(issues) > 0 {
 			issueCount++
-			_, _ = fmt.Fprintf(&sb, "\n📛 %s/%s\n", pod.Namespace, pod.Name)
+			sb.WriteString(messages.Sprintf(messages.IssueObject, pod.Namespace, pod.Name))
 			for _, issue := range issues {
 				_, _ = fmt.Fprintf(&sb, "   - %s\n", issue)
 			}
@@ -108,10 +178,17 @@ func (s *Server) toolFindPodIssues(ctx context.Context, args map[string]interfac
 	}
 
 	if issueCount == 0 {
-		return "✅ No pod issues found", false
+		return messages.Get(messages.PodIssuesNone), false
+	}
+
+	if missingSecrets {
+		sb.WriteString(messages.Get(messages.PodIssuesMissingSecrets))
+	}
+	if platformMismatch {
+		sb.WriteString(messages.Get(messages.PodIssuesWrongPlatform))
 	}
 
-	header := fmt.Sprintf("Found %d pods with issues:\n", issueCount)
+	header := messages.Sprintf(messages.PodIssuesFound, issueCount)
 	return header + sb.String(), false
 }
 
@@ -168,25 +245,25 @@ func (s *Server) toolFindDeploymentIssues(ctx context.Context, args map[string]i
 
 		// Check replica status
 		if deploy.Status.Replicas != deploy.Status.ReadyReplicas {
-			issues = append(issues, fmt.Sprintf("Only %d/%d replicas ready",
+			issues = append(issues, messages.Sprintf(messages.DeploymentReplicasReady,
 				deploy.Status.ReadyReplicas, deploy.Status.Replicas))
 		}
 
 		if deploy.Status.UnavailableReplicas > 0 {
-			issues = append(issues, fmt.Sprintf("%d replicas unavailable",
+			issues = append(issues, messages.Sprintf(messages.DeploymentUnavailable,
 				deploy.Status.UnavailableReplicas))
 		}
 
 		// Check conditions
 		for _, cond := range deploy.Status.Conditions {
 			if cond.Type == appsv1.DeploymentProgressing && cond.Status == corev1.ConditionFalse {
-				issues = append(issues, fmt.Sprintf("Rollout stuck: %s", cond.Message))
+				issues = append(issues, messages.Sprintf(messages.DeploymentRolloutStuck, cond.Message))
 			}
 			if cond.Type == appsv1.DeploymentAvailable && cond.Status == corev1.ConditionFalse {
-				issues = append(issues, fmt.Sprintf("Not available: %s", cond.Message))
+				issues = append(issues, messages.Sprintf(messages.DeploymentNotAvailable, cond.Message))
 			}
 			if cond.Type == appsv1.DeploymentReplicaFailure && cond.Status == corev1.ConditionTrue {
-				issues = append(issues, fmt.Sprintf("Replica failure: %s", cond.Message))
+				issues = append(issues, messages.Sprintf(messages.DeploymentReplicaFailure, cond.Message))
 			}
 		}
 
@@ -195,14 +272,14 @@ func (s *Server) toolFindDeploymentIssues(ctx context.Context, args map[string]i
 		if rs, ok := rsMap[key]; ok {
 			for _, cond := range rs.Status.Conditions {
 				if cond.Type == appsv1.ReplicaSetReplicaFailure && cond.Status == corev1.ConditionTrue {
-					issues = append(issues, fmt.Sprintf("ReplicaSet error: %s", cond.Message))
+					issues = append(issues, messages.Sprintf(messages.DeploymentReplicaSetErr, cond.Message))
 				}
 			}
 		}
 
 		if len(issues) > 0 {
 			issueCount++
-			_, _ = fmt.Fprintf(&sb, "\n📛 %s/%s\n", deploy.Namespace, deploy.Name)
+			sb.WriteString(messages.Sprintf(messages.IssueObject, deploy.Namespace, deploy.Name))
 			for _, issue := range issues {
 				_, _ = fmt.Fprintf(&sb, "   - %s\n", issue)
 			}
@@ -210,10 +287,10 @@ func (s *Server) toolFindDeploymentIssues(ctx context.Context, args map[string]i
 	}
 
 	if issueCount == 0 {
-		return "✅ No deployment issues found", false
+		return messages.Get(messages.DeploymentIssuesNone), false
 	}
 
-	header := fmt.Sprintf("Found %d deployments with issues:\n", issueCount)
+	header := messages.Sprintf(messages.DeploymentIssuesFound, issueCount)
 	return header + sb.String(), false
 }
 
@@ -255,27 +332,27 @@ func (s *Server) toolCheckResourceLimits(ctx context.Context, args map[string]in
 			issues := []string{}
 
 			if container.Resources.Limits.Cpu().IsZero() {
-				issues = append(issues, "no CPU limit")
+				issues = append(issues, messages.Get(messages.LimitsNoCPULimit))
 			}
 			if container.Resources.Limits.Memory().IsZero() {
-				issues = append(issues, "no memory limit")
+				issues = append(issues, messages.Get(messages.LimitsNoMemoryLimit))
 			}
 			if container.Resources.Requests.Cpu().IsZero() {
-				issues = append(issues, "no CPU request")
+				issues = append(issues, messages.Get(messages.LimitsNoCPURequest))
 			}
 			if container.Resources.Requests.Memory().IsZero() {
-				issues = append(issues, "no memory request")
+				issues = append(issues, messages.Get(messages.LimitsNoMemoryReq))
 			}
 
 			if len(issues) > 0 {
 				containerIssues = append(containerIssues,
-					fmt.Sprintf("Container %s: %s", container.Name, strings.Join(issues, ", ")))
+					messages.Sprintf(messages.LimitsContainer, container.Name, strings.Join(issues, ", ")))
 			}
 		}
 
 		if len(containerIssues) > 0 {
 			issueCount++
-			_, _ = fmt.Fprintf(&sb, "\n⚠️  %s/%s\n", pod.Namespace, pod.Name)
+			sb.WriteString(messages.Sprintf(messages.LimitsPod, pod.Namespace, pod.Name))
 			for _, issue := range containerIssues {
 				_, _ = fmt.Fprintf(&sb, "   - %s\n", issue)
 			}
@@ -283,10 +360,10 @@ func (s *Server) toolCheckResourceLimits(ctx context.Context, args map[string]in
 	}
 
 	if issueCount == 0 {
-		return "✅ All pods have resource limits configured", false
+		return messages.Get(messages.LimitsNone), false
 	}
 
-	header := fmt.Sprintf("Found %d pods without proper resource limits:\n", issueCount)
+	header := messages.Sprintf(messages.LimitsFound, issueCount)
 	return header + sb.String(), false
 }
 
@@ -302,11 +379,14 @@ func (s *Server) toolCheckSecurityIssues(ctx context.Context, args map[string]in
 		return fmt.Sprintf("Failed to create client: %v", err), true
 	}
 
+	// Completed pods are skipped below, so let the API server drop them.
+	listOpts := metav1.ListOptions{FieldSelector: activePodsFieldSelector}
+
 	var pods *corev1.PodList
 	if namespace == "" {
-		pods, err = client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+		pods, err = client.CoreV1().Pods("").List(ctx, listOpts)
 	} else {
-		pods, err = client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		pods, err = client.CoreV1().Pods(namespace).List(ctx, listOpts)
 	}
 
 	if err != nil {
@@ -330,13 +410,13 @@ func (s *Server) toolCheckSecurityIssues(ctx context.Context, args map[string]in
 
 		// Check pod-level security
 		if pod.Spec.HostNetwork {
-			issues = append(issues, "🔴 Uses host network")
+			issues = append(issues, messages.Get(messages.SecurityHostNetwork))
 		}
 		if pod.Spec.HostPID {
-			issues = append(issues, "🔴 Uses host PID namespace")
+			issues = append(issues, messages.Get(messages.SecurityHostPID))
 		}
 		if pod.Spec.HostIPC {
-			issues = append(issues, "🔴 Uses host IPC namespace")
+			issues = append(issues, messages.Get(messages.SecurityHostIPC))
 		}
 
 		// Check containers
@@ -345,32 +425,32 @@ func (s *Server) toolCheckSecurityIssues(ctx context.Context, args map[string]in
 
 			if sc != nil {
 				if sc.Privileged != nil && *sc.Privileged {
-					issues = append(issues, fmt.Sprintf("🔴 Container %s is privileged", container.Name))
+					issues = append(issues, messages.Sprintf(messages.SecurityPrivileged, container.Name))
 				}
 				if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
-					issues = append(issues, fmt.Sprintf("🟠 Container %s runs as root (UID 0)", container.Name))
+					issues = append(issues, messages.Sprintf(messages.SecurityRunsAsRoot, container.Name))
 				}
 				if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
-					issues = append(issues, fmt.Sprintf("🟡 Container %s allows privilege escalation", container.Name))
+					issues = append(issues, messages.Sprintf(messages.SecurityPrivilegeEscalation, container.Name))
 				}
 				if sc.ReadOnlyRootFilesystem == nil || !*sc.ReadOnlyRootFilesystem {
-					issues = append(issues, fmt.Sprintf("🟡 Container %s has writable root filesystem", container.Name))
+					issues = append(issues, messages.Sprintf(messages.SecurityWritableRootFS, container.Name))
 				}
 			} else {
-				issues = append(issues, fmt.Sprintf("🟡 Container %s has no security context", container.Name))
+				issues = append(issues, messages.Sprintf(messages.SecurityNoSecurityContext, container.Name))
 			}
 
 			// Check for sensitive mounts
 			for _, mount := range container.VolumeMounts {
 				if mount.MountPath == "/var/run/docker.sock" {
-					issues = append(issues, fmt.Sprintf("🔴 Container %s mounts Docker socket", container.Name))
+					issues = append(issues, messages.Sprintf(messages.SecurityDockerSocket, container.Name))
 				}
 			}
 		}
 
 		if len(issues) > 0 {
 			issueCount++
-			_, _ = fmt.Fprintf(&sb, "\n🔓 %s/%s\n", pod.Namespace, pod.Name)
+			sb.WriteString(messages.Sprintf(messages.SecurityPod, pod.Namespace, pod.Name))
 			for _, issue := range issues {
 				_, _ = fmt.Fprintf(&sb, "   - %s\n", issue)
 			}
@@ -378,10 +458,10 @@ func (s *Server) toolCheckSecurityIssues(ctx context.Context, args map[string]in
 	}
 
 	if issueCount == 0 {
-		return "✅ No obvious security issues found", false
+		return messages.Get(messages.SecurityNone), false
 	}
 
-	header := fmt.Sprintf("Found %d pods with security concerns:\n🔴 Critical | 🟠 High | 🟡 Medium\n", issueCount)
+	header := messages.Sprintf(messages.SecurityFound, issueCount)
 	return header + sb.String(), false
 }
 
@@ -401,7 +481,7 @@ func (s *Server) toolAnalyzeNamespace(ctx context.Context, args map[string]inter
 	}
 
 	var sb strings.Builder
-	_, _ = fmt.Fprintf(&sb, "📊 Namespace Analysis: %s\n\n", namespace)
+	sb.WriteString(messages.Sprintf(messages.NamespaceTitle, namespace))
 
 	// Get namespace
 	ns, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
@@ -409,13 +489,13 @@ func (s *Server) toolAnalyzeNamespace(ctx context.Context, args map[string]inter
 		return fmt.Sprintf("Failed to get namespace: %v", err), true
 	}
 
-	_, _ = fmt.Fprintf(&sb, "Status: %s\n", ns.Status.Phase)
-	_, _ = fmt.Fprintf(&sb, "Created: %s\n\n", ns.CreationTimestamp.Format("2006-01-02 15:04:05"))
+	sb.WriteString(messages.Sprintf(messages.NamespaceStatus, ns.Status.Phase))
+	sb.WriteString(messages.Sprintf(messages.NamespaceCreated, ns.CreationTimestamp.Format("2006-01-02 15:04:05")))
 
 	// Get resource quotas
 	quotas, _ := client.CoreV1().ResourceQuotas(namespace).List(ctx, metav1.ListOptions{})
 	if len(quotas.Items) > 0 {
-		sb.WriteString("📋 Resource Quotas:\n")
+		sb.WriteString(messages.Get(messages.NamespaceQuotas))
 		for _, quota := range quotas.Items {
 			_, _ = fmt.Fprintf(&sb, "  %s:\n", quota.Name)
 			for resource, hard := range quota.Status.Hard {
@@ -429,7 +509,7 @@ func (s *Server) toolAnalyzeNamespace(ctx context.Context, args map[string]inter
 	// Get limit ranges
 	limitRanges, _ := client.CoreV1().LimitRanges(namespace).List(ctx, metav1.ListOptions{})
 	if len(limitRanges.Items) > 0 {
-		sb.WriteString("📏 Limit Ranges:\n")
+		sb.WriteString(messages.Get(messages.NamespaceLimitRanges))
 		for _, lr := range limitRanges.Items {
 			_, _ = fmt.Fprintf(&sb, "  %s\n", lr.Name)
 		}
@@ -460,17 +540,17 @@ func (s *Server) toolAnalyzeNamespace(ctx context.Context, args map[string]inter
 		}
 	}
 
-	sb.WriteString("📦 Pods:\n")
-	_, _ = fmt.Fprintf(&sb, "  Total: %d\n", len(pods.Items))
-	_, _ = fmt.Fprintf(&sb, "  Running: %d\n", runningPods)
+	sb.WriteString(messages.Get(messages.NamespacePods))
+	sb.WriteString(messages.Sprintf(messages.NamespacePodsTotal, len(pods.Items)))
+	sb.WriteString(messages.Sprintf(messages.NamespacePodsRunning, runningPods))
 	if pendingPods > 0 {
-		_, _ = fmt.Fprintf(&sb, "  Pending: %d ⚠️\n", pendingPods)
+		sb.WriteString(messages.Sprintf(messages.NamespacePodsPending, pendingPods))
 	}
 	if failedPods > 0 {
-		_, _ = fmt.Fprintf(&sb, "  Failed: %d ❌\n", failedPods)
+		sb.WriteString(messages.Sprintf(messages.NamespacePodsFailed, failedPods))
 	}
 	if crashingPods > 0 {
-		_, _ = fmt.Fprintf(&sb, "  Crashing/Restarting: %d 🔄\n", crashingPods)
+		sb.WriteString(messages.Sprintf(messages.NamespacePodsCrashing, crashingPods))
 	}
 	sb.WriteString("\n")
 
@@ -482,15 +562,15 @@ func (s *Server) toolAnalyzeNamespace(ctx context.Context, args map[string]inter
 			unhealthyDeploys++
 		}
 	}
-	_, _ = fmt.Fprintf(&sb, "🚀 Deployments: %d", len(deployments.Items))
+	sb.WriteString(messages.Sprintf(messages.NamespaceDeployments, len(deployments.Items)))
 	if unhealthyDeploys > 0 {
-		_, _ = fmt.Fprintf(&sb, " (%d unhealthy ⚠️)", unhealthyDeploys)
+		sb.WriteString(messages.Sprintf(messages.NamespaceUnhealthy, unhealthyDeploys))
 	}
 	sb.WriteString("\n")
 
 	// Get services
 	services, _ := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
-	_, _ = fmt.Fprintf(&sb, "🌐 Services: %d\n", len(services.Items))
+	sb.WriteString(messages.Sprintf(messages.NamespaceServices, len(services.Items)))
 
 	// Get PVCs and check status
 	pvcs, _ := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
@@ -500,24 +580,24 @@ func (s *Server) toolAnalyzeNamespace(ctx context.Context, args map[string]inter
 			pendingPVCs++
 		}
 	}
-	_, _ = fmt.Fprintf(&sb, "💾 PVCs: %d", len(pvcs.Items))
+	sb.WriteString(messages.Sprintf(messages.NamespacePVCs, len(pvcs.Items)))
 	if pendingPVCs > 0 {
-		_, _ = fmt.Fprintf(&sb, " (%d pending ⚠️)", pendingPVCs)
+		sb.WriteString(messages.Sprintf(messages.NamespacePVCsPending, pendingPVCs))
 	}
 	sb.WriteString("\n")
 
 	// Get configmaps and secrets
 	configMaps, _ := client.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
 	secrets, _ := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
-	_, _ = fmt.Fprintf(&sb, "📄 ConfigMaps: %d\n", len(configMaps.Items))
-	_, _ = fmt.Fprintf(&sb, "🔐 Secrets: %d\n", len(secrets.Items))
+	sb.WriteString(messages.Sprintf(messages.NamespaceConfigMaps, len(configMaps.Items)))
+	sb.WriteString(messages.Sprintf(messages.NamespaceSecrets, len(secrets.Items)))
 
 	// Check for warning events
 	events, _ := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
 		FieldSelector: "type=Warning",
 	})
 	if len(events.Items) > 0 {
-		_, _ = fmt.Fprintf(&sb, "\n⚠️  Recent Warnings: %d events\n", len(events.Items))
+		sb.WriteString(messages.Sprintf(messages.NamespaceWarnings, len(events.Items)))
 	}
 
 	return sb.String(), false
@@ -530,6 +610,8 @@ func (s *Server) toolGetWarningEvents(ctx context.Context, args map[string]inter
 		return fmt.Sprintf("error: %v", err), true
 	}
 	involvedObject, _ := args["involved_object"].(string)
+	involvedKind, _ := args["involved_kind"].(string)
+	reason, _ := args["reason"].(string)
 	limit := int64(50)
 	if v, ok := args["limit"].(float64); ok {
 		limit = int64(v)
@@ -540,57 +622,144 @@ func (s *Server) toolGetWarningEvents(ctx context.Context, args map[string]inter
 		return fmt.Sprintf("Failed to create client: %v", err), true
 	}
 
-	listOpts := metav1.ListOptions{
-		FieldSelector: "type=Warning",
+	// Filtering on the server keeps the limit from being spent on events
+	// about other objects.
+	events, err := client.EventsV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: warningEventsFieldSelector(involvedKind, involvedObject, reason),
 		Limit:         limit,
-	}
-
-	var events *corev1.EventList
-	if namespace == "" {
-		events, err = client.CoreV1().Events("").List(ctx, listOpts)
-	} else {
-		events, err = client.CoreV1().Events(namespace).List(ctx, listOpts)
-	}
-
+	})
 	if err != nil {
 		return fmt.Sprintf("Failed to list events: %v", err), true
 	}
 
-	var sb strings.Builder
-	count := 0
-
+	var matched []eventsv1.Event
 	for _, event := range events.Items {
-		// Filter by involved object if specified
-		if involvedObject != "" && event.InvolvedObject.Name != involvedObject {
+		// Fake and older clients may ignore the field selector.
+		if event.Type != corev1.EventTypeWarning ||
+			(involvedKind != "" && event.Regarding.Kind != involvedKind) ||
+			(involvedObject != "" && event.Regarding.Name != involvedObject) ||
+			(reason != "" && event.Reason != reason) {
 			continue
 		}
+		matched = append(matched, event)
+	}
+	series := warningEventSeries(matched)
+	if limit > 0 && int64(len(series)) > limit {
+		series = series[:limit]
+	}
+
+	if len(series) == 0 {
+		return messages.Get(messages.WarningEventsNone), false
+	}
 
-		count++
+	var sb strings.Builder
+	for _, ws := range series {
 		age := ""
-		if event.LastTimestamp.Time.IsZero() {
-			age = "unknown"
+		if ws.lastSeen.IsZero() {
+			age = messages.Get(messages.WarningEventAge)
 		} else {
-			age = formatAge(event.LastTimestamp.Time)
+			age = formatAge(ws.lastSeen)
 		}
 
-		_, _ = fmt.Fprintf(&sb, "⚠️  [%s] %s/%s\n", age, event.InvolvedObject.Kind, event.InvolvedObject.Name)
-		_, _ = fmt.Fprintf(&sb, "   %s: %s\n", event.Reason, event.Message)
-		if event.Count > 1 {
-			_, _ = fmt.Fprintf(&sb, "   (occurred %d times)\n", event.Count)
+		sb.WriteString(messages.Sprintf(messages.WarningEvent, age, ws.kind, ws.name))
+		_, _ = fmt.Fprintf(&sb, "   %s: %s\n", ws.reason, ws.note)
+		if ws.count > 1 {
+			sb.WriteString(messages.Sprintf(messages.WarningEventCount, ws.count))
 		}
 		sb.WriteString("\n")
 	}
 
-	if count == 0 {
-		return "✅ No warning events found", false
+	header := messages.Sprintf(messages.WarningEventsFound, len(series))
+	return header + sb.String(), false
+}
+
+// warningEventsFieldSelector selects the Warning events of get_warning_events
+// in the events.k8s.io/v1 API, optionally only those about objects of kind,
+// the object named name, or with reason.
+func warningEventsFieldSelector(kind, name, reason string) string {
+	selectors := []fields.Selector{fields.OneTermEqualSelector("type", corev1.EventTypeWarning)}
+	if kind != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector("regarding.kind", kind))
+	}
+	if name != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector("regarding.name", name))
 	}
+	if reason != "" {
+		selectors = append(selectors, fields.OneTermEqualSelector("reason", reason))
+	}
+	return fields.AndSelectors(selectors...).String()
+}
 
-	header := fmt.Sprintf("Found %d warning events:\n\n", count)
-	return header + sb.String(), false
+// warningSeries is a recurring warning: the events about one object with the
+// same reason and note.
+type warningSeries struct {
+	kind, namespace, name string
+	reason, note          string
+	count                 int32
+	lastSeen              time.Time
+}
+
+// warningEventSeries merges events into series, most recent first. The
+// events of a series are counted by their series count, or the deprecated
+// count of events recorded through the core API.
+func warningEventSeries(events []eventsv1.Event) []*warningSeries {
+	var series []*warningSeries
+	byKey := map[string]*warningSeries{}
+	for _, event := range events {
+		key := strings.Join([]string{event.Regarding.Kind, event.Regarding.Namespace, event.Regarding.Name, event.Reason, event.Note}, "\x00")
+		ws, ok := byKey[key]
+		if !ok {
+			ws = &warningSeries{
+				kind:      event.Regarding.Kind,
+				namespace: event.Regarding.Namespace,
+				name:      event.Regarding.Name,
+				reason:    event.Reason,
+				note:      event.Note,
+			}
+			byKey[key] = ws
+			series = append(series, ws)
+		}
+		ws.count += eventCount(event)
+		if seen := eventLastSeen(event); seen.After(ws.lastSeen) {
+			ws.lastSeen = seen
+		}
+	}
+	sort.SliceStable(series, func(i, j int) bool { return series[i].lastSeen.After(series[j].lastSeen) })
+	return series
+}
+
+// eventCount returns how many times event occurred.
+func eventCount(event eventsv1.Event) int32 {
+	switch {
+	case event.Series != nil:
+		return event.Series.Count
+	case event.DeprecatedCount > 0:
+		return event.DeprecatedCount
+	default:
+		return 1
+	}
+}
+
+// eventLastSeen returns when event last occurred, or zero if unknown.
+func eventLastSeen(event eventsv1.Event) time.Time {
+	switch {
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.DeprecatedLastTimestamp.IsZero():
+		return event.DeprecatedLastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
 }
 
 func formatAge(t time.Time) string {
-	d := time.Since(t)
+	return formatDuration(time.Since(t))
+}
+
+// formatDuration formats d in its largest whole unit, from seconds to days.
+func formatDuration(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%ds", int(d.Seconds()))
 	}
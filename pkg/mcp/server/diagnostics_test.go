@@ -5,9 +5,11 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -34,6 +36,49 @@ func TestToolFindPodIssues_NoPods(t *testing.T) {
 	}
 }
 
+func TestToolFindPodIssues_PushesSelectorsToAPIServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       map[string]interface{}
+		wantFields string
+		wantLabels string
+	}{
+		{"active pods only by default", map[string]interface{}{}, activePodsFieldSelector, ""},
+		{"include completed", map[string]interface{}{"include_completed": "true"}, "", ""},
+		{"label selector", map[string]interface{}{"label_selector": "app=web"}, activePodsFieldSelector, "app=web"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := k8sfake.NewSimpleClientset()
+			s := &Server{
+				clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+					return client, nil
+				},
+			}
+
+			if result, isErr := s.toolFindPodIssues(context.Background(), tt.args); isErr {
+				t.Fatalf("toolFindPodIssues() returned error: %s", result)
+			}
+
+			actions := client.Actions()
+			if len(actions) != 1 {
+				t.Fatalf("actions = %d, want 1", len(actions))
+			}
+			list, ok := actions[0].(k8stesting.ListAction)
+			if !ok {
+				t.Fatalf("action = %T, want list", actions[0])
+			}
+			restrictions := list.GetListRestrictions()
+			if got := restrictions.Fields.String(); got != tt.wantFields {
+				t.Errorf("field selector = %q, want %q", got, tt.wantFields)
+			}
+			if got := restrictions.Labels.String(); got != tt.wantLabels {
+				t.Errorf("label selector = %q, want %q", got, tt.wantLabels)
+			}
+		})
+	}
+}
+
 func TestToolFindPodIssues_CrashLoopBackOff(t *testing.T) {
 	client := k8sfake.NewSimpleClientset(&corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{Name: "bad-pod", Namespace: "default"},
@@ -148,6 +193,47 @@ func TestToolFindPodIssues_OOMKilled(t *testing.T) {
 	}
 }
 
+func TestToolFindPodIssues_PlatformMismatch(t *testing.T) {
+	client := k8sfake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "arm-1", Labels: map[string]string{
+			corev1.LabelOSStable: "linux", corev1.LabelArchStable: "arm64",
+		}}},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-pod", Namespace: "default"},
+			Spec:       corev1.PodSpec{NodeName: "arm-1"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:                 "web",
+						RestartCount:         3,
+						State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+						LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "StartError", Message: "exec: \"/app\": exec format error"}},
+					},
+				},
+			},
+		},
+	)
+
+	s := &Server{
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			return client, nil
+		},
+	}
+
+	result, isErr := s.toolFindPodIssues(context.Background(), map[string]interface{}{})
+	if isErr {
+		t.Fatalf("toolFindPodIssues() returned error: %s", result)
+	}
+
+	wantStrings := []string{"Container web image is not built for node arm-1 (linux/arm64)", "Build multi-arch images"}
+	for _, want := range wantStrings {
+		if !strings.Contains(result, want) {
+			t.Errorf("toolFindPodIssues() missing %q in:\n%s", want, result)
+		}
+	}
+}
+
 func TestToolFindPodIssues_Unschedulable(t *testing.T) {
 	client := k8sfake.NewSimpleClientset(&corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{Name: "pending-pod", Namespace: "default"},
@@ -558,17 +644,16 @@ func TestToolGetWarningEvents_NoEvents(t *testing.T) {
 }
 
 func TestToolGetWarningEvents_HasEvents(t *testing.T) {
-	client := k8sfake.NewSimpleClientset(&corev1.Event{
+	client := k8sfake.NewSimpleClientset(&eventsv1.Event{
 		ObjectMeta: metav1.ObjectMeta{Name: "event-1", Namespace: "default"},
 		Type:       "Warning",
 		Reason:     "FailedScheduling",
-		Message:    "0/3 nodes are available",
-		Count:      5,
-		InvolvedObject: corev1.ObjectReference{
+		Note:       "0/3 nodes are available",
+		Series:     &eventsv1.EventSeries{Count: 5, LastObservedTime: metav1.NowMicro()},
+		Regarding: corev1.ObjectReference{
 			Kind: "Pod",
 			Name: "pending-pod",
 		},
-		LastTimestamp: metav1.Now(),
 	})
 
 	s := &Server{
@@ -590,6 +675,104 @@ func TestToolGetWarningEvents_HasEvents(t *testing.T) {
 	}
 }
 
+func TestToolGetWarningEvents_FieldSelector(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	var selector string
+	client.PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Group == "events.k8s.io" {
+			selector = action.(k8stesting.ListAction).GetListRestrictions().Fields.String()
+		}
+		return false, nil, nil
+	})
+
+	s := &Server{
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			return client, nil
+		},
+	}
+
+	result, isErr := s.toolGetWarningEvents(context.Background(), map[string]interface{}{
+		"involved_kind":   "Pod",
+		"involved_object": "web-0",
+		"reason":          "BackOff",
+	})
+	if isErr {
+		t.Fatalf("toolGetWarningEvents() returned error: %s", result)
+	}
+
+	for _, want := range []string{"type=Warning", "regarding.kind=Pod", "regarding.name=web-0", "reason=BackOff"} {
+		if !strings.Contains(selector, want) {
+			t.Errorf("field selector %q missing %q", selector, want)
+		}
+	}
+}
+
+func TestToolGetWarningEvents_MergesSeries(t *testing.T) {
+	now := time.Now()
+	regarding := corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-0"}
+	client := k8sfake.NewSimpleClientset(
+		&eventsv1.Event{
+			ObjectMeta:              metav1.ObjectMeta{Name: "web-0.1", Namespace: "shop"},
+			Type:                    "Warning",
+			Reason:                  "BackOff",
+			Note:                    "Back-off restarting failed container",
+			Regarding:               regarding,
+			DeprecatedCount:         3,
+			DeprecatedLastTimestamp: metav1.NewTime(now.Add(-time.Hour)),
+		},
+		&eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0.2", Namespace: "shop"},
+			Type:       "Warning",
+			Reason:     "BackOff",
+			Note:       "Back-off restarting failed container",
+			Regarding:  regarding,
+			Series:     &eventsv1.EventSeries{Count: 4, LastObservedTime: metav1.NewMicroTime(now.Add(-time.Minute))},
+		},
+		&eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0.3", Namespace: "shop"},
+			Type:       "Warning",
+			Reason:     "FailedMount",
+			Note:       "secret \"tls\" not found",
+			Regarding:  regarding,
+			EventTime:  metav1.NewMicroTime(now.Add(-2 * time.Hour)),
+		},
+		&eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0.4", Namespace: "shop"},
+			Type:       "Normal",
+			Reason:     "Pulled",
+			Regarding:  regarding,
+		},
+	)
+
+	s := &Server{
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			return client, nil
+		},
+	}
+
+	result, isErr := s.toolGetWarningEvents(context.Background(), map[string]interface{}{"namespace": "shop"})
+	if isErr {
+		t.Fatalf("toolGetWarningEvents() returned error: %s", result)
+	}
+	if !strings.Contains(result, "Found 2 warning events") {
+		t.Errorf("toolGetWarningEvents() did not merge the BackOff series:\n%s", result)
+	}
+	if !strings.Contains(result, "occurred 7 times") {
+		t.Errorf("toolGetWarningEvents() missing the merged count in:\n%s", result)
+	}
+	if strings.Contains(result, "Pulled") {
+		t.Errorf("toolGetWarningEvents() included a Normal event:\n%s", result)
+	}
+	if strings.Index(result, "BackOff") > strings.Index(result, "FailedMount") {
+		t.Errorf("toolGetWarningEvents() did not put the most recent warning first:\n%s", result)
+	}
+
+	result, _ = s.toolGetWarningEvents(context.Background(), map[string]interface{}{"reason": "FailedMount"})
+	if strings.Contains(result, "BackOff") || !strings.Contains(result, "FailedMount") {
+		t.Errorf("toolGetWarningEvents(reason) = %s", result)
+	}
+}
+
 func TestToolFindPodIssues_ClientError(t *testing.T) {
 	client := k8sfake.NewSimpleClientset()
 	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
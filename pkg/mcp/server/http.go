@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
+)
+
+// maxHTTPRequestBytes bounds the size of a JSON-RPC request body.
+const maxHTTPRequestBytes = 4 << 20
+
+// TokenVerifier authenticates the bearer token of an HTTP request.
+// *auth.OIDCVerifier implements it.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (auth.Identity, error)
+}
+
+// SetTokenVerifier requires every HTTP request to carry a bearer token
+// accepted by v. Each call then runs as the token's identity: clusters see
+// it through impersonation, policies see it as input.identity, and it is
+// recorded in the audit bucket. It must be called before RunHTTP.
+func (s *Server) SetTokenVerifier(v TokenVerifier) {
+	s.tokenVerifier = v
+}
+
+// TokenVerifier returns the verifier set by SetTokenVerifier, or nil.
+func (s *Server) TokenVerifier() TokenVerifier {
+	return s.tokenVerifier
+}
+
+// httpRequest is the per-request state of a call received over HTTP.
+type httpRequest struct {
+	// caller is the authenticated identity, or nil without a verifier.
+	caller  *auth.Identity
+	respond func(Response)
+}
+
+type httpRequestKey struct{}
+
+func httpRequestFrom(ctx context.Context) *httpRequest {
+	r, _ := ctx.Value(httpRequestKey{}).(*httpRequest)
+	return r
+}
+
+func isHTTPRequest(ctx context.Context) bool {
+	return httpRequestFrom(ctx) != nil
+}
+
+// responderFrom returns the function that delivers responses for the HTTP
+// request in ctx, or nil for stdio.
+func responderFrom(ctx context.Context) func(Response) {
+	if r := httpRequestFrom(ctx); r != nil {
+		return r.respond
+	}
+	return nil
+}
+
+// callerFrom returns the authenticated HTTP caller, if any.
+func callerFrom(ctx context.Context) *auth.Identity {
+	if r := httpRequestFrom(ctx); r != nil {
+		return r.caller
+	}
+	return nil
+}
+
+// WithRemoteCaller returns a context for a tool call received by a network
+// transport other than MCP over HTTP, such as the gRPC API. CallTool treats
+// the call as an HTTP request: it runs as caller, which is nil without a
+// token verifier, and is recorded in the audit bucket.
+func WithRemoteCaller(ctx context.Context, caller *auth.Identity) context.Context {
+	return context.WithValue(ctx, httpRequestKey{}, &httpRequest{caller: caller})
+}
+
+// RunHTTP serves MCP over HTTP on addr until ctx is done. Each POST carries
+// one JSON-RPC message and gets its response in the reply body. Without a
+// token verifier the server only listens on loopback addresses, since every
+// caller would act with the server's own credentials.
+func (s *Server) RunHTTP(ctx context.Context, addr string) error {
+	if s.tokenVerifier == nil && !IsLoopback(addr) {
+		return fmt.Errorf("refusing to serve unauthenticated MCP on %s: configure OIDC or listen on a loopback address", addr)
+	}
+	s.startScheduler(ctx)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.HTTPHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+		return nil
+	}
+}
+
+// HTTPHandler returns the handler RunHTTP serves.
+func (s *Server) HTTPHandler() http.Handler {
+	return http.HandlerFunc(s.serveHTTP)
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "MCP requests must be POSTed", http.StatusMethodNotAllowed)
+		return
+	}
+	hr := &httpRequest{}
+	if s.tokenVerifier != nil {
+		token, ok := auth.BearerToken(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "bearer token required", http.StatusUnauthorized)
+			return
+		}
+		id, err := s.tokenVerifier.Verify(r.Context(), token)
+		if err != nil {
+			log.Printf("Rejected MCP HTTP request: %v", err)
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		hr.caller = &id
+	}
+
+	var resp *Response
+	hr.respond = func(r Response) { resp = &r }
+	ctx := context.WithValue(r.Context(), httpRequestKey{}, hr)
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPRequestBytes+1))
+	var req Request
+	switch {
+	case err != nil:
+		http.Error(w, "failed to read request", http.StatusBadRequest)
+		return
+	case len(body) > maxHTTPRequestBytes:
+		http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+		return
+	case json.Unmarshal(body, &req) != nil:
+		s.sendError(ctx, nil, -32700, "Parse error", nil)
+	default:
+		s.handleRequest(ctx, &req)
+	}
+
+	if resp == nil {
+		// Notifications have no response.
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to write MCP response: %v", err)
+	}
+}
+
+// IsLoopback reports whether addr only listens on the local host.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// wrapCallerImpersonation returns a copy of config whose requests
+// impersonate the authenticated HTTP caller in the request context,
+// replacing any server-wide impersonation.
+func wrapCallerImpersonation(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &callerImpersonationTransport{next: rt}
+	})
+	return config
+}
+
+type callerImpersonationTransport struct {
+	next http.RoundTripper
+}
+
+func (t *callerImpersonationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	caller := callerFrom(req.Context())
+	if caller == nil {
+		return t.next.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	for name := range req.Header {
+		if strings.HasPrefix(name, "Impersonate-") {
+			req.Header.Del(name)
+		}
+	}
+	req.Header.Set("Impersonate-User", caller.User)
+	for _, group := range caller.Groups {
+		req.Header.Add("Impersonate-Group", group)
+	}
+	return t.next.RoundTrip(req)
+}
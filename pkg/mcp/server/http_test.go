@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+type staticVerifier map[string]auth.Identity
+
+func (v staticVerifier) Verify(_ context.Context, token string) (auth.Identity, error) {
+	id, ok := v[token]
+	if !ok {
+		return auth.Identity{}, errors.New("unknown token")
+	}
+	return id, nil
+}
+
+func postMCP(t *testing.T, h http.Handler, token, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHTTPHandler_Authentication(t *testing.T) {
+	s := &Server{}
+	s.SetTokenVerifier(staticVerifier{"alice-token": {User: "alice"}})
+	h := s.HTTPHandler()
+	ping := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+
+	w := postMCP(t, h, "", ping)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+	w = postMCP(t, h, "forged", ping)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = postMCP(t, h, "alice-token", ping)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Nil(t, resp.Error)
+	assert.EqualValues(t, 1, resp.ID)
+
+	w = postMCP(t, h, "alice-token", `{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestHTTPHandler_RejectsSessionImpersonation(t *testing.T) {
+	s := &Server{impersonation: Impersonation{AllowedUsers: []string{"*"}}}
+	s.SetTokenVerifier(staticVerifier{"alice-token": {User: "alice"}})
+
+	body, _ := json.Marshal(Request{JSONRPC: "2.0", ID: 1, Method: "initialize", Params: initializeParams(t, "admin")})
+	w := postMCP(t, s.HTTPHandler(), "alice-token", string(body))
+	var resp Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.NotNil(t, resp.Error)
+	assert.Nil(t, s.session, "an HTTP caller must not change the identity of other callers")
+}
+
+func TestHTTPHandler_ToolCallsRunAsCaller(t *testing.T) {
+	var seen []string
+	registerTestTool(t, "whoami_test", func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+		seen = append(seen, callerFrom(ctx).User)
+		return "ok", false
+	})
+	st := store.NewMemory()
+	s := &Server{stateStore: st}
+	s.stateStoreOnce.Do(func() {})
+	s.SetTokenVerifier(staticVerifier{"alice-token": {User: "alice", Groups: []string{"sre"}}})
+
+	w := postMCP(t, s.HTTPHandler(), "alice-token", `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"whoami_test","arguments":{}}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"alice"}, seen)
+
+	items, err := st.List(context.Background(), store.BucketAudit)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	var entry auditEntry
+	require.NoError(t, json.Unmarshal(items[0].Value, &entry))
+	assert.Equal(t, "alice", entry.User)
+	assert.Equal(t, []string{"sre"}, entry.Groups)
+	assert.Equal(t, "whoami_test", entry.Tool)
+}
+
+func TestWrapCallerImpersonation(t *testing.T) {
+	var headers http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	config := &rest.Config{Host: srv.URL, Impersonate: rest.ImpersonationConfig{UserName: "server-wide"}}
+	client, err := rest.HTTPClientFor(wrapCallerImpersonation(config))
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), httpRequestKey{}, &httpRequest{caller: &auth.Identity{User: "alice", Groups: []string{"sre", "dev"}}})
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api", nil)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "alice", headers.Get("Impersonate-User"))
+	assert.Equal(t, []string{"sre", "dev"}, headers.Values("Impersonate-Group"))
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/api", nil)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "server-wide", headers.Get("Impersonate-User"), "requests without a caller keep the server's identity")
+}
+
+func TestRunHTTP_RequiresAuthOffLoopback(t *testing.T) {
+	s := &Server{}
+	err := s.RunHTTP(context.Background(), ":0")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthenticated")
+
+	assert.True(t, IsLoopback("127.0.0.1:8080"))
+	assert.True(t, IsLoopback("localhost:8080"))
+	assert.True(t, IsLoopback("[::1]:8080"))
+	assert.False(t, IsLoopback("0.0.0.0:8080"))
+}
+
+// registerTestTool adds a tool to the registry for the duration of a test.
+func registerTestTool(t *testing.T, name string, handler ToolHandler) {
+	t.Helper()
+	saved := toolRegistry
+	toolRegistry = append(append([]ToolDef(nil), toolRegistry...), ToolDef{Schema: Tool{Name: name}, Handler: handler})
+	t.Cleanup(func() { toolRegistry = saved })
+}
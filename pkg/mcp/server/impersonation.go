@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+)
+
+// impersonationCapability is the experimental client capability an MCP
+// client sets in initialize to act as its own user for the session:
+//
+//	"capabilities": {"experimental": {"kubestellar.io/impersonate":
+//	    {"user": "alice@example.com", "groups": ["team-a"]}}}
+const impersonationCapability = "kubestellar.io/impersonate"
+
+// Impersonation controls the identity the server presents to clusters, so
+// a shared deployment can enforce the caller's RBAC instead of its own.
+type Impersonation struct {
+	// User and Groups are applied to every client (--as, --as-group).
+	User   string
+	Groups []string
+	// AllowedUsers and AllowedGroups are the identities an MCP client may
+	// request for its session. Entries may use path.Match wildcards, e.g.
+	// "system:serviceaccount:team-a:*". Empty lists disable per-session
+	// impersonation.
+	AllowedUsers  []string
+	AllowedGroups []string
+}
+
+// SessionImpersonation is the identity requested by an MCP client.
+type SessionImpersonation struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// SetImpersonation configures impersonation for all clients built by the
+// server. It must be called before Run.
+func (s *Server) SetImpersonation(imp Impersonation) {
+	s.impersonationMu.Lock()
+	defer s.impersonationMu.Unlock()
+	s.impersonation = imp
+}
+
+// impersonationConfig returns the identity to impersonate: the session's
+// if the client requested one, otherwise the server-wide setting.
+func (s *Server) impersonationConfig() rest.ImpersonationConfig {
+	s.impersonationMu.RLock()
+	defer s.impersonationMu.RUnlock()
+	if s.session != nil {
+		return rest.ImpersonationConfig{UserName: s.session.User, Groups: s.session.Groups}
+	}
+	return rest.ImpersonationConfig{UserName: s.impersonation.User, Groups: s.impersonation.Groups}
+}
+
+// withImpersonation returns config with the current impersonation applied,
+// copying it so cached or shared configs are never modified.
+func (s *Server) withImpersonation(config *rest.Config) *rest.Config {
+	ic := s.impersonationConfig()
+	if ic.UserName == "" && len(ic.Groups) == 0 {
+		return config
+	}
+	config = rest.CopyConfig(config)
+	config.Impersonate = ic
+	return config
+}
+
+// setSessionImpersonation validates and applies the identity requested in
+// the initialize capabilities, if any.
+func (s *Server) setSessionImpersonation(params json.RawMessage) error {
+	requested, err := requestedImpersonation(params)
+	if err != nil || requested == nil {
+		return err
+	}
+
+	s.impersonationMu.Lock()
+	defer s.impersonationMu.Unlock()
+	if requested.User == "" {
+		return fmt.Errorf("%s requires a user", impersonationCapability)
+	}
+	if !guardrail.MatchesAny(requested.User, s.impersonation.AllowedUsers) {
+		return fmt.Errorf("impersonating user %q is not allowed", requested.User)
+	}
+	for _, group := range requested.Groups {
+		if !guardrail.MatchesAny(group, s.impersonation.AllowedGroups) {
+			return fmt.Errorf("impersonating group %q is not allowed", group)
+		}
+	}
+	s.session = requested
+	return nil
+}
+
+// rejectSessionImpersonation refuses the impersonation capability for
+// clients whose identity comes from the HTTP transport.
+func rejectSessionImpersonation(params json.RawMessage) error {
+	requested, err := requestedImpersonation(params)
+	if err != nil {
+		return err
+	}
+	if requested != nil {
+		return fmt.Errorf("%s is not supported over HTTP: calls run as the authenticated caller", impersonationCapability)
+	}
+	return nil
+}
+
+// requestedImpersonation returns the identity requested in the initialize
+// capabilities, or nil if none was requested.
+func requestedImpersonation(params json.RawMessage) (*SessionImpersonation, error) {
+	var init struct {
+		Capabilities struct {
+			Experimental map[string]json.RawMessage `json:"experimental"`
+		} `json:"capabilities"`
+	}
+	if len(params) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(params, &init); err != nil {
+		return nil, nil
+	}
+	raw, ok := init.Capabilities.Experimental[impersonationCapability]
+	if !ok {
+		return nil, nil
+	}
+	var requested SessionImpersonation
+	if err := json.Unmarshal(raw, &requested); err != nil {
+		return nil, fmt.Errorf("invalid %s capability: %w", impersonationCapability, err)
+	}
+	return &requested, nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+)
+
+func initializeParams(t *testing.T, user string, groups ...string) json.RawMessage {
+	t.Helper()
+	params, err := json.Marshal(map[string]interface{}{
+		"protocolVersion": MCPVersion,
+		"capabilities": map[string]interface{}{
+			"experimental": map[string]interface{}{
+				impersonationCapability: SessionImpersonation{User: user, Groups: groups},
+			},
+		},
+	})
+	require.NoError(t, err)
+	return params
+}
+
+func TestWithImpersonation_AppliesServerIdentityWithoutMutatingInput(t *testing.T) {
+	s := &Server{}
+	base := &rest.Config{Host: "https://example"}
+	assert.Same(t, base, s.withImpersonation(base), "no impersonation should return the config unchanged")
+
+	s.SetImpersonation(Impersonation{User: "ops-bot", Groups: []string{"sre"}})
+	got := s.withImpersonation(base)
+	assert.Equal(t, "ops-bot", got.Impersonate.UserName)
+	assert.Equal(t, []string{"sre"}, got.Impersonate.Groups)
+	assert.Empty(t, base.Impersonate.UserName, "input config must not be modified")
+}
+
+func TestHandleInitialize_SessionImpersonationWithinAllowList(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Server{writer: &buf}
+	s.SetImpersonation(Impersonation{
+		User:          "ops-bot",
+		AllowedUsers:  []string{"alice@example.com", "system:serviceaccount:team-a:*"},
+		AllowedGroups: []string{"team-a"},
+	})
+
+	s.handleInitialize(context.Background(), &Request{ID: 1, Params: initializeParams(t, "system:serviceaccount:team-a:ci", "team-a")})
+	responses := decodeResponses(t, buf.String())
+	require.Len(t, responses, 1)
+	require.Nil(t, responses[0].Error)
+
+	got := s.withImpersonation(&rest.Config{})
+	assert.Equal(t, "system:serviceaccount:team-a:ci", got.Impersonate.UserName)
+	assert.Equal(t, []string{"team-a"}, got.Impersonate.Groups)
+}
+
+func TestHandleInitialize_RejectsImpersonationOutsideAllowList(t *testing.T) {
+	tests := []struct {
+		name   string
+		imp    Impersonation
+		params json.RawMessage
+	}{
+		{"no allow list", Impersonation{}, initializeParams(t, "alice")},
+		{"user not allowed", Impersonation{AllowedUsers: []string{"bob"}}, initializeParams(t, "alice")},
+		{"group not allowed", Impersonation{AllowedUsers: []string{"alice"}, AllowedGroups: []string{"dev"}}, initializeParams(t, "alice", "system:masters")},
+		{"missing user", Impersonation{AllowedUsers: []string{"*"}}, initializeParams(t, "")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := &Server{writer: &buf}
+			s.SetImpersonation(tt.imp)
+
+			s.handleInitialize(context.Background(), &Request{ID: 1, Params: tt.params})
+			responses := decodeResponses(t, buf.String())
+			require.Len(t, responses, 1)
+			require.NotNil(t, responses[0].Error)
+			assert.Equal(t, -32602, responses[0].Error.Code)
+			assert.Empty(t, s.withImpersonation(&rest.Config{}).Impersonate.UserName)
+		})
+	}
+}
+
+func TestHandleInitialize_WithoutImpersonationCapability(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Server{writer: &buf}
+	s.handleInitialize(context.Background(), &Request{ID: 1, Params: json.RawMessage(`{"capabilities":{}}`)})
+	responses := decodeResponses(t, buf.String())
+	require.Len(t, responses, 1)
+	assert.Nil(t, responses[0].Error)
+}
+
+func TestPrepareConfig_AppliesNamespaceGuardrails(t *testing.T) {
+	s := &Server{}
+	base := &rest.Config{Host: "https://example"}
+	assert.Same(t, base, s.prepareConfig(base))
+
+	s.SetNamespacePolicy(guardrail.DefaultNamespacePolicy())
+	got := s.prepareConfig(base)
+	assert.NotSame(t, base, got)
+	assert.NotNil(t, got.WrapTransport, "guarded configs must wrap the transport")
+	assert.Nil(t, base.WrapTransport, "input config must not be modified")
+}
@@ -5,17 +5,53 @@ import (
 	"encoding/json"
 	"fmt"
 	"sync"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
 )
 
 // ClusterResult represents the result of an operation on a single cluster
 const maxConcurrentClusterOperations = 20
 
 type ClusterResult struct {
-	Cluster string      `json:"cluster"`
-	Result  interface{} `json:"result,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Cluster    string      `json:"cluster"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	ErrorClass retry.Class `json:"errorClass,omitempty"`
+}
+
+// clusterRetryPolicy retries transient per-cluster failures. The budget is
+// shared by every tool call so an unreachable fleet cannot multiply traffic.
+var clusterRetryPolicy = func() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.Budget = retry.NewBudget(50, time.Minute)
+	return policy
+}()
+
+func clusterErrorResult(clusterName string, err error) ClusterResult {
+	return ClusterResult{
+		Cluster:    clusterName,
+		Error:      err.Error(),
+		ErrorClass: retry.Classify(err),
+	}
+}
+
+// runWithRetry invokes fn, retrying retryable errors per clusterRetryPolicy
+// when ctx marks the operation idempotent (see retry.WithIdempotent).
+func runWithRetry(ctx context.Context, client kubernetes.Interface, clusterName string, fn ExecuteFunc) (interface{}, error) {
+	if !retry.IsIdempotent(ctx) {
+		return fn(ctx, client, clusterName)
+	}
+	var result interface{}
+	err := retry.Do(ctx, clusterRetryPolicy, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx, client, clusterName)
+		return err
+	})
+	return result, err
 }
 
 // ExecuteFunc is a function that executes on a single cluster
@@ -36,26 +72,28 @@ func (s *Server) executeMultiCluster(ctx context.Context, clusterName string, fn
 
 // executeSingle runs the operation on a single cluster
 func (s *Server) executeSingle(ctx context.Context, clusterName string, fn ExecuteFunc) ([]ClusterResult, error) {
+	if fanout.Skip(ctx, clusterName) {
+		return []ClusterResult{}, nil
+	}
+	return []ClusterResult{s.runOnCluster(ctx, clusterName, fn)}, nil
+}
+
+// runOnCluster runs the operation on one cluster and records its status for
+// the call's fan-out status matrix.
+func (s *Server) runOnCluster(ctx context.Context, clusterName string, fn ExecuteFunc) ClusterResult {
+	start := time.Now()
 	client, err := s.getClientForCluster(clusterName)
 	if err != nil {
-		return []ClusterResult{{
-			Cluster: clusterName,
-			Error:   err.Error(),
-		}}, nil
+		fanout.Record(ctx, clusterName, err, time.Since(start))
+		return clusterErrorResult(clusterName, err)
 	}
 
-	result, err := fn(ctx, client, clusterName)
+	result, err := runWithRetry(ctx, client, clusterName, fn)
+	fanout.Record(ctx, clusterName, err, time.Since(start))
 	if err != nil {
-		return []ClusterResult{{
-			Cluster: clusterName,
-			Error:   err.Error(),
-		}}, nil
+		return clusterErrorResult(clusterName, err)
 	}
-
-	return []ClusterResult{{
-		Cluster: clusterName,
-		Result:  result,
-	}}, nil
+	return ClusterResult{Cluster: clusterName, Result: result}
 }
 
 // executeAll runs the operation across all discovered clusters in parallel
@@ -75,36 +113,18 @@ func (s *Server) executeAll(ctx context.Context, fn ExecuteFunc) ([]ClusterResul
 	var mu sync.Mutex
 
 	for _, cluster := range clusters {
+		if fanout.Skip(ctx, cluster.Name) {
+			continue
+		}
 		sem <- struct{}{}
 		wg.Add(1)
 		go func(clusterName string) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			client, err := s.getClientForCluster(clusterName)
-			if err != nil {
-				mu.Lock()
-				results = append(results, ClusterResult{
-					Cluster: clusterName,
-					Error:   err.Error(),
-				})
-				mu.Unlock()
-				return
-			}
-
-			result, err := fn(ctx, client, clusterName)
+			result := s.runOnCluster(ctx, clusterName, fn)
 			mu.Lock()
-			if err != nil {
-				results = append(results, ClusterResult{
-					Cluster: clusterName,
-					Error:   err.Error(),
-				})
-			} else {
-				results = append(results, ClusterResult{
-					Cluster: clusterName,
-					Result:  result,
-				})
-			}
+			results = append(results, result)
 			mu.Unlock()
 		}(cluster.Name)
 	}
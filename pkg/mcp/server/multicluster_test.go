@@ -9,10 +9,13 @@ import (
 	"testing"
 	"time"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 
 	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -43,7 +46,7 @@ func TestExecuteSingleClusterVariants(t *testing.T) {
 			execFn: func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
 				return nil, nil
 			},
-			want: ClusterResult{Cluster: "alpha", Error: "client boom"},
+			want: ClusterResult{Cluster: "alpha", Error: "client boom", ErrorClass: retry.ClassUnknown},
 		},
 		{
 			name: "execution error becomes result error",
@@ -53,7 +56,7 @@ func TestExecuteSingleClusterVariants(t *testing.T) {
 			execFn: func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
 				return nil, errors.New("query failed")
 			},
-			want: ClusterResult{Cluster: "alpha", Error: "query failed"},
+			want: ClusterResult{Cluster: "alpha", Error: "query failed", ErrorClass: retry.ClassUnknown},
 		},
 	}
 
@@ -93,8 +96,8 @@ func TestExecuteAllAggregatesResultsAndErrors(t *testing.T) {
 	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
 	assert.Equal(t, "alpha", results[0].Cluster)
 	assert.Equal(t, map[string]string{"status": "alpha-ok"}, results[0].Result)
-	assert.Equal(t, ClusterResult{Cluster: "beta", Error: "client unavailable"}, results[1])
-	assert.Equal(t, ClusterResult{Cluster: "gamma", Error: "fan-out failed"}, results[2])
+	assert.Equal(t, ClusterResult{Cluster: "beta", Error: "client unavailable", ErrorClass: retry.ClassUnknown}, results[1])
+	assert.Equal(t, ClusterResult{Cluster: "gamma", Error: "fan-out failed", ErrorClass: retry.ClassUnknown}, results[2])
 }
 
 func TestExecuteAllDiscoveryFailures(t *testing.T) {
@@ -201,3 +204,61 @@ func TestExecuteAllBoundsConcurrency(t *testing.T) {
 		t.Fatal("timed out waiting for executeAll() to finish")
 	}
 }
+
+func TestRetryFailedRunsOnlyFailedClusters(t *testing.T) {
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": nil, "beta": nil})
+	var betaDown atomic.Bool
+	betaDown.Store(true)
+	server.clientFactory = func(clusterName string) (kubernetes.Interface, error) {
+		if clusterName == "beta" && betaDown.Load() {
+			return nil, errors.New("dial tcp 10.0.0.2:6443: connect: connection refused")
+		}
+		return clients[clusterName], nil
+	}
+	statuses := func(result CallToolResult) map[string]string {
+		got := make(map[string]string)
+		matrix, _ := result.Meta[fanout.MetaKey].([]interface{})
+		for _, row := range matrix {
+			row := row.(map[string]interface{})
+			got[row["cluster"].(string)] = row["status"].(string)
+		}
+		return got
+	}
+
+	result, rpcErr := callTool(t, server, "list_addons", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	assert.Equal(t, map[string]string{"alpha": "ok", "beta": "unreachable"}, statuses(result))
+
+	betaDown.Store(false)
+	result, rpcErr = callTool(t, server, "list_addons", map[string]interface{}{fanout.ArgRetryFailed: true})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Equal(t, map[string]string{"alpha": "skipped", "beta": "ok"}, statuses(result))
+	assert.NotContains(t, result.Content[0].Text, "## alpha")
+
+	result, _ = callTool(t, server, "list_addons", map[string]interface{}{fanout.ArgRetryFailed: true})
+	require.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "succeeded on every cluster")
+}
+
+func TestRunWithRetryRetriesOnlyIdempotentCalls(t *testing.T) {
+	refusedOnce := func(calls *atomic.Int32) ExecuteFunc {
+		return func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+			if calls.Add(1) == 1 {
+				return nil, errors.New("dial tcp 10.0.0.1:6443: connect: connection refused")
+			}
+			return "ok", nil
+		}
+	}
+
+	var writes atomic.Int32
+	_, err := runWithRetry(context.Background(), nil, "alpha", refusedOnce(&writes))
+	require.Error(t, err)
+	assert.Equal(t, int32(1), writes.Load(), "a write must not be sent twice")
+
+	var reads atomic.Int32
+	result, err := runWithRetry(retry.WithIdempotent(context.Background()), nil, "alpha", refusedOnce(&reads))
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, int32(2), reads.Load())
+}
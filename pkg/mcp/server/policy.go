@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/policy"
+)
+
+// authorizeToolCall evaluates the authorization policy for a call. It
+// returns an error if the call is denied or the policy cannot be evaluated,
+// and otherwise the policy's reasons for requiring approval, if any.
+func (s *Server) authorizeToolCall(ctx context.Context, td *ToolDef, args map[string]interface{}) ([]string, error) {
+	engine := s.getPolicyEngine()
+	if !engine.Enabled() {
+		return nil, nil
+	}
+	id := policy.Identity{}
+	if caller := callerFrom(ctx); caller != nil {
+		id.User, id.Groups = caller.User, caller.Groups
+	} else {
+		ic := s.impersonationConfig()
+		id.User, id.Groups = ic.UserName, ic.Groups
+	}
+	in := policy.NewInput(ServerName, td.Schema.Name, args, td.Mutating, id)
+	decision, err := engine.Evaluate(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	if err := decision.Err(); err != nil {
+		return nil, err
+	}
+	return decision.RequireApproval, nil
+}
+
+// getPolicyEngine returns the engine configured by $KUBESTELLAR_POLICY, or
+// nil if no policy is configured.
+func (s *Server) getPolicyEngine() *policy.Engine {
+	s.policyEngineOnce.Do(func() {
+		if s.policyEngine == nil {
+			s.policyEngine = policy.FromEnv()
+		}
+	})
+	return s.policyEngine
+}
+
+// policyApprovalNote explains why a call needs approval.
+func policyApprovalNote(reasons []string) string {
+	return fmt.Sprintf("Approval required by policy: %s.", strings.Join(reasons, "; "))
+}
+
+// policyApprovalError is the result for a read-only call that the policy
+// requires approval for. Such calls have no dry run, so the caller must
+// repeat the call with approved: true.
+func policyApprovalError(reasons []string) CallToolResult {
+	return CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: fmt.Sprintf("%s Repeat the call with %s: true to proceed.",
+			policyApprovalNote(reasons), approval.ArgApproved)}},
+		IsError: true,
+	}
+}
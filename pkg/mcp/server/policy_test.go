@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/policy"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// fakeOPA writes an opa stand-in that prints output for every evaluation.
+func fakeOPA(t *testing.T, output string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "opa")
+	script := "#!/bin/sh\ncat >/dev/null\necho '" + output + "'\n"
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+	return path
+}
+
+func TestAuthorizeToolCall(t *testing.T) {
+	td := &ToolDef{Schema: Tool{Name: "delete_pod"}, Mutating: true}
+
+	s := &Server{policyEngine: policy.New("policy.rego", fakeOPA(t,
+		`{"result":[{"expressions":[{"value":{"deny":["no deletes in prod"]}}]}]}`))}
+	_, err := s.authorizeToolCall(context.Background(), td, map[string]interface{}{"cluster": "prod"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no deletes in prod")
+
+	s = &Server{policyEngine: policy.New("policy.rego", fakeOPA(t,
+		`{"result":[{"expressions":[{"value":{"require_approval":["deletes need review"]}}]}]}`))}
+	reasons, err := s.authorizeToolCall(context.Background(), td, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deletes need review"}, reasons)
+
+	s = &Server{policyEngine: policy.New("policy.rego", filepath.Join(t.TempDir(), "missing"))}
+	_, err = s.authorizeToolCall(context.Background(), td, nil)
+	assert.Error(t, err, "an unevaluable policy must deny the call")
+}
+
+func TestCallMutatingTool_PolicyRequiresApproval(t *testing.T) {
+	var dryRuns []bool
+	td := &ToolDef{
+		Schema:   Tool{Name: "set_ownership_policy_mode"},
+		Mutating: true,
+		Handler: func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			dryRuns = append(dryRuns, approval.IsDryRun(ctx))
+			return "mode updated", false
+		},
+	}
+	// Approval mode is off, but the policy requires approval.
+	s := &Server{approvalGate: approval.NewGate(approval.ModeOff, store.NewMemory())}
+	reasons := []string{"mode changes need review"}
+
+	preview := s.callMutatingTool(context.Background(), td, map[string]interface{}{"mode": "enforce"}, reasons)
+	require.False(t, preview.IsError)
+	require.Len(t, preview.Content, 3)
+	assert.Contains(t, preview.Content[1].Text, "mode changes need review")
+
+	applied := s.callMutatingTool(context.Background(), td, map[string]interface{}{"mode": "enforce", "approved": true}, reasons)
+	assert.False(t, applied.IsError)
+	assert.Equal(t, []bool{true, false}, dryRuns)
+}
@@ -0,0 +1,229 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// promptDef is a built-in prompt template. Its messages tell the model
+// which tools to call with which arguments, so clients with a prompt picker
+// can run a troubleshooting workflow without knowing the tool catalog.
+type promptDef struct {
+	Prompt Prompt
+	// Tools are the tools the prompt calls, checked against the registry
+	// by the tests.
+	Tools  []string
+	Render func(args map[string]string) string
+}
+
+var (
+	clusterPromptArg = PromptArgument{Name: "cluster", Description: "Cluster name (uses current context if not specified)"}
+	subjectKinds     = []string{"User", "Group", "ServiceAccount"}
+)
+
+var builtinPrompts = []promptDef{
+	{
+		Prompt: Prompt{
+			Name:        "diagnose_failing_pod",
+			Description: "Find out why a pod is crashing, pending, or not ready, and propose a fix",
+			Arguments: []PromptArgument{
+				{Name: "namespace", Description: "Namespace of the pod", Required: true},
+				{Name: "pod", Description: "Name of the pod", Required: true},
+				clusterPromptArg,
+			},
+		},
+		Tools: []string{"describe_pod", "get_pod_logs", "get_warning_events", "what_changed", "find_pod_issues"},
+		Render: func(args map[string]string) string {
+			cluster, ns, pod := args["cluster"], args["namespace"], args["pod"]
+			var sb strings.Builder
+			_, _ = fmt.Fprintf(&sb, "Pod %s/%s%s is failing. Find out why and propose a fix.\n\n", ns, pod, onCluster(cluster))
+			sb.WriteString("Gather evidence with these tools, in order:\n\n")
+			writePromptSteps(&sb,
+				promptStep("describe_pod", "for its status, container states, restarts, and events", "cluster", cluster, "namespace", ns, "name", pod, "include_events", true),
+				promptStep("get_pod_logs", "for the last lines of each container that restarted or failed; pass container when there are several", "cluster", cluster, "namespace", ns, "name", pod, "tail_lines", 200),
+				promptStep("get_warning_events", "for scheduling, image pull, probe, and volume failures", "cluster", cluster, "namespace", ns, "involved_object", pod),
+				promptStep("what_changed", "to see whether a recent change to the namespace explains it", "cluster", cluster, "namespace", ns, "since", "2h"),
+				promptStep("find_pod_issues", "to see whether other pods in the namespace fail the same way", "cluster", cluster, "namespace", ns),
+			)
+			sb.WriteString("\nThen report:\n\n")
+			sb.WriteString("- the root cause, quoting the log lines, events, or status fields that show it\n")
+			sb.WriteString("- the fix, as the exact change to make (manifest field, command, or configuration)\n")
+			sb.WriteString("- how to confirm the fix worked\n\n")
+			sb.WriteString("Do not change anything in the cluster; only diagnose and recommend.\n")
+			return sb.String()
+		},
+	},
+	{
+		Prompt: Prompt{
+			Name:        "prepare_upgrade_runbook",
+			Description: "Check a cluster's readiness for a Kubernetes or OpenShift upgrade and write a step-by-step runbook",
+			Arguments: []PromptArgument{
+				{Name: "target_version", Description: "Kubernetes (e.g., 1.32) or, on OpenShift, OpenShift (e.g., 4.16) version to upgrade to", Required: true},
+				clusterPromptArg,
+			},
+		},
+		Tools: []string{"get_cluster_version_info", "get_upgrade_prerequisites", "simulate_upgrade_impact", "check_admission_webhooks", "check_helm_release_upgrades"},
+		Render: func(args map[string]string) string {
+			cluster, target := args["cluster"], args["target_version"]
+			var sb strings.Builder
+			_, _ = fmt.Fprintf(&sb, "Prepare a runbook for upgrading %s to %s.\n\n", promptCluster(cluster), target)
+			sb.WriteString("Assess the cluster with these tools, in order:\n\n")
+			writePromptSteps(&sb,
+				promptStep("get_cluster_version_info", "for the current version and whether the upgrade path to the target is supported", "cluster", cluster),
+				promptStep("get_upgrade_prerequisites", "for unhealthy nodes, failing pods, and degraded operators that must be fixed first", "cluster", cluster),
+				promptStep("simulate_upgrade_impact", "for removed APIs in use, workloads that lose all replicas during node drains, and operators without support for the target", "cluster", cluster, "target_version", target),
+				promptStep("check_admission_webhooks", "for fail-closed webhooks without ready backends, which block the API server during the upgrade", "cluster", cluster),
+				promptStep("check_helm_release_upgrades", "for charts that need a newer version to support the target", "cluster", cluster),
+			)
+			sb.WriteString("\nThen write the runbook as Markdown with these sections:\n\n")
+			sb.WriteString("1. **Go/No-Go**: whether the upgrade can proceed, and the blockers if not\n")
+			sb.WriteString("2. **Before the upgrade**: each blocker and risk with the exact command or manifest change that resolves it, and a backup step\n")
+			sb.WriteString("3. **Upgrade**: the control plane and node pool steps in order, with what to watch at each step\n")
+			sb.WriteString("4. **Verification**: the checks that show the cluster is healthy afterwards\n")
+			sb.WriteString("5. **Rollback**: what to do if a step fails\n\n")
+			sb.WriteString("Do not start the upgrade; only prepare the runbook.\n")
+			return sb.String()
+		},
+	},
+	{
+		Prompt: Prompt{
+			Name:        "rbac_audit_subject",
+			Description: "Audit what a user, group, or service account can do, flag risky permissions, and suggest least-privilege changes",
+			Arguments: []PromptArgument{
+				{Name: "subject_kind", Description: "Kind of subject: User, Group, or ServiceAccount", Required: true},
+				{Name: "subject_name", Description: "Name of the subject", Required: true},
+				{Name: "namespace", Description: "Namespace of a ServiceAccount subject"},
+				clusterPromptArg,
+			},
+		},
+		Tools: []string{"analyze_subject_permissions", "describe_role", "get_cluster_role_bindings"},
+		Render: func(args map[string]string) string {
+			cluster, kind, name, ns := args["cluster"], args["subject_kind"], args["subject_name"], args["namespace"]
+			subject := kind + " " + name
+			if ns != "" {
+				subject = kind + " " + ns + "/" + name
+			}
+			var sb strings.Builder
+			_, _ = fmt.Fprintf(&sb, "Audit the RBAC permissions of %s%s.\n\n", subject, onCluster(cluster))
+			sb.WriteString("Gather its permissions with these tools, in order:\n\n")
+			writePromptSteps(&sb,
+				promptStep("analyze_subject_permissions", "for every role bound to it and the rules they grant", "cluster", cluster, "subject_kind", kind, "subject_name", name, "namespace", ns),
+				promptStep("describe_role", "for any role whose rules need a closer look, adding its name, and its namespace for a Role", "cluster", cluster),
+				promptStep("get_cluster_role_bindings", "to find bindings through groups the subject belongs to", "cluster", cluster),
+			)
+			sb.WriteString("\nThen report:\n\n")
+			sb.WriteString("- a table of its effective permissions: resource, verbs, scope (cluster or namespaces), and the binding that grants them\n")
+			sb.WriteString("- risky permissions, with why each is risky: wildcards, reading Secrets, pods/exec and pods/attach, creating pods or workloads in privileged namespaces, bind, escalate, impersonate, and changes to RBAC or admission webhooks\n")
+			sb.WriteString("- least-privilege changes, as the Role or ClusterRole rules and bindings to use instead\n\n")
+			sb.WriteString("Do not change any roles or bindings; only audit and recommend.\n")
+			return sb.String()
+		},
+	},
+}
+
+// promptCluster names the cluster a prompt is about, or the current
+// context.
+func promptCluster(cluster string) string {
+	if cluster == "" {
+		return "the current cluster"
+	}
+	return "cluster " + cluster
+}
+
+func onCluster(cluster string) string {
+	return " in " + promptCluster(cluster)
+}
+
+// promptStep renders a tool call for a prompt: the tool, its arguments as
+// JSON (empty ones left out), and why to call it. kv alternates argument
+// names and values.
+func promptStep(tool, why string, kv ...interface{}) string {
+	args := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if v, ok := kv[i+1].(string); ok && v == "" {
+			continue
+		}
+		args[kv[i].(string)] = kv[i+1]
+	}
+	data, _ := json.Marshal(args)
+	return fmt.Sprintf("`%s` with `%s` %s", tool, data, why)
+}
+
+func writePromptSteps(sb *strings.Builder, steps ...string) {
+	for i, step := range steps {
+		_, _ = fmt.Fprintf(sb, "%d. %s\n", i+1, step)
+	}
+}
+
+func findPromptDef(name string) *promptDef {
+	for i := range builtinPrompts {
+		if builtinPrompts[i].Prompt.Name == name {
+			return &builtinPrompts[i]
+		}
+	}
+	return nil
+}
+
+// validatePromptArgs checks that args holds every required argument of p
+// and no unknown ones, and validates namespaces and subject kinds as the
+// tools would.
+func validatePromptArgs(p *promptDef, args map[string]string) error {
+	for name := range args {
+		if !slices.ContainsFunc(p.Prompt.Arguments, func(a PromptArgument) bool { return a.Name == name }) {
+			return fmt.Errorf("unknown argument %q for prompt %s", name, p.Prompt.Name)
+		}
+	}
+	for _, a := range p.Prompt.Arguments {
+		if a.Required && args[a.Name] == "" {
+			return fmt.Errorf("argument %q is required for prompt %s", a.Name, p.Prompt.Name)
+		}
+	}
+	if ns, ok := args["namespace"]; ok && ns != "" {
+		if err := ValidateNamespace(ns); err != nil {
+			return err
+		}
+	}
+	if kind, ok := args["subject_kind"]; ok && !slices.Contains(subjectKinds, kind) {
+		return fmt.Errorf("subject_kind must be one of %s", strings.Join(subjectKinds, ", "))
+	}
+	if args["subject_kind"] == "ServiceAccount" && args["namespace"] == "" {
+		return fmt.Errorf("namespace is required for ServiceAccount subjects")
+	}
+	return nil
+}
+
+func (s *Server) handlePromptsList(ctx context.Context, req *Request) {
+	prompts := make([]Prompt, len(builtinPrompts))
+	for i, p := range builtinPrompts {
+		prompts[i] = p.Prompt
+	}
+	s.sendResult(ctx, req.ID, PromptsListResult{Prompts: prompts})
+}
+
+func (s *Server) handlePromptsGet(ctx context.Context, req *Request) {
+	var params GetPromptParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		s.sendError(ctx, req.ID, errCodeInvalidParams, "Invalid params", nil)
+		return
+	}
+	p := findPromptDef(params.Name)
+	if p == nil {
+		s.sendError(ctx, req.ID, errCodeInvalidParams, fmt.Sprintf("Unknown prompt: %s", params.Name), nil)
+		return
+	}
+	args := make(map[string]string, len(params.Arguments))
+	for k, v := range params.Arguments {
+		args[k] = strings.TrimSpace(v)
+	}
+	if err := validatePromptArgs(p, args); err != nil {
+		s.sendError(ctx, req.ID, errCodeInvalidParams, err.Error(), nil)
+		return
+	}
+	s.sendResult(ctx, req.ID, GetPromptResult{
+		Description: p.Prompt.Description,
+		Messages:    []PromptMessage{{Role: "user", Content: ContentBlock{Type: "text", Text: p.Render(args)}}},
+	})
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltinPromptsCallRegisteredTools(t *testing.T) {
+	for _, p := range builtinPrompts {
+		for _, tool := range p.Tools {
+			assert.NotNil(t, findToolDef(tool), "prompt %s calls unknown tool %s", p.Prompt.Name, tool)
+		}
+	}
+}
+
+func TestPromptsListAndGet(t *testing.T) {
+	server := &Server{}
+
+	resp := sendMCPRequest(t, server, "prompts/list", nil)
+	require.Nil(t, resp.Error)
+	var list PromptsListResult
+	require.NoError(t, json.Unmarshal(resp.Result, &list))
+	var names []string
+	for _, p := range list.Prompts {
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"diagnose_failing_pod", "prepare_upgrade_runbook", "rbac_audit_subject"}, names)
+
+	resp = sendMCPRequest(t, server, "prompts/get", GetPromptParams{
+		Name:      "diagnose_failing_pod",
+		Arguments: map[string]string{"namespace": "shop", "pod": " web-1 ", "cluster": "prod"},
+	})
+	require.Nil(t, resp.Error)
+	var got GetPromptResult
+	require.NoError(t, json.Unmarshal(resp.Result, &got))
+	require.Len(t, got.Messages, 1)
+	assert.Equal(t, "user", got.Messages[0].Role)
+	text := got.Messages[0].Content.Text
+	assert.Contains(t, text, "Pod shop/web-1 in cluster prod is failing.")
+	assert.Contains(t, text, "1. `describe_pod` with `{\"cluster\":\"prod\",\"include_events\":true,\"name\":\"web-1\",\"namespace\":\"shop\"}`")
+	assert.Contains(t, text, "`get_warning_events` with `{\"cluster\":\"prod\",\"involved_object\":\"web-1\",\"namespace\":\"shop\"}`")
+
+	resp = sendMCPRequest(t, server, "prompts/get", GetPromptParams{
+		Name:      "prepare_upgrade_runbook",
+		Arguments: map[string]string{"target_version": "1.32"},
+	})
+	require.Nil(t, resp.Error)
+	require.NoError(t, json.Unmarshal(resp.Result, &got))
+	text = got.Messages[0].Content.Text
+	assert.Contains(t, text, "Prepare a runbook for upgrading the current cluster to 1.32.")
+	assert.Contains(t, text, "`simulate_upgrade_impact` with `{\"target_version\":\"1.32\"}`")
+
+	for _, tc := range []struct {
+		name string
+		args map[string]string
+		want string
+	}{
+		{"diagnose_failing_pod", map[string]string{"namespace": "shop"}, `argument "pod" is required`},
+		{"diagnose_failing_pod", map[string]string{"namespace": "kube-system", "pod": "dns"}, "kube-system"},
+		{"diagnose_failing_pod", map[string]string{"namespace": "shop", "pod": "web", "container": "app"}, `unknown argument "container"`},
+		{"rbac_audit_subject", map[string]string{"subject_kind": "Robot", "subject_name": "r2"}, "subject_kind must be one of"},
+		{"rbac_audit_subject", map[string]string{"subject_kind": "ServiceAccount", "subject_name": "ci"}, "namespace is required"},
+		{"missing", nil, "Unknown prompt: missing"},
+	} {
+		resp = sendMCPRequest(t, server, "prompts/get", GetPromptParams{Name: tc.name, Arguments: tc.args})
+		require.NotNil(t, resp.Error, tc.want)
+		assert.Equal(t, errCodeInvalidParams, resp.Error.Code)
+		assert.Contains(t, resp.Error.Message, tc.want)
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"log"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/provenance"
+)
+
+// getProvenanceSigner returns the signer configured by
+// $KUBESTELLAR_PROVENANCE_KEY, or nil if results are not signed.
+func (s *Server) getProvenanceSigner() *provenance.Signer {
+	s.provenanceOnce.Do(func() {
+		if s.provenanceSigner != nil {
+			return
+		}
+		signer, err := provenance.SignerFromEnv()
+		if err != nil {
+			log.Printf("Tool results will not be signed: %v", err)
+			return
+		}
+		s.provenanceSigner = signer
+	})
+	return s.provenanceSigner
+}
+
+// withProvenance signs a result as it will be sent, after redaction, and
+// attaches the block as _meta.provenance.
+func withProvenance(signer *provenance.Signer, tool string, c *provenance.Collector, result CallToolResult) CallToolResult {
+	texts := make([]string, len(result.Content))
+	for i, block := range result.Content {
+		texts[i] = block.Text
+	}
+	cached, _ := result.Meta["cached"].(bool)
+	if result.Meta == nil {
+		result.Meta = make(map[string]interface{})
+	}
+	result.Meta["provenance"] = signer.Sign(ServerName, ServerVersion, tool, texts, c, cached)
+	return result
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/provenance"
+)
+
+func TestHandleToolsCall_SignsResults(t *testing.T) {
+	registerTestTool(t, "health_test", func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+		return "all clusters healthy", false
+	})
+	key := []byte("0123456789abcdef0123456789abcdef")
+	var buf bytes.Buffer
+	s := &Server{writer: &buf, provenanceSigner: provenance.NewHMACSigner(key)}
+
+	s.handleToolsCall(context.Background(), &Request{ID: 1, Params: json.RawMessage(`{"name":"health_test","arguments":{}}`)})
+
+	var resp struct {
+		Result struct {
+			Content []ContentBlock `json:"content"`
+			Meta    struct {
+				Provenance provenance.Block `json:"provenance"`
+			} `json:"_meta"`
+		} `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &resp))
+	block := resp.Result.Meta.Provenance
+	assert.Equal(t, ServerName, block.Server)
+	assert.Equal(t, "health_test", block.Tool)
+	require.Len(t, resp.Result.Content, 1)
+	assert.NoError(t, provenance.Verify(&block, []string{resp.Result.Content[0].Text}, key),
+		"the block must verify after a JSON round trip")
+}
@@ -0,0 +1,485 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/redact"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
+)
+
+// Cluster resources are addressed as k8s://<cluster>,
+// k8s://<cluster>/<namespace>, and
+// k8s://<cluster>/<namespace>/deployments/<name>. The results of recent
+// read-only tool calls are kubestellar://diagnostics/<id>.
+const (
+	resourceScheme         = "k8s://"
+	diagnosticsURIPrefix   = "kubestellar://diagnostics/"
+	deploymentResourceKind = "deployments"
+)
+
+const (
+	// maxListedResources bounds a resources/list response; namespace
+	// resources list every deployment in them.
+	maxListedResources = 1000
+	// maxRecentDiagnostics is how many tool results are kept as
+	// resources.
+	maxRecentDiagnostics = 20
+	// maxResourceEvents is how many warning events a namespace resource
+	// shows.
+	maxResourceEvents = 20
+)
+
+// JSON-RPC error codes of resources/read.
+const (
+	errCodeInvalidParams    = -32602
+	errCodeInternal         = -32603
+	errCodeResourceNotFound = -32002
+)
+
+// errResourceNotFound is returned for URIs that name nothing.
+var errResourceNotFound = errors.New("resource not found")
+
+// resourceURI is a parsed k8s:// URI. Namespace, Kind, and Name are empty
+// for a cluster, and Kind and Name for a namespace.
+type resourceURI struct {
+	Cluster   string
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+func (u resourceURI) String() string {
+	parts := []string{u.Cluster}
+	if u.Namespace != "" {
+		parts = append(parts, u.Namespace)
+	}
+	if u.Kind != "" {
+		parts = append(parts, u.Kind, u.Name)
+	}
+	return resourceScheme + strings.Join(parts, "/")
+}
+
+// parseResourceURI parses a k8s:// URI. System namespaces are refused, as
+// they are by the tools.
+func parseResourceURI(uri string) (resourceURI, error) {
+	rest, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return resourceURI{}, fmt.Errorf("unsupported resource URI %q: must start with %s or %s", uri, resourceScheme, diagnosticsURIPrefix)
+	}
+	parts := strings.Split(strings.TrimSuffix(rest, "/"), "/")
+	for _, p := range parts {
+		if p == "" {
+			return resourceURI{}, fmt.Errorf("invalid resource URI %q: empty path segment", uri)
+		}
+	}
+	var u resourceURI
+	switch len(parts) {
+	case 1:
+		return resourceURI{Cluster: parts[0]}, nil
+	case 2:
+		u = resourceURI{Cluster: parts[0], Namespace: parts[1]}
+	case 4:
+		u = resourceURI{Cluster: parts[0], Namespace: parts[1], Kind: strings.ToLower(parts[2]), Name: parts[3]}
+		if u.Kind != deploymentResourceKind {
+			return resourceURI{}, fmt.Errorf("unsupported kind %q in resource URI %q: only %s can be read", parts[2], uri, deploymentResourceKind)
+		}
+	default:
+		return resourceURI{}, fmt.Errorf("invalid resource URI %q: must be %s<cluster>[/<namespace>[/<kind>/<name>]]", uri, resourceScheme)
+	}
+	if err := ValidateNamespace(u.Namespace); err != nil {
+		return resourceURI{}, err
+	}
+	return u, nil
+}
+
+// diagnosticRecord is a read-only tool result kept as a resource.
+type diagnosticRecord struct {
+	ID      int
+	Tool    string
+	Cluster string
+	Owner   string
+	Time    time.Time
+	Text    string
+}
+
+func (d diagnosticRecord) uri() string {
+	return diagnosticsURIPrefix + strconv.Itoa(d.ID)
+}
+
+// recordDiagnostic keeps the redacted result of a read-only tool call for
+// resources/read, dropping the oldest beyond maxRecentDiagnostics. get_details
+// only expands results that are already kept.
+func (s *Server) recordDiagnostic(ctx context.Context, td *ToolDef, args map[string]interface{}, result CallToolResult) {
+	if td.Mutating || result.IsError || len(result.Content) == 0 || td.Schema.Name == "get_details" {
+		return
+	}
+	cluster, _ := args["cluster"].(string)
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+	s.diagnosticsSeq++
+	s.diagnostics = append(s.diagnostics, diagnosticRecord{
+		ID: s.diagnosticsSeq, Tool: td.Schema.Name, Cluster: cluster, Owner: callerName(ctx), Time: time.Now(), Text: result.Content[0].Text,
+	})
+	if len(s.diagnostics) > maxRecentDiagnostics {
+		s.diagnostics = s.diagnostics[len(s.diagnostics)-maxRecentDiagnostics:]
+	}
+}
+
+// recentDiagnostics returns the results the caller of ctx may read, newest
+// first.
+func (s *Server) recentDiagnostics(ctx context.Context) []diagnosticRecord {
+	owner := callerName(ctx)
+	s.diagnosticsMu.Lock()
+	defer s.diagnosticsMu.Unlock()
+	var records []diagnosticRecord
+	for i := len(s.diagnostics) - 1; i >= 0; i-- {
+		if s.diagnostics[i].Owner == owner {
+			records = append(records, s.diagnostics[i])
+		}
+	}
+	return records
+}
+
+func (s *Server) handleResourcesList(ctx context.Context, req *Request) {
+	resources, err := s.listResources(ctx)
+	s.audit(ctx, "resources/list", CallToolResult{IsError: err != nil})
+	if err != nil {
+		s.sendError(ctx, req.ID, errCodeInternal, err.Error(), nil)
+		return
+	}
+	s.sendResult(ctx, req.ID, ResourcesListResult{Resources: resources})
+}
+
+func (s *Server) handleResourcesRead(ctx context.Context, req *Request) {
+	var params ReadResourceParams
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		s.sendError(ctx, req.ID, errCodeInvalidParams, "Invalid params: uri is required", nil)
+		return
+	}
+	contents, err := s.readResource(retry.WithIdempotent(ctx), params.URI)
+	s.audit(ctx, "resources/read", CallToolResult{IsError: err != nil})
+	var invalid *invalidResourceError
+	switch {
+	case errors.As(err, &invalid):
+		s.sendError(ctx, req.ID, errCodeInvalidParams, err.Error(), nil)
+	case errors.Is(err, errResourceNotFound):
+		s.sendError(ctx, req.ID, errCodeResourceNotFound, err.Error(), map[string]string{"uri": params.URI})
+	case err != nil:
+		s.sendError(ctx, req.ID, errCodeInternal, err.Error(), nil)
+	default:
+		text, _ := redact.String(contents.Text)
+		contents.Text = text
+		s.sendResult(ctx, req.ID, ReadResourceResult{Contents: []ResourceContents{contents}})
+	}
+}
+
+// invalidResourceError is a URI that cannot name a resource, or that the
+// policy refuses.
+type invalidResourceError struct{ err error }
+
+func (e *invalidResourceError) Error() string { return e.err.Error() }
+func (e *invalidResourceError) Unwrap() error { return e.err }
+
+// authorizeResource checks a resources/list or resources/read request
+// against the policy, which sees it as a call of a tool with the method's
+// name. Reads a policy would hold for approval are refused, as there is no
+// way to approve them.
+func (s *Server) authorizeResource(ctx context.Context, method string, args map[string]interface{}) error {
+	requireApproval, err := s.authorizeToolCall(ctx, &ToolDef{Schema: Tool{Name: method}}, args)
+	if err != nil {
+		return err
+	}
+	if len(requireApproval) > 0 {
+		return fmt.Errorf("policy requires approval for %s (%s); use the equivalent tool instead", method, strings.Join(requireApproval, "; "))
+	}
+	return nil
+}
+
+// listResources lists the recent diagnostics of the caller, and for each
+// discovered cluster the cluster, its namespaces, and their deployments.
+// Clusters that cannot be reached are listed alone.
+func (s *Server) listResources(ctx context.Context) ([]Resource, error) {
+	if err := s.authorizeResource(ctx, "resources/list", map[string]interface{}{}); err != nil {
+		return nil, err
+	}
+	resources := []Resource{}
+	for _, d := range s.recentDiagnostics(ctx) {
+		where := "all clusters"
+		if d.Cluster != "" {
+			where = d.Cluster
+		}
+		resources = append(resources, Resource{
+			URI:         d.uri(),
+			Name:        fmt.Sprintf("%s (%s)", d.Tool, where),
+			Description: fmt.Sprintf("Result of %s on %s at %s", d.Tool, where, d.Time.UTC().Format(time.RFC3339)),
+			MimeType:    "text/markdown",
+		})
+	}
+
+	clusters, err := s.discoverer.DiscoverClusters("all")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover clusters: %w", err)
+	}
+	if len(clusters) == 0 {
+		return resources, nil
+	}
+	results, err := s.executeAll(ctx, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return clusterResources(ctx, client, clusterName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortClusterResults(results)
+	for _, r := range results {
+		resources = append(resources, Resource{
+			URI:         resourceURI{Cluster: r.Cluster}.String(),
+			Name:        r.Cluster,
+			Description: "Cluster " + r.Cluster + ": version, nodes, and namespaces",
+			MimeType:    "text/markdown",
+		})
+		if r.Error == "" {
+			resources = append(resources, r.Result.([]Resource)...)
+		}
+	}
+	if len(resources) > maxListedResources {
+		resources = resources[:maxListedResources]
+	}
+	return resources, nil
+}
+
+// clusterResources lists the namespaces of a cluster that the tools may
+// operate on, each followed by its deployments.
+func clusterResources(ctx context.Context, client kubernetes.Interface, clusterName string) ([]Resource, error) {
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	deployments, err := client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	byNamespace := make(map[string][]string)
+	for _, d := range deployments.Items {
+		byNamespace[d.Namespace] = append(byNamespace[d.Namespace], d.Name)
+	}
+
+	var resources []Resource
+	names := make([]string, 0, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		if ValidateNamespace(ns.Name) == nil {
+			names = append(names, ns.Name)
+		}
+	}
+	sort.Strings(names)
+	for _, ns := range names {
+		resources = append(resources, Resource{
+			URI:         resourceURI{Cluster: clusterName, Namespace: ns}.String(),
+			Name:        clusterName + "/" + ns,
+			Description: "Namespace " + ns + " in " + clusterName + ": deployments and recent warning events",
+			MimeType:    "text/markdown",
+		})
+		sort.Strings(byNamespace[ns])
+		for _, name := range byNamespace[ns] {
+			resources = append(resources, Resource{
+				URI:         resourceURI{Cluster: clusterName, Namespace: ns, Kind: deploymentResourceKind, Name: name}.String(),
+				Name:        clusterName + "/" + ns + "/" + name,
+				Description: "Deployment " + ns + "/" + name + " in " + clusterName,
+				MimeType:    "application/json",
+			})
+		}
+	}
+	return resources, nil
+}
+
+// readResource returns the contents of the resource uri names.
+func (s *Server) readResource(ctx context.Context, uri string) (ResourceContents, error) {
+	if id, ok := strings.CutPrefix(uri, diagnosticsURIPrefix); ok {
+		if err := s.authorizeResource(ctx, "resources/read", map[string]interface{}{"uri": uri}); err != nil {
+			return ResourceContents{}, &invalidResourceError{err}
+		}
+		for _, d := range s.recentDiagnostics(ctx) {
+			if strconv.Itoa(d.ID) == id {
+				return ResourceContents{URI: uri, MimeType: "text/markdown", Text: d.Text}, nil
+			}
+		}
+		return ResourceContents{}, fmt.Errorf("%w: %s (only the last %d tool results are kept)", errResourceNotFound, uri, maxRecentDiagnostics)
+	}
+
+	u, err := parseResourceURI(uri)
+	if err != nil {
+		return ResourceContents{}, &invalidResourceError{err}
+	}
+	args := map[string]interface{}{"uri": uri, "cluster": u.Cluster}
+	if u.Namespace != "" {
+		args["namespace"] = u.Namespace
+	}
+	if err := s.authorizeResource(ctx, "resources/read", args); err != nil {
+		return ResourceContents{}, &invalidResourceError{err}
+	}
+	client, err := s.getClientForCluster(u.Cluster)
+	if err != nil {
+		return ResourceContents{}, fmt.Errorf("%w: cluster %s: %v", errResourceNotFound, u.Cluster, err)
+	}
+
+	contents := ResourceContents{URI: uri, MimeType: "text/markdown"}
+	switch {
+	case u.Kind != "":
+		contents.MimeType = "application/json"
+		contents.Text, err = readDeploymentResource(ctx, client, u)
+	case u.Namespace != "":
+		contents.Text, err = readNamespaceResource(ctx, client, u)
+	default:
+		contents.Text, err = readClusterResource(ctx, client, u)
+	}
+	if apierrors.IsNotFound(err) {
+		return ResourceContents{}, fmt.Errorf("%w: %s", errResourceNotFound, uri)
+	}
+	return contents, err
+}
+
+// readClusterResource describes a cluster: its version, nodes, and the
+// namespaces the tools may operate on.
+func readClusterResource(ctx context.Context, client kubernetes.Interface, u resourceURI) (string, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list nodes: %w", err)
+	}
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	deployments, err := client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# Cluster %s\n\n", u.Cluster)
+	if version, err := client.Discovery().ServerVersion(); err == nil {
+		_, _ = fmt.Fprintf(&sb, "Kubernetes: %s\n", version.GitVersion)
+	}
+	ready := 0
+	for _, node := range nodes.Items {
+		for _, c := range node.Status.Conditions {
+			if c.Type == corev1.NodeReady && c.Status == corev1.ConditionTrue {
+				ready++
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(&sb, "Nodes ready: %d/%d\n", ready, len(nodes.Items))
+
+	counts := make(map[string]int)
+	for _, d := range deployments.Items {
+		counts[d.Namespace]++
+	}
+	var names []string
+	for _, ns := range namespaces.Items {
+		if ValidateNamespace(ns.Name) == nil {
+			names = append(names, ns.Name)
+		}
+	}
+	sort.Strings(names)
+	sb.WriteString("\n## Namespaces\n\n")
+	if len(names) == 0 {
+		sb.WriteString("No namespaces outside the system namespaces.\n")
+		return sb.String(), nil
+	}
+	sb.WriteString("| Namespace | Deployments | Resource |\n")
+	sb.WriteString("|-----------|-------------|----------|\n")
+	for _, ns := range names {
+		_, _ = fmt.Fprintf(&sb, "| %s | %d | %s |\n", ns, counts[ns], resourceURI{Cluster: u.Cluster, Namespace: ns})
+	}
+	return sb.String(), nil
+}
+
+// readNamespaceResource describes a namespace: its deployments and its most
+// recent warning events.
+func readNamespaceResource(ctx context.Context, client kubernetes.Interface, u resourceURI) (string, error) {
+	if _, err := client.CoreV1().Namespaces().Get(ctx, u.Namespace, metav1.GetOptions{}); err != nil {
+		return "", err
+	}
+	deployments, err := client.AppsV1().Deployments(u.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to list deployments: %w", err)
+	}
+	events, err := client.EventsV1().Events(u.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("type", corev1.EventTypeWarning).String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list events: %w", err)
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# Namespace %s in %s\n", u.Namespace, u.Cluster)
+	sb.WriteString("\n## Deployments\n\n")
+	if len(deployments.Items) == 0 {
+		sb.WriteString("No deployments.\n")
+	} else {
+		sort.Slice(deployments.Items, func(i, j int) bool { return deployments.Items[i].Name < deployments.Items[j].Name })
+		sb.WriteString("| Name | Ready | Up-to-date | Resource |\n")
+		sb.WriteString("|------|-------|------------|----------|\n")
+		for _, d := range deployments.Items {
+			desired := int32(1)
+			if d.Spec.Replicas != nil {
+				desired = *d.Spec.Replicas
+			}
+			_, _ = fmt.Fprintf(&sb, "| %s | %d/%d | %d | %s |\n", d.Name, d.Status.ReadyReplicas, desired, d.Status.UpdatedReplicas,
+				resourceURI{Cluster: u.Cluster, Namespace: u.Namespace, Kind: deploymentResourceKind, Name: d.Name})
+		}
+	}
+
+	warnings := make([]eventsv1.Event, 0, len(events.Items))
+	for _, e := range events.Items {
+		// Fake and older clients may ignore the field selector.
+		if e.Type == corev1.EventTypeWarning {
+			warnings = append(warnings, e)
+		}
+	}
+	sort.SliceStable(warnings, func(i, j int) bool { return eventLastSeen(warnings[i]).After(eventLastSeen(warnings[j])) })
+	if len(warnings) > maxResourceEvents {
+		warnings = warnings[:maxResourceEvents]
+	}
+	sb.WriteString("\n## Recent Warning Events\n\n")
+	if len(warnings) == 0 {
+		sb.WriteString("✅ No warning events.\n")
+		return sb.String(), nil
+	}
+	sb.WriteString("| Last seen | Object | Reason | Count | Message |\n")
+	sb.WriteString("|-----------|--------|--------|-------|---------|\n")
+	for _, e := range warnings {
+		_, _ = fmt.Fprintf(&sb, "| %s ago | %s/%s | %s | %d | %s |\n", formatAge(eventLastSeen(e)), e.Regarding.Kind, e.Regarding.Name,
+			e.Reason, eventCount(e), strings.ReplaceAll(e.Note, "\n", " "))
+	}
+	return sb.String(), nil
+}
+
+// readDeploymentResource returns a deployment as JSON, without its managed
+// fields.
+func readDeploymentResource(ctx context.Context, client kubernetes.Interface, u resourceURI) (string, error) {
+	d, err := client.AppsV1().Deployments(u.Namespace).Get(ctx, u.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	d.ManagedFields = nil
+	d.TypeMeta = metav1.TypeMeta{APIVersion: appsv1.SchemeGroupVersion.String(), Kind: "Deployment"}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode deployment: %w", err)
+	}
+	return string(data), nil
+}
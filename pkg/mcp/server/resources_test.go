@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// sendMCPRequest sends one request through handleRequest and returns
+// its response.
+func sendMCPRequest(t *testing.T, s *Server, method string, params interface{}) rpcEnvelope {
+	t.Helper()
+	var buf bytes.Buffer
+	s.writer = &buf
+	req := &Request{JSONRPC: "2.0", ID: 1, Method: method}
+	if params != nil {
+		data, err := json.Marshal(params)
+		require.NoError(t, err)
+		req.Params = data
+	}
+	s.handleRequest(context.Background(), req)
+	responses := decodeResponses(t, buf.String())
+	require.Len(t, responses, 1)
+	return responses[0]
+}
+
+func TestResourcesListAndRead(t *testing.T) {
+	replicas := int32(3)
+	server, _ := newJobsServer(map[string][]runtime.Object{
+		"prod": {
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+			&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}, Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			}}},
+			&appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}}},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     appsv1.DeploymentStatus{ReadyReplicas: 2, UpdatedReplicas: 3},
+			},
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}},
+			&eventsv1.Event{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-1.1", Namespace: "shop"},
+				Type:       corev1.EventTypeWarning,
+				Reason:     "BackOff",
+				Note:       "Back-off restarting failed container",
+				Regarding:  corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-1"},
+				EventTime:  metav1.NewMicroTime(time.Now().Add(-time.Minute)),
+			},
+		},
+	})
+
+	resp := sendMCPRequest(t, server, "resources/list", nil)
+	require.Nil(t, resp.Error)
+	var list ResourcesListResult
+	require.NoError(t, json.Unmarshal(resp.Result, &list))
+	var uris []string
+	for _, r := range list.Resources {
+		uris = append(uris, r.URI)
+	}
+	assert.Equal(t, []string{"k8s://prod", "k8s://prod/shop", "k8s://prod/shop/deployments/web"}, uris)
+
+	read := func(uri string) (ResourceContents, *Error) {
+		resp := sendMCPRequest(t, server, "resources/read", ReadResourceParams{URI: uri})
+		if resp.Error != nil {
+			return ResourceContents{}, resp.Error
+		}
+		var result ReadResourceResult
+		require.NoError(t, json.Unmarshal(resp.Result, &result))
+		require.Len(t, result.Contents, 1)
+		assert.Equal(t, uri, result.Contents[0].URI)
+		return result.Contents[0], nil
+	}
+
+	contents, rpcErr := read("k8s://prod")
+	require.Nil(t, rpcErr)
+	assert.Contains(t, contents.Text, "Nodes ready: 1/1")
+	assert.Contains(t, contents.Text, "| shop | 1 | k8s://prod/shop |")
+	assert.NotContains(t, contents.Text, "kube-system")
+
+	contents, rpcErr = read("k8s://prod/shop")
+	require.Nil(t, rpcErr)
+	assert.Contains(t, contents.Text, "| web | 2/3 | 3 | k8s://prod/shop/deployments/web |")
+	assert.Contains(t, contents.Text, "| Pod/web-1 | BackOff | 1 | Back-off restarting failed container |")
+
+	contents, rpcErr = read("k8s://prod/shop/deployments/web")
+	require.Nil(t, rpcErr)
+	assert.Equal(t, "application/json", contents.MimeType)
+	var deployment appsv1.Deployment
+	require.NoError(t, json.Unmarshal([]byte(contents.Text), &deployment))
+	assert.Equal(t, "Deployment", deployment.Kind)
+	assert.Equal(t, int32(2), deployment.Status.ReadyReplicas)
+	assert.Empty(t, deployment.ManagedFields)
+
+	_, rpcErr = read("k8s://prod/shop/deployments/missing")
+	require.NotNil(t, rpcErr)
+	assert.Equal(t, errCodeResourceNotFound, rpcErr.Code)
+
+	for _, uri := range []string{"k8s://prod/kube-system", "k8s://prod/shop/secrets/db", "k8s://prod/shop/deployments", "https://prod"} {
+		_, rpcErr = read(uri)
+		require.NotNil(t, rpcErr, uri)
+		assert.Equal(t, errCodeInvalidParams, rpcErr.Code, uri)
+	}
+}
+
+func TestResourcesExposeRecentDiagnostics(t *testing.T) {
+	server, _ := newJobsServer(map[string][]runtime.Object{"prod": nil})
+
+	result, rpcErr := callTool(t, server, "check_pod_priority", map[string]interface{}{"cluster": "prod"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError)
+
+	resp := sendMCPRequest(t, server, "resources/list", nil)
+	require.Nil(t, resp.Error)
+	var list ResourcesListResult
+	require.NoError(t, json.Unmarshal(resp.Result, &list))
+	require.NotEmpty(t, list.Resources)
+	diagnostic := list.Resources[0]
+	assert.Equal(t, "kubestellar://diagnostics/1", diagnostic.URI)
+	assert.Equal(t, "check_pod_priority (prod)", diagnostic.Name)
+
+	resp = sendMCPRequest(t, server, "resources/read", ReadResourceParams{URI: diagnostic.URI})
+	require.Nil(t, resp.Error)
+	var read ReadResourceResult
+	require.NoError(t, json.Unmarshal(resp.Result, &read))
+	assert.Equal(t, result.Content[0].Text, read.Contents[0].Text)
+
+	for i := 0; i < maxRecentDiagnostics; i++ {
+		_, rpcErr = callTool(t, server, "check_pod_priority", map[string]interface{}{"cluster": "prod"})
+		require.Nil(t, rpcErr)
+	}
+	resp = sendMCPRequest(t, server, "resources/read", ReadResourceParams{URI: diagnostic.URI})
+	require.NotNil(t, resp.Error)
+	assert.Equal(t, errCodeResourceNotFound, resp.Error.Code)
+}
@@ -8,14 +8,30 @@ import (
 	"io"
 	"log"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/auditlog"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
 	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+	"github.com/kubestellar/kubestellar-mcp/pkg/output"
 	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/protocol"
+	"github.com/kubestellar/kubestellar-mcp/pkg/policy"
+	"github.com/kubestellar/kubestellar-mcp/pkg/provenance"
+	"github.com/kubestellar/kubestellar-mcp/pkg/redact"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+	"github.com/kubestellar/kubestellar-mcp/pkg/toolerror"
 )
 
 const (
@@ -41,6 +57,21 @@ type (
 	CallToolParams  = protocol.CallToolParams
 	CallToolResult  = protocol.CallToolResult
 	ContentBlock    = protocol.ContentBlock
+
+	ResourcesCapability = protocol.ResourcesCapability
+	Resource            = protocol.Resource
+	ResourcesListResult = protocol.ResourcesListResult
+	ReadResourceParams  = protocol.ReadResourceParams
+	ResourceContents    = protocol.ResourceContents
+	ReadResourceResult  = protocol.ReadResourceResult
+
+	PromptsCapability = protocol.PromptsCapability
+	Prompt            = protocol.Prompt
+	PromptArgument    = protocol.PromptArgument
+	PromptsListResult = protocol.PromptsListResult
+	GetPromptParams   = protocol.GetPromptParams
+	PromptMessage     = protocol.PromptMessage
+	GetPromptResult   = protocol.GetPromptResult
 )
 
 type discoverer interface {
@@ -62,23 +93,88 @@ type Server struct {
 	dynamicClientFactory  func(clusterName string) (dynamic.Interface, error)
 	manifestReaderFactory func() manifestReader
 	driftDetectorFactory  func(config *rest.Config) (driftDetector, error)
+	// valuesReaderFactory reads Helm values files from git for
+	// detect_helm_values_drift. When nil, a gitops.ManifestReader is used.
+	valuesReaderFactory func() valuesReader
+	// auditSourceFactory returns a cluster's audit log source. When nil,
+	// sources come from $KUBESTELLAR_AUDIT_LOG; see tools_auditlog.go.
+	auditSourceFactory func(clusterName string) (auditlog.Source, error)
 	reader                *bufio.Reader
 	writer                io.Writer
 	mu                    sync.Mutex
+	resultCache           *cache.ResultCache
+	resultCacheOnce       sync.Once
+	fanoutMemory          *fanout.Memory
+	fanoutMemoryOnce      sync.Once
+	// impersonation and session (the identity requested by the MCP client)
+	// are read by every client builder; see impersonation.go.
+	impersonationMu sync.RWMutex
+	impersonation   Impersonation
+	session         *SessionImpersonation
+	// namespacePolicy is enforced on every client the server builds.
+	namespacePolicy guardrail.NamespacePolicy
+	// approvalGate decides whether mutating tools run or dry-run; see
+	// approval.go.
+	approvalGate     *approval.Gate
+	approvalGateOnce sync.Once
+	// tokenVerifier authenticates callers of the HTTP transport; see
+	// http.go.
+	tokenVerifier TokenVerifier
+	// auditWrites counts audit entries written, to trim the audit bucket
+	// periodically; see audit.go.
+	auditWrites atomic.Int64
+	// provenanceSigner signs every tool result when
+	// $KUBESTELLAR_PROVENANCE_KEY is set; see provenance.go.
+	provenanceSigner *provenance.Signer
+	provenanceOnce   sync.Once
+	// policyEngine authorizes every tool call against the Rego policies
+	// in $KUBESTELLAR_POLICY; see policy.go.
+	policyEngine     *policy.Engine
+	policyEngineOnce sync.Once
+	// recordChanges journals the objects changed by mutating tools so they
+	// can be reverted with undo_change; see tools_journal.go.
+	recordChanges bool
+	journal       *journal.Journal
+	journalOnce   sync.Once
+	// stateStore backs approval plans and the change journal.
+	stateStore     store.Store
+	stateStoreOnce sync.Once
+	// scheduleMu serializes changes to tool schedules between the tools
+	// and the scheduler; see tools_schedule.go.
+	scheduleMu    sync.Mutex
+	schedulerOnce sync.Once
+	// queryMu serializes changes to saved queries; see tools_queries.go.
+	queryMu sync.Mutex
+	// details keeps the full results behind summaries for get_details;
+	// see details.go.
+	details     *cache.ResultCache
+	detailsOnce sync.Once
+	// diagnostics keeps recent read-only tool results for resources/read;
+	// see resources.go.
+	diagnosticsMu  sync.Mutex
+	diagnostics    []diagnosticRecord
+	diagnosticsSeq int
 }
 
 // NewServer creates a new MCP server
 func NewServer(kubeconfig string) *Server {
-	return &Server{
-		kubeconfig: kubeconfig,
-		discoverer: cluster.NewDiscoverer(kubeconfig),
-		reader:     bufio.NewReader(os.Stdin),
-		writer:     os.Stdout,
+	discoverer := cluster.NewDiscoverer(kubeconfig)
+	s := &Server{
+		kubeconfig:      kubeconfig,
+		discoverer:      discoverer,
+		reader:          bufio.NewReader(os.Stdin),
+		writer:          os.Stdout,
+		namespacePolicy: guardrail.NamespacePolicyFromEnv(),
+		recordChanges:   true,
 	}
+	discoverer.SetConfigTransform(s.prepareConfig)
+	return s
 }
 
 // Run starts the MCP server
 func (s *Server) Run(ctx context.Context) error {
+	// Scheduled tool runs fire from this process while it serves requests.
+	s.startScheduler(ctx)
 	for {
 		select {
 		case <-ctx.Done():
@@ -96,7 +192,7 @@ func (s *Server) Run(ctx context.Context) error {
 
 		var req Request
 		if err := json.Unmarshal(line, &req); err != nil {
-			s.sendError(nil, -32700, "Parse error", nil)
+			s.sendError(ctx, nil, -32700, "Parse error", nil)
 			continue
 		}
 
@@ -107,69 +203,319 @@ func (s *Server) Run(ctx context.Context) error {
 func (s *Server) handleRequest(ctx context.Context, req *Request) {
 	switch req.Method {
 	case "initialize":
-		s.handleInitialize(req)
+		s.handleInitialize(ctx, req)
 	case "initialized", "notifications/initialized":
 		// No response needed for notification
 	case "tools/list":
-		s.handleToolsList(req)
+		s.handleToolsList(ctx, req)
 	case "tools/call":
 		s.handleToolsCall(ctx, req)
+	case "resources/list":
+		s.handleResourcesList(ctx, req)
+	case "resources/read":
+		s.handleResourcesRead(ctx, req)
+	case "prompts/list":
+		s.handlePromptsList(ctx, req)
+	case "prompts/get":
+		s.handlePromptsGet(ctx, req)
 	case "ping":
-		s.sendResult(req.ID, map[string]interface{}{})
+		s.sendResult(ctx, req.ID, map[string]interface{}{})
 	default:
-		s.sendError(req.ID, -32601, fmt.Sprintf("Method not found: %s", req.Method), nil)
+		s.sendError(ctx, req.ID, -32601, fmt.Sprintf("Method not found: %s", req.Method), nil)
 	}
 }
 
-func (s *Server) handleInitialize(req *Request) {
+func (s *Server) handleInitialize(ctx context.Context, req *Request) {
+	setSession := s.setSessionImpersonation
+	if isHTTPRequest(ctx) {
+		// Each HTTP request carries its caller's own identity.
+		setSession = rejectSessionImpersonation
+	}
+	if err := setSession(req.Params); err != nil {
+		s.sendError(ctx, req.ID, -32602, err.Error(), nil)
+		return
+	}
 	result := InitializeResult{
 		ProtocolVersion: protocol.MCPVersion,
 		Capabilities: Capabilities{
-			Tools: &ToolsCapability{},
+			Tools:     &ToolsCapability{},
+			Resources: &ResourcesCapability{},
+			Prompts:   &PromptsCapability{},
 		},
 		ServerInfo: ServerInfo{
 			Name:    ServerName,
 			Version: ServerVersion,
 		},
 	}
-	s.sendResult(req.ID, result)
+	s.sendResult(ctx, req.ID, result)
 }
 
-func (s *Server) handleToolsList(req *Request) {
-	s.sendResult(req.ID, ToolsListResult{Tools: registeredTools()})
+func (s *Server) handleToolsList(ctx context.Context, req *Request) {
+	s.sendResult(ctx, req.ID, ToolsListResult{Tools: registeredTools()})
 }
 
 
 func (s *Server) handleToolsCall(ctx context.Context, req *Request) {
 	var params CallToolParams
 	if err := json.Unmarshal(req.Params, &params); err != nil {
-		s.sendError(req.ID, -32602, "Invalid params", nil)
+		s.sendError(ctx, req.ID, -32602, "Invalid params", nil)
 		return
 	}
 
-	handler := findToolHandler(params.Name)
-	if handler == nil {
-		s.sendError(req.ID, -32602, fmt.Sprintf("Unknown tool: %s", params.Name), nil)
+	td := findToolDef(params.Name)
+	if td == nil {
+		s.sendError(ctx, req.ID, -32602, fmt.Sprintf("Unknown tool: %s", params.Name), nil)
 		return
 	}
 
-	result, isError := handler(ctx, s, params.Arguments)
-	s.sendResult(req.ID, CallToolResult{
+	// MCP clients are LLMs with limited context, so large results are
+	// summarized unless they ask for the full result.
+	if _, ok := params.Arguments[ArgDetail]; !ok && td.Summarize {
+		if params.Arguments == nil {
+			params.Arguments = make(map[string]interface{})
+		}
+		params.Arguments[ArgDetail] = detailAuto
+	}
+	s.sendResult(ctx, req.ID, s.callTool(ctx, td, params.Arguments))
+}
+
+// CallTool runs a tool as a tools/call request does, through the same
+// authorization, approval gate, redaction, and output formatting, for
+// callers without an MCP client such as the CLI.
+func (s *Server) CallTool(ctx context.Context, name string, args map[string]interface{}) (CallToolResult, error) {
+	td := findToolDef(name)
+	if td == nil {
+		return CallToolResult{}, fmt.Errorf("unknown tool: %s", name)
+	}
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+	return s.callTool(ctx, td, args), nil
+}
+
+func (s *Server) callTool(ctx context.Context, td *ToolDef, args map[string]interface{}) CallToolResult {
+	signer := s.getProvenanceSigner()
+	var reads *provenance.Collector
+	if signer != nil {
+		reads = provenance.NewCollector()
+		ctx = provenance.WithCollector(ctx, reads)
+	}
+
+	// The output format and detail level apply to the rendered result, so
+	// they are not arguments of the call itself: plans and cache entries
+	// ignore them.
+	format, err := output.Parse(args)
+	delete(args, output.Arg)
+	detail := detailFull
+	if err == nil && td.Summarize {
+		detail, err = parseDetail(args, detailFull)
+	}
+	delete(args, ArgDetail)
+
+	// retry_failed only narrows the clusters a call runs on, so it is not
+	// part of the call either.
+	retryFailed := fanout.Requested(args)
+	statuses := fanout.NewRecorder()
+	ctx = fanout.WithRecorder(ctx, statuses)
+	callKey := s.fanoutKey(ctx, td.Schema.Name, args)
+	if err == nil && retryFailed {
+		ctx, err = s.getFanoutMemory().Retry(ctx, callKey)
+	}
+
+	// Transient cluster errors are retried only for tools that do not
+	// change cluster state.
+	if !td.Mutating {
+		ctx = retry.WithIdempotent(ctx)
+	}
+
+	var result CallToolResult
+	var requireApproval []string
+	if err == nil {
+		requireApproval, err = s.authorizeToolCall(ctx, td, args)
+	}
+	switch {
+	case err != nil:
+		result = CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}
+	case td.Mutating:
+		result = s.callMutatingTool(ctx, td, args, requireApproval)
+	case len(requireApproval) > 0 && !approval.Approved(args):
+		result = policyApprovalError(requireApproval)
+	case td.CacheTTL > 0 && !retryFailed:
+		result = s.callCachedTool(ctx, td, args)
+	default:
+		text, isError := td.Handler(ctx, s, args)
+		result = CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: text}},
+			IsError: isError,
+		}
+	}
+	result = s.withClusterStatus(callKey, args, statuses, result)
+	s.audit(ctx, td.Schema.Name, result)
+	result = redactResult(result)
+	s.recordDiagnostic(ctx, td, args, result)
+	if !result.IsError && detail != detailFull {
+		// Summarize after redaction, so the kept details are redacted too.
+		result = s.summarizeResult(ctx, td.Schema.Name, result, detail == detailSummary)
+	}
+	if result.IsError {
+		result = withToolError(result, err, args)
+	} else if format != "" && len(result.Content) > 0 {
+		// Format after redaction, which relies on the native layout.
+		result.Content[0].Text = output.Render(result.Content[0].Text, format)
+	}
+	if messages.ASCIIEnabled() {
+		for i := range result.Content {
+			result.Content[i].Text = messages.ASCII(result.Content[i].Text)
+		}
+	}
+	if signer != nil {
+		result = withProvenance(signer, td.Schema.Name, reads, result)
+	}
+	return result
+}
+
+// withClusterStatus adds the status matrix of the clusters a call ran on to
+// its result, and remembers it for retry_failed unless the call was a dry
+// run.
+func (s *Server) withClusterStatus(callKey string, args map[string]interface{}, statuses *fanout.Recorder, result CallToolResult) CallToolResult {
+	meta := statuses.Meta()
+	if meta == nil {
+		return result
+	}
+	if dryRun, _ := result.Meta["dryRun"].(bool); !dryRun && !approval.DryRunRequested(args) {
+		s.getFanoutMemory().Remember(callKey, statuses)
+	}
+	if result.Meta == nil {
+		result.Meta = make(map[string]interface{})
+	}
+	for k, v := range meta {
+		result.Meta[k] = v
+	}
+	return result
+}
+
+// fanoutKey identifies a call for retry_failed. Like cached results, the
+// status of one HTTP caller's calls is not shared with another.
+func (s *Server) fanoutKey(ctx context.Context, tool string, args map[string]interface{}) string {
+	key := approval.Fingerprint(tool, args)
+	if caller := callerFrom(ctx); caller != nil {
+		key += "\x00" + caller.User + "\x00" + strings.Join(caller.Groups, ",")
+	}
+	return key
+}
+
+func (s *Server) getFanoutMemory() *fanout.Memory {
+	s.fanoutMemoryOnce.Do(func() {
+		s.fanoutMemory = fanout.NewMemory()
+	})
+	return s.fanoutMemory
+}
+
+// redactResult strips credentials from every content block before the
+// result leaves the server, and reports how many values were redacted.
+func redactResult(result CallToolResult) CallToolResult {
+	total := 0
+	for i := range result.Content {
+		var n int
+		result.Content[i].Text, n = redact.String(result.Content[i].Text)
+		total += n
+	}
+	if total > 0 {
+		if result.Meta == nil {
+			result.Meta = make(map[string]interface{})
+		}
+		result.Meta["redactions"] = total
+	}
+	return result
+}
+
+// nextTools suggests, for each kind of failure, the tool that helps an agent
+// recover from it.
+var nextTools = map[toolerror.Code]string{
+	toolerror.UnknownCluster:   "list_clusters",
+	toolerror.Unavailable:      "get_cluster_health",
+	toolerror.Unauthenticated:  "audit_kubeconfig",
+	toolerror.PermissionDenied: "can_i",
+}
+
+// withToolError attaches the classified form of a failed call to its result
+// under _meta.error. err is the failure when the server has one; handlers
+// only return text, which is classified instead.
+func withToolError(result CallToolResult, err error, args map[string]interface{}) CallToolResult {
+	var e *toolerror.Error
+	if err != nil {
+		e = toolerror.From(err)
+		e.Message, _ = redact.String(e.Message)
+	} else {
+		texts := make([]string, 0, len(result.Content))
+		for _, block := range result.Content {
+			texts = append(texts, block.Text)
+		}
+		e = toolerror.FromText(strings.Join(texts, "\n"))
+	}
+	if e.Cluster == "" {
+		e.Cluster, _ = args["cluster"].(string)
+	}
+	if result.Meta == nil {
+		result.Meta = make(map[string]interface{})
+	}
+	result.Meta[toolerror.MetaKey] = e.Suggest(nextTools)
+	return result
+}
+
+// callCachedTool serves a cacheable tool from the result cache when the
+// caller's freshness options allow it, and caches successful results.
+func (s *Server) callCachedTool(ctx context.Context, td *ToolDef, args map[string]interface{}) CallToolResult {
+	results := s.getResultCache()
+	key := cache.Key(td.Schema.Name, args)
+	if caller := callerFrom(ctx); caller != nil {
+		// Callers see what their own RBAC allows, so results are not
+		// shared between them.
+		key += "\x00" + caller.User + "\x00" + strings.Join(caller.Groups, ",")
+	}
+	opts := cache.ParseOptions(args)
+
+	if entry, ok := results.Get(key, td.CacheTTL, opts); ok {
+		return CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: entry.Value.(string)}},
+			Meta:    cache.Meta(entry, true),
+		}
+	}
+
+	result, isError := td.Handler(ctx, s, args)
+	if isError {
+		return CallToolResult{
+			Content: []ContentBlock{{Type: "text", Text: result}},
+			IsError: true,
+		}
+	}
+	entry := results.Put(key, result)
+	return CallToolResult{
 		Content: []ContentBlock{{Type: "text", Text: result}},
-		IsError: isError,
+		Meta:    cache.Meta(entry, false),
+	}
+}
+
+func (s *Server) getResultCache() *cache.ResultCache {
+	s.resultCacheOnce.Do(func() {
+		s.resultCache = cache.NewResultCache(0)
 	})
+	return s.resultCache
 }
 
-func (s *Server) sendResult(id interface{}, result interface{}) {
-	s.send(Response{
+func (s *Server) sendResult(ctx context.Context, id interface{}, result interface{}) {
+	s.send(ctx, Response{
 		JSONRPC: "2.0",
 		ID:      id,
 		Result:  result,
 	})
 }
 
-func (s *Server) sendError(id interface{}, code int, message string, data interface{}) {
-	s.send(Response{
+func (s *Server) sendError(ctx context.Context, id interface{}, code int, message string, data interface{}) {
+	s.send(ctx, Response{
 		JSONRPC: "2.0",
 		ID:      id,
 		Error: &Error{
@@ -180,7 +526,13 @@ func (s *Server) sendError(id interface{}, code int, message string, data interf
 	})
 }
 
-func (s *Server) send(resp Response) {
+// send writes a response to stdout, or hands it to the HTTP request that
+// carried the call.
+func (s *Server) send(ctx context.Context, resp Response) {
+	if respond := responderFrom(ctx); respond != nil {
+		respond(resp)
+		return
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
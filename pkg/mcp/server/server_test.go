@@ -33,7 +33,7 @@ func TestHandleInitializeReturnsServerMetadata(t *testing.T) {
 	var buf bytes.Buffer
 	s := &Server{writer: &buf}
 
-	s.handleInitialize(&Request{ID: "init-1"})
+	s.handleInitialize(context.Background(), &Request{ID: "init-1"})
 
 	responses := decodeResponses(t, buf.String())
 	require.Len(t, responses, 1)
@@ -45,6 +45,8 @@ func TestHandleInitializeReturnsServerMetadata(t *testing.T) {
 	require.NoError(t, json.Unmarshal(responses[0].Result, &result))
 	assert.Equal(t, MCPVersion, result.ProtocolVersion)
 	require.NotNil(t, result.Capabilities.Tools)
+	require.NotNil(t, result.Capabilities.Resources)
+	require.NotNil(t, result.Capabilities.Prompts)
 	assert.Equal(t, ServerName, result.ServerInfo.Name)
 	assert.Equal(t, ServerVersion, result.ServerInfo.Version)
 }
@@ -53,7 +55,7 @@ func TestHandleToolsListIncludesDiagnosticsAndUpgradeTools(t *testing.T) {
 	var buf bytes.Buffer
 	s := &Server{writer: &buf}
 
-	s.handleToolsList(&Request{ID: "tools-1"})
+	s.handleToolsList(context.Background(), &Request{ID: "tools-1"})
 
 	responses := decodeResponses(t, buf.String())
 	require.Len(t, responses, 1)
@@ -1,6 +1,14 @@
 package server
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+	"github.com/kubestellar/kubestellar-mcp/pkg/output"
+)
 
 // ToolHandler is a function that executes a tool and returns (result, isError).
 type ToolHandler func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool)
@@ -9,8 +17,20 @@ type ToolHandler func(ctx context.Context, s *Server, args map[string]interface{
 type ToolDef struct {
 	Schema  Tool
 	Handler ToolHandler
+	// CacheTTL, when positive, caches successful results per argument set
+	// for this long. See RegisterCachedTool.
+	CacheTTL time.Duration
+	// Mutating marks tools that change cluster state. They are subject to
+	// the approval gate; see RegisterMutatingTool.
+	Mutating bool
+	// Summarize marks tools whose large results MCP clients get as a
+	// summary with a details token; see details.go.
+	Summarize bool
 }
 
+// scanCacheTTL is how long results of expensive read-only scans are reused.
+const scanCacheTTL = 2 * time.Minute
+
 // toolRegistry holds all registered tool definitions. Domain files append to
 // this slice via init() or explicit registration functions.
 var toolRegistry []ToolDef
@@ -22,20 +42,121 @@ func RegisterTool(schema Tool, handler ToolHandler) {
 	toolRegistry = append(toolRegistry, ToolDef{Schema: schema, Handler: handler})
 }
 
-// registeredTools returns all registered tool schemas.
+// RegisterCachedTool registers a read-only, expensive tool whose results are
+// reused for ttl. The allow_stale and force_refresh arguments are added to
+// the schema, and results report their age in _meta.asOf. Such scans can
+// cover a whole fleet, so their results are also summarized and take the
+// detail argument.
+func RegisterCachedTool(schema Tool, ttl time.Duration, handler ToolHandler) {
+	properties := make(map[string]Property, len(schema.InputSchema.Properties)+3)
+	for name, prop := range schema.InputSchema.Properties {
+		properties[name] = prop
+	}
+	properties[cache.ArgAllowStale] = Property{
+		Type:        "boolean",
+		Description: "Return a cached result even if it is older than the cache TTL",
+	}
+	properties[cache.ArgForceRefresh] = Property{
+		Type:        "boolean",
+		Description: "Ignore any cached result and rescan",
+	}
+	properties[ArgDetail] = Property{
+		Type:        "string",
+		Description: "auto (default) returns large results as a summary with a details_token for get_details; summary always does; full returns everything",
+		Enum:        []string{detailAuto, detailSummary, detailFull},
+	}
+	schema.InputSchema.Properties = properties
+	toolRegistry = append(toolRegistry, ToolDef{Schema: schema, Handler: handler, CacheTTL: ttl, Summarize: true})
+}
+
+// RegisterMutatingTool registers a tool that changes cluster state. When
+// the approval gate is enabled, calls run as server-side dry runs unless
+// approved, so the approved and plan_id arguments are added to the schema.
+func RegisterMutatingTool(schema Tool, handler ToolHandler) {
+	properties := make(map[string]Property, len(schema.InputSchema.Properties)+2)
+	for name, prop := range schema.InputSchema.Properties {
+		properties[name] = prop
+	}
+	properties[approval.ArgApproved] = Property{
+		Type:        "boolean",
+		Description: "Apply the change. Required when the server runs in approval mode; without it the call is a dry run",
+	}
+	properties[approval.ArgPlanID] = Property{
+		Type:        "string",
+		Description: "Plan id returned by the dry run being approved (required in plan mode)",
+	}
+	schema.InputSchema.Properties = properties
+	toolRegistry = append(toolRegistry, ToolDef{Schema: schema, Handler: handler, Mutating: true})
+}
+
+// registeredTools returns all registered tool schemas, each with the output
+// argument that every tool honors.
 func registeredTools() []Tool {
 	tools := make([]Tool, len(toolRegistry))
 	for i, td := range toolRegistry {
-		tools[i] = td.Schema
+		tools[i] = withRetryFailedArg(withOutputArg(td.Schema))
 	}
 	return tools
 }
 
+// ToolSchema returns the schema tools/list advertises for the named tool.
+func ToolSchema(name string) (Tool, bool) {
+	td := findToolDef(name)
+	if td == nil {
+		return Tool{}, false
+	}
+	return withRetryFailedArg(withOutputArg(td.Schema)), true
+}
+
+// withOutputArg adds the output argument to a copy of schema.
+func withOutputArg(schema Tool) Tool {
+	properties := make(map[string]Property, len(schema.InputSchema.Properties)+1)
+	for name, prop := range schema.InputSchema.Properties {
+		properties[name] = prop
+	}
+	properties[output.Arg] = Property{
+		Type:        "string",
+		Description: output.Description,
+		Enum:        output.Names(),
+	}
+	schema.InputSchema.Properties = properties
+	return schema
+}
+
+// withRetryFailedArg adds the retry_failed argument to a copy of schema if
+// the tool runs on clusters.
+func withRetryFailedArg(schema Tool) Tool {
+	props := schema.InputSchema.Properties
+	if _, ok := props["cluster"]; !ok {
+		if _, ok := props["clusters"]; !ok {
+			return schema
+		}
+	}
+	properties := make(map[string]Property, len(props)+1)
+	for name, prop := range props {
+		properties[name] = prop
+	}
+	properties[fanout.ArgRetryFailed] = Property{
+		Type:        "boolean",
+		Description: fanout.ArgDescription,
+	}
+	schema.InputSchema.Properties = properties
+	return schema
+}
+
 // findToolHandler looks up a handler by tool name. Returns nil if not found.
 func findToolHandler(name string) ToolHandler {
-	for _, td := range toolRegistry {
-		if td.Schema.Name == name {
-			return td.Handler
+	if td := findToolDef(name); td != nil {
+		return td.Handler
+	}
+	return nil
+}
+
+// findToolDef looks up a tool definition by name. Returns nil if not found.
+func findToolDef(name string) *ToolDef {
+	for i := range toolRegistry {
+		if toolRegistry[i].Schema.Name == name {
+			return &toolRegistry[i]
 		}
 	}
 	return nil
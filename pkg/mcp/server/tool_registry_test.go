@@ -1,7 +1,10 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -65,3 +68,74 @@ func TestRegisteredTools_InputSchemaHasObjectType(t *testing.T) {
 			"tool %q InputSchema.Type should be 'object'", td.Schema.Name)
 	}
 }
+
+func TestCallCachedTool_ReusesResultsAndHonoursForceRefresh(t *testing.T) {
+	calls := 0
+	td := &ToolDef{
+		Schema:   Tool{Name: "expensive_scan"},
+		CacheTTL: time.Minute,
+		Handler: func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			calls++
+			return fmt.Sprintf("scan %d", calls), false
+		},
+	}
+	s := &Server{}
+	args := map[string]interface{}{"namespace": "default"}
+
+	first := s.callCachedTool(context.Background(), td, args)
+	second := s.callCachedTool(context.Background(), td, args)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, first.Content[0].Text, second.Content[0].Text)
+	assert.Equal(t, false, first.Meta["cached"])
+	assert.Equal(t, true, second.Meta["cached"])
+	assert.Equal(t, first.Meta["asOf"], second.Meta["asOf"])
+
+	refreshed := s.callCachedTool(context.Background(), td, map[string]interface{}{"namespace": "default", "force_refresh": true})
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "scan 2", refreshed.Content[0].Text)
+
+	s.callCachedTool(context.Background(), td, map[string]interface{}{"namespace": "other"})
+	assert.Equal(t, 3, calls, "different arguments must not share a cache entry")
+}
+
+func TestCallCachedTool_DoesNotCacheErrors(t *testing.T) {
+	calls := 0
+	td := &ToolDef{
+		Schema:   Tool{Name: "failing_scan"},
+		CacheTTL: time.Minute,
+		Handler: func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			calls++
+			return "boom", true
+		},
+	}
+	s := &Server{}
+
+	result := s.callCachedTool(context.Background(), td, nil)
+	s.callCachedTool(context.Background(), td, nil)
+	assert.True(t, result.IsError)
+	assert.Nil(t, result.Meta)
+	assert.Equal(t, 2, calls)
+}
+
+func TestRegisterCachedTool_AddsFreshnessArguments(t *testing.T) {
+	td := findToolDef("find_pod_issues")
+	require.NotNil(t, td)
+	assert.Equal(t, scanCacheTTL, td.CacheTTL)
+	assert.Contains(t, td.Schema.InputSchema.Properties, "allow_stale")
+	assert.Contains(t, td.Schema.InputSchema.Properties, "force_refresh")
+	assert.Contains(t, td.Schema.InputSchema.Properties, "namespace")
+}
+
+func TestRedactResult_StripsCredentialsAndCountsThem(t *testing.T) {
+	result := redactResult(CallToolResult{
+		Content: []ContentBlock{{Type: "text", Text: "Authorization: Bearer abcdef0123456789"}},
+		Meta:    map[string]interface{}{"cached": true},
+	})
+	assert.NotContains(t, result.Content[0].Text, "abcdef0123456789")
+	assert.Equal(t, 1, result.Meta["redactions"])
+	assert.Equal(t, true, result.Meta["cached"])
+
+	clean := redactResult(CallToolResult{Content: []ContentBlock{{Type: "text", Text: "all pods healthy"}}})
+	assert.Equal(t, "all pods healthy", clean.Content[0].Text)
+	assert.Nil(t, clean.Meta)
+}
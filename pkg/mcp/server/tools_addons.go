@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Categories of add-ons.
+const (
+	addonIngress      = "ingress"
+	addonCertificates = "certificates"
+	addonDNS          = "dns"
+	addonMetrics      = "metrics"
+	addonMonitoring   = "monitoring"
+	addonCNI          = "cni"
+	addonCSI          = "csi"
+)
+
+// addonDef recognizes an add-on by the image repositories of its
+// workloads.
+type addonDef struct {
+	name     string
+	category string
+	// images are repository paths the add-on's images end with.
+	images []string
+}
+
+var addonDefs = []addonDef{
+	{name: "ingress-nginx", category: addonIngress, images: []string{"ingress-nginx/controller"}},
+	{name: "cert-manager", category: addonCertificates, images: []string{"jetstack/cert-manager-controller", "bitnami/cert-manager"}},
+	{name: "external-dns", category: addonDNS, images: []string{"external-dns/external-dns", "bitnami/external-dns"}},
+	{name: "metrics-server", category: addonMetrics, images: []string{"metrics-server/metrics-server", "bitnami/metrics-server"}},
+	{name: "prometheus-operator", category: addonMonitoring, images: []string{"prometheus-operator/prometheus-operator", "bitnami/prometheus-operator"}},
+	{name: "cilium", category: addonCNI, images: []string{"cilium/cilium"}},
+	{name: "calico", category: addonCNI, images: []string{"calico/node"}},
+	{name: "flannel", category: addonCNI, images: []string{"flannel/flannel", "flannel-io/flannel", "coreos/flannel"}},
+	{name: "aws-vpc-cni", category: addonCNI, images: []string{"amazon-k8s-cni"}},
+	{name: "weave-net", category: addonCNI, images: []string{"weaveworks/weave-kube"}},
+	{name: "antrea", category: addonCNI, images: []string{"antrea/antrea-agent-ubuntu", "antrea/antrea-ubuntu"}},
+	{name: "kindnet", category: addonCNI, images: []string{"kindest/kindnetd"}},
+}
+
+// Addon is an add-on installed in a cluster.
+type Addon struct {
+	Name      string `json:"name"`
+	Category  string `json:"category"`
+	Namespace string `json:"namespace,omitempty"`
+	Workload  string `json:"workload,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// imageRepository returns image without its tag or digest.
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// matchAddon returns the add-on image is an image of, or nil.
+func matchAddon(image string) *addonDef {
+	repo := imageRepository(image)
+	for i := range addonDefs {
+		for _, suffix := range addonDefs[i].images {
+			if repo == suffix || strings.HasSuffix(repo, "/"+suffix) {
+				return &addonDefs[i]
+			}
+		}
+	}
+	return nil
+}
+
+// csiSidecars are the Kubernetes CSI sidecar images deployed next to a
+// driver's own plugin.
+var csiSidecars = map[string]bool{
+	"node-driver-registrar": true, "csi-node-driver-registrar": true,
+	"livenessprobe": true, "csi-provisioner": true, "csi-attacher": true,
+	"csi-resizer": true, "csi-snapshotter": true, "csi-external-health-monitor-controller": true,
+}
+
+// isCSISidecar reports whether image is a CSI sidecar.
+func isCSISidecar(image string) bool {
+	repo := imageRepository(image)
+	return csiSidecars[repo[strings.LastIndex(repo, "/")+1:]]
+}
+
+// workloadTemplate is the pod template of a Deployment or DaemonSet.
+type workloadTemplate struct {
+	kind, namespace, name string
+	spec                  corev1.PodSpec
+}
+
+// detectAddons finds the add-ons among workloads and the CSI drivers
+// registered in the cluster. A CSI driver's version is that of the node
+// plugin whose registrar names its socket, ignoring the CSI sidecars.
+func detectAddons(workloads []workloadTemplate, drivers []storagev1.CSIDriver) []Addon {
+	var addons []Addon
+	seen := make(map[string]bool)
+	for _, w := range workloads {
+		for _, c := range w.spec.Containers {
+			def := matchAddon(c.Image)
+			if def == nil || seen[def.name] {
+				continue
+			}
+			seen[def.name] = true
+			addons = append(addons, Addon{
+				Name: def.name, Category: def.category,
+				Namespace: w.namespace, Workload: w.kind + "/" + w.name,
+				Version: imageTag(c.Image),
+			})
+		}
+	}
+
+	for _, d := range drivers {
+		addon := Addon{Name: d.Name, Category: addonCSI}
+		socket := "/plugins/" + d.Name + "/"
+	search:
+		for _, w := range workloads {
+			if w.kind != "DaemonSet" {
+				continue
+			}
+			for _, c := range w.spec.Containers {
+				if !strings.Contains(strings.Join(c.Args, " "), socket) {
+					continue
+				}
+				addon.Namespace, addon.Workload = w.namespace, w.kind+"/"+w.name
+				for _, plugin := range w.spec.Containers {
+					if !isCSISidecar(plugin.Image) {
+						addon.Version = imageTag(plugin.Image)
+						break
+					}
+				}
+				break search
+			}
+		}
+		addons = append(addons, addon)
+	}
+
+	sort.Slice(addons, func(i, j int) bool {
+		if addons[i].Category != addons[j].Category {
+			return addons[i].Category < addons[j].Category
+		}
+		return addons[i].Name < addons[j].Name
+	})
+	return addons
+}
+
+func listAddons(ctx context.Context, client kubernetes.Interface) ([]Addon, error) {
+	deployments, err := client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	daemonSets, err := client.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daemonsets: %w", err)
+	}
+	drivers, err := client.StorageV1().CSIDrivers().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CSI drivers: %w", err)
+	}
+
+	workloads := make([]workloadTemplate, 0, len(deployments.Items)+len(daemonSets.Items))
+	for _, d := range daemonSets.Items {
+		workloads = append(workloads, workloadTemplate{kind: "DaemonSet", namespace: d.Namespace, name: d.Name, spec: d.Spec.Template.Spec})
+	}
+	for _, d := range deployments.Items {
+		workloads = append(workloads, workloadTemplate{kind: "Deployment", namespace: d.Namespace, name: d.Name, spec: d.Spec.Template.Spec})
+	}
+	sort.SliceStable(workloads, func(i, j int) bool {
+		if workloads[i].namespace != workloads[j].namespace {
+			return workloads[i].namespace < workloads[j].namespace
+		}
+		return workloads[i].name < workloads[j].name
+	})
+	return detectAddons(workloads, drivers.Items), nil
+}
+
+// AddonGap is an add-on some clusters lack or run an older version of.
+type AddonGap struct {
+	Addon    string            `json:"addon"`
+	Latest   string            `json:"latest,omitempty"`
+	Missing  []string          `json:"missing,omitempty"`
+	Outdated map[string]string `json:"outdated,omitempty"`
+}
+
+// compareAddons finds, for every add-on any cluster runs, the clusters
+// without it and those running a version older than the newest in the
+// fleet. Clusters choose their CNI and their infrastructure's CSI drivers,
+// so those are only compared by version. Versions that are not semantic
+// versions are not compared.
+func compareAddons(byCluster map[string][]Addon) []AddonGap {
+	clusters := make([]string, 0, len(byCluster))
+	versions := make(map[string]map[string]string)
+	categories := make(map[string]string)
+	for cluster, addons := range byCluster {
+		clusters = append(clusters, cluster)
+		for _, a := range addons {
+			if versions[a.Name] == nil {
+				versions[a.Name] = make(map[string]string)
+			}
+			versions[a.Name][cluster] = a.Version
+			categories[a.Name] = a.Category
+		}
+	}
+	sort.Strings(clusters)
+
+	var gaps []AddonGap
+	for name, installed := range versions {
+		gap := AddonGap{Addon: name}
+		var latest *semver.Version
+		for _, v := range installed {
+			if parsed, err := semver.ParseTolerant(v); err == nil && (latest == nil || parsed.GT(*latest)) {
+				latest = &parsed
+				gap.Latest = v
+			}
+		}
+		for _, cluster := range clusters {
+			v, ok := installed[cluster]
+			if !ok {
+				if categories[name] != addonCNI && categories[name] != addonCSI {
+					gap.Missing = append(gap.Missing, cluster)
+				}
+				continue
+			}
+			if parsed, err := semver.ParseTolerant(v); err == nil && latest != nil && parsed.LT(*latest) {
+				if gap.Outdated == nil {
+					gap.Outdated = make(map[string]string)
+				}
+				gap.Outdated[cluster] = v
+			}
+		}
+		if len(gap.Missing) > 0 || len(gap.Outdated) > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i].Addon < gaps[j].Addon })
+	return gaps
+}
+
+func (s *Server) toolListAddons(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return listAddons(ctx, client)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list add-ons: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Cluster Add-ons\n")
+	byCluster := make(map[string][]Addon)
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		addons := r.Result.([]Addon)
+		byCluster[r.Cluster] = addons
+		if len(addons) == 0 {
+			sb.WriteString("No known add-ons detected.\n")
+			continue
+		}
+		sb.WriteString("| Add-on | Category | Version | Workload |\n")
+		sb.WriteString("|--------|----------|---------|----------|\n")
+		for _, a := range addons {
+			version, workload := a.Version, "-"
+			if version == "" {
+				version = "-"
+			}
+			if a.Workload != "" {
+				workload = a.Namespace + "/" + a.Workload
+			}
+			_, _ = fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", a.Name, a.Category, version, workload)
+		}
+	}
+
+	if len(byCluster) > 1 {
+		sb.WriteString("\n## Fleet Comparison\n\n")
+		gaps := compareAddons(byCluster)
+		if len(gaps) == 0 {
+			sb.WriteString("✅ Every cluster runs the same add-ons at the same versions.\n")
+		}
+		for _, gap := range gaps {
+			_, _ = fmt.Fprintf(&sb, "- **%s**", gap.Addon)
+			if gap.Latest != "" {
+				_, _ = fmt.Fprintf(&sb, " (newest %s)", gap.Latest)
+			}
+			sb.WriteString(":")
+			var parts []string
+			if len(gap.Missing) > 0 {
+				parts = append(parts, "missing on "+strings.Join(gap.Missing, ", "))
+			}
+			if len(gap.Outdated) > 0 {
+				outdated := make([]string, 0, len(gap.Outdated))
+				for cluster, v := range gap.Outdated {
+					outdated = append(outdated, fmt.Sprintf("%s runs %s", cluster, v))
+				}
+				sort.Strings(outdated)
+				parts = append(parts, "outdated: "+strings.Join(outdated, ", "))
+			}
+			_, _ = fmt.Fprintf(&sb, " %s\n", strings.Join(parts, "; "))
+		}
+	}
+	return sb.String(), false
+}
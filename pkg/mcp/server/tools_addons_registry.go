@@ -0,0 +1,23 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "list_addons",
+		Description: "List the common add-ons installed in each cluster with their versions: ingress-nginx, cert-manager, external-dns, metrics-server, Prometheus operator, the CNI (Cilium, Calico, Flannel, AWS VPC CNI, ...), and CSI drivers. Across clusters, highlights add-ons missing from some clusters or older than the newest version in the fleet",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListAddons(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func addonDeployment(namespace, name string, containers ...corev1.Container) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: containers}}},
+	}
+}
+
+func addonDaemonSet(namespace, name string, containers ...corev1.Container) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       appsv1.DaemonSetSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: containers}}},
+	}
+}
+
+func TestMatchAddon(t *testing.T) {
+	assert.Equal(t, "ingress-nginx", matchAddon("registry.k8s.io/ingress-nginx/controller:v1.9.4@sha256:abc").name)
+	assert.Equal(t, "aws-vpc-cni", matchAddon("602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.15.1-eksbuild.1").name)
+	assert.Equal(t, "calico", matchAddon("docker.io/calico/node:v3.26.1").name)
+	assert.Nil(t, matchAddon("docker.io/calico/cni:v3.26.1"), "calico is detected by its node agent only")
+	assert.Nil(t, matchAddon("example.com/my-ingress-nginx/controllers:v1"))
+}
+
+func TestListAddonsComparesFleet(t *testing.T) {
+	ebsNode := addonDaemonSet("kube-system", "ebs-csi-node",
+		corev1.Container{Name: "ebs-plugin", Image: "public.ecr.aws/ebs-csi-driver/aws-ebs-csi-driver:v1.25.0"},
+		corev1.Container{
+			Name: "node-driver-registrar", Image: "public.ecr.aws/eks-distro/kubernetes-csi/node-driver-registrar:v2.9.0",
+			Args: []string{"--kubelet-registration-path=/var/lib/kubelet/plugins/ebs.csi.aws.com/csi.sock"},
+		})
+	// The registrar of hostpath's plugin runs first, from sig-storage.
+	hostpathNode := addonDaemonSet("storage", "csi-hostpathplugin",
+		corev1.Container{
+			Name: "node-driver-registrar", Image: "registry.k8s.io/sig-storage/csi-node-driver-registrar:v2.9.0",
+			Args: []string{"--kubelet-registration-path=/var/lib/kubelet/plugins/hostpath.csi.k8s.io/csi.sock"},
+		},
+		corev1.Container{Name: "hostpath", Image: "registry.k8s.io/sig-storage/hostpathplugin:v1.11.0"})
+	server, _ := newJobsServer(map[string][]runtime.Object{
+		"alpha": {
+			addonDeployment("ingress-nginx", "ingress-nginx-controller", corev1.Container{Image: "registry.k8s.io/ingress-nginx/controller:v1.9.4"}),
+			addonDeployment("cert-manager", "cert-manager", corev1.Container{Image: "quay.io/jetstack/cert-manager-controller:v1.13.2"}),
+			addonDaemonSet("kube-system", "aws-node", corev1.Container{Image: "602401143452.dkr.ecr.us-west-2.amazonaws.com/amazon-k8s-cni:v1.15.1"}),
+			ebsNode,
+			&storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "ebs.csi.aws.com"}},
+		},
+		"beta": {
+			addonDeployment("ingress-nginx", "ingress-nginx-controller", corev1.Container{Image: "registry.k8s.io/ingress-nginx/controller:v1.8.1"}),
+			addonDaemonSet("kube-system", "cilium", corev1.Container{Image: "quay.io/cilium/cilium:v1.14.4"}),
+			hostpathNode,
+			&storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "hostpath.csi.k8s.io"}},
+		},
+	})
+
+	result, rpcErr := callTool(t, server, "list_addons", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "## alpha\n\n| Add-on | Category | Version | Workload |\n|--------|----------|---------|----------|\n"+
+		"| cert-manager | certificates | v1.13.2 | cert-manager/Deployment/cert-manager |\n"+
+		"| aws-vpc-cni | cni | v1.15.1 | kube-system/DaemonSet/aws-node |\n"+
+		"| ebs.csi.aws.com | csi | v1.25.0 | kube-system/DaemonSet/ebs-csi-node |\n"+
+		"| ingress-nginx | ingress | v1.9.4 | ingress-nginx/Deployment/ingress-nginx-controller |\n")
+	assert.Contains(t, text, "| hostpath.csi.k8s.io | csi | v1.11.0 | storage/DaemonSet/csi-hostpathplugin |")
+	assert.Contains(t, text, "## Fleet Comparison\n\n"+
+		"- **cert-manager** (newest v1.13.2): missing on beta\n"+
+		"- **ingress-nginx** (newest v1.9.4): outdated: beta runs v1.8.1\n")
+	assert.NotContains(t, text, "**cilium**", "clusters may choose different CNIs")
+}
@@ -0,0 +1,320 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+	"github.com/kubestellar/kubestellar-mcp/pkg/prometheus"
+)
+
+const (
+	defaultAnomalyBaseline  = 24 * time.Hour
+	defaultAnomalyRecent    = 30 * time.Minute
+	defaultAnomalyThreshold = 3.0
+	anomalyStep             = 5 * time.Minute
+	// minBaselinePoints is how many baseline samples a workload needs
+	// before its recent usage is judged against them.
+	minBaselinePoints = 6
+)
+
+// anomalyMetric is a usage metric find_resource_anomalies watches.
+type anomalyMetric struct {
+	name string
+	// query returns the metric per namespace and pod; %s is a label
+	// matcher restricting it to a namespace, or empty.
+	query string
+	unit  string
+	// minDelta is the smallest change from the baseline worth reporting,
+	// however unusual, so idle workloads do not flag on noise.
+	minDelta float64
+}
+
+var anomalyMetrics = []anomalyMetric{
+	{
+		name:     "cpu",
+		query:    `sum by (namespace, pod) (rate(container_cpu_usage_seconds_total{container!=""%s}[5m]))`,
+		unit:     "cores",
+		minDelta: 0.05,
+	},
+	{
+		name:     "memory",
+		query:    `sum by (namespace, pod) (container_memory_working_set_bytes{container!=""%s})`,
+		unit:     "bytes",
+		minDelta: 32 << 20,
+	},
+	{
+		name:     "restarts",
+		query:    `sum by (namespace, pod) (increase(kube_pod_container_status_restarts_total{%s}[5m]))`,
+		unit:     "restarts/5m",
+		minDelta: 0.5,
+	},
+}
+
+// ResourceAnomaly is a workload whose recent usage of a metric deviates
+// from its baseline.
+type ResourceAnomaly struct {
+	Namespace    string  `json:"namespace"`
+	Workload     string  `json:"workload"`
+	Metric       string  `json:"metric"`
+	Unit         string  `json:"unit"`
+	Recent       float64 `json:"recent"`
+	BaselineMean float64 `json:"baselineMean"`
+	BaselineStd  float64 `json:"baselineStdDev"`
+	// ZScore is how many baseline standard deviations Recent is from the
+	// baseline mean; ±Inf when the baseline never varied.
+	ZScore float64 `json:"zScore"`
+}
+
+// anomalyOptions are the windows and threshold of find_resource_anomalies.
+type anomalyOptions struct {
+	namespace string
+	baseline  time.Duration
+	recent    time.Duration
+	threshold float64
+}
+
+// workloadResolver names the workload of a pod in metrics: from the pods
+// running now or, for pods since replaced, the running workload whose
+// name prefixes the pod's.
+type workloadResolver struct {
+	pods map[string]string
+	// names are the workloads in each namespace, longest name first.
+	names map[string][]string
+}
+
+func newWorkloadResolver(pods []corev1.Pod) *workloadResolver {
+	r := &workloadResolver{pods: make(map[string]string), names: make(map[string][]string)}
+	seen := make(map[string]bool)
+	for i := range pods {
+		pod := &pods[i]
+		workload := podWorkload(pod)
+		r.pods[pod.Namespace+"/"+pod.Name] = workload
+		if key := pod.Namespace + "/" + workload; !seen[key] {
+			seen[key] = true
+			r.names[pod.Namespace] = append(r.names[pod.Namespace], workload)
+		}
+	}
+	for _, names := range r.names {
+		sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	}
+	return r
+}
+
+func (r *workloadResolver) workload(namespace, pod string) string {
+	if w, ok := r.pods[namespace+"/"+pod]; ok {
+		return w
+	}
+	for _, w := range r.names[namespace] {
+		if _, name, _ := strings.Cut(w, "/"); strings.HasPrefix(pod, name+"-") {
+			return w
+		}
+	}
+	return "Pod/" + pod
+}
+
+// workloadSeries sums the per-pod series of a metric into one series per
+// workload, keyed by namespace/workload.
+func workloadSeries(series []prometheus.Series, resolver *workloadResolver) map[string]map[time.Time]float64 {
+	sums := make(map[string]map[time.Time]float64)
+	for _, s := range series {
+		namespace, pod := s.Labels["namespace"], s.Labels["pod"]
+		if namespace == "" || pod == "" {
+			continue
+		}
+		key := namespace + "/" + resolver.workload(namespace, pod)
+		if sums[key] == nil {
+			sums[key] = make(map[time.Time]float64)
+		}
+		for _, p := range s.Points {
+			sums[key][p.Time] += p.Value
+		}
+	}
+	return sums
+}
+
+// scoreAnomaly compares the mean of the samples after cutoff with the
+// samples before it. It reports false if there are too few samples to
+// judge.
+func scoreAnomaly(samples map[time.Time]float64, cutoff time.Time) (recent, mean, std, z float64, ok bool) {
+	var baseline, current []float64
+	for t, v := range samples {
+		if t.After(cutoff) {
+			current = append(current, v)
+		} else {
+			baseline = append(baseline, v)
+		}
+	}
+	if len(baseline) < minBaselinePoints || len(current) == 0 {
+		return 0, 0, 0, 0, false
+	}
+	mean, std = meanStdDev(baseline)
+	recent, _ = meanStdDev(current)
+	switch {
+	case std > 0:
+		z = (recent - mean) / std
+	case recent > mean:
+		z = math.Inf(1)
+	case recent < mean:
+		z = math.Inf(-1)
+	}
+	return recent, mean, std, z, true
+}
+
+func meanStdDev(values []float64) (mean, std float64) {
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		std += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(std / float64(len(values)))
+}
+
+// findAnomalies queries each metric's history from prom and returns the
+// workloads whose recent usage is at least threshold standard deviations
+// from their baseline, most anomalous first.
+func findAnomalies(ctx context.Context, prom *prometheus.Client, resolver *workloadResolver, opts anomalyOptions, now time.Time) ([]ResourceAnomaly, error) {
+	matcher := ""
+	if opts.namespace != "" {
+		matcher = fmt.Sprintf(`namespace=%q`, opts.namespace)
+	}
+	start := now.Add(-opts.baseline - opts.recent)
+	cutoff := now.Add(-opts.recent)
+
+	anomalies := []ResourceAnomaly{}
+	for _, metric := range anomalyMetrics {
+		m := matcher
+		if m != "" && strings.Contains(metric.query, `container!=""`) {
+			m = "," + m
+		}
+		series, err := prom.QueryRange(ctx, fmt.Sprintf(metric.query, m), start, now, anomalyStep)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", metric.name, err)
+		}
+		for key, samples := range workloadSeries(series, resolver) {
+			recent, mean, std, z, ok := scoreAnomaly(samples, cutoff)
+			if !ok || math.Abs(z) < opts.threshold || math.Abs(recent-mean) < metric.minDelta {
+				continue
+			}
+			namespace, workload, _ := strings.Cut(key, "/")
+			anomalies = append(anomalies, ResourceAnomaly{
+				Namespace: namespace, Workload: workload, Metric: metric.name, Unit: metric.unit,
+				Recent: recent, BaselineMean: mean, BaselineStd: std, ZScore: z,
+			})
+		}
+	}
+	sort.Slice(anomalies, func(i, j int) bool {
+		zi, zj := math.Abs(anomalies[i].ZScore), math.Abs(anomalies[j].ZScore)
+		if zi != zj {
+			return zi > zj
+		}
+		ki, kj := anomalies[i].Namespace+"/"+anomalies[i].Workload, anomalies[j].Namespace+"/"+anomalies[j].Workload
+		if ki != kj {
+			return ki < kj
+		}
+		return anomalies[i].Metric < anomalies[j].Metric
+	})
+	return anomalies, nil
+}
+
+func (s *Server) toolFindResourceAnomalies(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	opts := anomalyOptions{namespace: namespace, baseline: defaultAnomalyBaseline, recent: defaultAnomalyRecent, threshold: defaultAnomalyThreshold}
+	if v, ok := args["baseline_hours"].(float64); ok {
+		if v < 1 || v > 24*14 {
+			return "error: baseline_hours must be between 1 and 336", true
+		}
+		opts.baseline = time.Duration(v * float64(time.Hour))
+	}
+	if v, ok := args["recent_minutes"].(float64); ok {
+		if v < 5 || time.Duration(v*float64(time.Minute)) >= opts.baseline {
+			return "error: recent_minutes must be at least 5 and shorter than the baseline", true
+		}
+		opts.recent = time.Duration(v * float64(time.Minute))
+	}
+	if v, ok := args["threshold"].(float64); ok {
+		if v <= 0 {
+			return "error: threshold must be positive", true
+		}
+		opts.threshold = v
+	}
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		endpoint := config.PrometheusURL(clusterName)
+		if endpoint == "" {
+			return nil, fmt.Errorf("no Prometheus endpoint for cluster %s; set %s", clusterName, config.EnvPrometheus)
+		}
+		prom, err := prometheus.New(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: activePodsFieldSelector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		return findAnomalies(ctx, prom, newWorkloadResolver(pods.Items), opts, now)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to find resource anomalies: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Resource Usage Anomalies\n\n")
+	_, _ = fmt.Fprintf(&sb, "Last %s compared with the %s before it; flagged at |z| ≥ %g.\n", opts.recent, opts.baseline, opts.threshold)
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		writeAnomalies(&sb, r.Result.([]ResourceAnomaly))
+	}
+	return sb.String(), false
+}
+
+func writeAnomalies(sb *strings.Builder, anomalies []ResourceAnomaly) {
+	if len(anomalies) == 0 {
+		sb.WriteString("✅ No workload deviates from its baseline.\n")
+		return
+	}
+	sb.WriteString("| Workload | Metric | Recent | Baseline | z |\n")
+	sb.WriteString("|----------|--------|--------|----------|---|\n")
+	for _, a := range anomalies {
+		z := fmt.Sprintf("%+.1f", a.ZScore)
+		if math.IsInf(a.ZScore, 0) {
+			z = "new"
+			if a.ZScore < 0 {
+				z = "stopped"
+			}
+		}
+		_, _ = fmt.Fprintf(sb, "| %s/%s | %s | %s | %s ± %s | %s |\n",
+			a.Namespace, a.Workload, a.Metric, formatUsage(a.Recent, a.Unit), formatUsage(a.BaselineMean, a.Unit), formatUsage(a.BaselineStd, a.Unit), z)
+	}
+}
+
+// formatUsage renders a metric value in its unit, memory in MiB.
+func formatUsage(v float64, unit string) string {
+	switch unit {
+	case "bytes":
+		return fmt.Sprintf("%.0f MiB", v/(1<<20))
+	case "cores":
+		return fmt.Sprintf("%.3f cores", v)
+	}
+	return fmt.Sprintf("%.1f %s", v, unit)
+}
@@ -0,0 +1,39 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "find_resource_anomalies",
+		Description: "Flag workloads whose recent CPU, memory, or restart rate deviates strongly from their trailing baseline (z-score), per cluster, from the cluster's Prometheus. Points investigations of \"something is slow\" at the workloads behaving differently",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to check (all namespaces if not specified)",
+				},
+				"baseline_hours": {
+					Type:        "number",
+					Description: "Hours of history before the recent window to use as the baseline (default 24, max 336)",
+				},
+				"recent_minutes": {
+					Type:        "number",
+					Description: "Minutes of recent usage compared with the baseline (default 30)",
+				},
+				"threshold": {
+					Type:        "number",
+					Description: "Standard deviations from the baseline mean at which a workload is flagged (default 3)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolFindResourceAnomalies(ctx, args)
+		},
+	)
+}
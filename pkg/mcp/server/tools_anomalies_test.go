@@ -0,0 +1,123 @@
+package server
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+)
+
+// fakePrometheus serves query_range results: per pod of series, the value
+// at each step from start to end. Queries for other metrics return nothing.
+func fakePrometheus(t *testing.T, metric string, series map[string]func(ts, end int64) float64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		query := r.Form.Get("query")
+		assert.Contains(t, query, `namespace="shop"`)
+		start, _ := strconv.ParseInt(r.Form.Get("start"), 10, 64)
+		end, _ := strconv.ParseInt(r.Form.Get("end"), 10, 64)
+		result := []interface{}{}
+		if strings.Contains(query, metric) {
+			for pod, value := range series {
+				var values []interface{}
+				for ts := start; ts <= end; ts += 300 {
+					values = append(values, []interface{}{ts, strconv.FormatFloat(value(ts, end), 'f', -1, 64)})
+				}
+				result = append(result, map[string]interface{}{
+					"metric": map[string]string{"namespace": "shop", "pod": pod},
+					"values": values,
+				})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "success",
+			"data":   map[string]interface{}{"resultType": "matrix", "result": result},
+		})
+	}))
+}
+
+func TestFindResourceAnomaliesFlagsDeviatingWorkloads(t *testing.T) {
+	recent := func(ts, end int64) bool { return ts > end-int64(defaultAnomalyRecent.Seconds()) }
+	prom := fakePrometheus(t, "container_cpu_usage_seconds_total", map[string]func(ts, end int64) float64{
+		// web's pod was replaced during the baseline; its old pod's
+		// samples count toward the Deployment.
+		"web-5d9f-old12": func(ts, end int64) float64 {
+			if ts > end-6*3600 {
+				return 0
+			}
+			return 0.1 + 0.01*math.Sin(float64(ts))
+		},
+		"web-7c8d-new34": func(ts, end int64) float64 {
+			switch {
+			case ts <= end-6*3600:
+				return 0
+			case recent(ts, end):
+				return 1.2
+			}
+			return 0.1 + 0.01*math.Sin(float64(ts))
+		},
+		"api-6b5c-aaaaa": func(ts, end int64) float64 { return 0.5 + 0.1*math.Sin(float64(ts)) },
+		// Flat and busy, but only 0.01 cores more: not worth reporting.
+		"worker-0": func(ts, end int64) float64 {
+			if recent(ts, end) {
+				return 0.02
+			}
+			return 0.01
+		},
+	})
+	defer prom.Close()
+	t.Setenv(config.EnvPrometheus, "*="+prom.URL)
+
+	server, _ := newJobsServer(map[string][]runtime.Object{"alpha": {
+		meshPod("shop", "web", "new34", "", ""),
+		meshPod("shop", "api", "aaaaa", "", ""),
+	}})
+	result, rpcErr := callTool(t, server, "find_resource_anomalies", map[string]interface{}{"cluster": "alpha", "namespace": "shop"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "Last 30m0s compared with the 24h0m0s before it; flagged at |z| ≥ 3.")
+	assert.Regexp(t, `\| shop/Deployment/web \| cpu \| 1\.200 cores \| 0\.100 cores ± 0\.007 cores \| \+1\d\d\.\d \|`, text)
+	assert.NotContains(t, text, "api")
+	assert.NotContains(t, text, "worker-0")
+}
+
+func TestFindResourceAnomaliesNeedsPrometheus(t *testing.T) {
+	t.Setenv(config.EnvPrometheus, "")
+	server, _ := newJobsServer(map[string][]runtime.Object{"alpha": {}})
+	result, rpcErr := callTool(t, server, "find_resource_anomalies", map[string]interface{}{"cluster": "alpha"})
+	require.Nil(t, rpcErr)
+	assert.Contains(t, result.Content[0].Text, "error: no Prometheus endpoint for cluster alpha; set KUBESTELLAR_PROMETHEUS")
+
+	result, rpcErr = callTool(t, server, "find_resource_anomalies", map[string]interface{}{"cluster": "alpha", "recent_minutes": float64(2)})
+	require.Nil(t, rpcErr)
+	assert.True(t, result.IsError)
+}
+
+func TestScoreAnomaly(t *testing.T) {
+	cutoff := time.Unix(10000, 0)
+	samples := map[time.Time]float64{}
+	for i := 0; i < 10; i++ {
+		samples[cutoff.Add(-time.Duration(i)*anomalyStep)] = 2
+	}
+	_, _, _, _, ok := scoreAnomaly(samples, cutoff)
+	assert.False(t, ok, "no recent samples")
+
+	samples[cutoff.Add(anomalyStep)] = 0
+	recent, mean, std, z, ok := scoreAnomaly(samples, cutoff)
+	require.True(t, ok)
+	assert.Equal(t, 0.0, recent)
+	assert.Equal(t, 2.0, mean)
+	assert.Equal(t, 0.0, std)
+	assert.True(t, math.IsInf(z, -1), "a flat baseline makes any change infinitely unusual")
+}
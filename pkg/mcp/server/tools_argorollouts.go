@@ -0,0 +1,660 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	argoRolloutGVR              = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	argoAnalysisRunGVR          = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "analysisruns"}
+	deploymentGVR               = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	errArgoRolloutsNotInstalled = errors.New("the Argo Rollouts controller is not installed (no rollouts.argoproj.io API)")
+)
+
+// analysisRunsShown is how many of a Rollout's most recent AnalysisRuns
+// get_argo_rollout reports.
+const analysisRunsShown = 5
+
+// ArgoRolloutSummary is the strategy, progress, and health of an Argo
+// Rollout.
+type ArgoRolloutSummary struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Strategy  string `json:"strategy"`
+	Phase     string `json:"phase,omitempty"`
+	Message   string `json:"message,omitempty"`
+	// Step is the current canary step, as index/total, 1-based.
+	Step string `json:"step,omitempty"`
+	// Weight is the canary's share of traffic from the last setWeight
+	// step reached.
+	Weight       *int64   `json:"weight,omitempty"`
+	Paused       bool     `json:"paused,omitempty"`
+	PauseReasons []string `json:"pauseReasons,omitempty"`
+	Aborted      bool     `json:"aborted,omitempty"`
+	Replicas     int64    `json:"replicas"`
+	Updated      int64    `json:"updatedReplicas"`
+	Ready        int64    `json:"readyReplicas"`
+	Available    int64    `json:"availableReplicas"`
+	// StableRevision and UpdateRevision are the pod template hashes of the
+	// stable ReplicaSet and of the one being rolled out.
+	StableRevision string `json:"stableRevision,omitempty"`
+	UpdateRevision string `json:"updateRevision,omitempty"`
+	// WorkloadRef is the Deployment the Rollout takes its pod template
+	// from, if any.
+	WorkloadRef string   `json:"workloadRef,omitempty"`
+	Images      []string `json:"images,omitempty"`
+}
+
+// ArgoAnalysisRunSummary is the outcome of an AnalysisRun and its metrics.
+type ArgoAnalysisRunSummary struct {
+	Name    string               `json:"name"`
+	Phase   string               `json:"phase"`
+	Message string               `json:"message,omitempty"`
+	Metrics []ArgoAnalysisMetric `json:"metrics,omitempty"`
+}
+
+// ArgoAnalysisMetric is the measurements of one metric of an AnalysisRun.
+type ArgoAnalysisMetric struct {
+	Name         string `json:"name"`
+	Phase        string `json:"phase"`
+	Successful   int64  `json:"successful"`
+	Failed       int64  `json:"failed"`
+	Inconclusive int64  `json:"inconclusive"`
+	Errors       int64  `json:"errors"`
+	LastValue    string `json:"lastValue,omitempty"`
+	Message      string `json:"message,omitempty"`
+}
+
+func listArgoRollouts(ctx context.Context, dyn dynamic.Interface, namespace string) ([]unstructured.Unstructured, error) {
+	list, err := dyn.Resource(argoRolloutGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errArgoRolloutsNotInstalled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rollouts: %w", err)
+	}
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+	return items, nil
+}
+
+// getArgoRollout gets a Rollout, telling a missing Rollout apart from a
+// cluster without Argo Rollouts.
+func getArgoRollout(ctx context.Context, dyn dynamic.Interface, namespace, name string) (*unstructured.Unstructured, error) {
+	ro, err := dyn.Resource(argoRolloutGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if _, listErr := listArgoRollouts(ctx, dyn, namespace); listErr != nil {
+			return nil, listErr
+		}
+		return nil, fmt.Errorf("rollout %s/%s not found", namespace, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rollout %s/%s: %w", namespace, name, err)
+	}
+	return ro, nil
+}
+
+// canarySteps returns the canary steps of ro, or nil for a blue-green
+// Rollout.
+func canarySteps(ro *unstructured.Unstructured) []interface{} {
+	steps, _, _ := unstructured.NestedSlice(ro.Object, "spec", "strategy", "canary", "steps")
+	return steps
+}
+
+// currentStepIndex returns the index of the canary step ro is at.
+func currentStepIndex(ro *unstructured.Unstructured) (int64, bool) {
+	index, found, _ := unstructured.NestedInt64(ro.Object, "status", "currentStepIndex")
+	return index, found
+}
+
+// describeCanaryStep formats a canary step such as {"setWeight": 20}.
+func describeCanaryStep(step interface{}) string {
+	m, _ := step.(map[string]interface{})
+	switch {
+	case m["setWeight"] != nil:
+		return fmt.Sprintf("setWeight %v%%", m["setWeight"])
+	case m["pause"] != nil:
+		pause, _ := m["pause"].(map[string]interface{})
+		if duration, ok := pause["duration"]; ok {
+			return fmt.Sprintf("pause %v", duration)
+		}
+		return "pause until promoted"
+	case m["analysis"] != nil:
+		templates, _, _ := unstructured.NestedSlice(m, "analysis", "templates")
+		names := make([]string, 0, len(templates))
+		for _, t := range templates {
+			if t, ok := t.(map[string]interface{}); ok {
+				names = append(names, fmt.Sprint(t["templateName"]))
+			}
+		}
+		return "analysis " + strings.Join(names, ", ")
+	}
+	for key := range m {
+		return key
+	}
+	return "unknown step"
+}
+
+// rolloutImages returns the container=image pairs of ro's pod template.
+func rolloutImages(ro *unstructured.Unstructured) []string {
+	containers, _, _ := unstructured.NestedSlice(ro.Object, "spec", "template", "spec", "containers")
+	var images []string
+	for _, c := range containers {
+		if c, ok := c.(map[string]interface{}); ok {
+			images = append(images, fmt.Sprintf("%v=%v", c["name"], c["image"]))
+		}
+	}
+	return images
+}
+
+// rolloutUpdating reports whether ro is rolling out a new revision.
+func rolloutUpdating(ro *unstructured.Unstructured) bool {
+	stable, _, _ := unstructured.NestedString(ro.Object, "status", "stableRS")
+	current, _, _ := unstructured.NestedString(ro.Object, "status", "currentPodHash")
+	return stable != "" && current != "" && stable != current
+}
+
+func summarizeArgoRollout(ro *unstructured.Unstructured) ArgoRolloutSummary {
+	s := ArgoRolloutSummary{
+		Namespace: ro.GetNamespace(),
+		Name:      ro.GetName(),
+		Strategy:  "canary",
+		Replicas:  nestedInt(ro, "spec", "replicas"),
+		Updated:   nestedInt(ro, "status", "updatedReplicas"),
+		Ready:     nestedInt(ro, "status", "readyReplicas"),
+		Available: nestedInt(ro, "status", "availableReplicas"),
+		Images:    rolloutImages(ro),
+	}
+	if _, ok, _ := unstructured.NestedMap(ro.Object, "spec", "strategy", "blueGreen"); ok {
+		s.Strategy = "blueGreen"
+	}
+	s.Phase, _, _ = unstructured.NestedString(ro.Object, "status", "phase")
+	s.Message, _, _ = unstructured.NestedString(ro.Object, "status", "message")
+	s.Aborted, _, _ = unstructured.NestedBool(ro.Object, "status", "abort")
+	s.StableRevision, _, _ = unstructured.NestedString(ro.Object, "status", "stableRS")
+	s.UpdateRevision, _, _ = unstructured.NestedString(ro.Object, "status", "currentPodHash")
+	s.WorkloadRef = objectRefName(ro, "spec", "workloadRef")
+
+	s.Paused, _, _ = unstructured.NestedBool(ro.Object, "spec", "paused")
+	conditions, _, _ := unstructured.NestedSlice(ro.Object, "status", "pauseConditions")
+	for _, c := range conditions {
+		if c, ok := c.(map[string]interface{}); ok {
+			s.Paused = true
+			s.PauseReasons = append(s.PauseReasons, fmt.Sprint(c["reason"]))
+		}
+	}
+
+	steps := canarySteps(ro)
+	if index, ok := currentStepIndex(ro); ok && len(steps) > 0 {
+		s.Step = fmt.Sprintf("%d/%d", min(index+1, int64(len(steps))), len(steps))
+		for i := int64(0); i <= index && i < int64(len(steps)); i++ {
+			step, _ := steps[i].(map[string]interface{})
+			if weight, ok, _ := unstructured.NestedInt64(step, "setWeight"); ok {
+				s.Weight = &weight
+			}
+		}
+		if !rolloutUpdating(ro) || s.Aborted {
+			s.Weight = nil
+		}
+	}
+	return s
+}
+
+// rolloutAnalysisRuns returns the most recent AnalysisRuns the Rollout
+// name owns, newest first.
+func rolloutAnalysisRuns(ctx context.Context, dyn dynamic.Interface, namespace, name string) ([]ArgoAnalysisRunSummary, error) {
+	list, err := dyn.Resource(argoAnalysisRunGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list analysis runs: %w", err)
+	}
+	var runs []unstructured.Unstructured
+	for _, run := range list.Items {
+		for _, owner := range run.GetOwnerReferences() {
+			if owner.Kind == "Rollout" && owner.Name == name {
+				runs = append(runs, run)
+				break
+			}
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		ti, tj := runs[i].GetCreationTimestamp(), runs[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return tj.Before(&ti)
+		}
+		return runs[i].GetName() > runs[j].GetName()
+	})
+	if len(runs) > analysisRunsShown {
+		runs = runs[:analysisRunsShown]
+	}
+
+	summaries := make([]ArgoAnalysisRunSummary, 0, len(runs))
+	for i := range runs {
+		summaries = append(summaries, summarizeAnalysisRun(&runs[i]))
+	}
+	return summaries, nil
+}
+
+func summarizeAnalysisRun(run *unstructured.Unstructured) ArgoAnalysisRunSummary {
+	s := ArgoAnalysisRunSummary{Name: run.GetName()}
+	s.Phase, _, _ = unstructured.NestedString(run.Object, "status", "phase")
+	s.Message, _, _ = unstructured.NestedString(run.Object, "status", "message")
+	results, _, _ := unstructured.NestedSlice(run.Object, "status", "metricResults")
+	for _, r := range results {
+		r, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		m := ArgoAnalysisMetric{}
+		m.Name, _, _ = unstructured.NestedString(r, "name")
+		m.Phase, _, _ = unstructured.NestedString(r, "phase")
+		m.Message, _, _ = unstructured.NestedString(r, "message")
+		m.Successful, _, _ = unstructured.NestedInt64(r, "successful")
+		m.Failed, _, _ = unstructured.NestedInt64(r, "failed")
+		m.Inconclusive, _, _ = unstructured.NestedInt64(r, "inconclusive")
+		m.Errors, _, _ = unstructured.NestedInt64(r, "error")
+		if measurements, _, _ := unstructured.NestedSlice(r, "measurements"); len(measurements) > 0 {
+			last, _ := measurements[len(measurements)-1].(map[string]interface{})
+			m.LastValue, _, _ = unstructured.NestedString(last, "value")
+		}
+		s.Metrics = append(s.Metrics, m)
+	}
+	return s
+}
+
+func (s *Server) toolListArgoRollouts(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		rollouts, err := listArgoRollouts(ctx, dyn, namespace)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]ArgoRolloutSummary, 0, len(rollouts))
+		for i := range rollouts {
+			summaries = append(summaries, summarizeArgoRollout(&rollouts[i]))
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list Argo Rollouts: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+// argoRolloutDetail is a Rollout with its steps and analysis runs.
+type argoRolloutDetail struct {
+	Summary ArgoRolloutSummary
+	Steps   []string
+	// StepIndex is the index of the current step in Steps.
+	StepIndex int64
+	// Updating is whether a new revision is being rolled out.
+	Updating bool
+	Analysis []ArgoAnalysisRunSummary
+}
+
+func (s *Server) toolGetArgoRollout(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "error: name is required", true
+	}
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if namespace == "" {
+		return "error: namespace is required", true
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		ro, err := getArgoRollout(ctx, dyn, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		d := argoRolloutDetail{Summary: summarizeArgoRollout(ro), StepIndex: -1}
+		d.Updating = rolloutUpdating(ro) && !d.Summary.Aborted
+		for _, step := range canarySteps(ro) {
+			d.Steps = append(d.Steps, describeCanaryStep(step))
+		}
+		if index, ok := currentStepIndex(ro); ok {
+			d.StepIndex = index
+		}
+		if d.Analysis, err = rolloutAnalysisRuns(ctx, dyn, namespace, name); err != nil {
+			return nil, err
+		}
+		return d, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to get Argo Rollout: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# Argo Rollout %s/%s\n", namespace, name)
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		writeArgoRolloutDetail(&sb, r.Result.(argoRolloutDetail))
+	}
+	return sb.String(), false
+}
+
+func writeArgoRolloutDetail(sb *strings.Builder, d argoRolloutDetail) {
+	ro := d.Summary
+	_, _ = fmt.Fprintf(sb, "**Phase:** %s", ro.Phase)
+	if ro.Message != "" {
+		_, _ = fmt.Fprintf(sb, " (%s)", ro.Message)
+	}
+	_, _ = fmt.Fprintf(sb, "\nStrategy: %s", ro.Strategy)
+	if ro.Step != "" {
+		_, _ = fmt.Fprintf(sb, ", step %s", ro.Step)
+	}
+	if ro.Weight != nil {
+		_, _ = fmt.Fprintf(sb, ", canary weight %d%%", *ro.Weight)
+	}
+	_, _ = fmt.Fprintf(sb, "\nReplicas: %d desired, %d updated, %d ready, %d available\n", ro.Replicas, ro.Updated, ro.Ready, ro.Available)
+	if ro.StableRevision != "" {
+		_, _ = fmt.Fprintf(sb, "Revisions: stable `%s`, updating to `%s`\n", ro.StableRevision, ro.UpdateRevision)
+	}
+	if ro.WorkloadRef != "" {
+		_, _ = fmt.Fprintf(sb, "Pod template from: %s\n", ro.WorkloadRef)
+	}
+	for _, image := range ro.Images {
+		_, _ = fmt.Fprintf(sb, "Image: `%s`\n", image)
+	}
+	switch {
+	case ro.Aborted:
+		sb.WriteString("⛔ Aborted: traffic is back on the stable revision. Set a new image to start over.\n")
+	case ro.Paused:
+		_, _ = fmt.Fprintf(sb, "⏸️ Paused (%s). Use `promote_argo_rollout` to continue or `abort_argo_rollout` to roll back.\n", strings.Join(ro.PauseReasons, ", "))
+	}
+
+	if len(d.Steps) > 0 {
+		sb.WriteString("\n**Steps:**\n")
+		for i, step := range d.Steps {
+			marker := ""
+			switch {
+			case int64(i) < d.StepIndex:
+				marker = " ✅"
+			case int64(i) == d.StepIndex && d.Updating:
+				marker = " ⬅️ current"
+			}
+			_, _ = fmt.Fprintf(sb, "%d. %s%s\n", i+1, step, marker)
+		}
+	}
+
+	if len(d.Analysis) > 0 {
+		sb.WriteString("\n**Analysis runs:**\n")
+		for _, run := range d.Analysis {
+			_, _ = fmt.Fprintf(sb, "- `%s`: %s", run.Name, run.Phase)
+			if run.Message != "" {
+				_, _ = fmt.Fprintf(sb, " (%s)", run.Message)
+			}
+			sb.WriteString("\n")
+			for _, m := range run.Metrics {
+				_, _ = fmt.Fprintf(sb, "  - %s: %s, %d successful, %d failed, %d inconclusive, %d errors",
+					m.Name, m.Phase, m.Successful, m.Failed, m.Inconclusive, m.Errors)
+				if m.LastValue != "" {
+					_, _ = fmt.Fprintf(sb, ", last value %s", m.LastValue)
+				}
+				if m.Message != "" {
+					_, _ = fmt.Fprintf(sb, " (%s)", m.Message)
+				}
+				sb.WriteString("\n")
+			}
+		}
+	}
+}
+
+// argoRolloutTarget reads the cluster, name, and namespace of the Rollout
+// a mutating tool acts on.
+func argoRolloutTarget(args map[string]interface{}) (cluster, name, namespace string, err error) {
+	cluster, _ = args["cluster"].(string)
+	name, _ = args["name"].(string)
+	if name == "" {
+		return "", "", "", errors.New("name is required")
+	}
+	if namespace, err = extractAndValidateNamespace(args); err != nil {
+		return "", "", "", err
+	}
+	if namespace == "" {
+		return "", "", "", errors.New("namespace is required")
+	}
+	return cluster, name, namespace, nil
+}
+
+// patchArgoRollout merge-patches the spec, then the status, of a Rollout.
+// Either patch may be nil.
+func patchArgoRollout(ctx context.Context, dyn dynamic.Interface, namespace, name string, spec, status map[string]interface{}) error {
+	rollouts := dyn.Resource(argoRolloutGVR).Namespace(namespace)
+	if spec != nil {
+		patch, _ := json.Marshal(map[string]interface{}{"spec": spec})
+		if _, err := rollouts.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Errorf("failed to patch rollout: %w", err)
+		}
+	}
+	if status != nil {
+		patch, _ := json.Marshal(map[string]interface{}{"status": status})
+		if _, err := rollouts.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+			return fmt.Errorf("failed to patch rollout status: %w", err)
+		}
+	}
+	return nil
+}
+
+// promotePatches returns the patches that promote ro the way the Argo
+// Rollouts kubectl plugin does: full skips the remaining steps and
+// analysis, otherwise the pause ro is in is lifted. A canary paused on an
+// inconclusive analysis also moves past the step it is stuck on.
+func promotePatches(ro *unstructured.Unstructured, full bool) (spec, status map[string]interface{}, err error) {
+	if aborted, _, _ := unstructured.NestedBool(ro.Object, "status", "abort"); aborted {
+		return nil, nil, errors.New("the rollout was aborted; set a new image, or the previous one to return to it, to start a new update")
+	}
+	if paused, _, _ := unstructured.NestedBool(ro.Object, "spec", "paused"); paused {
+		spec = map[string]interface{}{"paused": false}
+	}
+	if full {
+		if !rolloutUpdating(ro) {
+			return nil, nil, errors.New("the rollout is not updating; there is nothing to promote")
+		}
+		return spec, map[string]interface{}{"promoteFull": true}, nil
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(ro.Object, "status", "pauseConditions")
+	if len(conditions) == 0 {
+		if spec != nil {
+			return spec, nil, nil
+		}
+		return nil, nil, errors.New("the rollout is not paused; use full to skip its remaining steps")
+	}
+	status = map[string]interface{}{"pauseConditions": nil}
+	steps := canarySteps(ro)
+	index, ok := currentStepIndex(ro)
+	for _, c := range conditions {
+		if c, _ := c.(map[string]interface{}); c["reason"] == "InconclusiveAnalysis" && ok && len(steps) > 0 {
+			if index+1 >= int64(len(steps)) {
+				status["promoteFull"] = true
+			} else {
+				status["currentStepIndex"] = index + 1
+			}
+			break
+		}
+	}
+	return spec, status, nil
+}
+
+func (s *Server) toolPromoteArgoRollout(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, name, namespace, err := argoRolloutTarget(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	full, _ := args["full"].(bool)
+
+	dyn, err := s.getDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	ro, err := getArgoRollout(ctx, dyn, namespace, name)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	spec, status, err := promotePatches(ro, full)
+	if err != nil {
+		return fmt.Sprintf("error: cannot promote Rollout `%s/%s`: %v", namespace, name, err), true
+	}
+	if err := patchArgoRollout(ctx, dyn, namespace, name, spec, status); err != nil {
+		return fmt.Sprintf("Failed to promote Rollout `%s/%s`: %v", namespace, name, err), true
+	}
+
+	how := "to its next step"
+	if full {
+		how = "fully, skipping its remaining steps and analysis"
+	}
+	if approval.IsDryRun(ctx) {
+		return fmt.Sprintf("Would promote Rollout `%s/%s` %s.\n", namespace, name, how), false
+	}
+	return fmt.Sprintf("Promoted Rollout `%s/%s` %s. Use `get_argo_rollout` to follow it.\n", namespace, name, how), false
+}
+
+func (s *Server) toolAbortArgoRollout(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, name, namespace, err := argoRolloutTarget(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	dyn, err := s.getDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	ro, err := getArgoRollout(ctx, dyn, namespace, name)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if aborted, _, _ := unstructured.NestedBool(ro.Object, "status", "abort"); aborted {
+		return fmt.Sprintf("Rollout `%s/%s` is already aborted.\n", namespace, name), false
+	}
+	if !rolloutUpdating(ro) {
+		return fmt.Sprintf("error: Rollout `%s/%s` is not updating; there is nothing to abort", namespace, name), true
+	}
+	if err := patchArgoRollout(ctx, dyn, namespace, name, nil, map[string]interface{}{"abort": true}); err != nil {
+		return fmt.Sprintf("Failed to abort Rollout `%s/%s`: %v", namespace, name, err), true
+	}
+
+	if approval.IsDryRun(ctx) {
+		return fmt.Sprintf("Would abort Rollout `%s/%s` and return traffic to the stable revision.\n", namespace, name), false
+	}
+	return fmt.Sprintf("Aborted Rollout `%s/%s`: Argo Rollouts scales the update down and returns traffic to the stable revision. Set a new image to start over.\n", namespace, name), false
+}
+
+// setTemplateImage sets the image of the container named container in the
+// pod template of obj, or of its only container if container is empty,
+// and returns the image it replaced.
+func setTemplateImage(obj *unstructured.Unstructured, container, image string) (string, error) {
+	fields := []string{"spec", "template", "spec", "containers"}
+	containers, _, _ := unstructured.NestedSlice(obj.Object, fields...)
+	if container == "" && len(containers) != 1 {
+		names := make([]string, 0, len(containers))
+		for _, c := range containers {
+			if c, ok := c.(map[string]interface{}); ok {
+				names = append(names, fmt.Sprint(c["name"]))
+			}
+		}
+		return "", fmt.Errorf("the pod template has %d containers (%s); name the container", len(containers), strings.Join(names, ", "))
+	}
+	for _, c := range containers {
+		c, ok := c.(map[string]interface{})
+		if !ok || (container != "" && c["name"] != container) {
+			continue
+		}
+		old, _ := c["image"].(string)
+		c["image"] = image
+		return old, unstructured.SetNestedSlice(obj.Object, containers, fields...)
+	}
+	return "", fmt.Errorf("the pod template has no container %q", container)
+}
+
+func (s *Server) toolSetArgoRolloutImage(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, name, namespace, err := argoRolloutTarget(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	image, _ := args["image"].(string)
+	if image == "" {
+		return "error: image is required", true
+	}
+	container, _ := args["container"].(string)
+
+	dyn, err := s.getDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	ro, err := getArgoRollout(ctx, dyn, namespace, name)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	// A Rollout with a workloadRef takes its pod template from the
+	// Deployment, so that is where the image changes.
+	target, resource, obj := fmt.Sprintf("Rollout `%s/%s`", namespace, name), dyn.Resource(argoRolloutGVR).Namespace(namespace), ro
+	if ref, _, _ := unstructured.NestedStringMap(ro.Object, "spec", "workloadRef"); ref["name"] != "" {
+		if ref["kind"] != "Deployment" {
+			return fmt.Sprintf("error: Rollout `%s/%s` takes its pod template from %s %s; set the image there", namespace, name, ref["kind"], ref["name"]), true
+		}
+		resource = dyn.Resource(deploymentGVR).Namespace(namespace)
+		if obj, err = resource.Get(ctx, ref["name"], metav1.GetOptions{}); err != nil {
+			return fmt.Sprintf("Failed to get Deployment %s referenced by the rollout: %v", ref["name"], err), true
+		}
+		target = fmt.Sprintf("Deployment `%s/%s` (the pod template of Rollout `%s`)", namespace, ref["name"], name)
+	}
+
+	old, err := setTemplateImage(obj, container, image)
+	if err != nil {
+		return fmt.Sprintf("error: cannot set the image of %s: %v", target, err), true
+	}
+	if old == image {
+		return fmt.Sprintf("%s already runs %s.\n", target, image), false
+	}
+	if _, err := resource.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Sprintf("Failed to set the image of %s: %v", target, err), true
+	}
+
+	if approval.IsDryRun(ctx) {
+		return fmt.Sprintf("Would set the image of %s from %s to %s.\n", target, old, image), false
+	}
+	return fmt.Sprintf("Set the image of %s from %s to %s. Argo Rollouts starts an update through the rollout's steps; use `get_argo_rollout` to follow it.\n", target, old, image), false
+}
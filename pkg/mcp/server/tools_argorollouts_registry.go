@@ -0,0 +1,143 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "list_argo_rollouts",
+		Description: "List Argo Rollouts with their strategy, phase, current canary step and weight, pause and abort state, and replica counts, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Rollouts (all namespaces if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListArgoRollouts(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "get_argo_rollout",
+		Description: "Show an Argo Rollout's status per cluster: phase, canary steps and the one it is at, stable and updating revisions, images, and its recent AnalysisRuns with their metric results",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the Rollout",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Rollout",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetArgoRollout(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "promote_argo_rollout",
+		Description: "Promote a paused Argo Rollout to its next step, or with full, skip its remaining steps and analysis and make the update stable",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the Rollout",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Rollout",
+				},
+				"full": {
+					Type:        "boolean",
+					Description: "Skip all remaining steps and analysis (default: false)",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolPromoteArgoRollout(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "abort_argo_rollout",
+		Description: "Abort an Argo Rollout's update: the controller scales the new revision down and returns traffic to the stable one",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the Rollout",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Rollout",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolAbortArgoRollout(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "set_argo_rollout_image",
+		Description: "Set a container image of an Argo Rollout, starting an update through its steps. Rollouts with a workloadRef get the image set on the referenced Deployment",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the Rollout",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Rollout",
+				},
+				"container": {
+					Type:        "string",
+					Description: "Container to update (may be omitted if the pod template has one container)",
+				},
+				"image": {
+					Type:        "string",
+					Description: "New image, e.g. registry.example.com/web:1.4.2",
+				},
+			},
+			Required: []string{"name", "namespace", "image"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolSetArgoRolloutImage(ctx, args)
+		},
+	)
+}
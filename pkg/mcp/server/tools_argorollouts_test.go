@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+var argoRolloutListKinds = map[schema.GroupVersionResource]string{
+	argoRolloutGVR:     "RolloutList",
+	argoAnalysisRunGVR: "AnalysisRunList",
+	deploymentGVR:      "DeploymentList",
+}
+
+// argoCanary returns a canary Rollout of web at step index, updating from
+// revision stable to revision current.
+func argoCanary(index int64, stable, current string, pauseReasons ...string) *unstructured.Unstructured {
+	var conditions []interface{}
+	for _, reason := range pauseReasons {
+		conditions = append(conditions, map[string]interface{}{"reason": reason, "startTime": "2026-10-16T10:00:00Z"})
+	}
+	ro := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1", "kind": "Rollout",
+		"spec": map[string]interface{}{
+			"replicas": int64(5),
+			"strategy": map[string]interface{}{"canary": map[string]interface{}{"steps": []interface{}{
+				map[string]interface{}{"setWeight": int64(20)},
+				map[string]interface{}{"pause": map[string]interface{}{}},
+				map[string]interface{}{"analysis": map[string]interface{}{"templates": []interface{}{
+					map[string]interface{}{"templateName": "success-rate"},
+				}}},
+				map[string]interface{}{"setWeight": int64(60)},
+				map[string]interface{}{"pause": map[string]interface{}{"duration": "10m"}},
+			}}},
+			"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "web", "image": "web:1.1"},
+			}}},
+		},
+		"status": map[string]interface{}{
+			"phase": "Paused", "currentStepIndex": index, "stableRS": stable, "currentPodHash": current,
+			"pauseConditions": conditions, "updatedReplicas": int64(1), "readyReplicas": int64(5), "availableReplicas": int64(5),
+		},
+	}}
+	ro.SetNamespace("shop")
+	ro.SetName("web")
+	return ro
+}
+
+func analysisRun(name, phase string, age time.Duration, metrics ...interface{}) *unstructured.Unstructured {
+	run := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1", "kind": "AnalysisRun",
+		"status": map[string]interface{}{"phase": phase, "metricResults": metrics},
+	}}
+	run.SetNamespace("shop")
+	run.SetName(name)
+	run.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+	run.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "web", UID: "web-uid"}})
+	return run
+}
+
+func TestPromotePatches(t *testing.T) {
+	spec, status, err := promotePatches(argoCanary(1, "a", "b", "CanaryPauseStep"), false)
+	require.NoError(t, err)
+	assert.Nil(t, spec)
+	assert.Equal(t, map[string]interface{}{"pauseConditions": nil}, status, "the controller moves past a pause step itself")
+
+	_, status, err = promotePatches(argoCanary(2, "a", "b", "InconclusiveAnalysis"), false)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"pauseConditions": nil, "currentStepIndex": int64(3)}, status)
+
+	_, status, err = promotePatches(argoCanary(4, "a", "b", "InconclusiveAnalysis"), false)
+	require.NoError(t, err)
+	assert.Equal(t, true, status["promoteFull"], "there is no step after the last one")
+
+	_, _, err = promotePatches(argoCanary(3, "a", "b"), false)
+	assert.ErrorContains(t, err, "not paused")
+	_, status, err = promotePatches(argoCanary(3, "a", "b"), true)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"promoteFull": true}, status)
+	_, _, err = promotePatches(argoCanary(5, "a", "a"), true)
+	assert.ErrorContains(t, err, "not updating")
+
+	aborted := argoCanary(0, "a", "b")
+	aborted.Object["status"].(map[string]interface{})["abort"] = true
+	_, _, err = promotePatches(aborted, false)
+	assert.ErrorContains(t, err, "aborted")
+}
+
+func TestGetArgoRolloutReportsStepsAndAnalysis(t *testing.T) {
+	successRate := func(phase string, successful, failed int64, value string) interface{} {
+		return map[string]interface{}{
+			"name": "success-rate", "phase": phase, "successful": successful, "failed": failed,
+			"measurements": []interface{}{map[string]interface{}{"phase": phase, "value": value}},
+		}
+	}
+	other := analysisRun("api-7f9c-2", "Successful", time.Minute)
+	other.SetOwnerReferences([]metav1.OwnerReference{{Kind: "Rollout", Name: "api"}})
+	dyns := map[string]dynamic.Interface{
+		"prod": dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), argoRolloutListKinds,
+			argoCanary(1, "6d4b9", "7f9c8", "CanaryPauseStep"),
+			analysisRun("web-6d4b9-1", "Successful", time.Hour, successRate("Successful", 3, 0, "0.99")),
+			analysisRun("web-7f9c8-2", "Failed", time.Minute, successRate("Failed", 1, 2, "0.82")),
+			other),
+	}
+	plain := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), argoRolloutListKinds)
+	plain.PrependReactor("*", "rollouts", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(argoRolloutGVR.GroupResource(), "")
+	})
+	dyns["edge"] = plain
+	infos := []cluster.ClusterInfo{{Name: "prod", Context: "prod"}, {Name: "edge", Context: "edge"}}
+	server := &Server{
+		discoverer:           stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory:        func(string) (kubernetes.Interface, error) { return k8sfake.NewSimpleClientset(), nil },
+		dynamicClientFactory: func(name string) (dynamic.Interface, error) { return dyns[name], nil },
+	}
+
+	result, rpcErr := callTool(t, server, "get_argo_rollout", map[string]interface{}{"name": "web", "namespace": "shop"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "## edge\n\nerror: the Argo Rollouts controller is not installed")
+	assert.Contains(t, text, "Strategy: canary, step 2/5, canary weight 20%\n")
+	assert.Contains(t, text, "Revisions: stable `6d4b9`, updating to `7f9c8`\n")
+	assert.Contains(t, text, "⏸️ Paused (CanaryPauseStep). Use `promote_argo_rollout`")
+	assert.Contains(t, text, "1. setWeight 20% ✅\n2. pause until promoted ⬅️ current\n3. analysis success-rate\n4. setWeight 60%\n5. pause 10m\n")
+	assert.Contains(t, text, "- `web-7f9c8-2`: Failed\n  - success-rate: Failed, 1 successful, 2 failed, 0 inconclusive, 0 errors, last value 0.82\n- `web-6d4b9-1`: Successful",
+		"newest analysis run first")
+	assert.NotContains(t, text, "api-7f9c-2")
+
+	result, rpcErr = callTool(t, server, "list_argo_rollouts", map[string]interface{}{"cluster": "prod"})
+	require.Nil(t, rpcErr)
+	assert.Contains(t, result.Content[0].Text, `"pauseReasons": [`)
+	assert.Contains(t, result.Content[0].Text, `"weight": 20`)
+}
+
+func TestArgoRolloutMutations(t *testing.T) {
+	referenced := argoCanary(5, "a", "a")
+	unstructured.RemoveNestedField(referenced.Object, "spec", "template")
+	referenced.SetName("api")
+	_ = unstructured.SetNestedStringMap(referenced.Object, map[string]string{"apiVersion": "apps/v1", "kind": "Deployment", "name": "api"}, "spec", "workloadRef")
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1", "kind": "Deployment",
+		"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+			map[string]interface{}{"name": "api", "image": "api:2.0"},
+			map[string]interface{}{"name": "proxy", "image": "envoy:1.30"},
+		}}}},
+	}}
+	deployment.SetNamespace("shop")
+	deployment.SetName("api")
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), argoRolloutListKinds,
+		argoCanary(3, "a", "b"), referenced, deployment)
+	server := &Server{dynamicClientFactory: func(string) (dynamic.Interface, error) { return dyn, nil }}
+	get := func(gvr schema.GroupVersionResource, name string) *unstructured.Unstructured {
+		obj, err := dyn.Resource(gvr).Namespace("shop").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return obj
+	}
+
+	text, isErr := server.toolAbortArgoRollout(context.Background(), map[string]interface{}{"name": "web", "namespace": "shop"})
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "Aborted Rollout `shop/web`")
+	aborted, _, _ := unstructured.NestedBool(get(argoRolloutGVR, "web").Object, "status", "abort")
+	assert.True(t, aborted)
+	text, isErr = server.toolAbortArgoRollout(context.Background(), map[string]interface{}{"name": "api", "namespace": "shop"})
+	assert.True(t, isErr)
+	assert.Contains(t, text, "nothing to abort")
+
+	text, isErr = server.toolSetArgoRolloutImage(context.Background(), map[string]interface{}{"name": "web", "namespace": "shop", "image": "web:1.2"})
+	require.False(t, isErr, text)
+	assert.Equal(t, []string{"web=web:1.2"}, rolloutImages(get(argoRolloutGVR, "web")))
+
+	text, isErr = server.toolSetArgoRolloutImage(context.Background(), map[string]interface{}{"name": "api", "namespace": "shop", "image": "api:2.1"})
+	assert.True(t, isErr)
+	assert.Contains(t, text, "2 containers (api, proxy); name the container")
+	text, isErr = server.toolSetArgoRolloutImage(context.Background(), map[string]interface{}{"name": "api", "namespace": "shop", "container": "api", "image": "api:2.1"})
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "Set the image of Deployment `shop/api` (the pod template of Rollout `api`) from api:2.0 to api:2.1.")
+	assert.Equal(t, []string{"api=api:2.1", "proxy=envoy:1.30"}, rolloutImages(get(deploymentGVR, "api")))
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auditlog"
+)
+
+const defaultAuditLogWindow = 24 * time.Hour
+
+// auditLogSource returns the audit log source of a cluster.
+func (s *Server) auditLogSource(clusterName string) (auditlog.Source, error) {
+	if s.auditSourceFactory != nil {
+		return s.auditSourceFactory(clusterName)
+	}
+	return auditlog.SourceFor(clusterName)
+}
+
+// parseLookback parses a duration such as 30m, 24h, or 7d.
+func parseLookback(v string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", v)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 30m, 24h, 7d)", v)
+	}
+	return d, nil
+}
+
+// auditLogResult is the events of one cluster and where they were read.
+type auditLogResult struct {
+	Source string
+	Events []auditlog.Event
+}
+
+func (s *Server) toolQueryAuditLog(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	q := auditlog.Query{Namespace: namespace}
+	q.Resource, _ = args["resource"].(string)
+	q.Name, _ = args["name"].(string)
+	q.User, _ = args["user"].(string)
+	if v, _ := args["verb"].(string); v != "" {
+		for _, verb := range strings.Split(v, ",") {
+			q.Verbs = append(q.Verbs, strings.ToLower(strings.TrimSpace(verb)))
+		}
+	}
+	window := defaultAuditLogWindow
+	if v, _ := args["since"].(string); v != "" {
+		if window, err = parseLookback(v); err != nil {
+			return fmt.Sprintf("error: %v", err), true
+		}
+	}
+	q.Since = time.Now().Add(-window)
+	q.Limit = auditlog.DefaultLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		q.Limit = int(v)
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		source, err := s.auditLogSource(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		events, err := source.Query(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s: %w", source, err)
+		}
+		return &auditLogResult{Source: source.String(), Events: events}, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to query audit logs: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# Audit Log: %s in the last %s\n", describeAuditQuery(q), formatDuration(window))
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		result := r.Result.(*auditLogResult)
+		_, _ = fmt.Fprintf(&sb, "Source: %s\n\n", result.Source)
+		if len(result.Events) == 0 {
+			sb.WriteString("No matching events\n")
+			continue
+		}
+		sb.WriteString("| Time | User | Verb | Object | Status | Client |\n")
+		sb.WriteString("|------|------|------|--------|--------|--------|\n")
+		for _, e := range result.Events {
+			_, _ = fmt.Fprintf(&sb, "| %s (%s ago) | %s | %s | %s | %s | %s |\n",
+				e.StageTimestamp.UTC().Format("2006-01-02 15:04:05"), formatAge(e.StageTimestamp),
+				auditUser(e), e.Verb, auditObject(e.ObjectRef), auditStatus(e.ResponseStatus), auditClient(e))
+		}
+		if len(result.Events) == q.Limit {
+			sb.WriteString("\nShowing the most recent events only; narrow the query or raise limit for more.\n")
+		}
+	}
+	return sb.String(), false
+}
+
+// describeAuditQuery summarizes q for the report title, e.g.
+// "delete of deployments shop/web by alice".
+func describeAuditQuery(q auditlog.Query) string {
+	verbs := "changes"
+	if len(q.Verbs) > 0 {
+		verbs = strings.Join(q.Verbs, ", ")
+	}
+	parts := []string{verbs}
+	if q.Resource != "" {
+		parts = append(parts, "of "+q.Resource)
+	}
+	switch {
+	case q.Namespace != "" && q.Name != "":
+		parts = append(parts, q.Namespace+"/"+q.Name)
+	case q.Namespace != "":
+		parts = append(parts, "in "+q.Namespace)
+	case q.Name != "":
+		parts = append(parts, q.Name)
+	}
+	if q.User != "" {
+		parts = append(parts, "by "+q.User)
+	}
+	return strings.Join(parts, " ")
+}
+
+func auditUser(e auditlog.Event) string {
+	if e.ImpersonatedUser != nil {
+		return fmt.Sprintf("%s (as %s)", e.User.Username, e.ImpersonatedUser.Username)
+	}
+	return e.User.Username
+}
+
+func auditObject(ref *auditlog.ObjectRef) string {
+	if ref == nil {
+		return "-"
+	}
+	resource := ref.Resource
+	if ref.APIGroup != "" {
+		resource += "." + ref.APIGroup
+	}
+	if ref.Subresource != "" {
+		resource += "/" + ref.Subresource
+	}
+	switch {
+	case ref.Namespace != "" && ref.Name != "":
+		return fmt.Sprintf("%s %s/%s", resource, ref.Namespace, ref.Name)
+	case ref.Namespace != "":
+		return fmt.Sprintf("%s in %s", resource, ref.Namespace)
+	case ref.Name != "":
+		return resource + " " + ref.Name
+	}
+	return resource
+}
+
+func auditStatus(status *auditlog.ResponseStatus) string {
+	switch {
+	case status == nil || status.Code == 0:
+		return "-"
+	case status.Code >= 400 && status.Message != "":
+		return fmt.Sprintf("%d %s", status.Code, status.Message)
+	}
+	return strconv.Itoa(status.Code)
+}
+
+func auditClient(e auditlog.Event) string {
+	client := e.UserAgent
+	if len(e.SourceIPs) > 0 {
+		if client != "" {
+			client += " "
+		}
+		client += "from " + e.SourceIPs[0]
+	}
+	if client == "" {
+		return "-"
+	}
+	return client
+}
@@ -0,0 +1,51 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "query_audit_log",
+		Description: "Query Kubernetes API server audit logs for who changed what and when (e.g. who deleted deployment X in the last 24h), per cluster. Reads the sources configured in KUBESTELLAR_AUDIT_LOG: audit log files, events posted to the audit webhook listener, EKS CloudWatch Logs, or GKE Cloud Logging. Complements the managedFields heuristics of find_resource_owners",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the objects (all namespaces if not specified)",
+				},
+				"resource": {
+					Type:        "string",
+					Description: "Resource or kind, e.g. deployments or Deployment",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Object name",
+				},
+				"user": {
+					Type:        "string",
+					Description: "Only requests by users whose name contains this",
+				},
+				"verb": {
+					Type:        "string",
+					Description: "Comma-separated verbs, e.g. delete or get,list (default: create,update,patch,delete,deletecollection)",
+				},
+				"since": {
+					Type:        "string",
+					Description: "How far back to look, e.g. 30m, 24h, or 7d (default: 24h)",
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum events per cluster, newest first (default: 50)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolQueryAuditLog(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auditlog"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+// fakeAuditSource returns its events and records the last query.
+type fakeAuditSource struct {
+	events []auditlog.Event
+	query  *auditlog.Query
+}
+
+func (f fakeAuditSource) String() string { return "fake" }
+
+func (f fakeAuditSource) Query(_ context.Context, q auditlog.Query) ([]auditlog.Event, error) {
+	*f.query = q
+	return f.events, nil
+}
+
+func TestParseLookback(t *testing.T) {
+	for in, want := range map[string]time.Duration{"30m": 30 * time.Minute, "24h": 24 * time.Hour, "7d": 7 * 24 * time.Hour} {
+		got, err := parseLookback(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	for _, in := range []string{"", "d", "-1h", "yesterday"} {
+		_, err := parseLookback(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestQueryAuditLogPerCluster(t *testing.T) {
+	var query auditlog.Query
+	sources := map[string]auditlog.Source{
+		"alpha": fakeAuditSource{query: &query, events: []auditlog.Event{{
+			Verb:             "delete",
+			User:             auditlog.UserInfo{Username: "alice@example.com"},
+			ImpersonatedUser: &auditlog.UserInfo{Username: "deployer"},
+			SourceIPs:        []string{"10.0.0.1"},
+			UserAgent:        "kubectl/v1.31",
+			ObjectRef:        &auditlog.ObjectRef{Resource: "deployments", APIGroup: "apps", Namespace: "shop", Name: "web"},
+			ResponseStatus:   &auditlog.ResponseStatus{Code: 200},
+			StageTimestamp:   time.Now().Add(-2 * time.Hour),
+		}}},
+	}
+	infos := []cluster.ClusterInfo{{Name: "alpha", Context: "alpha"}, {Name: "beta", Context: "beta"}}
+	server := &Server{
+		discoverer:    stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory: func(string) (kubernetes.Interface, error) { return k8sfake.NewSimpleClientset(), nil },
+		auditSourceFactory: func(name string) (auditlog.Source, error) {
+			if source, ok := sources[name]; ok {
+				return source, nil
+			}
+			return nil, errors.New("no audit log source is configured for cluster " + name)
+		},
+	}
+
+	result, rpcErr := callTool(t, server, "query_audit_log", map[string]interface{}{
+		"namespace": "shop", "resource": "Deployment", "name": "web", "verb": "Delete", "since": "2d",
+	})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "# Audit Log: delete of Deployment shop/web in the last 2d\n")
+	assert.Contains(t, text, "## alpha\n\nSource: fake\n")
+	assert.Contains(t, text, "(2h ago) | alice@example.com (as deployer) | delete | deployments.apps shop/web | 200 | kubectl/v1.31 from 10.0.0.1 |")
+	assert.Contains(t, text, "## beta\n\nerror: no audit log source is configured for cluster beta")
+
+	assert.Equal(t, []string{"delete"}, query.Verbs)
+	assert.Equal(t, "Deployment", query.Resource)
+	assert.Equal(t, auditlog.DefaultLimit, query.Limit)
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), query.Since, time.Minute)
+
+	result, rpcErr = callTool(t, server, "query_audit_log", map[string]interface{}{"since": "a while"})
+	require.Nil(t, rpcErr)
+	assert.True(t, result.IsError)
+}
+
+func TestFindResourceOwnersPointsToAuditLog(t *testing.T) {
+	client := k8sfake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}})
+	server := &Server{clientFactory: func(string) (kubernetes.Interface, error) { return client, nil }}
+	server.auditSourceFactory = func(string) (auditlog.Source, error) { return nil, errors.New("not configured") }
+
+	result, _ := callTool(t, server, "find_resource_owners", map[string]interface{}{"namespace": "shop"})
+	assert.NotContains(t, result.Content[0].Text, "query_audit_log")
+
+	server.auditSourceFactory = func(string) (auditlog.Source, error) { return fakeAuditSource{}, nil }
+	result, _ = callTool(t, server, "find_resource_owners", map[string]interface{}{"namespace": "shop"})
+	assert.Contains(t, result.Content[0].Text, "run query_audit_log with namespace shop")
+}
@@ -0,0 +1,607 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	capiClusterGVR            = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "clusters"}
+	machineDeploymentGVR      = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machinedeployments"}
+	machineGVR                = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machines"}
+	machineHealthCheckGVR     = schema.GroupVersionResource{Group: "cluster.x-k8s.io", Version: "v1beta1", Resource: "machinehealthchecks"}
+	errClusterAPINotInstalled = errors.New("the Cluster API is not installed (no clusters.cluster.x-k8s.io API); run these tools against the management cluster")
+)
+
+// Labels and annotations Cluster API sets on its objects.
+const (
+	capiDeploymentNameLabel  = "cluster.x-k8s.io/deployment-name"
+	capiControlPlaneLabel    = "cluster.x-k8s.io/control-plane"
+	capiTopologyOwnedLabel   = "topology.cluster.x-k8s.io/owned"
+	capiTopologyMDNameLabel  = "topology.cluster.x-k8s.io/deployment-name"
+	capiAutoscalerMinSizeKey = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+	capiAutoscalerMaxSizeKey = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+)
+
+// machineStuckAfter is how long a Machine may stay in a transitional phase
+// before it is reported as stuck.
+const machineStuckAfter = 15 * time.Minute
+
+// CAPIClusterSummary is the provisioning state of a Cluster API Cluster
+// and its machines.
+type CAPIClusterSummary struct {
+	Namespace           string `json:"namespace"`
+	Name                string `json:"name"`
+	Phase               string `json:"phase"`
+	ClusterClass        string `json:"clusterClass,omitempty"`
+	Version             string `json:"version,omitempty"`
+	Infrastructure      string `json:"infrastructure,omitempty"`
+	ControlPlane        string `json:"controlPlane,omitempty"`
+	InfrastructureReady bool   `json:"infrastructureReady"`
+	ControlPlaneReady   bool   `json:"controlPlaneReady"`
+	Paused              bool   `json:"paused,omitempty"`
+	Machines            int    `json:"machines"`
+	ReadyMachines       int    `json:"readyMachines"`
+	MachineDeployments  int    `json:"machineDeployments"`
+	Message             string `json:"message,omitempty"`
+}
+
+// MachineDeploymentSummary is the size and rollout state of a
+// MachineDeployment.
+type MachineDeploymentSummary struct {
+	Namespace           string `json:"namespace"`
+	Name                string `json:"name"`
+	Cluster             string `json:"cluster"`
+	Phase               string `json:"phase,omitempty"`
+	Version             string `json:"version,omitempty"`
+	Replicas            int64  `json:"replicas"`
+	ReadyReplicas       int64  `json:"readyReplicas"`
+	UpdatedReplicas     int64  `json:"updatedReplicas"`
+	AvailableReplicas   int64  `json:"availableReplicas"`
+	UnavailableReplicas int64  `json:"unavailableReplicas,omitempty"`
+	Rollout             string `json:"rollout"`
+	// Topology is the name of the Cluster topology entry that owns the
+	// MachineDeployment, if it is managed by a ClusterClass.
+	Topology      string `json:"topology,omitempty"`
+	AutoscalerMin string `json:"autoscalerMin,omitempty"`
+	AutoscalerMax string `json:"autoscalerMax,omitempty"`
+}
+
+// MachineProblem is a Machine that is failed, stuck, or unhealthy.
+type MachineProblem struct {
+	Name    string `json:"name"`
+	Owner   string `json:"owner,omitempty"`
+	Phase   string `json:"phase"`
+	Node    string `json:"node,omitempty"`
+	Problem string `json:"problem"`
+}
+
+// MachineHealthCheckSummary is the state of a MachineHealthCheck.
+type MachineHealthCheckSummary struct {
+	Name                string `json:"name"`
+	ExpectedMachines    int64  `json:"expectedMachines"`
+	CurrentHealthy      int64  `json:"currentHealthy"`
+	RemediationsAllowed int64  `json:"remediationsAllowed"`
+}
+
+// capiIndex is the Cluster API objects of a management cluster, grouped by
+// the namespace/name of the Cluster they belong to.
+type capiIndex struct {
+	clusters     []unstructured.Unstructured
+	deployments  map[string][]unstructured.Unstructured
+	machines     map[string][]unstructured.Unstructured
+	healthChecks map[string][]unstructured.Unstructured
+}
+
+// capiClusterKey is the namespace/name of the Cluster obj belongs to.
+func capiClusterKey(obj *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterName")
+	return obj.GetNamespace() + "/" + name
+}
+
+func listClusterAPI(ctx context.Context, dyn dynamic.Interface, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+	list, err := dyn.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errClusterAPINotInstalled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+	return items, nil
+}
+
+// loadCAPIIndex lists the Cluster API objects in namespace.
+// MachineHealthChecks are optional.
+func loadCAPIIndex(ctx context.Context, dyn dynamic.Interface, namespace string) (*capiIndex, error) {
+	clusters, err := listClusterAPI(ctx, dyn, capiClusterGVR, namespace)
+	if err != nil {
+		return nil, err
+	}
+	index := &capiIndex{clusters: clusters}
+	group := func(gvr schema.GroupVersionResource) (map[string][]unstructured.Unstructured, error) {
+		items, err := listClusterAPI(ctx, dyn, gvr, namespace)
+		if err != nil {
+			return nil, err
+		}
+		byCluster := make(map[string][]unstructured.Unstructured)
+		for i := range items {
+			key := capiClusterKey(&items[i])
+			byCluster[key] = append(byCluster[key], items[i])
+		}
+		return byCluster, nil
+	}
+	if index.deployments, err = group(machineDeploymentGVR); err != nil {
+		return nil, err
+	}
+	if index.machines, err = group(machineGVR); err != nil {
+		return nil, err
+	}
+	index.healthChecks, _ = group(machineHealthCheckGVR)
+	return index, nil
+}
+
+// objectRefName formats the kind/name of the object reference at fields.
+func objectRefName(obj *unstructured.Unstructured, fields ...string) string {
+	ref, _, _ := unstructured.NestedStringMap(obj.Object, fields...)
+	if ref["name"] == "" {
+		return ""
+	}
+	return ref["kind"] + "/" + ref["name"]
+}
+
+func nestedInt(obj *unstructured.Unstructured, fields ...string) int64 {
+	v, _, _ := unstructured.NestedInt64(obj.Object, fields...)
+	return v
+}
+
+// machineReady reports whether a Machine is running with a healthy node.
+func machineReady(m *unstructured.Unstructured) bool {
+	phase, _, _ := unstructured.NestedString(m.Object, "status", "phase")
+	status, _, _ := statusCondition(m, "NodeHealthy")
+	return phase == "Running" && status != "False"
+}
+
+func summarizeCAPICluster(c *unstructured.Unstructured, index *capiIndex) CAPIClusterSummary {
+	key := c.GetNamespace() + "/" + c.GetName()
+	s := CAPIClusterSummary{
+		Namespace:          c.GetNamespace(),
+		Name:               c.GetName(),
+		Infrastructure:     objectRefName(c, "spec", "infrastructureRef"),
+		ControlPlane:       objectRefName(c, "spec", "controlPlaneRef"),
+		Machines:           len(index.machines[key]),
+		MachineDeployments: len(index.deployments[key]),
+	}
+	s.Phase, _, _ = unstructured.NestedString(c.Object, "status", "phase")
+	s.ClusterClass, _, _ = unstructured.NestedString(c.Object, "spec", "topology", "class")
+	s.Version, _, _ = unstructured.NestedString(c.Object, "spec", "topology", "version")
+	s.InfrastructureReady, _, _ = unstructured.NestedBool(c.Object, "status", "infrastructureReady")
+	s.ControlPlaneReady, _, _ = unstructured.NestedBool(c.Object, "status", "controlPlaneReady")
+	s.Paused, _, _ = unstructured.NestedBool(c.Object, "spec", "paused")
+	for i := range index.machines[key] {
+		if machineReady(&index.machines[key][i]) {
+			s.ReadyMachines++
+		}
+	}
+	if status, reason, message := statusCondition(c, "Ready"); status == "False" {
+		s.Message = joinReason(reason, message)
+	}
+	return s
+}
+
+// machineDeploymentRollout describes md's rollout: complete, paused, or
+// how far it has got.
+func machineDeploymentRollout(md *unstructured.Unstructured) string {
+	if paused, _, _ := unstructured.NestedBool(md.Object, "spec", "paused"); paused {
+		return "paused"
+	}
+	if observed := nestedInt(md, "status", "observedGeneration"); observed < md.GetGeneration() {
+		return "pending"
+	}
+	desired := nestedInt(md, "spec", "replicas")
+	current := nestedInt(md, "status", "replicas")
+	updated := nestedInt(md, "status", "updatedReplicas")
+	available := nestedInt(md, "status", "availableReplicas")
+	if updated < desired || current > desired || available < desired {
+		return fmt.Sprintf("rolling out: %d/%d updated, %d available", updated, desired, available)
+	}
+	return "complete"
+}
+
+func summarizeMachineDeployment(md *unstructured.Unstructured) MachineDeploymentSummary {
+	s := MachineDeploymentSummary{
+		Namespace:           md.GetNamespace(),
+		Name:                md.GetName(),
+		Replicas:            nestedInt(md, "spec", "replicas"),
+		ReadyReplicas:       nestedInt(md, "status", "readyReplicas"),
+		UpdatedReplicas:     nestedInt(md, "status", "updatedReplicas"),
+		AvailableReplicas:   nestedInt(md, "status", "availableReplicas"),
+		UnavailableReplicas: nestedInt(md, "status", "unavailableReplicas"),
+		Rollout:             machineDeploymentRollout(md),
+		AutoscalerMin:       md.GetAnnotations()[capiAutoscalerMinSizeKey],
+		AutoscalerMax:       md.GetAnnotations()[capiAutoscalerMaxSizeKey],
+	}
+	s.Cluster, _, _ = unstructured.NestedString(md.Object, "spec", "clusterName")
+	s.Phase, _, _ = unstructured.NestedString(md.Object, "status", "phase")
+	s.Version, _, _ = unstructured.NestedString(md.Object, "spec", "template", "spec", "version")
+	if _, owned := md.GetLabels()[capiTopologyOwnedLabel]; owned {
+		s.Topology = md.GetLabels()[capiTopologyMDNameLabel]
+	}
+	return s
+}
+
+// machineProblem returns what is wrong with m, or "" if it is healthy.
+func machineProblem(m *unstructured.Unstructured, now time.Time) string {
+	phase, _, _ := unstructured.NestedString(m.Object, "status", "phase")
+	if reason, _, _ := unstructured.NestedString(m.Object, "status", "failureReason"); reason != "" {
+		message, _, _ := unstructured.NestedString(m.Object, "status", "failureMessage")
+		return "failed: " + joinReason(reason, message)
+	}
+	if phase == "Failed" {
+		return "failed"
+	}
+	if deleted := m.GetDeletionTimestamp(); deleted != nil {
+		if age := now.Sub(deleted.Time); age > machineStuckAfter {
+			problem := fmt.Sprintf("stuck deleting for %s", formatDuration(age))
+			if status, reason, message := statusCondition(m, "DrainingSucceeded"); status == "False" {
+				problem += ": drain " + joinReason(reason, message)
+			}
+			return problem
+		}
+		return ""
+	}
+	if phase != "Running" {
+		if age := now.Sub(m.GetCreationTimestamp().Time); age > machineStuckAfter {
+			problem := fmt.Sprintf("stuck in %s for %s", phase, formatDuration(age))
+			for _, cond := range []string{"BootstrapReady", "InfrastructureReady"} {
+				if status, reason, message := statusCondition(m, cond); status == "False" {
+					problem += fmt.Sprintf("; %s: %s", cond, joinReason(reason, message))
+				}
+			}
+			return problem
+		}
+		return ""
+	}
+	if status, reason, message := statusCondition(m, "NodeHealthy"); status == "False" {
+		return "node unhealthy: " + joinReason(reason, message)
+	}
+	return ""
+}
+
+// machineProblems returns the problems of machines, in name order.
+func machineProblems(machines []unstructured.Unstructured, now time.Time) []MachineProblem {
+	var problems []MachineProblem
+	for i := range machines {
+		m := &machines[i]
+		problem := machineProblem(m, now)
+		if problem == "" {
+			continue
+		}
+		p := MachineProblem{Name: m.GetName(), Problem: problem}
+		p.Phase, _, _ = unstructured.NestedString(m.Object, "status", "phase")
+		p.Node, _, _ = unstructured.NestedString(m.Object, "status", "nodeRef", "name")
+		if md := m.GetLabels()[capiDeploymentNameLabel]; md != "" {
+			p.Owner = "MachineDeployment/" + md
+		} else if _, ok := m.GetLabels()[capiControlPlaneLabel]; ok {
+			p.Owner = "control plane"
+		}
+		problems = append(problems, p)
+	}
+	sort.Slice(problems, func(i, j int) bool { return problems[i].Name < problems[j].Name })
+	return problems
+}
+
+func summarizeMachineHealthChecks(mhcs []unstructured.Unstructured) []MachineHealthCheckSummary {
+	summaries := make([]MachineHealthCheckSummary, 0, len(mhcs))
+	for i := range mhcs {
+		summaries = append(summaries, MachineHealthCheckSummary{
+			Name:                mhcs[i].GetName(),
+			ExpectedMachines:    nestedInt(&mhcs[i], "status", "expectedMachines"),
+			CurrentHealthy:      nestedInt(&mhcs[i], "status", "currentHealthy"),
+			RemediationsAllowed: nestedInt(&mhcs[i], "status", "remediationsAllowed"),
+		})
+	}
+	return summaries
+}
+
+func (s *Server) toolListCAPIClusters(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		index, err := loadCAPIIndex(ctx, dyn, namespace)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]CAPIClusterSummary, 0, len(index.clusters))
+		for i := range index.clusters {
+			summaries = append(summaries, summarizeCAPICluster(&index.clusters[i], index))
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list Cluster API clusters: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+func (s *Server) toolListMachineDeployments(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	capiCluster, _ := args["capi_cluster"].(string)
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		deployments, err := listClusterAPI(ctx, dyn, machineDeploymentGVR, namespace)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]MachineDeploymentSummary, 0, len(deployments))
+		for i := range deployments {
+			summary := summarizeMachineDeployment(&deployments[i])
+			if capiCluster == "" || summary.Cluster == capiCluster {
+				summaries = append(summaries, summary)
+			}
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list MachineDeployments: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+// capiClusterHealth is the machine health of one Cluster API Cluster.
+type capiClusterHealth struct {
+	Cluster      CAPIClusterSummary
+	Deployments  []MachineDeploymentSummary
+	Problems     []MachineProblem
+	HealthChecks []MachineHealthCheckSummary
+}
+
+func (s *Server) toolGetMachineHealth(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	capiCluster, _ := args["capi_cluster"].(string)
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		index, err := loadCAPIIndex(ctx, dyn, namespace)
+		if err != nil {
+			return nil, err
+		}
+		var health []capiClusterHealth
+		for i := range index.clusters {
+			c := &index.clusters[i]
+			if capiCluster != "" && c.GetName() != capiCluster {
+				continue
+			}
+			key := c.GetNamespace() + "/" + c.GetName()
+			h := capiClusterHealth{
+				Cluster:      summarizeCAPICluster(c, index),
+				Problems:     machineProblems(index.machines[key], now),
+				HealthChecks: summarizeMachineHealthChecks(index.healthChecks[key]),
+			}
+			for j := range index.deployments[key] {
+				h.Deployments = append(h.Deployments, summarizeMachineDeployment(&index.deployments[key][j]))
+			}
+			health = append(health, h)
+		}
+		return health, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to get machine health: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Cluster API Machine Health\n")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		health := r.Result.([]capiClusterHealth)
+		if len(health) == 0 {
+			sb.WriteString("No Cluster API clusters found\n")
+			continue
+		}
+		for _, h := range health {
+			writeCAPIClusterHealth(&sb, h)
+		}
+	}
+	return sb.String(), false
+}
+
+func writeCAPIClusterHealth(sb *strings.Builder, h capiClusterHealth) {
+	c := h.Cluster
+	_, _ = fmt.Fprintf(sb, "### %s/%s (%s", c.Namespace, c.Name, c.Phase)
+	if c.Version != "" {
+		_, _ = fmt.Fprintf(sb, ", %s", c.Version)
+	}
+	sb.WriteString(")\n\n")
+	if c.Paused {
+		sb.WriteString("⏸️ Reconciliation is paused\n")
+	}
+	if !c.InfrastructureReady || !c.ControlPlaneReady {
+		_, _ = fmt.Fprintf(sb, "⚠️ Infrastructure ready: %t, control plane ready: %t\n", c.InfrastructureReady, c.ControlPlaneReady)
+	}
+	if c.Message != "" {
+		_, _ = fmt.Fprintf(sb, "⚠️ Not ready: %s\n", c.Message)
+	}
+	_, _ = fmt.Fprintf(sb, "Machines ready: %d/%d\n", c.ReadyMachines, c.Machines)
+
+	if len(h.Deployments) > 0 {
+		sb.WriteString("\n| MachineDeployment | Version | Replicas | Ready | Updated | Available | Rollout |\n")
+		sb.WriteString("|-------------------|---------|----------|-------|---------|-----------|---------|\n")
+		for _, md := range h.Deployments {
+			_, _ = fmt.Fprintf(sb, "| %s | %s | %d | %d | %d | %d | %s |\n",
+				md.Name, md.Version, md.Replicas, md.ReadyReplicas, md.UpdatedReplicas, md.AvailableReplicas, md.Rollout)
+		}
+	}
+
+	if len(h.Problems) > 0 {
+		sb.WriteString("\n**Unhealthy machines:**\n")
+		for _, p := range h.Problems {
+			_, _ = fmt.Fprintf(sb, "- `%s`", p.Name)
+			if p.Owner != "" {
+				_, _ = fmt.Fprintf(sb, " (%s)", p.Owner)
+			}
+			if p.Node != "" {
+				_, _ = fmt.Fprintf(sb, " node `%s`", p.Node)
+			}
+			_, _ = fmt.Fprintf(sb, ": %s\n", p.Problem)
+		}
+	}
+
+	for _, mhc := range h.HealthChecks {
+		_, _ = fmt.Fprintf(sb, "\nMachineHealthCheck `%s`: %d/%d healthy", mhc.Name, mhc.CurrentHealthy, mhc.ExpectedMachines)
+		if mhc.RemediationsAllowed == 0 && mhc.CurrentHealthy < mhc.ExpectedMachines {
+			sb.WriteString(" ⚠️ remediation is blocked: more machines are unhealthy than maxUnhealthy allows")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+func (s *Server) toolScaleMachineDeployment(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "error: name is required", true
+	}
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if namespace == "" {
+		return "error: namespace is required", true
+	}
+	replicasArg, ok := args["replicas"].(float64)
+	if !ok || replicasArg < 0 {
+		return "error: replicas must be a non-negative number", true
+	}
+	replicas := int64(replicasArg)
+
+	dyn, err := s.getDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	md, err := dyn.Resource(machineDeploymentGVR).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("Failed to get MachineDeployment: %v", err), true
+	}
+	current := nestedInt(md, "spec", "replicas")
+
+	var notes []string
+	annotations := md.GetAnnotations()
+	if minSize, maxSize := annotations[capiAutoscalerMinSizeKey], annotations[capiAutoscalerMaxSizeKey]; minSize != "" || maxSize != "" {
+		lo, _ := strconv.ParseInt(minSize, 10, 64)
+		hi, err := strconv.ParseInt(maxSize, 10, 64)
+		if replicas < lo || (err == nil && replicas > hi) {
+			return fmt.Sprintf("error: %d replicas is outside the cluster autoscaler's range for `%s/%s` (min %s, max %s); change the %s and %s annotations instead",
+				replicas, namespace, name, minSize, maxSize, capiAutoscalerMinSizeKey, capiAutoscalerMaxSizeKey), true
+		}
+		notes = append(notes, "The cluster autoscaler manages this MachineDeployment and may change its size again.")
+	}
+
+	target := fmt.Sprintf("MachineDeployment `%s/%s`", namespace, name)
+	if _, owned := md.GetLabels()[capiTopologyOwnedLabel]; owned {
+		// The topology controller would revert a direct change, so scale
+		// the Cluster's topology entry instead.
+		clusterName, _, _ := unstructured.NestedString(md.Object, "spec", "clusterName")
+		entry := md.GetLabels()[capiTopologyMDNameLabel]
+		if err := scaleTopologyMachineDeployment(ctx, dyn, namespace, clusterName, entry, replicas); err != nil {
+			return fmt.Sprintf("Failed to scale %s: %v", target, err), true
+		}
+		target += fmt.Sprintf(" through the topology of Cluster `%s` (machineDeployments `%s`)", clusterName, entry)
+	} else {
+		patch, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"replicas": replicas}})
+		if _, err := dyn.Resource(machineDeploymentGVR).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+			return fmt.Sprintf("Failed to scale %s: %v", target, err), true
+		}
+	}
+
+	var sb strings.Builder
+	if approval.IsDryRun(ctx) {
+		_, _ = fmt.Fprintf(&sb, "Would scale %s from %d to %d replicas.\n", target, current, replicas)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "Scaled %s from %d to %d replicas. Use `get_machine_health` to follow the new machines.\n", target, current, replicas)
+	}
+	for _, note := range notes {
+		sb.WriteString(note + "\n")
+	}
+	return sb.String(), false
+}
+
+// scaleTopologyMachineDeployment sets the replicas of the machineDeployments
+// entry named entry in the topology of Cluster namespace/clusterName.
+func scaleTopologyMachineDeployment(ctx context.Context, dyn dynamic.Interface, namespace, clusterName, entry string, replicas int64) error {
+	clusters := dyn.Resource(capiClusterGVR).Namespace(namespace)
+	c, err := clusters.Get(ctx, clusterName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get Cluster %s: %w", clusterName, err)
+	}
+	workers, _, _ := unstructured.NestedSlice(c.Object, "spec", "topology", "workers", "machineDeployments")
+	found := false
+	for _, w := range workers {
+		if m, ok := w.(map[string]interface{}); ok && m["name"] == entry {
+			m["replicas"] = replicas
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("cluster %s has no topology machineDeployments entry %q", clusterName, entry)
+	}
+	if err := unstructured.SetNestedSlice(c.Object, workers, "spec", "topology", "workers", "machineDeployments"); err != nil {
+		return err
+	}
+	if _, err := clusters.Update(ctx, c, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update Cluster %s: %w", clusterName, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "list_capi_clusters",
+		Description: "List Cluster API workload clusters on management clusters with their phase, ClusterClass and version, infrastructure and control plane readiness, and ready machines",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Management cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Cluster objects (all namespaces if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListCAPIClusters(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "list_machine_deployments",
+		Description: "List Cluster API MachineDeployments with their Kubernetes version, replica counts, rollout status, and autoscaler bounds, per management cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Management cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the MachineDeployments (all namespaces if not specified)",
+				},
+				"capi_cluster": {
+					Type:        "string",
+					Description: "Only MachineDeployments of this Cluster API cluster",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListMachineDeployments(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "get_machine_health",
+		Description: "Report the machine health of Cluster API clusters: failed, stuck, and unhealthy Machines, MachineDeployment rollout status, and MachineHealthChecks whose remediation is blocked, per management cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Management cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Cluster objects (all namespaces if not specified)",
+				},
+				"capi_cluster": {
+					Type:        "string",
+					Description: "Only this Cluster API cluster",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetMachineHealth(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "scale_machine_deployment",
+		Description: "Scale a Cluster API MachineDeployment. MachineDeployments managed by a ClusterClass topology are scaled through their Cluster, and sizes outside cluster autoscaler bounds are refused",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Management cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the MachineDeployment",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the MachineDeployment",
+				},
+				"replicas": {
+					Type:        "integer",
+					Description: "Desired number of machines",
+				},
+			},
+			Required: []string{"name", "namespace", "replicas"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolScaleMachineDeployment(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+var capiListKinds = map[schema.GroupVersionResource]string{
+	capiClusterGVR:        "ClusterList",
+	machineDeploymentGVR:  "MachineDeploymentList",
+	machineGVR:            "MachineList",
+	machineHealthCheckGVR: "MachineHealthCheckList",
+}
+
+func capiObject(kind, name string, spec, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cluster.x-k8s.io/v1beta1", "kind": kind, "spec": spec, "status": status,
+	}}
+	obj.SetNamespace("fleet")
+	obj.SetName(name)
+	obj.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-time.Hour)))
+	return obj
+}
+
+func capiCondition(condType, status, reason, message string) interface{} {
+	return map[string]interface{}{"type": condType, "status": status, "reason": reason, "message": message}
+}
+
+func capiMachine(name, deployment, phase string, conditions ...interface{}) *unstructured.Unstructured {
+	status := map[string]interface{}{"phase": phase, "conditions": conditions}
+	if phase == "Running" {
+		status["nodeRef"] = map[string]interface{}{"kind": "Node", "name": name}
+	}
+	m := capiObject("Machine", name, map[string]interface{}{"clusterName": "prod"}, status)
+	m.SetLabels(map[string]string{capiDeploymentNameLabel: deployment})
+	return m
+}
+
+func capiMachineDeployment(name string, replicas, updated, available int64) *unstructured.Unstructured {
+	return capiObject("MachineDeployment", name,
+		map[string]interface{}{"clusterName": "prod", "replicas": replicas,
+			"template": map[string]interface{}{"spec": map[string]interface{}{"version": "v1.30.2"}}},
+		map[string]interface{}{"replicas": replicas, "readyReplicas": available, "updatedReplicas": updated,
+			"availableReplicas": available, "phase": "Running"})
+}
+
+func TestMachineProblem(t *testing.T) {
+	now := time.Now()
+	assert.Empty(t, machineProblem(capiMachine("m", "md", "Running"), now))
+	assert.Equal(t, "failed: InsufficientCapacity: no m5.large capacity",
+		machineProblem(capiObject("Machine", "m", nil, map[string]interface{}{
+			"phase": "Failed", "failureReason": "InsufficientCapacity", "failureMessage": "no m5.large capacity",
+		}), now))
+	assert.Equal(t, "stuck in Provisioning for 1h; InfrastructureReady: WaitingForInfrastructure: instance pending",
+		machineProblem(capiMachine("m", "md", "Provisioning",
+			capiCondition("BootstrapReady", "True", "", ""),
+			capiCondition("InfrastructureReady", "False", "WaitingForInfrastructure", "instance pending")), now))
+
+	young := capiMachine("m", "md", "Provisioning")
+	young.SetCreationTimestamp(metav1.NewTime(now.Add(-time.Minute)))
+	assert.Empty(t, machineProblem(young, now), "machines are given time to provision")
+
+	deleting := capiMachine("m", "md", "Deleting", capiCondition("DrainingSucceeded", "False", "Draining", "cannot evict pod web-1: PDB"))
+	deleting.SetDeletionTimestamp(&metav1.Time{Time: now.Add(-20 * time.Minute)})
+	assert.Equal(t, "stuck deleting for 20m: drain Draining: cannot evict pod web-1: PDB", machineProblem(deleting, now))
+
+	assert.Equal(t, "node unhealthy: NodeConditionsFailed: Ready=False",
+		machineProblem(capiMachine("m", "md", "Running", capiCondition("NodeHealthy", "False", "NodeConditionsFailed", "Ready=False")), now))
+}
+
+func TestMachineDeploymentRollout(t *testing.T) {
+	assert.Equal(t, "complete", machineDeploymentRollout(capiMachineDeployment("md", 3, 3, 3)))
+	assert.Equal(t, "rolling out: 1/3 updated, 2 available", machineDeploymentRollout(capiMachineDeployment("md", 3, 1, 2)))
+
+	paused := capiMachineDeployment("md", 3, 1, 2)
+	paused.Object["spec"].(map[string]interface{})["paused"] = true
+	assert.Equal(t, "paused", machineDeploymentRollout(paused))
+}
+
+func TestGetMachineHealthPerCluster(t *testing.T) {
+	prod := capiObject("Cluster", "prod",
+		map[string]interface{}{"topology": map[string]interface{}{"class": "aws", "version": "v1.30.2"}},
+		map[string]interface{}{"phase": "Provisioned", "infrastructureReady": true, "controlPlaneReady": true})
+	mhc := capiObject("MachineHealthCheck", "prod-workers", map[string]interface{}{"clusterName": "prod"},
+		map[string]interface{}{"expectedMachines": int64(3), "currentHealthy": int64(1), "remediationsAllowed": int64(0)})
+	dyns := map[string]dynamic.Interface{
+		"mgmt": dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), capiListKinds,
+			prod, mhc,
+			capiMachineDeployment("prod-md-0", 3, 1, 2),
+			capiMachine("prod-md-0-a", "prod-md-0", "Running"),
+			capiMachine("prod-md-0-b", "prod-md-0", "Running", capiCondition("NodeHealthy", "False", "NodeConditionsFailed", "Ready=Unknown")),
+			capiMachine("prod-md-0-c", "prod-md-0", "Provisioning")),
+	}
+	workload := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), capiListKinds)
+	workload.PrependReactor("list", "clusters", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(capiClusterGVR.GroupResource(), "")
+	})
+	dyns["workload"] = workload
+	infos := []cluster.ClusterInfo{{Name: "mgmt", Context: "mgmt"}, {Name: "workload", Context: "workload"}}
+	server := &Server{
+		discoverer:           stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory:        func(string) (kubernetes.Interface, error) { return k8sfake.NewSimpleClientset(), nil },
+		dynamicClientFactory: func(name string) (dynamic.Interface, error) { return dyns[name], nil },
+	}
+
+	result, rpcErr := callTool(t, server, "get_machine_health", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "### fleet/prod (Provisioned, v1.30.2)\n\nMachines ready: 1/3\n")
+	assert.Contains(t, text, "| prod-md-0 | v1.30.2 | 3 | 2 | 1 | 2 | rolling out: 1/3 updated, 2 available |")
+	assert.Contains(t, text, "- `prod-md-0-b` (MachineDeployment/prod-md-0) node `prod-md-0-b`: node unhealthy: NodeConditionsFailed: Ready=Unknown")
+	assert.Contains(t, text, "- `prod-md-0-c` (MachineDeployment/prod-md-0): stuck in Provisioning for 1h")
+	assert.Contains(t, text, "MachineHealthCheck `prod-workers`: 1/3 healthy ⚠️ remediation is blocked")
+	assert.Contains(t, text, "## workload\n\nerror: the Cluster API is not installed")
+
+	result, rpcErr = callTool(t, server, "list_capi_clusters", map[string]interface{}{"cluster": "mgmt"})
+	require.Nil(t, rpcErr)
+	assert.Contains(t, result.Content[0].Text, `"clusterClass": "aws"`)
+	assert.Contains(t, result.Content[0].Text, `"readyMachines": 1`)
+}
+
+func TestScaleMachineDeployment(t *testing.T) {
+	plain := capiMachineDeployment("plain", 3, 3, 3)
+	bounded := capiMachineDeployment("bounded", 3, 3, 3)
+	bounded.SetAnnotations(map[string]string{capiAutoscalerMinSizeKey: "2", capiAutoscalerMaxSizeKey: "5"})
+	managed := capiMachineDeployment("prod-md-0-x7k2", 3, 3, 3)
+	managed.SetLabels(map[string]string{capiTopologyOwnedLabel: "", capiTopologyMDNameLabel: "md-0"})
+	prod := capiObject("Cluster", "prod", map[string]interface{}{"topology": map[string]interface{}{
+		"workers": map[string]interface{}{"machineDeployments": []interface{}{
+			map[string]interface{}{"name": "md-0", "class": "default-worker", "replicas": int64(3)},
+		}},
+	}}, nil)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), capiListKinds, plain, bounded, managed, prod)
+	server := &Server{dynamicClientFactory: func(string) (dynamic.Interface, error) { return dyn, nil }}
+	scale := func(name string, replicas float64) (string, bool) {
+		return server.toolScaleMachineDeployment(context.Background(), map[string]interface{}{"namespace": "fleet", "name": name, "replicas": replicas})
+	}
+	get := func(gvr schema.GroupVersionResource, name string) *unstructured.Unstructured {
+		obj, err := dyn.Resource(gvr).Namespace("fleet").Get(context.Background(), name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return obj
+	}
+
+	text, isErr := scale("plain", 5)
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "Scaled MachineDeployment `fleet/plain` from 3 to 5 replicas.")
+	assert.Equal(t, int64(5), nestedInt(get(machineDeploymentGVR, "plain"), "spec", "replicas"))
+
+	text, isErr = scale("bounded", 8)
+	assert.True(t, isErr)
+	assert.Contains(t, text, "outside the cluster autoscaler's range")
+	text, isErr = scale("bounded", 4)
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "The cluster autoscaler manages this MachineDeployment")
+
+	text, isErr = scale("prod-md-0-x7k2", 6)
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "through the topology of Cluster `prod` (machineDeployments `md-0`)")
+	workers, _, _ := unstructured.NestedSlice(get(capiClusterGVR, "prod").Object, "spec", "topology", "workers", "machineDeployments")
+	assert.Equal(t, int64(6), workers[0].(map[string]interface{})["replicas"])
+	assert.Equal(t, int64(3), nestedInt(get(machineDeploymentGVR, "prod-md-0-x7k2"), "spec", "replicas"), "the topology controller scales the MachineDeployment")
+}
@@ -0,0 +1,642 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	certificateGVR        = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	certificateRequestGVR = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificaterequests"}
+	issuerGVR             = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "issuers"}
+	clusterIssuerGVR      = schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"}
+	acmeOrderGVR          = schema.GroupVersionResource{Group: "acme.cert-manager.io", Version: "v1", Resource: "orders"}
+	acmeChallengeGVR      = schema.GroupVersionResource{Group: "acme.cert-manager.io", Version: "v1", Resource: "challenges"}
+)
+
+const (
+	// challengeStuckAfter is how long an ACME challenge may stay pending
+	// before it is reported as stuck.
+	challengeStuckAfter = 10 * time.Minute
+	// certExpiryWarning is how close to expiry a Certificate is reported.
+	certExpiryWarning = 7 * 24 * time.Hour
+	// renewalGrace is how long past its renewal time a Certificate may go
+	// unrenewed before it is reported.
+	renewalGrace = time.Hour
+)
+
+var errCertManagerNotInstalled = errors.New("cert-manager is not installed (no certificates.cert-manager.io API)")
+
+// CertificateSummary is the readiness and renewal schedule of a
+// cert-manager Certificate.
+type CertificateSummary struct {
+	Namespace   string   `json:"namespace"`
+	Name        string   `json:"name"`
+	SecretName  string   `json:"secretName"`
+	DNSNames    []string `json:"dnsNames,omitempty"`
+	Issuer      string   `json:"issuer"`
+	Ready       bool     `json:"ready"`
+	Reason      string   `json:"reason,omitempty"`
+	Message     string   `json:"message,omitempty"`
+	Issuing     bool     `json:"issuing,omitempty"`
+	NotAfter    string   `json:"notAfter,omitempty"`
+	RenewalTime string   `json:"renewalTime,omitempty"`
+	ExpiresIn   string   `json:"expiresIn,omitempty"`
+}
+
+// IssuerSummary is the type and readiness of a cert-manager Issuer or
+// ClusterIssuer.
+type IssuerSummary struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Server    string `json:"server,omitempty"`
+	Ready     bool   `json:"ready"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// CertificateProblem is one thing keeping a Certificate from being issued
+// or renewed.
+type CertificateProblem struct {
+	Severity   string `json:"severity"`
+	Problem    string `json:"problem"`
+	Detail     string `json:"detail,omitempty"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// CertificateDiagnosis is the problems found with one Certificate.
+type CertificateDiagnosis struct {
+	Namespace string               `json:"namespace"`
+	Name      string               `json:"name"`
+	Issuer    string               `json:"issuer"`
+	Ready     bool                 `json:"ready"`
+	Problems  []CertificateProblem `json:"problems"`
+}
+
+// certificateDiagnoses is the diagnosis of the Certificates in one cluster.
+type certificateDiagnoses struct {
+	Checked   int
+	Diagnoses []CertificateDiagnosis
+}
+
+// listCertManager lists the objects of a cert-manager resource, reporting
+// errCertManagerNotInstalled when its API is missing.
+func listCertManager(ctx context.Context, dyn dynamic.Interface, gvr schema.GroupVersionResource, namespace string) ([]unstructured.Unstructured, error) {
+	list, err := dyn.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errCertManagerNotInstalled
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+	return items, nil
+}
+
+// statusCondition returns the status, reason, and message of the condition
+// of type condType in obj's status.
+func statusCondition(obj *unstructured.Unstructured, condType string) (status, reason, message string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok || m["type"] != condType {
+			continue
+		}
+		status, _ = m["status"].(string)
+		reason, _ = m["reason"].(string)
+		message, _ = m["message"].(string)
+		return status, reason, message
+	}
+	return "", "", ""
+}
+
+// issuerRef returns the kind and name of the issuer cert refers to, and
+// whether it is a cert-manager issuer rather than an external one.
+func issuerRef(cert *unstructured.Unstructured) (kind, name string, builtIn bool) {
+	ref, _, _ := unstructured.NestedStringMap(cert.Object, "spec", "issuerRef")
+	kind = ref["kind"]
+	if kind == "" {
+		kind = "Issuer"
+	}
+	group := ref["group"]
+	return kind, ref["name"], group == "" || group == certificateGVR.Group
+}
+
+// certTime parses an RFC 3339 timestamp from obj's status.
+func certTime(obj *unstructured.Unstructured, field string) (time.Time, bool) {
+	v, _, _ := unstructured.NestedString(obj.Object, "status", field)
+	t, err := time.Parse(time.RFC3339, v)
+	return t, err == nil
+}
+
+func summarizeCertificate(cert *unstructured.Unstructured, now time.Time) CertificateSummary {
+	kind, name, _ := issuerRef(cert)
+	summary := CertificateSummary{
+		Namespace: cert.GetNamespace(),
+		Name:      cert.GetName(),
+		Issuer:    kind + "/" + name,
+	}
+	summary.SecretName, _, _ = unstructured.NestedString(cert.Object, "spec", "secretName")
+	summary.DNSNames, _, _ = unstructured.NestedStringSlice(cert.Object, "spec", "dnsNames")
+	status, reason, message := statusCondition(cert, "Ready")
+	summary.Ready = status == "True"
+	if !summary.Ready {
+		summary.Reason, summary.Message = reason, message
+	}
+	issuing, _, _ := statusCondition(cert, "Issuing")
+	summary.Issuing = issuing == "True"
+	if t, ok := certTime(cert, "notAfter"); ok {
+		summary.NotAfter = t.UTC().Format(time.RFC3339)
+		if t.After(now) {
+			summary.ExpiresIn = formatDuration(t.Sub(now))
+		} else {
+			summary.ExpiresIn = "expired"
+		}
+	}
+	if t, ok := certTime(cert, "renewalTime"); ok {
+		summary.RenewalTime = t.UTC().Format(time.RFC3339)
+	}
+	return summary
+}
+
+func summarizeIssuer(issuer *unstructured.Unstructured) IssuerSummary {
+	summary := IssuerSummary{
+		Kind:      issuer.GetKind(),
+		Namespace: issuer.GetNamespace(),
+		Name:      issuer.GetName(),
+		Type:      issuerType(issuer),
+	}
+	summary.Server, _, _ = unstructured.NestedString(issuer.Object, "spec", "acme", "server")
+	status, reason, message := statusCondition(issuer, "Ready")
+	summary.Ready = status == "True"
+	summary.Reason, summary.Message = reason, message
+	return summary
+}
+
+// issuerType returns which of cert-manager's issuer types issuer
+// configures.
+func issuerType(issuer *unstructured.Unstructured) string {
+	spec, _, _ := unstructured.NestedMap(issuer.Object, "spec")
+	for _, t := range []string{"acme", "ca", "selfSigned", "vault", "venafi"} {
+		if _, ok := spec[t]; ok {
+			return t
+		}
+	}
+	return "unknown"
+}
+
+func (s *Server) toolListCertificates(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		certs, err := listCertManager(ctx, dyn, certificateGVR, namespace)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]CertificateSummary, 0, len(certs))
+		for i := range certs {
+			summaries = append(summaries, summarizeCertificate(&certs[i], now))
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list certificates: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+func (s *Server) toolListCertificateIssuers(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		clusterIssuers, err := listCertManager(ctx, dyn, clusterIssuerGVR, "")
+		if err != nil {
+			return nil, err
+		}
+		issuers, err := listCertManager(ctx, dyn, issuerGVR, namespace)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]IssuerSummary, 0, len(clusterIssuers)+len(issuers))
+		for _, list := range [][]unstructured.Unstructured{clusterIssuers, issuers} {
+			for i := range list {
+				summaries = append(summaries, summarizeIssuer(&list[i]))
+			}
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list issuers: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+// certManagerIndex holds the cert-manager objects of one cluster that
+// explain a Certificate's state, keyed for lookup.
+type certManagerIndex struct {
+	issuers        map[string]*unstructured.Unstructured // namespace/name
+	clusterIssuers map[string]*unstructured.Unstructured
+	// requests, orders, and challenges are keyed by the UID of their owner:
+	// Certificate, CertificateRequest, and Order respectively.
+	requests   map[types.UID][]*unstructured.Unstructured
+	orders     map[types.UID][]*unstructured.Unstructured
+	challenges map[types.UID][]*unstructured.Unstructured
+}
+
+func byOwner(items []unstructured.Unstructured) map[types.UID][]*unstructured.Unstructured {
+	index := make(map[types.UID][]*unstructured.Unstructured)
+	for i := range items {
+		for _, ref := range items[i].GetOwnerReferences() {
+			index[ref.UID] = append(index[ref.UID], &items[i])
+		}
+	}
+	return index
+}
+
+func loadCertManagerIndex(ctx context.Context, dyn dynamic.Interface, namespace string) (*certManagerIndex, error) {
+	idx := &certManagerIndex{
+		issuers:        make(map[string]*unstructured.Unstructured),
+		clusterIssuers: make(map[string]*unstructured.Unstructured),
+	}
+	issuers, err := listCertManager(ctx, dyn, issuerGVR, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range issuers {
+		idx.issuers[issuers[i].GetNamespace()+"/"+issuers[i].GetName()] = &issuers[i]
+	}
+	clusterIssuers, err := listCertManager(ctx, dyn, clusterIssuerGVR, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusterIssuers {
+		idx.clusterIssuers[clusterIssuers[i].GetName()] = &clusterIssuers[i]
+	}
+	requests, err := listCertManager(ctx, dyn, certificateRequestGVR, namespace)
+	if err != nil {
+		return nil, err
+	}
+	idx.requests = byOwner(requests)
+	// The ACME resources only matter for ACME issuers; a cluster may not
+	// let the caller read them.
+	orders, _ := listCertManager(ctx, dyn, acmeOrderGVR, namespace)
+	idx.orders = byOwner(orders)
+	challenges, _ := listCertManager(ctx, dyn, acmeChallengeGVR, namespace)
+	idx.challenges = byOwner(challenges)
+	return idx, nil
+}
+
+// latestCreated returns the most recently created of objs, or nil.
+func latestCreated(objs []*unstructured.Unstructured) *unstructured.Unstructured {
+	var latest *unstructured.Unstructured
+	for _, obj := range objs {
+		if latest == nil || obj.GetCreationTimestamp().After(latest.GetCreationTimestamp().Time) {
+			latest = obj
+		}
+	}
+	return latest
+}
+
+// diagnoseCertificate reports what keeps cert from being issued or renewed:
+// a missing or failing issuer, a denied or failed CertificateRequest, a
+// failed ACME order or stuck challenge, and expiry or overdue renewal.
+func diagnoseCertificate(cert *unstructured.Unstructured, idx *certManagerIndex, now time.Time) []CertificateProblem {
+	var problems []CertificateProblem
+	add := func(severity, problem, detail, suggestion string) {
+		problems = append(problems, CertificateProblem{Severity: severity, Problem: problem, Detail: detail, Suggestion: suggestion})
+	}
+
+	kind, name, builtIn := issuerRef(cert)
+	if builtIn {
+		issuer := idx.clusterIssuers[name]
+		if kind == "Issuer" {
+			issuer = idx.issuers[cert.GetNamespace()+"/"+name]
+		}
+		if issuer == nil {
+			suggestion := fmt.Sprintf("Create %s %q or fix spec.issuerRef.", kind, name)
+			if kind == "Issuer" {
+				suggestion += " Issuers only serve Certificates in their own namespace; use kind: ClusterIssuer for a cluster-wide issuer."
+			}
+			add("critical", fmt.Sprintf("%s %q not found", kind, name), "", suggestion)
+		} else if status, reason, message := statusCondition(issuer, "Ready"); status != "True" {
+			suggestion := "Check the issuer's configuration and the cert-manager controller logs."
+			if issuerType(issuer) == "acme" {
+				suggestion = "Check the ACME server URL, the account email, and that the privateKeySecretRef Secret is readable by cert-manager."
+			}
+			add("critical", fmt.Sprintf("%s %q is not ready", kind, name), joinReason(reason, message), suggestion)
+		}
+	}
+
+	readyStatus, readyReason, readyMessage := statusCondition(cert, "Ready")
+	issuing, _, _ := statusCondition(cert, "Issuing")
+	if readyStatus != "True" || issuing == "True" {
+		if request := latestCreated(idx.requests[cert.GetUID()]); request != nil {
+			problems = append(problems, diagnoseCertificateRequest(request, idx, now)...)
+		}
+	}
+
+	notAfter, hasNotAfter := certTime(cert, "notAfter")
+	renewal, hasRenewal := certTime(cert, "renewalTime")
+	switch {
+	case hasNotAfter && !notAfter.After(now):
+		add("critical", fmt.Sprintf("Certificate expired %s ago", formatDuration(now.Sub(notAfter))), "",
+			"Fix the problems above, then use renew_certificate to issue a new certificate.")
+	case hasRenewal && now.Sub(renewal) > renewalGrace:
+		add("warning", fmt.Sprintf("Renewal is overdue by %s", formatDuration(now.Sub(renewal))),
+			fmt.Sprintf("The certificate expires %s.", notAfter.UTC().Format(time.RFC3339)),
+			"Fix the problems above, or use renew_certificate to retry.")
+	case hasNotAfter && notAfter.Sub(now) < certExpiryWarning:
+		add("warning", fmt.Sprintf("Certificate expires in %s", formatDuration(notAfter.Sub(now))), "", "")
+	}
+
+	if readyStatus != "True" && len(problems) == 0 {
+		severity := "warning"
+		if issuing == "True" {
+			severity = "info"
+		}
+		add(severity, "Certificate is not ready", joinReason(readyReason, readyMessage), "")
+	}
+	return problems
+}
+
+// diagnoseCertificateRequest reports why request, the latest request of a
+// Certificate, has not been issued.
+func diagnoseCertificateRequest(request *unstructured.Unstructured, idx *certManagerIndex, now time.Time) []CertificateProblem {
+	var problems []CertificateProblem
+	if status, reason, message := statusCondition(request, "Denied"); status == "True" {
+		problems = append(problems, CertificateProblem{
+			Severity: "critical", Problem: fmt.Sprintf("CertificateRequest %q was denied", request.GetName()),
+			Detail:     joinReason(reason, message),
+			Suggestion: "An approver denied the request; check the approval policy that covers this issuer.",
+		})
+		return problems
+	}
+	if status, reason, message := statusCondition(request, "InvalidRequest"); status == "True" {
+		problems = append(problems, CertificateProblem{
+			Severity: "critical", Problem: fmt.Sprintf("CertificateRequest %q is invalid", request.GetName()),
+			Detail:     joinReason(reason, message),
+			Suggestion: "Fix the Certificate spec (DNS names, key settings, or usages) the issuer rejected.",
+		})
+		return problems
+	}
+	if status, reason, message := statusCondition(request, "Ready"); status == "False" && reason == "Failed" {
+		problems = append(problems, CertificateProblem{
+			Severity: "critical", Problem: fmt.Sprintf("CertificateRequest %q failed", request.GetName()),
+			Detail: message,
+		})
+	}
+
+	for _, order := range idx.orders[request.GetUID()] {
+		state, _, _ := unstructured.NestedString(order.Object, "status", "state")
+		switch state {
+		case "invalid", "errored", "expired":
+			reason, _, _ := unstructured.NestedString(order.Object, "status", "reason")
+			problems = append(problems, CertificateProblem{
+				Severity: "critical", Problem: fmt.Sprintf("ACME order %q is %s", order.GetName(), state),
+				Detail:     reason,
+				Suggestion: "cert-manager retries failed orders with backoff; fix the cause, then use renew_certificate to retry now.",
+			})
+		}
+		for _, challenge := range idx.challenges[order.GetUID()] {
+			if p, ok := diagnoseChallenge(challenge, now); ok {
+				problems = append(problems, p)
+			}
+		}
+	}
+	return problems
+}
+
+// diagnoseChallenge reports an ACME challenge that failed or has been
+// pending for longer than challengeStuckAfter.
+func diagnoseChallenge(challenge *unstructured.Unstructured, now time.Time) (CertificateProblem, bool) {
+	state, _, _ := unstructured.NestedString(challenge.Object, "status", "state")
+	reason, _, _ := unstructured.NestedString(challenge.Object, "status", "reason")
+	challengeType, _, _ := unstructured.NestedString(challenge.Object, "spec", "type")
+	dnsName, _, _ := unstructured.NestedString(challenge.Object, "spec", "dnsName")
+	challengeType = strings.ToUpper(challengeType)
+
+	var suggestion string
+	switch challengeType {
+	case "HTTP-01":
+		suggestion = fmt.Sprintf("Check that http://%s/.well-known/acme-challenge/ is reachable from the internet and routed to the solver pod, and that the solver's ingress class is correct.", dnsName)
+	case "DNS-01":
+		suggestion = fmt.Sprintf("Check the DNS provider credentials in the issuer's solver and that the TXT record _acme-challenge.%s has propagated.", dnsName)
+	}
+
+	age := now.Sub(challenge.GetCreationTimestamp().Time)
+	switch {
+	case state == "invalid" || state == "errored":
+		return CertificateProblem{
+			Severity: "critical", Problem: fmt.Sprintf("ACME %s challenge for %s is %s", challengeType, dnsName, state),
+			Detail: reason, Suggestion: suggestion,
+		}, true
+	case state != "valid" && age > challengeStuckAfter:
+		if state == "" {
+			state = "pending"
+		}
+		return CertificateProblem{
+			Severity: "critical", Problem: fmt.Sprintf("ACME %s challenge for %s stuck %s for %s", challengeType, dnsName, state, formatDuration(age)),
+			Detail: reason, Suggestion: suggestion,
+		}, true
+	}
+	return CertificateProblem{}, false
+}
+
+func joinReason(reason, message string) string {
+	switch {
+	case reason == "":
+		return message
+	case message == "":
+		return reason
+	}
+	return reason + ": " + message
+}
+
+func (s *Server) toolDiagnoseCertificates(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	name, _ := args["name"].(string)
+	if name != "" && namespace == "" {
+		return "error: namespace is required with name", true
+	}
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		certs, err := listCertManager(ctx, dyn, certificateGVR, namespace)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := loadCertManagerIndex(ctx, dyn, namespace)
+		if err != nil {
+			return nil, err
+		}
+		result := &certificateDiagnoses{}
+		for i := range certs {
+			cert := &certs[i]
+			if name != "" && cert.GetName() != name {
+				continue
+			}
+			result.Checked++
+			problems := diagnoseCertificate(cert, idx, now)
+			if len(problems) == 0 {
+				continue
+			}
+			kind, issuer, _ := issuerRef(cert)
+			ready, _, _ := statusCondition(cert, "Ready")
+			result.Diagnoses = append(result.Diagnoses, CertificateDiagnosis{
+				Namespace: cert.GetNamespace(), Name: cert.GetName(),
+				Issuer: kind + "/" + issuer, Ready: ready == "True", Problems: problems,
+			})
+		}
+		return result, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to diagnose certificates: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# cert-manager Certificate Diagnosis\n")
+	checked := 0
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		d, _ := r.Result.(*certificateDiagnoses)
+		checked += d.Checked
+		if len(d.Diagnoses) == 0 {
+			_, _ = fmt.Fprintf(&sb, "✅ %d certificates checked, no problems found\n", d.Checked)
+			continue
+		}
+		_, _ = fmt.Fprintf(&sb, "%d of %d certificates have problems:\n", len(d.Diagnoses), d.Checked)
+		for _, diag := range d.Diagnoses {
+			state := "ready"
+			if !diag.Ready {
+				state = "not ready"
+			}
+			_, _ = fmt.Fprintf(&sb, "\n📛 %s/%s (%s, %s)\n", diag.Namespace, diag.Name, diag.Issuer, state)
+			for _, p := range diag.Problems {
+				_, _ = fmt.Fprintf(&sb, "   - [%s] %s\n", p.Severity, p.Problem)
+				if p.Detail != "" {
+					_, _ = fmt.Fprintf(&sb, "     %s\n", p.Detail)
+				}
+				if p.Suggestion != "" {
+					_, _ = fmt.Fprintf(&sb, "     Fix: %s\n", p.Suggestion)
+				}
+			}
+		}
+	}
+	if name != "" && checked == 0 {
+		return fmt.Sprintf("Certificate %s/%s not found in any cluster.\n\n%s", namespace, name, sb.String()), true
+	}
+	return sb.String(), false
+}
+
+// toolRenewCertificate forces re-issuance the way cmctl renew does: by
+// setting the Certificate's Issuing condition, which cert-manager acts on.
+func (s *Server) toolRenewCertificate(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "error: name is required", true
+	}
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if namespace == "" {
+		return "error: namespace is required", true
+	}
+
+	dyn, err := s.getDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	certs := dyn.Resource(certificateGVR).Namespace(namespace)
+	cert, err := certs.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("Failed to get certificate: %v", err), true
+	}
+	if status, _, _ := statusCondition(cert, "Issuing"); status == "True" {
+		return fmt.Sprintf("Certificate `%s/%s` is already being issued.", namespace, name), false
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(cert.Object, "status", "conditions")
+	kept := make([]interface{}, 0, len(conditions)+1)
+	for _, c := range conditions {
+		if m, ok := c.(map[string]interface{}); ok && m["type"] == "Issuing" {
+			continue
+		}
+		kept = append(kept, c)
+	}
+	kept = append(kept, map[string]interface{}{
+		"type":               "Issuing",
+		"status":             "True",
+		"reason":             "ManuallyTriggered",
+		"message":            "Certificate re-issuance manually triggered",
+		"lastTransitionTime": time.Now().UTC().Format(time.RFC3339),
+		"observedGeneration": cert.GetGeneration(),
+	})
+	if err := unstructured.SetNestedSlice(cert.Object, kept, "status", "conditions"); err != nil {
+		return fmt.Sprintf("Failed to set condition: %v", err), true
+	}
+	if _, err := certs.UpdateStatus(ctx, cert, metav1.UpdateOptions{}); err != nil {
+		return fmt.Sprintf("Failed to update certificate status: %v", err), true
+	}
+
+	if approval.IsDryRun(ctx) {
+		return fmt.Sprintf("Would trigger renewal of Certificate `%s/%s`.\n", namespace, name), false
+	}
+	secret, _, _ := unstructured.NestedString(cert.Object, "spec", "secretName")
+	return fmt.Sprintf("Triggered renewal of Certificate `%s/%s`. cert-manager issues a new certificate into Secret `%s`; use `diagnose_certificates` if it does not become ready.\n",
+		namespace, name, secret), false
+}
@@ -0,0 +1,99 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "list_certificates",
+		Description: "List cert-manager Certificates with their readiness, DNS names, issuer, expiry, and next renewal time, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to list Certificates from (all namespaces if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListCertificates(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "list_certificate_issuers",
+		Description: "List cert-manager ClusterIssuers and Issuers with their type (acme, ca, selfSigned, vault, venafi), ACME server, and readiness, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to list Issuers from (all namespaces if not specified). ClusterIssuers are always listed",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListCertificateIssuers(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "diagnose_certificates",
+		Description: "Find why cert-manager Certificates are not issued or renewed: missing or unready issuers, denied or failed CertificateRequests, failed ACME orders, stuck HTTP-01/DNS-01 challenges, and expired or overdue certificates, with suggested fixes, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to check (all namespaces if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Check only this Certificate (requires namespace)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolDiagnoseCertificates(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "renew_certificate",
+		Description: "Force cert-manager to re-issue a Certificate now, like cmctl renew, by marking it for issuance",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the Certificate",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Certificate",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolRenewCertificate(ctx, args)
+		},
+	)
+}
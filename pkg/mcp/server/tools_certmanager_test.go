@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+var certManagerListKinds = map[schema.GroupVersionResource]string{
+	certificateGVR:        "CertificateList",
+	certificateRequestGVR: "CertificateRequestList",
+	issuerGVR:             "IssuerList",
+	clusterIssuerGVR:      "ClusterIssuerList",
+	acmeOrderGVR:          "OrderList",
+	acmeChallengeGVR:      "ChallengeList",
+}
+
+// certManagerObject returns a cert-manager object owned by owner, if set.
+func certManagerObject(apiVersion, kind, namespace, name, uid string, created time.Time, owner string, spec, status map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"spec":       spec,
+		"status":     status,
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(uid))
+	obj.SetCreationTimestamp(metav1.NewTime(created))
+	if owner != "" {
+		obj.SetOwnerReferences([]metav1.OwnerReference{{Name: "owner", UID: types.UID(owner)}})
+	}
+	return obj
+}
+
+func conditions(conds ...map[string]interface{}) map[string]interface{} {
+	list := make([]interface{}, len(conds))
+	for i, c := range conds {
+		list[i] = c
+	}
+	return map[string]interface{}{"conditions": list}
+}
+
+func condition(condType, status, reason, message string) map[string]interface{} {
+	return map[string]interface{}{"type": condType, "status": status, "reason": reason, "message": message}
+}
+
+func testCertificate(name, issuerKind, issuerName string, now time.Time, status map[string]interface{}) *unstructured.Unstructured {
+	return certManagerObject("cert-manager.io/v1", "Certificate", "shop", name, "uid-"+name, now.Add(-90*24*time.Hour), "",
+		map[string]interface{}{
+			"secretName": name,
+			"dnsNames":   []interface{}{name + ".example.com"},
+			"issuerRef":  map[string]interface{}{"kind": issuerKind, "name": issuerName},
+		}, status)
+}
+
+// certManagerFixtures returns a fleet's cert-manager objects: web-tls is
+// stuck on an HTTP-01 challenge, api-tls names a missing Issuer, old-tls
+// is overdue for renewal, and ok-tls is healthy.
+func certManagerFixtures(now time.Time) []runtime.Object {
+	rfc := func(d time.Duration) string { return now.Add(d).UTC().Format(time.RFC3339) }
+	ready := condition("Ready", "True", "Ready", "Certificate is up to date and has not expired")
+	return []runtime.Object{
+		certManagerObject("cert-manager.io/v1", "ClusterIssuer", "", "letsencrypt", "uid-le", now, "",
+			map[string]interface{}{"acme": map[string]interface{}{"server": "https://acme-v02.api.letsencrypt.org/directory"}},
+			conditions(condition("Ready", "True", "ACMEAccountRegistered", ""))),
+		certManagerObject("cert-manager.io/v1", "Issuer", "shop", "internal-ca", "uid-ca", now, "",
+			map[string]interface{}{"ca": map[string]interface{}{"secretName": "ca"}},
+			conditions(condition("Ready", "False", "ErrGetKeyPair", `secret "ca" not found`))),
+
+		testCertificate("web-tls", "ClusterIssuer", "letsencrypt", now, conditions(
+			condition("Ready", "False", "DoesNotExist", "Issuing certificate as Secret does not exist"),
+			condition("Issuing", "True", "DoesNotExist", ""))),
+		certManagerObject("cert-manager.io/v1", "CertificateRequest", "shop", "web-tls-1", "uid-cr", now.Add(-time.Hour), "uid-web-tls",
+			map[string]interface{}{}, conditions(condition("Ready", "False", "Pending", "Waiting on certificate issuance"))),
+		certManagerObject("acme.cert-manager.io/v1", "Order", "shop", "web-tls-1-42", "uid-order", now.Add(-time.Hour), "uid-cr",
+			map[string]interface{}{}, map[string]interface{}{"state": "pending"}),
+		certManagerObject("acme.cert-manager.io/v1", "Challenge", "shop", "web-tls-1-42-7", "uid-ch", now.Add(-time.Hour), "uid-order",
+			map[string]interface{}{"type": "HTTP-01", "dnsName": "web-tls.example.com"},
+			map[string]interface{}{"state": "pending", "reason": "Waiting for HTTP-01 challenge propagation: wrong status code '404', expected '200'"}),
+
+		testCertificate("api-tls", "Issuer", "missing", now, conditions(
+			condition("Ready", "False", "DoesNotExist", "Issuing certificate as Secret does not exist"))),
+		testCertificate("ca-tls", "Issuer", "internal-ca", now, conditions(ready)),
+		testCertificate("old-tls", "ClusterIssuer", "letsencrypt", now, map[string]interface{}{
+			"conditions":  conditions(ready)["conditions"],
+			"notAfter":    rfc(3 * 24 * time.Hour),
+			"renewalTime": rfc(-2 * 24 * time.Hour),
+		}),
+		testCertificate("ok-tls", "ClusterIssuer", "letsencrypt", now, map[string]interface{}{
+			"conditions":  conditions(ready)["conditions"],
+			"notAfter":    rfc(60 * 24 * time.Hour),
+			"renewalTime": rfc(30 * 24 * time.Hour),
+		}),
+	}
+}
+
+// newCertManagerServer returns a server over clusters alpha, running
+// cert-manager with certManagerFixtures, and beta, without cert-manager.
+func newCertManagerServer(t *testing.T) (*Server, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	alpha := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), certManagerListKinds, certManagerFixtures(time.Now())...)
+	beta := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), certManagerListKinds)
+	beta.PrependReactor("*", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+	dynamicClients := map[string]dynamic.Interface{"alpha": alpha, "beta": beta}
+	infos := []cluster.ClusterInfo{{Name: "alpha", Context: "alpha"}, {Name: "beta", Context: "beta"}}
+	return &Server{
+		discoverer: stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			return k8sfake.NewSimpleClientset(), nil
+		},
+		dynamicClientFactory: func(clusterName string) (dynamic.Interface, error) {
+			return dynamicClients[clusterName], nil
+		},
+	}, alpha
+}
+
+func TestListCertificatesPerCluster(t *testing.T) {
+	server, _ := newCertManagerServer(t)
+
+	result, rpcErr := callTool(t, server, "list_certificates", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var decoded []struct {
+		Cluster string               `json:"cluster"`
+		Result  []CertificateSummary `json:"result"`
+		Error   string               `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "beta", decoded[1].Cluster)
+	assert.Equal(t, errCertManagerNotInstalled.Error(), decoded[1].Error)
+
+	certs := decoded[0].Result
+	require.Len(t, certs, 5)
+	byName := make(map[string]CertificateSummary)
+	for _, c := range certs {
+		byName[c.Name] = c
+	}
+	web := byName["web-tls"]
+	assert.False(t, web.Ready)
+	assert.True(t, web.Issuing)
+	assert.Equal(t, "DoesNotExist", web.Reason)
+	assert.Equal(t, "ClusterIssuer/letsencrypt", web.Issuer)
+	assert.Equal(t, []string{"web-tls.example.com"}, web.DNSNames)
+
+	ok := byName["ok-tls"]
+	assert.True(t, ok.Ready)
+	assert.Empty(t, ok.Reason)
+	assert.NotEmpty(t, ok.RenewalTime)
+	assert.Equal(t, "59d", ok.ExpiresIn)
+}
+
+func TestListCertificateIssuers(t *testing.T) {
+	server, _ := newCertManagerServer(t)
+
+	result, rpcErr := callTool(t, server, "list_certificate_issuers", map[string]interface{}{"cluster": "alpha"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var decoded []struct {
+		Result []IssuerSummary `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &decoded))
+	require.Len(t, decoded, 1)
+	assert.Equal(t, []IssuerSummary{
+		{Kind: "ClusterIssuer", Name: "letsencrypt", Type: "acme", Server: "https://acme-v02.api.letsencrypt.org/directory", Ready: true, Reason: "ACMEAccountRegistered"},
+		{Kind: "Issuer", Namespace: "shop", Name: "internal-ca", Type: "ca", Reason: "ErrGetKeyPair", Message: `secret "ca" not found`},
+	}, decoded[0].Result)
+}
+
+func TestDiagnoseCertificates(t *testing.T) {
+	server, _ := newCertManagerServer(t)
+
+	result, rpcErr := callTool(t, server, "diagnose_certificates", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+
+	assert.Contains(t, text, "## alpha\n\n4 of 5 certificates have problems:")
+	assert.Contains(t, text, "📛 shop/web-tls (ClusterIssuer/letsencrypt, not ready)\n"+
+		"   - [critical] ACME HTTP-01 challenge for web-tls.example.com stuck pending for 1h\n"+
+		"     Waiting for HTTP-01 challenge propagation: wrong status code '404', expected '200'\n"+
+		"     Fix: Check that http://web-tls.example.com/.well-known/acme-challenge/ is reachable")
+	assert.Contains(t, text, "📛 shop/api-tls (Issuer/missing, not ready)\n   - [critical] Issuer \"missing\" not found")
+	assert.Contains(t, text, "[critical] Issuer \"internal-ca\" is not ready\n     ErrGetKeyPair: secret \"ca\" not found")
+	assert.Contains(t, text, "📛 shop/old-tls (ClusterIssuer/letsencrypt, ready)\n   - [warning] Renewal is overdue by 2d")
+	assert.NotContains(t, text, "ok-tls")
+	assert.Contains(t, text, "## beta\n\nerror: cert-manager is not installed")
+
+	result, _ = callTool(t, server, "diagnose_certificates", map[string]interface{}{"namespace": "shop", "name": "ok-tls"})
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "✅ 1 certificates checked, no problems found")
+
+	result, _ = callTool(t, server, "diagnose_certificates", map[string]interface{}{"namespace": "shop", "name": "nope"})
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "Certificate shop/nope not found in any cluster")
+}
+
+func TestDiagnoseChallengeReportsFailedAndStuckChallenges(t *testing.T) {
+	now := time.Now()
+	challenge := func(state string, age time.Duration) *unstructured.Unstructured {
+		return certManagerObject("acme.cert-manager.io/v1", "Challenge", "shop", "c", "uid", now.Add(-age), "",
+			map[string]interface{}{"type": "DNS-01", "dnsName": "shop.example.com"},
+			map[string]interface{}{"state": state, "reason": "no such zone"})
+	}
+
+	_, found := diagnoseChallenge(challenge("pending", time.Minute), now)
+	assert.False(t, found, "a new challenge is not stuck yet")
+	_, found = diagnoseChallenge(challenge("valid", time.Hour), now)
+	assert.False(t, found)
+
+	p, found := diagnoseChallenge(challenge("errored", time.Minute), now)
+	require.True(t, found)
+	assert.Equal(t, "ACME DNS-01 challenge for shop.example.com is errored", p.Problem)
+	assert.Equal(t, "no such zone", p.Detail)
+	assert.Contains(t, p.Suggestion, "_acme-challenge.shop.example.com")
+}
+
+func TestRenewCertificateMarksItForIssuance(t *testing.T) {
+	server, alpha := newCertManagerServer(t)
+	args := map[string]interface{}{"cluster": "alpha", "namespace": "shop", "name": "ok-tls"}
+
+	result, rpcErr := callTool(t, server, "renew_certificate", args)
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "Triggered renewal of Certificate `shop/ok-tls`")
+
+	cert, err := alpha.Resource(certificateGVR).Namespace("shop").Get(context.Background(), "ok-tls", metav1.GetOptions{})
+	require.NoError(t, err)
+	status, reason, _ := statusCondition(cert, "Issuing")
+	assert.Equal(t, "True", status)
+	assert.Equal(t, "ManuallyTriggered", reason)
+	ready, _, _ := statusCondition(cert, "Ready")
+	assert.Equal(t, "True", ready, "other conditions are kept")
+
+	result, _ = callTool(t, server, "renew_certificate", args)
+	assert.Contains(t, result.Content[0].Text, "already being issued")
+
+	result, _ = callTool(t, server, "renew_certificate", map[string]interface{}{"cluster": "alpha", "name": "ok-tls"})
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "namespace is required")
+}
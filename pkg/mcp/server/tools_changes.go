@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auditlog"
+)
+
+const (
+	defaultChangeWindow = time.Hour
+	defaultChangeLimit  = 200
+	// changeAuditLimit is how many audit events are read to find the
+	// users behind changes and the deletions in the window.
+	changeAuditLimit = 1000
+	// auditMatchSkew is how far apart a managedFields time, which has
+	// second precision, and an audit event may be to be the same write.
+	auditMatchSkew = 2 * time.Second
+)
+
+// churnResources change on their own all the time and would bury the
+// changes people make; what_changed skips them.
+var churnResources = []schema.GroupResource{
+	{Group: "", Resource: "events"},
+	{Group: "events.k8s.io", Resource: "events"},
+	{Group: "", Resource: "endpoints"},
+	{Group: "discovery.k8s.io", Resource: "endpointslices"},
+	{Group: "coordination.k8s.io", Resource: "leases"},
+}
+
+// ResourceChange is one write to an object in the window: a creation or
+// the latest write of one field manager.
+type ResourceChange struct {
+	Time        time.Time `json:"time"`
+	Kind        string    `json:"kind"`
+	Resource    string    `json:"resource"`
+	Name        string    `json:"name"`
+	Manager     string    `json:"manager"`
+	Operation   string    `json:"operation"`
+	Subresource string    `json:"subresource,omitempty"`
+	// User is who made the change, when the audit log has it.
+	User string `json:"user,omitempty"`
+}
+
+// namespaceChanges is what changed in a namespace of one cluster.
+type namespaceChanges struct {
+	Changes   []ResourceChange
+	Truncated bool
+	// Skipped lists resources that could not be listed.
+	Skipped []string
+	// AuditSource is where deletions and users were read, if an audit
+	// log is configured; AuditError is set when reading it failed.
+	AuditSource string
+	AuditError  string
+	Deletions   []auditlog.Event
+}
+
+// listableNamespacedResources returns the preferred version of every
+// namespaced resource that can be listed, except churnResources and the
+// ones kinds does not name. Discovery failures for some API groups are
+// ignored so that one broken aggregated API does not hide the rest.
+func listableNamespacedResources(client kubernetes.Interface, kinds []string) ([]schema.GroupVersionResource, map[schema.GroupVersionResource]string, error) {
+	lists, err := discovery.ServerPreferredNamespacedResources(client.Discovery())
+	if err != nil && len(lists) == 0 {
+		return nil, nil, fmt.Errorf("failed to discover resources: %w", err)
+	}
+	var gvrs []schema.GroupVersionResource
+	kindOf := make(map[schema.GroupVersionResource]string)
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !slices.Contains(r.Verbs, "list") {
+				continue
+			}
+			gvr := gv.WithResource(r.Name)
+			if slices.Contains(churnResources, gvr.GroupResource()) {
+				continue
+			}
+			if len(kinds) > 0 && !slices.ContainsFunc(kinds, func(k string) bool {
+				return strings.EqualFold(k, r.Name) || strings.EqualFold(k, r.Kind)
+			}) {
+				continue
+			}
+			gvrs = append(gvrs, gvr)
+			kindOf[gvr] = r.Kind
+		}
+	}
+	sort.Slice(gvrs, func(i, j int) bool { return gvrs[i].String() < gvrs[j].String() })
+	return gvrs, kindOf, nil
+}
+
+// objectChanges returns the writes to an object since a time: its
+// creation, and the latest write of each field manager. Status writes are
+// skipped unless includeStatus is set.
+func objectChanges(meta metav1.Object, kind, resource string, since time.Time, includeStatus bool) []ResourceChange {
+	var changes []ResourceChange
+	created := meta.GetCreationTimestamp().Time
+	for _, entry := range meta.GetManagedFields() {
+		if entry.Time == nil || entry.Time.Time.Before(since) {
+			continue
+		}
+		if entry.Subresource != "" && !includeStatus {
+			continue
+		}
+		operation := string(entry.Operation)
+		// The first write of an object is recorded as its creator's
+		// Update or Apply at the creation time.
+		if entry.Time.Time.Equal(created) && entry.Subresource == "" {
+			operation = "Create"
+		}
+		changes = append(changes, ResourceChange{
+			Time: entry.Time.Time, Kind: kind, Resource: resource, Name: meta.GetName(),
+			Manager: entry.Manager, Operation: operation, Subresource: entry.Subresource,
+		})
+	}
+	if len(changes) == 0 && !created.Before(since) {
+		changes = append(changes, ResourceChange{Time: created, Kind: kind, Resource: resource, Name: meta.GetName(), Manager: "(unknown)", Operation: "Create"})
+	}
+	return changes
+}
+
+// attributeChanges fills in the users behind changes from audit events of
+// the same object at about the same time.
+func attributeChanges(changes []ResourceChange, events []auditlog.Event) {
+	for i := range changes {
+		c := &changes[i]
+		for _, e := range events {
+			ref := e.ObjectRef
+			if ref == nil || ref.Resource != c.Resource || ref.Name != c.Name || ref.Subresource != c.Subresource {
+				continue
+			}
+			if d := e.StageTimestamp.Sub(c.Time); d < -auditMatchSkew || d > auditMatchSkew {
+				continue
+			}
+			c.User = auditUser(e)
+			break
+		}
+	}
+}
+
+func (s *Server) toolWhatChanged(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if namespace == "" {
+		return "namespace is required", true
+	}
+	window := defaultChangeWindow
+	if v, _ := args["since"].(string); v != "" {
+		if window, err = parseLookback(v); err != nil {
+			return fmt.Sprintf("error: %v", err), true
+		}
+	}
+	var kinds []string
+	if v, _ := args["kinds"].(string); v != "" {
+		for _, k := range strings.Split(v, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				kinds = append(kinds, k)
+			}
+		}
+	}
+	includeStatus, _ := args["include_status"].(bool)
+	limit := defaultChangeLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	since := time.Now().Add(-window)
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		gvrs, kindOf, err := listableNamespacedResources(client, kinds)
+		if err != nil {
+			return nil, err
+		}
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		result := &namespaceChanges{}
+		for _, gvr := range gvrs {
+			list, err := dyn.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%v)", gvr.GroupResource(), err))
+				continue
+			}
+			for i := range list.Items {
+				result.Changes = append(result.Changes, objectChanges(&list.Items[i], kindOf[gvr], gvr.Resource, since, includeStatus)...)
+			}
+		}
+		sort.SliceStable(result.Changes, func(i, j int) bool { return result.Changes[i].Time.After(result.Changes[j].Time) })
+		if len(result.Changes) > limit {
+			result.Changes, result.Truncated = result.Changes[:limit], true
+		}
+
+		// managedFields only keep the last write of each manager and
+		// nothing of deleted objects; the audit log has both.
+		source, err := s.auditLogSource(clusterName)
+		if err != nil {
+			return result, nil
+		}
+		result.AuditSource = source.String()
+		events, err := source.Query(ctx, auditlog.Query{Namespace: namespace, Since: since, Limit: changeAuditLimit})
+		if err != nil {
+			result.AuditError = err.Error()
+			return result, nil
+		}
+		attributeChanges(result.Changes, events)
+		for _, e := range events {
+			if (e.Verb == "delete" || e.Verb == "deletecollection") && (e.ResponseStatus == nil || e.ResponseStatus.Code < 400) {
+				result.Deletions = append(result.Deletions, e)
+			}
+		}
+		return result, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to find changes: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# What Changed in %s in the last %s\n", namespace, formatDuration(window))
+	auditConfigured := false
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		writeNamespaceChanges(&sb, r.Result.(*namespaceChanges))
+		auditConfigured = auditConfigured || r.Result.(*namespaceChanges).AuditSource != ""
+	}
+	if !auditConfigured {
+		sb.WriteString("\nmanagedFields only record the latest write of each manager and nothing about deleted objects. Configure an audit log source (KUBESTELLAR_AUDIT_LOG) to see deletions and who made each change.\n")
+	}
+	return sb.String(), false
+}
+
+func writeNamespaceChanges(sb *strings.Builder, result *namespaceChanges) {
+	if len(result.Changes) == 0 && len(result.Deletions) == 0 {
+		sb.WriteString("No changes\n")
+	}
+
+	byManager := make(map[string][]ResourceChange)
+	var managers []string
+	for _, c := range result.Changes {
+		if _, ok := byManager[c.Manager]; !ok {
+			managers = append(managers, c.Manager)
+		}
+		byManager[c.Manager] = append(byManager[c.Manager], c)
+	}
+	if len(managers) > 0 {
+		_, _ = fmt.Fprintf(sb, "%d changes by %d managers, newest first\n", len(result.Changes), len(managers))
+	}
+	// Changes are sorted newest first, so managers are in the order of
+	// their latest change.
+	for _, manager := range managers {
+		changes := byManager[manager]
+		_, _ = fmt.Fprintf(sb, "\n### %s (%d)\n\n", manager, len(changes))
+		for _, c := range changes {
+			object := c.Kind + "/" + c.Name
+			if c.Subresource != "" {
+				object += " " + c.Subresource
+			}
+			_, _ = fmt.Fprintf(sb, "- %s (%s ago) %s %s", c.Time.UTC().Format("2006-01-02 15:04:05"), formatAge(c.Time), c.Operation, object)
+			if c.User != "" {
+				_, _ = fmt.Fprintf(sb, " by %s", c.User)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if result.Truncated {
+		sb.WriteString("\nShowing the most recent changes only; narrow the window or kinds, or raise limit, for more.\n")
+	}
+
+	if result.AuditSource != "" {
+		_, _ = fmt.Fprintf(sb, "\n### Deleted (audit log: %s)\n\n", result.AuditSource)
+		switch {
+		case result.AuditError != "":
+			_, _ = fmt.Fprintf(sb, "error: %s\n", result.AuditError)
+		case len(result.Deletions) == 0:
+			sb.WriteString("No deletions\n")
+		}
+		for _, e := range result.Deletions {
+			_, _ = fmt.Fprintf(sb, "- %s (%s ago) %s by %s\n", e.StageTimestamp.UTC().Format("2006-01-02 15:04:05"),
+				formatAge(e.StageTimestamp), auditObject(e.ObjectRef), auditUser(e))
+		}
+	}
+	if len(result.Skipped) > 0 {
+		_, _ = fmt.Fprintf(sb, "\nCould not list: %s\n", strings.Join(result.Skipped, ", "))
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "what_changed",
+		Description: "List what changed in a namespace in a recent window, per cluster: objects created or written, from their managedFields, grouped by field manager (kubectl, Helm, Argo CD, controllers), newest first. With an audit log source configured, also names the user behind each change and lists deletions. Answers \"what changed right before the outage?\"",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to look at",
+				},
+				"since": {
+					Type:        "string",
+					Description: "How far back to look, e.g. 15m, 1h, or 2d (default: 1h)",
+				},
+				"kinds": {
+					Type:        "string",
+					Description: "Comma-separated resources or kinds to look at, e.g. deployments,ConfigMap (default: all except events, endpoints, and leases)",
+				},
+				"include_status": {
+					Type:        "boolean",
+					Description: "Also list status writes by controllers",
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum changes per cluster, newest first (default: 200)",
+				},
+			},
+			Required: []string{"namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolWhatChanged(ctx, args)
+		},
+	)
+}
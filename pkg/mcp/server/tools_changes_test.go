@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auditlog"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+func managedFieldsEntry(manager string, op metav1.ManagedFieldsOperationType, subresource string, at time.Time) metav1.ManagedFieldsEntry {
+	return metav1.ManagedFieldsEntry{Manager: manager, Operation: op, Subresource: subresource, Time: &metav1.Time{Time: at}}
+}
+
+func TestObjectChanges(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	since := now.Add(-time.Hour)
+	deploy := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+		Name: "web", CreationTimestamp: metav1.Time{Time: now.Add(-3 * time.Hour)},
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			managedFieldsEntry("kubectl-client-side-apply", metav1.ManagedFieldsOperationUpdate, "", now.Add(-3*time.Hour)),
+			managedFieldsEntry("helm", metav1.ManagedFieldsOperationApply, "", now.Add(-10*time.Minute)),
+			managedFieldsEntry("kube-controller-manager", metav1.ManagedFieldsOperationUpdate, "status", now.Add(-5*time.Minute)),
+		},
+	}}
+
+	got := objectChanges(deploy, "Deployment", "deployments", since, false)
+	require.Len(t, got, 1)
+	assert.Equal(t, "helm", got[0].Manager)
+	assert.Equal(t, "Apply", got[0].Operation)
+
+	got = objectChanges(deploy, "Deployment", "deployments", since, true)
+	require.Len(t, got, 2)
+	assert.Equal(t, "status", got[1].Subresource)
+
+	created := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+		Name: "settings", CreationTimestamp: metav1.Time{Time: now.Add(-20 * time.Minute)},
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			managedFieldsEntry("kubectl-create", metav1.ManagedFieldsOperationUpdate, "", now.Add(-20*time.Minute)),
+		},
+	}}
+	got = objectChanges(created, "ConfigMap", "configmaps", since, false)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Create", got[0].Operation)
+
+	// Objects without managedFields still show their creation.
+	bare := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "bare", CreationTimestamp: metav1.Time{Time: now.Add(-time.Minute)}}}
+	got = objectChanges(bare, "ConfigMap", "configmaps", since, false)
+	require.Len(t, got, 1)
+	assert.Equal(t, ResourceChange{Time: now.Add(-time.Minute), Kind: "ConfigMap", Resource: "configmaps", Name: "bare", Manager: "(unknown)", Operation: "Create"}, got[0])
+}
+
+func toUnstructured(t *testing.T, obj runtime.Object) *unstructured.Unstructured {
+	raw, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: raw}
+}
+
+func TestWhatChangedPerCluster(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	client := k8sfake.NewSimpleClientset()
+	client.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "events", Kind: "Event", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: []string{"get"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"get", "list"}},
+		}},
+	}
+
+	deploy := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "web", Namespace: "shop", CreationTimestamp: metav1.Time{Time: now.Add(-3 * time.Hour)},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				managedFieldsEntry("kubectl-client-side-apply", metav1.ManagedFieldsOperationUpdate, "", now.Add(-3*time.Hour)),
+				managedFieldsEntry("helm", metav1.ManagedFieldsOperationApply, "", now.Add(-10*time.Minute)),
+			},
+		},
+	}
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "settings", Namespace: "shop", CreationTimestamp: metav1.Time{Time: now.Add(-20 * time.Minute)},
+			ManagedFields: []metav1.ManagedFieldsEntry{
+				managedFieldsEntry("kubectl-create", metav1.ManagedFieldsOperationUpdate, "", now.Add(-20*time.Minute)),
+			},
+		},
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}:                 "ConfigMapList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+	}, toUnstructured(t, deploy), toUnstructured(t, configMap))
+
+	var query auditlog.Query
+	source := fakeAuditSource{query: &query, events: []auditlog.Event{
+		{
+			Verb: "delete", User: auditlog.UserInfo{Username: "alice"},
+			ObjectRef:      &auditlog.ObjectRef{Resource: "secrets", Namespace: "shop", Name: "old-creds"},
+			ResponseStatus: &auditlog.ResponseStatus{Code: 200}, StageTimestamp: now.Add(-15 * time.Minute),
+		},
+		{
+			Verb: "patch", User: auditlog.UserInfo{Username: "ci-bot"},
+			ObjectRef:      &auditlog.ObjectRef{Resource: "deployments", APIGroup: "apps", Namespace: "shop", Name: "web"},
+			ResponseStatus: &auditlog.ResponseStatus{Code: 200}, StageTimestamp: now.Add(-10*time.Minute + 300*time.Millisecond),
+		},
+	}}
+	server := &Server{
+		discoverer: stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) {
+			return []cluster.ClusterInfo{{Name: "prod", Context: "prod"}}, nil
+		}},
+		clientFactory:        func(string) (kubernetes.Interface, error) { return client, nil },
+		dynamicClientFactory: func(string) (dynamic.Interface, error) { return dyn, nil },
+		auditSourceFactory:   func(string) (auditlog.Source, error) { return source, nil },
+	}
+
+	result, rpcErr := callTool(t, server, "what_changed", map[string]interface{}{"namespace": "shop"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "# What Changed in shop in the last 1h\n\n## prod\n\n2 changes by 2 managers, newest first\n")
+	assert.Contains(t, text, "### helm (1)\n\n- ")
+	assert.Contains(t, text, "(10m ago) Apply Deployment/web by ci-bot\n")
+	assert.Contains(t, text, "### kubectl-create (1)\n\n- ")
+	assert.Contains(t, text, "(20m ago) Create ConfigMap/settings\n")
+	assert.Less(t, strings.Index(text, "### helm"), strings.Index(text, "### kubectl-create"))
+	assert.Contains(t, text, "### Deleted (audit log: fake)\n\n- ")
+	assert.Contains(t, text, "secrets shop/old-creds by alice\n")
+	assert.NotContains(t, text, "kubectl-client-side-apply")
+	assert.NotContains(t, text, "KUBESTELLAR_AUDIT_LOG")
+	assert.Equal(t, "shop", query.Namespace)
+	assert.WithinDuration(t, now.Add(-time.Hour), query.Since, time.Minute)
+
+	result, _ = callTool(t, server, "what_changed", map[string]interface{}{"namespace": "shop", "kinds": "ConfigMap"})
+	assert.NotContains(t, result.Content[0].Text, "Deployment/web")
+	assert.Contains(t, result.Content[0].Text, "ConfigMap/settings")
+
+	server.auditSourceFactory = func(string) (auditlog.Source, error) { return nil, context.Canceled }
+	result, _ = callTool(t, server, "what_changed", map[string]interface{}{"namespace": "shop", "since": "2d"})
+	text = result.Content[0].Text
+	assert.Contains(t, text, "(10m ago) Apply Deployment/web\n")
+	assert.Contains(t, text, "Configure an audit log source (KUBESTELLAR_AUDIT_LOG)")
+	assert.NotContains(t, text, "### Deleted")
+
+	result, _ = callTool(t, server, "what_changed", map[string]interface{}{})
+	assert.True(t, result.IsError)
+}
@@ -6,6 +6,8 @@ import (
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
 )
 
 func (s *Server) toolListClusters(args map[string]interface{}) (string, bool) {
@@ -34,6 +36,9 @@ func (s *Server) toolListClusters(args map[string]interface{}) (string, bool) {
 		_, _ = fmt.Fprintf(&sb, "- %s%s\n", c.Name, current)
 		_, _ = fmt.Fprintf(&sb, "  Source: %s\n", c.Source)
 		_, _ = fmt.Fprintf(&sb, "  Server: %s\n", c.Server)
+		if len(c.Aliases) > 0 {
+			_, _ = fmt.Fprintf(&sb, "  Aliases: %s\n", strings.Join(c.Aliases, ", "))
+		}
 		if c.Status != "" {
 			_, _ = fmt.Fprintf(&sb, "  Status: %s\n", c.Status)
 		}
@@ -79,6 +84,26 @@ func (s *Server) toolGetClusterHealth(args map[string]interface{}) (string, bool
 		}
 	}
 
+	if targetCluster == nil && clusterName != "" {
+		// The cluster may be named by an alias, its kubeconfig cluster, or
+		// its API server URL.
+		if contextName, err := s.contextForCluster(clusterName); err != nil {
+			return fmt.Sprintf("Failed to resolve cluster %q: %v", clusterName, err), true
+		} else if contextName != clusterName {
+			for _, c := range clusters {
+				if c.Context == contextName {
+					targetCluster = &struct {
+						Name    string
+						Context string
+						Server  string
+						Current bool
+					}{c.Name, c.Context, c.Server, c.Current}
+					break
+				}
+			}
+		}
+	}
+
 	if targetCluster == nil {
 		if clusterName == "" {
 			return "No current cluster context set", true
@@ -114,9 +139,13 @@ func (s *Server) getClientForCluster(clusterName string) (kubernetes.Interface,
 		loadingRules.ExplicitPath = s.kubeconfig
 	}
 
+	contextName, err := s.contextForCluster(clusterName)
+	if err != nil {
+		return nil, err
+	}
 	configOverrides := &clientcmd.ConfigOverrides{}
-	if clusterName != "" {
-		configOverrides.CurrentContext = clusterName
+	if contextName != "" {
+		configOverrides.CurrentContext = contextName
 	}
 
 	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
@@ -125,6 +154,6 @@ func (s *Server) getClientForCluster(clusterName string) (kubernetes.Interface,
 		return nil, err
 	}
 
-	return kubernetes.NewForConfig(config)
+	return kubernetes.NewForConfig(multicluster.ProtobufConfig(s.prepareConfig(config)))
 }
 
@@ -108,3 +108,50 @@ users:
 		t.Fatal("expected non-nil client with override")
 	}
 }
+
+func TestClusterBuildersResolveClusterNames(t *testing.T) {
+	dir := t.TempDir()
+	kubeconfigPath := filepath.Join(dir, "kubeconfig")
+	kubeconfig := `apiVersion: v1
+kind: Config
+current-context: admin@dev
+clusters:
+- name: dev
+  cluster:
+    server: https://dev.example.com:6443
+- name: prod
+  cluster:
+    server: https://prod.example.com:6443
+contexts:
+- name: admin@dev
+  context:
+    cluster: dev
+    user: user1
+- name: admin@prod
+  context:
+    cluster: prod
+    user: user1
+users:
+- name: user1
+  user:
+    token: abc
+`
+	if err := os.WriteFile(kubeconfigPath, []byte(kubeconfig), 0o600); err != nil {
+		t.Fatalf("write kubeconfig: %v", err)
+	}
+	t.Setenv("KUBESTELLAR_CLUSTER_ALIASES", "live=admin@prod")
+	srv := &Server{kubeconfig: kubeconfigPath}
+
+	for _, name := range []string{"admin@prod", "prod", "live", "https://prod.example.com:6443"} {
+		config, err := srv.getRestConfigForCluster(name)
+		if err != nil {
+			t.Fatalf("getRestConfigForCluster(%q): %v", name, err)
+		}
+		if config.Host != "https://prod.example.com:6443" {
+			t.Fatalf("getRestConfigForCluster(%q) host = %q, want the prod server", name, config.Host)
+		}
+	}
+	if _, err := srv.getRestConfigForCluster("staging"); err == nil {
+		t.Fatal("expected an error for an unknown cluster")
+	}
+}
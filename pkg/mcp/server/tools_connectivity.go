@@ -0,0 +1,294 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Paths test_connectivity probes.
+const (
+	pathService      = "pod-to-service"
+	pathExternal     = "pod-to-external"
+	pathCrossCluster = "cross-cluster-service"
+)
+
+const (
+	defaultProbeTimeout = 5 * time.Second
+	maxProbeTimeout     = 60 * time.Second
+	// probePodSlack is how much longer than its probes a probe pod may
+	// take to be scheduled, pull its image and start.
+	probePodSlack     = 2 * time.Minute
+	probePollInterval = 2 * time.Second
+	probeContainer    = "probe"
+)
+
+// probeResultPattern matches the line the probe script prints per probe.
+var probeResultPattern = regexp.MustCompile(`(?m)^probe (\d+) (ok|fail) (\d+)$`)
+
+// probePodLogs returns the output of a finished probe pod. A variable so
+// tests can stand in for the fake clientset, whose logs are fixed.
+var probePodLogs = func(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod) (string, error) {
+	data, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: probeContainer}).DoRaw(ctx)
+	return string(data), err
+}
+
+// connectivityProbe is one path test_connectivity checks from each cluster.
+type connectivityProbe struct {
+	path   string
+	target string
+	// command checks the target and fails if it is unreachable.
+	command []string
+}
+
+// ProbeResult is the outcome of one probe from one cluster.
+type ProbeResult struct {
+	Path      string `json:"path"`
+	Target    string `json:"target"`
+	Success   bool   `json:"success"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// connectivityProbesFromArgs builds the probes of test_connectivity: each
+// service in-cluster and, with cross_cluster, through its clusterset.local
+// name, and each endpoint.
+func connectivityProbesFromArgs(args map[string]interface{}, timeout time.Duration) ([]connectivityProbe, error) {
+	crossCluster, _ := args["cross_cluster"].(bool)
+	var probes []connectivityProbe
+	for _, v := range stringArgs(args, "services") {
+		namespace, name, port, err := parseServiceTarget(v)
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, tcpProbe(pathService, fmt.Sprintf("%s.%s.svc.cluster.local", name, namespace), port, timeout))
+		if crossCluster {
+			probes = append(probes, tcpProbe(pathCrossCluster, fmt.Sprintf("%s.%s.svc.clusterset.local", name, namespace), port, timeout))
+		}
+	}
+	for _, v := range stringArgs(args, "endpoints") {
+		probe, err := endpointProbe(v, timeout)
+		if err != nil {
+			return nil, err
+		}
+		probes = append(probes, probe)
+	}
+	if len(probes) == 0 {
+		return nil, fmt.Errorf("at least one of services or endpoints is required")
+	}
+	return probes, nil
+}
+
+// stringArgs returns the non-empty strings of the array argument key.
+func stringArgs(args map[string]interface{}, key string) []string {
+	var values []string
+	if v, ok := args[key].([]interface{}); ok {
+		for _, item := range v {
+			if s, _ := item.(string); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// parseServiceTarget parses a service given as NAMESPACE/NAME:PORT.
+func parseServiceTarget(v string) (namespace, name, port string, err error) {
+	ref, port, err := net.SplitHostPort(v)
+	namespace, name, ok := strings.Cut(ref, "/")
+	if err != nil || !ok || len(validation.IsDNS1123Label(namespace)) > 0 || len(validation.IsDNS1123Label(name)) > 0 || !validPort(port) {
+		return "", "", "", fmt.Errorf("invalid service %q: must be NAMESPACE/NAME:PORT", v)
+	}
+	return namespace, name, port, nil
+}
+
+// endpointProbe probes an endpoint given as HOST:PORT with a TCP connect,
+// or as an http or https URL with a GET.
+func endpointProbe(v string, timeout time.Duration) (connectivityProbe, error) {
+	seconds := strconv.Itoa(int(timeout.Seconds()))
+	if strings.Contains(v, "://") {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return connectivityProbe{}, fmt.Errorf("invalid endpoint %q: URLs must be http or https", v)
+		}
+		return connectivityProbe{
+			path:    pathExternal,
+			target:  v,
+			command: []string{"wget", "-q", "-T", seconds, "-O", "/dev/null", v},
+		}, nil
+	}
+	host, port, err := net.SplitHostPort(v)
+	if err != nil || !validPort(port) || (net.ParseIP(host) == nil && len(validation.IsDNS1123Subdomain(host)) > 0) {
+		return connectivityProbe{}, fmt.Errorf("invalid endpoint %q: must be HOST:PORT or a URL", v)
+	}
+	return tcpProbe(pathExternal, host, port, timeout), nil
+}
+
+func tcpProbe(path, host, port string, timeout time.Duration) connectivityProbe {
+	return connectivityProbe{
+		path:    path,
+		target:  net.JoinHostPort(host, port),
+		command: []string{"nc", "-z", "-w", strconv.Itoa(int(timeout.Seconds())), host, port},
+	}
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// probeScript is the shell script a probe pod runs: each probe's command,
+// timed, printing "probe INDEX ok|fail MILLISECONDS".
+func probeScript(probes []connectivityProbe) string {
+	var sb strings.Builder
+	sb.WriteString(`probe() { i=$1; shift; t0=$(date +%s%N); if "$@" >/dev/null 2>&1; then r=ok; else r=fail; fi; echo "probe $i $r $(( ($(date +%s%N) - t0) / 1000000 ))"; }` + "\n")
+	for i, p := range probes {
+		_, _ = fmt.Fprintf(&sb, "probe %d", i)
+		for _, arg := range p.command {
+			sb.WriteString(" " + shellQuote(arg))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// probePod builds the pod that runs probes once and exits.
+func probePod(namespace, image string, probes []connectivityProbe, timeout time.Duration, now time.Time) *corev1.Pod {
+	deadline := int64((time.Duration(len(probes))*timeout + probePodSlack).Seconds())
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "connectivity-probe-" + strconv.FormatInt(now.UnixNano(), 36),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": ServerName,
+				"app.kubernetes.io/component":  "connectivity-probe",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:         corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds: &deadline,
+			Containers: []corev1.Container{{
+				Name:    probeContainer,
+				Image:   image,
+				Command: []string{"sh", "-c", probeScript(probes)},
+			}},
+		},
+	}
+}
+
+// parseProbeResults reads the results of probes from a probe pod's output.
+// Probes without a result line are reported as failed.
+func parseProbeResults(probes []connectivityProbe, output string) []ProbeResult {
+	results := make([]ProbeResult, len(probes))
+	for i, p := range probes {
+		results[i] = ProbeResult{Path: p.path, Target: p.target, Error: "no result from probe pod"}
+	}
+	for _, m := range probeResultPattern.FindAllStringSubmatch(output, -1) {
+		i, _ := strconv.Atoi(m[1])
+		if i >= len(results) {
+			continue
+		}
+		latency, _ := strconv.ParseInt(m[3], 10, 64)
+		results[i].Success = m[2] == "ok"
+		results[i].LatencyMs = latency
+		results[i].Error = ""
+		if !results[i].Success {
+			results[i].Error = "unreachable"
+		}
+	}
+	return results
+}
+
+// runProbePod runs a probe pod to completion and returns its output. The
+// pod is deleted when it finishes or the wait gives up.
+func runProbePod(ctx context.Context, client kubernetes.Interface, pod *corev1.Pod) (string, error) {
+	created, err := client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to create probe pod: %w", err)
+	}
+	defer func() {
+		_ = client.CoreV1().Pods(pod.Namespace).Delete(context.Background(), created.Name, metav1.DeleteOptions{})
+	}()
+
+	timeout := time.Duration(*pod.Spec.ActiveDeadlineSeconds) * time.Second
+	err = wait.PollUntilContextTimeout(ctx, probePollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		current, err := client.CoreV1().Pods(pod.Namespace).Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		created = current
+		return current.Status.Phase == corev1.PodSucceeded || current.Status.Phase == corev1.PodFailed, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("probe pod %s/%s did not finish (phase %s): %w", pod.Namespace, created.Name, created.Status.Phase, err)
+	}
+	return probePodLogs(ctx, client, created)
+}
+
+func (s *Server) toolTestConnectivity(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if namespace == "" {
+		namespace = defaultDebugNamespace
+	}
+	image, _ := args["image"].(string)
+	if image == "" {
+		image = defaultDebugImage
+	}
+	timeout := defaultProbeTimeout
+	if v, ok := args["timeout_seconds"].(float64); ok {
+		timeout = time.Duration(v) * time.Second
+		if timeout <= 0 || timeout > maxProbeTimeout {
+			return fmt.Sprintf("error: timeout_seconds must be between 1 and %d", int(maxProbeTimeout.Seconds())), true
+		}
+	}
+	probes, err := connectivityProbesFromArgs(args, timeout)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	if approval.IsDryRun(ctx) {
+		where := "each cluster"
+		if cluster != "" {
+			where = "cluster " + cluster
+		}
+		var sb strings.Builder
+		_, _ = fmt.Fprintf(&sb, "Would run a probe pod (`%s`) in namespace `%s` of %s, deleted when done, probing:\n", image, namespace, where)
+		for _, p := range probes {
+			_, _ = fmt.Fprintf(&sb, "- %s %s\n", p.path, p.target)
+		}
+		return sb.String(), false
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		out, err := runProbePod(ctx, client, probePod(namespace, image, probes, timeout, time.Now()))
+		if err != nil {
+			return nil, err
+		}
+		return parseProbeResults(probes, out), nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to test connectivity: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
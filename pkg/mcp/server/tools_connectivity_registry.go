@@ -0,0 +1,49 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterMutatingTool(Tool{
+		Name:        "test_connectivity",
+		Description: "Test network reachability from each cluster with a short-lived probe pod: pod to service, pod to external endpoint, and, with cross_cluster, pod to a service through its clusterset.local name (Submariner, MCS). Reports success and latency per path and cluster; the probe pod is deleted when done",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster to probe from (all clusters if not specified)",
+				},
+				"services": {
+					Type:        "array",
+					Description: "Services to probe as NAMESPACE/NAME:PORT, through NAME.NAMESPACE.svc.cluster.local. Services shared by a Cilium cluster mesh resolve to every cluster's backends under this name",
+					Items:       &Items{Type: "string"},
+				},
+				"endpoints": {
+					Type:        "array",
+					Description: "Endpoints to probe: HOST:PORT for a TCP connect, or an http or https URL for a GET",
+					Items:       &Items{Type: "string"},
+				},
+				"cross_cluster": {
+					Type:        "boolean",
+					Description: "Also probe each service through NAME.NAMESPACE.svc.clusterset.local, as exported to the cluster set by Submariner Lighthouse or the Multi-Cluster Services API",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to run the probe pod in (default: default)",
+				},
+				"image": {
+					Type:        "string",
+					Description: "Probe pod image; needs sh, date, nc and wget (default: busybox:1.36)",
+				},
+				"timeout_seconds": {
+					Type:        "integer",
+					Description: "Timeout of each probe (default 5, max 60)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolTestConnectivity(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestTestConnectivityReportsEachPathPerCluster(t *testing.T) {
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": {}, "beta": {}})
+	var (
+		mu      sync.Mutex
+		scripts []string
+	)
+	for _, client := range clients {
+		// Probe pods finish as soon as they are created.
+		client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+			pod.Status.Phase = corev1.PodSucceeded
+			mu.Lock()
+			scripts = append(scripts, pod.Spec.Containers[0].Command[2])
+			mu.Unlock()
+			return false, nil, nil
+		})
+	}
+	origLogs := probePodLogs
+	defer func() { probePodLogs = origLogs }()
+	probePodLogs = func(_ context.Context, client kubernetes.Interface, pod *corev1.Pod) (string, error) {
+		if client == clients["beta"] {
+			// The cross-cluster path is down from beta and the last
+			// probe never reported.
+			return "probe 0 ok 3\nprobe 1 fail 5000\n", nil
+		}
+		return "probe 0 ok 2\nprobe 1 ok 41\nprobe 2 ok 120\n", nil
+	}
+
+	result, rpcErr := callTool(t, server, "test_connectivity", map[string]interface{}{
+		"services":      []interface{}{"apps/web:8080"},
+		"cross_cluster": true,
+		"endpoints":     []interface{}{"https://example.com/healthz"},
+	})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var decoded []struct {
+		Cluster string        `json:"cluster"`
+		Result  []ProbeResult `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, []ProbeResult{
+		{Path: pathService, Target: "web.apps.svc.cluster.local:8080", Success: true, LatencyMs: 2},
+		{Path: pathCrossCluster, Target: "web.apps.svc.clusterset.local:8080", Success: true, LatencyMs: 41},
+		{Path: pathExternal, Target: "https://example.com/healthz", Success: true, LatencyMs: 120},
+	}, decoded[0].Result)
+	beta := decoded[1].Result
+	assert.False(t, beta[1].Success)
+	assert.Equal(t, "unreachable", beta[1].Error)
+	assert.False(t, beta[2].Success)
+	assert.Equal(t, "no result from probe pod", beta[2].Error)
+
+	require.Len(t, scripts, 2)
+	assert.Contains(t, scripts[0], "probe 0 'nc' '-z' '-w' '5' 'web.apps.svc.cluster.local' '8080'")
+	assert.Contains(t, scripts[0], "probe 2 'wget' '-q' '-T' '5' '-O' '/dev/null' 'https://example.com/healthz'")
+
+	for name, client := range clients {
+		pods, err := client.CoreV1().Pods(defaultDebugNamespace).List(context.Background(), metav1.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, pods.Items, "probe pod left behind in %s", name)
+	}
+}
+
+func TestConnectivityProbesFromArgs(t *testing.T) {
+	probes, err := connectivityProbesFromArgs(map[string]interface{}{
+		"endpoints": []interface{}{"10.0.0.1:53", "db.example.com:5432"},
+	}, 3*time.Second)
+	require.NoError(t, err)
+	require.Len(t, probes, 2)
+	assert.Equal(t, pathExternal, probes[0].path)
+	assert.Equal(t, []string{"nc", "-z", "-w", "3", "db.example.com", "5432"}, probes[1].command)
+
+	for _, args := range []map[string]interface{}{
+		{},
+		{"services": []interface{}{"web:80"}},
+		{"services": []interface{}{"apps/web"}},
+		{"services": []interface{}{"apps/web:70000"}},
+		{"endpoints": []interface{}{"ftp://example.com"}},
+		{"endpoints": []interface{}{"$(reboot):80"}},
+	} {
+		_, err := connectivityProbesFromArgs(args, time.Second)
+		assert.Error(t, err, "%v", args)
+	}
+}
+
+func TestProbeScriptQuotesArguments(t *testing.T) {
+	script := probeScript([]connectivityProbe{{command: []string{"wget", "http://example.com/?q='x'"}}})
+	assert.True(t, strings.HasSuffix(script, `probe 0 'wget' 'http://example.com/?q='\''x'\'''`+"\n"), script)
+}
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Defaults and limits of launch_debug_pod.
+const (
+	defaultDebugImage     = "busybox:1.36"
+	defaultDebugNamespace = "default"
+	defaultDebugTTL       = 60 * time.Minute
+	maxDebugTTL           = 24 * time.Hour
+	debugContainerName    = "debugger"
+	debugHostRoot         = "/host"
+)
+
+// debugExpiresAnnotation records when a debug Job is deleted.
+const debugExpiresAnnotation = "kubestellar.io/debug-expires"
+
+// debugSpec is what launch_debug_pod starts: a debug Job, on node when
+// set, or an ephemeral container in pod when set.
+type debugSpec struct {
+	namespace       string
+	image           string
+	node            string
+	pod             string
+	targetContainer string
+	privileged      bool
+	ttl             time.Duration
+}
+
+// debugSpecFromArgs extracts and validates the arguments of
+// launch_debug_pod.
+func debugSpecFromArgs(args map[string]interface{}) (debugSpec, error) {
+	spec := debugSpec{image: defaultDebugImage, ttl: defaultDebugTTL}
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return spec, err
+	}
+	spec.namespace = namespace
+	if spec.namespace == "" {
+		spec.namespace = defaultDebugNamespace
+	}
+	if image, _ := args["image"].(string); image != "" {
+		spec.image = image
+	}
+	spec.node, _ = args["node"].(string)
+	spec.pod, _ = args["pod"].(string)
+	spec.targetContainer, _ = args["target_container"].(string)
+	spec.privileged, _ = args["privileged"].(bool)
+	if spec.node != "" && spec.pod != "" {
+		return spec, fmt.Errorf("node and pod cannot both be set")
+	}
+	if spec.targetContainer != "" && spec.pod == "" {
+		return spec, fmt.Errorf("target_container requires pod")
+	}
+	if v, ok := args["ttl_minutes"].(float64); ok {
+		spec.ttl = time.Duration(v) * time.Minute
+		if spec.ttl <= 0 || spec.ttl > maxDebugTTL {
+			return spec, fmt.Errorf("ttl_minutes must be between 1 and %d", int(maxDebugTTL.Minutes()))
+		}
+	}
+	return spec, nil
+}
+
+// command keeps a debug container alive for the TTL and no longer.
+func (d debugSpec) command() []string {
+	return []string{"sleep", strconv.Itoa(int(d.ttl.Seconds()))}
+}
+
+func (d debugSpec) securityContext() *corev1.SecurityContext {
+	if !d.privileged {
+		return nil
+	}
+	privileged := true
+	return &corev1.SecurityContext{Privileged: &privileged}
+}
+
+func (s *Server) toolLaunchDebugPod(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	spec, err := debugSpecFromArgs(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	client, err := s.getClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+
+	if spec.pod != "" {
+		pod, err := client.CoreV1().Pods(spec.namespace).Get(ctx, spec.pod, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Sprintf("Failed to get pod: %v", err), true
+		}
+		container, err := addDebugContainer(pod, spec)
+		if err != nil {
+			return fmt.Sprintf("error: %v", err), true
+		}
+		if _, err := client.CoreV1().Pods(spec.namespace).UpdateEphemeralContainers(ctx, pod.Name, pod, metav1.UpdateOptions{}); err != nil {
+			return fmt.Sprintf("Failed to add ephemeral container: %v", err), true
+		}
+		return formatDebugContainer(ctx, cluster, spec, container), false
+	}
+
+	job := debugJob(spec, time.Now())
+	created, err := client.BatchV1().Jobs(spec.namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Sprintf("Failed to create debug job: %v", err), true
+	}
+	return formatDebugJob(ctx, cluster, spec, created.Name), false
+}
+
+// debugJob builds a Job whose single pod runs spec's image until the TTL,
+// when the Job's deadline ends it and the Job and its pod are deleted. On
+// a node, the pod shares the host's namespaces and mounts its root
+// filesystem at /host, like kubectl debug node/NAME.
+func debugJob(spec debugSpec, now time.Time) *batchv1.Job {
+	base := "debug"
+	if spec.node != "" {
+		base = "debug-" + spec.node
+	}
+	suffix := "-" + strconv.FormatInt(now.Unix(), 36)
+	if max := 63 - len(suffix); len(base) > max {
+		base = strings.TrimRight(base[:max], "-.")
+	}
+
+	deadline := int64(spec.ttl.Seconds())
+	ttlAfterFinished := int32(0)
+	backoffLimit := int32(0)
+	podSpec := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers: []corev1.Container{{
+			Name:            debugContainerName,
+			Image:           spec.image,
+			Command:         spec.command(),
+			Stdin:           true,
+			TTY:             true,
+			SecurityContext: spec.securityContext(),
+		}},
+	}
+	if spec.node != "" {
+		podSpec.NodeName = spec.node
+		podSpec.HostPID = true
+		podSpec.HostNetwork = true
+		podSpec.HostIPC = true
+		podSpec.Tolerations = []corev1.Toleration{{Operator: corev1.TolerationOpExists}}
+		podSpec.Volumes = []corev1.Volume{{
+			Name:         "host-root",
+			VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/"}},
+		}}
+		podSpec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "host-root", MountPath: debugHostRoot}}
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": ServerName,
+		"app.kubernetes.io/component":  "debug",
+	}
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        base + suffix,
+			Namespace:   spec.namespace,
+			Labels:      labels,
+			Annotations: map[string]string{debugExpiresAnnotation: now.Add(spec.ttl).UTC().Format(time.RFC3339)},
+		},
+		Spec: batchv1.JobSpec{
+			ActiveDeadlineSeconds:   &deadline,
+			TTLSecondsAfterFinished: &ttlAfterFinished,
+			BackoffLimit:            &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       podSpec,
+			},
+		},
+	}
+}
+
+// addDebugContainer adds an ephemeral debug container to pod, like
+// kubectl debug. It shares the process namespace of targetContainer, or of
+// the pod's only container, and is named debugger, or debugger-N if that
+// is taken. It returns the container's name.
+func addDebugContainer(pod *corev1.Pod, spec debugSpec) (string, error) {
+	target := spec.targetContainer
+	if target == "" && len(pod.Spec.Containers) == 1 {
+		target = pod.Spec.Containers[0].Name
+	}
+	taken := make(map[string]bool)
+	found := target == ""
+	for _, c := range pod.Spec.Containers {
+		taken[c.Name] = true
+		found = found || c.Name == target
+	}
+	if !found {
+		return "", fmt.Errorf("pod %s/%s has no container %q", pod.Namespace, pod.Name, target)
+	}
+	for _, c := range pod.Spec.InitContainers {
+		taken[c.Name] = true
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		taken[c.Name] = true
+	}
+	name := debugContainerName
+	for i := 1; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", debugContainerName, i)
+	}
+
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            name,
+			Image:           spec.image,
+			Command:         spec.command(),
+			Stdin:           true,
+			TTY:             true,
+			SecurityContext: spec.securityContext(),
+		},
+		TargetContainerName: target,
+	})
+	return name, nil
+}
+
+func formatDebugJob(ctx context.Context, cluster string, spec debugSpec, name string) string {
+	var sb strings.Builder
+	where := ""
+	if spec.node != "" {
+		where = fmt.Sprintf(" on node `%s`", spec.node)
+	}
+	if approval.IsDryRun(ctx) {
+		_, _ = fmt.Fprintf(&sb, "Would create debug Job `%s/%s`%s running `%s` for %s.\n", spec.namespace, name, where, spec.image, spec.ttl)
+		return sb.String()
+	}
+	_, _ = fmt.Fprintf(&sb, "Created debug Job `%s/%s`%s running `%s`.\n", spec.namespace, name, where, spec.image)
+	_, _ = fmt.Fprintf(&sb, "It is deleted with its pod after %s.\n", spec.ttl)
+	if spec.node != "" {
+		_, _ = fmt.Fprintf(&sb, "The pod shares the node's network and process namespaces; the node's filesystem is at `%s`.\n", debugHostRoot)
+	}
+	sb.WriteString("\nOpen a shell once the pod is running:\n\n")
+	_, _ = fmt.Fprintf(&sb, "    kubectl exec -it%s -n %s job/%s -- sh\n", contextFlag(cluster), spec.namespace, name)
+	return sb.String()
+}
+
+func formatDebugContainer(ctx context.Context, cluster string, spec debugSpec, container string) string {
+	var sb strings.Builder
+	if approval.IsDryRun(ctx) {
+		_, _ = fmt.Fprintf(&sb, "Would add ephemeral container `%s` running `%s` to pod `%s/%s` for %s.\n", container, spec.image, spec.namespace, spec.pod, spec.ttl)
+		return sb.String()
+	}
+	_, _ = fmt.Fprintf(&sb, "Added ephemeral container `%s` running `%s` to pod `%s/%s`.\n", container, spec.image, spec.namespace, spec.pod)
+	_, _ = fmt.Fprintf(&sb, "It exits after %s. Ephemeral containers cannot be removed; it stays in the pod's status until the pod is deleted.\n", spec.ttl)
+	sb.WriteString("\nOpen a shell once it is running:\n\n")
+	_, _ = fmt.Fprintf(&sb, "    kubectl exec -it%s -n %s %s -c %s -- sh\n", contextFlag(cluster), spec.namespace, spec.pod, container)
+	return sb.String()
+}
+
+// contextFlag returns the kubectl --context flag for cluster, or nothing
+// for the current context.
+func contextFlag(cluster string) string {
+	if cluster == "" {
+		return ""
+	}
+	return " --context " + cluster
+}
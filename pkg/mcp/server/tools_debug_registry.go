@@ -0,0 +1,51 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterMutatingTool(Tool{
+		Name:        "launch_debug_pod",
+		Description: "Launch a short-lived debug pod, on a node with its host namespaces and filesystem when node is set, or add an ephemeral debug container to an existing pod like kubectl debug. Debug pods are deleted after ttl_minutes; ephemeral containers exit then. Returns the kubectl exec command for a shell",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the debug pod, or of pod (default: default)",
+				},
+				"image": {
+					Type:        "string",
+					Description: "Debug container image (default: busybox:1.36; e.g. nicolaka/netshoot for network tools)",
+				},
+				"node": {
+					Type:        "string",
+					Description: "Run the debug pod on this node, sharing its network, PID and IPC namespaces with the node's root filesystem at /host",
+				},
+				"pod": {
+					Type:        "string",
+					Description: "Add an ephemeral debug container to this pod instead of launching a debug pod",
+				},
+				"target_container": {
+					Type:        "string",
+					Description: "Container of pod whose process namespace the debug container shares (default: the pod's only container)",
+				},
+				"privileged": {
+					Type:        "boolean",
+					Description: "Run the debug container privileged, e.g. for packet capture",
+				},
+				"ttl_minutes": {
+					Type:        "integer",
+					Description: "Minutes until the debug pod is deleted or the ephemeral container exits (default 60, max 1440)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolLaunchDebugPod(ctx, args)
+		},
+	)
+}
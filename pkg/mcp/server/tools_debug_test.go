@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestLaunchDebugPodOnNode(t *testing.T) {
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": {}})
+
+	result, rpcErr := callTool(t, server, "launch_debug_pod", map[string]interface{}{
+		"cluster": "alpha", "node": "worker-1", "image": "nicolaka/netshoot", "ttl_minutes": float64(15),
+	})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	jobs, err := clients["alpha"].BatchV1().Jobs(defaultDebugNamespace).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, jobs.Items, 1)
+	job := jobs.Items[0]
+	assert.Contains(t, job.Name, "debug-worker-1-")
+	assert.Equal(t, int64(900), *job.Spec.ActiveDeadlineSeconds)
+	assert.Equal(t, int32(0), *job.Spec.TTLSecondsAfterFinished)
+	assert.NotEmpty(t, job.Annotations[debugExpiresAnnotation])
+
+	pod := job.Spec.Template.Spec
+	assert.Equal(t, "worker-1", pod.NodeName)
+	assert.True(t, pod.HostPID)
+	assert.True(t, pod.HostNetwork)
+	require.Len(t, pod.Containers, 1)
+	assert.Equal(t, "nicolaka/netshoot", pod.Containers[0].Image)
+	assert.Equal(t, []string{"sleep", "900"}, pod.Containers[0].Command)
+	assert.Equal(t, debugHostRoot, pod.Containers[0].VolumeMounts[0].MountPath)
+	assert.Nil(t, pod.Containers[0].SecurityContext)
+
+	text := result.Content[0].Text
+	assert.Contains(t, text, "kubectl exec -it --context alpha -n default job/"+job.Name+" -- sh")
+	assert.Contains(t, text, "/host")
+}
+
+func TestLaunchDebugPodAddsEphemeralContainer(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "apps"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "web", Image: "web:v1"}},
+			EphemeralContainers: []corev1.EphemeralContainer{{
+				EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: debugContainerName, Image: defaultDebugImage},
+			}},
+		},
+	}
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": {pod}})
+
+	result, rpcErr := callTool(t, server, "launch_debug_pod", map[string]interface{}{
+		"cluster": "alpha", "namespace": "apps", "pod": "web-0", "privileged": true,
+	})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	updated, err := clients["alpha"].CoreV1().Pods("apps").Get(context.Background(), "web-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, updated.Spec.EphemeralContainers, 2)
+	added := updated.Spec.EphemeralContainers[1]
+	assert.Equal(t, "debugger-1", added.Name)
+	assert.Equal(t, "web", added.TargetContainerName, "the pod's only container is the default target")
+	assert.Equal(t, []string{"sleep", "3600"}, added.Command)
+	require.NotNil(t, added.SecurityContext)
+	assert.True(t, *added.SecurityContext.Privileged)
+	assert.Contains(t, result.Content[0].Text, "kubectl exec -it --context alpha -n apps web-0 -c debugger-1 -- sh")
+}
+
+func TestLaunchDebugPodRejectsInvalidArgs(t *testing.T) {
+	for name, args := range map[string]map[string]interface{}{
+		"node and pod":          {"node": "worker-1", "pod": "web-0"},
+		"target without pod":    {"target_container": "web"},
+		"ttl too long":          {"ttl_minutes": float64(2000)},
+		"ttl not positive":      {"ttl_minutes": float64(0)},
+		"system namespace":      {"namespace": "kube-system"},
+		"target missing in pod": {"namespace": "apps", "pod": "web-0", "target_container": "sidecar"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "apps"},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web"}}},
+			}
+			server, _ := newJobsServer(map[string][]runtime.Object{"alpha": {pod}})
+			args["cluster"] = "alpha"
+			result, rpcErr := callTool(t, server, "launch_debug_pod", args)
+			require.Nil(t, rpcErr)
+			assert.True(t, result.IsError, result.Content[0].Text)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "get_details",
+		Description: "Expand part of a summarized result. Large results of fleet-wide scans (find_pod_issues, check_security_issues, detect_drift, ...) come back as a summary with a details_token and an index of sections; pass the token with a section ID (s3), a finding ID (s3.2), or a name such as a cluster, namespace, or pod to get the matching details, or \"all\" for the full result",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"details_token": {
+					Type:        "string",
+					Description: "details_token of the summarized result",
+				},
+				"section": {
+					Type:        "string",
+					Description: "Section ID, finding ID, name to search for, or \"all\". Omit to list the sections",
+				},
+			},
+			Required: []string{"details_token"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetDetails(ctx, args)
+		},
+	)
+}
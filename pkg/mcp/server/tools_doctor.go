@@ -0,0 +1,489 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/advisories"
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+	"github.com/kubestellar/kubestellar-mcp/pkg/policy"
+)
+
+// maxConcurrentAccessReviews bounds the SelfSubjectAccessReviews sent to
+// one cluster at a time.
+const maxConcurrentAccessReviews = 8
+
+// Seams for tests.
+var (
+	lookPath         = exec.LookPath
+	doctorHTTPClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// permission is an action a tool takes on the API.
+type permission struct {
+	verb, group, resource, subresource string
+}
+
+func (p permission) String() string {
+	resource := p.resource
+	if p.subresource != "" {
+		resource += "/" + p.subresource
+	}
+	if p.group != "" {
+		resource += "." + p.group
+	}
+	return p.verb + " " + resource
+}
+
+// toolFamily is a group of tools that need the same permissions.
+type toolFamily struct {
+	name        string
+	tools       []string
+	permissions []permission
+}
+
+var toolFamilies = []toolFamily{
+	{
+		name:  "Health and diagnostics",
+		tools: []string{"get_cluster_health", "find_pod_issues", "find_deployment_issues", "check_security_issues", "get_warning_events"},
+		permissions: []permission{
+			{verb: "list", resource: "nodes"}, {verb: "list", resource: "pods"},
+			{verb: "list", group: "apps", resource: "deployments"}, {verb: "list", resource: "events"},
+		},
+	},
+	{
+		name:        "Pod logs",
+		tools:       []string{"get_pod_logs", "get_cronjob_logs"},
+		permissions: []permission{{verb: "get", resource: "pods", subresource: "log"}},
+	},
+	{
+		name:  "RBAC analysis",
+		tools: []string{"analyze_subject_permissions", "get_roles", "get_cluster_roles", "get_role_bindings", "find_resource_owners"},
+		permissions: []permission{
+			{verb: "list", group: "rbac.authorization.k8s.io", resource: "roles"},
+			{verb: "list", group: "rbac.authorization.k8s.io", resource: "clusterroles"},
+			{verb: "list", group: "rbac.authorization.k8s.io", resource: "rolebindings"},
+			{verb: "list", group: "rbac.authorization.k8s.io", resource: "clusterrolebindings"},
+		},
+	},
+	{
+		name:        "Helm releases",
+		tools:       []string{"check_helm_release_upgrades", "detect_helm_values_drift"},
+		permissions: []permission{{verb: "list", resource: "secrets"}},
+	},
+	{
+		name:  "Upgrade readiness",
+		tools: []string{"get_upgrade_prerequisites", "simulate_upgrade_impact"},
+		permissions: []permission{
+			{verb: "list", resource: "nodes"}, {verb: "list", resource: "secrets"},
+			{verb: "list", group: "policy", resource: "poddisruptionbudgets"},
+		},
+	},
+	{
+		name:  "CronJobs",
+		tools: []string{"run_cronjob_now", "suspend_cronjob", "resume_cronjob"},
+		permissions: []permission{
+			{verb: "create", group: "batch", resource: "jobs"}, {verb: "patch", group: "batch", resource: "cronjobs"},
+		},
+	},
+	{
+		name:  "Debug and connectivity",
+		tools: []string{"launch_debug_pod", "test_connectivity"},
+		permissions: []permission{
+			{verb: "create", resource: "pods"}, {verb: "create", group: "batch", resource: "jobs"},
+			{verb: "update", resource: "pods", subresource: "ephemeralcontainers"},
+		},
+	},
+	{
+		name:        "Ownership policy",
+		tools:       []string{"install_ownership_policy", "set_ownership_policy_mode", "uninstall_ownership_policy"},
+		permissions: []permission{{verb: "create", group: "templates.gatekeeper.sh", resource: "constrainttemplates"}},
+	},
+}
+
+// binaryRequirement is an executable an optional feature runs.
+type binaryRequirement struct {
+	name string
+	// env overrides the executable's name.
+	env       string
+	neededFor string
+}
+
+var doctorBinaries = []binaryRequirement{
+	{name: "git", neededFor: "detect_drift and detect_helm_values_drift from Git repositories"},
+	{name: "wkhtmltopdf", env: EnvPDFConverter, neededFor: "generate_report with format pdf"},
+	{name: "opa", env: policy.EnvOPABinary, neededFor: "Rego ownership policies in $" + policy.EnvPolicyPath},
+	{name: "aws", neededFor: "query_audit_log with cloudwatch sources"},
+	{name: "gcloud", neededFor: "query_audit_log with gcp sources"},
+}
+
+// FamilyAccess is the access of the caller to the permissions of a tool
+// family on a cluster.
+type FamilyAccess struct {
+	Family string   `json:"family"`
+	Tools  []string `json:"tools"`
+	// Access is full, partial, or none.
+	Access  string   `json:"access"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// ClusterDoctor is the outcome of the checks on a cluster.
+type ClusterDoctor struct {
+	Version   string         `json:"version"`
+	LatencyMs int64          `json:"latencyMs"`
+	User      string         `json:"user,omitempty"`
+	Groups    []string       `json:"groups,omitempty"`
+	Families  []FamilyAccess `json:"families"`
+}
+
+// checkCluster checks that the API server answers and which tool families
+// the caller may use, in namespace or across all namespaces.
+func checkCluster(ctx context.Context, client kubernetes.Interface, namespace string) (*ClusterDoctor, error) {
+	start := time.Now()
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("API server unreachable: %w", err)
+	}
+	result := &ClusterDoctor{Version: version.GitVersion, LatencyMs: time.Since(start).Milliseconds()}
+
+	// SelfSubjectReview needs Kubernetes 1.28; older clusters only lose
+	// the identity.
+	if review, err := client.AuthenticationV1().SelfSubjectReviews().Create(ctx, &authenticationv1.SelfSubjectReview{}, metav1.CreateOptions{}); err == nil {
+		result.User, result.Groups = review.Status.UserInfo.Username, review.Status.UserInfo.Groups
+	}
+
+	allowed, err := reviewAccess(ctx, client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	for _, family := range toolFamilies {
+		access := FamilyAccess{Family: family.name, Tools: family.tools, Access: "full"}
+		for _, p := range family.permissions {
+			if !allowed[p] {
+				access.Missing = append(access.Missing, p.String())
+			}
+		}
+		switch len(access.Missing) {
+		case 0:
+		case len(family.permissions):
+			access.Access = "none"
+		default:
+			access.Access = "partial"
+		}
+		result.Families = append(result.Families, access)
+	}
+	return result, nil
+}
+
+// reviewAccess asks the API server, in parallel batches, whether the
+// caller has each permission of the tool families.
+func reviewAccess(ctx context.Context, client kubernetes.Interface, namespace string) (map[permission]bool, error) {
+	var perms []permission
+	seen := make(map[permission]bool)
+	for _, family := range toolFamilies {
+		for _, p := range family.permissions {
+			if !seen[p] {
+				seen[p] = true
+				perms = append(perms, p)
+			}
+		}
+	}
+
+	allowed := make(map[permission]bool, len(perms))
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	sem := make(chan struct{}, maxConcurrentAccessReviews)
+	for _, p := range perms {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(p permission) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Namespace: namespace, Verb: p.verb, Group: p.group, Resource: p.resource, Subresource: p.subresource,
+				}},
+			}, metav1.CreateOptions{})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("failed to review access: %w", err)
+				}
+				return
+			}
+			allowed[p] = review.Status.Allowed
+		}(p)
+	}
+	wg.Wait()
+	return allowed, firstErr
+}
+
+// endpointCheck is an external endpoint a tool calls.
+type endpointCheck struct {
+	name, url, usedBy string
+}
+
+// doctorEndpoints returns the configured Prometheus and advisories
+// endpoints.
+func doctorEndpoints() []endpointCheck {
+	var endpoints []endpointCheck
+	for _, entry := range strings.Split(os.Getenv(config.EnvPrometheus), ",") {
+		name, endpoint, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || endpoint == "" {
+			continue
+		}
+		if name == "*" {
+			name = "other clusters"
+		}
+		endpoints = append(endpoints, endpointCheck{
+			name: "Prometheus for " + name, url: strings.TrimSuffix(endpoint, "/") + "/api/v1/status/buildinfo", usedBy: "find_resource_anomalies",
+		})
+	}
+	if url := os.Getenv(advisories.EnvAdvisoriesURL); url != "" {
+		endpoints = append(endpoints, endpointCheck{name: "Version advisories", url: url, usedBy: "get_cluster_version_info"})
+	}
+	return endpoints
+}
+
+// probeEndpoint returns an empty string if a GET of url succeeds, or why
+// it did not.
+func probeEndpoint(ctx context.Context, url string) string {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err.Error()
+	}
+	resp, err := doctorHTTPClient.Do(req)
+	if err != nil {
+		return err.Error()
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return "returned " + resp.Status
+	}
+	return ""
+}
+
+func (s *Server) toolCheckEnvironment(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	var sb strings.Builder
+	var broken []string
+	sb.WriteString("# Environment Check\n\n## Kubeconfig\n\n")
+	clusters, err := s.discoverer.DiscoverClusters("all")
+	servers := make(map[string]string)
+	switch {
+	case err != nil:
+		_, _ = fmt.Fprintf(&sb, "❌ %v\n", err)
+		broken = append(broken, "every cluster tool: the kubeconfig cannot be loaded")
+	case len(clusters) == 0:
+		sb.WriteString("❌ No contexts found\n")
+		broken = append(broken, "every cluster tool: the kubeconfig has no contexts")
+	default:
+		current := "none"
+		for _, c := range clusters {
+			servers[c.Name] = c.Server
+			if c.Current {
+				current = c.Name
+			}
+		}
+		_, _ = fmt.Fprintf(&sb, "✅ %d contexts (current: %s)\n", len(clusters), current)
+	}
+
+	if err == nil && len(clusters) > 0 {
+		results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+			return checkCluster(ctx, client, namespace)
+		})
+		if err != nil {
+			return fmt.Sprintf("Failed to check clusters: %v", err), true
+		}
+		sortClusterResults(results)
+		sb.WriteString("\n## Clusters\n")
+		scope := "all namespaces"
+		if namespace != "" {
+			scope = "namespace " + namespace
+		}
+		for _, r := range results {
+			_, _ = fmt.Fprintf(&sb, "\n### %s\n\n", r.Cluster)
+			server := servers[r.Cluster]
+			if server == "" {
+				server = "API server"
+			}
+			if r.Error != "" {
+				_, _ = fmt.Fprintf(&sb, "❌ %s: %s\n", server, r.Error)
+				broken = append(broken, fmt.Sprintf("every tool on %s: %s", r.Cluster, r.Error))
+				continue
+			}
+			d := r.Result.(*ClusterDoctor)
+			_, _ = fmt.Fprintf(&sb, "✅ %s reachable in %dms (Kubernetes %s)\n", server, d.LatencyMs, d.Version)
+			if d.User != "" {
+				_, _ = fmt.Fprintf(&sb, "✅ Authenticated as %s", d.User)
+				if len(d.Groups) > 0 {
+					_, _ = fmt.Fprintf(&sb, " (groups: %s)", strings.Join(d.Groups, ", "))
+				}
+				sb.WriteString("\n")
+			}
+			_, _ = fmt.Fprintf(&sb, "\nPermissions in %s:\n\n", scope)
+			writeFamilyAccess(&sb, d.Families)
+			for _, f := range d.Families {
+				if f.Access != "full" {
+					broken = append(broken, fmt.Sprintf("%s on %s (%s): missing %s", f.Family, r.Cluster, strings.Join(f.Tools, ", "), strings.Join(f.Missing, ", ")))
+				}
+			}
+		}
+	}
+
+	sb.WriteString("\n## Binaries\n\n")
+	sb.WriteString("| Binary | Needed for | Status |\n")
+	sb.WriteString("|--------|------------|--------|\n")
+	for _, b := range doctorBinaries {
+		name := b.name
+		if b.env != "" {
+			if v := os.Getenv(b.env); v != "" {
+				name = v
+			}
+		}
+		status := "❌ not found"
+		if path, err := lookPath(name); err == nil {
+			status = "✅ " + path
+		} else {
+			broken = append(broken, fmt.Sprintf("%s: %s not found", b.neededFor, name))
+		}
+		_, _ = fmt.Fprintf(&sb, "| %s | %s | %s |\n", name, b.neededFor, status)
+	}
+
+	sb.WriteString("\n## Endpoints\n\n")
+	endpoints := doctorEndpoints()
+	if len(endpoints) == 0 {
+		_, _ = fmt.Fprintf(&sb, "No external endpoints configured ($%s, $%s).\n", config.EnvPrometheus, advisories.EnvAdvisoriesURL)
+	} else {
+		sb.WriteString("| Endpoint | Used by | Status |\n")
+		sb.WriteString("|----------|---------|--------|\n")
+		for _, e := range endpoints {
+			status := "✅ reachable"
+			if problem := probeEndpoint(ctx, e.url); problem != "" {
+				status = "❌ " + problem
+				broken = append(broken, fmt.Sprintf("%s: %s %s", e.usedBy, e.name, problem))
+			}
+			_, _ = fmt.Fprintf(&sb, "| %s (%s) | %s | %s |\n", e.name, e.url, e.usedBy, status)
+		}
+	}
+
+	sb.WriteString("\n## Summary\n\n")
+	if len(broken) == 0 {
+		sb.WriteString("✅ Everything checked works in this environment.\n")
+		return sb.String(), false
+	}
+	sort.Strings(broken)
+	sb.WriteString("Will not work, or only in part:\n\n")
+	for _, b := range broken {
+		_, _ = fmt.Fprintf(&sb, "- %s\n", b)
+	}
+	return sb.String(), false
+}
+
+// toolWhoami reports who the caller is on each cluster and which tool
+// families its RBAC lets it use, so that an agent learns of missing
+// permissions before it calls the tools that need them.
+func (s *Server) toolWhoami(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return checkCluster(ctx, client, namespace)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to check clusters: %v", err), true
+	}
+	sortClusterResults(results)
+	scope := "all namespaces"
+	if namespace != "" {
+		scope = "namespace " + namespace
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Who Am I\n")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "❌ %s\n", r.Error)
+			continue
+		}
+		d := r.Result.(*ClusterDoctor)
+		if d.User == "" {
+			_, _ = fmt.Fprintf(&sb, "**User:** unknown (SelfSubjectReview is unavailable on Kubernetes %s; it needs 1.28 or later)\n", d.Version)
+		} else {
+			_, _ = fmt.Fprintf(&sb, "**User:** %s\n", d.User)
+		}
+		if len(d.Groups) > 0 {
+			_, _ = fmt.Fprintf(&sb, "**Groups:** %s\n", strings.Join(d.Groups, ", "))
+		}
+		_, _ = fmt.Fprintf(&sb, "\nPermissions in %s:\n\n", scope)
+		writeFamilyAccess(&sb, d.Families)
+
+		var works, partial, none []string
+		for _, f := range d.Families {
+			switch f.Access {
+			case "full":
+				works = append(works, f.Family)
+			case "partial":
+				partial = append(partial, fmt.Sprintf("%s (%s)", f.Family, strings.Join(f.Tools, ", ")))
+			default:
+				none = append(none, fmt.Sprintf("%s (%s)", f.Family, strings.Join(f.Tools, ", ")))
+			}
+		}
+		sb.WriteString("\n")
+		if len(works) > 0 {
+			_, _ = fmt.Fprintf(&sb, "**Will work:** %s\n", strings.Join(works, ", "))
+		}
+		if len(partial) > 0 {
+			_, _ = fmt.Fprintf(&sb, "**Will work in part:** %s\n", strings.Join(partial, "; "))
+		}
+		if len(none) > 0 {
+			_, _ = fmt.Fprintf(&sb, "**Will not work:** %s\n", strings.Join(none, "; "))
+		}
+	}
+	return sb.String(), false
+}
+
+// writeFamilyAccess writes the access of the caller to each tool family as
+// a table.
+func writeFamilyAccess(sb *strings.Builder, families []FamilyAccess) {
+	sb.WriteString("| Tool family | Access | Missing permissions |\n")
+	sb.WriteString("|-------------|--------|---------------------|\n")
+	for _, f := range families {
+		icon := "✅"
+		switch f.Access {
+		case "partial":
+			icon = "⚠️"
+		case "none":
+			icon = "❌"
+		}
+		missing := strings.Join(f.Missing, ", ")
+		if missing == "" {
+			missing = "-"
+		}
+		_, _ = fmt.Fprintf(sb, "| %s | %s %s | %s |\n", f.Family, icon, f.Access, missing)
+	}
+}
@@ -0,0 +1,48 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "check_environment",
+		Description: "Check what works in this environment: kubeconfig access, reachability of each cluster's API server, the caller's permissions for each tool family (via SelfSubjectAccessReviews), the binaries optional features run, and the configured Prometheus and advisories endpoints. Ends with a list of what will not work",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster to check (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Check permissions in this namespace instead of across all namespaces",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolCheckEnvironment(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "whoami",
+		Description: "Show who the server authenticates as on each cluster (user and groups, via SelfSubjectReview) and which tool families its RBAC allows, via SelfSubjectAccessReviews. Call it first to learn which tools will fail for lack of permissions",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster to check (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Check permissions in this namespace instead of across all namespaces",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolWhoami(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/advisories"
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+)
+
+func TestCheckEnvironmentReportsWhatWillNotWork(t *testing.T) {
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/status/buildinfo", r.URL.Path)
+		_, _ = w.Write([]byte(`{"status":"success"}`))
+	}))
+	t.Cleanup(prom.Close)
+	t.Setenv(config.EnvPrometheus, "alpha="+prom.URL)
+	t.Setenv(advisories.EnvAdvisoriesURL, "")
+	t.Setenv(EnvPDFConverter, "")
+	savedLookPath := lookPath
+	lookPath = func(name string) (string, error) {
+		if name == "git" {
+			return "/usr/bin/git", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	t.Cleanup(func() { lookPath = savedLookPath })
+
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": nil, "beta": nil})
+	alpha := clients["alpha"]
+	alpha.PrependReactor("create", "selfsubjectreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		review := &authenticationv1.SelfSubjectReview{}
+		review.Status.UserInfo = authenticationv1.UserInfo{Username: "jane", Groups: []string{"devs"}}
+		return true, review, nil
+	})
+	var namespaces []string
+	alpha.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		namespaces = append(namespaces, attrs.Namespace)
+		review.Status.Allowed = attrs.Resource != "secrets" && attrs.Group != "templates.gatekeeper.sh"
+		return true, review, nil
+	})
+	clients["beta"].Discovery().(interface {
+		PrependReactor(string, string, k8stesting.ReactionFunc)
+	}).PrependReactor("get", "version", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("x509: certificate signed by unknown authority")
+	})
+
+	result, rpcErr := callTool(t, server, "check_environment", map[string]interface{}{"namespace": "team-a"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+
+	assert.Contains(t, text, "## Kubeconfig\n\n✅ 2 contexts (current: none)\n")
+	assert.Contains(t, text, "### alpha\n\n✅ API server reachable in ")
+	assert.Contains(t, text, "✅ Authenticated as jane (groups: devs)\n\nPermissions in namespace team-a:\n")
+	assert.Contains(t, text, "| Health and diagnostics | ✅ full | - |\n")
+	assert.Contains(t, text, "| Helm releases | ❌ none | list secrets |\n")
+	assert.Contains(t, text, "| Upgrade readiness | ⚠️ partial | list secrets |\n")
+	assert.Contains(t, text, "| Ownership policy | ❌ none | create constrainttemplates.templates.gatekeeper.sh |\n")
+	assert.Contains(t, text, "### beta\n\n❌ API server: API server unreachable: x509: certificate signed by unknown authority\n")
+	assert.Contains(t, text, "| git | detect_drift and detect_helm_values_drift from Git repositories | ✅ /usr/bin/git |\n")
+	assert.Contains(t, text, "| wkhtmltopdf | generate_report with format pdf | ❌ not found |\n")
+	assert.Contains(t, text, "| Prometheus for alpha ("+prom.URL+"/api/v1/status/buildinfo) | find_resource_anomalies | ✅ reachable |\n")
+	assert.Contains(t, text, "\n## Summary\n\nWill not work, or only in part:\n\n")
+	assert.Contains(t, text, "- Helm releases on alpha (check_helm_release_upgrades, detect_helm_values_drift): missing list secrets\n")
+	assert.Contains(t, text, "- every tool on beta: API server unreachable: x509: certificate signed by unknown authority\n")
+	assert.Contains(t, text, "- generate_report with format pdf: wkhtmltopdf not found\n")
+
+	for _, ns := range namespaces {
+		assert.Equal(t, "team-a", ns)
+	}
+	// Each permission shared by families is reviewed once.
+	assert.Len(t, namespaces, 16)
+}
+
+func TestWhoamiSummarizesToolFamilies(t *testing.T) {
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": nil, "beta": nil})
+	alpha := clients["alpha"]
+	alpha.PrependReactor("create", "selfsubjectreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		review := &authenticationv1.SelfSubjectReview{}
+		review.Status.UserInfo = authenticationv1.UserInfo{Username: "jane", Groups: []string{"devs", "system:authenticated"}}
+		return true, review, nil
+	})
+	reviewAccess := func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		review.Status.Allowed = attrs.Resource != "secrets" && attrs.Group != "templates.gatekeeper.sh"
+		return true, review, nil
+	}
+	alpha.PrependReactor("create", "selfsubjectaccessreviews", reviewAccess)
+	clients["beta"].PrependReactor("create", "selfsubjectaccessreviews", reviewAccess)
+	clients["beta"].PrependReactor("create", "selfsubjectreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("the server could not find the requested resource")
+	})
+
+	result, rpcErr := callTool(t, server, "whoami", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+
+	assert.Contains(t, text, "## alpha\n\n**User:** jane\n**Groups:** devs, system:authenticated\n\nPermissions in all namespaces:\n")
+	assert.Contains(t, text, "| Helm releases | ❌ none | list secrets |\n")
+	assert.Contains(t, text, "**Will work:** Health and diagnostics, Pod logs, RBAC analysis, CronJobs, Debug and connectivity\n")
+	assert.Contains(t, text, "**Will work in part:** Upgrade readiness (get_upgrade_prerequisites, simulate_upgrade_impact)\n")
+	assert.Contains(t, text, "**Will not work:** Helm releases (check_helm_release_upgrades, detect_helm_values_drift); Ownership policy (")
+	assert.Contains(t, text, "## beta\n\n**User:** unknown (SelfSubjectReview is unavailable on Kubernetes ")
+}
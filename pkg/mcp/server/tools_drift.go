@@ -11,7 +11,7 @@ import (
 )
 
 type manifestReader interface {
-	ReadFromGit(ctx context.Context, source gitops.ManifestSource) ([]gitops.Manifest, error)
+	Read(ctx context.Context, source gitops.ManifestSource) ([]gitops.Manifest, error)
 	Cleanup()
 }
 
@@ -36,13 +36,19 @@ func (s *Server) newDriftDetector(config *rest.Config) (driftDetector, error) {
 
 func (s *Server) toolDetectDrift(ctx context.Context, args map[string]interface{}) (string, bool) {
 	repoURL, _ := args["repo_url"].(string)
+	localPath, _ := args["local_path"].(string)
+	encodedArchive, _ := args["archive"].(string)
 	path, _ := args["path"].(string)
 	branch, _ := args["branch"].(string)
 	cluster, _ := args["cluster"].(string)
 	namespace, _ := args["namespace"].(string)
 
-	if repoURL == "" {
-		return "repo_url is required", true
+	if repoURL == "" && localPath == "" && encodedArchive == "" {
+		return "one of repo_url, local_path, or archive is required", true
+	}
+	archive, err := gitops.DecodeArchive(encodedArchive)
+	if err != nil {
+		return err.Error(), true
 	}
 
 	// Get REST config for the cluster
@@ -62,18 +68,20 @@ func (s *Server) toolDetectDrift(ctx context.Context, args map[string]interface{
 	defer reader.Cleanup()
 
 	source := gitops.ManifestSource{
-		Repo:   repoURL,
-		Path:   path,
-		Branch: branch,
+		Repo:      repoURL,
+		Path:      path,
+		Branch:    branch,
+		LocalPath: localPath,
+		Archive:   archive,
 	}
 
-	manifests, err := reader.ReadFromGit(ctx, source)
+	manifests, err := reader.Read(ctx, source)
 	if err != nil {
-		return fmt.Sprintf("Failed to read manifests from git: %v", err), true
+		return fmt.Sprintf("Failed to read manifests from %s: %v", source.Location(), err), true
 	}
 
 	if len(manifests) == 0 {
-		return fmt.Sprintf("No manifests found in %s (path: %s)", repoURL, path), false
+		return fmt.Sprintf("No manifests found in %s (path: %s)", source.Location(), path), false
 	}
 
 	// Create drift detector
@@ -102,7 +110,14 @@ func (s *Server) toolDetectDrift(ctx context.Context, args map[string]interface{
 	// Build response
 	var sb strings.Builder
 	sb.WriteString("# GitOps Drift Detection\n\n")
-	_, _ = fmt.Fprintf(&sb, "**Repository:** %s\n", repoURL)
+	switch {
+	case repoURL != "":
+		_, _ = fmt.Fprintf(&sb, "**Repository:** %s\n", repoURL)
+	case localPath != "":
+		_, _ = fmt.Fprintf(&sb, "**Local path:** %s\n", localPath)
+	default:
+		_, _ = fmt.Fprintf(&sb, "**Archive:** %s\n", source.Location())
+	}
 	if path != "" {
 		_, _ = fmt.Fprintf(&sb, "**Path:** %s\n", path)
 	}
@@ -3,9 +3,9 @@ package server
 import "context"
 
 func init() {
-	RegisterTool(Tool{
+	RegisterCachedTool(Tool{
 			Name:        "detect_drift",
-			Description: "Detect configuration drift between Git repository manifests and cluster state. Shows which resources differ.",
+			Description: "Detect configuration drift between manifests in a Git repository, a local directory, or an uploaded archive and cluster state. Shows which resources differ.",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]Property{
@@ -13,9 +13,17 @@ func init() {
 						Type:        "string",
 						Description: "Git repository URL (e.g., https://github.com/org/manifests)",
 					},
+					"local_path": {
+						Type:        "string",
+						Description: "Local directory, manifest file, or .tar.gz of manifests to use instead of a Git repository, e.g. a working tree before it is pushed. Must be within $KUBESTELLAR_MANIFEST_ROOTS (default: the server's working directory)",
+					},
+					"archive": {
+						Type:        "string",
+						Description: "Base64-encoded .tar.gz of manifests to use instead of a Git repository",
+					},
 					"path": {
 						Type:        "string",
-						Description: "Path within repository to YAML manifests (e.g., production/)",
+						Description: "Path within the repository, local directory, or archive to YAML manifests (e.g., production/)",
 					},
 					"branch": {
 						Type:        "string",
@@ -30,9 +38,9 @@ func init() {
 						Description: "Override namespace for all resources",
 					},
 				},
-				Required: []string{"repo_url"},
 			},
 		},
+		scanCacheTTL,
 		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 			return s.toolDetectDrift(ctx, args)
 		},
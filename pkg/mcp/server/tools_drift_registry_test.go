@@ -37,7 +37,7 @@ func TestDriftToolRegistry_ToolCount(t *testing.T) {
 
 func TestDriftToolRegistry_RequiredFields(t *testing.T) {
 	requiredFields := map[string][]string{
-		"detect_drift": {"repo_url"},
+		"detect_drift": {},
 	}
 
 	registered := make(map[string]Tool)
@@ -62,7 +62,7 @@ func TestDriftToolRegistry_StringProperties(t *testing.T) {
 	tool, ok := registered["detect_drift"]
 	require.True(t, ok, "detect_drift should be registered")
 
-	expectedStringProps := []string{"repo_url", "path", "branch", "cluster", "namespace"}
+	expectedStringProps := []string{"repo_url", "local_path", "archive", "path", "branch", "cluster", "namespace"}
 	for _, propName := range expectedStringProps {
 		prop, exists := tool.InputSchema.Properties[propName]
 		require.True(t, exists, "%q property should exist", propName)
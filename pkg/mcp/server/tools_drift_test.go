@@ -18,7 +18,7 @@ type fakeManifestReader struct {
 	cleaned   bool
 }
 
-func (f *fakeManifestReader) ReadFromGit(_ context.Context, source gitops.ManifestSource) ([]gitops.Manifest, error) {
+func (f *fakeManifestReader) Read(_ context.Context, source gitops.ManifestSource) ([]gitops.Manifest, error) {
 	f.source = source
 	if f.err != nil {
 		return nil, f.err
@@ -54,15 +54,15 @@ func (f *fakeDriftDetector) DetectDrift(_ context.Context, manifests []gitops.Ma
 }
 
 func TestToolDetectDrift(t *testing.T) {
-	t.Run("missing repo_url", func(t *testing.T) {
+	t.Run("missing source", func(t *testing.T) {
 		result, rpcErr := callTool(t, &Server{}, "detect_drift", map[string]interface{}{})
 		if rpcErr != nil {
 			t.Fatalf("unexpected RPC error: %v", rpcErr)
 		}
 		if !result.IsError {
-			t.Fatal("expected tool error for missing repo_url")
+			t.Fatal("expected tool error for missing source")
 		}
-		if !strings.Contains(result.Content[0].Text, "repo_url is required") {
+		if !strings.Contains(result.Content[0].Text, "one of repo_url, local_path, or archive is required") {
 			t.Fatalf("unexpected error text: %s", result.Content[0].Text)
 		}
 	})
@@ -206,6 +206,48 @@ func TestToolDetectDrift(t *testing.T) {
 		}
 	})
 
+	t.Run("local path and archive sources", func(t *testing.T) {
+		reader := &fakeManifestReader{manifests: []gitops.Manifest{
+			{APIVersion: "v1", Kind: "ConfigMap", Metadata: gitops.ManifestMetadata{Name: "settings", Namespace: "apps"}},
+		}}
+		server := &Server{
+			restConfigFactory: func(string) (*rest.Config, error) {
+				return &rest.Config{Host: "https://cluster.example"}, nil
+			},
+			manifestReaderFactory: func() manifestReader { return reader },
+			driftDetectorFactory: func(*rest.Config) (driftDetector, error) {
+				return &fakeDriftDetector{}, nil
+			},
+		}
+
+		result, rpcErr := callTool(t, server, "detect_drift", map[string]interface{}{"local_path": "./deploy", "path": "prod"})
+		if rpcErr != nil || result.IsError {
+			t.Fatalf("unexpected error: %v %v", rpcErr, result.Content)
+		}
+		if reader.source.LocalPath != "./deploy" || reader.source.Path != "prod" || reader.source.Repo != "" {
+			t.Fatalf("unexpected manifest source: %#v", reader.source)
+		}
+		if !strings.Contains(result.Content[0].Text, "**Local path:** ./deploy\n") {
+			t.Fatalf("unexpected output: %s", result.Content[0].Text)
+		}
+
+		result, rpcErr = callTool(t, server, "detect_drift", map[string]interface{}{"archive": "H4sIAAAA"})
+		if rpcErr != nil || result.IsError {
+			t.Fatalf("unexpected error: %v %v", rpcErr, result.Content)
+		}
+		if string(reader.source.Archive) != "\x1f\x8b\x08\x00\x00\x00" {
+			t.Fatalf("archive = %q, want the decoded bytes", reader.source.Archive)
+		}
+		if !strings.Contains(result.Content[0].Text, "**Archive:** uploaded archive (6 bytes)\n") {
+			t.Fatalf("unexpected output: %s", result.Content[0].Text)
+		}
+
+		result, _ = callTool(t, server, "detect_drift", map[string]interface{}{"archive": "not base64!"})
+		if !result.IsError || !strings.Contains(result.Content[0].Text, "archive is not valid base64") {
+			t.Fatalf("expected a base64 error, got: %v", result.Content)
+		}
+	})
+
 	t.Run("cluster config error", func(t *testing.T) {
 		server := &Server{
 			restConfigFactory: func(clusterName string) (*rest.Config, error) {
@@ -0,0 +1,607 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const externalSecretsGroup = "external-secrets.io"
+
+// externalSecretsVersions are the API versions of the External Secrets
+// Operator, newest first. Clusters running releases before v1 was served
+// only have v1beta1.
+var externalSecretsVersions = []string{"v1", "v1beta1"}
+
+const (
+	// forceSyncAnnotation is the annotation whose change makes the operator
+	// refresh an ExternalSecret immediately.
+	forceSyncAnnotation = "force-sync"
+	// defaultRefreshInterval is the operator's refresh interval when an
+	// ExternalSecret does not set one.
+	defaultRefreshInterval = time.Hour
+)
+
+var errExternalSecretsNotInstalled = errors.New("the External Secrets Operator is not installed (no externalsecrets.external-secrets.io API)")
+
+// ExternalSecretSummary is the sync state of an ExternalSecret.
+type ExternalSecretSummary struct {
+	Namespace       string `json:"namespace"`
+	Name            string `json:"name"`
+	Store           string `json:"store"`
+	Target          string `json:"target"`
+	RefreshInterval string `json:"refreshInterval"`
+	Ready           bool   `json:"ready"`
+	Reason          string `json:"reason,omitempty"`
+	Message         string `json:"message,omitempty"`
+	LastSync        string `json:"lastSync,omitempty"`
+}
+
+// SecretStoreSummary is the provider and readiness of a SecretStore or
+// ClusterSecretStore.
+type SecretStoreSummary struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Provider  string `json:"provider"`
+	Ready     bool   `json:"ready"`
+	Reason    string `json:"reason,omitempty"`
+	Message   string `json:"message,omitempty"`
+}
+
+// ExternalSecretDiagnosis is the problems found with one ExternalSecret and
+// the failing pods that use its Secret.
+type ExternalSecretDiagnosis struct {
+	Namespace    string               `json:"namespace"`
+	Name         string               `json:"name"`
+	Store        string               `json:"store"`
+	Target       string               `json:"target"`
+	Ready        bool                 `json:"ready"`
+	Problems     []CertificateProblem `json:"problems"`
+	AffectedPods []string             `json:"affectedPods,omitempty"`
+}
+
+// externalSecretDiagnoses is the diagnosis of the ExternalSecrets in one
+// cluster.
+type externalSecretDiagnoses struct {
+	Checked   int
+	Diagnoses []ExternalSecretDiagnosis
+}
+
+func externalSecretsGVR(version, resource string) schema.GroupVersionResource {
+	return schema.GroupVersionResource{Group: externalSecretsGroup, Version: version, Resource: resource}
+}
+
+// listExternalSecretsAPI lists the objects of an External Secrets Operator
+// resource in the newest API version the cluster serves, reporting
+// errExternalSecretsNotInstalled when it serves none.
+func listExternalSecretsAPI(ctx context.Context, dyn dynamic.Interface, resource, namespace string) ([]unstructured.Unstructured, error) {
+	for _, version := range externalSecretsVersions {
+		list, err := dyn.Resource(externalSecretsGVR(version, resource)).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", resource, err)
+		}
+		items := list.Items
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].GetNamespace() != items[j].GetNamespace() {
+				return items[i].GetNamespace() < items[j].GetNamespace()
+			}
+			return items[i].GetName() < items[j].GetName()
+		})
+		return items, nil
+	}
+	return nil, errExternalSecretsNotInstalled
+}
+
+// storeRef returns the kind and name of the store an ExternalSecret reads
+// from.
+func storeRef(es *unstructured.Unstructured) (kind, name string) {
+	ref, _, _ := unstructured.NestedStringMap(es.Object, "spec", "secretStoreRef")
+	kind = ref["kind"]
+	if kind == "" {
+		kind = "SecretStore"
+	}
+	return kind, ref["name"]
+}
+
+// targetSecret returns the name of the Secret an ExternalSecret writes,
+// which defaults to the ExternalSecret's own name.
+func targetSecret(es *unstructured.Unstructured) string {
+	if name, _, _ := unstructured.NestedString(es.Object, "spec", "target", "name"); name != "" {
+		return name
+	}
+	return es.GetName()
+}
+
+// refreshInterval returns how often an ExternalSecret is synced; zero
+// means it is synced only once.
+func refreshInterval(es *unstructured.Unstructured) (time.Duration, string) {
+	v, found, _ := unstructured.NestedString(es.Object, "spec", "refreshInterval")
+	if !found || v == "" {
+		return defaultRefreshInterval, "1h"
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return defaultRefreshInterval, v
+	}
+	return d, v
+}
+
+func summarizeExternalSecret(es *unstructured.Unstructured) ExternalSecretSummary {
+	kind, name := storeRef(es)
+	_, interval := refreshInterval(es)
+	summary := ExternalSecretSummary{
+		Namespace:       es.GetNamespace(),
+		Name:            es.GetName(),
+		Store:           kind + "/" + name,
+		Target:          targetSecret(es),
+		RefreshInterval: interval,
+	}
+	status, reason, message := statusCondition(es, "Ready")
+	summary.Ready = status == "True"
+	if !summary.Ready {
+		summary.Reason, summary.Message = reason, message
+	}
+	summary.LastSync, _, _ = unstructured.NestedString(es.Object, "status", "refreshTime")
+	return summary
+}
+
+func summarizeSecretStore(store *unstructured.Unstructured) SecretStoreSummary {
+	summary := SecretStoreSummary{
+		Kind:      store.GetKind(),
+		Namespace: store.GetNamespace(),
+		Name:      store.GetName(),
+		Provider:  storeProvider(store),
+	}
+	status, reason, message := statusCondition(store, "Ready")
+	summary.Ready = status == "True"
+	summary.Reason, summary.Message = reason, message
+	return summary
+}
+
+// storeProvider returns which provider (aws, vault, gcpsm, ...) a store
+// configures.
+func storeProvider(store *unstructured.Unstructured) string {
+	provider, _, _ := unstructured.NestedMap(store.Object, "spec", "provider")
+	names := make([]string, 0, len(provider))
+	for name := range provider {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "unknown"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+func (s *Server) toolListExternalSecrets(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		secrets, err := listExternalSecretsAPI(ctx, dyn, "externalsecrets", namespace)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]ExternalSecretSummary, 0, len(secrets))
+		for i := range secrets {
+			summaries = append(summaries, summarizeExternalSecret(&secrets[i]))
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list external secrets: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+func (s *Server) toolListSecretStores(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		clusterStores, err := listExternalSecretsAPI(ctx, dyn, "clustersecretstores", "")
+		if err != nil {
+			return nil, err
+		}
+		stores, err := listExternalSecretsAPI(ctx, dyn, "secretstores", namespace)
+		if err != nil {
+			return nil, err
+		}
+		summaries := make([]SecretStoreSummary, 0, len(clusterStores)+len(stores))
+		for _, list := range [][]unstructured.Unstructured{clusterStores, stores} {
+			for i := range list {
+				summaries = append(summaries, summarizeSecretStore(&list[i]))
+			}
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list secret stores: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+// externalSecretsIndex holds the objects of one cluster that explain an
+// ExternalSecret's state, keyed for lookup.
+type externalSecretsIndex struct {
+	stores        map[string]*unstructured.Unstructured // namespace/name
+	clusterStores map[string]*unstructured.Unstructured
+	secrets       map[string]bool // namespace/name
+	// events are the Warning events of each ExternalSecret, newest first.
+	events map[types.UID][]corev1.Event
+	pods   []corev1.Pod
+}
+
+func loadExternalSecretsIndex(ctx context.Context, client kubernetes.Interface, dyn dynamic.Interface, namespace string) (*externalSecretsIndex, error) {
+	idx := &externalSecretsIndex{
+		stores:        make(map[string]*unstructured.Unstructured),
+		clusterStores: make(map[string]*unstructured.Unstructured),
+		secrets:       make(map[string]bool),
+		events:        make(map[types.UID][]corev1.Event),
+	}
+	stores, err := listExternalSecretsAPI(ctx, dyn, "secretstores", namespace)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stores {
+		idx.stores[stores[i].GetNamespace()+"/"+stores[i].GetName()] = &stores[i]
+	}
+	clusterStores, err := listExternalSecretsAPI(ctx, dyn, "clustersecretstores", "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range clusterStores {
+		idx.clusterStores[clusterStores[i].GetName()] = &clusterStores[i]
+	}
+
+	// Secret values are never read: only names are needed.
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list secrets: %w", err)
+	}
+	for _, secret := range secrets.Items {
+		idx.secrets[secret.Namespace+"/"+secret.Name] = true
+	}
+
+	events, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": "ExternalSecret", "type": corev1.EventTypeWarning}.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	for _, event := range events.Items {
+		if event.InvolvedObject.Kind != "ExternalSecret" || event.Type != corev1.EventTypeWarning {
+			continue
+		}
+		idx.events[event.InvolvedObject.UID] = append(idx.events[event.InvolvedObject.UID], event)
+	}
+	for uid := range idx.events {
+		events := idx.events[uid]
+		sort.Slice(events, func(i, j int) bool { return eventTime(events[i]).After(eventTime(events[j])) })
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: activePodsFieldSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	idx.pods = pods.Items
+	return idx, nil
+}
+
+// eventTime returns when an event last occurred.
+func eventTime(e corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	}
+	return e.CreationTimestamp.Time
+}
+
+// providerSuggestion is what to check when a store of a provider cannot
+// authenticate or read secrets.
+func providerSuggestion(provider string) string {
+	switch provider {
+	case "aws":
+		return "Check the store's IAM role (IRSA or Pod Identity) or access key Secret, and that its policy allows secretsmanager:GetSecretValue or ssm:GetParameter on the key."
+	case "vault":
+		return "Check the store's Vault auth method and role, that the token or service account is allowed to read the path, and the server URL and CA."
+	case "gcpsm":
+		return "Check the store's Workload Identity binding or service account key and that it has roles/secretmanager.secretAccessor on the secret."
+	case "azurekv":
+		return "Check the store's managed identity or service principal and that it has get permission on secrets in the Key Vault."
+	}
+	return "Check the store's provider credentials and the external-secrets controller logs."
+}
+
+// diagnoseExternalSecret reports what keeps es from syncing: a missing or
+// unready store, provider errors from its events, a missing target
+// Secret, and syncs older than its refresh interval.
+func diagnoseExternalSecret(es *unstructured.Unstructured, idx *externalSecretsIndex, now time.Time) []CertificateProblem {
+	var problems []CertificateProblem
+	add := func(severity, problem, detail, suggestion string) {
+		problems = append(problems, CertificateProblem{Severity: severity, Problem: problem, Detail: detail, Suggestion: suggestion})
+	}
+
+	kind, name := storeRef(es)
+	store := idx.clusterStores[name]
+	if kind == "SecretStore" {
+		store = idx.stores[es.GetNamespace()+"/"+name]
+	}
+	if store == nil {
+		suggestion := fmt.Sprintf("Create %s %q or fix spec.secretStoreRef.", kind, name)
+		if kind == "SecretStore" {
+			suggestion += " SecretStores only serve ExternalSecrets in their own namespace; use kind: ClusterSecretStore for a cluster-wide store."
+		}
+		add("critical", fmt.Sprintf("%s %q not found", kind, name), "", suggestion)
+	} else if status, reason, message := statusCondition(store, "Ready"); status != "True" {
+		add("critical", fmt.Sprintf("%s %q is not ready", kind, name), joinReason(reason, message), providerSuggestion(storeProvider(store)))
+	}
+
+	readyStatus, readyReason, readyMessage := statusCondition(es, "Ready")
+	if readyStatus != "True" {
+		detail := joinReason(readyReason, readyMessage)
+		// The Ready condition only says the sync failed; the provider's
+		// error is in the ExternalSecret's events.
+		if events := idx.events[es.GetUID()]; len(events) > 0 {
+			detail = joinReason(events[0].Reason, events[0].Message)
+		}
+		suggestion := "Check that the remote keys in spec.data and spec.dataFrom exist in the provider and that the store may read them."
+		if store != nil {
+			suggestion = providerSuggestion(storeProvider(store)) + " " + suggestion
+		}
+		add("critical", "Sync failed", detail, suggestion)
+	}
+
+	target := targetSecret(es)
+	if !idx.secrets[es.GetNamespace()+"/"+target] {
+		policy, _, _ := unstructured.NestedString(es.Object, "spec", "target", "creationPolicy")
+		suggestion := "The operator creates it after the first successful sync; fix the problems above."
+		if policy == "Merge" || policy == "None" {
+			suggestion = fmt.Sprintf("creationPolicy is %s, so the operator does not create the Secret; create it or set creationPolicy: Owner.", policy)
+		}
+		add("critical", fmt.Sprintf("Target Secret %q does not exist", target), "", suggestion)
+	}
+
+	interval, intervalText := refreshInterval(es)
+	if refreshed, _, _ := unstructured.NestedString(es.Object, "status", "refreshTime"); refreshed != "" && interval > 0 && readyStatus == "True" {
+		if t, err := time.Parse(time.RFC3339, refreshed); err == nil && now.Sub(t) > 2*interval {
+			add("warning", fmt.Sprintf("Last synced %s ago", formatDuration(now.Sub(t))),
+				fmt.Sprintf("The refresh interval is %s.", intervalText),
+				"Check the external-secrets controller is running, or use refresh_external_secret to sync now.")
+		}
+	}
+	return problems
+}
+
+// podUsesSecret reports whether a pod reads a Secret through env, envFrom,
+// or a volume.
+func podUsesSecret(pod *corev1.Pod, secret string) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.Secret != nil && v.Secret.SecretName == secret {
+			return true
+		}
+		if v.Projected != nil {
+			for _, source := range v.Projected.Sources {
+				if source.Secret != nil && source.Secret.Name == secret {
+					return true
+				}
+			}
+		}
+	}
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, c := range containers {
+		for _, from := range c.EnvFrom {
+			if from.SecretRef != nil && from.SecretRef.Name == secret {
+				return true
+			}
+		}
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == secret {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// podFailure returns why a pod is failing to start or keeps crashing, or
+// "" if it is not.
+func podFailure(pod *corev1.Pod) string {
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.State.Waiting == nil {
+			continue
+		}
+		switch cs.State.Waiting.Reason {
+		case "CrashLoopBackOff", "CreateContainerConfigError", "ContainerCreating":
+			return cs.State.Waiting.Reason
+		}
+	}
+	return ""
+}
+
+// affectedPods lists the failing pods that use the target Secret of es.
+func affectedPods(es *unstructured.Unstructured, idx *externalSecretsIndex) []string {
+	target := targetSecret(es)
+	var pods []string
+	for i := range idx.pods {
+		pod := &idx.pods[i]
+		if pod.Namespace != es.GetNamespace() || !podUsesSecret(pod, target) {
+			continue
+		}
+		if reason := podFailure(pod); reason != "" {
+			pods = append(pods, fmt.Sprintf("%s (%s)", pod.Name, reason))
+		}
+	}
+	return pods
+}
+
+func (s *Server) toolDiagnoseExternalSecrets(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	name, _ := args["name"].(string)
+	if name != "" && namespace == "" {
+		return "error: namespace is required with name", true
+	}
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		secrets, err := listExternalSecretsAPI(ctx, dyn, "externalsecrets", namespace)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := loadExternalSecretsIndex(ctx, client, dyn, namespace)
+		if err != nil {
+			return nil, err
+		}
+		result := &externalSecretDiagnoses{}
+		for i := range secrets {
+			es := &secrets[i]
+			if name != "" && es.GetName() != name {
+				continue
+			}
+			result.Checked++
+			problems := diagnoseExternalSecret(es, idx, now)
+			if len(problems) == 0 {
+				continue
+			}
+			ready, _, _ := statusCondition(es, "Ready")
+			kind, store := storeRef(es)
+			result.Diagnoses = append(result.Diagnoses, ExternalSecretDiagnosis{
+				Namespace: es.GetNamespace(), Name: es.GetName(),
+				Store: kind + "/" + store, Target: targetSecret(es), Ready: ready == "True",
+				Problems: problems, AffectedPods: affectedPods(es, idx),
+			})
+		}
+		return result, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to diagnose external secrets: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# External Secrets Diagnosis\n")
+	checked := 0
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		d, _ := r.Result.(*externalSecretDiagnoses)
+		checked += d.Checked
+		if len(d.Diagnoses) == 0 {
+			_, _ = fmt.Fprintf(&sb, "✅ %d external secrets checked, no problems found\n", d.Checked)
+			continue
+		}
+		_, _ = fmt.Fprintf(&sb, "%d of %d external secrets have problems:\n", len(d.Diagnoses), d.Checked)
+		for _, diag := range d.Diagnoses {
+			state := "synced"
+			if !diag.Ready {
+				state = "not synced"
+			}
+			_, _ = fmt.Fprintf(&sb, "\n📛 %s/%s (%s → Secret %s, %s)\n", diag.Namespace, diag.Name, diag.Store, diag.Target, state)
+			for _, p := range diag.Problems {
+				_, _ = fmt.Fprintf(&sb, "   - [%s] %s\n", p.Severity, p.Problem)
+				if p.Detail != "" {
+					_, _ = fmt.Fprintf(&sb, "     %s\n", p.Detail)
+				}
+				if p.Suggestion != "" {
+					_, _ = fmt.Fprintf(&sb, "     Fix: %s\n", p.Suggestion)
+				}
+			}
+			if len(diag.AffectedPods) > 0 {
+				_, _ = fmt.Fprintf(&sb, "   Failing pods using this Secret: %s\n", strings.Join(diag.AffectedPods, ", "))
+			}
+		}
+	}
+	if name != "" && checked == 0 {
+		return fmt.Sprintf("ExternalSecret %s/%s not found in any cluster.\n\n%s", namespace, name, sb.String()), true
+	}
+	return sb.String(), false
+}
+
+// toolRefreshExternalSecret makes the operator sync an ExternalSecret now
+// by changing its force-sync annotation.
+func (s *Server) toolRefreshExternalSecret(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "error: name is required", true
+	}
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if namespace == "" {
+		return "error: namespace is required", true
+	}
+
+	dyn, err := s.getDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, forceSyncAnnotation, strconv.FormatInt(time.Now().Unix(), 10))
+	var es *unstructured.Unstructured
+	for _, version := range externalSecretsVersions {
+		es, err = dyn.Resource(externalSecretsGVR(version, "externalsecrets")).Namespace(namespace).
+			Patch(ctx, name, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		if !apierrors.IsNotFound(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Sprintf("Failed to refresh external secret: %v", err), true
+	}
+
+	if approval.IsDryRun(ctx) {
+		return fmt.Sprintf("Would refresh ExternalSecret `%s/%s`.\n", namespace, name), false
+	}
+	return fmt.Sprintf("Requested a refresh of ExternalSecret `%s/%s`. The operator syncs Secret `%s` from the provider now; use `diagnose_external_secrets` if it does not become ready.\n",
+		namespace, name, targetSecret(es)), false
+}
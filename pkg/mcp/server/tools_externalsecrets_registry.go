@@ -0,0 +1,99 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "list_external_secrets",
+		Description: "List External Secrets Operator ExternalSecrets with their store, target Secret, refresh interval, sync status, and last sync time, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to list ExternalSecrets from (all namespaces if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListExternalSecrets(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "list_secret_stores",
+		Description: "List External Secrets Operator ClusterSecretStores and SecretStores with their provider (aws, vault, gcpsm, azurekv, ...) and readiness, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to list SecretStores from (all namespaces if not specified). ClusterSecretStores are always listed",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListSecretStores(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "diagnose_external_secrets",
+		Description: "Find why ExternalSecrets do not sync: missing or unready stores, provider errors such as access denied or missing keys, missing target Secrets, and stale syncs, with suggested fixes and the crashing or stuck pods that use each Secret, per cluster. Failed syncs are a common cause of CrashLoopBackOff and CreateContainerConfigError",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to check (all namespaces if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Check only this ExternalSecret (requires namespace)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolDiagnoseExternalSecrets(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "refresh_external_secret",
+		Description: "Make the External Secrets Operator sync an ExternalSecret from its provider now instead of waiting for its refresh interval, by updating its force-sync annotation",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the ExternalSecret",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the ExternalSecret",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolRefreshExternalSecret(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+// externalSecretsListKinds registers every External Secrets API version
+// with the fake dynamic client.
+func externalSecretsListKinds() map[schema.GroupVersionResource]string {
+	kinds := make(map[schema.GroupVersionResource]string)
+	for _, version := range externalSecretsVersions {
+		kinds[externalSecretsGVR(version, "externalsecrets")] = "ExternalSecretList"
+		kinds[externalSecretsGVR(version, "secretstores")] = "SecretStoreList"
+		kinds[externalSecretsGVR(version, "clustersecretstores")] = "ClusterSecretStoreList"
+	}
+	return kinds
+}
+
+func esObject(kind, namespace, name string, spec map[string]interface{}, conditions ...interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "external-secrets.io/v1", "kind": kind, "spec": spec,
+		"status": map[string]interface{}{"conditions": conditions},
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(kind + "/" + name))
+	return obj
+}
+
+// externalSecret returns an ExternalSecret in namespace shop that syncs
+// Secret name-credentials from a store.
+func externalSecret(name, storeKind, store string, conditions ...interface{}) *unstructured.Unstructured {
+	return esObject("ExternalSecret", "shop", name, map[string]interface{}{
+		"refreshInterval": "1h",
+		"secretStoreRef":  map[string]interface{}{"kind": storeKind, "name": store},
+		"target":          map[string]interface{}{"name": name + "-credentials"},
+	}, conditions...)
+}
+
+func readyCondition(status, reason, message string) interface{} {
+	return map[string]interface{}{"type": "Ready", "status": status, "reason": reason, "message": message}
+}
+
+// podUsingSecret returns a pod in namespace shop that reads secret with
+// envFrom, waiting with reason.
+func podUsingSecret(name, secret, reason string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:    "app",
+			EnvFrom: []corev1.EnvFromSource{{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: secret}}}},
+		}}},
+		Status: corev1.PodStatus{Phase: corev1.PodPending, ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "app",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: `secret "` + secret + `" not found`}},
+		}}},
+	}
+}
+
+func TestDiagnoseExternalSecretsPerCluster(t *testing.T) {
+	awsStore := esObject("SecretStore", "shop", "aws", map[string]interface{}{
+		"provider": map[string]interface{}{"aws": map[string]interface{}{"service": "SecretsManager"}},
+	}, readyCondition("False", "InvalidProviderConfig", "unable to validate store: AccessDeniedException"))
+	vaultStore := esObject("ClusterSecretStore", "", "vault", map[string]interface{}{
+		"provider": map[string]interface{}{"vault": map[string]interface{}{"server": "https://vault:8200"}},
+	}, readyCondition("True", "Valid", "store validated"))
+
+	db := externalSecret("db", "SecretStore", "aws", readyCondition("False", "SecretSyncedError", "could not get secret data from provider"))
+	stale := externalSecret("cache", "ClusterSecretStore", "vault", readyCondition("True", "SecretSynced", "secret synced"))
+	_ = unstructured.SetNestedField(stale.Object, time.Now().Add(-5*time.Hour).UTC().Format(time.RFC3339), "status", "refreshTime")
+	synced := externalSecret("api", "ClusterSecretStore", "vault", readyCondition("True", "SecretSynced", "secret synced"))
+	_ = unstructured.SetNestedField(synced.Object, time.Now().UTC().Format(time.RFC3339), "status", "refreshTime")
+	orphan := externalSecret("search", "ClusterSecretStore", "gcp", readyCondition("False", "SecretSyncedError", "could not get ClusterSecretStore"))
+
+	alphaDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), externalSecretsListKinds(),
+		awsStore, vaultStore, db, stale, synced, orphan)
+	alpha := k8sfake.NewSimpleClientset(
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "cache-credentials", Namespace: "shop"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "api-credentials", Namespace: "shop"}},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "db.1", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "ExternalSecret", Name: "db", Namespace: "shop", UID: db.GetUID()},
+			Type:           corev1.EventTypeWarning, Reason: "UpdateFailed",
+			Message:       "error retrieving secret at .data[0], key: prod/db, err: Secret does not exist",
+			LastTimestamp: metav1.NewTime(time.Now()),
+		},
+		podUsingSecret("checkout-7d9f", "db-credentials", "CreateContainerConfigError"),
+		podUsingSecret("web-5c4b", "api-credentials", "CrashLoopBackOff"),
+	)
+	betaDyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), externalSecretsListKinds())
+	betaDyn.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+	dyns := map[string]dynamic.Interface{"alpha": alphaDyn, "beta": betaDyn}
+	clients := map[string]kubernetes.Interface{"alpha": alpha, "beta": k8sfake.NewSimpleClientset()}
+	infos := []cluster.ClusterInfo{{Name: "alpha", Context: "alpha"}, {Name: "beta", Context: "beta"}}
+	server := &Server{
+		discoverer:           stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory:        func(name string) (kubernetes.Interface, error) { return clients[name], nil },
+		dynamicClientFactory: func(name string) (dynamic.Interface, error) { return dyns[name], nil },
+	}
+
+	result, rpcErr := callTool(t, server, "diagnose_external_secrets", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "3 of 4 external secrets have problems:")
+	assert.Contains(t, text, "📛 shop/db (SecretStore/aws → Secret db-credentials, not synced)")
+	assert.Contains(t, text, `[critical] SecretStore "aws" is not ready`)
+	assert.Contains(t, text, "InvalidProviderConfig: unable to validate store: AccessDeniedException")
+	assert.Contains(t, text, "UpdateFailed: error retrieving secret at .data[0], key: prod/db, err: Secret does not exist", "the provider error comes from the events")
+	assert.Contains(t, text, "secretsmanager:GetSecretValue")
+	assert.Contains(t, text, `[critical] Target Secret "db-credentials" does not exist`)
+	assert.Contains(t, text, "Failing pods using this Secret: checkout-7d9f (CreateContainerConfigError)")
+	assert.Contains(t, text, "[warning] Last synced 5h ago")
+	assert.Contains(t, text, `[critical] ClusterSecretStore "gcp" not found`)
+	assert.NotContains(t, text, "shop/api ", "synced secrets are not reported")
+	assert.Contains(t, text, "## beta\n\nerror: the External Secrets Operator is not installed")
+
+	result, rpcErr = callTool(t, server, "diagnose_external_secrets", map[string]interface{}{"namespace": "shop", "name": "missing"})
+	require.Nil(t, rpcErr)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "ExternalSecret shop/missing not found in any cluster")
+}
+
+func TestListSecretStoresFallsBackToV1beta1(t *testing.T) {
+	store := esObject("ClusterSecretStore", "", "vault", map[string]interface{}{
+		"provider": map[string]interface{}{"vault": map[string]interface{}{}},
+	}, readyCondition("True", "Valid", ""))
+	store.SetAPIVersion("external-secrets.io/v1beta1")
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), externalSecretsListKinds(), store)
+	dyn.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetResource().Version == "v1" {
+			return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+		}
+		return false, nil, nil
+	})
+
+	items, err := listExternalSecretsAPI(context.Background(), dyn, "clustersecretstores", "")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, SecretStoreSummary{Kind: "ClusterSecretStore", Name: "vault", Provider: "vault", Ready: true, Reason: "Valid"}, summarizeSecretStore(&items[0]))
+}
+
+func TestRefreshExternalSecret(t *testing.T) {
+	es := externalSecret("db", "SecretStore", "aws")
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), externalSecretsListKinds(), es)
+	server := &Server{dynamicClientFactory: func(string) (dynamic.Interface, error) { return dyn, nil }}
+
+	text, isErr := server.toolRefreshExternalSecret(context.Background(), map[string]interface{}{"namespace": "shop", "name": "db"})
+	require.False(t, isErr, text)
+	assert.Contains(t, text, "Requested a refresh of ExternalSecret `shop/db`. The operator syncs Secret `db-credentials`")
+	got, err := dyn.Resource(externalSecretsGVR("v1", "externalsecrets")).Namespace("shop").Get(context.Background(), "db", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, got.GetAnnotations()[forceSyncAnnotation])
+
+	text, isErr = server.toolRefreshExternalSecret(context.Background(), map[string]interface{}{"namespace": "shop", "name": "nope"})
+	assert.True(t, isErr)
+	assert.Contains(t, text, "Failed to refresh external secret")
+}
+
+func TestFindPodIssuesPointsToExternalSecrets(t *testing.T) {
+	client := k8sfake.NewSimpleClientset(podUsingSecret("checkout-7d9f", "db-credentials", "CreateContainerConfigError"))
+	server := &Server{clientFactory: func(string) (kubernetes.Interface, error) { return client, nil }}
+
+	result, rpcErr := callTool(t, server, "find_pod_issues", map[string]interface{}{"namespace": "shop"})
+	require.Nil(t, rpcErr)
+	assert.Contains(t, result.Content[0].Text, "run diagnose_external_secrets")
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/tools/upgrades"
+)
+
+// clusterPlaceholder in a values file path is replaced by each cluster's
+// name, for per-cluster overrides such as envs/{cluster}/values.yaml.
+const clusterPlaceholder = "{cluster}"
+
+// maxValueDisplay is how much of a value the markdown report shows.
+const maxValueDisplay = 80
+
+type valuesReader interface {
+	ReadFilesFromGit(ctx context.Context, source gitops.ManifestSource, paths []string) (map[string][]byte, error)
+	Cleanup()
+}
+
+func (s *Server) newValuesReader() valuesReader {
+	if s.valuesReaderFactory != nil {
+		return s.valuesReaderFactory()
+	}
+	return gitops.NewManifestReader()
+}
+
+// helmValuesDrift is the result of comparing a release's values on one
+// cluster with git.
+type helmValuesDrift struct {
+	Cluster     string                    `json:"cluster"`
+	Drifted     bool                      `json:"drifted"`
+	Revision    int                       `json:"revision,omitempty"`
+	Chart       string                    `json:"chart,omitempty"`
+	ValuesFiles []string                  `json:"valuesFiles"`
+	Skipped     []string                  `json:"skippedFiles,omitempty"`
+	Differences []gitops.ValuesDifference `json:"differences"`
+	Error       string                    `json:"error,omitempty"`
+}
+
+func (s *Server) toolDetectHelmValuesDrift(ctx context.Context, args map[string]interface{}) (string, bool) {
+	repoURL, _ := args["repo_url"].(string)
+	path, _ := args["path"].(string)
+	branch, _ := args["branch"].(string)
+	release, _ := args["release"].(string)
+	if repoURL == "" {
+		return "repo_url is required", true
+	}
+	if release == "" {
+		return "release is required", true
+	}
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	var valuesFiles []string
+	if v, ok := args["values_files"].([]interface{}); ok {
+		for _, f := range v {
+			if name, _ := f.(string); name != "" {
+				valuesFiles = append(valuesFiles, name)
+			}
+		}
+	}
+	if len(valuesFiles) == 0 {
+		return "values_files is required", true
+	}
+
+	var clusters []string
+	if v, ok := args["clusters"].([]interface{}); ok && len(v) > 0 {
+		for _, c := range v {
+			name, _ := c.(string)
+			clusters = append(clusters, name)
+		}
+	} else {
+		discovered, err := s.discoverer.DiscoverClusters("all")
+		if err != nil {
+			return fmt.Sprintf("Failed to discover clusters: %v", err), true
+		}
+		for _, c := range discovered {
+			clusters = append(clusters, c.Name)
+		}
+	}
+	if len(clusters) == 0 {
+		return "error: no clusters to check", true
+	}
+	sort.Strings(clusters)
+
+	// Read every values file any cluster uses in one clone.
+	var paths []string
+	seen := map[string]bool{}
+	for _, cluster := range clusters {
+		for _, f := range clusterValuesFiles(valuesFiles, cluster) {
+			if !seen[f] {
+				seen[f] = true
+				paths = append(paths, f)
+			}
+		}
+	}
+	reader := s.newValuesReader()
+	defer reader.Cleanup()
+	files, err := reader.ReadFilesFromGit(ctx, gitops.ManifestSource{Repo: repoURL, Path: path, Branch: branch}, paths)
+	if err != nil {
+		return fmt.Sprintf("Failed to read values files from git: %v", err), true
+	}
+	values := make(map[string]map[string]interface{}, len(files))
+	for _, f := range valuesFiles {
+		if !strings.Contains(f, clusterPlaceholder) && files[f] == nil {
+			return fmt.Sprintf("Values file %s not found in %s", f, repoURL), true
+		}
+	}
+	for name, data := range files {
+		var v map[string]interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return fmt.Sprintf("Invalid values file %s: %v", name, err), true
+		}
+		values[name] = v
+	}
+
+	results := make([]helmValuesDrift, len(clusters))
+	sem := make(chan struct{}, maxConcurrentClusterOperations)
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, cluster string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = s.helmValuesDrift(ctx, cluster, namespace, release, valuesFiles, values)
+		}(i, cluster)
+	}
+	wg.Wait()
+
+	return renderHelmValuesDrift(repoURL, path, branch, namespace, release, valuesFiles, results), false
+}
+
+// clusterValuesFiles returns the values files of cluster, in order.
+func clusterValuesFiles(valuesFiles []string, cluster string) []string {
+	files := make([]string, len(valuesFiles))
+	for i, f := range valuesFiles {
+		files[i] = strings.ReplaceAll(f, clusterPlaceholder, cluster)
+	}
+	return files
+}
+
+// helmValuesDrift compares the user-supplied values of the newest revision
+// of release on cluster with its values files merged in order. Per-cluster
+// files missing from git are skipped.
+func (s *Server) helmValuesDrift(ctx context.Context, cluster, namespace, release string, valuesFiles []string, values map[string]map[string]interface{}) helmValuesDrift {
+	result := helmValuesDrift{Cluster: cluster, Differences: []gitops.ValuesDifference{}}
+	var layers []map[string]interface{}
+	for _, f := range clusterValuesFiles(valuesFiles, cluster) {
+		v, ok := values[f]
+		if !ok {
+			result.Skipped = append(result.Skipped, f)
+			continue
+		}
+		result.ValuesFiles = append(result.ValuesFiles, f)
+		layers = append(layers, v)
+	}
+
+	client, err := s.getClientForCluster(cluster)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to create client: %v", err)
+		return result
+	}
+	rel, err := upgrades.LatestHelmRelease(ctx, client, namespace, release)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if rel == nil {
+		result.Error = fmt.Sprintf("release %s/%s is not installed", namespace, release)
+		return result
+	}
+	result.Revision = rel.Revision
+	result.Chart = strings.TrimSpace(rel.Chart + " " + rel.Version)
+	result.Differences = gitops.DiffValues(gitops.MergeValues(layers...), rel.Values)
+	result.Drifted = len(result.Differences) > 0
+	return result
+}
+
+func renderHelmValuesDrift(repoURL, path, branch, namespace, release string, valuesFiles []string, results []helmValuesDrift) string {
+	var sb strings.Builder
+	sb.WriteString("# Helm Values Drift\n\n")
+	_, _ = fmt.Fprintf(&sb, "**Repository:** %s\n", repoURL)
+	if path != "" {
+		_, _ = fmt.Fprintf(&sb, "**Path:** %s\n", path)
+	}
+	if branch != "" {
+		_, _ = fmt.Fprintf(&sb, "**Branch:** %s\n", branch)
+	}
+	_, _ = fmt.Fprintf(&sb, "**Release:** %s/%s\n", namespace, release)
+	_, _ = fmt.Fprintf(&sb, "**Values Files:** %s\n", strings.Join(valuesFiles, ", "))
+
+	drifted, failed := 0, 0
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			failed++
+		case r.Drifted:
+			drifted++
+		}
+	}
+	_, _ = fmt.Fprintf(&sb, "**Clusters:** %d checked, %d drifted, %d failed\n", len(results), drifted, failed)
+
+	for _, r := range results {
+		switch {
+		case r.Error != "":
+			_, _ = fmt.Fprintf(&sb, "\n## ❌ %s\n\n%s\n", r.Cluster, r.Error)
+			continue
+		case r.Drifted:
+			_, _ = fmt.Fprintf(&sb, "\n## ⚠️ %s\n\n", r.Cluster)
+			_, _ = fmt.Fprintf(&sb, "Revision %d (%s): %d value(s) differ from git\n\n", r.Revision, r.Chart, len(r.Differences))
+		default:
+			_, _ = fmt.Fprintf(&sb, "\n## ✅ %s\n\n", r.Cluster)
+			_, _ = fmt.Fprintf(&sb, "Revision %d (%s): values match git\n", r.Revision, r.Chart)
+		}
+		for _, d := range r.Differences {
+			switch d.Type {
+			case gitops.ValuesDiffChanged:
+				_, _ = fmt.Fprintf(&sb, "- `%s`: git `%s`, cluster `%s`\n", d.Key, displayValue(d.GitValue), displayValue(d.ClusterValue))
+			case gitops.ValuesDiffMissing:
+				_, _ = fmt.Fprintf(&sb, "- `%s`: set in git (`%s`), not in the release\n", d.Key, displayValue(d.GitValue))
+			case gitops.ValuesDiffExtra:
+				_, _ = fmt.Fprintf(&sb, "- `%s`: set in the release (`%s`), not in git\n", d.Key, displayValue(d.ClusterValue))
+			}
+		}
+		if len(r.Skipped) > 0 {
+			_, _ = fmt.Fprintf(&sb, "\nNot in git, skipped: %s\n", strings.Join(r.Skipped, ", "))
+		}
+	}
+
+	// Also return JSON for programmatic parsing
+	jsonBytes, _ := json.MarshalIndent(map[string]interface{}{
+		"release":   release,
+		"namespace": namespace,
+		"drifted":   drifted > 0,
+		"clusters":  results,
+	}, "", "  ")
+	sb.WriteString("\n```json\n")
+	sb.Write(jsonBytes)
+	sb.WriteString("\n```\n")
+	return sb.String()
+}
+
+// displayValue renders a Helm value for the markdown report, shortened to
+// maxValueDisplay.
+func displayValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	text := string(data)
+	if len(text) > maxValueDisplay {
+		text = text[:maxValueDisplay-3] + "..."
+	}
+	return text
+}
@@ -0,0 +1,51 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterCachedTool(Tool{
+		Name:        "detect_helm_values_drift",
+		Description: "Compare the Helm values files committed to git with the user-supplied values of a deployed release on each cluster, and report the keys that differ, are missing from the release, or were set outside git (e.g. with --set)",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"repo_url": {
+					Type:        "string",
+					Description: "Git repository URL (e.g., https://github.com/org/manifests)",
+				},
+				"path": {
+					Type:        "string",
+					Description: "Directory within the repository the values files are relative to",
+				},
+				"branch": {
+					Type:        "string",
+					Description: "Git branch to use (default: main)",
+				},
+				"values_files": {
+					Type:        "array",
+					Description: "Values files merged in order, as with helm -f. {cluster} in a path is replaced by each cluster's name (e.g. envs/{cluster}/values.yaml); such files missing from git are skipped",
+					Items:       &Items{Type: "string"},
+				},
+				"release": {
+					Type:        "string",
+					Description: "Name of the Helm release",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the Helm release (default: default)",
+				},
+				"clusters": {
+					Type:        "array",
+					Description: "Clusters to check (default all discovered clusters)",
+					Items:       &Items{Type: "string"},
+				},
+			},
+			Required: []string{"repo_url", "values_files", "release"},
+		},
+	},
+		scanCacheTTL,
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolDetectHelmValuesDrift(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/gitops"
+)
+
+type fakeValuesReader struct {
+	files   map[string][]byte
+	source  gitops.ManifestSource
+	paths   []string
+	cleaned bool
+}
+
+func (f *fakeValuesReader) ReadFilesFromGit(_ context.Context, source gitops.ManifestSource, paths []string) (map[string][]byte, error) {
+	f.source = source
+	f.paths = paths
+	files := map[string][]byte{}
+	for _, p := range paths {
+		if data, ok := f.files[p]; ok {
+			files[p] = data
+		}
+	}
+	return files, nil
+}
+
+func (f *fakeValuesReader) Cleanup() {
+	f.cleaned = true
+}
+
+// helmReleaseSecret returns the Secret of revision of the release shop in
+// namespace shop, with values as its user-supplied values.
+func helmReleaseSecret(t *testing.T, revision int, values map[string]interface{}) *corev1.Secret {
+	t.Helper()
+	secret := newHelmSecret(t, map[string]interface{}{
+		"name":    "shop",
+		"version": float64(revision),
+		"info":    map[string]interface{}{"status": "deployed"},
+		"chart":   map[string]interface{}{"metadata": map[string]interface{}{"name": "shop", "version": "1.4.0"}},
+		"config":  values,
+	}, true)
+	secret.Name = fmt.Sprintf("sh.helm.release.v1.shop.v%d", revision)
+	secret.Namespace = "shop"
+	secret.Labels = map[string]string{"owner": "helm", "name": "shop"}
+	return secret
+}
+
+func TestDetectHelmValuesDrift(t *testing.T) {
+	reader := &fakeValuesReader{files: map[string][]byte{
+		"values.yaml":           []byte("image:\n  tag: \"2.0\"\nreplicaCount: 2\n"),
+		"envs/prod/values.yaml": []byte("replicaCount: 5\n"),
+	}}
+	clients := map[string][]runtime.Object{
+		"prod": {
+			helmReleaseSecret(t, 1, map[string]interface{}{"image": map[string]interface{}{"tag": "1.0"}}),
+			helmReleaseSecret(t, 2, map[string]interface{}{"image": map[string]interface{}{"tag": "2.0"}, "replicaCount": 5}),
+		},
+		"dev": {
+			helmReleaseSecret(t, 3, map[string]interface{}{"image": map[string]interface{}{"tag": "2.1"}, "debug": true}),
+		},
+		"staging": nil,
+	}
+	s := &Server{
+		valuesReaderFactory: func() valuesReader { return reader },
+		clientFactory: func(cluster string) (kubernetes.Interface, error) {
+			return k8sfake.NewSimpleClientset(clients[cluster]...), nil
+		},
+	}
+
+	out, isErr := s.toolDetectHelmValuesDrift(context.Background(), map[string]interface{}{
+		"repo_url":     "https://github.com/org/fleet",
+		"path":         "charts/shop",
+		"values_files": []interface{}{"values.yaml", "envs/{cluster}/values.yaml"},
+		"release":      "shop",
+		"namespace":    "shop",
+		"clusters":     []interface{}{"prod", "dev", "staging"},
+	})
+	require.False(t, isErr, out)
+	assert.True(t, reader.cleaned)
+	assert.Equal(t, "charts/shop", reader.source.Path)
+	assert.ElementsMatch(t, []string{"values.yaml", "envs/dev/values.yaml", "envs/prod/values.yaml", "envs/staging/values.yaml"}, reader.paths)
+
+	assert.Contains(t, out, "**Clusters:** 3 checked, 1 drifted, 1 failed")
+	assert.Contains(t, out, "## ✅ prod\n\nRevision 2 (shop 1.4.0): values match git")
+	assert.Contains(t, out, "## ⚠️ dev")
+	assert.Contains(t, out, "- `image.tag`: git `\"2.0\"`, cluster `\"2.1\"`")
+	assert.Contains(t, out, "- `replicaCount`: set in git (`2`), not in the release")
+	assert.Contains(t, out, "- `debug`: set in the release (`true`), not in git")
+	assert.Contains(t, out, "Not in git, skipped: envs/dev/values.yaml")
+	assert.Contains(t, out, "## ❌ staging\n\nrelease shop/shop is not installed")
+	assert.Contains(t, out, `"drifted": true`)
+}
+
+func TestDetectHelmValuesDrift_Validation(t *testing.T) {
+	s := &Server{valuesReaderFactory: func() valuesReader { return &fakeValuesReader{} }}
+	base := map[string]interface{}{
+		"repo_url":     "https://github.com/org/fleet",
+		"values_files": []interface{}{"values.yaml"},
+		"release":      "shop",
+		"clusters":     []interface{}{"prod"},
+	}
+
+	for _, missing := range []string{"repo_url", "values_files", "release"} {
+		args := map[string]interface{}{}
+		for k, v := range base {
+			if k != missing {
+				args[k] = v
+			}
+		}
+		out, isErr := s.toolDetectHelmValuesDrift(context.Background(), args)
+		assert.True(t, isErr)
+		assert.Contains(t, out, missing+" is required")
+	}
+
+	out, isErr := s.toolDetectHelmValuesDrift(context.Background(), base)
+	assert.True(t, isErr)
+	assert.Contains(t, out, "Values file values.yaml not found")
+}
@@ -4,6 +4,12 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/provenance"
 )
 
 func (s *Server) getDynamicClientForCluster(clusterName string) (dynamic.Interface, error) {
@@ -15,9 +21,13 @@ func (s *Server) getDynamicClientForCluster(clusterName string) (dynamic.Interfa
 		loadingRules.ExplicitPath = s.kubeconfig
 	}
 
+	contextName, err := s.contextForCluster(clusterName)
+	if err != nil {
+		return nil, err
+	}
 	configOverrides := &clientcmd.ConfigOverrides{}
-	if clusterName != "" {
-		configOverrides.CurrentContext = clusterName
+	if contextName != "" {
+		configOverrides.CurrentContext = contextName
 	}
 
 	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
@@ -26,12 +36,16 @@ func (s *Server) getDynamicClientForCluster(clusterName string) (dynamic.Interfa
 		return nil, err
 	}
 
-	return dynamic.NewForConfig(config)
+	return dynamic.NewForConfig(s.prepareConfig(config))
 }
 
 func (s *Server) getRestConfigForCluster(clusterName string) (*rest.Config, error) {
 	if s.restConfigFactory != nil {
-		return s.restConfigFactory(clusterName)
+		config, err := s.restConfigFactory(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		return s.prepareConfig(config), nil
 	}
 
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
@@ -39,11 +53,60 @@ func (s *Server) getRestConfigForCluster(clusterName string) (*rest.Config, erro
 		loadingRules.ExplicitPath = s.kubeconfig
 	}
 
+	contextName, err := s.contextForCluster(clusterName)
+	if err != nil {
+		return nil, err
+	}
 	configOverrides := &clientcmd.ConfigOverrides{}
-	if clusterName != "" {
-		configOverrides.CurrentContext = clusterName
+	if contextName != "" {
+		configOverrides.CurrentContext = contextName
 	}
 
-	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		loadingRules, configOverrides).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return s.prepareConfig(config), nil
+}
+
+// contextForCluster returns the kubeconfig context of the cluster a tool
+// was given by context, alias, kubeconfig cluster, or API server URL. An
+// empty name stays empty, for the current context.
+func (s *Server) contextForCluster(clusterName string) (string, error) {
+	if clusterName == "" {
+		return "", nil
+	}
+	resolver, err := multicluster.LoadResolver(s.kubeconfig)
+	if err != nil {
+		return "", err
+	}
+	return resolver.Resolve(clusterName)
+}
+
+// prepareConfig applies the server's impersonation (or the HTTP caller's),
+// namespace guardrails, change journal, provenance read log, and approval
+// dry-run transport to a freshly loaded REST config. Every client builder
+// must call it.
+func (s *Server) prepareConfig(config *rest.Config) *rest.Config {
+	config = s.namespacePolicy.Wrap(s.withImpersonation(config))
+	if s.tokenVerifier != nil {
+		config = wrapCallerImpersonation(config)
+	}
+	if s.recordChanges {
+		config = journal.Wrap(config)
+	}
+	if s.getProvenanceSigner() != nil {
+		config = provenance.Wrap(config)
+	}
+	if s.getApprovalGate().Enabled() {
+		config = approval.Wrap(config)
+	}
+	return config
+}
+
+// SetNamespacePolicy replaces the namespace guardrails. It must be called
+// before Run.
+func (s *Server) SetNamespacePolicy(policy guardrail.NamespacePolicy) {
+	s.namespacePolicy = policy
 }
@@ -0,0 +1,461 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cron"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// instantiateAnnotation marks Jobs created from a CronJob by hand, as
+// kubectl create job --from does.
+const instantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+
+// Statuses of a Job run.
+const (
+	jobSucceeded = "Succeeded"
+	jobFailed    = "Failed"
+	jobRunning   = "Running"
+	jobPending   = "Pending"
+)
+
+// JobRun summarizes one Job created by a CronJob.
+type JobRun struct {
+	Name           string `json:"name"`
+	Status         string `json:"status"`
+	StartTime      string `json:"startTime,omitempty"`
+	CompletionTime string `json:"completionTime,omitempty"`
+	Manual         bool   `json:"manual,omitempty"`
+}
+
+// CronJobSummary is the schedule and run status of a CronJob.
+type CronJobSummary struct {
+	Namespace          string  `json:"namespace"`
+	Name               string  `json:"name"`
+	Schedule           string  `json:"schedule"`
+	TimeZone           string  `json:"timeZone,omitempty"`
+	Suspended          bool    `json:"suspended"`
+	Active             int     `json:"active"`
+	LastScheduleTime   string  `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime string  `json:"lastSuccessfulTime,omitempty"`
+	NextRun            string  `json:"nextRun,omitempty"`
+	LastRun            *JobRun `json:"lastRun,omitempty"`
+	Error              string  `json:"error,omitempty"`
+}
+
+func (s *Server) toolGetCronJobs(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		cronJobs, err := client.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cronjobs: %w", err)
+		}
+		jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+		summaries := make([]CronJobSummary, 0, len(cronJobs.Items))
+		for i := range cronJobs.Items {
+			summaries = append(summaries, summarizeCronJob(&cronJobs.Items[i], jobs.Items, now))
+		}
+		return summaries, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list cronjobs: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+// summarizeCronJob reports a CronJob's schedule, its next run after now,
+// and the status of the latest of jobs it created.
+func summarizeCronJob(cj *batchv1.CronJob, jobs []batchv1.Job, now time.Time) CronJobSummary {
+	summary := CronJobSummary{
+		Namespace: cj.Namespace,
+		Name:      cj.Name,
+		Schedule:  cj.Spec.Schedule,
+		Suspended: cj.Spec.Suspend != nil && *cj.Spec.Suspend,
+		Active:    len(cj.Status.Active),
+	}
+	if cj.Spec.TimeZone != nil {
+		summary.TimeZone = *cj.Spec.TimeZone
+	}
+	if t := cj.Status.LastScheduleTime; t != nil {
+		summary.LastScheduleTime = t.UTC().Format(time.RFC3339)
+	}
+	if t := cj.Status.LastSuccessfulTime; t != nil {
+		summary.LastSuccessfulTime = t.UTC().Format(time.RFC3339)
+	}
+	if !summary.Suspended {
+		schedule, err := cron.Parse(cj.Spec.Schedule, summary.TimeZone)
+		if err != nil {
+			summary.Error = fmt.Sprintf("invalid schedule: %v", err)
+		} else if next := schedule.Next(now); !next.IsZero() {
+			summary.NextRun = next.UTC().Format(time.RFC3339)
+		}
+	}
+	if latest := latestJob(cj, jobs); latest != nil {
+		run := jobRun(latest)
+		summary.LastRun = &run
+	}
+	return summary
+}
+
+// latestJob returns the most recently created of jobs owned by cj, or nil.
+func latestJob(cj *batchv1.CronJob, jobs []batchv1.Job) *batchv1.Job {
+	var latest *batchv1.Job
+	for i := range jobs {
+		job := &jobs[i]
+		owner := metav1.GetControllerOf(job)
+		if owner == nil || owner.Kind != "CronJob" || owner.Name != cj.Name || job.Namespace != cj.Namespace {
+			continue
+		}
+		if owner.UID != "" && cj.UID != "" && owner.UID != cj.UID {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&job.CreationTimestamp) ||
+			(latest.CreationTimestamp.Equal(&job.CreationTimestamp) && latest.Name < job.Name) {
+			latest = job
+		}
+	}
+	return latest
+}
+
+func jobRun(job *batchv1.Job) JobRun {
+	run := JobRun{
+		Name:   job.Name,
+		Status: jobStatus(job),
+		Manual: job.Annotations[instantiateAnnotation] == "manual",
+	}
+	if t := job.Status.StartTime; t != nil {
+		run.StartTime = t.UTC().Format(time.RFC3339)
+	}
+	if t := job.Status.CompletionTime; t != nil {
+		run.CompletionTime = t.UTC().Format(time.RFC3339)
+	}
+	return run
+}
+
+func jobStatus(job *batchv1.Job) string {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch cond.Type {
+		case batchv1.JobComplete:
+			return jobSucceeded
+		case batchv1.JobFailed:
+			return jobFailed
+		}
+	}
+	if job.Status.Active > 0 {
+		return jobRunning
+	}
+	return jobPending
+}
+
+// sortClusterResults orders results by cluster name; executeAll returns
+// them in completion order.
+func sortClusterResults(results []ClusterResult) {
+	sort.Slice(results, func(i, j int) bool { return results[i].Cluster < results[j].Cluster })
+}
+
+// cronJobArgs extracts and validates the name and namespace of a CronJob.
+func cronJobArgs(args map[string]interface{}) (name, namespace string, err error) {
+	name, _ = args["name"].(string)
+	if name == "" {
+		return "", "", fmt.Errorf("name is required")
+	}
+	namespace, err = extractAndValidateNamespace(args)
+	if err != nil {
+		return "", "", err
+	}
+	if namespace == "" {
+		return "", "", fmt.Errorf("namespace is required")
+	}
+	return name, namespace, nil
+}
+
+func (s *Server) toolRunCronJobNow(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, namespace, err := cronJobArgs(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	jobName, _ := args["job_name"].(string)
+
+	client, err := s.getClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+
+	cj, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("Failed to get cronjob: %v", err), true
+	}
+
+	job := jobFromCronJob(cj, jobName, time.Now())
+	created, err := client.BatchV1().Jobs(namespace).Create(ctx, job, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Sprintf("Failed to create job: %v", err), true
+	}
+
+	var sb strings.Builder
+	if approval.IsDryRun(ctx) {
+		_, _ = fmt.Fprintf(&sb, "Would create Job `%s/%s` from CronJob `%s`.\n", namespace, created.Name, name)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "Created Job `%s/%s` from CronJob `%s`.\n", namespace, created.Name, name)
+		sb.WriteString("\nUse `get_cronjob_logs` to follow its output.\n")
+	}
+	if cj.Spec.Suspend != nil && *cj.Spec.Suspend {
+		sb.WriteString("\nNote: the CronJob is suspended; this run does not resume its schedule.\n")
+	}
+	return sb.String(), false
+}
+
+// jobFromCronJob builds a Job from a CronJob's template, owned by the
+// CronJob and marked as a manual run. If name is empty one is generated
+// from the CronJob's name.
+func jobFromCronJob(cj *batchv1.CronJob, name string, now time.Time) *batchv1.Job {
+	if name == "" {
+		suffix := fmt.Sprintf("-manual-%d", now.Unix())
+		base := cj.Name
+		if max := 63 - len(suffix); len(base) > max {
+			base = strings.TrimRight(base[:max], "-.")
+		}
+		name = base + suffix
+	}
+	annotations := map[string]string{instantiateAnnotation: "manual"}
+	for k, v := range cj.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	jobLabels := make(map[string]string, len(cj.Spec.JobTemplate.Labels))
+	for k, v := range cj.Spec.JobTemplate.Labels {
+		jobLabels[k] = v
+	}
+	controller := true
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   cj.Namespace,
+			Labels:      jobLabels,
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "batch/v1",
+				Kind:       "CronJob",
+				Name:       cj.Name,
+				UID:        cj.UID,
+				Controller: &controller,
+			}},
+		},
+		Spec: *cj.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+}
+
+func (s *Server) toolSuspendCronJob(ctx context.Context, args map[string]interface{}) (string, bool) {
+	return s.setCronJobSuspended(ctx, args, true)
+}
+
+func (s *Server) toolResumeCronJob(ctx context.Context, args map[string]interface{}) (string, bool) {
+	return s.setCronJobSuspended(ctx, args, false)
+}
+
+// setCronJobSuspended suspends or resumes a CronJob's schedule. Running
+// Jobs are not affected.
+func (s *Server) setCronJobSuspended(ctx context.Context, args map[string]interface{}, suspend bool) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, namespace, err := cronJobArgs(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	client, err := s.getClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+
+	cj, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("Failed to get cronjob: %v", err), true
+	}
+
+	state := "suspended"
+	if !suspend {
+		state = "resumed"
+	}
+	if current := cj.Spec.Suspend != nil && *cj.Spec.Suspend; current == suspend {
+		return fmt.Sprintf("CronJob `%s/%s` is already %s.", namespace, name, state), false
+	}
+
+	cj.Spec.Suspend = &suspend
+	if _, err := client.BatchV1().CronJobs(namespace).Update(ctx, cj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Sprintf("Failed to update cronjob: %v", err), true
+	}
+
+	var sb strings.Builder
+	if approval.IsDryRun(ctx) {
+		_, _ = fmt.Fprintf(&sb, "Would mark CronJob `%s/%s` %s.\n", namespace, name, state)
+		return sb.String(), false
+	}
+	_, _ = fmt.Fprintf(&sb, "CronJob `%s/%s` %s.\n", namespace, name, state)
+	if suspend && len(cj.Status.Active) > 0 {
+		_, _ = fmt.Fprintf(&sb, "\n%d running Job(s) continue until they finish.\n", len(cj.Status.Active))
+	}
+	if !suspend {
+		if schedule, err := cron.Parse(cj.Spec.Schedule, stringValue(cj.Spec.TimeZone)); err == nil {
+			if next := schedule.Next(time.Now()); !next.IsZero() {
+				_, _ = fmt.Fprintf(&sb, "\nNext run: %s\n", next.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+	return sb.String(), false
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// PodLogs is the log output of one pod of a Job.
+type PodLogs struct {
+	Pod   string `json:"pod"`
+	Phase string `json:"phase"`
+	Logs  string `json:"logs,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// cronJobLogs is the latest run of a CronJob in one cluster and the logs
+// of its pods.
+type cronJobLogs struct {
+	Run  *JobRun
+	Pods []PodLogs
+}
+
+func (s *Server) toolGetCronJobLogs(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, namespace, err := cronJobArgs(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	container, _ := args["container"].(string)
+	tailLines := int64(100)
+	if v, ok := args["tail_lines"].(float64); ok {
+		tailLines = int64(v)
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return latestCronJobLogs(ctx, client, namespace, name, container, tailLines)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to get cronjob logs: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	found := 0
+	for _, r := range results {
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "=== %s: error: %s\n\n", r.Cluster, r.Error)
+			continue
+		}
+		logs, _ := r.Result.(*cronJobLogs)
+		if logs == nil {
+			_, _ = fmt.Fprintf(&sb, "=== %s: CronJob %s/%s not found\n\n", r.Cluster, namespace, name)
+			continue
+		}
+		found++
+		if logs.Run == nil {
+			_, _ = fmt.Fprintf(&sb, "=== %s: no runs yet\n\n", r.Cluster)
+			continue
+		}
+		_, _ = fmt.Fprintf(&sb, "=== %s: Job %s (%s", r.Cluster, logs.Run.Name, logs.Run.Status)
+		if logs.Run.StartTime != "" {
+			_, _ = fmt.Fprintf(&sb, ", started %s", logs.Run.StartTime)
+		}
+		sb.WriteString(")\n")
+		if len(logs.Pods) == 0 {
+			sb.WriteString("No pods found; they may have been cleaned up.\n")
+		}
+		for _, pod := range logs.Pods {
+			_, _ = fmt.Fprintf(&sb, "--- pod %s (%s)\n", pod.Pod, pod.Phase)
+			if pod.Error != "" {
+				_, _ = fmt.Fprintf(&sb, "Failed to get logs: %s\n", pod.Error)
+				continue
+			}
+			sb.WriteString(pod.Logs)
+			if !strings.HasSuffix(pod.Logs, "\n") {
+				sb.WriteString("\n")
+			}
+		}
+		sb.WriteString("\n")
+	}
+	if found == 0 {
+		return fmt.Sprintf("CronJob %s/%s not found in any cluster.\n\n%s", namespace, name, sb.String()), true
+	}
+	return sb.String(), false
+}
+
+// latestCronJobLogs returns the logs of the pods of the latest Job of a
+// CronJob, or nil if the CronJob does not exist.
+func latestCronJobLogs(ctx context.Context, client kubernetes.Interface, namespace, name, container string, tailLines int64) (*cronJobLogs, error) {
+	cj, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cronjob: %w", err)
+	}
+	jobs, err := client.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	result := &cronJobLogs{}
+	job := latestJob(cj, jobs.Items)
+	if job == nil {
+		return result, nil
+	}
+	run := jobRun(job)
+	result.Run = &run
+
+	selector := labels.SelectorFromSet(labels.Set{batchv1.JobNameLabel: job.Name}).String()
+	if job.Spec.Selector != nil {
+		if sel, err := metav1.LabelSelectorAsSelector(job.Spec.Selector); err == nil {
+			selector = sel.String()
+		}
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	for _, pod := range pods.Items {
+		logs := PodLogs{Pod: pod.Name, Phase: string(pod.Status.Phase)}
+		opts := &corev1.PodLogOptions{TailLines: &tailLines, Container: container}
+		data, err := client.CoreV1().Pods(namespace).GetLogs(pod.Name, opts).DoRaw(ctx)
+		if err != nil {
+			logs.Error = err.Error()
+		} else {
+			logs.Logs = string(data)
+		}
+		result.Pods = append(result.Pods, logs)
+	}
+	return result, nil
+}
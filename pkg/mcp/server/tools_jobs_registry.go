@@ -0,0 +1,143 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "get_cronjobs",
+		Description: "List CronJobs with their schedule, whether they are suspended, the next scheduled run, and the status of the latest Job run, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to list CronJobs from (all namespaces if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetCronJobs(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "run_cronjob_now",
+		Description: "Trigger an ad-hoc run of a CronJob by creating a Job from its template, like kubectl create job --from=cronjob/NAME",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the CronJob",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the CronJob",
+				},
+				"job_name": {
+					Type:        "string",
+					Description: "Name of the Job to create (default: NAME-manual-TIMESTAMP)",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolRunCronJobNow(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "suspend_cronjob",
+		Description: "Suspend a CronJob so it schedules no new runs. Jobs already running continue",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the CronJob",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the CronJob",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolSuspendCronJob(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "resume_cronjob",
+		Description: "Resume a suspended CronJob's schedule",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (uses current context if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the CronJob",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the CronJob",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolResumeCronJob(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "get_cronjob_logs",
+		Description: "Get the logs of the pods of a CronJob's latest Job run, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Name of the CronJob",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace of the CronJob",
+				},
+				"container": {
+					Type:        "string",
+					Description: "Container name (required for multi-container pods)",
+				},
+				"tail_lines": {
+					Type:        "integer",
+					Description: "Number of lines from the end of each pod's logs (default 100)",
+				},
+			},
+			Required: []string{"name", "namespace"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetCronJobLogs(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+func testCronJob(suspended bool) *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "apps", UID: types.UID("cj-uid")},
+		Spec: batchv1.CronJobSpec{
+			Schedule: "0 2 * * *",
+			Suspend:  &suspended,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "report"}},
+				Spec: batchv1.JobSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers:    []corev1.Container{{Name: "report", Image: "report:v1"}},
+				}}},
+			},
+		},
+	}
+}
+
+// testJobRun returns a Job of testCronJob created at created, and its pod.
+func testJobRun(name string, created time.Time, condition batchv1.JobConditionType) (*batchv1.Job, *corev1.Pod) {
+	controller := true
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "apps", CreationTimestamp: metav1.NewTime(created),
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "report", UID: "cj-uid", Controller: &controller}},
+		},
+		Spec: batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{batchv1.JobNameLabel: name}}},
+	}
+	if condition != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: corev1.ConditionTrue}}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name + "-abcde", Namespace: "apps", Labels: map[string]string{batchv1.JobNameLabel: name}},
+		Status:     corev1.PodStatus{Phase: corev1.PodSucceeded},
+	}
+	return job, pod
+}
+
+// newJobsServer returns a server over clusters alpha and beta, each
+// backed by a fake clientset holding objects.
+func newJobsServer(objects map[string][]runtime.Object) (*Server, map[string]*k8sfake.Clientset) {
+	clients := make(map[string]*k8sfake.Clientset, len(objects))
+	var infos []cluster.ClusterInfo
+	for name, objs := range objects {
+		clients[name] = k8sfake.NewSimpleClientset(objs...)
+		infos = append(infos, cluster.ClusterInfo{Name: name, Context: name})
+	}
+	server := &Server{
+		discoverer: stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			return clients[clusterName], nil
+		},
+	}
+	return server, clients
+}
+
+func TestGetCronJobsReportsNextAndLastRunPerCluster(t *testing.T) {
+	now := time.Now()
+	older, _ := testJobRun("report-100", now.Add(-48*time.Hour), batchv1.JobFailed)
+	latest, _ := testJobRun("report-200", now.Add(-24*time.Hour), batchv1.JobComplete)
+	server, _ := newJobsServer(map[string][]runtime.Object{
+		"alpha": {testCronJob(false), older, latest},
+		"beta":  {testCronJob(true)},
+	})
+
+	result, rpcErr := callTool(t, server, "get_cronjobs", map[string]interface{}{"namespace": "apps"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+
+	var decoded []struct {
+		Cluster string           `json:"cluster"`
+		Result  []CronJobSummary `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "alpha", decoded[0].Cluster)
+	alpha := decoded[0].Result[0]
+	assert.False(t, alpha.Suspended)
+	require.NotNil(t, alpha.LastRun)
+	assert.Equal(t, "report-200", alpha.LastRun.Name)
+	assert.Equal(t, jobSucceeded, alpha.LastRun.Status)
+	next, err := time.Parse(time.RFC3339, alpha.NextRun)
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.Hour())
+	assert.True(t, next.After(now))
+
+	beta := decoded[1].Result[0]
+	assert.True(t, beta.Suspended)
+	assert.Empty(t, beta.NextRun, "suspended CronJobs have no next run")
+	assert.Nil(t, beta.LastRun)
+}
+
+func TestRunCronJobNowCreatesOwnedJob(t *testing.T) {
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": {testCronJob(true)}})
+
+	result, rpcErr := callTool(t, server, "run_cronjob_now", map[string]interface{}{
+		"cluster": "alpha", "namespace": "apps", "name": "report", "job_name": "report-adhoc",
+	})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "Created Job `apps/report-adhoc`")
+	assert.Contains(t, result.Content[0].Text, "suspended")
+
+	job, err := clients["alpha"].BatchV1().Jobs("apps").Get(context.Background(), "report-adhoc", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "manual", job.Annotations[instantiateAnnotation])
+	assert.Equal(t, "report", job.Labels["app"])
+	owner := metav1.GetControllerOf(job)
+	require.NotNil(t, owner)
+	assert.Equal(t, "CronJob", owner.Kind)
+	assert.Equal(t, types.UID("cj-uid"), owner.UID)
+	assert.Equal(t, "report:v1", job.Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestJobFromCronJobGeneratesValidName(t *testing.T) {
+	cj := testCronJob(false)
+	cj.Name = "a-very-long-cronjob-name-that-goes-on-and-on-and-on-for-a-while"
+	job := jobFromCronJob(cj, "", time.Unix(1700000000, 0))
+	assert.LessOrEqual(t, len(job.Name), 63)
+	assert.Regexp(t, `-manual-1700000000$`, job.Name)
+}
+
+func TestSuspendAndResumeCronJob(t *testing.T) {
+	server, clients := newJobsServer(map[string][]runtime.Object{"alpha": {testCronJob(false)}})
+	args := map[string]interface{}{"cluster": "alpha", "namespace": "apps", "name": "report"}
+
+	result, rpcErr := callTool(t, server, "suspend_cronjob", args)
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	cj, err := clients["alpha"].BatchV1().CronJobs("apps").Get(context.Background(), "report", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, *cj.Spec.Suspend)
+
+	result, _ = callTool(t, server, "suspend_cronjob", args)
+	assert.Contains(t, result.Content[0].Text, "already suspended")
+
+	result, rpcErr = callTool(t, server, "resume_cronjob", args)
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "Next run:")
+	cj, err = clients["alpha"].BatchV1().CronJobs("apps").Get(context.Background(), "report", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.False(t, *cj.Spec.Suspend)
+}
+
+func TestGetCronJobLogsUsesLatestRunPerCluster(t *testing.T) {
+	now := time.Now()
+	older, olderPod := testJobRun("report-100", now.Add(-48*time.Hour), batchv1.JobComplete)
+	latest, latestPod := testJobRun("report-200", now.Add(-time.Hour), batchv1.JobFailed)
+	server, _ := newJobsServer(map[string][]runtime.Object{
+		"alpha": {testCronJob(false), older, olderPod, latest, latestPod},
+		"beta":  {testCronJob(false)},
+		"gamma": {},
+	})
+
+	result, rpcErr := callTool(t, server, "get_cronjob_logs", map[string]interface{}{"namespace": "apps", "name": "report"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "=== alpha: Job report-200 (Failed")
+	assert.Contains(t, text, "--- pod report-200-abcde (Succeeded)\nfake logs")
+	assert.NotContains(t, text, "report-100")
+	assert.Contains(t, text, "=== beta: no runs yet")
+	assert.Contains(t, text, "=== gamma: CronJob apps/report not found")
+}
+
+func TestCronJobToolsValidateArgs(t *testing.T) {
+	server, _ := newJobsServer(map[string][]runtime.Object{"alpha": {testCronJob(false)}})
+	for _, tool := range []string{"run_cronjob_now", "suspend_cronjob", "resume_cronjob", "get_cronjob_logs"} {
+		for _, args := range []map[string]interface{}{
+			{"cluster": "alpha", "namespace": "apps"},
+			{"cluster": "alpha", "name": "report"},
+			{"cluster": "alpha", "namespace": "kube-system", "name": "report"},
+		} {
+			result, rpcErr := callTool(t, server, tool, args)
+			require.Nil(t, rpcErr)
+			assert.True(t, result.IsError, "%s %v", tool, args)
+		}
+	}
+
+	result, _ := callTool(t, server, "get_cronjob_logs", map[string]interface{}{"namespace": "apps", "name": "missing"})
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "not found in any cluster")
+}
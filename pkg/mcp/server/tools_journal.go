@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+)
+
+func (s *Server) getJournal() *journal.Journal {
+	s.journalOnce.Do(func() {
+		if s.journal == nil {
+			s.journal = journal.New(s.getStateStore())
+		}
+	})
+	return s.journal
+}
+
+// commitChange stores the objects recorded during a tool call and returns
+// the result metadata that points at the change, if anything changed.
+func (s *Server) commitChange(ctx context.Context, rec *journal.Recorder) map[string]interface{} {
+	change, err := s.getJournal().Commit(ctx, rec)
+	if err != nil {
+		return map[string]interface{}{"journalError": err.Error()}
+	}
+	if change == nil {
+		return nil
+	}
+	meta := map[string]interface{}{"changeId": change.ID}
+	if notUndoable := change.NotUndoable(); len(notUndoable) > 0 {
+		meta["notUndoable"] = notUndoable
+	}
+	return meta
+}
+
+// configForServer finds the cluster for an API server URL recorded in the
+// journal.
+func (s *Server) configForServer(server string) (*rest.Config, error) {
+	clusters, err := s.discoverer.DiscoverClusters("all")
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range clusters {
+		if !journal.SameServer(c.Server, server) {
+			continue
+		}
+		name := c.Context
+		if name == "" {
+			name = c.Name
+		}
+		return s.getRestConfigForCluster(name)
+	}
+	return nil, fmt.Errorf("no kubeconfig context points at %s", server)
+}
+
+func (s *Server) toolListChanges(ctx context.Context, args map[string]interface{}) (string, bool) {
+	limit := 20
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	tool, _ := args["tool"].(string)
+
+	changes, err := s.getJournal().List(ctx, 0)
+	if err != nil {
+		return fmt.Sprintf("Failed to read change journal: %v", err), true
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Recent Changes\n\n")
+	shown := 0
+	for _, c := range changes {
+		if tool != "" && c.Tool != tool {
+			continue
+		}
+		if shown == limit {
+			break
+		}
+		shown++
+		_, _ = fmt.Fprintf(&sb, "## %s\n", c.ID)
+		_, _ = fmt.Fprintf(&sb, "**Tool:** %s  **Time:** %s\n", c.Tool, c.Time.UTC().Format("2006-01-02 15:04:05 UTC"))
+		if c.UndoOf != "" {
+			_, _ = fmt.Fprintf(&sb, "**Undoes:** %s\n", c.UndoOf)
+		}
+		if c.UndoneBy != "" {
+			_, _ = fmt.Fprintf(&sb, "**Undone by:** %s\n", c.UndoneBy)
+		}
+		for _, o := range c.Objects {
+			_, _ = fmt.Fprintf(&sb, "- %s `%s` on %s\n", o.Op, o.Path, o.Server)
+			if o.NotUndoable != "" {
+				_, _ = fmt.Fprintf(&sb, "  (cannot be undone: %s)\n", o.NotUndoable)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	if shown == 0 {
+		sb.WriteString("No changes recorded.\n")
+	}
+	return sb.String(), false
+}
+
+func (s *Server) toolUndoChange(ctx context.Context, args map[string]interface{}) (string, bool) {
+	changeID, _ := args["change_id"].(string)
+	if changeID == "" {
+		return "change_id is required", true
+	}
+	dryRun, _ := args["dry_run"].(bool)
+	dryRun = dryRun || approval.IsDryRun(ctx)
+
+	undo, results, err := s.getJournal().Undo(ctx, changeID, s.configForServer, dryRun)
+	if err != nil && results == nil {
+		return fmt.Sprintf("Failed to undo change: %v", err), true
+	}
+
+	var sb strings.Builder
+	if dryRun {
+		_, _ = fmt.Fprintf(&sb, "# Undo Preview: %s\n\n", changeID)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "# Undo: %s\n\n", changeID)
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+			_, _ = fmt.Fprintf(&sb, "- ❌ `%s` on %s: %s\n", r.Path, r.Server, r.Error)
+			continue
+		}
+		_, _ = fmt.Fprintf(&sb, "- ✓ `%s` on %s: %s\n", r.Path, r.Server, r.Action)
+	}
+	if undo != nil {
+		_, _ = fmt.Fprintf(&sb, "\nThe undo was recorded as change %s.\n", undo.ID)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(&sb, "\nFailed to record the undo: %v\n", err)
+	}
+	return sb.String(), failed > 0
+}
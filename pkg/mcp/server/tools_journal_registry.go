@@ -0,0 +1,49 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "list_changes",
+		Description: "List recent changes made by this server's tools, newest first, with the objects each one touched. Use the change ID with undo_change.",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum number of changes to return (default 20)",
+				},
+				"tool": {
+					Type:        "string",
+					Description: "Only list changes made by this tool",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListChanges(ctx, args)
+		},
+	)
+	RegisterMutatingTool(Tool{
+		Name:        "undo_change",
+		Description: "Revert a change made by this server: objects it created are deleted, and objects it updated or deleted are restored to their previous state",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"change_id": {
+					Type:        "string",
+					Description: "Change ID from list_changes or a tool result's _meta.changeId",
+				},
+				"dry_run": {
+					Type:        "boolean",
+					Description: "Preview the revert without applying",
+				},
+			},
+			Required: []string{"change_id"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolUndoChange(ctx, args)
+		},
+	)
+}
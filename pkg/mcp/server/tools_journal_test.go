@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/journal"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+func TestToolListChanges_Empty(t *testing.T) {
+	s := &Server{journal: journal.New(store.NewMemory())}
+	out, isErr := s.toolListChanges(context.Background(), map[string]interface{}{})
+	assert.False(t, isErr)
+	assert.Contains(t, out, "No changes recorded")
+}
+
+func TestToolUndoChange_RequiresKnownChange(t *testing.T) {
+	s := &Server{journal: journal.New(store.NewMemory())}
+	out, isErr := s.toolUndoChange(context.Background(), map[string]interface{}{})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "change_id is required")
+
+	out, isErr = s.toolUndoChange(context.Background(), map[string]interface{}{"change_id": "chg-missing"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "not found")
+}
+
+func TestJournalTools_Registered(t *testing.T) {
+	list := findToolDef("list_changes")
+	require.NotNil(t, list)
+	assert.False(t, list.Mutating)
+
+	undo := findToolDef("undo_change")
+	require.NotNil(t, undo)
+	assert.True(t, undo.Mutating)
+	assert.Contains(t, undo.Schema.InputSchema.Required, "change_id")
+}
@@ -0,0 +1,419 @@
+package server
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// kubeconfigExpiryWarning is how close to expiry a client certificate or
+// cluster CA is reported. It is longer than certExpiryWarning because
+// kubeconfig credentials are rotated by hand.
+const kubeconfigExpiryWarning = 30 * 24 * time.Hour
+
+// KubeconfigCredentialAudit is the security audit of the credentials and
+// cluster of one kubeconfig context.
+type KubeconfigCredentialAudit struct {
+	Context string `json:"context"`
+	// Credential is how the context authenticates: client certificate,
+	// token, exec: <command>, auth-provider: <name>, basic auth, or none.
+	Credential string     `json:"credential"`
+	Expires    *time.Time `json:"expires,omitempty"`
+	// TokenCache describes the token an exec plugin has cached, if any.
+	TokenCache string     `json:"tokenCache,omitempty"`
+	CAExpires  *time.Time `json:"caExpires,omitempty"`
+	// TLS is verified, skip-verify, or plain HTTP.
+	TLS      string               `json:"tls"`
+	Problems []CertificateProblem `json:"problems,omitempty"`
+}
+
+// auditKubeconfigCredentials audits the credentials and cluster of every
+// context of config, sorted by context. Certificate and token files are
+// read as referenced; exec plugin token caches are looked up under home.
+func auditKubeconfigCredentials(config *clientcmdapi.Config, home string, now time.Time) []KubeconfigCredentialAudit {
+	audits := make([]KubeconfigCredentialAudit, 0, len(config.Contexts))
+	for name, kctx := range config.Contexts {
+		a := KubeconfigCredentialAudit{Context: name, Credential: "none", TLS: "verified"}
+		if cluster, ok := config.Clusters[kctx.Cluster]; ok {
+			auditKubeconfigCluster(&a, cluster, now)
+		}
+		if user, ok := config.AuthInfos[kctx.AuthInfo]; ok {
+			auditKubeconfigUser(&a, user, home, now)
+		}
+		audits = append(audits, a)
+	}
+	sort.Slice(audits, func(i, j int) bool { return audits[i].Context < audits[j].Context })
+	return audits
+}
+
+func auditKubeconfigCluster(a *KubeconfigCredentialAudit, cluster *clientcmdapi.Cluster, now time.Time) {
+	if u, err := url.Parse(cluster.Server); err == nil && u.Scheme == "http" {
+		a.TLS = "plain HTTP"
+		a.Problems = append(a.Problems, CertificateProblem{
+			Severity:   "critical",
+			Problem:    "the API server is reached over plain HTTP",
+			Detail:     "credentials and every response cross the network unencrypted",
+			Suggestion: "use the cluster's https:// endpoint",
+		})
+		return
+	}
+	if cluster.InsecureSkipTLSVerify {
+		a.TLS = "skip-verify"
+		a.Problems = append(a.Problems, CertificateProblem{
+			Severity:   "warning",
+			Problem:    "insecure-skip-tls-verify is set",
+			Detail:     "the API server's certificate is not verified, so anyone on the network path can impersonate it and capture the credentials sent to it",
+			Suggestion: "set certificate-authority-data to the cluster's CA and remove insecure-skip-tls-verify",
+		})
+	}
+
+	bundle := cluster.CertificateAuthorityData
+	if len(bundle) == 0 && cluster.CertificateAuthority != "" {
+		data, err := os.ReadFile(cluster.CertificateAuthority)
+		if err != nil {
+			a.Problems = append(a.Problems, CertificateProblem{Severity: "critical", Problem: "cannot read certificate-authority file", Detail: err.Error()})
+			return
+		}
+		bundle = data
+	}
+	if len(bundle) == 0 {
+		return
+	}
+	// Any CA of the bundle may have signed the serving certificate, so the
+	// bundle is good until the last of them expires.
+	certs := parsePEMCertificates(bundle)
+	if len(certs) == 0 {
+		a.Problems = append(a.Problems, CertificateProblem{Severity: "critical", Problem: "certificate-authority holds no valid PEM certificate"})
+		return
+	}
+	latest := certs[0].NotAfter
+	for _, c := range certs[1:] {
+		if c.NotAfter.After(latest) {
+			latest = c.NotAfter
+		}
+	}
+	a.CAExpires = &latest
+	if p, ok := expiryProblem("cluster CA", latest, now); ok {
+		p.Suggestion = "fetch the cluster's current CA, for example from the kube-root-ca.crt ConfigMap, and update certificate-authority-data"
+		a.Problems = append(a.Problems, p)
+	}
+}
+
+func auditKubeconfigUser(a *KubeconfigCredentialAudit, user *clientcmdapi.AuthInfo, home string, now time.Time) {
+	switch {
+	case len(user.ClientCertificateData) > 0 || user.ClientCertificate != "":
+		a.Credential = "client certificate"
+		data := user.ClientCertificateData
+		if len(data) == 0 {
+			var err error
+			if data, err = os.ReadFile(user.ClientCertificate); err != nil {
+				a.Problems = append(a.Problems, CertificateProblem{Severity: "critical", Problem: "cannot read client-certificate file", Detail: err.Error()})
+				return
+			}
+		}
+		certs := parsePEMCertificates(data)
+		if len(certs) == 0 {
+			a.Problems = append(a.Problems, CertificateProblem{Severity: "critical", Problem: "client certificate holds no valid PEM certificate"})
+			return
+		}
+		a.Expires = &certs[0].NotAfter
+		what := "client certificate"
+		if cn := certs[0].Subject.CommonName; cn != "" {
+			what += " " + cn
+		}
+		if p, ok := expiryProblem(what, certs[0].NotAfter, now); ok {
+			p.Suggestion = "renew it, for example with kubeadm certs renew admin.conf or a new CertificateSigningRequest, and update client-certificate-data"
+			a.Problems = append(a.Problems, p)
+		}
+	case user.Exec != nil:
+		a.Credential = "exec: " + filepath.Base(user.Exec.Command)
+		auditExecPlugin(a, user.Exec, home, now)
+	case user.AuthProvider != nil:
+		a.Credential = "auth-provider: " + user.AuthProvider.Name
+		a.Problems = append(a.Problems, CertificateProblem{
+			Severity:   "warning",
+			Problem:    fmt.Sprintf("the %s auth-provider plugin is deprecated", user.AuthProvider.Name),
+			Detail:     "kubectl 1.26 and later no longer ship the gcp and azure auth providers",
+			Suggestion: "switch to the provider's exec plugin, such as gke-gcloud-auth-plugin or kubelogin",
+		})
+		cfg := user.AuthProvider.Config
+		if t, err := time.Parse(time.RFC3339, cfg["expiry"]); err == nil {
+			a.Expires = &t
+		} else if exp, ok := jwtExpiry(cfg["id-token"]); ok {
+			a.Expires = &exp
+		}
+	case user.Token != "" || user.TokenFile != "":
+		a.Credential = "token"
+		token := user.Token
+		if token == "" {
+			data, err := os.ReadFile(user.TokenFile)
+			if err != nil {
+				a.Problems = append(a.Problems, CertificateProblem{Severity: "critical", Problem: "cannot read token file", Detail: err.Error()})
+				return
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		if exp, ok := jwtExpiry(token); ok {
+			a.Expires = &exp
+			if !exp.After(now) {
+				a.Problems = append(a.Problems, CertificateProblem{
+					Severity:   "critical",
+					Problem:    fmt.Sprintf("bearer token expired %s ago", formatDuration(now.Sub(exp))),
+					Suggestion: "request a new one, for example with kubectl create token",
+				})
+			}
+			return
+		}
+		a.Problems = append(a.Problems, CertificateProblem{
+			Severity:   "warning",
+			Problem:    "static bearer token without an expiry",
+			Detail:     "a leaked copy of this kubeconfig grants access until the token is revoked",
+			Suggestion: "use short-lived tokens from kubectl create token, or an exec plugin",
+		})
+	case user.Username != "" || user.Password != "":
+		a.Credential = "basic auth"
+		a.Problems = append(a.Problems, CertificateProblem{
+			Severity:   "warning",
+			Problem:    "basic authentication",
+			Detail:     "the API server dropped basic authentication in Kubernetes 1.19",
+			Suggestion: "use a client certificate, a token, or an exec plugin",
+		})
+	}
+}
+
+// auditExecPlugin checks that an exec plugin is installed and reports the
+// freshness of the tokens cached by the plugins whose cache is known.
+func auditExecPlugin(a *KubeconfigCredentialAudit, plugin *clientcmdapi.ExecConfig, home string, now time.Time) {
+	if _, err := exec.LookPath(plugin.Command); err != nil {
+		a.Problems = append(a.Problems, CertificateProblem{
+			Severity:   "critical",
+			Problem:    fmt.Sprintf("exec plugin %s is not installed", plugin.Command),
+			Detail:     "every connection through this context fails until it is",
+			Suggestion: plugin.InstallHint,
+		})
+	}
+
+	var exp time.Time
+	var refreshable bool
+	switch name := filepath.Base(plugin.Command); {
+	case name == "gke-gcloud-auth-plugin":
+		var cache struct {
+			TokenExpiry time.Time `json:"token_expiry"`
+		}
+		data, err := os.ReadFile(filepath.Join(home, ".kube", "gke_gcloud_auth_plugin_cache"))
+		if err != nil || json.Unmarshal(data, &cache) != nil || cache.TokenExpiry.IsZero() {
+			a.TokenCache = "none"
+			return
+		}
+		// gcloud refreshes the access token silently.
+		exp, refreshable = cache.TokenExpiry, true
+	case slices.Contains(plugin.Args, "oidc-login"):
+		var ok bool
+		if exp, refreshable, ok = oidcLoginCachedToken(plugin.Args, home); !ok {
+			a.TokenCache = "none"
+			return
+		}
+	case name == "aws" || name == "aws-iam-authenticator":
+		a.TokenCache = "none (a token is minted per connection)"
+		return
+	default:
+		return
+	}
+
+	if exp.After(now) {
+		a.TokenCache = fmt.Sprintf("valid for %s", formatDuration(exp.Sub(now)))
+		return
+	}
+	a.TokenCache = fmt.Sprintf("expired %s ago", formatDuration(now.Sub(exp)))
+	if !refreshable {
+		a.Problems = append(a.Problems, CertificateProblem{
+			Severity: "warning",
+			Problem:  fmt.Sprintf("the cached token expired %s ago and there is no refresh token", formatDuration(now.Sub(exp))),
+			Detail:   "the next connection opens a browser login, which fails in non-interactive use such as this server",
+		})
+	}
+}
+
+// oidcLoginCachedToken finds the ID token kubelogin (kubectl oidc-login)
+// cached for the issuer and client ID in args, in its --token-cache-dir or
+// the default cache, and returns its expiry and whether a refresh token
+// was cached with it.
+func oidcLoginCachedToken(args []string, home string) (time.Time, bool, bool) {
+	flag := func(name string) string {
+		for i, arg := range args {
+			if v, ok := strings.CutPrefix(arg, "--"+name+"="); ok {
+				return v
+			}
+			if arg == "--"+name && i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+		return ""
+	}
+	issuer, clientID := flag("oidc-issuer-url"), flag("oidc-client-id")
+	dir := flag("token-cache-dir")
+	if dir == "" {
+		dir = filepath.Join(home, ".kube", "cache", "oidc-login")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	var latest time.Time
+	var refreshable, found bool
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		var cached struct {
+			IDToken      string `json:"id_token"`
+			RefreshToken string `json:"refresh_token"`
+		}
+		if json.Unmarshal(data, &cached) != nil {
+			continue
+		}
+		claims, ok := jwtClaims(cached.IDToken)
+		if !ok || (issuer != "" && claims.Issuer != issuer) || (clientID != "" && !slices.Contains(claims.Audience, clientID)) {
+			continue
+		}
+		exp := time.Unix(claims.Expiry, 0)
+		if !found || exp.After(latest) {
+			latest, refreshable, found = exp, cached.RefreshToken != "", true
+		}
+	}
+	return latest, refreshable, found
+}
+
+// tokenClaims are the claims of a JWT the audit reads.
+type tokenClaims struct {
+	Issuer   string
+	Audience []string
+	Expiry   int64
+}
+
+// jwtClaims reads the claims of a JWT without verifying it.
+func jwtClaims(token string) (tokenClaims, bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims{}, false
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return tokenClaims{}, false
+	}
+	var raw struct {
+		Issuer   string          `json:"iss"`
+		Audience json.RawMessage `json:"aud"`
+		Expiry   int64           `json:"exp"`
+	}
+	if json.Unmarshal(data, &raw) != nil {
+		return tokenClaims{}, false
+	}
+	claims := tokenClaims{Issuer: raw.Issuer, Expiry: raw.Expiry}
+	// aud is a string or an array of strings.
+	var one string
+	if json.Unmarshal(raw.Audience, &one) == nil {
+		claims.Audience = []string{one}
+	} else {
+		_ = json.Unmarshal(raw.Audience, &claims.Audience)
+	}
+	return claims, true
+}
+
+// jwtExpiry returns the exp claim of a JWT, if it is one and has one.
+func jwtExpiry(token string) (time.Time, bool) {
+	claims, ok := jwtClaims(token)
+	if !ok || claims.Expiry == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.Expiry, 0), true
+}
+
+func parsePEMCertificates(data []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// expiryProblem reports a certificate that has expired or expires within
+// kubeconfigExpiryWarning.
+func expiryProblem(what string, notAfter, now time.Time) (CertificateProblem, bool) {
+	switch {
+	case !notAfter.After(now):
+		return CertificateProblem{Severity: "critical", Problem: fmt.Sprintf("%s expired %s ago", what, formatDuration(now.Sub(notAfter)))}, true
+	case notAfter.Sub(now) < kubeconfigExpiryWarning:
+		return CertificateProblem{Severity: "warning", Problem: fmt.Sprintf("%s expires in %s", what, formatDuration(notAfter.Sub(now)))}, true
+	}
+	return CertificateProblem{}, false
+}
+
+func writeKubeconfigCredentialAudit(sb *strings.Builder, audits []KubeconfigCredentialAudit, now time.Time) {
+	sb.WriteString("## Credential Security\n\n")
+	expiry := func(t *time.Time) string {
+		switch {
+		case t == nil:
+			return "-"
+		case t.After(now):
+			return fmt.Sprintf("%s (in %s)", t.UTC().Format("2006-01-02"), formatDuration(t.Sub(now)))
+		}
+		return fmt.Sprintf("%s (expired)", t.UTC().Format("2006-01-02"))
+	}
+	sb.WriteString("| Context | Credential | Expires | Token Cache | CA Expires | TLS |\n")
+	sb.WriteString("|---------|------------|---------|-------------|------------|-----|\n")
+	flagged := 0
+	for _, a := range audits {
+		tokenCache, tls := a.TokenCache, a.TLS
+		if tokenCache == "" {
+			tokenCache = "-"
+		}
+		if tls != "verified" {
+			tls = "⚠️ " + tls
+		}
+		_, _ = fmt.Fprintf(sb, "| %s | %s | %s | %s | %s | %s |\n", a.Context, a.Credential, expiry(a.Expires), tokenCache, expiry(a.CAExpires), tls)
+		if len(a.Problems) > 0 {
+			flagged++
+		}
+	}
+
+	if flagged == 0 {
+		sb.WriteString("\n✅ No credential problems found.\n\n")
+		return
+	}
+	for _, a := range audits {
+		if len(a.Problems) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(sb, "\n📛 %s (%s)\n", a.Context, a.Credential)
+		for _, p := range a.Problems {
+			_, _ = fmt.Fprintf(sb, "   - [%s] %s\n", p.Severity, p.Problem)
+			if p.Detail != "" {
+				_, _ = fmt.Fprintf(sb, "     %s\n", p.Detail)
+			}
+			if p.Suggestion != "" {
+				_, _ = fmt.Fprintf(sb, "     Fix: %s\n", p.Suggestion)
+			}
+		}
+	}
+	sb.WriteString("\n")
+}
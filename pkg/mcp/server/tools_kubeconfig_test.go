@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// testJWT returns an unsigned JWT with claims.
+func testJWT(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc(payload) + ".sig"
+}
+
+func TestAuditKubeconfigCredentials(t *testing.T) {
+	now := time.Now()
+	home := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(home, ".kube", "cache", "oidc-login"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".kube", "gke_gcloud_auth_plugin_cache"),
+		[]byte(`{"access_token":"ya29.x","token_expiry":"`+now.Add(30*time.Minute).UTC().Format(time.RFC3339)+`"}`), 0o600))
+	writeCache := func(name string, claims map[string]interface{}, refresh string) {
+		data, err := json.Marshal(map[string]string{"id_token": testJWT(t, claims), "refresh_token": refresh})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(home, ".kube", "cache", "oidc-login", name), data, 0o600))
+	}
+	writeCache("a", map[string]interface{}{"iss": "https://issuer", "aud": "kube", "exp": now.Add(-2 * time.Hour).Unix()}, "")
+	writeCache("b", map[string]interface{}{"iss": "https://other", "aud": "kube", "exp": now.Add(time.Hour).Unix()}, "r")
+
+	ca := []byte(selfSignedPEM(t, now.Add(365*24*time.Hour)))
+	config := &clientcmdapi.Config{
+		Clusters: map[string]*clientcmdapi.Cluster{
+			"secure":   {Server: "https://secure:6443", CertificateAuthorityData: ca},
+			"insecure": {Server: "https://insecure:6443", InsecureSkipTLSVerify: true},
+			"http":     {Server: "http://legacy:8080"},
+		},
+		AuthInfos: map[string]*clientcmdapi.AuthInfo{
+			"expiring": {ClientCertificateData: []byte(selfSignedPEM(t, now.Add(10*24*time.Hour)))},
+			"expired":  {ClientCertificateData: []byte(selfSignedPEM(t, now.Add(-24*time.Hour)))},
+			"static":   {Token: "abc"},
+			"gke":      {Exec: &clientcmdapi.ExecConfig{Command: "gke-gcloud-auth-plugin", InstallHint: "gcloud components install gke-gcloud-auth-plugin"}},
+			"oidc": {Exec: &clientcmdapi.ExecConfig{Command: "sh", Args: []string{
+				"oidc-login", "get-token", "--oidc-issuer-url=https://issuer", "--oidc-client-id", "kube",
+			}}},
+		},
+		Contexts: map[string]*clientcmdapi.Context{
+			"expiring": {Cluster: "secure", AuthInfo: "expiring"},
+			"expired":  {Cluster: "secure", AuthInfo: "expired"},
+			"insecure": {Cluster: "insecure", AuthInfo: "static"},
+			"gke":      {Cluster: "secure", AuthInfo: "gke"},
+			"oidc":     {Cluster: "secure", AuthInfo: "oidc"},
+			"http":     {Cluster: "http", AuthInfo: "missing"},
+		},
+	}
+
+	audits := auditKubeconfigCredentials(config, home, now)
+	byContext := make(map[string]KubeconfigCredentialAudit, len(audits))
+	var contexts []string
+	for _, a := range audits {
+		byContext[a.Context] = a
+		contexts = append(contexts, a.Context)
+	}
+	assert.Equal(t, []string{"expired", "expiring", "gke", "http", "insecure", "oidc"}, contexts)
+	problems := func(name string) []string {
+		var out []string
+		for _, p := range byContext[name].Problems {
+			out = append(out, "["+p.Severity+"] "+p.Problem)
+		}
+		return out
+	}
+
+	expiring := byContext["expiring"]
+	assert.Equal(t, "client certificate", expiring.Credential)
+	require.NotNil(t, expiring.CAExpires)
+	assert.Equal(t, []string{"[warning] client certificate expires in 9d"}, problems("expiring"))
+	assert.Equal(t, []string{"[critical] client certificate expired 1d ago"}, problems("expired"))
+
+	assert.Equal(t, "skip-verify", byContext["insecure"].TLS)
+	assert.Equal(t, []string{"[warning] insecure-skip-tls-verify is set", "[warning] static bearer token without an expiry"}, problems("insecure"))
+
+	gke := byContext["gke"]
+	assert.Equal(t, "exec: gke-gcloud-auth-plugin", gke.Credential)
+	assert.Equal(t, "valid for 29m", gke.TokenCache)
+	require.Len(t, gke.Problems, 1)
+	assert.Equal(t, "exec plugin gke-gcloud-auth-plugin is not installed", gke.Problems[0].Problem)
+	assert.Equal(t, "gcloud components install gke-gcloud-auth-plugin", gke.Problems[0].Suggestion)
+
+	oidc := byContext["oidc"]
+	assert.Equal(t, "expired 2h ago", oidc.TokenCache)
+	assert.Equal(t, []string{"[warning] the cached token expired 2h ago and there is no refresh token"}, problems("oidc"))
+
+	assert.Equal(t, "none", byContext["http"].Credential)
+	assert.Equal(t, []string{"[critical] the API server is reached over plain HTTP"}, problems("http"))
+
+	var sb strings.Builder
+	writeKubeconfigCredentialAudit(&sb, audits, now)
+	text := sb.String()
+	assert.Contains(t, text, "| insecure | token | - | - | - | ⚠️ skip-verify |")
+	assert.Contains(t, text, "📛 gke (exec: gke-gcloud-auth-plugin)")
+	assert.Contains(t, text, "Fix: gcloud components install gke-gcloud-auth-plugin")
+}
@@ -0,0 +1,474 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Meshes diagnose_service_mesh recognizes.
+const (
+	meshIstio   = "istio"
+	meshLinkerd = "linkerd"
+)
+
+const (
+	istioProxyContainer   = "istio-proxy"
+	linkerdProxyContainer = "linkerd-proxy"
+	// istiodSelector and linkerdSelector find the control plane
+	// Deployments whose images carry the mesh version.
+	istiodSelector  = "app=istiod"
+	linkerdSelector = "linkerd.io/control-plane-component=destination"
+)
+
+var peerAuthenticationGVR = schema.GroupVersionResource{Group: "security.istio.io", Version: "v1beta1", Resource: "peerauthentications"}
+
+// MeshControlPlane is one installed mesh control plane.
+type MeshControlPlane struct {
+	Mesh      string `json:"mesh"`
+	Namespace string `json:"namespace"`
+	Revision  string `json:"revision,omitempty"`
+	Version   string `json:"version"`
+}
+
+// NamespaceMeshCoverage is the sidecar injection and mTLS state of one
+// namespace.
+type NamespaceMeshCoverage struct {
+	Namespace string `json:"namespace"`
+	Mesh      string `json:"mesh,omitempty"`
+	// Injection is enabled, disabled, or empty when the namespace does
+	// not say.
+	Injection string `json:"injection,omitempty"`
+	Pods      int    `json:"pods"`
+	Meshed    int    `json:"meshed"`
+	MTLS      string `json:"mtls,omitempty"`
+}
+
+// MeshWorkload is a workload with a sidecar problem.
+type MeshWorkload struct {
+	Namespace    string `json:"namespace"`
+	Workload     string `json:"workload"`
+	Mesh         string `json:"mesh"`
+	Pods         int    `json:"pods"`
+	ProxyVersion string `json:"proxyVersion,omitempty"`
+}
+
+// MeshReport is the service mesh state of one cluster.
+type MeshReport struct {
+	ControlPlanes   []MeshControlPlane      `json:"controlPlanes"`
+	MeshWideMTLS    string                  `json:"meshWideMTLS,omitempty"`
+	Namespaces      []NamespaceMeshCoverage `json:"namespaces"`
+	MissingSidecars []MeshWorkload          `json:"missingSidecars,omitempty"`
+	VersionSkew     []MeshWorkload          `json:"versionSkew,omitempty"`
+}
+
+// imageTag returns the tag of image without a registry-specific variant
+// suffix, such as Istio's -distroless.
+func imageTag(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return ""
+	}
+	return strings.TrimSuffix(image[i+1:], "-distroless")
+}
+
+// findMeshControlPlanes returns the Istio revisions and Linkerd control
+// planes among deployments.
+func findMeshControlPlanes(istiods, linkerds []appsv1.Deployment) []MeshControlPlane {
+	var planes []MeshControlPlane
+	for _, d := range istiods {
+		cp := MeshControlPlane{Mesh: meshIstio, Namespace: d.Namespace, Revision: d.Labels["istio.io/rev"]}
+		if cp.Revision == "" {
+			cp.Revision = "default"
+		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			if c.Name == "discovery" || cp.Version == "" {
+				cp.Version = imageTag(c.Image)
+			}
+		}
+		planes = append(planes, cp)
+	}
+	for _, d := range linkerds {
+		cp := MeshControlPlane{Mesh: meshLinkerd, Namespace: d.Namespace, Version: d.Labels["linkerd.io/control-plane-version"]}
+		if cp.Version == "" && len(d.Spec.Template.Spec.Containers) > 0 {
+			cp.Version = imageTag(d.Spec.Template.Spec.Containers[0].Image)
+		}
+		planes = append(planes, cp)
+	}
+	sort.Slice(planes, func(i, j int) bool {
+		if planes[i].Mesh != planes[j].Mesh {
+			return planes[i].Mesh < planes[j].Mesh
+		}
+		return planes[i].Revision < planes[j].Revision
+	})
+	return planes
+}
+
+// namespaceInjection returns the mesh a namespace asks to be injected by
+// and whether injection is enabled or disabled.
+func namespaceInjection(ns *corev1.Namespace) (mesh, injection string) {
+	switch {
+	case ns.Labels["istio-injection"] == "enabled" || ns.Labels["istio.io/rev"] != "":
+		return meshIstio, "enabled"
+	case ns.Labels["istio-injection"] == "disabled":
+		return meshIstio, "disabled"
+	case ns.Annotations["linkerd.io/inject"] == "enabled":
+		return meshLinkerd, "enabled"
+	case ns.Annotations["linkerd.io/inject"] == "disabled":
+		return meshLinkerd, "disabled"
+	}
+	return "", ""
+}
+
+// podProxy returns the mesh whose sidecar pod runs and the sidecar's
+// version. Native sidecars run as init containers.
+func podProxy(pod *corev1.Pod) (mesh, version string) {
+	containers := append(slices.Clone(pod.Spec.Containers), pod.Spec.InitContainers...)
+	for _, c := range containers {
+		switch c.Name {
+		case istioProxyContainer:
+			return meshIstio, imageTag(c.Image)
+		case linkerdProxyContainer:
+			if v := pod.Labels["linkerd.io/proxy-version"]; v != "" {
+				return meshLinkerd, v
+			}
+			return meshLinkerd, imageTag(c.Image)
+		}
+	}
+	return "", ""
+}
+
+// podWantsProxy returns the mesh that should have injected pod, given its
+// namespace's injection setting, or "" if none should.
+func podWantsProxy(pod *corev1.Pod, nsMesh, nsInjection string) string {
+	if pod.Spec.HostNetwork {
+		return ""
+	}
+	istioInject := pod.Labels["sidecar.istio.io/inject"]
+	if istioInject == "" {
+		istioInject = pod.Annotations["sidecar.istio.io/inject"]
+	}
+	linkerdInject := pod.Annotations["linkerd.io/inject"]
+	switch {
+	case istioInject == "true":
+		return meshIstio
+	case linkerdInject == "enabled":
+		return meshLinkerd
+	case nsInjection != "enabled":
+		return ""
+	case nsMesh == meshIstio && istioInject != "false":
+		return meshIstio
+	case nsMesh == meshLinkerd && linkerdInject != "disabled":
+		return meshLinkerd
+	}
+	return ""
+}
+
+// podWorkload names the workload that owns pod, folding ReplicaSets into
+// their Deployment.
+func podWorkload(pod *corev1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod/" + pod.Name
+	}
+	if owner.Kind == "ReplicaSet" {
+		if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+			return "Deployment/" + strings.TrimSuffix(owner.Name, "-"+hash)
+		}
+	}
+	return owner.Kind + "/" + owner.Name
+}
+
+// peerAuthenticationModes returns the mesh-wide mTLS mode set in the Istio
+// root namespace and the mode each namespace sets, ignoring
+// workload-specific policies.
+func peerAuthenticationModes(policies []unstructured.Unstructured, rootNamespace string) (meshWide string, byNamespace map[string]string) {
+	byNamespace = make(map[string]string)
+	for i := range policies {
+		p := &policies[i]
+		if _, hasSelector, _ := unstructured.NestedMap(p.Object, "spec", "selector"); hasSelector {
+			continue
+		}
+		mode, _, _ := unstructured.NestedString(p.Object, "spec", "mtls", "mode")
+		if mode == "" || mode == "UNSET" {
+			continue
+		}
+		if p.GetNamespace() == rootNamespace {
+			meshWide = mode
+		} else {
+			byNamespace[p.GetNamespace()] = mode
+		}
+	}
+	return meshWide, byNamespace
+}
+
+// meshSystemNamespace reports namespaces whose pods are not expected to
+// carry sidecars.
+func meshSystemNamespace(name string, planes []MeshControlPlane) bool {
+	if strings.HasPrefix(name, "kube-") || strings.HasPrefix(name, "linkerd") {
+		return true
+	}
+	for _, cp := range planes {
+		if cp.Namespace == name {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeMesh builds the mesh report of a cluster. policies are Istio
+// PeerAuthentications; policiesErr reports they could not be listed.
+func analyzeMesh(namespaces []corev1.Namespace, pods []corev1.Pod, planes []MeshControlPlane, policies []unstructured.Unstructured, policiesErr error) *MeshReport {
+	report := &MeshReport{ControlPlanes: planes}
+	versions := make(map[string][]string)
+	istioRoot := ""
+	for _, cp := range planes {
+		versions[cp.Mesh] = append(versions[cp.Mesh], cp.Version)
+		if cp.Mesh == meshIstio && (istioRoot == "" || cp.Revision == "default") {
+			istioRoot = cp.Namespace
+		}
+	}
+
+	var nsModes map[string]string
+	if istioRoot != "" {
+		if policiesErr != nil {
+			report.MeshWideMTLS = "unknown"
+		} else if report.MeshWideMTLS, nsModes = peerAuthenticationModes(policies, istioRoot); report.MeshWideMTLS == "" {
+			report.MeshWideMTLS = "PERMISSIVE (default)"
+		}
+	}
+
+	coverage := make(map[string]*NamespaceMeshCoverage)
+	for i := range namespaces {
+		ns := &namespaces[i]
+		if meshSystemNamespace(ns.Name, planes) {
+			continue
+		}
+		c := &NamespaceMeshCoverage{Namespace: ns.Name}
+		c.Mesh, c.Injection = namespaceInjection(ns)
+		coverage[ns.Name] = c
+	}
+
+	missing := make(map[string]*MeshWorkload)
+	skewed := make(map[string]*MeshWorkload)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		c := coverage[pod.Namespace]
+		if c == nil {
+			continue
+		}
+		c.Pods++
+		key := pod.Namespace + "/" + podWorkload(pod)
+		mesh, version := podProxy(pod)
+		if mesh == "" {
+			if want := podWantsProxy(pod, c.Mesh, c.Injection); want != "" && len(versions[want]) > 0 {
+				w := missing[key]
+				if w == nil {
+					w = &MeshWorkload{Namespace: pod.Namespace, Workload: podWorkload(pod), Mesh: want}
+					missing[key] = w
+				}
+				w.Pods++
+			}
+			continue
+		}
+		c.Meshed++
+		if c.Mesh == "" {
+			c.Mesh = mesh
+		}
+		if version != "" && len(versions[mesh]) > 0 && !slices.Contains(versions[mesh], version) {
+			w := skewed[key]
+			if w == nil {
+				w = &MeshWorkload{Namespace: pod.Namespace, Workload: podWorkload(pod), Mesh: mesh, ProxyVersion: version}
+				skewed[key] = w
+			}
+			w.Pods++
+		}
+	}
+
+	for _, c := range coverage {
+		if c.Pods == 0 && c.Injection == "" {
+			continue
+		}
+		switch {
+		case c.Mesh == meshLinkerd && c.Meshed > 0:
+			c.MTLS = "automatic"
+		case c.Mesh == meshIstio || (istioRoot != "" && c.Meshed > 0):
+			c.MTLS = report.MeshWideMTLS
+			if mode := nsModes[c.Namespace]; mode != "" {
+				c.MTLS = mode
+			}
+		}
+		report.Namespaces = append(report.Namespaces, *c)
+	}
+	sort.Slice(report.Namespaces, func(i, j int) bool { return report.Namespaces[i].Namespace < report.Namespaces[j].Namespace })
+	report.MissingSidecars = sortedMeshWorkloads(missing)
+	report.VersionSkew = sortedMeshWorkloads(skewed)
+	return report
+}
+
+func sortedMeshWorkloads(m map[string]*MeshWorkload) []MeshWorkload {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	workloads := make([]MeshWorkload, 0, len(keys))
+	for _, k := range keys {
+		workloads = append(workloads, *m[k])
+	}
+	return workloads
+}
+
+func (s *Server) meshReport(ctx context.Context, client kubernetes.Interface, clusterName, namespace string) (*MeshReport, error) {
+	istiods, err := client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{LabelSelector: istiodSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list istiod deployments: %w", err)
+	}
+	linkerds, err := client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{LabelSelector: linkerdSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list linkerd deployments: %w", err)
+	}
+	planes := findMeshControlPlanes(istiods.Items, linkerds.Items)
+	if len(planes) == 0 {
+		return &MeshReport{}, nil
+	}
+
+	var namespaces []corev1.Namespace
+	if namespace != "" {
+		ns, err := client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get namespace: %w", err)
+		}
+		namespaces = []corev1.Namespace{*ns}
+	} else {
+		list, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		namespaces = list.Items
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: activePodsFieldSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var policies []unstructured.Unstructured
+	var policiesErr error
+	if slices.ContainsFunc(planes, func(cp MeshControlPlane) bool { return cp.Mesh == meshIstio }) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err == nil {
+			var list *unstructured.UnstructuredList
+			if list, err = dyn.Resource(peerAuthenticationGVR).List(ctx, metav1.ListOptions{}); err == nil {
+				policies = list.Items
+			}
+		}
+		policiesErr = err
+	}
+	return analyzeMesh(namespaces, pods.Items, planes, policies, policiesErr), nil
+}
+
+func (s *Server) toolDiagnoseServiceMesh(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return s.meshReport(ctx, client, clusterName, namespace)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to diagnose service mesh: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Service Mesh Diagnosis\n")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		writeMeshReport(&sb, r.Result.(*MeshReport))
+	}
+	return sb.String(), false
+}
+
+func writeMeshReport(sb *strings.Builder, report *MeshReport) {
+	if len(report.ControlPlanes) == 0 {
+		sb.WriteString("No service mesh detected (no istiod or Linkerd control plane).\n")
+		return
+	}
+	for _, cp := range report.ControlPlanes {
+		name := "Istio"
+		if cp.Mesh == meshLinkerd {
+			name = "Linkerd"
+		}
+		_, _ = fmt.Fprintf(sb, "**Mesh:** %s %s in `%s`", name, cp.Version, cp.Namespace)
+		if cp.Revision != "" {
+			_, _ = fmt.Fprintf(sb, " (revision %s)", cp.Revision)
+		}
+		sb.WriteString("\n")
+	}
+	if report.MeshWideMTLS != "" {
+		_, _ = fmt.Fprintf(sb, "**Mesh-wide mTLS:** %s\n", report.MeshWideMTLS)
+	}
+
+	if len(report.Namespaces) > 0 {
+		sb.WriteString("\n| Namespace | Injection | Sidecars | mTLS |\n")
+		sb.WriteString("|-----------|-----------|----------|------|\n")
+		var plaintext []string
+		for _, c := range report.Namespaces {
+			injection := c.Injection
+			if injection == "" {
+				injection = "-"
+			} else if c.Mesh != "" {
+				injection = c.Mesh + " " + injection
+			}
+			mtls := c.MTLS
+			if mtls == "" {
+				mtls = "-"
+			}
+			_, _ = fmt.Fprintf(sb, "| %s | %s | %d/%d | %s |\n", c.Namespace, injection, c.Meshed, c.Pods, mtls)
+			if c.Meshed > 0 && (strings.HasPrefix(c.MTLS, "PERMISSIVE") || c.MTLS == "DISABLE") {
+				plaintext = append(plaintext, c.Namespace)
+			}
+		}
+		if len(plaintext) > 0 {
+			_, _ = fmt.Fprintf(sb, "\n⚠️ %d meshed namespaces accept plaintext traffic: %s. Set a STRICT PeerAuthentication once every client has a sidecar.\n",
+				len(plaintext), strings.Join(plaintext, ", "))
+		}
+	}
+
+	if len(report.MissingSidecars) > 0 {
+		sb.WriteString("\n### Workloads Missing Sidecars\n\n")
+		for _, w := range report.MissingSidecars {
+			_, _ = fmt.Fprintf(sb, "- %s/%s: %d pods without the %s proxy\n", w.Namespace, w.Workload, w.Pods, w.Mesh)
+		}
+		sb.WriteString("\nInjection happens at pod creation; restart these workloads to inject the proxy.\n")
+	}
+	if len(report.VersionSkew) > 0 {
+		sb.WriteString("\n### Proxy Version Skew\n\n")
+		for _, w := range report.VersionSkew {
+			_, _ = fmt.Fprintf(sb, "- %s/%s: %d pods run %s proxy %s\n", w.Namespace, w.Workload, w.Pods, w.Mesh, w.ProxyVersion)
+		}
+		sb.WriteString("\nThese proxies do not match any control plane version; restart the workloads to pick up the current proxy.\n")
+	}
+	if len(report.MissingSidecars) == 0 && len(report.VersionSkew) == 0 {
+		sb.WriteString("\n✅ Every workload that should have a sidecar has a current one.\n")
+	}
+}
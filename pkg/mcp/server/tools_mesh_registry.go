@@ -0,0 +1,27 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "diagnose_service_mesh",
+		Description: "Detect Istio and Linkerd, and report per namespace the sidecar injection setting, sidecar coverage, and effective mTLS mode, plus workloads missing sidecars and proxies whose version skews from the control plane, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to check (all namespaces if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolDiagnoseServiceMesh(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+func meshNamespace(name string, labels, annotations map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels, Annotations: annotations}}
+}
+
+// meshPod returns a running pod of Deployment deploy, with a sidecar
+// container named proxy running image, if set.
+func meshPod(namespace, deploy, suffix, proxy, image string) *corev1.Pod {
+	controller := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: deploy + "-5d9f-" + suffix, Namespace: namespace,
+			Labels:          map[string]string{appsv1.DefaultDeploymentUniqueLabelKey: "5d9f"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: deploy + "-5d9f", Controller: &controller}},
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "app", Image: "app:v1"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if proxy != "" {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: proxy, Image: image})
+	}
+	return pod
+}
+
+func controlPlaneDeployment(namespace, name string, labels map[string]string, container, image string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: container, Image: image}},
+		}}},
+	}
+}
+
+func peerAuthentication(namespace, mode string, selector bool) *unstructured.Unstructured {
+	spec := map[string]interface{}{"mtls": map[string]interface{}{"mode": mode}}
+	if selector {
+		spec["selector"] = map[string]interface{}{"matchLabels": map[string]interface{}{"app": "legacy"}}
+	}
+	pa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "security.istio.io/v1beta1", "kind": "PeerAuthentication", "spec": spec,
+	}}
+	pa.SetNamespace(namespace)
+	pa.SetName("default")
+	return pa
+}
+
+func TestImageTag(t *testing.T) {
+	assert.Equal(t, "1.20.3", imageTag("docker.io/istio/proxyv2:1.20.3"))
+	assert.Equal(t, "1.20.3", imageTag("gcr.io/istio-release/proxyv2:1.20.3-distroless"))
+	assert.Equal(t, "stable-2.14.10", imageTag("cr.l5d.io/linkerd/proxy:stable-2.14.10@sha256:abc"))
+	assert.Equal(t, "", imageTag("registry:5000/istio/proxyv2"))
+}
+
+func TestAnalyzeMeshIstio(t *testing.T) {
+	istiod := controlPlaneDeployment("istio-system", "istiod", map[string]string{"app": "istiod"}, "discovery", "docker.io/istio/pilot:1.20.3")
+	planes := findMeshControlPlanes([]appsv1.Deployment{*istiod}, nil)
+	require.Equal(t, []MeshControlPlane{{Mesh: meshIstio, Namespace: "istio-system", Revision: "default", Version: "1.20.3"}}, planes)
+
+	namespaces := []corev1.Namespace{
+		*meshNamespace("istio-system", nil, nil),
+		*meshNamespace("kube-system", nil, nil),
+		*meshNamespace("shop", map[string]string{"istio-injection": "enabled"}, nil),
+		*meshNamespace("legacy", map[string]string{"istio-injection": "disabled"}, nil),
+		*meshNamespace("batch", nil, nil),
+	}
+	optedOut := meshPod("shop", "migrate", "a", "", "")
+	optedOut.Annotations = map[string]string{"sidecar.istio.io/inject": "false"}
+	pods := []corev1.Pod{
+		*meshPod("istio-system", "istiod", "a", "", ""),
+		*meshPod("shop", "web", "a", istioProxyContainer, "docker.io/istio/proxyv2:1.20.3"),
+		*meshPod("shop", "web", "b", "", ""),
+		*meshPod("shop", "api", "a", istioProxyContainer, "docker.io/istio/proxyv2:1.19.5"),
+		*meshPod("shop", "api", "b", istioProxyContainer, "docker.io/istio/proxyv2:1.19.5"),
+		*optedOut,
+		*meshPod("legacy", "app", "a", "", ""),
+	}
+	policies := []unstructured.Unstructured{
+		*peerAuthentication("istio-system", "STRICT", false),
+		*peerAuthentication("shop", "PERMISSIVE", false),
+		*peerAuthentication("legacy", "DISABLE", true),
+	}
+
+	report := analyzeMesh(namespaces, pods, planes, policies, nil)
+	assert.Equal(t, "STRICT", report.MeshWideMTLS)
+	assert.Equal(t, []NamespaceMeshCoverage{
+		{Namespace: "legacy", Mesh: meshIstio, Injection: "disabled", Pods: 1, MTLS: "STRICT"},
+		{Namespace: "shop", Mesh: meshIstio, Injection: "enabled", Pods: 5, Meshed: 3, MTLS: "PERMISSIVE"},
+	}, report.Namespaces, "namespaces without pods or injection and the mesh's own are left out")
+	assert.Equal(t, []MeshWorkload{{Namespace: "shop", Workload: "Deployment/web", Mesh: meshIstio, Pods: 1}}, report.MissingSidecars)
+	assert.Equal(t, []MeshWorkload{{Namespace: "shop", Workload: "Deployment/api", Mesh: meshIstio, Pods: 2, ProxyVersion: "1.19.5"}}, report.VersionSkew)
+
+	report = analyzeMesh(namespaces, pods, planes, nil, nil)
+	assert.Equal(t, "PERMISSIVE (default)", report.MeshWideMTLS)
+	report = analyzeMesh(namespaces, pods, planes, nil, assert.AnError)
+	assert.Equal(t, "unknown", report.MeshWideMTLS)
+}
+
+func TestAnalyzeMeshLinkerd(t *testing.T) {
+	destination := controlPlaneDeployment("linkerd", "linkerd-destination",
+		map[string]string{"linkerd.io/control-plane-version": "stable-2.14.10"}, "destination", "cr.l5d.io/linkerd/controller:stable-2.14.10")
+	planes := findMeshControlPlanes(nil, []appsv1.Deployment{*destination})
+
+	meshed := meshPod("payments", "ledger", "a", linkerdProxyContainer, "cr.l5d.io/linkerd/proxy:stable-2.14.10")
+	stale := meshPod("payments", "ledger", "b", linkerdProxyContainer, "cr.l5d.io/linkerd/proxy:stable-2.13.0")
+	stale.Labels["linkerd.io/proxy-version"] = "stable-2.13.0"
+	report := analyzeMesh(
+		[]corev1.Namespace{*meshNamespace("payments", nil, map[string]string{"linkerd.io/inject": "enabled"})},
+		[]corev1.Pod{*meshed, *stale, *meshPod("payments", "cron", "a", "", "")},
+		planes, nil, nil)
+
+	assert.Empty(t, report.MeshWideMTLS, "mesh-wide mTLS is an Istio setting")
+	assert.Equal(t, []NamespaceMeshCoverage{{Namespace: "payments", Mesh: meshLinkerd, Injection: "enabled", Pods: 3, Meshed: 2, MTLS: "automatic"}}, report.Namespaces)
+	assert.Equal(t, []MeshWorkload{{Namespace: "payments", Workload: "Deployment/cron", Mesh: meshLinkerd, Pods: 1}}, report.MissingSidecars)
+	assert.Equal(t, "stable-2.13.0", report.VersionSkew[0].ProxyVersion)
+}
+
+func TestDiagnoseServiceMeshPerCluster(t *testing.T) {
+	istiod := controlPlaneDeployment("istio-system", "istiod", map[string]string{"app": "istiod"}, "discovery", "docker.io/istio/pilot:1.20.3")
+	clients := map[string]kubernetes.Interface{
+		"alpha": k8sfake.NewSimpleClientset(istiod,
+			meshNamespace("shop", map[string]string{"istio-injection": "enabled"}, nil),
+			meshPod("shop", "web", "a", istioProxyContainer, "docker.io/istio/proxyv2:1.20.3"),
+			meshPod("shop", "web", "b", "", "")),
+		"beta": k8sfake.NewSimpleClientset(meshNamespace("shop", nil, nil), meshPod("shop", "web", "a", "", "")),
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{peerAuthenticationGVR: "PeerAuthenticationList"},
+		peerAuthentication("istio-system", "STRICT", false))
+	infos := []cluster.ClusterInfo{{Name: "alpha", Context: "alpha"}, {Name: "beta", Context: "beta"}}
+	server := &Server{
+		discoverer:           stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory:        func(name string) (kubernetes.Interface, error) { return clients[name], nil },
+		dynamicClientFactory: func(string) (dynamic.Interface, error) { return dyn, nil },
+	}
+
+	result, rpcErr := callTool(t, server, "diagnose_service_mesh", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "## alpha\n\n**Mesh:** Istio 1.20.3 in `istio-system` (revision default)\n**Mesh-wide mTLS:** STRICT\n")
+	assert.Contains(t, text, "| shop | istio enabled | 1/2 | STRICT |")
+	assert.Contains(t, text, "- shop/Deployment/web: 1 pods without the istio proxy")
+	assert.Contains(t, text, "## beta\n\nNo service mesh detected")
+}
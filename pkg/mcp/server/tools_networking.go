@@ -0,0 +1,495 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	submarinerGatewayGVR = schema.GroupVersionResource{Group: "submariner.io", Version: "v1", Resource: "gateways"}
+	serviceExportGVR     = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceexports"}
+	serviceImportGVR     = schema.GroupVersionResource{Group: "multicluster.x-k8s.io", Version: "v1alpha1", Resource: "serviceimports"}
+)
+
+const (
+	// clusterMeshSelector finds the Cilium ClusterMesh API server.
+	clusterMeshSelector = "k8s-app=clustermesh-apiserver"
+	// clusterMeshSecret holds the connection settings of each remote
+	// cluster, keyed by cluster name.
+	clusterMeshSecret = "cilium-clustermesh"
+	ciliumConfigMap   = "cilium-config"
+	// Annotations of Services shared across a Cilium ClusterMesh.
+	ciliumGlobalAnnotation       = "service.cilium.io/global"
+	ciliumLegacyGlobalAnnotation = "io.cilium/global-service"
+)
+
+// GatewayConnection is a Submariner gateway's tunnel to a remote cluster.
+type GatewayConnection struct {
+	Cluster    string `json:"cluster"`
+	Endpoint   string `json:"endpoint,omitempty"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+	LatencyRTT string `json:"latencyRTT,omitempty"`
+}
+
+// SubmarinerGateway is one Submariner gateway node.
+type SubmarinerGateway struct {
+	Node        string              `json:"node"`
+	HAStatus    string              `json:"haStatus"`
+	Failure     string              `json:"failure,omitempty"`
+	Connections []GatewayConnection `json:"connections"`
+}
+
+// SubmarinerStatus is the Submariner state of one cluster.
+type SubmarinerStatus struct {
+	ClusterID string              `json:"clusterID,omitempty"`
+	Gateways  []SubmarinerGateway `json:"gateways"`
+}
+
+// ClusterMeshStatus is the Cilium ClusterMesh state of one cluster.
+type ClusterMeshStatus struct {
+	Namespace      string   `json:"namespace"`
+	ClusterName    string   `json:"clusterName,omitempty"`
+	APIServerReady int32    `json:"apiServerReady"`
+	APIServerWant  int32    `json:"apiServerWant"`
+	RemoteClusters []string `json:"remoteClusters"`
+	GlobalServices []string `json:"globalServices,omitempty"`
+}
+
+// MultiClusterService is an exported or imported service of the
+// Multi-Cluster Services API, and what is wrong with it.
+type MultiClusterService struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Clusters  []string `json:"clusters,omitempty"`
+	Problem   string   `json:"problem,omitempty"`
+}
+
+// NetworkReport is the multi-cluster networking state of one cluster.
+type NetworkReport struct {
+	Submariner  *SubmarinerStatus     `json:"submariner,omitempty"`
+	ClusterMesh *ClusterMeshStatus    `json:"clusterMesh,omitempty"`
+	Exports     []MultiClusterService `json:"exports,omitempty"`
+	Imports     []MultiClusterService `json:"imports,omitempty"`
+	Warnings    []string              `json:"warnings,omitempty"`
+}
+
+// detected reports whether any multi-cluster networking was found.
+func (r *NetworkReport) detected() bool {
+	return r.Submariner != nil || r.ClusterMesh != nil || len(r.Exports) > 0 || len(r.Imports) > 0
+}
+
+// listMultiCluster lists the objects of gvr, or none if its API is not
+// installed.
+func listMultiCluster(ctx context.Context, dyn dynamic.Interface, gvr schema.GroupVersionResource) ([]unstructured.Unstructured, error) {
+	list, err := dyn.Resource(gvr).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", gvr.GroupResource(), err)
+	}
+	items := list.Items
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+	return items, nil
+}
+
+// submarinerStatus reads the status Submariner's gateway engine writes to
+// its Gateway objects.
+func submarinerStatus(gateways []unstructured.Unstructured) *SubmarinerStatus {
+	status := &SubmarinerStatus{}
+	for i := range gateways {
+		gw := &gateways[i]
+		g := SubmarinerGateway{Node: gw.GetName()}
+		g.HAStatus, _, _ = unstructured.NestedString(gw.Object, "status", "haStatus")
+		g.Failure, _, _ = unstructured.NestedString(gw.Object, "status", "statusFailure")
+		if id, _, _ := unstructured.NestedString(gw.Object, "status", "localEndpoint", "cluster_id"); id != "" {
+			status.ClusterID = id
+		}
+		connections, _, _ := unstructured.NestedSlice(gw.Object, "status", "connections")
+		for _, c := range connections {
+			m, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			conn := GatewayConnection{}
+			conn.Status, _, _ = unstructured.NestedString(m, "status")
+			conn.Message, _, _ = unstructured.NestedString(m, "statusMessage")
+			conn.Cluster, _, _ = unstructured.NestedString(m, "endpoint", "cluster_id")
+			conn.Endpoint, _, _ = unstructured.NestedString(m, "endpoint", "hostname")
+			conn.LatencyRTT, _, _ = unstructured.NestedString(m, "latencyRTT", "average")
+			g.Connections = append(g.Connections, conn)
+		}
+		sort.Slice(g.Connections, func(i, j int) bool { return g.Connections[i].Cluster < g.Connections[j].Cluster })
+		status.Gateways = append(status.Gateways, g)
+	}
+	return status
+}
+
+// serviceExports reports ServiceExports and why any is not exported.
+func serviceExports(exports []unstructured.Unstructured) []MultiClusterService {
+	services := make([]MultiClusterService, 0, len(exports))
+	for i := range exports {
+		export := &exports[i]
+		svc := MultiClusterService{Namespace: export.GetNamespace(), Name: export.GetName()}
+		for _, condType := range []string{"Valid", "Ready", "Synced"} {
+			if status, reason, message := statusCondition(export, condType); status == string(metav1.ConditionFalse) {
+				svc.Problem = conditionProblem(condType+"=False", reason, message)
+				break
+			}
+		}
+		if status, reason, message := statusCondition(export, "Conflict"); svc.Problem == "" && status == string(metav1.ConditionTrue) {
+			svc.Problem = conditionProblem("Conflict", reason, message)
+		}
+		services = append(services, svc)
+	}
+	return services
+}
+
+func conditionProblem(condition, reason, message string) string {
+	problem := condition
+	if reason != "" {
+		problem += " (" + reason + ")"
+	}
+	if message != "" {
+		problem += ": " + message
+	}
+	return problem
+}
+
+// serviceImports reports ServiceImports with the clusters backing them.
+// An import no cluster backs resolves to nothing.
+func serviceImports(imports []unstructured.Unstructured) []MultiClusterService {
+	services := make([]MultiClusterService, 0, len(imports))
+	for i := range imports {
+		imp := &imports[i]
+		svc := MultiClusterService{Namespace: imp.GetNamespace(), Name: imp.GetName()}
+		clusters, _, _ := unstructured.NestedSlice(imp.Object, "status", "clusters")
+		for _, c := range clusters {
+			if m, ok := c.(map[string]interface{}); ok {
+				if name, _ := m["cluster"].(string); name != "" {
+					svc.Clusters = append(svc.Clusters, name)
+				}
+			}
+		}
+		sort.Strings(svc.Clusters)
+		if len(svc.Clusters) == 0 {
+			svc.Problem = "no cluster exports this service"
+		}
+		if ports, _, _ := unstructured.NestedSlice(imp.Object, "spec", "ports"); len(ports) == 0 && svc.Problem == "" {
+			svc.Problem = "no ports"
+		}
+		services = append(services, svc)
+	}
+	return services
+}
+
+// clusterMeshStatus reports the Cilium ClusterMesh API server, the
+// clusters this cluster connects to, and its global services, with
+// warnings about what it could not read. It returns nil if no ClusterMesh
+// API server runs.
+func clusterMeshStatus(ctx context.Context, client kubernetes.Interface) (*ClusterMeshStatus, []string, error) {
+	deployments, err := client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{LabelSelector: clusterMeshSelector})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list clustermesh-apiserver deployments: %w", err)
+	}
+	if len(deployments.Items) == 0 {
+		return nil, nil, nil
+	}
+	d := deployments.Items[0]
+	status := &ClusterMeshStatus{Namespace: d.Namespace, APIServerReady: d.Status.ReadyReplicas, APIServerWant: 1}
+	if d.Spec.Replicas != nil {
+		status.APIServerWant = *d.Spec.Replicas
+	}
+
+	var warnings []string
+	if cm, err := client.CoreV1().ConfigMaps(d.Namespace).Get(ctx, ciliumConfigMap, metav1.GetOptions{}); err == nil {
+		status.ClusterName = cm.Data["cluster-name"]
+	}
+	secret, err := client.CoreV1().Secrets(d.Namespace).Get(ctx, clusterMeshSecret, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		warnings = append(warnings, fmt.Sprintf("cannot read remote clusters from secret %s/%s: %v", d.Namespace, clusterMeshSecret, err))
+	default:
+		status.RemoteClusters = remoteClusters(secret)
+	}
+
+	services, err := client.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("cannot list global services: %v", err))
+	} else {
+		status.GlobalServices = globalServices(services.Items)
+	}
+	return status, warnings, nil
+}
+
+// remoteClusters returns the clusters a cilium-clustermesh secret
+// configures. Keys holding TLS material for a cluster have a dotted
+// suffix, as cluster names cannot.
+func remoteClusters(secret *corev1.Secret) []string {
+	clusters := []string{}
+	for key := range secret.Data {
+		if !strings.Contains(key, ".") {
+			clusters = append(clusters, key)
+		}
+	}
+	sort.Strings(clusters)
+	return clusters
+}
+
+// globalServices returns the Services shared across the ClusterMesh.
+func globalServices(services []corev1.Service) []string {
+	var global []string
+	for _, svc := range services {
+		if svc.Annotations[ciliumGlobalAnnotation] == "true" || svc.Annotations[ciliumLegacyGlobalAnnotation] == "true" {
+			global = append(global, svc.Namespace+"/"+svc.Name)
+		}
+	}
+	sort.Strings(global)
+	return global
+}
+
+func (s *Server) networkReport(ctx context.Context, client kubernetes.Interface, clusterName string) (*NetworkReport, error) {
+	report := &NetworkReport{}
+	mesh, warnings, err := clusterMeshStatus(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	report.ClusterMesh = mesh
+	report.Warnings = warnings
+
+	dyn, err := s.getDynamicClientForCluster(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	// Submariner's gateway engine creates a Gateway per gateway node.
+	gateways, err := listMultiCluster(ctx, dyn, submarinerGatewayGVR)
+	if err != nil {
+		report.Warnings = append(report.Warnings, err.Error())
+	} else if len(gateways) > 0 {
+		report.Submariner = submarinerStatus(gateways)
+	}
+	exports, err := listMultiCluster(ctx, dyn, serviceExportGVR)
+	if err != nil {
+		report.Warnings = append(report.Warnings, err.Error())
+	}
+	report.Exports = serviceExports(exports)
+	imports, err := listMultiCluster(ctx, dyn, serviceImportGVR)
+	if err != nil {
+		report.Warnings = append(report.Warnings, err.Error())
+	}
+	report.Imports = serviceImports(imports)
+	return report, nil
+}
+
+// fleetNetworkIssues cross-checks the clusters' reports: every Submariner
+// cluster's active gateway should have a connected tunnel to every other
+// Submariner cluster, and ClusterMesh connections should go both ways.
+func fleetNetworkIssues(reports map[string]*NetworkReport) []string {
+	var issues []string
+	clusters := make([]string, 0, len(reports))
+	for name := range reports {
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters)
+
+	var submarinerIDs []string
+	for _, name := range clusters {
+		if sm := reports[name].Submariner; sm != nil && sm.ClusterID != "" {
+			submarinerIDs = append(submarinerIDs, sm.ClusterID)
+		}
+	}
+	for _, name := range clusters {
+		sm := reports[name].Submariner
+		if sm == nil || sm.ClusterID == "" {
+			continue
+		}
+		connected := make(map[string]bool)
+		active := false
+		for _, gw := range sm.Gateways {
+			if gw.HAStatus != "active" {
+				continue
+			}
+			active = true
+			for _, c := range gw.Connections {
+				connected[c.Cluster] = c.Status == "connected"
+			}
+		}
+		if !active {
+			issues = append(issues, fmt.Sprintf("%s: no active Submariner gateway", name))
+			continue
+		}
+		for _, id := range submarinerIDs {
+			if id == sm.ClusterID {
+				continue
+			}
+			if up, ok := connected[id]; !ok {
+				issues = append(issues, fmt.Sprintf("%s: no Submariner tunnel to cluster %s", name, id))
+			} else if !up {
+				issues = append(issues, fmt.Sprintf("%s: Submariner tunnel to cluster %s is not connected", name, id))
+			}
+		}
+	}
+
+	meshByName := make(map[string]*ClusterMeshStatus)
+	for _, name := range clusters {
+		if cm := reports[name].ClusterMesh; cm != nil && cm.ClusterName != "" {
+			meshByName[cm.ClusterName] = cm
+		}
+	}
+	for _, name := range clusters {
+		cm := reports[name].ClusterMesh
+		if cm == nil || cm.ClusterName == "" {
+			continue
+		}
+		for _, remote := range cm.RemoteClusters {
+			peer, ok := meshByName[remote]
+			if ok && !slices.Contains(peer.RemoteClusters, cm.ClusterName) {
+				issues = append(issues, fmt.Sprintf("%s: ClusterMesh connects to %s, but %s does not connect back", name, remote, remote))
+			}
+		}
+	}
+	return issues
+}
+
+func (s *Server) toolGetMultiClusterNetworkStatus(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return s.networkReport(ctx, client, clusterName)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to get multi-cluster network status: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Multi-Cluster Networking\n")
+	reports := make(map[string]*NetworkReport)
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		report := r.Result.(*NetworkReport)
+		reports[r.Cluster] = report
+		writeNetworkReport(&sb, report)
+	}
+
+	if len(reports) > 1 {
+		sb.WriteString("\n## Fleet\n\n")
+		if issues := fleetNetworkIssues(reports); len(issues) > 0 {
+			for _, issue := range issues {
+				_, _ = fmt.Fprintf(&sb, "- ⚠️ %s\n", issue)
+			}
+		} else {
+			sb.WriteString("✅ Every Submariner and ClusterMesh cluster is connected to its peers.\n")
+		}
+	}
+	return sb.String(), false
+}
+
+func writeNetworkReport(sb *strings.Builder, report *NetworkReport) {
+	for _, w := range report.Warnings {
+		_, _ = fmt.Fprintf(sb, "warning: %s\n", w)
+	}
+	if !report.detected() {
+		sb.WriteString("No multi-cluster networking detected (no Submariner, Cilium ClusterMesh, or Multi-Cluster Services).\n")
+		return
+	}
+
+	if sm := report.Submariner; sm != nil {
+		_, _ = fmt.Fprintf(sb, "**Submariner:** cluster ID `%s`, %d gateways\n", sm.ClusterID, len(sm.Gateways))
+		if len(sm.Gateways) > 0 {
+			sb.WriteString("\n| Gateway | HA | Remote Cluster | Tunnel | RTT |\n")
+			sb.WriteString("|---------|----|----------------|--------|-----|\n")
+		}
+		for _, gw := range sm.Gateways {
+			if len(gw.Connections) == 0 {
+				_, _ = fmt.Fprintf(sb, "| %s | %s | - | - | - |\n", gw.Node, gw.HAStatus)
+			}
+			for _, c := range gw.Connections {
+				tunnel := c.Status
+				if c.Status != "connected" && c.Message != "" {
+					tunnel += ": " + c.Message
+				}
+				rtt := c.LatencyRTT
+				if rtt == "" {
+					rtt = "-"
+				}
+				_, _ = fmt.Fprintf(sb, "| %s | %s | %s | %s | %s |\n", gw.Node, gw.HAStatus, c.Cluster, tunnel, rtt)
+			}
+			if gw.Failure != "" {
+				_, _ = fmt.Fprintf(sb, "\n⚠️ Gateway %s: %s\n", gw.Node, gw.Failure)
+			}
+		}
+	}
+
+	if cm := report.ClusterMesh; cm != nil {
+		if report.Submariner != nil {
+			sb.WriteString("\n")
+		}
+		name := cm.ClusterName
+		if name == "" {
+			name = "unknown"
+		}
+		_, _ = fmt.Fprintf(sb, "**Cilium ClusterMesh:** cluster name `%s`, API server %d/%d ready in `%s`\n", name, cm.APIServerReady, cm.APIServerWant, cm.Namespace)
+		if len(cm.RemoteClusters) > 0 {
+			_, _ = fmt.Fprintf(sb, "**Remote clusters:** %s\n", strings.Join(cm.RemoteClusters, ", "))
+		} else {
+			sb.WriteString("**Remote clusters:** none configured\n")
+		}
+		if len(cm.GlobalServices) > 0 {
+			_, _ = fmt.Fprintf(sb, "**Global services:** %s\n", strings.Join(cm.GlobalServices, ", "))
+		}
+		if cm.APIServerReady < cm.APIServerWant {
+			sb.WriteString("\n⚠️ The ClusterMesh API server is not fully ready; remote clusters cannot sync this cluster's services.\n")
+		}
+	}
+
+	if len(report.Exports) > 0 || len(report.Imports) > 0 {
+		sb.WriteString("\n### Multi-Cluster Services\n\n")
+		sb.WriteString("| Service | Direction | Clusters | Status |\n")
+		sb.WriteString("|---------|-----------|----------|--------|\n")
+		var broken int
+		for _, svc := range report.Exports {
+			status := "✅"
+			if svc.Problem != "" {
+				status, broken = "⚠️ "+svc.Problem, broken+1
+			}
+			_, _ = fmt.Fprintf(sb, "| %s/%s | exported | - | %s |\n", svc.Namespace, svc.Name, status)
+		}
+		for _, svc := range report.Imports {
+			status := "✅"
+			if svc.Problem != "" {
+				status, broken = "⚠️ "+svc.Problem, broken+1
+			}
+			clusters := strings.Join(svc.Clusters, ", ")
+			if clusters == "" {
+				clusters = "-"
+			}
+			_, _ = fmt.Fprintf(sb, "| %s/%s | imported | %s | %s |\n", svc.Namespace, svc.Name, clusters, status)
+		}
+		if broken > 0 {
+			_, _ = fmt.Fprintf(sb, "\n⚠️ %d broken service exports or imports.\n", broken)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "get_multicluster_network_status",
+		Description: "Detect Submariner and Cilium ClusterMesh and report gateway health, tunnel status and latency to each remote cluster, ClusterMesh remote clusters and global services, and Multi-Cluster Services exports and imports with broken ones flagged, per cluster. Across clusters, flags missing or down tunnels and one-way ClusterMesh connections",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetMultiClusterNetworkStatus(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+// submarinerGateway returns the Gateway of node in cluster id, with a
+// connection to each of remotes in status.
+func submarinerGateway(id, node, haStatus string, remotes map[string]string) *unstructured.Unstructured {
+	var connections []interface{}
+	for remote, status := range remotes {
+		connections = append(connections, map[string]interface{}{
+			"status":        status,
+			"statusMessage": "handshake timed out",
+			"endpoint":      map[string]interface{}{"cluster_id": remote, "hostname": remote + "-gw"},
+			"latencyRTT":    map[string]interface{}{"average": "1.2ms"},
+		})
+	}
+	gw := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "submariner.io/v1", "kind": "Gateway",
+		"status": map[string]interface{}{
+			"haStatus":      haStatus,
+			"localEndpoint": map[string]interface{}{"cluster_id": id},
+			"connections":   connections,
+		},
+	}}
+	gw.SetNamespace("submariner-operator")
+	gw.SetName(node)
+	return gw
+}
+
+func mcsObject(kind, namespace, name string, status map[string]interface{}, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "multicluster.x-k8s.io/v1alpha1", "kind": kind, "spec": spec, "status": status,
+	}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// networkingDynamicClient returns a dynamic client holding objects. They
+// are added by resource: the fake would guess "gatewaies" for Gateways.
+func networkingDynamicClient(t *testing.T, objects ...*unstructured.Unstructured) dynamic.Interface {
+	gvrs := map[string]schema.GroupVersionResource{
+		"Gateway":       submarinerGatewayGVR,
+		"ServiceExport": serviceExportGVR,
+		"ServiceImport": serviceImportGVR,
+	}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		submarinerGatewayGVR: "GatewayList",
+		serviceExportGVR:     "ServiceExportList",
+		serviceImportGVR:     "ServiceImportList",
+	})
+	for _, obj := range objects {
+		require.NoError(t, dyn.Tracker().Create(gvrs[obj.GetKind()], obj, obj.GetNamespace()))
+	}
+	return dyn
+}
+
+func TestServiceExportsAndImportsFlagBrokenServices(t *testing.T) {
+	exports := serviceExports([]unstructured.Unstructured{
+		*mcsObject("ServiceExport", "shop", "web", map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Valid", "status": "True"},
+			map[string]interface{}{"type": "Conflict", "status": "True", "reason": "ConflictingType", "message": "type differs"},
+		}}, nil),
+		*mcsObject("ServiceExport", "shop", "cart", map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Valid", "status": "False", "reason": "NoService"},
+		}}, nil),
+		*mcsObject("ServiceExport", "shop", "api", map[string]interface{}{"conditions": []interface{}{
+			map[string]interface{}{"type": "Valid", "status": "True"},
+		}}, nil),
+	})
+	assert.Equal(t, []MultiClusterService{
+		{Namespace: "shop", Name: "web", Problem: "Conflict (ConflictingType): type differs"},
+		{Namespace: "shop", Name: "cart", Problem: "Valid=False (NoService)"},
+		{Namespace: "shop", Name: "api"},
+	}, exports)
+
+	ports := map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(80)}}}
+	imports := serviceImports([]unstructured.Unstructured{
+		*mcsObject("ServiceImport", "shop", "web", map[string]interface{}{"clusters": []interface{}{
+			map[string]interface{}{"cluster": "east"}, map[string]interface{}{"cluster": "west"},
+		}}, ports),
+		*mcsObject("ServiceImport", "shop", "cart", nil, ports),
+	})
+	assert.Equal(t, []MultiClusterService{
+		{Namespace: "shop", Name: "web", Clusters: []string{"east", "west"}},
+		{Namespace: "shop", Name: "cart", Problem: "no cluster exports this service"},
+	}, imports)
+}
+
+func TestFleetNetworkIssues(t *testing.T) {
+	reports := map[string]*NetworkReport{
+		"east": {Submariner: submarinerStatus([]unstructured.Unstructured{
+			*submarinerGateway("east", "gw-1", "active", map[string]string{"west": "connected"}),
+			*submarinerGateway("east", "gw-2", "passive", nil),
+		})},
+		"west": {Submariner: submarinerStatus([]unstructured.Unstructured{
+			*submarinerGateway("west", "gw-1", "active", map[string]string{"east": "error"}),
+		})},
+		"north": {Submariner: submarinerStatus([]unstructured.Unstructured{
+			*submarinerGateway("north", "gw-1", "passive", nil),
+		})},
+		"mesh-a": {ClusterMesh: &ClusterMeshStatus{ClusterName: "a", RemoteClusters: []string{"b"}}},
+		"mesh-b": {ClusterMesh: &ClusterMeshStatus{ClusterName: "b", RemoteClusters: []string{}}},
+	}
+	assert.Equal(t, []string{
+		"east: no Submariner tunnel to cluster north",
+		"north: no active Submariner gateway",
+		"west: Submariner tunnel to cluster east is not connected",
+		"west: no Submariner tunnel to cluster north",
+		"mesh-a: ClusterMesh connects to b, but b does not connect back",
+	}, fleetNetworkIssues(reports))
+}
+
+func TestGetMultiClusterNetworkStatusPerCluster(t *testing.T) {
+	replicas := int32(2)
+	clusterMesh := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "clustermesh-apiserver", Namespace: "kube-system", Labels: map[string]string{"k8s-app": "clustermesh-apiserver"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status:     appsv1.DeploymentStatus{ReadyReplicas: 1},
+	}
+	clients := map[string]kubernetes.Interface{
+		"alpha": k8sfake.NewSimpleClientset(),
+		"beta": k8sfake.NewSimpleClientset(clusterMesh,
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: ciliumConfigMap, Namespace: "kube-system"}, Data: map[string]string{"cluster-name": "beta"}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: clusterMeshSecret, Namespace: "kube-system"}, Data: map[string][]byte{
+				"gamma": []byte("endpoints: ..."), "gamma.etcd-client.crt": []byte("cert"),
+			}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", Annotations: map[string]string{ciliumGlobalAnnotation: "true"}}}),
+		"gamma": k8sfake.NewSimpleClientset(),
+	}
+	dynamics := map[string]dynamic.Interface{
+		"alpha": networkingDynamicClient(t,
+			submarinerGateway("alpha", "node-1", "active", map[string]string{"gamma": "error"}),
+			mcsObject("ServiceImport", "shop", "cart", nil, nil)),
+		"beta":  networkingDynamicClient(t),
+		"gamma": networkingDynamicClient(t, submarinerGateway("gamma", "node-9", "active", map[string]string{"alpha": "connected"})),
+	}
+	infos := []cluster.ClusterInfo{{Name: "alpha", Context: "alpha"}, {Name: "beta", Context: "beta"}, {Name: "gamma", Context: "gamma"}}
+	server := &Server{
+		discoverer:           stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory:        func(name string) (kubernetes.Interface, error) { return clients[name], nil },
+		dynamicClientFactory: func(name string) (dynamic.Interface, error) { return dynamics[name], nil },
+	}
+
+	result, rpcErr := callTool(t, server, "get_multicluster_network_status", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "## alpha\n\n**Submariner:** cluster ID `alpha`, 1 gateways\n")
+	assert.Contains(t, text, "| node-1 | active | gamma | error: handshake timed out | 1.2ms |")
+	assert.Contains(t, text, "| shop/cart | imported | - | ⚠️ no cluster exports this service |")
+	assert.Contains(t, text, "**Cilium ClusterMesh:** cluster name `beta`, API server 1/2 ready in `kube-system`\n**Remote clusters:** gamma\n**Global services:** shop/web\n")
+	assert.Contains(t, text, "⚠️ The ClusterMesh API server is not fully ready")
+	assert.Contains(t, text, "## gamma\n\n**Submariner:** cluster ID `gamma`")
+	assert.Contains(t, text, "## Fleet\n\n- ⚠️ alpha: Submariner tunnel to cluster gamma is not connected\n")
+	assert.NotContains(t, text, "gamma: no Submariner tunnel")
+}
+
+func TestGetMultiClusterNetworkStatusNothingDetected(t *testing.T) {
+	server := &Server{
+		discoverer:           stubDiscoverer{},
+		clientFactory:        func(string) (kubernetes.Interface, error) { return k8sfake.NewSimpleClientset(), nil },
+		dynamicClientFactory: func(string) (dynamic.Interface, error) { return networkingDynamicClient(t), nil },
+	}
+	result, rpcErr := callTool(t, server, "get_multicluster_network_status", map[string]interface{}{"cluster": "alpha"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "No multi-cluster networking detected")
+	assert.NotContains(t, result.Content[0].Text, "## Fleet")
+}
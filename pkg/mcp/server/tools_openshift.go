@@ -0,0 +1,626 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/guardrail"
+)
+
+var (
+	routeGVR = schema.GroupVersionResource{Group: "route.openshift.io", Version: "v1", Resource: "routes"}
+	sccGVR   = schema.GroupVersionResource{Group: "security.openshift.io", Version: "v1", Resource: "securitycontextconstraints"}
+
+	errRoutesNotAvailable = errors.New("not an OpenShift cluster (no routes.route.openshift.io API)")
+	errSCCNotAvailable    = errors.New("not an OpenShift cluster (no securitycontextconstraints.security.openshift.io API)")
+)
+
+// sccAnnotation is set by the SCC admission plugin to the SCC a pod was
+// admitted under.
+const sccAnnotation = "openshift.io/scc"
+
+// elevatedSCCNames are the built-in SCCs that relax the restricted
+// defaults, used when an SCC named on a pod no longer exists.
+var elevatedSCCNames = []string{"anyuid", "hostaccess", "hostmount-anyuid", "hostnetwork", "hostnetwork-v2", "node-exporter", "privileged"}
+
+// RouteAdmission is whether one router admitted a Route.
+type RouteAdmission struct {
+	Router   string `json:"router"`
+	Admitted bool   `json:"admitted"`
+	Reason   string `json:"reason,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// RouteSummary is the host, backend, TLS configuration, and admission
+// state of an OpenShift Route.
+type RouteSummary struct {
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Host       string `json:"host"`
+	Path       string `json:"path,omitempty"`
+	Service    string `json:"service"`
+	TargetPort string `json:"targetPort,omitempty"`
+	// TLS is the termination type: edge, passthrough, reencrypt, or none.
+	TLS                     string `json:"tls"`
+	InsecureEdgeTermination string `json:"insecureEdgeTermination,omitempty"`
+	// Certificate is "custom" when the Route carries its own certificate
+	// and "router default" when the router's wildcard certificate is used.
+	Certificate       string           `json:"certificate,omitempty"`
+	CertificateExpiry string           `json:"certificateExpiry,omitempty"`
+	Admitted          bool             `json:"admitted"`
+	Routers           []RouteAdmission `json:"routers,omitempty"`
+	Problems          []string         `json:"problems,omitempty"`
+}
+
+// routeAdmissions returns the Admitted condition each router reports in a
+// Route's status.
+func routeAdmissions(route *unstructured.Unstructured) []RouteAdmission {
+	ingress, _, _ := unstructured.NestedSlice(route.Object, "status", "ingress")
+	var admissions []RouteAdmission
+	for _, i := range ingress {
+		m, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		a := RouteAdmission{}
+		a.Router, _ = m["routerName"].(string)
+		conditions, _ := m["conditions"].([]interface{})
+		for _, c := range conditions {
+			cm, ok := c.(map[string]interface{})
+			if !ok || cm["type"] != "Admitted" {
+				continue
+			}
+			a.Admitted = cm["status"] == "True"
+			a.Reason, _ = cm["reason"].(string)
+			a.Message, _ = cm["message"].(string)
+		}
+		admissions = append(admissions, a)
+	}
+	return admissions
+}
+
+// certificateNotAfter returns the expiry of the first certificate in a
+// PEM bundle.
+func certificateNotAfter(bundle string) (time.Time, bool) {
+	block, _ := pem.Decode([]byte(bundle))
+	if block == nil {
+		return time.Time{}, false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return cert.NotAfter, true
+}
+
+// summarizeRoute describes a Route. services holds the names of the
+// Services in the Route's namespace, or is nil when they could not be
+// listed.
+func summarizeRoute(route *unstructured.Unstructured, services map[string]bool, now time.Time) RouteSummary {
+	obj := route.Object
+	r := RouteSummary{Namespace: route.GetNamespace(), Name: route.GetName(), TLS: "none"}
+	r.Host, _, _ = unstructured.NestedString(obj, "spec", "host")
+	r.Path, _, _ = unstructured.NestedString(obj, "spec", "path")
+	r.Service, _, _ = unstructured.NestedString(obj, "spec", "to", "name")
+	if port, found, _ := unstructured.NestedFieldNoCopy(obj, "spec", "port", "targetPort"); found {
+		r.TargetPort = fmt.Sprint(port)
+	}
+
+	if termination, _, _ := unstructured.NestedString(obj, "spec", "tls", "termination"); termination != "" {
+		r.TLS = termination
+		r.InsecureEdgeTermination, _, _ = unstructured.NestedString(obj, "spec", "tls", "insecureEdgeTerminationPolicy")
+		switch cert, _, _ := unstructured.NestedString(obj, "spec", "tls", "certificate"); {
+		case termination == "passthrough":
+			// The backend terminates TLS with its own certificate.
+		case cert == "":
+			r.Certificate = "router default"
+		default:
+			r.Certificate = "custom"
+			if notAfter, ok := certificateNotAfter(cert); ok {
+				r.CertificateExpiry = notAfter.UTC().Format(time.RFC3339)
+				switch {
+				case !notAfter.After(now):
+					r.Problems = append(r.Problems, fmt.Sprintf("certificate expired %s ago", formatDuration(now.Sub(notAfter))))
+				case notAfter.Sub(now) < certExpiryWarning:
+					r.Problems = append(r.Problems, fmt.Sprintf("certificate expires in %s", formatDuration(notAfter.Sub(now))))
+				}
+			}
+		}
+	}
+
+	r.Routers = routeAdmissions(route)
+	for _, a := range r.Routers {
+		if a.Admitted {
+			r.Admitted = true
+			continue
+		}
+		r.Problems = append(r.Problems, fmt.Sprintf("not admitted by router %s: %s", a.Router, joinReason(a.Reason, a.Message)))
+	}
+	if len(r.Routers) == 0 {
+		r.Problems = append(r.Problems, "not admitted by any router yet; check that a router's namespace and route selectors match")
+	}
+
+	switch {
+	case r.TLS == "none":
+		r.Problems = append(r.Problems, "served over plain HTTP only")
+	case r.InsecureEdgeTermination == "Allow":
+		r.Problems = append(r.Problems, "also serves plain HTTP (insecureEdgeTerminationPolicy: Allow); use Redirect")
+	}
+	if services != nil && r.Service != "" && !services[r.Service] {
+		r.Problems = append(r.Problems, fmt.Sprintf("backend Service %q not found", r.Service))
+	}
+	return r
+}
+
+func (s *Server) toolListRoutes(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	problemsOnly, _ := args["problems_only"].(bool)
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		list, err := dyn.Resource(routeGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, errRoutesNotAvailable
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Backend Services are only checked when they can be listed.
+		var services map[string]map[string]bool
+		if svcs, err := client.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{}); err == nil {
+			services = make(map[string]map[string]bool)
+			for _, svc := range svcs.Items {
+				if services[svc.Namespace] == nil {
+					services[svc.Namespace] = make(map[string]bool)
+				}
+				services[svc.Namespace][svc.Name] = true
+			}
+		}
+
+		routes := make([]RouteSummary, 0, len(list.Items))
+		for i := range list.Items {
+			route := &list.Items[i]
+			var known map[string]bool
+			if services != nil {
+				known = services[route.GetNamespace()]
+				if known == nil {
+					known = map[string]bool{}
+				}
+			}
+			summary := summarizeRoute(route, known, now)
+			if problemsOnly && len(summary.Problems) == 0 {
+				continue
+			}
+			routes = append(routes, summary)
+		}
+		sort.Slice(routes, func(i, j int) bool {
+			if routes[i].Namespace != routes[j].Namespace {
+				return routes[i].Namespace < routes[j].Namespace
+			}
+			return routes[i].Name < routes[j].Name
+		})
+		return routes, nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to list routes: %v", err), true
+	}
+	sortClusterResults(results)
+	return formatMultiClusterResults(results), false
+}
+
+// sccElevations returns how an SCC relaxes the restricted defaults, or
+// nil if it does not.
+func sccElevations(scc *unstructured.Unstructured) []string {
+	var elevations []string
+	flag := func(field, description string) {
+		if v, _, _ := unstructured.NestedBool(scc.Object, field); v {
+			elevations = append(elevations, description)
+		}
+	}
+	flag("allowPrivilegedContainer", "privileged containers")
+	flag("allowHostNetwork", "host network")
+	flag("allowHostPID", "host PID")
+	flag("allowHostIPC", "host IPC")
+	flag("allowHostPorts", "host ports")
+	flag("allowHostDirVolumePlugin", "hostPath volumes")
+	if strategy, _, _ := unstructured.NestedString(scc.Object, "runAsUser", "type"); strategy == "RunAsAny" {
+		elevations = append(elevations, "any UID including root (runAsUser RunAsAny)")
+	}
+	return elevations
+}
+
+// sccElevationsByName returns the elevations of the named SCC, falling
+// back to the built-in SCC names when the SCC object is missing.
+func sccElevationsByName(sccs map[string]*unstructured.Unstructured, name string) []string {
+	if scc, ok := sccs[name]; ok {
+		return sccElevations(scc)
+	}
+	if slices.Contains(elevatedSCCNames, name) {
+		return []string{"built-in " + name + " SCC"}
+	}
+	return nil
+}
+
+// serviceAccountGroups are the groups a ServiceAccount's token is a
+// member of.
+func serviceAccountGroups(namespace string) []string {
+	return []string{"system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated"}
+}
+
+// subjectIsServiceAccount reports whether a binding subject includes the
+// ServiceAccount namespace/name, directly or through one of its groups.
+func subjectIsServiceAccount(subject rbacv1.Subject, namespace, name string) bool {
+	switch subject.Kind {
+	case rbacv1.ServiceAccountKind:
+		return subject.Name == name && subject.Namespace == namespace
+	case rbacv1.UserKind:
+		return subject.Name == "system:serviceaccount:"+namespace+":"+name
+	case rbacv1.GroupKind:
+		return slices.Contains(serviceAccountGroups(namespace), subject.Name)
+	}
+	return false
+}
+
+// rulesAllowSCC returns the SCCs that rules grant "use" of. all is true
+// when the rules grant every SCC.
+func rulesAllowSCC(rules []rbacv1.PolicyRule) (all bool, names []string) {
+	for _, rule := range rules {
+		if !guardrail.MatchesAny(sccGVR.Group, rule.APIGroups) || !guardrail.MatchesAny(sccGVR.Resource, rule.Resources) || !guardrail.MatchesAny("use", rule.Verbs) {
+			continue
+		}
+		if len(rule.ResourceNames) == 0 {
+			return true, nil
+		}
+		names = append(names, rule.ResourceNames...)
+	}
+	return false, names
+}
+
+// sccIndex holds the SCCs of a cluster and the RBAC objects that grant
+// their use.
+type sccIndex struct {
+	sccs                map[string]*unstructured.Unstructured
+	clusterRoles        map[string][]rbacv1.PolicyRule
+	roles               map[string][]rbacv1.PolicyRule
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+	roleBindings        map[string][]rbacv1.RoleBinding
+}
+
+func loadSCCIndex(ctx context.Context, s *Server, client kubernetes.Interface, clusterName, namespace string) (*sccIndex, error) {
+	dyn, err := s.getDynamicClientForCluster(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	sccs, err := dyn.Resource(sccGVR).List(ctx, metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, errSCCNotAvailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx := &sccIndex{
+		sccs:         make(map[string]*unstructured.Unstructured),
+		clusterRoles: make(map[string][]rbacv1.PolicyRule),
+		roles:        make(map[string][]rbacv1.PolicyRule),
+		roleBindings: make(map[string][]rbacv1.RoleBinding),
+	}
+	for i := range sccs.Items {
+		idx.sccs[sccs.Items[i].GetName()] = &sccs.Items[i]
+	}
+
+	rbac := client.RbacV1()
+	clusterRoles, err := rbac.ClusterRoles().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing cluster roles: %w", err)
+	}
+	for _, cr := range clusterRoles.Items {
+		idx.clusterRoles[cr.Name] = cr.Rules
+	}
+	crbs, err := rbac.ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing cluster role bindings: %w", err)
+	}
+	idx.clusterRoleBindings = crbs.Items
+	roles, err := rbac.Roles(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing roles: %w", err)
+	}
+	for _, r := range roles.Items {
+		idx.roles[r.Namespace+"/"+r.Name] = r.Rules
+	}
+	rbs, err := rbac.RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing role bindings: %w", err)
+	}
+	for _, rb := range rbs.Items {
+		idx.roleBindings[rb.Namespace] = append(idx.roleBindings[rb.Namespace], rb)
+	}
+	return idx, nil
+}
+
+// SCCGrant is an SCC a ServiceAccount may use and what grants it.
+type SCCGrant struct {
+	SCC        string   `json:"scc"`
+	Via        []string `json:"via"`
+	Elevations []string `json:"elevations,omitempty"`
+}
+
+// grantsFor resolves the SCCs the ServiceAccount namespace/name may use,
+// from the SCCs' own users and groups lists and from RBAC "use" grants.
+func (idx *sccIndex) grantsFor(namespace, name string) []SCCGrant {
+	via := make(map[string][]string)
+	grant := func(scc, source string) {
+		if !slices.Contains(via[scc], source) {
+			via[scc] = append(via[scc], source)
+		}
+	}
+	grantRules := func(rules []rbacv1.PolicyRule, source string) {
+		all, names := rulesAllowSCC(rules)
+		if all {
+			for scc := range idx.sccs {
+				grant(scc, source)
+			}
+		}
+		for _, scc := range names {
+			grant(scc, source)
+		}
+	}
+
+	user := "system:serviceaccount:" + namespace + ":" + name
+	for sccName, scc := range idx.sccs {
+		users, _, _ := unstructured.NestedStringSlice(scc.Object, "users")
+		if slices.Contains(users, user) {
+			grant(sccName, "SCC users list")
+		}
+		groups, _, _ := unstructured.NestedStringSlice(scc.Object, "groups")
+		for _, g := range serviceAccountGroups(namespace) {
+			if slices.Contains(groups, g) {
+				grant(sccName, "SCC groups list ("+g+")")
+			}
+		}
+	}
+	for _, crb := range idx.clusterRoleBindings {
+		if crb.RoleRef.Kind == "ClusterRole" && bindsServiceAccount(crb.Subjects, namespace, name) {
+			grantRules(idx.clusterRoles[crb.RoleRef.Name], "ClusterRoleBinding "+crb.Name)
+		}
+	}
+	for _, rb := range idx.roleBindings[namespace] {
+		if !bindsServiceAccount(rb.Subjects, namespace, name) {
+			continue
+		}
+		source := "RoleBinding " + rb.Namespace + "/" + rb.Name
+		if rb.RoleRef.Kind == "ClusterRole" {
+			grantRules(idx.clusterRoles[rb.RoleRef.Name], source)
+		} else {
+			grantRules(idx.roles[rb.Namespace+"/"+rb.RoleRef.Name], source)
+		}
+	}
+
+	grants := make([]SCCGrant, 0, len(via))
+	for scc, sources := range via {
+		grants = append(grants, SCCGrant{SCC: scc, Via: sources, Elevations: sccElevationsByName(idx.sccs, scc)})
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].SCC < grants[j].SCC })
+	return grants
+}
+
+func bindsServiceAccount(subjects []rbacv1.Subject, namespace, name string) bool {
+	for _, subject := range subjects {
+		if subjectIsServiceAccount(subject, namespace, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkloadSCC is the SCC a workload's pods were admitted under and the
+// SCCs its ServiceAccount may use.
+type WorkloadSCC struct {
+	Namespace      string               `json:"namespace"`
+	Workload       string               `json:"workload"`
+	ServiceAccount string               `json:"serviceAccount"`
+	Admitted       []string             `json:"admitted"`
+	Available      []SCCGrant           `json:"available"`
+	Problems       []CertificateProblem `json:"problems,omitempty"`
+}
+
+// checkWorkloadSCC flags a workload whose pods were admitted under an
+// elevated SCC, and elevated SCCs its ServiceAccount may use but does not
+// need.
+func checkWorkloadSCC(w *WorkloadSCC, sccs map[string]*unstructured.Unstructured) {
+	grants := make(map[string]SCCGrant, len(w.Available))
+	for _, g := range w.Available {
+		grants[g.SCC] = g
+	}
+	for _, scc := range w.Admitted {
+		elevations := sccElevationsByName(sccs, scc)
+		if len(elevations) == 0 {
+			continue
+		}
+		severity := "warning"
+		if slices.ContainsFunc(elevations, func(e string) bool { return !strings.HasPrefix(e, "any UID") }) {
+			severity = "critical"
+		}
+		detail := "Granted by: unknown (the grant may have been removed since the pods started)"
+		if g, ok := grants[scc]; ok {
+			detail = "Granted by: " + strings.Join(g.Via, ", ")
+		}
+		if priority, found, _ := unstructured.NestedInt64(sccObject(sccs, scc), "priority"); found && priority > 0 {
+			detail += fmt.Sprintf(". Its priority %d makes admission prefer it over restricted SCCs, so the pods may not need it", priority)
+		}
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity:   severity,
+			Problem:    fmt.Sprintf("Runs only because of SCC %q: %s", scc, strings.Join(elevations, ", ")),
+			Detail:     detail,
+			Suggestion: "Make the image run as an arbitrary non-root UID and drop host access so the pods are admitted under restricted-v2, then remove the grant.",
+		})
+	}
+	for _, g := range w.Available {
+		if len(g.Elevations) == 0 || slices.Contains(w.Admitted, g.SCC) {
+			continue
+		}
+		// Grants to every authenticated user are cluster-wide policy,
+		// not something this workload asked for.
+		if !slices.ContainsFunc(g.Via, func(v string) bool { return !strings.Contains(v, "system:authenticated") }) {
+			continue
+		}
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity:   "info",
+			Problem:    fmt.Sprintf("ServiceAccount %s can use SCC %q (%s) but its pods do not need it", w.ServiceAccount, g.SCC, strings.Join(g.Elevations, ", ")),
+			Detail:     "Granted by: " + strings.Join(g.Via, ", "),
+			Suggestion: "Remove the grant.",
+		})
+	}
+}
+
+func sccObject(sccs map[string]*unstructured.Unstructured, name string) map[string]interface{} {
+	if scc, ok := sccs[name]; ok {
+		return scc.Object
+	}
+	return nil
+}
+
+// workloadSCCs groups active pods by workload and resolves the SCCs of
+// each workload's ServiceAccount. workload limits the result to one
+// workload, given as Kind/name or name.
+func workloadSCCs(pods []corev1.Pod, idx *sccIndex, workload string) []WorkloadSCC {
+	byWorkload := make(map[string]*WorkloadSCC)
+	var keys []string
+	for i := range pods {
+		pod := &pods[i]
+		name := podWorkload(pod)
+		if workload != "" && name != workload && name[strings.Index(name, "/")+1:] != workload {
+			continue
+		}
+		sa := pod.Spec.ServiceAccountName
+		if sa == "" {
+			sa = "default"
+		}
+		key := pod.Namespace + "/" + name
+		w, ok := byWorkload[key]
+		if !ok {
+			w = &WorkloadSCC{Namespace: pod.Namespace, Workload: name, ServiceAccount: sa}
+			byWorkload[key] = w
+			keys = append(keys, key)
+		}
+		if scc := pod.Annotations[sccAnnotation]; scc != "" && !slices.Contains(w.Admitted, scc) {
+			w.Admitted = append(w.Admitted, scc)
+		}
+	}
+	sort.Strings(keys)
+
+	workloads := make([]WorkloadSCC, 0, len(keys))
+	for _, key := range keys {
+		w := byWorkload[key]
+		sort.Strings(w.Admitted)
+		w.Available = idx.grantsFor(w.Namespace, w.ServiceAccount)
+		checkWorkloadSCC(w, idx.sccs)
+		workloads = append(workloads, *w)
+	}
+	return workloads
+}
+
+func (s *Server) toolCheckWorkloadSCC(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	workload, _ := args["workload"].(string)
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		idx, err := loadSCCIndex(ctx, s, client, clusterName, namespace)
+		if err != nil {
+			return nil, err
+		}
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: activePodsFieldSelector})
+		if err != nil {
+			return nil, fmt.Errorf("listing pods: %w", err)
+		}
+		return workloadSCCs(pods.Items, idx, workload), nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to check workload SCCs: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Workload SCC Check\n")
+	checked := 0
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		workloads, _ := r.Result.([]WorkloadSCC)
+		checked += len(workloads)
+		if len(workloads) == 0 {
+			sb.WriteString("No running workloads found\n")
+			continue
+		}
+		flagged := 0
+		for _, w := range workloads {
+			if len(w.Problems) > 0 {
+				flagged++
+			}
+		}
+		_, _ = fmt.Fprintf(&sb, "%d of %d workloads have SCC findings\n", flagged, len(workloads))
+		for _, w := range workloads {
+			admitted := strings.Join(w.Admitted, ", ")
+			if admitted == "" {
+				admitted = "no SCC annotation"
+			}
+			available := make([]string, 0, len(w.Available))
+			for _, g := range w.Available {
+				available = append(available, g.SCC)
+			}
+			icon := "✅"
+			if len(w.Problems) > 0 {
+				icon = "📛"
+			}
+			_, _ = fmt.Fprintf(&sb, "\n%s %s/%s (ServiceAccount %s) admitted under %s\n", icon, w.Namespace, w.Workload, w.ServiceAccount, admitted)
+			if len(available) == 0 {
+				available = append(available, "none")
+			}
+			_, _ = fmt.Fprintf(&sb, "   Can use: %s\n", strings.Join(available, ", "))
+			for _, p := range w.Problems {
+				_, _ = fmt.Fprintf(&sb, "   - [%s] %s\n", p.Severity, p.Problem)
+				if p.Detail != "" {
+					_, _ = fmt.Fprintf(&sb, "     %s\n", p.Detail)
+				}
+				if p.Suggestion != "" {
+					_, _ = fmt.Fprintf(&sb, "     Fix: %s\n", p.Suggestion)
+				}
+			}
+		}
+	}
+	if workload != "" && checked == 0 {
+		return fmt.Sprintf("Workload %s not found in any cluster.\n\n%s", workload, sb.String()), true
+	}
+	return sb.String(), false
+}
@@ -0,0 +1,56 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "list_routes",
+		Description: "List OpenShift Routes with their host, backend Service, TLS termination and certificate, and whether each router admitted them, flagging rejected Routes, plain HTTP, and expiring certificates",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace (all namespaces if not specified)",
+				},
+				"problems_only": {
+					Type:        "boolean",
+					Description: "Only list Routes with problems",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListRoutes(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "check_workload_scc",
+		Description: "Report which OpenShift SecurityContextConstraints each workload was admitted under and which SCCs its ServiceAccount can use and why, flagging workloads that only run because of anyuid, privileged, or host access SCCs",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace (all namespaces if not specified)",
+				},
+				"workload": {
+					Type:        "string",
+					Description: "Only this workload, as Kind/name (e.g. Deployment/web) or name",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolCheckWorkloadSCC(ctx, args)
+		},
+	)
+}
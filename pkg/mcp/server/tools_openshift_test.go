@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+var openshiftListKinds = map[schema.GroupVersionResource]string{
+	routeGVR: "RouteList",
+	sccGVR:   "SecurityContextConstraintsList",
+}
+
+// selfSignedPEM returns a PEM certificate that expires at notAfter.
+func selfSignedPEM(t *testing.T, notAfter time.Time) string {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: notAfter.Add(-90 * 24 * time.Hour), NotAfter: notAfter}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func route(name string, spec map[string]interface{}, ingress ...interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "route.openshift.io/v1", "kind": "Route", "spec": spec,
+		"status": map[string]interface{}{"ingress": ingress},
+	}}
+	obj.SetNamespace("shop")
+	obj.SetName(name)
+	return obj
+}
+
+func routerIngress(router, status, reason, message string) interface{} {
+	return map[string]interface{}{"routerName": router, "conditions": []interface{}{
+		map[string]interface{}{"type": "Admitted", "status": status, "reason": reason, "message": message},
+	}}
+}
+
+func TestSummarizeRoute(t *testing.T) {
+	now := time.Now()
+	services := map[string]bool{"web": true}
+
+	web := summarizeRoute(route("web", map[string]interface{}{
+		"host": "web.apps.example.com", "to": map[string]interface{}{"kind": "Service", "name": "web"},
+		"port": map[string]interface{}{"targetPort": int64(8080)},
+		"tls":  map[string]interface{}{"termination": "edge", "insecureEdgeTerminationPolicy": "Redirect"},
+	}, routerIngress("default", "True", "", "")), services, now)
+	assert.Equal(t, RouteSummary{
+		Namespace: "shop", Name: "web", Host: "web.apps.example.com", Service: "web", TargetPort: "8080",
+		TLS: "edge", InsecureEdgeTermination: "Redirect", Certificate: "router default", Admitted: true,
+		Routers: []RouteAdmission{{Router: "default", Admitted: true}},
+	}, web)
+
+	claimed := summarizeRoute(route("shop", map[string]interface{}{
+		"host": "web.apps.example.com", "to": map[string]interface{}{"kind": "Service", "name": "storefront"},
+		"tls": map[string]interface{}{"termination": "reencrypt", "insecureEdgeTerminationPolicy": "Allow",
+			"certificate": selfSignedPEM(t, now.Add(72*time.Hour))},
+	}, routerIngress("default", "False", "HostAlreadyClaimed", "route web already exposes web.apps.example.com and is older")), services, now)
+	assert.False(t, claimed.Admitted)
+	assert.Equal(t, "custom", claimed.Certificate)
+	assert.Equal(t, []string{
+		"certificate expires in 2d",
+		"not admitted by router default: HostAlreadyClaimed: route web already exposes web.apps.example.com and is older",
+		"also serves plain HTTP (insecureEdgeTerminationPolicy: Allow); use Redirect",
+		`backend Service "storefront" not found`,
+	}, claimed.Problems)
+
+	plain := summarizeRoute(route("plain", map[string]interface{}{"to": map[string]interface{}{"name": "web"}}), nil, now)
+	assert.Equal(t, []string{
+		"not admitted by any router yet; check that a router's namespace and route selectors match",
+		"served over plain HTTP only",
+	}, plain.Problems)
+}
+
+func scc(name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "security.openshift.io/v1", "kind": "SecurityContextConstraints",
+		"runAsUser": map[string]interface{}{"type": "MustRunAsRange"},
+	}}
+	for k, v := range fields {
+		obj.Object[k] = v
+	}
+	obj.SetName(name)
+	return obj
+}
+
+// sccPod returns a pod of Deployment workload in namespace shop that runs
+// as serviceAccount and was admitted under admittedSCC.
+func sccPod(name, workload, serviceAccount, admittedSCC string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "shop",
+			Labels:          map[string]string{"pod-template-hash": "5c4b"},
+			Annotations:     map[string]string{sccAnnotation: admittedSCC},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: workload + "-5c4b", Controller: &controller}},
+		},
+		Spec:   corev1.PodSpec{ServiceAccountName: serviceAccount},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func sccUseRole(name, scc string) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{"security.openshift.io"}, Resources: []string{"securitycontextconstraints"},
+			Verbs: []string{"use"}, ResourceNames: []string{scc},
+		}},
+	}
+}
+
+func TestCheckWorkloadSCCPerCluster(t *testing.T) {
+	// The fake client would guess the plural of SecurityContextConstraints
+	// wrongly, so the SCCs are created through the real resource.
+	ocp := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), openshiftListKinds)
+	for _, obj := range []*unstructured.Unstructured{
+		scc("restricted-v2", nil),
+		scc("anyuid", map[string]interface{}{"priority": int64(10), "runAsUser": map[string]interface{}{"type": "RunAsAny"}}),
+		scc("privileged", map[string]interface{}{
+			"allowPrivilegedContainer": true, "allowHostNetwork": true,
+			"runAsUser": map[string]interface{}{"type": "RunAsAny"},
+			"users":     []interface{}{"system:admin", "system:serviceaccount:shop:web"},
+		}),
+	} {
+		_, err := ocp.Resource(sccGVR).Create(context.Background(), obj, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	dyns := map[string]dynamic.Interface{"ocp": ocp}
+	kind := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), openshiftListKinds)
+	kind.PrependReactor("list", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "")
+	})
+	dyns["kind"] = kind
+
+	clients := map[string]kubernetes.Interface{
+		"ocp": k8sfake.NewSimpleClientset(
+			sccPod("web-5c4b-a", "web", "web", "restricted-v2"),
+			sccPod("legacy-5c4b-a", "legacy", "legacy", "anyuid"),
+			sccUseRole("system:openshift:scc:restricted-v2", "restricted-v2"),
+			sccUseRole("system:openshift:scc:anyuid", "anyuid"),
+			&rbacv1.ClusterRoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "system:openshift:scc:restricted-v2"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "system:openshift:scc:restricted-v2"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:authenticated"}},
+			},
+			&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy-anyuid", Namespace: "shop"},
+				RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "system:openshift:scc:anyuid"},
+				Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "legacy", Namespace: "shop"}},
+			},
+		),
+		"kind": k8sfake.NewSimpleClientset(),
+	}
+	infos := []cluster.ClusterInfo{{Name: "ocp", Context: "ocp"}, {Name: "kind", Context: "kind"}}
+	server := &Server{
+		discoverer:           stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory:        func(name string) (kubernetes.Interface, error) { return clients[name], nil },
+		dynamicClientFactory: func(name string) (dynamic.Interface, error) { return dyns[name], nil },
+	}
+
+	result, rpcErr := callTool(t, server, "check_workload_scc", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "2 of 2 workloads have SCC findings")
+	assert.Contains(t, text, "📛 shop/Deployment/legacy (ServiceAccount legacy) admitted under anyuid\n   Can use: anyuid, restricted-v2\n")
+	assert.Contains(t, text, `[warning] Runs only because of SCC "anyuid": any UID including root (runAsUser RunAsAny)`)
+	assert.Contains(t, text, "Granted by: RoleBinding shop/legacy-anyuid. Its priority 10 makes admission prefer it over restricted SCCs")
+	assert.Contains(t, text, "📛 shop/Deployment/web (ServiceAccount web) admitted under restricted-v2\n   Can use: privileged, restricted-v2\n")
+	assert.Contains(t, text, `[info] ServiceAccount web can use SCC "privileged" (privileged containers, host network, any UID including root (runAsUser RunAsAny)) but its pods do not need it`)
+	assert.Contains(t, text, "Granted by: SCC users list")
+	assert.Contains(t, text, "## kind\n\nerror: not an OpenShift cluster (no securitycontextconstraints.security.openshift.io API)")
+
+	result, rpcErr = callTool(t, server, "check_workload_scc", map[string]interface{}{"cluster": "ocp", "workload": "Deployment/nope"})
+	require.Nil(t, rpcErr)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].Text, "Workload Deployment/nope not found in any cluster")
+}
+
+func TestListRoutesOnNonOpenShiftCluster(t *testing.T) {
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), openshiftListKinds)
+	dyn.PrependReactor("list", "routes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(routeGVR.GroupResource(), "")
+	})
+	server := &Server{
+		discoverer: stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) {
+			return []cluster.ClusterInfo{{Name: "kind", Context: "kind"}}, nil
+		}},
+		clientFactory:        func(string) (kubernetes.Interface, error) { return k8sfake.NewSimpleClientset(), nil },
+		dynamicClientFactory: func(string) (dynamic.Interface, error) { return dyn, nil },
+	}
+
+	result, rpcErr := callTool(t, server, "list_routes", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	assert.Contains(t, result.Content[0].Text, "not an OpenShift cluster (no routes.route.openshift.io API)")
+}
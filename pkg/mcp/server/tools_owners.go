@@ -0,0 +1,363 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/tools/upgrades"
+)
+
+var (
+	argoApplicationGVR   = schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "applications"}
+	fluxKustomizationGVR = schema.GroupVersionResource{Group: "kustomize.toolkit.fluxcd.io", Version: "v1", Resource: "kustomizations"}
+	fluxHelmReleaseGVR   = schema.GroupVersionResource{Group: "helm.toolkit.fluxcd.io", Version: "v2", Resource: "helmreleases"}
+)
+
+// fluxSourceGVRs are the Flux sources Kustomizations and HelmReleases
+// reference, by kind.
+var fluxSourceGVRs = map[string]schema.GroupVersionResource{
+	"GitRepository":  {Group: "source.toolkit.fluxcd.io", Version: "v1", Resource: "gitrepositories"},
+	"OCIRepository":  {Group: "source.toolkit.fluxcd.io", Version: "v1", Resource: "ocirepositories"},
+	"Bucket":         {Group: "source.toolkit.fluxcd.io", Version: "v1", Resource: "buckets"},
+	"HelmRepository": {Group: "source.toolkit.fluxcd.io", Version: "v1", Resource: "helmrepositories"},
+	"HelmChart":      {Group: "source.toolkit.fluxcd.io", Version: "v1", Resource: "helmcharts"},
+}
+
+// Labels and annotations GitOps tools put on the objects they apply.
+const (
+	argoTrackingIDAnnotation = "argocd.argoproj.io/tracking-id"
+	argoInstanceLabel        = "app.kubernetes.io/instance"
+	fluxKustomizeNameLabel   = "kustomize.toolkit.fluxcd.io/name"
+	fluxKustomizeNSLabel     = "kustomize.toolkit.fluxcd.io/namespace"
+	fluxHelmNameLabel        = "helm.toolkit.fluxcd.io/name"
+	fluxHelmNSLabel          = "helm.toolkit.fluxcd.io/namespace"
+	helmReleaseNameAnno      = "meta.helm.sh/release-name"
+	helmReleaseNSAnno        = "meta.helm.sh/release-namespace"
+)
+
+// GitOpsOwner is the Argo CD Application, Flux Kustomization or
+// HelmRelease, or Helm release that manages an object, with where it
+// deploys from once resolved.
+type GitOpsOwner struct {
+	Tool      string `json:"tool"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Source    string `json:"source,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Status    string `json:"status,omitempty"`
+	// Error is why the owner could not be resolved.
+	Error string `json:"error,omitempty"`
+	// guessed owners come from the app.kubernetes.io/instance label, which
+	// Helm charts set too, and are dropped when no Application has the name.
+	guessed bool
+}
+
+func (o *GitOpsOwner) String() string {
+	if o.Namespace == "" {
+		return fmt.Sprintf("%s %s %s", o.Tool, o.Kind, o.Name)
+	}
+	return fmt.Sprintf("%s %s %s/%s", o.Tool, o.Kind, o.Namespace, o.Name)
+}
+
+func (o *GitOpsOwner) key() string {
+	return o.Tool + "/" + o.Kind + "/" + o.Namespace + "/" + o.Name
+}
+
+// detectGitOpsOwner reads the owner of an object in namespace from its
+// labels and annotations. Flux labels win over Helm's annotations, since
+// a Flux HelmRelease installs a Helm release, and Helm's annotations win
+// over the instance label Argo CD tracks apps with by default, since Helm
+// charts set that label to the release name.
+func detectGitOpsOwner(namespace string, labels, annotations map[string]string) *GitOpsOwner {
+	if name := labels[fluxHelmNameLabel]; name != "" {
+		return &GitOpsOwner{Tool: "Flux", Kind: "HelmRelease", Namespace: labelOr(labels[fluxHelmNSLabel], namespace), Name: name}
+	}
+	if name := labels[fluxKustomizeNameLabel]; name != "" {
+		return &GitOpsOwner{Tool: "Flux", Kind: "Kustomization", Namespace: labelOr(labels[fluxKustomizeNSLabel], namespace), Name: name}
+	}
+	// The tracking ID is <app>:<group>/<kind>:<namespace>/<name>, where
+	// apps outside Argo CD's namespace are <namespace>_<app>.
+	if id := annotations[argoTrackingIDAnnotation]; id != "" {
+		app, _, _ := strings.Cut(id, ":")
+		appNamespace, name, ok := strings.Cut(app, "_")
+		if !ok {
+			appNamespace, name = "", app
+		}
+		return &GitOpsOwner{Tool: "Argo CD", Kind: "Application", Namespace: appNamespace, Name: name}
+	}
+	if name := annotations[helmReleaseNameAnno]; name != "" {
+		return &GitOpsOwner{Tool: "Helm", Kind: "release", Namespace: labelOr(annotations[helmReleaseNSAnno], namespace), Name: name}
+	}
+	if name := labels[argoInstanceLabel]; name != "" {
+		return &GitOpsOwner{Tool: "Argo CD", Kind: "Application", Name: name, guessed: true}
+	}
+	return nil
+}
+
+func labelOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// ownerResolver looks up GitOps owners in one cluster, reading each owner
+// and each list of Argo CD Applications once.
+type ownerResolver struct {
+	client   kubernetes.Interface
+	dynamic  func() (dynamic.Interface, error)
+	resolved map[string]*GitOpsOwner
+	apps     []unstructured.Unstructured
+	appsErr  error
+	appsRead bool
+}
+
+// newOwnerResolver returns a resolver that creates the dynamic client with
+// dyn on first use, so that clusters without GitOps owners never need one.
+func newOwnerResolver(client kubernetes.Interface, dyn func() (dynamic.Interface, error)) *ownerResolver {
+	return &ownerResolver{client: client, dynamic: sync.OnceValues(dyn), resolved: make(map[string]*GitOpsOwner)}
+}
+
+// resolve returns the owner with its source, revision, and status filled
+// in, or nil for a guessed Argo CD owner that does not exist. Owners are
+// shared by the objects they manage.
+func (r *ownerResolver) resolve(ctx context.Context, owner *GitOpsOwner) *GitOpsOwner {
+	key := owner.key()
+	if cached, ok := r.resolved[key]; ok {
+		return cached
+	}
+	switch owner.Kind {
+	case "Application":
+		r.resolveArgoApplication(ctx, owner)
+	case "Kustomization":
+		r.resolveFluxKustomization(ctx, owner)
+	case "HelmRelease":
+		r.resolveFluxHelmRelease(ctx, owner)
+	case "release":
+		r.resolveHelmRelease(ctx, owner)
+	}
+	if owner.guessed && owner.Error != "" {
+		owner = nil
+	}
+	r.resolved[key] = owner
+	return owner
+}
+
+func (r *ownerResolver) get(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, error) {
+	dyn, err := r.dynamic()
+	if err != nil {
+		return nil, err
+	}
+	obj, err := dyn.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("%s %s/%s not found", gvr.Resource, namespace, name)
+	}
+	return obj, err
+}
+
+func (r *ownerResolver) resolveArgoApplication(ctx context.Context, owner *GitOpsOwner) {
+	if !r.appsRead {
+		r.appsRead = true
+		if dyn, err := r.dynamic(); err != nil {
+			r.appsErr = err
+		} else if list, err := dyn.Resource(argoApplicationGVR).List(ctx, metav1.ListOptions{}); err != nil {
+			r.appsErr = err
+		} else {
+			r.apps = list.Items
+		}
+	}
+	if r.appsErr != nil {
+		owner.Error = fmt.Sprintf("failed to list Argo CD Applications: %v", r.appsErr)
+		return
+	}
+	var app *unstructured.Unstructured
+	for i := range r.apps {
+		if r.apps[i].GetName() == owner.Name && (owner.Namespace == "" || r.apps[i].GetNamespace() == owner.Namespace) {
+			app = &r.apps[i]
+			break
+		}
+	}
+	if app == nil {
+		owner.Error = "Application not found; it may live on the Argo CD management cluster"
+		return
+	}
+	owner.Namespace = app.GetNamespace()
+
+	sources, _, _ := unstructured.NestedSlice(app.Object, "spec", "sources")
+	if source, ok, _ := unstructured.NestedMap(app.Object, "spec", "source"); ok {
+		sources = append([]interface{}{source}, sources...)
+	}
+	var described []string
+	for _, s := range sources {
+		if m, ok := s.(map[string]interface{}); ok {
+			described = append(described, describeArgoSource(m))
+		}
+	}
+	owner.Source = strings.Join(described, "; ")
+	owner.Revision, _, _ = unstructured.NestedString(app.Object, "status", "sync", "revision")
+	if owner.Revision == "" {
+		revisions, _, _ := unstructured.NestedStringSlice(app.Object, "status", "sync", "revisions")
+		owner.Revision = strings.Join(revisions, ", ")
+	}
+	sync, _, _ := unstructured.NestedString(app.Object, "status", "sync", "status")
+	health, _, _ := unstructured.NestedString(app.Object, "status", "health", "status")
+	owner.Status = strings.Trim(sync+"/"+health, "/")
+}
+
+// describeArgoSource formats an Application source as its repository with
+// the chart or path and target revision, e.g.
+// "https://github.com/org/apps path web @ main".
+func describeArgoSource(source map[string]interface{}) string {
+	repo, _ := source["repoURL"].(string)
+	parts := []string{repo}
+	if chart, _ := source["chart"].(string); chart != "" {
+		parts = append(parts, "chart "+chart)
+	} else if path, _ := source["path"].(string); path != "" {
+		parts = append(parts, "path "+path)
+	}
+	if rev, _ := source["targetRevision"].(string); rev != "" {
+		parts = append(parts, "@ "+rev)
+	}
+	return strings.Join(parts, " ")
+}
+
+func (r *ownerResolver) resolveFluxKustomization(ctx context.Context, owner *GitOpsOwner) {
+	ks, err := r.get(ctx, fluxKustomizationGVR, owner.Namespace, owner.Name)
+	if err != nil {
+		owner.Error = err.Error()
+		return
+	}
+	source := r.describeFluxSourceRef(ctx, ks, "spec", "sourceRef")
+	if path, _, _ := unstructured.NestedString(ks.Object, "spec", "path"); path != "" {
+		source += " path " + path
+	}
+	owner.Source = source
+	owner.Revision, _, _ = unstructured.NestedString(ks.Object, "status", "lastAppliedRevision")
+	owner.Status = fluxReadyStatus(ks)
+}
+
+func (r *ownerResolver) resolveFluxHelmRelease(ctx context.Context, owner *GitOpsOwner) {
+	hr, err := r.get(ctx, fluxHelmReleaseGVR, owner.Namespace, owner.Name)
+	if err != nil {
+		owner.Error = err.Error()
+		return
+	}
+	if _, ok, _ := unstructured.NestedMap(hr.Object, "spec", "chartRef"); ok {
+		owner.Source = r.describeFluxSourceRef(ctx, hr, "spec", "chartRef")
+	} else {
+		chart, _, _ := unstructured.NestedString(hr.Object, "spec", "chart", "spec", "chart")
+		version, _, _ := unstructured.NestedString(hr.Object, "spec", "chart", "spec", "version")
+		owner.Source = "chart " + chart
+		if version != "" {
+			owner.Source += " " + version
+		}
+		owner.Source += " from " + r.describeFluxSourceRef(ctx, hr, "spec", "chart", "spec", "sourceRef")
+	}
+	owner.Revision, _, _ = unstructured.NestedString(hr.Object, "status", "lastAttemptedRevision")
+	owner.Status = fluxReadyStatus(hr)
+}
+
+// describeFluxSourceRef returns the URL of the Flux source obj references
+// at fields, with its branch, tag, or semver range, or the reference
+// itself when the source cannot be read.
+func (r *ownerResolver) describeFluxSourceRef(ctx context.Context, obj *unstructured.Unstructured, fields ...string) string {
+	ref, _, _ := unstructured.NestedStringMap(obj.Object, fields...)
+	kind, name := ref["kind"], ref["name"]
+	namespace := labelOr(ref["namespace"], obj.GetNamespace())
+	described := fmt.Sprintf("%s %s/%s", kind, namespace, name)
+	gvr, ok := fluxSourceGVRs[kind]
+	if !ok {
+		return described
+	}
+	source, err := r.get(ctx, gvr, namespace, name)
+	if err != nil {
+		return described
+	}
+	url, _, _ := unstructured.NestedString(source.Object, "spec", "url")
+	if kind == "Bucket" {
+		endpoint, _, _ := unstructured.NestedString(source.Object, "spec", "endpoint")
+		bucket, _, _ := unstructured.NestedString(source.Object, "spec", "bucketName")
+		url = endpoint + "/" + bucket
+	}
+	if url == "" {
+		return described
+	}
+	for _, field := range []string{"branch", "tag", "semver", "commit"} {
+		if v, _, _ := unstructured.NestedString(source.Object, "spec", "ref", field); v != "" {
+			return fmt.Sprintf("%s (%s %s)", url, field, v)
+		}
+	}
+	return url
+}
+
+func fluxReadyStatus(obj *unstructured.Unstructured) string {
+	status, reason, message := statusCondition(obj, "Ready")
+	switch status {
+	case "True":
+		return "Ready"
+	case "":
+		return ""
+	}
+	return "Not ready: " + joinReason(reason, message)
+}
+
+// resolveHelmRelease reads the deployed chart from the release's newest
+// revision Secret.
+func (r *ownerResolver) resolveHelmRelease(ctx context.Context, owner *GitOpsOwner) {
+	latest, err := upgrades.LatestHelmRelease(ctx, r.client, owner.Namespace, owner.Name)
+	if err != nil {
+		owner.Error = err.Error()
+		return
+	}
+	if latest == nil {
+		owner.Error = "no release Secrets found; it may have been rendered with helm template or uninstalled"
+		return
+	}
+	owner.Source = "chart " + latest.Chart
+	if latest.Version != "" {
+		owner.Source += " " + latest.Version
+	}
+	owner.Revision = fmt.Sprintf("revision %d", latest.Revision)
+	owner.Status = latest.Status
+}
+
+// writeGitOpsOwners writes the GitOps owners of resources, each with the
+// resources it manages.
+func writeGitOpsOwners(sb *strings.Builder, resources map[*GitOpsOwner][]string) {
+	if len(resources) == 0 {
+		return
+	}
+	owners := make([]*GitOpsOwner, 0, len(resources))
+	for o := range resources {
+		owners = append(owners, o)
+	}
+	sort.Slice(owners, func(i, j int) bool { return owners[i].String() < owners[j].String() })
+	sb.WriteString("## GitOps Owners\n\n")
+	for _, o := range owners {
+		_, _ = fmt.Fprintf(sb, "### %s\n", o)
+		if o.Error != "" {
+			_, _ = fmt.Fprintf(sb, "- error: %s\n", o.Error)
+		}
+		if o.Source != "" {
+			_, _ = fmt.Fprintf(sb, "- Source: %s\n", o.Source)
+		}
+		if o.Revision != "" {
+			_, _ = fmt.Fprintf(sb, "- Revision: %s\n", o.Revision)
+		}
+		if o.Status != "" {
+			_, _ = fmt.Fprintf(sb, "- Status: %s\n", o.Status)
+		}
+		_, _ = fmt.Fprintf(sb, "- Manages: %s\n\n", strings.Join(resources[o], ", "))
+	}
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDetectGitOpsOwner(t *testing.T) {
+	tests := []struct {
+		name        string
+		labels      map[string]string
+		annotations map[string]string
+		want        *GitOpsOwner
+	}{
+		{name: "none", labels: map[string]string{"app": "web"}},
+		{
+			name: "Flux HelmRelease over Helm annotations",
+			labels: map[string]string{
+				fluxHelmNameLabel: "web", fluxHelmNSLabel: "flux-system",
+			},
+			annotations: map[string]string{helmReleaseNameAnno: "shop-web"},
+			want:        &GitOpsOwner{Tool: "Flux", Kind: "HelmRelease", Namespace: "flux-system", Name: "web"},
+		},
+		{
+			name:   "Flux Kustomization",
+			labels: map[string]string{fluxKustomizeNameLabel: "apps", fluxKustomizeNSLabel: "flux-system"},
+			want:   &GitOpsOwner{Tool: "Flux", Kind: "Kustomization", Namespace: "flux-system", Name: "apps"},
+		},
+		{
+			name:        "Argo CD tracking ID of an app in another namespace",
+			annotations: map[string]string{argoTrackingIDAnnotation: "team-a_web:apps/Deployment:shop/web"},
+			want:        &GitOpsOwner{Tool: "Argo CD", Kind: "Application", Namespace: "team-a", Name: "web"},
+		},
+		{
+			name:        "Helm annotations over the instance label",
+			labels:      map[string]string{argoInstanceLabel: "web"},
+			annotations: map[string]string{helmReleaseNameAnno: "web"},
+			want:        &GitOpsOwner{Tool: "Helm", Kind: "release", Namespace: "shop", Name: "web"},
+		},
+		{
+			name:   "instance label",
+			labels: map[string]string{argoInstanceLabel: "web"},
+			want:   &GitOpsOwner{Tool: "Argo CD", Kind: "Application", Name: "web", guessed: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, detectGitOpsOwner("shop", tt.labels, tt.annotations))
+		})
+	}
+}
+
+func TestFindResourceOwnersResolvesGitOpsOwners(t *testing.T) {
+	deployment := func(name string, labels, annotations map[string]string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: labels, Annotations: annotations}}
+	}
+	release := makeHelmReleaseSecret("cache", "shop", "redis", "19.0.1", "7.2", "deployed", 1)
+	release.Labels["name"] = "cache"
+	upgraded := makeHelmReleaseSecret("cache", "shop", "redis", "19.1.0", "7.2", "failed", 2)
+	upgraded.Name, upgraded.Labels["name"] = "sh.helm.release.v1.cache.v2", "cache"
+	client := k8sfake.NewSimpleClientset(
+		deployment("web", nil, map[string]string{argoTrackingIDAnnotation: "web:apps/Deployment:shop/web"}),
+		deployment("api", map[string]string{fluxKustomizeNameLabel: "apps", fluxKustomizeNSLabel: "flux-system"}, nil),
+		deployment("worker", map[string]string{fluxHelmNameLabel: "worker", fluxHelmNSLabel: "flux-system"}, nil),
+		deployment("cache", map[string]string{argoInstanceLabel: "cache"}, map[string]string{helmReleaseNameAnno: "cache"}),
+		deployment("legacy", map[string]string{argoInstanceLabel: "legacy"}, nil),
+		release, upgraded,
+	)
+
+	object := func(apiVersion, kind, namespace, name string, fields map[string]interface{}) runtime.Object {
+		obj := &unstructured.Unstructured{Object: fields}
+		obj.SetAPIVersion(apiVersion)
+		obj.SetKind(kind)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return obj
+	}
+	ready := map[string]interface{}{"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}}}
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		argoApplicationGVR: "ApplicationList",
+	},
+		object("argoproj.io/v1alpha1", "Application", "argocd", "web", map[string]interface{}{
+			"spec": map[string]interface{}{"source": map[string]interface{}{
+				"repoURL": "https://github.com/example/apps", "path": "shop/web", "targetRevision": "main",
+			}},
+			"status": map[string]interface{}{
+				"sync":   map[string]interface{}{"status": "OutOfSync", "revision": "a1b2c3"},
+				"health": map[string]interface{}{"status": "Healthy"},
+			},
+		}),
+		object("kustomize.toolkit.fluxcd.io/v1", "Kustomization", "flux-system", "apps", map[string]interface{}{
+			"spec":   map[string]interface{}{"path": "./apps", "sourceRef": map[string]interface{}{"kind": "GitRepository", "name": "fleet"}},
+			"status": map[string]interface{}{"lastAppliedRevision": "main@sha1:d4e5f6", "conditions": ready["conditions"]},
+		}),
+		object("source.toolkit.fluxcd.io/v1", "GitRepository", "flux-system", "fleet", map[string]interface{}{
+			"spec": map[string]interface{}{"url": "https://github.com/example/fleet", "ref": map[string]interface{}{"branch": "main"}},
+		}),
+		object("helm.toolkit.fluxcd.io/v2", "HelmRelease", "flux-system", "worker", map[string]interface{}{
+			"spec": map[string]interface{}{"chart": map[string]interface{}{"spec": map[string]interface{}{
+				"chart": "worker", "version": "2.x", "sourceRef": map[string]interface{}{"kind": "HelmRepository", "name": "charts"},
+			}}},
+			"status": map[string]interface{}{"lastAttemptedRevision": "2.3.0", "conditions": []interface{}{map[string]interface{}{
+				"type": "Ready", "status": "False", "reason": "UpgradeFailed", "message": "timed out waiting for the condition",
+			}}},
+		}),
+		object("source.toolkit.fluxcd.io/v1", "HelmRepository", "flux-system", "charts", map[string]interface{}{
+			"spec": map[string]interface{}{"url": "https://charts.example.com"},
+		}),
+	)
+	server := &Server{
+		discoverer:           stubDiscoverer{},
+		clientFactory:        func(string) (kubernetes.Interface, error) { return client, nil },
+		dynamicClientFactory: func(string) (dynamic.Interface, error) { return dyn, nil },
+	}
+
+	result, rpcErr := callTool(t, server, "find_resource_owners", map[string]interface{}{"namespace": "shop", "resource_type": "deployments"})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "### Argo CD Application argocd/web\n"+
+		"- Source: https://github.com/example/apps path shop/web @ main\n"+
+		"- Revision: a1b2c3\n"+
+		"- Status: OutOfSync/Healthy\n"+
+		"- Manages: Deployment/web\n")
+	assert.Contains(t, text, "### Flux Kustomization flux-system/apps\n"+
+		"- Source: https://github.com/example/fleet (branch main) path ./apps\n"+
+		"- Revision: main@sha1:d4e5f6\n"+
+		"- Status: Ready\n")
+	assert.Contains(t, text, "### Flux HelmRelease flux-system/worker\n"+
+		"- Source: chart worker 2.x from https://charts.example.com\n"+
+		"- Revision: 2.3.0\n"+
+		"- Status: Not ready: UpgradeFailed: timed out waiting for the condition\n")
+	assert.Contains(t, text, "### Helm release shop/cache\n"+
+		"- Source: chart redis 19.1.0\n"+
+		"- Revision: revision 2\n"+
+		"- Status: failed\n")
+	assert.Contains(t, text, "**Deployment/web** [gitops: Argo CD Application argocd/web]")
+	// The instance label alone only names an owner when the Application
+	// exists.
+	assert.NotContains(t, text, "legacy]")
+	assert.NotContains(t, text, "Application legacy")
+}
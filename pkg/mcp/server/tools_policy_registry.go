@@ -62,7 +62,7 @@ func init() {
 			return s.toolListOwnershipViolations(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterMutatingTool(Tool{
 			Name:        "install_ownership_policy",
 			Description: "Install the ownership labels policy (ConstraintTemplate and Constraint) for OPA Gatekeeper",
 			InputSchema: InputSchema{
@@ -99,7 +99,7 @@ func init() {
 			return s.toolInstallOwnershipPolicy(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterMutatingTool(Tool{
 			Name:        "set_ownership_policy_mode",
 			Description: "Change the enforcement mode of the ownership labels policy",
 			InputSchema: InputSchema{
@@ -122,7 +122,7 @@ func init() {
 			return s.toolSetOwnershipPolicyMode(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterMutatingTool(Tool{
 			Name:        "uninstall_ownership_policy",
 			Description: "Remove the ownership labels policy from the cluster",
 			InputSchema: InputSchema{
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	defaultPreemptionWindow = 24 * time.Hour
+	// maxPreemptions is how many preemptions are listed per cluster.
+	maxPreemptions = 50
+)
+
+// preemptedNoteRe matches the note of the scheduler's Preempted events:
+// "Preempted by pod <uid> on node <node>", or in newer releases
+// "Preempted by <namespace>/<name> on node <node>".
+var preemptedNoteRe = regexp.MustCompile(`^Preempted by (?:pod )?(\S+) on node (\S+)`)
+
+// PriorityClassUsage is a PriorityClass and how many pods use it.
+type PriorityClassUsage struct {
+	Name             string `json:"name"`
+	Value            int32  `json:"value"`
+	GlobalDefault    bool   `json:"globalDefault,omitempty"`
+	PreemptionPolicy string `json:"preemptionPolicy"`
+	Pods             int    `json:"pods"`
+}
+
+// UnprioritizedWorkload is a workload whose pods name no PriorityClass.
+type UnprioritizedWorkload struct {
+	Namespace string `json:"namespace"`
+	Workload  string `json:"workload"`
+	Pods      int    `json:"pods"`
+	// Priority is the priority the pods got: that of the global default
+	// class, or 0.
+	Priority int32 `json:"priority"`
+	GPU      bool  `json:"gpu,omitempty"`
+}
+
+// Preemption is a pod the scheduler evicted to make room for another.
+type Preemption struct {
+	Time      time.Time `json:"time"`
+	Victim    string    `json:"victim"`
+	Preemptor string    `json:"preemptor,omitempty"`
+	// PreemptorPriority is the preemptor's priority, if it still exists.
+	PreemptorPriority *int32 `json:"preemptorPriority,omitempty"`
+	Node              string `json:"node,omitempty"`
+	Count             int32  `json:"count"`
+}
+
+// PriorityReport is the outcome of check_pod_priority in one cluster.
+type PriorityReport struct {
+	Classes       []PriorityClassUsage    `json:"classes"`
+	GlobalDefault string                  `json:"globalDefault,omitempty"`
+	Unprioritized []UnprioritizedWorkload `json:"unprioritized"`
+	Preemptions   []Preemption            `json:"preemptions"`
+}
+
+func (s *Server) toolCheckPodPriority(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	window := defaultPreemptionWindow
+	if v, ok := args["since_hours"].(float64); ok {
+		if v <= 0 || v > 24*30 {
+			return "error: since_hours must be between 0 and 720", true
+		}
+		window = time.Duration(v * float64(time.Hour))
+	}
+
+	since := time.Now().Add(-window)
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return priorityReport(ctx, client, namespace, since)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to check pod priority: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Pod Priority and Preemption\n")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		writePriorityReport(&sb, r.Result.(*PriorityReport), window)
+	}
+	return sb.String(), false
+}
+
+// priorityReport lists the PriorityClasses of a cluster, the workloads in
+// namespace, or every non-system namespace, whose pods name none, and the
+// preemptions since since.
+func priorityReport(ctx context.Context, client kubernetes.Interface, namespace string, since time.Time) (*PriorityReport, error) {
+	classes, err := client.SchedulingV1().PriorityClasses().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list priorityclasses: %w", err)
+	}
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: activePodsFieldSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	events, err := client.EventsV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("reason", "Preempted").String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	report := &PriorityReport{Classes: []PriorityClassUsage{}, Unprioritized: []UnprioritizedWorkload{}, Preemptions: []Preemption{}}
+	usage := make(map[string]int)
+	byUID := make(map[types.UID]*corev1.Pod, len(pods.Items))
+	byName := make(map[string]*corev1.Pod, len(pods.Items))
+	unprioritized := make(map[string]*UnprioritizedWorkload)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		byUID[pod.UID] = pod
+		byName[pod.Namespace+"/"+pod.Name] = pod
+		if pod.Spec.PriorityClassName != "" {
+			usage[pod.Spec.PriorityClassName]++
+			continue
+		}
+		if ValidateNamespace(pod.Namespace) != nil {
+			continue
+		}
+		workload := podWorkload(pod)
+		key := pod.Namespace + "/" + workload
+		w, ok := unprioritized[key]
+		if !ok {
+			w = &UnprioritizedWorkload{Namespace: pod.Namespace, Workload: workload}
+			unprioritized[key] = w
+		}
+		w.Pods++
+		if pod.Spec.Priority != nil {
+			w.Priority = *pod.Spec.Priority
+		}
+		w.GPU = w.GPU || podRequestsGPU(pod)
+	}
+
+	for _, pc := range classes.Items {
+		policy := string(corev1.PreemptLowerPriority)
+		if pc.PreemptionPolicy != nil {
+			policy = string(*pc.PreemptionPolicy)
+		}
+		report.Classes = append(report.Classes, PriorityClassUsage{
+			Name: pc.Name, Value: pc.Value, GlobalDefault: pc.GlobalDefault, PreemptionPolicy: policy, Pods: usage[pc.Name],
+		})
+		if pc.GlobalDefault {
+			report.GlobalDefault = pc.Name
+		}
+	}
+	sort.Slice(report.Classes, func(i, j int) bool {
+		if report.Classes[i].Value != report.Classes[j].Value {
+			return report.Classes[i].Value > report.Classes[j].Value
+		}
+		return report.Classes[i].Name < report.Classes[j].Name
+	})
+	for _, w := range unprioritized {
+		report.Unprioritized = append(report.Unprioritized, *w)
+	}
+	sort.Slice(report.Unprioritized, func(i, j int) bool {
+		a, b := report.Unprioritized[i], report.Unprioritized[j]
+		if a.GPU != b.GPU {
+			return a.GPU
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Workload < b.Workload
+	})
+
+	for _, event := range events.Items {
+		// Fake and older clients may ignore the field selector.
+		if event.Reason != "Preempted" || eventLastSeen(event).Before(since) {
+			continue
+		}
+		report.Preemptions = append(report.Preemptions, preemption(event, byUID, byName))
+	}
+	sort.SliceStable(report.Preemptions, func(i, j int) bool { return report.Preemptions[i].Time.After(report.Preemptions[j].Time) })
+	if len(report.Preemptions) > maxPreemptions {
+		report.Preemptions = report.Preemptions[:maxPreemptions]
+	}
+	return report, nil
+}
+
+// preemption reads who preempted whom from a Preempted event. The scheduler
+// records the preemptor as the event's related object; events recorded
+// through the core API only name it, by UID or name, in the note.
+func preemption(event eventsv1.Event, byUID map[types.UID]*corev1.Pod, byName map[string]*corev1.Pod) Preemption {
+	p := Preemption{
+		Time:   eventLastSeen(event),
+		Victim: event.Regarding.Kind + " " + event.Regarding.Namespace + "/" + event.Regarding.Name,
+		Count:  eventCount(event),
+	}
+	var preemptor *corev1.Pod
+	if m := preemptedNoteRe.FindStringSubmatch(event.Note); m != nil {
+		p.Node = m[2]
+		if pod := byUID[types.UID(m[1])]; pod != nil {
+			preemptor = pod
+		} else {
+			p.Preemptor = m[1]
+			preemptor = byName[m[1]]
+		}
+	}
+	if rel := event.Related; rel != nil && rel.Name != "" {
+		p.Preemptor = rel.Namespace + "/" + rel.Name
+		if pod := byUID[rel.UID]; pod != nil {
+			preemptor = pod
+		}
+	}
+	if preemptor != nil {
+		p.Preemptor = preemptor.Namespace + "/" + preemptor.Name
+		p.PreemptorPriority = preemptor.Spec.Priority
+	}
+	return p
+}
+
+// podRequestsGPU reports whether any container of pod asks for a GPU, of
+// any vendor.
+func podRequestsGPU(pod *corev1.Pod) bool {
+	for _, c := range pod.Spec.Containers {
+		for name := range c.Resources.Limits {
+			if strings.HasSuffix(string(name), "/gpu") {
+				return true
+			}
+		}
+		for name := range c.Resources.Requests {
+			if strings.HasSuffix(string(name), "/gpu") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writePriorityReport(sb *strings.Builder, r *PriorityReport, window time.Duration) {
+	sb.WriteString("### PriorityClasses\n\n")
+	sb.WriteString("| Name | Value | Preemption | Pods |\n")
+	sb.WriteString("|------|-------|------------|------|\n")
+	for _, c := range r.Classes {
+		name := c.Name
+		if c.GlobalDefault {
+			name += " (global default)"
+		}
+		_, _ = fmt.Fprintf(sb, "| %s | %d | %s | %d |\n", name, c.Value, c.PreemptionPolicy, c.Pods)
+	}
+
+	sb.WriteString("\n### Workloads Without a PriorityClass\n\n")
+	if len(r.Unprioritized) == 0 {
+		sb.WriteString("✅ Every workload names a PriorityClass.\n")
+	} else {
+		if r.GlobalDefault != "" {
+			_, _ = fmt.Fprintf(sb, "These get the priority of the global default class %s.\n\n", r.GlobalDefault)
+		} else {
+			sb.WriteString("There is no global default class, so these run at priority 0 and are the first to be preempted.\n\n")
+		}
+		sb.WriteString("| Workload | Pods | Priority | GPU |\n")
+		sb.WriteString("|----------|------|----------|-----|\n")
+		for _, w := range r.Unprioritized {
+			gpu := ""
+			if w.GPU {
+				gpu = "⚠️ yes"
+			}
+			_, _ = fmt.Fprintf(sb, "| %s/%s | %d | %d | %s |\n", w.Namespace, w.Workload, w.Pods, w.Priority, gpu)
+		}
+	}
+
+	_, _ = fmt.Fprintf(sb, "\n### Preemptions in the Last %s\n\n", formatDuration(window))
+	if len(r.Preemptions) == 0 {
+		sb.WriteString("✅ No preemptions recorded. Events are kept for an hour by default, so older preemptions may not show.\n")
+		return
+	}
+	sb.WriteString("| When | Victim | Preempted by | Priority | Node | Count |\n")
+	sb.WriteString("|------|--------|--------------|----------|------|-------|\n")
+	for _, p := range r.Preemptions {
+		preemptor, priority := p.Preemptor, ""
+		if preemptor == "" {
+			preemptor = "unknown"
+		}
+		if p.PreemptorPriority != nil {
+			priority = fmt.Sprintf("%d", *p.PreemptorPriority)
+		}
+		_, _ = fmt.Fprintf(sb, "| %s ago | %s | %s | %s | %s | %d |\n", formatAge(p.Time), p.Victim, preemptor, priority, p.Node, p.Count)
+	}
+}
@@ -0,0 +1,31 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "check_pod_priority",
+		Description: "List PriorityClasses and how many pods use each, the workloads whose pods name no PriorityClass (GPU workloads first), and recent preemptions: which pod was evicted, by which pod, on which node. Explains surprise preemptions on shared and GPU clusters",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to check (all namespaces if not specified)",
+				},
+				"since_hours": {
+					Type:        "number",
+					Description: "Hours of preemption events to report (default 24, max 720). Events are only kept as long as the API server's event TTL",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolCheckPodPriority(ctx, args)
+		},
+	)
+}
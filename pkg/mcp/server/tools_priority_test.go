@@ -0,0 +1,94 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testPriorityPod(name, class string, priority int32, gpu bool) *corev1.Pod {
+	controller := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "ml", UID: types.UID("uid-" + name),
+			OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: name[:len(name)-2], Controller: &controller}},
+		},
+		Spec: corev1.PodSpec{
+			PriorityClassName: class,
+			Priority:          &priority,
+			Containers:        []corev1.Container{{Name: "app"}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if gpu {
+		pod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}
+	}
+	return pod
+}
+
+func TestCheckPodPriority(t *testing.T) {
+	never := corev1.PreemptNever
+	server, _ := newJobsServer(map[string][]runtime.Object{
+		"gpu": {
+			&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "training-high"}, Value: 1000},
+			&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "batch"}, Value: 100, PreemptionPolicy: &never},
+			testPriorityPod("trainer-0", "training-high", 1000, true),
+			testPriorityPod("notebook-0", "", 0, true),
+			testPriorityPod("notebook-1", "", 0, true),
+			testPriorityPod("web-0", "", 0, false),
+			&eventsv1.Event{
+				ObjectMeta: metav1.ObjectMeta{Name: "notebook-2.1", Namespace: "ml"},
+				Reason:     "Preempted",
+				Note:       "Preempted by pod uid-trainer-0 on node gpu-node-1",
+				Regarding:  corev1.ObjectReference{Kind: "Pod", Namespace: "ml", Name: "notebook-2"},
+				EventTime:  metav1.NewMicroTime(time.Now().Add(-time.Hour)),
+			},
+			&eventsv1.Event{
+				ObjectMeta: metav1.ObjectMeta{Name: "old.1", Namespace: "ml"},
+				Reason:     "Preempted",
+				Note:       "Preempted by ml/trainer-0 on node gpu-node-2",
+				Regarding:  corev1.ObjectReference{Kind: "Pod", Namespace: "ml", Name: "old"},
+				EventTime:  metav1.NewMicroTime(time.Now().Add(-72 * time.Hour)),
+			},
+		},
+		"cpu": {
+			&schedulingv1.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: "default"}, Value: 10, GlobalDefault: true},
+		},
+	})
+
+	result, rpcErr := callTool(t, server, "check_pod_priority", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	out := result.Content[0].Text
+
+	assert.Contains(t, out, "| training-high | 1000 | PreemptLowerPriority | 1 |")
+	assert.Contains(t, out, "| batch | 100 | Never | 0 |")
+	assert.Contains(t, out, "| default (global default) | 10 | PreemptLowerPriority | 0 |")
+	assert.Contains(t, out, "There is no global default class")
+	assert.Contains(t, out, "| ml/StatefulSet/notebook | 2 | 0 | ⚠️ yes |")
+	assert.Contains(t, out, "| ml/StatefulSet/web | 1 | 0 |  |")
+	assert.Less(t, strings.Index(out, "notebook |"), strings.Index(out, "web |"), "GPU workloads come first")
+	assert.Contains(t, out, "| Pod ml/notebook-2 | ml/trainer-0 | 1000 | gpu-node-1 | 1 |")
+	assert.NotContains(t, out, "ml/old", "preemptions before since_hours are left out")
+	assert.Contains(t, out, "✅ Every workload names a PriorityClass.")
+	assert.Less(t, strings.Index(out, "## cpu"), strings.Index(out, "## gpu"))
+
+	result, rpcErr = callTool(t, server, "check_pod_priority", map[string]interface{}{"cluster": "gpu", "since_hours": 96})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	assert.Contains(t, result.Content[0].Text, "| Pod ml/old | ml/trainer-0 | 1000 | gpu-node-2 | 1 |")
+
+	result, rpcErr = callTool(t, server, "check_pod_priority", map[string]interface{}{"since_hours": -1})
+	require.Nil(t, rpcErr)
+	assert.True(t, result.IsError)
+}
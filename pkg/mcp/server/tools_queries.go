@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// Where a saved query was defined.
+const (
+	querySourceTool   = "tool"
+	querySourceConfig = "config"
+)
+
+// queryTools manage saved queries, so they cannot be saved as queries.
+var queryTools = map[string]bool{
+	"save_query":   true,
+	"run_query":    true,
+	"list_queries": true,
+	"delete_query": true,
+}
+
+// savedQuery is a named tool call with fixed arguments. Queries saved with
+// save_query are persisted in store.BucketSavedQueries; those of the
+// configuration file are read from config.EnvQueries.
+type savedQuery struct {
+	Name        string                 `json:"name"`
+	Tool        string                 `json:"tool"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	// Source is save_query or the configuration file, which owns the
+	// queries it lists.
+	Source string `json:"source"`
+	// User is the HTTP caller that saved the query. Only that caller can
+	// see, run, or change it over HTTP.
+	User    string    `json:"user,omitempty"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// visibleTo reports whether the caller of ctx may see and run q. Queries
+// of the configuration file are shared by everyone; over HTTP, queries
+// saved with save_query are only visible to the caller that saved them.
+func (q *savedQuery) visibleTo(ctx context.Context) bool {
+	if q.Source == querySourceConfig || !isHTTPRequest(ctx) {
+		return true
+	}
+	owner := ""
+	if caller := callerFrom(ctx); caller != nil {
+		owner = caller.User
+	}
+	return q.User == owner
+}
+
+// checkQueryable returns why tool cannot be saved as a query, if it can't.
+// Required arguments may be left to run_query.
+func checkQueryable(tool string) error {
+	switch {
+	case findToolDef(tool) == nil:
+		return fmt.Errorf("unknown tool: %s", tool)
+	case queryTools[tool]:
+		return fmt.Errorf("%s cannot be saved as a query", tool)
+	}
+	return nil
+}
+
+// configQueries returns the queries of the configuration file by name.
+func configQueries() map[string]*savedQuery {
+	queries, err := config.QueriesFromEnv()
+	if err != nil {
+		log.Printf("Failed to load configured queries: %v", err)
+		return nil
+	}
+	byName := make(map[string]*savedQuery, len(queries))
+	for _, q := range queries {
+		byName[q.Name] = &savedQuery{
+			Name:        q.Name,
+			Tool:        q.Tool,
+			Arguments:   q.Arguments,
+			Description: q.Description,
+			Source:      querySourceConfig,
+		}
+	}
+	return byName
+}
+
+// loadQuery returns query name. Queries of the configuration file take
+// precedence over those saved with save_query.
+func (s *Server) loadQuery(ctx context.Context, name string) (*savedQuery, error) {
+	if q, ok := configQueries()[name]; ok {
+		return q, nil
+	}
+	var q savedQuery
+	if err := store.GetJSON(ctx, s.getStateStore(), store.BucketSavedQueries, name, &q); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("query %q not found", name)
+		}
+		return nil, err
+	}
+	return &q, nil
+}
+
+// savedQueries returns every query visible to the caller of ctx, by name.
+func (s *Server) savedQueries(ctx context.Context) ([]*savedQuery, error) {
+	byName := configQueries()
+	items, err := s.getStateStore().List(ctx, store.BucketSavedQueries)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		var q savedQuery
+		if json.Unmarshal(item.Value, &q) != nil {
+			continue
+		}
+		if _, ok := byName[q.Name]; !ok {
+			byName[q.Name] = &q
+		}
+	}
+	queries := make([]*savedQuery, 0, len(byName))
+	for _, q := range byName {
+		if q.visibleTo(ctx) {
+			queries = append(queries, q)
+		}
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries, nil
+}
+
+func (s *Server) toolSaveQuery(ctx context.Context, args map[string]interface{}) (string, bool) {
+	name, _ := args["name"].(string)
+	tool, _ := args["tool"].(string)
+	description, _ := args["description"].(string)
+	arguments, _ := args["arguments"].(map[string]interface{})
+	if name == "" || tool == "" {
+		return "name and tool are required", true
+	}
+	if len(name) > 63 || !k8sNamespaceRe.MatchString(name) {
+		return fmt.Sprintf("name %q is invalid: must be lowercase alphanumeric and hyphens only", name), true
+	}
+	if err := checkQueryable(tool); err != nil {
+		return err.Error(), true
+	}
+	now := time.Now()
+	q := &savedQuery{
+		Name:        name,
+		Tool:        tool,
+		Arguments:   arguments,
+		Description: description,
+		Source:      querySourceTool,
+		Created:     now,
+		Updated:     now,
+	}
+	if isHTTPRequest(ctx) {
+		if caller := callerFrom(ctx); caller != nil {
+			q.User = caller.User
+		}
+	}
+
+	s.queryMu.Lock()
+	replaced := false
+	existing, err := s.loadQuery(ctx, name)
+	switch {
+	case err == nil && existing.Source == querySourceConfig:
+		err = fmt.Errorf("query %q is defined in the configuration file; change it there", name)
+	case err == nil && !existing.visibleTo(ctx):
+		err = fmt.Errorf("query %q belongs to another user", name)
+	case err == nil:
+		q.Created, replaced = existing.Created, true
+		err = store.PutJSON(ctx, s.getStateStore(), store.BucketSavedQueries, name, q)
+	default:
+		err = store.PutJSON(ctx, s.getStateStore(), store.BucketSavedQueries, name, q)
+	}
+	s.queryMu.Unlock()
+	if err != nil {
+		return err.Error(), true
+	}
+
+	var sb strings.Builder
+	if replaced {
+		_, _ = fmt.Fprintf(&sb, "# Query Updated: %s\n\n", name)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "# Query Saved: %s\n\n", name)
+	}
+	writeSavedQuery(&sb, q)
+	_, _ = fmt.Fprintf(&sb, "\nRun it with run_query name=%s.\n", name)
+	return sb.String(), false
+}
+
+// toolRunQuery runs a saved query through the same authorization, approval
+// gate, and redaction as a tools/call request of its tool. The arguments
+// of the call override those of the query.
+func (s *Server) toolRunQuery(ctx context.Context, args map[string]interface{}) (string, bool) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "name is required", true
+	}
+	q, err := s.loadQuery(ctx, name)
+	if err == nil && !q.visibleTo(ctx) {
+		err = fmt.Errorf("query %q not found", name)
+	}
+	if err != nil {
+		return err.Error(), true
+	}
+	overrides, _ := args["arguments"].(map[string]interface{})
+	runArgs := make(map[string]interface{}, len(q.Arguments)+len(overrides))
+	for k, v := range q.Arguments {
+		runArgs[k] = v
+	}
+	for k, v := range overrides {
+		runArgs[k] = v
+	}
+
+	result, err := s.CallTool(ctx, q.Tool, runArgs)
+	if err != nil {
+		return fmt.Sprintf("query %s: %v", name, err), true
+	}
+	texts := make([]string, 0, len(result.Content))
+	for _, block := range result.Content {
+		texts = append(texts, block.Text)
+	}
+	return strings.Join(texts, "\n"), result.IsError
+}
+
+func (s *Server) toolListQueries(ctx context.Context, args map[string]interface{}) (string, bool) {
+	queries, err := s.savedQueries(ctx)
+	if err != nil {
+		return fmt.Sprintf("Failed to read saved queries: %v", err), true
+	}
+	var sb strings.Builder
+	sb.WriteString("# Saved Queries\n\n")
+	if len(queries) == 0 {
+		sb.WriteString("No saved queries. Save one with save_query or in the configuration file's queries section.\n")
+		return sb.String(), false
+	}
+	for _, q := range queries {
+		_, _ = fmt.Fprintf(&sb, "## %s\n", q.Name)
+		writeSavedQuery(&sb, q)
+		sb.WriteString("\n")
+	}
+	return sb.String(), false
+}
+
+func (s *Server) toolDeleteQuery(ctx context.Context, args map[string]interface{}) (string, bool) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "name is required", true
+	}
+	s.queryMu.Lock()
+	defer s.queryMu.Unlock()
+	q, err := s.loadQuery(ctx, name)
+	switch {
+	case err == nil && !q.visibleTo(ctx):
+		err = fmt.Errorf("query %q not found", name)
+	case err == nil && q.Source == querySourceConfig:
+		err = fmt.Errorf("query %q is defined in the configuration file; remove it there", name)
+	}
+	if err != nil {
+		return err.Error(), true
+	}
+	if err := s.getStateStore().Delete(ctx, store.BucketSavedQueries, name); err != nil {
+		return fmt.Sprintf("Failed to delete query %s: %v", name, err), true
+	}
+	return fmt.Sprintf("Deleted query %s.", name), false
+}
+
+func writeSavedQuery(sb *strings.Builder, q *savedQuery) {
+	if q.Description != "" {
+		_, _ = fmt.Fprintf(sb, "%s\n\n", q.Description)
+	}
+	_, _ = fmt.Fprintf(sb, "**Tool:** %s\n", q.Tool)
+	if len(q.Arguments) > 0 {
+		_, _ = fmt.Fprintf(sb, "**Arguments:** %s\n", formatToolArguments(q.Arguments))
+	}
+	_, _ = fmt.Fprintf(sb, "**Source:** %s\n", q.Source)
+	if q.User != "" {
+		_, _ = fmt.Fprintf(sb, "**Owner:** %s\n", q.User)
+	}
+}
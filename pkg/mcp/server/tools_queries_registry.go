@@ -0,0 +1,88 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "save_query",
+		Description: "Save a named tool call with fixed arguments, e.g. prod-pod-issues for find_pod_issues with cluster=prod and namespace=payments, so recurring requests can be run by name with run_query. Replaces a query of the same name. Over HTTP, queries are private to the caller that saved them",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"name": {
+					Type:        "string",
+					Description: "Query name (lowercase alphanumeric and hyphens)",
+				},
+				"tool": {
+					Type:        "string",
+					Description: "Tool the query runs",
+				},
+				"arguments": {
+					Type:        "object",
+					Description: "Arguments of the tool, as in a tools/call request. Required arguments may be left to run_query",
+				},
+				"description": {
+					Type:        "string",
+					Description: "What the query is for",
+				},
+			},
+			Required: []string{"name", "tool"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolSaveQuery(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "run_query",
+		Description: "Run a saved query, from save_query or the configuration file, and return its tool's result. The call is authorized, and gated for approval if the tool changes cluster state, as a direct call of the tool would be",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"name": {
+					Type:        "string",
+					Description: "Query name",
+				},
+				"arguments": {
+					Type:        "object",
+					Description: "Arguments that override or add to those of the query, e.g. {\"cluster\": \"staging\"} or {\"approved\": true}",
+				},
+			},
+			Required: []string{"name"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolRunQuery(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "list_queries",
+		Description: "List saved queries, from save_query and the configuration file, with their tools and arguments",
+		InputSchema: InputSchema{
+			Type:       "object",
+			Properties: map[string]Property{},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolListQueries(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "delete_query",
+		Description: "Delete a query saved with save_query. Queries from the configuration file are removed there",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"name": {
+					Type:        "string",
+					Description: "Query name",
+				},
+			},
+			Required: []string{"name"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolDeleteQuery(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+func TestSaveQuery_Validation(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"missing", map[string]interface{}{"name": "changes"}, "name and tool are required"},
+		{"bad name", map[string]interface{}{"name": "Prod_Pods", "tool": "find_pod_issues"}, "is invalid"},
+		{"unknown tool", map[string]interface{}{"name": "changes", "tool": "nope"}, "unknown tool: nope"},
+		{"query tool", map[string]interface{}{"name": "changes", "tool": "run_query"}, "cannot be saved as a query"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, isErr := s.toolSaveQuery(ctx, tt.args)
+			assert.True(t, isErr)
+			assert.Contains(t, out, tt.want)
+		})
+	}
+
+	out, isErr := s.toolScheduleToolRun(ctx, map[string]interface{}{"name": "nightly", "tool": "run_query", "schedule": "@daily"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "schedule the tool of the query instead")
+}
+
+func TestSavedQueries_SaveRunListDelete(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	ctx := context.Background()
+
+	out, isErr := s.toolSaveQuery(ctx, map[string]interface{}{
+		"name":        "recent-changes",
+		"tool":        "list_changes",
+		"arguments":   map[string]interface{}{"limit": float64(3)},
+		"description": "The last few changes",
+	})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "# Query Saved: recent-changes")
+	assert.Contains(t, out, "**Arguments:** limit=3")
+
+	out, isErr = s.toolRunQuery(ctx, map[string]interface{}{"name": "recent-changes"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "No changes recorded")
+	out, isErr = s.toolRunQuery(ctx, map[string]interface{}{"name": "recent-changes", "arguments": map[string]interface{}{"output": "nope"}})
+	assert.True(t, isErr, "arguments of run_query reach the tool")
+	assert.Contains(t, out, "nope")
+
+	out, isErr = s.toolSaveQuery(ctx, map[string]interface{}{"name": "recent-changes", "tool": "list_changes"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "# Query Updated: recent-changes")
+
+	out, _ = s.toolListQueries(ctx, nil)
+	assert.Contains(t, out, "## recent-changes\n**Tool:** list_changes\n**Source:** tool\n")
+
+	out, isErr = s.toolDeleteQuery(ctx, map[string]interface{}{"name": "recent-changes"})
+	require.False(t, isErr, out)
+	_, isErr = s.toolRunQuery(ctx, map[string]interface{}{"name": "recent-changes"})
+	assert.True(t, isErr)
+	out, _ = s.toolListQueries(ctx, nil)
+	assert.Contains(t, out, "No saved queries")
+}
+
+func TestSavedQueries_ConfigQueries(t *testing.T) {
+	t.Setenv(config.EnvQueries, `[{"name":"changes","tool":"list_changes","description":"Recent changes"}]`)
+	s := &Server{stateStore: store.NewMemory()}
+	alice := WithRemoteCaller(context.Background(), &auth.Identity{User: "alice"})
+
+	out, isErr := s.toolRunQuery(alice, map[string]interface{}{"name": "changes"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "No changes recorded")
+	out, _ = s.toolListQueries(alice, nil)
+	assert.Contains(t, out, "## changes\nRecent changes\n\n**Tool:** list_changes\n**Source:** config\n", "configured queries are shared")
+
+	out, isErr = s.toolSaveQuery(alice, map[string]interface{}{"name": "changes", "tool": "get_pods"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "change it there")
+	out, isErr = s.toolDeleteQuery(alice, map[string]interface{}{"name": "changes"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "remove it there")
+}
+
+func TestSavedQueries_HTTPCallersSeeTheirOwn(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	alice := WithRemoteCaller(context.Background(), &auth.Identity{User: "alice"})
+	bob := WithRemoteCaller(context.Background(), &auth.Identity{User: "bob"})
+
+	out, isErr := s.toolSaveQuery(alice, map[string]interface{}{"name": "mine", "tool": "list_changes"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "**Owner:** alice")
+
+	out, _ = s.toolListQueries(bob, nil)
+	assert.Contains(t, out, "No saved queries")
+	for _, call := range []func(context.Context, map[string]interface{}) (string, bool){s.toolRunQuery, s.toolDeleteQuery} {
+		out, isErr = call(bob, map[string]interface{}{"name": "mine"})
+		assert.True(t, isErr)
+		assert.Contains(t, out, `query "mine" not found`)
+	}
+	_, isErr = s.toolSaveQuery(bob, map[string]interface{}{"name": "mine", "tool": "get_pods"})
+	assert.True(t, isErr)
+
+	out, _ = s.toolListQueries(context.Background(), nil)
+	assert.Contains(t, out, "## mine", "stdio sees every query")
+}
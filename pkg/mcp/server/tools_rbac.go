@@ -3,14 +3,18 @@ package server
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	authorizationv1 "k8s.io/api/authorization/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
 )
 
 func (s *Server) toolGetRoles(ctx context.Context, args map[string]interface{}) (string, bool) {
@@ -461,6 +465,7 @@ func (s *Server) toolFindResourceOwners(ctx context.Context, args map[string]int
 		Owner      string
 		Team       string
 		LastUpdate string
+		GitOps     *GitOpsOwner
 	}
 
 	owners := []resourceOwner{}
@@ -515,6 +520,7 @@ func (s *Server) toolFindResourceOwners(ctx context.Context, args map[string]int
 		if val, ok := annotations["meta.helm.sh/release-name"]; ok {
 			ro.ManagedBy = "helm:" + val
 		}
+		ro.GitOps = detectGitOpsOwner(ns, labels, annotations)
 
 		return ro
 	}
@@ -572,10 +578,25 @@ func (s *Server) toolFindResourceOwners(ctx context.Context, args map[string]int
 		return "No resources found in namespace " + namespace, false
 	}
 
+	// Resolve GitOps labels to the Application, Kustomization, HelmRelease,
+	// or Helm release they name and its source
+	resolver := newOwnerResolver(client, func() (dynamic.Interface, error) { return s.getDynamicClientForCluster(cluster) })
+	gitOpsResources := make(map[*GitOpsOwner][]string)
+	for i := range owners {
+		ro := &owners[i]
+		if ro.GitOps == nil {
+			continue
+		}
+		if ro.GitOps = resolver.resolve(ctx, ro.GitOps); ro.GitOps != nil {
+			gitOpsResources[ro.GitOps] = append(gitOpsResources[ro.GitOps], ro.Kind+"/"+ro.Name)
+		}
+	}
+
 	// Build output
 	var sb strings.Builder
 	_, _ = fmt.Fprintf(&sb, "# Resource Ownership in namespace: %s\n\n", namespace)
 	_, _ = fmt.Fprintf(&sb, "Found %d resources\n\n", len(owners))
+	writeGitOpsOwners(&sb, gitOpsResources)
 
 	// Group by manager
 	managerGroups := make(map[string][]resourceOwner)
@@ -598,6 +619,9 @@ func (s *Server) toolFindResourceOwners(ctx context.Context, args map[string]int
 			if ro.ManagedBy != "" {
 				_, _ = fmt.Fprintf(&sb, " [managed-by: %s]", ro.ManagedBy)
 			}
+			if ro.GitOps != nil {
+				_, _ = fmt.Fprintf(&sb, " [gitops: %s]", ro.GitOps)
+			}
 			if ro.Team != "" {
 				_, _ = fmt.Fprintf(&sb, " [team: %s]", ro.Team)
 			}
@@ -638,6 +662,12 @@ func (s *Server) toolFindResourceOwners(ctx context.Context, args map[string]int
 			ro.Kind, ro.Name, manager, owner, managedBy, team, lastUpdate)
 	}
 
+	// Managers are only the field managers of the last writes; the audit
+	// log, when there is one, has the users behind them.
+	if _, err := s.auditLogSource(cluster); err == nil {
+		_, _ = fmt.Fprintf(&sb, "\nFor who made each change, run query_audit_log with namespace %s.\n", namespace)
+	}
+
 	return sb.String(), false
 }
 
@@ -704,7 +734,7 @@ func (s *Server) toolAuditKubeconfig(ctx context.Context, args map[string]interf
 		// Set timeout
 		restConfig.Timeout = time.Duration(timeoutSeconds) * time.Second
 
-		clientset, err := kubernetes.NewForConfig(restConfig)
+		clientset, err := kubernetes.NewForConfig(multicluster.ProtobufConfig(s.prepareConfig(restConfig)))
 		if err != nil {
 			result.Accessible = false
 			result.Error = fmt.Sprintf("Client error: %v", err)
@@ -798,6 +828,14 @@ func (s *Server) toolAuditKubeconfig(ctx context.Context, args map[string]interf
 		sb.WriteString("\n")
 	}
 
+	home, _ := os.UserHomeDir()
+	credentials := auditKubeconfigCredentials(config, home, time.Now())
+	writeKubeconfigCredentialAudit(&sb, credentials, time.Now())
+	credentialProblems := 0
+	for _, c := range credentials {
+		credentialProblems += len(c.Problems)
+	}
+
 	// Find duplicate contexts (same server URL)
 	serverToContexts := make(map[string][]string)
 	for _, r := range results {
@@ -878,9 +916,9 @@ func (s *Server) toolAuditKubeconfig(ctx context.Context, args map[string]interf
 	}
 
 	// Summary
-	if inaccessible == 0 && !hasDuplicates {
+	if inaccessible == 0 && !hasDuplicates && credentialProblems == 0 {
 		sb.WriteString("## All Good!\n\n")
-		sb.WriteString("All clusters are accessible and no duplicates found.\n")
+		sb.WriteString("All clusters are accessible, no duplicates found, and no credential problems found.\n")
 	}
 
 	return sb.String(), false
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // writeKubeconfig writes body to a temp file under t.TempDir() and returns the
@@ -159,7 +162,9 @@ func TestToolAuditKubeconfig_AccessibleClusterAllGood(t *testing.T) {
 	// Stand up a fake API server serving the discovery /version endpoint so
 	// clientset.Discovery().ServerVersion() succeeds and we exercise the
 	// "Accessible" render path + the "All Good!" summary branch.
-	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	// It serves TLS and the context holds its CA and an expiring token, so
+	// the credential audit has nothing to flag either.
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/version" {
 			w.Header().Set("Content-Type", "application/json")
 			_, _ = w.Write([]byte(`{"major":"1","minor":"29","gitVersion":"v1.29.3","gitCommit":"abc","gitTreeState":"clean","buildDate":"2026-01-01T00:00:00Z","goVersion":"go1.22","compiler":"gc","platform":"linux/amd64"}`))
@@ -176,17 +181,18 @@ clusters:
 - name: cluster-live
   cluster:
     server: %s
-    insecure-skip-tls-verify: true
+    certificate-authority-data: %s
 users:
 - name: user-live
   user:
-    token: abc
+    token: %s
 contexts:
 - name: ctx-live
   context:
     cluster: cluster-live
     user: user-live
-`, ts.URL)
+`, ts.URL, base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})),
+		testJWT(t, map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix()}))
 	path := writeKubeconfig(t, body)
 	server := &Server{
 		discoverer: stubDiscoverer{},
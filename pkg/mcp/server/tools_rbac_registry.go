@@ -125,7 +125,7 @@ func init() {
 			return s.toolCanI(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterCachedTool(Tool{
 			Name:        "analyze_subject_permissions",
 			Description: "Analyze all RBAC permissions for a specific subject (user, group, or service account)",
 			InputSchema: InputSchema{
@@ -152,6 +152,7 @@ func init() {
 				Required: []string{"subject_kind", "subject_name"},
 			},
 		},
+		scanCacheTTL,
 		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 			return s.toolAnalyzeSubjectPermissions(ctx, args)
 		},
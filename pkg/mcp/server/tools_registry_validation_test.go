@@ -9,8 +9,40 @@ import (
 
 // expectedToolsByRegistry maps each registry file to its expected tool names.
 var expectedToolsByRegistry = map[string][]string{
-	"cluster": {"list_clusters", "get_cluster_health"},
-	"drift":   {"detect_drift"},
+	"addons":    {"list_addons"},
+	"doctor":    {"check_environment", "whoami"},
+	"anomalies": {"find_resource_anomalies"},
+	"argorollouts": {
+		"list_argo_rollouts", "get_argo_rollout", "promote_argo_rollout",
+		"abort_argo_rollout", "set_argo_rollout_image",
+	},
+	"auditlog": {"query_audit_log"},
+	"changes":  {"what_changed"},
+	"capi": {
+		"list_capi_clusters", "list_machine_deployments",
+		"get_machine_health", "scale_machine_deployment",
+	},
+	"certmanager": {
+		"list_certificates", "list_certificate_issuers",
+		"diagnose_certificates", "renew_certificate",
+	},
+	"cluster":      {"list_clusters", "get_cluster_health"},
+	"connectivity": {"test_connectivity"},
+	"debug":        {"launch_debug_pod"},
+	"drift":        {"detect_drift"},
+	"externalsecrets": {
+		"list_external_secrets", "list_secret_stores",
+		"diagnose_external_secrets", "refresh_external_secret",
+	},
+	"mesh":       {"diagnose_service_mesh"},
+	"networking": {"get_multicluster_network_status"},
+	"openshift":  {"list_routes", "check_workload_scc"},
+	"priority":   {"check_pod_priority"},
+	"webhooks":   {"check_admission_webhooks"},
+	"jobs": {
+		"get_cronjobs", "run_cronjob_now", "suspend_cronjob",
+		"resume_cronjob", "get_cronjob_logs",
+	},
 	"policy": {
 		"check_gatekeeper", "get_ownership_policy_status",
 		"list_ownership_violations", "install_ownership_policy",
@@ -28,6 +60,7 @@ var expectedToolsByRegistry = map[string][]string{
 		"check_security_issues", "analyze_namespace", "get_warning_events",
 		"audit_kubeconfig", "find_resource_owners",
 	},
+	"vulnerabilities": {"get_image_vulnerabilities"},
 }
 
 func TestRegistryTools_AllExpectedToolsRegistered(t *testing.T) {
@@ -0,0 +1,299 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+	"github.com/kubestellar/kubestellar-mcp/pkg/output"
+	"github.com/kubestellar/kubestellar-mcp/pkg/redact"
+)
+
+// EnvReportDir is the directory generate_report writes reports to. It
+// defaults to kubestellar-reports in the system temporary directory.
+const EnvReportDir = "KUBESTELLAR_REPORT_DIR"
+
+// EnvPDFConverter names the wkhtmltopdf-compatible executable that converts
+// reports to PDF. It defaults to wkhtmltopdf on the PATH.
+const EnvPDFConverter = "KUBESTELLAR_PDF_CONVERTER"
+
+// reportSection is an analysis a report can include, run per cluster by
+// an existing tool.
+type reportSection struct {
+	Name  string
+	Title string
+	Tool  string
+	// Namespaced sections are limited to the report's namespace, if any.
+	Namespaced bool
+	// Flag, if set, is the message whose presence in a cluster's result
+	// flags the cluster in the report's contents.
+	Flag messages.Key
+}
+
+var reportSections = []reportSection{
+	{Name: "fleet_health", Title: "Fleet Health", Tool: "get_cluster_health"},
+	{Name: "security", Title: "Security Posture", Tool: "check_security_issues", Namespaced: true},
+	{Name: "rbac", Title: "RBAC Audit", Tool: "get_cluster_role_bindings"},
+	{Name: "upgrades", Title: "Upgrade Readiness", Tool: "get_upgrade_prerequisites"},
+	{Name: "versions", Title: "Version Support", Tool: "get_cluster_version_info", Flag: messages.AdvisoryTitle},
+}
+
+func reportSectionNames() []string {
+	names := make([]string, len(reportSections))
+	for i, section := range reportSections {
+		names[i] = section.Name
+	}
+	return names
+}
+
+// htmlToPDF converts a report to PDF. Tests replace it.
+var htmlToPDF = func(ctx context.Context, page []byte) ([]byte, error) {
+	converter := os.Getenv(EnvPDFConverter)
+	if converter == "" {
+		converter = "wkhtmltopdf"
+	}
+	path, err := exec.LookPath(converter)
+	if err != nil {
+		return nil, fmt.Errorf("PDF output needs %s (set %s to another wkhtmltopdf-compatible converter): %w", converter, EnvPDFConverter, err)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "--quiet", "-", "-")
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(page), &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", converter, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// reportResult is one section's result in one cluster.
+type reportResult struct {
+	text    string
+	isError bool
+}
+
+// toolGenerateReport runs the selected analyses on each cluster and renders
+// them into a standalone HTML, or PDF, document for sharing outside the
+// chat. The document is written to the report directory or returned base64
+// encoded.
+func (s *Server) toolGenerateReport(ctx context.Context, args map[string]interface{}) (string, bool) {
+	title, _ := args["title"].(string)
+	if title == "" {
+		title = "Fleet Report"
+	}
+	format, _ := args["format"].(string)
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" && format != "pdf" {
+		return fmt.Sprintf("error: invalid format %q: must be html or pdf", format), true
+	}
+	destination, _ := args["destination"].(string)
+	if destination == "" {
+		destination = "inline"
+	}
+	if destination != "inline" && destination != "file" {
+		return fmt.Sprintf("error: invalid destination %q: must be inline or file", destination), true
+	}
+	filename, _ := args["filename"].(string)
+	if filename != "" && (filename != filepath.Base(filename) || strings.HasPrefix(filename, ".")) {
+		return fmt.Sprintf("error: invalid filename %q: must be a plain file name", filename), true
+	}
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+
+	sections := reportSections
+	if v, ok := args["sections"].([]interface{}); ok && len(v) > 0 {
+		sections = nil
+		for _, name := range v {
+			section, ok := findReportSection(name)
+			if !ok {
+				return fmt.Sprintf("error: unknown section %v: must be one of %s", name, strings.Join(reportSectionNames(), ", ")), true
+			}
+			sections = append(sections, section)
+		}
+	}
+
+	var clusters []string
+	if v, ok := args["clusters"].([]interface{}); ok && len(v) > 0 {
+		for _, c := range v {
+			name, _ := c.(string)
+			clusters = append(clusters, name)
+		}
+	} else {
+		discovered, err := s.discoverer.DiscoverClusters("all")
+		if err != nil {
+			return fmt.Sprintf("Failed to discover clusters: %v", err), true
+		}
+		for _, c := range discovered {
+			clusters = append(clusters, c.Name)
+		}
+	}
+	if len(clusters) == 0 {
+		return "error: no clusters to report on", true
+	}
+	sort.Strings(clusters)
+
+	now := time.Now().UTC()
+	results := s.runReportSections(ctx, sections, clusters, namespace)
+	page := []byte(renderReport(title, now, sections, clusters, namespace, results))
+	mediaType := "text/html"
+	if format == "pdf" {
+		if page, err = htmlToPDF(ctx, page); err != nil {
+			return fmt.Sprintf("Failed to convert report to PDF: %v", err), true
+		}
+		mediaType = "application/pdf"
+	}
+	if filename == "" {
+		filename = fmt.Sprintf("fleet-report-%s.%s", now.Format("20060102-150405"), format)
+	}
+
+	sectionNames := make([]string, len(sections))
+	for i, section := range sections {
+		sectionNames[i] = section.Name
+	}
+	response := map[string]interface{}{
+		"title":       title,
+		"format":      format,
+		"mediaType":   mediaType,
+		"sections":    sectionNames,
+		"clusters":    clusters,
+		"generatedAt": now.Format(time.RFC3339),
+		"bytes":       len(page),
+	}
+	if destination == "file" {
+		dir := os.Getenv(EnvReportDir)
+		if dir == "" {
+			dir = filepath.Join(os.TempDir(), "kubestellar-reports")
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Sprintf("Failed to create report directory: %v", err), true
+		}
+		path := filepath.Join(dir, filename)
+		if err := os.WriteFile(path, page, 0o644); err != nil {
+			return fmt.Sprintf("Failed to write report: %v", err), true
+		}
+		response["path"] = path
+	} else {
+		response["filename"] = filename
+		response["encoding"] = "base64"
+		response["data"] = base64.StdEncoding.EncodeToString(page)
+	}
+	data, _ := json.MarshalIndent(response, "", "  ")
+	return string(data), false
+}
+
+func findReportSection(name interface{}) (reportSection, bool) {
+	for _, section := range reportSections {
+		if section.Name == name {
+			return section, true
+		}
+	}
+	return reportSection{}, false
+}
+
+// runReportSections runs each section's tool on each cluster, clusters in
+// parallel. Results are keyed by section name, then cluster.
+func (s *Server) runReportSections(ctx context.Context, sections []reportSection, clusters []string, namespace string) map[string]map[string]reportResult {
+	results := make(map[string]map[string]reportResult, len(sections))
+	for _, section := range sections {
+		results[section.Name] = make(map[string]reportResult, len(clusters))
+	}
+	sem := make(chan struct{}, maxConcurrentClusterOperations)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, cluster := range clusters {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(cluster string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			for _, section := range sections {
+				args := map[string]interface{}{"cluster": cluster}
+				if section.Namespaced && namespace != "" {
+					args["namespace"] = namespace
+				}
+				var result reportResult
+				if handler := findToolHandler(section.Tool); handler != nil {
+					result.text, result.isError = handler(ctx, s, args)
+				} else {
+					result = reportResult{text: fmt.Sprintf("%s is not available", section.Tool), isError: true}
+				}
+				mu.Lock()
+				results[section.Name][cluster] = result
+				mu.Unlock()
+			}
+		}(cluster)
+	}
+	wg.Wait()
+	return results
+}
+
+const reportStyle = `body{font-family:-apple-system,"Segoe UI",Helvetica,Arial,sans-serif;margin:2em auto;max-width:1100px;color:#1f2328;padding:0 1em}
+h1{border-bottom:2px solid #d0d7de;padding-bottom:.3em}h2{margin-top:2em;border-bottom:1px solid #d0d7de}
+.meta{color:#59636e}.error{background:#ffebe9;border:1px solid #ff8182;padding:.5em 1em;border-radius:6px}
+table{border-collapse:collapse;margin:.5em 0}th,td{border:1px solid #d0d7de;padding:4px 8px;text-align:left}th{background:#f6f8fa}
+pre{background:#f6f8fa;padding:1em;overflow-x:auto;border-radius:6px}code{background:#f6f8fa;padding:0 .2em}`
+
+// renderReport lays the results out as a standalone HTML document, with
+// credentials redacted as they are from every tool result.
+func renderReport(title string, generated time.Time, sections []reportSection, clusters []string, namespace string, results map[string]map[string]reportResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", html.EscapeString(title), reportStyle)
+	fmt.Fprintf(&b, "<h1>%s</h1>\n", html.EscapeString(title))
+	fmt.Fprintf(&b, "<p class=\"meta\">Generated by %s %s at %s for clusters %s", ServerName, ServerVersion, generated.Format(time.RFC3339), html.EscapeString(strings.Join(clusters, ", ")))
+	if namespace != "" {
+		fmt.Fprintf(&b, " (namespace %s)", html.EscapeString(namespace))
+	}
+	b.WriteString(".</p>\n<ul>\n")
+	for _, section := range sections {
+		failed := 0
+		var flagged []string
+		for _, cluster := range clusters {
+			r := results[section.Name][cluster]
+			if r.isError {
+				failed++
+			} else if section.Flag != "" && messages.Pattern(section.Flag).MatchString(r.text) {
+				flagged = append(flagged, cluster)
+			}
+		}
+		fmt.Fprintf(&b, "<li><a href=\"#%s\">%s</a>", section.Name, section.Title)
+		if failed > 0 {
+			fmt.Fprintf(&b, " (failed in %d of %d clusters)", failed, len(clusters))
+		}
+		if len(flagged) > 0 {
+			fmt.Fprintf(&b, " (flagged: %s)", html.EscapeString(strings.Join(flagged, ", ")))
+		}
+		b.WriteString("</li>\n")
+	}
+	b.WriteString("</ul>\n")
+	for _, section := range sections {
+		fmt.Fprintf(&b, "<h2 id=\"%s\">%s</h2>\n", section.Name, section.Title)
+		for _, cluster := range clusters {
+			fmt.Fprintf(&b, "<h3>%s</h3>\n", html.EscapeString(cluster))
+			r := results[section.Name][cluster]
+			// Redact before rendering, while the text is still in the
+			// layout the patterns expect.
+			text, _ := redact.String(r.text)
+			if r.isError {
+				fmt.Fprintf(&b, "<div class=\"error\">%s</div>\n", html.EscapeString(text))
+				continue
+			}
+			b.WriteString(output.HTML(text))
+		}
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
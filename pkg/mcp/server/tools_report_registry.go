@@ -0,0 +1,51 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "generate_report",
+		Description: "Render fleet analyses (fleet health, security posture, RBAC audit, upgrade readiness, version support) for each cluster into a standalone HTML or PDF report for sharing outside the chat, written to disk or returned base64 encoded",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"sections": {
+					Type:        "array",
+					Description: "Analyses to include: fleet_health, security, rbac, upgrades, versions (default all)",
+					Items:       &Items{Type: "string"},
+				},
+				"clusters": {
+					Type:        "array",
+					Description: "Clusters to report on (default all discovered clusters)",
+					Items:       &Items{Type: "string"},
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Limit the security posture section to this namespace",
+				},
+				"title": {
+					Type:        "string",
+					Description: "Report title (default \"Fleet Report\")",
+				},
+				"format": {
+					Type:        "string",
+					Description: "Document format: html, or pdf (requires wkhtmltopdf or $KUBESTELLAR_PDF_CONVERTER)",
+					Enum:        []string{"html", "pdf"},
+				},
+				"destination": {
+					Type:        "string",
+					Description: "inline returns the document base64 encoded; file writes it to $KUBESTELLAR_REPORT_DIR and returns the path (default inline)",
+					Enum:        []string{"inline", "file"},
+				},
+				"filename": {
+					Type:        "string",
+					Description: "File name of the report (default fleet-report-<timestamp>.<format>)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGenerateReport(ctx, args)
+		},
+	)
+}
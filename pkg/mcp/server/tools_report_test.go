@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+// newReportServer returns a server with clusters east and west, where west
+// runs a privileged pod and east cannot be reached.
+func newReportServer() *Server {
+	privileged := true
+	return &Server{
+		discoverer: stubDiscoverer{
+			discoverClusters: func(string) ([]cluster.ClusterInfo, error) {
+				return []cluster.ClusterInfo{{Name: "west"}, {Name: "east"}}, nil
+			},
+			checkHealthByCtxFn: func(contextName string) (*cluster.HealthInfo, error) {
+				return &cluster.HealthInfo{Status: "Healthy", NodesReady: "3/3", APIServerStatus: "OK"}, nil
+			},
+		},
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			if clusterName == "east" {
+				return nil, errors.New("dial tcp: connection refused")
+			}
+			return k8sfake.NewSimpleClientset(&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: "miner", Namespace: "shop"},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "miner", SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				}}},
+			}), nil
+		},
+	}
+}
+
+func reportResponse(t *testing.T, result CallToolResult) map[string]interface{} {
+	t.Helper()
+	require.False(t, result.IsError, result.Content[0].Text)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].Text), &response))
+	return response
+}
+
+func TestGenerateReportInline(t *testing.T) {
+	result, rpcErr := callTool(t, newReportServer(), "generate_report", map[string]interface{}{
+		"sections": []interface{}{"security", "fleet_health"},
+		"title":    "Q3 <Review>",
+	})
+	require.Nil(t, rpcErr)
+	response := reportResponse(t, result)
+	assert.Equal(t, "text/html", response["mediaType"])
+	assert.Equal(t, []interface{}{"east", "west"}, response["clusters"])
+	assert.Equal(t, []interface{}{"security", "fleet_health"}, response["sections"])
+
+	page, err := base64.StdEncoding.DecodeString(response["data"].(string))
+	require.NoError(t, err)
+	html := string(page)
+	assert.Contains(t, html, "<title>Q3 &lt;Review&gt;</title>")
+	assert.Contains(t, html, `<h2 id="security">Security Posture</h2>`)
+	assert.Contains(t, html, "miner")
+	assert.Contains(t, html, `<div class="error">`, "east's failure is shown")
+	assert.Contains(t, html, "Security Posture</a> (failed in 1 of 2 clusters)")
+	assert.NotContains(t, html, "RBAC Audit")
+	assert.Less(t, strings.Index(html, "Security Posture</h2>"), strings.Index(html, "Fleet Health</h2>"))
+}
+
+func TestGenerateReportToFileAsPDF(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvReportDir, dir)
+	saved := htmlToPDF
+	t.Cleanup(func() { htmlToPDF = saved })
+	htmlToPDF = func(_ context.Context, page []byte) ([]byte, error) {
+		return append([]byte("%PDF-"), page[:15]...), nil
+	}
+
+	result, _ := callTool(t, newReportServer(), "generate_report", map[string]interface{}{
+		"clusters": []interface{}{"west"}, "format": "pdf", "destination": "file", "filename": "west.pdf",
+	})
+	response := reportResponse(t, result)
+	assert.Equal(t, filepath.Join(dir, "west.pdf"), response["path"])
+	assert.Nil(t, response["data"])
+	written, err := os.ReadFile(filepath.Join(dir, "west.pdf"))
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-<!DOCTYPE html>", string(written))
+}
+
+func TestGenerateReportInvalidArgs(t *testing.T) {
+	for _, args := range []map[string]interface{}{
+		{"sections": []interface{}{"costs"}},
+		{"format": "docx"},
+		{"destination": "email"},
+		{"filename": "../etc/passwd"},
+		{"namespace": "Not_A_Namespace"},
+	} {
+		result, _ := callTool(t, newReportServer(), "generate_report", args)
+		assert.True(t, result.IsError, "%v", args)
+	}
+}
+
+func TestGenerateReportFlagsUnsupportedVersions(t *testing.T) {
+	server := &Server{
+		discoverer: stubDiscoverer{
+			discoverClusters: func(string) ([]cluster.ClusterInfo, error) {
+				return []cluster.ClusterInfo{{Name: "legacy"}, {Name: "current"}}, nil
+			},
+		},
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			cs := k8sfake.NewSimpleClientset()
+			gitVersion := "v1.99.0"
+			if clusterName == "legacy" {
+				gitVersion = "v1.20.15"
+			}
+			cs.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: gitVersion}
+			return cs, nil
+		},
+		dynamicClientFactory: func(string) (dynamic.Interface, error) {
+			return dynfake.NewSimpleDynamicClient(upgradesScheme), nil
+		},
+	}
+
+	result, rpcErr := callTool(t, server, "generate_report", map[string]interface{}{"sections": []interface{}{"versions"}})
+	require.Nil(t, rpcErr)
+	page, err := base64.StdEncoding.DecodeString(reportResponse(t, result)["data"].(string))
+	require.NoError(t, err)
+	html := string(page)
+	assert.Contains(t, html, "Version Support</a> (flagged: legacy)")
+	assert.Contains(t, html, "Kubernetes v1.20.15 is older than every supported release")
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/resultdiff"
+)
+
+// runTimeFormat is how run times are shown, and one of the forms accepted
+// to select a run.
+const runTimeFormat = "2006-01-02 15:04 UTC"
+
+// runAt returns the index of the latest of results, oldest first, at or
+// before the time in value: an RFC 3339 time, a time as shown by
+// get_scheduled_run_results, or a date, meaning the end of that day.
+func runAt(results []scheduledResult, value string) (int, error) {
+	var until time.Time
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		until = t
+	} else if t, err := time.Parse(runTimeFormat, value); err == nil {
+		until = t.Add(time.Minute - time.Nanosecond)
+	} else if t, err := time.Parse(time.DateOnly, value); err == nil {
+		until = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	} else {
+		return 0, fmt.Errorf("invalid run time %q: use an RFC 3339 time, %q, or a date", value, runTimeFormat)
+	}
+	for i := len(results) - 1; i >= 0; i-- {
+		if !results[i].Time.After(until) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("no run at or before %s", value)
+}
+
+func (s *Server) toolDiffResults(ctx context.Context, args map[string]interface{}) (string, bool) {
+	name, _ := args["schedule"].(string)
+	from, _ := args["from"].(string)
+	to, _ := args["to"].(string)
+	limit := 50
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	if name == "" {
+		return "schedule is required", true
+	}
+	ts, err := s.loadToolSchedule(ctx, name)
+	if err == nil && !ts.visibleTo(ctx) {
+		err = fmt.Errorf("schedule %q not found", name)
+	}
+	if err != nil {
+		return err.Error(), true
+	}
+	results, err := s.scheduledResults(ctx, name)
+	if err != nil {
+		return fmt.Sprintf("Failed to read scheduled run results: %v", err), true
+	}
+
+	// newer is results[to], or a run made now past the end of results.
+	var newer scheduledResult
+	toIndex := len(results)
+	switch to {
+	case "now":
+		newer = s.callToolSchedule(ctx, ts, time.Now())
+	case "":
+		if len(results) == 0 {
+			return fmt.Sprintf("Schedule %s has not run yet; set to to \"now\" to compare against a run made now", name), true
+		}
+		toIndex = len(results) - 1
+		newer = results[toIndex]
+	default:
+		if toIndex, err = runAt(results, to); err != nil {
+			return err.Error(), true
+		}
+		newer = results[toIndex]
+	}
+	var older scheduledResult
+	if from == "" {
+		if toIndex == 0 {
+			return fmt.Sprintf("Schedule %s has no run before %s to compare with", name, newer.Time.UTC().Format(runTimeFormat)), true
+		}
+		older = results[toIndex-1]
+	} else {
+		fromIndex, err := runAt(results, from)
+		if err != nil {
+			return err.Error(), true
+		}
+		if fromIndex >= toIndex {
+			return "from must select a run before to", true
+		}
+		older = results[fromIndex]
+	}
+	for _, res := range []scheduledResult{older, newer} {
+		if res.IsError {
+			firstLine, _, _ := strings.Cut(res.Text, "\n")
+			return fmt.Sprintf("The run at %s failed, so it has no findings to compare: %s", res.Time.UTC().Format(runTimeFormat), firstLine), true
+		}
+	}
+
+	d := resultdiff.Compare(older.Text, newer.Text)
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# Result Diff: %s (%s)\n\n", name, ts.Tool)
+	_, _ = fmt.Fprintf(&sb, "**From:** %s  **To:** %s", older.Time.UTC().Format(runTimeFormat), newer.Time.UTC().Format(runTimeFormat))
+	if to == "now" {
+		sb.WriteString(" (run now)")
+	}
+	_, _ = fmt.Fprintf(&sb, "\n**Added:** %d  **Resolved:** %d  **Unchanged:** %d\n", len(d.Added), len(d.Resolved), d.Unchanged)
+	if older.Truncated || newer.Truncated {
+		_, _ = fmt.Fprintf(&sb, "\n⚠️ A result was truncated to %d bytes; findings past that point are not compared.\n", maxScheduledResultBytes)
+	}
+	writeFindings(&sb, "Added", d.Added, limit)
+	writeFindings(&sb, "Resolved", d.Resolved, limit)
+	if len(d.Added) == 0 && len(d.Resolved) == 0 {
+		sb.WriteString("\nNo changes in findings.\n")
+	}
+	return sb.String(), false
+}
+
+func writeFindings(sb *strings.Builder, title string, findings []string, limit int) {
+	if len(findings) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(sb, "\n## %s\n\n", title)
+	for i, f := range findings {
+		if i == limit {
+			_, _ = fmt.Fprintf(sb, "- ... and %d more\n", len(findings)-limit)
+			break
+		}
+		_, _ = fmt.Fprintf(sb, "- %s\n", f)
+	}
+}
@@ -0,0 +1,36 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "diff_results",
+		Description: "Compare two stored runs of a scheduled tool (see schedule_tool_run), e.g. last week's check_security_issues with today's, and report the findings added and resolved between them instead of every known issue. By default compares the latest run with the one before it",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"schedule": {
+					Type:        "string",
+					Description: "Schedule whose runs to compare",
+				},
+				"from": {
+					Type:        "string",
+					Description: "Older run: the latest run at or before this RFC 3339 time, 'YYYY-MM-DD HH:MM UTC' time as listed by get_scheduled_run_results, or date (default: the run before to)",
+				},
+				"to": {
+					Type:        "string",
+					Description: "Newer run, selected like from (default: the latest run). 'now' runs the tool now and compares that result without storing it",
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum number of added and of resolved findings to list (default 50)",
+				},
+			},
+			Required: []string{"schedule"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolDiffResults(ctx, args)
+		},
+	)
+}
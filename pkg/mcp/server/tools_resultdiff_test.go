@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// newDiffTestServer returns a server with schedule "scan" and a stored run
+// of it for each text, a day apart from 2026-01-01 02:00 UTC.
+func newDiffTestServer(t *testing.T, texts ...string) *Server {
+	t.Helper()
+	s := &Server{stateStore: store.NewMemory()}
+	ctx := context.Background()
+	ts := &toolSchedule{Name: "scan", Tool: "list_changes", Schedule: "0 2 * * *", Source: scheduleSourceTool}
+	require.NoError(t, store.PutJSON(ctx, s.stateStore, store.BucketToolSchedules, ts.Name, ts))
+	start := time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)
+	for i, text := range texts {
+		at := start.AddDate(0, 0, i)
+		res := scheduledResult{Schedule: ts.Name, Tool: ts.Tool, Time: at, Text: text}
+		require.NoError(t, store.PutJSON(ctx, s.stateStore, store.BucketScheduledResults, scheduledResultKey(ts.Name, at), res))
+	}
+	return s
+}
+
+func TestDiffResults_LatestRuns(t *testing.T) {
+	s := newDiffTestServer(t,
+		"- web is privileged\n- api runs as root\n",
+		"- api runs as root\n- db mounts the Docker socket\n",
+		"- api runs as root\n",
+	)
+	out, isErr := s.toolDiffResults(context.Background(), map[string]interface{}{"schedule": "scan"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "**From:** 2026-01-02 02:00 UTC  **To:** 2026-01-03 02:00 UTC")
+	assert.Contains(t, out, "**Added:** 0  **Resolved:** 1  **Unchanged:** 1")
+	assert.Contains(t, out, "## Resolved\n\n- db mounts the Docker socket")
+
+	out, isErr = s.toolDiffResults(context.Background(), map[string]interface{}{"schedule": "scan", "from": "2026-01-01", "to": "2026-01-02 02:00 UTC"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "**Added:** 1  **Resolved:** 1  **Unchanged:** 1")
+	assert.Contains(t, out, "## Added\n\n- db mounts the Docker socket")
+	assert.Contains(t, out, "## Resolved\n\n- web is privileged")
+}
+
+func TestDiffResults_Now(t *testing.T) {
+	s := newDiffTestServer(t, "No changes recorded.\n")
+	out, isErr := s.toolDiffResults(context.Background(), map[string]interface{}{"schedule": "scan", "to": "now"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "(run now)")
+	assert.Contains(t, out, "No changes in findings")
+
+	results, err := s.scheduledResults(context.Background(), "scan")
+	require.NoError(t, err)
+	assert.Len(t, results, 1, "a run made now is not stored")
+}
+
+func TestDiffResults_Errors(t *testing.T) {
+	ctx := context.Background()
+	s := newDiffTestServer(t, "- one\n")
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"no schedule", map[string]interface{}{}, "schedule is required"},
+		{"unknown schedule", map[string]interface{}{"schedule": "nope"}, `schedule "nope" not found`},
+		{"single run", map[string]interface{}{"schedule": "scan"}, "no run before 2026-01-01 02:00 UTC"},
+		{"bad time", map[string]interface{}{"schedule": "scan", "to": "yesterday"}, "invalid run time"},
+		{"no run before", map[string]interface{}{"schedule": "scan", "to": "2025-12-31"}, "no run at or before"},
+		{"same run", map[string]interface{}{"schedule": "scan", "from": "2026-01-01", "to": "2026-01-01"}, "from must select a run before to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, isErr := s.toolDiffResults(ctx, tt.args)
+			assert.True(t, isErr)
+			assert.Contains(t, out, tt.want)
+		})
+	}
+
+	failed := newDiffTestServer(t, "- one\n")
+	res := scheduledResult{Schedule: "scan", Tool: "list_changes", Time: time.Date(2026, 1, 2, 2, 0, 0, 0, time.UTC), IsError: true, Text: "Failed to list pods: forbidden"}
+	require.NoError(t, store.PutJSON(ctx, failed.stateStore, store.BucketScheduledResults, scheduledResultKey("scan", res.Time), res))
+	out, isErr := failed.toolDiffResults(ctx, map[string]interface{}{"schedule": "scan"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "The run at 2026-01-02 02:00 UTC failed")
+}
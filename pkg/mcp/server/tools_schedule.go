@@ -0,0 +1,558 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cache"
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+	"github.com/kubestellar/kubestellar-mcp/pkg/cron"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+// schedulerTickInterval is how often the scheduler checks for due runs.
+// Cron schedules have minute resolution.
+const schedulerTickInterval = time.Minute
+
+// scheduledRunTimeout bounds each scheduled run.
+const scheduledRunTimeout = 30 * time.Minute
+
+// maxScheduledResults is how many results are kept per schedule, and
+// maxScheduledResultBytes how much of each result's text. The state store
+// may be a ConfigMap, which holds 1 MiB.
+const (
+	maxScheduledResults     = 10
+	maxScheduledResultBytes = 32 << 10
+)
+
+// Where a schedule was defined.
+const (
+	scheduleSourceTool   = "tool"
+	scheduleSourceConfig = "config"
+)
+
+// schedulerTools manage schedules, so they are not scheduled themselves.
+var schedulerTools = map[string]bool{
+	"schedule_tool_run":         true,
+	"get_scheduled_run_results": true,
+	"delete_scheduled_tool_run": true,
+}
+
+// toolSchedule runs a read-only tool with fixed arguments on a cron
+// schedule. It is persisted in store.BucketToolSchedules and run by the
+// scheduler started with the server.
+type toolSchedule struct {
+	Name      string                 `json:"name"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Schedule  string                 `json:"schedule"`
+	TimeZone  string                 `json:"timeZone,omitempty"`
+	// Source is schedule_tool_run or the configuration file, which owns
+	// the schedules it lists.
+	Source string `json:"source"`
+	// User and Groups are the HTTP caller that created the schedule. Its
+	// runs act as that caller, and only that caller can see or change it
+	// over HTTP.
+	User   string   `json:"user,omitempty"`
+	Groups []string `json:"groups,omitempty"`
+	// LastChecked is when the scheduler last looked at the schedule; runs
+	// due up to then have been started.
+	LastChecked time.Time `json:"lastChecked"`
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+}
+
+// scheduledResult is the result of one scheduled run, persisted in
+// store.BucketScheduledResults under <schedule>/<time> so a schedule's
+// results sort by time.
+type scheduledResult struct {
+	Schedule   string                 `json:"schedule"`
+	Tool       string                 `json:"tool"`
+	Time       time.Time              `json:"time"`
+	DurationMs int64                  `json:"durationMs"`
+	IsError    bool                   `json:"isError,omitempty"`
+	Text       string                 `json:"text"`
+	Truncated  bool                   `json:"truncated,omitempty"`
+	Meta       map[string]interface{} `json:"meta,omitempty"`
+}
+
+func scheduledResultKey(name string, t time.Time) string {
+	return name + "/" + t.UTC().Format("20060102T150405.000000000")
+}
+
+// parse parses the schedule.
+func (ts *toolSchedule) parse() (*cron.Schedule, error) {
+	sched, err := cron.Parse(ts.Schedule, ts.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+	return sched, nil
+}
+
+// due reports whether the schedule fired since it was last checked. A run
+// missed while no server was running is made late, once.
+func (ts *toolSchedule) due(now time.Time) bool {
+	sched, err := ts.parse()
+	if err != nil {
+		return false
+	}
+	prev := sched.Prev(now)
+	return !prev.IsZero() && prev.After(ts.LastChecked)
+}
+
+// visibleTo reports whether the caller of ctx may see and change ts. Over
+// stdio every schedule is; over HTTP only those the caller created.
+func (ts *toolSchedule) visibleTo(ctx context.Context) bool {
+	if !isHTTPRequest(ctx) {
+		return true
+	}
+	owner := ""
+	if caller := callerFrom(ctx); caller != nil {
+		owner = caller.User
+	}
+	return ts.Source == scheduleSourceTool && ts.User == owner
+}
+
+// checkSchedulable returns why tool cannot run on a schedule, if it can't.
+// Scheduled runs are unattended, so they must not change cluster state.
+func checkSchedulable(tool string, args map[string]interface{}) error {
+	td := findToolDef(tool)
+	switch {
+	case td == nil:
+		return fmt.Errorf("unknown tool: %s", tool)
+	case td.Mutating:
+		return fmt.Errorf("%s changes cluster state; only read-only tools can be scheduled", tool)
+	case schedulerTools[tool]:
+		return fmt.Errorf("%s cannot be scheduled", tool)
+	case queryTools[tool]:
+		return fmt.Errorf("%s cannot be scheduled; schedule the tool of the query instead", tool)
+	}
+	for _, name := range td.Schema.InputSchema.Required {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("%s requires the argument %s", tool, name)
+		}
+	}
+	return nil
+}
+
+// startScheduler runs the scheduler in the background until ctx is done,
+// once per server.
+func (s *Server) startScheduler(ctx context.Context) {
+	s.schedulerOnce.Do(func() {
+		go s.runScheduler(ctx)
+	})
+}
+
+// runScheduler loads the schedules of the configuration file, then starts
+// the runs that fall due every schedulerTickInterval until ctx is done.
+func (s *Server) runScheduler(ctx context.Context) {
+	if runs, err := config.SchedulesFromEnv(); err != nil {
+		log.Printf("Failed to load configured schedules: %v", err)
+	} else {
+		s.syncConfigSchedules(ctx, runs, time.Now())
+	}
+	ticker := time.NewTicker(schedulerTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.runDueSchedules(ctx, now)
+		}
+	}
+}
+
+// syncConfigSchedules makes the stored schedules from the configuration
+// file match runs. Schedules that stay keep when they were last checked, so
+// a restart neither repeats nor skips a run.
+func (s *Server) syncConfigSchedules(ctx context.Context, runs []config.ScheduledRun, now time.Time) {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	st := s.getStateStore()
+	configured := make(map[string]bool, len(runs))
+	for _, r := range runs {
+		ts := &toolSchedule{
+			Name:        r.Name,
+			Tool:        r.Tool,
+			Arguments:   r.Arguments,
+			Schedule:    r.Schedule,
+			TimeZone:    r.TimeZone,
+			Source:      scheduleSourceConfig,
+			LastChecked: now,
+			Created:     now,
+			Updated:     now,
+		}
+		if err := checkSchedulable(ts.Tool, ts.Arguments); err != nil {
+			log.Printf("Skipping configured schedule %s: %v", ts.Name, err)
+			continue
+		}
+		if _, err := ts.parse(); err != nil {
+			log.Printf("Skipping configured schedule %s: %v", ts.Name, err)
+			continue
+		}
+		configured[ts.Name] = true
+		if existing, err := s.loadToolSchedule(ctx, ts.Name); err == nil {
+			if existing.Source != scheduleSourceConfig {
+				log.Printf("Configured schedule %s replaces the one created with schedule_tool_run", ts.Name)
+			}
+			ts.LastChecked, ts.Created = existing.LastChecked, existing.Created
+		}
+		if err := store.PutJSON(ctx, st, store.BucketToolSchedules, ts.Name, ts); err != nil {
+			log.Printf("Failed to save configured schedule %s: %v", ts.Name, err)
+		}
+	}
+
+	items, err := st.List(ctx, store.BucketToolSchedules)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		var ts toolSchedule
+		if json.Unmarshal(item.Value, &ts) != nil {
+			continue
+		}
+		if ts.Source == scheduleSourceConfig && !configured[ts.Name] {
+			s.deleteToolSchedule(ctx, ts.Name)
+		}
+	}
+}
+
+// runDueSchedules runs each schedule that fell due by now, one after
+// another.
+func (s *Server) runDueSchedules(ctx context.Context, now time.Time) {
+	items, err := s.getStateStore().List(ctx, store.BucketToolSchedules)
+	if err != nil {
+		return
+	}
+	for _, item := range items {
+		var due bool
+		ts, err := s.updateToolSchedule(ctx, item.Key, func(ts *toolSchedule) {
+			due = ts.due(now)
+			ts.LastChecked = now
+		})
+		if err == nil && due {
+			s.runToolSchedule(ctx, ts, now)
+		}
+	}
+}
+
+// runToolSchedule runs the tool of ts and stores the result, keeping the
+// latest maxScheduledResults.
+func (s *Server) runToolSchedule(ctx context.Context, ts *toolSchedule, now time.Time) scheduledResult {
+	res := s.callToolSchedule(ctx, ts, now)
+	st := s.getStateStore()
+	if err := store.PutJSON(ctx, st, store.BucketScheduledResults, scheduledResultKey(ts.Name, now), res); err != nil {
+		log.Printf("Failed to store the result of scheduled run %s: %v", ts.Name, err)
+		return res
+	}
+	results, err := s.scheduledResults(ctx, ts.Name)
+	if err == nil && len(results) > maxScheduledResults {
+		for _, old := range results[:len(results)-maxScheduledResults] {
+			_ = st.Delete(ctx, store.BucketScheduledResults, scheduledResultKey(ts.Name, old.Time))
+		}
+	}
+	return res
+}
+
+// callToolSchedule runs the tool of ts through the same authorization,
+// redaction, and audit as a tools/call request.
+func (s *Server) callToolSchedule(ctx context.Context, ts *toolSchedule, now time.Time) scheduledResult {
+	runCtx, cancel := context.WithTimeout(ctx, scheduledRunTimeout)
+	defer cancel()
+	if ts.User != "" {
+		runCtx = WithRemoteCaller(runCtx, &auth.Identity{User: ts.User, Groups: ts.Groups})
+	}
+	args := make(map[string]interface{}, len(ts.Arguments)+1)
+	for k, v := range ts.Arguments {
+		args[k] = v
+	}
+	if td := findToolDef(ts.Tool); td != nil && td.CacheTTL > 0 {
+		// A scheduled scan is meant to look at the clusters now.
+		if _, ok := args[cache.ArgForceRefresh]; !ok {
+			args[cache.ArgForceRefresh] = true
+		}
+	}
+
+	res := scheduledResult{Schedule: ts.Name, Tool: ts.Tool, Time: now}
+	start := time.Now()
+	result, err := s.CallTool(runCtx, ts.Tool, args)
+	res.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		res.IsError, res.Text = true, err.Error()
+	} else {
+		texts := make([]string, 0, len(result.Content))
+		for _, block := range result.Content {
+			texts = append(texts, block.Text)
+		}
+		res.IsError, res.Text, res.Meta = result.IsError, strings.Join(texts, "\n"), result.Meta
+	}
+	if len(res.Text) > maxScheduledResultBytes {
+		res.Text, res.Truncated = res.Text[:maxScheduledResultBytes], true
+	}
+	return res
+}
+
+// scheduledResults returns the stored results of schedule name, oldest
+// first.
+func (s *Server) scheduledResults(ctx context.Context, name string) ([]scheduledResult, error) {
+	items, err := s.getStateStore().List(ctx, store.BucketScheduledResults)
+	if err != nil {
+		return nil, err
+	}
+	var results []scheduledResult
+	for _, item := range items {
+		if !strings.HasPrefix(item.Key, name+"/") {
+			continue
+		}
+		var res scheduledResult
+		if json.Unmarshal(item.Value, &res) == nil {
+			results = append(results, res)
+		}
+	}
+	return results, nil
+}
+
+func (s *Server) loadToolSchedule(ctx context.Context, name string) (*toolSchedule, error) {
+	var ts toolSchedule
+	if err := store.GetJSON(ctx, s.getStateStore(), store.BucketToolSchedules, name, &ts); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, fmt.Errorf("schedule %q not found", name)
+		}
+		return nil, err
+	}
+	return &ts, nil
+}
+
+// updateToolSchedule applies fn to schedule name and saves it. Schedules
+// are changed one at a time, so the scheduler and the tools never
+// interleave.
+func (s *Server) updateToolSchedule(ctx context.Context, name string, fn func(*toolSchedule)) (*toolSchedule, error) {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	ts, err := s.loadToolSchedule(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	fn(ts)
+	return ts, store.PutJSON(ctx, s.getStateStore(), store.BucketToolSchedules, name, ts)
+}
+
+// deleteToolSchedule removes schedule name and its results. The caller
+// holds scheduleMu.
+func (s *Server) deleteToolSchedule(ctx context.Context, name string) {
+	st := s.getStateStore()
+	_ = st.Delete(ctx, store.BucketToolSchedules, name)
+	if results, err := s.scheduledResults(ctx, name); err == nil {
+		for _, res := range results {
+			_ = st.Delete(ctx, store.BucketScheduledResults, scheduledResultKey(name, res.Time))
+		}
+	}
+}
+
+func (s *Server) toolScheduleToolRun(ctx context.Context, args map[string]interface{}) (string, bool) {
+	name, _ := args["name"].(string)
+	tool, _ := args["tool"].(string)
+	schedule, _ := args["schedule"].(string)
+	timeZone, _ := args["time_zone"].(string)
+	arguments, _ := args["arguments"].(map[string]interface{})
+	if name == "" || tool == "" || schedule == "" {
+		return "name, tool, and schedule are required", true
+	}
+	if len(name) > 63 || !k8sNamespaceRe.MatchString(name) {
+		return fmt.Sprintf("name %q is invalid: must be lowercase alphanumeric and hyphens only", name), true
+	}
+	if err := checkSchedulable(tool, arguments); err != nil {
+		return err.Error(), true
+	}
+	now := time.Now()
+	ts := &toolSchedule{
+		Name:        name,
+		Tool:        tool,
+		Arguments:   arguments,
+		Schedule:    schedule,
+		TimeZone:    timeZone,
+		Source:      scheduleSourceTool,
+		LastChecked: now,
+		Created:     now,
+		Updated:     now,
+	}
+	sched, err := ts.parse()
+	if err != nil {
+		return err.Error(), true
+	}
+	if isHTTPRequest(ctx) {
+		if caller := callerFrom(ctx); caller != nil {
+			ts.User, ts.Groups = caller.User, caller.Groups
+		}
+	}
+
+	s.scheduleMu.Lock()
+	replaced := false
+	existing, err := s.loadToolSchedule(ctx, name)
+	switch {
+	case err == nil && existing.Source == scheduleSourceConfig:
+		err = fmt.Errorf("schedule %q is defined in the configuration file; change it there", name)
+	case err == nil && !existing.visibleTo(ctx):
+		err = fmt.Errorf("schedule %q belongs to another user", name)
+	case err == nil:
+		ts.Created, replaced = existing.Created, true
+		err = store.PutJSON(ctx, s.getStateStore(), store.BucketToolSchedules, name, ts)
+	default:
+		err = store.PutJSON(ctx, s.getStateStore(), store.BucketToolSchedules, name, ts)
+	}
+	s.scheduleMu.Unlock()
+	if err != nil {
+		return err.Error(), true
+	}
+
+	var sb strings.Builder
+	if replaced {
+		_, _ = fmt.Fprintf(&sb, "# Schedule Updated: %s\n\n", name)
+	} else {
+		_, _ = fmt.Fprintf(&sb, "# Schedule Created: %s\n\n", name)
+	}
+	writeToolSchedule(&sb, ts, sched, now)
+	sb.WriteString("\nResults are kept in the state store; read them with get_scheduled_run_results.\n")
+	return sb.String(), false
+}
+
+func (s *Server) toolGetScheduledRunResults(ctx context.Context, args map[string]interface{}) (string, bool) {
+	name, _ := args["name"].(string)
+	limit := 5
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	now := time.Now()
+
+	if name != "" {
+		ts, err := s.loadToolSchedule(ctx, name)
+		if err == nil && !ts.visibleTo(ctx) {
+			err = fmt.Errorf("schedule %q not found", name)
+		}
+		if err != nil {
+			return err.Error(), true
+		}
+		results, err := s.scheduledResults(ctx, name)
+		if err != nil {
+			return fmt.Sprintf("Failed to read scheduled run results: %v", err), true
+		}
+		var sb strings.Builder
+		_, _ = fmt.Fprintf(&sb, "# Scheduled Runs: %s\n\n", name)
+		sched, _ := ts.parse()
+		writeToolSchedule(&sb, ts, sched, now)
+		if len(results) == 0 {
+			sb.WriteString("\nNo runs yet.\n")
+		}
+		for i := len(results) - 1; i >= 0 && i >= len(results)-limit; i-- {
+			res := results[i]
+			status := "OK"
+			if res.IsError {
+				status = "Error"
+			}
+			_, _ = fmt.Fprintf(&sb, "\n## %s: %s (%s)\n\n", res.Time.UTC().Format(runTimeFormat), status, time.Duration(res.DurationMs)*time.Millisecond)
+			sb.WriteString(res.Text)
+			if res.Truncated {
+				_, _ = fmt.Fprintf(&sb, "\n\n_Truncated to %d bytes._", maxScheduledResultBytes)
+			}
+			sb.WriteString("\n")
+		}
+		return sb.String(), false
+	}
+
+	items, err := s.getStateStore().List(ctx, store.BucketToolSchedules)
+	if err != nil {
+		return fmt.Sprintf("Failed to read schedules: %v", err), true
+	}
+	var sb strings.Builder
+	sb.WriteString("# Scheduled Tool Runs\n\n")
+	shown := 0
+	for _, item := range items {
+		var ts toolSchedule
+		if json.Unmarshal(item.Value, &ts) != nil || !ts.visibleTo(ctx) {
+			continue
+		}
+		shown++
+		_, _ = fmt.Fprintf(&sb, "## %s\n", ts.Name)
+		sched, _ := ts.parse()
+		writeToolSchedule(&sb, &ts, sched, now)
+		if results, err := s.scheduledResults(ctx, ts.Name); err == nil && len(results) > 0 {
+			last := results[len(results)-1]
+			status := "OK"
+			if last.IsError {
+				status = "Error"
+			}
+			_, _ = fmt.Fprintf(&sb, "**Last run:** %s (%s)\n", last.Time.UTC().Format(runTimeFormat), status)
+		} else {
+			sb.WriteString("**Last run:** never\n")
+		}
+		sb.WriteString("\n")
+	}
+	if shown == 0 {
+		sb.WriteString("No schedules. Create one with schedule_tool_run or in the configuration file's schedules section.\n")
+	}
+	return sb.String(), false
+}
+
+func (s *Server) toolDeleteScheduledToolRun(ctx context.Context, args map[string]interface{}) (string, bool) {
+	name, _ := args["name"].(string)
+	if name == "" {
+		return "name is required", true
+	}
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+	ts, err := s.loadToolSchedule(ctx, name)
+	switch {
+	case err == nil && !ts.visibleTo(ctx):
+		err = fmt.Errorf("schedule %q not found", name)
+	case err == nil && ts.Source == scheduleSourceConfig:
+		err = fmt.Errorf("schedule %q is defined in the configuration file; remove it there", name)
+	}
+	if err != nil {
+		return err.Error(), true
+	}
+	s.deleteToolSchedule(ctx, name)
+	return fmt.Sprintf("Deleted schedule %s and its results.", name), false
+}
+
+// writeToolSchedule describes ts and its next run at now.
+func writeToolSchedule(sb *strings.Builder, ts *toolSchedule, sched *cron.Schedule, now time.Time) {
+	_, _ = fmt.Fprintf(sb, "**Tool:** %s\n", ts.Tool)
+	if len(ts.Arguments) > 0 {
+		_, _ = fmt.Fprintf(sb, "**Arguments:** %s\n", formatToolArguments(ts.Arguments))
+	}
+	schedule := ts.Schedule
+	if ts.TimeZone != "" {
+		schedule += " (" + ts.TimeZone + ")"
+	}
+	_, _ = fmt.Fprintf(sb, "**Schedule:** `%s`  **Source:** %s\n", schedule, ts.Source)
+	if ts.User != "" {
+		_, _ = fmt.Fprintf(sb, "**Runs as:** %s\n", ts.User)
+	}
+	if sched != nil {
+		if next := sched.Next(now); !next.IsZero() {
+			_, _ = fmt.Fprintf(sb, "**Next run:** %s\n", next.UTC().Format(runTimeFormat))
+		}
+	}
+}
+
+// formatToolArguments lists tool arguments as key=value pairs, by key.
+func formatToolArguments(args map[string]interface{}) string {
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = fmt.Sprintf("%s=%v", k, args[k])
+	}
+	return strings.Join(parts, ", ")
+}
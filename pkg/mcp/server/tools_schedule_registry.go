@@ -0,0 +1,79 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "schedule_tool_run",
+		Description: "Run a read-only tool with fixed arguments on a cron schedule from this server, e.g. check_security_issues nightly or get_cluster_role_bindings weekly, without external cron. Results are kept in the state store; read them with get_scheduled_run_results. Replaces a schedule of the same name. Runs act as the caller that created the schedule",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"name": {
+					Type:        "string",
+					Description: "Schedule name (lowercase alphanumeric and hyphens)",
+				},
+				"tool": {
+					Type:        "string",
+					Description: "Tool to run. Tools that change cluster state cannot be scheduled",
+				},
+				"arguments": {
+					Type:        "object",
+					Description: "Arguments of each run, as in a tools/call request",
+				},
+				"schedule": {
+					Type:        "string",
+					Description: "Five-field cron schedule, e.g. '0 2 * * *' for 02:00 daily, or a macro such as @weekly",
+				},
+				"time_zone": {
+					Type:        "string",
+					Description: "IANA time zone of the schedule (default UTC)",
+				},
+			},
+			Required: []string{"name", "tool", "schedule"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolScheduleToolRun(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "get_scheduled_run_results",
+		Description: "List scheduled tool runs, from schedule_tool_run and the configuration file, with their next and last runs. With name, returns the results of that schedule's latest runs, newest first",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"name": {
+					Type:        "string",
+					Description: "Schedule whose results to return",
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum number of runs to return with name (default 5)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetScheduledRunResults(ctx, args)
+		},
+	)
+	RegisterTool(Tool{
+		Name:        "delete_scheduled_tool_run",
+		Description: "Delete a schedule created with schedule_tool_run, and its results. Schedules from the configuration file are removed there",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"name": {
+					Type:        "string",
+					Description: "Schedule name",
+				},
+			},
+			Required: []string{"name"},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolDeleteScheduledToolRun(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/auth"
+	"github.com/kubestellar/kubestellar-mcp/pkg/config"
+	"github.com/kubestellar/kubestellar-mcp/pkg/store"
+)
+
+func TestScheduleToolRun_Validation(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want string
+	}{
+		{"missing", map[string]interface{}{"name": "nightly"}, "name, tool, and schedule are required"},
+		{"bad name", map[string]interface{}{"name": "Nightly_Scan", "tool": "list_changes", "schedule": "@daily"}, "is invalid"},
+		{"unknown tool", map[string]interface{}{"name": "nightly", "tool": "nope", "schedule": "@daily"}, "unknown tool: nope"},
+		{"mutating", map[string]interface{}{"name": "nightly", "tool": "undo_change", "schedule": "@daily"}, "only read-only tools can be scheduled"},
+		{"scheduler tool", map[string]interface{}{"name": "nightly", "tool": "get_scheduled_run_results", "schedule": "@daily"}, "cannot be scheduled"},
+		{"required argument", map[string]interface{}{"name": "nightly", "tool": "can_i", "schedule": "@daily"}, "requires the argument"},
+		{"bad schedule", map[string]interface{}{"name": "nightly", "tool": "list_changes", "schedule": "0 2 * *"}, "invalid schedule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, isErr := s.toolScheduleToolRun(ctx, tt.args)
+			assert.True(t, isErr)
+			assert.Contains(t, out, tt.want)
+		})
+	}
+}
+
+func TestScheduledRuns_RunAndReport(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	ctx := context.Background()
+
+	out, isErr := s.toolScheduleToolRun(ctx, map[string]interface{}{
+		"name":      "hourly-changes",
+		"tool":      "list_changes",
+		"arguments": map[string]interface{}{"limit": float64(3)},
+		"schedule":  "@hourly",
+	})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "Schedule Created: hourly-changes")
+	assert.Contains(t, out, "**Arguments:** limit=3")
+
+	ts, err := s.loadToolSchedule(ctx, "hourly-changes")
+	require.NoError(t, err)
+	created := ts.LastChecked
+
+	// Nothing is due until the next hour begins.
+	s.runDueSchedules(ctx, created)
+	results, err := s.scheduledResults(ctx, "hourly-changes")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	next := created.Truncate(time.Hour).Add(time.Hour + time.Second)
+	s.runDueSchedules(ctx, next)
+	s.runDueSchedules(ctx, next.Add(time.Minute))
+	results, err = s.scheduledResults(ctx, "hourly-changes")
+	require.NoError(t, err)
+	require.Len(t, results, 1, "a schedule runs once per firing")
+	assert.False(t, results[0].IsError)
+	assert.Contains(t, results[0].Text, "No changes recorded")
+
+	out, isErr = s.toolGetScheduledRunResults(ctx, map[string]interface{}{})
+	require.False(t, isErr)
+	assert.Contains(t, out, "## hourly-changes")
+	assert.Contains(t, out, "(OK)")
+
+	out, isErr = s.toolGetScheduledRunResults(ctx, map[string]interface{}{"name": "hourly-changes"})
+	require.False(t, isErr)
+	assert.Contains(t, out, "# Scheduled Runs: hourly-changes")
+	assert.Contains(t, out, "No changes recorded")
+
+	out, isErr = s.toolDeleteScheduledToolRun(ctx, map[string]interface{}{"name": "hourly-changes"})
+	require.False(t, isErr, out)
+	_, err = s.loadToolSchedule(ctx, "hourly-changes")
+	assert.Error(t, err)
+	results, err = s.scheduledResults(ctx, "hourly-changes")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestScheduledRuns_KeepLatestResults(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	ctx := context.Background()
+	ts := &toolSchedule{Name: "changes", Tool: "list_changes", Schedule: "* * * * *", Source: scheduleSourceTool}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxScheduledResults+3; i++ {
+		s.runToolSchedule(ctx, ts, start.Add(time.Duration(i)*time.Minute))
+	}
+	results, err := s.scheduledResults(ctx, "changes")
+	require.NoError(t, err)
+	require.Len(t, results, maxScheduledResults)
+	assert.Equal(t, start.Add(3*time.Minute), results[0].Time.UTC())
+}
+
+func TestScheduledRuns_ConfigSchedules(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	ctx := context.Background()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	s.syncConfigSchedules(ctx, []config.ScheduledRun{
+		{Name: "nightly", Tool: "list_changes", Schedule: "0 2 * * *"},
+		{Name: "undo", Tool: "undo_change", Arguments: map[string]interface{}{"change_id": "x"}, Schedule: "@daily"},
+	}, now)
+	ts, err := s.loadToolSchedule(ctx, "nightly")
+	require.NoError(t, err)
+	assert.Equal(t, scheduleSourceConfig, ts.Source)
+	_, err = s.loadToolSchedule(ctx, "undo")
+	assert.Error(t, err, "mutating tools are skipped")
+
+	out, isErr := s.toolScheduleToolRun(ctx, map[string]interface{}{"name": "nightly", "tool": "list_changes", "schedule": "@daily"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "defined in the configuration file")
+	out, isErr = s.toolDeleteScheduledToolRun(ctx, map[string]interface{}{"name": "nightly"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "remove it there")
+
+	// A restart keeps when the schedule was last checked.
+	s.syncConfigSchedules(ctx, []config.ScheduledRun{{Name: "nightly", Tool: "list_changes", Schedule: "0 3 * * *"}}, now.Add(time.Hour))
+	ts, err = s.loadToolSchedule(ctx, "nightly")
+	require.NoError(t, err)
+	assert.Equal(t, "0 3 * * *", ts.Schedule)
+	assert.True(t, ts.LastChecked.Equal(now))
+
+	s.syncConfigSchedules(ctx, nil, now)
+	_, err = s.loadToolSchedule(ctx, "nightly")
+	assert.Error(t, err, "schedules removed from the file are deleted")
+}
+
+func TestScheduledRuns_HTTPCallersSeeTheirOwn(t *testing.T) {
+	s := &Server{stateStore: store.NewMemory()}
+	alice := WithRemoteCaller(context.Background(), &auth.Identity{User: "alice", Groups: []string{"sre"}})
+	bob := WithRemoteCaller(context.Background(), &auth.Identity{User: "bob"})
+
+	out, isErr := s.toolScheduleToolRun(alice, map[string]interface{}{"name": "mine", "tool": "list_changes", "schedule": "@daily"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "**Runs as:** alice")
+	ts, err := s.loadToolSchedule(context.Background(), "mine")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sre"}, ts.Groups)
+
+	out, _ = s.toolGetScheduledRunResults(bob, map[string]interface{}{})
+	assert.Contains(t, out, "No schedules")
+	_, isErr = s.toolScheduleToolRun(bob, map[string]interface{}{"name": "mine", "tool": "list_changes", "schedule": "@hourly"})
+	assert.True(t, isErr)
+	_, isErr = s.toolDeleteScheduledToolRun(bob, map[string]interface{}{"name": "mine"})
+	assert.True(t, isErr)
+
+	out, _ = s.toolGetScheduledRunResults(context.Background(), map[string]interface{}{})
+	assert.Contains(t, out, "## mine", "stdio sees every schedule")
+}
+
+func TestScheduleTools_Registered(t *testing.T) {
+	for _, name := range []string{"schedule_tool_run", "get_scheduled_run_results", "delete_scheduled_tool_run"} {
+		td := findToolDef(name)
+		require.NotNil(t, td, name)
+		assert.False(t, td.Mutating, name)
+		assert.True(t, schedulerTools[name], name)
+	}
+}
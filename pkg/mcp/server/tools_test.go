@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -314,7 +315,7 @@ func TestHandleToolsCallValidationErrors(t *testing.T) {
 		{name: "describe role requires name", tool: "describe_role", args: map[string]interface{}{}, want: "name is required"},
 		{name: "find resource owners requires namespace", tool: "find_resource_owners", args: map[string]interface{}{}, want: "namespace is required"},
 		{name: "analyze namespace requires namespace", tool: "analyze_namespace", args: map[string]interface{}{}, want: "namespace is required"},
-		{name: "detect drift requires repo url", tool: "detect_drift", args: map[string]interface{}{}, want: "repo_url is required"},
+		{name: "detect drift requires a source", tool: "detect_drift", args: map[string]interface{}{}, want: "one of repo_url, local_path, or archive is required"},
 		{name: "trigger openshift upgrade requires target version", tool: "trigger_openshift_upgrade", args: map[string]interface{}{}, want: "target_version is required"},
 	}
 
@@ -398,3 +399,75 @@ func callTool(t *testing.T, s *Server, tool string, args map[string]interface{})
 	}
 	return result, nil
 }
+
+func TestHandleToolsCallReturnsStructuredErrors(t *testing.T) {
+	registerTestTool(t, "forbidden_test", func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+		return `Failed to list pods: pods is forbidden: User "dev" cannot list resource "pods"`, true
+	})
+
+	result, rpcErr := callTool(t, &Server{}, "forbidden_test", map[string]interface{}{"cluster": "prod"})
+	if rpcErr != nil {
+		t.Fatalf("handleToolsCall returned RPC error: %v", rpcErr)
+	}
+	want := map[string]interface{}{
+		"code":      "permission_denied",
+		"message":   result.Content[0].Text,
+		"cluster":   "prod",
+		"retryable": false,
+		"nextTool":  "can_i",
+	}
+	if got := result.Meta["error"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("_meta.error = %#v, want %#v", got, want)
+	}
+
+	result, _ = callTool(t, &Server{}, "get_pod_logs", map[string]interface{}{"namespace": "apps"})
+	if got := result.Meta["error"].(map[string]interface{})["code"]; got != "invalid_argument" {
+		t.Fatalf("validation failure code = %v, want invalid_argument", got)
+	}
+
+	result, _ = callTool(t, &Server{clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+		return k8sfake.NewSimpleClientset(), nil
+	}}, "get_nodes", map[string]interface{}{})
+	if result.IsError || result.Meta["error"] != nil {
+		t.Fatalf("successful call has _meta.error %v", result.Meta["error"])
+	}
+}
+
+func TestHandleToolsCallHonorsOutputFormat(t *testing.T) {
+	registerTestTool(t, "report_test", func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+		if _, ok := args["output"]; ok {
+			return "handler saw the output argument", true
+		}
+		return "## Roles\n\n| Name | Namespace |\n|------|-----------|\n| admin | shop |", false
+	})
+
+	result, _ := callTool(t, &Server{}, "report_test", map[string]interface{}{"output": "json"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", result.Content[0].Text)
+	}
+	var payload struct {
+		Tables [][]map[string]string `json:"tables"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].Text), &payload); err != nil {
+		t.Fatalf("json output is not JSON: %v", err)
+	}
+	if want := [][]map[string]string{{{"Name": "admin", "Namespace": "shop"}}}; !reflect.DeepEqual(payload.Tables, want) {
+		t.Fatalf("tables = %v, want %v", payload.Tables, want)
+	}
+
+	result, _ = callTool(t, &Server{}, "report_test", map[string]interface{}{"output": "table"})
+	if want := "## Roles\n\nNAME   NAMESPACE\nadmin  shop"; result.Content[0].Text != want {
+		t.Fatalf("table output = %q, want %q", result.Content[0].Text, want)
+	}
+
+	result, _ = callTool(t, &Server{}, "report_test", map[string]interface{}{"output": "xml"})
+	if !result.IsError || result.Meta["error"].(map[string]interface{})["code"] != "invalid_argument" {
+		t.Fatalf("invalid output format was not rejected: %+v", result)
+	}
+
+	for _, tool := range registeredTools() {
+		if _, ok := tool.InputSchema.Properties["output"]; !ok {
+			t.Fatalf("tool %s does not advertise the output argument", tool.Name)
+		}
+	}
+}
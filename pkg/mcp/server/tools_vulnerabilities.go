@@ -0,0 +1,336 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// vulnerabilityReportGVR is the Trivy Operator's per-container scan result.
+var vulnerabilityReportGVR = schema.GroupVersionResource{Group: "aquasecurity.github.io", Version: "v1alpha1", Resource: "vulnerabilityreports"}
+
+var errTrivyNotInstalled = errors.New("the Trivy Operator is not installed (no vulnerabilityreports.aquasecurity.github.io API)")
+
+// trivySeverities are the severities Trivy assigns, most severe first.
+var trivySeverities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"}
+
+// Labels the Trivy Operator puts on VulnerabilityReports.
+const (
+	trivyResourceKind      = "trivy-operator.resource.kind"
+	trivyResourceName      = "trivy-operator.resource.name"
+	trivyResourceNamespace = "trivy-operator.resource.namespace"
+	trivyContainerName     = "trivy-operator.container.name"
+)
+
+const defaultVulnerabilityLimit = 5
+
+// Vulnerability is one CVE found in an image.
+type Vulnerability struct {
+	ID               string  `json:"id"`
+	Severity         string  `json:"severity"`
+	Resource         string  `json:"resource"`
+	InstalledVersion string  `json:"installedVersion"`
+	FixedVersion     string  `json:"fixedVersion,omitempty"`
+	Title            string  `json:"title,omitempty"`
+	Link             string  `json:"link,omitempty"`
+	Score            float64 `json:"score,omitempty"`
+}
+
+// ImageVulnerabilities is the CVEs of one image that pass the filters and
+// the workloads running it.
+type ImageVulnerabilities struct {
+	Image           string          `json:"image"`
+	Workloads       []string        `json:"workloads"`
+	Namespaces      []string        `json:"namespaces"`
+	Counts          map[string]int  `json:"counts"`
+	Fixable         int             `json:"fixable"`
+	Vulnerabilities []Vulnerability `json:"vulnerabilities"`
+}
+
+// vulnerabilityScan is the image vulnerabilities of one cluster.
+type vulnerabilityScan struct {
+	Scanned int
+	Images  []ImageVulnerabilities
+}
+
+// parseSeverities parses a comma-separated list of Trivy severities.
+func parseSeverities(spec string) ([]string, error) {
+	if spec == "" {
+		return []string{"CRITICAL", "HIGH"}, nil
+	}
+	var severities []string
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.ToUpper(strings.TrimSpace(s))
+		if !slices.Contains(trivySeverities, s) {
+			return nil, fmt.Errorf("unknown severity %q (expected %s)", s, strings.Join(trivySeverities, ", "))
+		}
+		if !slices.Contains(severities, s) {
+			severities = append(severities, s)
+		}
+	}
+	sort.Slice(severities, func(i, j int) bool {
+		return slices.Index(trivySeverities, severities[i]) < slices.Index(trivySeverities, severities[j])
+	})
+	return severities, nil
+}
+
+// reportImage returns the image a VulnerabilityReport scanned.
+func reportImage(report *unstructured.Unstructured) string {
+	server, _, _ := unstructured.NestedString(report.Object, "report", "registry", "server")
+	repository, _, _ := unstructured.NestedString(report.Object, "report", "artifact", "repository")
+	tag, _, _ := unstructured.NestedString(report.Object, "report", "artifact", "tag")
+	digest, _, _ := unstructured.NestedString(report.Object, "report", "artifact", "digest")
+	image := repository
+	if server != "" {
+		image = server + "/" + repository
+	}
+	switch {
+	case tag != "":
+		return image + ":" + tag
+	case digest != "":
+		return image + "@" + digest
+	}
+	return image
+}
+
+// reportWorkload names the workload and container a VulnerabilityReport
+// covers.
+func reportWorkload(report *unstructured.Unstructured) string {
+	labels := report.GetLabels()
+	namespace := labels[trivyResourceNamespace]
+	if namespace == "" {
+		namespace = report.GetNamespace()
+	}
+	workload := fmt.Sprintf("%s/%s/%s", namespace, labels[trivyResourceKind], labels[trivyResourceName])
+	if c := labels[trivyContainerName]; c != "" {
+		workload += " (" + c + ")"
+	}
+	return workload
+}
+
+// summarizeVulnerabilityReports groups reports by image and keeps the
+// vulnerabilities with the given severities, and only fixable ones if
+// fixableOnly is set.
+func summarizeVulnerabilityReports(reports []unstructured.Unstructured, severities []string, fixableOnly bool) *vulnerabilityScan {
+	byImage := make(map[string]*ImageVulnerabilities)
+	seen := make(map[string]map[string]bool)
+	for i := range reports {
+		report := &reports[i]
+		image := reportImage(report)
+		iv := byImage[image]
+		if iv == nil {
+			iv = &ImageVulnerabilities{Image: image, Counts: make(map[string]int)}
+			byImage[image] = iv
+			seen[image] = make(map[string]bool)
+		}
+		iv.Workloads = append(iv.Workloads, reportWorkload(report))
+		if !slices.Contains(iv.Namespaces, report.GetNamespace()) {
+			iv.Namespaces = append(iv.Namespaces, report.GetNamespace())
+		}
+
+		// Reports of the same image list the same CVEs; count each once.
+		vulns, _, _ := unstructured.NestedSlice(report.Object, "report", "vulnerabilities")
+		for _, raw := range vulns {
+			m, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			v := Vulnerability{}
+			v.ID, _ = m["vulnerabilityID"].(string)
+			v.Severity, _ = m["severity"].(string)
+			v.Resource, _ = m["resource"].(string)
+			v.InstalledVersion, _ = m["installedVersion"].(string)
+			v.FixedVersion, _ = m["fixedVersion"].(string)
+			v.Title, _ = m["title"].(string)
+			v.Link, _ = m["primaryLink"].(string)
+			v.Score, _ = m["score"].(float64)
+			if !slices.Contains(severities, v.Severity) || (fixableOnly && v.FixedVersion == "") {
+				continue
+			}
+			key := v.ID + "|" + v.Resource + "|" + v.InstalledVersion
+			if seen[image][key] {
+				continue
+			}
+			seen[image][key] = true
+			iv.Counts[v.Severity]++
+			if v.FixedVersion != "" {
+				iv.Fixable++
+			}
+			iv.Vulnerabilities = append(iv.Vulnerabilities, v)
+		}
+	}
+
+	scan := &vulnerabilityScan{Scanned: len(byImage)}
+	for _, iv := range byImage {
+		if len(iv.Vulnerabilities) == 0 {
+			continue
+		}
+		sort.Strings(iv.Workloads)
+		sort.Strings(iv.Namespaces)
+		sort.SliceStable(iv.Vulnerabilities, func(i, j int) bool {
+			a, b := iv.Vulnerabilities[i], iv.Vulnerabilities[j]
+			if a.Severity != b.Severity {
+				return slices.Index(trivySeverities, a.Severity) < slices.Index(trivySeverities, b.Severity)
+			}
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+			return a.ID < b.ID
+		})
+		scan.Images = append(scan.Images, *iv)
+	}
+	sort.Slice(scan.Images, func(i, j int) bool {
+		a, b := scan.Images[i], scan.Images[j]
+		for _, s := range trivySeverities {
+			if a.Counts[s] != b.Counts[s] {
+				return a.Counts[s] > b.Counts[s]
+			}
+		}
+		return a.Image < b.Image
+	})
+	return scan
+}
+
+func (s *Server) toolGetImageVulnerabilities(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	namespace, err := extractAndValidateNamespace(args)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	severitySpec, _ := args["severity"].(string)
+	severities, err := parseSeverities(severitySpec)
+	if err != nil {
+		return fmt.Sprintf("error: %v", err), true
+	}
+	fixableOnly, _ := args["fixable_only"].(bool)
+	limit := defaultVulnerabilityLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, _ kubernetes.Interface, clusterName string) (interface{}, error) {
+		dyn, err := s.getDynamicClientForCluster(clusterName)
+		if err != nil {
+			return nil, err
+		}
+		list, err := dyn.Resource(vulnerabilityReportGVR).Namespace(namespace).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil, errTrivyNotInstalled
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list vulnerability reports: %w", err)
+		}
+		return summarizeVulnerabilityReports(list.Items, severities, fixableOnly), nil
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to get image vulnerabilities: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "# Image Vulnerabilities (%s", strings.Join(severities, ", "))
+	if fixableOnly {
+		sb.WriteString(", fixable only")
+	}
+	sb.WriteString(")\n")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		writeVulnerabilityScan(&sb, r.Result.(*vulnerabilityScan), severities, limit)
+	}
+	return sb.String(), false
+}
+
+func writeVulnerabilityScan(sb *strings.Builder, scan *vulnerabilityScan, severities []string, limit int) {
+	if scan.Scanned == 0 {
+		sb.WriteString("No vulnerability reports yet; the Trivy Operator may still be scanning.\n")
+		return
+	}
+	if len(scan.Images) == 0 {
+		_, _ = fmt.Fprintf(sb, "✅ %d images scanned, none with matching vulnerabilities\n", scan.Scanned)
+		return
+	}
+	_, _ = fmt.Fprintf(sb, "%d of %d scanned images have matching vulnerabilities.\n", len(scan.Images), scan.Scanned)
+
+	header := func(first string) {
+		_, _ = fmt.Fprintf(sb, "\n| %s | %s | Fixable |\n", first, strings.Join(severities, " | "))
+		sb.WriteString("|" + strings.Repeat("---|", len(severities)+2) + "\n")
+	}
+
+	type nsTotals struct {
+		counts  map[string]int
+		fixable int
+		images  int
+	}
+	namespaces := make(map[string]*nsTotals)
+	for _, iv := range scan.Images {
+		for _, ns := range iv.Namespaces {
+			t := namespaces[ns]
+			if t == nil {
+				t = &nsTotals{counts: make(map[string]int)}
+				namespaces[ns] = t
+			}
+			t.images++
+			t.fixable += iv.Fixable
+			for s, n := range iv.Counts {
+				t.counts[s] += n
+			}
+		}
+	}
+	names := make([]string, 0, len(namespaces))
+	for ns := range namespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	sb.WriteString("\n### By Namespace\n")
+	header("Namespace (images)")
+	for _, ns := range names {
+		t := namespaces[ns]
+		_, _ = fmt.Fprintf(sb, "| %s (%d) |", ns, t.images)
+		for _, s := range severities {
+			_, _ = fmt.Fprintf(sb, " %d |", t.counts[s])
+		}
+		_, _ = fmt.Fprintf(sb, " %d |\n", t.fixable)
+	}
+
+	sb.WriteString("\n### By Image\n")
+	header("Image")
+	for _, iv := range scan.Images {
+		_, _ = fmt.Fprintf(sb, "| %s |", iv.Image)
+		for _, s := range severities {
+			_, _ = fmt.Fprintf(sb, " %d |", iv.Counts[s])
+		}
+		_, _ = fmt.Fprintf(sb, " %d |\n", iv.Fixable)
+	}
+
+	for _, iv := range scan.Images {
+		_, _ = fmt.Fprintf(sb, "\n#### %s\n\nUsed by: %s\n\n", iv.Image, strings.Join(iv.Workloads, ", "))
+		for i, v := range iv.Vulnerabilities {
+			if i == limit {
+				_, _ = fmt.Fprintf(sb, "- ... and %d more\n", len(iv.Vulnerabilities)-limit)
+				break
+			}
+			fix := "no fix yet"
+			if v.FixedVersion != "" {
+				fix = "fixed in " + v.FixedVersion
+			}
+			_, _ = fmt.Fprintf(sb, "- **%s** (%s) %s %s, %s", v.ID, v.Severity, v.Resource, v.InstalledVersion, fix)
+			if v.Title != "" {
+				_, _ = fmt.Fprintf(sb, ": %s", v.Title)
+			}
+			sb.WriteString("\n")
+		}
+	}
+}
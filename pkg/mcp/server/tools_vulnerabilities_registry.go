@@ -0,0 +1,40 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterCachedTool(Tool{
+		Name:        "get_image_vulnerabilities",
+		Description: "Summarize CVEs in running images from Trivy Operator VulnerabilityReports, per image and per namespace, with the workloads using each image and the versions that fix them, per cluster",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+				"namespace": {
+					Type:        "string",
+					Description: "Namespace to check (all namespaces if not specified)",
+				},
+				"severity": {
+					Type:        "string",
+					Description: "Comma-separated severities to include: CRITICAL, HIGH, MEDIUM, LOW, UNKNOWN (default: CRITICAL,HIGH)",
+				},
+				"fixable_only": {
+					Type:        "boolean",
+					Description: "Only include vulnerabilities that have a fixed version",
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum CVEs to list per image (default: 5)",
+				},
+			},
+		},
+	},
+		scanCacheTTL,
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolGetImageVulnerabilities(ctx, args)
+		},
+	)
+}
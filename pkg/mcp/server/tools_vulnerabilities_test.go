@@ -0,0 +1,125 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cluster"
+)
+
+func cve(id, severity, resource, installed, fixed string) interface{} {
+	return map[string]interface{}{
+		"vulnerabilityID": id, "severity": severity, "resource": resource,
+		"installedVersion": installed, "fixedVersion": fixed, "title": id + " in " + resource,
+	}
+}
+
+// vulnerabilityReport returns the Trivy report for container of the
+// ReplicaSet name in namespace, which runs nginx:tag.
+func vulnerabilityReport(namespace, name, container, tag string, vulns ...interface{}) *unstructured.Unstructured {
+	report := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "aquasecurity.github.io/v1alpha1", "kind": "VulnerabilityReport",
+		"report": map[string]interface{}{
+			"registry":        map[string]interface{}{"server": "index.docker.io"},
+			"artifact":        map[string]interface{}{"repository": "library/nginx", "tag": tag},
+			"vulnerabilities": vulns,
+		},
+	}}
+	report.SetNamespace(namespace)
+	report.SetName("replicaset-" + name + "-" + container)
+	report.SetLabels(map[string]string{
+		trivyResourceKind: "ReplicaSet", trivyResourceName: name,
+		trivyResourceNamespace: namespace, trivyContainerName: container,
+	})
+	return report
+}
+
+func TestParseSeverities(t *testing.T) {
+	severities, err := parseSeverities("")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CRITICAL", "HIGH"}, severities)
+
+	severities, err = parseSeverities("low, critical,LOW")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"CRITICAL", "LOW"}, severities)
+
+	_, err = parseSeverities("CRITICAL,severe")
+	assert.ErrorContains(t, err, `unknown severity "SEVERE"`)
+}
+
+func TestSummarizeVulnerabilityReports(t *testing.T) {
+	reports := []unstructured.Unstructured{
+		*vulnerabilityReport("shop", "web-5d9f", "nginx", "1.21",
+			cve("CVE-2023-0001", "HIGH", "libssl3", "3.0.2", "3.0.8"),
+			cve("CVE-2023-0002", "CRITICAL", "zlib", "1.2.11", ""),
+			cve("CVE-2023-0003", "LOW", "bash", "5.1", "5.2")),
+		*vulnerabilityReport("blog", "front-7c8d", "nginx", "1.21",
+			cve("CVE-2023-0001", "HIGH", "libssl3", "3.0.2", "3.0.8"),
+			cve("CVE-2023-0002", "CRITICAL", "zlib", "1.2.11", "")),
+		*vulnerabilityReport("shop", "api-6b7c", "proxy", "1.25"),
+	}
+
+	scan := summarizeVulnerabilityReports(reports, []string{"CRITICAL", "HIGH"}, false)
+	assert.Equal(t, 2, scan.Scanned)
+	require.Len(t, scan.Images, 1, "images without matching CVEs are left out")
+	image := scan.Images[0]
+	assert.Equal(t, "index.docker.io/library/nginx:1.21", image.Image)
+	assert.Equal(t, []string{"blog/ReplicaSet/front-7c8d (nginx)", "shop/ReplicaSet/web-5d9f (nginx)"}, image.Workloads)
+	assert.Equal(t, []string{"blog", "shop"}, image.Namespaces)
+	assert.Equal(t, map[string]int{"CRITICAL": 1, "HIGH": 1}, image.Counts, "an image's CVEs are counted once across its reports")
+	assert.Equal(t, 1, image.Fixable)
+	assert.Equal(t, "CVE-2023-0002", image.Vulnerabilities[0].ID, "most severe first")
+
+	scan = summarizeVulnerabilityReports(reports, []string{"CRITICAL", "HIGH"}, true)
+	require.Len(t, scan.Images, 1)
+	assert.Equal(t, map[string]int{"HIGH": 1}, scan.Images[0].Counts)
+}
+
+func TestGetImageVulnerabilitiesPerCluster(t *testing.T) {
+	gvrs := map[schema.GroupVersionResource]string{vulnerabilityReportGVR: "VulnerabilityReportList"}
+	dyns := map[string]dynamic.Interface{
+		"alpha": dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrs,
+			vulnerabilityReport("shop", "web-5d9f", "nginx", "1.21",
+				cve("CVE-2023-0001", "HIGH", "libssl3", "3.0.2", "3.0.8"),
+				cve("CVE-2023-0002", "CRITICAL", "zlib", "1.2.11", "")),
+			vulnerabilityReport("shop", "api-6b7c", "proxy", "1.25")),
+	}
+	missing := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrs)
+	missing.PrependReactor("list", "vulnerabilityreports", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(vulnerabilityReportGVR.GroupResource(), "")
+	})
+	dyns["beta"] = missing
+	infos := []cluster.ClusterInfo{{Name: "alpha", Context: "alpha"}, {Name: "beta", Context: "beta"}}
+	server := &Server{
+		discoverer:           stubDiscoverer{discoverClusters: func(string) ([]cluster.ClusterInfo, error) { return infos, nil }},
+		clientFactory:        func(string) (kubernetes.Interface, error) { return k8sfake.NewSimpleClientset(), nil },
+		dynamicClientFactory: func(name string) (dynamic.Interface, error) { return dyns[name], nil },
+	}
+
+	result, rpcErr := callTool(t, server, "get_image_vulnerabilities", map[string]interface{}{"limit": float64(1)})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	text := result.Content[0].Text
+	assert.Contains(t, text, "# Image Vulnerabilities (CRITICAL, HIGH)\n")
+	assert.Contains(t, text, "1 of 2 scanned images have matching vulnerabilities.")
+	assert.Contains(t, text, "| shop (1) | 1 | 1 | 1 |")
+	assert.Contains(t, text, "| index.docker.io/library/nginx:1.21 | 1 | 1 | 1 |")
+	assert.Contains(t, text, "Used by: shop/ReplicaSet/web-5d9f (nginx)")
+	assert.Contains(t, text, "- **CVE-2023-0002** (CRITICAL) zlib 1.2.11, no fix yet: CVE-2023-0002 in zlib\n- ... and 1 more")
+	assert.Contains(t, text, "## beta\n\nerror: the Trivy Operator is not installed")
+
+	result, rpcErr = callTool(t, server, "get_image_vulnerabilities", map[string]interface{}{"severity": "medium,bogus"})
+	require.Nil(t, rpcErr)
+	assert.True(t, result.IsError)
+}
@@ -0,0 +1,503 @@
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// defaultWebhookTimeout is the timeoutSeconds of a webhook that does
+	// not set it.
+	defaultWebhookTimeout = 10
+	// slowWebhookCall is the mean call latency at which a webhook is
+	// flagged as slow.
+	slowWebhookCall = 500 * time.Millisecond
+)
+
+// webhookMetricRe matches the samples of the API server's admission webhook
+// metrics, capturing the metric name, labels, and value.
+var (
+	webhookMetricRe = regexp.MustCompile(`^(apiserver_admission_webhook_(?:admission_duration_seconds_sum|admission_duration_seconds_count|rejection_count))\{(.*)\} (\S+)`)
+	metricLabelRe   = regexp.MustCompile(`(\w+)="((?:[^"\\]|\\.)*)"`)
+)
+
+// WebhookLatency is what the API server measured for a webhook since it
+// started.
+type WebhookLatency struct {
+	Calls       float64 `json:"calls"`
+	MeanSeconds float64 `json:"meanSeconds"`
+	Rejected    float64 `json:"rejected,omitempty"`
+	CallErrors  float64 `json:"callErrors,omitempty"`
+	seconds     float64
+}
+
+// WebhookAudit is one webhook of a Validating- or
+// MutatingWebhookConfiguration and the problems found with it.
+type WebhookAudit struct {
+	Configuration  string `json:"configuration"`
+	Name           string `json:"name"`
+	Type           string `json:"type"` // validating or mutating
+	FailurePolicy  string `json:"failurePolicy"`
+	TimeoutSeconds int32  `json:"timeoutSeconds"`
+	Backend        string `json:"backend"`
+	// ReadyEndpoints is nil for webhooks called by URL.
+	ReadyEndpoints *int                 `json:"readyEndpoints,omitempty"`
+	CAExpiry       *time.Time           `json:"caExpiry,omitempty"`
+	Latency        *WebhookLatency      `json:"latency,omitempty"`
+	Problems       []CertificateProblem `json:"problems,omitempty"`
+	clientConfig   admissionregistrationv1.WebhookClientConfig
+}
+
+// WebhookAuditResult is the webhooks of one cluster and the admission
+// latency they add.
+type WebhookAuditResult struct {
+	Webhooks []WebhookAudit `json:"webhooks"`
+	// WorstCase is the latency the webhooks add to a request they all
+	// match if every call runs to its timeout: mutating webhooks are
+	// called one after another, validating ones in parallel.
+	WorstCase time.Duration `json:"worstCase"`
+	// Observed is the same from the mean call latencies, if the API
+	// server's metrics could be read; otherwise MetricsError says why.
+	Observed     *time.Duration `json:"observed,omitempty"`
+	MetricsError string         `json:"metricsError,omitempty"`
+}
+
+func (s *Server) toolCheckAdmissionWebhooks(ctx context.Context, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+
+	now := time.Now()
+	results, err := s.executeMultiCluster(ctx, cluster, func(ctx context.Context, client kubernetes.Interface, clusterName string) (interface{}, error) {
+		return auditWebhooks(ctx, client, now)
+	})
+	if err != nil {
+		return fmt.Sprintf("Failed to check admission webhooks: %v", err), true
+	}
+	sortClusterResults(results)
+
+	var sb strings.Builder
+	sb.WriteString("# Admission Webhook Audit\n")
+	for _, r := range results {
+		_, _ = fmt.Fprintf(&sb, "\n## %s\n\n", r.Cluster)
+		if r.Error != "" {
+			_, _ = fmt.Fprintf(&sb, "error: %s\n", r.Error)
+			continue
+		}
+		writeWebhookAudit(&sb, r.Result.(*WebhookAuditResult))
+	}
+	return sb.String(), false
+}
+
+// auditWebhooks checks the backend and CA bundle of every admission
+// webhook of a cluster, and reads their latency from the API server's
+// metrics.
+func auditWebhooks(ctx context.Context, client kubernetes.Interface, now time.Time) (*WebhookAuditResult, error) {
+	validating, err := client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validatingwebhookconfigurations: %w", err)
+	}
+	mutating, err := client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutatingwebhookconfigurations: %w", err)
+	}
+
+	result := &WebhookAuditResult{Webhooks: []WebhookAudit{}}
+	for _, c := range mutating.Items {
+		for _, wh := range c.Webhooks {
+			result.Webhooks = append(result.Webhooks, newWebhookAudit(c.Name, "mutating", wh.Name, wh.FailurePolicy, wh.TimeoutSeconds, wh.ClientConfig))
+		}
+	}
+	for _, c := range validating.Items {
+		for _, wh := range c.Webhooks {
+			result.Webhooks = append(result.Webhooks, newWebhookAudit(c.Name, "validating", wh.Name, wh.FailurePolicy, wh.TimeoutSeconds, wh.ClientConfig))
+		}
+	}
+
+	latencies, err := webhookLatencies(ctx, client)
+	if err != nil {
+		result.MetricsError = err.Error()
+	}
+	backends := make(map[string]webhookBackend)
+	var (
+		worstMutating, observedMutating     time.Duration
+		worstValidating, observedValidating time.Duration
+	)
+	for i := range result.Webhooks {
+		w := &result.Webhooks[i]
+		if svc := w.clientConfig.Service; svc != nil {
+			key := svc.Namespace + "/" + svc.Name
+			backend, ok := backends[key]
+			if !ok {
+				backend = loadWebhookBackend(ctx, client, svc.Namespace, svc.Name)
+				backends[key] = backend
+			}
+			checkWebhookBackend(w, backend, svc)
+		}
+		checkWebhookCABundle(w, now)
+		if latencies != nil {
+			w.Latency = latencies[w.Name]
+			checkWebhookLatency(w)
+		}
+
+		timeout := time.Duration(w.TimeoutSeconds) * time.Second
+		var observed time.Duration
+		if w.Latency != nil {
+			observed = time.Duration(w.Latency.MeanSeconds * float64(time.Second))
+		}
+		if w.Type == "mutating" {
+			worstMutating += timeout
+			observedMutating += observed
+		} else {
+			worstValidating = max(worstValidating, timeout)
+			observedValidating = max(observedValidating, observed)
+		}
+	}
+	result.WorstCase = worstMutating + worstValidating
+	if latencies != nil {
+		observed := observedMutating + observedValidating
+		result.Observed = &observed
+	}
+
+	sort.SliceStable(result.Webhooks, func(i, j int) bool {
+		a, b := result.Webhooks[i], result.Webhooks[j]
+		if (len(a.Problems) > 0) != (len(b.Problems) > 0) {
+			return len(a.Problems) > 0
+		}
+		if a.Configuration != b.Configuration {
+			return a.Configuration < b.Configuration
+		}
+		return a.Name < b.Name
+	})
+	return result, nil
+}
+
+func newWebhookAudit(configuration, typ, name string, policy *admissionregistrationv1.FailurePolicyType, timeout *int32, clientConfig admissionregistrationv1.WebhookClientConfig) WebhookAudit {
+	w := WebhookAudit{
+		Configuration:  configuration,
+		Name:           name,
+		Type:           typ,
+		FailurePolicy:  string(admissionregistrationv1.Fail),
+		TimeoutSeconds: defaultWebhookTimeout,
+		clientConfig:   clientConfig,
+	}
+	if policy != nil {
+		w.FailurePolicy = string(*policy)
+	}
+	if timeout != nil {
+		w.TimeoutSeconds = *timeout
+	}
+	switch {
+	case clientConfig.Service != nil:
+		svc := clientConfig.Service
+		w.Backend = fmt.Sprintf("service %s/%s:%d", svc.Namespace, svc.Name, webhookServicePort(svc))
+		if svc.Path != nil {
+			w.Backend += *svc.Path
+		}
+	case clientConfig.URL != nil:
+		w.Backend = *clientConfig.URL
+	}
+	return w
+}
+
+func webhookServicePort(svc *admissionregistrationv1.ServiceReference) int32 {
+	if svc.Port != nil {
+		return *svc.Port
+	}
+	return 443
+}
+
+// webhookBackend is the Service a webhook calls and its ready endpoints.
+type webhookBackend struct {
+	service *corev1.Service
+	ready   int
+	err     error
+}
+
+func loadWebhookBackend(ctx context.Context, client kubernetes.Interface, namespace, name string) webhookBackend {
+	svc, err := client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return webhookBackend{err: err}
+	}
+	backend := webhookBackend{service: svc}
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return backend
+	}
+	endpointSlices, err := client.DiscoveryV1().EndpointSlices(namespace).List(ctx, metav1.ListOptions{LabelSelector: discoveryv1.LabelServiceName + "=" + name})
+	if err != nil {
+		backend.err = err
+		return backend
+	}
+	for _, slice := range endpointSlices.Items {
+		for _, ep := range slice.Endpoints {
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				backend.ready++
+			}
+		}
+	}
+	return backend
+}
+
+// webhookImpact is what happens to the requests a webhook matches when it
+// cannot be called.
+func webhookImpact(w *WebhookAudit) (severity, impact string) {
+	if w.FailurePolicy == string(admissionregistrationv1.Ignore) {
+		return "warning", "matching requests are admitted without this webhook's check"
+	}
+	return "critical", "failurePolicy Fail rejects every request it matches, cluster-wide"
+}
+
+func checkWebhookBackend(w *WebhookAudit, backend webhookBackend, ref *admissionregistrationv1.ServiceReference) {
+	severity, impact := webhookImpact(w)
+	name := ref.Namespace + "/" + ref.Name
+	switch {
+	case apierrors.IsNotFound(backend.err) && backend.service == nil:
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity:   severity,
+			Problem:    fmt.Sprintf("Service %s does not exist", name),
+			Detail:     impact,
+			Suggestion: fmt.Sprintf("restore the Service, or delete the %s webhook configuration %s if the component that served it was uninstalled", w.Type, w.Configuration),
+		})
+		return
+	case backend.service == nil:
+		w.Problems = append(w.Problems, CertificateProblem{Severity: "warning", Problem: fmt.Sprintf("Service %s could not be read: %v", name, backend.err)})
+		return
+	case backend.service.Spec.Type == corev1.ServiceTypeExternalName:
+		return
+	}
+	port := webhookServicePort(ref)
+	if !servicePortDefined(backend.service, port) {
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity: severity,
+			Problem:  fmt.Sprintf("Service %s has no port %d", name, port),
+			Detail:   impact,
+		})
+	}
+	if backend.err != nil {
+		w.Problems = append(w.Problems, CertificateProblem{Severity: "warning", Problem: fmt.Sprintf("endpoints of Service %s could not be read: %v", name, backend.err)})
+		return
+	}
+	ready := backend.ready
+	w.ReadyEndpoints = &ready
+	if ready == 0 {
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity:   severity,
+			Problem:    fmt.Sprintf("Service %s has no ready endpoints", name),
+			Detail:     impact,
+			Suggestion: "check the pods behind the Service; run more than one replica of webhook servers",
+		})
+	}
+}
+
+func servicePortDefined(svc *corev1.Service, port int32) bool {
+	for _, p := range svc.Spec.Ports {
+		if p.Port == port {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWebhookCABundle checks that the caBundle of a webhook holds a
+// certificate that has not expired. The API server verifies the webhook's
+// serving certificate against it, or against its own roots if it is empty.
+func checkWebhookCABundle(w *WebhookAudit, now time.Time) {
+	severity, impact := webhookImpact(w)
+	clientConfig := w.clientConfig
+	if len(clientConfig.CABundle) == 0 {
+		if clientConfig.Service != nil {
+			w.Problems = append(w.Problems, CertificateProblem{
+				Severity:   "warning",
+				Problem:    "caBundle is empty",
+				Detail:     "the API server verifies the webhook's certificate against its own trust roots, which an in-cluster serving certificate is rarely signed by",
+				Suggestion: "set caBundle, or let cert-manager's CA injector fill it with the cert-manager.io/inject-ca-from annotation",
+			})
+		}
+		return
+	}
+	var latest time.Time
+	rest := clientConfig.CABundle
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil && cert.NotAfter.After(latest) {
+			latest = cert.NotAfter
+		}
+	}
+	switch {
+	case latest.IsZero():
+		w.Problems = append(w.Problems, CertificateProblem{Severity: severity, Problem: "caBundle holds no valid PEM certificate", Detail: impact})
+	case !latest.After(now):
+		w.CAExpiry = &latest
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity: severity,
+			Problem:  fmt.Sprintf("caBundle expired %s ago", formatDuration(now.Sub(latest))),
+			Detail:   impact,
+		})
+	default:
+		w.CAExpiry = &latest
+		if latest.Sub(now) < certExpiryWarning {
+			w.Problems = append(w.Problems, CertificateProblem{
+				Severity: "warning",
+				Problem:  fmt.Sprintf("caBundle expires in %s", formatDuration(latest.Sub(now))),
+				Detail:   impact + " once it expires",
+			})
+		}
+	}
+}
+
+func checkWebhookLatency(w *WebhookAudit) {
+	l := w.Latency
+	if l == nil || l.Calls == 0 {
+		return
+	}
+	if mean := time.Duration(l.MeanSeconds * float64(time.Second)); mean >= slowWebhookCall {
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity: "warning",
+			Problem:  fmt.Sprintf("calls take %s on average", mean.Round(time.Millisecond)),
+			Detail:   fmt.Sprintf("every request it matches waits for it, up to its %ds timeout", w.TimeoutSeconds),
+		})
+	}
+	if l.CallErrors > 0 {
+		severity, impact := webhookImpact(w)
+		w.Problems = append(w.Problems, CertificateProblem{
+			Severity: severity,
+			Problem:  fmt.Sprintf("%.0f calls failed", l.CallErrors),
+			Detail:   impact + " while it cannot be called",
+		})
+	}
+}
+
+// webhookLatencies reads the admission webhook metrics of the API server
+// the client talks to, keyed by webhook name.
+func webhookLatencies(ctx context.Context, client kubernetes.Interface) (map[string]*WebhookLatency, error) {
+	restClient := client.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, fmt.Errorf("the API server's metrics are not available")
+	}
+	raw, err := restClient.Get().AbsPath("/metrics").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the API server's metrics: %w", err)
+	}
+	return parseWebhookMetrics(string(raw)), nil
+}
+
+// parseWebhookMetrics sums the admission webhook metrics of the API
+// server, in the Prometheus text format, per webhook.
+func parseWebhookMetrics(text string) map[string]*WebhookLatency {
+	latencies := make(map[string]*WebhookLatency)
+	for _, line := range strings.Split(text, "\n") {
+		m := webhookMetricRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		value, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			continue
+		}
+		labels := make(map[string]string)
+		for _, l := range metricLabelRe.FindAllStringSubmatch(m[2], -1) {
+			labels[l[1]] = l[2]
+		}
+		name := labels["name"]
+		if name == "" {
+			continue
+		}
+		l, ok := latencies[name]
+		if !ok {
+			l = &WebhookLatency{}
+			latencies[name] = l
+		}
+		switch m[1] {
+		case "apiserver_admission_webhook_admission_duration_seconds_sum":
+			l.seconds += value
+		case "apiserver_admission_webhook_admission_duration_seconds_count":
+			l.Calls += value
+			if labels["rejected"] == "true" {
+				l.Rejected += value
+			}
+		case "apiserver_admission_webhook_rejection_count":
+			if labels["error_type"] == "calling_webhook_error" {
+				l.CallErrors += value
+			}
+		}
+	}
+	for _, l := range latencies {
+		if l.Calls > 0 {
+			l.MeanSeconds = l.seconds / l.Calls
+		}
+	}
+	return latencies
+}
+
+func writeWebhookAudit(sb *strings.Builder, r *WebhookAuditResult) {
+	if len(r.Webhooks) == 0 {
+		sb.WriteString("✅ No admission webhooks configured\n")
+		return
+	}
+	flagged := 0
+	for _, w := range r.Webhooks {
+		if len(w.Problems) > 0 {
+			flagged++
+		}
+	}
+	if flagged == 0 {
+		_, _ = fmt.Fprintf(sb, "✅ %d webhooks checked, no problems found\n", len(r.Webhooks))
+	} else {
+		_, _ = fmt.Fprintf(sb, "%d of %d webhooks have problems\n", flagged, len(r.Webhooks))
+	}
+
+	sb.WriteString("\n| Webhook | Type | Failure Policy | Timeout | Backend | Ready Endpoints | Mean Latency | Calls |\n")
+	sb.WriteString("|---------|------|----------------|---------|---------|-----------------|--------------|-------|\n")
+	for _, w := range r.Webhooks {
+		endpoints, latency, calls := "-", "-", "-"
+		if w.ReadyEndpoints != nil {
+			endpoints = strconv.Itoa(*w.ReadyEndpoints)
+		}
+		if w.Latency != nil && w.Latency.Calls > 0 {
+			latency = time.Duration(w.Latency.MeanSeconds * float64(time.Second)).Round(time.Millisecond).String()
+			calls = fmt.Sprintf("%.0f", w.Latency.Calls)
+		}
+		_, _ = fmt.Fprintf(sb, "| %s/%s | %s | %s | %ds | %s | %s | %s | %s |\n",
+			w.Configuration, w.Name, w.Type, w.FailurePolicy, w.TimeoutSeconds, w.Backend, endpoints, latency, calls)
+	}
+
+	for _, w := range r.Webhooks {
+		if len(w.Problems) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(sb, "\n📛 %s/%s (%s, failurePolicy %s)\n", w.Configuration, w.Name, w.Type, w.FailurePolicy)
+		for _, p := range w.Problems {
+			_, _ = fmt.Fprintf(sb, "   - [%s] %s\n", p.Severity, p.Problem)
+			if p.Detail != "" {
+				_, _ = fmt.Fprintf(sb, "     %s\n", p.Detail)
+			}
+			if p.Suggestion != "" {
+				_, _ = fmt.Fprintf(sb, "     Fix: %s\n", p.Suggestion)
+			}
+		}
+	}
+
+	sb.WriteString("\n### Added Admission Latency\n\n")
+	_, _ = fmt.Fprintf(sb, "A request every webhook matches waits up to %s if every call runs to its timeout (mutating webhooks are called in turn, validating ones in parallel).\n", r.WorstCase)
+	if r.Observed != nil {
+		_, _ = fmt.Fprintf(sb, "At the mean latencies the API server measured, it waits about %s.\n", r.Observed.Round(time.Millisecond))
+	} else {
+		_, _ = fmt.Fprintf(sb, "Observed latency not measured: %s.\n", r.MetricsError)
+	}
+}
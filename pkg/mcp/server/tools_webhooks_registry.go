@@ -0,0 +1,23 @@
+package server
+
+import "context"
+
+func init() {
+	RegisterTool(Tool{
+		Name:        "check_admission_webhooks",
+		Description: "Audit Validating and Mutating admission webhooks: check that each backend Service exists and has ready endpoints on the webhook's port, that caBundle holds an unexpired certificate, flag failurePolicy Fail webhooks whose backend is missing (which rejects every matching request cluster-wide), and estimate the latency webhooks add to requests from their timeouts and the API server's admission metrics",
+		InputSchema: InputSchema{
+			Type: "object",
+			Properties: map[string]Property{
+				"cluster": {
+					Type:        "string",
+					Description: "Cluster name (all clusters if not specified)",
+				},
+			},
+		},
+	},
+		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
+			return s.toolCheckAdmissionWebhooks(ctx, args)
+		},
+	)
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func webhookClient(t *testing.T, namespace, service string, caExpiry time.Time) admissionregistrationv1.WebhookClientConfig {
+	return admissionregistrationv1.WebhookClientConfig{
+		Service:  &admissionregistrationv1.ServiceReference{Namespace: namespace, Name: service},
+		CABundle: []byte(selfSignedPEM(t, caExpiry)),
+	}
+}
+
+func TestCheckAdmissionWebhooks(t *testing.T) {
+	now := time.Now()
+	ignore := admissionregistrationv1.Ignore
+	timeout := int32(30)
+	ready := false
+	server, _ := newJobsServer(map[string][]runtime.Object{
+		"prod": {
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "gatekeeper"},
+				Webhooks: []admissionregistrationv1.ValidatingWebhook{
+					{Name: "validation.gatekeeper.sh", ClientConfig: webhookClient(t, "gatekeeper-system", "gatekeeper-webhook", now.AddDate(1, 0, 0))},
+				},
+			},
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "old-operator"},
+				Webhooks: []admissionregistrationv1.ValidatingWebhook{
+					{Name: "vwidget.example.com", TimeoutSeconds: &timeout, ClientConfig: webhookClient(t, "widgets", "widget-webhook", now.AddDate(1, 0, 0))},
+				},
+			},
+			&admissionregistrationv1.MutatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+				Webhooks: []admissionregistrationv1.MutatingWebhook{
+					{Name: "inject.example.com", FailurePolicy: &ignore, ClientConfig: webhookClient(t, "mesh", "injector", now.Add(-time.Hour))},
+				},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "gatekeeper-webhook", Namespace: "gatekeeper-system"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 443}}},
+			},
+			&discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: "gatekeeper-webhook-1", Namespace: "gatekeeper-system", Labels: map[string]string{discoveryv1.LabelServiceName: "gatekeeper-webhook"}},
+				Endpoints:  []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.1"}}, {Addresses: []string{"10.0.0.2"}}},
+			},
+			&corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "injector", Namespace: "mesh"},
+				Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 443}}},
+			},
+			&discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{Name: "injector-1", Namespace: "mesh", Labels: map[string]string{discoveryv1.LabelServiceName: "injector"}},
+				Endpoints:  []discoveryv1.Endpoint{{Addresses: []string{"10.0.1.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}}},
+			},
+		},
+		"dev": {},
+	})
+
+	result, rpcErr := callTool(t, server, "check_admission_webhooks", map[string]interface{}{})
+	require.Nil(t, rpcErr)
+	require.False(t, result.IsError, result.Content[0].Text)
+	out := result.Content[0].Text
+
+	assert.Contains(t, out, "## dev\n\n✅ No admission webhooks configured")
+	assert.Contains(t, out, "2 of 3 webhooks have problems")
+	assert.Contains(t, out, "| gatekeeper/validation.gatekeeper.sh | validating | Fail | 10s | service gatekeeper-system/gatekeeper-webhook:443 | 2 | - | - |")
+	assert.Contains(t, out, "📛 old-operator/vwidget.example.com (validating, failurePolicy Fail)\n   - [critical] Service widgets/widget-webhook does not exist\n     failurePolicy Fail rejects every request it matches, cluster-wide")
+	assert.Contains(t, out, "📛 injector/inject.example.com (mutating, failurePolicy Ignore)\n   - [warning] Service mesh/injector has no ready endpoints")
+	assert.Contains(t, out, "[warning] caBundle expired 1h ago")
+	assert.NotContains(t, out, "📛 gatekeeper/")
+	// The mutating webhook's 10s, then the slowest validating webhook's 30s.
+	assert.Contains(t, out, "waits up to 40s")
+	assert.Contains(t, out, "Observed latency not measured: the API server's metrics are not available.")
+}
+
+func TestParseWebhookMetrics(t *testing.T) {
+	latencies := parseWebhookMetrics(`# HELP apiserver_admission_webhook_admission_duration_seconds [STABLE] Admission webhook latency
+apiserver_admission_webhook_admission_duration_seconds_bucket{name="validation.gatekeeper.sh",operation="CREATE",rejected="false",type="validating",le="0.005"} 3
+apiserver_admission_webhook_admission_duration_seconds_sum{name="validation.gatekeeper.sh",operation="CREATE",rejected="false",type="validating"} 2.5
+apiserver_admission_webhook_admission_duration_seconds_count{name="validation.gatekeeper.sh",operation="CREATE",rejected="false",type="validating"} 8
+apiserver_admission_webhook_admission_duration_seconds_sum{name="validation.gatekeeper.sh",operation="UPDATE",rejected="true",type="validating"} 1.5
+apiserver_admission_webhook_admission_duration_seconds_count{name="validation.gatekeeper.sh",operation="UPDATE",rejected="true",type="validating"} 2
+apiserver_admission_webhook_rejection_count{error_type="calling_webhook_error",name="validation.gatekeeper.sh",operation="UPDATE",rejection_code="0",type="validating"} 2
+apiserver_admission_webhook_rejection_count{error_type="no_error",name="validation.gatekeeper.sh",operation="UPDATE",rejection_code="403",type="validating"} 5
+apiserver_request_total{code="200",verb="GET"} 100
+`)
+	require.Len(t, latencies, 1)
+	l := latencies["validation.gatekeeper.sh"]
+	assert.Equal(t, 10.0, l.Calls)
+	assert.InDelta(t, 0.4, l.MeanSeconds, 1e-9)
+	assert.Equal(t, 2.0, l.Rejected)
+	assert.Equal(t, 2.0, l.CallErrors)
+
+	w := &WebhookAudit{Name: "validation.gatekeeper.sh", FailurePolicy: "Fail", TimeoutSeconds: 10, Latency: l}
+	checkWebhookLatency(w)
+	require.Len(t, w.Problems, 1)
+	assert.Equal(t, "2 calls failed", w.Problems[0].Problem)
+}
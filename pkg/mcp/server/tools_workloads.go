@@ -4,10 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
 )
 
 func (s *Server) toolGetPods(ctx context.Context, args map[string]interface{}) (string, bool) {
@@ -263,6 +267,11 @@ func (s *Server) toolDescribePod(ctx context.Context, args map[string]interface{
 	if !ok || name == "" {
 		return "Pod name is required", true
 	}
+	var logLines int64
+	if v, ok := args["log_lines"].(float64); ok && v > 0 {
+		logLines = int64(v)
+	}
+	includeEvents, _ := args["include_events"].(bool)
 
 	if namespace == "" {
 		namespace = "default"
@@ -308,9 +317,122 @@ func (s *Server) toolDescribePod(ctx context.Context, args map[string]interface{
 		_, _ = fmt.Fprintf(&sb, "  - %s: %s\n", cond.Type, cond.Status)
 	}
 
+	if includeEvents {
+		writePodEvents(ctx, &sb, client, pod)
+	}
+	if logLines > 0 {
+		writePodLogs(ctx, &sb, client, pod, logLines)
+	}
+
 	return sb.String(), false
 }
 
+// describePodEvents is how many of a pod's most recent events describe_pod
+// shows.
+const describePodEvents = 20
+
+// describePodLogBytes caps each log describe_pod inlines, so a container
+// writing long lines cannot blow up the result.
+const describePodLogBytes = 64 * 1024
+
+// writePodEvents writes the most recent events about pod.
+func writePodEvents(ctx context.Context, sb *strings.Builder, client kubernetes.Interface, pod *corev1.Pod) {
+	sb.WriteString("\nEvents:\n")
+	events, err := client.EventsV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermEqualSelector("regarding.kind", "Pod"),
+			fields.OneTermEqualSelector("regarding.name", pod.Name),
+		).String(),
+	})
+	if err != nil {
+		_, _ = fmt.Fprintf(sb, "  (unavailable: %v)\n", err)
+		return
+	}
+	var matched []eventsv1.Event
+	for _, event := range events.Items {
+		// Fake and older clients may ignore the field selector.
+		if event.Regarding.Kind == "Pod" && event.Regarding.Name == pod.Name {
+			matched = append(matched, event)
+		}
+	}
+	if len(matched) == 0 {
+		sb.WriteString("  (none)\n")
+		return
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return eventLastSeen(matched[i]).After(eventLastSeen(matched[j])) })
+	if len(matched) > describePodEvents {
+		matched = matched[:describePodEvents]
+	}
+	for _, event := range matched {
+		age := "unknown"
+		if seen := eventLastSeen(event); !seen.IsZero() {
+			age = formatAge(seen)
+		}
+		_, _ = fmt.Fprintf(sb, "  - [%s] %s %s: %s", age, event.Type, event.Reason, event.Note)
+		if count := eventCount(event); count > 1 {
+			_, _ = fmt.Fprintf(sb, " (x%d)", count)
+		}
+		sb.WriteString("\n")
+	}
+}
+
+// writePodLogs writes the last lines of the current log of each container of
+// pod, and of the previous instance of containers that restarted.
+func writePodLogs(ctx context.Context, sb *strings.Builder, client kubernetes.Interface, pod *corev1.Pod, lines int64) {
+	statuses := map[string]corev1.ContainerStatus{}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+	var containers []string
+	for _, c := range pod.Spec.InitContainers {
+		containers = append(containers, c.Name)
+	}
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+
+	_, _ = fmt.Fprintf(sb, "\nLogs (last %d lines):\n", lines)
+	for _, container := range containers {
+		writeContainerLog(ctx, sb, client, pod, container, false, lines)
+		cs := statuses[container]
+		if cs.RestartCount > 0 || cs.LastTerminationState.Terminated != nil {
+			writeContainerLog(ctx, sb, client, pod, container, true, lines)
+		}
+	}
+}
+
+// writeContainerLog writes the last lines of the log of one container, or of
+// its previous instance.
+func writeContainerLog(ctx context.Context, sb *strings.Builder, client kubernetes.Interface, pod *corev1.Pod, container string, previous bool, lines int64) {
+	title := container
+	if previous {
+		title += " (previous)"
+	}
+	_, _ = fmt.Fprintf(sb, "\n--- %s ---\n", title)
+
+	limitBytes := int64(describePodLogBytes)
+	data, err := client.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		TailLines:  &lines,
+		LimitBytes: &limitBytes,
+	}).DoRaw(ctx)
+	switch {
+	case err != nil:
+		_, _ = fmt.Fprintf(sb, "(unavailable: %v)\n", err)
+	case len(data) == 0:
+		sb.WriteString("(empty)\n")
+	default:
+		sb.Write(data)
+		if data[len(data)-1] != '\n' {
+			sb.WriteString("\n")
+		}
+	}
+}
+
 func (s *Server) toolGetPodLogs(ctx context.Context, args map[string]interface{}) (string, bool) {
 	cluster, _ := args["cluster"].(string)
 	namespace, err := extractAndValidateNamespace(args)
@@ -114,7 +114,7 @@ func init() {
 	)
 	RegisterTool(Tool{
 			Name:        "describe_pod",
-			Description: "Get detailed information about a specific pod",
+			Description: "Get detailed information about a specific pod. With log_lines and include_events it also returns the end of each container's current and previous logs and the pod's recent events, so one call is enough to diagnose it",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]Property{
@@ -130,6 +130,14 @@ func init() {
 						Type:        "string",
 						Description: "Name of the pod",
 					},
+					"log_lines": {
+						Type:        "integer",
+						Description: "Include this many lines from the end of each container's log, and of the previous instance of containers that restarted (default 0, no logs)",
+					},
+					"include_events": {
+						Type:        "boolean",
+						Description: "Include the pod's most recent events",
+					},
 				},
 				Required: []string{"name"},
 			},
@@ -172,7 +180,7 @@ func init() {
 			return s.toolGetPodLogs(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterCachedTool(Tool{
 			Name:        "find_pod_issues",
 			Description: "Find pods with issues like CrashLoopBackOff, ImagePullBackOff, Pending, OOMKilled, or restarts",
 			InputSchema: InputSchema{
@@ -190,14 +198,19 @@ func init() {
 						Type:        "string",
 						Description: "Include completed/succeeded pods (true/false, default false)",
 					},
+					"label_selector": {
+						Type:        "string",
+						Description: "Only check pods matching this label selector (e.g., app=nginx)",
+					},
 				},
 			},
 		},
+		scanCacheTTL,
 		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 			return s.toolFindPodIssues(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterCachedTool(Tool{
 			Name:        "find_deployment_issues",
 			Description: "Find deployments with issues like unavailable replicas, stuck rollouts, or misconfigurations",
 			InputSchema: InputSchema{
@@ -214,11 +227,12 @@ func init() {
 				},
 			},
 		},
+		scanCacheTTL,
 		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 			return s.toolFindDeploymentIssues(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterCachedTool(Tool{
 			Name:        "check_resource_limits",
 			Description: "Find pods/containers without CPU or memory limits/requests configured",
 			InputSchema: InputSchema{
@@ -235,11 +249,12 @@ func init() {
 				},
 			},
 		},
+		scanCacheTTL,
 		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 			return s.toolCheckResourceLimits(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterCachedTool(Tool{
 			Name:        "check_security_issues",
 			Description: "Find security misconfigurations: privileged containers, running as root, host network/PID, missing security context",
 			InputSchema: InputSchema{
@@ -256,11 +271,12 @@ func init() {
 				},
 			},
 		},
+		scanCacheTTL,
 		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 			return s.toolCheckSecurityIssues(ctx, args)
 		},
 	)
-	RegisterTool(Tool{
+	RegisterCachedTool(Tool{
 			Name:        "analyze_namespace",
 			Description: "Comprehensive namespace analysis: resource quotas, limit ranges, pod count, issues summary",
 			InputSchema: InputSchema{
@@ -278,13 +294,14 @@ func init() {
 				Required: []string{"namespace"},
 			},
 		},
+		scanCacheTTL,
 		func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 			return s.toolAnalyzeNamespace(ctx, args)
 		},
 	)
 	RegisterTool(Tool{
 			Name:        "get_warning_events",
-			Description: "Get only Warning events, filtered by namespace or resource",
+			Description: "Get only Warning events, filtered by namespace, involved object, or reason. Repeats of the same warning are merged into one entry with their total count",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]Property{
@@ -300,6 +317,14 @@ func init() {
 						Type:        "string",
 						Description: "Filter by involved object name",
 					},
+					"involved_kind": {
+						Type:        "string",
+						Description: "Filter by involved object kind (e.g. Pod, Deployment)",
+					},
+					"reason": {
+						Type:        "string",
+						Description: "Filter by event reason (e.g. FailedScheduling, BackOff)",
+					},
 					"limit": {
 						Type:        "integer",
 						Description: "Maximum number of events (default 50)",
@@ -313,7 +338,7 @@ func init() {
 	)
 	RegisterTool(Tool{
 			Name:        "audit_kubeconfig",
-			Description: "Audit all clusters in kubeconfig: check connectivity, identify stale/inaccessible clusters, and recommend cleanup",
+			Description: "Audit all clusters in kubeconfig: check connectivity, identify stale/inaccessible clusters, and recommend cleanup, and report client certificate and cluster CA expiry, exec plugin token cache freshness, static and deprecated credentials, and contexts that skip TLS verification",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]Property{
@@ -330,7 +355,7 @@ func init() {
 	)
 	RegisterTool(Tool{
 			Name:        "find_resource_owners",
-			Description: "Find who owns/manages resources by checking managedFields, ownership labels, and annotations",
+			Description: "Find who owns/manages resources by checking managedFields, ownership labels, and annotations, resolving Argo CD, Flux, and Helm metadata to the owning Application, Kustomization, HelmRelease, or Helm release and its source repo",
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]Property{
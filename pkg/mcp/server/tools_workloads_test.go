@@ -6,6 +6,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
@@ -323,6 +324,82 @@ func TestToolDescribePodSuccess(t *testing.T) {
 	}
 }
 
+func TestToolDescribePodWithLogsAndEvents(t *testing.T) {
+	client := k8sfake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "shop"},
+			Spec: corev1.PodSpec{
+				InitContainers: []corev1.Container{{Name: "migrate"}},
+				Containers:     []corev1.Container{{Name: "app"}, {Name: "proxy"}},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "app", RestartCount: 2},
+					{Name: "proxy"},
+				},
+			},
+		},
+		&eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-0.1", Namespace: "shop"},
+			Type:       "Warning",
+			Reason:     "BackOff",
+			Note:       "Back-off restarting failed container app",
+			Regarding:  corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-0"},
+			Series:     &eventsv1.EventSeries{Count: 6, LastObservedTime: metav1.NowMicro()},
+		},
+		&eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1.1", Namespace: "shop"},
+			Type:       "Warning",
+			Reason:     "FailedMount",
+			Regarding:  corev1.ObjectReference{Kind: "Pod", Namespace: "shop", Name: "web-1"},
+		},
+	)
+	server := &Server{
+		discoverer: stubDiscoverer{},
+		clientFactory: func(clusterName string) (kubernetes.Interface, error) {
+			return client, nil
+		},
+	}
+
+	result, rpcErr := callTool(t, server, "describe_pod", map[string]interface{}{
+		"name":           "web-0",
+		"namespace":      "shop",
+		"log_lines":      float64(20),
+		"include_events": true,
+	})
+	if rpcErr != nil {
+		t.Fatalf("unexpected RPC error: %v", rpcErr)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Content[0].Text)
+	}
+
+	text := result.Content[0].Text
+	for _, want := range []string{
+		"Warning BackOff: Back-off restarting failed container app (x6)",
+		"Logs (last 20 lines):",
+		"--- migrate ---",
+		"--- app ---",
+		"--- app (previous) ---",
+		"--- proxy ---",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("expected %q in output, got: %s", want, text)
+		}
+	}
+	for _, unwanted := range []string{"FailedMount", "proxy (previous)"} {
+		if strings.Contains(text, unwanted) {
+			t.Fatalf("unexpected %q in output: %s", unwanted, text)
+		}
+	}
+
+	result, _ = callTool(t, server, "describe_pod", map[string]interface{}{"name": "web-0", "namespace": "shop"})
+	if text := result.Content[0].Text; strings.Contains(text, "Events:") || strings.Contains(text, "Logs") {
+		t.Fatalf("expected no events or logs by default, got: %s", text)
+	}
+}
+
 func TestToolDescribePodMissingName(t *testing.T) {
 	server := &Server{discoverer: stubDiscoverer{}}
 	result, rpcErr := callTool(t, server, "describe_pod", map[string]interface{}{"namespace": "default"})
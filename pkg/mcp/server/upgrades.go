@@ -29,7 +29,11 @@ var _ upgrades.ClusterAccess = (*serverClusterAccess)(nil)
 func init() {
 	for _, td := range upgrades.Tools() {
 		td := td // capture loop variable
-		RegisterTool(td.Schema,
+		register := RegisterTool
+		if td.Mutating {
+			register = RegisterMutatingTool
+		}
+		register(td.Schema,
 			func(ctx context.Context, s *Server, args map[string]interface{}) (string, bool) {
 				return td.Handler(ctx, &serverClusterAccess{s: s}, args)
 			},
@@ -0,0 +1,741 @@
+package upgrades
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+)
+
+// openShiftKubeOffset is the difference between the minor version of an
+// OpenShift 4 release and that of the Kubernetes release it ships: 4.16 is
+// Kubernetes 1.29.
+const openShiftKubeOffset = 13
+
+// impactRows caps each table of the impact report.
+const impactRows = 50
+
+// removedAPI is an API version of a kind that Kubernetes stopped serving.
+type removedAPI struct {
+	GroupVersion string
+	Kind         string
+	Resource     string
+	// RemovedIn is the Kubernetes 1.x minor version without the API.
+	RemovedIn int
+	// Replacement is the served version to migrate to, empty if the kind
+	// was removed with no replacement.
+	Replacement string
+}
+
+// removedAPIs are the API removals since Kubernetes 1.22, from the
+// Kubernetes deprecated API migration guide.
+var removedAPIs = []removedAPI{
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", "mutatingwebhookconfigurations", 22, "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "validatingwebhookconfigurations", 22, "admissionregistration.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "customresourcedefinitions", 22, "apiextensions.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", "apiservices", 22, "apiregistration.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", "certificatesigningrequests", 22, "certificates.k8s.io/v1"},
+	{"extensions/v1beta1", "Ingress", "ingresses", 22, "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", "ingresses", 22, "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "IngressClass", "ingressclasses", 22, "networking.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "clusterroles", 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", "clusterrolebindings", 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", "roles", 22, "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", "rolebindings", 22, "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "priorityclasses", 22, "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIDriver", "csidrivers", 22, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSINode", "csinodes", 22, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "StorageClass", "storageclasses", 22, "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", "volumeattachments", 22, "storage.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", "cronjobs", 25, "batch/v1"},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", "endpointslices", 25, "discovery.k8s.io/v1"},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "horizontalpodautoscalers", 25, "autoscaling/v2"},
+	{"policy/v1beta1", "PodDisruptionBudget", "poddisruptionbudgets", 25, "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", "podsecuritypolicies", 25, ""},
+	{"node.k8s.io/v1beta1", "RuntimeClass", "runtimeclasses", 25, "node.k8s.io/v1"},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", "horizontalpodautoscalers", 26, "autoscaling/v2"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema", "flowschemas", 26, "flowcontrol.apiserver.k8s.io/v1beta3"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration", "prioritylevelconfigurations", 26, "flowcontrol.apiserver.k8s.io/v1beta3"},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "csistoragecapacities", 27, "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", "flowschemas", 29, "flowcontrol.apiserver.k8s.io/v1beta3"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", "prioritylevelconfigurations", 29, "flowcontrol.apiserver.k8s.io/v1beta3"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", "flowschemas", 32, "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration", "prioritylevelconfigurations", 32, "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+var csvGVR = schema.GroupVersionResource{
+	Group:    "operators.coreos.com",
+	Version:  "v1alpha1",
+	Resource: "clusterserviceversions",
+}
+
+// SimulateUpgradeImpact reports what upgrading a cluster to a Kubernetes or
+// OpenShift version would break: objects and Helm releases using APIs the
+// target removes, Deployments a node drain takes to zero ready replicas,
+// pods that only one node can run, and third-party operators that do not
+// state support for the target.
+func SimulateUpgradeImpact(ctx context.Context, ca ClusterAccess, args map[string]interface{}) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	target, _ := args["target_version"].(string)
+	targetMajor, targetMinor, ok := minorOf(target)
+	if !ok || (targetMajor != 1 && targetMajor != 4) {
+		return fmt.Sprintf("error: invalid target_version %q: must be a Kubernetes (1.32) or OpenShift (4.16) version", target), true
+	}
+
+	client, err := ca.GetClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	dynClient, err := ca.GetDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create dynamic client: %v", err), true
+	}
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return fmt.Sprintf("Failed to get server version: %v", err), true
+	}
+	_, currentMinor, ok := minorOf(version.GitVersion)
+	if !ok {
+		return fmt.Sprintf("error: cannot read the cluster's version %q", version.GitVersion), true
+	}
+
+	current := version.GitVersion
+	cv, err := dynClient.Resource(clusterVersionGVR).Get(ctx, "version", metav1.GetOptions{})
+	openShift := err == nil
+	if openShift {
+		if desired, _, _ := unstructured.NestedString(cv.Object, "status", "desired", "version"); desired != "" {
+			current = desired
+		}
+	}
+	kubeMinor := targetMinor
+	if targetMajor == 4 {
+		if !openShift {
+			return fmt.Sprintf("error: target_version %s is an OpenShift version, but the cluster is not OpenShift", target), true
+		}
+		kubeMinor = targetMinor + openShiftKubeOffset
+	}
+	if kubeMinor < currentMinor {
+		return fmt.Sprintf("error: target_version %s is older than the cluster's version %s", target, current), true
+	}
+	kubeTarget := fmt.Sprintf("1.%d", kubeMinor)
+
+	var sb strings.Builder
+	sb.WriteString(messages.Sprintf(messages.ImpactTitle, current, target))
+	if targetMajor == 4 {
+		sb.WriteString(messages.Sprintf(messages.ImpactKubernetesTarget, kubeTarget))
+	}
+
+	releases, helmErr := latestHelmReleases(ctx, client)
+
+	sb.WriteString(messages.Get(messages.ImpactRemovedAPIs))
+	if helmErr != nil {
+		sb.WriteString(messages.Sprintf(messages.ImpactUnchecked, helmErr))
+	}
+	removed := removedAPIUses(ctx, dynClient, releases, currentMinor, kubeMinor)
+	if len(removed) == 0 {
+		sb.WriteString(messages.Sprintf(messages.ImpactRemovedAPIsNone, kubeTarget))
+	} else {
+		sb.WriteString(messages.Get(messages.ImpactRemovedAPIsTable))
+		writeImpactRows(&sb, removed)
+	}
+
+	pods, podsErr := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	var drains, stranded [][]string
+	sb.WriteString(messages.Get(messages.ImpactDrains))
+	if podsErr == nil {
+		drains, err = drainOutages(ctx, client, pods.Items)
+	}
+	switch {
+	case podsErr != nil:
+		sb.WriteString(messages.Sprintf(messages.ImpactUnchecked, podsErr))
+	case err != nil:
+		sb.WriteString(messages.Sprintf(messages.ImpactUnchecked, err))
+	case len(drains) == 0:
+		sb.WriteString(messages.Get(messages.ImpactDrainsNone))
+	default:
+		sb.WriteString(messages.Get(messages.ImpactDrainsTable))
+		writeImpactRows(&sb, drains)
+	}
+
+	sb.WriteString(messages.Get(messages.ImpactAffinity))
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if podsErr == nil && err == nil {
+		stranded = strandedPods(pods.Items, nodes.Items)
+	}
+	switch {
+	case podsErr != nil:
+		sb.WriteString(messages.Sprintf(messages.ImpactUnchecked, podsErr))
+	case err != nil:
+		sb.WriteString(messages.Sprintf(messages.ImpactUnchecked, err))
+	case len(stranded) == 0:
+		sb.WriteString(messages.Get(messages.ImpactAffinityNone))
+	default:
+		sb.WriteString(messages.Get(messages.ImpactAffinityTable))
+		writeImpactRows(&sb, stranded)
+	}
+
+	supportTarget := kubeTarget
+	if openShift {
+		supportTarget = fmt.Sprintf("4.%d", kubeMinor-openShiftKubeOffset)
+	}
+	sb.WriteString(messages.Get(messages.ImpactOperators))
+	operators := unsupportedOperators(ctx, dynClient, releases, kubeMinor, openShift, supportTarget)
+	if len(operators) == 0 {
+		sb.WriteString(messages.Sprintf(messages.ImpactOperatorsNone, supportTarget))
+	} else {
+		sb.WriteString(messages.Sprintf(messages.ImpactOperatorsTable, supportTarget))
+		writeImpactRows(&sb, operators)
+	}
+
+	sb.WriteString(messages.Sprintf(messages.ImpactSummary, len(removed), len(drains), len(stranded), len(operators), supportTarget))
+	return sb.String(), false
+}
+
+// writeImpactRows writes rows as a markdown table body, at most impactRows
+// of them.
+func writeImpactRows(sb *strings.Builder, rows [][]string) {
+	for i, row := range rows {
+		if i == impactRows {
+			sb.WriteString(messages.Sprintf(messages.ImpactMore, len(rows)-impactRows))
+			return
+		}
+		_, _ = fmt.Fprintf(sb, "| %s |\n", strings.Join(row, " | "))
+	}
+}
+
+// latestHelmReleases returns the newest revision of every Helm release,
+// sorted by namespace and name.
+func latestHelmReleases(ctx context.Context, client kubernetes.Interface) ([]HelmRelease, error) {
+	secrets, err := client.CoreV1().Secrets("").List(ctx, metav1.ListOptions{LabelSelector: "owner=helm"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Helm secrets: %w", err)
+	}
+	latest := make(map[string]HelmRelease)
+	for i := range secrets.Items {
+		release := ParseHelmSecret(&secrets.Items[i])
+		if release == nil {
+			continue
+		}
+		key := release.Namespace + "/" + release.Name
+		if existing, ok := latest[key]; !ok || release.Revision > existing.Revision {
+			latest[key] = *release
+		}
+	}
+	releases := make([]HelmRelease, 0, len(latest))
+	for _, r := range latest {
+		releases = append(releases, r)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Namespace+"/"+releases[i].Name < releases[j].Namespace+"/"+releases[j].Name
+	})
+	return releases, nil
+}
+
+// lastAppliedAnnotation holds the object kubectl apply last sent.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// removedAPIUses returns a row per object that is written through, or per
+// Helm release manifest that declares, an API removed after the cluster's
+// minor version up to the target's. Objects are read through the
+// replacement API, and those whose managedFields or last-applied
+// configuration were written through the removed version are reported;
+// kinds removed without a replacement are reported whenever they exist.
+func removedAPIUses(ctx context.Context, dynClient dynamic.Interface, releases []HelmRelease, currentMinor, targetMinor int) [][]string {
+	var apis []removedAPI
+	for _, api := range removedAPIs {
+		if api.RemovedIn > currentMinor && api.RemovedIn <= targetMinor {
+			apis = append(apis, api)
+		}
+	}
+	var rows [][]string
+	row := func(object string, api removedAPI, source string) []string {
+		replacement := api.Replacement
+		if replacement == "" {
+			replacement = messages.Get(messages.ImpactNoReplacement)
+		}
+		return []string{object, api.GroupVersion, fmt.Sprintf("1.%d", api.RemovedIn), replacement, source}
+	}
+
+	for _, api := range apis {
+		listVersion := api.Replacement
+		if listVersion == "" {
+			listVersion = api.GroupVersion
+		}
+		gv, err := schema.ParseGroupVersion(listVersion)
+		if err != nil {
+			continue
+		}
+		list, err := dynClient.Resource(gv.WithResource(api.Resource)).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			// The cluster does not serve the kind at all.
+			continue
+		}
+		for _, obj := range list.Items {
+			var sources []string
+			if api.Replacement == "" {
+				sources = append(sources, "cluster")
+			}
+			for _, entry := range obj.GetManagedFields() {
+				if entry.APIVersion == api.GroupVersion {
+					sources = append(sources, "managedFields ("+entry.Manager+")")
+				}
+			}
+			var applied struct {
+				APIVersion string `json:"apiVersion"`
+			}
+			if last := obj.GetAnnotations()[lastAppliedAnnotation]; last != "" && json.Unmarshal([]byte(last), &applied) == nil && applied.APIVersion == api.GroupVersion {
+				sources = append(sources, "kubectl apply")
+			}
+			if len(sources) > 0 {
+				rows = append(rows, row(objectName(api.Kind, obj.GetNamespace(), obj.GetName()), api, strings.Join(sources, ", ")))
+			}
+		}
+	}
+
+	for _, release := range releases {
+		for _, doc := range manifestObjects(release.Manifest) {
+			for _, api := range apis {
+				if doc.APIVersion == api.GroupVersion && doc.Kind == api.Kind {
+					rows = append(rows, row(objectName(api.Kind, doc.Metadata.Namespace, doc.Metadata.Name), api, "Helm release "+release.Namespace+"/"+release.Name))
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// manifestObject is the identity of an object in a rendered manifest.
+type manifestObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// manifestObjects returns the objects of a multi-document YAML manifest,
+// skipping documents that do not parse.
+func manifestObjects(manifest string) []manifestObject {
+	var objects []manifestObject
+	for _, doc := range strings.Split("\n"+manifest, "\n---") {
+		var obj manifestObject
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.Kind == "" {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects
+}
+
+func objectName(kind, namespace, name string) string {
+	if namespace == "" {
+		return kind + " " + name
+	}
+	return kind + " " + namespace + "/" + name
+}
+
+// drainOutages returns a row per Deployment that no PodDisruptionBudget
+// covers and whose ready pods all run on one node, so draining that node
+// leaves it without ready replicas until the pods start elsewhere.
+func drainOutages(ctx context.Context, client kubernetes.Interface, pods []corev1.Pod) ([][]string, error) {
+	deployments, err := client.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Deployments: %w", err)
+	}
+	pdbs, err := client.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+
+	var rows [][]string
+	for _, d := range deployments.Items {
+		if deploymentReplicas(d) == 0 || coveredByPDB(d, pdbs.Items) {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(d.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		nodes := make(map[string]bool)
+		for _, pod := range pods {
+			if pod.Namespace == d.Namespace && pod.Spec.NodeName != "" && pod.DeletionTimestamp == nil &&
+				pod.Status.Phase == corev1.PodRunning && selector.Matches(labels.Set(pod.Labels)) {
+				nodes[pod.Spec.NodeName] = true
+			}
+		}
+		if len(nodes) != 1 {
+			continue
+		}
+		var node string
+		for n := range nodes {
+			node = n
+		}
+		rows = append(rows, []string{d.Namespace + "/" + d.Name, strconv.Itoa(int(deploymentReplicas(d))), node})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	return rows, nil
+}
+
+func deploymentReplicas(d appsv1.Deployment) int32 {
+	if d.Spec.Replicas == nil {
+		return 1
+	}
+	return *d.Spec.Replicas
+}
+
+// coveredByPDB reports whether a PodDisruptionBudget in the Deployment's
+// namespace selects its pods.
+func coveredByPDB(d appsv1.Deployment, pdbs []policyv1.PodDisruptionBudget) bool {
+	for _, pdb := range pdbs {
+		if pdb.Namespace != d.Namespace || pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err == nil && selector.Matches(labels.Set(d.Spec.Template.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// strandedPods returns a row per pod, other than DaemonSet and static pods,
+// whose node selector and required node affinity match fewer than two
+// nodes, so there is nowhere to run it while its node is drained.
+func strandedPods(pods []corev1.Pod, nodes []corev1.Node) [][]string {
+	var rows [][]string
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if _, static := pod.Annotations[corev1.MirrorPodAnnotationKey]; static || ownedByDaemonSet(pod) {
+			continue
+		}
+		constraint := nodeConstraint(pod)
+		if constraint == "" {
+			continue
+		}
+		var matching []string
+		for _, node := range nodes {
+			if nodeMatches(pod, node) {
+				matching = append(matching, node.Name)
+			}
+		}
+		if len(matching) >= 2 {
+			continue
+		}
+		matched := strings.Join(matching, ", ")
+		if matched == "" {
+			matched = "none"
+		}
+		rows = append(rows, []string{pod.Namespace + "/" + pod.Name, constraint, matched})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	return rows
+}
+
+func ownedByDaemonSet(pod corev1.Pod) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
+
+// requiredNodeTerms returns the pod's required node affinity terms.
+func requiredNodeTerms(pod corev1.Pod) []corev1.NodeSelectorTerm {
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil || pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return nil
+	}
+	return pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+}
+
+// nodeConstraint describes the pod's node selector and required node
+// affinity, or returns "" if it has neither.
+func nodeConstraint(pod corev1.Pod) string {
+	var parts []string
+	keys := make([]string, 0, len(pod.Spec.NodeSelector))
+	for k := range pod.Spec.NodeSelector {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		parts = append(parts, k+"="+pod.Spec.NodeSelector[k])
+	}
+	var terms []string
+	for _, term := range requiredNodeTerms(pod) {
+		var reqs []string
+		for _, req := range append(append([]corev1.NodeSelectorRequirement{}, term.MatchExpressions...), term.MatchFields...) {
+			r := req.Key + " " + string(req.Operator)
+			if len(req.Values) > 0 {
+				r += " (" + strings.Join(req.Values, ", ") + ")"
+			}
+			reqs = append(reqs, r)
+		}
+		terms = append(terms, strings.Join(reqs, " and "))
+	}
+	if len(terms) > 0 {
+		parts = append(parts, strings.Join(terms, " or "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// nodeMatches reports whether the pod's node selector and required node
+// affinity allow node. Terms are ORed and the requirements of a term ANDed,
+// as the scheduler does.
+func nodeMatches(pod corev1.Pod, node corev1.Node) bool {
+	if !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	terms := requiredNodeTerms(pod)
+	if len(terms) == 0 {
+		return true
+	}
+	for _, term := range terms {
+		if termMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+func termMatches(term corev1.NodeSelectorTerm, node corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		r, err := labels.NewRequirement(req.Key, selection.Operator(strings.ToLower(string(req.Operator))), req.Values)
+		if err != nil || !r.Matches(labels.Set(node.Labels)) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		if req.Key != "metadata.name" {
+			return false
+		}
+		in := false
+		for _, v := range req.Values {
+			in = in || v == node.Name
+		}
+		if (req.Operator == corev1.NodeSelectorOpIn) != in {
+			return false
+		}
+	}
+	return true
+}
+
+// unsupportedOperators returns a row per third-party operator that does not
+// state support for the target: OLM operators whose maxOpenShiftVersion is
+// missing or older than an OpenShift target, and Helm charts installing
+// operators whose kubeVersion is missing or excludes the Kubernetes target.
+// OLM declares no maximum Kubernetes version, so OLM operators on other
+// clusters have no support statement.
+func unsupportedOperators(ctx context.Context, dynClient dynamic.Interface, releases []HelmRelease, kubeMinor int, openShift bool, target string) [][]string {
+	var rows [][]string
+	noStatement := messages.Get(messages.ImpactNoStatement)
+
+	if list, err := dynClient.Resource(csvGVR).List(ctx, metav1.ListOptions{}); err == nil {
+		for _, csv := range list.Items {
+			if _, copied := csv.GetLabels()["olm.copiedFrom"]; copied {
+				continue
+			}
+			provider, _, _ := unstructured.NestedString(csv.Object, "spec", "provider", "name")
+			if strings.Contains(strings.ToLower(provider), "red hat") {
+				continue
+			}
+			version, _, _ := unstructured.NestedString(csv.Object, "spec", "version")
+			support := noStatement
+			if openShift {
+				maxVersion := maxOpenShiftVersion(csv.GetAnnotations()["olm.properties"])
+				if maxVersion != "" {
+					_, maxMinor, ok := minorOf(maxVersion)
+					if ok && maxMinor+openShiftKubeOffset >= kubeMinor {
+						continue
+					}
+					support = messages.Sprintf(messages.ImpactUnsupported, "maxOpenShiftVersion "+maxVersion)
+				}
+			}
+			rows = append(rows, []string{csv.GetNamespace() + "/" + csv.GetName(), "OLM", version, support})
+		}
+	}
+
+	for _, release := range releases {
+		if !installsOperator(release) {
+			continue
+		}
+		support := noStatement
+		if release.KubeVersion != "" {
+			allowed, err := kubeVersionAllows(release.KubeVersion, 1, kubeMinor)
+			if err == nil && allowed {
+				continue
+			}
+			support = messages.Sprintf(messages.ImpactUnsupported, "kubeVersion "+release.KubeVersion)
+		}
+		rows = append(rows, []string{release.Namespace + "/" + release.Name, "Helm chart " + release.Chart, release.Version, support})
+	}
+	return rows
+}
+
+// maxOpenShiftVersion returns the olm.maxOpenShiftVersion property of a
+// CSV's olm.properties annotation.
+func maxOpenShiftVersion(properties string) string {
+	var props []struct {
+		Type  string      `json:"type"`
+		Value interface{} `json:"value"`
+	}
+	if json.Unmarshal([]byte(properties), &props) != nil {
+		return ""
+	}
+	for _, p := range props {
+		if p.Type == "olm.maxOpenShiftVersion" {
+			return strings.Trim(fmt.Sprint(p.Value), `"`)
+		}
+	}
+	return ""
+}
+
+// installsOperator reports whether a Helm release installs an operator:
+// its chart is named as one or it defines CustomResourceDefinitions.
+func installsOperator(release HelmRelease) bool {
+	if strings.Contains(strings.ToLower(release.Chart), "operator") {
+		return true
+	}
+	for _, obj := range manifestObjects(release.Manifest) {
+		if obj.Kind == "CustomResourceDefinition" {
+			return true
+		}
+	}
+	return false
+}
+
+// kubeVersionAllows reports whether a Helm chart's kubeVersion constraint,
+// such as ">=1.25.0-0 <1.31.0-0" or "^1.28 || ~2.0", allows major.minor.0.
+// Constraints separated by || are alternatives; within one, comma or space
+// separated comparisons must all hold. Pre-release suffixes are ignored.
+func kubeVersionAllows(constraint string, major, minor int) (bool, error) {
+	target := [3]int{major, minor, 0}
+	for _, alternative := range strings.Split(constraint, "||") {
+		fields := strings.Fields(strings.ReplaceAll(alternative, ",", " "))
+		// Join operators written apart from their version, as in ">= 1.25".
+		var comparisons []string
+		for i := 0; i < len(fields); i++ {
+			if strings.Trim(fields[i], "<>=!~^") == "" && i+1 < len(fields) {
+				fields[i+1] = fields[i] + fields[i+1]
+				continue
+			}
+			comparisons = append(comparisons, fields[i])
+		}
+		if len(comparisons) == 0 {
+			continue
+		}
+		allowed := true
+		for _, c := range comparisons {
+			ok, err := compareVersion(c, target)
+			if err != nil {
+				return false, fmt.Errorf("invalid kubeVersion %q: %w", constraint, err)
+			}
+			allowed = allowed && ok
+		}
+		if allowed {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// compareVersion evaluates one comparison, such as ">=1.25.0-0", "~1.28" or
+// "1.29.x", against target.
+func compareVersion(comparison string, target [3]int) (bool, error) {
+	op := comparison[:len(comparison)-len(strings.TrimLeft(comparison, "<>=!~^"))]
+	raw := strings.TrimPrefix(strings.TrimLeft(comparison, "<>=!~^"), "v")
+	if i := strings.IndexAny(raw, "-+"); i >= 0 {
+		raw = raw[:i]
+	}
+	var v [3]int
+	parts := strings.Split(raw, ".")
+	given := 0
+	for i, p := range parts {
+		if i == 3 {
+			return false, fmt.Errorf("%q is not a version", comparison)
+		}
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return false, fmt.Errorf("%q is not a version", comparison)
+		}
+		v[i] = n
+		given++
+	}
+	if given == 0 {
+		// A wildcard allows every version.
+		return true, nil
+	}
+	cmp := compareTriples(target, v)
+	// upper is the first version past a partial version or a ~ or ^
+	// range.
+	upper := func(level int) [3]int {
+		u := v
+		u[level]++
+		for i := level + 1; i < 3; i++ {
+			u[i] = 0
+		}
+		return u
+	}
+	switch op {
+	case "", "=", "==":
+		if given == 3 {
+			return cmp == 0, nil
+		}
+		return cmp >= 0 && compareTriples(target, upper(given-1)) < 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case "~":
+		level := 1
+		if given < 2 {
+			level = 0
+		}
+		return cmp >= 0 && compareTriples(target, upper(level)) < 0, nil
+	case "^":
+		return cmp >= 0 && compareTriples(target, upper(0)) < 0, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
+
+func compareTriples(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
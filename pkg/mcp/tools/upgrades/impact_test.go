@@ -0,0 +1,210 @@
+package upgrades
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// impactDynamicClient returns a dynamic client serving every API the impact
+// simulation reads, holding objs.
+func impactDynamicClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{csvGVR: "ClusterServiceVersionList"}
+	for _, api := range removedAPIs {
+		for _, gv := range []string{api.GroupVersion, api.Replacement} {
+			if parsed, err := schema.ParseGroupVersion(gv); err == nil && gv != "" {
+				listKinds[parsed.WithResource(api.Resource)] = api.Kind + "List"
+			}
+		}
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objs...)
+	client.PrependReactor("get", "clusterversions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("not found")
+	})
+	return client
+}
+
+func unstructuredObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func runningPod(namespace, name, node string, labels map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func deployment(namespace, name string, replicas int32, labels map[string]string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+		},
+	}
+}
+
+func TestSimulateUpgradeImpact(t *testing.T) {
+	cronJob := unstructuredObject("batch/v1", "CronJob", "shop", "nightly")
+	cronJob.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{Manager: "kubectl-client-side-apply", APIVersion: "batch/v1beta1"},
+		{Manager: "kube-controller-manager", APIVersion: "batch/v1"},
+	})
+	modernCronJob := unstructuredObject("batch/v1", "CronJob", "shop", "hourly")
+	modernCronJob.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl", APIVersion: "batch/v1"}})
+	pdb := unstructuredObject("policy/v1", "PodDisruptionBudget", "shop", "web")
+	pdb.SetAnnotations(map[string]string{lastAppliedAnnotation: `{"apiVersion":"policy/v1beta1","kind":"PodDisruptionBudget"}`})
+	psp := unstructuredObject("policy/v1beta1", "PodSecurityPolicy", "", "restricted")
+	csv := unstructuredObject("operators.coreos.com/v1alpha1", "ClusterServiceVersion", "operators", "strimzi.v0.40.0")
+	_ = unstructured.SetNestedField(csv.Object, "Strimzi", "spec", "provider", "name")
+	_ = unstructured.SetNestedField(csv.Object, "0.40.0", "spec", "version")
+	redHat := unstructuredObject("operators.coreos.com/v1alpha1", "ClusterServiceVersion", "operators", "rhacm.v2.10.0")
+	_ = unstructured.SetNestedField(redHat.Object, "Red Hat", "spec", "provider", "name")
+	dynClient := impactDynamicClient(cronJob, modernCronJob, pdb, psp, csv, redHat)
+
+	hpaRelease := helmSecretFor("web", "shop", 2, map[string]interface{}{
+		"name": "web", "version": float64(2),
+		"chart":    map[string]interface{}{"metadata": map[string]interface{}{"name": "web", "version": "1.0.0"}},
+		"manifest": "---\napiVersion: v1\nkind: Service\nmetadata:\n  name: web\n---\napiVersion: autoscaling/v2beta1\nkind: HorizontalPodAutoscaler\nmetadata:\n  name: web\n",
+	})
+	oldOperator := helmSecretFor("redis", "data", 1, map[string]interface{}{
+		"name": "redis", "version": float64(1),
+		"chart": map[string]interface{}{"metadata": map[string]interface{}{"name": "redis-operator", "version": "0.15.0", "kubeVersion": ">= 1.19.0-0, < 1.25.0-0"}},
+	})
+	unstatedOperator := helmSecretFor("cert-manager", "cert-manager", 1, map[string]interface{}{
+		"name": "cert-manager", "version": float64(1),
+		"chart":    map[string]interface{}{"metadata": map[string]interface{}{"name": "cert-manager", "version": "1.14.0"}},
+		"manifest": "apiVersion: apiextensions.k8s.io/v1\nkind: CustomResourceDefinition\nmetadata:\n  name: certificates.cert-manager.io\n",
+	})
+	supportedOperator := helmSecretFor("argo", "argo", 1, map[string]interface{}{
+		"name": "argo", "version": float64(1),
+		"chart": map[string]interface{}{"metadata": map[string]interface{}{"name": "argo-operator", "version": "2.0.0", "kubeVersion": ">=1.23.0-0"}},
+	})
+
+	nodeA := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"kubernetes.io/hostname": "node-a", "zone": "a"}}}
+	nodeB := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"kubernetes.io/hostname": "node-b", "zone": "b"}}}
+	pinned := runningPod("shop", "pinned", "node-a", nil)
+	pinned.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": "node-a"}
+	zoned := runningPod("shop", "zoned", "node-b", nil)
+	zoned.Spec.Affinity = &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a", "b"}}},
+		}}},
+	}}
+	agent := runningPod("kube-system", "agent-x", "node-a", nil)
+	agent.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": "node-a"}
+	agent.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent"}}
+
+	webPDB := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+	}
+	client := newFakeClientWithVersion("v1.24.17",
+		hpaRelease, oldOperator, unstatedOperator, supportedOperator,
+		nodeA, nodeB, pinned, zoned, agent, webPDB,
+		deployment("shop", "api", 2, map[string]string{"app": "api"}),
+		runningPod("shop", "api-1", "node-a", map[string]string{"app": "api"}),
+		runningPod("shop", "api-2", "node-a", map[string]string{"app": "api"}),
+		deployment("shop", "web", 1, map[string]string{"app": "web"}),
+		runningPod("shop", "web-1", "node-b", map[string]string{"app": "web"}),
+		deployment("shop", "spread", 2, map[string]string{"app": "spread"}),
+		runningPod("shop", "spread-1", "node-a", map[string]string{"app": "spread"}),
+		runningPod("shop", "spread-2", "node-b", map[string]string{"app": "spread"}),
+	)
+
+	ca := &mockClusterAccess{client: client, dynClient: dynClient}
+	result, isErr := SimulateUpgradeImpact(context.Background(), ca, map[string]interface{}{"target_version": "1.25"})
+	require.False(t, isErr, result)
+
+	assert.Contains(t, result, "# Upgrade Impact: v1.24.17 to 1.25")
+	assert.Contains(t, result, "| CronJob shop/nightly | batch/v1beta1 | 1.25 | batch/v1 | managedFields (kubectl-client-side-apply) |")
+	assert.NotContains(t, result, "hourly")
+	assert.Contains(t, result, "| PodDisruptionBudget shop/web | policy/v1beta1 | 1.25 | policy/v1 | kubectl apply |")
+	assert.Contains(t, result, "| PodSecurityPolicy restricted | policy/v1beta1 | 1.25 | none; remove it | cluster |")
+	assert.Contains(t, result, "| HorizontalPodAutoscaler web | autoscaling/v2beta1 | 1.25 | autoscaling/v2 | Helm release shop/web |")
+
+	assert.Contains(t, result, "| shop/api | 2 | node-a |")
+	assert.NotContains(t, result, "| shop/web | 1 |")
+	assert.NotContains(t, result, "shop/spread")
+
+	assert.Contains(t, result, "| shop/pinned | kubernetes.io/hostname=node-a | node-a |")
+	assert.NotContains(t, result, "shop/zoned")
+	assert.NotContains(t, result, "agent-x")
+
+	assert.Contains(t, result, "| operators/strimzi.v0.40.0 | OLM | 0.40.0 | ⚠️ No support statement |")
+	assert.NotContains(t, result, "rhacm")
+	assert.Contains(t, result, "| data/redis | Helm chart redis-operator | 0.15.0 | ❌ Not supported (kubeVersion >= 1.19.0-0, < 1.25.0-0) |")
+	assert.Contains(t, result, "| cert-manager/cert-manager | Helm chart cert-manager | 1.14.0 | ⚠️ No support statement |")
+	assert.NotContains(t, result, "argo")
+
+	assert.Contains(t, result, "**Summary:** 4 removed API uses, 1 Deployments at risk during drains, 1 pods that may be stranded, 3 operators without support for 1.25")
+}
+
+func TestSimulateUpgradeImpact_InvalidTarget(t *testing.T) {
+	ca := &mockClusterAccess{client: newFakeClientWithVersion("v1.29.2"), dynClient: impactDynamicClient()}
+	for target, want := range map[string]string{
+		"":       "invalid target_version",
+		"latest": "invalid target_version",
+		"1.28":   "older than the cluster's version v1.29.2",
+		"4.16":   "is an OpenShift version, but the cluster is not OpenShift",
+	} {
+		result, isErr := SimulateUpgradeImpact(context.Background(), ca, map[string]interface{}{"target_version": target})
+		assert.True(t, isErr, target)
+		assert.Contains(t, result, want, target)
+	}
+}
+
+func TestSimulateUpgradeImpact_NothingAffected(t *testing.T) {
+	ca := &mockClusterAccess{client: newFakeClientWithVersion("v1.33.1"), dynClient: impactDynamicClient()}
+	result, isErr := SimulateUpgradeImpact(context.Background(), ca, map[string]interface{}{"target_version": "v1.34.0"})
+	require.False(t, isErr, result)
+	assert.Contains(t, result, "No objects or Helm releases use APIs removed by 1.34")
+	assert.Contains(t, result, "Every Deployment keeps ready replicas while a node drains")
+	assert.Contains(t, result, "No pods are limited to a single node")
+	assert.Contains(t, result, "Every third-party operator states support for 1.34")
+}
+
+func TestKubeVersionAllows(t *testing.T) {
+	for _, tt := range []struct {
+		constraint string
+		minor      int
+		want       bool
+	}{
+		{">=1.25.0-0", 32, true},
+		{">= 1.19.0-0, < 1.25.0-0", 25, false},
+		{">= 1.19.0-0, < 1.25.0-0", 24, true},
+		{">=1.21.0-0 <1.31.0-0", 30, true},
+		{"^1.28", 34, true},
+		{"~1.28", 29, false},
+		{"1.29.x", 29, true},
+		{"1.29", 30, false},
+		{"<1.20 || >=1.30", 32, true},
+		{"*", 40, true},
+	} {
+		got, err := kubeVersionAllows(tt.constraint, 1, tt.minor)
+		require.NoError(t, err, tt.constraint)
+		assert.Equal(t, tt.want, got, "%s allows 1.%d", tt.constraint, tt.minor)
+	}
+	_, err := kubeVersionAllows(">=one", 1, 30)
+	assert.Error(t, err)
+}
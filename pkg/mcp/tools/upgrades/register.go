@@ -10,6 +10,8 @@ import (
 type ToolDef struct {
 	Schema  protocol.Tool
 	Handler func(ctx context.Context, ca ClusterAccess, args map[string]interface{}) (string, bool)
+	// Mutating marks tools that change cluster state.
+	Mutating bool
 }
 
 // Tools returns all upgrade tool definitions. The caller is responsible for
@@ -104,6 +106,27 @@ func Tools() []ToolDef {
 			},
 			Handler: GetUpgradePrerequisites,
 		},
+		{
+			Schema: protocol.Tool{
+				Name:        "simulate_upgrade_impact",
+				Description: "Simulate upgrading a cluster to a Kubernetes or OpenShift version: list objects and Helm releases using APIs the version removes, Deployments without a PodDisruptionBudget that drop to zero replicas during node drains, pods whose node affinity leaves them nowhere to run, and third-party operators without a support statement for the version",
+				InputSchema: protocol.InputSchema{
+					Type: "object",
+					Properties: map[string]protocol.Property{
+						"cluster": {
+							Type:        "string",
+							Description: "Cluster name (uses current context if not specified)",
+						},
+						"target_version": {
+							Type:        "string",
+							Description: "Kubernetes (e.g., 1.32) or, on OpenShift, OpenShift (e.g., 4.16) version to upgrade to",
+						},
+					},
+					Required: []string{"target_version"},
+				},
+			},
+			Handler: SimulateUpgradeImpact,
+		},
 		{
 			Schema: protocol.Tool{
 				Name:        "trigger_openshift_upgrade",
@@ -123,11 +146,68 @@ func Tools() []ToolDef {
 							Type:        "string",
 							Description: "Must be 'yes-upgrade-now' to proceed with the upgrade",
 						},
+						"staged": {
+							Type:        "boolean",
+							Description: "Pause the worker MachineConfigPools, upgrade the control plane first, then unpause the worker pools in batches, each gated on the previous batch updating healthily. Calling again with the same target_version resumes a stopped staged upgrade",
+						},
+						"batch_size": {
+							Type:        "integer",
+							Description: "Worker pools unpaused per batch of a staged upgrade (default: 1)",
+						},
+						"health_timeout_minutes": {
+							Type:        "integer",
+							Description: "Minutes each batch of a staged upgrade may take to update before the upgrade stops (default: 60)",
+						},
 					},
 					Required: []string{"target_version", "confirm"},
 				},
 			},
-			Handler: TriggerOpenShiftUpgrade,
+			Handler:  TriggerOpenShiftUpgrade,
+			Mutating: true,
+		},
+		{
+			Schema: protocol.Tool{
+				Name:        "pause_mcp",
+				Description: "Pause an OpenShift MachineConfigPool so its machines keep their current configuration, holding back updates such as upgrades of its nodes",
+				InputSchema: protocol.InputSchema{
+					Type: "object",
+					Properties: map[string]protocol.Property{
+						"cluster": {
+							Type:        "string",
+							Description: "Cluster name (uses current context if not specified)",
+						},
+						"pool": {
+							Type:        "string",
+							Description: "Name of the MachineConfigPool (e.g., worker)",
+						},
+					},
+					Required: []string{"pool"},
+				},
+			},
+			Handler:  PauseMCP,
+			Mutating: true,
+		},
+		{
+			Schema: protocol.Tool{
+				Name:        "unpause_mcp",
+				Description: "Unpause an OpenShift MachineConfigPool so its machines update to its current configuration, taking it out of any staged upgrade",
+				InputSchema: protocol.InputSchema{
+					Type: "object",
+					Properties: map[string]protocol.Property{
+						"cluster": {
+							Type:        "string",
+							Description: "Cluster name (uses current context if not specified)",
+						},
+						"pool": {
+							Type:        "string",
+							Description: "Name of the MachineConfigPool (e.g., worker)",
+						},
+					},
+					Required: []string{"pool"},
+				},
+			},
+			Handler:  UnpauseMCP,
+			Mutating: true,
 		},
 		{
 			Schema: protocol.Tool{
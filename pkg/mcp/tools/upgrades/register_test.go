@@ -14,7 +14,10 @@ func TestUpgradesToolRegistry_AllToolsRegistered(t *testing.T) {
 		"check_olm_operator_upgrades",
 		"check_helm_release_upgrades",
 		"get_upgrade_prerequisites",
+		"simulate_upgrade_impact",
 		"trigger_openshift_upgrade",
+		"pause_mcp",
+		"unpause_mcp",
 		"get_upgrade_status",
 	}
 
@@ -34,7 +37,7 @@ func TestUpgradesToolRegistry_AllToolsRegistered(t *testing.T) {
 }
 
 func TestUpgradesToolRegistry_ToolCount(t *testing.T) {
-	expectedCount := 7
+	expectedCount := 10
 	tools := Tools()
 	assert.Equal(t, expectedCount, len(tools), "Upgrades registry should have exactly %d tools", expectedCount)
 }
@@ -42,6 +45,9 @@ func TestUpgradesToolRegistry_ToolCount(t *testing.T) {
 func TestUpgradesToolRegistry_RequiredFields(t *testing.T) {
 	requiredFields := map[string][]string{
 		"trigger_openshift_upgrade": {"target_version", "confirm"},
+		"simulate_upgrade_impact":   {"target_version"},
+		"pause_mcp":                 {"pool"},
+		"unpause_mcp":               {"pool"},
 	}
 
 	tools := Tools()
@@ -76,6 +82,16 @@ func TestUpgradesToolRegistry_ConfirmationGating(t *testing.T) {
 	assert.Contains(t, tool.Schema.Description, "REQUIRES CONFIRMATION", "tool description should indicate confirmation requirement")
 }
 
+func TestUpgradesToolRegistry_MutatingTools(t *testing.T) {
+	var mutating []string
+	for _, td := range Tools() {
+		if td.Mutating {
+			mutating = append(mutating, td.Schema.Name)
+		}
+	}
+	assert.ElementsMatch(t, []string{"trigger_openshift_upgrade", "pause_mcp", "unpause_mcp"}, mutating)
+}
+
 func TestUpgradesToolRegistry_AllHandlersExist(t *testing.T) {
 	tools := Tools()
 	for _, td := range tools {
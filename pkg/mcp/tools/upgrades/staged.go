@@ -0,0 +1,452 @@
+package upgrades
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+)
+
+// Annotations recording a staged upgrade on the worker pools it holds, so
+// the upgrade can be followed, and resumed, from the cluster alone.
+const (
+	// stagedUpgradeAnnotation holds the target version on each worker pool
+	// the upgrade paused and has not yet finished updating.
+	stagedUpgradeAnnotation = "kubestellar.io/staged-upgrade"
+	// stagedResumedAnnotation is when the pool's batch was unpaused.
+	stagedResumedAnnotation = "kubestellar.io/staged-upgrade-resumed"
+	// stagedStoppedAnnotation is why the upgrade stopped with the pool
+	// still held.
+	stagedStoppedAnnotation = "kubestellar.io/staged-upgrade-stopped"
+)
+
+// masterPool is the MachineConfigPool of the control plane nodes.
+const masterPool = "master"
+
+const (
+	defaultStagedBatchSize     = 1
+	defaultPoolHealthTimeout   = 60 * time.Minute
+	stagedControlPlaneDeadline = 4 * time.Hour
+)
+
+// stagedPollInterval is how often a staged upgrade checks its progress.
+const stagedPollInterval = 30 * time.Second
+
+// startStagedUpgrade follows a staged upgrade in the background. The
+// upgrade outlives the call that started it, and its later writes belong
+// to no journaled change. Tests replace it.
+var startStagedUpgrade = func(dynClient dynamic.Interface, plan stagedPlan) {
+	go runStagedUpgrade(context.Background(), dynClient, plan)
+}
+
+// PauseMCP pauses a MachineConfigPool so its machines keep their current
+// configuration.
+func PauseMCP(ctx context.Context, ca ClusterAccess, args map[string]interface{}) (string, bool) {
+	return setPoolPaused(ctx, ca, args, true)
+}
+
+// UnpauseMCP unpauses a MachineConfigPool, taking it out of any staged
+// upgrade holding it.
+func UnpauseMCP(ctx context.Context, ca ClusterAccess, args map[string]interface{}) (string, bool) {
+	return setPoolPaused(ctx, ca, args, false)
+}
+
+func setPoolPaused(ctx context.Context, ca ClusterAccess, args map[string]interface{}, paused bool) (string, bool) {
+	cluster, _ := args["cluster"].(string)
+	name, _ := args["pool"].(string)
+	if name == "" {
+		return "pool is required", true
+	}
+
+	dynClient, err := ca.GetDynamicClientForCluster(cluster)
+	if err != nil {
+		return fmt.Sprintf("Failed to create client: %v", err), true
+	}
+	pool, err := dynClient.Resource(machineConfigPoolGVR).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Sprintf("Failed to get MachineConfigPool %s: %v", name, err), true
+	}
+
+	wasPaused, _, _ := unstructured.NestedBool(pool.Object, "spec", "paused")
+	stagedTarget := pool.GetAnnotations()[stagedUpgradeAnnotation]
+	if wasPaused == paused && (paused || stagedTarget == "") {
+		if paused {
+			return messages.Sprintf(messages.PoolAlreadyPaused, name), false
+		}
+		return messages.Sprintf(messages.PoolNotPaused, name), false
+	}
+
+	spec := map[string]interface{}{"paused": paused}
+	patch := map[string]interface{}{"spec": spec}
+	if !paused {
+		// Unpausing by hand finishes the pool's part of a staged upgrade.
+		patch["metadata"] = map[string]interface{}{"annotations": map[string]interface{}{
+			stagedUpgradeAnnotation: nil,
+			stagedResumedAnnotation: nil,
+			stagedStoppedAnnotation: nil,
+		}}
+	}
+	if err := patchPool(ctx, dynClient, name, patch); err != nil {
+		return err.Error(), true
+	}
+
+	var sb strings.Builder
+	if approval.IsDryRun(ctx) {
+		sb.WriteString(messages.Get(messages.PoolDryRun))
+	}
+	if paused {
+		sb.WriteString(messages.Sprintf(messages.PoolPaused, name))
+		return sb.String(), false
+	}
+	sb.WriteString(messages.Sprintf(messages.PoolUnpaused, name))
+	machines, _, _ := unstructured.NestedInt64(pool.Object, "status", "machineCount")
+	updated, _, _ := unstructured.NestedInt64(pool.Object, "status", "updatedMachineCount")
+	if updated < machines {
+		sb.WriteString(messages.Sprintf(messages.PoolPendingMachines, machines-updated, machines))
+	}
+	if stagedTarget != "" {
+		sb.WriteString(messages.Sprintf(messages.PoolLeftStaged, stagedTarget))
+	}
+	return sb.String(), false
+}
+
+func patchPool(ctx context.Context, dynClient dynamic.Interface, name string, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return fmt.Errorf("failed to build patch for MachineConfigPool %s: %w", name, err)
+	}
+	if _, err := dynClient.Resource(machineConfigPoolGVR).Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to update MachineConfigPool %s: %w", name, err)
+	}
+	return nil
+}
+
+// stagedPlan is how a staged upgrade resumes its worker pools.
+type stagedPlan struct {
+	target        string
+	batchSize     int
+	healthTimeout time.Duration
+	started       time.Time
+}
+
+// stagedPlanFromArgs reads the staged upgrade options of
+// trigger_openshift_upgrade.
+func stagedPlanFromArgs(target string, args map[string]interface{}) (stagedPlan, error) {
+	plan := stagedPlan{target: target, batchSize: defaultStagedBatchSize, healthTimeout: defaultPoolHealthTimeout}
+	if v, ok := args["batch_size"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 1 || n != float64(int(n)) {
+			return plan, fmt.Errorf("batch_size must be a positive integer")
+		}
+		plan.batchSize = int(n)
+	}
+	if v, ok := args["health_timeout_minutes"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 1 || n != float64(int(n)) {
+			return plan, fmt.Errorf("health_timeout_minutes must be a positive integer")
+		}
+		plan.healthTimeout = time.Duration(n) * time.Minute
+	}
+	return plan, nil
+}
+
+// holdWorkerPools pauses the worker pools for a staged upgrade to target
+// and records the upgrade on them. Pools already held by a staged upgrade
+// are taken over, so calling it again resumes a stopped upgrade. It
+// returns the pools held and the worker pools someone else paused, which
+// are left alone.
+func holdWorkerPools(ctx context.Context, dynClient dynamic.Interface, target string) (held, leftPaused []string, err error) {
+	pools, err := dynClient.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list MachineConfigPools: %w", err)
+	}
+	for _, pool := range pools.Items {
+		name := pool.GetName()
+		if name == masterPool {
+			continue
+		}
+		paused, _, _ := unstructured.NestedBool(pool.Object, "spec", "paused")
+		_, staged := pool.GetAnnotations()[stagedUpgradeAnnotation]
+		annotations := map[string]interface{}{stagedUpgradeAnnotation: target, stagedStoppedAnnotation: nil}
+		switch {
+		case staged && !paused:
+			// The pool's batch was updating when the upgrade stopped;
+			// give it a fresh health timeout.
+			annotations[stagedResumedAnnotation] = now().UTC().Format(time.RFC3339)
+		case paused && !staged:
+			leftPaused = append(leftPaused, name)
+			continue
+		case !staged:
+			paused = true
+		}
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": annotations},
+			"spec":     map[string]interface{}{"paused": paused},
+		}
+		if err := patchPool(ctx, dynClient, name, patch); err != nil {
+			return held, leftPaused, err
+		}
+		held = append(held, name)
+	}
+	sort.Strings(held)
+	sort.Strings(leftPaused)
+	return held, leftPaused, nil
+}
+
+// runStagedUpgrade follows a staged upgrade until every held worker pool
+// has updated, stopping it with the remaining pools paused when a health
+// gate fails.
+func runStagedUpgrade(ctx context.Context, dynClient dynamic.Interface, plan stagedPlan) {
+	for {
+		done, err := plan.step(ctx, dynClient)
+		switch {
+		case err != nil:
+			log.Printf("Staged upgrade to %s stopped: %v", plan.target, err)
+			stopStagedUpgrade(ctx, dynClient, plan.target, err.Error())
+			return
+		case done:
+			log.Printf("Staged upgrade to %s finished", plan.target)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(stagedPollInterval):
+		}
+	}
+}
+
+// step advances a staged upgrade: once the control plane has upgraded, it
+// releases the pools of a finished batch and unpauses the next batch. It
+// reports whether every held pool has updated, and an error when a health
+// gate fails.
+func (p stagedPlan) step(ctx context.Context, dynClient dynamic.Interface) (bool, error) {
+	cv, err := dynClient.Resource(clusterVersionGVR).Get(ctx, "version", metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to get ClusterVersion: %w", err)
+	}
+	if desired, _, _ := unstructured.NestedString(cv.Object, "spec", "desiredUpdate", "version"); desired != p.target {
+		return false, fmt.Errorf("the ClusterVersion's desired update changed to %q", desired)
+	}
+	pools, err := dynClient.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return false, fmt.Errorf("failed to list MachineConfigPools: %w", err)
+	}
+
+	var master *unstructured.Unstructured
+	var waiting []*unstructured.Unstructured
+	updating := 0
+	for i := range pools.Items {
+		pool := &pools.Items[i]
+		if pool.GetName() == masterPool {
+			master = pool
+		}
+		if pool.GetAnnotations()[stagedUpgradeAnnotation] != p.target {
+			continue
+		}
+		if paused, _, _ := unstructured.NestedBool(pool.Object, "spec", "paused"); paused {
+			waiting = append(waiting, pool)
+			continue
+		}
+		finished, err := p.poolFinished(pool)
+		if err != nil {
+			return false, err
+		}
+		if !finished {
+			updating++
+			continue
+		}
+		if err := releasePool(ctx, dynClient, pool.GetName()); err != nil {
+			return false, err
+		}
+	}
+
+	if !controlPlaneUpgraded(cv, master, p.target) {
+		if now().Sub(p.started) > stagedControlPlaneDeadline {
+			return false, fmt.Errorf("the control plane did not finish upgrading to %s within %s", p.target, stagedControlPlaneDeadline)
+		}
+		return false, nil
+	}
+	if updating > 0 {
+		return false, nil
+	}
+	if len(waiting) == 0 {
+		return true, nil
+	}
+
+	if degraded := degradedClusterOperators(ctx, dynClient); len(degraded) > 0 {
+		return false, fmt.Errorf("ClusterOperators are degraded: %s", strings.Join(degraded, ", "))
+	}
+	sort.Slice(waiting, func(i, j int) bool { return waiting[i].GetName() < waiting[j].GetName() })
+	if len(waiting) > p.batchSize {
+		waiting = waiting[:p.batchSize]
+	}
+	resumed := now().UTC().Format(time.RFC3339)
+	for _, pool := range waiting {
+		patch := map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": map[string]interface{}{stagedResumedAnnotation: resumed}},
+			"spec":     map[string]interface{}{"paused": false},
+		}
+		if err := patchPool(ctx, dynClient, pool.GetName(), patch); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// poolFinished is the health gate of an unpaused pool: it has finished
+// once every machine is updated and ready, and fails when the pool is
+// degraded or has not finished within the health timeout.
+func (p stagedPlan) poolFinished(pool *unstructured.Unstructured) (bool, error) {
+	name := pool.GetName()
+	if poolCondition(pool, "Degraded") == "True" {
+		return false, fmt.Errorf("MachineConfigPool %s is degraded", name)
+	}
+	if poolUpdated(pool) {
+		return true, nil
+	}
+	resumed, err := time.Parse(time.RFC3339, pool.GetAnnotations()[stagedResumedAnnotation])
+	if err == nil && now().Sub(resumed) > p.healthTimeout {
+		return false, fmt.Errorf("MachineConfigPool %s did not finish updating within %s", name, p.healthTimeout)
+	}
+	return false, nil
+}
+
+// releasePool removes a pool that has finished updating from the staged
+// upgrade.
+func releasePool(ctx context.Context, dynClient dynamic.Interface, name string) error {
+	return patchPool(ctx, dynClient, name, map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": map[string]interface{}{
+			stagedUpgradeAnnotation: nil,
+			stagedResumedAnnotation: nil,
+		}},
+	})
+}
+
+// stopStagedUpgrade records why a staged upgrade stopped on the pools it
+// still holds.
+func stopStagedUpgrade(ctx context.Context, dynClient dynamic.Interface, target, reason string) {
+	pools, err := dynClient.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		log.Printf("Failed to record the stop of the staged upgrade to %s: %v", target, err)
+		return
+	}
+	for _, pool := range pools.Items {
+		if pool.GetAnnotations()[stagedUpgradeAnnotation] != target {
+			continue
+		}
+		patch := map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{stagedStoppedAnnotation: reason}}}
+		if err := patchPool(ctx, dynClient, pool.GetName(), patch); err != nil {
+			log.Printf("Failed to record the stop of the staged upgrade to %s: %v", target, err)
+		}
+	}
+}
+
+// controlPlaneUpgraded reports whether the cluster has completed the
+// upgrade to target, which paused worker pools do not hold back, and the
+// master pool has updated.
+func controlPlaneUpgraded(cv, master *unstructured.Unstructured, target string) bool {
+	history, _, _ := unstructured.NestedSlice(cv.Object, "status", "history")
+	if len(history) == 0 {
+		return false
+	}
+	latest, ok := history[0].(map[string]interface{})
+	if !ok || latest["version"] != target || latest["state"] != "Completed" {
+		return false
+	}
+	return master == nil || poolUpdated(master)
+}
+
+// poolUpdated reports whether every machine of a pool runs its current
+// configuration and is ready.
+func poolUpdated(pool *unstructured.Unstructured) bool {
+	machines, _, _ := unstructured.NestedInt64(pool.Object, "status", "machineCount")
+	updated, _, _ := unstructured.NestedInt64(pool.Object, "status", "updatedMachineCount")
+	ready, _, _ := unstructured.NestedInt64(pool.Object, "status", "readyMachineCount")
+	return poolCondition(pool, "Updated") == "True" && updated == machines && ready == machines
+}
+
+func poolCondition(pool *unstructured.Unstructured, condType string) string {
+	conditions, _, _ := unstructured.NestedSlice(pool.Object, "status", "conditions")
+	for _, cond := range conditions {
+		condMap, ok := cond.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if t, _, _ := unstructured.NestedString(condMap, "type"); t == condType {
+			status, _, _ := unstructured.NestedString(condMap, "status")
+			return status
+		}
+	}
+	return ""
+}
+
+// degradedClusterOperators returns the names of degraded ClusterOperators.
+func degradedClusterOperators(ctx context.Context, dynClient dynamic.Interface) []string {
+	cos, err := dynClient.Resource(clusterOperatorGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	var degraded []string
+	for i := range cos.Items {
+		if poolCondition(&cos.Items[i], "Degraded") == "True" {
+			degraded = append(degraded, cos.Items[i].GetName())
+		}
+	}
+	sort.Strings(degraded)
+	return degraded
+}
+
+// writeStagedUpgradeStatus adds the progress of staged upgrades holding
+// any of pools to sb.
+func writeStagedUpgradeStatus(sb *strings.Builder, pools []unstructured.Unstructured) {
+	type progress struct{ waiting, updating, stopped []string }
+	byTarget := map[string]*progress{}
+	var targets []string
+	for i := range pools {
+		pool := &pools[i]
+		target := pool.GetAnnotations()[stagedUpgradeAnnotation]
+		if target == "" {
+			continue
+		}
+		pr := byTarget[target]
+		if pr == nil {
+			pr = &progress{}
+			byTarget[target] = pr
+			targets = append(targets, target)
+		}
+		if paused, _, _ := unstructured.NestedBool(pool.Object, "spec", "paused"); paused {
+			pr.waiting = append(pr.waiting, pool.GetName())
+		} else {
+			pr.updating = append(pr.updating, pool.GetName())
+		}
+		if reason := pool.GetAnnotations()[stagedStoppedAnnotation]; reason != "" {
+			pr.stopped = append(pr.stopped, reason)
+		}
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		pr := byTarget[target]
+		sb.WriteString(messages.Sprintf(messages.StagedStatusTitle, target))
+		if len(pr.updating) > 0 {
+			sb.WriteString(messages.Sprintf(messages.StagedStatusUpdating, strings.Join(pr.updating, ", ")))
+		}
+		if len(pr.waiting) > 0 {
+			sb.WriteString(messages.Sprintf(messages.StagedStatusWaiting, strings.Join(pr.waiting, ", ")))
+		}
+		if len(pr.stopped) > 0 {
+			sb.WriteString(messages.Sprintf(messages.StagedStatusStopped, pr.stopped[0]))
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package upgrades
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+)
+
+// stagedDynamicClient returns a dynamic client serving ClusterVersions,
+// ClusterOperators, and MachineConfigPools, holding objs.
+func stagedDynamicClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		clusterVersionGVR:    "ClusterVersionList",
+		clusterOperatorGVR:   "ClusterOperatorList",
+		machineConfigPoolGVR: "MachineConfigPoolList",
+	}, objs...)
+}
+
+// workerPool builds a MachineConfigPool of machines machines, updated
+// machines of which run its current configuration.
+func workerPool(name string, machines, updated int64, paused bool, annotations map[string]string) *unstructured.Unstructured {
+	pool := makeMachineConfigPool(name, []map[string]interface{}{
+		{"type": "Updated", "status": map[bool]string{true: "True", false: "False"}[updated == machines]},
+		{"type": "Degraded", "status": "False"},
+	})
+	_ = unstructured.SetNestedField(pool.Object, paused, "spec", "paused")
+	_ = unstructured.SetNestedField(pool.Object, machines, "status", "machineCount")
+	_ = unstructured.SetNestedField(pool.Object, updated, "status", "updatedMachineCount")
+	_ = unstructured.SetNestedField(pool.Object, machines, "status", "readyMachineCount")
+	pool.SetAnnotations(annotations)
+	return pool
+}
+
+func getPool(t *testing.T, dyn *dynamicfake.FakeDynamicClient, name string) *unstructured.Unstructured {
+	t.Helper()
+	pool, err := dyn.Resource(machineConfigPoolGVR).Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return pool
+}
+
+func putObject(t *testing.T, dyn *dynamicfake.FakeDynamicClient, gvr schema.GroupVersionResource, obj *unstructured.Unstructured) {
+	t.Helper()
+	_, err := dyn.Resource(gvr).Update(context.Background(), obj, metav1.UpdateOptions{})
+	require.NoError(t, err)
+}
+
+func isPaused(t *testing.T, dyn *dynamicfake.FakeDynamicClient, name string) bool {
+	t.Helper()
+	paused, _, _ := unstructured.NestedBool(getPool(t, dyn, name).Object, "spec", "paused")
+	return paused
+}
+
+// upgradingClusterVersion is a ClusterVersion upgrading to target, in
+// history state state.
+func upgradingClusterVersion(target, state string) *unstructured.Unstructured {
+	cv := makeClusterVersion(target, "stable-4.15", "", nil, nil,
+		[]map[string]interface{}{{"version": target, "state": state}})
+	_ = unstructured.SetNestedField(cv.Object, target, "spec", "desiredUpdate", "version")
+	return cv
+}
+
+func TestPauseAndUnpauseMCP(t *testing.T) {
+	dyn := stagedDynamicClient(
+		workerPool("worker", 3, 3, false, nil),
+		workerPool("infra", 2, 0, true, map[string]string{stagedUpgradeAnnotation: "4.15.1", stagedStoppedAnnotation: "stuck"}),
+	)
+	ca := &mockClusterAccess{dynClient: dyn}
+
+	out, isErr := PauseMCP(context.Background(), ca, map[string]interface{}{"pool": "worker"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "MachineConfigPool Paused")
+	assert.Contains(t, out, "certificate rotations")
+	assert.True(t, isPaused(t, dyn, "worker"))
+
+	out, _ = PauseMCP(context.Background(), ca, map[string]interface{}{"pool": "worker"})
+	assert.Contains(t, out, "already paused")
+
+	out, isErr = UnpauseMCP(context.Background(), ca, map[string]interface{}{"pool": "infra"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "MachineConfigPool Unpaused")
+	assert.Contains(t, out, "2 of 2 machines will now update")
+	assert.Contains(t, out, "no longer held by the staged upgrade to 4.15.1")
+	infra := getPool(t, dyn, "infra")
+	assert.Empty(t, infra.GetAnnotations())
+	assert.False(t, isPaused(t, dyn, "infra"))
+
+	out, _ = UnpauseMCP(context.Background(), ca, map[string]interface{}{"pool": "infra"})
+	assert.Contains(t, out, "is not paused")
+
+	out, isErr = PauseMCP(context.Background(), ca, map[string]interface{}{})
+	assert.True(t, isErr)
+	assert.Equal(t, "pool is required", out)
+
+	out, isErr = UnpauseMCP(context.Background(), ca, map[string]interface{}{"pool": "missing"})
+	assert.True(t, isErr)
+	assert.Contains(t, out, "Failed to get MachineConfigPool missing")
+}
+
+func TestPauseMCP_DryRun(t *testing.T) {
+	dyn := stagedDynamicClient(workerPool("worker", 3, 3, false, nil))
+	out, isErr := PauseMCP(approval.WithDryRun(context.Background()), &mockClusterAccess{dynClient: dyn}, map[string]interface{}{"pool": "worker"})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "**Dry run:**")
+}
+
+func TestTriggerOpenShiftUpgrade_Staged(t *testing.T) {
+	var started []stagedPlan
+	orig := startStagedUpgrade
+	startStagedUpgrade = func(_ dynamic.Interface, plan stagedPlan) { started = append(started, plan) }
+	t.Cleanup(func() { startStagedUpgrade = orig })
+
+	cv := makeClusterVersion("4.14.7", "stable-4.15", "", nil,
+		[]map[string]interface{}{{"version": "4.15.1"}}, nil)
+	dyn := stagedDynamicClient(cv,
+		workerPool("master", 3, 3, false, nil),
+		workerPool("worker", 6, 6, false, nil),
+		workerPool("infra", 3, 3, false, nil),
+		workerPool("gpu", 2, 2, true, nil),
+	)
+	ca := &mockClusterAccess{dynClient: dyn}
+
+	out, isErr := TriggerOpenShiftUpgrade(context.Background(), ca, map[string]interface{}{
+		"target_version": "4.15.1",
+		"confirm":        "yes-upgrade-now",
+		"staged":         true,
+		"batch_size":     float64(2),
+	})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "Staged Upgrade Initiated")
+	assert.Contains(t, out, "unpaused 2 at a time")
+	assert.Contains(t, out, "**Worker pools held until the control plane upgrades:** infra, worker")
+	assert.Contains(t, out, "**Left paused:** gpu")
+
+	for _, name := range []string{"worker", "infra"} {
+		assert.True(t, isPaused(t, dyn, name), name)
+		assert.Equal(t, "4.15.1", getPool(t, dyn, name).GetAnnotations()[stagedUpgradeAnnotation], name)
+	}
+	assert.False(t, isPaused(t, dyn, "master"))
+	assert.Empty(t, getPool(t, dyn, "gpu").GetAnnotations())
+	updated, err := dyn.Resource(clusterVersionGVR).Get(context.Background(), "version", metav1.GetOptions{})
+	require.NoError(t, err)
+	desired, _, _ := unstructured.NestedString(updated.Object, "spec", "desiredUpdate", "version")
+	assert.Equal(t, "4.15.1", desired)
+	require.Len(t, started, 1)
+	assert.Equal(t, 2, started[0].batchSize)
+	assert.Equal(t, defaultPoolHealthTimeout, started[0].healthTimeout)
+
+	// Calling again resumes the upgrade, although 4.15.1 is no longer an
+	// available update.
+	unstructured.RemoveNestedField(updated.Object, "status", "availableUpdates")
+	putObject(t, dyn, clusterVersionGVR, updated)
+	out, isErr = TriggerOpenShiftUpgrade(context.Background(), ca, map[string]interface{}{
+		"target_version": "4.15.1",
+		"confirm":        "yes-upgrade-now",
+		"staged":         true,
+	})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "infra, worker")
+	assert.Len(t, started, 2)
+
+	out, isErr = TriggerOpenShiftUpgrade(context.Background(), ca, map[string]interface{}{
+		"target_version": "4.15.1",
+		"confirm":        "yes-upgrade-now",
+		"batch_size":     float64(0),
+	})
+	assert.True(t, isErr)
+	assert.Equal(t, "batch_size must be a positive integer", out)
+}
+
+func TestStagedPlanStep(t *testing.T) {
+	fixNow(t, "2026-03-01")
+	held := map[string]string{stagedUpgradeAnnotation: "4.15.1"}
+	dyn := stagedDynamicClient(
+		upgradingClusterVersion("4.15.1", "Partial"),
+		makeClusterOperator("etcd", []map[string]interface{}{{"type": "Degraded", "status": "False"}}),
+		workerPool("master", 3, 1, false, nil),
+		workerPool("worker", 6, 0, true, held),
+		workerPool("infra", 3, 0, true, held),
+	)
+	ctx := context.Background()
+	plan := stagedPlan{target: "4.15.1", batchSize: 1, healthTimeout: time.Hour, started: now()}
+
+	done, err := plan.step(ctx, dyn)
+	require.NoError(t, err)
+	assert.False(t, done)
+	assert.True(t, isPaused(t, dyn, "infra"), "worker pools wait for the control plane")
+
+	putObject(t, dyn, clusterVersionGVR, upgradingClusterVersion("4.15.1", "Completed"))
+	putObject(t, dyn, machineConfigPoolGVR, workerPool("master", 3, 3, false, nil))
+	done, err = plan.step(ctx, dyn)
+	require.NoError(t, err)
+	assert.False(t, done)
+	assert.False(t, isPaused(t, dyn, "infra"), "the first batch is unpaused")
+	assert.True(t, isPaused(t, dyn, "worker"))
+	assert.NotEmpty(t, getPool(t, dyn, "infra").GetAnnotations()[stagedResumedAnnotation])
+
+	done, err = plan.step(ctx, dyn)
+	require.NoError(t, err)
+	assert.False(t, done)
+	assert.True(t, isPaused(t, dyn, "worker"), "the next batch waits for infra to update")
+
+	infra := getPool(t, dyn, "infra")
+	_ = unstructured.SetNestedField(infra.Object, int64(3), "status", "updatedMachineCount")
+	_ = unstructured.SetNestedSlice(infra.Object, []interface{}{map[string]interface{}{"type": "Updated", "status": "True"}}, "status", "conditions")
+	putObject(t, dyn, machineConfigPoolGVR, infra)
+	done, err = plan.step(ctx, dyn)
+	require.NoError(t, err)
+	assert.False(t, done)
+	assert.Empty(t, getPool(t, dyn, "infra").GetAnnotations(), "updated pools are released")
+	assert.False(t, isPaused(t, dyn, "worker"))
+
+	worker := getPool(t, dyn, "worker")
+	_ = unstructured.SetNestedField(worker.Object, int64(6), "status", "updatedMachineCount")
+	_ = unstructured.SetNestedSlice(worker.Object, []interface{}{map[string]interface{}{"type": "Updated", "status": "True"}}, "status", "conditions")
+	putObject(t, dyn, machineConfigPoolGVR, worker)
+	done, err = plan.step(ctx, dyn)
+	require.NoError(t, err)
+	assert.True(t, done)
+}
+
+func TestStagedPlanStep_HealthGates(t *testing.T) {
+	fixNow(t, "2026-03-01")
+	ctx := context.Background()
+	plan := stagedPlan{target: "4.15.1", batchSize: 1, healthTimeout: time.Hour, started: now()}
+	resumed := map[string]string{
+		stagedUpgradeAnnotation: "4.15.1",
+		stagedResumedAnnotation: now().Add(-2 * time.Hour).Format(time.RFC3339),
+	}
+	completed := upgradingClusterVersion("4.15.1", "Completed")
+
+	degraded := workerPool("worker", 6, 2, false, map[string]string{stagedUpgradeAnnotation: "4.15.1"})
+	_ = unstructured.SetNestedSlice(degraded.Object, []interface{}{map[string]interface{}{"type": "Degraded", "status": "True"}}, "status", "conditions")
+	for name, tt := range map[string]struct {
+		objs []runtime.Object
+		want string
+	}{
+		"pool degraded": {[]runtime.Object{completed, degraded}, "MachineConfigPool worker is degraded"},
+		"pool timeout":  {[]runtime.Object{completed, workerPool("worker", 6, 2, false, resumed)}, "did not finish updating within 1h0m0s"},
+		"operator degraded": {[]runtime.Object{completed,
+			makeClusterOperator("ingress", []map[string]interface{}{{"type": "Degraded", "status": "True"}}),
+			workerPool("worker", 6, 0, true, map[string]string{stagedUpgradeAnnotation: "4.15.1"})}, "ClusterOperators are degraded: ingress"},
+		"target changed":         {[]runtime.Object{upgradingClusterVersion("4.15.2", "Partial")}, `desired update changed to "4.15.2"`},
+		"control plane too slow": {[]runtime.Object{upgradingClusterVersion("4.15.1", "Partial")}, "control plane did not finish upgrading"},
+	} {
+		p := plan
+		if name == "control plane too slow" {
+			p.started = now().Add(-5 * time.Hour)
+		}
+		dyn := stagedDynamicClient(tt.objs...)
+		_, err := p.step(ctx, dyn)
+		assert.ErrorContains(t, err, tt.want, name)
+	}
+}
+
+func TestRunStagedUpgrade_RecordsStop(t *testing.T) {
+	degraded := workerPool("worker", 6, 2, false, map[string]string{stagedUpgradeAnnotation: "4.15.1"})
+	_ = unstructured.SetNestedSlice(degraded.Object, []interface{}{map[string]interface{}{"type": "Degraded", "status": "True"}}, "status", "conditions")
+	dyn := stagedDynamicClient(upgradingClusterVersion("4.15.1", "Completed"), degraded,
+		workerPool("infra", 3, 0, true, map[string]string{stagedUpgradeAnnotation: "4.15.1"}))
+
+	runStagedUpgrade(context.Background(), dyn, stagedPlan{target: "4.15.1", batchSize: 1, healthTimeout: time.Hour, started: now()})
+	for _, name := range []string{"worker", "infra"} {
+		assert.Equal(t, "MachineConfigPool worker is degraded", getPool(t, dyn, name).GetAnnotations()[stagedStoppedAnnotation], name)
+	}
+	assert.True(t, isPaused(t, dyn, "infra"), "pools of later batches stay paused")
+}
+
+func TestGetUpgradeStatus_StagedUpgrade(t *testing.T) {
+	dyn := stagedDynamicClient(
+		upgradingClusterVersion("4.15.1", "Completed"),
+		workerPool("master", 3, 3, false, nil),
+		workerPool("infra", 3, 1, false, map[string]string{stagedUpgradeAnnotation: "4.15.1"}),
+		workerPool("worker", 6, 0, true, map[string]string{stagedUpgradeAnnotation: "4.15.1"}),
+	)
+	stopStagedUpgrade(context.Background(), dyn, "4.15.1", "MachineConfigPool infra is degraded")
+
+	ca := &mockClusterAccess{client: newFakeClientWithVersion("v1.28.0"), dynClient: dyn}
+	out, isErr := GetUpgradeStatus(context.Background(), ca, map[string]interface{}{})
+	require.False(t, isErr, out)
+	assert.Contains(t, out, "| Pool | Ready | Updated | Updating | Degraded | Paused |")
+	assert.Contains(t, out, "| worker | 6/6 | 0/6 | False | False | true |")
+	assert.Contains(t, out, "## Staged Upgrade to 4.15.1")
+	assert.Contains(t, out, "- Updating: infra")
+	assert.Contains(t, out, "- Paused until their batch: worker")
+	assert.Contains(t, out, "- ❌ Stopped: MachineConfigPool infra is degraded")
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,6 +20,10 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/advisories"
+	"github.com/kubestellar/kubestellar-mcp/pkg/approval"
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
 )
 
 // ClusterAccess abstracts the Kubernetes client factories required by upgrade
@@ -74,6 +79,14 @@ type HelmRelease struct {
 	AppVer    string
 	Status    string
 	Revision  int
+	// Values are the user-supplied values of the release, from values
+	// files and --set, without the chart's defaults.
+	Values map[string]interface{}
+	// KubeVersion is the chart's constraint on the Kubernetes versions it
+	// supports, such as ">=1.25.0-0".
+	KubeVersion string
+	// Manifest is the rendered YAML the release installed.
+	Manifest string
 }
 
 // DetectClusterType detects the Kubernetes distribution type.
@@ -91,33 +104,33 @@ func DetectClusterType(ctx context.Context, ca ClusterAccess, args map[string]in
 	}
 
 	var sb strings.Builder
-	sb.WriteString("# Cluster Type Detection\n\n")
+	sb.WriteString(messages.Get(messages.ClusterTypeTitle))
 
 	version, err := client.Discovery().ServerVersion()
 	if err != nil {
 		return fmt.Sprintf("Failed to get server version: %v", err), true
 	}
-	_, _ = fmt.Fprintf(&sb, "**Kubernetes Version:** %s\n", version.GitVersion)
+	sb.WriteString(messages.Sprintf(messages.KubernetesVersion, version.GitVersion))
 
 	// Check for OpenShift first (ClusterVersion CRD)
 	_, err = dynClient.Resource(clusterVersionGVR).Get(ctx, "version", metav1.GetOptions{})
 	if err == nil {
-		_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeOpenShift)
-		sb.WriteString("**Detection Method:** ClusterVersion CRD found (config.openshift.io/v1)\n")
+		sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeOpenShift))
+		sb.WriteString(messages.Get(messages.DetectedClusterVersion))
 		return sb.String(), false
 	}
 
 	// Get nodes to check labels
 	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{Limit: 1})
 	if err != nil {
-		_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeUnknown)
-		_, _ = fmt.Fprintf(&sb, "**Note:** Unable to list nodes: %v\n", err)
+		sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeUnknown))
+		sb.WriteString(messages.Sprintf(messages.DetectionNodesUnlisted, err))
 		return sb.String(), false
 	}
 
 	if len(nodes.Items) == 0 {
-		_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeUnknown)
-		sb.WriteString("**Note:** No nodes found\n")
+		sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeUnknown))
+		sb.WriteString(messages.Get(messages.DetectionNoNodes))
 		return sb.String(), false
 	}
 
@@ -130,9 +143,9 @@ func DetectClusterType(ctx context.Context, ca ClusterAccess, args map[string]in
 	if strings.Contains(providerID, "aws") {
 		for label := range labels {
 			if strings.Contains(label, "eks.amazonaws.com") {
-				_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeEKS)
-				sb.WriteString("**Detection Method:** Node labels contain eks.amazonaws.com\n")
-				_, _ = fmt.Fprintf(&sb, "**Provider ID:** %s\n", providerID)
+				sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeEKS))
+				sb.WriteString(messages.Sprintf(messages.DetectedNodeLabel, "eks.amazonaws.com"))
+				sb.WriteString(messages.Sprintf(messages.ProviderID, providerID))
 				return sb.String(), false
 			}
 		}
@@ -142,15 +155,15 @@ func DetectClusterType(ctx context.Context, ca ClusterAccess, args map[string]in
 	if strings.Contains(providerID, "gce") {
 		for label := range labels {
 			if strings.Contains(label, "cloud.google.com/gke") {
-				_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeGKE)
-				sb.WriteString("**Detection Method:** Node labels contain cloud.google.com/gke\n")
-				_, _ = fmt.Fprintf(&sb, "**Provider ID:** %s\n", providerID)
+				sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeGKE))
+				sb.WriteString(messages.Sprintf(messages.DetectedNodeLabel, "cloud.google.com/gke"))
+				sb.WriteString(messages.Sprintf(messages.ProviderID, providerID))
 				return sb.String(), false
 			}
 		}
-		_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeGKE)
-		sb.WriteString("**Detection Method:** Provider ID contains gce://\n")
-		_, _ = fmt.Fprintf(&sb, "**Provider ID:** %s\n", providerID)
+		sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeGKE))
+		sb.WriteString(messages.Sprintf(messages.DetectedProviderID, "gce://"))
+		sb.WriteString(messages.Sprintf(messages.ProviderID, providerID))
 		return sb.String(), false
 	}
 
@@ -158,9 +171,9 @@ func DetectClusterType(ctx context.Context, ca ClusterAccess, args map[string]in
 	if strings.Contains(providerID, "azure") {
 		for label := range labels {
 			if strings.Contains(label, "kubernetes.azure.com") {
-				_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeAKS)
-				sb.WriteString("**Detection Method:** Node labels contain kubernetes.azure.com\n")
-				_, _ = fmt.Fprintf(&sb, "**Provider ID:** %s\n", providerID)
+				sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeAKS))
+				sb.WriteString(messages.Sprintf(messages.DetectedNodeLabel, "kubernetes.azure.com"))
+				sb.WriteString(messages.Sprintf(messages.ProviderID, providerID))
 				return sb.String(), false
 			}
 		}
@@ -169,8 +182,8 @@ func DetectClusterType(ctx context.Context, ca ClusterAccess, args map[string]in
 	// Check for kind
 	for label := range labels {
 		if strings.Contains(label, "io.x-k8s.kind") {
-			_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeKind)
-			sb.WriteString("**Detection Method:** Node labels contain io.x-k8s.kind\n")
+			sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeKind))
+			sb.WriteString(messages.Sprintf(messages.DetectedNodeLabel, "io.x-k8s.kind"))
 			return sb.String(), false
 		}
 	}
@@ -178,32 +191,31 @@ func DetectClusterType(ctx context.Context, ca ClusterAccess, args map[string]in
 	// Check for minikube
 	for label := range labels {
 		if strings.Contains(label, "minikube.k8s.io") {
-			_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeMinikube)
-			sb.WriteString("**Detection Method:** Node labels contain minikube.k8s.io\n")
+			sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeMinikube))
+			sb.WriteString(messages.Sprintf(messages.DetectedNodeLabel, "minikube.k8s.io"))
 			return sb.String(), false
 		}
 	}
 
 	// Check for k3s
 	if strings.Contains(version.GitVersion, "k3s") {
-		_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeK3s)
-		sb.WriteString("**Detection Method:** Server version contains k3s\n")
+		sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeK3s))
+		sb.WriteString(messages.Sprintf(messages.DetectedServerVersion, "k3s"))
 		return sb.String(), false
 	}
 
 	// Check for kubeadm
 	for key := range annotations {
 		if strings.Contains(key, "kubeadm") {
-			_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeKubeadm)
-			sb.WriteString("**Detection Method:** Node annotations contain kubeadm\n")
+			sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeKubeadm))
+			sb.WriteString(messages.Sprintf(messages.DetectedNodeAnnotation, "kubeadm"))
 			return sb.String(), false
 		}
 	}
 
 	// Default to unknown
-	_, _ = fmt.Fprintf(&sb, "**Cluster Type:** %s\n", ClusterTypeUnknown)
-	sb.WriteString("**Detection Method:** No specific distribution markers found\n")
-	sb.WriteString("**Note:** This appears to be a vanilla Kubernetes cluster\n")
+	sb.WriteString(messages.Sprintf(messages.ClusterType, ClusterTypeUnknown))
+	sb.WriteString(messages.Get(messages.DetectedNothing))
 
 	return sb.String(), false
 }
@@ -223,7 +235,7 @@ func GetClusterVersionInfo(ctx context.Context, ca ClusterAccess, args map[strin
 	}
 
 	var sb strings.Builder
-	sb.WriteString("# Cluster Version Information\n\n")
+	sb.WriteString(messages.Get(messages.VersionTitle))
 
 	version, err := client.Discovery().ServerVersion()
 	if err != nil {
@@ -237,16 +249,14 @@ func GetClusterVersionInfo(ctx context.Context, ca ClusterAccess, args map[strin
 	}
 
 	// Vanilla Kubernetes
-	sb.WriteString("**Cluster Type:** Kubernetes\n")
-	_, _ = fmt.Fprintf(&sb, "**Current Version:** %s\n", version.GitVersion)
-	_, _ = fmt.Fprintf(&sb, "**Platform:** %s\n", version.Platform)
-	_, _ = fmt.Fprintf(&sb, "**Build Date:** %s\n\n", version.BuildDate)
+	sb.WriteString(messages.Sprintf(messages.ClusterType, "Kubernetes"))
+	sb.WriteString(messages.Sprintf(messages.CurrentVersion, version.GitVersion))
+	sb.WriteString(messages.Sprintf(messages.Platform, version.Platform))
+	sb.WriteString(messages.Sprintf(messages.BuildDate, version.BuildDate))
 
 	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err == nil && len(nodes.Items) > 0 {
-		sb.WriteString("## Node Versions\n\n")
-		sb.WriteString("| Node | Kubelet Version | Status |\n")
-		sb.WriteString("|------|-----------------|--------|\n")
+		sb.WriteString(messages.Get(messages.NodeVersionsTable))
 
 		for _, node := range nodes.Items {
 			status := "NotReady"
@@ -263,28 +273,94 @@ func GetClusterVersionInfo(ctx context.Context, ca ClusterAccess, args map[strin
 		}
 	}
 
-	sb.WriteString("\n## Upgrade Information\n\n")
-	sb.WriteString("For vanilla Kubernetes clusters, upgrade paths depend on your installation method:\n\n")
-	sb.WriteString("- **kubeadm**: Use `kubeadm upgrade plan` to see available versions\n")
-	sb.WriteString("- **EKS**: Check AWS Console or use `aws eks describe-addon-versions`\n")
-	sb.WriteString("- **GKE**: Check Google Cloud Console or use `gcloud container get-server-config`\n")
-	sb.WriteString("- **AKS**: Check Azure Portal or use `az aks get-upgrades`\n")
+	writeVersionAdvisory(ctx, &sb, advisories.Kubernetes, version.GitVersion)
+	sb.WriteString(messages.Get(messages.VanillaUpgradeInfo))
 
 	return sb.String(), false
 }
 
-func getOpenShiftVersionInfo(_ context.Context, cv *unstructured.Unstructured, sb *strings.Builder) (string, bool) {
-	sb.WriteString("**Cluster Type:** OpenShift\n")
+// now is the time versions are judged against. Tests replace it.
+var now = time.Now
+
+// writeVersionAdvisory notes when version's support ends and, for versions
+// past end of life or with known critical CVEs, writes a Version Advisory
+// section with the version to upgrade to.
+func writeVersionAdvisory(ctx context.Context, sb *strings.Builder, product advisories.Product, version string) {
+	data := advisories.Current(ctx)
+	a := data.Assess(product, version, now())
+	name := "Kubernetes"
+	if product == advisories.OpenShift {
+		name = "OpenShift"
+	}
+	if !a.Flagged() {
+		if a.Minor != "" {
+			sb.WriteString(messages.Sprintf(messages.SupportedUntil, a.EndOfLife.Format(time.DateOnly)))
+		}
+		return
+	}
+
+	sb.WriteString(messages.Get(messages.AdvisoryTitle))
+	switch {
+	case a.EOL && a.Minor != "":
+		sb.WriteString(messages.Sprintf(messages.AdvisoryEndOfLife, name, a.Minor, a.EndOfLife.Format(time.DateOnly)))
+	case a.EOL:
+		sb.WriteString(messages.Sprintf(messages.AdvisoryUntracked, name, version))
+	}
+	if len(a.CVEs) > 0 {
+		sb.WriteString(messages.Sprintf(messages.AdvisoryCVEs, len(a.CVEs), version))
+		for _, cve := range a.CVEs {
+			icon := "🟠"
+			if strings.EqualFold(cve.Severity, "critical") {
+				icon = "🔴"
+			}
+			if cve.FixedIn != "" {
+				sb.WriteString(messages.Sprintf(messages.AdvisoryCVE, icon, cve.ID, cve.Severity, cve.Summary, cve.FixedIn))
+			} else {
+				sb.WriteString(messages.Sprintf(messages.AdvisoryCVEUnfixed, icon, cve.ID, cve.Severity, cve.Summary, a.Minor))
+			}
+		}
+	}
+	if a.Recommended == "" {
+		sb.WriteString(messages.Get(messages.AdvisoryNoTarget))
+	} else {
+		sb.WriteString(messages.Sprintf(messages.AdvisoryRecommended, a.Recommended))
+		if skipsMinor(version, a.Recommended) {
+			sb.WriteString(messages.Sprintf(messages.AdvisoryOneMinorAtATime, version, a.Recommended))
+		}
+	}
+	sb.WriteString(messages.Sprintf(messages.AdvisoryDataUpdated, data.Updated))
+}
+
+// skipsMinor reports whether upgrading from one version to another crosses
+// more than one minor version.
+func skipsMinor(from, to string) bool {
+	fromMajor, fromMinor, ok := minorOf(from)
+	if !ok {
+		return false
+	}
+	toMajor, toMinor, ok := minorOf(to)
+	return ok && fromMajor == toMajor && toMinor > fromMinor+1
+}
+
+// minorOf returns the major and minor numbers of versions such as v1.29.2,
+// 1.29 and 4.14.5.
+func minorOf(version string) (major, minor int, ok bool) {
+	_, err := fmt.Sscanf(strings.TrimPrefix(strings.TrimSpace(version), "v"), "%d.%d", &major, &minor)
+	return major, minor, err == nil
+}
+
+func getOpenShiftVersionInfo(ctx context.Context, cv *unstructured.Unstructured, sb *strings.Builder) (string, bool) {
+	sb.WriteString(messages.Sprintf(messages.ClusterType, "OpenShift"))
 
 	desiredVersion, _, _ := unstructured.NestedString(cv.Object, "status", "desired", "version")
-	_, _ = fmt.Fprintf(sb, "**Current Version:** %s\n", desiredVersion)
+	sb.WriteString(messages.Sprintf(messages.CurrentVersion, desiredVersion))
 
 	channel, _, _ := unstructured.NestedString(cv.Object, "spec", "channel")
-	_, _ = fmt.Fprintf(sb, "**Update Channel:** %s\n", channel)
+	sb.WriteString(messages.Sprintf(messages.UpdateChannel, channel))
 
 	clusterID, _, _ := unstructured.NestedString(cv.Object, "spec", "clusterID")
 	if clusterID != "" {
-		_, _ = fmt.Fprintf(sb, "**Cluster ID:** %s\n", clusterID)
+		sb.WriteString(messages.Sprintf(messages.ClusterID, clusterID))
 	}
 
 	conditions, _, _ := unstructured.NestedSlice(cv.Object, "status", "conditions")
@@ -297,16 +373,16 @@ func getOpenShiftVersionInfo(_ context.Context, cv *unstructured.Unstructured, s
 		condStatus, _, _ := unstructured.NestedString(condMap, "status")
 		if condType == "Progressing" && condStatus == "True" {
 			message, _, _ := unstructured.NestedString(condMap, "message")
-			sb.WriteString("\n**Upgrade Status:** In Progress\n")
-			_, _ = fmt.Fprintf(sb, "**Progress:** %s\n", message)
+			sb.WriteString(messages.Get(messages.VersionUpgrading))
+			sb.WriteString(messages.Sprintf(messages.Progress, message))
 		}
 	}
 
+	writeVersionAdvisory(ctx, sb, advisories.OpenShift, desiredVersion)
+
 	availableUpdates, _, _ := unstructured.NestedSlice(cv.Object, "status", "availableUpdates")
 	if len(availableUpdates) > 0 {
-		sb.WriteString("\n## Available Updates\n\n")
-		sb.WriteString("| Version | Image |\n")
-		sb.WriteString("|---------|-------|\n")
+		sb.WriteString(messages.Get(messages.AvailableUpdatesTable))
 
 		for _, update := range availableUpdates {
 			updateMap, ok := update.(map[string]interface{})
@@ -321,14 +397,12 @@ func getOpenShiftVersionInfo(_ context.Context, cv *unstructured.Unstructured, s
 			_, _ = fmt.Fprintf(sb, "| %s | %s |\n", ver, image)
 		}
 	} else {
-		sb.WriteString("\n**Available Updates:** None (cluster is at latest version for this channel)\n")
+		sb.WriteString(messages.Get(messages.NoAvailableUpdates))
 	}
 
 	history, _, _ := unstructured.NestedSlice(cv.Object, "status", "history")
 	if len(history) > 0 {
-		sb.WriteString("\n## Upgrade History\n\n")
-		sb.WriteString("| Version | State | Completion Time |\n")
-		sb.WriteString("|---------|-------|------------------|\n")
+		sb.WriteString(messages.Get(messages.UpgradeHistoryTable))
 
 		limit := 5
 		if len(history) < limit {
@@ -343,7 +417,7 @@ func getOpenShiftVersionInfo(_ context.Context, cv *unstructured.Unstructured, s
 			state, _, _ := unstructured.NestedString(entry, "state")
 			completionTime, _, _ := unstructured.NestedString(entry, "completionTime")
 			if completionTime == "" {
-				completionTime = "In progress"
+				completionTime = messages.Get(messages.HistoryInProgress)
 			}
 			_, _ = fmt.Fprintf(sb, "| %s | %s | %s |\n", ver, state, completionTime)
 		}
@@ -363,7 +437,7 @@ func CheckOLMOperatorUpgrades(ctx context.Context, ca ClusterAccess, args map[st
 	}
 
 	var sb strings.Builder
-	sb.WriteString("# OLM Operator Upgrades\n\n")
+	sb.WriteString(messages.Get(messages.OLMTitle))
 
 	var subscriptions *unstructured.UnstructuredList
 	if namespace == "" {
@@ -375,27 +449,23 @@ func CheckOLMOperatorUpgrades(ctx context.Context, ca ClusterAccess, args map[st
 	if err != nil {
 		if strings.Contains(err.Error(), "could not find the requested resource") ||
 			strings.Contains(err.Error(), "no matches for kind") {
-			sb.WriteString("**OLM Status:** Not installed\n\n")
-			sb.WriteString("Operator Lifecycle Manager (OLM) is not installed on this cluster.\n")
-			sb.WriteString("OLM is required for managing operators through subscriptions.\n\n")
-			sb.WriteString("To install OLM, visit: https://olm.operatorframework.io/docs/getting-started/\n")
+			sb.WriteString(messages.Get(messages.OLMNotInstalled))
 			return sb.String(), false
 		}
 		return fmt.Sprintf("Failed to list subscriptions: %v", err), true
 	}
 
 	if len(subscriptions.Items) == 0 {
-		sb.WriteString("**OLM Status:** Installed\n")
-		sb.WriteString("**Subscriptions Found:** 0\n\n")
-		sb.WriteString("No operator subscriptions found.\n")
+		sb.WriteString(messages.Get(messages.OLMInstalled))
+		sb.WriteString(messages.Sprintf(messages.OLMSubscriptions, 0))
+		sb.WriteString(messages.Get(messages.OLMNoSubscriptions))
 		return sb.String(), false
 	}
 
-	sb.WriteString("**OLM Status:** Installed\n")
-	_, _ = fmt.Fprintf(&sb, "**Subscriptions Found:** %d\n\n", len(subscriptions.Items))
+	sb.WriteString(messages.Get(messages.OLMInstalled))
+	sb.WriteString(messages.Sprintf(messages.OLMSubscriptions, len(subscriptions.Items)))
 
-	sb.WriteString("| Operator | Namespace | Current CSV | Channel | Auto-Update | Status |\n")
-	sb.WriteString("|----------|-----------|-------------|---------|-------------|--------|\n")
+	sb.WriteString(messages.Get(messages.OLMTable))
 
 	upgradesPending := 0
 	for _, sub := range subscriptions.Items {
@@ -414,20 +484,20 @@ func CheckOLMOperatorUpgrades(ctx context.Context, ca ClusterAccess, args map[st
 		statusEmoji := ""
 		switch state {
 		case "AtLatestKnown":
-			statusEmoji = "Up to date"
+			statusEmoji = messages.Get(messages.OLMUpToDate)
 		case "UpgradePending":
-			statusEmoji = "Upgrade pending"
+			statusEmoji = messages.Get(messages.OLMUpgradePending)
 			upgradesPending++
 		case "UpgradeAvailable":
-			statusEmoji = "Upgrade available"
+			statusEmoji = messages.Get(messages.OLMUpgradeAvailable)
 			upgradesPending++
 		default:
 			statusEmoji = state
 		}
 
-		autoUpdateStr := "Manual"
+		autoUpdateStr := messages.Get(messages.OLMManual)
 		if autoUpdate {
-			autoUpdateStr = "Auto"
+			autoUpdateStr = messages.Get(messages.OLMAuto)
 		}
 
 		_, _ = fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s |\n",
@@ -436,9 +506,9 @@ func CheckOLMOperatorUpgrades(ctx context.Context, ca ClusterAccess, args map[st
 
 	sb.WriteString("\n")
 	if upgradesPending > 0 {
-		_, _ = fmt.Fprintf(&sb, "**Upgrades Available:** %d operator(s) have pending upgrades\n", upgradesPending)
+		sb.WriteString(messages.Sprintf(messages.OLMUpgradesPending, upgradesPending))
 	} else {
-		sb.WriteString("**Upgrades Available:** All operators are at their latest known version\n")
+		sb.WriteString(messages.Get(messages.OLMAllLatest))
 	}
 
 	return sb.String(), false
@@ -455,7 +525,7 @@ func CheckHelmReleaseUpgrades(ctx context.Context, ca ClusterAccess, args map[st
 	}
 
 	var sb strings.Builder
-	sb.WriteString("# Helm Releases\n\n")
+	sb.WriteString(messages.Get(messages.HelmTitle))
 
 	labelSelector := "owner=helm"
 	var secrets *corev1.SecretList
@@ -474,8 +544,8 @@ func CheckHelmReleaseUpgrades(ctx context.Context, ca ClusterAccess, args map[st
 	}
 
 	if len(secrets.Items) == 0 {
-		sb.WriteString("**Helm Releases Found:** 0\n\n")
-		sb.WriteString("No Helm releases found in the cluster.\n")
+		sb.WriteString(messages.Sprintf(messages.HelmReleases, 0))
+		sb.WriteString(messages.Get(messages.HelmNoReleases))
 		return sb.String(), false
 	}
 
@@ -493,23 +563,16 @@ func CheckHelmReleaseUpgrades(ctx context.Context, ca ClusterAccess, args map[st
 		}
 	}
 
-	_, _ = fmt.Fprintf(&sb, "**Helm Releases Found:** %d\n\n", len(releases))
+	sb.WriteString(messages.Sprintf(messages.HelmReleases, len(releases)))
 
-	sb.WriteString("| Release | Namespace | Chart | Version | App Version | Status |\n")
-	sb.WriteString("|---------|-----------|-------|---------|-------------|--------|\n")
+	sb.WriteString(messages.Get(messages.HelmTable))
 
 	for _, rel := range releases {
 		_, _ = fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s |\n",
 			rel.Name, rel.Namespace, rel.Chart, rel.Version, rel.AppVer, rel.Status)
 	}
 
-	sb.WriteString("\n## Checking for Updates\n\n")
-	sb.WriteString("To check for available chart updates, you need to:\n\n")
-	sb.WriteString("1. Ensure Helm repos are added: `helm repo list`\n")
-	sb.WriteString("2. Update repos: `helm repo update`\n")
-	sb.WriteString("3. Search for updates: `helm search repo <chart-name>`\n\n")
-	sb.WriteString("**Note:** This tool shows currently deployed releases. Checking for newer chart versions\n")
-	sb.WriteString("requires access to Helm repositories which are typically configured on the client side.\n")
+	sb.WriteString(messages.Get(messages.HelmCheckingUpdates))
 
 	return sb.String(), false
 }
@@ -573,6 +636,9 @@ func ParseHelmSecret(secret *corev1.Secret) *HelmRelease {
 			if appVer, ok := metadata["appVersion"].(string); ok {
 				release.AppVer = appVer
 			}
+			if kubeVersion, ok := metadata["kubeVersion"].(string); ok {
+				release.KubeVersion = kubeVersion
+			}
 		}
 	}
 
@@ -580,9 +646,39 @@ func ParseHelmSecret(secret *corev1.Secret) *HelmRelease {
 		release.Revision = int(version)
 	}
 
+	if config, ok := releaseObj["config"].(map[string]interface{}); ok {
+		release.Values = config
+	}
+
+	if manifest, ok := releaseObj["manifest"].(string); ok {
+		release.Manifest = manifest
+	}
+
 	return release
 }
 
+// LatestHelmRelease returns the newest revision of the Helm release name
+// in namespace, or nil if it is not installed.
+func LatestHelmRelease(ctx context.Context, client kubernetes.Interface, namespace, name string) (*HelmRelease, error) {
+	secrets, err := client.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "owner=helm,name=" + name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Helm secrets: %w", err)
+	}
+	var latest *HelmRelease
+	for i := range secrets.Items {
+		release := ParseHelmSecret(&secrets.Items[i])
+		if release == nil || release.Name != name {
+			continue
+		}
+		if latest == nil || release.Revision > latest.Revision {
+			latest = release
+		}
+	}
+	return latest, nil
+}
+
 // GetUpgradePrerequisites checks prerequisites before upgrading.
 func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[string]interface{}) (string, bool) {
 	cluster, _ := args["cluster"].(string)
@@ -598,17 +694,17 @@ func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[str
 	}
 
 	var sb strings.Builder
-	sb.WriteString("# Upgrade Prerequisites Check\n\n")
+	sb.WriteString(messages.Get(messages.PrereqTitle))
 
 	passed := 0
 	failed := 0
 	warnings := 0
 
 	// Check 1: All nodes ready
-	sb.WriteString("## Node Health\n\n")
+	sb.WriteString(messages.Get(messages.PrereqNodeHealth))
 	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
-		_, _ = fmt.Fprintf(&sb, "- [ ] Unable to check nodes: %v\n", err)
+		sb.WriteString(messages.Sprintf(messages.PrereqNodesUnchecked, err))
 		failed++
 	} else {
 		readyNodes := 0
@@ -629,20 +725,20 @@ func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[str
 		}
 
 		if len(notReadyNodes) == 0 {
-			_, _ = fmt.Fprintf(&sb, "- [x] All nodes ready (%d/%d)\n", readyNodes, len(nodes.Items))
+			sb.WriteString(messages.Sprintf(messages.PrereqNodesReady, readyNodes, len(nodes.Items)))
 			passed++
 		} else {
-			_, _ = fmt.Fprintf(&sb, "- [ ] Some nodes not ready (%d/%d)\n", readyNodes, len(nodes.Items))
-			_, _ = fmt.Fprintf(&sb, "  - Not ready: %s\n", strings.Join(notReadyNodes, ", "))
+			sb.WriteString(messages.Sprintf(messages.PrereqNodesNotReady, readyNodes, len(nodes.Items)))
+			sb.WriteString(messages.Sprintf(messages.PrereqNotReadyList, strings.Join(notReadyNodes, ", ")))
 			failed++
 		}
 	}
 
 	// Check 2: No pods in CrashLoopBackOff or ImagePullBackOff
-	sb.WriteString("\n## Pod Health\n\n")
+	sb.WriteString(messages.Get(messages.PrereqPodHealth))
 	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
 	if err != nil {
-		_, _ = fmt.Fprintf(&sb, "- [ ] Unable to check pods: %v\n", err)
+		sb.WriteString(messages.Sprintf(messages.PrereqPodsUnchecked, err))
 		failed++
 	} else {
 		crashingPods := []string{}
@@ -672,24 +768,24 @@ func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[str
 		}
 
 		if len(crashingPods) == 0 {
-			sb.WriteString("- [x] No pods in CrashLoopBackOff\n")
+			sb.WriteString(messages.Get(messages.PrereqNoCrashLoop))
 			passed++
 		} else {
-			_, _ = fmt.Fprintf(&sb, "- [ ] %d pods in CrashLoopBackOff\n", len(crashingPods))
+			sb.WriteString(messages.Sprintf(messages.PrereqCrashLoop, len(crashingPods)))
 			for _, p := range crashingPods[:min(5, len(crashingPods))] {
 				_, _ = fmt.Fprintf(&sb, "  - %s\n", p)
 			}
 			if len(crashingPods) > 5 {
-				_, _ = fmt.Fprintf(&sb, "  - ... and %d more\n", len(crashingPods)-5)
+				sb.WriteString(messages.Sprintf(messages.PrereqMore, len(crashingPods)-5))
 			}
 			failed++
 		}
 
 		if len(imagePullPods) == 0 {
-			sb.WriteString("- [x] No pods with image pull errors\n")
+			sb.WriteString(messages.Get(messages.PrereqNoImagePull))
 			passed++
 		} else {
-			_, _ = fmt.Fprintf(&sb, "- [ ] %d pods with image pull errors\n", len(imagePullPods))
+			sb.WriteString(messages.Sprintf(messages.PrereqImagePull, len(imagePullPods)))
 			for _, p := range imagePullPods[:min(5, len(imagePullPods))] {
 				_, _ = fmt.Fprintf(&sb, "  - %s\n", p)
 			}
@@ -697,10 +793,10 @@ func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[str
 		}
 
 		if len(pendingPods) <= 5 {
-			_, _ = fmt.Fprintf(&sb, "- [x] Few pending pods (%d)\n", len(pendingPods))
+			sb.WriteString(messages.Sprintf(messages.PrereqFewPending, len(pendingPods)))
 			passed++
 		} else {
-			_, _ = fmt.Fprintf(&sb, "- [ ] Many pending pods (%d)\n", len(pendingPods))
+			sb.WriteString(messages.Sprintf(messages.PrereqManyPending, len(pendingPods)))
 			warnings++
 		}
 	}
@@ -708,11 +804,11 @@ func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[str
 	// Check 3: OpenShift-specific checks
 	_, err = dynClient.Resource(clusterVersionGVR).Get(ctx, "version", metav1.GetOptions{})
 	if err == nil {
-		sb.WriteString("\n## OpenShift-Specific Checks\n\n")
+		sb.WriteString(messages.Get(messages.PrereqOpenShift))
 
 		cos, err := dynClient.Resource(clusterOperatorGVR).List(ctx, metav1.ListOptions{})
 		if err != nil {
-			_, _ = fmt.Fprintf(&sb, "- [ ] Unable to check ClusterOperators: %v\n", err)
+			sb.WriteString(messages.Sprintf(messages.PrereqOperatorsUnchecked, err))
 			failed++
 		} else {
 			degradedOps := []string{}
@@ -747,26 +843,26 @@ func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[str
 			}
 
 			if len(degradedOps) == 0 {
-				sb.WriteString("- [x] No degraded ClusterOperators\n")
+				sb.WriteString(messages.Get(messages.PrereqNoDegradedOps))
 				passed++
 			} else {
-				_, _ = fmt.Fprintf(&sb, "- [ ] %d degraded ClusterOperators: %s\n", len(degradedOps), strings.Join(degradedOps, ", "))
+				sb.WriteString(messages.Sprintf(messages.PrereqDegradedOps, len(degradedOps), strings.Join(degradedOps, ", ")))
 				failed++
 			}
 
 			if len(unavailableOps) == 0 {
-				sb.WriteString("- [x] All ClusterOperators available\n")
+				sb.WriteString(messages.Get(messages.PrereqOpsAvailable))
 				passed++
 			} else {
-				_, _ = fmt.Fprintf(&sb, "- [ ] %d unavailable ClusterOperators: %s\n", len(unavailableOps), strings.Join(unavailableOps, ", "))
+				sb.WriteString(messages.Sprintf(messages.PrereqUnavailableOps, len(unavailableOps), strings.Join(unavailableOps, ", ")))
 				failed++
 			}
 
 			if len(progressingOps) == 0 {
-				sb.WriteString("- [x] No ClusterOperators progressing\n")
+				sb.WriteString(messages.Get(messages.PrereqNoProgressingOps))
 				passed++
 			} else {
-				_, _ = fmt.Fprintf(&sb, "- [ ] %d ClusterOperators progressing: %s\n", len(progressingOps), strings.Join(progressingOps, ", "))
+				sb.WriteString(messages.Sprintf(messages.PrereqProgressingOps, len(progressingOps), strings.Join(progressingOps, ", ")))
 				warnings++
 			}
 		}
@@ -796,65 +892,59 @@ func GetUpgradePrerequisites(ctx context.Context, ca ClusterAccess, args map[str
 			}
 
 			if len(updatingPools) == 0 {
-				sb.WriteString("- [x] No MachineConfigPools updating\n")
+				sb.WriteString(messages.Get(messages.PrereqNoPoolsUpdating))
 				passed++
 			} else {
-				_, _ = fmt.Fprintf(&sb, "- [ ] %d MachineConfigPools updating: %s\n", len(updatingPools), strings.Join(updatingPools, ", "))
-				sb.WriteString("  - Wait for current updates to complete before upgrading\n")
+				sb.WriteString(messages.Sprintf(messages.PrereqPoolsUpdating, len(updatingPools), strings.Join(updatingPools, ", ")))
 				failed++
 			}
 
 			if len(degradedPools) == 0 {
-				sb.WriteString("- [x] No MachineConfigPools degraded\n")
+				sb.WriteString(messages.Get(messages.PrereqNoPoolsDegraded))
 				passed++
 			} else {
-				_, _ = fmt.Fprintf(&sb, "- [ ] %d MachineConfigPools degraded: %s\n", len(degradedPools), strings.Join(degradedPools, ", "))
+				sb.WriteString(messages.Sprintf(messages.PrereqPoolsDegraded, len(degradedPools), strings.Join(degradedPools, ", ")))
 				failed++
 			}
 		}
 	}
 
 	// Summary
-	sb.WriteString("\n## Summary\n\n")
-	_, _ = fmt.Fprintf(&sb, "- **Passed:** %d\n", passed)
-	_, _ = fmt.Fprintf(&sb, "- **Failed:** %d\n", failed)
-	_, _ = fmt.Fprintf(&sb, "- **Warnings:** %d\n\n", warnings)
+	sb.WriteString(messages.Get(messages.PrereqSummary))
+	sb.WriteString(messages.Sprintf(messages.PrereqPassed, passed))
+	sb.WriteString(messages.Sprintf(messages.PrereqFailed, failed))
+	sb.WriteString(messages.Sprintf(messages.PrereqWarnings, warnings))
 
 	if failed > 0 {
-		sb.WriteString("**Recommendation:** Fix the failed checks before proceeding with the upgrade.\n")
+		sb.WriteString(messages.Get(messages.PrereqFixFailures))
 	} else if warnings > 0 {
-		sb.WriteString("**Recommendation:** Review warnings before proceeding. The upgrade can proceed but may encounter issues.\n")
+		sb.WriteString(messages.Get(messages.PrereqReviewWarnings))
 	} else {
-		sb.WriteString("**Recommendation:** All prerequisites passed. The cluster is ready for upgrade.\n")
+		sb.WriteString(messages.Get(messages.PrereqReady))
 	}
 
 	return sb.String(), false
 }
 
-// TriggerOpenShiftUpgrade triggers an OpenShift cluster upgrade.
+// TriggerOpenShiftUpgrade triggers an OpenShift cluster upgrade. With
+// staged set, it pauses the worker pools first and resumes them in batches
+// once the control plane has upgraded.
 func TriggerOpenShiftUpgrade(ctx context.Context, ca ClusterAccess, args map[string]interface{}) (string, bool) {
 	cluster, _ := args["cluster"].(string)
 	targetVersion, _ := args["target_version"].(string)
 	confirm, _ := args["confirm"].(string)
+	staged, _ := args["staged"].(bool)
 
 	if targetVersion == "" {
 		return "target_version is required", true
 	}
+	plan, err := stagedPlanFromArgs(targetVersion, args)
+	if err != nil {
+		return err.Error(), true
+	}
 
 	if confirm != "yes-upgrade-now" {
-		var sb strings.Builder
-		sb.WriteString("# Safety Check Failed\n\n")
-		sb.WriteString("**IMPORTANT:** Cluster upgrades are significant operations that will:\n")
-		sb.WriteString("- Temporarily make the API server unavailable\n")
-		sb.WriteString("- Rolling restart all nodes\n")
-		sb.WriteString("- Potentially impact running workloads\n\n")
-		sb.WriteString("To proceed with the upgrade, you must pass `confirm='yes-upgrade-now'`\n\n")
-		sb.WriteString("**Before confirming:**\n")
-		sb.WriteString("1. Run `get_upgrade_prerequisites` to verify cluster readiness\n")
-		sb.WriteString("2. Ensure you have recent etcd backups\n")
-		sb.WriteString("3. Notify relevant teams about the maintenance window\n")
-		sb.WriteString("4. Verify the target version is in the available updates list\n")
-		return sb.String(), false
+		return messages.Get(messages.TriggerSafetyCheck), false
 	}
 
 	dynClient, err := ca.GetDynamicClientForCluster(cluster)
@@ -868,7 +958,9 @@ func TriggerOpenShiftUpgrade(ctx context.Context, ca ClusterAccess, args map[str
 	}
 
 	availableUpdates, _, _ := unstructured.NestedSlice(cv.Object, "status", "availableUpdates")
-	validVersion := false
+	// A staged upgrade to the version already requested resumes it.
+	desiredVersion, _, _ := unstructured.NestedString(cv.Object, "spec", "desiredUpdate", "version")
+	validVersion := staged && desiredVersion == targetVersion
 	for _, update := range availableUpdates {
 		updateMap, ok := update.(map[string]interface{})
 		if !ok {
@@ -883,9 +975,7 @@ func TriggerOpenShiftUpgrade(ctx context.Context, ca ClusterAccess, args map[str
 
 	if !validVersion {
 		var sb strings.Builder
-		sb.WriteString("# Invalid Target Version\n\n")
-		_, _ = fmt.Fprintf(&sb, "Version `%s` is not in the list of available updates.\n\n", targetVersion)
-		sb.WriteString("**Available versions:**\n")
+		sb.WriteString(messages.Sprintf(messages.TriggerInvalidVersion, targetVersion))
 		for _, update := range availableUpdates {
 			updateMap, ok := update.(map[string]interface{})
 			if !ok {
@@ -895,11 +985,19 @@ func TriggerOpenShiftUpgrade(ctx context.Context, ca ClusterAccess, args map[str
 			_, _ = fmt.Fprintf(&sb, "- %s\n", ver)
 		}
 		if len(availableUpdates) == 0 {
-			sb.WriteString("- (none available - cluster may be at latest version)\n")
+			sb.WriteString(messages.Get(messages.TriggerNoVersions))
 		}
 		return sb.String(), false
 	}
 
+	var held, leftPaused []string
+	if staged {
+		held, leftPaused, err = holdWorkerPools(ctx, dynClient, targetVersion)
+		if err != nil {
+			return fmt.Sprintf("Failed to pause worker pools: %v", err), true
+		}
+	}
+
 	err = unstructured.SetNestedField(cv.Object, targetVersion, "spec", "desiredUpdate", "version")
 	if err != nil {
 		return fmt.Sprintf("Failed to set desired version: %v", err), true
@@ -910,19 +1008,32 @@ func TriggerOpenShiftUpgrade(ctx context.Context, ca ClusterAccess, args map[str
 		return fmt.Sprintf("Failed to trigger upgrade: %v", err), true
 	}
 
-	var sb strings.Builder
-	sb.WriteString("# Upgrade Initiated\n\n")
-	_, _ = fmt.Fprintf(&sb, "**Target Version:** %s\n", targetVersion)
-	sb.WriteString("**Status:** Upgrade has been triggered\n\n")
-	sb.WriteString("The cluster will now begin the upgrade process. This typically takes:\n")
-	sb.WriteString("- 30-60 minutes for control plane\n")
-	sb.WriteString("- Additional time for worker nodes (depends on node count)\n\n")
-	sb.WriteString("**Monitor progress with:**\n")
-	sb.WriteString("- `get_upgrade_status` - Check overall progress\n")
-	sb.WriteString("- `get_cluster_health` - Monitor cluster health\n")
-	sb.WriteString("- `find_pod_issues` - Check for pod problems during upgrade\n\n")
-	sb.WriteString("**Important:** Do not make additional changes to the cluster during the upgrade.\n")
+	if !staged {
+		if approval.IsDryRun(ctx) {
+			return messages.Sprintf(messages.TriggerDryRun, targetVersion), false
+		}
+		return messages.Sprintf(messages.TriggerInitiated, targetVersion), false
+	}
 
+	var sb strings.Builder
+	switch {
+	case approval.IsDryRun(ctx):
+		sb.WriteString(messages.Sprintf(messages.TriggerDryRun, targetVersion))
+	case len(held) == 0:
+		sb.WriteString(messages.Sprintf(messages.TriggerInitiated, targetVersion))
+	default:
+		plan.started = now()
+		startStagedUpgrade(dynClient, plan)
+		sb.WriteString(messages.Sprintf(messages.StagedInitiated, targetVersion, plan.batchSize, plan.healthTimeout))
+	}
+	if len(held) > 0 {
+		sb.WriteString(messages.Sprintf(messages.StagedHeldPools, strings.Join(held, ", ")))
+	} else {
+		sb.WriteString(messages.Get(messages.StagedNoWorkerPools))
+	}
+	if len(leftPaused) > 0 {
+		sb.WriteString(messages.Sprintf(messages.StagedLeftPaused, strings.Join(leftPaused, ", ")))
+	}
 	return sb.String(), false
 }
 
@@ -941,20 +1052,18 @@ func GetUpgradeStatus(ctx context.Context, ca ClusterAccess, args map[string]int
 	}
 
 	var sb strings.Builder
-	sb.WriteString("# Upgrade Status\n\n")
+	sb.WriteString(messages.Get(messages.StatusTitle))
 
 	cv, err := dynClient.Resource(clusterVersionGVR).Get(ctx, "version", metav1.GetOptions{})
 	if err != nil {
-		sb.WriteString("**Cluster Type:** Kubernetes\n\n")
+		sb.WriteString(messages.Sprintf(messages.ClusterType, "Kubernetes") + "\n")
 
 		nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 		if err != nil {
 			return fmt.Sprintf("Failed to list nodes: %v", err), true
 		}
 
-		sb.WriteString("## Node Versions\n\n")
-		sb.WriteString("| Node | Kubelet Version | Status |\n")
-		sb.WriteString("|------|-----------------|--------|\n")
+		sb.WriteString(messages.Get(messages.NodeVersionsTable))
 
 		for _, node := range nodes.Items {
 			status := "NotReady"
@@ -970,15 +1079,14 @@ func GetUpgradeStatus(ctx context.Context, ca ClusterAccess, args map[string]int
 				status)
 		}
 
-		sb.WriteString("\n**Note:** For non-OpenShift clusters, detailed upgrade progress tracking\n")
-		sb.WriteString("depends on your installation method (kubeadm, EKS, GKE, AKS, etc.)\n")
+		sb.WriteString(messages.Get(messages.StatusNonOpenShift))
 		return sb.String(), false
 	}
 
-	sb.WriteString("**Cluster Type:** OpenShift\n\n")
+	sb.WriteString(messages.Sprintf(messages.ClusterType, "OpenShift") + "\n")
 
 	desiredVersion, _, _ := unstructured.NestedString(cv.Object, "status", "desired", "version")
-	_, _ = fmt.Fprintf(&sb, "**Target Version:** %s\n", desiredVersion)
+	sb.WriteString(messages.Sprintf(messages.TargetVersion, desiredVersion))
 
 	conditions, _, _ := unstructured.NestedSlice(cv.Object, "status", "conditions")
 	isProgressing := false
@@ -1002,17 +1110,15 @@ func GetUpgradeStatus(ctx context.Context, ca ClusterAccess, args map[string]int
 	}
 
 	if isProgressing {
-		sb.WriteString("**Status:** Upgrade in progress\n")
-		_, _ = fmt.Fprintf(&sb, "**Progress:** %s\n\n", progressMessage)
+		sb.WriteString(messages.Get(messages.StatusUpgrading))
+		sb.WriteString(messages.Sprintf(messages.Progress, progressMessage) + "\n")
 	} else {
-		sb.WriteString("**Status:** Not currently upgrading\n\n")
+		sb.WriteString(messages.Get(messages.StatusIdle))
 	}
 
 	cos, err := dynClient.Resource(clusterOperatorGVR).List(ctx, metav1.ListOptions{})
 	if err == nil {
-		sb.WriteString("## ClusterOperator Status\n\n")
-		sb.WriteString("| Operator | Available | Progressing | Degraded |\n")
-		sb.WriteString("|----------|-----------|-------------|----------|\n")
+		sb.WriteString(messages.Get(messages.OperatorStatusTable))
 
 		for _, co := range cos.Items {
 			available := "-"
@@ -1045,9 +1151,7 @@ func GetUpgradeStatus(ctx context.Context, ca ClusterAccess, args map[string]int
 
 	mcps, err := dynClient.Resource(machineConfigPoolGVR).List(ctx, metav1.ListOptions{})
 	if err == nil {
-		sb.WriteString("\n## MachineConfigPool Status\n\n")
-		sb.WriteString("| Pool | Ready | Updated | Updating | Degraded |\n")
-		sb.WriteString("|------|-------|---------|----------|----------|\n")
+		sb.WriteString(messages.Get(messages.PoolStatusTable))
 
 		for _, mcp := range mcps.Items {
 			status, _, _ := unstructured.NestedMap(mcp.Object, "status")
@@ -1075,16 +1179,17 @@ func GetUpgradeStatus(ctx context.Context, ca ClusterAccess, args map[string]int
 				}
 			}
 
-			_, _ = fmt.Fprintf(&sb, "| %s | %d/%d | %d/%d | %s | %s |\n",
-				mcp.GetName(), readyCount, machineCount, updatedCount, machineCount, updating, degraded)
+			paused, _, _ := unstructured.NestedBool(mcp.Object, "spec", "paused")
+
+			_, _ = fmt.Fprintf(&sb, "| %s | %d/%d | %d/%d | %s | %s | %t |\n",
+				mcp.GetName(), readyCount, machineCount, updatedCount, machineCount, updating, degraded, paused)
 		}
+		writeStagedUpgradeStatus(&sb, mcps.Items)
 	}
 
 	history, _, _ := unstructured.NestedSlice(cv.Object, "status", "history")
 	if len(history) > 0 {
-		sb.WriteString("\n## Recent History\n\n")
-		sb.WriteString("| Version | State | Started | Completed |\n")
-		sb.WriteString("|---------|-------|---------|------------|\n")
+		sb.WriteString(messages.Get(messages.RecentHistoryTable))
 
 		limit := 3
 		if len(history) < limit {
@@ -1101,7 +1206,7 @@ func GetUpgradeStatus(ctx context.Context, ca ClusterAccess, args map[string]int
 			completionTime, _, _ := unstructured.NestedString(entry, "completionTime")
 
 			if completionTime == "" {
-				completionTime = "In progress"
+				completionTime = messages.Get(messages.HistoryInProgress)
 			}
 
 			_, _ = fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", ver, state, startTime, completionTime)
@@ -84,6 +84,17 @@ func TestGetOpenShiftVersionInfo_MinimalCluster(t *testing.T) {
 	assert.NotContains(t, out, "## Upgrade History")
 }
 
+func TestGetOpenShiftVersionInfo_EndOfLife(t *testing.T) {
+	fixNow(t, "2026-10-16")
+	cv := makeClusterVersion("4.13.12", "stable-4.13", "", nil, nil, nil)
+	var sb strings.Builder
+	out, _ := getOpenShiftVersionInfo(context.Background(), cv, &sb)
+
+	assert.Contains(t, out, "**End of Life:** OpenShift 4.13 reached end of life on 2024-11-17")
+	assert.Contains(t, out, "**Recommended Target:** 4.18 or later")
+	assert.Less(t, strings.Index(out, "## Version Advisory"), strings.Index(out, "**Available Updates:**"))
+}
+
 func TestGetOpenShiftVersionInfo_WithClusterID(t *testing.T) {
 	cv := makeClusterVersion("4.14.7", "stable-4.14", "abc-123", nil, nil, nil)
 	var sb strings.Builder
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -72,6 +73,11 @@ func makeHelmSecret(name, namespace, chartName, chartVersion, appVersion, status
 			},
 		},
 	}
+	return helmSecretFor(name, namespace, revision, releaseObj)
+}
+
+// helmSecretFor encodes a Helm release object into its release secret.
+func helmSecretFor(name, namespace string, revision int, releaseObj map[string]interface{}) *corev1.Secret {
 	data, _ := json.Marshal(releaseObj)
 
 	var buf bytes.Buffer
@@ -334,6 +340,48 @@ func TestGetClusterVersionInfo_VanillaKubernetes(t *testing.T) {
 	assert.Contains(t, result, "Kubernetes")
 }
 
+// fixNow judges versions as of date for the duration of the test.
+func fixNow(t *testing.T, date string) {
+	t.Helper()
+	at, err := time.Parse(time.DateOnly, date)
+	require.NoError(t, err)
+	now = func() time.Time { return at }
+	t.Cleanup(func() { now = time.Now })
+}
+
+// versionInfo runs GetClusterVersionInfo on a vanilla cluster at gitVersion.
+func versionInfo(t *testing.T, gitVersion string) string {
+	t.Helper()
+	dynClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	dynClient.PrependReactor("get", "clusterversions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, fmt.Errorf("not found")
+	})
+	ca := &mockClusterAccess{client: newFakeClientWithVersion(gitVersion), dynClient: dynClient}
+	result, isErr := GetClusterVersionInfo(context.Background(), ca, map[string]interface{}{})
+	require.False(t, isErr, result)
+	return result
+}
+
+func TestGetClusterVersionInfo_EndOfLifeAndCVEs(t *testing.T) {
+	fixNow(t, "2026-10-16")
+	result := versionInfo(t, "v1.27.3")
+	assert.Contains(t, result, "## Version Advisory")
+	assert.Contains(t, result, "**End of Life:** Kubernetes 1.27 reached end of life on 2024-06-28")
+	assert.Contains(t, result, "**Known Vulnerabilities:** 3 not fixed in v1.27.3")
+	assert.Contains(t, result, "**CVE-2023-5528** (high)")
+	assert.Contains(t, result, "fixed in 1.27.8")
+	assert.Contains(t, result, "**Recommended Target:** 1.34 or later")
+	assert.Contains(t, result, "plan each minor version between v1.27.3 and 1.34")
+	assert.Less(t, strings.Index(result, "## Version Advisory"), strings.Index(result, "## Upgrade Information"))
+}
+
+func TestGetClusterVersionInfo_SupportedVersion(t *testing.T) {
+	fixNow(t, "2026-10-16")
+	result := versionInfo(t, "v1.35.1-eks-5e0fdde")
+	assert.Contains(t, result, "**Supported Until:** 2027-02-28")
+	assert.NotContains(t, result, "## Version Advisory")
+}
+
 // --- GetUpgradePrerequisites tests ---
 
 func TestGetUpgradePrerequisites_ClientError(t *testing.T) {
@@ -0,0 +1,80 @@
+package messages
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EnvASCII, when true, makes both MCP servers return tool results as
+// plain ASCII, for terminals without emoji fonts and for parsers.
+const EnvASCII = "KUBESTELLAR_ASCII"
+
+// ASCIIEnabled reports whether $KUBESTELLAR_ASCII asks for ASCII results.
+func ASCIIEnabled() bool {
+	on, _ := strconv.ParseBool(os.Getenv(EnvASCII))
+	return on
+}
+
+// asciiSymbols spells out the symbols reports use for status and severity.
+var asciiSymbols = map[rune]string{
+	'✅':      "[OK]",
+	'✓':      "[OK]",
+	'🟢':      "[OK]",
+	'❌':      "[FAIL]",
+	'✗':      "[FAIL]",
+	'✖':      "[FAIL]",
+	'⚠':      "[WARN]",
+	'🔴':      "[CRITICAL]",
+	'🟠':      "[HIGH]",
+	'🟡':      "[MEDIUM]",
+	'💡':      "Hint:",
+	'⏳':      "[PENDING]",
+	'⏸':      "[PAUSED]",
+	'🔄':      "[RESTARTING]",
+	'—':      "-",
+	'–':      "-",
+	'›':      ">",
+	'→':      "->",
+	'←':      "<-",
+	'↑':      "^",
+	'↓':      "v",
+	'…':      "...",
+	'•':      "*",
+	'●':      "*",
+	'‘':      "'",
+	'’':      "'",
+	'“':      `"`,
+	'”':      `"`,
+	'\u00a0': " ",
+}
+
+// ASCII returns text with status and severity symbols spelled out, such as
+// ✅ as [OK] and 🔴 as [CRITICAL], punctuation replaced by its ASCII
+// counterpart, and decorative emoji dropped with the space after them.
+// Anything else outside ASCII becomes ?.
+func ASCII(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case asciiSymbols[r] != "":
+			b.WriteString(asciiSymbols[r])
+		case r == '\uFE0F' || r == '\u200D':
+			// Emoji presentation selectors and joiners.
+		case unicode.Is(unicode.So, r):
+			// Decorative emoji, such as the 📛 before a pod name, go with
+			// the space after them.
+			rest := strings.TrimPrefix(text[i:], "\uFE0F")
+			i = len(text) - len(strings.TrimPrefix(rest, " "))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
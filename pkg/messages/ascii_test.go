@@ -0,0 +1,38 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestASCII(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Found 3 pods", "Found 3 pods"},
+		{"status", "✅ No pod issues found", "[OK] No pod issues found"},
+		{"severity", "🔴 Critical | 🟠 High | 🟡 Medium", "[CRITICAL] Critical | [HIGH] High | [MEDIUM] Medium"},
+		{"presentation selector", "⚠️ 2 warnings", "[WARN] 2 warnings"},
+		{"decorative", "🔓 shop/pod-0", "shop/pod-0"},
+		{"decorative with selector", "🛡️ RBAC", "RBAC"},
+		{"punctuation", "a → b — “c” …", `a -> b - "c" ...`},
+		{"other", "Größe", "Gr??e"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ASCII(tt.in))
+		})
+	}
+}
+
+func TestASCIIEnabled(t *testing.T) {
+	t.Setenv(EnvASCII, "")
+	assert.False(t, ASCIIEnabled())
+	t.Setenv(EnvASCII, "true")
+	assert.True(t, ASCIIEnabled())
+	t.Setenv(EnvASCII, "yes")
+	assert.False(t, ASCIIEnabled())
+}
@@ -0,0 +1,489 @@
+package messages
+
+// Messages of the diagnostic tools: find_pod_issues,
+// find_deployment_issues, check_resource_limits, check_security_issues,
+// analyze_namespace, and get_warning_events.
+const (
+	IssueObject Key = "issues.object"
+
+	PodIssuesNone           Key = "podIssues.none"
+	PodIssuesFound          Key = "podIssues.found"
+	PodIssuesMissingSecrets Key = "podIssues.missingSecretsHint"
+	PodIssuesWrongPlatform  Key = "podIssues.wrongPlatformHint"
+	PodPending              Key = "podIssues.pending"
+	PodFailed               Key = "podIssues.failed"
+	PodUnschedulable        Key = "podIssues.unschedulable"
+	ContainerRestarts       Key = "podIssues.containerRestarts"
+	ContainerWaiting        Key = "podIssues.containerWaiting"
+	ContainerWrongPlatform  Key = "podIssues.containerWrongPlatform"
+	ContainerOOMKilled      Key = "podIssues.containerOOMKilled"
+	ContainerNotReady       Key = "podIssues.containerNotReady"
+	InitContainerWaiting    Key = "podIssues.initContainerWaiting"
+	NodePlatformUnknown     Key = "podIssues.nodePlatformUnknown"
+
+	DeploymentIssuesNone     Key = "deploymentIssues.none"
+	DeploymentIssuesFound    Key = "deploymentIssues.found"
+	DeploymentReplicasReady  Key = "deploymentIssues.replicasReady"
+	DeploymentUnavailable    Key = "deploymentIssues.replicasUnavailable"
+	DeploymentRolloutStuck   Key = "deploymentIssues.rolloutStuck"
+	DeploymentNotAvailable   Key = "deploymentIssues.notAvailable"
+	DeploymentReplicaFailure Key = "deploymentIssues.replicaFailure"
+	DeploymentReplicaSetErr  Key = "deploymentIssues.replicaSetError"
+
+	LimitsNone          Key = "resourceLimits.none"
+	LimitsFound         Key = "resourceLimits.found"
+	LimitsPod           Key = "resourceLimits.pod"
+	LimitsContainer     Key = "resourceLimits.container"
+	LimitsNoCPULimit    Key = "resourceLimits.noCPULimit"
+	LimitsNoMemoryLimit Key = "resourceLimits.noMemoryLimit"
+	LimitsNoCPURequest  Key = "resourceLimits.noCPURequest"
+	LimitsNoMemoryReq   Key = "resourceLimits.noMemoryRequest"
+
+	SecurityNone                Key = "security.none"
+	SecurityFound               Key = "security.found"
+	SecurityPod                 Key = "security.pod"
+	SecurityHostNetwork         Key = "security.hostNetwork"
+	SecurityHostPID             Key = "security.hostPID"
+	SecurityHostIPC             Key = "security.hostIPC"
+	SecurityPrivileged          Key = "security.privileged"
+	SecurityRunsAsRoot          Key = "security.runsAsRoot"
+	SecurityPrivilegeEscalation Key = "security.privilegeEscalation"
+	SecurityWritableRootFS      Key = "security.writableRootFilesystem"
+	SecurityNoSecurityContext   Key = "security.noSecurityContext"
+	SecurityDockerSocket        Key = "security.dockerSocket"
+
+	NamespaceTitle        Key = "namespace.title"
+	NamespaceStatus       Key = "namespace.status"
+	NamespaceCreated      Key = "namespace.created"
+	NamespaceQuotas       Key = "namespace.quotas"
+	NamespaceLimitRanges  Key = "namespace.limitRanges"
+	NamespacePods         Key = "namespace.pods"
+	NamespacePodsTotal    Key = "namespace.podsTotal"
+	NamespacePodsRunning  Key = "namespace.podsRunning"
+	NamespacePodsPending  Key = "namespace.podsPending"
+	NamespacePodsFailed   Key = "namespace.podsFailed"
+	NamespacePodsCrashing Key = "namespace.podsCrashing"
+	NamespaceDeployments  Key = "namespace.deployments"
+	NamespaceUnhealthy    Key = "namespace.deploymentsUnhealthy"
+	NamespaceServices     Key = "namespace.services"
+	NamespacePVCs         Key = "namespace.pvcs"
+	NamespacePVCsPending  Key = "namespace.pvcsPending"
+	NamespaceConfigMaps   Key = "namespace.configMaps"
+	NamespaceSecrets      Key = "namespace.secrets"
+	NamespaceWarnings     Key = "namespace.warnings"
+
+	WarningEventsNone  Key = "warningEvents.none"
+	WarningEventsFound Key = "warningEvents.found"
+	WarningEvent       Key = "warningEvents.event"
+	WarningEventCount  Key = "warningEvents.count"
+	WarningEventAge    Key = "warningEvents.ageUnknown"
+)
+
+// Messages of the upgrade tools.
+const (
+	ClusterTypeTitle         Key = "upgrades.clusterTypeTitle"
+	KubernetesVersion        Key = "upgrades.kubernetesVersion"
+	ClusterType              Key = "upgrades.clusterType"
+	ProviderID               Key = "upgrades.providerID"
+	DetectedClusterVersion   Key = "upgrades.detectedClusterVersion"
+	DetectedNodeLabel        Key = "upgrades.detectedNodeLabel"
+	DetectedProviderID       Key = "upgrades.detectedProviderID"
+	DetectedServerVersion    Key = "upgrades.detectedServerVersion"
+	DetectedNodeAnnotation   Key = "upgrades.detectedNodeAnnotation"
+	DetectedNothing          Key = "upgrades.detectedNothing"
+	DetectionNodesUnlisted   Key = "upgrades.detectionNodesUnlisted"
+	DetectionNoNodes         Key = "upgrades.detectionNoNodes"
+	VersionTitle             Key = "upgrades.versionTitle"
+	CurrentVersion           Key = "upgrades.currentVersion"
+	Platform                 Key = "upgrades.platform"
+	BuildDate                Key = "upgrades.buildDate"
+	NodeVersionsTable        Key = "upgrades.nodeVersionsTable"
+	VanillaUpgradeInfo       Key = "upgrades.vanillaUpgradeInfo"
+	SupportedUntil           Key = "upgrades.supportedUntil"
+	AdvisoryTitle            Key = "upgrades.advisoryTitle"
+	AdvisoryEndOfLife        Key = "upgrades.advisoryEndOfLife"
+	AdvisoryUntracked        Key = "upgrades.advisoryUntracked"
+	AdvisoryCVEs             Key = "upgrades.advisoryCVEs"
+	AdvisoryCVE              Key = "upgrades.advisoryCVE"
+	AdvisoryCVEUnfixed       Key = "upgrades.advisoryCVEUnfixed"
+	AdvisoryRecommended      Key = "upgrades.advisoryRecommended"
+	AdvisoryOneMinorAtATime  Key = "upgrades.advisoryOneMinorAtATime"
+	AdvisoryNoTarget         Key = "upgrades.advisoryNoTarget"
+	AdvisoryDataUpdated      Key = "upgrades.advisoryDataUpdated"
+	UpdateChannel            Key = "upgrades.updateChannel"
+	ClusterID                Key = "upgrades.clusterID"
+	VersionUpgrading         Key = "upgrades.versionUpgrading"
+	Progress                 Key = "upgrades.progress"
+	AvailableUpdatesTable    Key = "upgrades.availableUpdatesTable"
+	NoAvailableUpdates       Key = "upgrades.noAvailableUpdates"
+	UpgradeHistoryTable      Key = "upgrades.historyTable"
+	HistoryInProgress        Key = "upgrades.historyInProgress"
+	OLMTitle                 Key = "upgrades.olmTitle"
+	OLMNotInstalled          Key = "upgrades.olmNotInstalled"
+	OLMInstalled             Key = "upgrades.olmInstalled"
+	OLMSubscriptions         Key = "upgrades.olmSubscriptions"
+	OLMNoSubscriptions       Key = "upgrades.olmNoSubscriptions"
+	OLMTable                 Key = "upgrades.olmTable"
+	OLMUpToDate              Key = "upgrades.olmUpToDate"
+	OLMUpgradePending        Key = "upgrades.olmUpgradePending"
+	OLMUpgradeAvailable      Key = "upgrades.olmUpgradeAvailable"
+	OLMManual                Key = "upgrades.olmManual"
+	OLMAuto                  Key = "upgrades.olmAuto"
+	OLMUpgradesPending       Key = "upgrades.olmUpgradesPending"
+	OLMAllLatest             Key = "upgrades.olmAllLatest"
+	HelmTitle                Key = "upgrades.helmTitle"
+	HelmReleases             Key = "upgrades.helmReleases"
+	HelmNoReleases           Key = "upgrades.helmNoReleases"
+	HelmTable                Key = "upgrades.helmTable"
+	HelmCheckingUpdates      Key = "upgrades.helmCheckingUpdates"
+	PrereqTitle              Key = "upgrades.prereqTitle"
+	PrereqNodeHealth         Key = "upgrades.prereqNodeHealth"
+	PrereqNodesUnchecked     Key = "upgrades.prereqNodesUnchecked"
+	PrereqNodesReady         Key = "upgrades.prereqNodesReady"
+	PrereqNodesNotReady      Key = "upgrades.prereqNodesNotReady"
+	PrereqNotReadyList       Key = "upgrades.prereqNotReadyList"
+	PrereqPodHealth          Key = "upgrades.prereqPodHealth"
+	PrereqPodsUnchecked      Key = "upgrades.prereqPodsUnchecked"
+	PrereqNoCrashLoop        Key = "upgrades.prereqNoCrashLoop"
+	PrereqCrashLoop          Key = "upgrades.prereqCrashLoop"
+	PrereqMore               Key = "upgrades.prereqMore"
+	PrereqNoImagePull        Key = "upgrades.prereqNoImagePull"
+	PrereqImagePull          Key = "upgrades.prereqImagePull"
+	PrereqFewPending         Key = "upgrades.prereqFewPending"
+	PrereqManyPending        Key = "upgrades.prereqManyPending"
+	PrereqOpenShift          Key = "upgrades.prereqOpenShift"
+	PrereqOperatorsUnchecked Key = "upgrades.prereqOperatorsUnchecked"
+	PrereqNoDegradedOps      Key = "upgrades.prereqNoDegradedOperators"
+	PrereqDegradedOps        Key = "upgrades.prereqDegradedOperators"
+	PrereqOpsAvailable       Key = "upgrades.prereqOperatorsAvailable"
+	PrereqUnavailableOps     Key = "upgrades.prereqUnavailableOperators"
+	PrereqNoProgressingOps   Key = "upgrades.prereqNoProgressingOperators"
+	PrereqProgressingOps     Key = "upgrades.prereqProgressingOperators"
+	PrereqNoPoolsUpdating    Key = "upgrades.prereqNoPoolsUpdating"
+	PrereqPoolsUpdating      Key = "upgrades.prereqPoolsUpdating"
+	PrereqNoPoolsDegraded    Key = "upgrades.prereqNoPoolsDegraded"
+	PrereqPoolsDegraded      Key = "upgrades.prereqPoolsDegraded"
+	PrereqSummary            Key = "upgrades.prereqSummary"
+	PrereqPassed             Key = "upgrades.prereqPassed"
+	PrereqFailed             Key = "upgrades.prereqFailed"
+	PrereqWarnings           Key = "upgrades.prereqWarnings"
+	PrereqFixFailures        Key = "upgrades.prereqFixFailures"
+	PrereqReviewWarnings     Key = "upgrades.prereqReviewWarnings"
+	PrereqReady              Key = "upgrades.prereqReady"
+	TriggerSafetyCheck       Key = "upgrades.triggerSafetyCheck"
+	TriggerInvalidVersion    Key = "upgrades.triggerInvalidVersion"
+	TriggerNoVersions        Key = "upgrades.triggerNoVersions"
+	TriggerInitiated         Key = "upgrades.triggerInitiated"
+	StatusTitle              Key = "upgrades.statusTitle"
+	StatusNonOpenShift       Key = "upgrades.statusNonOpenShift"
+	TargetVersion            Key = "upgrades.targetVersion"
+	StatusUpgrading          Key = "upgrades.statusUpgrading"
+	StatusIdle               Key = "upgrades.statusIdle"
+	OperatorStatusTable      Key = "upgrades.operatorStatusTable"
+	PoolStatusTable          Key = "upgrades.poolStatusTable"
+	RecentHistoryTable       Key = "upgrades.recentHistoryTable"
+	ImpactTitle              Key = "upgrades.impactTitle"
+	ImpactKubernetesTarget   Key = "upgrades.impactKubernetesTarget"
+	ImpactRemovedAPIs        Key = "upgrades.impactRemovedAPIs"
+	ImpactRemovedAPIsNone    Key = "upgrades.impactRemovedAPIsNone"
+	ImpactRemovedAPIsTable   Key = "upgrades.impactRemovedAPIsTable"
+	ImpactNoReplacement      Key = "upgrades.impactNoReplacement"
+	ImpactDrains             Key = "upgrades.impactDrains"
+	ImpactDrainsNone         Key = "upgrades.impactDrainsNone"
+	ImpactDrainsTable        Key = "upgrades.impactDrainsTable"
+	ImpactAffinity           Key = "upgrades.impactAffinity"
+	ImpactAffinityNone       Key = "upgrades.impactAffinityNone"
+	ImpactAffinityTable      Key = "upgrades.impactAffinityTable"
+	ImpactOperators          Key = "upgrades.impactOperators"
+	ImpactOperatorsNone      Key = "upgrades.impactOperatorsNone"
+	ImpactOperatorsTable     Key = "upgrades.impactOperatorsTable"
+	ImpactUnsupported        Key = "upgrades.impactUnsupported"
+	ImpactNoStatement        Key = "upgrades.impactNoStatement"
+	ImpactUnchecked          Key = "upgrades.impactUnchecked"
+	ImpactMore               Key = "upgrades.impactMore"
+	ImpactSummary            Key = "upgrades.impactSummary"
+
+	TriggerDryRun        Key = "upgrades.triggerDryRun"
+	StagedInitiated      Key = "upgrades.stagedInitiated"
+	StagedHeldPools      Key = "upgrades.stagedHeldPools"
+	StagedNoWorkerPools  Key = "upgrades.stagedNoWorkerPools"
+	StagedLeftPaused     Key = "upgrades.stagedLeftPaused"
+	StagedStatusTitle    Key = "upgrades.stagedStatusTitle"
+	StagedStatusUpdating Key = "upgrades.stagedStatusUpdating"
+	StagedStatusWaiting  Key = "upgrades.stagedStatusWaiting"
+	StagedStatusStopped  Key = "upgrades.stagedStatusStopped"
+	PoolDryRun           Key = "upgrades.poolDryRun"
+	PoolPaused           Key = "upgrades.poolPaused"
+	PoolAlreadyPaused    Key = "upgrades.poolAlreadyPaused"
+	PoolUnpaused         Key = "upgrades.poolUnpaused"
+	PoolNotPaused        Key = "upgrades.poolNotPaused"
+	PoolPendingMachines  Key = "upgrades.poolPendingMachines"
+	PoolLeftStaged       Key = "upgrades.poolLeftStaged"
+)
+
+// english is the catalog every translation falls back to.
+var english = Catalog{
+	IssueObject: "\n📛 %s/%s\n",
+
+	PodIssuesNone:           "✅ No pod issues found",
+	PodIssuesFound:          "Found %d pods with issues:\n",
+	PodIssuesMissingSecrets: "\n💡 Some pods reference Secrets that do not exist. If they are synced by the External Secrets Operator, run diagnose_external_secrets to find the failed sync.\n",
+	PodIssuesWrongPlatform:  "\n💡 Some images do not match the OS or CPU architecture of their node. Build multi-arch images, or set kubernetes.io/os and kubernetes.io/arch node selectors so the pods only run on nodes their images support.\n",
+	PodPending:              "Pod is Pending",
+	PodFailed:               "Pod Failed: %s",
+	PodUnschedulable:        "Unschedulable: %s",
+	ContainerRestarts:       "Container %s has %d restarts",
+	ContainerWaiting:        "Container %s: %s - %s",
+	ContainerWrongPlatform:  "Container %s image is not built for node %s (%s)",
+	ContainerOOMKilled:      "Container %s was OOMKilled",
+	ContainerNotReady:       "Container %s running but not ready",
+	InitContainerWaiting:    "Init container %s waiting: %s",
+	NodePlatformUnknown:     "unknown platform",
+
+	DeploymentIssuesNone:     "✅ No deployment issues found",
+	DeploymentIssuesFound:    "Found %d deployments with issues:\n",
+	DeploymentReplicasReady:  "Only %d/%d replicas ready",
+	DeploymentUnavailable:    "%d replicas unavailable",
+	DeploymentRolloutStuck:   "Rollout stuck: %s",
+	DeploymentNotAvailable:   "Not available: %s",
+	DeploymentReplicaFailure: "Replica failure: %s",
+	DeploymentReplicaSetErr:  "ReplicaSet error: %s",
+
+	LimitsNone:          "✅ All pods have resource limits configured",
+	LimitsFound:         "Found %d pods without proper resource limits:\n",
+	LimitsPod:           "\n⚠️  %s/%s\n",
+	LimitsContainer:     "Container %s: %s",
+	LimitsNoCPULimit:    "no CPU limit",
+	LimitsNoMemoryLimit: "no memory limit",
+	LimitsNoCPURequest:  "no CPU request",
+	LimitsNoMemoryReq:   "no memory request",
+
+	SecurityNone:                "✅ No obvious security issues found",
+	SecurityFound:               "Found %d pods with security concerns:\n🔴 Critical | 🟠 High | 🟡 Medium\n",
+	SecurityPod:                 "\n🔓 %s/%s\n",
+	SecurityHostNetwork:         "🔴 Uses host network",
+	SecurityHostPID:             "🔴 Uses host PID namespace",
+	SecurityHostIPC:             "🔴 Uses host IPC namespace",
+	SecurityPrivileged:          "🔴 Container %s is privileged",
+	SecurityRunsAsRoot:          "🟠 Container %s runs as root (UID 0)",
+	SecurityPrivilegeEscalation: "🟡 Container %s allows privilege escalation",
+	SecurityWritableRootFS:      "🟡 Container %s has writable root filesystem",
+	SecurityNoSecurityContext:   "🟡 Container %s has no security context",
+	SecurityDockerSocket:        "🔴 Container %s mounts Docker socket",
+
+	NamespaceTitle:        "📊 Namespace Analysis: %s\n\n",
+	NamespaceStatus:       "Status: %s\n",
+	NamespaceCreated:      "Created: %s\n\n",
+	NamespaceQuotas:       "📋 Resource Quotas:\n",
+	NamespaceLimitRanges:  "📏 Limit Ranges:\n",
+	NamespacePods:         "📦 Pods:\n",
+	NamespacePodsTotal:    "  Total: %d\n",
+	NamespacePodsRunning:  "  Running: %d\n",
+	NamespacePodsPending:  "  Pending: %d ⚠️\n",
+	NamespacePodsFailed:   "  Failed: %d ❌\n",
+	NamespacePodsCrashing: "  Crashing/Restarting: %d 🔄\n",
+	NamespaceDeployments:  "🚀 Deployments: %d",
+	NamespaceUnhealthy:    " (%d unhealthy ⚠️)",
+	NamespaceServices:     "🌐 Services: %d\n",
+	NamespacePVCs:         "💾 PVCs: %d",
+	NamespacePVCsPending:  " (%d pending ⚠️)",
+	NamespaceConfigMaps:   "📄 ConfigMaps: %d\n",
+	NamespaceSecrets:      "🔐 Secrets: %d\n",
+	NamespaceWarnings:     "\n⚠️  Recent Warnings: %d events\n",
+
+	WarningEventsNone:  "✅ No warning events found",
+	WarningEventsFound: "Found %d warning events:\n\n",
+	WarningEvent:       "⚠️  [%s] %s/%s\n",
+	WarningEventCount:  "   (occurred %d times)\n",
+	WarningEventAge:    "unknown",
+
+	ClusterTypeTitle:       "# Cluster Type Detection\n\n",
+	KubernetesVersion:      "**Kubernetes Version:** %s\n",
+	ClusterType:            "**Cluster Type:** %s\n",
+	ProviderID:             "**Provider ID:** %s\n",
+	DetectedClusterVersion: "**Detection Method:** ClusterVersion CRD found (config.openshift.io/v1)\n",
+	DetectedNodeLabel:      "**Detection Method:** Node labels contain %s\n",
+	DetectedProviderID:     "**Detection Method:** Provider ID contains %s\n",
+	DetectedServerVersion:  "**Detection Method:** Server version contains %s\n",
+	DetectedNodeAnnotation: "**Detection Method:** Node annotations contain %s\n",
+	DetectedNothing:        "**Detection Method:** No specific distribution markers found\n**Note:** This appears to be a vanilla Kubernetes cluster\n",
+	DetectionNodesUnlisted: "**Note:** Unable to list nodes: %v\n",
+	DetectionNoNodes:       "**Note:** No nodes found\n",
+	VersionTitle:           "# Cluster Version Information\n\n",
+	CurrentVersion:         "**Current Version:** %s\n",
+	Platform:               "**Platform:** %s\n",
+	BuildDate:              "**Build Date:** %s\n\n",
+	NodeVersionsTable:      "## Node Versions\n\n| Node | Kubelet Version | Status |\n|------|-----------------|--------|\n",
+	VanillaUpgradeInfo: "\n## Upgrade Information\n\n" +
+		"For vanilla Kubernetes clusters, upgrade paths depend on your installation method:\n\n" +
+		"- **kubeadm**: Use `kubeadm upgrade plan` to see available versions\n" +
+		"- **EKS**: Check AWS Console or use `aws eks describe-addon-versions`\n" +
+		"- **GKE**: Check Google Cloud Console or use `gcloud container get-server-config`\n" +
+		"- **AKS**: Check Azure Portal or use `az aks get-upgrades`\n",
+	SupportedUntil:          "**Supported Until:** %s\n",
+	AdvisoryTitle:           "\n## Version Advisory\n\n",
+	AdvisoryEndOfLife:       "⚠️ **End of Life:** %s %s reached end of life on %s and no longer gets security fixes\n",
+	AdvisoryUntracked:       "⚠️ **End of Life:** %s %s is older than every supported release\n",
+	AdvisoryCVEs:            "🔴 **Known Vulnerabilities:** %d not fixed in %s\n",
+	AdvisoryCVE:             "- %s **%s** (%s): %s; fixed in %s\n",
+	AdvisoryCVEUnfixed:      "- %s **%s** (%s): %s; not fixed in %s\n",
+	AdvisoryRecommended:     "\n**Recommended Target:** %s or later\n",
+	AdvisoryOneMinorAtATime: "Minor versions are upgraded one at a time, so plan each minor version between %s and %s.\n",
+	AdvisoryNoTarget:        "\n**Recommended Target:** none known yet; watch for a release fixing these vulnerabilities\n",
+	AdvisoryDataUpdated:     "\n_Advisory data as of %s._\n",
+	UpdateChannel:           "**Update Channel:** %s\n",
+	ClusterID:               "**Cluster ID:** %s\n",
+	VersionUpgrading:        "\n**Upgrade Status:** In Progress\n",
+	Progress:                "**Progress:** %s\n",
+	AvailableUpdatesTable:   "\n## Available Updates\n\n| Version | Image |\n|---------|-------|\n",
+	NoAvailableUpdates:      "\n**Available Updates:** None (cluster is at latest version for this channel)\n",
+	UpgradeHistoryTable:     "\n## Upgrade History\n\n| Version | State | Completion Time |\n|---------|-------|------------------|\n",
+	HistoryInProgress:       "In progress",
+	OLMTitle:                "# OLM Operator Upgrades\n\n",
+	OLMNotInstalled: "**OLM Status:** Not installed\n\n" +
+		"Operator Lifecycle Manager (OLM) is not installed on this cluster.\n" +
+		"OLM is required for managing operators through subscriptions.\n\n" +
+		"To install OLM, visit: https://olm.operatorframework.io/docs/getting-started/\n",
+	OLMInstalled:        "**OLM Status:** Installed\n",
+	OLMSubscriptions:    "**Subscriptions Found:** %d\n\n",
+	OLMNoSubscriptions:  "No operator subscriptions found.\n",
+	OLMTable:            "| Operator | Namespace | Current CSV | Channel | Auto-Update | Status |\n|----------|-----------|-------------|---------|-------------|--------|\n",
+	OLMUpToDate:         "Up to date",
+	OLMUpgradePending:   "Upgrade pending",
+	OLMUpgradeAvailable: "Upgrade available",
+	OLMManual:           "Manual",
+	OLMAuto:             "Auto",
+	OLMUpgradesPending:  "**Upgrades Available:** %d operator(s) have pending upgrades\n",
+	OLMAllLatest:        "**Upgrades Available:** All operators are at their latest known version\n",
+	HelmTitle:           "# Helm Releases\n\n",
+	HelmReleases:        "**Helm Releases Found:** %d\n\n",
+	HelmNoReleases:      "No Helm releases found in the cluster.\n",
+	HelmTable:           "| Release | Namespace | Chart | Version | App Version | Status |\n|---------|-----------|-------|---------|-------------|--------|\n",
+	HelmCheckingUpdates: "\n## Checking for Updates\n\n" +
+		"To check for available chart updates, you need to:\n\n" +
+		"1. Ensure Helm repos are added: `helm repo list`\n" +
+		"2. Update repos: `helm repo update`\n" +
+		"3. Search for updates: `helm search repo <chart-name>`\n\n" +
+		"**Note:** This tool shows currently deployed releases. Checking for newer chart versions\n" +
+		"requires access to Helm repositories which are typically configured on the client side.\n",
+	PrereqTitle:              "# Upgrade Prerequisites Check\n\n",
+	PrereqNodeHealth:         "## Node Health\n\n",
+	PrereqNodesUnchecked:     "- [ ] Unable to check nodes: %v\n",
+	PrereqNodesReady:         "- [x] All nodes ready (%d/%d)\n",
+	PrereqNodesNotReady:      "- [ ] Some nodes not ready (%d/%d)\n",
+	PrereqNotReadyList:       "  - Not ready: %s\n",
+	PrereqPodHealth:          "\n## Pod Health\n\n",
+	PrereqPodsUnchecked:      "- [ ] Unable to check pods: %v\n",
+	PrereqNoCrashLoop:        "- [x] No pods in CrashLoopBackOff\n",
+	PrereqCrashLoop:          "- [ ] %d pods in CrashLoopBackOff\n",
+	PrereqMore:               "  - ... and %d more\n",
+	PrereqNoImagePull:        "- [x] No pods with image pull errors\n",
+	PrereqImagePull:          "- [ ] %d pods with image pull errors\n",
+	PrereqFewPending:         "- [x] Few pending pods (%d)\n",
+	PrereqManyPending:        "- [ ] Many pending pods (%d)\n",
+	PrereqOpenShift:          "\n## OpenShift-Specific Checks\n\n",
+	PrereqOperatorsUnchecked: "- [ ] Unable to check ClusterOperators: %v\n",
+	PrereqNoDegradedOps:      "- [x] No degraded ClusterOperators\n",
+	PrereqDegradedOps:        "- [ ] %d degraded ClusterOperators: %s\n",
+	PrereqOpsAvailable:       "- [x] All ClusterOperators available\n",
+	PrereqUnavailableOps:     "- [ ] %d unavailable ClusterOperators: %s\n",
+	PrereqNoProgressingOps:   "- [x] No ClusterOperators progressing\n",
+	PrereqProgressingOps:     "- [ ] %d ClusterOperators progressing: %s\n",
+	PrereqNoPoolsUpdating:    "- [x] No MachineConfigPools updating\n",
+	PrereqPoolsUpdating:      "- [ ] %d MachineConfigPools updating: %s\n  - Wait for current updates to complete before upgrading\n",
+	PrereqNoPoolsDegraded:    "- [x] No MachineConfigPools degraded\n",
+	PrereqPoolsDegraded:      "- [ ] %d MachineConfigPools degraded: %s\n",
+	PrereqSummary:            "\n## Summary\n\n",
+	PrereqPassed:             "- **Passed:** %d\n",
+	PrereqFailed:             "- **Failed:** %d\n",
+	PrereqWarnings:           "- **Warnings:** %d\n\n",
+	PrereqFixFailures:        "**Recommendation:** Fix the failed checks before proceeding with the upgrade.\n",
+	PrereqReviewWarnings:     "**Recommendation:** Review warnings before proceeding. The upgrade can proceed but may encounter issues.\n",
+	PrereqReady:              "**Recommendation:** All prerequisites passed. The cluster is ready for upgrade.\n",
+	TriggerSafetyCheck: "# Safety Check Failed\n\n" +
+		"**IMPORTANT:** Cluster upgrades are significant operations that will:\n" +
+		"- Temporarily make the API server unavailable\n" +
+		"- Rolling restart all nodes\n" +
+		"- Potentially impact running workloads\n\n" +
+		"To proceed with the upgrade, you must pass `confirm='yes-upgrade-now'`\n\n" +
+		"**Before confirming:**\n" +
+		"1. Run `get_upgrade_prerequisites` to verify cluster readiness\n" +
+		"2. Ensure you have recent etcd backups\n" +
+		"3. Notify relevant teams about the maintenance window\n" +
+		"4. Verify the target version is in the available updates list\n",
+	TriggerInvalidVersion: "# Invalid Target Version\n\n" +
+		"Version `%s` is not in the list of available updates.\n\n" +
+		"**Available versions:**\n",
+	TriggerNoVersions: "- (none available - cluster may be at latest version)\n",
+	TriggerInitiated: "# Upgrade Initiated\n\n" +
+		"**Target Version:** %s\n" +
+		"**Status:** Upgrade has been triggered\n\n" +
+		"The cluster will now begin the upgrade process. This typically takes:\n" +
+		"- 30-60 minutes for control plane\n" +
+		"- Additional time for worker nodes (depends on node count)\n\n" +
+		"**Monitor progress with:**\n" +
+		"- `get_upgrade_status` - Check overall progress\n" +
+		"- `get_cluster_health` - Monitor cluster health\n" +
+		"- `find_pod_issues` - Check for pod problems during upgrade\n\n" +
+		"**Important:** Do not make additional changes to the cluster during the upgrade.\n",
+	StatusTitle: "# Upgrade Status\n\n",
+	StatusNonOpenShift: "\n**Note:** For non-OpenShift clusters, detailed upgrade progress tracking\n" +
+		"depends on your installation method (kubeadm, EKS, GKE, AKS, etc.)\n",
+	TargetVersion:       "**Target Version:** %s\n",
+	StatusUpgrading:     "**Status:** Upgrade in progress\n",
+	StatusIdle:          "**Status:** Not currently upgrading\n\n",
+	OperatorStatusTable: "## ClusterOperator Status\n\n| Operator | Available | Progressing | Degraded |\n|----------|-----------|-------------|----------|\n",
+	PoolStatusTable:     "\n## MachineConfigPool Status\n\n| Pool | Ready | Updated | Updating | Degraded | Paused |\n|------|-------|---------|----------|----------|--------|\n",
+	RecentHistoryTable:  "\n## Recent History\n\n| Version | State | Started | Completed |\n|---------|-------|---------|------------|\n",
+
+	ImpactTitle:            "# Upgrade Impact: %s to %s\n\n",
+	ImpactKubernetesTarget: "**Target Kubernetes Version:** %s\n",
+	ImpactRemovedAPIs:      "\n## Removed APIs\n\n",
+	ImpactRemovedAPIsNone:  "✅ No objects or Helm releases use APIs removed by %s\n",
+	ImpactRemovedAPIsTable: "| Object | API | Removed In | Migrate To | Found In |\n|--------|-----|------------|------------|----------|\n",
+	ImpactNoReplacement:    "none; remove it",
+	ImpactDrains:           "\n## Drain Availability\n\n",
+	ImpactDrainsNone:       "✅ Every Deployment keeps ready replicas while a node drains\n",
+	ImpactDrainsTable: "Deployments without a PodDisruptionBudget that drop to zero ready replicas while a node drains:\n\n" +
+		"| Deployment | Replicas | Nodes |\n|------------|----------|-------|\n",
+	ImpactAffinity:     "\n## Node Affinity\n\n",
+	ImpactAffinityNone: "✅ No pods are limited to a single node\n",
+	ImpactAffinityTable: "Pods whose node selector or required node affinity matches fewer than two nodes, so they stay Pending while their node is drained or replaced:\n\n" +
+		"| Pod | Constraint | Matching Nodes |\n|-----|------------|----------------|\n",
+	ImpactOperators:      "\n## Operator Support\n\n",
+	ImpactOperatorsNone:  "✅ Every third-party operator states support for %s\n",
+	ImpactOperatorsTable: "| Operator | Installed By | Version | Support for %s |\n|----------|--------------|---------|-------------|\n",
+	ImpactUnsupported:    "❌ Not supported (%s)",
+	ImpactNoStatement:    "⚠️ No support statement",
+	ImpactUnchecked:      "⚠️ Unable to check: %v\n",
+	ImpactMore:           "\n... and %d more\n",
+	ImpactSummary:        "\n**Summary:** %d removed API uses, %d Deployments at risk during drains, %d pods that may be stranded, %d operators without support for %s\n",
+
+	TriggerDryRun: "# Upgrade Not Started (Dry Run)\n\n" +
+		"**Target Version:** %s\n\n" +
+		"The API server accepted the changes below but did not apply them.\n",
+	StagedInitiated: "# Staged Upgrade Initiated\n\n" +
+		"**Target Version:** %s\n\n" +
+		"The control plane upgrades first while the worker pools stay paused. Once the upgrade completes and the master pool has updated, " +
+		"the worker pools are unpaused %d at a time. Each batch must have every machine updated and ready, with no pool or ClusterOperator degraded, " +
+		"within %s before the next batch starts; otherwise the upgrade stops with the remaining pools paused.\n\n" +
+		"**Monitor progress with** `get_upgrade_status`. This server resumes the pools: if it restarts, or the upgrade stops, call " +
+		"`trigger_openshift_upgrade` again with `staged` to continue, or `unpause_mcp` to update pools by hand.\n\n",
+	StagedHeldPools:      "**Worker pools held until the control plane upgrades:** %s\n",
+	StagedNoWorkerPools:  "**Worker pools:** none besides master, so nothing is held back\n",
+	StagedLeftPaused:     "**Left paused:** %s (paused before the upgrade; unpause them with `unpause_mcp`)\n",
+	StagedStatusTitle:    "\n## Staged Upgrade to %s\n\n",
+	StagedStatusUpdating: "- Updating: %s\n",
+	StagedStatusWaiting:  "- Paused until their batch: %s\n",
+	StagedStatusStopped:  "- ❌ Stopped: %s. Fix it, then call `trigger_openshift_upgrade` with `staged` to continue\n",
+	PoolDryRun:           "**Dry run:** the API server accepted the change but did not apply it.\n\n",
+	PoolPaused: "# MachineConfigPool Paused\n\n" +
+		"**Pool:** %s\n\n" +
+		"Its machines keep their current configuration, and new configurations, including those of upgrades, wait until it is unpaused. " +
+		"**Do not leave it paused for long:** paused machines also miss certificate rotations, and nodes whose certificates expire stop working.\n",
+	PoolAlreadyPaused:   "MachineConfigPool `%s` is already paused\n",
+	PoolUnpaused:        "# MachineConfigPool Unpaused\n\n**Pool:** %s\n",
+	PoolNotPaused:       "MachineConfigPool `%s` is not paused\n",
+	PoolPendingMachines: "\n%d of %d machines will now update to the pool's current configuration. Follow them with `get_upgrade_status`.\n",
+	PoolLeftStaged:      "\nThe pool is no longer held by the staged upgrade to %s.\n",
+}
@@ -0,0 +1,171 @@
+// Package messages is the catalog of the text tool reports are written
+// in. Each message has a Key and an English fmt template; a translation
+// file named by $KUBESTELLAR_MESSAGES replaces templates by key, so the
+// reports can be localized without touching the tools, and the programs
+// that read reports, such as the dashboard, match them through Pattern
+// rather than hardcoded English.
+//
+// A translation file is YAML or JSON mapping keys to templates, which must
+// take the same arguments as the English ones:
+//
+//	podIssues.none: "✅ Keine Pod-Probleme gefunden"
+//	podIssues.found: "%d Pods mit Problemen gefunden:\n"
+package messages
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// EnvMessages is a translation file for the catalog. Unset keeps English.
+const EnvMessages = "KUBESTELLAR_MESSAGES"
+
+// Key names a message.
+type Key string
+
+// Catalog maps keys to fmt templates. Keys it lacks fall back to English.
+type Catalog map[Key]string
+
+// Get returns the template of key.
+func (c Catalog) Get(key Key) string {
+	if tmpl, ok := c[key]; ok {
+		return tmpl
+	}
+	return english[key]
+}
+
+// Sprintf formats the message key with args.
+func (c Catalog) Sprintf(key Key, args ...interface{}) string {
+	return fmt.Sprintf(c.Get(key), args...)
+}
+
+// Keys returns the keys of every message, sorted.
+func Keys() []Key {
+	keys := make([]Key, 0, len(english))
+	for k := range english {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// verbPattern matches the fmt verbs of a template, with any explicit
+// argument index.
+var verbPattern = regexp.MustCompile(`%(?:\[\d+\])?[-+# 0]*\d*(?:\.\d+)?[a-zA-Z%]`)
+
+// verbs returns the sorted verbs of tmpl without flags or indexes, which
+// a translation may reorder.
+func verbs(tmpl string) []string {
+	var found []string
+	for _, v := range verbPattern.FindAllString(tmpl, -1) {
+		if v == "%%" {
+			continue
+		}
+		found = append(found, v[len(v)-1:])
+	}
+	sort.Strings(found)
+	return found
+}
+
+// Load reads a translation file.
+func Load(path string) (Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read messages: %w", err)
+	}
+	var raw map[string]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid messages file %s: %w", path, err)
+	}
+	c := make(Catalog, len(raw))
+	for k, tmpl := range raw {
+		en, ok := english[Key(k)]
+		if !ok {
+			return nil, fmt.Errorf("invalid messages file %s: unknown message %q", path, k)
+		}
+		if got, want := verbs(tmpl), verbs(en); strings.Join(got, "") != strings.Join(want, "") {
+			return nil, fmt.Errorf("invalid messages file %s: %s must take the arguments of %q", path, k, en)
+		}
+		c[Key(k)] = tmpl
+	}
+	return c, nil
+}
+
+// FromEnv loads the translation named by $KUBESTELLAR_MESSAGES, or returns
+// an empty catalog, which is English.
+func FromEnv() (Catalog, error) {
+	path := strings.TrimSpace(os.Getenv(EnvMessages))
+	if path == "" {
+		return Catalog{}, nil
+	}
+	return Load(path)
+}
+
+var (
+	current     Catalog
+	currentOnce sync.Once
+)
+
+// Current returns the catalog loaded from the environment on first use.
+// An invalid translation is logged and English used instead.
+func Current() Catalog {
+	currentOnce.Do(func() {
+		c, err := FromEnv()
+		if err != nil {
+			log.Printf("Using English messages: %v", err)
+			c = Catalog{}
+		}
+		current = c
+	})
+	return current
+}
+
+// Get returns the template of key in the current catalog.
+func Get(key Key) string {
+	return Current().Get(key)
+}
+
+// Sprintf formats the message key in the current catalog.
+func Sprintf(key Key, args ...interface{}) string {
+	return Current().Sprintf(key, args...)
+}
+
+// Pattern returns a regular expression matching the message key in the
+// current catalog, with a group capturing each argument, so programs can
+// read reports in any language. Text made plain by ASCII is matched too.
+func Pattern(key Key) *regexp.Regexp {
+	tmpl := strings.TrimSpace(Get(key))
+	var b strings.Builder
+	last := 0
+	for _, loc := range verbPattern.FindAllStringIndex(tmpl, -1) {
+		b.WriteString(literal(tmpl[last:loc[0]]))
+		if tmpl[loc[0]:loc[1]] == "%%" {
+			b.WriteString("%")
+		} else {
+			b.WriteString(`(.+?)`)
+		}
+		last = loc[1]
+	}
+	b.WriteString(literal(tmpl[last:]))
+	expr := b.String()
+	if strings.HasSuffix(expr, `(.+?)`) {
+		// A trailing argument runs to the end of its line.
+		expr = strings.TrimSuffix(expr, `(.+?)`) + `(.+)`
+	}
+	return regexp.MustCompile(expr)
+}
+
+// literal quotes s for Pattern, matching it as written or as ASCII made it.
+func literal(s string) string {
+	if plain := ASCII(s); plain != s {
+		return "(?:" + regexp.QuoteMeta(s) + "|" + regexp.QuoteMeta(plain) + ")"
+	}
+	return regexp.QuoteMeta(s)
+}
@@ -0,0 +1,82 @@
+package messages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeMessages(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "messages.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	c, err := Load(writeMessages(t, "podIssues.found: \"%d Pods mit Problemen gefunden:\\n\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "3 Pods mit Problemen gefunden:\n", c.Sprintf(PodIssuesFound, 3))
+	assert.Equal(t, "✅ No pod issues found", c.Get(PodIssuesNone), "missing keys fall back to English")
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown key", "podIssues.nothing: \"x\"\n", `unknown message "podIssues.nothing"`},
+		{"missing argument", "podIssues.found: \"Pods mit Problemen\"\n", "podIssues.found must take the arguments of"},
+		{"wrong verb", "podIssues.found: \"%s Pods\"\n", "podIssues.found must take the arguments of"},
+		{"not a map", "- a\n- b\n", "invalid messages file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeMessages(t, tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	_, err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read messages")
+}
+
+func TestLoad_ReorderedArguments(t *testing.T) {
+	_, err := Load(writeMessages(t, "upgrades.prereqFailed: \"- **Fehlgeschlagen:** %[1]d\\n\"\n"))
+	assert.NoError(t, err)
+}
+
+func TestEnglishTemplatesAreUnique(t *testing.T) {
+	seen := map[string]Key{}
+	for _, k := range Keys() {
+		tmpl := english[k]
+		if other, ok := seen[tmpl]; ok {
+			t.Errorf("%s and %s share the template %q", other, k, tmpl)
+		}
+		seen[tmpl] = k
+	}
+}
+
+func TestPattern(t *testing.T) {
+	re := Pattern(PrereqFailed)
+	m := re.FindStringSubmatch("## Prerequisites\n- **Passed:** 4\n- **Failed:** 2\n")
+	require.Len(t, m, 2)
+	assert.Equal(t, "2", m[1])
+
+	re = Pattern(TargetVersion)
+	m = re.FindStringSubmatch("**Target Version:** v1.30.2\n**Status:** ok")
+	require.Len(t, m, 2)
+	assert.Equal(t, "v1.30.2", m[1])
+}
+
+func TestPattern_MatchesASCII(t *testing.T) {
+	re := Pattern(PodIssuesNone)
+	assert.True(t, re.MatchString("✅ No pod issues found"))
+	assert.True(t, re.MatchString(ASCII("✅ No pod issues found")))
+}
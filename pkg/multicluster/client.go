@@ -2,6 +2,7 @@ package multicluster
 
 import (
 	"fmt"
+	"os"
 	"sync"
 
 	"k8s.io/client-go/kubernetes"
@@ -26,6 +27,12 @@ type ClientManager struct {
 	mu             sync.RWMutex
 	rawConfig      api.Config
 	currentContext string
+	// resolver maps the cluster names tools are given to contexts; nil
+	// treats every name as a context.
+	resolver *Resolver
+	// transform, when set, adjusts every REST config before it is cached
+	// or used to build a client (e.g. to apply namespace guardrails).
+	transform func(*rest.Config) *rest.Config
 }
 
 // NewClientManager creates a new multi-cluster client manager
@@ -42,6 +49,10 @@ func NewClientManager(kubeconfig string) (*ClientManager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
+	aliases, err := ParseClusterAliases(os.Getenv(EnvClusterAliases))
+	if err != nil {
+		return nil, err
+	}
 
 	return &ClientManager{
 		kubeconfig:     kubeconfig,
@@ -49,9 +60,18 @@ func NewClientManager(kubeconfig string) (*ClientManager, error) {
 		configs:        make(map[string]*rest.Config),
 		rawConfig:      rawConfig,
 		currentContext: rawConfig.CurrentContext,
+		resolver:       NewResolver(rawConfig, aliases),
 	}, nil
 }
 
+// SetConfigTransform sets a function applied to every REST config the
+// manager hands out. It must be called before the first client is created.
+func (m *ClientManager) SetConfigTransform(transform func(*rest.Config) *rest.Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transform = transform
+}
+
 // DiscoverClusters returns all clusters from kubeconfig
 func (m *ClientManager) DiscoverClusters() ([]ClusterInfo, error) {
 	var clusters []ClusterInfo
@@ -73,7 +93,9 @@ func (m *ClientManager) DiscoverClusters() ([]ClusterInfo, error) {
 	return clusters, nil
 }
 
-// GetClient returns a Kubernetes client for the specified cluster
+// GetClient returns a Kubernetes client for the specified cluster, named
+// by anything the manager's Resolver accepts. Clients are cached per
+// context, so aliases of a cluster share one.
 func (m *ClientManager) GetClient(clusterName string) (*kubernetes.Clientset, error) {
 	m.mu.RLock()
 	client, exists := m.clients[clusterName]
@@ -83,27 +105,34 @@ func (m *ClientManager) GetClient(clusterName string) (*kubernetes.Clientset, er
 		return client, nil
 	}
 
+	contextName, err := m.resolveContext(clusterName)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create new client
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Double-check after acquiring write lock
-	if client, exists := m.clients[clusterName]; exists {
+	if client, exists := m.clients[contextName]; exists {
 		return client, nil
 	}
 
-	config, err := m.getConfigForContext(clusterName)
+	config, err := m.getConfigForContext(contextName)
 	if err != nil {
 		return nil, err
 	}
 
-	client, err = kubernetes.NewForConfig(config)
+	// The typed client speaks protobuf; the cached config stays JSON for
+	// dynamic clients built from GetConfig.
+	client, err = kubernetes.NewForConfig(ProtobufConfig(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client for %s: %w", clusterName, err)
 	}
 
-	m.clients[clusterName] = client
-	m.configs[clusterName] = config
+	m.clients[contextName] = client
+	m.configs[contextName] = config
 
 	return client, nil
 }
@@ -123,10 +152,22 @@ func (m *ClientManager) GetConfig(clusterName string) (*rest.Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	contextName, err := m.resolveContext(clusterName)
+	if err != nil {
+		return nil, err
+	}
 
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.configs[clusterName], nil
+	return m.configs[contextName], nil
+}
+
+// resolveContext returns the context of the cluster called clusterName.
+func (m *ClientManager) resolveContext(clusterName string) (string, error) {
+	if m.resolver == nil {
+		return clusterName, nil
+	}
+	return m.resolver.Resolve(clusterName)
 }
 
 // getConfigForContext creates a REST config for a specific context
@@ -145,6 +186,9 @@ func (m *ClientManager) getConfigForContext(contextName string) (*rest.Config, e
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config for context %s: %w", contextName, err)
 	}
+	if m.transform != nil {
+		config = m.transform(config)
+	}
 
 	return config, nil
 }
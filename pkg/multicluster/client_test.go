@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 )
@@ -70,6 +71,25 @@ func TestGetClientAndConfigCacheByCluster(t *testing.T) {
 	}
 }
 
+func TestSetConfigTransformAppliesToCachedConfigs(t *testing.T) {
+	manager := newClientManagerFromKubeconfig(t, map[string]string{
+		"alpha": "https://alpha.example.com",
+	}, "alpha")
+	manager.SetConfigTransform(func(config *rest.Config) *rest.Config {
+		config = rest.CopyConfig(config)
+		config.UserAgent = "guarded"
+		return config
+	})
+
+	config, err := manager.GetConfig("alpha")
+	if err != nil {
+		t.Fatalf("GetConfig() error = %v", err)
+	}
+	if config.UserAgent != "guarded" {
+		t.Fatalf("UserAgent = %q, want transform applied", config.UserAgent)
+	}
+}
+
 func TestGetClientUnknownContext(t *testing.T) {
 	manager := newClientManagerFromKubeconfig(t, map[string]string{
 		"alpha": "https://alpha.example.com",
@@ -2,34 +2,79 @@ package multicluster
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
 )
 
 // ClusterResult represents the result of an operation on a single cluster
 type ClusterResult struct {
-	Cluster string      `json:"cluster"`
-	Result  interface{} `json:"result,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Cluster    string      `json:"cluster"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	ErrorClass retry.Class `json:"errorClass,omitempty"`
 }
 
-const defaultMaxConcurrentClusterOperations = 20
+const (
+	defaultMaxConcurrentClusterOperations = 20
+	// defaultRetryBudget caps retries across every cluster call made by one
+	// executor so a fleet-wide outage does not multiply API traffic.
+	defaultRetryBudget       = 50
+	defaultRetryBudgetWindow = time.Minute
+)
 
 // Executor handles multi-cluster operations
 type Executor struct {
 	manager        *ClientManager
 	maxConcurrency int
+	retryPolicy    retry.Policy
 }
 
 // NewExecutor creates a new multi-cluster executor
 func NewExecutor(manager *ClientManager) *Executor {
+	policy := retry.DefaultPolicy()
+	policy.Budget = retry.NewBudget(defaultRetryBudget, defaultRetryBudgetWindow)
 	return &Executor{
 		manager:        manager,
 		maxConcurrency: defaultMaxConcurrentClusterOperations,
+		retryPolicy:    policy,
 	}
 }
 
+// SetRetryPolicy replaces the policy used to retry transient cluster errors.
+func (e *Executor) SetRetryPolicy(policy retry.Policy) {
+	e.retryPolicy = policy
+}
+
+// errorResult builds a failed ClusterResult carrying the error's class.
+func errorResult(clusterName string, err error) ClusterResult {
+	return ClusterResult{
+		Cluster:    clusterName,
+		Error:      err.Error(),
+		ErrorClass: retry.Classify(err),
+	}
+}
+
+// run invokes fn, retrying transient failures according to the retry policy
+// when ctx marks the operation idempotent (see retry.WithIdempotent).
+func (e *Executor) run(ctx context.Context, client *kubernetes.Clientset, clusterName string, fn ExecuteFunc) (interface{}, error) {
+	if !retry.IsIdempotent(ctx) {
+		return fn(ctx, client, clusterName)
+	}
+	var result interface{}
+	err := retry.Do(ctx, e.retryPolicy, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx, client, clusterName)
+		return err
+	})
+	return result, err
+}
+
 // ExecuteFunc is the function type for operations that run on a single cluster
 type ExecuteFunc func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error)
 
@@ -48,26 +93,28 @@ func (e *Executor) Execute(ctx context.Context, clusterName string, fn ExecuteFu
 
 // executeSingle runs the operation on a single cluster
 func (e *Executor) executeSingle(ctx context.Context, clusterName string, fn ExecuteFunc) ([]ClusterResult, error) {
+	if fanout.Skip(ctx, clusterName) {
+		return []ClusterResult{}, nil
+	}
+	return []ClusterResult{e.runOnCluster(ctx, clusterName, fn)}, nil
+}
+
+// runOnCluster runs the operation on one cluster and records its status for
+// the call's fan-out status matrix.
+func (e *Executor) runOnCluster(ctx context.Context, clusterName string, fn ExecuteFunc) ClusterResult {
+	start := time.Now()
 	client, err := e.manager.GetClient(clusterName)
 	if err != nil {
-		return []ClusterResult{{
-			Cluster: clusterName,
-			Error:   err.Error(),
-		}}, nil
+		fanout.Record(ctx, clusterName, err, time.Since(start))
+		return errorResult(clusterName, err)
 	}
 
-	result, err := fn(ctx, client, clusterName)
+	result, err := e.run(ctx, client, clusterName, fn)
+	fanout.Record(ctx, clusterName, err, time.Since(start))
 	if err != nil {
-		return []ClusterResult{{
-			Cluster: clusterName,
-			Error:   err.Error(),
-		}}, nil
+		return errorResult(clusterName, err)
 	}
-
-	return []ClusterResult{{
-		Cluster: clusterName,
-		Result:  result,
-	}}, nil
+	return ClusterResult{Cluster: clusterName, Result: result}
 }
 
 // executeAll runs the operation across all clusters in parallel
@@ -92,47 +139,79 @@ func (e *Executor) ExecuteOnSelected(ctx context.Context, clusterNames []string,
 
 func (e *Executor) executeAcrossClusters(ctx context.Context, clusterNames []string, fn ExecuteFunc) []ClusterResult {
 	results := make([]ClusterResult, 0, len(clusterNames))
+	e.ExecuteStream(ctx, clusterNames, fn, func(result ClusterResult) {
+		results = append(results, result)
+	})
+	return results
+}
+
+// ExecuteStream runs the operation on the named clusters in parallel and hands
+// each cluster's result to emit as soon as it completes, so callers can
+// forward or fold results without holding the whole fleet's output at once.
+// emit is never called concurrently.
+func (e *Executor) ExecuteStream(ctx context.Context, clusterNames []string, fn ExecuteFunc, emit func(ClusterResult)) {
 	sem := make(chan struct{}, e.concurrencyLimit())
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
 	for _, clusterName := range clusterNames {
+		if fanout.Skip(ctx, clusterName) {
+			continue
+		}
 		sem <- struct{}{}
 		wg.Add(1)
 		go func(name string) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			client, err := e.manager.GetClient(name)
-			if err != nil {
-				mu.Lock()
-				results = append(results, ClusterResult{
-					Cluster: name,
-					Error:   err.Error(),
-				})
-				mu.Unlock()
-				return
-			}
-
-			result, err := fn(ctx, client, name)
+			result := e.runOnCluster(ctx, name, fn)
+
 			mu.Lock()
-			if err != nil {
-				results = append(results, ClusterResult{
-					Cluster: name,
-					Error:   err.Error(),
-				})
-			} else {
-				results = append(results, ClusterResult{
-					Cluster: name,
-					Result:  result,
-				})
-			}
+			emit(result)
 			mu.Unlock()
 		}(clusterName)
 	}
 
 	wg.Wait()
-	return results
+}
+
+// ClusterNames returns every discovered cluster name in sorted order, giving
+// paginated callers a stable iteration order across requests.
+func (e *Executor) ClusterNames() ([]string, error) {
+	clusters, err := e.manager.DiscoverClusters()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		names = append(names, cluster.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// PageClusters returns the slice of sorted cluster names that follows the
+// continue token (the last cluster of the previous page) and the token for
+// the next page, which is empty once the fleet is exhausted. A non-positive
+// pageSize returns every remaining cluster.
+func PageClusters(names []string, continueToken string, pageSize int) ([]string, string) {
+	start := 0
+	if continueToken != "" {
+		start = sort.SearchStrings(names, continueToken)
+		if start < len(names) && names[start] == continueToken {
+			start++
+		}
+	}
+	if start >= len(names) {
+		return nil, ""
+	}
+
+	remaining := names[start:]
+	if pageSize <= 0 || pageSize >= len(remaining) {
+		return remaining, ""
+	}
+	page := remaining[:pageSize]
+	return page, page[len(page)-1]
 }
 
 func (e *Executor) concurrencyLimit() int {
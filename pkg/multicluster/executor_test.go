@@ -9,9 +9,14 @@ import (
 	"testing"
 	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/fanout"
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
 )
 
 func TestExecutorExecuteSingleCluster(t *testing.T) {
@@ -183,6 +188,161 @@ func TestExecutorExecuteOnSelectedBoundsConcurrency(t *testing.T) {
 	}
 }
 
+func TestExecutorRetriesTransientErrorsAndClassifiesFailures(t *testing.T) {
+	manager := newTestManager(t, []string{"alpha", "beta"})
+	executor := NewExecutor(manager)
+	executor.SetRetryPolicy(retry.Policy{MaxAttempts: 3})
+
+	var alphaCalls atomic.Int32
+	results, err := executor.Execute(retry.WithIdempotent(context.Background()), "", func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		if clusterName == "beta" {
+			return nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("rbac"))
+		}
+		if alphaCalls.Add(1) < 3 {
+			return nil, apierrors.NewTooManyRequests("slow down", 1)
+		}
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	byCluster := make(map[string]ClusterResult, len(results))
+	for _, result := range results {
+		byCluster[result.Cluster] = result
+	}
+	if got := byCluster["alpha"]; got.Result != "ok" || got.ErrorClass != "" {
+		t.Fatalf("unexpected alpha result: %#v", got)
+	}
+	if alphaCalls.Load() != 3 {
+		t.Fatalf("alpha calls = %d, want 3", alphaCalls.Load())
+	}
+	if got := byCluster["beta"]; got.ErrorClass != retry.ClassAuth {
+		t.Fatalf("beta errorClass = %q, want %q", got.ErrorClass, retry.ClassAuth)
+	}
+}
+
+func TestExecutorDoesNotRetryNonIdempotentCalls(t *testing.T) {
+	manager := newTestManager(t, []string{"alpha"})
+	executor := NewExecutor(manager)
+	executor.SetRetryPolicy(retry.Policy{MaxAttempts: 3})
+
+	var calls atomic.Int32
+	results, err := executor.Execute(context.Background(), "alpha", func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		calls.Add(1)
+		return nil, apierrors.NewTooManyRequests("slow down", 1)
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1: a write must not be sent twice", calls.Load())
+	}
+	if len(results) != 1 || results[0].ErrorClass != retry.ClassThrottled {
+		t.Fatalf("unexpected results: %#v", results)
+	}
+}
+
+func TestExecutorExecuteStreamEmitsEachResult(t *testing.T) {
+	manager := newTestManager(t, []string{"alpha", "beta", "gamma"})
+	executor := NewExecutor(manager)
+
+	var emitted []string
+	executor.ExecuteStream(context.Background(), []string{"alpha", "beta", "gamma"}, func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return clusterName, nil
+	}, func(result ClusterResult) {
+		emitted = append(emitted, result.Result.(string))
+	})
+
+	sort.Strings(emitted)
+	if fmt.Sprint(emitted) != fmt.Sprint([]string{"alpha", "beta", "gamma"}) {
+		t.Fatalf("emitted = %v, want [alpha beta gamma]", emitted)
+	}
+}
+
+func TestExecutorRecordsClusterStatusForRetry(t *testing.T) {
+	manager := newTestManager(t, []string{"alpha", "beta", "gamma"})
+	executor := NewExecutor(manager)
+	executor.SetRetryPolicy(retry.Policy{MaxAttempts: 1})
+	var calls atomic.Int32
+	fn := func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		calls.Add(1)
+		switch clusterName {
+		case "beta":
+			return nil, errors.New("admission webhook denied the request")
+		case "gamma":
+			return nil, errors.New("dial tcp 10.0.0.3:6443: connect: connection refused")
+		}
+		return "ok", nil
+	}
+
+	statuses := fanout.NewRecorder()
+	ctx := fanout.WithRecorder(context.Background(), statuses)
+	executor.ExecuteOnSelected(ctx, []string{"alpha", "beta", "gamma"}, fn)
+	got := make(map[string]fanout.Status)
+	for _, s := range statuses.Matrix() {
+		got[s.Cluster] = s.Status
+	}
+	if fmt.Sprint(got) != "map[alpha:ok beta:failed gamma:unreachable]" {
+		t.Fatalf("statuses = %v", got)
+	}
+
+	memory := fanout.NewMemory()
+	memory.Remember("call", statuses)
+	statuses = fanout.NewRecorder()
+	ctx, err := memory.Retry(fanout.WithRecorder(context.Background(), statuses), "call")
+	if err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	calls.Store(0)
+	results, _ := executor.ExecuteOnSelected(ctx, []string{"alpha", "beta", "gamma"}, fn)
+	if len(results) != 2 || calls.Load() != 2 {
+		t.Fatalf("retry ran %d operations with %d results, want 2", calls.Load(), len(results))
+	}
+	if matrix := statuses.Matrix(); matrix[0].Cluster != "alpha" || matrix[0].Status != fanout.StatusSkipped {
+		t.Fatalf("retry statuses = %+v", matrix)
+	}
+}
+
+func TestExecutorClusterNamesSorted(t *testing.T) {
+	executor := NewExecutor(newTestManager(t, []string{"gamma", "alpha", "beta"}))
+
+	names, err := executor.ClusterNames()
+	if err != nil {
+		t.Fatalf("ClusterNames() error = %v", err)
+	}
+	if fmt.Sprint(names) != fmt.Sprint([]string{"alpha", "beta", "gamma"}) {
+		t.Fatalf("ClusterNames() = %v, want sorted", names)
+	}
+}
+
+func TestPageClusters(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name      string
+		token     string
+		pageSize  int
+		wantPage  []string
+		wantToken string
+	}{
+		{"all when unpaged", "", 0, names, ""},
+		{"first page", "", 2, []string{"a", "b"}, "b"},
+		{"middle page", "b", 2, []string{"c", "d"}, "d"},
+		{"last page", "d", 2, []string{"e"}, ""},
+		{"exact fit", "c", 2, []string{"d", "e"}, ""},
+		{"token for removed cluster", "bb", 2, []string{"c", "d"}, "d"},
+		{"exhausted", "e", 2, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, next := PageClusters(names, tt.token, tt.pageSize)
+			if fmt.Sprint(page) != fmt.Sprint(tt.wantPage) || next != tt.wantToken {
+				t.Fatalf("PageClusters() = %v, %q; want %v, %q", page, next, tt.wantPage, tt.wantToken)
+			}
+		})
+	}
+}
+
 func newTestManager(t *testing.T, clusters []string) *ClientManager {
 	t.Helper()
 
@@ -0,0 +1,443 @@
+package multicluster
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// Placement criteria, each scored from 0 to 100
+const (
+	// CriterionHeadroom is the share of CPU and memory left unrequested on
+	// the nodes the workload can use
+	CriterionHeadroom = "headroom"
+	// CriterionSchedulable is the share of ready nodes in the allowed
+	// regions, zones, OS, and architectures whose taints the workload
+	// tolerates
+	CriterionSchedulable = "schedulable"
+	// CriterionCost favors clusters with a lower cost weight
+	CriterionCost = "cost"
+	// CriterionSpread favors clusters that do not run the app yet
+	CriterionSpread = "spread"
+)
+
+// PlacementCriteria lists the placement criteria
+var PlacementCriteria = []string{CriterionHeadroom, CriterionSchedulable, CriterionCost, CriterionSpread}
+
+const (
+	regionLabel = "topology.kubernetes.io/region"
+	zoneLabel   = "topology.kubernetes.io/zone"
+)
+
+// PlacementRequest describes a workload to place. The embedded requirements,
+// Regions, Zones, OS, Arch, and Tolerations decide which clusters can run
+// it; the criteria rank those clusters.
+type PlacementRequest struct {
+	WorkloadRequirements
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	Regions     []string            `json:"regions,omitempty"`
+	Zones       []string            `json:"zones,omitempty"`
+	// OS is the node OS the workload's images are built for; only nodes
+	// running it are counted
+	OS string `json:"os,omitempty"`
+	// Arch lists the CPU architectures the workload's images are built
+	// for; only nodes with one of them are counted
+	Arch []string `json:"arch,omitempty"`
+	// Costs are relative per-cluster cost weights; clusters not listed
+	// cost 1
+	Costs map[string]float64 `json:"costs,omitempty"`
+	// RunningIn lists the clusters that already run the app
+	RunningIn []string `json:"runningIn,omitempty"`
+	// Weights weigh the criteria in the total score; criteria not listed
+	// weigh 1
+	Weights map[string]float64 `json:"weights,omitempty"`
+}
+
+// Validate checks the OS, costs, and weights of a placement request
+func (r PlacementRequest) Validate() error {
+	if r.OS != "" && !inAny(r.OS, NodeOSes) {
+		return fmt.Errorf("unknown os %q: must be one of %s", r.OS, strings.Join(NodeOSes, ", "))
+	}
+	for cluster, cost := range r.Costs {
+		if cost <= 0 || math.IsInf(cost, 0) || math.IsNaN(cost) {
+			return fmt.Errorf("cost of cluster %s must be a positive number", cluster)
+		}
+	}
+	total := 0.0
+	for _, criterion := range PlacementCriteria {
+		total += r.weight(criterion)
+	}
+	for criterion, weight := range r.Weights {
+		if !isPlacementCriterion(criterion) {
+			return fmt.Errorf("unknown criterion %q: must be one of %s", criterion, strings.Join(PlacementCriteria, ", "))
+		}
+		if weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+			return fmt.Errorf("weight of %s must not be negative", criterion)
+		}
+	}
+	if total == 0 {
+		return fmt.Errorf("at least one criterion must have a positive weight")
+	}
+	return nil
+}
+
+func (r PlacementRequest) weight(criterion string) float64 {
+	if w, ok := r.Weights[criterion]; ok {
+		return w
+	}
+	return 1
+}
+
+func (r PlacementRequest) cost(cluster string) float64 {
+	if c, ok := r.Costs[cluster]; ok {
+		return c
+	}
+	return 1
+}
+
+// ClusterScore is the placement score of one cluster
+type ClusterScore struct {
+	Cluster  string `json:"cluster"`
+	Eligible bool   `json:"eligible"`
+	// Score is the weighted mean of Criteria, from 0 to 100
+	Score       float64            `json:"score"`
+	Criteria    map[string]float64 `json:"criteria,omitempty"`
+	UsableNodes int                `json:"usableNodes"`
+	FreeCPU     string             `json:"freeCpu,omitempty"`
+	FreeMemory  string             `json:"freeMemory,omitempty"`
+	// Reasons explain why an ineligible cluster cannot run the workload,
+	// or note data that could not be gathered
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// clusterState is what placement scoring reads from a cluster
+type clusterState struct {
+	nodes []corev1.Node
+	// requested sums the resource requests of the pods on each node
+	requested map[string]corev1.ResourceList
+	// podsErr is set when pods could not be listed, so requests are unknown
+	podsErr error
+}
+
+// ScoreClusters scores every cluster for a workload and returns them
+// ranked, eligible clusters first by descending score
+func (s *Selector) ScoreClusters(ctx context.Context, req PlacementRequest) ([]ClusterScore, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	results, err := s.executor.Execute(ctx, "", func(ctx context.Context, client *kubernetes.Clientset, clusterName string) (interface{}, error) {
+		return readClusterState(ctx, client)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make([]ClusterScore, 0, len(results))
+	for _, result := range results {
+		state, ok := result.Result.(*clusterState)
+		if result.Error != "" || !ok {
+			scores = append(scores, ClusterScore{Cluster: result.Cluster, Reasons: []string{result.Error}})
+			continue
+		}
+		scores = append(scores, s.scoreCluster(result.Cluster, state, req))
+	}
+	finishScores(scores, req)
+	return scores, nil
+}
+
+// readClusterState lists the nodes of a cluster and the requests of the
+// pods running on them
+func readClusterState(ctx context.Context, client kubernetes.Interface) (*clusterState, error) {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	state := &clusterState{nodes: nodes.Items, requested: make(map[string]corev1.ResourceList)}
+	pods, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		state.podsErr = err
+		return state, nil
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		used := state.requested[pod.Spec.NodeName]
+		if used == nil {
+			used = corev1.ResourceList{}
+			state.requested[pod.Spec.NodeName] = used
+		}
+		for name, q := range podRequests(pod.Spec) {
+			sum := used[name]
+			sum.Add(q)
+			used[name] = sum
+		}
+	}
+	return state, nil
+}
+
+// podRequests returns the CPU and memory a pod reserves on its node: the
+// sum of its containers' requests, or its largest init container request
+// if that is higher
+func podRequests(spec corev1.PodSpec) corev1.ResourceList {
+	out := corev1.ResourceList{}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var sum resource.Quantity
+		for _, c := range spec.Containers {
+			if q, ok := c.Resources.Requests[name]; ok {
+				sum.Add(q)
+			}
+		}
+		for _, c := range spec.InitContainers {
+			if q, ok := c.Resources.Requests[name]; ok && q.Cmp(sum) > 0 {
+				sum = q.DeepCopy()
+			}
+		}
+		out[name] = sum
+	}
+	return out
+}
+
+// scoreCluster decides whether a cluster can run the workload and scores
+// every criterion but cost, which depends on the other clusters
+func (s *Selector) scoreCluster(clusterName string, state *clusterState, req PlacementRequest) ClusterScore {
+	score := ClusterScore{Cluster: clusterName, Criteria: make(map[string]float64)}
+	if state.podsErr != nil {
+		score.Reasons = append(score.Reasons, fmt.Sprintf("pod requests unknown, headroom assumes idle nodes: %v", state.podsErr))
+	}
+
+	cap := capabilitiesFromNodes(clusterName, state.nodes)
+	if !s.clusterMeetsRequirements(*cap, req.WorkloadRequirements) {
+		score.Reasons = append(score.Reasons, "does not meet the GPU, CPU, memory, or label requirements")
+		return score
+	}
+
+	var (
+		ready, inLocation, onPlatform        int
+		allocCPU, allocMem, freeCPU, freeMem resource.Quantity
+		untolerated                          = make(map[string]bool)
+	)
+	for _, node := range state.nodes {
+		if !nodeReady(node) || node.Spec.Unschedulable {
+			continue
+		}
+		ready++
+		if !inAny(node.Labels[regionLabel], req.Regions) || !inAny(node.Labels[zoneLabel], req.Zones) {
+			continue
+		}
+		inLocation++
+		if !req.allowsPlatform(nodePlatform(node)) {
+			continue
+		}
+		onPlatform++
+		if taint := untoleratedTaint(node.Spec.Taints, req.Tolerations); taint != nil {
+			untolerated[taint.ToString()] = true
+			continue
+		}
+		score.UsableNodes++
+		used := state.requested[node.Name]
+		for _, r := range []struct {
+			name        corev1.ResourceName
+			alloc, free *resource.Quantity
+		}{
+			{corev1.ResourceCPU, &allocCPU, &freeCPU},
+			{corev1.ResourceMemory, &allocMem, &freeMem},
+		} {
+			alloc := node.Status.Allocatable[r.name]
+			r.alloc.Add(alloc)
+			free := alloc.DeepCopy()
+			free.Sub(used[r.name])
+			if free.Sign() > 0 {
+				r.free.Add(free)
+			}
+		}
+	}
+	score.FreeCPU = freeCPU.String()
+	score.FreeMemory = freeMem.String()
+
+	switch {
+	case ready == 0:
+		score.Reasons = append(score.Reasons, "no ready, schedulable nodes")
+		return score
+	case inLocation == 0:
+		score.Reasons = append(score.Reasons, fmt.Sprintf("no ready nodes in %s", describeLocation(req)))
+		return score
+	case onPlatform == 0:
+		score.Reasons = append(score.Reasons, fmt.Sprintf("no ready nodes for %s; the cluster has %s nodes", describePlatform(req), describePlatforms(nodePlatforms(state.nodes))))
+		return score
+	case score.UsableNodes == 0:
+		score.Reasons = append(score.Reasons, fmt.Sprintf("every node has taints the workload does not tolerate: %s", strings.Join(sortedSet(untolerated), ", ")))
+		return score
+	}
+	if fits, reason := fitsFree("CPU", req.MinCPU, freeCPU); !fits {
+		score.Reasons = append(score.Reasons, reason)
+		return score
+	}
+	if fits, reason := fitsFree("memory", req.MinMemory, freeMem); !fits {
+		score.Reasons = append(score.Reasons, reason)
+		return score
+	}
+
+	score.Eligible = true
+	score.Criteria[CriterionHeadroom] = 100 * math.Min(share(freeCPU, allocCPU), share(freeMem, allocMem))
+	score.Criteria[CriterionSchedulable] = 100 * float64(score.UsableNodes) / float64(onPlatform)
+	score.Criteria[CriterionSpread] = 100
+	for _, c := range req.RunningIn {
+		if c == clusterName {
+			score.Criteria[CriterionSpread] = 0
+		}
+	}
+	return score
+}
+
+// finishScores scores cost relative to the cheapest eligible cluster,
+// computes the total scores, and ranks the clusters
+func finishScores(scores []ClusterScore, req PlacementRequest) {
+	cheapest := math.Inf(1)
+	for _, s := range scores {
+		if s.Eligible {
+			cheapest = math.Min(cheapest, req.cost(s.Cluster))
+		}
+	}
+	for i := range scores {
+		s := &scores[i]
+		if !s.Eligible {
+			s.Criteria = nil
+			continue
+		}
+		s.Criteria[CriterionCost] = 100 * cheapest / req.cost(s.Cluster)
+		var total, weights float64
+		for _, criterion := range PlacementCriteria {
+			s.Criteria[criterion] = round1(s.Criteria[criterion])
+			total += req.weight(criterion) * s.Criteria[criterion]
+			weights += req.weight(criterion)
+		}
+		s.Score = round1(total / weights)
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		a, b := scores[i], scores[j]
+		if a.Eligible != b.Eligible {
+			return a.Eligible
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Cluster < b.Cluster
+	})
+}
+
+// untoleratedTaint returns the first NoSchedule or NoExecute taint that no
+// toleration tolerates, or nil
+func untoleratedTaint(taints []corev1.Taint, tolerations []corev1.Toleration) *corev1.Taint {
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(klog.Background(), taint, true) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return taint
+		}
+	}
+	return nil
+}
+
+// fitsFree reports whether the free quantity covers a minimum, if one is set
+// and valid
+func fitsFree(what, min string, free resource.Quantity) (bool, string) {
+	if min == "" {
+		return true, ""
+	}
+	required, err := resource.ParseQuantity(min)
+	if err != nil || free.Cmp(required) >= 0 {
+		return true, ""
+	}
+	return false, fmt.Sprintf("only %s %s unrequested on usable nodes, %s needed", free.String(), what, required.String())
+}
+
+func describeLocation(req PlacementRequest) string {
+	var parts []string
+	if len(req.Regions) > 0 {
+		parts = append(parts, "regions "+strings.Join(req.Regions, ", "))
+	}
+	if len(req.Zones) > 0 {
+		parts = append(parts, "zones "+strings.Join(req.Zones, ", "))
+	}
+	return strings.Join(parts, " and ")
+}
+
+// allowsPlatform reports whether the workload can run on a node of
+// platform p
+func (r PlacementRequest) allowsPlatform(p NodePlatform) bool {
+	return (r.OS == "" || p.OS == r.OS) && inAny(p.Arch, r.Arch)
+}
+
+func describePlatform(req PlacementRequest) string {
+	os := req.OS
+	if os == "" {
+		os = "any OS"
+	}
+	if len(req.Arch) == 0 {
+		return os
+	}
+	return os + "/" + strings.Join(req.Arch, " or ")
+}
+
+func isPlacementCriterion(name string) bool {
+	for _, c := range PlacementCriteria {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// inAny reports whether value is one of allowed; an empty allowed list
+// allows everything
+func inAny(value string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, a := range allowed {
+		if a == value {
+			return true
+		}
+	}
+	return false
+}
+
+func share(part, whole resource.Quantity) float64 {
+	if whole.IsZero() {
+		return 0
+	}
+	return math.Min(1, float64(part.MilliValue())/float64(whole.MilliValue()))
+}
+
+func round1(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+func sortedSet(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
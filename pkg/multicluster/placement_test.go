@@ -0,0 +1,207 @@
+package multicluster
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func withTaints(node corev1.Node, taints ...corev1.Taint) corev1.Node {
+	node.Spec.Taints = taints
+	return node
+}
+
+func requested(cpu, mem string) corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(mem),
+	}
+}
+
+var gpuTaint = corev1.Taint{Key: "nvidia.com/gpu", Value: "present", Effect: corev1.TaintEffectNoSchedule}
+
+func TestScoreClusterExcludesUnplaceableClusters(t *testing.T) {
+	east := map[string]string{regionLabel: "us-east-1", zoneLabel: "us-east-1a"}
+	tests := []struct {
+		name   string
+		nodes  []corev1.Node
+		used   map[string]corev1.ResourceList
+		req    PlacementRequest
+		reason string
+	}{
+		{
+			name:   "no ready nodes",
+			nodes:  []corev1.Node{mkNode("n1", false, "4", "8Gi", nil, east)},
+			reason: "no ready",
+		},
+		{
+			name:   "outside allowed regions",
+			nodes:  []corev1.Node{mkNode("n1", true, "4", "8Gi", nil, east)},
+			req:    PlacementRequest{Regions: []string{"eu-west-1"}},
+			reason: "regions eu-west-1",
+		},
+		{
+			name:   "outside allowed zones",
+			nodes:  []corev1.Node{mkNode("n1", true, "4", "8Gi", nil, east)},
+			req:    PlacementRequest{Zones: []string{"us-east-1b"}},
+			reason: "zones us-east-1b",
+		},
+		{
+			name:   "untolerated taints",
+			nodes:  []corev1.Node{withTaints(mkNode("n1", true, "4", "8Gi", nil, east), gpuTaint)},
+			reason: "nvidia.com/gpu=present:NoSchedule",
+		},
+		{
+			name:   "free cpu below minimum",
+			nodes:  []corev1.Node{mkNode("n1", true, "4", "8Gi", nil, east)},
+			used:   map[string]corev1.ResourceList{"n1": requested("3", "1Gi")},
+			req:    PlacementRequest{WorkloadRequirements: WorkloadRequirements{MinCPU: "2"}},
+			reason: "only 1 CPU unrequested",
+		},
+		{
+			name:   "static requirements",
+			nodes:  []corev1.Node{mkNode("n1", true, "4", "8Gi", nil, east)},
+			req:    PlacementRequest{WorkloadRequirements: WorkloadRequirements{MinGPU: 1}},
+			reason: "requirements",
+		},
+	}
+
+	s := &Selector{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.scoreCluster("c", &clusterState{nodes: tt.nodes, requested: tt.used}, tt.req)
+			if got.Eligible {
+				t.Fatalf("expected the cluster to be excluded, got %+v", got)
+			}
+			if len(got.Reasons) == 0 || !strings.Contains(got.Reasons[len(got.Reasons)-1], tt.reason) {
+				t.Fatalf("reasons = %v, want one containing %q", got.Reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestScoreClusterCriteria(t *testing.T) {
+	nodes := []corev1.Node{
+		mkNode("n1", true, "4", "8Gi", nil, nil),
+		withTaints(mkNode("n2", true, "4", "8Gi", nil, nil), gpuTaint),
+		withTaints(mkNode("n3", true, "4", "8Gi", nil, nil), corev1.Taint{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule}),
+	}
+	used := map[string]corev1.ResourceList{"n1": requested("3", "2Gi"), "n3": requested("1", "2Gi")}
+
+	s := &Selector{}
+	got := s.scoreCluster("c", &clusterState{nodes: nodes, requested: used}, PlacementRequest{RunningIn: []string{"c"}})
+	if !got.Eligible || got.UsableNodes != 2 {
+		t.Fatalf("unexpected score: %+v", got)
+	}
+	// n1 and n3 leave 4 of 8 CPUs and 12 of 16Gi unrequested.
+	if h := got.Criteria[CriterionHeadroom]; h != 50 {
+		t.Fatalf("headroom = %v, want 50", h)
+	}
+	if sched := round1(got.Criteria[CriterionSchedulable]); sched != 66.7 {
+		t.Fatalf("schedulable = %v, want 66.7", sched)
+	}
+	if got.Criteria[CriterionSpread] != 0 {
+		t.Fatalf("spread = %v, want 0 for a cluster running the app", got.Criteria[CriterionSpread])
+	}
+
+	tolerant := PlacementRequest{Tolerations: []corev1.Toleration{{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}}}
+	got = s.scoreCluster("c", &clusterState{nodes: nodes, requested: used}, tolerant)
+	if got.UsableNodes != 3 || got.Criteria[CriterionSchedulable] != 100 || got.Criteria[CriterionSpread] != 100 {
+		t.Fatalf("tolerating the taint should make every node usable: %+v", got)
+	}
+}
+
+func TestFinishScoresRanksByWeightedScore(t *testing.T) {
+	criteria := func(headroom, spread float64) map[string]float64 {
+		return map[string]float64{CriterionHeadroom: headroom, CriterionSchedulable: 100, CriterionSpread: spread}
+	}
+	scores := []ClusterScore{
+		{Cluster: "cheap-busy", Eligible: true, Criteria: criteria(20, 100)},
+		{Cluster: "down", Reasons: []string{"unreachable"}},
+		{Cluster: "pricey-idle", Eligible: true, Criteria: criteria(100, 100)},
+		{Cluster: "running", Eligible: true, Criteria: criteria(100, 0)},
+	}
+	req := PlacementRequest{Costs: map[string]float64{"pricey-idle": 4, "cheap-busy": 0.5, "running": 0.5}}
+	finishScores(scores, req)
+
+	var order []string
+	for _, s := range scores {
+		order = append(order, s.Cluster)
+	}
+	// cheap-busy: (20+100+100+100)/4, pricey-idle: (100+100+12.5+100)/4,
+	// running: (100+100+100+0)/4.
+	if strings.Join(order, ",") != "cheap-busy,pricey-idle,running,down" {
+		t.Fatalf("ranking = %v", order)
+	}
+	if scores[0].Criteria[CriterionCost] != 100 || scores[1].Criteria[CriterionCost] != 12.5 {
+		t.Fatalf("cost is relative to the cheapest cluster: %+v", scores)
+	}
+	if scores[0].Score != 80 || scores[1].Score != 78.1 || scores[2].Score != 75 {
+		t.Fatalf("scores are the weighted means: %+v", scores)
+	}
+	if scores[3].Criteria != nil || scores[3].Score != 0 {
+		t.Fatalf("excluded clusters are not scored: %+v", scores[3])
+	}
+
+	scores = []ClusterScore{
+		{Cluster: "a", Eligible: true, Criteria: criteria(20, 100)},
+		{Cluster: "b", Eligible: true, Criteria: criteria(100, 0)},
+	}
+	finishScores(scores, PlacementRequest{Weights: map[string]float64{CriterionSpread: 5}})
+	if scores[0].Cluster != "a" {
+		t.Fatalf("a heavy spread weight should rank a first: %+v", scores)
+	}
+}
+
+func TestPlacementRequestValidate(t *testing.T) {
+	for _, req := range []PlacementRequest{
+		{Costs: map[string]float64{"a": 0}},
+		{Weights: map[string]float64{"latency": 1}},
+		{Weights: map[string]float64{CriterionCost: -1}},
+		{Weights: map[string]float64{CriterionHeadroom: 0, CriterionSchedulable: 0, CriterionCost: 0, CriterionSpread: 0}},
+	} {
+		if err := req.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", req)
+		}
+	}
+	if err := (PlacementRequest{Weights: map[string]float64{CriterionCost: 0}}).Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestPodRequestsUsesLargestInitContainer(t *testing.T) {
+	container := func(cpu string) corev1.Container {
+		return corev1.Container{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}}}
+	}
+	got := podRequests(corev1.PodSpec{
+		Containers:     []corev1.Container{container("100m"), container("200m")},
+		InitContainers: []corev1.Container{container("500m")},
+	})
+	cpu := got[corev1.ResourceCPU]
+	if cpu.MilliValue() != 500 {
+		t.Fatalf("cpu = %s, want 500m", cpu.String())
+	}
+}
+
+func TestScoreClustersWithoutPodAccess(t *testing.T) {
+	// nodesHandler serves no pods, so headroom assumes idle nodes.
+	mgr, cleanup := managerWithServers(t, map[string][]corev1.Node{
+		"alpha": {mkNode("a1", true, "8", "16Gi", nil, nil)},
+		"beta":  {withTaints(mkNode("b1", true, "8", "16Gi", nil, nil), gpuTaint)},
+	})
+	defer cleanup()
+
+	scores, err := NewSelector(NewExecutor(mgr)).ScoreClusters(context.Background(), PlacementRequest{})
+	if err != nil {
+		t.Fatalf("ScoreClusters: %v", err)
+	}
+	if len(scores) != 2 || scores[0].Cluster != "alpha" || !scores[0].Eligible || scores[1].Eligible {
+		t.Fatalf("unexpected scores: %+v", scores)
+	}
+	if scores[0].Score != 100 || len(scores[0].Reasons) != 1 || !strings.Contains(scores[0].Reasons[0], "pod requests unknown") {
+		t.Fatalf("expected an idle-node score with a note: %+v", scores[0])
+	}
+}
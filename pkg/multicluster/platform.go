@@ -0,0 +1,161 @@
+package multicluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NodeOSes are the operating systems Kubernetes nodes run
+var NodeOSes = []string{"linux", "windows"}
+
+// NodePlatform counts the nodes of one OS and architecture
+type NodePlatform struct {
+	OS    string `json:"os"`
+	Arch  string `json:"arch"`
+	Nodes int    `json:"nodes"`
+}
+
+func (p NodePlatform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// nodePlatform returns a node's OS and architecture from its well-known
+// labels, falling back to what the kubelet reports
+func nodePlatform(node corev1.Node) NodePlatform {
+	p := NodePlatform{OS: node.Labels[corev1.LabelOSStable], Arch: node.Labels[corev1.LabelArchStable]}
+	if p.OS == "" {
+		p.OS = node.Status.NodeInfo.OperatingSystem
+	}
+	if p.Arch == "" {
+		p.Arch = node.Status.NodeInfo.Architecture
+	}
+	return p
+}
+
+// nodePlatforms counts nodes by OS and architecture, most common first
+func nodePlatforms(nodes []corev1.Node) []NodePlatform {
+	counts := make(map[NodePlatform]int)
+	for _, node := range nodes {
+		counts[nodePlatform(node)]++
+	}
+	platforms := make([]NodePlatform, 0, len(counts))
+	for p, n := range counts {
+		p.Nodes = n
+		platforms = append(platforms, p)
+	}
+	sort.Slice(platforms, func(i, j int) bool {
+		if platforms[i].Nodes != platforms[j].Nodes {
+			return platforms[i].Nodes > platforms[j].Nodes
+		}
+		return platforms[i].String() < platforms[j].String()
+	})
+	return platforms
+}
+
+func describePlatforms(platforms []NodePlatform) string {
+	parts := make([]string, 0, len(platforms))
+	for _, p := range platforms {
+		parts = append(parts, fmt.Sprintf("%d %s", p.Nodes, p))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// podFitsNode reports whether a pod's node selector and required node
+// affinity select a node
+func podFitsNode(spec corev1.PodSpec, node corev1.Node) bool {
+	for key, value := range spec.NodeSelector {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil || spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return true
+	}
+	// Terms are ORed, the expressions of a term ANDed
+	for _, term := range spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		matches := true
+		for _, expr := range term.MatchExpressions {
+			if !nodeSelectorRequirementMatches(expr, node.Labels) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeSelectorRequirementMatches evaluates one node affinity expression.
+// Gt and Lt are treated as matching, since they do not select an OS or
+// architecture
+func nodeSelectorRequirementMatches(expr corev1.NodeSelectorRequirement, labels map[string]string) bool {
+	value, ok := labels[expr.Key]
+	switch expr.Operator {
+	case corev1.NodeSelectorOpIn:
+		return ok && inAny(value, expr.Values)
+	case corev1.NodeSelectorOpNotIn:
+		return !ok || !inAny(value, expr.Values)
+	case corev1.NodeSelectorOpExists:
+		return ok
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !ok
+	}
+	return true
+}
+
+// PodPlatformWarnings checks a pod spec against a cluster's node mix and
+// warns when the pod could land on nodes of more than one OS or
+// architecture because it does not pin them, or cannot be scheduled
+// because no node of the OS and architecture it selects is usable
+func PodPlatformWarnings(spec corev1.PodSpec, nodes []corev1.Node) []string {
+	var (
+		selected, usable []corev1.Node
+		untolerated      = make(map[string]bool)
+	)
+	for _, node := range nodes {
+		if !nodeReady(node) || node.Spec.Unschedulable || !podFitsNode(spec, node) {
+			continue
+		}
+		selected = append(selected, node)
+		if taint := untoleratedTaint(node.Spec.Taints, spec.Tolerations); taint != nil {
+			untolerated[taint.ToString()] = true
+			continue
+		}
+		usable = append(usable, node)
+	}
+
+	if len(usable) == 0 {
+		switch {
+		case len(selected) > 0:
+			return []string{fmt.Sprintf("its %s nodes have taints it does not tolerate (%s); add tolerations for them",
+				describePlatforms(nodePlatforms(selected)), strings.Join(sortedSet(untolerated), ", "))}
+		case len(nodes) > 0:
+			return []string{fmt.Sprintf("no ready node matches its node selector and affinity; the cluster has %s nodes",
+				describePlatforms(nodePlatforms(nodes)))}
+		}
+		return nil
+	}
+
+	var warnings []string
+	platforms := nodePlatforms(usable)
+	oses := make(map[string]bool)
+	arches := make(map[string]bool)
+	for _, p := range platforms {
+		oses[p.OS] = true
+		arches[p.Arch] = true
+	}
+	if len(oses) > 1 {
+		warnings = append(warnings, fmt.Sprintf("it may be scheduled on %s nodes; set a %s node selector for the OS its images are built for",
+			describePlatforms(platforms), corev1.LabelOSStable))
+	}
+	if len(arches) > 1 {
+		warnings = append(warnings, fmt.Sprintf("it may be scheduled on %s nodes; make sure its images are multi-arch or set a %s node selector",
+			strings.Join(sortedSet(arches), " and "), corev1.LabelArchStable))
+	}
+	return warnings
+}
@@ -0,0 +1,94 @@
+package multicluster
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func platformNode(name, os, arch string, taints ...corev1.Taint) corev1.Node {
+	return withTaints(mkNode(name, true, "4", "8Gi", nil, map[string]string{
+		corev1.LabelOSStable:   os,
+		corev1.LabelArchStable: arch,
+	}), taints...)
+}
+
+var windowsTaint = corev1.Taint{Key: "os", Value: "windows", Effect: corev1.TaintEffectNoSchedule}
+
+func TestNodePlatforms(t *testing.T) {
+	kubelet := mkNode("n4", true, "4", "8Gi", nil, nil)
+	kubelet.Status.NodeInfo = corev1.NodeSystemInfo{OperatingSystem: "linux", Architecture: "arm64"}
+	got := nodePlatforms([]corev1.Node{
+		platformNode("n1", "linux", "amd64"),
+		platformNode("n2", "windows", "amd64"),
+		platformNode("n3", "linux", "amd64"),
+		kubelet,
+	})
+	want := []NodePlatform{{OS: "linux", Arch: "amd64", Nodes: 2}, {OS: "linux", Arch: "arm64", Nodes: 1}, {OS: "windows", Arch: "amd64", Nodes: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("nodePlatforms() = %+v, want %+v", got, want)
+	}
+}
+
+func TestPodPlatformWarnings(t *testing.T) {
+	mixedOS := []corev1.Node{platformNode("l1", "linux", "amd64"), platformNode("w1", "windows", "amd64")}
+	taintedWindows := []corev1.Node{platformNode("l1", "linux", "amd64"), platformNode("w1", "windows", "amd64", windowsTaint)}
+	mixedArch := []corev1.Node{platformNode("a1", "linux", "amd64"), platformNode("r1", "linux", "arm64")}
+	onLinux := corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelOSStable: "linux"}}
+	onWindows := corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelOSStable: "windows"}}
+	amd64Affinity := corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: corev1.LabelArchStable, Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64"}}},
+		}}},
+	}}}
+
+	tests := []struct {
+		name  string
+		spec  corev1.PodSpec
+		nodes []corev1.Node
+		want  string
+	}{
+		{name: "no OS selector on mixed OS", nodes: mixedOS, want: "1 linux/amd64, 1 windows/amd64 nodes; set a kubernetes.io/os node selector"},
+		{name: "OS selector", spec: onLinux, nodes: mixedOS},
+		{name: "windows nodes tainted", nodes: taintedWindows},
+		{name: "windows workload without toleration", spec: onWindows, nodes: taintedWindows, want: "its 1 windows/amd64 nodes have taints it does not tolerate (os=windows:NoSchedule)"},
+		{name: "no node for selected OS", spec: onWindows, nodes: mixedArch, want: "no ready node matches its node selector and affinity; the cluster has 1 linux/amd64, 1 linux/arm64 nodes"},
+		{name: "mixed architectures", spec: onLinux, nodes: mixedArch, want: "amd64 and arm64 nodes; make sure its images are multi-arch"},
+		{name: "architecture affinity", spec: amd64Affinity, nodes: mixedArch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PodPlatformWarnings(tt.spec, tt.nodes)
+			if tt.want == "" {
+				if len(got) != 0 {
+					t.Fatalf("unexpected warnings: %v", got)
+				}
+				return
+			}
+			if len(got) != 1 || !strings.Contains(got[0], tt.want) {
+				t.Fatalf("warnings = %v, want one containing %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreClusterFiltersByPlatform(t *testing.T) {
+	nodes := []corev1.Node{platformNode("l1", "linux", "amd64"), platformNode("r1", "linux", "arm64"), platformNode("w1", "windows", "amd64")}
+	s := &Selector{}
+
+	got := s.scoreCluster("c", &clusterState{nodes: nodes}, PlacementRequest{OS: "linux", Arch: []string{"arm64"}})
+	if !got.Eligible || got.UsableNodes != 1 || got.Criteria[CriterionSchedulable] != 100 {
+		t.Fatalf("expected only the linux/arm64 node to count: %+v", got)
+	}
+
+	got = s.scoreCluster("c", &clusterState{nodes: nodes}, PlacementRequest{OS: "windows", Arch: []string{"arm64"}})
+	if got.Eligible || !strings.Contains(got.Reasons[0], "no ready nodes for windows/arm64; the cluster has 1 linux/amd64, 1 linux/arm64, 1 windows/amd64 nodes") {
+		t.Fatalf("expected the cluster to be excluded: %+v", got)
+	}
+
+	if err := (PlacementRequest{OS: "darwin"}).Validate(); err == nil || !strings.Contains(err.Error(), "must be one of linux, windows") {
+		t.Fatalf("Validate() = %v, want an unknown os error", err)
+	}
+}
@@ -0,0 +1,20 @@
+package multicluster
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+)
+
+// ProtobufConfig returns a copy of config that negotiates the Kubernetes
+// protobuf encoding, falling back to JSON for anything the API server only
+// serves as JSON. Protobuf is much cheaper to encode and decode than JSON for
+// built-in types, which matters for fleet-wide lists.
+//
+// Only typed clientsets can decode protobuf. Dynamic, metadata, and
+// unstructured clients must keep using the original config.
+func ProtobufConfig(config *rest.Config) *rest.Config {
+	protoConfig := rest.CopyConfig(config)
+	protoConfig.ContentType = runtime.ContentTypeProtobuf
+	protoConfig.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	return protoConfig
+}
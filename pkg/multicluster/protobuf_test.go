@@ -0,0 +1,29 @@
+package multicluster
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestProtobufConfigNegotiatesProtobufOnACopy(t *testing.T) {
+	original := &rest.Config{Host: "https://example.com", QPS: 42}
+
+	got := ProtobufConfig(original)
+
+	if got == original {
+		t.Fatal("ProtobufConfig() must return a copy")
+	}
+	if got.ContentType != "application/vnd.kubernetes.protobuf" {
+		t.Errorf("ContentType = %q, want protobuf", got.ContentType)
+	}
+	if got.AcceptContentTypes != "application/vnd.kubernetes.protobuf,application/json" {
+		t.Errorf("AcceptContentTypes = %q, want protobuf with JSON fallback", got.AcceptContentTypes)
+	}
+	if got.Host != original.Host || got.QPS != original.QPS {
+		t.Errorf("ProtobufConfig() dropped fields: %+v", got)
+	}
+	if original.ContentType != "" || original.AcceptContentTypes != "" {
+		t.Errorf("original config was modified: %+v", original)
+	}
+}
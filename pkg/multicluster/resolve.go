@@ -0,0 +1,140 @@
+package multicluster
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/clientcmd/api"
+)
+
+// EnvClusterAliases names clusters for the tools, as comma-separated
+// alias=cluster entries. The cluster may be anything Resolve accepts other
+// than an alias, e.g. prod=gke_acme_us-east1_prod.
+const EnvClusterAliases = "KUBESTELLAR_CLUSTER_ALIASES"
+
+// Resolver maps the names tools accept for a cluster to the kubeconfig
+// context whose credentials reach it. A cluster can be named by:
+//
+//   - its context name;
+//   - an alias from EnvClusterAliases;
+//   - the kubeconfig cluster entry its context uses, which often differs
+//     from the context name (kubectl config get-clusters lists them); or
+//   - its API server URL, as reported by a KubeStellar ManagedCluster.
+//
+// Both servers resolve every cluster name through a Resolver before
+// building a client, so all tools accept the same names.
+type Resolver struct {
+	config  api.Config
+	aliases map[string]string
+}
+
+// NewResolver returns a Resolver for the contexts of config.
+func NewResolver(config api.Config, aliases map[string]string) *Resolver {
+	return &Resolver{config: config, aliases: aliases}
+}
+
+// LoadResolver loads kubeconfig (or the default kubeconfig when empty) and
+// the aliases in EnvClusterAliases.
+func LoadResolver(kubeconfig string) (*Resolver, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loadingRules.ExplicitPath = kubeconfig
+	}
+	config, err := loadingRules.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	aliases, err := ParseClusterAliases(os.Getenv(EnvClusterAliases))
+	if err != nil {
+		return nil, err
+	}
+	return NewResolver(*config, aliases), nil
+}
+
+// ParseClusterAliases parses the alias=cluster entries of
+// EnvClusterAliases.
+func ParseClusterAliases(spec string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		alias, cluster, ok := strings.Cut(entry, "=")
+		alias, cluster = strings.TrimSpace(alias), strings.TrimSpace(cluster)
+		if !ok || alias == "" || cluster == "" {
+			return nil, fmt.Errorf("invalid %s entry %q: want alias=cluster", EnvClusterAliases, entry)
+		}
+		if _, dup := aliases[alias]; dup {
+			return nil, fmt.Errorf("invalid %s: alias %q is listed twice", EnvClusterAliases, alias)
+		}
+		aliases[alias] = cluster
+	}
+	return aliases, nil
+}
+
+// Resolve returns the context for the cluster called name. An empty name
+// is the current context. A name that matches nothing is returned as is,
+// so that loading its context reports it; a name that matches several
+// contexts, other than the current one, is an error.
+func (r *Resolver) Resolve(name string) (string, error) {
+	if name == "" {
+		return r.config.CurrentContext, nil
+	}
+	if _, ok := r.config.Contexts[name]; ok {
+		return name, nil
+	}
+	if target, ok := r.aliases[name]; ok {
+		if _, ok := r.config.Contexts[target]; ok {
+			return target, nil
+		}
+		context, err := r.resolveCluster(target)
+		if err != nil {
+			return "", fmt.Errorf("alias %s: %w", name, err)
+		}
+		return context, nil
+	}
+	return r.resolveCluster(name)
+}
+
+// resolveCluster finds the context of the kubeconfig cluster entry or API
+// server URL name.
+func (r *Resolver) resolveCluster(name string) (string, error) {
+	server := strings.TrimSuffix(name, "/")
+	var matches []string
+	for contextName, c := range r.config.Contexts {
+		cluster, ok := r.config.Clusters[c.Cluster]
+		if c.Cluster == name || (ok && strings.Contains(name, "://") && strings.TrimSuffix(cluster.Server, "/") == server) {
+			matches = append(matches, contextName)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return name, nil
+	case 1:
+		return matches[0], nil
+	}
+	for _, m := range matches {
+		if m == r.config.CurrentContext {
+			return m, nil
+		}
+	}
+	sort.Strings(matches)
+	return "", fmt.Errorf("cluster %s is reached by several contexts (%s); name one of them, or add an alias in %s",
+		name, strings.Join(matches, ", "), EnvClusterAliases)
+}
+
+// Aliases returns the aliases of context, sorted.
+func (r *Resolver) Aliases(context string) []string {
+	var aliases []string
+	for alias := range r.aliases {
+		if resolved, err := r.Resolve(alias); err == nil && resolved == context {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
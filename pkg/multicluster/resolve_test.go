@@ -0,0 +1,106 @@
+package multicluster
+
+import (
+	"strings"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func newResolverTestConfig() clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	config.CurrentContext = "admin@prod"
+	for _, c := range []struct{ context, cluster, server string }{
+		{"admin@prod", "prod", "https://prod.example.com"},
+		{"viewer@prod", "prod", "https://prod.example.com"},
+		{"kind-dev", "kind-dev", "https://127.0.0.1:6443"},
+		{"ops@stage", "stage", "https://stage.example.com/"},
+		{"dev@stage", "stage", "https://stage.example.com/"},
+	} {
+		config.Contexts[c.context] = &clientcmdapi.Context{Cluster: c.cluster, AuthInfo: c.context}
+		config.Clusters[c.cluster] = &clientcmdapi.Cluster{Server: c.server}
+	}
+	return *config
+}
+
+func TestResolverResolve(t *testing.T) {
+	r := NewResolver(newResolverTestConfig(), map[string]string{
+		"dev":     "kind-dev",
+		"staging": "ops@stage",
+		"primary": "prod",
+		"broken":  "stage",
+	})
+	tests := []struct {
+		name, want string
+	}{
+		{"", "admin@prod"},
+		{"viewer@prod", "viewer@prod"},
+		{"dev", "kind-dev"},
+		{"staging", "ops@stage"},
+		{"prod", "admin@prod"},
+		{"primary", "admin@prod"},
+		{"https://127.0.0.1:6443/", "kind-dev"},
+		{"https://stage.example.com", ""},
+		{"missing", "missing"},
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(tt.name)
+		if tt.want == "" && tt.name != "" {
+			if err == nil || !strings.Contains(err.Error(), "several contexts (dev@stage, ops@stage)") {
+				t.Fatalf("Resolve(%q) = %q, %v; want an ambiguity error", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Fatalf("Resolve(%q) = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+
+	if _, err := r.Resolve("broken"); err == nil || !strings.HasPrefix(err.Error(), "alias broken: cluster stage is reached by several contexts") {
+		t.Fatalf("Resolve(broken) error = %v", err)
+	}
+	if got := r.Aliases("admin@prod"); len(got) != 1 || got[0] != "primary" {
+		t.Fatalf("Aliases(admin@prod) = %v, want [primary]", got)
+	}
+}
+
+func TestParseClusterAliases(t *testing.T) {
+	aliases, err := ParseClusterAliases(" prod = gke_acme_prod ,dev=kind-dev,")
+	if err != nil {
+		t.Fatalf("ParseClusterAliases() error = %v", err)
+	}
+	if len(aliases) != 2 || aliases["prod"] != "gke_acme_prod" || aliases["dev"] != "kind-dev" {
+		t.Fatalf("ParseClusterAliases() = %v", aliases)
+	}
+	for _, spec := range []string{"prod", "prod=", "=kind-dev", "dev=a,dev=b"} {
+		if _, err := ParseClusterAliases(spec); err == nil {
+			t.Fatalf("ParseClusterAliases(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestGetClientResolvesAliases(t *testing.T) {
+	t.Setenv(EnvClusterAliases, "primary=alpha")
+	manager := newClientManagerFromKubeconfig(t, map[string]string{
+		"alpha": "https://alpha.example.com",
+		"beta":  "https://beta.example.com",
+	}, "beta")
+
+	byContext, err := manager.GetClient("alpha")
+	if err != nil {
+		t.Fatalf("GetClient(alpha) error = %v", err)
+	}
+	for _, name := range []string{"primary", "https://alpha.example.com"} {
+		client, err := manager.GetClient(name)
+		if err != nil {
+			t.Fatalf("GetClient(%s) error = %v", name, err)
+		}
+		if client != byContext {
+			t.Fatalf("GetClient(%s) built a second client for the alpha context", name)
+		}
+		config, err := manager.GetConfig(name)
+		if err != nil || config.Host != "https://alpha.example.com" {
+			t.Fatalf("GetConfig(%s) = %v, %v", name, config, err)
+		}
+	}
+}
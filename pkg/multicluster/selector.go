@@ -20,6 +20,7 @@ type ClusterCapabilities struct {
 	AllocatableCPU    string        `json:"allocatableCpu"`
 	AllocatableMemory string        `json:"allocatableMemory"`
 	GPUs          []GPUInfo         `json:"gpus,omitempty"`
+	Platforms     []NodePlatform    `json:"platforms,omitempty"`
 	Labels        map[string]string `json:"labels,omitempty"`
 }
 
@@ -81,10 +82,14 @@ func (s *Selector) GetCapabilitiesForCluster(ctx context.Context, client *kubern
 	if err != nil {
 		return nil, fmt.Errorf("failed to list nodes: %w", err)
 	}
+	return capabilitiesFromNodes(clusterName, nodes.Items), nil
+}
 
+// capabilitiesFromNodes sums the capabilities of a cluster's nodes
+func capabilitiesFromNodes(clusterName string, nodes []corev1.Node) *ClusterCapabilities {
 	cap := &ClusterCapabilities{
 		Cluster:   clusterName,
-		NodeCount: len(nodes.Items),
+		NodeCount: len(nodes),
 		Labels:    make(map[string]string),
 	}
 
@@ -92,13 +97,10 @@ func (s *Selector) GetCapabilitiesForCluster(ctx context.Context, client *kubern
 	var allocatableCPU, allocatableMemory resource.Quantity
 	gpuCounts := make(map[string]int64)
 
-	for _, node := range nodes.Items {
+	for _, node := range nodes {
 		// Count ready nodes
-		for _, condition := range node.Status.Conditions {
-			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
-				cap.ReadyNodes++
-				break
-			}
+		if nodeReady(node) {
+			cap.ReadyNodes++
 		}
 
 		// Sum resources
@@ -134,6 +136,7 @@ func (s *Selector) GetCapabilitiesForCluster(ctx context.Context, client *kubern
 	cap.TotalMemory = totalMemory.String()
 	cap.AllocatableCPU = allocatableCPU.String()
 	cap.AllocatableMemory = allocatableMemory.String()
+	cap.Platforms = nodePlatforms(nodes)
 
 	for gpuType, count := range gpuCounts {
 		cap.GPUs = append(cap.GPUs, GPUInfo{
@@ -142,7 +145,7 @@ func (s *Selector) GetCapabilitiesForCluster(ctx context.Context, client *kubern
 		})
 	}
 
-	return cap, nil
+	return cap
 }
 
 // FindClustersForWorkload finds clusters that can run the specified workload
@@ -251,3 +254,13 @@ func isClusterLabel(key string) bool {
 	}
 	return false
 }
+
+// nodeReady reports whether a node's Ready condition is true
+func nodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
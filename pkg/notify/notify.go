@@ -0,0 +1,238 @@
+// Package notify delivers alerts from background subsystems, such as
+// staged rollouts and scheduled scaling, to Slack, generic HTTP webhooks,
+// and email. Routes choose the sinks for each event type and severity.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// EnvNotifications holds the notification Config as JSON. The configuration
+// file's notifications section is passed to the servers through it.
+const EnvNotifications = "KUBESTELLAR_NOTIFICATIONS"
+
+// sendTimeout bounds each delivery, so a slow sink cannot stall the
+// subsystem that raised the event.
+const sendTimeout = 10 * time.Second
+
+// EventType is the kind of thing an event reports.
+type EventType string
+
+const (
+	EventDrift           EventType = "drift"
+	EventHealth          EventType = "health"
+	EventPolicyViolation EventType = "policy_violation"
+	EventUpgrade         EventType = "upgrade"
+	EventRollout         EventType = "rollout"
+	EventScaling         EventType = "scaling"
+)
+
+// EventTypes lists every event type, for validating routes.
+var EventTypes = []EventType{EventDrift, EventHealth, EventPolicyViolation, EventUpgrade, EventRollout, EventScaling}
+
+// Severity orders events for routing.
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityWarning:
+		return 1
+	case SeverityCritical:
+		return 2
+	}
+	return 0
+}
+
+// Event is one alert.
+type Event struct {
+	Type     EventType `json:"type"`
+	Severity Severity  `json:"severity"`
+	Title    string    `json:"title"`
+	Message  string    `json:"message,omitempty"`
+	Cluster  string    `json:"cluster,omitempty"`
+	// Source is the binary that raised the event.
+	Source string            `json:"source,omitempty"`
+	Time   time.Time         `json:"time"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Config is the notification configuration: named sinks and the routes
+// that select them.
+type Config struct {
+	Sinks  []SinkConfig `json:"sinks"`
+	Routes []Route      `json:"routes"`
+}
+
+// SinkConfig configures one sink. Exactly one of Slack, Webhook, and SMTP is
+// set.
+type SinkConfig struct {
+	Name    string         `json:"name"`
+	Slack   *SlackConfig   `json:"slack,omitempty"`
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	SMTP    *SMTPConfig    `json:"smtp,omitempty"`
+}
+
+// Route sends events of the listed types, at or above MinSeverity, to the
+// named sinks. No types means every type.
+type Route struct {
+	Events      []EventType `json:"events,omitempty"`
+	MinSeverity Severity    `json:"minSeverity,omitempty"`
+	Sinks       []string    `json:"sinks"`
+}
+
+func (r Route) matches(e Event) bool {
+	if len(r.Events) > 0 && !slices.Contains(r.Events, e.Type) {
+		return false
+	}
+	return e.Severity.rank() >= r.MinSeverity.rank()
+}
+
+// Sink delivers events to one destination.
+type Sink interface {
+	Send(ctx context.Context, e Event) error
+}
+
+// Validate checks that every sink is well formed and every route names
+// known event types, severities, and sinks.
+func (c Config) Validate() error {
+	names := make(map[string]bool)
+	for _, sc := range c.Sinks {
+		if sc.Name == "" {
+			return fmt.Errorf("notifications: every sink needs a name")
+		}
+		if names[sc.Name] {
+			return fmt.Errorf("notifications: sink %q is defined twice", sc.Name)
+		}
+		names[sc.Name] = true
+		if _, err := sc.build(); err != nil {
+			return fmt.Errorf("notifications: sink %q: %w", sc.Name, err)
+		}
+	}
+	for i, r := range c.Routes {
+		if len(r.Sinks) == 0 {
+			return fmt.Errorf("notifications: route %d has no sinks", i+1)
+		}
+		for _, name := range r.Sinks {
+			if !names[name] {
+				return fmt.Errorf("notifications: route %d: unknown sink %q", i+1, name)
+			}
+		}
+		for _, t := range r.Events {
+			if !slices.Contains(EventTypes, t) {
+				return fmt.Errorf("notifications: route %d: unknown event type %q", i+1, t)
+			}
+		}
+		switch r.MinSeverity {
+		case "", SeverityInfo, SeverityWarning, SeverityCritical:
+		default:
+			return fmt.Errorf("notifications: route %d: unknown severity %q (expected info, warning, or critical)", i+1, r.MinSeverity)
+		}
+	}
+	return nil
+}
+
+func (sc SinkConfig) build() (Sink, error) {
+	set := 0
+	for _, ok := range []bool{sc.Slack != nil, sc.Webhook != nil, sc.SMTP != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("set exactly one of slack, webhook, and smtp")
+	}
+	switch {
+	case sc.Slack != nil:
+		return sc.Slack.sink()
+	case sc.Webhook != nil:
+		return sc.Webhook.sink()
+	default:
+		return sc.SMTP.sink()
+	}
+}
+
+// Notifier routes events to sinks. A nil Notifier drops every event.
+type Notifier struct {
+	source string
+	routes []Route
+	sinks  map[string]Sink
+}
+
+// New builds a Notifier from cfg. source names the binary raising events.
+func New(cfg Config, source string) (*Notifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	n := &Notifier{source: source, routes: cfg.Routes, sinks: make(map[string]Sink, len(cfg.Sinks))}
+	for _, sc := range cfg.Sinks {
+		sink, err := sc.build()
+		if err != nil {
+			return nil, err
+		}
+		n.sinks[sc.Name] = sink
+	}
+	return n, nil
+}
+
+// FromEnv builds a Notifier from EnvNotifications. It returns nil when the
+// variable is unset.
+func FromEnv(source string) (*Notifier, error) {
+	spec := os.Getenv(EnvNotifications)
+	if spec == "" {
+		return nil, nil
+	}
+	var cfg Config
+	if err := json.Unmarshal([]byte(spec), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", EnvNotifications, err)
+	}
+	return New(cfg, source)
+}
+
+// Notify sends e to the sinks of every route it matches, each sink at most
+// once. Delivery failures are returned together; the event still reaches
+// the other sinks.
+func (n *Notifier) Notify(ctx context.Context, e Event) error {
+	if n == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Severity == "" {
+		e.Severity = SeverityInfo
+	}
+	if e.Source == "" {
+		e.Source = n.source
+	}
+	var errs []error
+	sent := make(map[string]bool)
+	for _, r := range n.routes {
+		if !r.matches(e) {
+			continue
+		}
+		for _, name := range r.Sinks {
+			if sent[name] {
+				continue
+			}
+			sent[name] = true
+			sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+			if err := n.sinks[name].Send(sendCtx, e); err != nil {
+				errs = append(errs, fmt.Errorf("notify %s: %w", name, err))
+			}
+			cancel()
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is an HTTP endpoint that records the bodies and headers posted
+// to it.
+type recorder struct {
+	mu      sync.Mutex
+	bodies  []string
+	headers []http.Header
+	status  int
+}
+
+func newRecorder(t *testing.T) (*recorder, string) {
+	r := &recorder{status: http.StatusOK}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, string(body))
+		r.headers = append(r.headers, req.Header.Clone())
+		status := r.status
+		r.mu.Unlock()
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("nope"))
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv.URL
+}
+
+func (r *recorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies)
+}
+
+var testTime = time.Date(2026, time.October, 16, 9, 30, 0, 0, time.UTC)
+
+func TestNotifyRoutesByTypeAndSeverity(t *testing.T) {
+	oncall, oncallURL := newRecorder(t)
+	audit, auditURL := newRecorder(t)
+	n, err := New(Config{
+		Sinks: []SinkConfig{
+			{Name: "oncall", Slack: &SlackConfig{URL: oncallURL}},
+			{Name: "audit", Webhook: &WebhookConfig{URL: auditURL}},
+		},
+		Routes: []Route{
+			{Events: []EventType{EventRollout, EventDrift}, MinSeverity: SeverityWarning, Sinks: []string{"oncall"}},
+			{Sinks: []string{"audit", "oncall"}, MinSeverity: SeverityCritical},
+			{Sinks: []string{"audit"}},
+		},
+	}, "kubestellar-deploy")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, n.Notify(ctx, Event{Type: EventScaling, Title: "scaled"}))
+	assert.Equal(t, 0, oncall.count(), "info scaling events only go to audit")
+	assert.Equal(t, 1, audit.count())
+
+	require.NoError(t, n.Notify(ctx, Event{Type: EventRollout, Severity: SeverityCritical, Title: "failed"}))
+	assert.Equal(t, 1, oncall.count(), "a sink matched by two routes gets the event once")
+	assert.Equal(t, 2, audit.count())
+
+	require.NoError(t, n.Notify(ctx, Event{Type: EventHealth, Severity: SeverityWarning, Title: "degraded"}))
+	assert.Equal(t, 1, oncall.count())
+	assert.Equal(t, 3, audit.count())
+}
+
+func TestSlackMessage(t *testing.T) {
+	rec, url := newRecorder(t)
+	t.Setenv("TEST_SLACK_URL", url)
+	n, err := New(Config{
+		Sinks:  []SinkConfig{{Name: "slack", Slack: &SlackConfig{URLEnv: "TEST_SLACK_URL"}}},
+		Routes: []Route{{Sinks: []string{"slack"}}},
+	}, "kubestellar-ops")
+	require.NoError(t, err)
+
+	require.NoError(t, n.Notify(context.Background(), Event{
+		Type: EventDrift, Severity: SeverityWarning, Title: "Drift detected", Cluster: "prod-east",
+		Message: "3 resources out of sync", Fields: map[string]string{"repo": "org/config"},
+	}))
+	var msg map[string]string
+	require.NoError(t, json.Unmarshal([]byte(rec.bodies[0]), &msg))
+	assert.Equal(t, ":warning: *Drift detected* (cluster `prod-east`)\n3 resources out of sync\n• repo: org/config", msg["text"])
+}
+
+func TestWebhookPostsEventWithHeaders(t *testing.T) {
+	rec, url := newRecorder(t)
+	t.Setenv("TEST_HOOK_TOKEN", "Bearer s3cret")
+	n, err := New(Config{
+		Sinks: []SinkConfig{{Name: "hook", Webhook: &WebhookConfig{
+			URL:        url,
+			Headers:    map[string]string{"X-Source": "fleet"},
+			HeadersEnv: map[string]string{"Authorization": "TEST_HOOK_TOKEN"},
+		}}},
+		Routes: []Route{{Sinks: []string{"hook"}}},
+	}, "kubestellar-deploy")
+	require.NoError(t, err)
+
+	require.NoError(t, n.Notify(context.Background(), Event{Type: EventRollout, Title: "Rollout done", Time: testTime}))
+	var got Event
+	require.NoError(t, json.Unmarshal([]byte(rec.bodies[0]), &got))
+	assert.Equal(t, Event{Type: EventRollout, Severity: SeverityInfo, Title: "Rollout done", Source: "kubestellar-deploy", Time: testTime}, got)
+	assert.Equal(t, "Bearer s3cret", rec.headers[0].Get("Authorization"))
+	assert.Equal(t, "fleet", rec.headers[0].Get("X-Source"))
+
+	rec.status = http.StatusBadGateway
+	err = n.Notify(context.Background(), Event{Type: EventRollout, Title: "again"})
+	assert.EqualError(t, err, "notify hook: post failed: 502 Bad Gateway: nope")
+}
+
+func TestSMTPSendsEmail(t *testing.T) {
+	var gotAddr, gotFrom, gotMsg string
+	var gotTo []string
+	var gotAuth smtp.Auth
+	old := sendMail
+	sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, string(msg)
+		return nil
+	}
+	t.Cleanup(func() { sendMail = old })
+	t.Setenv("TEST_SMTP_PASSWORD", "pw")
+
+	n, err := New(Config{
+		Sinks: []SinkConfig{{Name: "mail", SMTP: &SMTPConfig{
+			Host: "smtp.example.com", From: "fleet@example.com", To: []string{"sre@example.com"},
+			Username: "fleet", PasswordEnv: "TEST_SMTP_PASSWORD",
+		}}},
+		Routes: []Route{{Sinks: []string{"mail"}}},
+	}, "kubestellar-deploy")
+	require.NoError(t, err)
+
+	require.NoError(t, n.Notify(context.Background(), Event{
+		Type: EventScaling, Severity: SeverityWarning, Title: "Scaling failed", Message: "east: forbidden", Time: testTime,
+	}))
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.NotNil(t, gotAuth)
+	assert.Equal(t, "fleet@example.com", gotFrom)
+	assert.Equal(t, []string{"sre@example.com"}, gotTo)
+	assert.True(t, strings.HasPrefix(gotMsg, "From: fleet@example.com\r\nTo: sre@example.com\r\nSubject: [WARNING] Scaling failed\r\n"), gotMsg)
+	assert.Contains(t, gotMsg, "\r\neast: forbidden\r\n")
+	assert.Contains(t, gotMsg, "Type: scaling\r\nSeverity: warning\r\n")
+}
+
+func TestConfigValidate(t *testing.T) {
+	hook := SinkConfig{Name: "hook", Webhook: &WebhookConfig{URL: "https://hooks.example.com/x"}}
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"no sink kind", Config{Sinks: []SinkConfig{{Name: "x"}}}, `sink "x": set exactly one of slack, webhook, and smtp`},
+		{"two sink kinds", Config{Sinks: []SinkConfig{{Name: "x", Slack: &SlackConfig{URL: "https://a"}, Webhook: &WebhookConfig{URL: "https://b"}}}}, "set exactly one"},
+		{"duplicate sink", Config{Sinks: []SinkConfig{hook, hook}}, `sink "hook" is defined twice`},
+		{"slack url", Config{Sinks: []SinkConfig{{Name: "s", Slack: &SlackConfig{}}}}, "set exactly one of url and urlEnv"},
+		{"webhook url", Config{Sinks: []SinkConfig{{Name: "w", Webhook: &WebhookConfig{URL: "ftp://x"}}}}, `url "ftp://x" is not an http(s) URL`},
+		{"smtp to", Config{Sinks: []SinkConfig{{Name: "m", SMTP: &SMTPConfig{Host: "h", From: "a@b"}}}}, "smtp to is required"},
+		{"unknown sink", Config{Sinks: []SinkConfig{hook}, Routes: []Route{{Sinks: []string{"pager"}}}}, `route 1: unknown sink "pager"`},
+		{"unknown event", Config{Sinks: []SinkConfig{hook}, Routes: []Route{{Events: []EventType{"deploy"}, Sinks: []string{"hook"}}}}, `unknown event type "deploy"`},
+		{"unknown severity", Config{Sinks: []SinkConfig{hook}, Routes: []Route{{MinSeverity: "high", Sinks: []string{"hook"}}}}, `unknown severity "high"`},
+		{"no route sinks", Config{Sinks: []SinkConfig{hook}, Routes: []Route{{}}}, "route 1 has no sinks"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, tt.cfg.Validate(), tt.want)
+		})
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvNotifications, "")
+	n, err := FromEnv("kubestellar-ops")
+	require.NoError(t, err)
+	assert.Nil(t, n)
+	assert.NoError(t, n.Notify(context.Background(), Event{Title: "dropped"}), "a nil notifier drops events")
+
+	t.Setenv(EnvNotifications, `{"sinks":[{"name":"hook","webhook":{"url":"https://hooks.example.com/x"}}],"routes":[{"sinks":["hook"]}]}`)
+	n, err = FromEnv("kubestellar-ops")
+	require.NoError(t, err)
+	assert.NotNil(t, n)
+
+	t.Setenv(EnvNotifications, `{"sinks":`)
+	_, err = FromEnv("kubestellar-ops")
+	assert.ErrorContains(t, err, "invalid KUBESTELLAR_NOTIFICATIONS")
+}
@@ -0,0 +1,248 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Secrets, such as webhook URLs with embedded tokens and SMTP passwords,
+// are referenced by environment variable and read at send time, so the
+// configuration file never holds them.
+
+// secret returns value, or the contents of the variable env names.
+func secret(field, value, env string) (string, error) {
+	if value != "" {
+		return value, nil
+	}
+	if v := os.Getenv(env); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s: %s is not set", field, env)
+}
+
+func checkOneOf(field, value, env string) error {
+	if (value == "") == (env == "") {
+		return fmt.Errorf("set exactly one of %s and %sEnv", field, field)
+	}
+	return nil
+}
+
+// SlackConfig posts to a Slack incoming webhook.
+type SlackConfig struct {
+	URL    string `json:"url,omitempty"`
+	URLEnv string `json:"urlEnv,omitempty"`
+}
+
+func (c *SlackConfig) sink() (Sink, error) {
+	if err := checkOneOf("url", c.URL, c.URLEnv); err != nil {
+		return nil, err
+	}
+	return slackSink{cfg: *c, client: http.DefaultClient}, nil
+}
+
+type slackSink struct {
+	cfg    SlackConfig
+	client *http.Client
+}
+
+var slackEmoji = map[Severity]string{
+	SeverityInfo:     ":information_source:",
+	SeverityWarning:  ":warning:",
+	SeverityCritical: ":rotating_light:",
+}
+
+func (s slackSink) Send(ctx context.Context, e Event) error {
+	target, err := secret("url", s.cfg.URL, s.cfg.URLEnv)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s *%s*", slackEmoji[e.Severity], e.Title)
+	if e.Cluster != "" {
+		fmt.Fprintf(&b, " (cluster `%s`)", e.Cluster)
+	}
+	if e.Message != "" {
+		b.WriteString("\n" + e.Message)
+	}
+	for _, k := range sortedKeys(e.Fields) {
+		fmt.Fprintf(&b, "\n• %s: %s", k, e.Fields[k])
+	}
+	return postJSON(ctx, s.client, target, nil, map[string]string{"text": b.String()})
+}
+
+// WebhookConfig posts each event as JSON to an HTTP endpoint.
+type WebhookConfig struct {
+	URL    string `json:"url,omitempty"`
+	URLEnv string `json:"urlEnv,omitempty"`
+	// Headers are sent with every request. HeadersEnv maps header names to
+	// the variables holding their values, for tokens.
+	Headers    map[string]string `json:"headers,omitempty"`
+	HeadersEnv map[string]string `json:"headersEnv,omitempty"`
+}
+
+func (c *WebhookConfig) sink() (Sink, error) {
+	if err := checkOneOf("url", c.URL, c.URLEnv); err != nil {
+		return nil, err
+	}
+	if c.URL != "" {
+		if u, err := url.Parse(c.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("url %q is not an http(s) URL", c.URL)
+		}
+	}
+	return webhookSink{cfg: *c, client: http.DefaultClient}, nil
+}
+
+type webhookSink struct {
+	cfg    WebhookConfig
+	client *http.Client
+}
+
+func (s webhookSink) Send(ctx context.Context, e Event) error {
+	target, err := secret("url", s.cfg.URL, s.cfg.URLEnv)
+	if err != nil {
+		return err
+	}
+	headers := make(map[string]string, len(s.cfg.Headers)+len(s.cfg.HeadersEnv))
+	for k, v := range s.cfg.Headers {
+		headers[k] = v
+	}
+	for k, env := range s.cfg.HeadersEnv {
+		v, err := secret("header "+k, "", env)
+		if err != nil {
+			return err
+		}
+		headers[k] = v
+	}
+	return postJSON(ctx, s.client, target, headers, e)
+}
+
+func postJSON(ctx context.Context, client *http.Client, target string, headers map[string]string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		// The URL may embed a token; keep it out of the error.
+		return errors.New("invalid webhook URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("post failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("post failed: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// SMTPConfig sends each event as an email. The connection uses STARTTLS
+// when the server offers it, and authenticates when Username is set.
+type SMTPConfig struct {
+	Host        string   `json:"host"`
+	Port        int      `json:"port,omitempty"`
+	From        string   `json:"from"`
+	To          []string `json:"to"`
+	Username    string   `json:"username,omitempty"`
+	PasswordEnv string   `json:"passwordEnv,omitempty"`
+}
+
+func (c *SMTPConfig) sink() (Sink, error) {
+	switch {
+	case c.Host == "":
+		return nil, errors.New("smtp host is required")
+	case c.From == "":
+		return nil, errors.New("smtp from is required")
+	case len(c.To) == 0:
+		return nil, errors.New("smtp to is required")
+	case c.Username != "" && c.PasswordEnv == "":
+		return nil, errors.New("smtp username needs passwordEnv")
+	}
+	for _, addr := range append([]string{c.From}, c.To...) {
+		if strings.ContainsAny(addr, "\r\n") {
+			return nil, fmt.Errorf("invalid address %q", addr)
+		}
+	}
+	return smtpSink{cfg: *c}, nil
+}
+
+// sendMail sends an email. Tests replace it.
+var sendMail = smtp.SendMail
+
+type smtpSink struct {
+	cfg SMTPConfig
+}
+
+func (s smtpSink) Send(ctx context.Context, e Event) error {
+	port := s.cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		password, err := secret("password", "", s.cfg.PasswordEnv)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", s.cfg.Username, password, s.cfg.Host)
+	}
+	subject := fmt.Sprintf("[%s] %s", strings.ToUpper(string(e.Severity)), e.Title)
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n",
+		s.cfg.From, strings.Join(s.cfg.To, ", "), strings.NewReplacer("\r", " ", "\n", " ").Replace(subject))
+	fmt.Fprintf(&body, "%s\r\n", e.Title)
+	if e.Message != "" {
+		fmt.Fprintf(&body, "\r\n%s\r\n", strings.ReplaceAll(e.Message, "\n", "\r\n"))
+	}
+	fmt.Fprintf(&body, "\r\nType: %s\r\nSeverity: %s\r\n", e.Type, e.Severity)
+	if e.Cluster != "" {
+		fmt.Fprintf(&body, "Cluster: %s\r\n", e.Cluster)
+	}
+	for _, k := range sortedKeys(e.Fields) {
+		fmt.Fprintf(&body, "%s: %s\r\n", k, e.Fields[k])
+	}
+	fmt.Fprintf(&body, "Time: %s\r\nSource: %s\r\n", e.Time.Format("2006-01-02 15:04:05 MST"), e.Source)
+
+	// net/smtp has no context support; run it so ctx still bounds the wait.
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- sendMail(net.JoinHostPort(s.cfg.Host, strconv.Itoa(port)), auth, s.cfg.From, s.cfg.To, []byte(body.String()))
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
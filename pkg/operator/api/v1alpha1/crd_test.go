@@ -0,0 +1,66 @@
+package v1alpha1
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// TestCRDsMatchTypes checks that the schemas in config/crd declare exactly
+// the JSON fields of the Go types, so neither drifts from the other.
+func TestCRDsMatchTypes(t *testing.T) {
+	for plural, obj := range map[string]interface{}{
+		"driftchecks":      DriftCheck{},
+		"fleetreports":     FleetReport{},
+		"upgradecampaigns": UpgradeCampaign{},
+	} {
+		data, err := os.ReadFile(filepath.Join("..", "..", "..", "..", "config", "crd", "ops.kubestellar.io_"+plural+".yaml"))
+		require.NoError(t, err)
+		var crd apiextensionsv1.CustomResourceDefinition
+		require.NoError(t, yaml.UnmarshalStrict(data, &crd))
+		require.Equal(t, GroupVersion.Group, crd.Spec.Group)
+		require.Len(t, crd.Spec.Versions, 1)
+		require.Equal(t, GroupVersion.Version, crd.Spec.Versions[0].Name)
+
+		schema := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties
+		typ := reflect.TypeOf(obj)
+		specField, _ := typ.FieldByName("Spec")
+		statusField, _ := typ.FieldByName("Status")
+		assert.Equal(t, jsonFields(specField.Type), propertyNames(schema["spec"]), "%s spec", plural)
+		assert.Equal(t, jsonFields(statusField.Type), propertyNames(schema["status"]), "%s status", plural)
+	}
+}
+
+func propertyNames(schema apiextensionsv1.JSONSchemaProps) []string {
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jsonFields returns the sorted JSON names of a struct's fields, with the
+// fields of inlined structs.
+func jsonFields(typ reflect.Type) []string {
+	var names []string
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" {
+			names = append(names, jsonFields(f.Type)...)
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
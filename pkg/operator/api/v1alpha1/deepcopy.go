@@ -0,0 +1,177 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func (in *RunStatus) DeepCopyInto(out *RunStatus) {
+	*out = *in
+	if in.LastRunTime != nil {
+		out.LastRunTime = in.LastRunTime.DeepCopy()
+	}
+	if in.NextRunTime != nil {
+		out.NextRunTime = in.NextRunTime.DeepCopy()
+	}
+	out.Conditions = copyConditions(in.Conditions)
+}
+
+func copyConditions(in []metav1.Condition) []metav1.Condition {
+	if in == nil {
+		return nil
+	}
+	out := make([]metav1.Condition, len(in))
+	for i := range in {
+		in[i].DeepCopyInto(&out[i])
+	}
+	return out
+}
+
+func (in *DriftCheck) DeepCopyInto(out *DriftCheck) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Clusters = append([]string(nil), in.Spec.Clusters...)
+	in.Status.RunStatus.DeepCopyInto(&out.Status.RunStatus)
+	out.Status.Clusters = append([]DriftCheckClusterStatus(nil), in.Status.Clusters...)
+}
+
+func (in *DriftCheck) DeepCopy() *DriftCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *DriftCheck) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *DriftCheckList) DeepCopyInto(out *DriftCheckList) {
+	*out = *in
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]DriftCheck, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *DriftCheckList) DeepCopy() *DriftCheckList {
+	if in == nil {
+		return nil
+	}
+	out := new(DriftCheckList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *DriftCheckList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *FleetReport) DeepCopyInto(out *FleetReport) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Sections = append([]string(nil), in.Spec.Sections...)
+	out.Spec.Clusters = append([]string(nil), in.Spec.Clusters...)
+	in.Status.RunStatus.DeepCopyInto(&out.Status.RunStatus)
+}
+
+func (in *FleetReport) DeepCopy() *FleetReport {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *FleetReport) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *FleetReportList) DeepCopyInto(out *FleetReportList) {
+	*out = *in
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]FleetReport, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *FleetReportList) DeepCopy() *FleetReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *FleetReportList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *UpgradeCampaign) DeepCopyInto(out *UpgradeCampaign) {
+	*out = *in
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec.Clusters = append([]string(nil), in.Spec.Clusters...)
+	if in.Spec.StartTime != nil {
+		out.Spec.StartTime = in.Spec.StartTime.DeepCopy()
+	}
+	if in.Status.Clusters != nil {
+		out.Status.Clusters = make([]UpgradeCampaignClusterStatus, len(in.Status.Clusters))
+		for i, c := range in.Status.Clusters {
+			if c.StartTime != nil {
+				c.StartTime = c.StartTime.DeepCopy()
+			}
+			if c.CompletionTime != nil {
+				c.CompletionTime = c.CompletionTime.DeepCopy()
+			}
+			out.Status.Clusters[i] = c
+		}
+	}
+	out.Status.Conditions = copyConditions(in.Status.Conditions)
+}
+
+func (in *UpgradeCampaign) DeepCopy() *UpgradeCampaign {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeCampaign)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *UpgradeCampaign) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
+
+func (in *UpgradeCampaignList) DeepCopyInto(out *UpgradeCampaignList) {
+	*out = *in
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		out.Items = make([]UpgradeCampaign, len(in.Items))
+		for i := range in.Items {
+			in.Items[i].DeepCopyInto(&out.Items[i])
+		}
+	}
+}
+
+func (in *UpgradeCampaignList) DeepCopy() *UpgradeCampaignList {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeCampaignList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+func (in *UpgradeCampaignList) DeepCopyObject() runtime.Object {
+	return in.DeepCopy()
+}
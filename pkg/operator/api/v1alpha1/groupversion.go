@@ -0,0 +1,30 @@
+// Package v1alpha1 contains the ops.kubestellar.io/v1alpha1 API: the
+// DriftCheck, UpgradeCampaign, and FleetReport resources that declare the
+// scheduled operations the kubestellar-ops operator runs.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupVersion is the group and version of the API.
+var GroupVersion = schema.GroupVersion{Group: "ops.kubestellar.io", Version: "v1alpha1"}
+
+var (
+	// SchemeBuilder registers the API's types.
+	SchemeBuilder = runtime.NewSchemeBuilder(addKnownTypes)
+	// AddToScheme adds the API's types to a scheme.
+	AddToScheme = SchemeBuilder.AddToScheme
+)
+
+func addKnownTypes(scheme *runtime.Scheme) error {
+	scheme.AddKnownTypes(GroupVersion,
+		&DriftCheck{}, &DriftCheckList{},
+		&UpgradeCampaign{}, &UpgradeCampaignList{},
+		&FleetReport{}, &FleetReportList{},
+	)
+	metav1.AddToGroupVersion(scheme, GroupVersion)
+	return nil
+}
@@ -0,0 +1,193 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition types.
+const (
+	// ConditionReady is true when the last run succeeded.
+	ConditionReady = "Ready"
+	// ConditionDrifted is true when a DriftCheck's last run found drift.
+	ConditionDrifted = "Drifted"
+)
+
+// Schedule is when a recurring operation runs.
+type Schedule struct {
+	// Schedule is a five-field cron schedule, as in a CronJob.
+	Schedule string `json:"schedule"`
+	// TimeZone is the IANA time zone of the schedule; UTC if empty.
+	TimeZone string `json:"timeZone,omitempty"`
+	// Suspend stops further runs; a run in progress is not interrupted.
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// RunStatus is the status shared by recurring operations.
+type RunStatus struct {
+	// ObservedGeneration is the generation the status was computed for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastRunTime is when the operation last ran.
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+	// NextRunTime is when the operation runs next, unless it is suspended.
+	NextRunTime *metav1.Time `json:"nextRunTime,omitempty"`
+	// Conditions include Ready.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// DriftCheck compares manifests in a Git repository with the state of
+// clusters on a schedule, as detect_drift does.
+type DriftCheck struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DriftCheckSpec   `json:"spec"`
+	Status DriftCheckStatus `json:"status,omitempty"`
+}
+
+// DriftCheckSpec is the desired state of a DriftCheck.
+type DriftCheckSpec struct {
+	Schedule `json:",inline"`
+	// RepoURL is the Git repository holding the manifests.
+	RepoURL string `json:"repoURL"`
+	// Path is the directory of the manifests in the repository.
+	Path string `json:"path,omitempty"`
+	// Branch is the branch to read; main if empty.
+	Branch string `json:"branch,omitempty"`
+	// Clusters are the kubeconfig contexts to check; the current context
+	// if empty.
+	Clusters []string `json:"clusters,omitempty"`
+	// Namespace limits the check to manifests in this namespace.
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// DriftCheckStatus is the observed state of a DriftCheck.
+type DriftCheckStatus struct {
+	RunStatus `json:",inline"`
+	// Clusters are the results of the last run, by cluster.
+	Clusters []DriftCheckClusterStatus `json:"clusters,omitempty"`
+}
+
+// DriftCheckClusterStatus is the result of a drift check in one cluster.
+type DriftCheckClusterStatus struct {
+	Name string `json:"name"`
+	// Drifted is the number of resources that differ from Git, Missing and
+	// Modified the number that are absent and changed.
+	Drifted  int `json:"drifted"`
+	Missing  int `json:"missing"`
+	Modified int `json:"modified"`
+	// Error is why the check failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// DriftCheckList is a list of DriftChecks.
+type DriftCheckList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DriftCheck `json:"items"`
+}
+
+// FleetReport renders a fleet report on a schedule, as generate_report
+// does, into a ConfigMap.
+type FleetReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   FleetReportSpec   `json:"spec"`
+	Status FleetReportStatus `json:"status,omitempty"`
+}
+
+// FleetReportSpec is the desired state of a FleetReport.
+type FleetReportSpec struct {
+	Schedule `json:",inline"`
+	// Title is the report's title; "Fleet Report" if empty.
+	Title string `json:"title,omitempty"`
+	// Sections are the analyses to include: fleet_health, security, rbac,
+	// and upgrades; all if empty.
+	Sections []string `json:"sections,omitempty"`
+	// Clusters are the clusters to report on; all discovered clusters if
+	// empty.
+	Clusters []string `json:"clusters,omitempty"`
+	// Namespace limits the security posture section to one namespace.
+	Namespace string `json:"namespace,omitempty"`
+	// Format is html or pdf; html if empty.
+	Format string `json:"format,omitempty"`
+	// ConfigMapName is the ConfigMap in the FleetReport's namespace the
+	// report is written to; the FleetReport's name if empty.
+	ConfigMapName string `json:"configMapName,omitempty"`
+}
+
+// FleetReportStatus is the observed state of a FleetReport.
+type FleetReportStatus struct {
+	RunStatus `json:",inline"`
+	// ConfigMapName is the ConfigMap holding the latest report.
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Bytes is the size of the latest report.
+	Bytes int `json:"bytes,omitempty"`
+}
+
+// FleetReportList is a list of FleetReports.
+type FleetReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []FleetReport `json:"items"`
+}
+
+// Upgrade campaign and cluster phases.
+const (
+	PhasePending   = "Pending"
+	PhaseUpgrading = "Upgrading"
+	PhaseSucceeded = "Succeeded"
+	PhaseFailed    = "Failed"
+)
+
+// UpgradeCampaign upgrades OpenShift clusters to a version one at a time,
+// checking each cluster's upgrade prerequisites first and waiting for its
+// upgrade to complete before starting the next. The campaign stops at the
+// first cluster that fails.
+type UpgradeCampaign struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   UpgradeCampaignSpec   `json:"spec"`
+	Status UpgradeCampaignStatus `json:"status,omitempty"`
+}
+
+// UpgradeCampaignSpec is the desired state of an UpgradeCampaign.
+type UpgradeCampaignSpec struct {
+	// TargetVersion is the OpenShift version to upgrade to. It must be
+	// among each cluster's available updates.
+	TargetVersion string `json:"targetVersion"`
+	// Clusters are the clusters to upgrade, in order.
+	Clusters []string `json:"clusters"`
+	// StartTime is when the campaign may start; immediately if unset.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// Suspend stops the campaign from starting further cluster upgrades.
+	Suspend bool `json:"suspend,omitempty"`
+}
+
+// UpgradeCampaignStatus is the observed state of an UpgradeCampaign.
+type UpgradeCampaignStatus struct {
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Phase is Pending, Upgrading, Succeeded, or Failed.
+	Phase string `json:"phase,omitempty"`
+	// Clusters are the progress of each cluster, in campaign order.
+	Clusters   []UpgradeCampaignClusterStatus `json:"clusters,omitempty"`
+	Conditions []metav1.Condition             `json:"conditions,omitempty"`
+}
+
+// UpgradeCampaignClusterStatus is the progress of one cluster's upgrade.
+type UpgradeCampaignClusterStatus struct {
+	Name string `json:"name"`
+	// Phase is Pending, Upgrading, Succeeded, or Failed.
+	Phase          string       `json:"phase"`
+	Message        string       `json:"message,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// UpgradeCampaignList is a list of UpgradeCampaigns.
+type UpgradeCampaignList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []UpgradeCampaign `json:"items"`
+}
@@ -0,0 +1,126 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/operator/api/v1alpha1"
+)
+
+// driftCheckReconciler runs detect_drift for DriftChecks on their
+// schedules.
+type driftCheckReconciler struct {
+	client.Client
+	tools toolCaller
+	now   func() time.Time
+}
+
+func (r *driftCheckReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var dc v1alpha1.DriftCheck
+	if err := r.Get(ctx, req.NamespacedName, &dc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	now := r.now()
+	status := &dc.Status
+	status.ObservedGeneration = dc.Generation
+	due, result := schedule(dc.Spec.Schedule, &status.RunStatus, dc.CreationTimestamp, now)
+	if due {
+		r.run(ctx, &dc)
+		status.LastRunTime = &metav1.Time{Time: now}
+	}
+	if err := r.Status().Update(ctx, &dc); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// run checks every cluster of dc and records the results in its status.
+func (r *driftCheckReconciler) run(ctx context.Context, dc *v1alpha1.DriftCheck) {
+	clusters := dc.Spec.Clusters
+	if len(clusters) == 0 {
+		clusters = []string{""}
+	}
+	dc.Status.Clusters = nil
+	var drifted, failed []string
+	for _, cluster := range clusters {
+		cs := r.check(ctx, dc.Spec, cluster)
+		dc.Status.Clusters = append(dc.Status.Clusters, cs)
+		switch {
+		case cs.Error != "":
+			failed = append(failed, clusterLabel(cluster))
+		case cs.Drifted > 0:
+			drifted = append(drifted, clusterLabel(cluster))
+		}
+	}
+
+	if len(failed) > 0 {
+		setReady(&dc.Status.Conditions, false, "CheckFailed", "drift check failed in "+strings.Join(failed, ", "))
+	} else {
+		setReady(&dc.Status.Conditions, true, "Checked", "")
+	}
+	drift := metav1.Condition{Type: v1alpha1.ConditionDrifted, Status: metav1.ConditionFalse, Reason: "InSync"}
+	if len(drifted) > 0 {
+		drift.Status, drift.Reason = metav1.ConditionTrue, "Drifted"
+		drift.Message = "resources differ from Git in " + strings.Join(drifted, ", ")
+	}
+	apimeta.SetStatusCondition(&dc.Status.Conditions, drift)
+}
+
+// check runs detect_drift in one cluster.
+func (r *driftCheckReconciler) check(ctx context.Context, spec v1alpha1.DriftCheckSpec, cluster string) v1alpha1.DriftCheckClusterStatus {
+	cs := v1alpha1.DriftCheckClusterStatus{Name: clusterLabel(cluster)}
+	result, err := r.tools.Call(ctx, "detect_drift", map[string]interface{}{
+		"repo_url":  spec.RepoURL,
+		"path":      spec.Path,
+		"branch":    spec.Branch,
+		"cluster":   cluster,
+		"namespace": spec.Namespace,
+	})
+	if err != nil {
+		cs.Error = err.Error()
+		return cs
+	}
+	if err := readDriftSummary(result.Text, &cs); err != nil {
+		cs.Error = err.Error()
+	}
+	return cs
+}
+
+// readDriftSummary reads the counts of drifted resources into cs from the
+// JSON block detect_drift ends its report with. A report without one found
+// no manifests, and so no drift.
+func readDriftSummary(text string, cs *v1alpha1.DriftCheckClusterStatus) error {
+	const fence = "```json\n"
+	start := strings.LastIndex(text, fence)
+	if start < 0 {
+		return nil
+	}
+	block := text[start+len(fence):]
+	if end := strings.Index(block, "```"); end >= 0 {
+		block = block[:end]
+	}
+	report := struct {
+		Summary *v1alpha1.DriftCheckClusterStatus `json:"summary"`
+	}{Summary: cs}
+	if err := json.Unmarshal([]byte(block), &report); err != nil {
+		return fmt.Errorf("failed to read detect_drift summary: %w", err)
+	}
+	return nil
+}
+
+// clusterLabel names a cluster in status; the empty name is the current
+// context.
+func clusterLabel(cluster string) string {
+	if cluster == "" {
+		return "current-context"
+	}
+	return cluster
+}
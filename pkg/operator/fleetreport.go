@@ -0,0 +1,117 @@
+package operator
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/operator/api/v1alpha1"
+)
+
+// maxReportBytes is the largest report a ConfigMap holds, leaving room for
+// its metadata under the API server's 1 MiB object limit.
+const maxReportBytes = 1000 << 10
+
+// fleetReportReconciler runs generate_report for FleetReports on their
+// schedules and writes the reports to ConfigMaps.
+type fleetReportReconciler struct {
+	client.Client
+	scheme *runtime.Scheme
+	tools  toolCaller
+	now    func() time.Time
+}
+
+func (r *fleetReportReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var fr v1alpha1.FleetReport
+	if err := r.Get(ctx, req.NamespacedName, &fr); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	now := r.now()
+	status := &fr.Status
+	status.ObservedGeneration = fr.Generation
+	due, result := schedule(fr.Spec.Schedule, &status.RunStatus, fr.CreationTimestamp, now)
+	if due {
+		if err := r.run(ctx, &fr); err != nil {
+			setReady(&status.Conditions, false, "ReportFailed", err.Error())
+		} else {
+			setReady(&status.Conditions, true, "Reported", "")
+		}
+		status.LastRunTime = &metav1.Time{Time: now}
+	}
+	if err := r.Status().Update(ctx, &fr); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// run renders the report of fr into its ConfigMap.
+func (r *fleetReportReconciler) run(ctx context.Context, fr *v1alpha1.FleetReport) error {
+	args := map[string]interface{}{
+		"title":     fr.Spec.Title,
+		"namespace": fr.Spec.Namespace,
+		"format":    fr.Spec.Format,
+	}
+	if len(fr.Spec.Sections) > 0 {
+		args["sections"] = stringsToArgs(fr.Spec.Sections)
+	}
+	if len(fr.Spec.Clusters) > 0 {
+		args["clusters"] = stringsToArgs(fr.Spec.Clusters)
+	}
+	result, err := r.tools.Call(ctx, "generate_report", args)
+	if err != nil {
+		return err
+	}
+	var report struct {
+		Format      string `json:"format"`
+		MediaType   string `json:"mediaType"`
+		GeneratedAt string `json:"generatedAt"`
+		Data        string `json:"data"`
+	}
+	if err := result.Decode(&report); err != nil {
+		return err
+	}
+	page, err := base64.StdEncoding.DecodeString(report.Data)
+	if err != nil {
+		return fmt.Errorf("failed to decode report: %w", err)
+	}
+	if len(page) > maxReportBytes {
+		return fmt.Errorf("report is %d bytes, more than a ConfigMap holds; report on fewer clusters or sections", len(page))
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: fr.Spec.ConfigMapName, Namespace: fr.Namespace}}
+	if cm.Name == "" {
+		cm.Name = fr.Name
+	}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, cm, func() error {
+		if cm.Annotations == nil {
+			cm.Annotations = make(map[string]string)
+		}
+		cm.Annotations["ops.kubestellar.io/generated-at"] = report.GeneratedAt
+		cm.Annotations["ops.kubestellar.io/media-type"] = report.MediaType
+		cm.Data = nil
+		cm.BinaryData = map[string][]byte{"report." + report.Format: page}
+		return controllerutil.SetControllerReference(fr, cm, r.scheme)
+	}); err != nil {
+		return fmt.Errorf("failed to write ConfigMap %s: %w", cm.Name, err)
+	}
+	fr.Status.ConfigMapName = cm.Name
+	fr.Status.Bytes = len(page)
+	return nil
+}
+
+// stringsToArgs converts a list to the form of a tools/call array argument.
+func stringsToArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
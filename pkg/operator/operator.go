@@ -0,0 +1,172 @@
+// Package operator runs kubestellar-ops as a Kubernetes operator. DriftCheck,
+// UpgradeCampaign, and FleetReport resources on a hub cluster declare
+// scheduled operations, so they can be kept in Git with the rest of the
+// fleet's configuration instead of being set up on each server instance.
+// Controllers built with controller-runtime reconcile the resources and run
+// the same tools as the MCP server, through pkg/ops, against the clusters of
+// the operator's kubeconfig.
+package operator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/cron"
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/operator/api/v1alpha1"
+	"github.com/kubestellar/kubestellar-mcp/pkg/ops"
+)
+
+// Options configure the operator.
+type Options struct {
+	// Kubeconfig holds the clusters the operations run against; empty uses
+	// the default loading rules.
+	Kubeconfig string
+	// Namespace limits the operator to the resources of one hub namespace;
+	// every namespace if empty. It also holds the leader election lease.
+	Namespace string
+	// LeaderElection makes only one of several replicas reconcile at a
+	// time.
+	LeaderElection bool
+	// HealthProbeAddr serves /healthz and /readyz; disabled if empty.
+	HealthProbeAddr string
+	// Impersonation is the identity the operations act as, as with the
+	// server's --as and --as-group flags.
+	Impersonation server.Impersonation
+}
+
+// leaderElectionID names the operator's leader election lease.
+const leaderElectionID = "kubestellar-ops-operator"
+
+// Scheme returns a scheme with the built-in types and the
+// ops.kubestellar.io types.
+func Scheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// Run reconciles the resources on the hub cluster of config until ctx is
+// done.
+func Run(ctx context.Context, config *rest.Config, opts Options) error {
+	ctrl.SetLogger(klog.NewKlogr())
+	scheme, err := Scheme()
+	if err != nil {
+		return err
+	}
+	mgrOpts := ctrl.Options{
+		Scheme:                  scheme,
+		LeaderElection:          opts.LeaderElection,
+		LeaderElectionID:        leaderElectionID,
+		LeaderElectionNamespace: opts.Namespace,
+		HealthProbeBindAddress:  opts.HealthProbeAddr,
+		Metrics:                 metricsserver.Options{BindAddress: "0"},
+	}
+	if opts.Namespace != "" {
+		mgrOpts.Cache = cache.Options{DefaultNamespaces: map[string]cache.Config{opts.Namespace: {}}}
+	}
+	mgr, err := ctrl.NewManager(config, mgrOpts)
+	if err != nil {
+		return fmt.Errorf("failed to create manager: %w", err)
+	}
+	clients, err := multicluster.NewClientManager(opts.Kubeconfig)
+	if err != nil {
+		return err
+	}
+	if err := Setup(mgr, ops.New(opts.Kubeconfig, ops.WithImpersonation(opts.Impersonation)), kubeconfigClusterVersions{clients}); err != nil {
+		return err
+	}
+	if opts.HealthProbeAddr != "" {
+		if err := mgr.AddHealthzCheck("ping", healthz.Ping); err != nil {
+			return err
+		}
+		if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+			return err
+		}
+	}
+	return mgr.Start(ctx)
+}
+
+// Setup registers the controllers with mgr. They run tools with client
+// and read the upgrade progress of OpenShift clusters with versions.
+func Setup(mgr ctrl.Manager, client *ops.Client, versions ClusterVersionReader) error {
+	// Status updates do not change the generation, so they do not trigger
+	// reconciles of their own.
+	changed := builder.WithPredicates(predicate.GenerationChangedPredicate{})
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.DriftCheck{}, changed).
+		Complete(&driftCheckReconciler{Client: mgr.GetClient(), tools: client, now: time.Now}); err != nil {
+		return err
+	}
+	if err := ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.FleetReport{}, changed).
+		Complete(&fleetReportReconciler{Client: mgr.GetClient(), scheme: mgr.GetScheme(), tools: client, now: time.Now}); err != nil {
+		return err
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.UpgradeCampaign{}, changed).
+		Complete(&upgradeCampaignReconciler{Client: mgr.GetClient(), upgrades: client, versions: versions, now: time.Now})
+}
+
+// toolCaller runs a tool by name; *ops.Client implements it.
+type toolCaller interface {
+	Call(ctx context.Context, tool string, args map[string]interface{}) (*ops.Result, error)
+}
+
+// schedule works out whether a recurring operation is due at now: whether
+// its schedule fired since the last run or, before the first, since the
+// resource was created. It records the next run in status and returns the
+// result that requeues the resource for it.
+func schedule(spec v1alpha1.Schedule, status *v1alpha1.RunStatus, created metav1.Time, now time.Time) (bool, ctrl.Result) {
+	status.NextRunTime = nil
+	sched, err := cron.Parse(spec.Schedule, spec.TimeZone)
+	if err != nil {
+		setReady(&status.Conditions, false, "InvalidSchedule", err.Error())
+		return false, ctrl.Result{}
+	}
+	if spec.Suspend {
+		return false, ctrl.Result{}
+	}
+	since := created.Time
+	if status.LastRunTime != nil {
+		since = status.LastRunTime.Time
+	}
+	next := sched.Next(now)
+	status.NextRunTime = &metav1.Time{Time: next}
+	prev := sched.Prev(now)
+	return !prev.IsZero() && prev.After(since), ctrl.Result{RequeueAfter: next.Sub(now)}
+}
+
+// setReady sets the Ready condition.
+func setReady(conditions *[]metav1.Condition, ready bool, reason, message string) {
+	status := metav1.ConditionFalse
+	if ready {
+		status = metav1.ConditionTrue
+	}
+	apimeta.SetStatusCondition(conditions, metav1.Condition{
+		Type:    v1alpha1.ConditionReady,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	})
+}
@@ -0,0 +1,281 @@
+package operator
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/operator/api/v1alpha1"
+	"github.com/kubestellar/kubestellar-mcp/pkg/ops"
+)
+
+var created = time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	t.Helper()
+	scheme, err := Scheme()
+	require.NoError(t, err)
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objs...).
+		WithStatusSubresource(&v1alpha1.DriftCheck{}, &v1alpha1.FleetReport{}, &v1alpha1.UpgradeCampaign{}).
+		Build()
+}
+
+func request(name string) ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "ops", Name: name}}
+}
+
+func objectMeta(name string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{Name: name, Namespace: "ops", CreationTimestamp: metav1.NewTime(created)}
+}
+
+// fakeTools returns a result or error per cluster argument.
+type fakeTools struct {
+	calls   []map[string]interface{}
+	results map[string]string
+	errs    map[string]error
+}
+
+func (f *fakeTools) Call(_ context.Context, tool string, args map[string]interface{}) (*ops.Result, error) {
+	f.calls = append(f.calls, args)
+	cluster, _ := args["cluster"].(string)
+	if err := f.errs[cluster]; err != nil {
+		return nil, err
+	}
+	return &ops.Result{Tool: tool, Text: f.results[cluster]}, nil
+}
+
+func TestScheduleIsDueOnceTheScheduleFires(t *testing.T) {
+	spec := v1alpha1.Schedule{Schedule: "0 10 * * *"}
+	var status v1alpha1.RunStatus
+
+	due, result := schedule(spec, &status, metav1.NewTime(created), created.Add(30*time.Minute))
+	assert.False(t, due)
+	assert.Equal(t, 30*time.Minute, result.RequeueAfter)
+	assert.Equal(t, created.Add(time.Hour), status.NextRunTime.Time)
+
+	due, _ = schedule(spec, &status, metav1.NewTime(created), created.Add(61*time.Minute))
+	assert.True(t, due)
+
+	status.LastRunTime = &metav1.Time{Time: created.Add(61 * time.Minute)}
+	due, _ = schedule(spec, &status, metav1.NewTime(created), created.Add(2*time.Hour))
+	assert.False(t, due)
+
+	spec.Suspend = true
+	due, result = schedule(spec, &status, metav1.NewTime(created), created.Add(25*time.Hour))
+	assert.False(t, due)
+	assert.Zero(t, result.RequeueAfter)
+	assert.Nil(t, status.NextRunTime)
+
+	due, _ = schedule(v1alpha1.Schedule{Schedule: "every day"}, &status, metav1.NewTime(created), created)
+	assert.False(t, due)
+	ready := apimeta.FindStatusCondition(status.Conditions, v1alpha1.ConditionReady)
+	require.NotNil(t, ready)
+	assert.Equal(t, "InvalidSchedule", ready.Reason)
+}
+
+func TestDriftCheckRecordsDriftPerCluster(t *testing.T) {
+	dc := &v1alpha1.DriftCheck{
+		ObjectMeta: objectMeta("prod"),
+		Spec: v1alpha1.DriftCheckSpec{
+			Schedule: v1alpha1.Schedule{Schedule: "@hourly"},
+			RepoURL:  "https://github.com/org/config",
+			Path:     "prod",
+			Clusters: []string{"east", "west", "north"},
+		},
+	}
+	c := newFakeClient(t, dc)
+	tools := &fakeTools{
+		results: map[string]string{
+			"east":  "# GitOps Drift Detection\n\n```json\n{\"drifted\": true, \"summary\": {\"total\": 5, \"drifted\": 2, \"missing\": 1, \"modified\": 1}}\n```\n",
+			"north": "No manifests found in https://github.com/org/config (path: prod)",
+		},
+		errs: map[string]error{"west": errors.New("cluster unreachable")},
+	}
+	r := &driftCheckReconciler{Client: c, tools: tools, now: func() time.Time { return created.Add(90 * time.Minute) }}
+
+	result, err := r.Reconcile(context.Background(), request("prod"))
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, result.RequeueAfter)
+	assert.Equal(t, "https://github.com/org/config", tools.calls[0]["repo_url"])
+
+	require.NoError(t, c.Get(context.Background(), request("prod").NamespacedName, dc))
+	assert.Equal(t, []v1alpha1.DriftCheckClusterStatus{
+		{Name: "east", Drifted: 2, Missing: 1, Modified: 1},
+		{Name: "west", Error: "cluster unreachable"},
+		{Name: "north"},
+	}, dc.Status.Clusters)
+	assert.True(t, apimeta.IsStatusConditionTrue(dc.Status.Conditions, v1alpha1.ConditionDrifted))
+	assert.False(t, apimeta.IsStatusConditionTrue(dc.Status.Conditions, v1alpha1.ConditionReady))
+	assert.True(t, created.Add(90*time.Minute).Equal(dc.Status.LastRunTime.Time))
+	assert.True(t, created.Add(2*time.Hour).Equal(dc.Status.NextRunTime.Time))
+
+	// Not due again until the next hour.
+	tools.calls = nil
+	_, err = r.Reconcile(context.Background(), request("prod"))
+	require.NoError(t, err)
+	assert.Empty(t, tools.calls)
+}
+
+func TestFleetReportWritesConfigMap(t *testing.T) {
+	fr := &v1alpha1.FleetReport{
+		ObjectMeta: objectMeta("weekly"),
+		Spec: v1alpha1.FleetReportSpec{
+			Schedule: v1alpha1.Schedule{Schedule: "0 6 * * 1"},
+			Sections: []string{"security"},
+		},
+	}
+	c := newFakeClient(t, fr)
+	page := "<html>report</html>"
+	tools := &fakeTools{results: map[string]string{"": fmt.Sprintf(
+		`{"format": "html", "mediaType": "text/html", "generatedAt": "2026-03-09T06:00:00Z", "data": %q}`,
+		base64.StdEncoding.EncodeToString([]byte(page)))}}
+	scheme, err := Scheme()
+	require.NoError(t, err)
+	// 2026-03-09 is a Monday.
+	r := &fleetReportReconciler{Client: c, scheme: scheme, tools: tools, now: func() time.Time { return time.Date(2026, 3, 9, 6, 5, 0, 0, time.UTC) }}
+
+	_, err = r.Reconcile(context.Background(), request("weekly"))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"security"}, tools.calls[0]["sections"])
+
+	var cm corev1.ConfigMap
+	require.NoError(t, c.Get(context.Background(), request("weekly").NamespacedName, &cm))
+	assert.Equal(t, page, string(cm.BinaryData["report.html"]))
+	assert.Equal(t, "2026-03-09T06:00:00Z", cm.Annotations["ops.kubestellar.io/generated-at"])
+	require.Len(t, cm.OwnerReferences, 1)
+	assert.Equal(t, "FleetReport", cm.OwnerReferences[0].Kind)
+
+	require.NoError(t, c.Get(context.Background(), request("weekly").NamespacedName, fr))
+	assert.Equal(t, "weekly", fr.Status.ConfigMapName)
+	assert.Equal(t, len(page), fr.Status.Bytes)
+	assert.True(t, apimeta.IsStatusConditionTrue(fr.Status.Conditions, v1alpha1.ConditionReady))
+}
+
+// fakeUpgrades records triggered upgrades; the prerequisites of clusters
+// in failing fail.
+type fakeUpgrades struct {
+	ops.Upgrades
+	failing   map[string]bool
+	triggered []string
+	versions  *fakeVersions
+}
+
+func (f *fakeUpgrades) GetUpgradePrerequisites(_ context.Context, cluster string) (*ops.Result, error) {
+	failed := 0
+	if f.failing[cluster] {
+		failed = 2
+	}
+	return &ops.Result{Text: fmt.Sprintf("## Summary\n\n- **Passed:** 4\n- **Failed:** %d\n- **Warnings:** 0\n", failed)}, nil
+}
+
+func (f *fakeUpgrades) TriggerOpenShiftUpgrade(_ context.Context, cluster, targetVersion string) (*ops.Result, error) {
+	f.triggered = append(f.triggered, cluster)
+	f.versions.desired[cluster] = targetVersion
+	return &ops.Result{Text: "# Upgrade Initiated"}, nil
+}
+
+// fakeVersions serves ClusterVersions whose desired update was requested
+// and whose history holds the completed versions.
+type fakeVersions struct {
+	desired   map[string]string
+	completed map[string]string
+}
+
+func (f *fakeVersions) ClusterVersion(_ context.Context, cluster string) (*unstructured.Unstructured, error) {
+	cv := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	if v := f.desired[cluster]; v != "" {
+		_ = unstructured.SetNestedField(cv.Object, v, "spec", "desiredUpdate", "version")
+	}
+	if v := f.completed[cluster]; v != "" {
+		_ = unstructured.SetNestedSlice(cv.Object, []interface{}{
+			map[string]interface{}{"version": v, "state": "Completed"},
+		}, "status", "history")
+	}
+	return cv, nil
+}
+
+func TestUpgradeCampaignUpgradesClustersInOrder(t *testing.T) {
+	uc := &v1alpha1.UpgradeCampaign{
+		ObjectMeta: objectMeta("q1"),
+		Spec:       v1alpha1.UpgradeCampaignSpec{TargetVersion: "4.15.3", Clusters: []string{"staging", "prod"}},
+	}
+	c := newFakeClient(t, uc)
+	versions := &fakeVersions{desired: map[string]string{}, completed: map[string]string{"staging": "4.14.9", "prod": "4.14.9"}}
+	upgrades := &fakeUpgrades{versions: versions}
+	r := &upgradeCampaignReconciler{Client: c, upgrades: upgrades, versions: versions, now: func() time.Time { return created }}
+	reconcile := func() *v1alpha1.UpgradeCampaign {
+		t.Helper()
+		_, err := r.Reconcile(context.Background(), request("q1"))
+		require.NoError(t, err)
+		var got v1alpha1.UpgradeCampaign
+		require.NoError(t, c.Get(context.Background(), request("q1").NamespacedName, &got))
+		return &got
+	}
+
+	got := reconcile()
+	assert.Equal(t, v1alpha1.PhaseUpgrading, got.Status.Phase)
+	assert.Equal(t, []string{"staging"}, upgrades.triggered)
+	assert.Equal(t, v1alpha1.PhaseUpgrading, got.Status.Clusters[0].Phase)
+	assert.Equal(t, v1alpha1.PhasePending, got.Status.Clusters[1].Phase)
+
+	// prod waits while staging upgrades.
+	got = reconcile()
+	assert.Equal(t, []string{"staging"}, upgrades.triggered)
+
+	versions.completed["staging"] = "4.15.3"
+	got = reconcile()
+	assert.Equal(t, v1alpha1.PhaseSucceeded, got.Status.Clusters[0].Phase)
+	got = reconcile()
+	assert.Equal(t, []string{"staging", "prod"}, upgrades.triggered)
+
+	versions.completed["prod"] = "4.15.3"
+	reconcile()
+	got = reconcile()
+	assert.Equal(t, v1alpha1.PhaseSucceeded, got.Status.Phase)
+	assert.True(t, apimeta.IsStatusConditionTrue(got.Status.Conditions, v1alpha1.ConditionReady))
+}
+
+func TestUpgradeCampaignStopsAtFailedPrerequisites(t *testing.T) {
+	start := metav1.NewTime(created.Add(time.Hour))
+	uc := &v1alpha1.UpgradeCampaign{
+		ObjectMeta: objectMeta("q1"),
+		Spec:       v1alpha1.UpgradeCampaignSpec{TargetVersion: "4.15.3", Clusters: []string{"staging", "prod"}, StartTime: &start},
+	}
+	c := newFakeClient(t, uc)
+	versions := &fakeVersions{desired: map[string]string{}, completed: map[string]string{}}
+	upgrades := &fakeUpgrades{versions: versions, failing: map[string]bool{"staging": true}}
+	now := created
+	r := &upgradeCampaignReconciler{Client: c, upgrades: upgrades, versions: versions, now: func() time.Time { return now }}
+
+	result, err := r.Reconcile(context.Background(), request("q1"))
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, result.RequeueAfter)
+	require.NoError(t, c.Get(context.Background(), request("q1").NamespacedName, uc))
+	assert.Equal(t, v1alpha1.PhasePending, uc.Status.Phase)
+
+	now = created.Add(time.Hour)
+	_, err = r.Reconcile(context.Background(), request("q1"))
+	require.NoError(t, err)
+	require.NoError(t, c.Get(context.Background(), request("q1").NamespacedName, uc))
+	assert.Equal(t, v1alpha1.PhaseFailed, uc.Status.Phase)
+	assert.Equal(t, v1alpha1.PhaseFailed, uc.Status.Clusters[0].Phase)
+	assert.Contains(t, uc.Status.Clusters[0].Message, "2 upgrade prerequisites failed")
+	assert.Equal(t, v1alpha1.PhasePending, uc.Status.Clusters[1].Phase)
+	assert.Empty(t, upgrades.triggered)
+}
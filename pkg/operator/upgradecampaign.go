@@ -0,0 +1,242 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/messages"
+	"github.com/kubestellar/kubestellar-mcp/pkg/multicluster"
+	"github.com/kubestellar/kubestellar-mcp/pkg/operator/api/v1alpha1"
+	"github.com/kubestellar/kubestellar-mcp/pkg/ops"
+)
+
+// upgradePollInterval is how often the progress of a cluster's upgrade is
+// checked. OpenShift upgrades take the better part of an hour.
+const upgradePollInterval = time.Minute
+
+var clusterVersionGVR = schema.GroupVersionResource{Group: "config.openshift.io", Version: "v1", Resource: "clusterversions"}
+
+// ClusterVersionReader reads the ClusterVersion of an OpenShift cluster,
+// which reports the progress of its upgrades.
+type ClusterVersionReader interface {
+	ClusterVersion(ctx context.Context, cluster string) (*unstructured.Unstructured, error)
+}
+
+// kubeconfigClusterVersions reads ClusterVersions from the clusters of a
+// kubeconfig.
+type kubeconfigClusterVersions struct {
+	clients *multicluster.ClientManager
+}
+
+func (k kubeconfigClusterVersions) ClusterVersion(ctx context.Context, cluster string) (*unstructured.Unstructured, error) {
+	config, err := k.clients.GetConfig(cluster)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return dyn.Resource(clusterVersionGVR).Get(ctx, "version", metav1.GetOptions{})
+}
+
+// upgradeCampaignReconciler upgrades the clusters of UpgradeCampaigns one
+// at a time. Each reconcile takes at most one step: starting a cluster's
+// upgrade or checking on it.
+type upgradeCampaignReconciler struct {
+	client.Client
+	upgrades ops.Upgrades
+	versions ClusterVersionReader
+	now      func() time.Time
+}
+
+func (r *upgradeCampaignReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var uc v1alpha1.UpgradeCampaign
+	if err := r.Get(ctx, req.NamespacedName, &uc); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	result := r.step(ctx, &uc)
+	if err := r.Status().Update(ctx, &uc); err != nil {
+		return ctrl.Result{}, err
+	}
+	return result, nil
+}
+
+// step advances the campaign and returns when to look at it again.
+func (r *upgradeCampaignReconciler) step(ctx context.Context, uc *v1alpha1.UpgradeCampaign) ctrl.Result {
+	now := r.now()
+	status := &uc.Status
+	status.ObservedGeneration = uc.Generation
+	syncCampaignClusters(status, uc.Spec.Clusters)
+	if status.Phase == v1alpha1.PhaseSucceeded || status.Phase == v1alpha1.PhaseFailed {
+		return ctrl.Result{}
+	}
+	if status.Phase == "" {
+		status.Phase = v1alpha1.PhasePending
+	}
+
+	var current *v1alpha1.UpgradeCampaignClusterStatus
+	for i := range status.Clusters {
+		if status.Clusters[i].Phase != v1alpha1.PhaseSucceeded {
+			current = &status.Clusters[i]
+			break
+		}
+	}
+	if current == nil {
+		status.Phase = v1alpha1.PhaseSucceeded
+		setReady(&status.Conditions, true, "Upgraded", fmt.Sprintf("all clusters run %s", uc.Spec.TargetVersion))
+		return ctrl.Result{}
+	}
+
+	switch current.Phase {
+	case v1alpha1.PhasePending:
+		if uc.Spec.Suspend {
+			return ctrl.Result{}
+		}
+		if start := uc.Spec.StartTime; start != nil && now.Before(start.Time) {
+			return ctrl.Result{RequeueAfter: start.Sub(now)}
+		}
+		r.start(ctx, current, uc.Spec.TargetVersion, now)
+	case v1alpha1.PhaseUpgrading:
+		r.check(ctx, current, uc.Spec.TargetVersion, now)
+	}
+
+	switch current.Phase {
+	case v1alpha1.PhaseFailed:
+		status.Phase = v1alpha1.PhaseFailed
+		setReady(&status.Conditions, false, "UpgradeFailed", fmt.Sprintf("%s: %s", current.Name, current.Message))
+		return ctrl.Result{}
+	case v1alpha1.PhaseSucceeded:
+		// Move on to the next cluster.
+		return ctrl.Result{RequeueAfter: time.Second}
+	}
+	status.Phase = v1alpha1.PhaseUpgrading
+	setReady(&status.Conditions, false, "Upgrading", fmt.Sprintf("upgrading %s", current.Name))
+	return ctrl.Result{RequeueAfter: upgradePollInterval}
+}
+
+// start checks a cluster's upgrade prerequisites and triggers its upgrade.
+func (r *upgradeCampaignReconciler) start(ctx context.Context, cs *v1alpha1.UpgradeCampaignClusterStatus, target string, now time.Time) {
+	fail := func(format string, args ...interface{}) {
+		cs.Phase = v1alpha1.PhaseFailed
+		cs.Message = fmt.Sprintf(format, args...)
+		cs.CompletionTime = &metav1.Time{Time: now}
+	}
+	cv, err := r.versions.ClusterVersion(ctx, cs.Name)
+	if err != nil {
+		fail("failed to read ClusterVersion: %v", err)
+		return
+	}
+	if upgraded(cv, target) {
+		cs.Phase = v1alpha1.PhaseSucceeded
+		cs.Message = "already at " + target
+		cs.CompletionTime = &metav1.Time{Time: now}
+		return
+	}
+
+	prereqs, err := r.upgrades.GetUpgradePrerequisites(ctx, cs.Name)
+	if err != nil {
+		fail("failed to check upgrade prerequisites: %v", err)
+		return
+	}
+	if n := failedPrerequisites(prereqs.Text); n > 0 {
+		fail("%d upgrade prerequisites failed; see get_upgrade_prerequisites", n)
+		return
+	}
+	if _, err := r.upgrades.TriggerOpenShiftUpgrade(ctx, cs.Name, target); err != nil {
+		fail("failed to trigger upgrade: %v", err)
+		return
+	}
+	// The tool reports a version that is not an available update without
+	// failing, so confirm the upgrade was requested.
+	if cv, err = r.versions.ClusterVersion(ctx, cs.Name); err != nil {
+		fail("failed to read ClusterVersion: %v", err)
+		return
+	}
+	if desired, _, _ := unstructured.NestedString(cv.Object, "spec", "desiredUpdate", "version"); desired != target {
+		fail("%s is not an available update", target)
+		return
+	}
+	cs.Phase = v1alpha1.PhaseUpgrading
+	cs.Message = "upgrade to " + target + " started"
+	cs.StartTime = &metav1.Time{Time: now}
+}
+
+// check follows a cluster's upgrade. A cluster whose API server cannot be
+// reached is not failed: that is expected while the control plane
+// restarts.
+func (r *upgradeCampaignReconciler) check(ctx context.Context, cs *v1alpha1.UpgradeCampaignClusterStatus, target string, now time.Time) {
+	cv, err := r.versions.ClusterVersion(ctx, cs.Name)
+	if err != nil {
+		cs.Message = fmt.Sprintf("failed to read ClusterVersion: %v", err)
+		return
+	}
+	if upgraded(cv, target) {
+		cs.Phase = v1alpha1.PhaseSucceeded
+		cs.Message = "upgraded to " + target
+		cs.CompletionTime = &metav1.Time{Time: now}
+		return
+	}
+	cs.Message = "upgrading to " + target
+	conditions, _, _ := unstructured.NestedSlice(cv.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, _ := c.(map[string]interface{})
+		condType, _ := cond["type"].(string)
+		condStatus, _ := cond["status"].(string)
+		message, _ := cond["message"].(string)
+		if condStatus == "True" && message != "" && (condType == "Progressing" || condType == "Failing") {
+			cs.Message = message
+		}
+	}
+}
+
+// upgraded reports whether the latest update in a ClusterVersion's history
+// is a completed update to target.
+func upgraded(cv *unstructured.Unstructured, target string) bool {
+	history, _, _ := unstructured.NestedSlice(cv.Object, "status", "history")
+	if len(history) == 0 {
+		return false
+	}
+	latest, _ := history[0].(map[string]interface{})
+	version, _ := latest["version"].(string)
+	state, _ := latest["state"].(string)
+	return version == target && state == "Completed"
+}
+
+// failedPrerequisites returns the number of failed checks reported by
+// get_upgrade_prerequisites, read through the message catalog.
+func failedPrerequisites(text string) int {
+	m := messages.Pattern(messages.PrereqFailed).FindStringSubmatch(text)
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(m[1]))
+	return n
+}
+
+// syncCampaignClusters lays out the status of each cluster in campaign
+// order, keeping the progress of clusters already in it.
+func syncCampaignClusters(status *v1alpha1.UpgradeCampaignStatus, clusters []string) {
+	existing := make(map[string]v1alpha1.UpgradeCampaignClusterStatus, len(status.Clusters))
+	for _, cs := range status.Clusters {
+		existing[cs.Name] = cs
+	}
+	status.Clusters = make([]v1alpha1.UpgradeCampaignClusterStatus, 0, len(clusters))
+	for _, name := range clusters {
+		cs, ok := existing[name]
+		if !ok {
+			cs = v1alpha1.UpgradeCampaignClusterStatus{Name: name, Phase: v1alpha1.PhasePending}
+		}
+		status.Clusters = append(status.Clusters, cs)
+	}
+}
@@ -0,0 +1,78 @@
+package ops
+
+import "context"
+
+// Diagnostics finds problems in a cluster's workloads.
+type Diagnostics interface {
+	// FindPodIssues reports pods that are pending, failing, crash looping,
+	// restarting often, or cannot pull their images.
+	FindPodIssues(ctx context.Context, opts PodIssuesOptions) (*Result, error)
+	// FindDeploymentIssues reports Deployments that are not fully
+	// available or whose rollout is stuck.
+	FindDeploymentIssues(ctx context.Context, scope Scope) (*Result, error)
+	// CheckResourceLimits reports containers without CPU or memory
+	// requests and limits.
+	CheckResourceLimits(ctx context.Context, scope Scope) (*Result, error)
+	// CheckSecurityIssues reports privileged, root, and otherwise risky
+	// pod configurations.
+	CheckSecurityIssues(ctx context.Context, scope Scope) (*Result, error)
+	// AnalyzeNamespace summarizes the health of one namespace; Namespace
+	// is required.
+	AnalyzeNamespace(ctx context.Context, scope Scope) (*Result, error)
+	// GetWarningEvents returns recent Warning events.
+	GetWarningEvents(ctx context.Context, opts WarningEventsOptions) (*Result, error)
+}
+
+// PodIssuesOptions are the arguments of find_pod_issues.
+type PodIssuesOptions struct {
+	Scope
+	// IncludeCompleted also reports pods that have run to completion.
+	IncludeCompleted bool
+	LabelSelector    string
+}
+
+// WarningEventsOptions are the arguments of get_warning_events.
+type WarningEventsOptions struct {
+	Scope
+	// InvolvedObject only returns events about the object of this name.
+	InvolvedObject string `json:"involved_object,omitempty"`
+	// InvolvedKind only returns events about objects of this kind.
+	InvolvedKind string `json:"involved_kind,omitempty"`
+	// Reason only returns events with this reason.
+	Reason string `json:"reason,omitempty"`
+	// Limit is the maximum number of events, 50 if zero.
+	Limit int `json:"limit,omitempty"`
+}
+
+func (c *Client) FindPodIssues(ctx context.Context, opts PodIssuesOptions) (*Result, error) {
+	args := struct {
+		Scope
+		IncludeCompleted string `json:"include_completed,omitempty"`
+		LabelSelector    string `json:"label_selector,omitempty"`
+	}{Scope: opts.Scope, LabelSelector: opts.LabelSelector}
+	if opts.IncludeCompleted {
+		// The tool takes the flag as a string.
+		args.IncludeCompleted = "true"
+	}
+	return c.call(ctx, "find_pod_issues", args)
+}
+
+func (c *Client) FindDeploymentIssues(ctx context.Context, scope Scope) (*Result, error) {
+	return c.call(ctx, "find_deployment_issues", scope)
+}
+
+func (c *Client) CheckResourceLimits(ctx context.Context, scope Scope) (*Result, error) {
+	return c.call(ctx, "check_resource_limits", scope)
+}
+
+func (c *Client) CheckSecurityIssues(ctx context.Context, scope Scope) (*Result, error) {
+	return c.call(ctx, "check_security_issues", scope)
+}
+
+func (c *Client) AnalyzeNamespace(ctx context.Context, scope Scope) (*Result, error) {
+	return c.call(ctx, "analyze_namespace", scope)
+}
+
+func (c *Client) GetWarningEvents(ctx context.Context, opts WarningEventsOptions) (*Result, error) {
+	return c.call(ctx, "get_warning_events", opts)
+}
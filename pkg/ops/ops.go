@@ -0,0 +1,125 @@
+// Package ops is a Go API for the kubestellar-ops tool suite. It runs the
+// same tool implementations as the MCP server, in process and without the
+// MCP transport, so other KubeStellar components and platforms can call
+// them programmatically. Calls go through the server's authorization
+// policies, approval gate, redaction, and audit log, exactly as tools/call
+// requests do.
+//
+// The typed interfaces (Diagnostics, Upgrades, Policy) cover the most used
+// tools; Client.Call runs any other tool by name.
+package ops
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/toolerror"
+)
+
+// Result is the result of a tool.
+type Result struct {
+	// Tool is the name of the tool that produced the result.
+	Tool string
+	// Text is the tool's output, usually Markdown, sometimes JSON.
+	Text string
+	// Meta is the result's metadata, such as the age of a cached result
+	// or the number of redacted values.
+	Meta map[string]interface{}
+}
+
+// Decode unmarshals the text of a tool that returns JSON into v.
+func (r *Result) Decode(v interface{}) error {
+	if err := json.Unmarshal([]byte(r.Text), v); err != nil {
+		return fmt.Errorf("result of %s is not JSON: %w", r.Tool, err)
+	}
+	return nil
+}
+
+// runner runs a tool by name; *server.Server implements it.
+type runner interface {
+	CallTool(ctx context.Context, name string, args map[string]interface{}) (server.CallToolResult, error)
+}
+
+// Client runs tools. It implements Diagnostics, Upgrades, and Policy, and
+// is safe for concurrent use.
+type Client struct {
+	runner runner
+}
+
+var (
+	_ Diagnostics = (*Client)(nil)
+	_ Upgrades    = (*Client)(nil)
+	_ Policy      = (*Client)(nil)
+)
+
+// Option configures a Client created by New.
+type Option func(*server.Server)
+
+// WithImpersonation makes every call act as the given identity, as the
+// server's --as and --as-group flags do.
+func WithImpersonation(imp server.Impersonation) Option {
+	return func(s *server.Server) { s.SetImpersonation(imp) }
+}
+
+// New returns a Client for the clusters of kubeconfig; an empty path uses
+// the default loading rules.
+func New(kubeconfig string, opts ...Option) *Client {
+	s := server.NewServer(kubeconfig)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return &Client{runner: s}
+}
+
+// NewForServer returns a Client that runs tools on an existing server,
+// sharing its clients, caches, and configuration.
+func NewForServer(s *server.Server) *Client {
+	return &Client{runner: s}
+}
+
+// Call runs the named tool with args, given as in a tools/call request.
+// A tool that fails returns a *toolerror.Error, classified so callers can
+// tell, for example, an unreachable cluster from a permission problem.
+func (c *Client) Call(ctx context.Context, tool string, args map[string]interface{}) (*Result, error) {
+	result, err := c.runner.CallTool(ctx, tool, args)
+	if err != nil {
+		return nil, err
+	}
+	texts := make([]string, 0, len(result.Content))
+	for _, block := range result.Content {
+		texts = append(texts, block.Text)
+	}
+	text := strings.Join(texts, "\n")
+	if result.IsError {
+		if e, ok := result.Meta[toolerror.MetaKey].(*toolerror.Error); ok {
+			return nil, e
+		}
+		return nil, toolerror.FromText(text)
+	}
+	return &Result{Tool: tool, Text: text, Meta: result.Meta}, nil
+}
+
+// call runs tool with the arguments of opts, a struct whose JSON fields are
+// the tool's arguments.
+func (c *Client) call(ctx context.Context, tool string, opts interface{}) (*Result, error) {
+	data, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	args := make(map[string]interface{})
+	if err := json.Unmarshal(data, &args); err != nil {
+		return nil, err
+	}
+	return c.Call(ctx, tool, args)
+}
+
+// Scope selects the cluster and namespace a tool looks at. An empty
+// Cluster is the kubeconfig's current context; an empty Namespace is every
+// namespace.
+type Scope struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
@@ -0,0 +1,154 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/mcp/server"
+	"github.com/kubestellar/kubestellar-mcp/pkg/toolerror"
+)
+
+// recordingRunner records calls and returns result.
+type recordingRunner struct {
+	calls  []recordedCall
+	result server.CallToolResult
+}
+
+type recordedCall struct {
+	tool string
+	args map[string]interface{}
+}
+
+func (r *recordingRunner) CallTool(_ context.Context, name string, args map[string]interface{}) (server.CallToolResult, error) {
+	r.calls = append(r.calls, recordedCall{name, args})
+	return r.result, nil
+}
+
+// TestMethodsMatchToolSchemas calls every typed method with every option
+// set and checks that the tools and arguments exist, so a renamed tool or
+// argument breaks the build's tests rather than embedders.
+func TestMethodsMatchToolSchemas(t *testing.T) {
+	r := &recordingRunner{result: server.CallToolResult{Content: []server.ContentBlock{{Type: "text", Text: "ok"}}}}
+	c := &Client{runner: r}
+	ctx := context.Background()
+	scope := Scope{Cluster: "prod", Namespace: "shop"}
+	approval := Approval{Approved: true, PlanID: "plan-1"}
+
+	for _, call := range []func() (*Result, error){
+		func() (*Result, error) {
+			return c.FindPodIssues(ctx, PodIssuesOptions{Scope: scope, IncludeCompleted: true, LabelSelector: "app=web"})
+		},
+		func() (*Result, error) { return c.FindDeploymentIssues(ctx, scope) },
+		func() (*Result, error) { return c.CheckResourceLimits(ctx, scope) },
+		func() (*Result, error) { return c.CheckSecurityIssues(ctx, scope) },
+		func() (*Result, error) { return c.AnalyzeNamespace(ctx, scope) },
+		func() (*Result, error) {
+			return c.GetWarningEvents(ctx, WarningEventsOptions{Scope: scope, InvolvedObject: "web", Limit: 10})
+		},
+		func() (*Result, error) { return c.DetectClusterType(ctx, "prod") },
+		func() (*Result, error) { return c.GetClusterVersionInfo(ctx, "prod") },
+		func() (*Result, error) { return c.GetUpgradePrerequisites(ctx, "prod") },
+		func() (*Result, error) { return c.GetUpgradeStatus(ctx, "prod") },
+		func() (*Result, error) { return c.SimulateUpgradeImpact(ctx, "prod", "1.32") },
+		func() (*Result, error) { return c.CheckHelmReleaseUpgrades(ctx, scope) },
+		func() (*Result, error) { return c.CheckOLMOperatorUpgrades(ctx, scope) },
+		func() (*Result, error) { return c.TriggerOpenShiftUpgrade(ctx, "prod", "4.14.5") },
+		func() (*Result, error) { return c.TriggerStagedOpenShiftUpgrade(ctx, "prod", "4.14.5", 2) },
+		func() (*Result, error) { return c.PauseMCP(ctx, "prod", "worker") },
+		func() (*Result, error) { return c.UnpauseMCP(ctx, "prod", "worker") },
+		func() (*Result, error) { return c.CheckGatekeeper(ctx, "prod") },
+		func() (*Result, error) { return c.GetOwnershipPolicyStatus(ctx, "prod") },
+		func() (*Result, error) {
+			return c.ListOwnershipViolations(ctx, OwnershipViolationsOptions{Scope: scope, Limit: 5})
+		},
+		func() (*Result, error) {
+			return c.InstallOwnershipPolicy(ctx, InstallOwnershipPolicyOptions{
+				Cluster: "prod", Labels: []string{"owner"}, TargetNamespaces: []string{"shop"},
+				ExcludeNamespaces: []string{"kube-system"}, Mode: "warn", Approval: approval,
+			})
+		},
+		func() (*Result, error) {
+			return c.SetOwnershipPolicyMode(ctx, SetOwnershipPolicyModeOptions{Cluster: "prod", Mode: "enforce", Approval: approval})
+		},
+		func() (*Result, error) {
+			return c.UninstallOwnershipPolicy(ctx, UninstallOwnershipPolicyOptions{Cluster: "prod", Approval: approval})
+		},
+	} {
+		result, err := call()
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result.Text)
+	}
+
+	for _, call := range r.calls {
+		schema, ok := server.ToolSchema(call.tool)
+		if !assert.True(t, ok, "tool %s is not registered", call.tool) {
+			continue
+		}
+		for arg := range call.args {
+			assert.Contains(t, schema.InputSchema.Properties, arg, "tool %s has no argument %s", call.tool, arg)
+		}
+	}
+	assert.Equal(t, "true", r.calls[0].args["include_completed"])
+	assert.Equal(t, float64(10), r.calls[5].args["limit"])
+}
+
+func TestCallReturnsToolErrors(t *testing.T) {
+	classified := toolerror.New(toolerror.PermissionDenied, "pods is forbidden")
+	r := &recordingRunner{result: server.CallToolResult{
+		Content: []server.ContentBlock{{Type: "text", Text: "pods is forbidden"}},
+		IsError: true,
+		Meta:    map[string]interface{}{toolerror.MetaKey: classified},
+	}}
+	c := &Client{runner: r}
+
+	_, err := c.FindDeploymentIssues(context.Background(), Scope{})
+	var e *toolerror.Error
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, toolerror.PermissionDenied, e.Code)
+
+	r.result.Meta = nil
+	_, err = c.FindDeploymentIssues(context.Background(), Scope{})
+	require.True(t, errors.As(err, &e))
+	assert.Equal(t, "pods is forbidden", e.Message)
+}
+
+func TestClientRunsToolsInProcess(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/namespaces/shop/pods" {
+			_, _ = w.Write([]byte(`{"kind":"PodList","apiVersion":"v1","metadata":{},"items":[]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+	}))
+	t.Cleanup(api.Close)
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster: {server: `+api.URL+`}
+contexts:
+- name: test
+  context: {cluster: test, user: test}
+current-context: test
+users:
+- name: test
+  user: {}
+`), 0o600))
+
+	c := New(kubeconfig)
+	result, err := c.FindPodIssues(context.Background(), PodIssuesOptions{Scope: Scope{Namespace: "shop"}})
+	require.NoError(t, err)
+	assert.Equal(t, "find_pod_issues", result.Tool)
+	assert.NotEmpty(t, result.Text)
+}
@@ -0,0 +1,89 @@
+package ops
+
+import "context"
+
+// Policy manages the OPA Gatekeeper ownership labels policy, which
+// requires owner and team labels on workloads.
+type Policy interface {
+	// CheckGatekeeper reports whether Gatekeeper is installed and running.
+	CheckGatekeeper(ctx context.Context, cluster string) (*Result, error)
+	// GetOwnershipPolicyStatus reports the policy's mode and violation
+	// count.
+	GetOwnershipPolicyStatus(ctx context.Context, cluster string) (*Result, error)
+	// ListOwnershipViolations lists the resources missing the labels.
+	ListOwnershipViolations(ctx context.Context, opts OwnershipViolationsOptions) (*Result, error)
+	// InstallOwnershipPolicy installs the policy's ConstraintTemplate and
+	// Constraint.
+	InstallOwnershipPolicy(ctx context.Context, opts InstallOwnershipPolicyOptions) (*Result, error)
+	// SetOwnershipPolicyMode changes the policy's enforcement mode.
+	SetOwnershipPolicyMode(ctx context.Context, opts SetOwnershipPolicyModeOptions) (*Result, error)
+	// UninstallOwnershipPolicy removes the policy.
+	UninstallOwnershipPolicy(ctx context.Context, opts UninstallOwnershipPolicyOptions) (*Result, error)
+}
+
+// Approval approves a change when the server runs in approval mode; see
+// the approved and plan_id arguments of mutating tools. Without it such a
+// call is a dry run.
+type Approval struct {
+	Approved bool   `json:"approved,omitempty"`
+	PlanID   string `json:"plan_id,omitempty"`
+}
+
+// OwnershipViolationsOptions are the arguments of list_ownership_violations.
+type OwnershipViolationsOptions struct {
+	Scope
+	// Limit is the maximum number of violations, 50 if zero.
+	Limit int `json:"limit,omitempty"`
+}
+
+// InstallOwnershipPolicyOptions are the arguments of
+// install_ownership_policy. Empty fields take the tool's defaults.
+type InstallOwnershipPolicyOptions struct {
+	Cluster           string   `json:"cluster,omitempty"`
+	Labels            []string `json:"labels,omitempty"`
+	TargetNamespaces  []string `json:"target_namespaces,omitempty"`
+	ExcludeNamespaces []string `json:"exclude_namespaces,omitempty"`
+	// Mode is dryrun, warn, or enforce.
+	Mode string `json:"mode,omitempty"`
+	Approval
+}
+
+// SetOwnershipPolicyModeOptions are the arguments of
+// set_ownership_policy_mode.
+type SetOwnershipPolicyModeOptions struct {
+	Cluster string `json:"cluster,omitempty"`
+	// Mode is dryrun, warn, or enforce.
+	Mode string `json:"mode"`
+	Approval
+}
+
+// UninstallOwnershipPolicyOptions are the arguments of
+// uninstall_ownership_policy.
+type UninstallOwnershipPolicyOptions struct {
+	Cluster string `json:"cluster,omitempty"`
+	Approval
+}
+
+func (c *Client) CheckGatekeeper(ctx context.Context, cluster string) (*Result, error) {
+	return c.call(ctx, "check_gatekeeper", clusterArgs{cluster})
+}
+
+func (c *Client) GetOwnershipPolicyStatus(ctx context.Context, cluster string) (*Result, error) {
+	return c.call(ctx, "get_ownership_policy_status", clusterArgs{cluster})
+}
+
+func (c *Client) ListOwnershipViolations(ctx context.Context, opts OwnershipViolationsOptions) (*Result, error) {
+	return c.call(ctx, "list_ownership_violations", opts)
+}
+
+func (c *Client) InstallOwnershipPolicy(ctx context.Context, opts InstallOwnershipPolicyOptions) (*Result, error) {
+	return c.call(ctx, "install_ownership_policy", opts)
+}
+
+func (c *Client) SetOwnershipPolicyMode(ctx context.Context, opts SetOwnershipPolicyModeOptions) (*Result, error) {
+	return c.call(ctx, "set_ownership_policy_mode", opts)
+}
+
+func (c *Client) UninstallOwnershipPolicy(ctx context.Context, opts UninstallOwnershipPolicyOptions) (*Result, error) {
+	return c.call(ctx, "uninstall_ownership_policy", opts)
+}
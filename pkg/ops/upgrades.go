@@ -0,0 +1,113 @@
+package ops
+
+import "context"
+
+// Upgrades checks whether clusters are ready to upgrade and follows
+// upgrades in progress. An empty cluster is the kubeconfig's current
+// context.
+type Upgrades interface {
+	// DetectClusterType reports the distribution of a cluster, such as
+	// OpenShift, EKS, GKE, AKS, kubeadm, k3s, kind, or minikube.
+	DetectClusterType(ctx context.Context, cluster string) (*Result, error)
+	// GetClusterVersionInfo reports the Kubernetes or OpenShift version of
+	// a cluster and the upgrades available.
+	GetClusterVersionInfo(ctx context.Context, cluster string) (*Result, error)
+	// GetUpgradePrerequisites checks node health, pod issues, and, on
+	// OpenShift, ClusterOperators and MachineConfigPools before an upgrade.
+	GetUpgradePrerequisites(ctx context.Context, cluster string) (*Result, error)
+	// GetUpgradeStatus reports the progress of an upgrade.
+	GetUpgradeStatus(ctx context.Context, cluster string) (*Result, error)
+	// SimulateUpgradeImpact reports what upgrading a cluster to
+	// targetVersion would break: removed APIs in use, Deployments a node
+	// drain takes down, pods only one node can run, and operators without
+	// support for the version.
+	SimulateUpgradeImpact(ctx context.Context, cluster, targetVersion string) (*Result, error)
+	// CheckHelmReleaseUpgrades reports Helm releases with newer charts.
+	CheckHelmReleaseUpgrades(ctx context.Context, scope Scope) (*Result, error)
+	// CheckOLMOperatorUpgrades reports OLM operators with pending
+	// upgrades.
+	CheckOLMOperatorUpgrades(ctx context.Context, scope Scope) (*Result, error)
+	// TriggerOpenShiftUpgrade starts an OpenShift upgrade to
+	// targetVersion.
+	TriggerOpenShiftUpgrade(ctx context.Context, cluster, targetVersion string) (*Result, error)
+	// TriggerStagedOpenShiftUpgrade starts an OpenShift upgrade to
+	// targetVersion that upgrades the control plane first, then unpauses
+	// the worker MachineConfigPools batchSize at a time (1 when zero).
+	TriggerStagedOpenShiftUpgrade(ctx context.Context, cluster, targetVersion string, batchSize int) (*Result, error)
+	// PauseMCP pauses a MachineConfigPool.
+	PauseMCP(ctx context.Context, cluster, pool string) (*Result, error)
+	// UnpauseMCP unpauses a MachineConfigPool.
+	UnpauseMCP(ctx context.Context, cluster, pool string) (*Result, error)
+}
+
+// clusterArgs are the arguments of the tools that only take a cluster.
+type clusterArgs struct {
+	Cluster string `json:"cluster,omitempty"`
+}
+
+func (c *Client) DetectClusterType(ctx context.Context, cluster string) (*Result, error) {
+	return c.call(ctx, "detect_cluster_type", clusterArgs{cluster})
+}
+
+func (c *Client) GetClusterVersionInfo(ctx context.Context, cluster string) (*Result, error) {
+	return c.call(ctx, "get_cluster_version_info", clusterArgs{cluster})
+}
+
+func (c *Client) GetUpgradePrerequisites(ctx context.Context, cluster string) (*Result, error) {
+	return c.call(ctx, "get_upgrade_prerequisites", clusterArgs{cluster})
+}
+
+func (c *Client) GetUpgradeStatus(ctx context.Context, cluster string) (*Result, error) {
+	return c.call(ctx, "get_upgrade_status", clusterArgs{cluster})
+}
+
+func (c *Client) SimulateUpgradeImpact(ctx context.Context, cluster, targetVersion string) (*Result, error) {
+	return c.call(ctx, "simulate_upgrade_impact", struct {
+		Cluster       string `json:"cluster,omitempty"`
+		TargetVersion string `json:"target_version"`
+	}{cluster, targetVersion})
+}
+
+func (c *Client) CheckHelmReleaseUpgrades(ctx context.Context, scope Scope) (*Result, error) {
+	return c.call(ctx, "check_helm_release_upgrades", scope)
+}
+
+func (c *Client) CheckOLMOperatorUpgrades(ctx context.Context, scope Scope) (*Result, error) {
+	return c.call(ctx, "check_olm_operator_upgrades", scope)
+}
+
+// TriggerOpenShiftUpgrade passes the tool's confirmation itself: calling
+// the method is the confirmation.
+func (c *Client) TriggerOpenShiftUpgrade(ctx context.Context, cluster, targetVersion string) (*Result, error) {
+	return c.call(ctx, "trigger_openshift_upgrade", struct {
+		Cluster       string `json:"cluster,omitempty"`
+		TargetVersion string `json:"target_version"`
+		Confirm       string `json:"confirm"`
+	}{cluster, targetVersion, "yes-upgrade-now"})
+}
+
+// TriggerStagedOpenShiftUpgrade passes the tool's confirmation itself, as
+// TriggerOpenShiftUpgrade does.
+func (c *Client) TriggerStagedOpenShiftUpgrade(ctx context.Context, cluster, targetVersion string, batchSize int) (*Result, error) {
+	return c.call(ctx, "trigger_openshift_upgrade", struct {
+		Cluster       string `json:"cluster,omitempty"`
+		TargetVersion string `json:"target_version"`
+		Confirm       string `json:"confirm"`
+		Staged        bool   `json:"staged"`
+		BatchSize     int    `json:"batch_size,omitempty"`
+	}{cluster, targetVersion, "yes-upgrade-now", true, batchSize})
+}
+
+// poolArgs are the arguments of the MachineConfigPool tools.
+type poolArgs struct {
+	Cluster string `json:"cluster,omitempty"`
+	Pool    string `json:"pool"`
+}
+
+func (c *Client) PauseMCP(ctx context.Context, cluster, pool string) (*Result, error) {
+	return c.call(ctx, "pause_mcp", poolArgs{cluster, pool})
+}
+
+func (c *Client) UnpauseMCP(ctx context.Context, cluster, pool string) (*Result, error) {
+	return c.call(ctx, "unpause_mcp", poolArgs{cluster, pool})
+}
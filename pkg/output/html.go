@@ -0,0 +1,116 @@
+package output
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	bulletPattern  = regexp.MustCompile(`^\s*[-*]\s+(.*)$`)
+	boldPattern    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	codePattern    = regexp.MustCompile("`([^`]+)`")
+	// alignedPattern matches text laid out in columns with runs of spaces.
+	alignedPattern = regexp.MustCompile(`\S {2,}\S`)
+)
+
+// HTML renders a tool result as an HTML fragment. JSON results are rendered
+// as Markdown first. Markdown headings, lists, tables, bold, and code become
+// their HTML equivalents; runs of column-aligned text are kept in <pre>
+// blocks so they stay aligned. All text is escaped.
+func HTML(text string) string {
+	if v, ok := decodeJSON(text); ok {
+		text = renderValue(v, Markdown)
+	}
+	lines := strings.Split(text, "\n")
+	tables := findTables(lines)
+	var b strings.Builder
+	var list, block []string
+	flushList := func() {
+		if len(list) == 0 {
+			return
+		}
+		b.WriteString("<ul>\n")
+		for _, item := range list {
+			fmt.Fprintf(&b, "<li>%s</li>\n", inlineHTML(item))
+		}
+		b.WriteString("</ul>\n")
+		list = nil
+	}
+	flushBlock := func() {
+		if len(block) == 0 {
+			return
+		}
+		aligned := false
+		for _, line := range block {
+			aligned = aligned || alignedPattern.MatchString(strings.TrimSpace(line))
+		}
+		if aligned {
+			fmt.Fprintf(&b, "<pre>%s</pre>\n", html.EscapeString(strings.Join(block, "\n")))
+		} else {
+			escaped := make([]string, len(block))
+			for i, line := range block {
+				escaped[i] = inlineHTML(strings.TrimSpace(line))
+			}
+			fmt.Fprintf(&b, "<p>%s</p>\n", strings.Join(escaped, "<br>\n"))
+		}
+		block = nil
+	}
+	flush := func() { flushList(); flushBlock() }
+
+	for i := 0; i < len(lines); i++ {
+		if len(tables) > 0 && tables[0].start == i {
+			flush()
+			writeHTMLTable(&b, tables[0])
+			i = tables[0].end - 1
+			tables = tables[1:]
+			continue
+		}
+		line := lines[i]
+		if m := headingPattern.FindStringSubmatch(line); m != nil {
+			flush()
+			// Results sit under the report's own h1-h3.
+			level := min(len(m[1])+3, 6)
+			fmt.Fprintf(&b, "<h%d>%s</h%d>\n", level, inlineHTML(m[2]), level)
+			continue
+		}
+		if m := bulletPattern.FindStringSubmatch(line); m != nil {
+			flushBlock()
+			list = append(list, m[1])
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		flushList()
+		block = append(block, line)
+	}
+	flush()
+	return b.String()
+}
+
+func writeHTMLTable(b *strings.Builder, t markdownTable) {
+	b.WriteString("<table>\n<tr>")
+	for _, h := range t.header {
+		fmt.Fprintf(b, "<th>%s</th>", html.EscapeString(h))
+	}
+	b.WriteString("</tr>\n")
+	for _, row := range t.rows {
+		b.WriteString("<tr>")
+		for _, c := range row {
+			fmt.Fprintf(b, "<td>%s</td>", html.EscapeString(c))
+		}
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// inlineHTML escapes s and renders its Markdown bold and code spans.
+func inlineHTML(s string) string {
+	s = html.EscapeString(s)
+	s = boldPattern.ReplaceAllString(s, "<strong>$1</strong>")
+	return codePattern.ReplaceAllString(s, "<code>$1</code>")
+}
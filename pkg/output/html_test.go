@@ -0,0 +1,24 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	assert.Equal(t, `<h5>RBAC Summary</h5>
+<p><strong>Roles:</strong> 2</p>
+<table>
+<tr><th>Name</th><th>Namespace</th></tr>
+<tr><td>admin</td><td>shop</td></tr>
+<tr><td>view</td><td>default</td></tr>
+</table>
+<p>Done.</p>
+`, HTML(report))
+
+	assert.Equal(t, "<pre>NAME          STATUS\nshop/web-1    Running &lt;ok&gt;</pre>\n<ul>\n<li>uses <code>latest</code> tag</li>\n</ul>\n",
+		HTML("NAME          STATUS\nshop/web-1    Running <ok>\n- uses `latest` tag"))
+
+	assert.Contains(t, HTML(instances), "<tr><td>east</td><td>shop</td><td>true</td><td>2</td></tr>")
+}
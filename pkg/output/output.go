@@ -0,0 +1,506 @@
+// Package output renders tool results in the format a caller asks for with
+// the output argument. Both MCP servers pass their native result text
+// through Render, so every tool honors the same formats: JSON results are
+// re-rendered from their data, and text results are converted as far as
+// their structure allows.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Arg is the tool argument that selects the output format.
+const Arg = "output"
+
+// Description documents Arg in tool input schemas.
+const Description = "Result format: markdown, json, table, or brief (a terse summary for small context windows). Defaults to the tool's own format"
+
+// Format is an output format.
+type Format string
+
+const (
+	Markdown Format = "markdown"
+	JSON     Format = "json"
+	Table    Format = "table"
+	Brief    Format = "brief"
+)
+
+// Formats lists the supported formats.
+var Formats = []Format{Markdown, JSON, Table, Brief}
+
+// Names returns the supported formats as strings, for schema enums.
+func Names() []string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return names
+}
+
+// briefLines is how many lines of a text result brief output keeps.
+const briefLines = 10
+
+// briefItems is how many items of a list brief output names.
+const briefItems = 5
+
+// Parse returns the format requested in args, or "" if none was.
+func Parse(args map[string]interface{}) (Format, error) {
+	v, ok := args[Arg]
+	if !ok || v == nil {
+		return "", nil
+	}
+	s, _ := v.(string)
+	for _, f := range Formats {
+		if Format(s) == f {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("invalid %s %v: must be one of markdown, json, table, or brief", Arg, v)
+}
+
+// Render returns text in format f. An empty format returns text unchanged.
+func Render(text string, f Format) string {
+	if f == "" {
+		return text
+	}
+	if v, ok := decodeJSON(text); ok {
+		return renderValue(v, f)
+	}
+	return renderText(text, f)
+}
+
+func decodeJSON(text string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+func renderValue(v interface{}, f Format) string {
+	switch f {
+	case JSON:
+		data, _ := json.MarshalIndent(v, "", "  ")
+		return string(data)
+	case Markdown:
+		var b strings.Builder
+		writeMarkdown(&b, v, 3)
+		return strings.TrimRight(b.String(), "\n")
+	case Table:
+		var b strings.Builder
+		writeTable(&b, v)
+		return strings.TrimRight(b.String(), "\n")
+	default:
+		return briefValue(v)
+	}
+}
+
+// writeMarkdown writes objects as bullet lists, with lists of objects as
+// tables under headings of the given level.
+func writeMarkdown(b *strings.Builder, v interface{}, level int) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		for _, k := range keys {
+			if !isNested(v[k]) || level > 4 {
+				fmt.Fprintf(b, "- **%s:** %s\n", k, cell(v[k]))
+			}
+		}
+		for _, k := range keys {
+			if isNested(v[k]) && level <= 4 {
+				fmt.Fprintf(b, "\n%s %s\n\n", strings.Repeat("#", level), k)
+				writeMarkdown(b, v[k], level+1)
+			}
+		}
+	case []interface{}:
+		rows, columns := tabular(v)
+		if rows == nil {
+			for _, item := range v {
+				fmt.Fprintf(b, "- %s\n", cell(item))
+			}
+			return
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(columns, " | "))
+		fmt.Fprintf(b, "|%s\n", strings.Repeat(" --- |", len(columns)))
+		for _, row := range rows {
+			cells := make([]string, len(columns))
+			for i, c := range columns {
+				cells[i] = strings.ReplaceAll(cell(row[c]), "|", `\|`)
+			}
+			fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
+		}
+	default:
+		fmt.Fprintf(b, "%s\n", cell(v))
+	}
+}
+
+// writeTable writes lists of objects as aligned columns and objects as
+// aligned key/value pairs.
+func writeTable(b *strings.Builder, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var pairs [][]string
+		var nested []string
+		for _, k := range sortedKeys(v) {
+			if isNested(v[k]) {
+				nested = append(nested, k)
+				continue
+			}
+			pairs = append(pairs, []string{k + ":", cell(v[k])})
+		}
+		writeColumns(b, pairs)
+		for _, k := range nested {
+			fmt.Fprintf(b, "\n%s:\n", strings.ToUpper(k))
+			writeTable(b, v[k])
+		}
+	case []interface{}:
+		rows, columns := tabular(v)
+		if rows == nil {
+			for _, item := range v {
+				fmt.Fprintf(b, "%s\n", cell(item))
+			}
+			return
+		}
+		lines := [][]string{make([]string, len(columns))}
+		for i, c := range columns {
+			lines[0][i] = strings.ToUpper(c)
+		}
+		for _, row := range rows {
+			line := make([]string, len(columns))
+			for i, c := range columns {
+				line[i] = cell(row[c])
+			}
+			lines = append(lines, line)
+		}
+		writeColumns(b, lines)
+	default:
+		fmt.Fprintf(b, "%s\n", cell(v))
+	}
+}
+
+func writeColumns(b *strings.Builder, lines [][]string) {
+	var widths []int
+	for _, line := range lines {
+		for i, c := range line {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], len(c))
+		}
+	}
+	for _, line := range lines {
+		for i, c := range line {
+			if i == len(line)-1 {
+				b.WriteString(c)
+			} else {
+				fmt.Fprintf(b, "%-*s  ", widths[i], c)
+			}
+		}
+		b.WriteString("\n")
+	}
+}
+
+// briefValue summarizes v on a line or two: the scalar fields of an
+// object, and the size and first few names of each list.
+func briefValue(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		var parts []string
+		for _, k := range sortedKeys(v) {
+			switch field := v[k].(type) {
+			case []interface{}:
+				parts = append(parts, k+": "+briefList(field))
+			case map[string]interface{}:
+				if len(field) > 0 {
+					parts = append(parts, fmt.Sprintf("%s: {%d fields}", k, len(field)))
+				}
+			case nil:
+			default:
+				parts = append(parts, k+"="+cell(field))
+			}
+		}
+		return strings.Join(parts, ", ")
+	case []interface{}:
+		return briefList(v)
+	default:
+		return cell(v)
+	}
+}
+
+func briefList(items []interface{}) string {
+	var names []string
+	for _, item := range items {
+		if name := identity(item); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("%d items", len(items))
+	}
+	if len(names) > briefItems {
+		names = append(names[:briefItems], "...")
+	}
+	return fmt.Sprintf("%d items (%s)", len(items), strings.Join(names, ", "))
+}
+
+// identityKeys are the fields that name an item, most specific first.
+var identityKeys = []string{"name", "cluster", "id", "app", "kind"}
+
+func identity(item interface{}) string {
+	switch item := item.(type) {
+	case map[string]interface{}:
+		for _, k := range identityKeys {
+			if s, ok := item[k].(string); ok && s != "" {
+				return s
+			}
+		}
+		return ""
+	case []interface{}:
+		return ""
+	default:
+		return cell(item)
+	}
+}
+
+// tabular returns items as rows with their columns if every item is an
+// object, or nil rows otherwise.
+func tabular(items []interface{}) ([]map[string]interface{}, []string) {
+	rows := make([]map[string]interface{}, 0, len(items))
+	seen := make(map[string]bool)
+	for _, item := range items {
+		row, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, nil
+		}
+		rows = append(rows, row)
+		for k := range row {
+			seen[k] = true
+		}
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	columns := make([]string, 0, len(seen))
+	for k := range seen {
+		columns = append(columns, k)
+	}
+	sortColumns(columns)
+	return rows, columns
+}
+
+// sortColumns puts the identity columns first and the rest in name order.
+func sortColumns(columns []string) {
+	rank := func(c string) int {
+		for i, k := range identityKeys {
+			if c == k {
+				return i
+			}
+		}
+		return len(identityKeys)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		if ri, rj := rank(columns[i]), rank(columns[j]); ri != rj {
+			return ri < rj
+		}
+		return columns[i] < columns[j]
+	})
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sortColumns(keys)
+	return keys
+}
+
+// isNested reports whether v is worth its own section rather than a cell:
+// a non-empty object, or a list with an object in it.
+func isNested(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) > 0
+	case []interface{}:
+		for _, item := range v {
+			if _, ok := item.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cell renders v inline: scalars as themselves, lists of scalars joined
+// with commas, and anything else as compact JSON.
+func cell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprint(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			if isNested(item) {
+				return compact(v)
+			}
+			parts = append(parts, cell(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return compact(v)
+	}
+}
+
+func compact(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return strings.TrimSpace(buf.String())
+}
+
+// renderText converts a text result. Markdown tables in it are the only
+// structure text results reliably have.
+func renderText(text string, f Format) string {
+	switch f {
+	case JSON:
+		result := map[string]interface{}{"text": text}
+		if tables := markdownTables(text); len(tables) > 0 {
+			result["tables"] = tables
+		}
+		data, _ := json.MarshalIndent(result, "", "  ")
+		return string(data)
+	case Table:
+		return plainTables(text)
+	case Brief:
+		return briefText(text)
+	default:
+		return text
+	}
+}
+
+// markdownTable is a table found in a text result.
+type markdownTable struct {
+	start, end int // lines [start, end) of the text
+	header     []string
+	rows       [][]string
+}
+
+// findTables finds the pipe tables in lines: a header row, a --- separator
+// row, and the rows that follow.
+func findTables(lines []string) []markdownTable {
+	var tables []markdownTable
+	for i := 0; i+1 < len(lines); i++ {
+		if !isTableRow(lines[i]) || !isSeparatorRow(lines[i+1]) {
+			continue
+		}
+		t := markdownTable{start: i, header: splitRow(lines[i])}
+		j := i + 2
+		for ; j < len(lines) && isTableRow(lines[j]); j++ {
+			t.rows = append(t.rows, splitRow(lines[j]))
+		}
+		t.end = j
+		tables = append(tables, t)
+		i = j - 1
+	}
+	return tables
+}
+
+func isTableRow(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) > 1 && strings.HasPrefix(line, "|") && strings.HasSuffix(line, "|")
+}
+
+func isSeparatorRow(line string) bool {
+	return isTableRow(line) && strings.Trim(strings.TrimSpace(line), "|-: ") == ""
+}
+
+func splitRow(line string) []string {
+	line = strings.TrimSpace(line)
+	cells := strings.Split(line[1:len(line)-1], "|")
+	for i, c := range cells {
+		cells[i] = stripEmphasis(strings.TrimSpace(c))
+	}
+	return cells
+}
+
+func markdownTables(text string) [][]map[string]string {
+	var tables [][]map[string]string
+	for _, t := range findTables(strings.Split(text, "\n")) {
+		rows := make([]map[string]string, 0, len(t.rows))
+		for _, cells := range t.rows {
+			row := make(map[string]string, len(t.header))
+			for i, h := range t.header {
+				if i < len(cells) {
+					row[h] = cells[i]
+				}
+			}
+			rows = append(rows, row)
+		}
+		tables = append(tables, rows)
+	}
+	return tables
+}
+
+// plainTables replaces the markdown tables in text with aligned columns and
+// drops markdown emphasis.
+func plainTables(text string) string {
+	lines := strings.Split(text, "\n")
+	var b strings.Builder
+	next := 0
+	for _, t := range findTables(lines) {
+		for _, line := range lines[next:t.start] {
+			b.WriteString(stripEmphasis(line) + "\n")
+		}
+		header := make([]string, len(t.header))
+		for i, h := range t.header {
+			header[i] = strings.ToUpper(h)
+		}
+		writeColumns(&b, append([][]string{header}, t.rows...))
+		next = t.end
+	}
+	for _, line := range lines[next:] {
+		b.WriteString(stripEmphasis(line) + "\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// briefText keeps the first non-blank lines of text.
+func briefText(text string) string {
+	var kept []string
+	total := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(stripEmphasis(line))
+		if line == "" || isSeparatorRow(line) {
+			continue
+		}
+		total++
+		if len(kept) < briefLines {
+			kept = append(kept, line)
+		}
+	}
+	if total > len(kept) {
+		kept = append(kept, fmt.Sprintf("... (%d more lines)", total-len(kept)))
+	}
+	return strings.Join(kept, "\n")
+}
+
+func stripEmphasis(s string) string {
+	return strings.NewReplacer("**", "", "__", "", "`", "").Replace(s)
+}
@@ -0,0 +1,88 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const instances = `{
+  "app": "web",
+  "count": 2,
+  "instances": [
+    {"cluster": "east", "namespace": "shop", "replicas": 2, "ready": true},
+    {"cluster": "west", "namespace": "shop", "replicas": 3, "ready": false}
+  ],
+  "labels": ["tier=frontend", "team=shop"]
+}`
+
+func TestParse(t *testing.T) {
+	f, err := Parse(map[string]interface{}{"output": "brief"})
+	require.NoError(t, err)
+	assert.Equal(t, Brief, f)
+
+	f, err = Parse(map[string]interface{}{"namespace": "shop"})
+	require.NoError(t, err)
+	assert.Empty(t, f)
+
+	for _, bad := range []interface{}{"yaml", 3, ""} {
+		_, err = Parse(map[string]interface{}{"output": bad})
+		assert.Error(t, err, "%v", bad)
+	}
+}
+
+func TestRenderJSONResults(t *testing.T) {
+	assert.Equal(t, instances, Render(instances, ""))
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(Render(instances, JSON)), &decoded))
+	assert.Equal(t, "web", decoded["app"])
+
+	assert.Equal(t, "app=web, count=2, instances: 2 items (east, west), labels: 2 items (tier=frontend, team=shop)", Render(instances, Brief))
+
+	assert.Equal(t, `- **app:** web
+- **count:** 2
+- **labels:** tier=frontend, team=shop
+
+### instances
+
+| cluster | namespace | ready | replicas |
+| --- | --- | --- | --- |
+| east | shop | true | 2 |
+| west | shop | false | 3 |`, Render(instances, Markdown))
+
+	assert.Equal(t, `app:     web
+count:   2
+labels:  tier=frontend, team=shop
+
+INSTANCES:
+CLUSTER  NAMESPACE  READY  REPLICAS
+east     shop       true   2
+west     shop       false  3`, Render(instances, Table))
+}
+
+const report = "## RBAC Summary\n\n**Roles:** 2\n\n| Name | Namespace |\n|------|-----------|\n| `admin` | shop |\n| view | default |\n\nDone."
+
+func TestRenderTextResults(t *testing.T) {
+	assert.Equal(t, report, Render(report, Markdown))
+
+	var decoded struct {
+		Text   string                `json:"text"`
+		Tables [][]map[string]string `json:"tables"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(Render(report, JSON)), &decoded))
+	assert.Equal(t, report, decoded.Text)
+	assert.Equal(t, [][]map[string]string{{{"Name": "admin", "Namespace": "shop"}, {"Name": "view", "Namespace": "default"}}}, decoded.Tables)
+
+	assert.Equal(t, "## RBAC Summary\n\nRoles: 2\n\nNAME   NAMESPACE\nadmin  shop\nview   default\n\nDone.", Render(report, Table))
+
+	long := "Found 12 pods:\n"
+	for i := 0; i < 12; i++ {
+		long += "\npod"
+	}
+	brief := Render(long, Brief)
+	assert.Contains(t, brief, "Found 12 pods:\npod")
+	assert.Contains(t, brief, "(3 more lines)")
+}
@@ -0,0 +1,188 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Section is a part of a tool result that can be shown on its own: what a
+// heading covers, a label with the list under it, or a field of a JSON
+// result.
+type Section struct {
+	Title string
+	Text  string
+	// Items are the section's list items and table rows, or the elements
+	// of a JSON list.
+	Items []string
+}
+
+var (
+	itemPattern           = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+	tableSeparatorPattern = regexp.MustCompile(`^\|?[\s:|-]+\|?$`)
+)
+
+// Sections splits a tool result into its preamble and the sections after
+// it. It returns no sections when the result has fewer than two, since
+// such a result cannot be summarized by section.
+func Sections(text string) (string, []Section) {
+	var preamble string
+	var sections []Section
+	if v, ok := decodeJSON(text); ok {
+		preamble, sections = jsonSections(v)
+	} else if level := sectionHeadingLevel(text); level > 0 {
+		preamble, sections = headingSections(text, level)
+	} else {
+		preamble, sections = labelSections(text)
+	}
+	if len(sections) < 2 {
+		return text, nil
+	}
+	return preamble, sections
+}
+
+// sectionHeadingLevel returns the shallowest heading level used at least
+// twice, or 0 if there is none.
+func sectionHeadingLevel(text string) int {
+	counts := make(map[int]int)
+	for _, line := range strings.Split(text, "\n") {
+		if m := headingPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			counts[len(m[1])]++
+		}
+	}
+	for level := 1; level <= 6; level++ {
+		if counts[level] >= 2 {
+			return level
+		}
+	}
+	return 0
+}
+
+func headingSections(text string, level int) (string, []Section) {
+	var preamble []string
+	var sections []Section
+	var current *Section
+	var body []string
+	flush := func() {
+		if current != nil {
+			current.Text = strings.TrimRight(strings.Join(body, "\n"), "\n")
+			current.Items = items(body)
+			sections = append(sections, *current)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if m := headingPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil && len(m[1]) == level {
+			flush()
+			current, body = &Section{Title: m[2]}, []string{line}
+			continue
+		}
+		if current == nil {
+			preamble = append(preamble, line)
+		} else {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return strings.TrimRight(strings.Join(preamble, "\n"), "\n"), sections
+}
+
+// labelSections splits text at the unindented lines that have a list
+// under them, such as a pod followed by its issues.
+func labelSections(text string) (string, []Section) {
+	lines := strings.Split(text, "\n")
+	isItem := func(line string) bool {
+		return itemPattern.MatchString(line) || (line != strings.TrimLeft(line, " \t") && strings.TrimSpace(line) != "")
+	}
+	var preamble []string
+	var sections []Section
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		label := strings.TrimSpace(line) != "" && !isItem(line)
+		if label && i+1 < len(lines) && isItem(lines[i+1]) {
+			body := []string{line}
+			for i+1 < len(lines) && (isItem(lines[i+1]) || strings.TrimSpace(lines[i+1]) == "") {
+				i++
+				body = append(body, lines[i])
+			}
+			sections = append(sections, Section{
+				Title: strings.TrimSpace(line),
+				Text:  strings.TrimRight(strings.Join(body, "\n"), "\n"),
+				Items: items(body[1:]),
+			})
+			continue
+		}
+		if len(sections) == 0 {
+			preamble = append(preamble, line)
+		} else if strings.TrimSpace(line) != "" {
+			// A line after the sections, such as a footer, stays with
+			// the last one.
+			last := &sections[len(sections)-1]
+			last.Text += "\n" + line
+		}
+	}
+	return strings.TrimRight(strings.Join(preamble, "\n"), "\n"), sections
+}
+
+// items returns the list items and table rows among lines.
+func items(lines []string) []string {
+	var found []string
+	header := true
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "|"):
+			if !header && !tableSeparatorPattern.MatchString(trimmed) {
+				found = append(found, trimmed)
+			}
+			header = false
+		case itemPattern.MatchString(line):
+			found = append(found, itemPattern.FindStringSubmatch(line)[1])
+			header = true
+		default:
+			header = true
+		}
+	}
+	return found
+}
+
+// jsonSections makes a section of each nested field of an object, or of
+// each element of a list.
+func jsonSections(v interface{}) (string, []Section) {
+	indent := func(v interface{}) string {
+		data, _ := json.MarshalIndent(v, "", "  ")
+		return string(data)
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		scalars := make(map[string]interface{})
+		var sections []Section
+		for _, k := range sortedKeys(v) {
+			if !isNested(v[k]) {
+				scalars[k] = v[k]
+				continue
+			}
+			s := Section{Title: k, Text: indent(v[k])}
+			if list, ok := v[k].([]interface{}); ok {
+				for _, item := range list {
+					s.Items = append(s.Items, compact(item))
+				}
+			} else {
+				s.Items = []string{compact(v[k])}
+			}
+			sections = append(sections, s)
+		}
+		return briefValue(scalars), sections
+	case []interface{}:
+		sections := make([]Section, 0, len(v))
+		for i, item := range v {
+			title := identity(item)
+			if title == "" {
+				title = fmt.Sprintf("[%d]", i)
+			}
+			sections = append(sections, Section{Title: title, Text: indent(item), Items: []string{compact(item)}})
+		}
+		return fmt.Sprintf("%d items", len(v)), sections
+	}
+	return "", nil
+}
@@ -0,0 +1,75 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSections_Headings(t *testing.T) {
+	text := `# Pod Issues
+
+Scanned 2 clusters.
+
+## prod-east
+
+- shop/web: CrashLoopBackOff
+- shop/api: OOMKilled
+
+## prod-west
+
+| Pod | Issue |
+|-----|-------|
+| shop/db | Pending |
+`
+	preamble, sections := Sections(text)
+	assert.Equal(t, "# Pod Issues\n\nScanned 2 clusters.", preamble)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "prod-east", sections[0].Title)
+	assert.Equal(t, []string{"shop/web: CrashLoopBackOff", "shop/api: OOMKilled"}, sections[0].Items)
+	assert.Equal(t, "## prod-east\n\n- shop/web: CrashLoopBackOff\n- shop/api: OOMKilled", sections[0].Text)
+	assert.Equal(t, "prod-west", sections[1].Title)
+	assert.Equal(t, []string{"| shop/db | Pending |"}, sections[1].Items)
+}
+
+func TestSections_Labels(t *testing.T) {
+	text := `Found 2 pods with security concerns:
+🔴 Critical | 🟠 High | 🟡 Medium
+
+🔓 shop/web
+   - 🔴 Container app is privileged
+   - 🟡 Container app has writable root filesystem
+
+🔓 shop/worker
+   - 🟡 Container app has writable root filesystem
+
+💡 Set securityContext on every container.`
+	preamble, sections := Sections(text)
+	assert.Equal(t, "Found 2 pods with security concerns:\n🔴 Critical | 🟠 High | 🟡 Medium", preamble)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "🔓 shop/web", sections[0].Title)
+	assert.Len(t, sections[0].Items, 2)
+	assert.Equal(t, "🔓 shop/worker", sections[1].Title)
+	assert.Contains(t, sections[1].Text, "💡 Set securityContext", "a footer stays with the last section")
+}
+
+func TestSections_JSON(t *testing.T) {
+	preamble, sections := Sections(`{"total": 3, "clusters": [{"name": "a"}, {"name": "b"}], "summary": {"drifted": 1}}`)
+	assert.Equal(t, "total=3", preamble)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "clusters", sections[0].Title)
+	assert.Equal(t, []string{`{"name":"a"}`, `{"name":"b"}`}, sections[0].Items)
+	assert.Equal(t, "summary", sections[1].Title)
+
+	_, sections = Sections(`[{"cluster": "a", "ok": true}, {"cluster": "b", "ok": false}]`)
+	require.Len(t, sections, 2)
+	assert.Equal(t, "b", sections[1].Title)
+}
+
+func TestSections_Unstructured(t *testing.T) {
+	text := "✅ No obvious security issues found"
+	preamble, sections := Sections(text)
+	assert.Equal(t, text, preamble)
+	assert.Nil(t, sections)
+}
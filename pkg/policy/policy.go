@@ -0,0 +1,250 @@
+// Package policy authorizes tool calls with administrator-supplied Rego
+// policies. Each call is described as an Input (tool, arguments, target
+// clusters and namespaces, and the caller's identity) and evaluated against
+// data.kubestellar.authz, which may deny the call or require approval.
+// Policies are evaluated with the opa CLI, in the same way the deploy tools
+// shell out to helm and kustomize.
+//
+// A policy looks like:
+//
+//	package kubestellar.authz
+//
+//	deny contains msg if {
+//	    input.mutating
+//	    "prod" in input.clusters
+//	    not "sre" in input.identity.groups
+//	    msg := "only SREs may change prod"
+//	}
+//
+//	require_approval contains "deletes need review" if input.tool == "delete_resource"
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Environment variables that configure the policy engine.
+const (
+	// EnvPolicyPath is a .rego file or a directory of them. Unset disables
+	// policy evaluation.
+	EnvPolicyPath = "KUBESTELLAR_POLICY"
+	// EnvOPABinary overrides the opa executable; the default is opa on PATH.
+	EnvOPABinary = "KUBESTELLAR_OPA_BINARY"
+)
+
+// Query is the document policies define. Its deny and require_approval
+// rules are sets of reasons; an undefined document allows every call.
+const Query = "data.kubestellar.authz"
+
+// Identity is the user a call is made for.
+type Identity struct {
+	User   string   `json:"user,omitempty"`
+	Groups []string `json:"groups"`
+}
+
+// Input is the document a policy sees as input.
+type Input struct {
+	// Server is the MCP server handling the call: kubestellar-ops or
+	// kubestellar-deploy.
+	Server   string                 `json:"server"`
+	Tool     string                 `json:"tool"`
+	Args     map[string]interface{} `json:"args"`
+	Mutating bool                   `json:"mutating"`
+	// Clusters and Namespaces are the targets named by the cluster(s) and
+	// namespace(s) arguments. Empty means the tool's default (usually all).
+	Clusters   []string `json:"clusters"`
+	Namespaces []string `json:"namespaces"`
+	Identity   Identity `json:"identity"`
+}
+
+// NewInput describes a call to tool with args, reading the targets from
+// the arguments the tools share.
+func NewInput(server, tool string, args map[string]interface{}, mutating bool, id Identity) Input {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	if id.Groups == nil {
+		id.Groups = []string{}
+	}
+	return Input{
+		Server:     server,
+		Tool:       tool,
+		Args:       args,
+		Mutating:   mutating,
+		Clusters:   stringArgs(args, "cluster", "clusters"),
+		Namespaces: stringArgs(args, "namespace", "namespaces"),
+		Identity:   id,
+	}
+}
+
+// stringArgs collects the distinct string values of the named arguments,
+// which may be strings or arrays of strings.
+func stringArgs(args map[string]interface{}, names ...string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+	add := func(v interface{}) {
+		if s, ok := v.(string); ok && s != "" && !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	for _, name := range names {
+		switch v := args[name].(type) {
+		case []interface{}:
+			for _, item := range v {
+				add(item)
+			}
+		case []string:
+			for _, item := range v {
+				add(item)
+			}
+		default:
+			add(v)
+		}
+	}
+	return out
+}
+
+// Decision is the outcome of evaluating a call.
+type Decision struct {
+	Deny            []string `json:"deny,omitempty"`
+	RequireApproval []string `json:"require_approval,omitempty"`
+}
+
+// Denied reports whether any deny rule matched.
+func (d Decision) Denied() bool { return len(d.Deny) > 0 }
+
+// Err returns the denial as an error, or nil if the call is allowed.
+func (d Decision) Err() error {
+	if !d.Denied() {
+		return nil
+	}
+	return fmt.Errorf("denied by policy: %s", strings.Join(d.Deny, "; "))
+}
+
+// Engine evaluates calls against the policies at a path. A nil *Engine
+// allows every call.
+type Engine struct {
+	path   string
+	binary string
+	// run evaluates Query for input and returns opa's JSON output. Tests
+	// replace it.
+	run func(ctx context.Context, input []byte) ([]byte, error)
+}
+
+// New creates an engine for the policies at path, evaluated with the opa
+// executable binary.
+func New(path, binary string) *Engine {
+	if binary == "" {
+		binary = "opa"
+	}
+	e := &Engine{path: path, binary: binary}
+	e.run = e.opaEval
+	return e
+}
+
+// FromEnv creates the engine configured by $KUBESTELLAR_POLICY, or returns
+// nil if no policy is configured.
+func FromEnv() *Engine {
+	path := strings.TrimSpace(os.Getenv(EnvPolicyPath))
+	if path == "" {
+		return nil
+	}
+	return New(path, os.Getenv(EnvOPABinary))
+}
+
+// Enabled reports whether calls are evaluated at all.
+func (e *Engine) Enabled() bool { return e != nil }
+
+// Evaluate decides whether the call described by in may run. Errors mean
+// the policy could not be evaluated; callers fail closed and deny the call.
+func (e *Engine) Evaluate(ctx context.Context, in Input) (Decision, error) {
+	if e == nil {
+		return Decision{}, nil
+	}
+	data, err := json.Marshal(in)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	out, err := e.run(ctx, data)
+	if err != nil {
+		return Decision{}, err
+	}
+	return parseResult(out)
+}
+
+func (e *Engine) opaEval(ctx context.Context, input []byte) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, e.binary, "eval", "--format", "json", "--stdin-input", "--data", e.path, Query)
+	cmd.Stdin = bytes.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		if msg == "" {
+			return nil, fmt.Errorf("policy evaluation failed: %w", err)
+		}
+		return nil, fmt.Errorf("policy evaluation failed: %w: %s", err, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// parseResult reads the decision from opa eval's JSON output.
+func parseResult(out []byte) (Decision, error) {
+	var result struct {
+		Result []struct {
+			Expressions []struct {
+				Value json.RawMessage `json:"value"`
+			} `json:"expressions"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return Decision{}, fmt.Errorf("invalid policy output: %w", err)
+	}
+	if len(result.Result) == 0 || len(result.Result[0].Expressions) == 0 {
+		// The policy does not define data.kubestellar.authz.
+		return Decision{}, nil
+	}
+	var doc struct {
+		Deny            interface{} `json:"deny"`
+		RequireApproval interface{} `json:"require_approval"`
+	}
+	if err := json.Unmarshal(result.Result[0].Expressions[0].Value, &doc); err != nil {
+		return Decision{}, fmt.Errorf("%s must be an object: %w", Query, err)
+	}
+	return Decision{Deny: reasons(doc.Deny), RequireApproval: reasons(doc.RequireApproval)}, nil
+}
+
+// reasons normalizes a rule's value: a set or array of messages, a single
+// message, or a boolean (true means the rule matched without a message).
+func reasons(v interface{}) []string {
+	var out []string
+	switch v := v.(type) {
+	case bool:
+		if v {
+			out = append(out, "policy rule matched")
+		}
+	case string:
+		out = append(out, v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			} else {
+				out = append(out, fmt.Sprint(item))
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewInputCollectsTargets(t *testing.T) {
+	args := map[string]interface{}{
+		"cluster":    "prod",
+		"clusters":   []interface{}{"prod", "staging"},
+		"namespaces": []interface{}{"web", 7},
+	}
+	in := NewInput("kubestellar-deploy", "scale_app", args, true, Identity{User: "alice"})
+	if !reflect.DeepEqual(in.Clusters, []string{"prod", "staging"}) {
+		t.Errorf("Clusters = %v", in.Clusters)
+	}
+	if !reflect.DeepEqual(in.Namespaces, []string{"web"}) {
+		t.Errorf("Namespaces = %v", in.Namespaces)
+	}
+
+	// Empty targets and groups encode as arrays so policies can iterate them.
+	data, _ := json.Marshal(NewInput("kubestellar-ops", "get_pods", nil, false, Identity{}))
+	var doc map[string]interface{}
+	_ = json.Unmarshal(data, &doc)
+	if _, ok := doc["clusters"].([]interface{}); !ok {
+		t.Errorf("clusters should be an array, got %s", data)
+	}
+	if _, ok := doc["identity"].(map[string]interface{})["groups"].([]interface{}); !ok {
+		t.Errorf("identity.groups should be an array, got %s", data)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   Decision
+	}{
+		{"undefined", `{}`, Decision{}},
+		{"allowed", `{"result":[{"expressions":[{"value":{"deny":[],"require_approval":[]}}]}]}`, Decision{}},
+		{"denied", `{"result":[{"expressions":[{"value":{"deny":["only SREs may change prod","no deletes"]}}]}]}`,
+			Decision{Deny: []string{"no deletes", "only SREs may change prod"}}},
+		{"boolean rule", `{"result":[{"expressions":[{"value":{"require_approval":true}}]}]}`,
+			Decision{RequireApproval: []string{"policy rule matched"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := New("policy.rego", "")
+			var input Input
+			e.run = func(_ context.Context, data []byte) ([]byte, error) {
+				_ = json.Unmarshal(data, &input)
+				return []byte(tt.output), nil
+			}
+			got, err := e.Evaluate(context.Background(), NewInput("kubestellar-ops", "delete_resource", nil, true, Identity{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate = %+v, want %+v", got, tt.want)
+			}
+			if input.Tool != "delete_resource" {
+				t.Errorf("policy input tool = %q", input.Tool)
+			}
+		})
+	}
+}
+
+func TestEvaluateFailsWithoutOPA(t *testing.T) {
+	e := New("policy.rego", filepath.Join(t.TempDir(), "opa"))
+	if _, err := e.Evaluate(context.Background(), Input{Tool: "get_pods"}); err == nil {
+		t.Fatal("a missing opa binary must be an error so callers fail closed")
+	}
+}
+
+func TestDecisionErr(t *testing.T) {
+	if (Decision{RequireApproval: []string{"review"}}).Err() != nil {
+		t.Error("require_approval alone must not deny")
+	}
+	if err := (Decision{Deny: []string{"a", "b"}}).Err(); err == nil || err.Error() != "denied by policy: a; b" {
+		t.Errorf("Err = %v", err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(EnvPolicyPath, "")
+	if FromEnv().Enabled() {
+		t.Error("no policy should disable the engine")
+	}
+	dir := t.TempDir()
+	t.Setenv(EnvPolicyPath, dir)
+	t.Setenv(EnvOPABinary, "/usr/local/bin/opa")
+	e := FromEnv()
+	if !e.Enabled() || e.path != dir || e.binary != "/usr/local/bin/opa" {
+		t.Errorf("FromEnv = %+v", e)
+	}
+}
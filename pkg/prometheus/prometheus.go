@@ -0,0 +1,115 @@
+// Package prometheus queries the Prometheus HTTP API for the metrics of a
+// cluster. Each cluster's endpoint comes from $KUBESTELLAR_PROMETHEUS; see
+// config.PrometheusURL.
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxResponseSize bounds the query results read from Prometheus.
+const maxResponseSize = 32 << 20
+
+// httpClient queries Prometheus.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Point is one sample of a series.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is the samples of one label set.
+type Series struct {
+	Labels map[string]string
+	Points []Point
+}
+
+// Client queries one Prometheus server.
+type Client struct {
+	url string
+}
+
+// New returns a client for the Prometheus server at endpoint, an http(s)
+// URL.
+func New(endpoint string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Prometheus URL %q: must be http or https", endpoint)
+	}
+	return &Client{url: strings.TrimSuffix(endpoint, "/")}, nil
+}
+
+// response is the envelope of every Prometheus API response.
+type response struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]interface{}  `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryRange evaluates query from start to end at every step and returns
+// the resulting series.
+func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) ([]Series, error) {
+	params := url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/api/v1/query_range", strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Prometheus request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+
+	var r response
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %s returned %s", c.url, resp.Status)
+	}
+	if r.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s: %s", r.ErrorType, r.Error)
+	}
+	if r.Data.ResultType != "matrix" {
+		return nil, fmt.Errorf("prometheus query returned a %s, not a matrix", r.Data.ResultType)
+	}
+
+	series := make([]Series, 0, len(r.Data.Result))
+	for _, result := range r.Data.Result {
+		s := Series{Labels: result.Metric, Points: make([]Point, 0, len(result.Values))}
+		for _, v := range result.Values {
+			ts, ok := v[0].(float64)
+			text, _ := v[1].(string)
+			value, err := strconv.ParseFloat(text, 64)
+			if !ok || err != nil {
+				return nil, fmt.Errorf("prometheus query returned an invalid sample %v", v)
+			}
+			s.Points = append(s.Points, Point{Time: time.Unix(0, int64(ts*float64(time.Second))), Value: value})
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
@@ -0,0 +1,57 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/prom/api/v1/query_range", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "up", r.Form.Get("query"))
+		assert.Equal(t, "1000", r.Form.Get("start"))
+		assert.Equal(t, "1600", r.Form.Get("end"))
+		assert.Equal(t, "300", r.Form.Get("step"))
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[
+			{"metric":{"pod":"web-1"},"values":[[1000,"1"],[1300,"0.5"]]}]}}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(srv.URL + "/prom/")
+	require.NoError(t, err)
+	series, err := c.QueryRange(context.Background(), "up", time.Unix(1000, 0), time.Unix(1600, 0), 5*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, series, 1)
+	assert.Equal(t, map[string]string{"pod": "web-1"}, series[0].Labels)
+	assert.Equal(t, []Point{{Time: time.Unix(1000, 0), Value: 1}, {Time: time.Unix(1300, 0), Value: 0.5}}, series[0].Points)
+}
+
+func TestQueryRangeErrors(t *testing.T) {
+	for name, body := range map[string]string{
+		"error":  `{"status":"error","errorType":"bad_data","error":"parse error"}`,
+		"vector": `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+		"sample": `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[1000,"NaN?"]]}]}}`,
+		"html":   `<html>bad gateway</html>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(body))
+			}))
+			defer srv.Close()
+			c, err := New(srv.URL)
+			require.NoError(t, err)
+			_, err = c.QueryRange(context.Background(), "up", time.Unix(0, 0), time.Unix(60, 0), time.Minute)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := New("prometheus:9090")
+	assert.Error(t, err)
+}
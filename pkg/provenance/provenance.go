@@ -0,0 +1,258 @@
+// Package provenance signs tool results so downstream automation can check
+// that a result (e.g. "the fleet is healthy") came from this server, at a
+// given time, from the cluster state it names. The servers attach a Block
+// to each result's _meta.provenance when a key is configured.
+//
+// A Block lists the API servers and resourceVersions the tool read, which
+// the client transport collects (see Wrap), and the SHA-256 of the result's
+// text. The signature covers the JSON encoding of the Block without its
+// signature field; Verify checks it.
+package provenance
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvProvenanceKey names the key file. A PEM-encoded Ed25519 private key
+// produces Ed25519 signatures that anyone with the public key can verify;
+// any other content is used as an HMAC-SHA256 secret shared with the
+// verifiers. Unset disables provenance.
+const EnvProvenanceKey = "KUBESTELLAR_PROVENANCE_KEY"
+
+// Signature algorithms.
+const (
+	AlgHMACSHA256 = "hmac-sha256"
+	AlgEd25519    = "ed25519"
+)
+
+// maxObservations bounds the resourceVersions listed in a Block.
+const maxObservations = 100
+
+// Observation is one object or list the tool read.
+type Observation struct {
+	// Server is the API server URL (scheme and host).
+	Server          string `json:"server"`
+	Path            string `json:"path"`
+	ResourceVersion string `json:"resourceVersion"`
+}
+
+// Collector gathers the reads made during one tool call. It is safe for
+// concurrent use.
+type Collector struct {
+	mu           sync.Mutex
+	observations []Observation
+	dropped      int
+}
+
+// NewCollector creates an empty collector.
+func NewCollector() *Collector { return &Collector{} }
+
+func (c *Collector) observe(o Observation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.observations) >= maxObservations {
+		c.dropped++
+		return
+	}
+	c.observations = append(c.observations, o)
+}
+
+type collectorKey struct{}
+
+// WithCollector returns a context whose reads are recorded by c.
+func WithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorKey{}, c)
+}
+
+func collectorFrom(ctx context.Context) *Collector {
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Block is the provenance of one tool result.
+type Block struct {
+	Server    string    `json:"server"`
+	Version   string    `json:"version"`
+	Tool      string    `json:"tool"`
+	Timestamp time.Time `json:"timestamp"`
+	// Clusters are the API servers the tool read from.
+	Clusters         []string      `json:"clusters"`
+	ResourceVersions []Observation `json:"resourceVersions"`
+	// Truncated counts reads left out of ResourceVersions.
+	Truncated int `json:"truncated,omitempty"`
+	// Cached reports that the result was served from the result cache, so
+	// the reads happened when it was first computed.
+	Cached bool `json:"cached,omitempty"`
+	// ContentSHA256 is the hex SHA-256 of the result's text blocks joined
+	// with newlines.
+	ContentSHA256 string `json:"contentSha256"`
+	Algorithm     string `json:"algorithm"`
+	KeyID         string `json:"keyId"`
+	Signature     string `json:"signature,omitempty"`
+}
+
+// ContentDigest returns the value of Block.ContentSHA256 for a result's
+// text blocks.
+func ContentDigest(texts []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(texts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Signer signs Blocks with one key.
+type Signer struct {
+	alg     string
+	keyID   string
+	secret  []byte
+	private ed25519.PrivateKey
+	now     func() time.Time
+}
+
+// NewHMACSigner signs with HMAC-SHA256 using secret.
+func NewHMACSigner(secret []byte) *Signer {
+	return &Signer{alg: AlgHMACSHA256, keyID: keyID(secret), secret: secret, now: time.Now}
+}
+
+// NewEd25519Signer signs with an Ed25519 private key.
+func NewEd25519Signer(key ed25519.PrivateKey) *Signer {
+	return &Signer{alg: AlgEd25519, keyID: keyID(key.Public().(ed25519.PublicKey)), private: key, now: time.Now}
+}
+
+// SignerFromEnv loads the key named by $KUBESTELLAR_PROVENANCE_KEY. It
+// returns nil if no key is configured.
+func SignerFromEnv() (*Signer, error) {
+	path := strings.TrimSpace(os.Getenv(EnvProvenanceKey))
+	if path == "" {
+		return nil, nil
+	}
+	return LoadSigner(path)
+}
+
+// LoadSigner reads a key file; see EnvProvenanceKey for the formats.
+func LoadSigner(path string) (*Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provenance key: %w", err)
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid provenance key: %w", err)
+		}
+		private, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("provenance key must be Ed25519, got %T", key)
+		}
+		return NewEd25519Signer(private), nil
+	}
+	secret := bytes.TrimSpace(data)
+	if len(secret) < 32 {
+		return nil, errors.New("provenance HMAC secret must be at least 32 bytes")
+	}
+	return NewHMACSigner(secret), nil
+}
+
+// Sign returns the signed provenance of a result with the given text
+// blocks, using the reads recorded by c (which may be nil).
+func (s *Signer) Sign(server, version, tool string, texts []string, c *Collector, cached bool) *Block {
+	b := &Block{
+		Server:           server,
+		Version:          version,
+		Tool:             tool,
+		Timestamp:        s.now().UTC(),
+		Clusters:         []string{},
+		ResourceVersions: []Observation{},
+		Cached:           cached,
+		ContentSHA256:    ContentDigest(texts),
+		Algorithm:        s.alg,
+		KeyID:            s.keyID,
+	}
+	if c != nil {
+		c.mu.Lock()
+		b.ResourceVersions = append(b.ResourceVersions, c.observations...)
+		b.Truncated = c.dropped
+		c.mu.Unlock()
+	}
+	seen := map[string]bool{}
+	for _, o := range b.ResourceVersions {
+		if !seen[o.Server] {
+			seen[o.Server] = true
+			b.Clusters = append(b.Clusters, o.Server)
+		}
+	}
+	sort.Strings(b.Clusters)
+
+	payload := b.payload()
+	switch s.alg {
+	case AlgEd25519:
+		b.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(s.private, payload))
+	default:
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write(payload)
+		b.Signature = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+	return b
+}
+
+// Verify checks that b was signed by key, a []byte HMAC secret or an
+// ed25519.PublicKey, and that it describes the given text blocks.
+func Verify(b *Block, texts []string, key interface{}) error {
+	if b.ContentSHA256 != ContentDigest(texts) {
+		return errors.New("result content does not match its provenance")
+	}
+	sig, err := base64.StdEncoding.DecodeString(b.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+	payload := b.payload()
+	switch key := key.(type) {
+	case []byte:
+		if b.Algorithm != AlgHMACSHA256 {
+			return fmt.Errorf("block is signed with %s, not %s", b.Algorithm, AlgHMACSHA256)
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write(payload)
+		if !hmac.Equal(sig, mac.Sum(nil)) {
+			return errors.New("invalid provenance signature")
+		}
+	case ed25519.PublicKey:
+		if b.Algorithm != AlgEd25519 {
+			return fmt.Errorf("block is signed with %s, not %s", b.Algorithm, AlgEd25519)
+		}
+		if !ed25519.Verify(key, payload, sig) {
+			return errors.New("invalid provenance signature")
+		}
+	default:
+		return fmt.Errorf("unsupported verification key %T", key)
+	}
+	return nil
+}
+
+// payload is the signed encoding: the Block without its signature.
+func (b *Block) payload() []byte {
+	unsigned := *b
+	unsigned.Signature = ""
+	data, _ := json.Marshal(unsigned)
+	return data
+}
+
+// keyID identifies a key without revealing it.
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
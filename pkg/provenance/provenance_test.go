@@ -0,0 +1,146 @@
+package provenance
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestHMACSignAndVerify(t *testing.T) {
+	s := NewHMACSigner(secret)
+	s.now = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+	c := NewCollector()
+	c.observe(Observation{Server: "https://b", Path: "/api/v1/pods", ResourceVersion: "7"})
+	c.observe(Observation{Server: "https://a", Path: "/api/v1/nodes", ResourceVersion: "3"})
+	texts := []string{"All clusters healthy"}
+
+	b := s.Sign("kubestellar-ops", "0.8.0", "get_cluster_health", texts, c, false)
+	if err := Verify(b, texts, secret); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if strings.Join(b.Clusters, ",") != "https://a,https://b" || len(b.ResourceVersions) != 2 {
+		t.Errorf("unexpected block %+v", b)
+	}
+
+	if err := Verify(b, []string{"All clusters healthy!"}, secret); err == nil {
+		t.Error("changed content must not verify")
+	}
+	forged := *b
+	forged.Timestamp = forged.Timestamp.Add(time.Hour)
+	if err := Verify(&forged, texts, secret); err == nil {
+		t.Error("changed block must not verify")
+	}
+	if err := Verify(b, texts, []byte("another secret, also 32 bytes long")); err == nil {
+		t.Error("a different key must not verify")
+	}
+}
+
+func TestEd25519KeyFile(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvProvenanceKey, path)
+	s, err := SignerFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := s.Sign("kubestellar-deploy", "0.8.0", "get_app_status", []string{"ok"}, nil, true)
+	if b.Algorithm != AlgEd25519 || !b.Cached {
+		t.Errorf("unexpected block %+v", b)
+	}
+	if err := Verify(b, []string{"ok"}, pub); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := Verify(b, []string{"ok"}, secret); err == nil {
+		t.Error("an HMAC key must not verify an Ed25519 block")
+	}
+}
+
+func TestLoadSignerRejectsShortSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte("short\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSigner(path); err == nil {
+		t.Error("short secrets must be rejected")
+	}
+	t.Setenv(EnvProvenanceKey, "")
+	if s, err := SignerFromEnv(); s != nil || err != nil {
+		t.Errorf("SignerFromEnv = %v, %v; want nothing configured", s, err)
+	}
+}
+
+func TestWrapRecordsResourceVersions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var obj runtime.Object
+		if strings.HasSuffix(r.URL.Path, "/pods") {
+			obj = &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "42"}}
+		} else {
+			obj = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1", ResourceVersion: "7"}}
+		}
+		// Typed clients ask for protobuf; answer in kind.
+		info, _ := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), runtime.ContentTypeProtobuf)
+		w.Header().Set("Content-Type", runtime.ContentTypeProtobuf)
+		_ = scheme.Codecs.EncoderForVersion(info.Serializer, corev1.SchemeGroupVersion).Encode(obj, w)
+	}))
+	defer srv.Close()
+
+	client := kubernetes.NewForConfigOrDie(Wrap(&rest.Config{Host: srv.URL, ContentConfig: rest.ContentConfig{ContentType: runtime.ContentTypeProtobuf}}))
+	c := NewCollector()
+	ctx := WithCollector(context.Background(), c)
+	if _, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CoreV1().Nodes().Get(ctx, "n1", metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Reads without a collector are not recorded.
+	if _, err := client.CoreV1().Nodes().Get(context.Background(), "n1", metav1.GetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.observations) != 2 || c.observations[0].ResourceVersion != "42" || c.observations[1].ResourceVersion != "7" {
+		t.Fatalf("observations = %+v", c.observations)
+	}
+	if c.observations[0].Server != srv.URL || c.observations[1].Path != "/api/v1/nodes/n1" {
+		t.Errorf("observations = %+v", c.observations)
+	}
+}
+
+func TestCollectorIsBounded(t *testing.T) {
+	c := NewCollector()
+	for i := 0; i < maxObservations+5; i++ {
+		c.observe(Observation{Server: "https://a", ResourceVersion: "1"})
+	}
+	b := NewHMACSigner(secret).Sign("s", "v", "t", nil, c, false)
+	if len(b.ResourceVersions) != maxObservations || b.Truncated != 5 {
+		t.Errorf("got %d observations, %d truncated", len(b.ResourceVersions), b.Truncated)
+	}
+}
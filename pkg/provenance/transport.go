@@ -0,0 +1,82 @@
+package provenance
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// Wrap returns a copy of config whose transport records the resourceVersion
+// of every successful read made with a context carrying a Collector.
+func Wrap(config *rest.Config) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &provenanceTransport{next: rt}
+	})
+	return config
+}
+
+type provenanceTransport struct {
+	next http.RoundTripper
+}
+
+func (t *provenanceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	c := collectorFrom(req.Context())
+	if c == nil || req.Method != http.MethodGet || req.URL.Query().Get("watch") != "" {
+		return t.next.RoundTrip(req)
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, nil
+	}
+	if rv := resourceVersion(resp.Header.Get("Content-Type"), body); rv != "" {
+		c.observe(Observation{
+			Server:          req.URL.Scheme + "://" + req.URL.Host,
+			Path:            req.URL.Path,
+			ResourceVersion: rv,
+		})
+	}
+	return resp, nil
+}
+
+// resourceVersion reads metadata.resourceVersion from an object or list in
+// JSON or protobuf.
+func resourceVersion(contentType string, body []byte) string {
+	if strings.Contains(contentType, "json") {
+		var obj struct {
+			Metadata struct {
+				ResourceVersion string `json:"resourceVersion"`
+			} `json:"metadata"`
+		}
+		if json.Unmarshal(body, &obj) != nil {
+			return ""
+		}
+		return obj.Metadata.ResourceVersion
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(body, nil, nil)
+	if err != nil {
+		return ""
+	}
+	if meta.IsListType(obj) {
+		if list, err := meta.ListAccessor(obj); err == nil {
+			return list.GetResourceVersion()
+		}
+		return ""
+	}
+	if accessor, err := meta.Accessor(obj); err == nil {
+		return accessor.GetResourceVersion()
+	}
+	return ""
+}
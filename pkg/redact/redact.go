@@ -0,0 +1,185 @@
+// Package redact strips obvious credentials from tool output before it is
+// returned to an MCP client, so secrets read from a cluster do not end up in
+// an LLM context window by accident.
+package redact
+
+import (
+	"expvar"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces every redacted value.
+const Placeholder = "[REDACTED]"
+
+// Rule names, also used as metric keys.
+const (
+	RulePrivateKey    = "private_key"
+	RuleBearerToken   = "bearer_token"
+	RuleJWT           = "jwt"
+	RuleAWSAccessKey  = "aws_access_key"
+	RuleAWSSecretKey  = "aws_secret_key"
+	RuleSecretData    = "secret_data"
+	RuleCredentialKey = "credential_field"
+)
+
+// hits counts redactions per rule for the life of the process. It is
+// published through expvar so any debug HTTP endpoint exposes it.
+var hits = expvar.NewMap("kubestellar_mcp_redactions")
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	// replace builds the replacement from the submatch indexes of one match.
+	replace func(text string, m []int) string
+}
+
+// keepPrefix keeps the first capture group (e.g. "Bearer ") and redacts
+// the rest of the match.
+func keepPrefix(text string, m []int) string {
+	return text[m[2]:m[3]] + Placeholder
+}
+
+func replaceAll(text string, m []int) string {
+	return Placeholder
+}
+
+var rules = []rule{
+	{
+		name:    RulePrivateKey,
+		pattern: regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`),
+		replace: func(string, []int) string { return "[REDACTED PRIVATE KEY]" },
+	},
+	{
+		name:    RuleBearerToken,
+		pattern: regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9\-._~+/]{8,}=*`),
+		replace: keepPrefix,
+	},
+	{
+		name:    RuleJWT,
+		pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`),
+		replace: replaceAll,
+	},
+	{
+		name:    RuleAWSAccessKey,
+		pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+		replace: replaceAll,
+	},
+	{
+		name:    RuleAWSSecretKey,
+		pattern: regexp.MustCompile(`(?i)(aws_secret_access_key["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`),
+		replace: keepPrefix,
+	},
+	{
+		name:    RuleCredentialKey,
+		pattern: regexp.MustCompile(`(?i)((?:password|passwd|client[_-]?secret|api[_-]?key|access[_-]?token|auth[_-]?token)["']?\s*[:=]\s*["']?)[^\s"',}\[][^\s"',}]{3,}`),
+		replace: keepPrefix,
+	},
+}
+
+// String returns text with credentials replaced by Placeholder, and the
+// number of values redacted.
+func String(text string) (string, int) {
+	total := 0
+	for _, r := range rules {
+		var n int
+		text, n = apply(text, r)
+		total += n
+	}
+	if looksLikeSecret(text) {
+		var n int
+		text, n = redactSecretData(text)
+		total += n
+	}
+	return text, total
+}
+
+// Metrics returns the number of redactions per rule since process start.
+func Metrics() map[string]int64 {
+	out := make(map[string]int64)
+	hits.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			out[kv.Key] = v.Value()
+		}
+	})
+	return out
+}
+
+func apply(text string, r rule) (string, int) {
+	matches := r.pattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, 0
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(text[last:m[0]])
+		sb.WriteString(r.replace(text, m))
+		last = m[1]
+	}
+	sb.WriteString(text[last:])
+	hits.Add(r.name, int64(len(matches)))
+	return sb.String(), len(matches)
+}
+
+var secretKindPattern = regexp.MustCompile(`"kind"\s*:\s*"Secret"|(?m)^\s*kind:\s*Secret\s*$`)
+
+func looksLikeSecret(text string) bool {
+	return secretKindPattern.MatchString(text)
+}
+
+var (
+	// jsonSecretData matches a "data" or "stringData" object.
+	jsonSecretData = regexp.MustCompile(`"(?:data|stringData)"\s*:\s*\{[^{}]*\}`)
+	jsonStringPair = regexp.MustCompile(`("[^"\\]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	yamlSecretData = regexp.MustCompile(`^(\s*)(?:data|stringData):\s*$`)
+	yamlKeyValue   = regexp.MustCompile(`^(\s*[^\s:#][^:]*:\s*)(\S.*)$`)
+)
+
+// redactSecretData blanks the values of Secret data and stringData maps in
+// JSON or YAML output, keeping the keys so callers can still see which
+// entries a Secret holds.
+func redactSecretData(text string) (string, int) {
+	count := 0
+	text = jsonSecretData.ReplaceAllStringFunc(text, func(block string) string {
+		return jsonStringPair.ReplaceAllStringFunc(block, func(pair string) string {
+			m := jsonStringPair.FindStringSubmatch(pair)
+			count++
+			return m[1] + `"` + Placeholder + `"`
+		})
+	})
+
+	// YAML: redact each entry of a data/stringData block and drop the
+	// continuation lines of multi-line values.
+	var out []string
+	dataIndent, entryIndent := -1, -1
+	for _, line := range strings.Split(text, "\n") {
+		if m := yamlSecretData.FindStringSubmatch(line); m != nil {
+			dataIndent, entryIndent = len(m[1]), -1
+			out = append(out, line)
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if dataIndent < 0 || strings.TrimSpace(line) == "" || indent <= dataIndent {
+			dataIndent = -1
+			out = append(out, line)
+			continue
+		}
+		if entryIndent < 0 {
+			entryIndent = indent
+		}
+		if indent > entryIndent {
+			continue
+		}
+		if m := yamlKeyValue.FindStringSubmatch(line); m != nil {
+			line = m[1] + Placeholder
+			count++
+		}
+		out = append(out, line)
+	}
+	text = strings.Join(out, "\n")
+	if count > 0 {
+		hits.Add(RuleSecretData, int64(count))
+	}
+	return text, count
+}
@@ -0,0 +1,192 @@
+// Package resultdiff compares two results of the same tool and reports the
+// findings that appeared and the ones that went away, so a recurring scan
+// can be read as a delta instead of the full list of known issues.
+//
+// Tool results have no common schema, so findings are taken from their
+// structure: list items and table rows of a text result, qualified by the
+// headings and labels they appear under, and the elements of the arrays in
+// a JSON result, qualified by their path.
+package resultdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Diff is the difference between two results.
+type Diff struct {
+	Added     []string `json:"added"`
+	Resolved  []string `json:"resolved"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// Compare returns the findings of newer that are not in older as added,
+// and those of older that are not in newer as resolved. A finding listed
+// twice in one result is matched twice.
+func Compare(older, newer string) Diff {
+	counts := make(map[string]int)
+	for _, f := range Findings(older) {
+		counts[f]++
+	}
+	d := Diff{Added: []string{}, Resolved: []string{}}
+	for _, f := range Findings(newer) {
+		if counts[f] > 0 {
+			counts[f]--
+			d.Unchanged++
+			continue
+		}
+		d.Added = append(d.Added, f)
+	}
+	for _, f := range Findings(older) {
+		if counts[f] > 0 {
+			counts[f]--
+			d.Resolved = append(d.Resolved, f)
+		}
+	}
+	sort.Strings(d.Added)
+	sort.Strings(d.Resolved)
+	return d
+}
+
+// contextSeparator joins a finding to the context it appears in.
+const contextSeparator = " › "
+
+var (
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	itemPattern      = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+	separatorPattern = regexp.MustCompile(`^\|?[\s:|-]+\|?$`)
+	// countPattern matches the counts headings and labels carry, such as
+	// "(3 issues)", which change between runs without changing what the
+	// findings under them are about.
+	countPattern = regexp.MustCompile(`\s*\(\d+[^()]*\)`)
+)
+
+// Findings returns the findings of a tool result.
+func Findings(text string) []string {
+	if v, ok := decodeJSON(text); ok {
+		var findings []string
+		jsonFindings(v, "", &findings)
+		return findings
+	}
+	return textFindings(text)
+}
+
+// textFindings returns the list items and table rows of text. Each is
+// prefixed with the headings above it and the label line it follows, e.g.
+// the pod a list of its issues is about.
+func textFindings(text string) []string {
+	var findings []string
+	var headings []string
+	label := ""
+	inTable := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			inTable = false
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(trimmed); m != nil {
+			level := len(m[1])
+			if len(headings) >= level {
+				headings = headings[:level-1]
+			}
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+			headings = append(headings, normalizeLabel(m[2]))
+			label, inTable = "", false
+			continue
+		}
+		if strings.HasPrefix(trimmed, "|") {
+			// The first row of a table is its header.
+			if inTable && !separatorPattern.MatchString(trimmed) {
+				findings = append(findings, qualify(headings, label, normalizeRow(trimmed)))
+			}
+			inTable = true
+			continue
+		}
+		inTable = false
+		if m := itemPattern.FindStringSubmatch(line); m != nil {
+			findings = append(findings, qualify(headings, label, strings.TrimSpace(m[1])))
+			continue
+		}
+		label = normalizeLabel(trimmed)
+	}
+	return findings
+}
+
+func qualify(headings []string, label, finding string) string {
+	parts := make([]string, 0, len(headings)+2)
+	for _, h := range headings {
+		if h != "" {
+			parts = append(parts, h)
+		}
+	}
+	if label != "" {
+		parts = append(parts, label)
+	}
+	return strings.Join(append(parts, finding), contextSeparator)
+}
+
+func normalizeLabel(s string) string {
+	return strings.TrimSuffix(strings.TrimSpace(countPattern.ReplaceAllString(s, "")), ":")
+}
+
+func normalizeRow(row string) string {
+	cells := strings.Split(strings.Trim(row, "|"), "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return strings.Join(cells, " | ")
+}
+
+func decodeJSON(text string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, false
+	}
+	return v, true
+}
+
+// jsonFindings adds the elements of every array in v, as compact JSON
+// prefixed with their path. Arrays of arrays are descended into.
+func jsonFindings(v interface{}, path string, findings *[]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			jsonFindings(field, joinPath(path, k), findings)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if nested, ok := item.([]interface{}); ok {
+				jsonFindings(nested, path, findings)
+				continue
+			}
+			*findings = append(*findings, qualify(nil, path, compact(item)))
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func compact(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(v)
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,61 @@
+package resultdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindings_Text(t *testing.T) {
+	text := `Found 2 pods with security concerns:
+🔴 Critical | 🟠 High | 🟡 Medium
+
+🔓 shop/web
+   - 🔴 Container app is privileged
+   - 🟡 Container app has writable root filesystem
+
+🔓 shop/worker
+   - 🟡 Container app has writable root filesystem
+
+## Cluster prod (2 issues)
+
+| Namespace | Pod | Issue |
+|-----------|-----|-------|
+| shop | api | CrashLoopBackOff |
+`
+	assert.Equal(t, []string{
+		"🔓 shop/web › 🔴 Container app is privileged",
+		"🔓 shop/web › 🟡 Container app has writable root filesystem",
+		"🔓 shop/worker › 🟡 Container app has writable root filesystem",
+		"Cluster prod › shop | api | CrashLoopBackOff",
+	}, Findings(text))
+}
+
+func TestFindings_JSON(t *testing.T) {
+	text := `{"cluster": "prod", "issues": [{"pod": "web", "reason": "OOMKilled"}, {"pod": "api", "reason": "CrashLoopBackOff"}], "total": 2}`
+	assert.ElementsMatch(t, []string{
+		`issues › {"pod":"web","reason":"OOMKilled"}`,
+		`issues › {"pod":"api","reason":"CrashLoopBackOff"}`,
+	}, Findings(text))
+}
+
+func TestCompare(t *testing.T) {
+	older := `# Security (3 issues)
+- web is privileged
+- api runs as root
+- api runs as root
+`
+	newer := `# Security (2 issues)
+- api runs as root
+- db mounts the Docker socket
+`
+	d := Compare(older, newer)
+	assert.Equal(t, []string{"Security › db mounts the Docker socket"}, d.Added)
+	assert.Equal(t, []string{"Security › api runs as root", "Security › web is privileged"}, d.Resolved)
+	assert.Equal(t, 1, d.Unchanged)
+
+	same := Compare(older, older)
+	assert.Empty(t, same.Added)
+	assert.Empty(t, same.Resolved)
+	assert.Equal(t, 3, same.Unchanged)
+}
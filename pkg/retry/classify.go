@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// Class is a coarse failure category reported alongside per-cluster errors so
+// agents can decide whether to retry, re-authenticate, or give up.
+type Class string
+
+const (
+	ClassAuth      Class = "auth"      // 401/403, expired or missing credentials
+	ClassNetwork   Class = "network"   // unreachable API server, timeouts, resets
+	ClassConflict  Class = "conflict"  // 409 conflicts and already-exists errors
+	ClassNotFound  Class = "not-found" // 404 and 410 responses
+	ClassThrottled Class = "throttled" // 429 and API server overload
+	ClassInvalid   Class = "invalid"   // 400/422 rejected requests
+	ClassUnknown   Class = "unknown"
+)
+
+// Classify maps an error returned by client-go (or the network stack beneath
+// it) to a Class. A nil error has no class.
+func Classify(err error) Class {
+	if err == nil {
+		return ""
+	}
+
+	switch {
+	case apierrors.IsUnauthorized(err), apierrors.IsForbidden(err):
+		return ClassAuth
+	case apierrors.IsNotFound(err), apierrors.IsGone(err):
+		return ClassNotFound
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return ClassConflict
+	case apierrors.IsTooManyRequests(err):
+		return ClassThrottled
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return ClassInvalid
+	case apierrors.IsServerTimeout(err), apierrors.IsTimeout(err), apierrors.IsServiceUnavailable(err):
+		return ClassNetwork
+	}
+
+	if isNetworkError(err) {
+		return ClassNetwork
+	}
+
+	// Errors that never reached the API server (for example kubeconfig or
+	// exec-plugin failures) only carry text, so fall back to the messages
+	// client-go is known to produce.
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "unauthorized"), strings.Contains(msg, "forbidden"),
+		strings.Contains(msg, "certificate"), strings.Contains(msg, "x509"):
+		return ClassAuth
+	case strings.Contains(msg, "not found"):
+		return ClassNotFound
+	case strings.Contains(msg, "connection refused"), strings.Contains(msg, "no such host"),
+		strings.Contains(msg, "i/o timeout"), strings.Contains(msg, "deadline exceeded"):
+		return ClassNetwork
+	}
+
+	return ClassUnknown
+}
+
+// IsRetryable reports whether an operation that failed with err is worth
+// repeating unchanged. Conflicts are not: repeating the same write fails
+// again until the object is read afresh, which only the caller can do.
+// Neither are auth, not-found, and validation failures.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if apierrors.IsInternalError(err) {
+		return true
+	}
+	switch Classify(err) {
+	case ClassNetwork, ClassThrottled:
+		return true
+	default:
+		return false
+	}
+}
+
+func isNetworkError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	if utilnet.IsConnectionRefused(err) || utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err) || utilnet.IsTimeout(err) || utilnet.IsHTTP2ConnectionLost(err) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
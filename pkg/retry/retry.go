@@ -0,0 +1,168 @@
+package retry
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Policy controls how often and how quickly a failed cluster operation is
+// repeated. Delays grow exponentially from InitialDelay up to MaxDelay, with
+// up to Jitter (a fraction of the delay) added or removed at random so that
+// parallel fan-outs do not hammer a recovering API server in lockstep.
+type Policy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	Jitter       float64
+	// Budget, when set, is shared across calls and caps the total number of
+	// retries issued in a time window. Nil means unlimited.
+	Budget *Budget
+}
+
+const (
+	defaultMaxAttempts  = 3
+	defaultInitialDelay = 200 * time.Millisecond
+	defaultMaxDelay     = 2 * time.Second
+	defaultMultiplier   = 2.0
+	defaultJitter       = 0.2
+)
+
+// DefaultPolicy returns the policy used for cluster API calls when callers do
+// not configure one.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:  defaultMaxAttempts,
+		InitialDelay: defaultInitialDelay,
+		MaxDelay:     defaultMaxDelay,
+		Multiplier:   defaultMultiplier,
+		Jitter:       defaultJitter,
+	}
+}
+
+// Delay returns the backoff before the given retry (1 for the first retry).
+func (p Policy) Delay(retry int) time.Duration {
+	if retry < 1 || p.InitialDelay <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(p.InitialDelay)
+	for i := 1; i < retry; i++ {
+		delay *= multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			delay = float64(p.MaxDelay)
+			break
+		}
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// Budget limits how many retries may be spent across all operations within a
+// rolling window. It keeps one misbehaving cluster from multiplying load on
+// every other call sharing the same budget.
+type Budget struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu    sync.Mutex
+	spent []time.Time
+}
+
+// NewBudget creates a budget allowing limit retries per window.
+func NewBudget(limit int, window time.Duration) *Budget {
+	return &Budget{limit: limit, window: window, now: time.Now}
+}
+
+// Allow consumes one retry from the budget, reporting false when exhausted.
+func (b *Budget) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	cutoff := now.Add(-b.window)
+	kept := b.spent[:0]
+	for _, t := range b.spent {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	b.spent = kept
+
+	if len(b.spent) >= b.limit {
+		return false
+	}
+	b.spent = append(b.spent, now)
+	return true
+}
+
+// sleep waits for d or until ctx is done. It is a variable so tests can run
+// without real delays.
+var sleep = func(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks ctx as carrying operations that are safe to repeat,
+// such as reads. Cluster executors retry transient failures only under such
+// a context: a write whose response was lost may already have been applied,
+// so it is never sent again.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// IsIdempotent reports whether ctx was marked with WithIdempotent.
+func IsIdempotent(ctx context.Context) bool {
+	idempotent, _ := ctx.Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, the policy's
+// attempts or budget run out, or ctx is cancelled. The last error from fn is
+// returned.
+func Do(ctx context.Context, policy Policy, fn func(ctx context.Context) error) error {
+	attempts := policy.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = fn(ctx)
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+		if !policy.Budget.Allow() {
+			return err
+		}
+		if sleepErr := sleep(ctx, policy.Delay(attempt)); sleepErr != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var podsResource = schema.GroupResource{Resource: "pods"}
+
+func withoutSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	orig := sleep
+	sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	t.Cleanup(func() { sleep = orig })
+	return &delays
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Class
+	}{
+		{"nil", nil, ""},
+		{"unauthorized", apierrors.NewUnauthorized("nope"), ClassAuth},
+		{"forbidden", apierrors.NewForbidden(podsResource, "p", errors.New("rbac")), ClassAuth},
+		{"not found", apierrors.NewNotFound(podsResource, "p"), ClassNotFound},
+		{"conflict", apierrors.NewConflict(podsResource, "p", errors.New("stale")), ClassConflict},
+		{"already exists", apierrors.NewAlreadyExists(podsResource, "p"), ClassConflict},
+		{"throttled", apierrors.NewTooManyRequests("slow down", 1), ClassThrottled},
+		{"invalid", apierrors.NewBadRequest("bad"), ClassInvalid},
+		{"server timeout", apierrors.NewServerTimeout(podsResource, "list", 1), ClassNetwork},
+		{"deadline", fmt.Errorf("list: %w", context.DeadlineExceeded), ClassNetwork},
+		{"refused text", errors.New("dial tcp 10.0.0.1:6443: connect: connection refused"), ClassNetwork},
+		{"x509 text", errors.New("x509: certificate signed by unknown authority"), ClassAuth},
+		{"other", errors.New("boom"), ClassUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.want {
+				t.Fatalf("Classify() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	retryable := []error{
+		apierrors.NewTooManyRequests("slow down", 1),
+		apierrors.NewInternalError(errors.New("etcd")),
+		errors.New("connection refused"),
+	}
+	for _, err := range retryable {
+		if !IsRetryable(err) {
+			t.Errorf("IsRetryable(%v) = false, want true", err)
+		}
+	}
+
+	permanent := []error{
+		nil,
+		context.Canceled,
+		apierrors.NewConflict(podsResource, "p", errors.New("stale")),
+		apierrors.NewAlreadyExists(podsResource, "p"),
+		apierrors.NewForbidden(podsResource, "p", errors.New("rbac")),
+		apierrors.NewNotFound(podsResource, "p"),
+		errors.New("boom"),
+	}
+	for _, err := range permanent {
+		if IsRetryable(err) {
+			t.Errorf("IsRetryable(%v) = true, want false", err)
+		}
+	}
+}
+
+func TestDoRetriesTransientErrors(t *testing.T) {
+	delays := withoutSleep(t)
+	policy := Policy{MaxAttempts: 4, InitialDelay: 10 * time.Millisecond, MaxDelay: 25 * time.Millisecond, Multiplier: 2}
+
+	calls := 0
+	err := Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		if calls < 4 {
+			return apierrors.NewTooManyRequests("slow down", 1)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if calls != 4 {
+		t.Fatalf("calls = %d, want 4", calls)
+	}
+	want := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 25 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(want) {
+		t.Fatalf("delays = %v, want %v", *delays, want)
+	}
+}
+
+func TestDoStopsOnPermanentError(t *testing.T) {
+	withoutSleep(t)
+
+	calls := 0
+	notFound := apierrors.NewNotFound(podsResource, "p")
+	err := Do(context.Background(), DefaultPolicy(), func(ctx context.Context) error {
+		calls++
+		return notFound
+	})
+	if !errors.Is(err, notFound) {
+		t.Fatalf("Do() error = %v, want %v", err, notFound)
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestDoReturnsLastErrorWhenAttemptsExhausted(t *testing.T) {
+	withoutSleep(t)
+
+	calls := 0
+	err := Do(context.Background(), Policy{MaxAttempts: 2}, func(ctx context.Context) error {
+		calls++
+		return fmt.Errorf("attempt %d: connection refused", calls)
+	})
+	if err == nil || err.Error() != "attempt 2: connection refused" {
+		t.Fatalf("Do() error = %v, want attempt 2 error", err)
+	}
+}
+
+func TestDoHonorsBudget(t *testing.T) {
+	withoutSleep(t)
+	policy := Policy{MaxAttempts: 5, Budget: NewBudget(1, time.Minute)}
+
+	calls := 0
+	_ = Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return errors.New("i/o timeout")
+	})
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2 (one try plus one budgeted retry)", calls)
+	}
+
+	calls = 0
+	_ = Do(context.Background(), policy, func(ctx context.Context) error {
+		calls++
+		return errors.New("i/o timeout")
+	})
+	if calls != 1 {
+		t.Fatalf("calls after budget exhausted = %d, want 1", calls)
+	}
+}
+
+func TestBudgetRefillsAfterWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	budget := NewBudget(1, time.Minute)
+	budget.now = func() time.Time { return now }
+
+	if !budget.Allow() {
+		t.Fatal("first Allow() = false, want true")
+	}
+	if budget.Allow() {
+		t.Fatal("second Allow() = true, want false")
+	}
+	now = now.Add(2 * time.Minute)
+	if !budget.Allow() {
+		t.Fatal("Allow() after window = false, want true")
+	}
+}
+
+func TestPolicyDelayJitterStaysBounded(t *testing.T) {
+	policy := Policy{InitialDelay: 100 * time.Millisecond, MaxDelay: time.Second, Multiplier: 2, Jitter: 0.5}
+	for range 50 {
+		d := policy.Delay(1)
+		if d < 50*time.Millisecond || d > 150*time.Millisecond {
+			t.Fatalf("Delay(1) = %v, want within 50ms-150ms", d)
+		}
+	}
+	if d := policy.Delay(10); d > time.Second {
+		t.Fatalf("Delay(10) = %v, want capped at 1s", d)
+	}
+}
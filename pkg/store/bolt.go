@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Bolt is a Store backed by a local bbolt database file. Each store bucket
+// maps to a bbolt bucket.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens (creating if needed) the bbolt database at path. Only one
+// process may hold the file open; a second opener fails after a short wait.
+func OpenBolt(path string) (*Bolt, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state store %s: %w", path, err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+func (b *Bolt) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	var value []byte
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(bucket))
+		if bkt == nil {
+			return ErrNotFound
+		}
+		v := bkt.Get([]byte(key))
+		if v == nil {
+			return ErrNotFound
+		}
+		// Values are only valid for the life of the transaction.
+		value = append([]byte(nil), v...)
+		return nil
+	})
+	return value, err
+}
+
+func (b *Bolt) Put(ctx context.Context, bucket, key string, value []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		if err != nil {
+			return err
+		}
+		return bkt.Put([]byte(key), value)
+	})
+}
+
+func (b *Bolt) Delete(ctx context.Context, bucket, key string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(bucket))
+		if bkt == nil {
+			return nil
+		}
+		return bkt.Delete([]byte(key))
+	})
+}
+
+func (b *Bolt) List(ctx context.Context, bucket string) ([]Item, error) {
+	var items []Item
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bkt := tx.Bucket([]byte(bucket))
+		if bkt == nil {
+			return nil
+		}
+		// bbolt iterates in byte-sorted key order.
+		return bkt.ForEach(func(k, v []byte) error {
+			items = append(items, Item{Key: string(k), Value: append([]byte(nil), v...)})
+			return nil
+		})
+	})
+	return items, err
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,170 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientretry "k8s.io/client-go/util/retry"
+)
+
+// bucketLabel records which store bucket a ConfigMap holds.
+const bucketLabel = "kubestellar.io/state-bucket"
+
+// ConfigMapStore is a Store backed by ConfigMaps on a hub cluster, one per
+// bucket, so state survives restarts and is shared by every server pointed
+// at the same hub. A bucket is limited by the ConfigMap size limit (1 MiB);
+// callers that keep history should trim old entries.
+type ConfigMapStore struct {
+	client    kubernetes.Interface
+	namespace string
+	prefix    string
+}
+
+// NewConfigMapStore stores each bucket in the ConfigMap
+// <prefix>-<bucket> in namespace.
+func NewConfigMapStore(client kubernetes.Interface, namespace, prefix string) *ConfigMapStore {
+	return &ConfigMapStore{client: client, namespace: namespace, prefix: prefix}
+}
+
+func (c *ConfigMapStore) name(bucket string) string {
+	return c.prefix + "-" + bucket
+}
+
+func (c *ConfigMapStore) get(ctx context.Context, bucket string) (*corev1.ConfigMap, error) {
+	cm, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, c.name(bucket), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read state bucket %s: %w", bucket, err)
+	}
+	return cm, nil
+}
+
+func (c *ConfigMapStore) Get(ctx context.Context, bucket, key string) ([]byte, error) {
+	cm, err := c.get(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if cm == nil {
+		return nil, ErrNotFound
+	}
+	value, ok := cm.BinaryData[encodeConfigMapKey(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+func (c *ConfigMapStore) Put(ctx context.Context, bucket, key string, value []byte) error {
+	return clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		cm, err := c.get(ctx, bucket)
+		if err != nil {
+			return err
+		}
+		if cm == nil {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      c.name(bucket),
+					Namespace: c.namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/managed-by": "kubestellar-mcp",
+						bucketLabel:                    bucket,
+					},
+				},
+				BinaryData: map[string][]byte{encodeConfigMapKey(key): value},
+			}
+			_, err = c.client.CoreV1().ConfigMaps(c.namespace).Create(ctx, cm, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Lost a race with another writer; retry as an update.
+				return apierrors.NewConflict(corev1.Resource("configmaps"), cm.Name, err)
+			}
+			return err
+		}
+		if cm.BinaryData == nil {
+			cm.BinaryData = make(map[string][]byte)
+		}
+		cm.BinaryData[encodeConfigMapKey(key)] = value
+		_, err = c.client.CoreV1().ConfigMaps(c.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (c *ConfigMapStore) Delete(ctx context.Context, bucket, key string) error {
+	return clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		cm, err := c.get(ctx, bucket)
+		if err != nil || cm == nil {
+			return err
+		}
+		encoded := encodeConfigMapKey(key)
+		if _, ok := cm.BinaryData[encoded]; !ok {
+			return nil
+		}
+		delete(cm.BinaryData, encoded)
+		_, err = c.client.CoreV1().ConfigMaps(c.namespace).Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func (c *ConfigMapStore) List(ctx context.Context, bucket string) ([]Item, error) {
+	cm, err := c.get(ctx, bucket)
+	if err != nil || cm == nil {
+		return nil, err
+	}
+	items := make([]Item, 0, len(cm.BinaryData))
+	for encoded, value := range cm.BinaryData {
+		key, err := decodeConfigMapKey(encoded)
+		if err != nil {
+			// Not written by this store; leave it alone.
+			continue
+		}
+		items = append(items, Item{Key: key, Value: value})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items, nil
+}
+
+func (c *ConfigMapStore) Close() error { return nil }
+
+// encodeConfigMapKey maps an arbitrary key onto the ConfigMap key alphabet
+// [-._a-zA-Z0-9] by writing every other byte, and '_' itself, as _XX hex.
+func encodeConfigMapKey(key string) string {
+	var sb strings.Builder
+	for i := 0; i < len(key); i++ {
+		ch := key[i]
+		if ch == '-' || ch == '.' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' {
+			sb.WriteByte(ch)
+			continue
+		}
+		_, _ = fmt.Fprintf(&sb, "_%02X", ch)
+	}
+	return sb.String()
+}
+
+// decodeConfigMapKey reverses encodeConfigMapKey.
+func decodeConfigMapKey(encoded string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(encoded); i++ {
+		if encoded[i] != '_' {
+			sb.WriteByte(encoded[i])
+			continue
+		}
+		if i+2 >= len(encoded) {
+			return "", fmt.Errorf("truncated escape in key %q", encoded)
+		}
+		ch, err := strconv.ParseUint(encoded[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("invalid escape in key %q: %w", encoded, err)
+		}
+		sb.WriteByte(byte(ch))
+		i += 2
+	}
+	return sb.String(), nil
+}
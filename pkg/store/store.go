@@ -0,0 +1,122 @@
+// Package store provides durable key/value state for long-running
+// subsystems such as drift watches, upgrade campaigns, health history, and
+// the audit trail. Values are grouped into buckets and stored by one of
+// several backends: in memory, a local bbolt file, or ConfigMaps on a hub
+// cluster.
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// Buckets used by the subsystems that persist state. Sharing the names here
+// keeps every backend laid out the same way.
+const (
+	BucketDriftWatches     = "drift-watches"
+	BucketCampaigns        = "upgrade-campaigns"
+	BucketHealthHistory    = "health-history"
+	BucketAudit            = "audit"
+	BucketPlans            = "approval-plans"
+	BucketChanges          = "change-journal"
+	BucketRollouts         = "rollouts"
+	BucketPromotions       = "promotions"
+	BucketScalingSchedules = "scaling-schedules"
+	BucketToolSchedules    = "tool-schedules"
+	BucketScheduledResults = "scheduled-results"
+	BucketSavedQueries     = "saved-queries"
+	BucketBaselines        = "config-baselines"
+)
+
+// EnvStateStore selects the store backend; see Open for the accepted forms.
+const EnvStateStore = "KUBESTELLAR_STATE_STORE"
+
+// ErrNotFound is returned by Get when the key does not exist.
+var ErrNotFound = errors.New("store: key not found")
+
+// Item is a single key/value pair returned by List.
+type Item struct {
+	Key   string
+	Value []byte
+}
+
+// Store is a bucketed key/value store. Implementations are safe for
+// concurrent use. Get returns ErrNotFound for missing keys; Delete of a
+// missing key is not an error. List returns items sorted by key.
+type Store interface {
+	Get(ctx context.Context, bucket, key string) ([]byte, error)
+	Put(ctx context.Context, bucket, key string, value []byte) error
+	Delete(ctx context.Context, bucket, key string) error
+	List(ctx context.Context, bucket string) ([]Item, error)
+	Close() error
+}
+
+// GetJSON reads key from bucket and decodes it into v.
+func GetJSON(ctx context.Context, s Store, bucket, key string, v interface{}) error {
+	data, err := s.Get(ctx, bucket, key)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s/%s: %w", bucket, key, err)
+	}
+	return nil
+}
+
+// PutJSON encodes v as JSON and writes it to key in bucket.
+func PutJSON(ctx context.Context, s Store, bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", bucket, key, err)
+	}
+	return s.Put(ctx, bucket, key, data)
+}
+
+// FromEnv opens the store selected by $KUBESTELLAR_STATE_STORE, defaulting
+// to an in-memory store when it is unset.
+func FromEnv(hub HubClientFunc) (Store, error) {
+	return Open(os.Getenv(EnvStateStore), hub)
+}
+
+// HubClientFunc returns a client for the hub cluster that hosts
+// ConfigMap-backed state. It is only called for configmap specs.
+type HubClientFunc func() (kubernetes.Interface, error)
+
+// Open creates a store from spec:
+//
+//	"" or "memory"                  in-process only, lost on exit
+//	"bolt:<path>"                   bbolt database file at path
+//	"configmap:<namespace>/<prefix>" ConfigMaps named <prefix>-<bucket> on the hub
+func Open(spec string, hub HubClientFunc) (Store, error) {
+	kind, arg, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "", "memory":
+		return NewMemory(), nil
+	case "bolt":
+		if arg == "" {
+			return nil, fmt.Errorf("store spec %q: bolt requires a file path", spec)
+		}
+		return OpenBolt(arg)
+	case "configmap":
+		namespace, prefix, ok := strings.Cut(arg, "/")
+		if !ok || namespace == "" || prefix == "" {
+			return nil, fmt.Errorf("store spec %q: expected configmap:<namespace>/<prefix>", spec)
+		}
+		if hub == nil {
+			return nil, fmt.Errorf("store spec %q: no hub cluster client available", spec)
+		}
+		client, err := hub()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create hub client for state store: %w", err)
+		}
+		return NewConfigMapStore(client, namespace, prefix), nil
+	default:
+		return nil, fmt.Errorf("unknown state store backend %q (expected memory, bolt, or configmap)", kind)
+	}
+}
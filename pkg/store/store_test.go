@@ -0,0 +1,176 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// exerciseStore runs the behaviour every backend must share.
+func exerciseStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := s.Get(ctx, BucketAudit, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if items, err := s.List(ctx, BucketAudit); err != nil || len(items) != 0 {
+		t.Fatalf("List(empty) = %v, %v; want no items", items, err)
+	}
+	if err := s.Delete(ctx, BucketAudit, "missing"); err != nil {
+		t.Fatalf("Delete(missing) error = %v", err)
+	}
+
+	for _, key := range []string{"b/2", "a_1", "c 3"} {
+		if err := s.Put(ctx, BucketAudit, key, []byte("v-"+key)); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+	}
+	if err := s.Put(ctx, BucketAudit, "a_1", []byte("updated")); err != nil {
+		t.Fatalf("Put(overwrite) error = %v", err)
+	}
+
+	got, err := s.Get(ctx, BucketAudit, "a_1")
+	if err != nil || string(got) != "updated" {
+		t.Fatalf("Get(a_1) = %q, %v; want updated", got, err)
+	}
+
+	items, err := s.List(ctx, BucketAudit)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	var keys []string
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	if len(keys) != 3 || keys[0] != "a_1" || keys[1] != "b/2" || keys[2] != "c 3" {
+		t.Fatalf("List() keys = %v, want [a_1 b/2 c 3]", keys)
+	}
+
+	if _, err := s.Get(ctx, BucketCampaigns, "a_1"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("buckets must be isolated, Get in other bucket error = %v", err)
+	}
+
+	if err := s.Delete(ctx, BucketAudit, "b/2"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Get(ctx, BucketAudit, "b/2"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(deleted) error = %v, want ErrNotFound", err)
+	}
+
+	type watch struct {
+		Repo     string `json:"repo"`
+		Interval int    `json:"interval"`
+	}
+	if err := PutJSON(ctx, s, BucketDriftWatches, "w1", watch{Repo: "r", Interval: 30}); err != nil {
+		t.Fatalf("PutJSON() error = %v", err)
+	}
+	var w watch
+	if err := GetJSON(ctx, s, BucketDriftWatches, "w1", &w); err != nil || w.Repo != "r" || w.Interval != 30 {
+		t.Fatalf("GetJSON() = %+v, %v", w, err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	exerciseStore(t, NewMemory())
+}
+
+func TestBoltStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "state.db")
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("OpenBolt() error = %v", err)
+	}
+	exerciseStore(t, s)
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	reopened, err := OpenBolt(path)
+	if err != nil {
+		t.Fatalf("reopen error = %v", err)
+	}
+	defer reopened.Close()
+	if got, err := reopened.Get(context.Background(), BucketAudit, "a_1"); err != nil || string(got) != "updated" {
+		t.Fatalf("state did not survive reopen: %q, %v", got, err)
+	}
+}
+
+func TestConfigMapStore(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	exerciseStore(t, NewConfigMapStore(client, "kubestellar-system", "mcp-state"))
+
+	cm, err := client.CoreV1().ConfigMaps("kubestellar-system").Get(context.Background(), "mcp-state-audit", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("bucket ConfigMap not created: %v", err)
+	}
+	if cm.Labels[bucketLabel] != BucketAudit {
+		t.Fatalf("bucket label = %q, want %q", cm.Labels[bucketLabel], BucketAudit)
+	}
+}
+
+func TestConfigMapKeyEncodingRoundTrips(t *testing.T) {
+	for _, key := range []string{"plain-key.v1", "a_b", "ns/name", "spaces and ümlauts", "_41"} {
+		encoded := encodeConfigMapKey(key)
+		for _, ch := range encoded {
+			if !(ch == '-' || ch == '.' || ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9') {
+				t.Fatalf("encodeConfigMapKey(%q) = %q contains %q", key, encoded, ch)
+			}
+		}
+		decoded, err := decodeConfigMapKey(encoded)
+		if err != nil || decoded != key {
+			t.Fatalf("decodeConfigMapKey(%q) = %q, %v; want %q", encoded, decoded, err, key)
+		}
+	}
+	if _, err := decodeConfigMapKey("bad_4"); err == nil {
+		t.Fatal("expected error for truncated escape")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if s, err := Open("", nil); err != nil {
+		t.Fatalf("Open(\"\") error = %v", err)
+	} else if _, ok := s.(*Memory); !ok {
+		t.Fatalf("Open(\"\") = %T, want *Memory", s)
+	}
+
+	s, err := Open("bolt:"+filepath.Join(t.TempDir(), "state.db"), nil)
+	if err != nil {
+		t.Fatalf("Open(bolt) error = %v", err)
+	}
+	_ = s.Close()
+
+	hub := func() (kubernetes.Interface, error) { return k8sfake.NewSimpleClientset(), nil }
+	if s, err := Open("configmap:ns/prefix", hub); err != nil {
+		t.Fatalf("Open(configmap) error = %v", err)
+	} else if _, ok := s.(*ConfigMapStore); !ok {
+		t.Fatalf("Open(configmap) = %T, want *ConfigMapStore", s)
+	}
+
+	for _, spec := range []string{"bolt:", "configmap:ns", "configmap:/prefix", "redis:localhost"} {
+		if _, err := Open(spec, hub); err == nil {
+			t.Errorf("Open(%q) expected error", spec)
+		}
+	}
+	if _, err := Open("configmap:ns/prefix", nil); err == nil {
+		t.Error("Open(configmap) without hub client expected error")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.db")
+	t.Setenv(EnvStateStore, "bolt:"+path)
+	s, err := FromEnv(nil)
+	if err != nil {
+		t.Fatalf("FromEnv() error = %v", err)
+	}
+	defer s.Close()
+	if _, ok := s.(*Bolt); !ok {
+		t.Fatalf("FromEnv() = %T, want *Bolt", s)
+	}
+}
@@ -0,0 +1,179 @@
+// Package toolerror defines the machine-readable form of a failed tool call.
+// Both MCP servers attach it to isError results under _meta.error, so agents
+// can branch on the kind of failure instead of parsing the message.
+package toolerror
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/kubestellar/kubestellar-mcp/pkg/retry"
+)
+
+// MetaKey is the _meta key of the error payload in an isError result.
+const MetaKey = "error"
+
+// Code is the kind of a tool failure.
+type Code string
+
+const (
+	InvalidArgument  Code = "invalid_argument"  // the arguments were rejected before or by the API server
+	NotFound         Code = "not_found"         // the named object does not exist
+	UnknownCluster   Code = "unknown_cluster"   // the cluster is not in the kubeconfig
+	AlreadyExists    Code = "already_exists"    // an object of that name already exists
+	Conflict         Code = "conflict"          // the object changed while the call ran
+	Unauthenticated  Code = "unauthenticated"   // missing, expired, or untrusted credentials
+	PermissionDenied Code = "permission_denied" // RBAC, or a namespace guardrail, forbids the call
+	PolicyDenied     Code = "policy_denied"     // the authorization policy denied the call
+	ApprovalRequired Code = "approval_required" // the call must be repeated with approval
+	Unavailable      Code = "unavailable"       // the API server could not be reached
+	Throttled        Code = "throttled"         // the API server is rate limiting requests
+	Internal         Code = "internal"          // anything else
+)
+
+// Retryable reports whether a call that failed with code may succeed if
+// repeated unchanged.
+func (c Code) Retryable() bool {
+	switch c {
+	case Unavailable, Throttled, Conflict:
+		return true
+	}
+	return false
+}
+
+// Error is a classified tool failure.
+type Error struct {
+	Code    Code   `json:"code"`
+	Message string `json:"message"`
+	// Cluster is the cluster the failure happened in, if it is known.
+	Cluster   string `json:"cluster,omitempty"`
+	Retryable bool   `json:"retryable"`
+	// NextTool is the tool most likely to help the agent recover.
+	NextTool string `json:"nextTool,omitempty"`
+
+	err error
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Unwrap() error { return e.err }
+
+// New returns an error of the given code.
+func New(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), Retryable: code.Retryable()}
+}
+
+// Wrap gives err an explicit code, overriding classification.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Message: err.Error(), Retryable: code.Retryable(), err: err}
+}
+
+// InCluster records that err happened in cluster, unless err already names
+// a cluster.
+func InCluster(cluster string, err error) error {
+	if err == nil || cluster == "" {
+		return err
+	}
+	e := From(err)
+	if e.Cluster == "" {
+		e.Cluster = cluster
+	}
+	return e
+}
+
+// From classifies err. An *Error anywhere in err's chain keeps its code and
+// cluster; the message is always err's own.
+func From(err error) *Error {
+	var typed *Error
+	if errors.As(err, &typed) {
+		e := *typed
+		e.Message, e.err = err.Error(), err
+		return &e
+	}
+	code := classify(err)
+	return &Error{Code: code, Message: err.Error(), Retryable: code.Retryable() || retry.IsRetryable(err), err: err}
+}
+
+// FromText classifies a failure that only survives as text, as tool
+// handlers that return strings report it.
+func FromText(text string) *Error {
+	code := classifyText(text)
+	return &Error{Code: code, Message: text, Retryable: code.Retryable()}
+}
+
+// Suggest fills in NextTool from next, keyed by code, unless the error
+// already names one.
+func (e *Error) Suggest(next map[Code]string) *Error {
+	if e.NextTool == "" {
+		e.NextTool = next[e.Code]
+	}
+	return e
+}
+
+func classify(err error) Code {
+	// retry.Classify does not tell a missing token from a forbidden one.
+	switch {
+	case apierrors.IsAlreadyExists(err):
+		return AlreadyExists
+	case apierrors.IsUnauthorized(err):
+		return Unauthenticated
+	case apierrors.IsForbidden(err):
+		return PermissionDenied
+	}
+	switch retry.Classify(err) {
+	case retry.ClassNetwork:
+		return Unavailable
+	case retry.ClassThrottled:
+		return Throttled
+	case retry.ClassConflict:
+		return Conflict
+	case retry.ClassInvalid:
+		return InvalidArgument
+	}
+	return classifyText(err.Error())
+}
+
+// classifyText falls back to the messages this repo, client-go, and the
+// policy and approval gates are known to produce.
+func classifyText(text string) Code {
+	msg := strings.ToLower(text)
+	has := func(subs ...string) bool {
+		for _, sub := range subs {
+			if strings.Contains(msg, sub) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("denied by policy"):
+		return PolicyDenied
+	case has("approval required", "requires approval", "requires plan_id"):
+		return ApprovalRequired
+	case has("context") && has("does not exist", "was not found"):
+		return UnknownCluster
+	case has("unauthorized", "certificate", "x509", "credentials"):
+		return Unauthenticated
+	case has("forbidden", "protected from changes", "outside the allowed namespaces", "not allowed"):
+		return PermissionDenied
+	case has("too many requests", "rate limit"):
+		return Throttled
+	case has("connection refused", "no such host", "i/o timeout", "deadline exceeded", "unreachable"):
+		return Unavailable
+	case has("already exists"):
+		return AlreadyExists
+	case has("conflict", "has been modified"):
+		return Conflict
+	case has("not found", "does not exist"):
+		return NotFound
+	case has("invalid", "is required", "must ", "unknown ", "mutually exclusive", "expected "):
+		return InvalidArgument
+	}
+	return Internal
+}
@@ -0,0 +1,70 @@
+package toolerror
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestFrom(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		err       error
+		code      Code
+		retryable bool
+	}{
+		{apierrors.NewForbidden(pods, "web", errors.New("no RBAC")), PermissionDenied, false},
+		{apierrors.NewUnauthorized("token expired"), Unauthenticated, false},
+		{fmt.Errorf("failed to get pod: %w", apierrors.NewNotFound(pods, "web")), NotFound, false},
+		{apierrors.NewAlreadyExists(pods, "web"), AlreadyExists, false},
+		{apierrors.NewConflict(pods, "web", errors.New("stale")), Conflict, true},
+		{apierrors.NewTooManyRequests("slow down", 1), Throttled, true},
+		{apierrors.NewInternalError(errors.New("etcd")), Internal, true},
+		{errors.New("dial tcp 10.0.0.1:6443: connect: connection refused"), Unavailable, true},
+		{errors.New(`context "prod" does not exist`), UnknownCluster, false},
+		{errors.New("denied by policy: prod is read-only"), PolicyDenied, false},
+		{errors.New(`namespace "kube-system" is protected from changes`), PermissionDenied, false},
+		{errors.New("app is required"), InvalidArgument, false},
+		{errors.New("timeout_seconds must not be negative"), InvalidArgument, false},
+		{errors.New("helm exited with status 1"), Internal, false},
+	}
+	for _, tt := range tests {
+		e := From(tt.err)
+		assert.Equal(t, tt.code, e.Code, "%v", tt.err)
+		assert.Equal(t, tt.retryable, e.Retryable, "%v", tt.err)
+		assert.Equal(t, tt.err.Error(), e.Message)
+		assert.ErrorIs(t, e, tt.err)
+	}
+}
+
+func TestTypedErrorsKeepCodeAndCluster(t *testing.T) {
+	err := InCluster("east", Wrap(ApprovalRequired, errors.New("apply needs sign-off")))
+	err = InCluster("west", fmt.Errorf("deploy: %w", err))
+
+	e := From(err)
+	assert.Equal(t, ApprovalRequired, e.Code)
+	assert.Equal(t, "east", e.Cluster, "the innermost cluster wins")
+	assert.Equal(t, "deploy: apply needs sign-off", e.Message)
+
+	e = New(NotFound, "app %s not found", "web").Suggest(map[Code]string{NotFound: "get_app_instances"})
+	assert.Equal(t, "get_app_instances", e.NextTool)
+	assert.Equal(t, "app web not found", e.Message)
+	assert.Equal(t, "get_app_instances", e.Suggest(map[Code]string{NotFound: "other"}).NextTool)
+
+	assert.NoError(t, InCluster("east", nil))
+	assert.NoError(t, Wrap(Internal, nil))
+}
+
+func TestFromText(t *testing.T) {
+	e := FromText(`Failed to list pods: pods is forbidden: User "dev" cannot list resource "pods"`)
+	assert.Equal(t, PermissionDenied, e.Code)
+	assert.False(t, e.Retryable)
+
+	e = FromText("Failed to create client: Get \"https://10.0.0.1:6443\": dial tcp: i/o timeout")
+	assert.Equal(t, Unavailable, e.Code)
+	assert.True(t, e.Retryable)
+}